@@ -0,0 +1,185 @@
+// `quidnug-cli import` — bulk import of an existing registry.
+//
+//	import --mapping FILE --input FILE --quid FILE [--state FILE]
+//
+// The mapping (a bulkimport.Mapping as JSON) says how the CSV or
+// JSONL rows of --input become identity or title records; each is
+// signed with --quid and submitted to --node. Progress is written
+// to --state after every batch, and a later run with the same
+// input and state file resumes where the last one stopped. Import
+// identities before the titles that name them as owners.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/bulkimport"
+	"github.com/quidnug/quidnug/internal/safeio"
+	"github.com/quidnug/quidnug/pkg/client"
+)
+
+func cmdImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var cf commonFlags
+	cf.register(fs)
+	mappingPath := fs.String("mapping", "", "mapping file (JSON, required)")
+	inputPath := fs.String("input", "", "CSV or JSONL export (required)")
+	quidPath := fs.String("quid", "", "signer quid file (required)")
+	statePath := fs.String("state", "", "progress file (default: INPUT.progress.json)")
+	batch := fs.Int("batch", 0, "rows per progress checkpoint (default: mapping batchSize, else 100)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *mappingPath == "" || *inputPath == "" || *quidPath == "" {
+		return fmt.Errorf("import: --mapping, --input and --quid are required")
+	}
+	if *statePath == "" {
+		*statePath = *inputPath + ".progress.json"
+	}
+
+	raw, err := safeio.ReadFile(*mappingPath)
+	if err != nil {
+		return err
+	}
+	var mapping bulkimport.Mapping
+	if err := json.Unmarshal(raw, &mapping); err != nil {
+		return fmt.Errorf("import: --mapping: %w", err)
+	}
+	if _, ok := mapping.Fields[bulkimport.FieldAssetClass]; ok || mapping.Defaults[bulkimport.FieldAssetClass] != "" {
+		return fmt.Errorf("import: asset classes are not supported over the client API; use the node's /admin/import endpoint")
+	}
+	data, err := safeio.ReadFile(*inputPath)
+	if err != nil {
+		return err
+	}
+	reader, err := bulkimport.NewReader(bytes.NewReader(data), mapping)
+	if err != nil {
+		return err
+	}
+	signer, err := loadQuid(*quidPath)
+	if err != nil {
+		return err
+	}
+	c, err := cf.client()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	opts := bulkimport.Options{
+		Source:    hex.EncodeToString(digest[:]),
+		BatchSize: *batch,
+		OnBatch: func(p bulkimport.Progress) error {
+			body, err := json.MarshalIndent(p, "", "  ")
+			if err != nil {
+				return err
+			}
+			if cf.Verbose {
+				fmt.Fprintf(os.Stderr, "import: line=%d imported=%d failed=%d\n", p.LastLine, p.Imported, p.Failed)
+			}
+			return safeio.WriteFile(*statePath, body)
+		},
+	}
+	if prior, err := safeio.ReadFile(*statePath); err == nil {
+		var p bulkimport.Progress
+		if err := json.Unmarshal(prior, &p); err != nil {
+			return fmt.Errorf("import: --state: %w", err)
+		}
+		if p.Done {
+			return fmt.Errorf("import: %s records a finished import; remove it to import again", *statePath)
+		}
+		opts.Resume = &p
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	progress, err := bulkimport.Run(context.Background(), reader, clientImportSink{c: c, signer: signer, timeout: cf.Timeout}, opts)
+	if err != nil {
+		return fmt.Errorf("import: stopped (resume with the same --state): %w", err)
+	}
+	return emit(cf, map[string]any{
+		"imported": progress.Imported,
+		"failed":   progress.Failed,
+		"lastLine": progress.LastLine,
+		"state":    *statePath,
+	})
+}
+
+// clientImportSink submits records through the node's public write
+// endpoints, signed with the caller's quid.
+type clientImportSink struct {
+	c       *client.Client
+	signer  *client.Quid
+	timeout time.Duration
+}
+
+func (s clientImportSink) Submit(ctx context.Context, rec bulkimport.Record) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		out map[string]any
+		err error
+	)
+	switch rec.Kind {
+	case bulkimport.KindIdentity:
+		params := client.IdentityParams{
+			SubjectQuid: rec.QuidID,
+			Domain:      rec.TrustDomain,
+			Name:        rec.Name,
+			Description: rec.Description,
+			Attributes:  rec.Attributes,
+			HomeDomain:  rec.HomeDomain,
+		}
+		existing, getErr := s.c.GetIdentity(ctx, rec.QuidID, rec.TrustDomain)
+		if getErr != nil {
+			return "", importErr(getErr)
+		}
+		if existing != nil {
+			params.UpdateNonce = existing.UpdateNonce + 1
+		}
+		out, err = s.c.RegisterIdentity(ctx, s.signer, params)
+	case bulkimport.KindTitle:
+		owners := make([]client.OwnershipStake, 0, len(rec.Owners))
+		for _, o := range rec.Owners {
+			owners = append(owners, client.OwnershipStake{OwnerID: o.OwnerID, Percentage: o.Percentage, StakeType: o.StakeType})
+		}
+		out, err = s.c.RegisterTitle(ctx, s.signer, client.TitleParams{
+			AssetID:   rec.AssetID,
+			Owners:    owners,
+			Domain:    rec.TrustDomain,
+			TitleType: rec.TitleType,
+		})
+	default:
+		return "", fmt.Errorf("unknown record kind %q", rec.Kind)
+	}
+	if err != nil {
+		return "", importErr(err)
+	}
+	id, _ := out["transaction_id"].(string)
+	return id, nil
+}
+
+// importErr marks rate limiting, an unavailable node and transport
+// failures as transient so the import retries, then stops with a
+// resumable state.
+func importErr(err error) error {
+	var ne *client.NodeError
+	var ue *client.UnavailableError
+	switch {
+	case errors.As(err, &ue):
+		return fmt.Errorf("%w: %v", bulkimport.ErrTransient, err)
+	case errors.As(err, &ne) && (ne.StatusCode == 0 || ne.StatusCode == 429 || ne.StatusCode >= 500):
+		return fmt.Errorf("%w: %v", bulkimport.ErrTransient, err)
+	}
+	return err
+}
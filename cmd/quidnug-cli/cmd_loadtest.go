@@ -0,0 +1,112 @@
+// `quidnug-cli loadtest` — drive the node's hot paths and report
+// throughput and latency.
+//
+//	loadtest [--workload trust,block,http] [--duration 5s]
+//	         [--concurrency N] [--graph-size 10000] [--block-txs 1000]
+//	         [--target URL] [--baseline FILE] [--write FILE]
+//
+// trust and block always run in-process; http does too unless
+// --target names a live node. --baseline compares against a saved
+// report and exits non-zero when a workload's throughput drops by
+// more than --tolerance.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/quidnug/quidnug/internal/core"
+	"github.com/quidnug/quidnug/internal/loadtest"
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+func cmdLoadtest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	workloads := fs.String("workload", strings.Join(loadtest.AllWorkloads, ","), "comma-separated workloads (trust, block, http)")
+	duration := fs.Duration("duration", loadtest.DefaultDuration, "wall-clock time per workload")
+	concurrency := fs.Int("concurrency", 0, "workers per workload (default GOMAXPROCS)")
+	graphSize := fs.Int("graph-size", loadtest.DefaultGraphSize, "quids in the synthetic trust graph")
+	degree := fs.Int("degree", loadtest.DefaultDegree, "outgoing trust edges per synthetic quid")
+	blockTxs := fs.Int("block-txs", loadtest.DefaultBlockTxs, "signed transactions in the validated block")
+	seed := fs.Int64("seed", 1, "RNG seed for the graph and query mix")
+	target := fs.String("target", "", "run the http workload against this node URL instead of in-process")
+	baseline := fs.String("baseline", "", "compare against a report saved with --write")
+	tolerance := fs.Float64("tolerance", 0.2, "allowed throughput drop against --baseline (0.2 = 20%)")
+	writePath := fs.String("write", "", "save the report as JSON to this path")
+	logLevel := fs.String("log-level", "error", "node log level on stderr (debug|info|warn|error)")
+	jsonOut := fs.Bool("json", false, "emit the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(*logLevel))); err != nil {
+		return fmt.Errorf("loadtest: --log-level: %w", err)
+	}
+	core.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	var base *loadtest.Report
+	if *baseline != "" {
+		raw, err := safeio.ReadFile(*baseline)
+		if err != nil {
+			return err
+		}
+		base = &loadtest.Report{}
+		if err := json.Unmarshal(raw, base); err != nil {
+			return fmt.Errorf("loadtest: parse %s: %w", *baseline, err)
+		}
+	}
+
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		Workloads:   strings.Split(*workloads, ","),
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		GraphSize:   *graphSize,
+		Degree:      *degree,
+		BlockTxs:    *blockTxs,
+		Seed:        *seed,
+		Target:      *target,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.MarshalIndent(report, "", "  ")
+	if *writePath != "" {
+		if err := os.WriteFile(*writePath, append(body, '\n'), 0o600); err != nil {
+			return err
+		}
+	}
+	if *jsonOut {
+		fmt.Println(string(body))
+	} else {
+		fmt.Printf("go=%s cpus=%d concurrency=%d duration=%s\n",
+			report.GoVersion, report.CPUs, report.Options.Concurrency, report.Options.Duration)
+		for _, r := range report.Results {
+			fmt.Printf("%-6s ops/s=%.1f ops=%d errors=%d p50=%s p90=%s p99=%s max=%s\n",
+				r.Workload, r.OpsPerSec, r.Ops, r.Errors, r.P50, r.P90, r.P99, r.Max)
+		}
+	}
+
+	if base == nil {
+		return nil
+	}
+	regressions, err := loadtest.Compare(base, report, *tolerance)
+	if err != nil {
+		return err
+	}
+	for _, r := range regressions {
+		fmt.Printf("REGRESSION %s: %.1f ops/s vs baseline %.1f (-%.0f%%)\n",
+			r.Workload, r.Current, r.Baseline, r.DropRatio*100)
+	}
+	if len(regressions) > 0 {
+		return errors.New("loadtest: throughput regressed against baseline")
+	}
+	return nil
+}
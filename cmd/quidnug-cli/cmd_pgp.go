@@ -0,0 +1,425 @@
+// `quidnug-cli pgp` — bootstrap trust edges from a PGP web of trust.
+//
+//	pgp plan   --keyring FILE [--mapping-out FILE]
+//	pgp import --keyring FILE --owner FPR --mapping FILE --signer FILE
+//	           [--domain D] [--nonce N] [--register-identity] [--yes]
+//
+// plan lists the keys in a keyring and every certification between
+// them whose signature verifies, and can write a mapping template:
+// a JSON object from each key fingerprint to a quid ID, left empty
+// for the user to fill in. Only the user knows which quid belongs
+// to which PGP key, so nothing is mapped automatically.
+//
+// import turns the certifications the owner's PGP key made into
+// TRUST grants from the signer's quid to the quids the mapping
+// names. The grants are shown first and submitted only once the
+// user confirms (or passes --yes). Certifications between other
+// keys are not imported: only their holders can sign those edges.
+//
+// Certification types map to trust levels:
+//
+//	0x10 generic  0.5
+//	0x11 persona  0.25
+//	0x12 casual   0.5
+//	0x13 positive 0.75
+//
+// The keyring is parsed locally; it never reaches the node.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/quidnug/quidnug/internal/safeio"
+	"github.com/quidnug/quidnug/pkg/client"
+)
+
+// pgpCertLevels maps certification signature types to trust levels.
+var pgpCertLevels = map[packet.SignatureType]float64{
+	packet.SigTypeGenericCert:  0.5,
+	packet.SigTypePersonaCert:  0.25,
+	packet.SigTypeCasualCert:   0.5,
+	packet.SigTypePositiveCert: 0.75,
+}
+
+var pgpCertNames = map[packet.SignatureType]string{
+	packet.SigTypeGenericCert:  "generic",
+	packet.SigTypePersonaCert:  "persona",
+	packet.SigTypeCasualCert:   "casual",
+	packet.SigTypePositiveCert: "positive",
+}
+
+// pgpKey is one key of the keyring.
+type pgpKey struct {
+	Fingerprint string `json:"fingerprint"`
+	UserID      string `json:"userId"`
+}
+
+// pgpCert is a verified certification by one key of another's user
+// ID. Several certifications of the same key collapse into the one
+// with the highest level.
+type pgpCert struct {
+	Certifier string  `json:"certifier"`
+	Subject   string  `json:"subject"`
+	UserID    string  `json:"userId"`
+	Kind      string  `json:"kind"`
+	Level     float64 `json:"level"`
+}
+
+// pgpGrant is a TRUST grant import would submit.
+type pgpGrant struct {
+	Trustee     string  `json:"trustee"`
+	Fingerprint string  `json:"fingerprint"`
+	Level       float64 `json:"level"`
+	Description string  `json:"description"`
+}
+
+// pgpPlan is what import shows before submitting.
+type pgpPlan struct {
+	Owner    pgpKey     `json:"owner"`
+	Grants   []pgpGrant `json:"grants"`
+	Unmapped []pgpCert  `json:"unmapped,omitempty"`
+}
+
+func cmdPGP(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("pgp: subcommand required (plan | import)")
+	}
+	switch args[0] {
+	case "plan":
+		return cmdPGPPlan(args[1:])
+	case "import":
+		return cmdPGPImport(args[1:])
+	default:
+		return fmt.Errorf("pgp: unknown subcommand %q", args[0])
+	}
+}
+
+func cmdPGPPlan(args []string) error {
+	fs := flag.NewFlagSet("pgp plan", flag.ContinueOnError)
+	var cf commonFlags
+	cf.register(fs)
+	keyringPath := fs.String("keyring", "", "PGP keyring, armored or binary (required)")
+	mappingOut := fs.String("mapping-out", "", "write a fingerprint → quid mapping template here")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyringPath == "" {
+		return fmt.Errorf("pgp plan: --keyring is required")
+	}
+	keyring, err := readPGPKeyring(*keyringPath)
+	if err != nil {
+		return err
+	}
+	keys := pgpKeys(keyring)
+	certs := pgpCertifications(keyring)
+
+	if *mappingOut != "" {
+		mapping := make(map[string]string, len(keys))
+		for _, k := range keys {
+			mapping[k.Fingerprint] = ""
+		}
+		data, _ := json.MarshalIndent(mapping, "", "  ")
+		if err := os.WriteFile(*mappingOut, append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("pgp plan: write mapping: %w", err)
+		}
+	}
+
+	if cf.JSON {
+		return emit(cf, map[string]any{"keys": keys, "certifications": certs})
+	}
+	for _, k := range keys {
+		fmt.Printf("key %s %s\n", k.Fingerprint, k.UserID)
+	}
+	for _, c := range certs {
+		fmt.Printf("cert %s -> %s %s level=%.2f\n", c.Certifier, c.Subject, c.Kind, c.Level)
+	}
+	return nil
+}
+
+func cmdPGPImport(args []string) error {
+	fs := flag.NewFlagSet("pgp import", flag.ContinueOnError)
+	var cf commonFlags
+	cf.register(fs)
+	keyringPath := fs.String("keyring", "", "PGP keyring, armored or binary (required)")
+	owner := fs.String("owner", "", "fingerprint of your own PGP key (required)")
+	mappingPath := fs.String("mapping", "", "JSON fingerprint → quid mapping (required)")
+	signerPath := fs.String("signer", "", "path to your quid file (required)")
+	domain := fs.String("domain", "default", "trust domain")
+	nonce := fs.Int64("nonce", 1, "nonce for each grant (monotonic per truster and trustee)")
+	registerIdentity := fs.Bool("register-identity", false, "also register your identity with your PGP user ID and fingerprint")
+	yes := fs.Bool("yes", false, "submit without asking for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyringPath == "" || *owner == "" || *mappingPath == "" || *signerPath == "" {
+		return fmt.Errorf("--keyring, --owner, --mapping and --signer are required")
+	}
+	keyring, err := readPGPKeyring(*keyringPath)
+	if err != nil {
+		return err
+	}
+	mapping, err := readPGPMapping(*mappingPath)
+	if err != nil {
+		return err
+	}
+	plan, err := buildPGPPlan(keyring, normalizeFingerprint(*owner), mapping)
+	if err != nil {
+		return err
+	}
+	signer, err := loadQuid(*signerPath)
+	if err != nil {
+		return err
+	}
+
+	printPGPPlan(os.Stdout, signer.ID, plan)
+	if len(plan.Grants) == 0 && !*registerIdentity {
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Sign and submit %d trust grants as %s? [y/N] ", len(plan.Grants), signer.ID))
+		if err != nil || !ok {
+			return fmt.Errorf("pgp import: not confirmed")
+		}
+	}
+
+	c, err := cf.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cf.Timeout+5*time.Second)
+	defer cancel()
+
+	var results []any
+	if *registerIdentity {
+		r, err := c.RegisterIdentity(ctx, signer, client.IdentityParams{
+			Domain:     *domain,
+			Name:       plan.Owner.UserID,
+			Attributes: map[string]any{"pgpFingerprint": plan.Owner.Fingerprint},
+		})
+		if err != nil {
+			return fmt.Errorf("pgp import: register identity: %w", err)
+		}
+		results = append(results, r)
+	}
+	for _, g := range plan.Grants {
+		r, err := c.GrantTrust(ctx, signer, client.TrustParams{
+			Trustee:     g.Trustee,
+			Level:       g.Level,
+			Domain:      *domain,
+			Nonce:       *nonce,
+			Description: g.Description,
+		})
+		if err != nil {
+			return fmt.Errorf("pgp import: grant to %s: %w", g.Trustee, err)
+		}
+		results = append(results, r)
+	}
+	return emit(cf, results)
+}
+
+// readPGPKeyring reads an armored or binary keyring.
+func readPGPKeyring(path string) (openpgp.EntityList, error) {
+	data, err := safeio.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pgp: read keyring: %w", err)
+	}
+	return parsePGPKeyring(data)
+}
+
+func parsePGPKeyring(data []byte) (openpgp.EntityList, error) {
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		el, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("pgp: parse armored keyring: %w", err)
+		}
+		return el, nil
+	}
+	el, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pgp: parse keyring: %w", err)
+	}
+	return el, nil
+}
+
+// readPGPMapping reads the fingerprint → quid mapping, dropping
+// entries left empty.
+func readPGPMapping(path string) (map[string]string, error) {
+	data, err := safeio.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pgp: read mapping: %w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("pgp: parse mapping: %w", err)
+	}
+	mapping := make(map[string]string, len(raw))
+	for fpr, quid := range raw {
+		if quid == "" {
+			continue
+		}
+		if !isQuidID(quid) {
+			return nil, fmt.Errorf("pgp: mapping for %s: %q is not a quid ID", fpr, quid)
+		}
+		mapping[normalizeFingerprint(fpr)] = quid
+	}
+	return mapping, nil
+}
+
+func isQuidID(s string) bool {
+	if len(s) != 16 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func normalizeFingerprint(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+}
+
+func pgpFingerprint(e *openpgp.Entity) string {
+	return strings.ToUpper(hex.EncodeToString(e.PrimaryKey.Fingerprint[:]))
+}
+
+// pgpUserID returns the entity's primary user ID, or the first by
+// name when none is marked primary.
+func pgpUserID(e *openpgp.Entity) string {
+	names := make([]string, 0, len(e.Identities))
+	for name, id := range e.Identities {
+		if id.SelfSignature != nil && id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId {
+			return name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// pgpKeys lists the keyring's keys by fingerprint.
+func pgpKeys(el openpgp.EntityList) []pgpKey {
+	keys := make([]pgpKey, 0, len(el))
+	for _, e := range el {
+		keys = append(keys, pgpKey{Fingerprint: pgpFingerprint(e), UserID: pgpUserID(e)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Fingerprint < keys[j].Fingerprint })
+	return keys
+}
+
+// pgpCertifications returns the certifications between keys of the
+// keyring whose signatures verify, one per certifier and subject.
+// Certifications by keys outside the keyring cannot be checked and
+// are skipped.
+func pgpCertifications(el openpgp.EntityList) []pgpCert {
+	best := make(map[[2]string]pgpCert)
+	for _, subject := range el {
+		subjectFpr := pgpFingerprint(subject)
+		for name, id := range subject.Identities {
+			for _, sig := range id.Signatures {
+				level, ok := pgpCertLevels[sig.SigType]
+				if !ok || sig.IssuerKeyId == nil {
+					continue
+				}
+				for _, key := range el.KeysById(*sig.IssuerKeyId) {
+					if key.Entity == subject || key.PublicKey != key.Entity.PrimaryKey {
+						continue
+					}
+					if key.PublicKey.VerifyUserIdSignature(name, subject.PrimaryKey, sig) != nil {
+						continue
+					}
+					cert := pgpCert{
+						Certifier: pgpFingerprint(key.Entity),
+						Subject:   subjectFpr,
+						UserID:    name,
+						Kind:      pgpCertNames[sig.SigType],
+						Level:     level,
+					}
+					k := [2]string{cert.Certifier, cert.Subject}
+					if prev, seen := best[k]; !seen || cert.Level > prev.Level {
+						best[k] = cert
+					}
+				}
+			}
+		}
+	}
+	certs := make([]pgpCert, 0, len(best))
+	for _, c := range best {
+		certs = append(certs, c)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		if certs[i].Certifier != certs[j].Certifier {
+			return certs[i].Certifier < certs[j].Certifier
+		}
+		return certs[i].Subject < certs[j].Subject
+	})
+	return certs
+}
+
+// buildPGPPlan selects the owner's certifications and maps their
+// subjects to quids.
+func buildPGPPlan(el openpgp.EntityList, owner string, mapping map[string]string) (*pgpPlan, error) {
+	plan := &pgpPlan{}
+	for _, k := range pgpKeys(el) {
+		if k.Fingerprint == owner {
+			plan.Owner = k
+		}
+	}
+	if plan.Owner.Fingerprint == "" {
+		return nil, fmt.Errorf("pgp: owner key %s is not in the keyring", owner)
+	}
+	for _, c := range pgpCertifications(el) {
+		if c.Certifier != owner {
+			continue
+		}
+		quid, ok := mapping[c.Subject]
+		if !ok {
+			plan.Unmapped = append(plan.Unmapped, c)
+			continue
+		}
+		plan.Grants = append(plan.Grants, pgpGrant{
+			Trustee:     quid,
+			Fingerprint: c.Subject,
+			Level:       c.Level,
+			Description: fmt.Sprintf("PGP %s certification of %s", c.Kind, c.UserID),
+		})
+	}
+	return plan, nil
+}
+
+func printPGPPlan(w io.Writer, signer string, plan *pgpPlan) {
+	fmt.Fprintf(w, "owner %s %s\n", plan.Owner.Fingerprint, plan.Owner.UserID)
+	for _, g := range plan.Grants {
+		fmt.Fprintf(w, "grant %s -> %s level=%.2f (%s, %s)\n", signer, g.Trustee, g.Level, g.Fingerprint, g.Description)
+	}
+	for _, c := range plan.Unmapped {
+		fmt.Fprintf(w, "skip %s %s: no quid in mapping\n", c.Subject, c.UserID)
+	}
+}
+
+// confirm asks a yes/no question on out and reads the answer from
+// in.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprint(out, question)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func newPGPEntity(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+	e, err := openpgp.NewEntity(name, "", strings.ToLower(name)+"@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func onlyIdentity(e *openpgp.Entity) string {
+	for name := range e.Identities {
+		return name
+	}
+	return ""
+}
+
+// TestPGPPlan builds a three-key keyring where alice certified bob
+// and carol, and carol certified bob, and checks that only alice's
+// certifications of mapped keys become grants.
+func TestPGPPlan(t *testing.T) {
+	alice := newPGPEntity(t, "Alice")
+	bob := newPGPEntity(t, "Bob")
+	carol := newPGPEntity(t, "Carol")
+	for _, c := range []struct{ subject, signer *openpgp.Entity }{
+		{bob, alice}, {carol, alice}, {bob, carol},
+	} {
+		if err := c.subject.SignIdentity(onlyIdentity(c.subject), c.signer, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ring bytes.Buffer
+	for _, e := range []*openpgp.Entity{alice, bob, carol} {
+		if err := e.Serialize(&ring); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keyring, err := parsePGPKeyring(ring.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certs := pgpCertifications(keyring); len(certs) != 3 {
+		t.Fatalf("certifications = %+v, want 3", certs)
+	}
+
+	bobFpr := pgpFingerprint(bob)
+	plan, err := buildPGPPlan(keyring, pgpFingerprint(alice), map[string]string{
+		bobFpr:                "b0b0000000000001",
+		pgpFingerprint(alice): "a11ce00000000001",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Owner.UserID != onlyIdentity(alice) {
+		t.Errorf("owner user ID = %q", plan.Owner.UserID)
+	}
+	if len(plan.Grants) != 1 || plan.Grants[0].Trustee != "b0b0000000000001" || plan.Grants[0].Fingerprint != bobFpr {
+		t.Fatalf("grants = %+v", plan.Grants)
+	}
+	if plan.Grants[0].Level != pgpCertLevels[packet.SigTypeGenericCert] {
+		t.Errorf("level = %v", plan.Grants[0].Level)
+	}
+	if len(plan.Unmapped) != 1 || plan.Unmapped[0].Subject != pgpFingerprint(carol) {
+		t.Errorf("unmapped = %+v", plan.Unmapped)
+	}
+
+	if _, err := buildPGPPlan(keyring, "00", nil); err == nil {
+		t.Error("owner outside the keyring accepted")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	for answer, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false} {
+		got, _ := confirm(strings.NewReader(answer), &out, "? ")
+		if got != want {
+			t.Errorf("confirm(%q) = %v", answer, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// `quidnug-cli simnet` — run an in-process multi-node network.
+//
+//	simnet run --scenario FILE   start the scenario's nodes on
+//	                             loopback, seed and drive them, and
+//	                             report convergence + expectations
+//
+// No --node is involved: every node lives inside the CLI process
+// and is torn down when the run ends. Node logs go to stderr at
+// --log-level so stdout carries only the report.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/core"
+	"github.com/quidnug/quidnug/internal/simnet"
+)
+
+func cmdSimnet(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("simnet: subcommand required (run)")
+	}
+	switch args[0] {
+	case "run":
+		return cmdSimnetRun(args[1:])
+	default:
+		return fmt.Errorf("simnet: unknown subcommand %q", args[0])
+	}
+}
+
+func cmdSimnetRun(args []string) error {
+	fs := flag.NewFlagSet("simnet run", flag.ContinueOnError)
+	scenarioPath := fs.String("scenario", "", "scenario file (YAML or JSON)")
+	nodes := fs.Int("nodes", 0, "override the scenario's node count")
+	timeout := fs.Duration("timeout", 2*time.Minute, "abort the run after this long")
+	logLevel := fs.String("log-level", "warn", "node log level on stderr (debug|info|warn|error)")
+	jsonOut := fs.Bool("json", false, "emit the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *scenarioPath == "" {
+		return fmt.Errorf("simnet run: --scenario is required")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(*logLevel))); err != nil {
+		return fmt.Errorf("simnet run: --log-level: %w", err)
+	}
+	core.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	sc, err := simnet.LoadScenario(*scenarioPath)
+	if err != nil {
+		return err
+	}
+	if *nodes > 0 {
+		sc.Nodes = *nodes
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report, err := simnet.Run(ctx, sc)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOut {
+		body, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(body))
+	} else {
+		printSimnetReport(report)
+	}
+	if report.Failed() {
+		return errors.New("simnet: scenario failed")
+	}
+	return nil
+}
+
+func printSimnetReport(r *simnet.Report) {
+	fmt.Printf("scenario=%s\n", r.Scenario)
+	fmt.Printf("nodes=%d\n", r.Nodes)
+	fmt.Printf("transactions=%d\n", r.Transactions)
+	fmt.Printf("blocksSealed=%d\n", r.BlocksSealed)
+	fmt.Printf("heights=%v\n", r.Heights)
+	fmt.Printf("converged=%v\n", r.Converged)
+	fmt.Printf("duration=%s\n", r.Duration.Round(time.Millisecond))
+	for _, x := range r.Expectations {
+		status := "PASS"
+		if !x.Passed {
+			status = "FAIL"
+		}
+		fmt.Printf("%s node=%d %s->%s trust=%.4f\n", status, x.Node, x.Observer, x.Target, x.Trust)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/core"
+	"github.com/quidnug/quidnug/pkg/client"
+)
+
+// The node checks the signature against the request URI it sees,
+// which includes /api and any prefix on the node URL.
+func TestAdminHeaderSignsRequestURI(t *testing.T) {
+	admin, err := client.GenerateQuid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := adminHeader(admin, "http://node.example/quidnug/", "/admin/verify?check=ownership_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, _ := strconv.ParseInt(header.Get(core.AdminTimestampHeader), 10, 64)
+	data, _ := json.Marshal(core.AdminRequestSignable{
+		Method:    http.MethodGet,
+		Path:      "/quidnug/api/admin/verify?check=ownership_total",
+		Timestamp: ts,
+		PublicKey: header.Get(core.AdminPublicKeyHeader),
+	})
+	if !core.VerifySignature(admin.PublicKeyHex, data, header.Get(core.AdminSignatureHeader)) {
+		t.Fatal("signature does not verify against the request URI")
+	}
+}
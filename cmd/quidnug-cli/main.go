@@ -114,6 +114,18 @@ func run(args []string) error {
 		return cmdWellKnown(rest)
 	case "dns":
 		return cmdDNS(rest)
+	case "simnet":
+		return cmdSimnet(rest)
+	case "replay":
+		return cmdReplay(rest)
+	case "verify":
+		return cmdVerify(rest)
+	case "loadtest":
+		return cmdLoadtest(rest)
+	case "pgp":
+		return cmdPGP(rest)
+	case "import":
+		return cmdImport(rest)
 	default:
 		return fmt.Errorf("unknown command %q (try `quidnug-cli help`)", cmd)
 	}
@@ -931,9 +943,18 @@ Commands:
   trust grant --signer FILE --trustee QUID --level N [--domain D] [--nonce N]
   trust get OBSERVER TARGET [--domain D] [--max-depth 5]
   trust edges QUID
+  pgp plan --keyring FILE [--mapping-out FILE]
+                                            List keys + verified certifications
+  pgp import --keyring FILE --owner FPR --mapping FILE --signer FILE
+             [--domain D] [--register-identity] [--yes]
+                                            Turn your PGP certifications into
+                                            trust grants after confirmation
 
   title register --signer FILE --asset ID --owners JSON [--title-type T]
   title get ASSET [--domain D]
+  import --mapping FILE --input FILE --quid FILE [--state FILE]
+                                            Bulk-import identities or titles
+                                            from a CSV/JSONL export; resumable
 
   event emit --signer FILE --subject-id X --subject-type QUID|TITLE \
              --type T [--payload JSON | --payload-cid CID]
@@ -973,6 +994,16 @@ Commands:
   well-known generate --operator-key FILE --api-gateway URL --seeds-json JSON \
                       [--domains-json JSON] [--operator-name ...] [--out FILE]
 
+  simnet run --scenario FILE [--nodes N]    Run an in-process multi-node scenario
+             [--timeout 2m] [--log-level warn] [--json]
+  replay --blocks FILE | --fuzz N [--seed S]  Replay blocks with a frozen clock;
+         [--runs 3] [--clock UNIX] [--process-all]  fail on divergence or panic
+  verify --admin-key FILE                   Run the node's registry invariant
+         [--check NAME,...]                 checks; fail on any violation
+  loadtest [--workload trust,block,http]    Benchmark hot paths in-process (or
+           [--duration 5s] [--target URL]   --target a node); --baseline FILE
+           [--baseline FILE] [--write FILE] fails on a throughput regression
+
 Global flags (honored everywhere):
   --node URL         (env QUIDNUG_NODE, default http://localhost:8080)
   --timeout DUR      (default 30s)
@@ -0,0 +1,279 @@
+// Package blobstore is a small content-addressed store for the
+// files transactions point at: documents cited as trust evidence,
+// identity avatars, title deeds and the like. Nothing here is
+// on-chain. A transaction carries a blob's digest, "sha256:<hex>",
+// and the node that accepted the upload serves the bytes.
+//
+// Each blob has a metadata file, <hex>.meta.json, under the store
+// directory. Its content lives either next to it as <hex>.blob or,
+// when the store is opened with a Remote, in IPFS, with the CID
+// recorded in the metadata. Reads always re-hash the content, so a
+// corrupted file or a misbehaving IPFS gateway cannot serve bytes
+// that do not match the digest.
+//
+// The store does not decide what to keep. The node's garbage
+// collector deletes blobs that no transaction references (see
+// internal/core/blobs.go).
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// DigestPrefix starts every blob digest.
+const DigestPrefix = "sha256:"
+
+const (
+	metaSuffix = ".meta.json"
+	blobSuffix = ".blob"
+)
+
+// Package-level errors for blob operations.
+var (
+	ErrNotFound      = errors.New("blobstore: blob not found")
+	ErrInvalidDigest = errors.New("blobstore: invalid digest")
+	ErrEmpty         = errors.New("blobstore: empty blob")
+	ErrCorrupt       = errors.New("blobstore: content does not match digest")
+)
+
+// DigestPattern matches a blob digest. Callers use it to find blob
+// references inside arbitrary transaction JSON.
+var DigestPattern = regexp.MustCompile(`sha256:[a-f0-9]{64}`)
+
+var fullDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Remote is the subset of an IPFS client the store needs.
+// ipfsclient.IPFSClient satisfies it.
+type Remote interface {
+	Pin(ctx context.Context, data []byte) (string, error)
+	Get(ctx context.Context, cid string) ([]byte, error)
+}
+
+// Unpinner is implemented by remotes that can release content.
+type Unpinner interface {
+	Unpin(ctx context.Context, cid string) error
+}
+
+// Meta describes one stored blob.
+type Meta struct {
+	Digest      string `json:"digest"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType,omitempty"`
+	Created     int64  `json:"created"`
+	CID         string `json:"cid,omitempty"`
+}
+
+// Store is a directory of blobs. It is safe for concurrent use.
+type Store struct {
+	dir    string
+	remote Remote
+	mu     sync.Mutex
+}
+
+// Open returns a store rooted at dir, creating it if needed. A nil
+// remote keeps content on local disk.
+func Open(dir string, remote Remote) (*Store, error) {
+	clean, err := safeio.ValidatePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("blobstore: %w", err)
+	}
+	if err := safeio.MkdirAll(clean); err != nil {
+		return nil, fmt.Errorf("blobstore: create %s: %w", clean, err)
+	}
+	return &Store{dir: clean, remote: remote}, nil
+}
+
+// Digest returns the digest of data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return DigestPrefix + hex.EncodeToString(sum[:])
+}
+
+// ValidDigest reports whether d is a well-formed digest.
+func ValidDigest(d string) bool {
+	return fullDigest.MatchString(d)
+}
+
+// Put stores data and returns its metadata. Storing content that is
+// already present returns the existing metadata unchanged.
+func (s *Store) Put(ctx context.Context, data []byte, contentType string) (Meta, error) {
+	if len(data) == 0 {
+		return Meta{}, ErrEmpty
+	}
+	digest := Digest(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if meta, err := s.readMeta(digest); err == nil {
+		return meta, nil
+	}
+
+	meta := Meta{
+		Digest:      digest,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		Created:     time.Now().Unix(),
+	}
+	if s.remote != nil {
+		cid, err := s.remote.Pin(ctx, data)
+		if err != nil {
+			return Meta{}, fmt.Errorf("blobstore: pin: %w", err)
+		}
+		meta.CID = cid
+	} else if err := s.writeAtomic(s.path(digest, blobSuffix), data); err != nil {
+		return Meta{}, err
+	}
+
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return Meta{}, err
+	}
+	if err := s.writeAtomic(s.path(digest, metaSuffix), encoded); err != nil {
+		return Meta{}, err
+	}
+	return meta, nil
+}
+
+// Stat returns a blob's metadata.
+func (s *Store) Stat(digest string) (Meta, error) {
+	if !ValidDigest(digest) {
+		return Meta{}, ErrInvalidDigest
+	}
+	return s.readMeta(digest)
+}
+
+// Get returns a blob's metadata and content.
+func (s *Store) Get(ctx context.Context, digest string) (Meta, []byte, error) {
+	meta, err := s.Stat(digest)
+	if err != nil {
+		return Meta{}, nil, err
+	}
+	var data []byte
+	if meta.CID != "" {
+		if s.remote == nil {
+			return Meta{}, nil, fmt.Errorf("blobstore: %s is held in IPFS but no IPFS client is configured", digest)
+		}
+		data, err = s.remote.Get(ctx, meta.CID)
+	} else {
+		data, err = safeio.ReadFile(s.path(digest, blobSuffix))
+		if errors.Is(err, os.ErrNotExist) {
+			err = ErrNotFound
+		}
+	}
+	if err != nil {
+		return Meta{}, nil, err
+	}
+	if Digest(data) != digest {
+		return Meta{}, nil, ErrCorrupt
+	}
+	return meta, data, nil
+}
+
+// Delete removes a blob, unpinning it from IPFS when the remote
+// supports that. Deleting a missing blob is not an error.
+func (s *Store) Delete(ctx context.Context, digest string) error {
+	if !ValidDigest(digest) {
+		return ErrInvalidDigest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta, err := s.readMeta(digest)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if meta.CID != "" {
+		if u, ok := s.remote.(Unpinner); ok {
+			if err := u.Unpin(ctx, meta.CID); err != nil {
+				return fmt.Errorf("blobstore: unpin %s: %w", meta.CID, err)
+			}
+		}
+	}
+	// Metadata goes first: a blob file without metadata is invisible
+	// and gets overwritten by the next Put of the same content.
+	if err := os.Remove(s.path(digest, metaSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.path(digest, blobSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the metadata of every blob, oldest first.
+func (s *Store) List() ([]Meta, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Meta
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), metaSuffix)
+		if !ok {
+			continue
+		}
+		meta, err := s.readMeta(DigestPrefix + name)
+		if err != nil {
+			continue
+		}
+		out = append(out, meta)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Created != out[j].Created {
+			return out[i].Created < out[j].Created
+		}
+		return out[i].Digest < out[j].Digest
+	})
+	return out, nil
+}
+
+func (s *Store) path(digest, suffix string) string {
+	return filepath.Join(s.dir, strings.TrimPrefix(digest, DigestPrefix)+suffix)
+}
+
+func (s *Store) readMeta(digest string) (Meta, error) {
+	if !ValidDigest(digest) {
+		return Meta{}, ErrInvalidDigest
+	}
+	data, err := safeio.ReadFile(s.path(digest, metaSuffix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Meta{}, ErrNotFound
+		}
+		return Meta{}, err
+	}
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Digest != digest {
+		return Meta{}, fmt.Errorf("blobstore: bad metadata for %s", digest)
+	}
+	return meta, nil
+}
+
+// writeAtomic writes data to p through a temporary file.
+func (s *Store) writeAtomic(p string, data []byte) error {
+	tmp := p + ".tmp"
+	if err := safeio.WriteFile(tmp, data); err != nil {
+		return fmt.Errorf("blobstore: write: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("blobstore: write: %w", err)
+	}
+	return nil
+}
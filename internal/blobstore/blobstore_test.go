@@ -0,0 +1,123 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRemote is an in-memory IPFS stand-in keyed by a fake CID.
+type fakeRemote struct {
+	pinned map[string][]byte
+}
+
+func (f *fakeRemote) Pin(_ context.Context, data []byte) (string, error) {
+	cid := "bafy" + strings.TrimPrefix(Digest(data), DigestPrefix)[:20]
+	f.pinned[cid] = append([]byte(nil), data...)
+	return cid, nil
+}
+
+func (f *fakeRemote) Get(_ context.Context, cid string) ([]byte, error) {
+	data, ok := f.pinned[cid]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (f *fakeRemote) Unpin(_ context.Context, cid string) error {
+	delete(f.pinned, cid)
+	return nil
+}
+
+func TestStore_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := s.Put(ctx, []byte("deed of sale"), "text/plain")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if meta.Digest != Digest([]byte("deed of sale")) || meta.Size != 12 || meta.CID != "" {
+		t.Fatalf("meta = %+v", meta)
+	}
+	again, err := s.Put(ctx, []byte("deed of sale"), "application/octet-stream")
+	if err != nil || again != meta {
+		t.Fatalf("second Put: %+v, %v; want the original metadata", again, err)
+	}
+
+	got, data, err := s.Get(ctx, meta.Digest)
+	if err != nil || string(data) != "deed of sale" || got.ContentType != "text/plain" {
+		t.Fatalf("Get: %+v %q %v", got, data, err)
+	}
+	if list, _ := s.List(); len(list) != 1 {
+		t.Fatalf("List returned %d blobs, want 1", len(list))
+	}
+
+	if err := s.Delete(ctx, meta.Digest); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := s.Get(ctx, meta.Digest); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v", err)
+	}
+	if _, err := s.Put(ctx, nil, ""); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("empty Put: err = %v", err)
+	}
+	if _, err := s.Stat("sha256:../../etc/passwd"); !errors.Is(err, ErrInvalidDigest) {
+		t.Fatalf("Stat of a bad digest: err = %v", err)
+	}
+}
+
+func TestStore_DetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir, nil)
+	meta, err := s.Put(context.Background(), []byte("original"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := filepath.Join(dir, strings.TrimPrefix(meta.Digest, DigestPrefix)+blobSuffix)
+	if err := os.WriteFile(blob, []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(context.Background(), meta.Digest); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Get of tampered blob: err = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestStore_RemoteBackend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	remote := &fakeRemote{pinned: make(map[string][]byte)}
+	s, _ := Open(dir, remote)
+
+	meta, err := s.Put(ctx, []byte("avatar bytes"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.CID == "" || len(remote.pinned) != 1 {
+		t.Fatalf("content not pinned: %+v", meta)
+	}
+	if _, err := os.Stat(filepath.Join(dir, strings.TrimPrefix(meta.Digest, DigestPrefix)+blobSuffix)); !os.IsNotExist(err) {
+		t.Fatal("remote-backed store kept a local copy")
+	}
+	if _, data, err := s.Get(ctx, meta.Digest); err != nil || string(data) != "avatar bytes" {
+		t.Fatalf("Get: %q, %v", data, err)
+	}
+
+	remote.pinned[meta.CID] = []byte("swapped by the gateway")
+	if _, _, err := s.Get(ctx, meta.Digest); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Get of swapped content: err = %v, want ErrCorrupt", err)
+	}
+
+	if err := s.Delete(ctx, meta.Digest); err != nil {
+		t.Fatal(err)
+	}
+	if len(remote.pinned) != 0 {
+		t.Fatal("Delete did not unpin")
+	}
+}
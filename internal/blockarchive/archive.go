@@ -0,0 +1,230 @@
+// Package blockarchive defines pluggable off-chain storage for
+// pruned blocks. The node keeps block headers in its chain and
+// moves full blocks into an Archive once they fall outside the
+// configured retention window; historical reads fetch them back on
+// demand.
+//
+// An Archive is a flat key → bytes object store. Encoding (the
+// node stores gzip-compressed JSON) and integrity checks (the node
+// re-hashes every fetched block against its retained header) live
+// in the caller, so backends stay dumb and interchangeable.
+//
+// Implementations: MemoryArchive, used in tests and as a reference
+// for the semantics; FileArchive, one file per key under a local
+// directory; S3Archive, for S3 and S3-compatible stores; and
+// GCSArchive, for Google Cloud Storage. The object-store backends
+// speak the HTTP APIs directly so no SDK dependency is needed.
+package blockarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// Backend names accepted in configuration.
+const (
+	BackendFile = "file"
+	BackendS3   = "s3"
+	BackendGCS  = "gcs"
+)
+
+// Package-level errors for archive operations.
+var (
+	ErrNotFound   = errors.New("block archive: object not found")
+	ErrInvalidKey = errors.New("block archive: invalid key")
+	ErrBackend    = errors.New("block archive: backend error")
+)
+
+// Archive stores immutable objects by key. Keys are slash-separated
+// segments of [A-Za-z0-9._-]; see ValidateKey.
+type Archive interface {
+	// Put stores data under key, replacing any previous object.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the object under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns every key starting with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Close releases backend resources.
+	Close() error
+}
+
+// ValidateKey rejects keys that are empty, contain characters
+// outside [A-Za-z0-9._-/], or have empty, "." or ".." segments, so
+// no backend can be steered outside its root.
+func ValidateKey(key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+		for _, r := range seg {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			case r == '.', r == '_', r == '-':
+			default:
+				return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+			}
+		}
+	}
+	return nil
+}
+
+// MemoryArchive implements Archive with an in-process map.
+type MemoryArchive struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryArchive creates an empty in-memory archive.
+func NewMemoryArchive() *MemoryArchive {
+	return &MemoryArchive{objects: make(map[string][]byte)}
+}
+
+// Put implements Archive.
+func (a *MemoryArchive) Put(_ context.Context, key string, data []byte) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	a.mu.Lock()
+	a.objects[key] = cp
+	a.mu.Unlock()
+	return nil
+}
+
+// Get implements Archive.
+func (a *MemoryArchive) Get(_ context.Context, key string) ([]byte, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	data, ok := a.objects[key]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	return cp, nil
+}
+
+// List implements Archive.
+func (a *MemoryArchive) List(_ context.Context, prefix string) ([]string, error) {
+	a.mu.RLock()
+	var keys []string
+	for k := range a.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	a.mu.RUnlock()
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Len returns the number of stored objects.
+func (a *MemoryArchive) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.objects)
+}
+
+// Close implements Archive.
+func (a *MemoryArchive) Close() error { return nil }
+
+// FileArchive implements Archive as one file per key under Dir.
+type FileArchive struct {
+	Dir string
+}
+
+// NewFileArchive returns an archive rooted at dir, creating it if
+// needed.
+func NewFileArchive(dir string) (*FileArchive, error) {
+	if err := safeio.MkdirAllMode(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("block archive: create %q: %w", dir, err)
+	}
+	return &FileArchive{Dir: dir}, nil
+}
+
+func (a *FileArchive) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(a.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put implements Archive. The object is written to a temporary
+// file and renamed into place so readers never see a partial
+// object.
+func (a *FileArchive) Put(_ context.Context, key string, data []byte) error {
+	p, err := a.path(key)
+	if err != nil {
+		return err
+	}
+	if err := safeio.MkdirAllMode(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := safeio.WriteFileMode(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Get implements Archive.
+func (a *FileArchive) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := a.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := safeio.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// List implements Archive. In-flight temporary files are skipped.
+func (a *FileArchive) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(a.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(a.Dir, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close implements Archive.
+func (a *FileArchive) Close() error { return nil }
@@ -0,0 +1,68 @@
+package blockarchive
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"blocks/example.com/abc123.json.gz", "a", "x_y-z/1"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) = %v, want nil", key, err)
+		}
+	}
+	for _, key := range []string{"", "/abs", "a//b", "../etc/passwd", "a/./b", "a b", "a\\b", "trail/"} {
+		if err := ValidateKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ValidateKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func testArchiveRoundTrip(t *testing.T, a Archive) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := a.Get(ctx, "blocks/d/missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing = %v, want ErrNotFound", err)
+	}
+	if err := a.Put(ctx, "blocks/d/h1", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Put(ctx, "blocks/d/h1", []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.Get(ctx, "blocks/d/h1")
+	if err != nil || string(got) != "two" {
+		t.Fatalf("Get = %q, %v; want \"two\"", got, err)
+	}
+	if err := a.Put(ctx, "../escape", []byte("x")); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Put with traversal key = %v, want ErrInvalidKey", err)
+	}
+
+	if err := a.Put(ctx, "snapshots/s1", []byte("s")); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := a.List(ctx, "blocks/")
+	if err != nil || len(keys) != 1 || keys[0] != "blocks/d/h1" {
+		t.Fatalf("List(blocks/) = %v, %v", keys, err)
+	}
+	if keys, _ := a.List(ctx, ""); len(keys) != 2 {
+		t.Fatalf("List(\"\") = %v, want 2 keys", keys)
+	}
+}
+
+func TestMemoryArchive(t *testing.T) {
+	a := NewMemoryArchive()
+	testArchiveRoundTrip(t, a)
+	if a.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", a.Len())
+	}
+}
+
+func TestFileArchive(t *testing.T) {
+	a, err := NewFileArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testArchiveRoundTrip(t, a)
+}
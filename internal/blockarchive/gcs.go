@@ -0,0 +1,183 @@
+package blockarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGCSEndpoint is the Cloud Storage XML API base URL.
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// gceMetadataTokenURL serves the default service account's access
+// token on GCE, GKE and Cloud Run.
+const gceMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// TokenSource returns an OAuth2 bearer token for Cloud Storage.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource that always yields token.
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
+// MetadataTokenSource fetches tokens from the GCE metadata server
+// and caches each until a minute before it expires.
+func MetadataTokenSource(client *http.Client) TokenSource {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expires) {
+			return token, nil
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gceMetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("%w: metadata token: %v", ErrBackend, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", backendError("metadata token", resp)
+		}
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.AccessToken == "" {
+			return "", fmt.Errorf("%w: metadata token: malformed response", ErrBackend)
+		}
+		token = body.AccessToken
+		expires = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
+
+// GCSConfig addresses a Cloud Storage bucket.
+type GCSConfig struct {
+	// Endpoint defaults to DefaultGCSEndpoint.
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to every key.
+	Prefix string
+	// Token authorizes requests. Required.
+	Token TokenSource
+}
+
+// GCSArchive implements Archive on a Cloud Storage bucket via the
+// XML API with OAuth2 bearer tokens.
+type GCSArchive struct {
+	cfg    GCSConfig
+	base   string
+	client *http.Client
+}
+
+// NewGCSArchive validates cfg and returns an archive using client
+// for all requests.
+func NewGCSArchive(cfg GCSConfig, client *http.Client) (*GCSArchive, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("block archive: gcs requires a bucket")
+	}
+	if cfg.Token == nil {
+		return nil, fmt.Errorf("block archive: gcs requires a token source")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultGCSEndpoint
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("block archive: invalid gcs endpoint %q", cfg.Endpoint)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GCSArchive{cfg: cfg, base: strings.TrimRight(cfg.Endpoint, "/") + "/" + cfg.Bucket, client: client}, nil
+}
+
+// Put implements Archive.
+func (a *GCSArchive) Put(ctx context.Context, key string, data []byte) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	resp, err := a.do(ctx, http.MethodPut, "/"+joinPrefix(a.cfg.Prefix, key), nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return backendError("gcs put", resp)
+	}
+	return nil
+}
+
+// Get implements Archive.
+func (a *GCSArchive) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := a.do(ctx, http.MethodGet, "/"+joinPrefix(a.cfg.Prefix, key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, backendError("gcs get", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// List implements Archive. The XML API accepts the same
+// ListObjectsV2 query as S3.
+func (a *GCSArchive) List(ctx context.Context, prefix string) ([]string, error) {
+	return listObjectsV2(ctx, prefix, a.cfg.Prefix, func(q url.Values) (*http.Response, error) {
+		return a.do(ctx, http.MethodGet, "", q, nil)
+	})
+}
+
+// Close implements Archive.
+func (a *GCSArchive) Close() error { return nil }
+
+func (a *GCSArchive) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	token, err := a.cfg.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target := a.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackend, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,317 @@
+package blockarchive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config addresses a bucket on AWS S3 or an S3-compatible store
+// (MinIO, Ceph RGW, R2, ...). Requests are path-style
+// (Endpoint/Bucket/Key) and signed with AWS Signature Version 4.
+type S3Config struct {
+	// Endpoint is the service base URL. Empty selects
+	// https://s3.<Region>.amazonaws.com.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every key, e.g. "quidnug/node-1".
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+}
+
+// S3Archive implements Archive on an S3 bucket.
+type S3Archive struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Archive validates cfg and returns an archive using client
+// for all requests.
+func NewS3Archive(cfg S3Config, client *http.Client) (*S3Archive, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("block archive: s3 requires a bucket")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("block archive: s3 requires access key credentials")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("block archive: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3Archive{cfg: cfg, endpoint: u, client: client, now: time.Now}, nil
+}
+
+// Put implements Archive.
+func (a *S3Archive) Put(ctx context.Context, key string, data []byte) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	resp, err := a.do(ctx, http.MethodPut, a.objectPath(key), nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return backendError("s3 put", resp)
+	}
+	return nil
+}
+
+// Get implements Archive.
+func (a *S3Archive) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := a.do(ctx, http.MethodGet, a.objectPath(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, backendError("s3 get", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// List implements Archive with ListObjectsV2, following
+// continuation tokens.
+func (a *S3Archive) List(ctx context.Context, prefix string) ([]string, error) {
+	return listObjectsV2(ctx, prefix, a.cfg.Prefix, func(q url.Values) (*http.Response, error) {
+		return a.do(ctx, http.MethodGet, "/"+a.cfg.Bucket, q, nil)
+	})
+}
+
+// Close implements Archive.
+func (a *S3Archive) Close() error { return nil }
+
+func (a *S3Archive) objectPath(key string) string {
+	return "/" + a.cfg.Bucket + "/" + joinPrefix(a.cfg.Prefix, key)
+}
+
+// do sends one signed request.
+func (a *S3Archive) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	u := *a.endpoint
+	u.Path = u.Path + path
+	u.RawPath = a.endpoint.EscapedPath() + s3EscapePath(path)
+	u.RawQuery = s3CanonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	a.sign(req, body)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackend, err)
+	}
+	return resp, nil
+}
+
+// sign adds SigV4 headers to req. Every header already set on req
+// is signed along with host.
+func (a *S3Archive) sign(req *http.Request, body []byte) {
+	now := a.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if a.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + a.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+a.cfg.SecretAccessKey), day)
+	key = hmacSHA256(key, a.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+a.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// s3Escape percent-encodes s per SigV4: everything except the
+// unreserved set A-Z a-z 0-9 - _ . ~ is encoded.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3EscapePath escapes each segment of a slash-separated path.
+func s3EscapePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = s3Escape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// s3CanonicalQuery encodes q sorted by key, as SigV4 requires.
+func s3CanonicalQuery(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func joinPrefix(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+// backendError summarizes a non-2xx response.
+func backendError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%w: %s: %s: %s", ErrBackend, op, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// listBucketResult is the ListObjectsV2 response shared by S3 and
+// the GCS XML API.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// listObjectsV2 pages through a ListObjectsV2 listing. storePrefix
+// is the archive's configured key prefix; it is added to the query
+// and stripped from returned keys.
+func listObjectsV2(ctx context.Context, prefix, storePrefix string, send func(url.Values) (*http.Response, error)) ([]string, error) {
+	full := joinPrefix(storePrefix, prefix)
+	if prefix == "" && storePrefix != "" {
+		full = strings.Trim(storePrefix, "/") + "/"
+	}
+	strip := ""
+	if p := strings.Trim(storePrefix, "/"); p != "" {
+		strip = p + "/"
+	}
+
+	var keys []string
+	token := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		q := url.Values{"list-type": {"2"}, "prefix": {full}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := send(q)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 != 2 {
+			err := backendError("list", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: list: %v", ErrBackend, err)
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(c.Key, strip))
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
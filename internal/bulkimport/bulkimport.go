@@ -0,0 +1,339 @@
+// Package bulkimport loads an existing asset registry into a node:
+// identities first, then the titles that reference them.
+//
+// A Mapping says how the rows of a CSV or JSONL export become
+// records: which column (or JSON key) feeds each record field,
+// which columns become identity attributes, and defaults for
+// fields the export lacks. A Reader turns the export into Records
+// and Run hands them to a Sink, which signs and submits each one
+// with the key it was given: `quidnug-cli import` submits over
+// HTTP, the node's /admin/import endpoint submits in-process with
+// the node key.
+//
+// Run reports Progress after every batch of BatchSize rows. The
+// progress carries the last row handled, so a run that stopped
+// (interrupted, or a node that stayed unavailable) can resume from
+// it without submitting any row twice.
+package bulkimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record kinds.
+const (
+	KindIdentity = "identity"
+	KindTitle    = "title"
+)
+
+// Source formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Record fields a Mapping can fill.
+const (
+	FieldQuidID      = "quidId"
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldHomeDomain  = "homeDomain"
+	FieldAssetClass  = "assetClass"
+	FieldAssetID     = "assetId"
+	FieldTitleType   = "titleType"
+	FieldOwners      = "owners"
+)
+
+// kindFields lists the required and optional fields of each kind.
+var kindFields = map[string]struct{ required, optional []string }{
+	KindIdentity: {
+		required: []string{FieldQuidID},
+		optional: []string{FieldName, FieldDescription, FieldHomeDomain, FieldAssetClass},
+	},
+	KindTitle: {
+		required: []string{FieldAssetID, FieldOwners},
+		optional: []string{FieldTitleType, FieldAssetClass},
+	},
+}
+
+// Defaults.
+const (
+	DefaultBatchSize  = 100
+	DefaultRetries    = 5
+	DefaultRetryDelay = 2 * time.Second
+	// MaxFailures caps the failures kept in Progress; the count
+	// in Failed is exact.
+	MaxFailures = 100
+)
+
+// Errors.
+var (
+	ErrInvalidMapping = errors.New("bulkimport: invalid mapping")
+	// ErrTransient marks a submit error worth retrying: rate
+	// limiting, or a node that is briefly unavailable. Sinks wrap
+	// it; any other error fails the row.
+	ErrTransient = errors.New("bulkimport: transient submit error")
+	// ErrSourceMismatch means a resume was attempted against a
+	// different source than the progress was recorded for.
+	ErrSourceMismatch = errors.New("bulkimport: progress belongs to a different source")
+)
+
+// Mapping describes how one export maps onto records.
+type Mapping struct {
+	Kind        string `json:"kind"`
+	Format      string `json:"format"`
+	TrustDomain string `json:"trustDomain"`
+	// Fields maps a record field to the source column (CSV header)
+	// or top-level JSON key holding it.
+	Fields map[string]string `json:"fields"`
+	// Attributes maps identity attribute names to source columns.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Defaults fill fields the source leaves empty.
+	Defaults  map[string]string `json:"defaults,omitempty"`
+	BatchSize int               `json:"batchSize,omitempty"`
+}
+
+// Validate checks the mapping is usable.
+func (m Mapping) Validate() error {
+	fields, ok := kindFields[m.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidMapping, m.Kind)
+	}
+	if m.Format != FormatCSV && m.Format != FormatJSONL {
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidMapping, m.Format)
+	}
+	if m.TrustDomain == "" {
+		return fmt.Errorf("%w: trustDomain is required", ErrInvalidMapping)
+	}
+	known := make(map[string]bool)
+	for _, f := range append(append([]string(nil), fields.required...), fields.optional...) {
+		known[f] = true
+	}
+	for f := range m.Fields {
+		if !known[f] {
+			return fmt.Errorf("%w: field %q does not apply to %s records", ErrInvalidMapping, f, m.Kind)
+		}
+	}
+	for _, f := range fields.required {
+		if m.Fields[f] == "" && m.Defaults[f] == "" {
+			return fmt.Errorf("%w: %s records need field %q mapped", ErrInvalidMapping, m.Kind, f)
+		}
+	}
+	if len(m.Attributes) > 0 && m.Kind != KindIdentity {
+		return fmt.Errorf("%w: attributes apply to identity records only", ErrInvalidMapping)
+	}
+	if m.BatchSize < 0 {
+		return fmt.Errorf("%w: batchSize must not be negative", ErrInvalidMapping)
+	}
+	return nil
+}
+
+// Owner is one ownership stake of a title record.
+type Owner struct {
+	OwnerID    string  `json:"ownerId"`
+	Percentage float64 `json:"percentage"`
+	StakeType  string  `json:"stakeType,omitempty"`
+}
+
+// Record is one mapped row, ready for a Sink.
+type Record struct {
+	// Line is the row's position in the source: the data row for
+	// CSV (header excluded), the line for JSONL. Both from 1.
+	Line        int    `json:"line"`
+	Kind        string `json:"kind"`
+	TrustDomain string `json:"trustDomain"`
+
+	QuidID      string                 `json:"quidId,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	HomeDomain  string                 `json:"homeDomain,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+
+	AssetID   string  `json:"assetId,omitempty"`
+	TitleType string  `json:"titleType,omitempty"`
+	Owners    []Owner `json:"owners,omitempty"`
+
+	AssetClass string `json:"assetClass,omitempty"`
+}
+
+// parseOwners reads "quid:share[:stakeType]" entries separated by
+// semicolons, e.g. "a1b2...:0.6;c3d4...:0.4".
+func parseOwners(s string) ([]Owner, error) {
+	var out []Owner
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("owner %q: want quid:share[:stakeType]", entry)
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("owner %q: %v", entry, err)
+		}
+		o := Owner{OwnerID: strings.TrimSpace(parts[0]), Percentage: pct}
+		if len(parts) == 3 {
+			o.StakeType = strings.TrimSpace(parts[2])
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// Sink signs and submits one record, returning its transaction ID.
+type Sink interface {
+	Submit(ctx context.Context, rec Record) (string, error)
+}
+
+// Failure is a row that could not be imported.
+type Failure struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Progress is the state of a run, and what a resumed run starts
+// from. Every row up to LastLine has been handled: imported or
+// recorded as failed.
+type Progress struct {
+	// Source identifies the input (a file digest, say); a resume
+	// must present the same one.
+	Source    string    `json:"source,omitempty"`
+	Kind      string    `json:"kind"`
+	LastLine  int       `json:"lastLine"`
+	Imported  int       `json:"imported"`
+	Failed    int       `json:"failed"`
+	Failures  []Failure `json:"failures,omitempty"`
+	StartedAt int64     `json:"startedAt"`
+	UpdatedAt int64     `json:"updatedAt"`
+	Done      bool      `json:"done"`
+}
+
+func (p *Progress) fail(line int, err error) {
+	p.Failed++
+	if len(p.Failures) < MaxFailures {
+		p.Failures = append(p.Failures, Failure{Line: line, Error: err.Error()})
+	}
+}
+
+// Options tunes a run. Zero fields take the defaults.
+type Options struct {
+	// Source identifies the input; see Progress.Source.
+	Source string
+	// Resume continues from an earlier run's progress.
+	Resume *Progress
+	// BatchSize overrides the mapping's batch size.
+	BatchSize  int
+	Retries    int
+	RetryDelay time.Duration
+	// OnBatch is called with the progress after every batch and
+	// at the end. An error stops the run.
+	OnBatch func(Progress) error
+}
+
+// Run reads every record from r, skipping rows a resumed run has
+// already handled, and submits them to sink. Rows that fail to map
+// or are rejected are recorded and skipped. A transient error that
+// outlasts the retries stops the run; the returned progress is
+// where to resume.
+func Run(ctx context.Context, r *Reader, sink Sink, opts Options) (Progress, error) {
+	progress := Progress{Source: opts.Source, Kind: r.mapping.Kind, StartedAt: time.Now().Unix()}
+	if opts.Resume != nil {
+		if opts.Resume.Source != opts.Source || opts.Resume.Kind != r.mapping.Kind {
+			return progress, ErrSourceMismatch
+		}
+		progress = *opts.Resume
+		progress.Done = false
+	}
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = r.mapping.BatchSize
+	}
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	retries := opts.Retries
+	if retries <= 0 {
+		retries = DefaultRetries
+	}
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	checkpoint := func() error {
+		progress.UpdatedAt = time.Now().Unix()
+		if opts.OnBatch == nil {
+			return nil
+		}
+		return opts.OnBatch(progress)
+	}
+
+	inBatch := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			if rowErr.Line > progress.LastLine {
+				progress.fail(rowErr.Line, rowErr.Err)
+				progress.LastLine = rowErr.Line
+				inBatch++
+			}
+		case err != nil:
+			return progress, err
+		case rec.Line <= progress.LastLine:
+			continue
+		default:
+			if err := submit(ctx, sink, rec, retries, delay); err != nil {
+				if errors.Is(err, ErrTransient) || ctx.Err() != nil {
+					_ = checkpoint()
+					return progress, fmt.Errorf("row %d: %w", rec.Line, err)
+				}
+				progress.fail(rec.Line, err)
+			} else {
+				progress.Imported++
+			}
+			progress.LastLine = rec.Line
+			inBatch++
+		}
+		if inBatch >= batch {
+			inBatch = 0
+			if err := checkpoint(); err != nil {
+				return progress, err
+			}
+		}
+	}
+	progress.Done = true
+	return progress, checkpoint()
+}
+
+// submit hands rec to sink, retrying transient errors.
+func submit(ctx context.Context, sink Sink, rec Record, retries int, delay time.Duration) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		if _, err = sink.Submit(ctx, rec); err == nil || !errors.Is(err, ErrTransient) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package bulkimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSink records submitted records; fail decides each outcome.
+type fakeSink struct {
+	got  []Record
+	fail func(rec Record, attempt int) error
+	seen map[int]int
+}
+
+func (s *fakeSink) Submit(_ context.Context, rec Record) (string, error) {
+	if s.seen == nil {
+		s.seen = make(map[int]int)
+	}
+	s.seen[rec.Line]++
+	if s.fail != nil {
+		if err := s.fail(rec, s.seen[rec.Line]); err != nil {
+			return "", err
+		}
+	}
+	s.got = append(s.got, rec)
+	return fmt.Sprintf("tx-%d", rec.Line), nil
+}
+
+var titleMapping = Mapping{
+	Kind:        KindTitle,
+	Format:      FormatCSV,
+	TrustDomain: "land.example",
+	Fields:      map[string]string{FieldAssetID: "parcel", FieldOwners: "holders"},
+	Defaults:    map[string]string{FieldTitleType: "freehold"},
+}
+
+func TestReader_CSVAndJSONL(t *testing.T) {
+	csv := "parcel,holders\nP-1,a1:0.6;b2:0.4\nP-2,\nP-3,c3:1:lease;d4:1\n"
+	r, err := NewReader(strings.NewReader(csv), titleMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := r.Next()
+	if err != nil || rec.AssetID != "P-1" || rec.TitleType != "freehold" || len(rec.Owners) != 2 || rec.Owners[0].Percentage != 0.6 {
+		t.Fatalf("row 1 = %+v, %v", rec, err)
+	}
+	var rowErr *RowError
+	if _, err := r.Next(); !errors.As(err, &rowErr) || rowErr.Line != 2 {
+		t.Fatalf("row 2 err = %v", err)
+	}
+	if rec, err := r.Next(); err != nil || rec.Owners[0].StakeType != "lease" {
+		t.Fatalf("row 3 = %+v, %v", rec, err)
+	}
+
+	m := titleMapping
+	m.Format = FormatJSONL
+	jsonl := `{"parcel":"P-9","holders":[{"ownerId":"a1","percentage":1}]}` + "\n\nnot json\n"
+	r, err = NewReader(strings.NewReader(jsonl), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, err := r.Next(); err != nil || rec.AssetID != "P-9" || rec.Owners[0].OwnerID != "a1" || rec.Line != 1 {
+		t.Fatalf("jsonl row = %+v, %v", rec, err)
+	}
+	if _, err := r.Next(); !errors.As(err, &rowErr) || rowErr.Line != 3 {
+		t.Fatalf("malformed line err = %v", err)
+	}
+
+	bad := titleMapping
+	bad.Fields = map[string]string{FieldAssetID: "parcel", FieldOwners: "holders", FieldName: "x"}
+	if _, err := NewReader(strings.NewReader(csv), bad); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("identity field on titles: err = %v", err)
+	}
+	if _, err := NewReader(strings.NewReader("parcel\nP-1\n"), titleMapping); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("missing column: err = %v", err)
+	}
+}
+
+func TestRun_ResumeAfterTransientStop(t *testing.T) {
+	var rows strings.Builder
+	rows.WriteString("parcel,holders\n")
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(&rows, "P-%d,a1:1\n", i)
+	}
+	data := rows.String()
+
+	var checkpoints []Progress
+	sink := &fakeSink{fail: func(rec Record, _ int) error {
+		switch rec.AssetID {
+		case "P-2":
+			return errors.New("duplicate asset")
+		case "P-4":
+			return fmt.Errorf("%w: node busy", ErrTransient)
+		}
+		return nil
+	}}
+	r, _ := NewReader(strings.NewReader(data), titleMapping)
+	opts := Options{
+		Source:     "digest",
+		BatchSize:  2,
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+		OnBatch:    func(p Progress) error { checkpoints = append(checkpoints, p); return nil },
+	}
+	progress, err := Run(context.Background(), r, sink, opts)
+	if !errors.Is(err, ErrTransient) {
+		t.Fatalf("err = %v", err)
+	}
+	if progress.LastLine != 3 || progress.Imported != 2 || progress.Failed != 1 || sink.seen[4] != 3 {
+		t.Fatalf("progress = %+v, attempts = %v", progress, sink.seen)
+	}
+	if len(checkpoints) != 2 || checkpoints[1].LastLine != 3 {
+		t.Errorf("checkpoints = %+v", checkpoints)
+	}
+
+	// The node recovers; a resumed run submits only rows 4 and 5.
+	sink.fail = nil
+	sink.got = nil
+	r, _ = NewReader(strings.NewReader(data), titleMapping)
+	opts.Resume = &progress
+	progress, err = Run(context.Background(), r, sink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !progress.Done || progress.Imported != 4 || progress.Failed != 1 || len(sink.got) != 2 || sink.got[0].AssetID != "P-4" {
+		t.Fatalf("resumed progress = %+v, submitted = %+v", progress, sink.got)
+	}
+
+	r, _ = NewReader(strings.NewReader(data), titleMapping)
+	opts.Source = "other"
+	if _, err := Run(context.Background(), r, sink, opts); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("resume against another source: err = %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package bulkimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxJSONLLine bounds one JSONL record.
+const MaxJSONLLine = 1 << 20
+
+// RowError is a row that could not be mapped to a record. Reading
+// can continue past it.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("row %d: %v", e.Line, e.Err) }
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Reader maps the rows of an export to records.
+type Reader struct {
+	mapping Mapping
+
+	csv    *csv.Reader
+	header map[string]int
+
+	lines *bufio.Scanner
+	line  int
+}
+
+// NewReader validates m and prepares to read r. For CSV it
+// consumes the header row, which must name every mapped column.
+func NewReader(r io.Reader, m Mapping) (*Reader, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	rd := &Reader{mapping: m}
+	if m.Format == FormatJSONL {
+		rd.lines = bufio.NewScanner(r)
+		rd.lines.Buffer(make([]byte, 0, 64*1024), MaxJSONLLine)
+		return rd, nil
+	}
+
+	rd.csv = csv.NewReader(r)
+	rd.csv.FieldsPerRecord = -1
+	header, err := rd.csv.Read()
+	if err != nil {
+		return nil, fmt.Errorf("bulkimport: read CSV header: %w", err)
+	}
+	rd.header = make(map[string]int, len(header))
+	for i, name := range header {
+		rd.header[strings.TrimSpace(name)] = i
+	}
+	for _, cols := range []map[string]string{m.Fields, m.Attributes} {
+		for _, col := range cols {
+			if _, ok := rd.header[col]; !ok {
+				return nil, fmt.Errorf("%w: column %q not in the CSV header", ErrInvalidMapping, col)
+			}
+		}
+	}
+	return rd, nil
+}
+
+// Next returns the next record, a *RowError for a row that does
+// not map, or io.EOF.
+func (rd *Reader) Next() (Record, error) {
+	if rd.lines != nil {
+		return rd.nextJSONL()
+	}
+	return rd.nextCSV()
+}
+
+func (rd *Reader) nextCSV() (Record, error) {
+	row, err := rd.csv.Read()
+	if err == io.EOF {
+		return Record{}, io.EOF
+	}
+	rd.line++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return Record{}, &RowError{Line: rd.line, Err: err}
+		}
+		return Record{}, err
+	}
+	get := func(col string) (interface{}, bool) {
+		i, ok := rd.header[col]
+		if !ok || i >= len(row) || row[i] == "" {
+			return nil, false
+		}
+		return row[i], true
+	}
+	return rd.record(get)
+}
+
+func (rd *Reader) nextJSONL() (Record, error) {
+	for rd.lines.Scan() {
+		rd.line++
+		text := strings.TrimSpace(rd.lines.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return Record{}, &RowError{Line: rd.line, Err: err}
+		}
+		get := func(key string) (interface{}, bool) {
+			v, ok := obj[key]
+			return v, ok && v != nil && v != ""
+		}
+		return rd.record(get)
+	}
+	if err := rd.lines.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// record maps one row, read through get, to a Record.
+func (rd *Reader) record(get func(string) (interface{}, bool)) (Record, error) {
+	m := rd.mapping
+	rec := Record{Line: rd.line, Kind: m.Kind, TrustDomain: m.TrustDomain}
+
+	str := func(field string) string {
+		if col, ok := m.Fields[field]; ok {
+			if v, ok := get(col); ok {
+				return scalarString(v)
+			}
+		}
+		return m.Defaults[field]
+	}
+
+	switch m.Kind {
+	case KindIdentity:
+		rec.QuidID = str(FieldQuidID)
+		rec.Name = str(FieldName)
+		rec.Description = str(FieldDescription)
+		rec.HomeDomain = str(FieldHomeDomain)
+		for attr, col := range m.Attributes {
+			if v, ok := get(col); ok {
+				if rec.Attributes == nil {
+					rec.Attributes = make(map[string]interface{})
+				}
+				rec.Attributes[attr] = v
+			}
+		}
+		if rec.QuidID == "" {
+			return Record{}, &RowError{Line: rd.line, Err: fmt.Errorf("missing %s", FieldQuidID)}
+		}
+	case KindTitle:
+		rec.AssetID = str(FieldAssetID)
+		rec.TitleType = str(FieldTitleType)
+		owners, err := rd.owners(get)
+		if err != nil {
+			return Record{}, &RowError{Line: rd.line, Err: err}
+		}
+		rec.Owners = owners
+		if rec.AssetID == "" || len(rec.Owners) == 0 {
+			return Record{}, &RowError{Line: rd.line, Err: fmt.Errorf("missing %s or %s", FieldAssetID, FieldOwners)}
+		}
+	}
+	rec.AssetClass = str(FieldAssetClass)
+	return rec, nil
+}
+
+// owners reads the owners field: a "quid:share;..." string, or in
+// JSONL an array of {ownerId, percentage, stakeType} objects.
+func (rd *Reader) owners(get func(string) (interface{}, bool)) ([]Owner, error) {
+	v, ok := get(rd.mapping.Fields[FieldOwners])
+	if !ok {
+		return parseOwners(rd.mapping.Defaults[FieldOwners])
+	}
+	if list, isList := v.([]interface{}); isList {
+		raw, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		var out []Owner
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, fmt.Errorf("owners: %v", err)
+		}
+		return out, nil
+	}
+	return parseOwners(scalarString(v))
+}
+
+// scalarString renders a CSV cell or JSON scalar as a string.
+func scalarString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		raw, _ := json.Marshal(t)
+		return string(raw)
+	}
+}
@@ -0,0 +1,396 @@
+// Package cborjson translates between JSON and CBOR (RFC 8949) for
+// the JSON data model: objects, arrays, strings, numbers, booleans
+// and null. It exists so peer-to-peer payloads can travel in a
+// compact binary form while every handler, struct tag and hash
+// keeps working on JSON.
+//
+// Encoding streams JSON tokens, so object key order is preserved
+// and objects and arrays are written as indefinite-length items.
+// Integers that fit in 64 bits become CBOR integers; every other
+// number becomes a float64. Decoding accepts definite and
+// indefinite lengths, half/single/double floats and byte strings
+// (rendered as base64, matching encoding/json's []byte). Tags,
+// undefined and other simple values are rejected.
+package cborjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ContentType is the media type peers use for CBOR bodies.
+const ContentType = "application/cbor"
+
+// maxDepth bounds nesting on decode so a hostile payload can't
+// exhaust the stack.
+const maxDepth = 512
+
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+
+	infoIndefinite = 31
+	breakByte      = 0xff
+)
+
+// Marshal encodes v as JSON (honoring its json tags) and returns the
+// CBOR form.
+func Marshal(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(raw)
+}
+
+// Unmarshal decodes CBOR data into v through its JSON form.
+func Unmarshal(data []byte, v interface{}) error {
+	raw, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// FromJSON converts a single JSON value to CBOR.
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := encodeValue(dec, &out); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("cborjson: trailing data after JSON value")
+	}
+	return out.Bytes(), nil
+}
+
+func encodeValue(dec *json.Decoder, out *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("cborjson: %w", err)
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			out.WriteByte(majorMap<<5 | infoIndefinite)
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return fmt.Errorf("cborjson: %w", err)
+				}
+				writeText(out, key.(string))
+				if err := encodeValue(dec, out); err != nil {
+					return err
+				}
+			}
+		case '[':
+			out.WriteByte(majorArray<<5 | infoIndefinite)
+			for dec.More() {
+				if err := encodeValue(dec, out); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("cborjson: unexpected delimiter %q", t)
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("cborjson: %w", err)
+		}
+		out.WriteByte(breakByte)
+	case string:
+		writeText(out, t)
+	case json.Number:
+		writeNumber(out, t)
+	case bool:
+		if t {
+			out.WriteByte(0xf5)
+		} else {
+			out.WriteByte(0xf4)
+		}
+	case nil:
+		out.WriteByte(0xf6)
+	}
+	return nil
+}
+
+func writeHead(out *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		out.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		out.WriteByte(major<<5 | 24)
+		out.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		out.WriteByte(major<<5 | 25)
+		out.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		out.WriteByte(major<<5 | 26)
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		out.WriteByte(major<<5 | 27)
+		out.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func writeText(out *bytes.Buffer, s string) {
+	writeHead(out, majorText, uint64(len(s)))
+	out.WriteString(s)
+}
+
+func writeNumber(out *bytes.Buffer, n json.Number) {
+	s := n.String()
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		writeHead(out, majorUint, u)
+		return
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && i < 0 {
+		writeHead(out, majorNegint, uint64(-1-i))
+		return
+	}
+	// The decoder already validated the literal; only range can fail,
+	// and ParseFloat then returns ±Inf, which JSON can't carry either.
+	f, _ := strconv.ParseFloat(s, 64)
+	out.WriteByte(majorSimple<<5 | 27)
+	out.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// ToJSON converts a single CBOR data item to JSON.
+func ToJSON(data []byte) ([]byte, error) {
+	d := &decoder{data: data}
+	var out bytes.Buffer
+	if err := d.value(&out, 0); err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, errors.New("cborjson: trailing data after CBOR item")
+	}
+	return out.Bytes(), nil
+}
+
+type decoder struct {
+	data []byte
+	off  int
+}
+
+var errShort = errors.New("cborjson: unexpected end of data")
+
+func (d *decoder) byte() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errShort
+	}
+	b := d.data[d.off]
+	d.off++
+	return b, nil
+}
+
+func (d *decoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, errShort
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// head reads an item's initial byte and argument. indefinite is set
+// for additional info 31, in which case n is meaningless.
+func (d *decoder) head() (major byte, info byte, n uint64, indefinite bool, err error) {
+	b, err := d.byte()
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		raw, err := d.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		for _, c := range raw {
+			n = n<<8 | uint64(c)
+		}
+		return major, info, n, false, nil
+	case info == infoIndefinite:
+		return major, info, 0, true, nil
+	}
+	return 0, 0, 0, false, fmt.Errorf("cborjson: reserved additional info %d", info)
+}
+
+func (d *decoder) atBreak() bool {
+	if d.off < len(d.data) && d.data[d.off] == breakByte {
+		d.off++
+		return true
+	}
+	return false
+}
+
+func (d *decoder) value(out *bytes.Buffer, depth int) error {
+	if depth > maxDepth {
+		return errors.New("cborjson: nesting too deep")
+	}
+	major, info, n, indef, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorUint:
+		out.WriteString(strconv.FormatUint(n, 10))
+	case majorNegint:
+		// -1-n, computed without overflowing for n up to 2^64-1.
+		out.WriteByte('-')
+		if n == math.MaxUint64 {
+			out.WriteString("18446744073709551616")
+		} else {
+			out.WriteString(strconv.FormatUint(n+1, 10))
+		}
+	case majorBytes, majorText:
+		s, err := d.stringBody(major, n, indef)
+		if err != nil {
+			return err
+		}
+		if major == majorBytes {
+			s = []byte(base64.StdEncoding.EncodeToString(s))
+		}
+		q, err := json.Marshal(string(s))
+		if err != nil {
+			return err
+		}
+		out.Write(q)
+	case majorArray:
+		out.WriteByte('[')
+		for i := uint64(0); indef || i < n; i++ {
+			if indef && d.atBreak() {
+				break
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := d.value(out, depth+1); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case majorMap:
+		out.WriteByte('{')
+		for i := uint64(0); indef || i < n; i++ {
+			if indef && d.atBreak() {
+				break
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if d.off >= len(d.data) || d.data[d.off]>>5 != majorText {
+				return errors.New("cborjson: map key is not a text string")
+			}
+			if err := d.value(out, depth+1); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if err := d.value(out, depth+1); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case majorTag:
+		return fmt.Errorf("cborjson: tag %d not supported", n)
+	case majorSimple:
+		return writeSimple(out, info, n)
+	}
+	return nil
+}
+
+// stringBody returns the payload of a byte or text string, joining
+// the chunks of an indefinite-length one.
+func (d *decoder) stringBody(major byte, n uint64, indef bool) ([]byte, error) {
+	if !indef {
+		return d.take(n)
+	}
+	var buf []byte
+	for !d.atBreak() {
+		m, _, cn, cindef, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || cindef {
+			return nil, errors.New("cborjson: malformed indefinite-length string")
+		}
+		chunk, err := d.take(cn)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+	}
+	return buf, nil
+}
+
+func writeSimple(out *bytes.Buffer, info byte, n uint64) error {
+	var f float64
+	switch info {
+	case 20:
+		out.WriteString("false")
+		return nil
+	case 21:
+		out.WriteString("true")
+		return nil
+	case 22:
+		out.WriteString("null")
+		return nil
+	case 25:
+		f = halfToFloat(uint16(n))
+	case 26:
+		f = float64(math.Float32frombits(uint32(n)))
+	case 27:
+		f = math.Float64frombits(n)
+	default:
+		return fmt.Errorf("cborjson: simple value %d not supported", n)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return errors.New("cborjson: NaN or infinity has no JSON form")
+	}
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	out.Write(raw)
+	return nil
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package cborjson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	cases := []string{
+		`null`,
+		`true`,
+		`[]`,
+		`{}`,
+		`"héllo <\"x\">"`,
+		`[0,23,24,255,256,65536,4294967296,18446744073709551615]`,
+		`[-1,-24,-25,-9223372036854775808]`,
+		`[0.5,-2.25,1e+300,1.5e-7]`,
+		`{"z":1,"a":[true,false,null],"m":{"k":"v"}}`,
+	}
+	for _, in := range cases {
+		enc, err := FromJSON([]byte(in))
+		if err != nil {
+			t.Fatalf("FromJSON(%s): %v", in, err)
+		}
+		out, err := ToJSON(enc)
+		if err != nil {
+			t.Fatalf("ToJSON(%s): %v", in, err)
+		}
+		// Recode to normalize float spelling and escaping.
+		again, err := FromJSON(out)
+		if err != nil {
+			t.Fatalf("FromJSON(%s): %v", out, err)
+		}
+		if !bytes.Equal(enc, again) {
+			t.Errorf("%s -> %s does not round-trip", in, out)
+		}
+	}
+}
+
+func TestToJSON_Vectors(t *testing.T) {
+	// Examples from RFC 8949 appendix A, restricted to the JSON model.
+	cases := map[string]string{
+		"1903e8":                     `1000`,
+		"3863":                       `-100`,
+		"f93c00":                     `1`,
+		"f9c400":                     `-4`,
+		"fa47c35000":                 `100000`,
+		"fb3ff199999999999a":         `1.1`,
+		"6449455446":                 `"IETF"`,
+		"4401020304":                 `"AQIDBA=="`,
+		"83010203":                   `[1,2,3]`,
+		"a26161016162820203":         `{"a":1,"b":[2,3]}`,
+		"9f018202039f0405ffff":       `[1,[2,3],[4,5]]`,
+		"7f657374726561646d696e67ff": `"streaming"`,
+		"bf61610161629f0203ffff":     `{"a":1,"b":[2,3]}`,
+	}
+	for in, want := range cases {
+		raw, _ := hex.DecodeString(in)
+		got, err := ToJSON(raw)
+		if err != nil {
+			t.Errorf("ToJSON(%s): %v", in, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("ToJSON(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestToJSON_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"truncated":   "1903",
+		"tag":         "c11a514b67b0",
+		"undefined":   "f7",
+		"int map key": "a10102",
+		"NaN":         "f97e00",
+		"trailing":    "0101",
+		"missing brk": "9f01",
+	}
+	for name, in := range cases {
+		raw, _ := hex.DecodeString(in)
+		if _, err := ToJSON(raw); err == nil {
+			t.Errorf("%s: accepted %s", name, in)
+		}
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	type block struct {
+		Index int      `json:"index"`
+		Hash  string   `json:"hash"`
+		Txs   []string `json:"transactions"`
+	}
+	in := block{Index: 7, Hash: "abc", Txs: []string{"t1", "t2"}}
+	enc, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out block
+	if err := Unmarshal(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Index != 7 || out.Hash != "abc" || len(out.Txs) != 2 {
+		t.Errorf("got %+v", out)
+	}
+}
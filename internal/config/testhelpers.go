@@ -35,6 +35,95 @@ func ClearConfigEnvVarsForTesting() {
 		"TRUST_CACHE_TTL",
 		"DOMAIN_GOSSIP_INTERVAL",
 		"DOMAIN_GOSSIP_TTL",
+		"GRAPH_STORE_BACKEND",
+		"NEO4J_URL",
+		"NEO4J_DATABASE",
+		"NEO4J_USERNAME",
+		"NEO4J_PASSWORD",
+		"TRUST_CACHE_MAX_ENTRIES",
+		"TRUST_PRECOMPUTE_TARGETS",
+		"TRUST_QUERY_MAX_MILLIS",
+		"LOG_FORMAT",
+		"LOG_OUTPUTS",
+		"LOG_FILE",
+		"LOG_FILE_MAX_SIZE_MB",
+		"LOG_FILE_MAX_BACKUPS",
+		"LOG_SYSLOG_ADDRESS",
+		"FEDERATION_CACHE_TTL",
+		"FEDERATION_CACHE_MAX_STALE",
+		"FEDERATION_QUERIES_PER_MINUTE",
+		"BLOCK_VALIDATION_WORKERS",
+		"BLOCK_PRODUCTION_MAX_TENTATIVE",
+		"BLOCK_PRODUCTION_MAX_SYNC_LAG",
+		"BLOCK_MAX_TRANSACTIONS",
+		"BLOCK_MAX_BYTES",
+		"MEMPOOL_TYPE_PRIORITIES",
+		"MEMPOOL_CREATOR_TRUST_WEIGHT",
+		"BLOCK_RETENTION",
+		"BLOCK_ARCHIVE_BACKEND",
+		"BLOCK_ARCHIVE_DIR",
+		"BLOCK_PRUNE_INTERVAL",
+		"BLOCK_ARCHIVE_BUCKET",
+		"BLOCK_ARCHIVE_PREFIX",
+		"BLOCK_ARCHIVE_ENDPOINT",
+		"BLOCK_ARCHIVE_REGION",
+		"BLOCK_ARCHIVE_ACCESS_KEY_ID",
+		"BLOCK_ARCHIVE_SECRET_ACCESS_KEY",
+		"BLOCK_ARCHIVE_SESSION_TOKEN",
+		"BLOCK_ARCHIVE_GCS_TOKEN",
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"REGISTRY_SNAPSHOT_INTERVAL",
+		"NODE_ROLE",
+		"REPLICA_UPSTREAMS",
+		"REPLICA_SYNC_INTERVAL",
+		"GATEWAY_DOMAINS",
+		"GATEWAY_BACKENDS",
+		"GATEWAY_BACKEND_QUIDS",
+		"GATEWAY_CACHE_TTL",
+		"CORS_ALLOWED_ORIGINS",
+		"EXPLORER_CORS_ORIGINS",
+		"CORS_ALLOWED_METHODS",
+		"CORS_ALLOWED_HEADERS",
+		"CORS_ALLOW_CREDENTIALS",
+		"CORS_MAX_AGE",
+		"WALLET_ENABLED",
+		"WALLET_DIR",
+		"OIDC_ISSUER",
+		"OIDC_AUDIENCE",
+		"OIDC_QUID_CLAIM",
+		"OIDC_REQUIRED",
+		"BLOB_STORE_ENABLED",
+		"BLOB_DIR",
+		"BLOB_BACKEND",
+		"BLOB_GC_GRACE",
+		"ANOMALY_DETECTION_ENABLED",
+		"ANOMALY_WEBHOOK_URL",
+		"ANOMALY_SUSPECT_DISCOUNT",
+		"SYBIL_MIN_SCORE",
+		"BLOCK_ACCEPTANCE_POLICY",
+		"TRUST_ANCHORS",
+		"TRUST_ANCHOR_CALLER_WEIGHT",
+		"TENANTS",
+		"FAULT_INJECTION_FILE",
+		"REBUILD_STATE_ON_START",
+		"CHECKPOINT_INTERVAL",
+		"EVM_ANCHOR_RPC_URL",
+		"EVM_ANCHOR_CONTRACT",
+		"EVM_ANCHOR_FROM",
+		"EVM_ANCHOR_DOMAINS",
+		"EVM_ANCHOR_INTERVAL",
+		"TIMESTAMP_AUTHORITY_URL",
+		"DNS_DISCOVERY",
+		"PEER_BAN_THRESHOLD",
+		"PEER_BAN_DURATION",
+		"PEER_BREAKER_FAILURES",
+		"PEER_BREAKER_COOLDOWN",
+		"PEER_MAX_CONCURRENT_REQUESTS",
+		"PEER_PROXY_URL",
+		"PEER_WIRE_FORMAT",
+		"MEMPOOL_SYNC_INTERVAL",
 	} {
 		os.Unsetenv(k)
 	}
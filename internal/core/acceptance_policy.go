@@ -0,0 +1,210 @@
+// Operator-written block acceptance policy.
+//
+// By default tiered validation sorts a block by two numbers: trust
+// in its validator at or above the domain's TrustThreshold is
+// trusted, above the node's DistrustThreshold tentative, anything
+// else untrusted. block_acceptance_policy replaces that pair with
+// ordered rules in the internal/policy expression language:
+//
+//	block_acceptance_policy:
+//	  - when: 'trust >= 0.7 && pathLength <= 2 && verified'
+//	    accept: trusted
+//	  - when: 'trust > 0 && domain != "payments.example"'
+//	    accept: tentative
+//
+// The first rule that holds decides; a block matching none is
+// untrusted. Rules see the variables in acceptancePolicyVars. The
+// policy only sorts blocks that already passed the cryptographic
+// checks, and a node still fully trusts blocks it signed itself.
+package core
+
+import (
+	"fmt"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/policy"
+)
+
+// Rule outcomes.
+const (
+	AcceptTrusted   = "trusted"
+	AcceptTentative = "tentative"
+	AcceptUntrusted = "untrusted"
+)
+
+// acceptancePolicyVars are the variables a rule may read.
+//
+//	trust              relational trust from this node to the validator
+//	pathLength         hops on the best trust path (0 when unreachable)
+//	verified           every hop on that path is a verified edge
+//	validator          the validator's quid
+//	domain             the block's trust domain
+//	validatorWeight    the validator's weight in the domain
+//	threshold          the domain's TrustThreshold
+//	distrustThreshold  this node's DistrustThreshold
+var acceptancePolicyVars = map[string]policy.Kind{
+	"trust":             policy.Number,
+	"pathLength":        policy.Number,
+	"verified":          policy.Bool,
+	"validator":         policy.String,
+	"domain":            policy.String,
+	"validatorWeight":   policy.Number,
+	"threshold":         policy.Number,
+	"distrustThreshold": policy.Number,
+}
+
+// AcceptancePolicy is a compiled block_acceptance_policy.
+type AcceptancePolicy struct {
+	rules []acceptanceRule
+}
+
+type acceptanceRule struct {
+	expr   *policy.Expr
+	tier   BlockAcceptance
+	source config.AcceptanceRule
+}
+
+// CompileAcceptancePolicy compiles rules. An empty list yields a nil
+// policy, meaning the threshold pair applies.
+func CompileAcceptancePolicy(rules []config.AcceptanceRule) (*AcceptancePolicy, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	p := &AcceptancePolicy{}
+	for i, r := range rules {
+		var tier BlockAcceptance
+		switch r.Accept {
+		case AcceptTrusted:
+			tier = BlockTrusted
+		case AcceptTentative:
+			tier = BlockTentative
+		case AcceptUntrusted:
+			tier = BlockUntrusted
+		default:
+			return nil, fmt.Errorf("block_acceptance_policy rule %d: accept must be %q, %q or %q, got %q",
+				i, AcceptTrusted, AcceptTentative, AcceptUntrusted, r.Accept)
+		}
+		expr, err := policy.Compile(r.When, acceptancePolicyVars)
+		if err != nil {
+			return nil, fmt.Errorf("block_acceptance_policy rule %d: %w", i, err)
+		}
+		p.rules = append(p.rules, acceptanceRule{expr: expr, tier: tier, source: r})
+	}
+	return p, nil
+}
+
+// Rules returns the policy's source rules.
+func (p *AcceptancePolicy) Rules() []config.AcceptanceRule {
+	out := make([]config.AcceptanceRule, len(p.rules))
+	for i, r := range p.rules {
+		out[i] = r.source
+	}
+	return out
+}
+
+// Decide returns the tier chosen by the first matching rule and its
+// index, or BlockUntrusted and -1 when none matches.
+func (p *AcceptancePolicy) Decide(env policy.Env) (BlockAcceptance, int, error) {
+	for i, r := range p.rules {
+		ok, err := r.expr.Eval(env)
+		if err != nil {
+			return BlockUntrusted, i, err
+		}
+		if ok {
+			return r.tier, i, nil
+		}
+	}
+	return BlockUntrusted, -1, nil
+}
+
+// SetAcceptancePolicy installs p, or restores the threshold pair
+// when p is nil.
+func (node *QuidnugNode) SetAcceptancePolicy(p *AcceptancePolicy) {
+	node.acceptancePolicy.Store(p)
+}
+
+// GetAcceptancePolicy returns the installed policy, or nil.
+func (node *QuidnugNode) GetAcceptancePolicy() *AcceptancePolicy {
+	return node.acceptancePolicy.Load()
+}
+
+// AcceptancePolicyUpdateRequest replaces the running policy without a
+// restart. Empty Rules restores the threshold pair. Signed by the
+// admin key.
+type AcceptancePolicyUpdateRequest struct {
+	Rules     []config.AcceptanceRule `json:"rules"`
+	Timestamp int64                   `json:"timestamp"`
+	PublicKey string                  `json:"publicKey"`
+	Signature string                  `json:"signature"`
+}
+
+// UpdateAcceptancePolicy verifies req, compiles its rules and
+// installs them. A rule that fails to compile leaves the running
+// policy in place.
+func (node *QuidnugNode) UpdateAcceptancePolicy(req AcceptancePolicyUpdateRequest) (*AcceptancePolicy, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return nil, err
+	}
+	p, err := CompileAcceptancePolicy(req.Rules)
+	if err != nil {
+		return nil, err
+	}
+	node.SetAcceptancePolicy(p)
+	logger.Info("Block acceptance policy updated", "rules", len(req.Rules))
+	return p, nil
+}
+
+// pathVerified reports whether every hop on path is a verified,
+// unexpired edge.
+func (node *QuidnugNode) pathVerified(path []string) bool {
+	if len(path) < 2 {
+		return false
+	}
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+	for i := 0; i+1 < len(path); i++ {
+		if _, ok := node.VerifiedTrustEdges[path[i]][path[i+1]]; !ok {
+			return false
+		}
+		if !node.isTrustEdgeValidLocked(path[i], path[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptByPolicy sorts a cryptographically valid block from another
+// validator using the installed policy.
+func (node *QuidnugNode) acceptByPolicy(p *AcceptancePolicy, block Block, domain TrustDomain, trustLevel float64, path []string) BlockAcceptance {
+	proof := block.TrustProof
+	pathLength := 0
+	if len(path) > 0 {
+		pathLength = len(path) - 1
+	}
+	env := policy.Env{
+		"trust":             trustLevel,
+		"pathLength":        pathLength,
+		"verified":          node.pathVerified(path),
+		"validator":         proof.ValidatorID,
+		"domain":            proof.TrustDomain,
+		"validatorWeight":   domain.Validators[proof.ValidatorID],
+		"threshold":         domain.TrustThreshold,
+		"distrustThreshold": node.DistrustThreshold,
+	}
+	tier, rule, err := p.Decide(env)
+	if err != nil {
+		logger.Warn("Block acceptance policy failed to evaluate; treating block as untrusted",
+			"rule", rule, "blockIndex", block.Index, "domain", proof.TrustDomain, "error", err)
+		return BlockUntrusted
+	}
+	logger.Debug("Block acceptance policy decision",
+		"validator", proof.ValidatorID,
+		"domain", proof.TrustDomain,
+		"trustLevel", trustLevel,
+		"pathLength", pathLength,
+		"rule", rule,
+		"tier", tier)
+	return tier
+}
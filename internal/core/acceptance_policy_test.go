@@ -0,0 +1,113 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func TestAcceptancePolicy_ReplacesThresholds(t *testing.T) {
+	node := newTestNode()
+	validator, relay := newTestNode(), "cccccccccccccccc"
+	node.TrustDomains["policy.example"] = TrustDomain{
+		Name:                "policy.example",
+		ValidatorNodes:      []string{validator.NodeID},
+		TrustThreshold:      0.5,
+		Validators:          map[string]float64{validator.NodeID: 1},
+		ValidatorPublicKeys: map[string]string{validator.NodeID: validator.GetPublicKeyHex()},
+	}
+	// Two hops at 0.9 each: 0.81, above the domain threshold.
+	node.TrustRegistry[node.NodeID] = map[string]float64{relay: 0.9}
+	node.TrustRegistry[relay] = map[string]float64{validator.NodeID: 0.9}
+	block := signedTestBlock(t, validator, "policy.example", 1, []interface{}{})
+
+	if got := node.ValidateTrustProofTiered(block); got != BlockTrusted {
+		t.Fatalf("threshold pair: got %v, want trusted", got)
+	}
+
+	p, err := CompileAcceptancePolicy([]config.AcceptanceRule{
+		{When: "trust >= 0.7 && pathLength <= 2 && verified", Accept: AcceptTrusted},
+		{When: "trust >= 0.7", Accept: AcceptTentative},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.SetAcceptancePolicy(p)
+	if got := node.ValidateTrustProofTiered(block); got != BlockTentative {
+		t.Fatalf("unverified path: got %v, want tentative", got)
+	}
+
+	node.AddVerifiedTrustEdge(TrustEdge{Truster: node.NodeID, Trustee: relay, TrustLevel: 0.9})
+	node.AddVerifiedTrustEdge(TrustEdge{Truster: relay, Trustee: validator.NodeID, TrustLevel: 0.9})
+	if got := node.ValidateTrustProofTiered(block); got != BlockTrusted {
+		t.Fatalf("verified path: got %v, want trusted", got)
+	}
+
+	// No rule matches: untrusted, even above the domain threshold.
+	node.TrustRegistry[relay][validator.NodeID] = 0.6
+	node.invalidateTrustFor(relay)
+	if got := node.ValidateTrustProofTiered(block); got != BlockUntrusted {
+		t.Fatalf("no matching rule: got %v, want untrusted", got)
+	}
+}
+
+func TestAcceptancePolicy_CompileErrors(t *testing.T) {
+	for _, rules := range [][]config.AcceptanceRule{
+		{{When: "trust >= 0.7", Accept: "maybe"}},
+		{{When: "trsut >= 0.7", Accept: AcceptTrusted}},
+		{{When: "trust", Accept: AcceptTrusted}},
+	} {
+		if _, err := CompileAcceptancePolicy(rules); err == nil {
+			t.Errorf("%+v compiled", rules)
+		}
+	}
+}
+
+func TestAcceptancePolicyHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	post := func(req AcceptancePolicyUpdateRequest) *httptest.ResponseRecorder {
+		req.Timestamp = time.Now().Unix()
+		req.PublicKey = node.GetPublicKeyHex()
+		data, _ := json.Marshal(req)
+		sig, err := node.SignData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Signature = hex.EncodeToString(sig)
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/admin/acceptance-policy", bytes.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(AcceptancePolicyUpdateRequest{Rules: []config.AcceptanceRule{{When: "trust >", Accept: AcceptTrusted}}}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad rule: status %d", rec.Code)
+	}
+	rules := []config.AcceptanceRule{{When: `validator in ["aaaaaaaaaaaaaaaa"]`, Accept: AcceptTrusted}}
+	if rec := post(AcceptancePolicyUpdateRequest{Rules: rules}); rec.Code != http.StatusOK {
+		t.Fatalf("update: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/admin/acceptance-policy", nil))
+	var resp struct {
+		Data struct {
+			Rules []config.AcceptanceRule `json:"rules"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Data.Rules) != 1 || resp.Data.Rules[0] != rules[0] {
+		t.Fatalf("get: %s", rec.Body.String())
+	}
+
+	if rec := post(AcceptancePolicyUpdateRequest{}); rec.Code != http.StatusOK || node.GetAcceptancePolicy() != nil {
+		t.Fatalf("reset: status %d", rec.Code)
+	}
+}
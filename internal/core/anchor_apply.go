@@ -1,7 +1,5 @@
 package core
 
-import "time"
-
 // applyAnchorFromBlock is invoked when an AnchorTransaction is
 // encountered during processBlockTransactions for a Trusted block.
 // It re-validates the anchor (defense in depth — the block was
@@ -20,7 +18,7 @@ func (node *QuidnugNode) applyAnchorFromBlock(a NonceAnchor, block Block) {
 	// Validate against current ledger state. The anchor's nonce must
 	// strictly advance lastAnchorNonce[signer] — which effectively
 	// enforces ordering between anchors in different blocks.
-	if err := ValidateAnchor(node.NonceLedger, a, time.Now()); err != nil {
+	if err := ValidateAnchor(node.NonceLedger, a, nowTime()); err != nil {
 		logger.Warn("Rejected anchor in Trusted block",
 			"blockIndex", block.Index,
 			"blockHash", block.Hash,
@@ -44,7 +42,7 @@ func (node *QuidnugNode) applyAnchorFromBlock(a NonceAnchor, block Block) {
 	// subsequent admission paths don't needlessly re-probe. Also
 	// release any quarantined txs for this signer now that we have
 	// fresh state.
-	node.NonceLedger.MarkEpochRefresh(a.SignerQuid, time.Now())
+	node.NonceLedger.MarkEpochRefresh(a.SignerQuid, nowTime())
 	if node.quarantine != nil {
 		node.releaseQuarantinedForSigner(a.SignerQuid, "anchor_applied")
 	}
@@ -0,0 +1,560 @@
+// Rate-of-change anomaly detection on the trust graph.
+//
+// With anomaly_detection_enabled, every applied block is scanned for
+// three patterns that honest activity rarely produces:
+//
+//   - trust-burst: many quids, each only recently seen, trust the
+//     same target highly within a short window;
+//   - trust-ring: new quids close a short cycle of high trust among
+//     themselves (A→B→A, A→B→C→A);
+//   - title-churn: one asset changes hands many times in a window.
+//
+// The edges involved are marked suspect and each finding is kept in
+// a bounded log, served at GET /anomalies, and posted to
+// anomaly_webhook_url. ComputeRelationalTrustEnhanced multiplies a
+// suspect edge's weight by anomaly_suspect_discount, so a Sybil
+// cluster vouching for itself loses most of its reach without any
+// edge being deleted.
+//
+// Detection runs on transaction timestamps rather than the wall
+// clock, so a node replaying its chain at startup flags the same
+// edges it flagged live. Only the webhook looks at the wall clock:
+// findings from blocks older than AnomalyWebhookMaxAge are logged
+// but not posted, so a restart doesn't re-page the operator.
+//
+// Suspect flags are local judgement, not consensus state: they
+// change what this node reports, never which blocks it accepts.
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// Anomaly kinds.
+const (
+	AnomalyTrustBurst = "trust-burst"
+	AnomalyTrustRing  = "trust-ring"
+	AnomalyTitleChurn = "title-churn"
+)
+
+// ErrAnomalyDetectionDisabled means an anomaly endpoint was called on
+// a node without anomaly_detection_enabled.
+var ErrAnomalyDetectionDisabled = errors.New("anomaly: detection is disabled on this node")
+
+// Anomaly detection limits.
+const (
+	// MaxAnomalyEvents bounds the in-memory finding log.
+	MaxAnomalyEvents = 1000
+	// AnomalyWebhookMaxAge is how recent a finding's transaction
+	// must be for the webhook to fire.
+	AnomalyWebhookMaxAge = time.Hour
+)
+
+// AnomalyThresholds tunes the detectors. Times are in seconds, to
+// match transaction timestamps.
+type AnomalyThresholds struct {
+	// HighTrust is the trust level at or above which an edge counts
+	// toward a burst or ring.
+	HighTrust float64
+	// NewQuidAge is how long after a quid first appears on the chain
+	// its edges still count as coming from a new quid.
+	NewQuidAge int64
+	// BurstWindow and BurstMinEdges: BurstMinEdges high-trust edges
+	// from distinct new quids into one target within BurstWindow.
+	BurstWindow   int64
+	BurstMinEdges int
+	// RingWindow and RingMaxLength: a cycle of at most RingMaxLength
+	// high-trust edges from new quids, all within RingWindow.
+	RingWindow    int64
+	RingMaxLength int
+	// TitleChurnWindow and TitleChurnTransfers: that many title
+	// transactions for one asset within the window.
+	TitleChurnWindow    int64
+	TitleChurnTransfers int
+}
+
+// DefaultAnomalyThresholds returns the thresholds used when the
+// feature is turned on from config.
+func DefaultAnomalyThresholds() AnomalyThresholds {
+	return AnomalyThresholds{
+		HighTrust:           0.8,
+		NewQuidAge:          7 * 24 * 3600,
+		BurstWindow:         3600,
+		BurstMinEdges:       5,
+		RingWindow:          24 * 3600,
+		RingMaxLength:       3,
+		TitleChurnWindow:    24 * 3600,
+		TitleChurnTransfers: 5,
+	}
+}
+
+// SuspectEdge is a trust edge marked by a detector.
+type SuspectEdge struct {
+	Truster string `json:"truster"`
+	Trustee string `json:"trustee"`
+	Kind    string `json:"kind"`
+}
+
+// AnomalyEvent is one finding. Subject is the burst target, the
+// quid whose edge closed the ring, or the churned asset.
+type AnomalyEvent struct {
+	ID         string        `json:"id"`
+	Kind       string        `json:"kind"`
+	Domain     string        `json:"domain"`
+	Subject    string        `json:"subject"`
+	Quids      []string      `json:"quids"`
+	Edges      []SuspectEdge `json:"edges"`
+	BlockIndex int64         `json:"blockIndex"`
+	Timestamp  int64         `json:"timestamp"`
+	DetectedAt int64         `json:"detectedAt"`
+}
+
+// AnomalyClearRequest unmarks a suspect edge after operator review.
+// Signed by the admin key like the other operator requests.
+type AnomalyClearRequest struct {
+	Truster   string `json:"truster"`
+	Trustee   string `json:"trustee"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+type freshEdge struct {
+	truster   string
+	timestamp int64
+}
+
+type titleChange struct {
+	timestamp int64
+	quids     []string
+}
+
+// AnomalyDetector holds the sliding windows and the suspect-edge
+// set. Owns its own lock.
+type AnomalyDetector struct {
+	mu         sync.RWMutex
+	thresholds AnomalyThresholds
+	discount   float64
+	webhookURL string
+
+	firstSeen map[string]int64
+	// inbound: trustee -> high-trust edges from new quids, oldest first.
+	inbound map[string][]freshEdge
+	// fresh: truster -> trustee -> timestamp of high-trust edges
+	// from new quids, for ring search.
+	fresh map[string]map[string]int64
+	// titles: asset -> recent title transactions, oldest first.
+	titles  map[string][]titleChange
+	suspect map[string]map[string]string
+	events  []AnomalyEvent
+}
+
+// NewAnomalyDetector creates a detector. discount is clamped to
+// [0, 1].
+func NewAnomalyDetector(thresholds AnomalyThresholds, discount float64, webhookURL string) *AnomalyDetector {
+	if discount < 0 {
+		discount = 0
+	}
+	if discount > 1 {
+		discount = 1
+	}
+	return &AnomalyDetector{
+		thresholds: thresholds,
+		discount:   discount,
+		webhookURL: webhookURL,
+		firstSeen:  make(map[string]int64),
+		inbound:    make(map[string][]freshEdge),
+		fresh:      make(map[string]map[string]int64),
+		titles:     make(map[string][]titleChange),
+		suspect:    make(map[string]map[string]string),
+	}
+}
+
+// newAnomalyDetector builds the configured detector, or nil when the
+// feature is off.
+func newAnomalyDetector(cfg *config.Config) *AnomalyDetector {
+	if !cfg.AnomalyDetectionEnabled {
+		return nil
+	}
+	return NewAnomalyDetector(DefaultAnomalyThresholds(), cfg.AnomalySuspectDiscount, cfg.AnomalyWebhookURL)
+}
+
+// seenLocked records quid's first appearance and reports whether it
+// is still new at ts.
+func (d *AnomalyDetector) seenLocked(quid string, ts int64) bool {
+	first, ok := d.firstSeen[quid]
+	if !ok || ts < first {
+		d.firstSeen[quid] = ts
+		first = ts
+	}
+	return ts-first < d.thresholds.NewQuidAge
+}
+
+// observeQuid notes a quid's first appearance without an edge.
+func (d *AnomalyDetector) observeQuid(quid string, ts int64) {
+	if quid == "" {
+		return
+	}
+	d.mu.Lock()
+	d.seenLocked(quid, ts)
+	d.mu.Unlock()
+}
+
+// observeTrust feeds one applied trust edge to the burst and ring
+// detectors and returns their findings, unflagged.
+func (d *AnomalyDetector) observeTrust(tx TrustTransaction) []AnomalyEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ts := tx.Timestamp
+	isNew := d.seenLocked(tx.Truster, ts)
+	d.seenLocked(tx.Trustee, ts)
+
+	if tx.TrustLevel < d.thresholds.HighTrust || !isNew {
+		if edges := d.fresh[tx.Truster]; edges != nil {
+			delete(edges, tx.Trustee)
+		}
+		return nil
+	}
+
+	var found []AnomalyEvent
+	if ev := d.burstLocked(tx); ev != nil {
+		found = append(found, *ev)
+	}
+	if ev := d.ringLocked(tx); ev != nil {
+		found = append(found, *ev)
+	}
+	return found
+}
+
+func (d *AnomalyDetector) burstLocked(tx TrustTransaction) *AnomalyEvent {
+	cutoff := tx.Timestamp - d.thresholds.BurstWindow
+	window := d.inbound[tx.Trustee][:0]
+	for _, e := range d.inbound[tx.Trustee] {
+		if e.timestamp >= cutoff && e.truster != tx.Truster {
+			window = append(window, e)
+		}
+	}
+	window = append(window, freshEdge{truster: tx.Truster, timestamp: tx.Timestamp})
+
+	if len(window) < d.thresholds.BurstMinEdges {
+		d.inbound[tx.Trustee] = window
+		return nil
+	}
+	// The burst is reported once; a fresh one has to build up anew.
+	delete(d.inbound, tx.Trustee)
+
+	ev := &AnomalyEvent{Kind: AnomalyTrustBurst, Domain: tx.TrustDomain, Subject: tx.Trustee, Timestamp: tx.Timestamp}
+	for _, e := range window {
+		ev.Quids = append(ev.Quids, e.truster)
+		ev.Edges = append(ev.Edges, SuspectEdge{Truster: e.truster, Trustee: tx.Trustee, Kind: AnomalyTrustBurst})
+	}
+	return ev
+}
+
+func (d *AnomalyDetector) ringLocked(tx TrustTransaction) *AnomalyEvent {
+	if d.fresh[tx.Truster] == nil {
+		d.fresh[tx.Truster] = make(map[string]int64)
+	}
+	d.fresh[tx.Truster][tx.Trustee] = tx.Timestamp
+
+	// Look for a path back from the trustee to the truster over
+	// fresh edges, closing a cycle of at most RingMaxLength.
+	cutoff := tx.Timestamp - d.thresholds.RingWindow
+	var search func(at string, path []string) []string
+	search = func(at string, path []string) []string {
+		if len(path) > d.thresholds.RingMaxLength {
+			return nil
+		}
+		for next, ts := range d.fresh[at] {
+			if ts < cutoff {
+				delete(d.fresh[at], next)
+				continue
+			}
+			if next == tx.Truster {
+				return append(path, next)
+			}
+			if containsString(path, next) {
+				continue
+			}
+			if ring := search(next, append(path, next)); ring != nil {
+				return ring
+			}
+		}
+		return nil
+	}
+	ring := search(tx.Trustee, []string{tx.Truster, tx.Trustee})
+	if ring == nil {
+		return nil
+	}
+
+	ev := &AnomalyEvent{Kind: AnomalyTrustRing, Domain: tx.TrustDomain, Subject: tx.Truster, Timestamp: tx.Timestamp}
+	ev.Quids = append(ev.Quids, ring[:len(ring)-1]...)
+	for i := 0; i+1 < len(ring); i++ {
+		ev.Edges = append(ev.Edges, SuspectEdge{Truster: ring[i], Trustee: ring[i+1], Kind: AnomalyTrustRing})
+	}
+	return ev
+}
+
+// observeTitle feeds one applied title transaction to the churn
+// detector. The finding lists the quids involved; the caller
+// supplies the edges among them.
+func (d *AnomalyDetector) observeTitle(tx TitleTransaction) *AnomalyEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	change := titleChange{timestamp: tx.Timestamp}
+	for _, stake := range append(append([]OwnershipStake(nil), tx.PreviousOwners...), tx.Owners...) {
+		change.quids = append(change.quids, stake.OwnerID)
+	}
+	cutoff := tx.Timestamp - d.thresholds.TitleChurnWindow
+	window := d.titles[tx.AssetID][:0]
+	for _, c := range d.titles[tx.AssetID] {
+		if c.timestamp >= cutoff {
+			window = append(window, c)
+		}
+	}
+	window = append(window, change)
+	if len(window) < d.thresholds.TitleChurnTransfers {
+		d.titles[tx.AssetID] = window
+		return nil
+	}
+	delete(d.titles, tx.AssetID)
+
+	ev := &AnomalyEvent{Kind: AnomalyTitleChurn, Domain: tx.TrustDomain, Subject: tx.AssetID, Timestamp: tx.Timestamp}
+	for _, c := range window {
+		for _, q := range c.quids {
+			if !containsString(ev.Quids, q) {
+				ev.Quids = append(ev.Quids, q)
+			}
+		}
+	}
+	return ev
+}
+
+// flag marks ev's edges suspect and logs it. A finding that marks
+// nothing new is dropped, except title churn, which is worth
+// reporting even when the owners share no trust edges. Returns
+// whether ev was logged.
+func (d *AnomalyDetector) flag(ev *AnomalyEvent) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	marked := false
+	for _, e := range ev.Edges {
+		if d.suspect[e.Truster] == nil {
+			d.suspect[e.Truster] = make(map[string]string)
+		}
+		if _, already := d.suspect[e.Truster][e.Trustee]; !already {
+			d.suspect[e.Truster][e.Trustee] = e.Kind
+			marked = true
+		}
+	}
+	if !marked && ev.Kind != AnomalyTitleChurn {
+		return false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(ev.Kind + "|" + ev.Subject + "|" + strconv.FormatInt(ev.BlockIndex, 10) + "|" + strconv.FormatInt(ev.Timestamp, 10)))
+	for _, q := range ev.Quids {
+		h.Write([]byte("|" + q))
+	}
+	ev.ID = hex.EncodeToString(h.Sum(nil)[:16])
+	ev.DetectedAt = time.Now().Unix()
+
+	d.events = append(d.events, *ev)
+	if len(d.events) > MaxAnomalyEvents {
+		d.events = d.events[len(d.events)-MaxAnomalyEvents:]
+	}
+	return true
+}
+
+// SuspectKind returns the kind that marked truster→trustee, or ""
+// if the edge is not suspect.
+func (d *AnomalyDetector) SuspectKind(truster, trustee string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.suspect[truster][trustee]
+}
+
+// ClearSuspect unmarks an edge an operator has reviewed and
+// reports whether it was marked.
+func (d *AnomalyDetector) ClearSuspect(truster, trustee string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.suspect[truster][trustee]; !ok {
+		return false
+	}
+	delete(d.suspect[truster], trustee)
+	if len(d.suspect[truster]) == 0 {
+		delete(d.suspect, truster)
+	}
+	return true
+}
+
+// SuspectEdges lists suspect edges touching quid, or all of them
+// when quid is empty, sorted by truster then trustee.
+func (d *AnomalyDetector) SuspectEdges(quid string) []SuspectEdge {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := []SuspectEdge{}
+	for truster, trustees := range d.suspect {
+		for trustee, kind := range trustees {
+			if quid == "" || quid == truster || quid == trustee {
+				out = append(out, SuspectEdge{Truster: truster, Trustee: trustee, Kind: kind})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Truster != out[j].Truster {
+			return out[i].Truster < out[j].Truster
+		}
+		return out[i].Trustee < out[j].Trustee
+	})
+	return out
+}
+
+// Events returns logged findings newest first, filtered by kind and
+// domain when non-empty, at most limit of them (0 for all).
+func (d *AnomalyDetector) Events(kind, domain string, limit int) []AnomalyEvent {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := []AnomalyEvent{}
+	for i := len(d.events) - 1; i >= 0; i-- {
+		ev := d.events[i]
+		if (kind != "" && ev.Kind != kind) || (domain != "" && ev.Domain != domain) {
+			continue
+		}
+		out = append(out, ev)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// observeTrustAnomalies runs the trust detectors on an applied edge.
+func (node *QuidnugNode) observeTrustAnomalies(tx TrustTransaction, block Block) {
+	if node.Anomalies == nil {
+		return
+	}
+	for _, ev := range node.Anomalies.observeTrust(tx) {
+		ev := ev
+		node.reportAnomaly(&ev, block)
+	}
+}
+
+// observeTitleAnomalies runs the churn detector on an applied title
+// and marks the trust edges among the owners involved.
+func (node *QuidnugNode) observeTitleAnomalies(tx TitleTransaction, block Block) {
+	if node.Anomalies == nil {
+		return
+	}
+	for _, stake := range tx.Owners {
+		node.Anomalies.observeQuid(stake.OwnerID, tx.Timestamp)
+	}
+	ev := node.Anomalies.observeTitle(tx)
+	if ev == nil {
+		return
+	}
+	node.TrustRegistryMutex.RLock()
+	for _, truster := range ev.Quids {
+		for _, trustee := range ev.Quids {
+			if level, ok := node.TrustRegistry[truster][trustee]; ok && level > 0 && truster != trustee {
+				ev.Edges = append(ev.Edges, SuspectEdge{Truster: truster, Trustee: trustee, Kind: AnomalyTitleChurn})
+			}
+		}
+	}
+	node.TrustRegistryMutex.RUnlock()
+	node.reportAnomaly(ev, block)
+}
+
+// reportAnomaly flags a finding, drops cached trust results that
+// used its edges, and notifies the webhook.
+func (node *QuidnugNode) reportAnomaly(ev *AnomalyEvent, block Block) {
+	ev.BlockIndex = block.Index
+	if !node.Anomalies.flag(ev) {
+		return
+	}
+	for _, e := range ev.Edges {
+		node.invalidateTrustFor(e.Truster)
+	}
+	logger.Warn("Trust graph anomaly detected",
+		"kind", ev.Kind, "domain", ev.Domain, "subject", ev.Subject,
+		"quids", strings.Join(ev.Quids, ","), "blockIndex", ev.BlockIndex)
+
+	url := node.Anomalies.webhookURL
+	if url == "" || time.Since(time.Unix(ev.Timestamp, 0)) > AnomalyWebhookMaxAge {
+		return
+	}
+	go node.postAnomalyWebhook(url, *ev)
+}
+
+func (node *QuidnugNode) postAnomalyWebhook(url string, ev AnomalyEvent) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	client := node.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("Anomaly webhook failed", "url", url, "id", ev.ID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Warn("Anomaly webhook rejected", "url", url, "id", ev.ID, "status", resp.StatusCode)
+	}
+}
+
+// ClearSuspectEdge verifies an admin-signed AnomalyClearRequest and
+// unmarks the edge, reporting whether it was marked.
+func (node *QuidnugNode) ClearSuspectEdge(req AnomalyClearRequest) (bool, error) {
+	if node.Anomalies == nil {
+		return false, ErrAnomalyDetectionDisabled
+	}
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return false, err
+	}
+	cleared := node.Anomalies.ClearSuspect(req.Truster, req.Trustee)
+	if cleared {
+		node.invalidateTrustFor(req.Truster)
+	}
+	return cleared, nil
+}
+
+// suspectEdgeFactor is the weight multiplier for truster→trustee in
+// enhanced trust computation: the configured discount for a suspect
+// edge, 1 otherwise.
+func (node *QuidnugNode) suspectEdgeFactor(truster, trustee string) float64 {
+	if node.Anomalies == nil || node.Anomalies.SuspectKind(truster, trustee) == "" {
+		return 1
+	}
+	return node.Anomalies.discount
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newAnomalyTestNode() *QuidnugNode {
+	node := newTestNode()
+	th := DefaultAnomalyThresholds()
+	th.BurstMinEdges = 3
+	th.TitleChurnTransfers = 3
+	node.Anomalies = NewAnomalyDetector(th, 0.5, "")
+	return node
+}
+
+func anomalyTrustTx(truster, trustee string, level float64, ts int64) TrustTransaction {
+	return TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: truster + trustee, Type: TxTypeTrust, TrustDomain: "test.domain.com", Timestamp: ts},
+		Truster:         truster,
+		Trustee:         trustee,
+		TrustLevel:      level,
+	}
+}
+
+func TestAnomaly_BurstFromNewQuids(t *testing.T) {
+	node := newAnomalyTestNode()
+	now := time.Now().Unix()
+	target := "eeeeeeeeeeeeeeee"
+
+	// An established quid's edge doesn't count toward a burst.
+	node.Anomalies.observeQuid("aaaaaaaaaaaaaaaa", now-30*24*3600)
+	txs := []interface{}{anomalyTrustTx("aaaaaaaaaaaaaaaa", target, 1.0, now)}
+	for i := 0; i < 2; i++ {
+		txs = append(txs, anomalyTrustTx(fmt.Sprintf("%016d", i), target, 0.95, now+int64(i)))
+	}
+	node.processBlockTransactions(Block{Index: 1, Transactions: txs, TrustProof: TrustProof{TrustDomain: "test.domain.com"}})
+	if got := node.Anomalies.Events("", "", 0); len(got) != 0 {
+		t.Fatalf("two new quids flagged as a burst: %+v", got)
+	}
+
+	// A low-trust edge from a new quid doesn't either.
+	node.processBlockTransactions(Block{Index: 2, Transactions: []interface{}{
+		anomalyTrustTx("1111111111111111", target, 0.3, now+5),
+	}})
+	if got := node.Anomalies.Events("", "", 0); len(got) != 0 {
+		t.Fatalf("low-trust edge completed a burst: %+v", got)
+	}
+
+	node.processBlockTransactions(Block{Index: 3, Transactions: []interface{}{
+		anomalyTrustTx("2222222222222222", target, 0.9, now+10),
+	}})
+	events := node.Anomalies.Events(AnomalyTrustBurst, "test.domain.com", 0)
+	if len(events) != 1 || events[0].Subject != target || len(events[0].Edges) != 3 || events[0].BlockIndex != 3 {
+		t.Fatalf("burst events = %+v", events)
+	}
+	if node.Anomalies.SuspectKind("0000000000000000", target) != AnomalyTrustBurst {
+		t.Error("burst edge not marked suspect")
+	}
+	if node.Anomalies.SuspectKind("aaaaaaaaaaaaaaaa", target) != "" {
+		t.Error("established quid's edge marked suspect")
+	}
+}
+
+func TestAnomaly_RingDiscountsEnhancedTrust(t *testing.T) {
+	node := newAnomalyTestNode()
+	now := time.Now().Unix()
+	a, b, c := "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", "cccccccccccccccc"
+	for _, e := range [][2]string{{a, b}, {b, c}} {
+		node.AddVerifiedTrustEdge(TrustEdge{Truster: e[0], Trustee: e[1], TrustLevel: 0.9})
+	}
+
+	before, err := node.ComputeRelationalTrustEnhanced(context.Background(), a, c, 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node.processBlockTransactions(Block{Index: 1, Transactions: []interface{}{
+		anomalyTrustTx(a, b, 0.9, now),
+		anomalyTrustTx(b, c, 0.9, now+1),
+	}})
+	if got := node.Anomalies.Events("", "", 0); len(got) != 0 {
+		t.Fatalf("open chain flagged: %+v", got)
+	}
+	node.processBlockTransactions(Block{Index: 2, Transactions: []interface{}{
+		anomalyTrustTx(c, a, 0.9, now+2),
+	}})
+	events := node.Anomalies.Events(AnomalyTrustRing, "", 0)
+	if len(events) != 1 || len(events[0].Edges) != 3 || events[0].Subject != c {
+		t.Fatalf("ring events = %+v", events)
+	}
+
+	// Both hops of a→b→c are now suspect: the cached result is
+	// dropped and each hop counts for half.
+	after, err := node.ComputeRelationalTrustEnhanced(context.Background(), a, c, 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatEquals(after.TrustLevel, before.TrustLevel*0.25, 0.0001) {
+		t.Errorf("trust after ring = %f, want %f", after.TrustLevel, before.TrustLevel*0.25)
+	}
+
+	// Clearing an edge needs the admin key.
+	req := AnomalyClearRequest{Truster: a, Trustee: b, Timestamp: time.Now().Unix(), PublicKey: node.GetPublicKeyHex()}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := req
+	forged.Signature = hex.EncodeToString(sig[:len(sig)-1])
+	if _, err := node.ClearSuspectEdge(forged); err == nil {
+		t.Fatal("clear with a bad signature accepted")
+	}
+	req.Signature = hex.EncodeToString(sig)
+	if cleared, err := node.ClearSuspectEdge(req); err != nil || !cleared {
+		t.Fatalf("clear: cleared=%v err=%v", cleared, err)
+	}
+	if edges := node.Anomalies.SuspectEdges(a); len(edges) != 1 || edges[0].Truster != c {
+		t.Errorf("suspect edges touching a = %+v", edges)
+	}
+}
+
+func TestAnomaly_TitleChurn(t *testing.T) {
+	node := newAnomalyTestNode()
+	now := time.Now().Unix()
+	owners := []string{"aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", "cccccccccccccccc"}
+	node.TrustRegistry[owners[0]] = map[string]float64{owners[1]: 0.4}
+
+	var txs []interface{}
+	for i, owner := range owners {
+		tx := TitleTransaction{
+			BaseTransaction: BaseTransaction{ID: fmt.Sprintf("title-%d", i), Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: now + int64(i)},
+			AssetID:         "asset-1",
+			Owners:          []OwnershipStake{{OwnerID: owner, Percentage: 100}},
+		}
+		if i > 0 {
+			tx.PreviousOwners = []OwnershipStake{{OwnerID: owners[i-1], Percentage: 100}}
+		}
+		txs = append(txs, tx)
+	}
+	node.processBlockTransactions(Block{Index: 1, Transactions: txs})
+
+	events := node.Anomalies.Events(AnomalyTitleChurn, "", 0)
+	if len(events) != 1 || events[0].Subject != "asset-1" {
+		t.Fatalf("churn events = %+v", events)
+	}
+	if node.Anomalies.SuspectKind(owners[0], owners[1]) != AnomalyTitleChurn {
+		t.Error("trust edge between churn owners not marked suspect")
+	}
+}
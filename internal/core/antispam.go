@@ -0,0 +1,294 @@
+// Package core. antispam.go — per-domain anti-spam admission policy.
+//
+// Open public domains have no intrinsic cost to flooding the
+// mempool: the QDP-0016 rate limiter bounds throughput per quid,
+// but quids are free to mint. A domain may therefore opt in to
+// an AntiSpamPolicy that requires each submitted transaction to
+// carry one of:
+//
+//   - a proof-of-work stamp: BaseTransaction.PoWStamp such that
+//     SHA-256(digest || ":" || signer || ":" || stamp) has at least
+//     PoWDifficulty leading zero bits, where digest is the hex
+//     SHA-256 of the transaction's canonical JSON without its
+//     signature and stamp (PoWStampDigest) and signer is the quid
+//     of its PublicKey. The digest covers every signed field, so a
+//     stamp can't be lifted onto another transaction, nor onto the
+//     same one re-signed by another key.
+//
+//   - a stake: the submitting quid holds at least
+//     MinStakePercentage of the domain's designated bond asset
+//     (StakeAssetID) in the title registry. The deposit is thus an
+//     ordinary on-chain title that every node can check
+//     deterministically, and it can be withdrawn by transferring
+//     the title away.
+//
+// The policy is enforced twice: at mempool admission in every
+// Add*Transaction path, and again in FilterTransactionsForBlock so
+// transactions that reached the pool via gossip or before a policy
+// was installed don't make it into a block.
+//
+// Domains without a policy (the default) behave exactly as before.
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+// Anti-spam policy modes.
+const (
+	// AntiSpamModeNone disables the policy. Equivalent to a nil
+	// TrustDomain.AntiSpam.
+	AntiSpamModeNone = "none"
+	// AntiSpamModePoW requires a valid proof-of-work stamp.
+	AntiSpamModePoW = "pow"
+	// AntiSpamModeStake requires a bond-asset stake.
+	AntiSpamModeStake = "stake"
+	// AntiSpamModePoWOrStake accepts either; staked quids skip
+	// the work.
+	AntiSpamModePoWOrStake = "pow_or_stake"
+)
+
+// MaxAntiSpamPoWDifficulty caps the difficulty a domain may
+// demand. 32 bits is already ~4 billion hashes on average, which
+// is well past what an interactive client will tolerate.
+const MaxAntiSpamPoWDifficulty = 32
+
+// AntiSpamPolicy is the per-domain admission-cost configuration
+// carried on TrustDomain.AntiSpam.
+type AntiSpamPolicy struct {
+	// Mode is one of the AntiSpamMode* constants. Empty is
+	// treated as AntiSpamModeNone.
+	Mode string `json:"mode"`
+	// PoWDifficulty is the required number of leading zero bits
+	// in the stamp hash. Ignored for stake-only policies.
+	PoWDifficulty int `json:"powDifficulty,omitempty"`
+	// StakeAssetID names the title whose ownership stakes count
+	// as deposits. Required for stake modes.
+	StakeAssetID string `json:"stakeAssetId,omitempty"`
+	// MinStakePercentage is the minimum share of StakeAssetID the
+	// submitting quid must hold. Must be in (0, 100].
+	MinStakePercentage float64 `json:"minStakePercentage,omitempty"`
+}
+
+// requiresPoW reports whether a proof-of-work stamp is one of the
+// accepted admission proofs.
+func (p *AntiSpamPolicy) requiresPoW() bool {
+	return p.Mode == AntiSpamModePoW || p.Mode == AntiSpamModePoWOrStake
+}
+
+// requiresStake reports whether a stake is one of the accepted
+// admission proofs.
+func (p *AntiSpamPolicy) requiresStake() bool {
+	return p.Mode == AntiSpamModeStake || p.Mode == AntiSpamModePoWOrStake
+}
+
+// Validate checks the policy for internal consistency. Called at
+// domain registration so a malformed policy never lands in
+// TrustDomains.
+func (p *AntiSpamPolicy) Validate() error {
+	switch p.Mode {
+	case "", AntiSpamModeNone:
+		return nil
+	case AntiSpamModePoW, AntiSpamModeStake, AntiSpamModePoWOrStake:
+	default:
+		return fmt.Errorf("unknown anti-spam mode %q", p.Mode)
+	}
+	if p.requiresPoW() {
+		if p.PoWDifficulty <= 0 || p.PoWDifficulty > MaxAntiSpamPoWDifficulty {
+			return fmt.Errorf("powDifficulty must be in 1..%d", MaxAntiSpamPoWDifficulty)
+		}
+	}
+	if p.requiresStake() {
+		if p.StakeAssetID == "" {
+			return fmt.Errorf("stakeAssetId is required for mode %q", p.Mode)
+		}
+		if p.MinStakePercentage <= 0 || p.MinStakePercentage > 100 {
+			return fmt.Errorf("minStakePercentage must be in (0, 100]")
+		}
+	}
+	return nil
+}
+
+// PoWStampDigest returns the hex SHA-256 of tx encoded as
+// key-sorted JSON without its signature and stamp: the part of a
+// transaction a stamp commits to. Clients mine against it before
+// signing.
+func PoWStampDigest(tx interface{}) (string, error) {
+	raw, err := json.Marshal(tx)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return "", err
+	}
+	delete(fields, "signature")
+	delete(fields, "powStamp")
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// PoWStampHash returns the hash a proof-of-work stamp is judged
+// by: SHA-256 over the tx digest, the signer quid and the stamp,
+// colon-separated.
+func PoWStampHash(digest, signer, stamp string) [32]byte {
+	return sha256.Sum256([]byte(digest + ":" + signer + ":" + stamp))
+}
+
+// leadingZeroBits counts the leading zero bits of a hash.
+func leadingZeroBits(sum [32]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		n += bits.LeadingZeros8(b)
+		break
+	}
+	return n
+}
+
+// VerifyPoWStamp reports whether stamp satisfies difficulty for
+// the given tx digest and signer quid.
+func VerifyPoWStamp(digest, signer, stamp string, difficulty int) bool {
+	if digest == "" || signer == "" || stamp == "" {
+		return false
+	}
+	return leadingZeroBits(PoWStampHash(digest, signer, stamp)) >= difficulty
+}
+
+// MinePoWStamp searches for a stamp satisfying difficulty for the
+// given tx digest and signer quid, trying at most maxAttempts
+// counters. Intended for clients and tests; nodes never mine.
+func MinePoWStamp(digest, signer string, difficulty int, maxAttempts uint64) (string, bool) {
+	for i := uint64(0); i < maxAttempts; i++ {
+		stamp := strconv.FormatUint(i, 16)
+		if VerifyPoWStamp(digest, signer, stamp, difficulty) {
+			return stamp, true
+		}
+	}
+	return "", false
+}
+
+// antiSpamPolicyFor returns the domain's policy, or nil if the
+// domain is unknown or has no active policy.
+func (node *QuidnugNode) antiSpamPolicyFor(domain string) *AntiSpamPolicy {
+	node.TrustDomainsMutex.RLock()
+	defer node.TrustDomainsMutex.RUnlock()
+	td, ok := node.TrustDomains[domain]
+	if !ok || td.AntiSpam == nil {
+		return nil
+	}
+	if td.AntiSpam.Mode == "" || td.AntiSpam.Mode == AntiSpamModeNone {
+		return nil
+	}
+	p := *td.AntiSpam
+	return &p
+}
+
+// quidStakePercentage returns the share of assetID held by quid
+// according to the committed title registry, as a percentage
+// whichever scale the title's stakes use.
+func (node *QuidnugNode) quidStakePercentage(assetID, quid string) float64 {
+	node.TitleRegistryMutex.RLock()
+	defer node.TitleRegistryMutex.RUnlock()
+	title, ok := node.TitleRegistry[assetID]
+	if !ok {
+		return 0
+	}
+	return normalizedStakes(title.Owners)[quid] * 100
+}
+
+// titleStakeQuid is the quid whose stake admits a title: its
+// signer. The owners a title names are whatever the submitter
+// wrote, so holding a stake doesn't make them vouch for it.
+func titleStakeQuid(tx TitleTransaction) string {
+	if tx.PublicKey == "" {
+		return ""
+	}
+	return QuidIDFromPublicKeyHex(tx.PublicKey)
+}
+
+// checkAntiSpam enforces the domain's AntiSpamPolicy for a
+// transaction submitted by quid. Returns nil when the domain has
+// no policy or the transaction carries an acceptable proof.
+func (node *QuidnugNode) checkAntiSpam(domain, quid string, tx interface{}) error {
+	policy := node.antiSpamPolicyFor(domain)
+	if policy == nil {
+		return nil
+	}
+
+	if policy.requiresStake() && quid != "" {
+		if node.quidStakePercentage(policy.StakeAssetID, quid) >= policy.MinStakePercentage {
+			return nil
+		}
+	}
+	if policy.requiresPoW() && hasValidPoWStamp(tx, policy.PoWDifficulty) {
+		return nil
+	}
+
+	switch policy.Mode {
+	case AntiSpamModePoW:
+		return fmt.Errorf("domain %s requires a proof-of-work stamp of %d bits", domain, policy.PoWDifficulty)
+	case AntiSpamModeStake:
+		return fmt.Errorf("domain %s requires a stake of %.2f%% in %s", domain, policy.MinStakePercentage, policy.StakeAssetID)
+	default:
+		return fmt.Errorf("domain %s requires a proof-of-work stamp of %d bits or a stake of %.2f%% in %s",
+			domain, policy.PoWDifficulty, policy.MinStakePercentage, policy.StakeAssetID)
+	}
+}
+
+// hasValidPoWStamp reports whether tx, which must embed
+// BaseTransaction, carries a stamp meeting difficulty for its
+// digest and signing key.
+func hasValidPoWStamp(tx interface{}, difficulty int) bool {
+	b, ok := tx.(interface{ baseTransaction() BaseTransaction })
+	if !ok {
+		return false
+	}
+	base := b.baseTransaction()
+	if base.PoWStamp == "" || base.PublicKey == "" {
+		return false
+	}
+	digest, err := PoWStampDigest(tx)
+	if err != nil {
+		return false
+	}
+	return VerifyPoWStamp(digest, QuidIDFromPublicKeyHex(base.PublicKey), base.PoWStamp, difficulty)
+}
+
+// baseTransaction returns the embedded BaseTransaction. Promoted to
+// every transaction type so type-agnostic checks can reach it.
+func (b BaseTransaction) baseTransaction() BaseTransaction {
+	return b
+}
+
+// admitAntiSpamOrReject wraps checkAntiSpam with the metrics and
+// logging every Add*Transaction path wants on rejection. tx is the
+// full transaction, which a stamp is checked against.
+func (node *QuidnugNode) admitAntiSpamOrReject(kind, domain, quid string, tx interface{}) error {
+	if err := node.checkAntiSpam(domain, quid, tx); err != nil {
+		RecordTransactionProcessed(kind, false)
+		txID := ""
+		if b, ok := tx.(interface{ baseTransaction() BaseTransaction }); ok {
+			txID = b.baseTransaction().ID
+		}
+		logger.Warn("Transaction rejected by anti-spam policy",
+			"txId", txID, "quid", quid, "domain", domain, "error", err)
+		return &TxRejection{Code: RejectAntiSpam, Message: err.Error(), Err: err}
+	}
+	return nil
+}
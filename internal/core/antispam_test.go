@@ -0,0 +1,267 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// stampTx mines a stamp of difficulty bits onto tx for its signer.
+func stampTx(t *testing.T, tx TrustTransaction, difficulty int) TrustTransaction {
+	t.Helper()
+	tx.PoWStamp = ""
+	digest, err := PoWStampDigest(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamp, ok := MinePoWStamp(digest, QuidIDFromPublicKeyHex(tx.PublicKey), difficulty, 1<<22)
+	if !ok {
+		t.Fatalf("no %d-bit stamp found", difficulty)
+	}
+	tx.PoWStamp = stamp
+	return tx
+}
+
+// installAntiSpamPolicy attaches policy to the test domain in
+// place, bypassing RegisterTrustDomain.
+func installAntiSpamPolicy(node *QuidnugNode, domain string, policy *AntiSpamPolicy) {
+	node.TrustDomainsMutex.Lock()
+	defer node.TrustDomainsMutex.Unlock()
+	td := node.TrustDomains[domain]
+	td.AntiSpam = policy
+	node.TrustDomains[domain] = td
+}
+
+func TestAntiSpamPolicy_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  AntiSpamPolicy
+		wantErr bool
+	}{
+		{"empty", AntiSpamPolicy{}, false},
+		{"none", AntiSpamPolicy{Mode: AntiSpamModeNone}, false},
+		{"pow ok", AntiSpamPolicy{Mode: AntiSpamModePoW, PoWDifficulty: 8}, false},
+		{"pow zero difficulty", AntiSpamPolicy{Mode: AntiSpamModePoW}, true},
+		{"pow too hard", AntiSpamPolicy{Mode: AntiSpamModePoW, PoWDifficulty: MaxAntiSpamPoWDifficulty + 1}, true},
+		{"stake ok", AntiSpamPolicy{Mode: AntiSpamModeStake, StakeAssetID: "bond", MinStakePercentage: 1}, false},
+		{"stake missing asset", AntiSpamPolicy{Mode: AntiSpamModeStake, MinStakePercentage: 1}, true},
+		{"stake bad percentage", AntiSpamPolicy{Mode: AntiSpamModeStake, StakeAssetID: "bond", MinStakePercentage: 101}, true},
+		{"either needs both halves", AntiSpamPolicy{Mode: AntiSpamModePoWOrStake, PoWDifficulty: 4}, true},
+		{"unknown mode", AntiSpamPolicy{Mode: "fee"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() err=%v, wantErr=%v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoWStamp_MineAndVerify(t *testing.T) {
+	const signer = "0000000000000001"
+	stamp, ok := MinePoWStamp("digest-abc", signer, 8, 1<<20)
+	if !ok {
+		t.Fatal("expected to find an 8-bit stamp")
+	}
+	if !VerifyPoWStamp("digest-abc", signer, stamp, 8) {
+		t.Fatal("mined stamp did not verify")
+	}
+	// Stamps are bound to the digest and the signer.
+	if VerifyPoWStamp("digest-other", signer, stamp, 16) {
+		t.Fatal("stamp unexpectedly verified for another tx at higher difficulty")
+	}
+	if VerifyPoWStamp("digest-abc", "0000000000000002", stamp, 16) {
+		t.Fatal("stamp unexpectedly verified for another signer at higher difficulty")
+	}
+	if VerifyPoWStamp("digest-abc", signer, "", 0) {
+		t.Fatal("empty stamp must never verify")
+	}
+}
+
+func TestPoWStampDigest_IgnoresSignatureAndStamp(t *testing.T) {
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx-1", TrustDomain: "test.domain.com", PublicKey: "04ab"},
+		Truster:         "0000000000000001",
+		Trustee:         "0000000000000002",
+		TrustLevel:      0.5,
+	}
+	before, _ := PoWStampDigest(tx)
+	tx.Signature, tx.PoWStamp = "sig", "stamp"
+	if after, _ := PoWStampDigest(tx); after != before {
+		t.Error("digest changed with the signature or stamp")
+	}
+	tx.TrustLevel = 0.6
+	if changed, _ := PoWStampDigest(tx); changed == before {
+		t.Error("digest did not cover a signed field")
+	}
+}
+
+func TestCheckAntiSpam_NoPolicyAdmits(t *testing.T) {
+	node := newTestNode()
+	if err := node.checkAntiSpam("test.domain.com", "0000000000000001", BaseTransaction{ID: "x"}); err != nil {
+		t.Fatalf("no policy should admit, got %v", err)
+	}
+}
+
+func TestCheckAntiSpam_PoW(t *testing.T) {
+	node := newTestNode()
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{Mode: AntiSpamModePoW, PoWDifficulty: 8})
+	alice, mallory := newTestNodeActor(t), newTestNodeActor(t)
+
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx-pow", TrustDomain: "test.domain.com", PublicKey: alice.PubHex},
+		Truster:         alice.QuidID,
+		Trustee:         "0000000000000002",
+		TrustLevel:      0.5,
+	}
+	if err := node.checkAntiSpam("test.domain.com", alice.QuidID, tx); err == nil {
+		t.Fatal("expected rejection without stamp")
+	}
+	tx = stampTx(t, tx, 8)
+	if err := node.checkAntiSpam("test.domain.com", alice.QuidID, tx); err != nil {
+		t.Fatalf("expected admission with stamp, got %v", err)
+	}
+
+	// The stamp commits to neither a different transaction reusing
+	// the ID nor the same one under another signer.
+	stampHash := func(tx TrustTransaction) [32]byte {
+		digest, _ := PoWStampDigest(tx)
+		return PoWStampHash(digest, QuidIDFromPublicKeyHex(tx.PublicKey), tx.PoWStamp)
+	}
+	other := tx
+	other.Trustee = "0000000000000003"
+	resigned := tx
+	resigned.PublicKey = mallory.PubHex
+	for name, lifted := range map[string]TrustTransaction{"other tx": other, "other signer": resigned} {
+		if stampHash(lifted) == stampHash(tx) {
+			t.Errorf("%s: stamp hash unchanged", name)
+		}
+	}
+}
+
+func TestCheckAntiSpam_StakeOrPoW(t *testing.T) {
+	node := newTestNode()
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{
+		Mode:               AntiSpamModePoWOrStake,
+		PoWDifficulty:      20,
+		StakeAssetID:       "bond-asset",
+		MinStakePercentage: 5,
+	})
+	node.TitleRegistry["bond-asset"] = TitleTransaction{
+		AssetID: "bond-asset",
+		Owners: []OwnershipStake{
+			{OwnerID: "0000000000000001", Percentage: 10},
+			{OwnerID: "0000000000000002", Percentage: 90},
+		},
+	}
+
+	if err := node.checkAntiSpam("test.domain.com", "0000000000000001", BaseTransaction{ID: "a"}); err != nil {
+		t.Fatalf("staked quid should be admitted, got %v", err)
+	}
+	if err := node.checkAntiSpam("test.domain.com", "0000000000000003", BaseTransaction{ID: "b"}); err == nil {
+		t.Fatal("unstaked quid without stamp should be rejected")
+	}
+}
+
+func TestCheckAntiSpam_StakeOnFractionScaleTitle(t *testing.T) {
+	node := newTestNode()
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{
+		Mode:               AntiSpamModeStake,
+		StakeAssetID:       "bond-asset",
+		MinStakePercentage: 5,
+	})
+	node.TitleRegistry["bond-asset"] = TitleTransaction{
+		AssetID: "bond-asset",
+		Owners: []OwnershipStake{
+			{OwnerID: "0000000000000001", Percentage: 0.1},
+			{OwnerID: "0000000000000002", Percentage: 0.01},
+			{OwnerID: "0000000000000003", Percentage: 0.89},
+		},
+	}
+
+	if err := node.checkAntiSpam("test.domain.com", "0000000000000001", BaseTransaction{ID: "a"}); err != nil {
+		t.Fatalf("10%% holder should be admitted, got %v", err)
+	}
+	if err := node.checkAntiSpam("test.domain.com", "0000000000000002", BaseTransaction{ID: "b"}); err == nil {
+		t.Fatal("1% holder should be rejected under a 5% minimum")
+	}
+}
+
+func TestAddTrustTransaction_AntiSpamRejects(t *testing.T) {
+	node := newTestNode()
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{Mode: AntiSpamModePoW, PoWDifficulty: 24})
+
+	_, err := node.AddTrustTransaction(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com"},
+		Truster:         "0000000000000001",
+		Trustee:         "0000000000000002",
+		TrustLevel:      0.5,
+	})
+	if err == nil || !strings.Contains(err.Error(), "proof-of-work") {
+		t.Fatalf("expected anti-spam rejection, got %v", err)
+	}
+}
+
+func TestFilterTransactionsForBlock_AntiSpam(t *testing.T) {
+	node := newTestNode()
+	node.TransactionTrustThreshold = 0.0
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{Mode: AntiSpamModePoW, PoWDifficulty: 8})
+
+	unstamped := TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx-unstamped", TrustDomain: "test.domain.com", PublicKey: node.GetPublicKeyHex()},
+		Truster:         node.NodeQuidID,
+		Trustee:         "0000000000000002",
+	}
+	stamped := unstamped
+	stamped.ID = "tx-stamped"
+	stamped = stampTx(t, stamped, 8)
+
+	filtered := node.FilterTransactionsForBlock([]interface{}{unstamped, stamped}, "test.domain.com")
+	if len(filtered) != 1 {
+		t.Fatalf("expected 1 transaction after filtering, got %d", len(filtered))
+	}
+	if got := filtered[0].(TrustTransaction).ID; got != "tx-stamped" {
+		t.Fatalf("expected stamped tx to survive, got %s", got)
+	}
+}
+
+func TestFilterTransactionsForBlock_TitleStakeIsSigners(t *testing.T) {
+	node := newTestNode()
+	node.TransactionTrustThreshold = 0.0
+	installAntiSpamPolicy(node, "test.domain.com", &AntiSpamPolicy{
+		Mode:               AntiSpamModeStake,
+		StakeAssetID:       "bond-asset",
+		MinStakePercentage: 5,
+	})
+	staked, unstaked := newTestNodeActor(t), newTestNodeActor(t)
+	node.TitleRegistry["bond-asset"] = TitleTransaction{
+		AssetID: "bond-asset",
+		Owners:  []OwnershipStake{{OwnerID: staked.QuidID, Percentage: 1.0}},
+	}
+
+	title := func(id string, signer *testNodeActor) TitleTransaction {
+		return TitleTransaction{
+			BaseTransaction: BaseTransaction{ID: id, TrustDomain: "test.domain.com", PublicKey: signer.PubHex},
+			AssetID:         "asset-" + id,
+			// Naming a staked quid as owner buys nothing.
+			Owners: []OwnershipStake{{OwnerID: staked.QuidID, Percentage: 1.0}},
+		}
+	}
+	filtered := node.FilterTransactionsForBlock([]interface{}{title("by-unstaked", unstaked), title("by-staked", staked)}, "test.domain.com")
+	if len(filtered) != 1 || filtered[0].(TitleTransaction).ID != "by-staked" {
+		t.Fatalf("expected only the staked signer's title, got %+v", filtered)
+	}
+}
+
+func TestRegisterTrustDomain_RejectsInvalidAntiSpam(t *testing.T) {
+	node := newTestNode()
+	node.AllowDomainRegistration = true
+	err := node.RegisterTrustDomain(TrustDomain{
+		Name:     "spam.test.domain.com",
+		AntiSpam: &AntiSpamPolicy{Mode: AntiSpamModeStake},
+	})
+	if err == nil || !strings.Contains(err.Error(), "anti-spam") {
+		t.Fatalf("expected anti-spam validation error, got %v", err)
+	}
+}
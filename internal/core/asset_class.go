@@ -0,0 +1,258 @@
+// Package core — asset classes.
+//
+// A domain that records several kinds of asset ("vehicle",
+// "real-estate", "artwork") usually wants different rules for
+// each: a vehicle's identity must carry a VIN, a parcel may only be
+// titled as "land" or "easement", an artwork has one owner. An
+// AssetClass declares those rules once per domain, and identities
+// and titles opt in by naming the class in their AssetClass field:
+//
+//   - identity: Attributes must hold every RequiredAttributes key
+//   - title:    TitleType must be in AllowedTitleTypes (when set),
+//     and the Transfer rules bound the owner count and how soon
+//     a title may change hands again
+//
+// A title's class is fixed once issued, and a title for an asset
+// whose identity names a class must name the same one.
+//
+// Classes are registered like custom transaction types
+// (custom_tx.go): in-process (RegisterAssetClass) or through an
+// admin-signed POST, and not persisted. Mempool admission requires
+// the class to be registered here; blocks accept a class this node
+// does not know and enforce only the rules of classes it does, so
+// nodes that don't run the application still follow the domain.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrAssetClassInvalid  = errors.New("asset class: invalid definition")
+	ErrAssetClassExists   = errors.New("asset class: already registered")
+	ErrAssetClassNotFound = errors.New("asset class: not registered")
+)
+
+// MaxAssetClassRules bounds each list in an AssetClass.
+const MaxAssetClassRules = 64
+
+// AssetTransferRules constrain titles of a class. Zero values
+// impose nothing.
+type AssetTransferRules struct {
+	// MaxOwners caps the number of stakes on a title.
+	MaxOwners int `json:"maxOwners,omitempty"`
+	// MinHoldSeconds is how long a title must stand before the
+	// next transfer, measured between transaction timestamps.
+	MinHoldSeconds int64 `json:"minHoldSeconds,omitempty"`
+}
+
+// AssetClass declares the rules for one kind of asset in a domain.
+type AssetClass struct {
+	Name        string `json:"name"`
+	Domain      string `json:"domain"`
+	Description string `json:"description,omitempty"`
+	// RequiredAttributes are the Attributes keys every identity
+	// of this class carries.
+	RequiredAttributes []string `json:"requiredAttributes,omitempty"`
+	// AllowedTitleTypes, when non-empty, restricts TitleType on
+	// titles of this class.
+	AllowedTitleTypes []string           `json:"allowedTitleTypes,omitempty"`
+	Transfer          AssetTransferRules `json:"transfer"`
+	RegisteredAt      int64              `json:"registeredAt"`
+}
+
+// AssetClassRegistry holds registered classes by domain and name.
+// Owns its own lock.
+type AssetClassRegistry struct {
+	mu      sync.RWMutex
+	classes map[string]map[string]AssetClass
+}
+
+// NewAssetClassRegistry constructs an empty registry.
+func NewAssetClassRegistry() *AssetClassRegistry {
+	return &AssetClassRegistry{classes: make(map[string]map[string]AssetClass)}
+}
+
+func (r *AssetClassRegistry) register(class AssetClass) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byName := r.classes[class.Domain]
+	if byName == nil {
+		byName = make(map[string]AssetClass)
+		r.classes[class.Domain] = byName
+	}
+	if _, exists := byName[class.Name]; exists {
+		return ErrAssetClassExists
+	}
+	byName[class.Name] = class
+	return nil
+}
+
+func (r *AssetClassRegistry) lookup(domain, name string) (AssetClass, bool) {
+	if r == nil {
+		return AssetClass{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	class, ok := r.classes[domain][name]
+	return class, ok
+}
+
+// list returns the classes of domain ordered by name.
+func (r *AssetClassRegistry) list(domain string) []AssetClass {
+	r.mu.RLock()
+	out := make([]AssetClass, 0, len(r.classes[domain]))
+	for _, class := range r.classes[domain] {
+		out = append(out, class)
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// RegisterAssetClass checks class and makes it available to
+// identities and titles in its domain.
+func (node *QuidnugNode) RegisterAssetClass(class AssetClass) error {
+	if !customTxTypeNamePattern.MatchString(class.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrAssetClassInvalid, customTxTypeNamePattern)
+	}
+	if class.Domain == "" || !ValidateStringField(class.Domain, MaxDomainLength) {
+		return fmt.Errorf("%w: invalid domain %q", ErrAssetClassInvalid, class.Domain)
+	}
+	if !ValidateStringField(class.Description, MaxDescriptionLength) {
+		return fmt.Errorf("%w: description too long or contains control characters", ErrAssetClassInvalid)
+	}
+	for field, list := range map[string][]string{
+		"requiredAttributes": class.RequiredAttributes,
+		"allowedTitleTypes":  class.AllowedTitleTypes,
+	} {
+		if len(list) > MaxAssetClassRules {
+			return fmt.Errorf("%w: %s has more than %d entries", ErrAssetClassInvalid, field, MaxAssetClassRules)
+		}
+		for _, v := range list {
+			if v == "" || !ValidateStringField(v, MaxNameLength) {
+				return fmt.Errorf("%w: invalid %s entry %q", ErrAssetClassInvalid, field, v)
+			}
+		}
+	}
+	if class.Transfer.MaxOwners < 0 || class.Transfer.MinHoldSeconds < 0 {
+		return fmt.Errorf("%w: transfer rules must not be negative", ErrAssetClassInvalid)
+	}
+	if class.RegisteredAt == 0 {
+		class.RegisteredAt = nowUnix()
+	}
+
+	if err := node.AssetClasses.register(class); err != nil {
+		return err
+	}
+	logger.Info("Registered asset class", "domain", class.Domain, "name", class.Name)
+	return nil
+}
+
+// AssetClassRequest is the admin-signed body for registering an
+// asset class over HTTP. Signature covers the JSON encoding of the
+// request with Signature empty.
+type AssetClassRequest struct {
+	Class     AssetClass `json:"class"`
+	Timestamp int64      `json:"timestamp"`
+	PublicKey string     `json:"publicKey"`
+	Signature string     `json:"signature"`
+}
+
+// RegisterAssetClassSigned verifies an admin-signed request and
+// registers its class.
+func (node *QuidnugNode) RegisterAssetClassSigned(req AssetClassRequest) error {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return err
+	}
+	return node.RegisterAssetClass(req.Class)
+}
+
+// GetAssetClass returns a registered class.
+func (node *QuidnugNode) GetAssetClass(domain, name string) (AssetClass, bool) {
+	return node.AssetClasses.lookup(domain, name)
+}
+
+// ListAssetClasses returns the classes registered for domain.
+func (node *QuidnugNode) ListAssetClasses(domain string) []AssetClass {
+	return node.AssetClasses.list(domain)
+}
+
+// requireAssetClass is the mempool-only check that a named class is
+// registered here.
+func (node *QuidnugNode) requireAssetClass(domain, name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := node.AssetClasses.lookup(domain, name); !ok {
+		return fmt.Errorf("%w: %q in domain %q", ErrAssetClassNotFound, name, domain)
+	}
+	return nil
+}
+
+// checkIdentityAssetClass enforces the class rules on an identity.
+// existing is the current record when tx is an update.
+func (node *QuidnugNode) checkIdentityAssetClass(tx IdentityTransaction, existing *IdentityTransaction) error {
+	if existing != nil && tx.AssetClass != existing.AssetClass {
+		return fmt.Errorf("asset class cannot change from %q to %q", existing.AssetClass, tx.AssetClass)
+	}
+	if tx.AssetClass != "" && !ValidateStringField(tx.AssetClass, MaxNameLength) {
+		return errors.New("asset class too long or contains control characters")
+	}
+	class, ok := node.AssetClasses.lookup(tx.TrustDomain, tx.AssetClass)
+	if !ok {
+		return nil
+	}
+	for _, key := range class.RequiredAttributes {
+		if _, present := tx.Attributes[key]; !present {
+			return fmt.Errorf("asset class %q requires attribute %q", class.Name, key)
+		}
+	}
+	return nil
+}
+
+// checkTitleAssetClass enforces the class rules on a title. current
+// is the title it replaces, if any.
+func (node *QuidnugNode) checkTitleAssetClass(tx TitleTransaction, current *TitleTransaction) error {
+	if tx.AssetClass != "" && !ValidateStringField(tx.AssetClass, MaxNameLength) {
+		return errors.New("asset class too long or contains control characters")
+	}
+	if current != nil && tx.AssetClass != current.AssetClass {
+		return fmt.Errorf("asset class cannot change from %q to %q", current.AssetClass, tx.AssetClass)
+	}
+	node.IdentityRegistryMutex.RLock()
+	assetIdentity, isQuid := node.IdentityRegistry[tx.AssetID]
+	node.IdentityRegistryMutex.RUnlock()
+	if isQuid && assetIdentity.AssetClass != "" && tx.AssetClass != assetIdentity.AssetClass {
+		return fmt.Errorf("asset %s is of class %q, title names %q", tx.AssetID, assetIdentity.AssetClass, tx.AssetClass)
+	}
+
+	class, ok := node.AssetClasses.lookup(tx.TrustDomain, tx.AssetClass)
+	if !ok {
+		return nil
+	}
+	if len(class.AllowedTitleTypes) > 0 {
+		allowed := false
+		for _, t := range class.AllowedTitleTypes {
+			if tx.TitleType == t {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("asset class %q does not allow title type %q", class.Name, tx.TitleType)
+		}
+	}
+	if limit := class.Transfer.MaxOwners; limit > 0 && len(tx.Owners) > limit {
+		return fmt.Errorf("asset class %q allows at most %d owners, title has %d", class.Name, limit, len(tx.Owners))
+	}
+	if hold := class.Transfer.MinHoldSeconds; hold > 0 && current != nil && tx.Timestamp-current.Timestamp < hold {
+		return fmt.Errorf("asset class %q requires holding a title %ds before transfer", class.Name, hold)
+	}
+	return nil
+}
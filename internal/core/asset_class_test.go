@@ -0,0 +1,175 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newAssetClassNode(t *testing.T) *QuidnugNode {
+	t.Helper()
+	node := newTestNode()
+	if err := node.RegisterAssetClass(AssetClass{
+		Name:               "vehicle",
+		Domain:             "test.domain.com",
+		RequiredAttributes: []string{"vin"},
+		AllowedTitleTypes:  []string{"registration", "lien-free"},
+		Transfer:           AssetTransferRules{MaxOwners: 2, MinHoldSeconds: 3600},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func (a *testNodeActor) signIdentity(tx IdentityTransaction) IdentityTransaction {
+	tx.Type = TxTypeIdentity
+	tx.TrustDomain = "test.domain.com"
+	tx.QuidID, tx.Creator, tx.PublicKey = a.QuidID, a.QuidID, a.PubHex
+	tx.Signature = ""
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+func TestAssetClass_IdentityAttributes(t *testing.T) {
+	node := newAssetClassNode(t)
+	car := newTestNodeActor(t)
+
+	missing := car.signIdentity(IdentityTransaction{Name: "car", AssetClass: "vehicle", UpdateNonce: 1})
+	if node.ValidateIdentityTransaction(missing) {
+		t.Error("vehicle without a vin accepted")
+	}
+	ok := car.signIdentity(IdentityTransaction{
+		Name: "car", AssetClass: "vehicle", UpdateNonce: 1,
+		Attributes: map[string]interface{}{"vin": "1HGCM82633A004352"},
+	})
+	if !node.ValidateIdentityTransaction(ok) {
+		t.Fatal("vehicle with a vin rejected")
+	}
+	node.IdentityRegistry[car.QuidID] = ok
+
+	reclassed := car.signIdentity(IdentityTransaction{Name: "car", UpdateNonce: 2})
+	if node.ValidateIdentityTransaction(reclassed) {
+		t.Error("identity update dropped its asset class")
+	}
+}
+
+func TestAssetClass_TitleRules(t *testing.T) {
+	node := newAssetClassNode(t)
+	owners := []*testNodeActor{newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)}
+	for _, o := range owners {
+		node.IdentityRegistry[o.QuidID] = IdentityTransaction{BaseTransaction: BaseTransaction{PublicKey: o.PubHex}, QuidID: o.QuidID}
+	}
+	issue := func(titleType string, n int) TitleTransaction {
+		tx := TitleTransaction{
+			BaseTransaction: BaseTransaction{Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: time.Now().Unix(), PublicKey: owners[0].PubHex},
+			AssetID:         "car-1",
+			AssetClass:      "vehicle",
+			TitleType:       titleType,
+		}
+		for _, o := range owners[:n] {
+			tx.Owners = append(tx.Owners, OwnershipStake{OwnerID: o.QuidID, Percentage: 1 / float64(n)})
+		}
+		signable, _ := json.Marshal(tx)
+		tx.Signature = signIEEE1363(owners[0].Priv, signable)
+		return tx
+	}
+
+	if node.ValidateTitleTransaction(issue("deed", 1)) {
+		t.Error("disallowed title type accepted")
+	}
+	if node.ValidateTitleTransaction(issue("registration", 3)) {
+		t.Error("three owners accepted under a two-owner cap")
+	}
+	first := issue("registration", 1)
+	if !node.ValidateTitleTransaction(first) {
+		t.Fatal("conforming title rejected")
+	}
+	node.updateTitleRegistry(first)
+
+	// Too soon after issue, even with a valid transfer signature.
+	transfer := TitleTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: first.Timestamp + 60},
+		AssetID:         "car-1",
+		AssetClass:      "vehicle",
+		TitleType:       "registration",
+		Owners:          []OwnershipStake{{OwnerID: owners[1].QuidID, Percentage: 1}},
+	}
+	if err := node.checkTitleAssetClass(transfer, &first); err == nil {
+		t.Error("transfer inside the holding period allowed")
+	}
+	transfer.Timestamp = first.Timestamp + 3600
+	if err := node.checkTitleAssetClass(transfer, &first); err != nil {
+		t.Errorf("transfer after the holding period: %v", err)
+	}
+	transfer.AssetClass = ""
+	if err := node.checkTitleAssetClass(transfer, &first); err == nil {
+		t.Error("transfer dropped the asset class")
+	}
+}
+
+func TestAssetClass_UnknownClassMempoolOnly(t *testing.T) {
+	node := newAssetClassNode(t)
+	car := newTestNodeActor(t)
+	tx := car.signIdentity(IdentityTransaction{Name: "boat", AssetClass: "vessel", UpdateNonce: 1})
+
+	if _, err := node.AddIdentityTransaction(tx); err == nil {
+		t.Error("mempool admitted an unregistered asset class")
+	}
+	if !node.ValidateIdentityTransaction(tx) {
+		t.Error("block path rejected a class this node does not know")
+	}
+}
+
+func TestAssetClassHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	req := AssetClassRequest{
+		Class:     AssetClass{Name: "artwork", Domain: "test.domain.com", Transfer: AssetTransferRules{MaxOwners: 1}},
+		Timestamp: time.Now().Unix(),
+		PublicKey: node.GetPublicKeyHex(),
+	}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	body, _ := json.Marshal(req)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/domains/other.domain/asset-classes", bytes.NewReader(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("mismatched domain: expected 400, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/domains/test.domain.com/asset-classes", bytes.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("registration: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/domains/test.domain.com/asset-classes", bytes.NewReader(body)))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("duplicate: expected 409, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/domains/test.domain.com/asset-classes/artwork", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Data AssetClass `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Transfer.MaxOwners != 1 || resp.Data.RegisteredAt == 0 {
+		t.Errorf("class = %+v", resp.Data)
+	}
+}
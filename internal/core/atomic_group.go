@@ -0,0 +1,585 @@
+// Atomic transaction groups.
+//
+// Some writes only make sense together: a new identity and the title
+// issued to it, say. Submitted one at a time, the title is refused
+// until a block carrying the identity commits, and a client that
+// wants both or neither is left to clean up when the second fails.
+//
+// A transaction joins a group by carrying an AtomicGroupRef naming
+// the group and its size. The reference is signed with the rest of
+// the transaction, so a member can't be lifted out of its group and
+// sealed alone. Groups of trust, identity, title and event
+// transactions are submitted whole, to POST /transactions/atomic-group:
+//
+//   - admission checks every member, counting the identities the
+//     group creates as registered for its titles and events, and
+//     pools all of them or none;
+//   - peers are sent the group as one request rather than member by
+//     member, and mempool sync leaves group members out;
+//   - GenerateBlock seals a group whole or holds it for a later
+//     block, never splitting it across blocks; a group the trust
+//     filter breaks up is dropped whole;
+//   - ValidateBlockTiered rejects a block carrying part of a group,
+//     and a group is only valid if every member is.
+//
+// An accepted block is applied in full, so a group's members take
+// effect together.
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/ratelimit"
+)
+
+// Bounds on the number of members in an atomic group.
+const (
+	MinAtomicGroupSize = 2
+	MaxAtomicGroupSize = 64
+)
+
+// AtomicGroupRef names the group a transaction belongs to and how
+// many members it has.
+type AtomicGroupRef struct {
+	ID   string `json:"id"`
+	Size int    `json:"size"`
+}
+
+// atomicGroupTxTypes are the transaction types a group may hold.
+var atomicGroupTxTypes = map[TransactionType]bool{
+	TxTypeTrust:    true,
+	TxTypeIdentity: true,
+	TxTypeTitle:    true,
+	TxTypeEvent:    true,
+}
+
+// AtomicGroupRequest is the body of POST /transactions/atomic-group:
+// every member of one group, each tagged with the type segment of its
+// /transactions/{type} route.
+type AtomicGroupRequest struct {
+	Transactions []MempoolTx `json:"transactions"`
+}
+
+// txAtomicGroup returns the group tx belongs to, or nil.
+func txAtomicGroup(tx interface{}) *AtomicGroupRef {
+	base, _, ok := txGossipRoute(tx)
+	if !ok {
+		return nil
+	}
+	return base.AtomicGroup
+}
+
+// loneGroupMember refuses a group member submitted on its own.
+func loneGroupMember(base BaseTransaction) error {
+	if base.AtomicGroup == nil {
+		return nil
+	}
+	return reject(RejectInvalidField, "atomicGroup",
+		"transaction belongs to atomic group %q; submit the whole group to /transactions/atomic-group", base.AtomicGroup.ID)
+}
+
+// atomicGroupShape checks that members agree on their group and
+// reports whether all of its members are there. An error means the
+// members can never make a valid group.
+func atomicGroupShape(members []BaseTransaction) (complete bool, err error) {
+	if len(members) == 0 || members[0].AtomicGroup == nil {
+		return false, fmt.Errorf("not an atomic group")
+	}
+	ref := *members[0].AtomicGroup
+	if ref.ID == "" || !ValidateStringField(ref.ID, MaxNameLength) {
+		return false, fmt.Errorf("group id is empty, too long or contains control characters")
+	}
+	if ref.Size < MinAtomicGroupSize || ref.Size > MaxAtomicGroupSize {
+		return false, fmt.Errorf("group size %d is outside %d-%d", ref.Size, MinAtomicGroupSize, MaxAtomicGroupSize)
+	}
+	ids := make(map[string]bool, len(members))
+	for _, m := range members {
+		switch {
+		case m.AtomicGroup == nil || *m.AtomicGroup != ref:
+			return false, fmt.Errorf("member %s disagrees on the group", m.ID)
+		case m.TrustDomain != members[0].TrustDomain:
+			return false, fmt.Errorf("members span trust domains %q and %q", members[0].TrustDomain, m.TrustDomain)
+		case !atomicGroupTxTypes[m.Type]:
+			return false, fmt.Errorf("%s transactions cannot join an atomic group", m.Type)
+		case m.ID == "":
+			return false, fmt.Errorf("members must carry an id")
+		case ids[m.ID]:
+			return false, fmt.Errorf("transaction %s appears twice", m.ID)
+		}
+		ids[m.ID] = true
+	}
+	if len(members) > ref.Size {
+		return false, fmt.Errorf("group %q has %d members, declared size %d", ref.ID, len(members), ref.Size)
+	}
+	return len(members) == ref.Size, nil
+}
+
+// groupIdentities returns the identities created by members of txs.
+func groupIdentities(txs []interface{}) map[string]IdentityTransaction {
+	out := make(map[string]IdentityTransaction)
+	for _, tx := range txs {
+		if id, ok := tx.(IdentityTransaction); ok {
+			out[id.QuidID] = id
+		}
+	}
+	return out
+}
+
+// blockAtomicGroups collects the atomic groups in a received block.
+type blockAtomicGroups struct {
+	order   []string
+	members map[string][]BaseTransaction
+	idents  map[string]map[string]IdentityTransaction
+}
+
+func newBlockAtomicGroups() *blockAtomicGroups {
+	return &blockAtomicGroups{
+		members: make(map[string][]BaseTransaction),
+		idents:  make(map[string]map[string]IdentityTransaction),
+	}
+}
+
+// add records base if it belongs to a group.
+func (g *blockAtomicGroups) add(base BaseTransaction) {
+	if base.AtomicGroup == nil {
+		return
+	}
+	id := base.AtomicGroup.ID
+	if _, seen := g.members[id]; !seen {
+		g.order = append(g.order, id)
+	}
+	g.members[id] = append(g.members[id], base)
+}
+
+// addIdentity records an identity its group creates.
+func (g *blockAtomicGroups) addIdentity(tx IdentityTransaction) {
+	if tx.AtomicGroup == nil {
+		return
+	}
+	id := tx.AtomicGroup.ID
+	if g.idents[id] == nil {
+		g.idents[id] = make(map[string]IdentityTransaction)
+	}
+	g.idents[id][tx.QuidID] = tx
+}
+
+// identities returns the identities created by base's group, nil for
+// a transaction outside any group.
+func (g *blockAtomicGroups) identities(base BaseTransaction) map[string]IdentityTransaction {
+	if base.AtomicGroup == nil {
+		return nil
+	}
+	return g.idents[base.AtomicGroup.ID]
+}
+
+// check reports the first group that is malformed or not wholly in
+// the block.
+func (g *blockAtomicGroups) check() error {
+	for _, id := range g.order {
+		members := g.members[id]
+		complete, err := atomicGroupShape(members)
+		if err != nil {
+			return fmt.Errorf("atomic group %q: %w", id, err)
+		}
+		if !complete {
+			return fmt.Errorf("atomic group %q: block carries %d of %d members",
+				id, len(members), members[0].AtomicGroup.Size)
+		}
+	}
+	return nil
+}
+
+// AddAtomicGroup admits every transaction of one atomic group to the
+// pending pool, or none of them. It returns the members' IDs.
+func (node *QuidnugNode) AddAtomicGroup(txs []interface{}) ([]string, error) {
+	return node.addAtomicGroup(context.Background(), txs)
+}
+
+// addAtomicGroup is AddAtomicGroup logging under the request ctx
+// carries.
+func (node *QuidnugNode) addAtomicGroup(ctx context.Context, txs []interface{}) ([]string, error) {
+	bases := make([]BaseTransaction, 0, len(txs))
+	ids := make([]string, 0, len(txs))
+	quids := make(map[string]bool)
+	for _, tx := range txs {
+		base, _, ok := txGossipRoute(tx)
+		if !ok {
+			return nil, reject(RejectInvalidField, "transactions", "unsupported transaction %T", tx)
+		}
+		if id, isIdentity := tx.(IdentityTransaction); isIdentity {
+			if quids[id.QuidID] {
+				return nil, reject(RejectInvalidField, "transactions", "group defines identity %s twice", id.QuidID)
+			}
+			quids[id.QuidID] = true
+		}
+		bases = append(bases, base)
+		ids = append(ids, base.ID)
+	}
+	complete, err := atomicGroupShape(bases)
+	if err == nil && !complete {
+		err = fmt.Errorf("group has %d members, declared size %d", len(bases), bases[0].AtomicGroup.Size)
+	}
+	if err != nil {
+		return nil, rejectErr(RejectInvalidField, "atomicGroup", "invalid atomic group", err)
+	}
+	ref := *bases[0].AtomicGroup
+	groupLog := loggerFrom(ctx).With("atomicGroup", ref.ID)
+	_, kind, _ := txGossipRoute(txs[0])
+	if err := node.admitBlockFitOrReject(kind, bases[0].TrustDomain, txs...); err != nil {
+		return nil, err
+	}
+
+	// Peers echo the group back; it is already pooled.
+	node.PendingTxsMutex.RLock()
+	pooled := node.atomicGroupPendingLocked(ref.ID)
+	node.PendingTxsMutex.RUnlock()
+	if pooled {
+		return ids, nil
+	}
+
+	identities := groupIdentities(txs)
+	for _, tx := range txs {
+		if err := node.admitGroupMember(tx, identities); err != nil {
+			groupLog.Info("Rejected atomic group", "txId", canonicalTxKey(tx).id, "error", err)
+			return nil, err
+		}
+	}
+
+	node.PendingTxsMutex.Lock()
+	if node.atomicGroupPendingLocked(ref.ID) {
+		node.PendingTxsMutex.Unlock()
+		return ids, nil
+	}
+	for _, tx := range txs {
+		id, ok := tx.(IdentityTransaction)
+		if !ok {
+			continue
+		}
+		if kept, conflict := node.pendingIdentityConflict(id); conflict {
+			node.PendingTxsMutex.Unlock()
+			node.recordIdentityConflict(kept, id, IdentityConflictStageMempool, nil)
+			return nil, fmt.Errorf("identity update nonce %d for %s already claimed by pending transaction %s",
+				id.UpdateNonce, id.QuidID, kept.ID)
+		}
+	}
+	node.PendingTxs = append(node.PendingTxs, txs...)
+	for _, tx := range txs {
+		if t, ok := tx.(TrustTransaction); ok && node.NonceLedger != nil {
+			node.NonceLedger.ReserveTentative(NonceKey{Quid: t.Truster, Domain: t.TrustDomain, Epoch: 0}, t.Nonce)
+		}
+	}
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+	node.PendingTxsMutex.Unlock()
+
+	for _, tx := range txs {
+		_, kind, _ := txGossipRoute(tx)
+		RecordTransactionProcessed(kind, true)
+	}
+	go node.broadcastAtomicGroup(ctx, pendingTxDomain(bases[0]), txs)
+
+	groupLog.Info("Added atomic group to pending pool", "members", len(txs), "domain", bases[0].TrustDomain)
+	return ids, nil
+}
+
+// atomicGroupPendingLocked reports whether any member of the group is
+// in the pending pool. Caller holds PendingTxsMutex.
+func (node *QuidnugNode) atomicGroupPendingLocked(groupID string) bool {
+	for _, tx := range node.PendingTxs {
+		if ref := txAtomicGroup(tx); ref != nil && ref.ID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// admitGroupMember runs tx through the admission checks of its type,
+// with identities standing in for registered identities. It pools
+// nothing.
+func (node *QuidnugNode) admitGroupMember(tx interface{}, identities map[string]IdentityTransaction) error {
+	switch t := tx.(type) {
+	case TrustTransaction:
+		if err := node.admitWriteOrReject(ratelimit.ActorKeys{Quid: t.Truster, Domain: t.TrustDomain}); err != nil {
+			RecordTransactionProcessed("trust", false)
+			return err
+		}
+		if err := node.admitAntiSpamOrReject("trust", t.TrustDomain, t.Truster, t); err != nil {
+			return err
+		}
+		if err := node.checkTrustTransaction(t); err != nil {
+			RecordTransactionProcessed("trust", false)
+			return fmt.Errorf("invalid trust transaction: %w", err)
+		}
+		if node.NonceLedger != nil {
+			key := NonceKey{Quid: t.Truster, Domain: t.TrustDomain, Epoch: 0}
+			if err := node.NonceLedger.Admit(key, t.Nonce); err != nil {
+				nonceReplayRejections.WithLabelValues(nonceRejectionReason(err), fmt.Sprintf("%t", node.NonceLedgerEnforce)).Inc()
+				if node.NonceLedgerEnforce {
+					RecordTransactionProcessed("trust", false)
+					return rejectErr(RejectNonceReplay, "nonce", "nonce ledger rejected transaction", err)
+				}
+			}
+		}
+
+	case IdentityTransaction:
+		if err := node.admitAntiSpamOrReject("identity", t.TrustDomain, t.Creator, t); err != nil {
+			return err
+		}
+		if err := node.requireAssetClass(t.TrustDomain, t.AssetClass); err != nil {
+			RecordTransactionProcessed("identity", false)
+			return err
+		}
+		if err := node.checkIdentityTransaction(t); err != nil {
+			RecordTransactionProcessed("identity", false)
+			return fmt.Errorf("invalid identity transaction: %w", err)
+		}
+
+	case TitleTransaction:
+		if err := node.admitAntiSpamOrReject("title", t.TrustDomain, titleStakeQuid(t), t); err != nil {
+			return err
+		}
+		if err := node.requireAssetClass(t.TrustDomain, t.AssetClass); err != nil {
+			RecordTransactionProcessed("title", false)
+			return err
+		}
+		if err := node.checkTitleTransactionIn(t, identities); err != nil {
+			RecordTransactionProcessed("title", false)
+			return fmt.Errorf("invalid title transaction: %w", err)
+		}
+
+	case EventTransaction:
+		signerQuid := ""
+		if t.PublicKey != "" {
+			signerQuid = QuidIDFromPublicKeyHex(t.PublicKey)
+		}
+		if err := node.admitWriteOrReject(ratelimit.ActorKeys{Quid: signerQuid, Domain: t.TrustDomain}); err != nil {
+			RecordTransactionProcessed("event", false)
+			return err
+		}
+		if err := node.admitAntiSpamOrReject("event", t.TrustDomain, signerQuid, t); err != nil {
+			return err
+		}
+		if err := node.checkEventTransactionIn(t, identities); err != nil {
+			RecordTransactionProcessed("event", false)
+			return fmt.Errorf("invalid event transaction: %w", err)
+		}
+
+	default:
+		return reject(RejectInvalidField, "transactions", "%T cannot join an atomic group", tx)
+	}
+	return nil
+}
+
+// decodeAtomicGroup decodes the members of req.
+func decodeAtomicGroup(req AtomicGroupRequest) ([]interface{}, error) {
+	txs := make([]interface{}, 0, len(req.Transactions))
+	for i, m := range req.Transactions {
+		var tx interface{}
+		var err error
+		switch m.Type {
+		case "trust":
+			tx, err = decodeGroupMember[TrustTransaction](m.Tx)
+		case "identity":
+			tx, err = decodeGroupMember[IdentityTransaction](m.Tx)
+		case "title":
+			tx, err = decodeGroupMember[TitleTransaction](m.Tx)
+		case "event":
+			tx, err = decodeGroupMember[EventTransaction](m.Tx)
+		default:
+			err = fmt.Errorf("type %q cannot join an atomic group", m.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transactions[%d]: %w", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// decodeGroupMember decodes raw as T.
+func decodeGroupMember[T any](raw json.RawMessage) (interface{}, error) {
+	var tx T
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return tx, nil
+}
+
+// encodeAtomicGroup is the inverse of decodeAtomicGroup.
+func encodeAtomicGroup(txs []interface{}) (AtomicGroupRequest, error) {
+	req := AtomicGroupRequest{Transactions: make([]MempoolTx, 0, len(txs))}
+	for _, tx := range txs {
+		_, kind, _ := txGossipRoute(tx)
+		raw, err := json.Marshal(tx)
+		if err != nil {
+			return req, err
+		}
+		req.Transactions = append(req.Transactions, MempoolTx{Type: kind, Tx: raw})
+	}
+	return req, nil
+}
+
+// broadcastAtomicGroup sends the group to the domain's validators in
+// one request. Inventory gossip carries single transactions, which a
+// peer could not admit one at a time.
+func (node *QuidnugNode) broadcastAtomicGroup(ctx context.Context, domain string, txs []interface{}) {
+	log := loggerFrom(ctx)
+	req, err := encodeAtomicGroup(txs)
+	if err != nil {
+		log.Error("Failed to encode atomic group for broadcast", "error", err)
+		return
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		log.Error("Failed to marshal atomic group for broadcast", "error", err)
+		return
+	}
+	if !node.markBroadcast(sha256.Sum256(body), time.Now()) {
+		return
+	}
+	bctx := withLogger(detachLog(node.lifetime(), ctx), log)
+	for _, peer := range node.inventoryPeers(domain) {
+		go node.broadcastToNode(bctx, peer, "atomic-group", body)
+	}
+}
+
+// completeAtomicGroups sorts out the atomic groups among a domain's
+// pending transactions (pending) before a block is filled from the
+// ones the trust filter kept (kept, with creator trust alongside).
+// Only whole groups stay in kept. Groups still waiting on members
+// are returned as held, to stay pending; groups that are malformed
+// or lost a member to the filter are dropped.
+func (node *QuidnugNode) completeAtomicGroups(pending, kept []interface{}, trust []float64) ([]interface{}, []float64, []interface{}) {
+	var order []string
+	members := make(map[string][]interface{})
+	for _, tx := range pending {
+		if ref := txAtomicGroup(tx); ref != nil {
+			if _, seen := members[ref.ID]; !seen {
+				order = append(order, ref.ID)
+			}
+			members[ref.ID] = append(members[ref.ID], tx)
+		}
+	}
+	if len(order) == 0 {
+		return kept, trust, nil
+	}
+	keptCount := make(map[string]int)
+	for _, tx := range kept {
+		if ref := txAtomicGroup(tx); ref != nil {
+			keptCount[ref.ID]++
+		}
+	}
+
+	sealable := make(map[string]bool)
+	var held []interface{}
+	for _, id := range order {
+		bases := make([]BaseTransaction, 0, len(members[id]))
+		for _, tx := range members[id] {
+			base, _, _ := txGossipRoute(tx)
+			bases = append(bases, base)
+		}
+		complete, err := atomicGroupShape(bases)
+		switch {
+		case err != nil:
+			logger.Warn("Dropping malformed atomic group", "atomicGroup", id, "error", err)
+		case !complete:
+			held = append(held, members[id]...)
+		case keptCount[id] < len(bases):
+			logger.Info("Dropping atomic group with a member filtered from the block",
+				"atomicGroup", id, "kept", keptCount[id], "size", len(bases))
+		default:
+			sealable[id] = true
+		}
+	}
+
+	outTxs := make([]interface{}, 0, len(kept))
+	outTrust := make([]float64, 0, len(trust))
+	for i, tx := range kept {
+		if ref := txAtomicGroup(tx); ref != nil && !sealable[ref.ID] {
+			continue
+		}
+		outTxs = append(outTxs, tx)
+		if i < len(trust) {
+			outTrust = append(outTrust, trust[i])
+		}
+	}
+	return outTxs, outTrust, held
+}
+
+// clusterAtomicGroups moves each group's members up to its highest
+// ranked member, so that ranked txs divide into whole units.
+func clusterAtomicGroups(txs []interface{}) []interface{} {
+	members := make(map[string][]interface{})
+	for _, tx := range txs {
+		if ref := txAtomicGroup(tx); ref != nil {
+			members[ref.ID] = append(members[ref.ID], tx)
+		}
+	}
+	if len(members) == 0 {
+		return txs
+	}
+	out := make([]interface{}, 0, len(txs))
+	placed := make(map[string]bool, len(members))
+	for _, tx := range txs {
+		ref := txAtomicGroup(tx)
+		if ref == nil {
+			out = append(out, tx)
+			continue
+		}
+		if !placed[ref.ID] {
+			placed[ref.ID] = true
+			out = append(out, members[ref.ID]...)
+		}
+	}
+	return out
+}
+
+// atomicUnits divides clustered txs into the units a block takes
+// whole: one transaction, or one atomic group.
+func atomicUnits(txs []interface{}) [][]interface{} {
+	var units [][]interface{}
+	for i := 0; i < len(txs); {
+		j := i + 1
+		if ref := txAtomicGroup(txs[i]); ref != nil {
+			for j < len(txs) {
+				next := txAtomicGroup(txs[j])
+				if next == nil || next.ID != ref.ID {
+					break
+				}
+				j++
+			}
+		}
+		units = append(units, txs[i:j])
+		i = j
+	}
+	return units
+}
+
+// CreateAtomicGroupHandler admits an atomic transaction group.
+func (node *QuidnugNode) CreateAtomicGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var req AtomicGroupRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	txs, err := decodeAtomicGroup(req)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	txIDs, err := node.addAtomicGroup(r.Context(), txs)
+	if err != nil {
+		writeTxRejection(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"status":          "success",
+		"transaction_ids": txIDs,
+		"message":         "Atomic group added to pending pool",
+	})
+}
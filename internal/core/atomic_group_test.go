@@ -0,0 +1,144 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// atomicTestGroup builds a signed group creating a new identity and
+// issuing it a title.
+func atomicTestGroup(node *QuidnugNode, groupID string) (IdentityTransaction, TitleTransaction) {
+	ref := &AtomicGroupRef{ID: groupID, Size: 2}
+	identity := signIdentityTx(node, IdentityTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          groupID + "-identity",
+			Type:        TxTypeIdentity,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+			AtomicGroup: ref,
+		},
+		QuidID:      "00000000000000aa",
+		Name:        "Holder",
+		Creator:     node.NodeID,
+		UpdateNonce: 1,
+	})
+	title := signTitleTx(node, TitleTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          groupID + "-title",
+			Type:        TxTypeTitle,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+			AtomicGroup: ref,
+		},
+		AssetID: groupID + "-asset",
+		Owners:  []OwnershipStake{{OwnerID: "00000000000000aa", Percentage: 1.0}},
+	})
+	return identity, title
+}
+
+func TestAddAtomicGroup_TitleRestsOnGroupIdentity(t *testing.T) {
+	node := newTestNode()
+	identity, title := atomicTestGroup(node, "g1")
+
+	if _, err := node.AddTitleTransaction(title); err == nil {
+		t.Fatal("a group member submitted alone should be refused")
+	}
+
+	ids, err := node.AddAtomicGroup([]interface{}{identity, title})
+	if err != nil {
+		t.Fatalf("AddAtomicGroup: %v", err)
+	}
+	if len(ids) != 2 || len(node.PendingTxs) != 2 {
+		t.Fatalf("expected both members pending, got ids %v and %d pending", ids, len(node.PendingTxs))
+	}
+
+	// A peer echoing the group back doesn't pool it twice.
+	if _, err := node.AddAtomicGroup([]interface{}{identity, title}); err != nil {
+		t.Fatalf("resubmission: %v", err)
+	}
+	if len(node.PendingTxs) != 2 {
+		t.Fatalf("resubmission pooled the group again: %d pending", len(node.PendingTxs))
+	}
+}
+
+func TestAddAtomicGroup_AllOrNothing(t *testing.T) {
+	node := newTestNode()
+	identity, title := atomicTestGroup(node, "g1")
+	title.Owners[0].Percentage = 0.5
+	title = signTitleTx(node, title)
+
+	if _, err := node.AddAtomicGroup([]interface{}{identity, title}); err == nil {
+		t.Fatal("a group with an invalid member should be rejected")
+	}
+	if len(node.PendingTxs) != 0 {
+		t.Fatalf("no member should be pooled, got %d", len(node.PendingTxs))
+	}
+
+	_, title = atomicTestGroup(node, "g2")
+	if _, err := node.AddAtomicGroup([]interface{}{title}); err == nil {
+		t.Fatal("an incomplete group should be rejected")
+	}
+}
+
+func TestGenerateBlock_KeepsAtomicGroupsWhole(t *testing.T) {
+	node := newTestNode()
+	node.MempoolTypePriorities = mempoolTypePriorities(map[string]float64{"trust": 5})
+	node.BlockMaxTransactions = 2
+	identity, title := atomicTestGroup(node, "g1")
+	node.PendingTxs = []interface{}{orderTestTrust(node, "t-1", 1), identity, title}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 || canonicalTxKey(block.Transactions[0]).id != "t-1" {
+		t.Fatalf("expected the group to spill whole, got %+v", block.Transactions)
+	}
+
+	block, err = node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 2 {
+		t.Fatalf("expected the whole group in the next block, got %d transactions", len(block.Transactions))
+	}
+}
+
+func TestGenerateBlock_HoldsIncompleteAtomicGroup(t *testing.T) {
+	node := newTestNode()
+	identity, _ := atomicTestGroup(node, "g1")
+	node.PendingTxs = []interface{}{orderTestTrust(node, "t-1", 1), identity}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 || canonicalTxKey(block.Transactions[0]).id != "t-1" {
+		t.Fatalf("expected only the trust transaction, got %+v", block.Transactions)
+	}
+	if len(node.PendingTxs) != 1 || canonicalTxKey(node.PendingTxs[0]).id != "g1-identity" {
+		t.Fatalf("expected the group member to stay pending, got %+v", node.PendingTxs)
+	}
+}
+
+func TestValidateBlockTiered_AtomicGroups(t *testing.T) {
+	node := newTestNode()
+	domain := node.TrustDomains["test.domain.com"]
+	domain.ValidatorPublicKeys = map[string]string{node.NodeID: node.GetPublicKeyHex()}
+	node.TrustDomains["test.domain.com"] = domain
+	identity, title := atomicTestGroup(node, "g1")
+
+	node.PendingTxs = []interface{}{identity, title}
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if got := node.ValidateBlockTiered(*block); got != BlockTrusted {
+		t.Fatalf("whole group: expected BlockTrusted, got %v", got)
+	}
+
+	partial := node.sealBlock(node.Blockchain[0], "test.domain.com", 1.0, []interface{}{identity})
+	if got := node.ValidateBlockTiered(partial); got != BlockInvalid {
+		t.Fatalf("partial group: expected BlockInvalid, got %v", got)
+	}
+}
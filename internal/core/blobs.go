@@ -0,0 +1,188 @@
+// Attachment blobs and their garbage collection.
+//
+// With blob_store_enabled, clients upload files to POST /blobs and
+// get back a "sha256:<hex>" digest to put in a transaction: a
+// document-hash TrustEvidence value, an identity attribute such as
+// "avatar", a title's document reference. The node keeps a blob for
+// as long as something references it. A reference is any occurrence
+// of the digest string in a transaction on the chain, in a tentative
+// block or in the pending pool, wherever it sits in the JSON, so new
+// transaction fields need no GC changes.
+//
+// Uploads are not themselves on-chain, so the collector gives each
+// blob blob_gc_grace to become referenced before deleting it. A pass
+// that cannot read every block (an archived block whose archive is
+// unreachable) deletes nothing, because it cannot tell which blobs
+// that block references.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/blobstore"
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/ipfsclient"
+)
+
+// DefaultBlobGCInterval is how often the blob collector runs.
+const DefaultBlobGCInterval = time.Hour
+
+// ErrBlobStoreDisabled means a blob operation was requested on a node
+// without blob_store_enabled.
+var ErrBlobStoreDisabled = errors.New("blobs: blob store is disabled on this node")
+
+// BlobGCReport summarizes one collection pass.
+type BlobGCReport struct {
+	Blobs      int `json:"blobs"`
+	Referenced int `json:"referenced"`
+	InGrace    int `json:"inGrace"`
+	Deleted    int `json:"deleted"`
+}
+
+// newBlobStore opens the configured blob store. A nil store with a
+// nil error means the feature is off.
+func newBlobStore(cfg *config.Config, ipfs ipfsclient.IPFSClient) (*blobstore.Store, error) {
+	if !cfg.BlobStoreEnabled {
+		return nil, nil
+	}
+	dir := cfg.BlobDir
+	if dir == "" {
+		if cfg.DataDir == "" {
+			return nil, fmt.Errorf("blob_store_enabled requires blob_dir or data_dir")
+		}
+		dir = filepath.Join(cfg.DataDir, "blobs")
+	}
+	switch cfg.BlobBackend {
+	case "", "file":
+		return blobstore.Open(dir, nil)
+	case "ipfs":
+		if !cfg.IPFSEnabled {
+			return nil, fmt.Errorf("blob_backend ipfs requires ipfs_enabled")
+		}
+		return blobstore.Open(dir, ipfs)
+	default:
+		return nil, fmt.Errorf("unknown blob_backend %q", cfg.BlobBackend)
+	}
+}
+
+// referencedBlobs collects every blob digest any known transaction
+// mentions. ok is false when an archived block could not be read
+// back.
+func (node *QuidnugNode) referencedBlobs(ctx context.Context) (refs map[string]bool, ok bool, err error) {
+	refs = make(map[string]bool)
+	scan := func(txs []interface{}) {
+		for _, tx := range txs {
+			raw, err := json.Marshal(tx)
+			if err != nil {
+				continue
+			}
+			for _, d := range blobstore.DigestPattern.FindAll(raw, -1) {
+				refs[string(d)] = true
+			}
+		}
+	}
+
+	node.BlockchainMutex.RLock()
+	chain := make([]Block, len(node.Blockchain))
+	copy(chain, node.Blockchain)
+	node.BlockchainMutex.RUnlock()
+
+	hydrate := node.blockHydrator(ctx)
+	for i := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		block := chain[i]
+		if block.Pruned && hydrate != nil {
+			hydrate(&block)
+		}
+		if block.Pruned {
+			return refs, false, nil
+		}
+		scan(block.Transactions)
+	}
+
+	node.TentativeBlocksMutex.RLock()
+	for _, blocks := range node.TentativeBlocks {
+		for _, block := range blocks {
+			scan(block.Transactions)
+		}
+	}
+	node.TentativeBlocksMutex.RUnlock()
+
+	node.PendingTxsMutex.RLock()
+	pending := make([]interface{}, len(node.PendingTxs))
+	copy(pending, node.PendingTxs)
+	node.PendingTxsMutex.RUnlock()
+	scan(pending)
+	return refs, true, nil
+}
+
+// CollectBlobGarbage deletes blobs that are past the grace period and
+// referenced by no transaction.
+func (node *QuidnugNode) CollectBlobGarbage(ctx context.Context) (*BlobGCReport, error) {
+	if node.Blobs == nil {
+		return nil, ErrBlobStoreDisabled
+	}
+	blobs, err := node.Blobs.List()
+	if err != nil {
+		return nil, err
+	}
+	report := &BlobGCReport{Blobs: len(blobs)}
+	if len(blobs) == 0 {
+		return report, nil
+	}
+
+	refs, complete, err := node.referencedBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		return nil, fmt.Errorf("blobs: archived blocks unavailable, skipping collection")
+	}
+
+	cutoff := time.Now().Add(-node.BlobGCGrace).Unix()
+	for _, meta := range blobs {
+		switch {
+		case refs[meta.Digest]:
+			report.Referenced++
+		case meta.Created > cutoff:
+			report.InGrace++
+		default:
+			if err := node.Blobs.Delete(ctx, meta.Digest); err != nil {
+				logger.Warn("Failed to delete unreferenced blob", "digest", meta.Digest, "error", err)
+				continue
+			}
+			report.Deleted++
+		}
+	}
+	if report.Deleted > 0 {
+		logger.Info("Collected unreferenced blobs", "deleted", report.Deleted, "remaining", report.Blobs-report.Deleted)
+	}
+	return report, nil
+}
+
+// runBlobGCLoop runs CollectBlobGarbage every interval until ctx is
+// done.
+func (node *QuidnugNode) runBlobGCLoop(ctx context.Context, interval time.Duration) {
+	if node.Blobs == nil || interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if _, err := node.CollectBlobGarbage(ctx); err != nil {
+				logger.Warn("Blob collection pass failed", "error", err)
+			}
+		}
+	}
+}
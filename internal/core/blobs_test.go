@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/blobstore"
+)
+
+func newTestBlobNode(t *testing.T) *QuidnugNode {
+	t.Helper()
+	node := newTestNode()
+	store, err := blobstore.Open(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Blobs = store
+	return node
+}
+
+func TestBlobHandlers_UploadAndServe(t *testing.T) {
+	node := newTestBlobNode(t)
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest("POST", "/api/v1/blobs", bytes.NewReader([]byte("%PDF-1.7 deed")))
+	req.Header.Set("Content-Type", "application/pdf")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data blobstore.Meta `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Digest != blobstore.Digest([]byte("%PDF-1.7 deed")) {
+		t.Fatalf("digest = %q", resp.Data.Digest)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/blobs/"+resp.Data.Digest, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "%PDF-1.7 deed" {
+		t.Fatalf("download: status %d body %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/blobs/sha256:"+string(bytes.Repeat([]byte("0"), 64)), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing blob: status %d, want 404", rec.Code)
+	}
+}
+
+func TestBlobHandlers_Disabled(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/blobs", bytes.NewReader([]byte("x"))))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", rec.Code)
+	}
+}
+
+func TestCollectBlobGarbage(t *testing.T) {
+	ctx := context.Background()
+	node := newTestBlobNode(t)
+
+	kept, _ := node.Blobs.Put(ctx, []byte("referenced evidence"), "")
+	orphan, _ := node.Blobs.Put(ctx, []byte("never referenced"), "")
+
+	node.PendingTxsMutex.Lock()
+	node.PendingTxs = append(node.PendingTxs, TrustTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTrust},
+		Evidence:        []TrustEvidence{{Type: EvidenceDocumentHash, Value: kept.Digest}},
+	})
+	node.PendingTxsMutex.Unlock()
+
+	// Both blobs are fresh, so an hour's grace spares the orphan.
+	node.BlobGCGrace = time.Hour
+	report, err := node.CollectBlobGarbage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 0 || report.InGrace != 1 || report.Referenced != 1 {
+		t.Fatalf("within grace: %+v", report)
+	}
+
+	// A negative grace puts the cutoff in the future.
+	node.BlobGCGrace = -time.Minute
+	report, err = node.CollectBlobGarbage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 1 || report.Referenced != 1 {
+		t.Fatalf("after grace: %+v", report)
+	}
+	if _, err := node.Blobs.Stat(kept.Digest); err != nil {
+		t.Errorf("referenced blob collected: %v", err)
+	}
+	if _, err := node.Blobs.Stat(orphan.Digest); err == nil {
+		t.Error("unreferenced blob survived")
+	}
+}
@@ -0,0 +1,291 @@
+// Trust domain archival and pruning.
+//
+// The chain used to keep every block body forever. With a block
+// archive configured (block_archive_backend), each domain now keeps
+// only its newest BlockRetention full blocks; older blocks are
+// written to the archive as gzip-compressed JSON and replaced in
+// the chain by their header: index, timestamp, trust proof and
+// validator signatures, prev hash, hash, TransactionsRoot and nonce
+// checkpoints. Chain linkage, fork choice and Merkle inclusion
+// proofs only need those fields, so they keep working on pruned
+// history.
+//
+// Pruned bodies come back on demand: LoadBlockchain fetches them to
+// replay registry state on restart, and GET /blocks serves full
+// blocks so syncing peers can still validate the whole chain. Every
+// fetched body is re-hashed against its retained header, so a
+// tampered or mismatched archive object is rejected rather than
+// served.
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/blockarchive"
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// ErrArchivedBlockMismatch means an archive object did not hash to
+// the header it was fetched for.
+var ErrArchivedBlockMismatch = errors.New("block archive: archived block does not match header")
+
+// blockArchiveTimeout bounds a single archive read or write.
+const blockArchiveTimeout = 30 * time.Second
+
+// newBlockArchive builds the configured archive. A nil archive with
+// a nil error means archival is off.
+func newBlockArchive(cfg *config.Config) (blockarchive.Archive, error) {
+	switch cfg.BlockArchiveBackend {
+	case "":
+		return nil, nil
+	case blockarchive.BackendFile:
+		dir := cfg.BlockArchiveDir
+		if dir == "" {
+			if cfg.DataDir == "" {
+				return nil, fmt.Errorf("block archive backend %q requires block_archive_dir or data_dir", cfg.BlockArchiveBackend)
+			}
+			dir = filepath.Join(cfg.DataDir, "archive")
+		}
+		return blockarchive.NewFileArchive(dir)
+	case blockarchive.BackendS3:
+		return blockarchive.NewS3Archive(blockarchive.S3Config{
+			Endpoint:        cfg.BlockArchiveEndpoint,
+			Region:          cfg.BlockArchiveRegion,
+			Bucket:          cfg.BlockArchiveBucket,
+			Prefix:          cfg.BlockArchivePrefix,
+			AccessKeyID:     cfg.BlockArchiveAccessKeyID,
+			SecretAccessKey: cfg.BlockArchiveSecretAccessKey,
+			SessionToken:    cfg.BlockArchiveSessionToken,
+		}, &http.Client{Timeout: blockArchiveTimeout})
+	case blockarchive.BackendGCS:
+		token := blockarchive.MetadataTokenSource(nil)
+		if cfg.BlockArchiveGCSToken != "" {
+			token = blockarchive.StaticToken(cfg.BlockArchiveGCSToken)
+		}
+		return blockarchive.NewGCSArchive(blockarchive.GCSConfig{
+			Endpoint: cfg.BlockArchiveEndpoint,
+			Bucket:   cfg.BlockArchiveBucket,
+			Prefix:   cfg.BlockArchivePrefix,
+			Token:    token,
+		}, &http.Client{Timeout: blockArchiveTimeout})
+	default:
+		return nil, fmt.Errorf("unknown block archive backend %q", cfg.BlockArchiveBackend)
+	}
+}
+
+// blockArchiveKey is where a block body lives in the archive.
+func blockArchiveKey(domain, hash string) string {
+	return "blocks/" + domain + "/" + hash + ".json.gz"
+}
+
+// blockHeader strips a block down to what the chain retains once
+// its body is archived.
+func blockHeader(block Block) Block {
+	block.Transactions = nil
+	block.Pruned = true
+	return block
+}
+
+// archiveBlock writes block's full body to the archive.
+func (node *QuidnugNode) archiveBlock(ctx context.Context, block Block) error {
+	raw, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("marshal block %d: %w", block.Index, err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, blockArchiveTimeout)
+	defer cancel()
+	return node.BlockArchive.Put(ctx, blockArchiveKey(block.TrustProof.TrustDomain, block.Hash), buf.Bytes())
+}
+
+// fetchArchivedBlock returns the full block for a pruned header.
+func (node *QuidnugNode) fetchArchivedBlock(ctx context.Context, header Block) (Block, error) {
+	if node.BlockArchive == nil {
+		return Block{}, blockarchive.ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, blockArchiveTimeout)
+	defer cancel()
+	data, err := node.BlockArchive.Get(ctx, blockArchiveKey(header.TrustProof.TrustDomain, header.Hash))
+	if err != nil {
+		if errors.Is(err, blockarchive.ErrNotFound) {
+			blockArchiveFetchTotal.WithLabelValues("missing").Inc()
+		} else {
+			blockArchiveFetchTotal.WithLabelValues("error").Inc()
+		}
+		return Block{}, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		blockArchiveFetchTotal.WithLabelValues("corrupt").Inc()
+		return Block{}, fmt.Errorf("%w: %v", ErrArchivedBlockMismatch, err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		blockArchiveFetchTotal.WithLabelValues("corrupt").Inc()
+		return Block{}, fmt.Errorf("%w: %v", ErrArchivedBlockMismatch, err)
+	}
+	var block Block
+	if err := json.Unmarshal(raw, &block); err != nil {
+		blockArchiveFetchTotal.WithLabelValues("corrupt").Inc()
+		return Block{}, fmt.Errorf("%w: %v", ErrArchivedBlockMismatch, err)
+	}
+	if block.Hash != header.Hash || calculateBlockHash(block) != header.Hash {
+		blockArchiveFetchTotal.WithLabelValues("corrupt").Inc()
+		return Block{}, ErrArchivedBlockMismatch
+	}
+	block.Pruned = false
+	blockArchiveFetchTotal.WithLabelValues("ok").Inc()
+	return block, nil
+}
+
+// hydrateBlocks replaces pruned headers in blocks with their
+// archived bodies, in place. Headers whose body cannot be fetched
+// are left as they are. blocks must not alias node.Blockchain.
+func (node *QuidnugNode) hydrateBlocks(ctx context.Context, blocks []Block) {
+	if node.BlockArchive == nil {
+		return
+	}
+	for i := range blocks {
+		if !blocks[i].Pruned {
+			continue
+		}
+		full, err := node.fetchArchivedBlock(ctx, blocks[i])
+		if err != nil {
+			logger.Debug("Serving pruned block header; archive fetch failed",
+				"index", blocks[i].Index, "hash", blocks[i].Hash, "error", err)
+			continue
+		}
+		blocks[i] = full
+	}
+}
+
+// blockHydrator returns a streamPage hook that swaps a pruned header
+// for its archived body, one block at a time.
+func (node *QuidnugNode) blockHydrator(ctx context.Context) func(*Block) {
+	if node.BlockArchive == nil {
+		return nil
+	}
+	return func(b *Block) {
+		one := []Block{*b}
+		node.hydrateBlocks(ctx, one)
+		*b = one[0]
+	}
+}
+
+// blockRetentionFor returns how many full blocks domain keeps; the
+// domain's own setting wins over the node-wide one. Caller must not
+// hold BlockchainMutex.
+func (node *QuidnugNode) blockRetentionFor(domain string) int {
+	node.TrustDomainsMutex.RLock()
+	td, ok := node.TrustDomains[domain]
+	node.TrustDomainsMutex.RUnlock()
+	if ok && td.BlockRetention > 0 {
+		return td.BlockRetention
+	}
+	return node.BlockRetention
+}
+
+// PruneBlocks archives every full block that falls outside its
+// domain's retention window and replaces it with its header.
+// Genesis and each domain's newest blocks are never pruned. Bodies
+// are written to the archive before the chain is touched, so a
+// failed write leaves the block in place; the first failure stops
+// the pass and is returned with the count pruned so far.
+func (node *QuidnugNode) PruneBlocks(ctx context.Context) (int, error) {
+	if node.BlockArchive == nil {
+		return 0, nil
+	}
+
+	node.BlockchainMutex.RLock()
+	positions := make(map[string][]int)
+	for i := 1; i < len(node.Blockchain); i++ {
+		domain := node.Blockchain[i].TrustProof.TrustDomain
+		positions[domain] = append(positions[domain], i)
+	}
+	node.BlockchainMutex.RUnlock()
+
+	retention := make(map[string]int, len(positions))
+	for domain := range positions {
+		retention[domain] = node.blockRetentionFor(domain)
+	}
+
+	var candidates []Block
+	node.BlockchainMutex.RLock()
+	for domain, idx := range positions {
+		keep := retention[domain]
+		if keep <= 0 || len(idx) <= keep {
+			continue
+		}
+		for _, pos := range idx[:len(idx)-keep] {
+			if pos < len(node.Blockchain) && !node.Blockchain[pos].Pruned {
+				candidates = append(candidates, node.Blockchain[pos])
+			}
+		}
+	}
+	node.BlockchainMutex.RUnlock()
+
+	archived := make(map[string]struct{}, len(candidates))
+	var archiveErr error
+	for _, block := range candidates {
+		if err := node.archiveBlock(ctx, block); err != nil {
+			archiveErr = fmt.Errorf("archive block %d (%s): %w", block.Index, block.TrustProof.TrustDomain, err)
+			break
+		}
+		archived[block.Hash] = struct{}{}
+	}
+
+	pruned := 0
+	node.BlockchainMutex.Lock()
+	for i := range node.Blockchain {
+		b := &node.Blockchain[i]
+		if _, ok := archived[b.Hash]; !ok || b.Pruned {
+			continue
+		}
+		*b = blockHeader(*b)
+		pruned++
+		blocksPrunedTotal.WithLabelValues(b.TrustProof.TrustDomain).Inc()
+	}
+	node.BlockchainMutex.Unlock()
+
+	if pruned > 0 {
+		logger.Info("Pruned archived blocks", "count", pruned)
+	}
+	return pruned, archiveErr
+}
+
+// runBlockPruneLoop runs PruneBlocks every interval until ctx is
+// cancelled. Failures log and the next tick retries.
+func (node *QuidnugNode) runBlockPruneLoop(ctx context.Context, interval time.Duration) {
+	if node.BlockArchive == nil || interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if _, err := node.PruneBlocks(ctx); err != nil {
+				logger.Warn("Block prune pass failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/blockarchive"
+)
+
+// appendArchiveTestBlocks appends n hash-linked blocks to
+// test.domain.com, each carrying one trust transaction to a
+// distinct trustee.
+func appendArchiveTestBlocks(node *QuidnugNode, n int) {
+	prev := node.Blockchain[len(node.Blockchain)-1]
+	for i := 0; i < n; i++ {
+		tx := signTrustTx(node, TrustTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          fmt.Sprintf("arch-%d", i),
+				Type:        TxTypeTrust,
+				TrustDomain: "test.domain.com",
+				Timestamp:   time.Now().Unix(),
+			},
+			Truster:    node.NodeID,
+			Trustee:    fmt.Sprintf("00000000000000b%d", i),
+			TrustLevel: 0.5,
+			Nonce:      int64(i + 1),
+		})
+		block := Block{
+			Index:        prev.Index + 1,
+			Timestamp:    time.Now().Unix(),
+			Transactions: []interface{}{tx},
+			TrustProof:   TrustProof{TrustDomain: "test.domain.com", ValidatorID: node.NodeID},
+			PrevHash:     prev.Hash,
+		}
+		block.Hash = calculateBlockHash(block)
+		node.Blockchain = append(node.Blockchain, block)
+		prev = block
+	}
+}
+
+func TestPruneBlocks_ArchivesBeyondRetention(t *testing.T) {
+	node := newTestNode()
+	archive := blockarchive.NewMemoryArchive()
+	node.BlockArchive = archive
+	node.BlockRetention = 2
+	appendArchiveTestBlocks(node, 5)
+
+	pruned, err := node.PruneBlocks(context.Background())
+	if err != nil || pruned != 3 {
+		t.Fatalf("PruneBlocks = %d, %v; want 3, nil", pruned, err)
+	}
+	if archive.Len() != 3 {
+		t.Fatalf("archive holds %d objects, want 3", archive.Len())
+	}
+	if node.Blockchain[0].Pruned {
+		t.Fatal("genesis must never be pruned")
+	}
+	for i, b := range node.Blockchain[1:] {
+		wantPruned := i < 3
+		if b.Pruned != wantPruned || (len(b.Transactions) == 0) != wantPruned {
+			t.Fatalf("block %d: pruned=%v txs=%d", b.Index, b.Pruned, len(b.Transactions))
+		}
+		if b.Hash == "" || b.PrevHash == "" {
+			t.Fatalf("block %d lost its header linkage", b.Index)
+		}
+	}
+
+	// A second pass has nothing left to do.
+	if pruned, err := node.PruneBlocks(context.Background()); err != nil || pruned != 0 {
+		t.Fatalf("second pass = %d, %v; want 0, nil", pruned, err)
+	}
+
+	full, err := node.fetchArchivedBlock(context.Background(), node.Blockchain[1])
+	if err != nil || full.Pruned || len(full.Transactions) != 1 {
+		t.Fatalf("fetchArchivedBlock = %+v, %v", full, err)
+	}
+}
+
+func TestPruneBlocks_DomainRetentionOverridesNode(t *testing.T) {
+	node := newTestNode()
+	node.BlockArchive = blockarchive.NewMemoryArchive()
+	node.BlockRetention = 1
+	domain := node.TrustDomains["test.domain.com"]
+	domain.BlockRetention = 4
+	node.TrustDomains["test.domain.com"] = domain
+	appendArchiveTestBlocks(node, 5)
+
+	if pruned, err := node.PruneBlocks(context.Background()); err != nil || pruned != 1 {
+		t.Fatalf("PruneBlocks = %d, %v; want 1, nil", pruned, err)
+	}
+}
+
+func TestPruneBlocks_NoArchiveIsNoop(t *testing.T) {
+	node := newTestNode()
+	node.BlockRetention = 1
+	appendArchiveTestBlocks(node, 3)
+	if pruned, err := node.PruneBlocks(context.Background()); err != nil || pruned != 0 {
+		t.Fatalf("PruneBlocks without archive = %d, %v", pruned, err)
+	}
+}
+
+func TestFetchArchivedBlock_RejectsMismatch(t *testing.T) {
+	node := newTestNode()
+	archive := blockarchive.NewMemoryArchive()
+	node.BlockArchive = archive
+	node.BlockRetention = 1
+	appendArchiveTestBlocks(node, 2)
+	if _, err := node.PruneBlocks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Store the newer block's body under the pruned block's key.
+	header := node.Blockchain[1]
+	other := node.Blockchain[2]
+	other.TrustProof.TrustDomain = header.TrustProof.TrustDomain
+	other.Hash = header.Hash
+	if err := node.archiveBlock(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.fetchArchivedBlock(context.Background(), header); !errors.Is(err, ErrArchivedBlockMismatch) {
+		t.Fatalf("expected ErrArchivedBlockMismatch, got %v", err)
+	}
+}
+
+func TestGetBlocksHandler_ServesArchivedBodies(t *testing.T) {
+	node := newTestNode()
+	node.BlockArchive = blockarchive.NewMemoryArchive()
+	node.BlockRetention = 1
+	appendArchiveTestBlocks(node, 3)
+	if _, err := node.PruneBlocks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	setupTestRouter(node).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/blocks?limit=10", nil))
+	var resp struct {
+		Data struct {
+			Data []Block `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Data) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(resp.Data.Data))
+	}
+	for _, b := range resp.Data.Data[1:] {
+		if b.Pruned || len(b.Transactions) != 1 {
+			t.Fatalf("block %d served as header: %+v", b.Index, b)
+		}
+	}
+	if !node.Blockchain[1].Pruned {
+		t.Fatal("serving a page must not un-prune the local chain")
+	}
+}
+
+func TestLoadBlockchain_ReplaysPrunedBlocksFromArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := blockarchive.NewMemoryArchive()
+
+	node := newTestNode()
+	node.BlockArchive = archive
+	node.BlockRetention = 1
+	appendArchiveTestBlocks(node, 3)
+	if _, err := node.PruneBlocks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.SaveBlockchain(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestNode()
+	restored.BlockArchive = archive
+	if err := restored.LoadBlockchain(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Blockchain[1].Pruned {
+		t.Fatal("restored chain should keep the pruned header")
+	}
+	for i := 0; i < 3; i++ {
+		trustee := fmt.Sprintf("00000000000000b%d", i)
+		if restored.GetTrustLevel(node.NodeID, trustee) != 0.5 {
+			t.Fatalf("trust edge to %s not replayed", trustee)
+		}
+	}
+}
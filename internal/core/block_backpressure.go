@@ -0,0 +1,124 @@
+// Block production backpressure.
+//
+// A validator that has fallen behind on a domain used to keep
+// sealing a block there every interval regardless. Each of those
+// blocks builds on a head the rest of the domain has moved past, so
+// it is at best wasted and at worst a fork the node then has to
+// reconcile on top of the validation work it is already behind on.
+//
+// Before each generation attempt runBlockGeneration asks
+// holdBlockProduction whether the domain carries too much debt:
+//
+//   - tentative: more than BlockProductionMaxTentative received
+//     blocks are parked in TentativeBlocks waiting for promotion;
+//   - sync-lag: the latest verified fingerprint for the domain is
+//     more than BlockProductionMaxSyncLag blocks ahead of the local
+//     head, i.e. its validators have sealed blocks this node has not
+//     caught up with yet.
+//
+// A held domain is skipped for that tick and re-checked on the next
+// one; other domains are unaffected. Every skip increments
+// quidnug_block_production_deferred_total{domain,reason}, and the
+// quidnug_block_production_backpressure{domain} gauge is 1 while the
+// domain is held. Entering and leaving backpressure are logged once
+// each rather than on every tick.
+package core
+
+import "time"
+
+// Backpressure reasons, as reported in metrics and logs.
+const (
+	BackpressureTentative = "tentative"
+	BackpressureSyncLag   = "sync-lag"
+)
+
+// backpressureState is a domain whose block production is held.
+type backpressureState struct {
+	reason string
+	since  time.Time
+}
+
+// blockProductionBackpressure returns why block production in domain
+// should wait, with the debt that tripped it and its limit, or an
+// empty reason when it may go ahead.
+func (node *QuidnugNode) blockProductionBackpressure(domain string) (reason string, debt, limit int64) {
+	if limit := int64(node.BlockProductionMaxTentative); limit > 0 {
+		node.TentativeBlocksMutex.RLock()
+		n := int64(len(node.TentativeBlocks[domain]))
+		node.TentativeBlocksMutex.RUnlock()
+		if n > limit {
+			return BackpressureTentative, n, limit
+		}
+	}
+	if limit := int64(node.BlockProductionMaxSyncLag); limit > 0 && node.NonceLedger != nil {
+		if fp, ok := node.NonceLedger.GetDomainFingerprint(domain); ok {
+			if lag := fp.BlockHeight - node.localDomainHeight(domain); lag > limit {
+				return BackpressureSyncLag, lag, limit
+			}
+		}
+	}
+	return "", 0, 0
+}
+
+// localDomainHeight is the index of the newest local block in
+// domain, or -1 when the node holds none.
+func (node *QuidnugNode) localDomainHeight(domain string) int64 {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	for i := len(node.Blockchain) - 1; i >= 0; i-- {
+		if node.Blockchain[i].TrustProof.TrustDomain == domain {
+			return node.Blockchain[i].Index
+		}
+	}
+	return -1
+}
+
+// holdBlockProduction reports whether block generation in domain
+// should be skipped this round, and records the skip.
+func (node *QuidnugNode) holdBlockProduction(domain string) bool {
+	reason, debt, limit := node.blockProductionBackpressure(domain)
+
+	node.backpressureMu.Lock()
+	prev, held := node.backpressured[domain]
+	if reason == "" {
+		delete(node.backpressured, domain)
+	} else if !held || prev.reason != reason {
+		if node.backpressured == nil {
+			node.backpressured = make(map[string]backpressureState)
+		}
+		node.backpressured[domain] = backpressureState{reason: reason, since: time.Now()}
+	}
+	node.backpressureMu.Unlock()
+
+	if reason == "" {
+		if held {
+			blockProductionBackpressure.WithLabelValues(domain).Set(0)
+			logger.Info("Block production resumed",
+				"domain", domain, "heldFor", time.Since(prev.since).Round(time.Second).String())
+		}
+		return false
+	}
+
+	blockProductionDeferredTotal.WithLabelValues(domain, reason).Inc()
+	if !held || prev.reason != reason {
+		blockProductionBackpressure.WithLabelValues(domain).Set(1)
+		logger.Warn("Block production held back",
+			"domain", domain, "reason", reason, "debt", debt, "limit", limit)
+	} else {
+		logger.Debug("Block production still held back",
+			"domain", domain, "reason", reason, "debt", debt, "limit", limit)
+	}
+	return true
+}
+
+// BlockProductionHeld returns the domains whose block production is
+// currently held back, with the reason.
+func (node *QuidnugNode) BlockProductionHeld() map[string]string {
+	node.backpressureMu.Lock()
+	defer node.backpressureMu.Unlock()
+	held := make(map[string]string, len(node.backpressured))
+	for domain, st := range node.backpressured {
+		held[domain] = st.reason
+	}
+	return held
+}
@@ -0,0 +1,55 @@
+package core
+
+import "testing"
+
+func TestHoldBlockProduction_Tentative(t *testing.T) {
+	node := newTestNode()
+	node.BlockProductionMaxTentative = 2
+	domain := "test.domain.com"
+
+	if node.holdBlockProduction(domain) {
+		t.Fatal("an empty tentative queue should not hold production")
+	}
+	for _, h := range []string{"a", "b", "c"} {
+		node.TentativeBlocks[domain] = append(node.TentativeBlocks[domain], Block{Hash: h})
+	}
+	if !node.holdBlockProduction(domain) {
+		t.Fatal("three tentative blocks over a limit of two should hold production")
+	}
+	if got := node.BlockProductionHeld()[domain]; got != BackpressureTentative {
+		t.Errorf("held reason = %q, want %q", got, BackpressureTentative)
+	}
+	if node.holdBlockProduction("other.domain.com") {
+		t.Error("backpressure in one domain should not hold another")
+	}
+
+	node.TentativeBlocks[domain] = nil
+	if node.holdBlockProduction(domain) {
+		t.Error("production should resume once the queue drains")
+	}
+	if len(node.BlockProductionHeld()) != 0 {
+		t.Errorf("nothing should be held, got %v", node.BlockProductionHeld())
+	}
+}
+
+func TestHoldBlockProduction_SyncLag(t *testing.T) {
+	node := newTestNode()
+	domain := "test.domain.com"
+	height := node.localDomainHeight(domain)
+	node.NonceLedger.StoreDomainFingerprint(DomainFingerprint{Domain: domain, BlockHeight: height + 20})
+
+	if node.holdBlockProduction(domain) {
+		t.Fatal("a disabled sync-lag check should not hold production")
+	}
+	node.BlockProductionMaxSyncLag = 25
+	if node.holdBlockProduction(domain) {
+		t.Fatal("a lag of 20 is within a limit of 25")
+	}
+	node.BlockProductionMaxSyncLag = 10
+	if !node.holdBlockProduction(domain) {
+		t.Fatal("a lag of 20 over a limit of 10 should hold production")
+	}
+	if got := node.BlockProductionHeld()[domain]; got != BackpressureSyncLag {
+		t.Errorf("held reason = %q, want %q", got, BackpressureSyncLag)
+	}
+}
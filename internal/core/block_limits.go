@@ -0,0 +1,166 @@
+// Per-block size limits.
+//
+// GenerateBlock used to seal every pending transaction for a domain
+// into one block, however large the backlog. A burst of submissions
+// produced a block too big to push to peers in one request or to
+// validate in one block interval. Blocks are now held to a
+// transaction count and a serialized (JSON) size: the domain's own
+// MaxBlockTransactions / MaxBlockBytes when set, the node's
+// block_max_transactions / block_max_bytes otherwise.
+//
+// GenerateBlock fills a block with the pending transactions, highest
+// priority first (mempool_priority.go), until the next one would
+// break a limit, and leaves that one and everything after it in the
+// mempool for the next block. Stopping at the first misfit, rather
+// than packing smaller transactions in after it, keeps each sender's
+// nonces in order across blocks. A transaction too large to fit even in an empty
+// block can never be sealed, so admission refuses it
+// (admitBlockFitOrReject); one that got into the mempool anyway,
+// say before the domain's limits were lowered, is dropped.
+//
+// The node-wide settings differ from node to node, so a received
+// block over the limits is not invalid: ValidateBlockTiered still
+// checks it in full and then holds it as BlockUntrusted, for an
+// operator to accept or purge, instead of forking off the
+// validators that sealed it.
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// blockOverheadBytes is the room GenerateBlock leaves for a block's
+// header, proof and nonce checkpoints when packing transactions.
+// It is an estimate; the sealed block is measured again and trimmed
+// if the estimate fell short.
+const blockOverheadBytes = 2048
+
+// blockLimits is the effective per-block limits for one domain; zero
+// or negative means unlimited.
+type blockLimits struct {
+	maxTxs   int
+	maxBytes int
+}
+
+// blockLimitsFor returns domain's block limits, the domain's own
+// settings winning over the node-wide ones.
+func (node *QuidnugNode) blockLimitsFor(domain string) blockLimits {
+	limits := blockLimits{maxTxs: node.BlockMaxTransactions, maxBytes: node.BlockMaxBytes}
+	node.TrustDomainsMutex.RLock()
+	td, ok := node.TrustDomains[domain]
+	node.TrustDomainsMutex.RUnlock()
+	if ok && td.MaxBlockTransactions > 0 {
+		limits.maxTxs = td.MaxBlockTransactions
+	}
+	if ok && td.MaxBlockBytes > 0 {
+		limits.maxBytes = td.MaxBlockBytes
+	}
+	return limits
+}
+
+// blockSize is the serialized size of block.
+func blockSize(block Block) int {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// split divides ranked txs into the prefix that fits in one block,
+// the rest, and anything that could not fit in a block on its own.
+// An atomic group (atomic_group.go) fits or spills as one unit.
+func (l blockLimits) split(txs []interface{}) (fit, spill, oversize []interface{}) {
+	size := blockOverheadBytes
+	units := atomicUnits(txs)
+	for i, unit := range units {
+		if l.maxTxs > 0 && len(unit) > l.maxTxs {
+			oversize = append(oversize, unit...)
+			continue
+		}
+		if l.maxTxs > 0 && len(fit)+len(unit) > l.maxTxs {
+			return fit, appendUnits(spill, units[i:]), oversize
+		}
+		if l.maxBytes > 0 {
+			n, ok := unitSize(unit)
+			if !ok {
+				continue
+			}
+			if blockOverheadBytes+n > l.maxBytes {
+				oversize = append(oversize, unit...)
+				continue
+			}
+			if size+n > l.maxBytes {
+				return fit, appendUnits(spill, units[i:]), oversize
+			}
+			size += n
+		}
+		fit = append(fit, unit...)
+	}
+	return fit, spill, oversize
+}
+
+// unitSize is the room unit takes in a block's transaction list.
+func unitSize(unit []interface{}) (int, bool) {
+	n := 0
+	for _, tx := range unit {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return 0, false
+		}
+		// One byte for the separating comma.
+		n += len(data) + 1
+	}
+	return n, true
+}
+
+// appendUnits appends the transactions of units to txs.
+func appendUnits(txs []interface{}, units [][]interface{}) []interface{} {
+	for _, unit := range units {
+		txs = append(txs, unit...)
+	}
+	return txs
+}
+
+// checkFitsBlock reports whether txs, one transaction or one whole
+// atomic group, would fit in an empty block of domain.
+func (node *QuidnugNode) checkFitsBlock(domain string, txs ...interface{}) error {
+	limits := node.blockLimitsFor(domain)
+	if limits.maxTxs > 0 && len(txs) > limits.maxTxs {
+		return fmt.Errorf("%d transactions cannot be sealed together, block limit is %d", len(txs), limits.maxTxs)
+	}
+	if limits.maxBytes > 0 {
+		if n, ok := unitSize(txs); ok && blockOverheadBytes+n > limits.maxBytes {
+			return fmt.Errorf("%d bytes of transactions cannot fit in a block of at most %d bytes", n, limits.maxBytes)
+		}
+	}
+	return nil
+}
+
+// admitBlockFitOrReject wraps checkFitsBlock with the metrics and
+// logging the Add*Transaction paths want on rejection.
+func (node *QuidnugNode) admitBlockFitOrReject(kind, domain string, txs ...interface{}) error {
+	if err := node.checkFitsBlock(domain, txs...); err != nil {
+		RecordTransactionProcessed(kind, false)
+		logger.Warn("Transaction rejected as too large for any block",
+			"txId", canonicalTxKey(txs[0]).id, "domain", domain, "error", err)
+		return &TxRejection{Code: RejectPayloadTooLarge, Message: err.Error(), Err: err}
+	}
+	return nil
+}
+
+// checkBlockLimits reports whether a received block breaks its
+// domain's block limits, and which one ("transactions" or "bytes").
+func (node *QuidnugNode) checkBlockLimits(block Block) (string, error) {
+	limits := node.blockLimitsFor(block.TrustProof.TrustDomain)
+	if limits.maxTxs > 0 && len(block.Transactions) > limits.maxTxs {
+		return "transactions", fmt.Errorf("block carries %d transactions, limit is %d", len(block.Transactions), limits.maxTxs)
+	}
+	if limits.maxBytes > 0 {
+		if n := blockSize(block); n > limits.maxBytes {
+			return "bytes", fmt.Errorf("block is %d bytes, limit is %d", n, limits.maxBytes)
+		}
+	}
+	return "", nil
+}
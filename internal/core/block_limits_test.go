@@ -0,0 +1,134 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateBlock_SpillsOverTransactionLimit(t *testing.T) {
+	node := newTestNode()
+	node.BlockMaxTransactions = 2
+	node.PendingTxs = []interface{}{
+		orderTestTrust(node, "t-3", 3),
+		orderTestTrust(node, "t-1", 1),
+		orderTestTrust(node, "t-2", 2),
+	}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 2 || canonicalTxKey(block.Transactions[1]).id != "t-2" {
+		t.Fatalf("expected t-1 and t-2 in the first block, got %+v", block.Transactions)
+	}
+	if len(node.PendingTxs) != 1 {
+		t.Fatalf("expected the third transaction to stay pending, got %d", len(node.PendingTxs))
+	}
+
+	block, err = node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 || canonicalTxKey(block.Transactions[0]).id != "t-3" {
+		t.Fatalf("expected t-3 in the second block, got %+v", block.Transactions)
+	}
+}
+
+func TestGenerateBlock_SpillsOverDomainByteLimit(t *testing.T) {
+	node := newTestNode()
+	tx := orderTestTrust(node, "t-1", 1)
+	data, _ := json.Marshal(tx)
+	maxBytes := blockOverheadBytes + 2*(len(data)+1) + 16
+
+	domain := node.TrustDomains["test.domain.com"]
+	domain.MaxBlockBytes = maxBytes
+	node.TrustDomains["test.domain.com"] = domain
+
+	huge := orderTestTrust(node, "t-huge", 0)
+	huge.Description = strings.Repeat("x", maxBytes)
+	node.PendingTxs = []interface{}{
+		tx,
+		orderTestTrust(node, "t-2", 2),
+		orderTestTrust(node, "t-3", 3),
+		huge,
+	}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if n := blockSize(*block); n > maxBytes {
+		t.Fatalf("block is %d bytes, limit %d", n, maxBytes)
+	}
+	if len(block.Transactions) == 0 || len(block.Transactions) > 2 {
+		t.Fatalf("expected one or two transactions, got %d", len(block.Transactions))
+	}
+	// The oversized transaction is dropped, the rest stay pending.
+	if want := 3 - len(block.Transactions); len(node.PendingTxs) != want {
+		t.Fatalf("expected %d pending, got %d", want, len(node.PendingTxs))
+	}
+	for _, p := range node.PendingTxs {
+		if canonicalTxKey(p).id == "t-huge" {
+			t.Fatal("a transaction too large for any block should be dropped")
+		}
+	}
+}
+
+func TestValidateBlockTiered_HoldsOverLimitBlockUntrusted(t *testing.T) {
+	node := newTestNode()
+	domain := node.TrustDomains["test.domain.com"]
+	domain.ValidatorPublicKeys = map[string]string{node.NodeID: node.GetPublicKeyHex()}
+	node.TrustDomains["test.domain.com"] = domain
+	node.PendingTxs = []interface{}{
+		orderTestTrust(node, "t-1", 1),
+		orderTestTrust(node, "t-2", 2),
+		orderTestTrust(node, "t-3", 3),
+	}
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if got := node.ValidateBlockTiered(*block); got != BlockTrusted {
+		t.Fatalf("within limits: expected BlockTrusted, got %v", got)
+	}
+
+	// The limits are this node's own; the validator that sealed
+	// the block may run others.
+	node.BlockMaxTransactions = 2
+	if got := node.ValidateBlockTiered(*block); got != BlockUntrusted {
+		t.Fatalf("over the transaction limit: expected BlockUntrusted, got %v", got)
+	}
+	node.BlockMaxTransactions = 0
+	node.BlockMaxBytes = blockSize(*block) - 1
+	if got := node.ValidateBlockTiered(*block); got != BlockUntrusted {
+		t.Fatalf("over the byte limit: expected BlockUntrusted, got %v", got)
+	}
+
+	// Being over the limits doesn't spare a block its other checks.
+	bad := orderTestTrust(node, "t-bad", 4)
+	bad.TrustLevel = 0.9
+	invalid := node.sealBlock(node.Blockchain[0], "test.domain.com", 1.0, []interface{}{bad})
+	node.BlockMaxBytes = blockSize(invalid) - 1
+	if got := node.ValidateBlockTiered(invalid); got != BlockInvalid {
+		t.Fatalf("over the limits with a bad signature: expected BlockInvalid, got %v", got)
+	}
+}
+
+func TestAddTrustTransaction_RefusesTransactionTooLargeForAnyBlock(t *testing.T) {
+	node := newTestNode()
+	node.BlockMaxBytes = blockOverheadBytes + 512
+	tx := orderTestTrust(node, "t-huge", 1)
+	tx.Description = strings.Repeat("x", 1024)
+	tx = signTrustTx(node, tx)
+
+	_, err := node.AddTrustTransaction(tx)
+	var rejection *TxRejection
+	if !errors.As(err, &rejection) || rejection.Code != RejectPayloadTooLarge {
+		t.Fatalf("expected a %s rejection, got %v", RejectPayloadTooLarge, err)
+	}
+	if len(node.PendingTxs) != 0 {
+		t.Fatalf("expected nothing pending, got %d", len(node.PendingTxs))
+	}
+}
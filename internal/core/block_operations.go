@@ -10,6 +10,10 @@ import (
 )
 
 func (node *QuidnugNode) runBlockGeneration(ctx context.Context, interval time.Duration) {
+	if node.IsReplica() {
+		logger.Info("Block generation disabled on read replica")
+		return
+	}
 	logger.Info("Starting block generation loop", "interval", interval)
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -34,6 +38,10 @@ func (node *QuidnugNode) runBlockGeneration(ctx context.Context, interval time.D
 				default:
 				}
 
+				if node.holdBlockProduction(domain) {
+					continue
+				}
+
 				block, err := node.GenerateBlock(domain)
 				if err != nil {
 					logger.Debug("Failed to generate block", "domain", domain, "error", err)
@@ -51,7 +59,17 @@ func (node *QuidnugNode) runBlockGeneration(ctx context.Context, interval time.D
 // Shutdown performs graceful shutdown of the node
 
 func (node *QuidnugNode) FilterTransactionsForBlock(txs []interface{}, domain string) []interface{} {
+	filtered, _ := node.filterTransactionsForBlock(txs, domain)
+	return filtered
+}
+
+// filterTransactionsForBlock is FilterTransactionsForBlock that also
+// returns, for each kept transaction, the trust computed in its
+// creator. Validator-signed transactions count as fully trusted.
+func (node *QuidnugNode) filterTransactionsForBlock(txs []interface{}, domain string) ([]interface{}, []float64) {
 	var filtered []interface{}
+	var creatorTrust []float64
+	sybil := node.newSybilScorer()
 
 	for _, tx := range txs {
 		var creatorQuid string
@@ -88,6 +106,45 @@ func (node *QuidnugNode) FilterTransactionsForBlock(txs []interface{}, domain st
 			// node quid is a redundant safety check.
 			creatorQuid = t.NodeQuid
 			txID = t.ID
+		case NameRegistrationTransaction:
+			creatorQuid = t.OwnerQuid
+			txID = t.ID
+		case LienTransaction:
+			creatorQuid = t.LienholderQuid
+			txID = t.ID
+		case SuccessionTransaction:
+			creatorQuid = t.SuccessorQuid
+			txID = t.ID
+		case MisbehaviorReportTransaction:
+			creatorQuid = t.ReporterQuid
+			txID = t.ID
+		case DomainJoinTransaction:
+			creatorQuid = t.NodeQuid
+			txID = t.ID
+		case CheckpointTransaction:
+			// Signed by a validator quorum rather than a creator
+			// quid, so there is no one to trust-filter.
+			filtered = append(filtered, tx)
+			creatorTrust = append(creatorTrust, 1)
+			continue
+		case DomainControlTransaction:
+			// Signed by a validator of the domain, which validation
+			// already requires.
+			filtered = append(filtered, tx)
+			creatorTrust = append(creatorTrust, 1)
+			continue
+		case TransferApprovalTransaction:
+			creatorQuid = t.ApproverQuid
+			txID = t.ID
+		case TitleRestructureTransaction:
+			creatorQuid = QuidIDFromPublicKeyHex(t.PublicKey)
+			txID = t.ID
+		case TitleDisputeTransaction:
+			creatorQuid = t.SignerQuid
+			txID = t.ID
+		case CustomTransaction:
+			creatorQuid = t.Signer
+			txID = t.ID
 		default:
 			// Unknown transaction type, skip
 			logger.Debug("Skipping unknown transaction type in trust filter")
@@ -102,8 +159,35 @@ func (node *QuidnugNode) FilterTransactionsForBlock(txs []interface{}, domain st
 			continue
 		}
 
-		// Compute relational trust from this node to the creator
-		trustLevel, _, err := node.ComputeRelationalTrust(node.NodeQuidID, creatorQuid, DefaultTrustMaxDepth)
+		// Re-check the domain's anti-spam policy: gossiped txs and
+		// txs admitted before the policy was installed never went
+		// through the Add*Transaction gate.
+		stakeQuid := creatorQuid
+		if t, ok := tx.(TitleTransaction); ok {
+			stakeQuid = titleStakeQuid(t)
+		}
+		if err := node.checkAntiSpam(domain, stakeQuid, tx); err != nil {
+			logger.Debug("Filtered out transaction by anti-spam policy",
+				"txId", txID,
+				"creator", stakeQuid,
+				"domain", domain,
+				"error", err)
+			continue
+		}
+
+		// Quids too new and unvouched to have cost anything to
+		// create wait until someone established trusts them.
+		if !sybil.passesSybilGate(creatorQuid) {
+			logger.Debug("Filtered out transaction by sybil score",
+				"txId", txID,
+				"creator", creatorQuid,
+				"minScore", node.SybilMinScore,
+				"domain", domain)
+			continue
+		}
+
+		// Compute relational trust from this node and its anchors to the creator
+		trustLevel, _, err := node.nodeTrustIn(node.NodeQuidID, creatorQuid)
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"txId", txID,
@@ -116,6 +200,7 @@ func (node *QuidnugNode) FilterTransactionsForBlock(txs []interface{}, domain st
 		// Include if trust meets threshold
 		if trustLevel >= node.TransactionTrustThreshold {
 			filtered = append(filtered, tx)
+			creatorTrust = append(creatorTrust, trustLevel)
 		} else {
 			logger.Debug("Filtered out transaction due to insufficient trust",
 				"txId", txID,
@@ -126,7 +211,7 @@ func (node *QuidnugNode) FilterTransactionsForBlock(txs []interface{}, domain st
 		}
 	}
 
-	return filtered
+	return filtered, creatorTrust
 }
 
 // GenerateBlock generates a new block with pending transactions.
@@ -198,6 +283,28 @@ func (node *QuidnugNode) GenerateBlock(trustDomain string) (*Block, error) {
 			txDomain = t.TrustDomain
 		case NodeAdvertisementTransaction:
 			txDomain = t.TrustDomain
+		case NameRegistrationTransaction:
+			txDomain = t.TrustDomain
+		case LienTransaction:
+			txDomain = t.TrustDomain
+		case SuccessionTransaction:
+			txDomain = t.TrustDomain
+		case MisbehaviorReportTransaction:
+			txDomain = t.TrustDomain
+		case DomainJoinTransaction:
+			txDomain = t.TrustDomain
+		case CheckpointTransaction:
+			txDomain = t.TrustDomain
+		case DomainControlTransaction:
+			txDomain = t.TrustDomain
+		case TransferApprovalTransaction:
+			txDomain = t.TrustDomain
+		case TitleRestructureTransaction:
+			txDomain = t.TrustDomain
+		case TitleDisputeTransaction:
+			txDomain = t.TrustDomain
+		case CustomTransaction:
+			txDomain = t.TrustDomain
 		default:
 			// Unknown transaction type, skip
 			continue
@@ -211,17 +318,68 @@ func (node *QuidnugNode) GenerateBlock(trustDomain string) (*Block, error) {
 	}
 
 	// Apply trust-based filtering to domain transactions
-	domainTxs = node.FilterTransactionsForBlock(domainTxs, trustDomain)
+	candidates := domainTxs
+	domainTxs, creatorTrust := node.filterTransactionsForBlock(domainTxs, trustDomain)
+
+	// Atomic groups go in whole or wait for their missing members.
+	domainTxs, creatorTrust, heldGroups := node.completeAtomicGroups(candidates, domainTxs, creatorTrust)
+	remainingTxs = append(remainingTxs, heldGroups...)
 
 	if len(domainTxs) == 0 {
 		return nil, fmt.Errorf("no pending transactions for trust domain: %s", trustDomain)
 	}
 
-	// Create a new block
+	// Highest priority first (mempool_priority.go), then hold the
+	// block to the domain's limits (block_limits.go); what doesn't
+	// fit stays pending for the next block.
+	domainTxs = clusterAtomicGroups(node.rankByPriority(domainTxs, creatorTrust))
+	limits := node.blockLimitsFor(trustDomain)
+	domainTxs, spilled, oversize := limits.split(domainTxs)
+	for _, tx := range oversize {
+		base, _, _ := txGossipRoute(tx)
+		logger.Warn("Dropping transaction too large for any block",
+			"txId", base.ID, "domain", trustDomain, "maxBlockBytes", limits.maxBytes)
+	}
+	if len(domainTxs) == 0 {
+		node.PendingTxs = append(remainingTxs, spilled...)
+		return nil, fmt.Errorf("no pending transactions fit a block for trust domain: %s", trustDomain)
+	}
+
+	newBlock := node.sealBlock(prevBlock, trustDomain, validatorWeight, domainTxs)
+	for limits.maxBytes > 0 && blockSize(newBlock) > limits.maxBytes {
+		units := atomicUnits(domainTxs)
+		if len(units) < 2 {
+			break
+		}
+		last := units[len(units)-1]
+		spilled = append(append([]interface{}(nil), last...), spilled...)
+		domainTxs = domainTxs[:len(domainTxs)-len(last)]
+		newBlock = node.sealBlock(prevBlock, trustDomain, validatorWeight, domainTxs)
+	}
+
+	// Update pending transactions (remove the ones included in this block)
+	node.PendingTxs = append(remainingTxs, spilled...)
+
+	blockLogger(newBlock).Info("Generated new block",
+		"txCount", len(domainTxs), "spilled", len(spilled))
+
+	RecordBlockGenerated(trustDomain)
+
+	return &newBlock, nil
+}
+
+// sealBlock builds and signs the block carrying txs on top of
+// prevBlock. txs itself is left in the order given.
+func (node *QuidnugNode) sealBlock(prevBlock Block, trustDomain string, validatorWeight float64, txs []interface{}) Block {
+	// Seal in canonical order (tx_order.go) so every validator
+	// applies the same set the same way.
+	txs = append([]interface{}(nil), txs...)
+	sortTransactionsCanonical(txs)
+
 	newBlock := Block{
 		Index:        prevBlock.Index + 1,
 		Timestamp:    time.Now().Unix(),
-		Transactions: domainTxs,
+		Transactions: txs,
 		TrustProof: TrustProof{
 			TrustDomain:             trustDomain,
 			ValidatorID:             node.NodeID,
@@ -230,14 +388,15 @@ func (node *QuidnugNode) GenerateBlock(trustDomain string) (*Block, error) {
 			ValidatorSigs:           []string{},
 			ValidationTime:          time.Now().Unix(),
 		},
-		PrevHash: prevBlock.Hash,
+		PrevHash:        prevBlock.Hash,
+		ProtocolVersion: headerProtocolVersion(node.domainProtocolVersion(trustDomain)),
 	}
 
 	// QDP-0001 §6.3: compute per-signer nonce checkpoints at seal time.
 	// Populated unconditionally so the receive path can rebuild the
 	// ledger from blocks. Not yet included in the signable envelope —
 	// see GetBlockSignableData for the rationale.
-	newBlock.NonceCheckpoints = computeNonceCheckpoints(domainTxs, trustDomain)
+	newBlock.NonceCheckpoints = computeNonceCheckpoints(txs, trustDomain)
 
 	// QDP-0010 / H2: compute the Merkle root over canonical tx
 	// bytes. Populated always (post-H2 code emits the field
@@ -256,19 +415,7 @@ func (node *QuidnugNode) GenerateBlock(trustDomain string) (*Block, error) {
 
 	// Calculate the hash of the new block
 	newBlock.Hash = calculateBlockHash(newBlock)
-
-	// Update pending transactions (remove the ones included in this block)
-	node.PendingTxs = remainingTxs
-
-	logger.Info("Generated new block",
-		"blockIndex", newBlock.Index,
-		"domain", trustDomain,
-		"txCount", len(domainTxs),
-		"hash", newBlock.Hash)
-
-	RecordBlockGenerated(trustDomain)
-
-	return &newBlock, nil
+	return newBlock
 }
 
 // AddBlock adds a block to the blockchain after validation.
@@ -284,6 +431,33 @@ func (node *QuidnugNode) AddBlock(block Block) error {
 	return nil
 }
 
+// dropSealedPending removes mempool entries that a trusted block
+// has committed. The producer already dropped them in
+// GenerateBlock; every other validator still holds its broadcast
+// copies and would otherwise seal them again.
+func (node *QuidnugNode) dropSealedPending(block Block) {
+	sealed := make(map[string]bool, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		if id := canonicalTxKey(tx).id; id != "" {
+			sealed[id] = true
+		}
+	}
+	if len(sealed) == 0 {
+		return
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+	kept := node.PendingTxs[:0]
+	for _, tx := range node.PendingTxs {
+		if !sealed[canonicalTxKey(tx).id] {
+			kept = append(kept, tx)
+		}
+	}
+	node.PendingTxs = kept
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+}
+
 // ReceiveBlock processes an incoming block with tiered acceptance.
 // Extracts trust graph data from all cryptographically valid blocks.
 //
@@ -325,8 +499,7 @@ func (node *QuidnugNode) ReceiveBlock(block Block) (BlockAcceptance, error) {
 			// validation step decide. A self-inconsistent proof
 			// (mismatched id/pubkey) gets rejected at step 1
 			// regardless.
-			logger.Debug("ENG-80: trust-domain bootstrap from block declined",
-				"domain", domain,
+			blockLogger(block).Debug("ENG-80: trust-domain bootstrap from block declined",
 				"validator", block.TrustProof.ValidatorID,
 				"error", err)
 		}
@@ -356,6 +529,8 @@ func (node *QuidnugNode) ReceiveBlock(block Block) (BlockAcceptance, error) {
 		node.BlockchainMutex.Lock()
 		node.Blockchain = append(node.Blockchain, block)
 		node.BlockchainMutex.Unlock()
+		node.announceBlock()
+		node.announceBlockInventory(block)
 
 		// QDP-0001 §6.4: Trusted tier advances both accepted and
 		// tentative per the ledger's tier table.
@@ -365,6 +540,10 @@ func (node *QuidnugNode) ReceiveBlock(block Block) (BlockAcceptance, error) {
 
 		// Process transactions
 		node.processBlockTransactions(block)
+		node.notifyWatchers(block)
+		node.publishChainEvents(block)
+		node.dropSealedPending(block)
+		node.signCheckpoint(block)
 
 		// Update domain head
 		node.TrustDomainsMutex.Lock()
@@ -380,10 +559,7 @@ func (node *QuidnugNode) ReceiveBlock(block Block) (BlockAcceptance, error) {
 		}
 
 		if logger != nil {
-			logger.Info("Received trusted block",
-				"blockIndex", block.Index,
-				"hash", block.Hash,
-				"domain", block.TrustProof.TrustDomain)
+			blockLogger(block).Info("Received trusted block")
 		}
 
 	case BlockTentative:
@@ -397,19 +573,21 @@ func (node *QuidnugNode) ReceiveBlock(block Block) (BlockAcceptance, error) {
 			node.NonceLedger.ApplyCheckpoints(block.NonceCheckpoints, false)
 		}
 		if logger != nil {
-			logger.Info("Received tentative block",
-				"blockIndex", block.Index,
-				"hash", block.Hash,
-				"domain", block.TrustProof.TrustDomain)
+			blockLogger(block).Info("Received tentative block")
 		}
 
 	case BlockUntrusted:
-		// Edges already added as unverified, don't store block
+		// Edges already added as unverified; hold the block for
+		// manual review rather than dropping it.
+		if node.BlockQuarantine != nil {
+			reason := "validator trust below domain threshold"
+			if _, err := node.checkBlockLimits(block); err != nil {
+				reason = err.Error()
+			}
+			node.BlockQuarantine.add(block, reason)
+		}
 		if logger != nil {
-			logger.Info("Received untrusted block - extracted edges only",
-				"blockIndex", block.Index,
-				"hash", block.Hash,
-				"domain", block.TrustProof.TrustDomain)
+			blockLogger(block).Info("Received untrusted block - extracted edges, quarantined")
 		}
 
 	case BlockInvalid:
@@ -473,8 +651,12 @@ func (node *QuidnugNode) ReEvaluateTentativeBlocks(domain string) error {
 			node.BlockchainMutex.Lock()
 			node.Blockchain = append(node.Blockchain, block)
 			node.BlockchainMutex.Unlock()
+			node.announceBlock()
 
 			node.processBlockTransactions(block)
+			node.notifyWatchers(block)
+			node.publishChainEvents(block)
+			node.signCheckpoint(block)
 
 			node.TrustDomainsMutex.Lock()
 			if d, exists := node.TrustDomains[block.TrustProof.TrustDomain]; exists {
@@ -489,10 +671,7 @@ func (node *QuidnugNode) ReEvaluateTentativeBlocks(domain string) error {
 			}
 
 			if logger != nil {
-				logger.Info("Promoted tentative block to trusted",
-					"blockIndex", block.Index,
-					"hash", block.Hash,
-					"domain", domain)
+				blockLogger(block).Info("Promoted tentative block to trusted")
 			}
 
 		case BlockTentative:
@@ -500,10 +679,7 @@ func (node *QuidnugNode) ReEvaluateTentativeBlocks(domain string) error {
 
 		case BlockUntrusted, BlockInvalid:
 			if logger != nil {
-				logger.Info("Removed tentative block",
-					"blockIndex", block.Index,
-					"hash", block.Hash,
-					"domain", domain,
+				blockLogger(block).Info("Removed tentative block",
 					"newStatus", acceptance)
 			}
 		}
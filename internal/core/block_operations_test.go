@@ -0,0 +1,34 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReceiveBlock_DropsSealedPending(t *testing.T) {
+	node := newTestNode()
+
+	sealed := TrustTransaction{BaseTransaction: BaseTransaction{ID: "tx_sealed", Type: TxTypeTrust}}
+	other := TrustTransaction{BaseTransaction: BaseTransaction{ID: "tx_other", Type: TxTypeTrust}}
+	node.PendingTxsMutex.Lock()
+	node.PendingTxs = []interface{}{sealed, other, sealed}
+	node.PendingTxsMutex.Unlock()
+
+	// A received block carries its transactions as decoded JSON,
+	// not as the typed structs held in the mempool.
+	raw, _ := json.Marshal(sealed)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	node.dropSealedPending(Block{Transactions: []interface{}{decoded}})
+
+	node.PendingTxsMutex.RLock()
+	defer node.PendingTxsMutex.RUnlock()
+	if len(node.PendingTxs) != 1 {
+		t.Fatalf("expected 1 pending transaction, got %d", len(node.PendingTxs))
+	}
+	if tx, ok := node.PendingTxs[0].(TrustTransaction); !ok || tx.ID != "tx_other" {
+		t.Fatalf("wrong transaction kept: %+v", node.PendingTxs[0])
+	}
+}
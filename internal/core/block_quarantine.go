@@ -0,0 +1,289 @@
+// Untrusted block quarantine.
+//
+// ReceiveBlock used to drop BlockUntrusted blocks after pulling
+// their trust edges into the unverified registry. While a new
+// validator is bootstrapping its trust, that throws away blocks an
+// operator may well want to keep. BlockQuarantine holds them in a
+// bounded, in-memory store (lost on restart, like PendingTxs) so
+// an operator can list and inspect them and then accept or purge
+// each one through an admin-signed request.
+//
+// Admin requests are signed by the operator quid's key, or by the
+// node's own key when no operator quid is configured. The signed
+// payload binds the action, the block hash, and a timestamp that
+// must be within AdminRequestMaxSkew of the node's clock.
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBlockQuarantineMaxSize bounds the store. On overflow
+	// the oldest block is evicted.
+	DefaultBlockQuarantineMaxSize = 256
+
+	// AdminRequestMaxSkew is how far an admin request's timestamp
+	// may drift from the node's clock in either direction.
+	AdminRequestMaxSkew = 5 * time.Minute
+)
+
+// Quarantine review actions.
+const (
+	QuarantineActionAccept = "accept"
+	QuarantineActionPurge  = "purge"
+)
+
+var (
+	ErrQuarantinedBlockNotFound = errors.New("quarantine: block not found")
+	ErrAdminSignature           = errors.New("admin request: signature invalid")
+	ErrAdminKey                 = errors.New("admin request: public key is not the operator key")
+	ErrAdminStale               = errors.New("admin request: timestamp outside allowed skew")
+)
+
+// QuarantinedBlock is one held untrusted block.
+type QuarantinedBlock struct {
+	Block      Block  `json:"block"`
+	ReceivedAt int64  `json:"receivedAt"`
+	Reason     string `json:"reason"`
+}
+
+// QuarantinedBlockSummary is the list view of a held block.
+type QuarantinedBlockSummary struct {
+	Hash        string `json:"hash"`
+	Index       int64  `json:"index"`
+	Domain      string `json:"domain"`
+	ValidatorID string `json:"validatorId"`
+	TxCount     int    `json:"txCount"`
+	ReceivedAt  int64  `json:"receivedAt"`
+	Reason      string `json:"reason"`
+}
+
+// BlockQuarantine is a bounded FIFO of untrusted blocks keyed by
+// hash. Owns its own lock.
+type BlockQuarantine struct {
+	mu      sync.RWMutex
+	maxSize int
+	order   []string
+	blocks  map[string]QuarantinedBlock
+}
+
+// NewBlockQuarantine constructs an empty store holding at most
+// maxSize blocks. maxSize <= 0 selects the default.
+func NewBlockQuarantine(maxSize int) *BlockQuarantine {
+	if maxSize <= 0 {
+		maxSize = DefaultBlockQuarantineMaxSize
+	}
+	return &BlockQuarantine{
+		maxSize: maxSize,
+		blocks:  make(map[string]QuarantinedBlock),
+	}
+}
+
+// add stores block, evicting the oldest entry on overflow.
+// Returns false if the block is already held.
+func (q *BlockQuarantine) add(block Block, reason string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.blocks[block.Hash]; exists {
+		return false
+	}
+	for len(q.order) >= q.maxSize {
+		oldest := q.order[0]
+		q.order = q.order[1:]
+		delete(q.blocks, oldest)
+	}
+	q.blocks[block.Hash] = QuarantinedBlock{
+		Block:      block,
+		ReceivedAt: time.Now().Unix(),
+		Reason:     reason,
+	}
+	q.order = append(q.order, block.Hash)
+	return true
+}
+
+// get returns the held block with hash.
+func (q *BlockQuarantine) get(hash string) (QuarantinedBlock, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	qb, ok := q.blocks[hash]
+	return qb, ok
+}
+
+// remove drops hash from the store. Returns false if absent.
+func (q *BlockQuarantine) remove(hash string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.blocks[hash]; !ok {
+		return false
+	}
+	delete(q.blocks, hash)
+	for i, h := range q.order {
+		if h == hash {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// list returns summaries of held blocks, optionally filtered to
+// one domain, ordered by domain then block index.
+func (q *BlockQuarantine) list(domain string) []QuarantinedBlockSummary {
+	q.mu.RLock()
+	out := make([]QuarantinedBlockSummary, 0, len(q.blocks))
+	for _, qb := range q.blocks {
+		if domain != "" && qb.Block.TrustProof.TrustDomain != domain {
+			continue
+		}
+		out = append(out, QuarantinedBlockSummary{
+			Hash:        qb.Block.Hash,
+			Index:       qb.Block.Index,
+			Domain:      qb.Block.TrustProof.TrustDomain,
+			ValidatorID: qb.Block.TrustProof.ValidatorID,
+			TxCount:     len(qb.Block.Transactions),
+			ReceivedAt:  qb.ReceivedAt,
+			Reason:      qb.Reason,
+		})
+	}
+	q.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Domain != out[j].Domain {
+			return out[i].Domain < out[j].Domain
+		}
+		return out[i].Index < out[j].Index
+	})
+	return out
+}
+
+// QuarantineReviewRequest is the admin-signed body for accepting
+// or purging a quarantined block. Signature covers the JSON
+// encoding of the request with Signature empty.
+type QuarantineReviewRequest struct {
+	Action    string `json:"action"`
+	BlockHash string `json:"blockHash"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// adminPublicKeyHex is the key admin requests must be signed
+// with: the operator quid's when configured, else the node's.
+func (node *QuidnugNode) adminPublicKeyHex() string {
+	if node.OperatorQuidPublicKeyHex != "" {
+		return node.OperatorQuidPublicKeyHex
+	}
+	return node.GetPublicKeyHex()
+}
+
+// verifyQuarantineReview checks the key, freshness, and signature
+// of an admin review request.
+func (node *QuidnugNode) verifyQuarantineReview(req QuarantineReviewRequest) error {
+	signable := req
+	signable.Signature = ""
+	return node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable)
+}
+
+// verifyAdminSigned is the shared check behind every admin-signed
+// request: publicKey must be the admin key, timestamp must be
+// fresh, and signature must cover the JSON encoding of signable
+// (the request with its Signature field cleared).
+func (node *QuidnugNode) verifyAdminSigned(publicKey string, timestamp int64, signature string, signable interface{}) error {
+	adminKey := node.adminPublicKeyHex()
+	if adminKey == "" || publicKey != adminKey {
+		return ErrAdminKey
+	}
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > AdminRequestMaxSkew || skew < -AdminRequestMaxSkew {
+		return ErrAdminStale
+	}
+	data, err := json.Marshal(signable)
+	if err != nil {
+		return err
+	}
+	if !VerifySignature(publicKey, data, signature) {
+		return ErrAdminSignature
+	}
+	return nil
+}
+
+// ReviewQuarantinedBlock applies an admin-signed accept or purge.
+func (node *QuidnugNode) ReviewQuarantinedBlock(req QuarantineReviewRequest) error {
+	if err := node.verifyQuarantineReview(req); err != nil {
+		return err
+	}
+	switch req.Action {
+	case QuarantineActionPurge:
+		if !node.BlockQuarantine.remove(req.BlockHash) {
+			return ErrQuarantinedBlockNotFound
+		}
+		logger.Info("Purged quarantined block", "hash", req.BlockHash)
+		return nil
+	case QuarantineActionAccept:
+		return node.acceptQuarantinedBlock(req.BlockHash)
+	default:
+		return fmt.Errorf("quarantine: unknown action %q", req.Action)
+	}
+}
+
+// acceptQuarantinedBlock commits a held block to the main chain as
+// if it had arrived trusted. The block is re-checked
+// cryptographically against the current chain first; if it no
+// longer links (the domain moved on while it sat here) it stays in
+// quarantine for the operator to purge.
+func (node *QuidnugNode) acceptQuarantinedBlock(hash string) error {
+	qb, ok := node.BlockQuarantine.get(hash)
+	if !ok {
+		return ErrQuarantinedBlockNotFound
+	}
+	block := qb.Block
+	if !node.ValidateBlockCryptographic(block) {
+		return fmt.Errorf("quarantine: block %s no longer passes cryptographic validation", hash)
+	}
+	node.BlockQuarantine.remove(hash)
+
+	node.BlockchainMutex.Lock()
+	node.Blockchain = append(node.Blockchain, block)
+	node.BlockchainMutex.Unlock()
+	node.announceBlock()
+
+	if node.NonceLedger != nil {
+		node.NonceLedger.ApplyCheckpoints(block.NonceCheckpoints, true)
+	}
+	node.processBlockTransactions(block)
+	node.notifyWatchers(block)
+	node.publishChainEvents(block)
+	node.signCheckpoint(block)
+
+	node.TrustDomainsMutex.Lock()
+	if d, exists := node.TrustDomains[block.TrustProof.TrustDomain]; exists {
+		d.BlockchainHead = block.Hash
+		node.TrustDomains[block.TrustProof.TrustDomain] = d
+	}
+	node.TrustDomainsMutex.Unlock()
+
+	for _, edge := range node.ExtractTrustEdgesFromBlock(block, true) {
+		node.PromoteTrustEdge(edge.Truster, edge.Trustee)
+	}
+
+	logger.Info("Manually accepted quarantined block",
+		"blockIndex", block.Index,
+		"hash", block.Hash,
+		"domain", block.TrustProof.TrustDomain)
+	return nil
+}
+
+// ListQuarantinedBlocks returns summaries of held blocks.
+func (node *QuidnugNode) ListQuarantinedBlocks(domain string) []QuarantinedBlockSummary {
+	return node.BlockQuarantine.list(domain)
+}
+
+// GetQuarantinedBlock returns one held block by hash.
+func (node *QuidnugNode) GetQuarantinedBlock(hash string) (QuarantinedBlock, bool) {
+	return node.BlockQuarantine.get(hash)
+}
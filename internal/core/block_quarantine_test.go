@@ -0,0 +1,154 @@
+// Block quarantine tests: untrusted blocks are held, listed, and
+// accepted or purged only through admin-signed review.
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// receiveUntrustedBlock delivers a block from a validator the
+// receiver has no trust in, returning the receiver and the block.
+func receiveUntrustedBlock(t *testing.T) (*QuidnugNode, Block) {
+	t.Helper()
+	validatorNode := newTestNode()
+	receiverNode := newTestNode()
+	td := TrustDomain{
+		Name:                "testdomain",
+		ValidatorNodes:      []string{validatorNode.NodeID},
+		TrustThreshold:      0.5,
+		ValidatorPublicKeys: map[string]string{validatorNode.NodeID: validatorNode.GetPublicKeyHex()},
+	}
+	validatorNode.TrustDomains["testdomain"] = td
+	receiverNode.TrustDomains["testdomain"] = td
+	receiverNode.Blockchain = validatorNode.Blockchain
+
+	block := Block{
+		Index:        1,
+		Timestamp:    1234567890,
+		Transactions: []interface{}{},
+		PrevHash:     validatorNode.Blockchain[0].Hash,
+		TrustProof: TrustProof{
+			TrustDomain: "testdomain",
+			ValidatorID: validatorNode.NodeID,
+		},
+	}
+	signBlock(validatorNode, &block)
+
+	acceptance, err := receiverNode.ReceiveBlock(block)
+	if err != nil || acceptance != BlockUntrusted {
+		t.Fatalf("expected untrusted block, got %v (%v)", acceptance, err)
+	}
+	return receiverNode, block
+}
+
+func signReview(t *testing.T, node *QuidnugNode, action, hash string) QuarantineReviewRequest {
+	t.Helper()
+	req := QuarantineReviewRequest{
+		Action:    action,
+		BlockHash: hash,
+		Timestamp: time.Now().Unix(),
+		PublicKey: node.GetPublicKeyHex(),
+	}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+func TestBlockQuarantine_HoldsUntrustedBlock(t *testing.T) {
+	node, block := receiveUntrustedBlock(t)
+
+	held := node.ListQuarantinedBlocks("testdomain")
+	if len(held) != 1 || held[0].Hash != block.Hash {
+		t.Fatalf("expected the untrusted block in quarantine, got %+v", held)
+	}
+	if got := node.ListQuarantinedBlocks("other"); len(got) != 0 {
+		t.Errorf("domain filter leaked %d entries", len(got))
+	}
+}
+
+func TestBlockQuarantine_EvictsOldest(t *testing.T) {
+	q := NewBlockQuarantine(2)
+	for _, h := range []string{"a", "b", "c"} {
+		q.add(Block{Hash: h}, "test")
+	}
+	if _, ok := q.get("a"); ok {
+		t.Error("oldest block should have been evicted")
+	}
+	if _, ok := q.get("c"); !ok {
+		t.Error("newest block should be held")
+	}
+}
+
+func TestBlockQuarantine_AcceptCommitsBlock(t *testing.T) {
+	node, block := receiveUntrustedBlock(t)
+
+	if err := node.ReviewQuarantinedBlock(signReview(t, node, QuarantineActionAccept, block.Hash)); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	node.BlockchainMutex.RLock()
+	head := node.Blockchain[len(node.Blockchain)-1]
+	node.BlockchainMutex.RUnlock()
+	if head.Hash != block.Hash {
+		t.Error("accepted block should be appended to the chain")
+	}
+	if _, ok := node.GetQuarantinedBlock(block.Hash); ok {
+		t.Error("accepted block should leave quarantine")
+	}
+}
+
+func TestBlockQuarantine_RejectsForeignSigner(t *testing.T) {
+	node, block := receiveUntrustedBlock(t)
+	stranger := newTestNode()
+
+	err := node.ReviewQuarantinedBlock(signReview(t, stranger, QuarantineActionPurge, block.Hash))
+	if err != ErrAdminKey {
+		t.Fatalf("expected ErrAdminKey, got %v", err)
+	}
+
+	stale := signReview(t, node, QuarantineActionPurge, block.Hash)
+	stale.Timestamp -= int64(2 * AdminRequestMaxSkew / time.Second)
+	if err := node.ReviewQuarantinedBlock(stale); err != ErrAdminStale {
+		t.Fatalf("expected ErrAdminStale, got %v", err)
+	}
+
+	tampered := signReview(t, node, QuarantineActionPurge, block.Hash)
+	tampered.Action = QuarantineActionAccept
+	if err := node.ReviewQuarantinedBlock(tampered); err != ErrAdminSignature {
+		t.Fatalf("expected ErrAdminSignature, got %v", err)
+	}
+
+	if _, ok := node.GetQuarantinedBlock(block.Hash); !ok {
+		t.Error("rejected reviews must leave the block in place")
+	}
+}
+
+func TestReviewQuarantinedBlockHandler_Purge(t *testing.T) {
+	node, block := receiveUntrustedBlock(t)
+	router := setupTestRouter(node)
+
+	body, _ := json.Marshal(signReview(t, node, QuarantineActionPurge, block.Hash))
+	req := httptest.NewRequest("POST", "/api/v1/blocks/quarantine/"+block.Hash+"/purge", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/blocks/quarantine/"+block.Hash, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("purged block should 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,238 @@
+// Streaming block replay for indexers.
+//
+// GET /blocks/stream?fromHeight=N replays the trusted chain from
+// height N and then keeps the connection open, emitting each new
+// block as it is appended. Output is NDJSON (one block per line) by
+// default, or Server-Sent Events with format=sse or an
+// "Accept: text/event-stream" header. Indexers can resume after a
+// disconnect from the last height they saw; SSE clients get that
+// for free through Last-Event-ID.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Block stream tuning.
+const (
+	// DefaultBlockStreamHeartbeat is how often an idle stream writes
+	// a keepalive so proxies don't reap the connection.
+	DefaultBlockStreamHeartbeat = 15 * time.Second
+	// blockStreamChunk is how many blocks are copied per lock hold
+	// while replaying history.
+	blockStreamChunk = 100
+)
+
+// BlockFeed signals that the trusted chain grew. Waiters grab the
+// current channel with Changed and wake when it is closed; notify
+// closes it and installs a fresh one. Owns its own lock.
+type BlockFeed struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// NewBlockFeed creates an empty feed.
+func NewBlockFeed() *BlockFeed {
+	return &BlockFeed{ch: make(chan struct{})}
+}
+
+// Changed returns a channel closed on the next append.
+func (f *BlockFeed) Changed() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ch
+}
+
+func (f *BlockFeed) notify() {
+	f.mu.Lock()
+	close(f.ch)
+	f.ch = make(chan struct{})
+	f.mu.Unlock()
+}
+
+// announceBlock wakes block stream subscribers after an append to
+// node.Blockchain.
+func (node *QuidnugNode) announceBlock() {
+	if node.BlockFeed != nil {
+		node.BlockFeed.notify()
+	}
+}
+
+// blocksFrom copies up to max trusted blocks with Index >= height,
+// returning them and the height to resume from.
+func (node *QuidnugNode) blocksFrom(height int64, max int) ([]Block, int64) {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+
+	chain := node.Blockchain
+	start := sort.Search(len(chain), func(i int) bool { return chain[i].Index >= height })
+	end := start + max
+	if end > len(chain) {
+		end = len(chain)
+	}
+	if start == end {
+		return nil, height
+	}
+	out := make([]Block, end-start)
+	copy(out, chain[start:end])
+	return out, out[len(out)-1].Index + 1
+}
+
+// StreamBlocksHandler streams historical then live blocks.
+// Query params: fromHeight (default 0), domain, format (ndjson|sse).
+func (node *QuidnugNode) StreamBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	height, sse, ok := parseChainStreamParams(w, r)
+	if !ok {
+		return
+	}
+	domain := r.URL.Query().Get("domain")
+	node.streamChain(w, r, height, sse, func(block Block) []streamItem {
+		if domain != "" && block.TrustProof.TrustDomain != domain {
+			return nil
+		}
+		return []streamItem{{event: "block", data: block}}
+	})
+}
+
+// parseChainStreamParams reads the fromHeight, format and
+// Last-Event-ID parameters shared by chain streams, writing a 400
+// when they are malformed.
+func parseChainStreamParams(w http.ResponseWriter, r *http.Request) (int64, bool, bool) {
+	q := r.URL.Query()
+	sse := q.Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if f := q.Get("format"); f != "" && f != "sse" && f != "ndjson" {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "format must be ndjson or sse")
+		return 0, false, false
+	}
+	height, ok := parseStreamHeight(w, r, sse)
+	return height, sse, ok
+}
+
+// parseStreamHeight returns the height a stream starts at: fromHeight,
+// or for SSE the block after Last-Event-ID when the client sent one.
+func parseStreamHeight(w http.ResponseWriter, r *http.Request, sse bool) (int64, bool) {
+	var height int64
+	if v := r.URL.Query().Get("fromHeight"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "fromHeight must be a non-negative integer")
+			return 0, false
+		}
+		height = parsed
+	}
+	if last := r.Header.Get("Last-Event-ID"); sse && last != "" {
+		if parsed, err := strconv.ParseInt(last, 10, 64); err == nil && parsed >= 0 {
+			height = parsed + 1
+		}
+	}
+	return height, true
+}
+
+// streamItem is one record written to a chain stream: an SSE event
+// name and its JSON payload.
+type streamItem struct {
+	event string
+	data  interface{}
+}
+
+// streamChain replays the trusted chain from height and then follows
+// it, writing whatever project makes of each block. Only the last
+// item of a block carries the block height as its SSE id, so
+// Last-Event-ID resumes after the last block the client saw in full
+// and a block cut off part way is sent again.
+func (node *QuidnugNode) streamChain(w http.ResponseWriter, r *http.Request, height int64, sse bool, project func(Block) []streamItem) {
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout by design.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "cannot stream on this connection")
+		return
+	}
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(DefaultBlockStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		// Grab the wakeup channel before reading so an append
+		// between the read and the wait is not missed.
+		var changed <-chan struct{}
+		if node.BlockFeed != nil {
+			changed = node.BlockFeed.Changed()
+		}
+
+		blocks, next := node.blocksFrom(height, blockStreamChunk)
+		height = next
+		wrote := false
+		for _, block := range blocks {
+			items := project(block)
+			for i, item := range items {
+				if err := writeStreamItem(w, block.Index, item, sse, i == len(items)-1); err != nil {
+					return
+				}
+				wrote = true
+			}
+		}
+		if wrote {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		if len(blocks) == blockStreamChunk {
+			continue
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-heartbeat.C:
+			keepalive := "\n"
+			if sse {
+				keepalive = ": keepalive\n\n"
+			}
+			if _, err := w.Write([]byte(keepalive)); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeStreamItem writes one item as an NDJSON line or an SSE event,
+// giving the SSE event the height of the block it came from as its
+// id when last is set.
+func writeStreamItem(w http.ResponseWriter, height int64, item streamItem, sse, last bool) error {
+	data, err := json.Marshal(item.data)
+	if err != nil {
+		return err
+	}
+	if sse {
+		var id string
+		if last {
+			id = "id: " + strconv.FormatInt(height, 10) + "\n"
+		}
+		_, err = w.Write([]byte(id + "event: " + item.event + "\ndata: " + string(data) + "\n\n"))
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appendTestBlock appends a block the way the trusted-tier path does.
+func appendTestBlock(node *QuidnugNode, domain string) Block {
+	node.BlockchainMutex.Lock()
+	block := Block{
+		Index:      node.Blockchain[len(node.Blockchain)-1].Index + 1,
+		Timestamp:  time.Now().Unix(),
+		TrustProof: TrustProof{TrustDomain: domain},
+	}
+	node.Blockchain = append(node.Blockchain, block)
+	node.BlockchainMutex.Unlock()
+	node.announceBlock()
+	return block
+}
+
+// openBlockStream starts a stream request and returns a line reader.
+func openBlockStream(t *testing.T, srv *httptest.Server, query string, header http.Header) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/blocks/stream"+query, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func readStreamedBlock(t *testing.T, rd *bufio.Reader) Block {
+	t.Helper()
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block Block
+	if err := json.Unmarshal([]byte(line), &block); err != nil {
+		t.Fatalf("bad NDJSON line %q: %v", line, err)
+	}
+	return block
+}
+
+func TestStreamBlocks_ReplayThenLive(t *testing.T) {
+	node := newTestNode()
+	first := appendTestBlock(node, "a.example")
+	appendTestBlock(node, "b.example")
+	srv := httptest.NewServer(setupTestRouter(node))
+	t.Cleanup(srv.Close) // runs after the stream's cancel
+
+	rd := openBlockStream(t, srv, "?fromHeight=1&domain=a.example", nil)
+	if got := readStreamedBlock(t, rd); got.Index != first.Index {
+		t.Fatalf("expected replay of block %d, got %d", first.Index, got.Index)
+	}
+
+	// The b.example block was filtered; the next line is live.
+	appendTestBlock(node, "b.example")
+	live := appendTestBlock(node, "a.example")
+	if got := readStreamedBlock(t, rd); got.Index != live.Index {
+		t.Errorf("expected live block %d, got %d", live.Index, got.Index)
+	}
+}
+
+func TestStreamBlocks_SSEResumesFromLastEventID(t *testing.T) {
+	node := newTestNode()
+	appendTestBlock(node, "a.example")
+	second := appendTestBlock(node, "a.example")
+	srv := httptest.NewServer(setupTestRouter(node))
+	t.Cleanup(srv.Close) // runs after the stream's cancel
+
+	rd := openBlockStream(t, srv, "", http.Header{
+		"Accept":        {"text/event-stream"},
+		"Last-Event-Id": {"1"},
+	})
+	id, _ := rd.ReadString('\n')
+	if strings.TrimSpace(id) != "id: 2" || second.Index != 2 {
+		t.Errorf("expected to resume at height 2, got %q", id)
+	}
+	event, _ := rd.ReadString('\n')
+	if strings.TrimSpace(event) != "event: block" {
+		t.Errorf("unexpected event line %q", event)
+	}
+}
+
+func TestStreamBlocks_BadParams(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	for _, q := range []string{"?fromHeight=-1", "?fromHeight=x", "?format=xml"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/blocks/stream"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestStreamChainEvents_SSE(t *testing.T) {
+	node := newTestNode()
+	appendTestBlock(node, "a.example")
+	node.BlockchainMutex.Lock()
+	node.Blockchain = append(node.Blockchain, watchTestBlock(2))
+	node.BlockchainMutex.Unlock()
+	srv := httptest.NewServer(setupTestRouter(node))
+	t.Cleanup(srv.Close) // runs after the stream's cancel
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/stream?kinds=trust,title", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected SSE, got %q", ct)
+	}
+
+	// Block 2's trust event, then its title event, which alone
+	// carries the block id.
+	rd := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 6 {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "event: trust" || lines[3] != "id: 2" || lines[4] != "event: title" {
+		t.Errorf("unexpected stream %q", lines)
+	}
+}
+
+func TestStreamChainEvents_BadKind(t *testing.T) {
+	w := httptest.NewRecorder()
+	setupTestRouter(newTestNode()).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stream?kinds=nope", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -41,9 +41,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -89,6 +89,56 @@ func (node *QuidnugNode) runBlockSyncLoop(ctx context.Context) {
 	}
 }
 
+// SyncBlocksOnce runs one block sync pass against every known
+// peer and returns once all pulls finish. Run() syncs on a timer
+// and does not wait; harnesses that step a network themselves
+// (simnet) need the pass complete before they inspect state.
+func (node *QuidnugNode) SyncBlocksOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range node.blockSyncPeers() {
+		wg.Add(1)
+		go func(id, addr string) {
+			defer wg.Done()
+			_ = node.pullBlocksFromPeer(ctx, id, addr)
+		}(p.ID, p.Address)
+	}
+	wg.Wait()
+}
+
+// blockSyncPeer is a peer snapshot taken for one sync pass.
+type blockSyncPeer struct {
+	ID      string
+	Address string
+}
+
+// blockSyncPeers snapshots the non-quarantined peers that serve
+// blocks, so a sync pass doesn't hold KnownNodesMutex during HTTP
+// calls.
+func (node *QuidnugNode) blockSyncPeers() []blockSyncPeer {
+	node.KnownNodesMutex.RLock()
+	peers := make([]blockSyncPeer, 0, len(node.KnownNodes))
+	for _, n := range node.KnownNodes {
+		// Gateways only proxy queries; they hold no chain.
+		if n.Address == "" || !n.servesBlocks() {
+			continue
+		}
+		// A peer past our protocol window serves blocks we
+		// cannot validate.
+		if !n.protocolCompatible() {
+			continue
+		}
+		if node.PeerScoreboard != nil && node.PeerScoreboard.IsQuarantined(n.ID) {
+			continue
+		}
+		if node.peerBanned(n.ID) {
+			continue
+		}
+		peers = append(peers, blockSyncPeer{ID: n.ID, Address: n.Address})
+	}
+	node.KnownNodesMutex.RUnlock()
+	return peers
+}
+
 // runBlockSyncOnce performs one sync pass. For each non-
 // quarantined peer in KnownNodes, walks the peer's chain and
 // applies any blocks not already present locally (hash-dedup'd).
@@ -99,33 +149,10 @@ func (node *QuidnugNode) runBlockSyncLoop(ctx context.Context) {
 // pullBlocksFromPeer now builds its own dedup set from the
 // local chain at the start of each pull.
 func (node *QuidnugNode) runBlockSyncOnce(ctx context.Context) {
-	// Snapshot peer list under the lock so the sync run
-	// doesn't hold KnownNodesMutex during HTTP calls.
-	type peerView struct {
-		ID      string
-		Address string
-	}
-	node.KnownNodesMutex.RLock()
-	peers := make([]peerView, 0, len(node.KnownNodes))
-	for _, n := range node.KnownNodes {
-		if n.Address == "" {
-			continue
-		}
-		peers = append(peers, peerView{ID: n.ID, Address: n.Address})
-	}
-	node.KnownNodesMutex.RUnlock()
-
-	if len(peers) == 0 {
-		return
-	}
-
 	// Pull from each non-quarantined peer concurrently. Capped
 	// at the existing httpClient's connection pool size (no
 	// explicit limit here).
-	for _, p := range peers {
-		if node.PeerScoreboard != nil && node.PeerScoreboard.IsQuarantined(p.ID) {
-			continue
-		}
+	for _, p := range node.blockSyncPeers() {
 		go node.pullBlocksFromPeer(ctx, p.ID, p.Address)
 	}
 }
@@ -137,7 +164,7 @@ func (node *QuidnugNode) runBlockSyncOnce(ctx context.Context) {
 // ENG-82: signature dropped the tipIdx int64 parameter that no
 // longer drives anything inside (hash-dedup superseded it).
 func (node *QuidnugNode) PullBlocksFromPeerForTest(ctx context.Context, nodeQuid, addr string) {
-	node.pullBlocksFromPeer(ctx, nodeQuid, addr)
+	_ = node.pullBlocksFromPeer(ctx, nodeQuid, addr)
 }
 
 // pullBlocksFromPeer paginates through one peer's /api/v1/blocks
@@ -159,8 +186,10 @@ func (node *QuidnugNode) PullBlocksFromPeerForTest(ctx context.Context, nodeQuid
 //
 // Failure is logged at warn (ENG-79: previously debug, which
 // masked the silent-non-convergence bug); the peer's score
-// takes a hit on transport failures.
-func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr string) {
+// takes a hit on transport failures. The returned error is nil
+// once at least one page was read; replica failover keys off it,
+// the fan-out in runBlockSyncOnce ignores it.
+func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr string) error {
 	// SSRF gate: peer-advertised address goes through the
 	// sanitizer just like every other outbound dial.
 	// ENG-79: use the node-method variant so per-peer
@@ -174,7 +203,7 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 		// running a healthy mesh should NOT see this line.
 		logger.Warn("block sync: refusing peer with invalid address",
 			"nodeQuid", nodeQuid, "address", addr, "error", err)
-		return
+		return err
 	}
 
 	// Snapshot local block hashes once at cycle start. New
@@ -201,6 +230,7 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 		lastHTTPError error
 	)
 
+	format := node.wireFormatForQuid(nodeQuid)
 	for pagesFetched < blockSyncMaxPages {
 		pagesFetched++
 
@@ -215,13 +245,15 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 			lastHTTPError = err
 			break
 		}
+		setWireAccept(req, format)
+		setSyncAcceptEncoding(req)
 		resp, err := node.httpClient.Do(req) // #nosec -- url built from sanitized address; transport enforces safedial
 		if err != nil {
 			cancel()
 			logger.Debug("block sync: dial failed",
 				"nodeQuid", nodeQuid, "page", pagesFetched, "error", err)
 			node.recordPeerScore(nodeQuid, EventClassQuery, false, "block-sync dial: "+err.Error())
-			return
+			return err
 		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 			logger.Debug("block sync: non-2xx",
@@ -230,11 +262,14 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 				"block-sync status "+strconv.Itoa(resp.StatusCode))
 			_ = resp.Body.Close()
 			cancel()
-			return
+			return fmt.Errorf("block sync: status %d from %s", resp.StatusCode, addr)
 		}
-		body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+		body, err := readSyncBody(resp, "blocks", 16<<20)
 		_ = resp.Body.Close()
 		cancel()
+		if err == nil {
+			body, err = decodeWireBody(resp.Header, body)
+		}
 		if err != nil {
 			lastHTTPError = err
 			break
@@ -284,9 +319,15 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 					"error", err)
 				// A peer serving us blocks we can't accept gets
 				// a validation hit. Repeat offenders fall through
-				// the scoring system into quarantine.
-				node.recordPeerScore(nodeQuid, EventClassValidation, false,
-					fmt.Sprintf("block %d rejected: %v", b.Index, err))
+				// the scoring system into quarantine; blocks that
+				// fail validation outright count as offenses and
+				// can get the peer banned.
+				note := fmt.Sprintf("block %d rejected: %v", b.Index, err)
+				if acceptance == BlockInvalid {
+					node.reportPeerOffense(nodeQuid, PeerOffenseInvalidBlock, note)
+				} else {
+					node.recordPeerScore(nodeQuid, EventClassValidation, false, note)
+				}
 				continue
 			}
 			if acceptance == BlockTrusted {
@@ -313,6 +354,10 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 	if lastHTTPError != nil {
 		logger.Debug("block sync: pagination ended on error",
 			"nodeQuid", nodeQuid, "page", pagesFetched, "error", lastHTTPError)
+		if pagesFetched == 1 {
+			// Not even one page came back intact.
+			return lastHTTPError
+		}
 	}
 	if totalApplied > 0 || totalSkipped > 0 {
 		logger.Info("Block sync cycle complete",
@@ -323,4 +368,5 @@ func (node *QuidnugNode) pullBlocksFromPeer(ctx context.Context, nodeQuid, addr
 			"localChainLen", len(seenHashes))
 	}
 	node.recordPeerScore(nodeQuid, EventClassQuery, true, "")
+	return nil
 }
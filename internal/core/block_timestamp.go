@@ -0,0 +1,407 @@
+// Package core — block_timestamp.go
+//
+// RFC 3161 timestamping of committed blocks.
+//
+// With timestamp_authority_url set, the node follows its chain and
+// asks the time-stamping authority (TSA) to timestamp each block's
+// hash. A block hash is already a SHA-256 digest, so it is sent as
+// the message imprint unchanged, and the returned token attests
+// that the block existed at the token's genTime, whatever the
+// block's own Timestamp claims.
+//
+// Before a token is stored the node checks that the reply granted
+// it, that its imprint is the block hash and that it echoes the
+// request nonce. The TSA's CMS signature is not checked here: the
+// token is kept exactly as received (it includes the TSA
+// certificate) so a client verifies it against the TSA's trust
+// chain with standard tooling, e.g.
+//
+//	openssl ts -verify -digest <blockHash> -in token.der -token_in -CAfile tsa-ca.pem
+//
+// Tokens are appended to block_timestamps.ndjson in the data
+// directory and served at GET /blocks/{hash}/timestamp.
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// Block timestamping tuning.
+const (
+	// blockTimestampRetryMin and blockTimestampRetryMax bound the
+	// backoff after the TSA fails.
+	blockTimestampRetryMin = 5 * time.Second
+	blockTimestampRetryMax = 10 * time.Minute
+
+	// blockTimestampTimeout bounds one TSA request.
+	blockTimestampTimeout = 30 * time.Second
+
+	// maxTimestampReplyBytes bounds a TSA reply.
+	maxTimestampReplyBytes = 64 << 10
+)
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidCMSSignedData   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	errTimestampDenied = errors.New("timestamp request not granted")
+)
+
+// BlockTimestamp is a TSA token for one block.
+type BlockTimestamp struct {
+	BlockHash string `json:"blockHash"`
+	Authority string `json:"authority"`
+	// GenTime is the time the TSA attests, from the token.
+	GenTime time.Time `json:"genTime"`
+	// SerialNumber is the TSA's serial for the token, in decimal.
+	SerialNumber string `json:"serialNumber"`
+	// Token is the DER timeStampToken (a CMS ContentInfo), base64.
+	Token string `json:"token"`
+}
+
+// RFC 3161 structures, limited to what the node sends and checks.
+
+type tsaMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsaRequest struct {
+	Version        int
+	MessageImprint tsaMessageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+type tsaStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type tsaResponse struct {
+	Status         tsaStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type cmsEncapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo cmsEncapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+type tsaAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tsaTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tsaMessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       tsaAccuracy   `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// BlockTimestamper requests, stores and serves block timestamps.
+type BlockTimestamper struct {
+	authority string
+	client    *http.Client
+	path      string
+
+	mu     sync.RWMutex
+	tokens map[string]BlockTimestamp // block hash -> token
+}
+
+// newBlockTimestamper builds the timestamper from cfg, or returns
+// nil when timestamp_authority_url is unset.
+func newBlockTimestamper(cfg *config.Config) (*BlockTimestamper, error) {
+	if cfg.TimestampAuthorityURL == "" {
+		return nil, nil
+	}
+	t := &BlockTimestamper{
+		authority: cfg.TimestampAuthorityURL,
+		client:    &http.Client{Timeout: blockTimestampTimeout},
+		tokens:    make(map[string]BlockTimestamp),
+	}
+	if cfg.DataDir != "" {
+		t.path = filepath.Join(cfg.DataDir, "block_timestamps.ndjson")
+		if err := t.load(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Get returns the timestamp for a block hash.
+func (t *BlockTimestamper) Get(blockHash string) (BlockTimestamp, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ts, ok := t.tokens[blockHash]
+	return ts, ok
+}
+
+func (t *BlockTimestamper) has(blockHash string) bool {
+	_, ok := t.Get(blockHash)
+	return ok
+}
+
+// load reads the stored tokens; a missing file is a clean start.
+func (t *BlockTimestamper) load() error {
+	raw, err := safeio.ReadFile(t.path)
+	if err != nil {
+		return nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64<<10), 2*maxTimestampReplyBytes)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ts BlockTimestamp
+		if err := json.Unmarshal(scanner.Bytes(), &ts); err != nil {
+			return fmt.Errorf("parse %s: %w", t.path, err)
+		}
+		t.tokens[ts.BlockHash] = ts
+	}
+	return scanner.Err()
+}
+
+// store records a token and appends it to the data file.
+func (t *BlockTimestamper) store(ts BlockTimestamp) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens[ts.BlockHash] = ts
+	if t.path == "" {
+		return nil
+	}
+	line, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+	clean, err := safeio.ValidatePath(t.path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(clean, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- path validated by safeio.ValidatePath
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// stamp obtains and checks a token for one block.
+func (t *BlockTimestamper) stamp(ctx context.Context, blockHash string) (BlockTimestamp, error) {
+	digest, err := hex.DecodeString(blockHash)
+	if err != nil || len(digest) != 32 {
+		return BlockTimestamp{}, fmt.Errorf("block hash %q is not a SHA-256 digest", blockHash)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+	body, err := asn1.Marshal(tsaRequest{
+		Version: 1,
+		MessageImprint: tsaMessageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.authority, bytes.NewReader(body))
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, maxTimestampReplyBytes))
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return BlockTimestamp{}, fmt.Errorf("TSA returned HTTP %d", resp.StatusCode)
+	}
+
+	token, info, err := parseTimestampReply(reply)
+	if err != nil {
+		return BlockTimestamp{}, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return BlockTimestamp{}, errors.New("token imprint does not match the block hash")
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return BlockTimestamp{}, errors.New("token nonce does not match the request")
+	}
+	return BlockTimestamp{
+		BlockHash:    blockHash,
+		Authority:    t.authority,
+		GenTime:      info.GenTime.UTC(),
+		SerialNumber: info.SerialNumber.String(),
+		Token:        base64.StdEncoding.EncodeToString(token),
+	}, nil
+}
+
+// parseTimestampReply returns the token and its TSTInfo from a DER
+// TimeStampResp.
+func parseTimestampReply(reply []byte) ([]byte, tsaTSTInfo, error) {
+	var info tsaTSTInfo
+	var resp tsaResponse
+	if _, err := asn1.Unmarshal(reply, &resp); err != nil {
+		return nil, info, fmt.Errorf("parse TSA reply: %w", err)
+	}
+	// 0 granted, 1 grantedWithMods.
+	if resp.Status.Status > 1 {
+		return nil, info, fmt.Errorf("%w: PKIStatus %d", errTimestampDenied, resp.Status.Status)
+	}
+	token := resp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, info, fmt.Errorf("%w: no token in reply", errTimestampDenied)
+	}
+	tstInfo, err := timestampTokenInfo(token)
+	if err != nil {
+		return nil, info, err
+	}
+	if _, err := asn1.Unmarshal(tstInfo, &info); err != nil {
+		return nil, info, fmt.Errorf("parse TSTInfo: %w", err)
+	}
+	if info.SerialNumber == nil {
+		return nil, info, errors.New("TSTInfo has no serial number")
+	}
+	return token, info, nil
+}
+
+// timestampTokenInfo extracts the DER TSTInfo a token signs.
+func timestampTokenInfo(token []byte) ([]byte, error) {
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(token, &ci); err != nil {
+		return nil, fmt.Errorf("parse token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidCMSSignedData) {
+		return nil, fmt.Errorf("token content type %v is not signed data", ci.ContentType)
+	}
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("parse token signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.EncapContentInfo.EContent) == 0 {
+		return nil, errors.New("token does not carry a TSTInfo")
+	}
+	return sd.EncapContentInfo.EContent, nil
+}
+
+// timestampBlocks stamps every block from height on that has no
+// token yet, returning the height to resume from.
+func (node *QuidnugNode) timestampBlocks(ctx context.Context, height int64) (int64, error) {
+	t := node.BlockTimestamps
+	for {
+		blocks, next := node.blocksFrom(height, blockStreamChunk)
+		for _, block := range blocks {
+			if t.has(block.Hash) {
+				continue
+			}
+			ts, err := t.stamp(ctx, block.Hash)
+			if err != nil {
+				return block.Index, fmt.Errorf("block %d: %w", block.Index, err)
+			}
+			if err := t.store(ts); err != nil {
+				return block.Index, fmt.Errorf("storing timestamp for block %d: %w", block.Index, err)
+			}
+		}
+		height = next
+		if len(blocks) < blockStreamChunk {
+			return height, nil
+		}
+	}
+}
+
+// runBlockTimestampLoop timestamps blocks as they commit until ctx
+// is done. No-op when no TSA is configured.
+func (node *QuidnugNode) runBlockTimestampLoop(ctx context.Context) {
+	if node.BlockTimestamps == nil {
+		return
+	}
+	var height int64
+	backoff := blockTimestampRetryMin
+	for {
+		var changed <-chan struct{}
+		if node.BlockFeed != nil {
+			changed = node.BlockFeed.Changed()
+		}
+		next, err := node.timestampBlocks(ctx, height)
+		height = next
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("Block timestamping failed", "error", err, "retryIn", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > blockTimestampRetryMax {
+				backoff = blockTimestampRetryMax
+			}
+			continue
+		}
+		backoff = blockTimestampRetryMin
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// fakeTSA answers RFC 3161 requests with an unsigned token built
+// from the request, or with the given status and a tampered imprint
+// when asked to misbehave.
+type fakeTSA struct {
+	status       int
+	wrongImprint bool
+}
+
+func (f *fakeTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req tsaRequest
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.status != 0 {
+		reply, _ := asn1.Marshal(tsaResponse{Status: tsaStatusInfo{Status: f.status}})
+		_, _ = w.Write(reply)
+		return
+	}
+	imprint := req.MessageImprint
+	if f.wrongImprint {
+		imprint.HashedMessage = bytes.Repeat([]byte{0xee}, 32)
+	}
+	info, _ := asn1.Marshal(tsaTSTInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4, 1},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(42),
+		GenTime:        time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Nonce:          req.Nonce,
+	})
+	emptySet := asn1.RawValue{Tag: asn1.TagSet, IsCompound: true}
+	signed, _ := asn1.Marshal(cmsSignedData{
+		Version:          3,
+		DigestAlgorithms: emptySet,
+		EncapContentInfo: cmsEncapContentInfo{EContentType: oidTSTInfo, EContent: info},
+		SignerInfos:      emptySet,
+	})
+	token, _ := asn1.Marshal(cmsContentInfo{
+		ContentType: oidCMSSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed},
+	})
+	reply, _ := asn1.Marshal(tsaResponse{TimeStampToken: asn1.RawValue{FullBytes: token}})
+	_, _ = w.Write(reply)
+}
+
+func TestBlockTimestamper_Stamp(t *testing.T) {
+	tsa := &fakeTSA{}
+	srv := httptest.NewServer(tsa)
+	defer srv.Close()
+	stamper, err := newBlockTimestamper(&config.Config{TimestampAuthorityURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := strings.Repeat("ab", 32)
+
+	ts, err := stamper.stamp(t.Context(), hash)
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	if ts.SerialNumber != "42" || !ts.GenTime.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("timestamp = %+v", ts)
+	}
+	der, _ := base64.StdEncoding.DecodeString(ts.Token)
+	if _, err := timestampTokenInfo(der); err != nil {
+		t.Errorf("stored token does not parse: %v", err)
+	}
+
+	tsa.wrongImprint = true
+	if _, err := stamper.stamp(t.Context(), hash); err == nil {
+		t.Error("token for another digest accepted")
+	}
+	tsa.wrongImprint = false
+	tsa.status = 2
+	if _, err := stamper.stamp(t.Context(), hash); !errors.Is(err, errTimestampDenied) {
+		t.Errorf("rejected request: err = %v", err)
+	}
+	if _, err := stamper.stamp(t.Context(), "not-hex"); err == nil {
+		t.Error("non-digest block hash accepted")
+	}
+}
+
+func TestTimestampBlocks_StoresAndServes(t *testing.T) {
+	srv := httptest.NewServer(&fakeTSA{})
+	defer srv.Close()
+	cfg := &config.Config{TimestampAuthorityURL: srv.URL, DataDir: t.TempDir()}
+
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = -1
+	stamper, err := newBlockTimestamper(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.BlockTimestamps = stamper
+
+	block, err := node.SyntheticTrustBlock("tsa.local", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	if _, err := node.timestampBlocks(t.Context(), 0); err != nil {
+		t.Fatalf("timestampBlocks: %v", err)
+	}
+	for _, b := range node.Blockchain {
+		if !stamper.has(b.Hash) {
+			t.Errorf("block %d not timestamped", b.Index)
+		}
+	}
+
+	reloaded, err := newBlockTimestamper(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.Get(block.Hash); !ok {
+		t.Error("timestamps not persisted")
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/blocks/"+block.Hash+"/timestamp?format=der", nil)
+	req = mux.SetURLVars(req, map[string]string{"hash": block.Hash})
+	rr := httptest.NewRecorder()
+	node.GetBlockTimestampHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler = %d %s", rr.Code, rr.Body.String())
+	}
+	if _, err := timestampTokenInfo(rr.Body.Bytes()); err != nil {
+		t.Errorf("served DER token does not parse: %v", err)
+	}
+}
@@ -29,7 +29,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -303,6 +302,7 @@ func (node *QuidnugNode) fetchSnapshotFromPeer(ctx context.Context, peer Node, d
 	if err != nil {
 		return NonceSnapshot{}, fmt.Errorf("bootstrap: new request: %w", err)
 	}
+	setSyncAcceptEncoding(req)
 	resp, err := node.httpClient.Do(req)
 	if err != nil {
 		return NonceSnapshot{}, fmt.Errorf("bootstrap: do: %w", err)
@@ -314,7 +314,7 @@ func (node *QuidnugNode) fetchSnapshotFromPeer(ctx context.Context, peer Node, d
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return NonceSnapshot{}, fmt.Errorf("bootstrap: peer %s returned %d", peer.ID, resp.StatusCode)
 	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := readSyncBody(resp, "nonce-snapshot", 16<<20)
 	if err != nil {
 		return NonceSnapshot{}, fmt.Errorf("bootstrap: read: %w", err)
 	}
@@ -0,0 +1,301 @@
+// Package core — bulk_import.go
+//
+// The /admin/import endpoint runs a bulkimport pipeline inside the
+// node. The export and its mapping arrive in one admin-signed
+// request; every record becomes an IDENTITY or TITLE transaction
+// signed with the node's own key and admitted through the usual
+// AddIdentityTransaction / AddTitleTransaction path, so validation,
+// asset classes and gossip all apply. `quidnug-cli import` runs
+// the same pipeline from outside, signing with a quid file.
+//
+// Imports run in the background; GET /admin/import/{id} reports
+// progress. A job that stopped early (the write limiter stayed
+// closed, or the node shut down) can be resumed by posting the
+// same data with resumeJobId, which picks up after the last row
+// the old job handled. Titles name owners that must already be
+// committed identities, so import identities first and let them
+// reach a block before importing titles.
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/bulkimport"
+)
+
+// Import job states.
+const (
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportStopped = "stopped"
+)
+
+// Import errors.
+var (
+	ErrImportJobNotFound = errors.New("import job not found")
+	ErrImportJobRunning  = errors.New("import job is still running")
+)
+
+// ImportRequest is the admin-signed body that starts an import.
+// Signature covers the JSON encoding of the request with Signature
+// empty.
+type ImportRequest struct {
+	Mapping bulkimport.Mapping `json:"mapping"`
+	// Data is the CSV or JSONL export.
+	Data string `json:"data"`
+	// ResumeJobID continues a stopped job over the same data.
+	ResumeJobID string `json:"resumeJobId,omitempty"`
+	Timestamp   int64  `json:"timestamp"`
+	PublicKey   string `json:"publicKey"`
+	Signature   string `json:"signature"`
+}
+
+// ImportJob is the state of one import.
+type ImportJob struct {
+	ID        string              `json:"id"`
+	Kind      string              `json:"kind"`
+	Domain    string              `json:"trustDomain"`
+	Status    string              `json:"status"`
+	Error     string              `json:"error,omitempty"`
+	Progress  bulkimport.Progress `json:"progress"`
+	ResumedOf string              `json:"resumedOf,omitempty"`
+}
+
+// importJobs tracks the node's import jobs. The zero value is
+// ready to use.
+type importJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
+}
+
+func (j *importJobs) put(job *ImportJob) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.jobs == nil {
+		j.jobs = make(map[string]*ImportJob)
+	}
+	j.jobs[job.ID] = job
+}
+
+func (j *importJobs) update(id string, fn func(*ImportJob)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+func (j *importJobs) get(id string) (ImportJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return ImportJob{}, false
+	}
+	return *job, true
+}
+
+func (j *importJobs) list() []ImportJob {
+	j.mu.Lock()
+	out := make([]ImportJob, 0, len(j.jobs))
+	for _, job := range j.jobs {
+		out = append(out, *job)
+	}
+	j.mu.Unlock()
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].Progress.StartedAt > out[b].Progress.StartedAt
+	})
+	return out
+}
+
+// StartImport verifies an admin-signed request and starts the
+// import in the background. The returned job is its initial state.
+func (node *QuidnugNode) StartImport(req ImportRequest) (ImportJob, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return ImportJob{}, err
+	}
+	reader, err := bulkimport.NewReader(strings.NewReader(req.Data), req.Mapping)
+	if err != nil {
+		return ImportJob{}, err
+	}
+	digest := sha256.Sum256([]byte(req.Data))
+	opts := bulkimport.Options{Source: hex.EncodeToString(digest[:])}
+
+	if req.ResumeJobID != "" {
+		prior, ok := node.bulkImports.get(req.ResumeJobID)
+		if !ok {
+			return ImportJob{}, ErrImportJobNotFound
+		}
+		if prior.Status == ImportRunning {
+			return ImportJob{}, ErrImportJobRunning
+		}
+		opts.Resume = &prior.Progress
+	}
+
+	job := &ImportJob{
+		ID: seedID(struct {
+			Source, Kind, Resume string
+			At                   int64
+		}{opts.Source, req.Mapping.Kind, req.ResumeJobID, time.Now().UnixNano()}),
+		Kind:      req.Mapping.Kind,
+		Domain:    req.Mapping.TrustDomain,
+		Status:    ImportRunning,
+		ResumedOf: req.ResumeJobID,
+		Progress:  bulkimport.Progress{Source: opts.Source, Kind: req.Mapping.Kind, StartedAt: time.Now().Unix()},
+	}
+	if opts.Resume != nil {
+		job.Progress = *opts.Resume
+	}
+	node.bulkImports.put(job)
+	initial := *job
+
+	opts.OnBatch = func(p bulkimport.Progress) error {
+		node.bulkImports.update(job.ID, func(j *ImportJob) { j.Progress = p })
+		return nil
+	}
+	go func() {
+		progress, err := bulkimport.Run(context.Background(), reader, nodeImportSink{node}, opts)
+		node.bulkImports.update(job.ID, func(j *ImportJob) {
+			j.Progress = progress
+			j.Status = ImportDone
+			if err != nil {
+				j.Status = ImportStopped
+				j.Error = err.Error()
+			}
+		})
+		logger.Info("Bulk import finished",
+			"jobId", job.ID, "kind", job.Kind, "imported", progress.Imported,
+			"failed", progress.Failed, "lastLine", progress.LastLine, "error", err)
+	}()
+	return initial, nil
+}
+
+// GetImportJob returns an import job by ID.
+func (node *QuidnugNode) GetImportJob(id string) (ImportJob, bool) {
+	return node.bulkImports.get(id)
+}
+
+// ListImportJobs returns every import job, newest first.
+func (node *QuidnugNode) ListImportJobs() []ImportJob {
+	return node.bulkImports.list()
+}
+
+// nodeImportSink submits import records as transactions signed with
+// the node key.
+type nodeImportSink struct {
+	node *QuidnugNode
+}
+
+func (s nodeImportSink) Submit(_ context.Context, rec bulkimport.Record) (string, error) {
+	var (
+		txID string
+		err  error
+	)
+	switch rec.Kind {
+	case bulkimport.KindIdentity:
+		txID, err = s.submitIdentity(rec)
+	case bulkimport.KindTitle:
+		txID, err = s.submitTitle(rec)
+	default:
+		return "", fmt.Errorf("unknown record kind %q", rec.Kind)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		err = fmt.Errorf("%w: %v", bulkimport.ErrTransient, err)
+	}
+	return txID, err
+}
+
+// sign returns the node's signature over a transaction whose
+// fields, ID included, are final.
+func (s nodeImportSink) sign(signable interface{}) (string, error) {
+	data, err := txSignableBytes(signable)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.node.SignData(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+func (s nodeImportSink) submitIdentity(rec bulkimport.Record) (string, error) {
+	node := s.node
+	nonce := int64(1)
+	node.IdentityRegistryMutex.RLock()
+	if existing, ok := node.IdentityRegistry[rec.QuidID]; ok {
+		nonce = existing.UpdateNonce + 1
+	}
+	node.IdentityRegistryMutex.RUnlock()
+
+	tx := IdentityTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeIdentity,
+			TrustDomain: rec.TrustDomain,
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		QuidID:      rec.QuidID,
+		Name:        rec.Name,
+		Description: rec.Description,
+		Attributes:  rec.Attributes,
+		Creator:     node.NodeID,
+		UpdateNonce: nonce,
+		HomeDomain:  rec.HomeDomain,
+		AssetClass:  rec.AssetClass,
+	}
+	tx.ID = seedID(struct {
+		QuidID, Creator, TrustDomain string
+		UpdateNonce, Timestamp       int64
+	}{tx.QuidID, tx.Creator, tx.TrustDomain, tx.UpdateNonce, tx.Timestamp})
+	sig, err := s.sign(tx)
+	if err != nil {
+		return "", err
+	}
+	tx.Signature = sig
+	return node.AddIdentityTransaction(tx)
+}
+
+func (s nodeImportSink) submitTitle(rec bulkimport.Record) (string, error) {
+	node := s.node
+	if _, exists := node.GetAssetOwnership(rec.AssetID); exists {
+		return "", fmt.Errorf("asset %s already has a title", rec.AssetID)
+	}
+	owners := make([]OwnershipStake, 0, len(rec.Owners))
+	for _, o := range rec.Owners {
+		owners = append(owners, OwnershipStake{OwnerID: o.OwnerID, Percentage: o.Percentage, StakeType: o.StakeType})
+	}
+	tx := TitleTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeTitle,
+			TrustDomain: rec.TrustDomain,
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		AssetID:    rec.AssetID,
+		Owners:     owners,
+		TitleType:  rec.TitleType,
+		AssetClass: rec.AssetClass,
+	}
+	tx.ID = seedID(struct {
+		AssetID, TrustDomain string
+		Owners               []OwnershipStake
+		Timestamp            int64
+	}{tx.AssetID, tx.TrustDomain, tx.Owners, tx.Timestamp})
+	sig, err := s.sign(tx)
+	if err != nil {
+		return "", err
+	}
+	tx.Signature = sig
+	return node.AddTitleTransaction(tx)
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/bulkimport"
+)
+
+func signImport(t *testing.T, node *QuidnugNode, req ImportRequest) ImportRequest {
+	t.Helper()
+	req.Timestamp = time.Now().Unix()
+	req.PublicKey = node.GetPublicKeyHex()
+	req.Signature = ""
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+// waitImport polls a job until it leaves the running state.
+func waitImport(t *testing.T, node *QuidnugNode, id string) ImportJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := node.GetImportJob(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status != ImportRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s still running", id)
+	return ImportJob{}
+}
+
+func TestBulkImport_Identities(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	req := signImport(t, node, ImportRequest{
+		Mapping: bulkimport.Mapping{
+			Kind:        bulkimport.KindIdentity,
+			Format:      bulkimport.FormatCSV,
+			TrustDomain: "test.domain.com",
+			Fields:      map[string]string{"quidId": "id", "name": "owner"},
+			Attributes:  map[string]string{"parcel": "parcel_no"},
+		},
+		Data: "id,owner,parcel_no\n00000000000000a1,Alice,P-1\n,Nobody,P-2\n00000000000000b2,Bob,P-3\n",
+	})
+
+	body, _ := json.Marshal(req)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/import", bytes.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("start: expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data ImportJob `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	job := waitImport(t, node, resp.Data.ID)
+	if job.Status != ImportDone || job.Progress.Imported != 2 || job.Progress.Failed != 1 || job.Progress.LastLine != 3 {
+		t.Fatalf("job = %+v", job)
+	}
+	if f := job.Progress.Failures; len(f) != 1 || f[0].Line != 2 {
+		t.Errorf("failures = %+v", f)
+	}
+	imported := map[string]IdentityTransaction{}
+	node.PendingTxsMutex.RLock()
+	for _, tx := range node.PendingTxs {
+		if id, ok := tx.(IdentityTransaction); ok {
+			imported[id.QuidID] = id
+		}
+	}
+	node.PendingTxsMutex.RUnlock()
+	if len(imported) != 2 || imported["00000000000000a1"].Name != "Alice" || imported["00000000000000b2"].Attributes["parcel"] != "P-3" {
+		t.Errorf("pending identities = %+v", imported)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/import/"+job.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("get job: expected 200, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/import/unknown", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown job: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestBulkImport_Rejections(t *testing.T) {
+	node := newTestNode()
+	req := signImport(t, node, ImportRequest{
+		Mapping: bulkimport.Mapping{Kind: bulkimport.KindIdentity, Format: bulkimport.FormatCSV, TrustDomain: "test.domain.com",
+			Fields: map[string]string{"quidId": "id"}},
+		Data: "id\n00000000000000a1\n",
+	})
+	tampered := req
+	tampered.Data = "id\n00000000000000ff\n"
+	if _, err := node.StartImport(tampered); !errors.Is(err, ErrAdminSignature) {
+		t.Errorf("tampered request: err = %v", err)
+	}
+
+	bad := req
+	bad.Mapping.Fields = map[string]string{"quidId": "missing"}
+	if _, err := node.StartImport(signImport(t, node, bad)); !errors.Is(err, bulkimport.ErrInvalidMapping) {
+		t.Errorf("unknown column: err = %v", err)
+	}
+
+	resume := req
+	resume.ResumeJobID = "nope"
+	if _, err := node.StartImport(signImport(t, node, resume)); !errors.Is(err, ErrImportJobNotFound) {
+		t.Errorf("resume of unknown job: err = %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Normalized chain events.
+//
+// Consumers that follow the chain (watch lists, streams) mostly want
+// to know which quids and assets a committed block touched, not to
+// decode every transaction type themselves. blockEvents turns a block
+// into one "block" event followed by one event per transaction, each
+// naming the quids and asset the transaction involves and carrying
+// the transaction itself.
+//
+// GET /stream serves the whole feed as Server-Sent Events, for
+// clients behind proxies that will pass a long-lived HTTP response
+// but nothing else. Each event's SSE name is its kind, and the id on
+// a block's last event is the block height, so a reconnecting
+// EventSource resumes with Last-Event-ID; fromHeight picks the start
+// on a first connect.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Chain event kinds. Transaction types without a kind of their own
+// are reported as ChainEventTx.
+const (
+	ChainEventBlock    = "block"
+	ChainEventTrust    = "trust"
+	ChainEventIdentity = "identity"
+	ChainEventTitle    = "title"
+	ChainEventEvent    = "event"
+	ChainEventTx       = "tx"
+)
+
+// chainEventKinds lists the valid kinds, for request validation.
+var chainEventKinds = map[string]bool{
+	ChainEventBlock: true, ChainEventTrust: true, ChainEventIdentity: true,
+	ChainEventTitle: true, ChainEventEvent: true, ChainEventTx: true,
+}
+
+// ChainEvent is one normalized change from a committed block. Quids
+// and AssetID are what the transaction touched; on a block event
+// Quids is the union over the block.
+type ChainEvent struct {
+	Kind        string          `json:"kind"`
+	BlockIndex  int64           `json:"blockIndex"`
+	BlockHash   string          `json:"blockHash"`
+	TrustDomain string          `json:"trustDomain"`
+	TxID        string          `json:"txId,omitempty"`
+	TxType      TransactionType `json:"txType,omitempty"`
+	Quids       []string        `json:"quids,omitempty"`
+	AssetID     string          `json:"assetId,omitempty"`
+	Timestamp   int64           `json:"timestamp"`
+	Transaction json.RawMessage `json:"transaction,omitempty"`
+}
+
+// chainEventKind maps a transaction type to its event kind.
+func chainEventKind(t TransactionType) string {
+	switch t {
+	case TxTypeTrust:
+		return ChainEventTrust
+	case TxTypeIdentity:
+		return ChainEventIdentity
+	case TxTypeTitle:
+		return ChainEventTitle
+	case TxTypeEvent:
+		return ChainEventEvent
+	default:
+		return ChainEventTx
+	}
+}
+
+// txTouches picks out the fields, across transaction types, that
+// name a quid or an asset.
+type txTouches struct {
+	PublicKey      string           `json:"publicKey"`
+	Truster        string           `json:"truster"`
+	Trustee        string           `json:"trustee"`
+	QuidID         string           `json:"quidId"`
+	Creator        string           `json:"creator"`
+	SubjectID      string           `json:"subjectId"`
+	SubjectType    string           `json:"subjectType"`
+	SubjectQuid    string           `json:"subjectQuid"`
+	SuccessorQuid  string           `json:"successorQuid"`
+	LienholderQuid string           `json:"lienholderQuid"`
+	OwnerQuid      string           `json:"ownerQuid"`
+	TargetQuid     string           `json:"targetQuid"`
+	ApproverQuid   string           `json:"approverQuid"`
+	ModeratorQuid  string           `json:"moderatorQuid"`
+	ReporterQuid   string           `json:"reporterQuid"`
+	AssetID        string           `json:"assetId"`
+	Owners         []OwnershipStake `json:"owners"`
+	PreviousOwners []OwnershipStake `json:"previousOwners"`
+}
+
+// quids returns the distinct quids t names, the signer included,
+// sorted.
+func (t txTouches) quids() []string {
+	seen := map[string]bool{}
+	add := func(q string) {
+		if q != "" {
+			seen[q] = true
+		}
+	}
+	if t.PublicKey != "" {
+		add(QuidIDFromPublicKeyHex(t.PublicKey))
+	}
+	for _, q := range []string{t.Truster, t.Trustee, t.QuidID, t.Creator, t.SubjectQuid, t.SuccessorQuid,
+		t.LienholderQuid, t.OwnerQuid, t.TargetQuid, t.ApproverQuid, t.ModeratorQuid, t.ReporterQuid} {
+		add(q)
+	}
+	if t.SubjectType == "QUID" {
+		add(t.SubjectID)
+	}
+	for _, s := range t.Owners {
+		add(s.OwnerID)
+	}
+	for _, s := range t.PreviousOwners {
+		add(s.OwnerID)
+	}
+	out := make([]string, 0, len(seen))
+	for q := range seen {
+		out = append(out, q)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// asset returns the asset t names, if any.
+func (t txTouches) asset() string {
+	if t.AssetID != "" {
+		return t.AssetID
+	}
+	if t.SubjectType == "TITLE" {
+		return t.SubjectID
+	}
+	return ""
+}
+
+// blockEvents normalizes block into a block event followed by one
+// event per transaction, in block order.
+func blockEvents(block Block) []ChainEvent {
+	domain := block.TrustProof.TrustDomain
+	events := make([]ChainEvent, 1, len(block.Transactions)+1)
+	all := map[string]bool{}
+	for _, txInterface := range block.Transactions {
+		raw, err := json.Marshal(txInterface)
+		if err != nil {
+			continue
+		}
+		var base BaseTransaction
+		var touches txTouches
+		if json.Unmarshal(raw, &base) != nil || json.Unmarshal(raw, &touches) != nil {
+			continue
+		}
+		ev := ChainEvent{
+			Kind:        chainEventKind(base.Type),
+			BlockIndex:  block.Index,
+			BlockHash:   block.Hash,
+			TrustDomain: domain,
+			TxID:        base.ID,
+			TxType:      base.Type,
+			Quids:       touches.quids(),
+			AssetID:     touches.asset(),
+			Timestamp:   base.Timestamp,
+			Transaction: raw,
+		}
+		for _, q := range ev.Quids {
+			all[q] = true
+		}
+		events = append(events, ev)
+	}
+
+	head := ChainEvent{
+		Kind:        ChainEventBlock,
+		BlockIndex:  block.Index,
+		BlockHash:   block.Hash,
+		TrustDomain: domain,
+		Timestamp:   block.Timestamp,
+	}
+	for q := range all {
+		head.Quids = append(head.Quids, q)
+	}
+	sort.Strings(head.Quids)
+	events[0] = head
+	return events
+}
+
+// StreamChainEventsHandler streams chain events as SSE, historical
+// then live. Query params: fromHeight, domain, kinds (comma
+// separated; default all).
+func (node *QuidnugNode) StreamChainEventsHandler(w http.ResponseWriter, r *http.Request) {
+	height, ok := parseStreamHeight(w, r, true)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	domain := q.Get("domain")
+	var kinds map[string]bool
+	if v := q.Get("kinds"); v != "" {
+		kinds = map[string]bool{}
+		for _, k := range strings.Split(v, ",") {
+			if !chainEventKinds[k] {
+				WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "unknown event kind "+k)
+				return
+			}
+			kinds[k] = true
+		}
+	}
+
+	node.streamChain(w, r, height, true, func(block Block) []streamItem {
+		if domain != "" && block.TrustProof.TrustDomain != domain {
+			return nil
+		}
+		var items []streamItem
+		for _, ev := range blockEvents(block) {
+			if kinds == nil || kinds[ev.Kind] {
+				items = append(items, streamItem{event: ev.Kind, data: ev})
+			}
+		}
+		return items
+	})
+}
@@ -0,0 +1,651 @@
+// Package core — checkpoint.go
+//
+// Validator-quorum checkpoints.
+//
+// Every CheckpointInterval blocks of a domain (by per-domain
+// height), each of its validators signs the tuple (height, block
+// hash, state root) it computed on committing that block, where the
+// state root is the Merkle root of the domain's trust edges,
+// identities and titles at that height (domainStateRoot). The
+// signatures travel between validators the way DOMAIN_JOIN
+// approvals do: each validator pools what it receives, forwards its
+// own signature to the others, and whichever first holds
+// signatures from CheckpointQuorum of the total validator weight
+// submits a CHECKPOINT transaction. The transaction ID is derived
+// from the tuple, so validators that reach quorum independently
+// submit the same transaction and the duplicates drop out of their
+// mempools when one is sealed.
+//
+// Once a checkpoint is committed, blocks of the domain at or below
+// its height are final: ValidateBlockCryptographic rejects any
+// block there, so a competing tentative or quarantined branch can
+// never be promoted beneath it. A node whose own root for a
+// checkpointed height differs from the signed one logs the
+// divergence; the checkpoint is still accepted, since the quorum
+// speaks for the chain.
+//
+// Light clients start from GET /domains/{name}/checkpoints/latest
+// instead of genesis: they check the signatures with
+// VerifyCheckpoint against a validator set they trust, then follow
+// the domain from Height+1 with /blocks/stream.
+//
+// Companion file structure mirrors domain_join.go:
+//
+//   - types.go                : TxTypeCheckpoint const
+//   - checkpoint.go           : this file — struct, pool, validator, apply
+//   - validation.go           : block dispatch, finality check
+//   - registry.go             : root recording and dispatch into applyCheckpoint
+//   - handlers_checkpoint.go  : checkpoint and signature relay endpoints
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// CheckpointQuorum is the fraction of total validator weight
+	// whose signatures make a checkpoint.
+	CheckpointQuorum = 2.0 / 3.0
+
+	// MaxPendingCheckpoints bounds the signature pool.
+	MaxPendingCheckpoints = 64
+
+	// checkpointRootsKept is how many recent checkpoint-height
+	// state roots a node keeps per domain to sign or compare with.
+	checkpointRootsKept = 8
+)
+
+// Errors returned by the checkpoint workflow.
+var (
+	ErrCheckpointStale    = errors.New("checkpoint: height is at or below the domain's latest checkpoint")
+	ErrCheckpointPoolFull = errors.New("checkpoint: too many pending checkpoints")
+)
+
+// CheckpointTransaction records that the domain's validators agree
+// on BlockHash and StateRoot at Height. Signatures maps each
+// validator's node ID to its signature over
+// CheckpointSignableBytes. Timestamp is the checkpointed block's
+// timestamp, so every validator builds the same transaction.
+type CheckpointTransaction struct {
+	BaseTransaction
+
+	Height    int64  `json:"height"`
+	BlockHash string `json:"blockHash"`
+	StateRoot string `json:"stateRoot"`
+
+	Signatures map[string]string `json:"signatures,omitempty"`
+}
+
+// CheckpointStatus reports how far a checkpoint is from quorum.
+type CheckpointStatus struct {
+	ID             string   `json:"id"`
+	TrustDomain    string   `json:"trustDomain"`
+	Height         int64    `json:"height"`
+	BlockHash      string   `json:"blockHash"`
+	StateRoot      string   `json:"stateRoot"`
+	Signers        []string `json:"signers"`
+	ApprovedWeight float64  `json:"approvedWeight"`
+	RequiredWeight float64  `json:"requiredWeight"`
+	// Submitted is true once quorum was reached and the
+	// transaction entered the pending pool.
+	Submitted bool `json:"submitted"`
+}
+
+// CheckpointSignableBytes returns the bytes each validator signs:
+// the domain and the checkpointed tuple.
+func CheckpointSignableBytes(tx CheckpointTransaction) ([]byte, error) {
+	return json.Marshal(struct {
+		TrustDomain string `json:"trustDomain"`
+		Height      int64  `json:"height"`
+		BlockHash   string `json:"blockHash"`
+		StateRoot   string `json:"stateRoot"`
+	}{tx.TrustDomain, tx.Height, tx.BlockHash, tx.StateRoot})
+}
+
+// checkpointID derives the transaction ID from the signed tuple.
+func checkpointID(tx CheckpointTransaction) string {
+	data, _ := CheckpointSignableBytes(tx)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkpointApprovals returns the validators whose signature
+// verifies, their combined weight, and the weight quorum requires.
+func checkpointApprovals(domain TrustDomain, tx CheckpointTransaction) (signers []string, approved, required float64) {
+	var total float64
+	for _, w := range domain.Validators {
+		total += w
+	}
+	signable, err := CheckpointSignableBytes(tx)
+	if err != nil {
+		return nil, 0, total * CheckpointQuorum
+	}
+	for validatorID, sig := range tx.Signatures {
+		weight, isValidator := domain.Validators[validatorID]
+		pub := domain.ValidatorPublicKeys[validatorID]
+		if !isValidator || pub == "" || !VerifySignature(pub, signable, sig) {
+			continue
+		}
+		signers = append(signers, validatorID)
+		approved += weight
+	}
+	sort.Strings(signers)
+	return signers, approved, total * CheckpointQuorum
+}
+
+// VerifyCheckpoint checks that tx is well formed and carries
+// signatures from a quorum of domain's validators. Light clients
+// call it with the validator set they trust.
+func VerifyCheckpoint(tx CheckpointTransaction, domain TrustDomain) error {
+	if tx.Type != TxTypeCheckpoint {
+		return fmt.Errorf("checkpoint: wrong transaction type %q", tx.Type)
+	}
+	if tx.Height < 1 || tx.BlockHash == "" {
+		return fmt.Errorf("checkpoint: missing height or block hash")
+	}
+	if tx.ID != checkpointID(tx) {
+		return fmt.Errorf("checkpoint: transaction ID does not match its contents")
+	}
+	_, approved, required := checkpointApprovals(domain, tx)
+	if required <= 0 || approved < required {
+		return fmt.Errorf("checkpoint: signed weight %v below quorum %v", approved, required)
+	}
+	return nil
+}
+
+// CheckpointRegistry holds committed checkpoints, the state roots
+// this node computed at checkpoint heights, and checkpoints still
+// collecting signatures.
+type CheckpointRegistry struct {
+	mu sync.RWMutex
+
+	// committed lists each domain's checkpoints in height order.
+	committed map[string][]CheckpointTransaction
+
+	// roots maps domain → height → state root, for the most recent
+	// checkpointRootsKept checkpoint heights.
+	roots map[string]map[int64]string
+
+	// pool holds checkpoints below quorum, by ID.
+	pool map[string]CheckpointTransaction
+}
+
+// NewCheckpointRegistry returns an empty registry.
+func NewCheckpointRegistry() *CheckpointRegistry {
+	return &CheckpointRegistry{
+		committed: make(map[string][]CheckpointTransaction),
+		roots:     make(map[string]map[int64]string),
+		pool:      make(map[string]CheckpointTransaction),
+	}
+}
+
+// Latest returns the domain's highest committed checkpoint.
+func (r *CheckpointRegistry) Latest(domain string) (CheckpointTransaction, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := r.committed[domain]
+	if len(list) == 0 {
+		return CheckpointTransaction{}, false
+	}
+	return list[len(list)-1], true
+}
+
+// List returns the domain's committed checkpoints, oldest first.
+func (r *CheckpointRegistry) List(domain string) []CheckpointTransaction {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]CheckpointTransaction{}, r.committed[domain]...)
+}
+
+// finalHeight is the height at or below which the domain's blocks
+// are final; 0 without a checkpoint.
+func (r *CheckpointRegistry) finalHeight(domain string) int64 {
+	if cp, ok := r.Latest(domain); ok {
+		return cp.Height
+	}
+	return 0
+}
+
+func (r *CheckpointRegistry) recordRoot(domain string, height int64, root string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byHeight := r.roots[domain]
+	if byHeight == nil {
+		byHeight = make(map[int64]string)
+		r.roots[domain] = byHeight
+	}
+	byHeight[height] = root
+	for len(byHeight) > checkpointRootsKept {
+		oldest := height
+		for h := range byHeight {
+			if h < oldest {
+				oldest = h
+			}
+		}
+		delete(byHeight, oldest)
+	}
+}
+
+func (r *CheckpointRegistry) root(domain string, height int64) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	root, ok := r.roots[domain][height]
+	return root, ok
+}
+
+// commit records tx if it is above the domain's latest checkpoint
+// and drops pooled checkpoints it supersedes.
+func (r *CheckpointRegistry) commit(tx CheckpointTransaction) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.committed[tx.TrustDomain]
+	if len(list) > 0 && tx.Height <= list[len(list)-1].Height {
+		return false
+	}
+	r.committed[tx.TrustDomain] = append(list, tx)
+	for id, pooled := range r.pool {
+		if pooled.TrustDomain == tx.TrustDomain && pooled.Height <= tx.Height {
+			delete(r.pool, id)
+		}
+	}
+	return true
+}
+
+// reset drops committed checkpoints and recorded roots, which the
+// chain determines; the signature pool stays.
+func (r *CheckpointRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewCheckpointRegistry()
+	r.mu.Lock()
+	r.committed, r.roots = fresh.committed, fresh.roots
+	r.mu.Unlock()
+}
+
+// stateRootLeaf is one entry of a domain's state in the state root.
+type stateRootLeaf struct {
+	Kind  string `json:"kind"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// domainStateRoot returns the Merkle root (see merkle.go) over the
+// domain's state: the level the domain last set on each trust edge,
+// and the identities and titles whose latest transaction came from
+// the domain. Leaves are sorted by kind and key. Empty state yields
+// "".
+func (node *QuidnugNode) domainStateRoot(domain string) (string, error) {
+	var leaves []stateRootLeaf
+
+	node.TrustRegistryMutex.RLock()
+	for truster, byTrustee := range node.TrustEdgeDomainLevels {
+		for trustee, byDomain := range byTrustee {
+			if level, ok := byDomain[domain]; ok {
+				leaves = append(leaves, stateRootLeaf{
+					Kind:  "trust",
+					Key:   truster + "/" + trustee,
+					Value: strconv.FormatFloat(level, 'g', -1, 64),
+				})
+			}
+		}
+	}
+	node.TrustRegistryMutex.RUnlock()
+
+	node.IdentityRegistryMutex.RLock()
+	for quid, tx := range node.IdentityRegistry {
+		if tx.TrustDomain == domain {
+			leaves = append(leaves, stateRootLeaf{Kind: "identity", Key: quid, Value: tx.ID})
+		}
+	}
+	node.IdentityRegistryMutex.RUnlock()
+
+	node.TitleRegistryMutex.RLock()
+	for asset, tx := range node.TitleRegistry {
+		if tx.TrustDomain != domain {
+			continue
+		}
+		owners, err := json.Marshal(tx.Owners)
+		if err != nil {
+			node.TitleRegistryMutex.RUnlock()
+			return "", err
+		}
+		leaves = append(leaves, stateRootLeaf{Kind: "title", Key: asset, Value: tx.ID + " " + string(owners)})
+	}
+	node.TitleRegistryMutex.RUnlock()
+
+	sort.Slice(leaves, func(i, j int) bool {
+		if leaves[i].Kind != leaves[j].Kind {
+			return leaves[i].Kind < leaves[j].Kind
+		}
+		return leaves[i].Key < leaves[j].Key
+	})
+	txs := make([]interface{}, len(leaves))
+	for i, l := range leaves {
+		txs[i] = l
+	}
+	return MerkleRoot(txs)
+}
+
+// recordCheckpointRoot stores the domain's state root after block
+// when block sits at a checkpoint height. Called at the end of
+// applyBlockTransactions, so replays record roots too.
+func (node *QuidnugNode) recordCheckpointRoot(block Block) {
+	if node.Checkpoints == nil || node.CheckpointInterval <= 0 ||
+		block.Index <= 0 || block.Index%node.CheckpointInterval != 0 {
+		return
+	}
+	domain := block.TrustProof.TrustDomain
+	root, err := node.domainStateRoot(domain)
+	if err != nil {
+		logger.Warn("Failed to compute checkpoint state root",
+			"domain", domain, "height", block.Index, "error", err)
+		return
+	}
+	node.Checkpoints.recordRoot(domain, block.Index, root)
+}
+
+// signCheckpoint adds this node's signature for a block at a
+// checkpoint height when it validates the block's domain, and
+// forwards it to the domain's other validators unless it completed
+// quorum here. Called after a block commits.
+func (node *QuidnugNode) signCheckpoint(block Block) {
+	if node.Checkpoints == nil {
+		return
+	}
+	domainName := block.TrustProof.TrustDomain
+	root, ok := node.Checkpoints.root(domainName, block.Index)
+	if !ok {
+		return
+	}
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[domainName]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok || domain.ValidatorPublicKeys[node.NodeID] != node.GetPublicKeyHex() {
+		return
+	}
+	if _, isValidator := domain.Validators[node.NodeID]; !isValidator {
+		return
+	}
+
+	tx := CheckpointTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeCheckpoint,
+			TrustDomain: domainName,
+			Timestamp:   block.Timestamp,
+		},
+		Height:    block.Index,
+		BlockHash: block.Hash,
+		StateRoot: root,
+	}
+	tx.ID = checkpointID(tx)
+	data, err := CheckpointSignableBytes(tx)
+	if err != nil {
+		return
+	}
+	sig, err := node.SignData(data)
+	if err != nil {
+		logger.Warn("Failed to sign checkpoint", "domain", domainName, "height", block.Index, "error", err)
+		return
+	}
+	tx.Signatures = map[string]string{node.NodeID: hex.EncodeToString(sig)}
+
+	status, err := node.SubmitCheckpointSignatures(tx)
+	if err != nil {
+		logger.Warn("Failed to pool checkpoint signature", "domain", domainName, "height", block.Index, "error", err)
+		return
+	}
+	if !status.Submitted {
+		go node.forwardCheckpointSignatures(tx)
+	}
+}
+
+// checkCheckpointTuple verifies everything about a checkpoint but
+// its signatures against this node's view and returns the domain.
+func (node *QuidnugNode) checkCheckpointTuple(tx CheckpointTransaction) (TrustDomain, error) {
+	if tx.Type != TxTypeCheckpoint {
+		return TrustDomain{}, fmt.Errorf("checkpoint: wrong transaction type %q", tx.Type)
+	}
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok {
+		return TrustDomain{}, fmt.Errorf("checkpoint: unknown trust domain %q", tx.TrustDomain)
+	}
+	if tx.Height < 1 || tx.BlockHash == "" {
+		return TrustDomain{}, fmt.Errorf("checkpoint: missing height or block hash")
+	}
+	if tx.ID != checkpointID(tx) {
+		return TrustDomain{}, fmt.Errorf("checkpoint: transaction ID does not match its contents")
+	}
+	if node.Checkpoints != nil && tx.Height <= node.Checkpoints.finalHeight(tx.TrustDomain) {
+		return TrustDomain{}, ErrCheckpointStale
+	}
+	if block, ok := node.domainBlockAt(tx.TrustDomain, tx.Height); ok && block.Hash != tx.BlockHash {
+		return TrustDomain{}, fmt.Errorf("checkpoint: block %d of %s is %s here, not %s",
+			tx.Height, tx.TrustDomain, block.Hash, tx.BlockHash)
+	}
+	return domain, nil
+}
+
+// domainBlockAt returns the domain's block at height from the local
+// chain, if held.
+func (node *QuidnugNode) domainBlockAt(domain string, height int64) (Block, bool) {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	for i := len(node.Blockchain) - 1; i >= 0; i-- {
+		b := node.Blockchain[i]
+		if b.TrustProof.TrustDomain != domain {
+			continue
+		}
+		if b.Index == height {
+			return b, true
+		}
+		if b.Index < height {
+			break
+		}
+	}
+	return Block{}, false
+}
+
+// ValidateCheckpointTransaction checks a checkpoint bound for a
+// block: a tuple consistent with the local chain, above the latest
+// checkpoint, signed by a validator quorum.
+func (node *QuidnugNode) ValidateCheckpointTransaction(tx CheckpointTransaction) bool {
+	domain, err := node.checkCheckpointTuple(tx)
+	if err != nil {
+		logger.Warn("Invalid checkpoint", "txId", tx.ID, "error", err)
+		return false
+	}
+	_, approved, required := checkpointApprovals(domain, tx)
+	if required <= 0 || approved < required {
+		logger.Warn("Checkpoint lacks validator quorum",
+			"txId", tx.ID, "domain", tx.TrustDomain,
+			"approvedWeight", approved, "requiredWeight", required)
+		return false
+	}
+	return true
+}
+
+// SubmitCheckpointSignatures pools a checkpoint's signatures,
+// merging them with any already held for the same ID. Signatures
+// that do not verify are dropped. When the merged set reaches
+// quorum the checkpoint moves to the pending pool.
+func (node *QuidnugNode) SubmitCheckpointSignatures(tx CheckpointTransaction) (*CheckpointStatus, error) {
+	domain, err := node.checkCheckpointTuple(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := node.Checkpoints
+	reg.mu.Lock()
+	merged := make(map[string]string)
+	pooled, exists := reg.pool[tx.ID]
+	if !exists && len(reg.pool) >= MaxPendingCheckpoints {
+		reg.mu.Unlock()
+		return nil, ErrCheckpointPoolFull
+	}
+	for id, sig := range pooled.Signatures {
+		merged[id] = sig
+	}
+	for id, sig := range tx.Signatures {
+		merged[id] = sig
+	}
+	tx.Signatures = merged
+	signers, approved, required := checkpointApprovals(domain, tx)
+	kept := make(map[string]string, len(signers))
+	for _, id := range signers {
+		kept[id] = merged[id]
+	}
+	tx.Signatures = kept
+
+	status := &CheckpointStatus{
+		ID:             tx.ID,
+		TrustDomain:    tx.TrustDomain,
+		Height:         tx.Height,
+		BlockHash:      tx.BlockHash,
+		StateRoot:      tx.StateRoot,
+		Signers:        signers,
+		ApprovedWeight: approved,
+		RequiredWeight: required,
+	}
+	if status.Signers == nil {
+		status.Signers = []string{}
+	}
+	if approved < required {
+		reg.pool[tx.ID] = tx
+		reg.mu.Unlock()
+		return status, nil
+	}
+	delete(reg.pool, tx.ID)
+	reg.mu.Unlock()
+
+	if _, err := node.AddCheckpointTransaction(tx); err != nil {
+		return nil, err
+	}
+	status.Submitted = true
+	return status, nil
+}
+
+// forwardCheckpointSignatures sends a checkpoint and the signatures
+// collected so far to the domain's other known validators.
+func (node *QuidnugNode) forwardCheckpointSignatures(tx CheckpointTransaction) {
+	for _, peer := range node.GetTrustDomainNodes(tx.TrustDomain) {
+		if peer.ID == node.NodeID {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if _, err := node.postCheckpointSignatures(ctx, peer.Address, tx); err != nil {
+			logger.Warn("Failed to forward checkpoint signatures",
+				"txId", tx.ID, "peer", peer.ID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// postCheckpointSignatures submits tx to a peer's checkpoint pool
+// and returns the peer's view of its status.
+func (node *QuidnugNode) postCheckpointSignatures(ctx context.Context, addr string, tx CheckpointTransaction) (*CheckpointStatus, error) {
+	safeAddr, err := node.validatePeerAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	path := "/api/v1/domains/" + url.PathEscape(tx.TrustDomain) + "/checkpoint-signatures"
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://"+safeAddr.String()+path, bytes.NewReader(body)) // #nosec -- URL built from sanitized address
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := GetNodeAuthSecret(); secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(NodeSignatureHeader, SignRequest("POST", path, body, secret, ts))
+		req.Header.Set(NodeTimestampHeader, strconv.FormatInt(ts, 10))
+	}
+	resp, err := node.httpClient.Do(req) // #nosec -- URL built from sanitized address; transport enforces safedial
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Data  CheckpointStatus `json:"data"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("checkpoint: bad response from %s: %w", addr, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("checkpoint: %s rejected signatures: %s", addr, envelope.Error.Message)
+	}
+	return &envelope.Data, nil
+}
+
+// AddCheckpointTransaction admits a quorum-signed checkpoint into
+// the pending pool and broadcasts it. A checkpoint already pending
+// is left alone. Checkpoints arrive fully signed, so nothing is
+// auto-filled.
+func (node *QuidnugNode) AddCheckpointTransaction(tx CheckpointTransaction) (string, error) {
+	if !node.ValidateCheckpointTransaction(tx) {
+		RecordTransactionProcessed("checkpoint", false)
+		return "", fmt.Errorf("invalid checkpoint transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	for _, pending := range node.PendingTxs {
+		if p, ok := pending.(CheckpointTransaction); ok && p.ID == tx.ID {
+			return tx.ID, nil
+		}
+	}
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("checkpoint", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added checkpoint to pending pool",
+		"txId", tx.ID,
+		"height", tx.Height,
+		"signatures", len(tx.Signatures),
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// applyCheckpoint commits a checkpoint. Called from
+// processBlockTransactions; a checkpoint at or below the latest one
+// is ignored, so replay is idempotent.
+func (node *QuidnugNode) applyCheckpoint(tx CheckpointTransaction) {
+	if node.Checkpoints == nil || !node.Checkpoints.commit(tx) {
+		return
+	}
+	if root, ok := node.Checkpoints.root(tx.TrustDomain, tx.Height); ok && root != tx.StateRoot {
+		logger.Warn("Local state root diverges from validator checkpoint",
+			"domain", tx.TrustDomain, "height", tx.Height,
+			"localRoot", root, "checkpointRoot", tx.StateRoot)
+	}
+	logger.Info("Committed checkpoint",
+		"domain", tx.TrustDomain, "height", tx.Height, "blockHash", tx.BlockHash)
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestDomainStateRoot(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	if root, _ := node.domainStateRoot("root.local"); root != "" {
+		t.Fatalf("empty domain root = %q", root)
+	}
+	node.CheckpointInterval = -1
+	block, err := node.SyntheticTrustBlock("root.local", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	first, err := node.domainStateRoot("root.local")
+	if err != nil || first == "" {
+		t.Fatalf("root = %q, err = %v", first, err)
+	}
+	if again, _ := node.domainStateRoot("root.local"); again != first {
+		t.Error("root not deterministic")
+	}
+	if other, _ := node.domainStateRoot("other.local"); other != "" {
+		t.Error("other domain's state leaked into root")
+	}
+	if _, ok := node.Checkpoints.root("root.local", block.Index); ok {
+		t.Error("root recorded with checkpoints disabled")
+	}
+}
+
+func TestCheckpoint_SingleValidatorFlow(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = 1
+
+	block, err := node.SyntheticTrustBlock("cp.local", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+
+	var pending *CheckpointTransaction
+	node.PendingTxsMutex.RLock()
+	for _, tx := range node.PendingTxs {
+		if cp, ok := tx.(CheckpointTransaction); ok {
+			pending = &cp
+		}
+	}
+	node.PendingTxsMutex.RUnlock()
+	if pending == nil {
+		t.Fatal("validator did not submit a checkpoint")
+	}
+	if pending.Height != block.Index || pending.BlockHash != block.Hash {
+		t.Fatalf("checkpoint tuple = (%d, %s)", pending.Height, pending.BlockHash)
+	}
+
+	node.TrustDomainsMutex.RLock()
+	domain := node.TrustDomains["cp.local"]
+	node.TrustDomainsMutex.RUnlock()
+	if err := VerifyCheckpoint(*pending, domain); err != nil {
+		t.Fatalf("VerifyCheckpoint: %v", err)
+	}
+
+	next, err := node.GenerateBlock("cp.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*next); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	latest, ok := node.Checkpoints.Latest("cp.local")
+	if !ok || latest.Height != block.Index {
+		t.Fatalf("latest checkpoint = %+v, %v", latest, ok)
+	}
+
+	if _, err := node.SubmitCheckpointSignatures(*pending); err != ErrCheckpointStale {
+		t.Errorf("resubmitting committed checkpoint: err = %v", err)
+	}
+}
+
+func TestCheckpoint_RejectsBlocksBelow(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = -1
+
+	node.TrustDomainsMutex.Lock()
+	node.TrustDomains["final.local"] = TrustDomain{
+		Name:                "final.local",
+		Validators:          map[string]float64{node.NodeID: 1.0},
+		ValidatorPublicKeys: map[string]string{node.NodeID: node.GetPublicKeyHex()},
+	}
+	node.TrustDomainsMutex.Unlock()
+	block, err := node.SyntheticTrustBlock("final.local", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !node.ValidateBlockCryptographic(*block) {
+		t.Fatal("block invalid before checkpoint")
+	}
+
+	node.Checkpoints.commit(CheckpointTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeCheckpoint, TrustDomain: "final.local"},
+		Height:          block.Index,
+		BlockHash:       "0000",
+	})
+	if node.ValidateBlockCryptographic(*block) {
+		t.Error("block at checkpoint height accepted")
+	}
+}
+
+func TestVerifyCheckpoint_Quorum(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	other, _ := NewQuidnugNode(nil)
+	domain := TrustDomain{
+		Name:       "q.local",
+		Validators: map[string]float64{node.NodeID: 1.0, other.NodeID: 1.0},
+		ValidatorPublicKeys: map[string]string{
+			node.NodeID:  node.GetPublicKeyHex(),
+			other.NodeID: other.GetPublicKeyHex(),
+		},
+	}
+	tx := CheckpointTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeCheckpoint, TrustDomain: "q.local"},
+		Height:          4,
+		BlockHash:       "abcd",
+		StateRoot:       "ef01",
+	}
+	tx.ID = checkpointID(tx)
+	data, _ := CheckpointSignableBytes(tx)
+	sign := func(n *QuidnugNode) string {
+		sig, err := n.SignData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(sig)
+	}
+
+	tx.Signatures = map[string]string{node.NodeID: sign(node)}
+	if err := VerifyCheckpoint(tx, domain); err == nil {
+		t.Fatal("half the validator weight passed quorum")
+	}
+	tx.Signatures[other.NodeID] = sign(node)
+	if err := VerifyCheckpoint(tx, domain); err == nil {
+		t.Fatal("signature under the wrong key counted")
+	}
+	tx.Signatures[other.NodeID] = sign(other)
+	if err := VerifyCheckpoint(tx, domain); err != nil {
+		t.Fatalf("full quorum: %v", err)
+	}
+	tx.StateRoot = "ffff"
+	if err := VerifyCheckpoint(tx, domain); err == nil {
+		t.Error("altered tuple passed")
+	}
+}
@@ -0,0 +1,156 @@
+// Response compression.
+//
+// CompressionMiddleware negotiates gzip or deflate from
+// Accept-Encoding and compresses the response on the fly. Blocks,
+// registry dumps and graph exports are large, repetitive JSON and
+// shrink several-fold. Server-sent event streams, responses that
+// already carry a Content-Encoding, and bodiless statuses pass
+// through untouched. ndjson streams stay live: flushing through
+// http.ResponseController flushes the compressor first.
+package core
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var gzipWriterPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+
+// compressor is the part of gzip.Writer and zlib.Writer the
+// middleware uses.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding
+// header, preferring gzip, and returns "" when neither is
+// acceptable.
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if v, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+		q[name] = weight
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if w, ok := q[enc]; ok {
+			if w > 0 {
+				return enc
+			}
+			continue
+		}
+		if w, ok := q["*"]; ok && w > 0 {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressResponseWriter compresses the body once the handler has
+// committed to a compressible response.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	w        compressor
+	decided  bool
+}
+
+func (c *compressResponseWriter) decide(status int) {
+	if c.decided {
+		return
+	}
+	c.decided = true
+	h := c.Header()
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" ||
+		strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") {
+		return
+	}
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", c.encoding)
+	if c.encoding == "gzip" {
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		gz.Reset(c.ResponseWriter)
+		c.w = gz
+	} else {
+		c.w = zlib.NewWriter(c.ResponseWriter)
+	}
+}
+
+func (c *compressResponseWriter) WriteHeader(code int) {
+	c.decide(code)
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *compressResponseWriter) Write(p []byte) (int, error) {
+	if !c.decided {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.w == nil {
+		return c.ResponseWriter.Write(p)
+	}
+	return c.w.Write(p)
+}
+
+// FlushError flushes buffered compressed data before the
+// underlying writer; http.ResponseController prefers it over
+// Unwrap.
+func (c *compressResponseWriter) FlushError() error {
+	if c.w != nil {
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+// for deadlines.
+func (c *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+func (c *compressResponseWriter) close() {
+	if c.w == nil {
+		return
+	}
+	_ = c.w.Close()
+	if gz, ok := c.w.(*gzip.Writer); ok {
+		gz.Reset(io.Discard)
+		gzipWriterPool.Put(gz)
+	}
+}
+
+// CompressionMiddleware compresses responses for clients that
+// accept gzip or deflate.
+func CompressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                      "",
+		"gzip":                  "gzip",
+		"deflate, gzip":         "gzip",
+		"gzip;q=0, deflate":     "deflate",
+		"br":                    "",
+		"*":                     "gzip",
+		"*;q=0.5, gzip;q=0":     "deflate",
+		"identity, deflate;q=1": "deflate",
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"hash":"abcdef"}`, 200)
+	handler := CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	do := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/blocks", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("gzip")
+	if rr.Header().Get("Content-Encoding") != "gzip" || rr.Body.Len() >= len(body) {
+		t.Fatalf("expected compressed gzip body, got encoding %q len %d", rr.Header().Get("Content-Encoding"), rr.Body.Len())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != body {
+		t.Fatal("gzip body did not round trip")
+	}
+
+	rr = do("deflate")
+	fr, err := zlib.NewReader(rr.Body)
+	if err != nil || rr.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("deflate: encoding %q err %v", rr.Header().Get("Content-Encoding"), err)
+	}
+	if got, _ := io.ReadAll(fr); string(got) != body {
+		t.Fatal("deflate body did not round trip")
+	}
+
+	rr = do("")
+	if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != body {
+		t.Fatal("uncompressed response altered")
+	}
+	if rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Vary = %q", rr.Header().Get("Vary"))
+	}
+}
+
+func TestCompressionMiddleware_SkipsEventStreamsAndNotModified(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		ctype  string
+	}{
+		{"sse", http.StatusOK, "text/event-stream"},
+		{"not modified", http.StatusNotModified, ""},
+	} {
+		handler := CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.ctype != "" {
+				w.Header().Set("Content-Type", tc.ctype)
+			}
+			w.WriteHeader(tc.status)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+			t.Errorf("%s: response was compressed", tc.name)
+		}
+	}
+}
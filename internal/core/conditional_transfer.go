@@ -0,0 +1,447 @@
+// Package core — conditional title transfers (escrow).
+//
+// A TitleTransaction may carry TransferConditions. Such a
+// transfer is still validated and committed in a block like any
+// other, but instead of rewriting the title registry on commit it
+// is parked in the EscrowRegistry. It becomes effective only once
+//
+//   - the NotBefore time lock has passed, and
+//   - RequiredApprovals of the named Approvers (an escrow agent,
+//     a notary, a co-signing bank) have committed a
+//     TRANSFER_APPROVAL transaction approving it.
+//
+// Any approver may instead reject, and a transfer still pending
+// at its Deadline is reverted. These rules are only ever applied
+// while processing a block of the transfer's domain and judged
+// against that block's timestamp, so a time lock or deadline takes
+// effect at the first such block at or after it, and every node
+// replaying the chain settles the same transfers the same way.
+//
+// While a conditional transfer is pending the asset is locked:
+// ValidateTitleTransaction rejects any other title rewrite for
+// it, so finalization never races a competing transfer.
+//
+// Companion files:
+//
+//   - types.go          : TxTypeTransferApproval + TitleTransaction.Conditions
+//   - transactions.go   : AddTransferApprovalTransaction (mempool)
+//   - validation.go     : approval dispatch + conditions check on titles
+//   - registry.go       : hold-instead-of-apply for conditional titles
+//   - handlers.go       : approval submit + pending-transfer reads
+//   - node.go           : EscrowRegistry field + init
+package core
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MaxTransferApprovers bounds the approver list on a single
+// conditional transfer.
+const MaxTransferApprovers = 16
+
+// Transfer approval decisions.
+const (
+	TransferDecisionApprove = "approve"
+	TransferDecisionReject  = "reject"
+)
+
+// Conditional transfer lifecycle states.
+const (
+	ConditionalTransferPending   = "pending"
+	ConditionalTransferFinalized = "finalized"
+	ConditionalTransferReverted  = "reverted"
+)
+
+// TransferConditions gate when a title transfer takes effect.
+type TransferConditions struct {
+	// NotBefore is the earliest Unix time the transfer may
+	// finalize. Zero means no time lock.
+	NotBefore int64 `json:"notBefore,omitempty"`
+	// Approvers are the quids whose TRANSFER_APPROVAL counts.
+	Approvers []string `json:"approvers,omitempty"`
+	// RequiredApprovals is how many Approvers must approve.
+	// Zero with a non-empty Approvers list means all of them.
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+	// Deadline is the Unix time after which a still-pending
+	// transfer is reverted. Zero means no deadline.
+	Deadline int64 `json:"deadline,omitempty"`
+}
+
+// required returns the effective approval threshold.
+func (c *TransferConditions) required() int {
+	if c.RequiredApprovals == 0 {
+		return len(c.Approvers)
+	}
+	return c.RequiredApprovals
+}
+
+// isApprover reports whether quid is on the approver list.
+func (c *TransferConditions) isApprover(quid string) bool {
+	for _, a := range c.Approvers {
+		if a == quid {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the conditions for internal consistency.
+func (c *TransferConditions) validate() error {
+	if c.NotBefore == 0 && len(c.Approvers) == 0 {
+		return fmt.Errorf("conditions must set notBefore or approvers")
+	}
+	if len(c.Approvers) > MaxTransferApprovers {
+		return fmt.Errorf("at most %d approvers allowed", MaxTransferApprovers)
+	}
+	seen := make(map[string]bool, len(c.Approvers))
+	for _, a := range c.Approvers {
+		if !IsValidQuidID(a) {
+			return fmt.Errorf("invalid approver quid %q", a)
+		}
+		if seen[a] {
+			return fmt.Errorf("duplicate approver %s", a)
+		}
+		seen[a] = true
+	}
+	if c.RequiredApprovals < 0 || c.RequiredApprovals > len(c.Approvers) {
+		return fmt.Errorf("requiredApprovals must be in 0..%d", len(c.Approvers))
+	}
+	if c.Deadline != 0 && c.NotBefore != 0 && c.Deadline <= c.NotBefore {
+		return fmt.Errorf("deadline must be after notBefore")
+	}
+	return nil
+}
+
+// TransferApprovalTransaction is an approver's decision on a
+// pending conditional transfer.
+type TransferApprovalTransaction struct {
+	BaseTransaction
+
+	TransferTxID string `json:"transferTxId"`
+	ApproverQuid string `json:"approverQuid"`
+	Decision     string `json:"decision"`
+}
+
+// ConditionalTransfer is the escrow view of a held transfer.
+type ConditionalTransfer struct {
+	Transfer    TitleTransaction `json:"transfer"`
+	Status      string           `json:"status"`
+	Approvals   []string         `json:"approvals"`
+	Rejections  []string         `json:"rejections,omitempty"`
+	HeldAt      int64            `json:"heldAt"`
+	SettledAt   int64            `json:"settledAt,omitempty"`
+	SettledNote string           `json:"settledNote,omitempty"`
+}
+
+// hasVoted reports whether quid has already approved or rejected.
+func (ct *ConditionalTransfer) hasVoted(quid string) bool {
+	for _, q := range ct.Approvals {
+		if q == quid {
+			return true
+		}
+	}
+	for _, q := range ct.Rejections {
+		if q == quid {
+			return true
+		}
+	}
+	return false
+}
+
+// EscrowRegistry holds conditional transfers between commit and
+// settlement.
+type EscrowRegistry struct {
+	mu sync.RWMutex
+
+	// transfers keyed by the title tx ID; settled entries are
+	// retained so clients can see how a transfer ended.
+	transfers map[string]*ConditionalTransfer
+
+	// pendingByAsset maps assetID → title tx ID of the pending
+	// transfer locking it.
+	pendingByAsset map[string]string
+}
+
+// NewEscrowRegistry constructs an empty registry.
+func NewEscrowRegistry() *EscrowRegistry {
+	return &EscrowRegistry{
+		transfers:      make(map[string]*ConditionalTransfer),
+		pendingByAsset: make(map[string]string),
+	}
+}
+
+// hold parks a committed conditional transfer. Idempotent on
+// replay.
+func (r *EscrowRegistry) hold(tx TitleTransaction, now int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, seen := r.transfers[tx.ID]; seen {
+		return
+	}
+	r.transfers[tx.ID] = &ConditionalTransfer{
+		Transfer:  tx,
+		Status:    ConditionalTransferPending,
+		Approvals: []string{},
+		HeldAt:    now,
+	}
+	r.pendingByAsset[tx.AssetID] = tx.ID
+}
+
+// get returns a copy of the transfer state.
+func (r *EscrowRegistry) get(transferTxID string) (ConditionalTransfer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ct, ok := r.transfers[transferTxID]
+	if !ok {
+		return ConditionalTransfer{}, false
+	}
+	return copyConditionalTransfer(ct), true
+}
+
+// pendingFor returns the transfer currently locking assetID.
+func (r *EscrowRegistry) pendingFor(assetID string) (ConditionalTransfer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.pendingByAsset[assetID]
+	if !ok {
+		return ConditionalTransfer{}, false
+	}
+	return copyConditionalTransfer(r.transfers[id]), true
+}
+
+func copyConditionalTransfer(ct *ConditionalTransfer) ConditionalTransfer {
+	out := *ct
+	out.Approvals = append([]string{}, ct.Approvals...)
+	out.Rejections = append([]string(nil), ct.Rejections...)
+	return out
+}
+
+// recordVote applies an approval decision. Returns false if the
+// transfer is not pending or the approver already voted.
+func (r *EscrowRegistry) recordVote(tx TransferApprovalTransaction) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ct, ok := r.transfers[tx.TransferTxID]
+	if !ok || ct.Status != ConditionalTransferPending || ct.hasVoted(tx.ApproverQuid) {
+		return false
+	}
+	if tx.Decision == TransferDecisionReject {
+		ct.Rejections = append(ct.Rejections, tx.ApproverQuid)
+	} else {
+		ct.Approvals = append(ct.Approvals, tx.ApproverQuid)
+	}
+	return true
+}
+
+// settleable returns the pending transfers of domain that should
+// be finalized or reverted at now, marking them settled. The
+// caller applies finalizations to the title registry outside this
+// lock.
+func (r *EscrowRegistry) settleable(domain string, now int64) (finalize []TitleTransaction, reverted []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for assetID, id := range r.pendingByAsset {
+		ct := r.transfers[id]
+		if !inBlockDomain(ct.Transfer.TrustDomain, domain) {
+			continue
+		}
+		cond := ct.Transfer.Conditions
+		switch {
+		case len(ct.Rejections) > 0:
+			ct.Status = ConditionalTransferReverted
+			ct.SettledNote = "rejected by " + ct.Rejections[0]
+		case len(ct.Approvals) >= cond.required() && now >= cond.NotBefore:
+			ct.Status = ConditionalTransferFinalized
+			finalize = append(finalize, ct.Transfer)
+		case cond.Deadline != 0 && now >= cond.Deadline:
+			ct.Status = ConditionalTransferReverted
+			ct.SettledNote = "deadline passed"
+		default:
+			continue
+		}
+		ct.SettledAt = now
+		delete(r.pendingByAsset, assetID)
+		if ct.Status == ConditionalTransferReverted {
+			reverted = append(reverted, id)
+		}
+	}
+	return finalize, reverted
+}
+
+// inBlockDomain reports whether a transaction of txDomain is
+// sealed into blocks of blockDomain, mirroring GenerateBlock.
+func inBlockDomain(txDomain, blockDomain string) bool {
+	return txDomain == blockDomain || (blockDomain == "default" && txDomain == "")
+}
+
+// GetConditionalTransfer returns the escrow state of a
+// conditional transfer by its title tx ID.
+func (node *QuidnugNode) GetConditionalTransfer(transferTxID string) (ConditionalTransfer, bool) {
+	if node.EscrowRegistry == nil {
+		return ConditionalTransfer{}, false
+	}
+	return node.EscrowRegistry.get(transferTxID)
+}
+
+// GetPendingTransferForAsset returns the conditional transfer, if
+// any, currently locking an asset.
+func (node *QuidnugNode) GetPendingTransferForAsset(assetID string) (ConditionalTransfer, bool) {
+	if node.EscrowRegistry == nil {
+		return ConditionalTransfer{}, false
+	}
+	return node.EscrowRegistry.pendingFor(assetID)
+}
+
+// holdConditionalTransfer is the processBlockTransactions hook for
+// a committed title carrying Conditions.
+func (node *QuidnugNode) holdConditionalTransfer(tx TitleTransaction, block Block) {
+	if node.EscrowRegistry == nil {
+		return
+	}
+	node.EscrowRegistry.hold(tx, block.Timestamp)
+	logger.Info("Holding conditional title transfer",
+		"txId", tx.ID,
+		"assetId", tx.AssetID,
+		"notBefore", tx.Conditions.NotBefore,
+		"approvers", len(tx.Conditions.Approvers),
+		"deadline", tx.Conditions.Deadline)
+	node.settleConditionalTransfers(block.TrustProof.TrustDomain, block.Timestamp)
+}
+
+// updateEscrowRegistry commits a validated approval and settles
+// anything it unblocked as of the containing block.
+func (node *QuidnugNode) updateEscrowRegistry(tx TransferApprovalTransaction, block Block) {
+	if node.EscrowRegistry == nil {
+		return
+	}
+	if !node.EscrowRegistry.recordVote(tx) {
+		logger.Warn("Transfer approval no longer applicable, skipped",
+			"txId", tx.ID, "transferTxId", tx.TransferTxID, "approver", tx.ApproverQuid)
+		return
+	}
+	logger.Debug("Recorded transfer approval",
+		"txId", tx.ID,
+		"transferTxId", tx.TransferTxID,
+		"approver", tx.ApproverQuid,
+		"decision", tx.Decision)
+	node.settleConditionalTransfers(block.TrustProof.TrustDomain, block.Timestamp)
+}
+
+// settleConditionalTransfers finalizes or reverts every pending
+// transfer in domain whose conditions resolve at now, the
+// timestamp of the block being processed. Returns the number
+// settled.
+func (node *QuidnugNode) settleConditionalTransfers(domain string, now int64) int {
+	if node.EscrowRegistry == nil {
+		return 0
+	}
+	finalize, reverted := node.EscrowRegistry.settleable(domain, now)
+	for _, tx := range finalize {
+		node.updateTitleRegistry(tx)
+		logger.Info("Finalized conditional title transfer",
+			"txId", tx.ID, "assetId", tx.AssetID, "owners", len(tx.Owners))
+	}
+	for _, id := range reverted {
+		logger.Info("Reverted conditional title transfer", "txId", id)
+	}
+	return len(finalize) + len(reverted)
+}
+
+// validateTitleEscrowRules enforces the escrow-side constraints
+// on any title transaction: its own Conditions must be
+// well-formed, and the asset must not be locked by a different
+// pending conditional transfer.
+func (node *QuidnugNode) validateTitleEscrowRules(tx TitleTransaction) bool {
+	if tx.Conditions != nil {
+		if err := tx.Conditions.validate(); err != nil {
+			logger.Warn("Title transaction has invalid transfer conditions",
+				"assetId", tx.AssetID, "txId", tx.ID, "error", err)
+			return false
+		}
+	}
+	if pending, locked := node.GetPendingTransferForAsset(tx.AssetID); locked && pending.Transfer.ID != tx.ID {
+		logger.Warn("Title transaction for asset locked by a pending conditional transfer",
+			"assetId", tx.AssetID, "pendingTxId", pending.Transfer.ID, "txId", tx.ID)
+		return false
+	}
+	return true
+}
+
+// ValidateTransferApprovalTransaction enforces the approval rules.
+// Returns false on any violation; every failure is logged at
+// Warn level.
+func (node *QuidnugNode) ValidateTransferApprovalTransaction(tx TransferApprovalTransaction) bool {
+	// 1. Domain must exist.
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Transfer approval from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Decision enum.
+	if tx.Decision != TransferDecisionApprove && tx.Decision != TransferDecisionReject {
+		logger.Warn("Transfer approval has unknown decision",
+			"decision", tx.Decision, "txId", tx.ID)
+		return false
+	}
+
+	// 3. Approver + signer consistency.
+	if !IsValidQuidID(tx.ApproverQuid) {
+		logger.Warn("Transfer approval has invalid ApproverQuid",
+			"approver", tx.ApproverQuid, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Transfer approval missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	computedQuid := QuidIDFromPublicKeyHex(tx.PublicKey)
+	if computedQuid == "" || !node.signsFor(tx.ApproverQuid, tx.PublicKey, TxTypeTransferApproval, tx.delegationTime()) {
+		logger.Warn("Transfer approval ApproverQuid does not match signing public key",
+			"expected", tx.ApproverQuid, "computed", computedQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 4. Target transfer is pending, in this domain, names this
+	// approver, and hasn't heard from them yet.
+	ct, ok := node.GetConditionalTransfer(tx.TransferTxID)
+	if !ok || ct.Status != ConditionalTransferPending {
+		logger.Warn("Transfer approval for unknown or settled transfer",
+			"transferTxId", tx.TransferTxID, "txId", tx.ID)
+		return false
+	}
+	if ct.Transfer.TrustDomain != tx.TrustDomain {
+		logger.Warn("Transfer approval domain does not match transfer",
+			"transferDomain", ct.Transfer.TrustDomain, "domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !ct.Transfer.Conditions.isApprover(tx.ApproverQuid) {
+		logger.Warn("Transfer approval from quid not on approver list",
+			"approver", tx.ApproverQuid, "transferTxId", tx.TransferTxID, "txId", tx.ID)
+		return false
+	}
+	if ct.hasVoted(tx.ApproverQuid) {
+		logger.Warn("Transfer approval duplicates an earlier decision",
+			"approver", tx.ApproverQuid, "transferTxId", tx.TransferTxID, "txId", tx.ID)
+		return false
+	}
+
+	// 5. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Transfer approval marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Transfer approval signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,220 @@
+// Conditional transfer tests: escrow hold on commit, approval
+// validation, block-time finalization/reversion, and the asset lock.
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// conditionalTransfer builds a seller→buyer transfer carrying
+// conditions, signed the same way lienTestFixture.transfer does.
+func (f *lienTestFixture) conditionalTransfer(id string, cond *TransferConditions) TitleTransaction {
+	tx := TitleTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          id,
+			Type:        TxTypeTitle,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		AssetID:        f.assetID,
+		Owners:         []OwnershipStake{{OwnerID: f.buyer.QuidID, Percentage: 1.0}},
+		PreviousOwners: []OwnershipStake{{OwnerID: f.seller.QuidID, Percentage: 1.0}},
+		Conditions:     cond,
+	}
+	ownerSignable, _ := json.Marshal(tx)
+	tx.Signatures = map[string]string{f.seller.QuidID: signIEEE1363(f.seller.Priv, ownerSignable)}
+	tx.PublicKey = f.seller.PubHex
+	issuerSignable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(f.seller.Priv, issuerSignable)
+	return tx
+}
+
+func (a *testNodeActor) signApproval(transferTxID, decision string) TransferApprovalTransaction {
+	tx := TransferApprovalTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "approval-" + a.QuidID,
+			Type:        TxTypeTransferApproval,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   a.PubHex,
+		},
+		TransferTxID: transferTxID,
+		ApproverQuid: a.QuidID,
+		Decision:     decision,
+	}
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+// blockAt is an empty block of the fixture's domain stamped ts.
+func blockAt(ts int64) Block {
+	return Block{Timestamp: ts, TrustProof: TrustProof{TrustDomain: "test.domain.com"}}
+}
+
+func (f *lienTestFixture) currentOwner(t *testing.T) string {
+	t.Helper()
+	title, ok := f.node.GetAssetOwnership(f.assetID)
+	if !ok || len(title.Owners) != 1 {
+		t.Fatalf("unexpected title state: %+v", title)
+	}
+	return title.Owners[0].OwnerID
+}
+
+func TestConditionalTransfer_InvalidConditionsRejected(t *testing.T) {
+	f := newLienTestFixture(t)
+	for _, cond := range []*TransferConditions{
+		{},
+		{Approvers: []string{"not-a-quid"}},
+		{Approvers: []string{f.lienholder.QuidID}, RequiredApprovals: 2},
+		{NotBefore: 200, Deadline: 100},
+	} {
+		if f.node.ValidateTitleTransaction(f.conditionalTransfer("ct-bad", cond)) {
+			t.Errorf("conditions %+v should be rejected", cond)
+		}
+	}
+}
+
+func TestConditionalTransfer_ApprovalFinalizes(t *testing.T) {
+	f := newLienTestFixture(t)
+	agent := f.lienholder // reuse as escrow agent
+	tx := f.conditionalTransfer("ct-1", &TransferConditions{Approvers: []string{agent.QuidID}})
+	if !f.node.ValidateTitleTransaction(tx) {
+		t.Fatal("conditional transfer should validate")
+	}
+	f.node.holdConditionalTransfer(tx, blockAt(time.Now().Unix()))
+
+	if got := f.currentOwner(t); got != f.seller.QuidID {
+		t.Fatal("held transfer must not change ownership before approval")
+	}
+
+	// The asset is locked while the transfer is pending.
+	if f.node.ValidateTitleTransaction(f.transfer()) {
+		t.Fatal("competing transfer of a locked asset should be rejected")
+	}
+
+	// A quid off the approver list can't approve.
+	if f.node.ValidateTransferApprovalTransaction(f.buyer.signApproval(tx.ID, TransferDecisionApprove)) {
+		t.Fatal("approval from non-approver should be rejected")
+	}
+
+	approval := agent.signApproval(tx.ID, TransferDecisionApprove)
+	if !f.node.ValidateTransferApprovalTransaction(approval) {
+		t.Fatal("approval from the agent should validate")
+	}
+	f.node.updateEscrowRegistry(approval, blockAt(time.Now().Unix()))
+
+	if got := f.currentOwner(t); got != f.buyer.QuidID {
+		t.Fatalf("approved transfer should finalize, owner is %s", got)
+	}
+	ct, _ := f.node.GetConditionalTransfer(tx.ID)
+	if ct.Status != ConditionalTransferFinalized {
+		t.Errorf("expected finalized, got %s", ct.Status)
+	}
+	if _, locked := f.node.GetPendingTransferForAsset(f.assetID); locked {
+		t.Error("asset should be unlocked after finalization")
+	}
+}
+
+func TestConditionalTransfer_TimeLockWaitsForBlockTime(t *testing.T) {
+	f := newLienTestFixture(t)
+	notBefore := time.Now().Add(time.Hour).Unix()
+	tx := f.conditionalTransfer("ct-2", &TransferConditions{NotBefore: notBefore})
+	f.node.holdConditionalTransfer(tx, blockAt(time.Now().Unix()))
+
+	// Wall-clock time past the lock is irrelevant; only a block
+	// of the transfer's domain stamped at or after it settles.
+	setTestClockNano((notBefore + 60) * int64(time.Second))
+	defer resetTestClock()
+	f.node.processBlockTransactions(blockAt(notBefore - 1))
+	if got := f.currentOwner(t); got != f.seller.QuidID {
+		t.Fatal("nothing should settle in a block before the time lock")
+	}
+	if n := f.node.settleConditionalTransfers("other.domain.com", notBefore); n != 0 {
+		t.Fatalf("a block of another domain must not settle the transfer, settled %d", n)
+	}
+	f.node.processBlockTransactions(blockAt(notBefore))
+	if got := f.currentOwner(t); got != f.buyer.QuidID {
+		t.Fatalf("time-locked transfer should finalize, owner is %s", got)
+	}
+}
+
+func TestConditionalTransfer_DeadlineReverts(t *testing.T) {
+	f := newLienTestFixture(t)
+	deadline := time.Now().Add(time.Hour).Unix()
+	tx := f.conditionalTransfer("ct-3", &TransferConditions{
+		Approvers: []string{f.lienholder.QuidID},
+		Deadline:  deadline,
+	})
+	f.node.holdConditionalTransfer(tx, blockAt(time.Now().Unix()))
+
+	f.node.processBlockTransactions(blockAt(deadline))
+	ct, _ := f.node.GetConditionalTransfer(tx.ID)
+	if ct.Status != ConditionalTransferReverted {
+		t.Fatalf("expected reverted at deadline, got %s", ct.Status)
+	}
+	if got := f.currentOwner(t); got != f.seller.QuidID {
+		t.Fatal("reverted transfer must leave ownership unchanged")
+	}
+	if f.node.ValidateTransferApprovalTransaction(f.lienholder.signApproval(tx.ID, TransferDecisionApprove)) {
+		t.Error("approval of a settled transfer should be rejected")
+	}
+}
+
+func TestConditionalTransfer_RejectionReverts(t *testing.T) {
+	f := newLienTestFixture(t)
+	tx := f.conditionalTransfer("ct-4", &TransferConditions{Approvers: []string{f.lienholder.QuidID}})
+	now := time.Now().Unix()
+	f.node.holdConditionalTransfer(tx, blockAt(now))
+
+	f.node.updateEscrowRegistry(f.lienholder.signApproval(tx.ID, TransferDecisionReject), blockAt(now))
+
+	ct, _ := f.node.GetConditionalTransfer(tx.ID)
+	if ct.Status != ConditionalTransferReverted {
+		t.Fatalf("expected reverted after rejection, got %s", ct.Status)
+	}
+	if got := f.currentOwner(t); got != f.seller.QuidID {
+		t.Fatal("rejected transfer must leave ownership unchanged")
+	}
+}
+
+// TestConditionalTransfer_ReplayIgnoresWallClock replays the hold
+// and approval blocks long after the deadline: the approval landed
+// in a block before the deadline, so the transfer must finalize
+// exactly as it did live.
+func TestConditionalTransfer_ReplayIgnoresWallClock(t *testing.T) {
+	f := newLienTestFixture(t)
+	now := time.Now().Unix()
+	deadline := now + 3600
+	tx := f.conditionalTransfer("ct-5", &TransferConditions{
+		Approvers: []string{f.lienholder.QuidID},
+		Deadline:  deadline,
+	})
+	approval := f.lienholder.signApproval(tx.ID, TransferDecisionApprove)
+
+	setTestClockNano((deadline + 86400) * int64(time.Second))
+	defer resetTestClock()
+
+	held := blockAt(now)
+	held.Index = 1
+	held.Transactions = []interface{}{tx}
+	f.node.processBlockTransactions(held)
+
+	approved := blockAt(deadline - 1)
+	approved.Index = 2
+	approved.Transactions = []interface{}{approval}
+	f.node.processBlockTransactions(approved)
+
+	ct, _ := f.node.GetConditionalTransfer(tx.ID)
+	if ct.Status != ConditionalTransferFinalized {
+		t.Fatalf("expected finalized on replay, got %s (%s)", ct.Status, ct.SettledNote)
+	}
+	if ct.SettledAt != deadline-1 {
+		t.Errorf("settledAt = %d, want block time %d", ct.SettledAt, deadline-1)
+	}
+	if got := f.currentOwner(t); got != f.buyer.QuidID {
+		t.Fatalf("replayed transfer should finalize, owner is %s", got)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
 )
 
 // TestCORS_DenyByDefault: with no EXPLORER_CORS_ORIGINS set,
@@ -163,6 +166,47 @@ func TestCORS_NoOriginHeader(t *testing.T) {
 	}
 }
 
+// TestNewCORSMiddleware_ConfiguredPolicy: methods, headers,
+// max age and credentials come from config.
+func TestNewCORSMiddleware_ConfiguredPolicy(t *testing.T) {
+	policy := newCORSPolicy(&config.Config{
+		CORSAllowedOrigins:   []string{"https://dash.example.com"},
+		CORSAllowedMethods:   []string{"GET"},
+		CORSAllowedHeaders:   []string{"X-Custom"},
+		CORSAllowCredentials: true,
+		CORSMaxAge:           time.Minute,
+	})
+	mw := NewCORSMiddleware(policy)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/info", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	rec := httptest.NewRecorder()
+	mw.ServeHTTP(rec, req)
+
+	h := rec.Header()
+	if rec.Code != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Fatalf("preflight: %d %v", rec.Code, h)
+	}
+	if h.Get("Access-Control-Allow-Methods") != "GET" || h.Get("Access-Control-Allow-Headers") != "X-Custom" {
+		t.Fatalf("methods/headers: %v", h)
+	}
+	if h.Get("Access-Control-Max-Age") != "60" || h.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("max age/credentials: %v", h)
+	}
+}
+
+// TestNewCORSPolicy_WildcardDropsCredentials: credentials are
+// never combined with a wildcard origin; defaults fill the rest.
+func TestNewCORSPolicy_WildcardDropsCredentials(t *testing.T) {
+	p := newCORSPolicy(&config.Config{CORSAllowedOrigins: []string{"*"}, CORSAllowCredentials: true})
+	if p.AllowCredentials {
+		t.Fatal("credentials allowed with wildcard origin")
+	}
+	if len(p.AllowedMethods) == 0 || len(p.AllowedHeaders) == 0 || p.MaxAge != config.DefaultCORSMaxAge {
+		t.Fatalf("defaults not applied: %+v", p)
+	}
+}
+
 // Ensure os import is used (the t.Setenv API is Go 1.17+ but
 // we lean on os indirectly in case future tests need it).
 var _ = os.Getenv
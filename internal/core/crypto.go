@@ -211,12 +211,16 @@ func GetBlockSignableData(block Block) []byte {
 		Transactions []interface{}
 		TrustProof   TrustProof
 		PrevHash     string
+
+		ProtocolVersion int `json:",omitempty"`
 	}{
 		Index:        block.Index,
 		Timestamp:    block.Timestamp,
 		Transactions: block.Transactions,
 		TrustProof:   trustProofForSigning,
 		PrevHash:     block.PrevHash,
+
+		ProtocolVersion: block.ProtocolVersion,
 	})
 	if err != nil {
 		return nil
@@ -274,12 +278,16 @@ func canonicalBlockBytes(block Block) ([]byte, error) {
 		Transactions []interface{}
 		TrustProof   TrustProof
 		PrevHash     string
+
+		ProtocolVersion int `json:",omitempty"`
 	}{
 		Index:        block.Index,
 		Timestamp:    block.Timestamp,
 		Transactions: block.Transactions,
 		TrustProof:   block.TrustProof,
 		PrevHash:     block.PrevHash,
+
+		ProtocolVersion: block.ProtocolVersion,
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,472 @@
+// Package core — application-defined transaction types.
+//
+// TxTypeGeneric used to be a reserved constant with no code path.
+// It now carries CustomTransactions: an application registers a
+// named type with a JSON Schema for its payload and a signer rule,
+// and the node validates, stores, indexes and serves transactions
+// of that type next to the built-ins, without a fork.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go          : TxTypeGeneric const
+//   - custom_tx.go      : this file — types, registry, validator
+//   - transactions.go   : AddCustomTransaction (mempool)
+//   - validation.go     : block dispatch
+//   - registry.go       : dispatch into updateCustomTxRegistry
+//   - handlers.go       : type registration + submit/query handlers
+//   - node.go           : CustomTxRegistry field + init
+//
+// Types are registered in-process (RegisterCustomTxType) or through
+// an admin-signed POST, and are not persisted: an application
+// registers its types at every start. Mempool admission requires a
+// registered type. Blocks are more lenient: a custom transaction of
+// a type this node does not know is accepted on signature, signer
+// and nonce alone and stored opaquely, so nodes that don't run the
+// application can still follow a domain that does.
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/quidnug/quidnug/internal/jsonschema"
+)
+
+// Signer rules for custom transaction types.
+const (
+	// CustomSignerAny accepts any key; Signer must be its quid.
+	CustomSignerAny = "any"
+	// CustomSignerIdentity additionally requires Signer to have a
+	// registered identity.
+	CustomSignerIdentity = "identity"
+	// CustomSignerValidator requires Signer to be a validator of
+	// the transaction's trust domain, signing with its registered
+	// validator key.
+	CustomSignerValidator = "validator"
+)
+
+// MaxCustomTxSchemaSize bounds a registered schema document.
+const MaxCustomTxSchemaSize = 16 * 1024
+
+var (
+	ErrCustomTxTypeInvalid  = errors.New("custom tx type: invalid definition")
+	ErrCustomTxTypeExists   = errors.New("custom tx type: already registered")
+	ErrCustomTxTypeNotFound = errors.New("custom tx type: not registered")
+)
+
+// customTxTypeNamePattern keeps names URL-safe and namespaced by
+// convention ("vehicles.inspection", "acme-loyalty.points").
+var customTxTypeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9._-]{1,63}$`)
+
+// CustomTxType is an application-registered transaction type.
+type CustomTxType struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	SignerRule  string          `json:"signerRule"`
+	// Domains, when non-empty, restricts the type to these trust
+	// domains.
+	Domains      []string `json:"domains,omitempty"`
+	RegisteredAt int64    `json:"registeredAt"`
+}
+
+// allowsDomain reports whether the type may be used in domain.
+func (t CustomTxType) allowsDomain(domain string) bool {
+	if len(t.Domains) == 0 {
+		return true
+	}
+	for _, d := range t.Domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomTransaction is a GENERIC transaction of a registered custom
+// type. Payload is validated against the type's schema; SubjectID
+// is an optional application key (an asset, a quid, an order
+// number) the registry indexes for lookup.
+type CustomTransaction struct {
+	BaseTransaction
+
+	CustomType string                 `json:"customType"`
+	Signer     string                 `json:"signer"`
+	SubjectID  string                 `json:"subjectId,omitempty"`
+	Payload    map[string]interface{} `json:"payload"`
+
+	Nonce int64 `json:"nonce"`
+}
+
+// customTxTypeEntry pairs a definition with its compiled schema.
+type customTxTypeEntry struct {
+	def    CustomTxType
+	schema *jsonschema.Schema
+}
+
+// CustomTxRegistry holds registered types and committed custom
+// transactions. Owns its own lock.
+type CustomTxRegistry struct {
+	mu sync.RWMutex
+
+	types map[string]customTxTypeEntry
+
+	// txs holds every committed custom transaction by ID,
+	// including ones whose type is not registered here.
+	txs map[string]CustomTransaction
+
+	// byType and bySubject list tx IDs in commit order. bySubject
+	// is keyed by customType + "\x00" + subjectID.
+	byType    map[string][]string
+	bySubject map[string][]string
+
+	// nonces tracks the highest accepted nonce per signer.
+	nonces map[string]int64
+}
+
+// NewCustomTxRegistry constructs an empty registry.
+func NewCustomTxRegistry() *CustomTxRegistry {
+	return &CustomTxRegistry{
+		types:     make(map[string]customTxTypeEntry),
+		txs:       make(map[string]CustomTransaction),
+		byType:    make(map[string][]string),
+		bySubject: make(map[string][]string),
+		nonces:    make(map[string]int64),
+	}
+}
+
+func customSubjectKey(customType, subject string) string {
+	return customType + "\x00" + subject
+}
+
+// register adds a compiled type. Names are unique.
+func (r *CustomTxRegistry) register(def CustomTxType, schema *jsonschema.Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.types[def.Name]; exists {
+		return ErrCustomTxTypeExists
+	}
+	r.types[def.Name] = customTxTypeEntry{def: def, schema: schema}
+	return nil
+}
+
+// lookup returns a registered type.
+func (r *CustomTxRegistry) lookup(name string) (customTxTypeEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.types[name]
+	return entry, ok
+}
+
+// listTypes returns every registered type ordered by name.
+func (r *CustomTxRegistry) listTypes() []CustomTxType {
+	r.mu.RLock()
+	out := make([]CustomTxType, 0, len(r.types))
+	for _, entry := range r.types {
+		out = append(out, entry.def)
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// currentNonce returns the highest accepted nonce for a signer.
+func (r *CustomTxRegistry) currentNonce(signer string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nonces[signer]
+}
+
+// get returns a committed transaction by ID.
+func (r *CustomTxRegistry) get(txID string) (CustomTransaction, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tx, ok := r.txs[txID]
+	return tx, ok
+}
+
+// list returns committed transactions of customType in commit
+// order, optionally limited to one subject.
+func (r *CustomTxRegistry) list(customType, subject string) []CustomTransaction {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := r.byType[customType]
+	if subject != "" {
+		ids = r.bySubject[customSubjectKey(customType, subject)]
+	}
+	out := make([]CustomTransaction, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, r.txs[id])
+	}
+	return out
+}
+
+// apply commits a validated transaction. Idempotent on replay.
+func (r *CustomTxRegistry) apply(tx CustomTransaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tx.Nonce > r.nonces[tx.Signer] {
+		r.nonces[tx.Signer] = tx.Nonce
+	}
+	if _, seen := r.txs[tx.ID]; seen {
+		return
+	}
+	r.txs[tx.ID] = tx
+	r.byType[tx.CustomType] = append(r.byType[tx.CustomType], tx.ID)
+	if tx.SubjectID != "" {
+		key := customSubjectKey(tx.CustomType, tx.SubjectID)
+		r.bySubject[key] = append(r.bySubject[key], tx.ID)
+	}
+}
+
+// RegisterCustomTxType checks and compiles def and makes it
+// available for new transactions.
+func (node *QuidnugNode) RegisterCustomTxType(def CustomTxType) error {
+	if !customTxTypeNamePattern.MatchString(def.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrCustomTxTypeInvalid, customTxTypeNamePattern)
+	}
+	if !ValidateStringField(def.Description, MaxDescriptionLength) {
+		return fmt.Errorf("%w: description too long or contains control characters", ErrCustomTxTypeInvalid)
+	}
+	switch def.SignerRule {
+	case "":
+		def.SignerRule = CustomSignerAny
+	case CustomSignerAny, CustomSignerIdentity, CustomSignerValidator:
+	default:
+		return fmt.Errorf("%w: unknown signer rule %q", ErrCustomTxTypeInvalid, def.SignerRule)
+	}
+	for _, d := range def.Domains {
+		if d == "" || !ValidateStringField(d, MaxDomainLength) {
+			return fmt.Errorf("%w: invalid domain %q", ErrCustomTxTypeInvalid, d)
+		}
+	}
+	if len(def.Schema) == 0 {
+		return fmt.Errorf("%w: schema is required", ErrCustomTxTypeInvalid)
+	}
+	if len(def.Schema) > MaxCustomTxSchemaSize {
+		return fmt.Errorf("%w: schema exceeds %d bytes", ErrCustomTxTypeInvalid, MaxCustomTxSchemaSize)
+	}
+	schema, err := jsonschema.Compile(def.Schema)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCustomTxTypeInvalid, err)
+	}
+	if def.RegisteredAt == 0 {
+		def.RegisteredAt = nowUnix()
+	}
+
+	if err := node.CustomTxRegistry.register(def, schema); err != nil {
+		return err
+	}
+	logger.Info("Registered custom transaction type",
+		"name", def.Name, "signerRule", def.SignerRule, "domains", def.Domains)
+	return nil
+}
+
+// CustomTxTypeRequest is the admin-signed body for registering a
+// custom type over HTTP. Signature covers the JSON encoding of the
+// request with Signature empty.
+type CustomTxTypeRequest struct {
+	Type      CustomTxType `json:"type"`
+	Timestamp int64        `json:"timestamp"`
+	PublicKey string       `json:"publicKey"`
+	Signature string       `json:"signature"`
+}
+
+// RegisterCustomTxTypeSigned verifies an admin-signed request and
+// registers its type.
+func (node *QuidnugNode) RegisterCustomTxTypeSigned(req CustomTxTypeRequest) error {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return err
+	}
+	return node.RegisterCustomTxType(req.Type)
+}
+
+// GetCustomTxType returns a registered type by name.
+func (node *QuidnugNode) GetCustomTxType(name string) (CustomTxType, bool) {
+	entry, ok := node.CustomTxRegistry.lookup(name)
+	return entry.def, ok
+}
+
+// ListCustomTxTypes returns every registered type.
+func (node *QuidnugNode) ListCustomTxTypes() []CustomTxType {
+	return node.CustomTxRegistry.listTypes()
+}
+
+// GetCustomTransaction returns a committed custom transaction.
+func (node *QuidnugNode) GetCustomTransaction(txID string) (CustomTransaction, bool) {
+	return node.CustomTxRegistry.get(txID)
+}
+
+// ListCustomTransactions returns committed transactions of a type,
+// optionally limited to one subject, in commit order.
+func (node *QuidnugNode) ListCustomTransactions(customType, subject string) []CustomTransaction {
+	return node.CustomTxRegistry.list(customType, subject)
+}
+
+// updateCustomTxRegistry commits a validated CustomTransaction.
+// Called from processBlockTransactions once the containing block
+// has been accepted.
+func (node *QuidnugNode) updateCustomTxRegistry(tx CustomTransaction) {
+	if node.CustomTxRegistry == nil {
+		return
+	}
+	node.CustomTxRegistry.apply(tx)
+	logger.Debug("Updated custom transaction registry",
+		"txId", tx.ID,
+		"customType", tx.CustomType,
+		"signer", tx.Signer,
+		"subjectId", tx.SubjectID,
+		"nonce", tx.Nonce)
+}
+
+// ValidateCustomTransaction enforces the rules for a custom
+// transaction entering the mempool: its type must be registered
+// here. Returns false on any violation; every failure is logged at
+// Warn level.
+func (node *QuidnugNode) ValidateCustomTransaction(tx CustomTransaction) bool {
+	return node.validateCustomTransaction(tx, true)
+}
+
+// validateCustomTransaction is ValidateCustomTransaction with the
+// block-path leniency: when requireType is false, a transaction of
+// an unregistered type skips the schema, domain-restriction and
+// signer-rule checks.
+func (node *QuidnugNode) validateCustomTransaction(tx CustomTransaction, requireType bool) bool {
+	if tx.Type != TxTypeGeneric {
+		logger.Warn("Custom transaction has wrong type", "type", tx.Type, "txId", tx.ID)
+		return false
+	}
+
+	// 1. Domain must exist + be supported.
+	if tx.TrustDomain == "" {
+		logger.Warn("Custom transaction missing trust domain", "txId", tx.ID)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	domain, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Custom transaction from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Custom transaction trust domain not supported by this node",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Type must be registered (mempool) and allowed here.
+	entry, known := node.CustomTxRegistry.lookup(tx.CustomType)
+	if !known {
+		if requireType {
+			logger.Warn("Custom transaction of unregistered type",
+				"customType", tx.CustomType, "txId", tx.ID)
+			return false
+		}
+		logger.Debug("Accepting custom transaction of unregistered type opaquely",
+			"customType", tx.CustomType, "txId", tx.ID)
+	}
+	if known && !entry.def.allowsDomain(tx.TrustDomain) {
+		logger.Warn("Custom transaction type not allowed in domain",
+			"customType", tx.CustomType, "domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 3. Signer + key consistency.
+	if !IsValidQuidID(tx.Signer) {
+		logger.Warn("Custom transaction has invalid Signer", "signer", tx.Signer, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Custom transaction missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	computedQuid := QuidIDFromPublicKeyHex(tx.PublicKey)
+	if computedQuid == "" || !node.signsFor(tx.Signer, tx.PublicKey, tx.Type, tx.delegationTime()) {
+		logger.Warn("Custom transaction Signer does not match signing public key",
+			"expected", tx.Signer, "computed", computedQuid, "txId", tx.ID)
+		return false
+	}
+	if !ValidateStringField(tx.SubjectID, MaxNameLength) {
+		logger.Warn("Custom transaction subject too long or contains control characters", "txId", tx.ID)
+		return false
+	}
+
+	// 4. Payload fits and matches the schema.
+	payloadBytes, err := json.Marshal(tx.Payload)
+	if err != nil {
+		logger.Warn("Custom transaction payload not serializable", "txId", tx.ID, "error", err)
+		return false
+	}
+	if len(payloadBytes) > MaxPayloadSize {
+		logger.Warn("Custom transaction payload exceeds max size",
+			"size", len(payloadBytes), "max", MaxPayloadSize, "txId", tx.ID)
+		return false
+	}
+	if known {
+		// Round-trip so values built in Go (ints, typed slices)
+		// are checked in the shape they take on the wire.
+		var decoded interface{}
+		if err := json.Unmarshal(payloadBytes, &decoded); err != nil {
+			logger.Warn("Custom transaction payload not decodable", "txId", tx.ID, "error", err)
+			return false
+		}
+		if errs := entry.schema.Validate(decoded); len(errs) > 0 {
+			logger.Warn("Custom transaction payload does not match schema",
+				"customType", tx.CustomType, "errors", errs, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 5. Signer rule.
+	if known {
+		switch entry.def.SignerRule {
+		case CustomSignerIdentity:
+			if _, exists := node.GetQuidIdentity(tx.Signer); !exists {
+				logger.Warn("Custom transaction signer has no registered identity",
+					"signer", tx.Signer, "txId", tx.ID)
+				return false
+			}
+		case CustomSignerValidator:
+			if _, isValidator := domain.Validators[tx.Signer]; !isValidator ||
+				domain.ValidatorPublicKeys[tx.Signer] != tx.PublicKey {
+				logger.Warn("Custom transaction signer is not a domain validator",
+					"signer", tx.Signer, "domain", tx.TrustDomain, "txId", tx.ID)
+				return false
+			}
+		}
+	}
+
+	// 6. Nonce strictly monotonic per signer.
+	if tx.Nonce <= 0 {
+		logger.Warn("Custom transaction has non-positive nonce", "nonce", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+	if prev := node.CustomTxRegistry.currentNonce(tx.Signer); tx.Nonce <= prev {
+		logger.Warn("Custom transaction nonce must be strictly greater than previous",
+			"previous", prev, "provided", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+
+	// 7. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Custom transaction marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Custom transaction signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	return node.passesTxHooks(TxTypeGeneric, tx.TrustDomain, tx.ID, tx)
+}
@@ -0,0 +1,247 @@
+// Custom transaction type tests: registration, schema and signer
+// rule enforcement, block commit and the HTTP surface.
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const inspectionSchema = `{
+	"type": "object",
+	"required": ["vin", "passed"],
+	"additionalProperties": false,
+	"properties": {
+		"vin": {"type": "string", "minLength": 17, "maxLength": 17},
+		"passed": {"type": "boolean"},
+		"mileage": {"type": "integer", "minimum": 0}
+	}
+}`
+
+func registerInspectionType(t *testing.T, node *QuidnugNode, signerRule string) {
+	t.Helper()
+	err := node.RegisterCustomTxType(CustomTxType{
+		Name:       "vehicles.inspection",
+		Schema:     json.RawMessage(inspectionSchema),
+		SignerRule: signerRule,
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
+
+func (a *testNodeActor) signCustom(tx CustomTransaction) CustomTransaction {
+	tx.PublicKey = a.PubHex
+	tx.Signer = a.QuidID
+	tx.Signature = ""
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+func baselineInspection(id string, nonce int64, payload map[string]interface{}) CustomTransaction {
+	return CustomTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          id,
+			Type:        TxTypeGeneric,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		CustomType: "vehicles.inspection",
+		SubjectID:  "1HGCM82633A004352",
+		Payload:    payload,
+		Nonce:      nonce,
+	}
+}
+
+func validInspectionPayload() map[string]interface{} {
+	return map[string]interface{}{"vin": "1HGCM82633A004352", "passed": true, "mileage": 42000}
+}
+
+func TestRegisterCustomTxType_RejectsBadDefinitions(t *testing.T) {
+	node := newTestNode()
+	cases := map[string]CustomTxType{
+		"bad name":        {Name: "Inspection!", Schema: json.RawMessage(`{}`)},
+		"missing schema":  {Name: "vehicles.inspection"},
+		"bad schema":      {Name: "vehicles.inspection", Schema: json.RawMessage(`{"type":"widget"}`)},
+		"bad signer rule": {Name: "vehicles.inspection", Schema: json.RawMessage(`{}`), SignerRule: "anyone"},
+	}
+	for name, def := range cases {
+		if err := node.RegisterCustomTxType(def); !errors.Is(err, ErrCustomTxTypeInvalid) {
+			t.Errorf("%s: expected ErrCustomTxTypeInvalid, got %v", name, err)
+		}
+	}
+
+	registerInspectionType(t, node, "")
+	def, ok := node.GetCustomTxType("vehicles.inspection")
+	if !ok || def.SignerRule != CustomSignerAny || def.RegisteredAt == 0 {
+		t.Fatalf("unexpected stored definition %+v", def)
+	}
+	err := node.RegisterCustomTxType(CustomTxType{Name: "vehicles.inspection", Schema: json.RawMessage(`{}`)})
+	if !errors.Is(err, ErrCustomTxTypeExists) {
+		t.Fatalf("expected ErrCustomTxTypeExists, got %v", err)
+	}
+}
+
+func TestAddCustomTransaction_EnforcesSchema(t *testing.T) {
+	node := newTestNode()
+	registerInspectionType(t, node, CustomSignerAny)
+	inspector := newTestNodeActor(t)
+
+	good := inspector.signCustom(baselineInspection("insp-1", 1, validInspectionPayload()))
+	if _, err := node.AddCustomTransaction(good); err != nil {
+		t.Fatalf("valid inspection rejected: %v", err)
+	}
+
+	bad := validInspectionPayload()
+	bad["passed"] = "yes"
+	tx := inspector.signCustom(baselineInspection("insp-2", 2, bad))
+	if _, err := node.AddCustomTransaction(tx); err == nil {
+		t.Fatal("expected schema violation to be rejected")
+	}
+
+	extra := validInspectionPayload()
+	extra["color"] = "red"
+	tx = inspector.signCustom(baselineInspection("insp-3", 2, extra))
+	if _, err := node.AddCustomTransaction(tx); err == nil {
+		t.Fatal("expected undeclared payload field to be rejected")
+	}
+
+	unknown := baselineInspection("insp-4", 2, validInspectionPayload())
+	unknown.CustomType = "vehicles.recall"
+	unknown = inspector.signCustom(unknown)
+	if _, err := node.AddCustomTransaction(unknown); !errors.Is(err, ErrCustomTxTypeNotFound) {
+		t.Fatalf("expected ErrCustomTxTypeNotFound, got %v", err)
+	}
+}
+
+func TestValidateCustomTransaction_SignerRules(t *testing.T) {
+	node := newTestNode()
+	registerInspectionType(t, node, CustomSignerIdentity)
+	inspector := newTestNodeActor(t)
+
+	tx := inspector.signCustom(baselineInspection("insp-1", 1, validInspectionPayload()))
+	if node.ValidateCustomTransaction(tx) {
+		t.Fatal("identity rule should reject a signer with no identity")
+	}
+	node.IdentityRegistry[inspector.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: inspector.PubHex},
+		QuidID:          inspector.QuidID,
+	}
+	if !node.ValidateCustomTransaction(tx) {
+		t.Fatal("identity rule should accept a registered signer")
+	}
+
+	forged := tx
+	forged.Payload = map[string]interface{}{"vin": "1HGCM82633A004352", "passed": false}
+	if node.ValidateCustomTransaction(forged) {
+		t.Fatal("tampered payload should fail signature verification")
+	}
+}
+
+func TestCustomTransaction_BlockCommitAndOpaqueTypes(t *testing.T) {
+	node := newTestNode()
+	inspector := newTestNodeActor(t)
+	tx := inspector.signCustom(baselineInspection("insp-1", 1, validInspectionPayload()))
+
+	// Unregistered here: refused by the mempool, accepted in blocks.
+	if node.ValidateCustomTransaction(tx) {
+		t.Fatal("mempool path should require a registered type")
+	}
+	if !node.validateCustomTransaction(tx, false) {
+		t.Fatal("block path should accept a well-signed unregistered type")
+	}
+
+	node.processBlockTransactions(Block{Index: 1, Transactions: []interface{}{tx}})
+	got, ok := node.GetCustomTransaction("insp-1")
+	if !ok || got.Signer != inspector.QuidID {
+		t.Fatalf("committed tx not stored: %+v", got)
+	}
+	if list := node.ListCustomTransactions("vehicles.inspection", "1HGCM82633A004352"); len(list) != 1 {
+		t.Fatalf("expected 1 tx for subject, got %d", len(list))
+	}
+	if list := node.ListCustomTransactions("vehicles.inspection", "other"); len(list) != 0 {
+		t.Fatalf("expected no txs for other subject, got %d", len(list))
+	}
+
+	if node.validateCustomTransaction(tx, false) {
+		t.Fatal("replayed nonce should be rejected")
+	}
+}
+
+func TestCustomTxTypeHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	req := CustomTxTypeRequest{
+		Type: CustomTxType{
+			Name:       "vehicles.inspection",
+			Schema:     json.RawMessage(inspectionSchema),
+			SignerRule: CustomSignerAny,
+		},
+		Timestamp: time.Now().Unix(),
+		PublicKey: node.GetPublicKeyHex(),
+	}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+
+	forged := req
+	forged.Type.SignerRule = CustomSignerValidator
+	body, _ := json.Marshal(forged)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/custom-types", bytes.NewReader(body)))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("forged registration: expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	body, _ = json.Marshal(req)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/custom-types", bytes.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("registration: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	inspector := newTestNodeActor(t)
+	tx := inspector.signCustom(baselineInspection("insp-1", 1, validInspectionPayload()))
+	body, _ = json.Marshal(tx)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/transactions/custom", bytes.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("submit: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	node.processBlockTransactions(Block{Index: 1, Transactions: []interface{}{tx}})
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet,
+		"/api/v1/custom-types/vehicles.inspection/transactions?subjectId=1HGCM82633A004352", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Data struct {
+			Transactions []CustomTransaction `json:"transactions"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Transactions) != 1 || resp.Data.Transactions[0].ID != "insp-1" {
+		t.Fatalf("unexpected listing %+v", resp.Data.Transactions)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/custom-types/vehicles.recall", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown type: expected 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,216 @@
+// Runtime diagnostics for production incidents.
+//
+// Everything here sits under /admin/debug and /admin/diagnostics and
+// needs an admin signature. These are GETs with no body to sign, so
+// the signature travels in headers: X-Admin-Public-Key,
+// X-Admin-Timestamp and X-Admin-Signature over the JSON of an
+// AdminRequestSignable naming the method and request URI (query
+// included, so a signed 5-second CPU profile cannot be replayed as a
+// 5-minute one). The key and freshness rules are verifyAdminSigned's.
+//
+//   - /admin/debug/pprof/...: the net/http/pprof handlers.
+//   - /admin/diagnostics/goroutines: every goroutine's stack, as text.
+//   - /admin/diagnostics: one JSON bundle with runtime stats, the
+//     config (secrets blanked), the peer table, mempool stats and
+//     chain heads.
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Headers carrying an admin signature on a bodiless request.
+const (
+	AdminPublicKeyHeader = "X-Admin-Public-Key"
+	AdminTimestampHeader = "X-Admin-Timestamp"
+	AdminSignatureHeader = "X-Admin-Signature"
+)
+
+// processStart is when this process started, for uptime.
+var processStart = time.Now()
+
+// AdminRequestSignable is what an admin signs to authorize a
+// bodiless request. Path is the request URI including the query.
+type AdminRequestSignable struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+}
+
+// requireAdminHeaders passes only requests carrying a valid admin
+// signature in the X-Admin-* headers.
+func (node *QuidnugNode) requireAdminHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts, _ := strconv.ParseInt(r.Header.Get(AdminTimestampHeader), 10, 64)
+		signable := AdminRequestSignable{
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Timestamp: ts,
+			PublicKey: r.Header.Get(AdminPublicKeyHeader),
+		}
+		err := node.verifyAdminSigned(signable.PublicKey, ts, r.Header.Get(AdminSignatureHeader), signable)
+		if err != nil {
+			if !errors.Is(err, ErrAdminKey) && !errors.Is(err, ErrAdminStale) && !errors.Is(err, ErrAdminSignature) {
+				err = ErrAdminSignature
+			}
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// registerDiagnosticsRoutes mounts the admin-signed diagnostics
+// endpoints. Called from registerAdminRoutes.
+func (node *QuidnugNode) registerDiagnosticsRoutes(router *mux.Router) {
+	guard := func(h http.HandlerFunc) http.Handler { return node.requireAdminHeaders(h) }
+
+	router.Handle("/admin/diagnostics", guard(node.GetDiagnosticsHandler)).Methods("GET")
+	router.Handle("/admin/diagnostics/goroutines", guard(GoroutineDumpHandler)).Methods("GET")
+
+	router.Handle("/admin/debug/pprof/", guard(pprof.Index)).Methods("GET")
+	router.Handle("/admin/debug/pprof/cmdline", guard(pprof.Cmdline)).Methods("GET")
+	router.Handle("/admin/debug/pprof/profile", guard(pprof.Profile)).Methods("GET")
+	router.Handle("/admin/debug/pprof/symbol", guard(pprof.Symbol)).Methods("GET", "POST")
+	router.Handle("/admin/debug/pprof/trace", guard(pprof.Trace)).Methods("GET")
+	// pprof.Index serves named profiles only under /debug/pprof/,
+	// so the rest are routed by name.
+	router.Handle("/admin/debug/pprof/{profile}", guard(func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	})).Methods("GET")
+}
+
+// GoroutineDumpHandler writes the stack of every goroutine as text.
+func GoroutineDumpHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// GetDiagnosticsHandler returns the diagnostics bundle.
+func (node *QuidnugNode) GetDiagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, node.Diagnostics())
+}
+
+// Diagnostics gathers the diagnostics bundle.
+func (node *QuidnugNode) Diagnostics() map[string]interface{} {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	bundle := map[string]interface{}{
+		"generatedAt": time.Now().Unix(),
+		"nodeId":      node.NodeID,
+		"version":     QuidnugVersion,
+		"runtime": map[string]interface{}{
+			"goVersion":     runtime.Version(),
+			"goroutines":    runtime.NumGoroutine(),
+			"numCPU":        runtime.NumCPU(),
+			"gomaxprocs":    runtime.GOMAXPROCS(0),
+			"uptimeSeconds": int64(time.Since(processStart) / time.Second),
+			"heapAlloc":     mem.HeapAlloc,
+			"heapObjects":   mem.HeapObjects,
+			"sys":           mem.Sys,
+			"numGC":         mem.NumGC,
+			"logLevel":      logLevelName(logLevel.Level()),
+		},
+		"peers":      node.diagnosticsPeers(),
+		"mempool":    node.diagnosticsMempool(),
+		"chainHeads": node.diagnosticsChainHeads(),
+	}
+	if node.startConfig != nil {
+		bundle["config"] = node.startConfig.Redacted()
+	}
+	return bundle
+}
+
+func (node *QuidnugNode) diagnosticsPeers() []nodeListEntry {
+	node.KnownNodesMutex.RLock()
+	peers := make([]nodeListEntry, 0, len(node.KnownNodes))
+	for _, n := range node.KnownNodes {
+		peers = append(peers, nodeListEntry{Node: n})
+	}
+	node.KnownNodesMutex.RUnlock()
+
+	for i := range peers {
+		peers[i].PeerScore = node.nodePeerScore(peers[i].ID)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers
+}
+
+func (node *QuidnugNode) diagnosticsMempool() map[string]interface{} {
+	node.PendingTxsMutex.RLock()
+	pending := make([]interface{}, len(node.PendingTxs))
+	copy(pending, node.PendingTxs)
+	node.PendingTxsMutex.RUnlock()
+
+	byType := make(map[string]int)
+	byDomain := make(map[string]int)
+	var oldest int64
+	for _, tx := range pending {
+		base, txType, ok := txGossipRoute(tx)
+		if !ok {
+			txType = "unknown"
+		}
+		byType[txType]++
+		byDomain[base.TrustDomain]++
+		if base.Timestamp > 0 && (oldest == 0 || base.Timestamp < oldest) {
+			oldest = base.Timestamp
+		}
+	}
+	stats := map[string]interface{}{
+		"pending":  len(pending),
+		"byType":   byType,
+		"byDomain": byDomain,
+	}
+	if oldest > 0 {
+		stats["oldestTimestamp"] = oldest
+	}
+	return stats
+}
+
+func (node *QuidnugNode) diagnosticsChainHeads() map[string]interface{} {
+	heads := map[string]interface{}{}
+
+	node.BlockchainMutex.RLock()
+	if n := len(node.Blockchain); n > 0 {
+		last := node.Blockchain[n-1]
+		heads["height"] = n
+		heads["latest"] = map[string]interface{}{
+			"index":     last.Index,
+			"hash":      last.Hash,
+			"timestamp": last.Timestamp,
+			"domain":    last.TrustProof.TrustDomain,
+		}
+	}
+	node.BlockchainMutex.RUnlock()
+
+	domains := make(map[string]string)
+	node.TrustDomainsMutex.RLock()
+	for name, d := range node.TrustDomains {
+		domains[name] = d.BlockchainHead
+	}
+	node.TrustDomainsMutex.RUnlock()
+	heads["domains"] = domains
+
+	tentative := make(map[string]int)
+	node.TentativeBlocksMutex.RLock()
+	for name, blocks := range node.TentativeBlocks {
+		if len(blocks) > 0 {
+			tentative[name] = len(blocks)
+		}
+	}
+	node.TentativeBlocksMutex.RUnlock()
+	heads["tentative"] = tentative
+	heads["productionHeld"] = node.BlockProductionHeld()
+	return heads
+}
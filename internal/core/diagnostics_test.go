@@ -0,0 +1,105 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// adminGet builds a GET for uri signed with node's key; signedURI,
+// when set, is what the signature covers instead.
+func adminGet(t *testing.T, node *QuidnugNode, uri, signedURI string) *http.Request {
+	t.Helper()
+	if signedURI == "" {
+		signedURI = uri
+	}
+	ts := time.Now().Unix()
+	data, _ := json.Marshal(AdminRequestSignable{
+		Method:    "GET",
+		Path:      signedURI,
+		Timestamp: ts,
+		PublicKey: node.GetPublicKeyHex(),
+	})
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", uri, nil)
+	req.Header.Set(AdminPublicKeyHeader, node.GetPublicKeyHex())
+	req.Header.Set(AdminTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(AdminSignatureHeader, hex.EncodeToString(sig))
+	return req
+}
+
+func TestDiagnosticsRequireAdminSignature(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/v1/admin/diagnostics", nil),
+		httptest.NewRequest("GET", "/api/v1/admin/debug/pprof/heap", nil),
+		// Signed for a different URI.
+		adminGet(t, node, "/api/v1/admin/debug/pprof/profile?seconds=60", "/api/v1/admin/debug/pprof/profile?seconds=1"),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", req.URL, w.Code)
+		}
+	}
+}
+
+func TestDiagnosticsBundle(t *testing.T) {
+	node := newTestNode()
+	node.startConfig = &config.Config{Port: "8080", NodeAuthSecret: "do-not-leak"}
+	router := setupTestRouter(node)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminGet(t, node, "/api/v1/admin/diagnostics", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "do-not-leak") {
+		t.Error("diagnostics leaked the node auth secret")
+	}
+	var resp struct {
+		Data struct {
+			Runtime    map[string]interface{} `json:"runtime"`
+			Config     map[string]interface{} `json:"config"`
+			Mempool    map[string]interface{} `json:"mempool"`
+			ChainHeads map[string]interface{} `json:"chainHeads"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	d := resp.Data
+	if d.Runtime["goroutines"] == nil || d.Config["port"] != "8080" || d.Mempool["pending"] != float64(0) || d.ChainHeads["height"] != float64(1) {
+		t.Errorf("unexpected bundle: %s", w.Body.String())
+	}
+}
+
+func TestDiagnosticsProfiles(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	for uri, want := range map[string]string{
+		"/api/v1/admin/debug/pprof/heap?debug=1":      "heap profile",
+		"/api/v1/admin/debug/pprof/":                  "Types of profiles available",
+		"/api/v1/admin/diagnostics/goroutines":        "goroutine ",
+		"/api/v1/admin/debug/pprof/goroutine?debug=1": "goroutine profile",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, adminGet(t, node, uri, ""))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s: %d, body lacks %q", uri, w.Code, want)
+		}
+	}
+}
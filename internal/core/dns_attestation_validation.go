@@ -357,12 +357,13 @@ func verifyDNSTxSignature(tx any) bool {
 // attestation, blind-key attestation, group encryption,
 // etc.). The caller passes a closure that produces the
 // signable-bytes form of the tx (typically a copy with
-// Signature cleared); we marshal + verify against PublicKey.
+// Signature cleared); we encode it per its schema version and
+// verify against PublicKey.
 func verifyStructSig(publicKeyHex, signatureHex string, signableProducer func() any) bool {
 	if publicKeyHex == "" || signatureHex == "" {
 		return false
 	}
-	b, err := json.Marshal(signableProducer())
+	b, err := txSignableBytes(signableProducer())
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,172 @@
+// Package core — dns_discovery.go
+//
+// DNS-based seed discovery for trust domains.
+//
+// A trust domain named like a DNS name can say where its validators
+// listen under that same name, so participants in "example.com"
+// need no out-of-band seed list:
+//
+//	_quidnug._tcp.example.com.  SRV  10 5 8080 node1.example.com.
+//	_quidnug.example.com.       TXT  "seed=node2.example.com:8080"
+//
+// With dns_discovery on, every discovery round looks both up for
+// each concrete domain the node serves or knows and uses the
+// answers as seeds. If no domain publishes any, the configured
+// seed_nodes are used instead, so turning the option on never
+// leaves a node with fewer seeds than before. Seeds found this way
+// go through the same address sanitization and admit pipeline as
+// configured ones.
+package core
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// dnsSeedService and dnsSeedProto name the SRV record
+	// _quidnug._tcp.<domain>.
+	dnsSeedService = "quidnug"
+	dnsSeedProto   = "tcp"
+
+	// dnsSeedTXTPrefix is prepended to the domain for TXT seeds.
+	dnsSeedTXTPrefix = "_quidnug."
+
+	// dnsSeedTXTKey prefixes each seed in a TXT record.
+	dnsSeedTXTKey = "seed="
+
+	// maxDNSSeedDomains caps the lookups per discovery round so a
+	// node that knows thousands of domains doesn't flood its
+	// resolver.
+	maxDNSSeedDomains = 64
+)
+
+// dnsSeedResolver is the subset of net.Resolver DNS discovery
+// needs; tests substitute a fake.
+type dnsSeedResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// dnsSeedLookup resolves seed records. It shares safedial's
+// pure-Go resolver.
+var dnsSeedLookup dnsSeedResolver = peerResolver
+
+// isDNSDomainName reports whether a trust domain name can be looked
+// up in DNS: at least two labels, no wildcards, and only letters,
+// digits and hyphens in each label.
+func isDNSDomainName(name string) bool {
+	if len(name) > 253 || !strings.Contains(name, ".") {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dnsSeedDomains returns the domains to look seeds up for: the
+// concrete entries of SupportedDomains and the registered trust
+// domains, sorted and capped at maxDNSSeedDomains.
+func (node *QuidnugNode) dnsSeedDomains() []string {
+	set := make(map[string]struct{})
+	for _, name := range node.SupportedDomains {
+		if isDNSDomainName(name) {
+			set[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	node.TrustDomainsMutex.RLock()
+	for name := range node.TrustDomains {
+		if isDNSDomainName(name) && node.IsDomainSupported(name) {
+			set[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	node.TrustDomainsMutex.RUnlock()
+
+	domains := make([]string, 0, len(set))
+	for name := range set {
+		domains = append(domains, name)
+	}
+	sort.Strings(domains)
+	if len(domains) > maxDNSSeedDomains {
+		domains = domains[:maxDNSSeedDomains]
+	}
+	return domains
+}
+
+// lookupDomainSeeds returns the host:port seeds a domain publishes,
+// SRV records first in priority order, then TXT records. Lookup
+// failures are logged at debug level and yield no seeds; most
+// domains publish nothing.
+func lookupDomainSeeds(ctx context.Context, domain string) []string {
+	var seeds []string
+	if _, srvs, err := dnsSeedLookup.LookupSRV(ctx, dnsSeedService, dnsSeedProto, domain); err != nil {
+		logger.Debug("DNS seed SRV lookup failed", "domain", domain, "error", err)
+	} else {
+		for _, srv := range srvs {
+			target := strings.TrimSuffix(srv.Target, ".")
+			if target == "" || srv.Port == 0 {
+				continue
+			}
+			seeds = append(seeds, net.JoinHostPort(target, strconv.Itoa(int(srv.Port))))
+		}
+	}
+	records, err := dnsSeedLookup.LookupTXT(ctx, dnsSeedTXTPrefix+domain)
+	if err != nil {
+		logger.Debug("DNS seed TXT lookup failed", "domain", domain, "error", err)
+		return seeds
+	}
+	for _, record := range records {
+		for _, field := range strings.Fields(record) {
+			addr, ok := strings.CutPrefix(field, dnsSeedTXTKey)
+			if !ok {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				logger.Debug("Ignoring malformed DNS seed", "domain", domain, "seed", addr)
+				continue
+			}
+			seeds = append(seeds, addr)
+		}
+	}
+	return seeds
+}
+
+// discoverySeeds returns the seeds for one discovery round. With
+// DNS discovery off it is the configured list. With it on, the
+// seeds every known domain publishes in DNS, de-duplicated in
+// lookup order, or the configured list when there are none.
+func (node *QuidnugNode) discoverySeeds(ctx context.Context, configured []string, dnsDiscovery bool) []string {
+	if !dnsDiscovery {
+		return configured
+	}
+	seen := make(map[string]struct{})
+	var seeds []string
+	for _, domain := range node.dnsSeedDomains() {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, seed := range lookupDomainSeeds(ctx, domain) {
+			if _, dup := seen[seed]; dup {
+				continue
+			}
+			seen[seed] = struct{}{}
+			seeds = append(seeds, seed)
+		}
+	}
+	if len(seeds) == 0 {
+		return configured
+	}
+	logger.Info("Using seeds from DNS", "seeds", len(seeds))
+	return seeds
+}
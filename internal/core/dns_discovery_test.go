@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type fakeSeedResolver struct {
+	srv map[string][]*net.SRV
+	txt map[string][]string
+}
+
+func (f fakeSeedResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	records, ok := f.srv["_"+service+"._"+proto+"."+name]
+	if !ok {
+		return "", nil, errors.New("no such host")
+	}
+	return "", records, nil
+}
+
+func (f fakeSeedResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := f.txt[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+func TestDiscoverySeeds_FromDNS(t *testing.T) {
+	prev := dnsSeedLookup
+	dnsSeedLookup = fakeSeedResolver{
+		srv: map[string][]*net.SRV{
+			"_quidnug._tcp.example.com": {
+				{Target: "node1.example.com.", Port: 8080},
+				{Target: "node2.example.com.", Port: 8081},
+			},
+		},
+		txt: map[string][]string{
+			"_quidnug.example.com": {"seed=node2.example.com:8081 seed=node3.example.com:9000", "v=spf1 -all"},
+			"_quidnug.other.org":   {"seed=not-an-address"},
+		},
+	}
+	defer func() { dnsSeedLookup = prev }()
+
+	node := newTestNode()
+	node.TrustDomainsMutex.Lock()
+	node.TrustDomains["example.com"] = TrustDomain{Name: "example.com"}
+	node.TrustDomains["other.org"] = TrustDomain{Name: "other.org"}
+	node.TrustDomainsMutex.Unlock()
+
+	configured := []string{"seed1.quidnug.net:8080"}
+	got := node.discoverySeeds(t.Context(), configured, true)
+	want := []string{"node1.example.com:8080", "node2.example.com:8081", "node3.example.com:9000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("seeds = %v, want %v", got, want)
+	}
+	if got := node.discoverySeeds(t.Context(), configured, false); !reflect.DeepEqual(got, configured) {
+		t.Errorf("dns discovery off: seeds = %v", got)
+	}
+}
+
+func TestDiscoverySeeds_FallsBackToConfigured(t *testing.T) {
+	prev := dnsSeedLookup
+	dnsSeedLookup = fakeSeedResolver{}
+	defer func() { dnsSeedLookup = prev }()
+
+	node := newTestNode()
+	node.TrustDomainsMutex.Lock()
+	node.TrustDomains["example.com"] = TrustDomain{Name: "example.com"}
+	node.TrustDomainsMutex.Unlock()
+
+	configured := []string{"seed1.quidnug.net:8080"}
+	if got := node.discoverySeeds(t.Context(), configured, true); !reflect.DeepEqual(got, configured) {
+		t.Errorf("seeds = %v, want configured %v", got, configured)
+	}
+}
+
+func TestIsDNSDomainName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"example.com":     true,
+		"a-b.example.org": true,
+		"default":         false,
+		"*.example.com":   false,
+		"-bad.example":    false,
+		"a..b":            false,
+		"under_score.com": false,
+	} {
+		if got := isDNSDomainName(name); got != want {
+			t.Errorf("isDNSDomainName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -51,7 +52,7 @@ func (node *QuidnugNode) ValidateSubdomainAuthority(childDomain, parentDomain st
 func (node *QuidnugNode) checkValidatorTrust(parentValidators, childValidators []string) bool {
 	for _, parentValidator := range parentValidators {
 		for _, childValidator := range childValidators {
-			trustLevel, _, _ := node.ComputeRelationalTrust(parentValidator, childValidator, DefaultTrustMaxDepth)
+			trustLevel, _, _ := node.ComputeRelationalTrust(context.Background(), parentValidator, childValidator, DefaultTrustMaxDepth)
 			if trustLevel > 0 {
 				return true
 			}
@@ -114,6 +115,12 @@ func (node *QuidnugNode) RegisterTrustDomain(domain TrustDomain) error {
 		return fmt.Errorf("trust domain %s is not supported by this node", domain.Name)
 	}
 
+	if domain.AntiSpam != nil {
+		if err := domain.AntiSpam.Validate(); err != nil {
+			return fmt.Errorf("invalid anti-spam policy for %s: %w", domain.Name, err)
+		}
+	}
+
 	// Ensure this node is included as a validator before the
 	// subdomain-authority check inspects the validator set.
 	validatorFound := false
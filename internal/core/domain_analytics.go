@@ -0,0 +1,220 @@
+// Per-domain analytics behind /api/v1/domains/{name}/stats.
+//
+// Unlike the top-domains view in domain_stats.go, which walks the
+// chain on a 30s cache, these counters are maintained
+// incrementally: processBlockTransactions feeds every committed
+// block (live or replayed from disk) into DomainAnalytics, so a
+// stats request costs O(validators + mempool) regardless of chain
+// length.
+//
+// Chain-derived (incremental):
+//   - block height, block count, tx counts by type
+//   - average block interval (from block timestamps)
+//   - trust-graph size (distinct truster→trustee edges and quids)
+//
+// Live (computed per request, all cheap):
+//   - mempool depth for the domain
+//   - tentative block count
+//   - validator list with relational trust from this node
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// domainCounters is the incremental state for one domain.
+type domainCounters struct {
+	height       int64
+	blocks       int64
+	txByType     map[string]int64
+	firstBlockAt int64
+	lastBlockAt  int64
+	trustEdges   map[string]struct{}
+	trustQuids   map[string]struct{}
+}
+
+// DomainAnalytics accumulates per-domain chain statistics as blocks
+// commit. Owns its own lock.
+type DomainAnalytics struct {
+	mu      sync.RWMutex
+	domains map[string]*domainCounters
+}
+
+// NewDomainAnalytics constructs an empty accumulator.
+func NewDomainAnalytics() *DomainAnalytics {
+	return &DomainAnalytics{domains: make(map[string]*domainCounters)}
+}
+
+// countersLocked returns (creating if needed) the counters for
+// domain. Caller holds a.mu.
+func (a *DomainAnalytics) countersLocked(domain string) *domainCounters {
+	c, ok := a.domains[domain]
+	if !ok {
+		c = &domainCounters{
+			height:     -1,
+			txByType:   make(map[string]int64),
+			trustEdges: make(map[string]struct{}),
+			trustQuids: make(map[string]struct{}),
+		}
+		a.domains[domain] = c
+	}
+	return c
+}
+
+// observeBlock records a committed block. Returns false if the
+// block is at or below the domain's recorded height (a replay),
+// in which case the caller should skip per-tx observation too.
+func (a *DomainAnalytics) observeBlock(block Block) bool {
+	domain := block.TrustProof.TrustDomain
+	if domain == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := a.countersLocked(domain)
+	if block.Index <= c.height {
+		return false
+	}
+	c.height = block.Index
+	c.blocks++
+	if c.firstBlockAt == 0 || block.Timestamp < c.firstBlockAt {
+		c.firstBlockAt = block.Timestamp
+	}
+	if block.Timestamp > c.lastBlockAt {
+		c.lastBlockAt = block.Timestamp
+	}
+	return true
+}
+
+// observeTx counts a committed transaction by type.
+func (a *DomainAnalytics) observeTx(domain string, txType TransactionType) {
+	if domain == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.countersLocked(domain).txByType[string(txType)]++
+}
+
+// observeTrustEdge adds an edge to the domain's trust-graph size.
+func (a *DomainAnalytics) observeTrustEdge(domain, truster, trustee string) {
+	if domain == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := a.countersLocked(domain)
+	c.trustEdges[truster+"→"+trustee] = struct{}{}
+	c.trustQuids[truster] = struct{}{}
+	c.trustQuids[trustee] = struct{}{}
+}
+
+// DomainChainStats is the chain-derived half of a stats response.
+type DomainChainStats struct {
+	BlockHeight          int64            `json:"blockHeight"`
+	BlockCount           int64            `json:"blockCount"`
+	TxCountsByType       map[string]int64 `json:"txCountsByType"`
+	TotalTransactions    int64            `json:"totalTransactions"`
+	AverageBlockInterval float64          `json:"averageBlockIntervalSeconds"`
+	TrustEdgeCount       int              `json:"trustEdgeCount"`
+	TrustQuidCount       int              `json:"trustQuidCount"`
+}
+
+// snapshot returns the chain-derived stats for domain.
+func (a *DomainAnalytics) snapshot(domain string) (DomainChainStats, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	c, ok := a.domains[domain]
+	if !ok {
+		return DomainChainStats{TxCountsByType: map[string]int64{}}, false
+	}
+	out := DomainChainStats{
+		BlockHeight:    c.height,
+		BlockCount:     c.blocks,
+		TxCountsByType: make(map[string]int64, len(c.txByType)),
+		TrustEdgeCount: len(c.trustEdges),
+		TrustQuidCount: len(c.trustQuids),
+	}
+	for t, n := range c.txByType {
+		out.TxCountsByType[t] = n
+		out.TotalTransactions += n
+	}
+	if c.blocks > 1 {
+		out.AverageBlockInterval = float64(c.lastBlockAt-c.firstBlockAt) / float64(c.blocks-1)
+	}
+	return out, true
+}
+
+// DomainValidatorStat is one validator row in a stats response.
+type DomainValidatorStat struct {
+	NodeID string  `json:"nodeId"`
+	Weight float64 `json:"weight"`
+	// Trust is the relational trust from this node to the
+	// validator, the same score block tiering uses.
+	Trust float64 `json:"trust"`
+}
+
+// DomainStatsReport is the /api/v1/domains/{name}/stats payload.
+type DomainStatsReport struct {
+	Domain string `json:"domain"`
+	DomainChainStats
+	MempoolDepth        int                   `json:"mempoolDepth"`
+	TentativeBlockCount int                   `json:"tentativeBlockCount"`
+	Validators          []DomainValidatorStat `json:"validators"`
+	TrustThreshold      float64               `json:"trustThreshold"`
+	GeneratedAt         int64                 `json:"generatedAt"`
+}
+
+// GetDomainStatsReport assembles the stats for a domain. The
+// second return is false when the node neither manages the domain
+// nor has seen a block for it.
+func (node *QuidnugNode) GetDomainStatsReport(ctx context.Context, domain string) (*DomainStatsReport, bool) {
+	node.TrustDomainsMutex.RLock()
+	td, known := node.TrustDomains[domain]
+	node.TrustDomainsMutex.RUnlock()
+
+	var chain DomainChainStats
+	seen := false
+	if node.DomainAnalytics != nil {
+		chain, seen = node.DomainAnalytics.snapshot(domain)
+	}
+	if !known && !seen {
+		return nil, false
+	}
+
+	report := &DomainStatsReport{
+		Domain:           domain,
+		DomainChainStats: chain,
+		TrustThreshold:   td.TrustThreshold,
+		Validators:       []DomainValidatorStat{},
+		GeneratedAt:      nowUnix(),
+	}
+
+	node.PendingTxsMutex.RLock()
+	for _, tx := range node.PendingTxs {
+		if extractTxDomain(tx) == domain {
+			report.MempoolDepth++
+		}
+	}
+	node.PendingTxsMutex.RUnlock()
+
+	node.TentativeBlocksMutex.RLock()
+	report.TentativeBlockCount = len(node.TentativeBlocks[domain])
+	node.TentativeBlocksMutex.RUnlock()
+
+	for id, weight := range td.Validators {
+		trust, _, _ := node.ComputeRelationalTrust(ctx, node.NodeID, id, DefaultTrustMaxDepth)
+		report.Validators = append(report.Validators, DomainValidatorStat{
+			NodeID: id,
+			Weight: weight,
+			Trust:  trust,
+		})
+	}
+	sort.Slice(report.Validators, func(i, j int) bool {
+		return report.Validators[i].NodeID < report.Validators[j].NodeID
+	})
+
+	return report, true
+}
@@ -0,0 +1,132 @@
+// Domain stats tests: incremental counters, replay idempotence,
+// live mempool/validator fields, and the HTTP endpoint.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// analyticsBlock builds a committed-looking block for domain with
+// the given transactions. Signatures are irrelevant here: the
+// analytics hook runs on already-accepted blocks.
+func analyticsBlock(domain string, index, ts int64, txs ...interface{}) Block {
+	return Block{
+		Index:        index,
+		Timestamp:    ts,
+		Transactions: txs,
+		TrustProof:   TrustProof{TrustDomain: domain},
+	}
+}
+
+func analyticsTrustTx(truster, trustee string) TrustTransaction {
+	return TrustTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTrust, TrustDomain: "test.domain.com"},
+		Truster:         truster,
+		Trustee:         trustee,
+		TrustLevel:      0.8,
+	}
+}
+
+func TestDomainAnalytics_IncrementalCounts(t *testing.T) {
+	node := newTestNode()
+
+	node.processBlockTransactions(analyticsBlock("test.domain.com", 1, 1000,
+		analyticsTrustTx("0000000000000001", "0000000000000002"),
+		analyticsTrustTx("0000000000000002", "0000000000000003"),
+	))
+	node.processBlockTransactions(analyticsBlock("test.domain.com", 2, 1060,
+		analyticsTrustTx("0000000000000001", "0000000000000002"), // same edge again
+		EventTransaction{BaseTransaction: BaseTransaction{Type: TxTypeEvent, TrustDomain: "test.domain.com"}},
+	))
+	node.processBlockTransactions(analyticsBlock("test.domain.com", 3, 1180))
+
+	report, ok := node.GetDomainStatsReport(context.Background(), "test.domain.com")
+	if !ok {
+		t.Fatal("expected a report for a managed domain")
+	}
+	if report.BlockHeight != 3 || report.BlockCount != 3 {
+		t.Errorf("height/count = %d/%d, want 3/3", report.BlockHeight, report.BlockCount)
+	}
+	if got := report.TxCountsByType[string(TxTypeTrust)]; got != 3 {
+		t.Errorf("trust tx count = %d, want 3", got)
+	}
+	if got := report.TxCountsByType[string(TxTypeEvent)]; got != 1 {
+		t.Errorf("event tx count = %d, want 1", got)
+	}
+	if report.TotalTransactions != 4 {
+		t.Errorf("total = %d, want 4", report.TotalTransactions)
+	}
+	if report.TrustEdgeCount != 2 || report.TrustQuidCount != 3 {
+		t.Errorf("trust graph = %d edges / %d quids, want 2 / 3", report.TrustEdgeCount, report.TrustQuidCount)
+	}
+	if report.AverageBlockInterval != 90 {
+		t.Errorf("average interval = %v, want 90", report.AverageBlockInterval)
+	}
+}
+
+func TestDomainAnalytics_ReplayDoesNotDoubleCount(t *testing.T) {
+	node := newTestNode()
+	b := analyticsBlock("test.domain.com", 1, 1000, analyticsTrustTx("0000000000000001", "0000000000000002"))
+	node.processBlockTransactions(b)
+	node.processBlockTransactions(b)
+
+	report, _ := node.GetDomainStatsReport(context.Background(), "test.domain.com")
+	if report.BlockCount != 1 || report.TotalTransactions != 1 {
+		t.Errorf("replay double-counted: blocks=%d txs=%d", report.BlockCount, report.TotalTransactions)
+	}
+}
+
+func TestDomainAnalytics_LiveFields(t *testing.T) {
+	node := newTestNode()
+	node.PendingTxs = append(node.PendingTxs,
+		analyticsTrustTx("0000000000000001", "0000000000000002"),
+		TrustTransaction{BaseTransaction: BaseTransaction{TrustDomain: "other.domain.com"}},
+	)
+	node.TentativeBlocks["test.domain.com"] = []Block{{Index: 9}}
+
+	report, _ := node.GetDomainStatsReport(context.Background(), "test.domain.com")
+	if report.MempoolDepth != 1 {
+		t.Errorf("mempool depth = %d, want 1", report.MempoolDepth)
+	}
+	if report.TentativeBlockCount != 1 {
+		t.Errorf("tentative count = %d, want 1", report.TentativeBlockCount)
+	}
+	if len(report.Validators) != 1 || report.Validators[0].NodeID != node.NodeID {
+		t.Fatalf("unexpected validators: %+v", report.Validators)
+	}
+	if report.Validators[0].Trust != 1.0 {
+		t.Errorf("self trust = %v, want 1.0", report.Validators[0].Trust)
+	}
+}
+
+func TestGetDomainStatsHandler(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest("GET", "/api/v1/domains/test.domain.com/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data DomainStatsReport `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Domain != "test.domain.com" {
+		t.Errorf("domain = %q", resp.Data.Domain)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/domains/unknown.example/stats", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown domain, got %d", w.Code)
+	}
+}
@@ -0,0 +1,496 @@
+// Package core — domain_control.go
+//
+// ACME-style domain control validation for trust domains.
+//
+// A trust domain's name looks like a DNS name, but nothing stops
+// anyone registering "example.com" on their node. Domain control
+// validation ties the trust domain to whoever controls that DNS
+// name:
+//
+//  1. A client asks a validator of the domain for a challenge,
+//     naming the quid that is to be recorded as controller and a
+//     method. The validator returns a random token and the key
+//     authorization "<token>.<controllerQuid>".
+//  2. The client publishes proof under the DNS name:
+//     - dns-01: a TXT record at _quidnug-challenge.<domain> holding
+//     base64url(SHA-256(key authorization));
+//     - http-01: the key authorization itself at
+//     http://<domain>/.well-known/quidnug-challenge/<token>.
+//  3. The client asks the validator to validate. The validator
+//     looks the proof up and, if it is there, signs a
+//     DOMAIN_CONTROL transaction binding the domain to the
+//     controller until ValidUntil.
+//
+// Because the key authorization names the controller, a proof
+// published for one quid cannot be claimed by another. The lookup
+// happens once, on the validator; block validation checks the
+// validator's signature and standing rather than re-querying DNS,
+// which would not be deterministic. The newest committed
+// attestation per domain is surfaced through
+// GET /domains/{name}/control and the "control" domain query.
+//
+// Companion file structure mirrors checkpoint.go:
+//
+//   - types.go                    : TxTypeDomainControl const
+//   - domain_control.go           : this file — struct, challenges, checks, apply
+//   - validation.go               : block dispatch
+//   - registry.go                 : dispatch into applyDomainControl
+//   - handlers_domain_control.go  : challenge, validate, status endpoints
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Domain control methods.
+const (
+	DomainControlDNS  = "dns-01"
+	DomainControlHTTP = "http-01"
+)
+
+const (
+	// DomainControlValidity is how long an attestation holds.
+	// Controllers revalidate before it lapses, as with ACME
+	// certificates.
+	DomainControlValidity = 90 * 24 * time.Hour
+
+	// DomainControlChallengeTTL bounds how long a challenge can be
+	// validated after it is issued.
+	DomainControlChallengeTTL = time.Hour
+
+	// MaxPendingDomainControlChallenges bounds the challenge pool.
+	MaxPendingDomainControlChallenges = 256
+
+	// domainControlTXTPrefix is the label the dns-01 record sits
+	// under.
+	domainControlTXTPrefix = "_quidnug-challenge."
+
+	// domainControlHTTPPath is the http-01 well-known path.
+	domainControlHTTPPath = "/.well-known/quidnug-challenge/"
+
+	// maxDomainControlHTTPBody bounds the http-01 response read.
+	maxDomainControlHTTPBody = 4 << 10
+)
+
+// Errors returned by the domain control workflow.
+var (
+	ErrDomainControlNotValidator = errors.New("domain control: this node does not validate the domain")
+	ErrDomainControlNoChallenge  = errors.New("domain control: unknown or expired challenge")
+	ErrDomainControlPoolFull     = errors.New("domain control: too many pending challenges")
+	ErrDomainControlUnproven     = errors.New("domain control: challenge not satisfied")
+)
+
+// DomainControlTransaction records that ValidatorID saw the
+// domain's DNS name prove control for ControllerQuid at Timestamp.
+// The validator signs it with its node key.
+type DomainControlTransaction struct {
+	BaseTransaction
+
+	ValidatorID    string `json:"validatorId"`
+	ControllerQuid string `json:"controllerQuid"`
+	Method         string `json:"method"`
+	Token          string `json:"token"`
+	ValidUntil     int64  `json:"validUntil"`
+}
+
+// DomainControlChallenge is an issued challenge and what the
+// client must publish to satisfy it.
+type DomainControlChallenge struct {
+	Token            string `json:"token"`
+	TrustDomain      string `json:"trustDomain"`
+	ControllerQuid   string `json:"controllerQuid"`
+	Method           string `json:"method"`
+	KeyAuthorization string `json:"keyAuthorization"`
+	TXTRecordName    string `json:"txtRecordName,omitempty"`
+	TXTValue         string `json:"txtValue,omitempty"`
+	HTTPURL          string `json:"httpUrl,omitempty"`
+	ExpiresAt        int64  `json:"expiresAt"`
+}
+
+// DomainControlStatus is a domain's current attestation as
+// surfaced in domain queries.
+type DomainControlStatus struct {
+	TrustDomain    string `json:"trustDomain"`
+	Validated      bool   `json:"validated"`
+	ControllerQuid string `json:"controllerQuid,omitempty"`
+	Method         string `json:"method,omitempty"`
+	ValidatorID    string `json:"validatorId,omitempty"`
+	VerifiedAt     int64  `json:"verifiedAt,omitempty"`
+	ValidUntil     int64  `json:"validUntil,omitempty"`
+	TxID           string `json:"txId,omitempty"`
+}
+
+// domainControlResolver looks up dns-01 records. A variable so
+// tests can stand in for DNS.
+var domainControlResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+} = peerResolver
+
+// domainControlHTTPURL returns where the http-01 proof for token
+// is fetched from. A variable so tests can point it at a local
+// server.
+var domainControlHTTPURL = func(domain, token string) string {
+	return "http://" + domain + domainControlHTTPPath + token
+}
+
+// domainControlKeyAuthorization binds a token to the controller.
+func domainControlKeyAuthorization(token, controller string) string {
+	return token + "." + controller
+}
+
+// domainControlTXTValue is the dns-01 record value for a key
+// authorization.
+func domainControlTXTValue(keyAuth string) string {
+	sum := sha256.Sum256([]byte(keyAuth))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// domainControlID derives the transaction ID from its contents.
+func domainControlID(tx DomainControlTransaction) string {
+	data, _ := json.Marshal(struct {
+		TrustDomain    string
+		ValidatorID    string
+		ControllerQuid string
+		Method         string
+		Token          string
+		Timestamp      int64
+		ValidUntil     int64
+	}{tx.TrustDomain, tx.ValidatorID, tx.ControllerQuid, tx.Method, tx.Token, tx.Timestamp, tx.ValidUntil})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DomainControlRegistry holds the newest committed attestation per
+// domain and the challenges this node has issued.
+type DomainControlRegistry struct {
+	mu         sync.RWMutex
+	byDomain   map[string]DomainControlTransaction
+	challenges map[string]DomainControlChallenge // token -> challenge
+}
+
+// NewDomainControlRegistry returns an empty registry.
+func NewDomainControlRegistry() *DomainControlRegistry {
+	return &DomainControlRegistry{
+		byDomain:   make(map[string]DomainControlTransaction),
+		challenges: make(map[string]DomainControlChallenge),
+	}
+}
+
+// Status returns the domain's attestation state at now.
+func (r *DomainControlRegistry) Status(domain string, now time.Time) DomainControlStatus {
+	status := DomainControlStatus{TrustDomain: domain}
+	if r == nil {
+		return status
+	}
+	r.mu.RLock()
+	tx, ok := r.byDomain[domain]
+	r.mu.RUnlock()
+	if !ok {
+		return status
+	}
+	status.Validated = now.Unix() < tx.ValidUntil
+	status.ControllerQuid = tx.ControllerQuid
+	status.Method = tx.Method
+	status.ValidatorID = tx.ValidatorID
+	status.VerifiedAt = tx.Timestamp
+	status.ValidUntil = tx.ValidUntil
+	status.TxID = tx.ID
+	return status
+}
+
+// commit records tx unless the domain already has one at least as
+// new, so replay is idempotent.
+func (r *DomainControlRegistry) commit(tx DomainControlTransaction) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.byDomain[tx.TrustDomain]; ok && cur.Timestamp >= tx.Timestamp {
+		return false
+	}
+	r.byDomain[tx.TrustDomain] = tx
+	return true
+}
+
+// addChallenge pools a challenge, dropping expired ones first.
+func (r *DomainControlRegistry) addChallenge(ch DomainControlChallenge, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for token, c := range r.challenges {
+		if now.Unix() >= c.ExpiresAt {
+			delete(r.challenges, token)
+		}
+	}
+	if len(r.challenges) >= MaxPendingDomainControlChallenges {
+		return ErrDomainControlPoolFull
+	}
+	r.challenges[ch.Token] = ch
+	return nil
+}
+
+// challenge returns an unexpired challenge for domain.
+func (r *DomainControlRegistry) challenge(domain, token string, now time.Time) (DomainControlChallenge, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ch, ok := r.challenges[token]
+	if !ok || ch.TrustDomain != domain || now.Unix() >= ch.ExpiresAt {
+		return DomainControlChallenge{}, false
+	}
+	return ch, true
+}
+
+func (r *DomainControlRegistry) removeChallenge(token string) {
+	r.mu.Lock()
+	delete(r.challenges, token)
+	r.mu.Unlock()
+}
+
+// reset drops committed attestations; issued challenges are not
+// chain state and survive.
+func (r *DomainControlRegistry) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.byDomain = make(map[string]DomainControlTransaction)
+	r.mu.Unlock()
+}
+
+// validatesDomain reports whether this node is a validator of the
+// domain under its own key.
+func (node *QuidnugNode) validatesDomain(domainName string) bool {
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[domainName]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok || domain.ValidatorPublicKeys[node.NodeID] != node.GetPublicKeyHex() {
+		return false
+	}
+	_, isValidator := domain.Validators[node.NodeID]
+	return isValidator
+}
+
+// IssueDomainControlChallenge creates a challenge for controller to
+// prove control of the domain's DNS name.
+func (node *QuidnugNode) IssueDomainControlChallenge(domain, controller, method string) (DomainControlChallenge, error) {
+	if method != DomainControlDNS && method != DomainControlHTTP {
+		return DomainControlChallenge{}, fmt.Errorf("domain control: unknown method %q", method)
+	}
+	if !IsValidQuidID(controller) {
+		return DomainControlChallenge{}, fmt.Errorf("domain control: invalid controller quid %q", controller)
+	}
+	if !node.validatesDomain(domain) {
+		return DomainControlChallenge{}, ErrDomainControlNotValidator
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return DomainControlChallenge{}, err
+	}
+	now := time.Now()
+	ch := DomainControlChallenge{
+		Token:          base64.RawURLEncoding.EncodeToString(raw),
+		TrustDomain:    domain,
+		ControllerQuid: controller,
+		Method:         method,
+		ExpiresAt:      now.Add(DomainControlChallengeTTL).Unix(),
+	}
+	ch.KeyAuthorization = domainControlKeyAuthorization(ch.Token, controller)
+	if method == DomainControlDNS {
+		ch.TXTRecordName = domainControlTXTPrefix + domain
+		ch.TXTValue = domainControlTXTValue(ch.KeyAuthorization)
+	} else {
+		ch.HTTPURL = domainControlHTTPURL(domain, ch.Token)
+	}
+	if err := node.DomainControlRegistry.addChallenge(ch, now); err != nil {
+		return DomainControlChallenge{}, err
+	}
+	return ch, nil
+}
+
+// checkDomainControlProof looks up the challenge's proof.
+func (node *QuidnugNode) checkDomainControlProof(ctx context.Context, ch DomainControlChallenge) error {
+	switch ch.Method {
+	case DomainControlDNS:
+		records, err := domainControlResolver.LookupTXT(ctx, ch.TXTRecordName)
+		if err != nil {
+			return fmt.Errorf("%w: TXT lookup %s: %v", ErrDomainControlUnproven, ch.TXTRecordName, err)
+		}
+		for _, record := range records {
+			if strings.TrimSpace(record) == ch.TXTValue {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: no matching TXT record at %s", ErrDomainControlUnproven, ch.TXTRecordName)
+
+	case DomainControlHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, domainControlHTTPURL(ch.TrustDomain, ch.Token), nil)
+		if err != nil {
+			return err
+		}
+		// node.httpClient refuses private and loopback targets, so a
+		// domain name pointed at internal hosts cannot be used to
+		// probe them.
+		resp, err := node.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDomainControlUnproven, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxDomainControlHTTPBody))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDomainControlUnproven, err)
+		}
+		if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != ch.KeyAuthorization {
+			return fmt.Errorf("%w: %s did not serve the key authorization", ErrDomainControlUnproven, req.URL)
+		}
+		return nil
+	}
+	return fmt.Errorf("domain control: unknown method %q", ch.Method)
+}
+
+// ValidateDomainControlChallenge checks the proof for a challenge
+// and, when it holds, signs and submits the DOMAIN_CONTROL
+// attestation. The challenge is consumed either way once the proof
+// has been looked up.
+func (node *QuidnugNode) ValidateDomainControlChallenge(ctx context.Context, domain, token string) (DomainControlTransaction, error) {
+	ch, ok := node.DomainControlRegistry.challenge(domain, token, time.Now())
+	if !ok {
+		return DomainControlTransaction{}, ErrDomainControlNoChallenge
+	}
+	if !node.validatesDomain(domain) {
+		return DomainControlTransaction{}, ErrDomainControlNotValidator
+	}
+	err := node.checkDomainControlProof(ctx, ch)
+	node.DomainControlRegistry.removeChallenge(token)
+	if err != nil {
+		return DomainControlTransaction{}, err
+	}
+
+	now := time.Now()
+	tx := DomainControlTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeDomainControl,
+			TrustDomain: domain,
+			Timestamp:   now.Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		ValidatorID:    node.NodeID,
+		ControllerQuid: ch.ControllerQuid,
+		Method:         ch.Method,
+		Token:          ch.Token,
+		ValidUntil:     now.Add(DomainControlValidity).Unix(),
+	}
+	tx.ID = domainControlID(tx)
+	signable, err := txSignableBytes(tx)
+	if err != nil {
+		return DomainControlTransaction{}, err
+	}
+	sig, err := node.SignData(signable)
+	if err != nil {
+		return DomainControlTransaction{}, err
+	}
+	tx.Signature = hex.EncodeToString(sig)
+
+	if _, err := node.AddDomainControlTransaction(tx); err != nil {
+		return DomainControlTransaction{}, err
+	}
+	return tx, nil
+}
+
+// ValidateDomainControlTransaction checks an attestation bound for
+// a block: well formed, and signed by a current validator of the
+// domain.
+func (node *QuidnugNode) ValidateDomainControlTransaction(tx DomainControlTransaction) bool {
+	if tx.Type != TxTypeDomainControl {
+		logger.Warn("Domain control has wrong type", "txId", tx.ID, "type", tx.Type)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok || !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Domain control for unknown or unsupported domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if tx.Method != DomainControlDNS && tx.Method != DomainControlHTTP {
+		logger.Warn("Domain control has unknown method", "method", tx.Method, "txId", tx.ID)
+		return false
+	}
+	if !IsValidQuidID(tx.ControllerQuid) || tx.Token == "" {
+		logger.Warn("Domain control missing controller or token", "txId", tx.ID)
+		return false
+	}
+	if tx.Timestamp <= 0 || tx.ValidUntil <= tx.Timestamp ||
+		tx.ValidUntil-tx.Timestamp > int64(DomainControlValidity/time.Second) {
+		logger.Warn("Domain control has invalid validity window",
+			"timestamp", tx.Timestamp, "validUntil", tx.ValidUntil, "txId", tx.ID)
+		return false
+	}
+	if tx.ID != domainControlID(tx) {
+		logger.Warn("Domain control ID does not match its contents", "txId", tx.ID)
+		return false
+	}
+	pub := domain.ValidatorPublicKeys[tx.ValidatorID]
+	if _, isValidator := domain.Validators[tx.ValidatorID]; !isValidator || pub == "" || pub != tx.PublicKey {
+		logger.Warn("Domain control not signed by a validator of the domain",
+			"validator", tx.ValidatorID, "domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil || !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Domain control signature invalid", "txId", tx.ID)
+		return false
+	}
+	return true
+}
+
+// AddDomainControlTransaction admits a validator-signed attestation
+// into the pending pool and broadcasts it.
+func (node *QuidnugNode) AddDomainControlTransaction(tx DomainControlTransaction) (string, error) {
+	if !node.ValidateDomainControlTransaction(tx) {
+		RecordTransactionProcessed("domain-control", false)
+		return "", fmt.Errorf("invalid domain control transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	for _, pending := range node.PendingTxs {
+		if p, ok := pending.(DomainControlTransaction); ok && p.ID == tx.ID {
+			return tx.ID, nil
+		}
+	}
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("domain-control", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added domain control attestation to pending pool",
+		"txId", tx.ID,
+		"domain", tx.TrustDomain,
+		"controller", tx.ControllerQuid,
+		"method", tx.Method)
+	return tx.ID, nil
+}
+
+// applyDomainControl commits an attestation. Called from
+// processBlockTransactions.
+func (node *QuidnugNode) applyDomainControl(tx DomainControlTransaction) {
+	if node.DomainControlRegistry == nil || !node.DomainControlRegistry.commit(tx) {
+		return
+	}
+	logger.Info("Committed domain control attestation",
+		"domain", tx.TrustDomain, "controller", tx.ControllerQuid, "validUntil", tx.ValidUntil)
+}
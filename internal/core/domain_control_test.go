@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeTXTResolver map[string][]string
+
+func (f fakeTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if records, ok := f[name]; ok {
+		return records, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func newDomainControlTestNode(t *testing.T, domain string) *QuidnugNode {
+	t.Helper()
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = -1
+	node.TrustDomainsMutex.Lock()
+	node.TrustDomains[domain] = TrustDomain{
+		Name:                domain,
+		ValidatorNodes:      []string{node.NodeID},
+		TrustThreshold:      0.75,
+		Validators:          map[string]float64{node.NodeID: 1.0},
+		ValidatorPublicKeys: map[string]string{node.NodeID: node.GetPublicKeyHex()},
+	}
+	node.TrustDomainsMutex.Unlock()
+	return node
+}
+
+func TestDomainControl_DNSFlow(t *testing.T) {
+	const domain = "example.com"
+	const controller = "0123456789abcdef"
+	node := newDomainControlTestNode(t, domain)
+
+	resolver := fakeTXTResolver{}
+	prev := domainControlResolver
+	domainControlResolver = resolver
+	defer func() { domainControlResolver = prev }()
+
+	ch, err := node.IssueDomainControlChallenge(domain, controller, DomainControlDNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.TXTRecordName != "_quidnug-challenge.example.com" || ch.TXTValue == "" {
+		t.Fatalf("challenge = %+v", ch)
+	}
+	if _, err := node.ValidateDomainControlChallenge(t.Context(), domain, ch.Token); !errors.Is(err, ErrDomainControlUnproven) {
+		t.Fatalf("unpublished proof: err = %v", err)
+	}
+	if _, err := node.ValidateDomainControlChallenge(t.Context(), domain, ch.Token); !errors.Is(err, ErrDomainControlNoChallenge) {
+		t.Errorf("challenge reusable after a failed check: err = %v", err)
+	}
+
+	ch, err = node.IssueDomainControlChallenge(domain, controller, DomainControlDNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver[ch.TXTRecordName] = []string{"unrelated", ch.TXTValue}
+	tx, err := node.ValidateDomainControlChallenge(t.Context(), domain, ch.Token)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	block, err := node.GenerateBlock(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	status := node.DomainControlRegistry.Status(domain, time.Now())
+	if !status.Validated || status.ControllerQuid != controller || status.TxID != tx.ID {
+		t.Fatalf("status = %+v", status)
+	}
+	if expired := node.DomainControlRegistry.Status(domain, time.Now().Add(DomainControlValidity+time.Hour)); expired.Validated {
+		t.Error("attestation still valid after ValidUntil")
+	}
+}
+
+func TestDomainControl_HTTPFlow(t *testing.T) {
+	const domain = "example.org"
+	node := newDomainControlTestNode(t, domain)
+
+	var keyAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(keyAuth + "\n"))
+	}))
+	defer srv.Close()
+	prev := domainControlHTTPURL
+	domainControlHTTPURL = func(_, token string) string { return srv.URL + domainControlHTTPPath + token }
+	defer func() { domainControlHTTPURL = prev }()
+	node.httpClient = srv.Client()
+
+	ch, err := node.IssueDomainControlChallenge(domain, "fedcba9876543210", DomainControlHTTP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyAuth = ch.KeyAuthorization
+	if _, err := node.ValidateDomainControlChallenge(t.Context(), domain, ch.Token); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+}
+
+func TestDomainControl_Rejections(t *testing.T) {
+	node := newDomainControlTestNode(t, "mine.local")
+	if _, err := node.IssueDomainControlChallenge("other.local", "0123456789abcdef", DomainControlDNS); !errors.Is(err, ErrDomainControlNotValidator) {
+		t.Errorf("challenge for a domain this node does not validate: err = %v", err)
+	}
+	if _, err := node.IssueDomainControlChallenge("mine.local", "0123456789abcdef", "tls-alpn-01"); err == nil {
+		t.Error("unknown method accepted")
+	}
+
+	tx := DomainControlTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeDomainControl,
+			TrustDomain: "mine.local",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		ValidatorID:    "aaaaaaaaaaaaaaaa",
+		ControllerQuid: "0123456789abcdef",
+		Method:         DomainControlDNS,
+		Token:          "t",
+		ValidUntil:     time.Now().Add(time.Hour).Unix(),
+	}
+	tx.ID = domainControlID(tx)
+	if node.ValidateDomainControlTransaction(tx) {
+		t.Error("attestation from a non-validator accepted")
+	}
+}
@@ -0,0 +1,590 @@
+// Package core — domain_join.go
+//
+// Validator onboarding for an existing trust domain.
+//
+// A node that wants to validate a domain it does not yet belong
+// to goes through three steps:
+//
+//  1. Its operator calls POST /domains/{name}/join on it with the
+//     address of one current validator. The node pulls that
+//     validator's chain (bootstrapping the domain locally, see
+//     BootstrapDomainFromBlock), then builds a DOMAIN_JOIN
+//     transaction signed with its node key and submits it to the
+//     validator's join-request pool.
+//  2. Operators of existing validators review the pool
+//     (GET /domains/{name}/join-requests) and approve with an
+//     admin-signed POST .../approve. The approving node adds its
+//     validator signature over the same signable bytes the
+//     candidate signed and forwards the request, approvals
+//     included, to the domain's other validators.
+//  3. Whichever validator first sees approvals from at least
+//     DomainJoinQuorum of the total validator weight moves the
+//     transaction into its pending pool. Once sealed, every node
+//     that processes the block adds the candidate to the domain's
+//     validator set and public-key table, so the new set
+//     propagates with the chain itself.
+//
+// Approvals are judged against the validator set the validating
+// node holds. A joiner that bootstrapped the domain from a block
+// sees only that block's signer until the chain it pulled
+// replays earlier joins, which is why step 1 syncs first.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go                : TxTypeDomainJoin const
+//   - domain_join.go          : this file — struct, pool, validator, apply
+//   - validation.go           : block dispatch
+//   - registry.go             : dispatch into applyDomainJoin
+//   - handlers_domain_join.go : join, pool, approve, and relay endpoints
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// DomainJoinQuorum is the fraction of total validator weight
+	// whose approval admits a new validator.
+	DomainJoinQuorum = 2.0 / 3.0
+
+	// DefaultDomainJoinWeight is the participation weight a
+	// candidate requests when it does not name one.
+	DefaultDomainJoinWeight = 1.0
+
+	// MaxPendingDomainJoins bounds the approval pool so
+	// unauthenticated submissions cannot grow it without limit.
+	MaxPendingDomainJoins = 256
+)
+
+// Errors returned by the join workflow.
+var (
+	ErrDomainJoinNotFound     = errors.New("domain join: no pending request with that ID")
+	ErrDomainJoinNotValidator = errors.New("domain join: this node is not a validator of the domain")
+	ErrDomainJoinPoolFull     = errors.New("domain join: too many pending requests")
+)
+
+// DomainJoinTransaction asks for NodeQuid to become a validator of
+// TrustDomain. The candidate signs it with its node key
+// (BaseTransaction.PublicKey/Signature); Approvals maps each
+// approving validator's node ID to its signature over the same
+// bytes.
+type DomainJoinTransaction struct {
+	BaseTransaction
+
+	NodeQuid string `json:"nodeQuid"`
+
+	// Weight is the participation weight the candidate will hold
+	// in the domain's Validators map, in (0, 1].
+	Weight float64 `json:"weight"`
+
+	Approvals map[string]string `json:"approvals,omitempty"`
+}
+
+// DomainJoinStatus reports how far a join request is from quorum.
+type DomainJoinStatus struct {
+	ID             string   `json:"id"`
+	TrustDomain    string   `json:"trustDomain"`
+	NodeQuid       string   `json:"nodeQuid"`
+	Weight         float64  `json:"weight"`
+	Approvals      []string `json:"approvals"`
+	ApprovedWeight float64  `json:"approvedWeight"`
+	RequiredWeight float64  `json:"requiredWeight"`
+	// Submitted is true once quorum was reached and the
+	// transaction entered the pending pool.
+	Submitted bool `json:"submitted"`
+}
+
+// DomainJoinRequest is the admin-signed body that starts a join on
+// the candidate node. Signature covers the JSON encoding of the
+// request with Signature empty.
+type DomainJoinRequest struct {
+	Domain           string  `json:"domain"`
+	ValidatorAddress string  `json:"validatorAddress"`
+	Weight           float64 `json:"weight,omitempty"`
+	Timestamp        int64   `json:"timestamp"`
+	PublicKey        string  `json:"publicKey"`
+	Signature        string  `json:"signature"`
+}
+
+// DomainJoinApprovalRequest is the admin-signed body with which a
+// validator's operator approves a pending join.
+type DomainJoinApprovalRequest struct {
+	Domain    string `json:"domain"`
+	RequestID string `json:"requestId"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// DomainJoinSignableBytes returns the bytes both the candidate and
+// the approving validators sign: the transaction with Signature
+// and Approvals cleared.
+func DomainJoinSignableBytes(tx DomainJoinTransaction) ([]byte, error) {
+	tx.Signature = ""
+	tx.Approvals = nil
+	return txSignableBytes(tx)
+}
+
+// domainJoinID derives the transaction ID from the fields that
+// identify a request.
+func domainJoinID(tx DomainJoinTransaction) string {
+	data, _ := json.Marshal(struct {
+		NodeQuid    string
+		TrustDomain string
+		PublicKey   string
+		Weight      float64
+		Timestamp   int64
+	}{tx.NodeQuid, tx.TrustDomain, tx.PublicKey, tx.Weight, tx.Timestamp})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDomainJoinRequest verifies everything about a join except
+// the approvals and returns the domain as this node sees it.
+func (node *QuidnugNode) checkDomainJoinRequest(tx DomainJoinTransaction) (TrustDomain, error) {
+	if tx.Type != TxTypeDomainJoin {
+		return TrustDomain{}, fmt.Errorf("domain join: wrong transaction type %q", tx.Type)
+	}
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok {
+		return TrustDomain{}, fmt.Errorf("domain join: unknown trust domain %q", tx.TrustDomain)
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		return TrustDomain{}, fmt.Errorf("domain join: trust domain %q is not supported by this node", tx.TrustDomain)
+	}
+	if !IsValidQuidID(tx.NodeQuid) {
+		return TrustDomain{}, fmt.Errorf("domain join: invalid node quid %q", tx.NodeQuid)
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		return TrustDomain{}, fmt.Errorf("domain join: missing signature or public key")
+	}
+	if computed := QuidIDFromPublicKeyHex(tx.PublicKey); computed != tx.NodeQuid {
+		return TrustDomain{}, fmt.Errorf("domain join: node quid does not match signing public key")
+	}
+	if _, already := domain.Validators[tx.NodeQuid]; already {
+		return TrustDomain{}, fmt.Errorf("domain join: %s is already a validator of %s", tx.NodeQuid, tx.TrustDomain)
+	}
+	if tx.Weight <= 0 || tx.Weight > 1 {
+		return TrustDomain{}, fmt.Errorf("domain join: weight must be in (0, 1], got %v", tx.Weight)
+	}
+	if tx.ID != domainJoinID(tx) {
+		return TrustDomain{}, fmt.Errorf("domain join: transaction ID does not match its contents")
+	}
+	signable, err := DomainJoinSignableBytes(tx)
+	if err != nil {
+		return TrustDomain{}, err
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		return TrustDomain{}, fmt.Errorf("domain join: candidate signature invalid")
+	}
+	return domain, nil
+}
+
+// domainJoinApprovals returns the validators whose approval
+// signature verifies, their combined weight, and the weight
+// quorum requires.
+func domainJoinApprovals(domain TrustDomain, tx DomainJoinTransaction) (approvers []string, approved, required float64) {
+	var total float64
+	for _, w := range domain.Validators {
+		total += w
+	}
+	signable, err := DomainJoinSignableBytes(tx)
+	if err != nil {
+		return nil, 0, total * DomainJoinQuorum
+	}
+	for validatorID, sig := range tx.Approvals {
+		weight, isValidator := domain.Validators[validatorID]
+		pub := domain.ValidatorPublicKeys[validatorID]
+		if !isValidator || pub == "" || !VerifySignature(pub, signable, sig) {
+			continue
+		}
+		approvers = append(approvers, validatorID)
+		approved += weight
+	}
+	sort.Strings(approvers)
+	return approvers, approved, total * DomainJoinQuorum
+}
+
+// ValidateDomainJoinTransaction checks a join bound for a block:
+// a valid candidate request carrying quorum approval.
+func (node *QuidnugNode) ValidateDomainJoinTransaction(tx DomainJoinTransaction) bool {
+	domain, err := node.checkDomainJoinRequest(tx)
+	if err != nil {
+		logger.Warn("Invalid domain join", "txId", tx.ID, "error", err)
+		return false
+	}
+	_, approved, required := domainJoinApprovals(domain, tx)
+	if required <= 0 || approved < required {
+		logger.Warn("Domain join lacks validator quorum",
+			"txId", tx.ID, "domain", tx.TrustDomain,
+			"approvedWeight", approved, "requiredWeight", required)
+		return false
+	}
+	return true
+}
+
+// SubmitDomainJoinRequest records a join request, merging its
+// approvals with any already pooled for the same ID. Approvals
+// that do not verify are dropped. When the merged request reaches
+// quorum it moves to the pending pool.
+func (node *QuidnugNode) SubmitDomainJoinRequest(tx DomainJoinTransaction) (*DomainJoinStatus, error) {
+	domain, err := node.checkDomainJoinRequest(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	node.domainJoinsMu.Lock()
+	if node.domainJoins == nil {
+		node.domainJoins = make(map[string]DomainJoinTransaction)
+	}
+	merged := make(map[string]string)
+	pooled, exists := node.domainJoins[tx.ID]
+	if !exists && len(node.domainJoins) >= MaxPendingDomainJoins {
+		node.domainJoinsMu.Unlock()
+		return nil, ErrDomainJoinPoolFull
+	}
+	for id, sig := range pooled.Approvals {
+		merged[id] = sig
+	}
+	for id, sig := range tx.Approvals {
+		merged[id] = sig
+	}
+	tx.Approvals = merged
+	approvers, approved, required := domainJoinApprovals(domain, tx)
+	kept := make(map[string]string, len(approvers))
+	for _, id := range approvers {
+		kept[id] = merged[id]
+	}
+	tx.Approvals = kept
+
+	status := &DomainJoinStatus{
+		ID:             tx.ID,
+		TrustDomain:    tx.TrustDomain,
+		NodeQuid:       tx.NodeQuid,
+		Weight:         tx.Weight,
+		Approvals:      approvers,
+		ApprovedWeight: approved,
+		RequiredWeight: required,
+	}
+	if status.Approvals == nil {
+		status.Approvals = []string{}
+	}
+	if approved < required {
+		node.domainJoins[tx.ID] = tx
+		node.domainJoinsMu.Unlock()
+		return status, nil
+	}
+	delete(node.domainJoins, tx.ID)
+	node.domainJoinsMu.Unlock()
+
+	if _, err := node.AddDomainJoinTransaction(tx); err != nil {
+		return nil, err
+	}
+	status.Submitted = true
+	return status, nil
+}
+
+// ListDomainJoinRequests returns the pooled requests for a domain,
+// oldest first.
+func (node *QuidnugNode) ListDomainJoinRequests(domainName string) []DomainJoinStatus {
+	node.TrustDomainsMutex.RLock()
+	domain := node.TrustDomains[domainName]
+	node.TrustDomainsMutex.RUnlock()
+
+	node.domainJoinsMu.Lock()
+	var txs []DomainJoinTransaction
+	for _, tx := range node.domainJoins {
+		if tx.TrustDomain == domainName {
+			txs = append(txs, tx)
+		}
+	}
+	node.domainJoinsMu.Unlock()
+
+	out := make([]DomainJoinStatus, 0, len(txs))
+	for _, tx := range txs {
+		approvers, approved, required := domainJoinApprovals(domain, tx)
+		if approvers == nil {
+			approvers = []string{}
+		}
+		out = append(out, DomainJoinStatus{
+			ID:             tx.ID,
+			TrustDomain:    tx.TrustDomain,
+			NodeQuid:       tx.NodeQuid,
+			Weight:         tx.Weight,
+			Approvals:      approvers,
+			ApprovedWeight: approved,
+			RequiredWeight: required,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// ApproveDomainJoin adds this node's validator signature to a
+// pooled request on its operator's admin-signed instruction, then
+// forwards the request to the domain's other validators unless
+// the approval completed quorum here.
+func (node *QuidnugNode) ApproveDomainJoin(req DomainJoinApprovalRequest) (*DomainJoinStatus, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return nil, err
+	}
+
+	node.TrustDomainsMutex.RLock()
+	domain, ok := node.TrustDomains[req.Domain]
+	node.TrustDomainsMutex.RUnlock()
+	if !ok || domain.ValidatorPublicKeys[node.NodeID] != node.GetPublicKeyHex() {
+		return nil, ErrDomainJoinNotValidator
+	}
+	if _, isValidator := domain.Validators[node.NodeID]; !isValidator {
+		return nil, ErrDomainJoinNotValidator
+	}
+
+	node.domainJoinsMu.Lock()
+	tx, ok := node.domainJoins[req.RequestID]
+	node.domainJoinsMu.Unlock()
+	if !ok || tx.TrustDomain != req.Domain {
+		return nil, ErrDomainJoinNotFound
+	}
+
+	data, err := DomainJoinSignableBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := node.SignData(data)
+	if err != nil {
+		return nil, err
+	}
+	approvals := make(map[string]string, len(tx.Approvals)+1)
+	for id, s := range tx.Approvals {
+		approvals[id] = s
+	}
+	approvals[node.NodeID] = hex.EncodeToString(sig)
+	tx.Approvals = approvals
+
+	status, err := node.SubmitDomainJoinRequest(tx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Approved domain join",
+		"txId", tx.ID, "domain", tx.TrustDomain, "candidate", tx.NodeQuid,
+		"approvedWeight", status.ApprovedWeight, "requiredWeight", status.RequiredWeight)
+	if !status.Submitted {
+		go node.forwardDomainJoinRequest(tx)
+	}
+	return status, nil
+}
+
+// RequestDomainJoin runs the candidate side of a join on its
+// operator's admin-signed instruction: sync the domain's chain
+// from the named validator, then submit a signed DOMAIN_JOIN to
+// that validator's pool.
+func (node *QuidnugNode) RequestDomainJoin(ctx context.Context, req DomainJoinRequest) (*DomainJoinStatus, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return nil, err
+	}
+	if !node.IsDomainSupported(req.Domain) {
+		return nil, fmt.Errorf("domain join: trust domain %q is not supported by this node", req.Domain)
+	}
+	weight := req.Weight
+	if weight == 0 {
+		weight = DefaultDomainJoinWeight
+	}
+
+	if err := node.pullBlocksFromPeer(ctx, "", req.ValidatorAddress); err != nil {
+		return nil, fmt.Errorf("domain join: sync from %s: %w", req.ValidatorAddress, err)
+	}
+	node.TrustDomainsMutex.RLock()
+	_, known := node.TrustDomains[req.Domain]
+	node.TrustDomainsMutex.RUnlock()
+	if !known {
+		return nil, fmt.Errorf("domain join: %s served no blocks for %q", req.ValidatorAddress, req.Domain)
+	}
+
+	tx, err := node.newDomainJoinTransaction(req.Domain, weight)
+	if err != nil {
+		return nil, err
+	}
+	status, err := node.postDomainJoinRequest(ctx, req.ValidatorAddress, tx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Submitted domain join request",
+		"txId", tx.ID, "domain", tx.TrustDomain, "validator", req.ValidatorAddress)
+	return status, nil
+}
+
+// newDomainJoinTransaction builds a join of this node to domain,
+// signed with the node key.
+func (node *QuidnugNode) newDomainJoinTransaction(domain string, weight float64) (DomainJoinTransaction, error) {
+	tx := DomainJoinTransaction{
+		BaseTransaction: BaseTransaction{
+			Type:        TxTypeDomainJoin,
+			TrustDomain: domain,
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		NodeQuid: node.NodeID,
+		Weight:   weight,
+	}
+	tx.ID = domainJoinID(tx)
+	data, err := DomainJoinSignableBytes(tx)
+	if err != nil {
+		return DomainJoinTransaction{}, err
+	}
+	sig, err := node.SignData(data)
+	if err != nil {
+		return DomainJoinTransaction{}, err
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	return tx, nil
+}
+
+// forwardDomainJoinRequest sends a request and the approvals
+// collected so far to the domain's other known validators.
+func (node *QuidnugNode) forwardDomainJoinRequest(tx DomainJoinTransaction) {
+	for _, peer := range node.GetTrustDomainNodes(tx.TrustDomain) {
+		if peer.ID == node.NodeID || peer.ID == tx.NodeQuid {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if _, err := node.postDomainJoinRequest(ctx, peer.Address, tx); err != nil {
+			logger.Warn("Failed to forward domain join request",
+				"txId", tx.ID, "peer", peer.ID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// postDomainJoinRequest submits tx to a peer's join-request pool
+// and returns the peer's view of its status.
+func (node *QuidnugNode) postDomainJoinRequest(ctx context.Context, addr string, tx DomainJoinTransaction) (*DomainJoinStatus, error) {
+	safeAddr, err := node.validatePeerAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	path := "/api/v1/domains/" + url.PathEscape(tx.TrustDomain) + "/join-requests"
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://"+safeAddr.String()+path, bytes.NewReader(body)) // #nosec -- URL built from sanitized address
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := GetNodeAuthSecret(); secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(NodeSignatureHeader, SignRequest("POST", path, body, secret, ts))
+		req.Header.Set(NodeTimestampHeader, strconv.FormatInt(ts, 10))
+	}
+	resp, err := node.httpClient.Do(req) // #nosec -- URL built from sanitized address; transport enforces safedial
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Data  DomainJoinStatus `json:"data"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("domain join: bad response from %s: %w", addr, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("domain join: %s rejected request: %s", addr, envelope.Error.Message)
+	}
+	return &envelope.Data, nil
+}
+
+// AddDomainJoinTransaction admits a quorum-approved join into the
+// pending pool and broadcasts it. Joins arrive fully signed, so
+// nothing is auto-filled.
+func (node *QuidnugNode) AddDomainJoinTransaction(tx DomainJoinTransaction) (string, error) {
+	if !node.ValidateDomainJoinTransaction(tx) {
+		RecordTransactionProcessed("domain_join", false)
+		return "", fmt.Errorf("invalid domain join transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("domain_join", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added domain join to pending pool",
+		"txId", tx.ID,
+		"candidate", tx.NodeQuid,
+		"approvals", len(tx.Approvals),
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// applyDomainJoin adds the candidate to the domain's validator
+// set. Called from processBlockTransactions; idempotent on replay.
+func (node *QuidnugNode) applyDomainJoin(tx DomainJoinTransaction) {
+	node.TrustDomainsMutex.Lock()
+	domain, ok := node.TrustDomains[tx.TrustDomain]
+	if !ok {
+		node.TrustDomainsMutex.Unlock()
+		return
+	}
+	if _, already := domain.Validators[tx.NodeQuid]; already {
+		node.TrustDomainsMutex.Unlock()
+		return
+	}
+	// Copy the maps: readers hold TrustDomain values whose maps
+	// they iterate without the lock.
+	validators := make(map[string]float64, len(domain.Validators)+1)
+	for id, w := range domain.Validators {
+		validators[id] = w
+	}
+	validators[tx.NodeQuid] = tx.Weight
+	keys := make(map[string]string, len(domain.ValidatorPublicKeys)+1)
+	for id, k := range domain.ValidatorPublicKeys {
+		keys[id] = k
+	}
+	keys[tx.NodeQuid] = tx.PublicKey
+	domain.Validators = validators
+	domain.ValidatorPublicKeys = keys
+	domain.ValidatorNodes = append(append([]string(nil), domain.ValidatorNodes...), tx.NodeQuid)
+	node.TrustDomains[tx.TrustDomain] = domain
+	node.TrustDomainsMutex.Unlock()
+
+	node.domainJoinsMu.Lock()
+	delete(node.domainJoins, tx.ID)
+	node.domainJoinsMu.Unlock()
+
+	if tx.NodeQuid == node.NodeID {
+		logger.Info("This node joined the domain's validator set",
+			"domain", tx.TrustDomain, "weight", tx.Weight)
+	} else {
+		logger.Info("Validator joined domain",
+			"domain", tx.TrustDomain, "validator", tx.NodeQuid, "weight", tx.Weight)
+	}
+}
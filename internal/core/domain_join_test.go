@@ -0,0 +1,199 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newJoinFixture returns a node that validates "join.example"
+// alongside two other validators of equal weight, plus those two.
+func newJoinFixture(t *testing.T) (*QuidnugNode, []*QuidnugNode) {
+	t.Helper()
+	node := newTestNode()
+	peers := []*QuidnugNode{newTestNode(), newTestNode()}
+	domain := TrustDomain{
+		Name:                "join.example",
+		ValidatorNodes:      []string{node.NodeID},
+		TrustThreshold:      0.75,
+		Validators:          map[string]float64{node.NodeID: 1.0},
+		ValidatorPublicKeys: map[string]string{node.NodeID: node.GetPublicKeyHex()},
+	}
+	for _, p := range peers {
+		domain.ValidatorNodes = append(domain.ValidatorNodes, p.NodeID)
+		domain.Validators[p.NodeID] = 1.0
+		domain.ValidatorPublicKeys[p.NodeID] = p.GetPublicKeyHex()
+	}
+	node.TrustDomains[domain.Name] = domain
+	return node, peers
+}
+
+func signJoinApproval(t *testing.T, validator *QuidnugNode, tx DomainJoinTransaction) string {
+	t.Helper()
+	data, err := DomainJoinSignableBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := validator.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(sig)
+}
+
+func signedJoinApprovalRequest(t *testing.T, node *QuidnugNode, domain, id string) DomainJoinApprovalRequest {
+	t.Helper()
+	req := DomainJoinApprovalRequest{Domain: domain, RequestID: id, Timestamp: time.Now().Unix(), PublicKey: node.GetPublicKeyHex()}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+func TestDomainJoin_QuorumAdmitsValidator(t *testing.T) {
+	node, peers := newJoinFixture(t)
+	candidate := newTestNode()
+	tx, err := candidate.newDomainJoinTransaction("join.example", 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := node.SubmitDomainJoinRequest(tx)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if status.Submitted || status.ApprovedWeight != 0 || status.RequiredWeight != 2 {
+		t.Fatalf("fresh request: %+v", status)
+	}
+
+	status, err = node.ApproveDomainJoin(signedJoinApprovalRequest(t, node, "join.example", tx.ID))
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if status.Submitted || status.ApprovedWeight != 1 {
+		t.Fatalf("after one approval: %+v", status)
+	}
+	if got := node.ListDomainJoinRequests("join.example"); len(got) != 1 || len(got[0].Approvals) != 1 {
+		t.Fatalf("pool = %+v", got)
+	}
+
+	// A second validator's approval arrives forwarded; the pool
+	// merges it with the first and quorum is reached.
+	forwarded := tx
+	forwarded.Approvals = map[string]string{peers[0].NodeID: signJoinApproval(t, peers[0], tx)}
+	status, err = node.SubmitDomainJoinRequest(forwarded)
+	if err != nil {
+		t.Fatalf("forwarded submit: %v", err)
+	}
+	if !status.Submitted || status.ApprovedWeight != 2 {
+		t.Fatalf("after quorum: %+v", status)
+	}
+	if got := node.ListDomainJoinRequests("join.example"); len(got) != 0 {
+		t.Fatalf("pool not drained: %+v", got)
+	}
+
+	node.PendingTxsMutex.RLock()
+	pending := node.PendingTxs[len(node.PendingTxs)-1]
+	node.PendingTxsMutex.RUnlock()
+	sealed, ok := pending.(DomainJoinTransaction)
+	if !ok || len(sealed.Approvals) != 2 {
+		t.Fatalf("pending tx = %#v", pending)
+	}
+
+	node.processBlockTransactions(Block{
+		Index:        1,
+		Transactions: []interface{}{sealed},
+		TrustProof:   TrustProof{TrustDomain: "join.example"},
+	})
+	domain := node.TrustDomains["join.example"]
+	if domain.Validators[candidate.NodeID] != 0.5 || domain.ValidatorPublicKeys[candidate.NodeID] != candidate.GetPublicKeyHex() {
+		t.Fatalf("candidate not in validator set: %+v", domain)
+	}
+	if node.ValidateDomainJoinTransaction(sealed) {
+		t.Error("a join for an existing validator still validates")
+	}
+}
+
+func TestDomainJoin_RejectsForgeries(t *testing.T) {
+	node, peers := newJoinFixture(t)
+	candidate := newTestNode()
+	tx, err := candidate.newDomainJoinTransaction("join.example", 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Approvals signed by the wrong key, or by a non-validator,
+	// are dropped rather than counted.
+	tx.Approvals = map[string]string{
+		peers[0].NodeID:      signJoinApproval(t, candidate, tx),
+		candidate.NodeID:     signJoinApproval(t, candidate, tx),
+		newTestNode().NodeID: signJoinApproval(t, peers[1], tx),
+	}
+	status, err := node.SubmitDomainJoinRequest(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ApprovedWeight != 0 || len(status.Approvals) != 0 {
+		t.Fatalf("forged approvals counted: %+v", status)
+	}
+
+	tampered := tx
+	tampered.Weight = 0.9
+	if _, err := node.SubmitDomainJoinRequest(tampered); err == nil {
+		t.Error("request with altered weight accepted")
+	}
+
+	tx.Approvals = map[string]string{peers[0].NodeID: signJoinApproval(t, peers[0], tx)}
+	if node.ValidateDomainJoinTransaction(tx) {
+		t.Error("join below quorum validates")
+	}
+}
+
+func TestDomainJoinHandlers(t *testing.T) {
+	node, _ := newJoinFixture(t)
+	router := setupTestRouter(node)
+	candidate := newTestNode()
+	tx, err := candidate.newDomainJoinTransaction("join.example", 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(tx)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/domains/join.example/join-requests", bytes.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("submit: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Approval signed by someone other than the operator.
+	forged := signedJoinApprovalRequest(t, candidate, "join.example", tx.ID)
+	body, _ = json.Marshal(forged)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/domains/join.example/join-requests/"+tx.ID+"/approve", bytes.NewReader(body)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("forged approval: status %d, want 403", rec.Code)
+	}
+
+	unknown := signedJoinApprovalRequest(t, node, "join.example", "deadbeef")
+	body, _ = json.Marshal(unknown)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/domains/join.example/join-requests/deadbeef/approve", bytes.NewReader(body)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown request: status %d, want 404", rec.Code)
+	}
+
+	ok := signedJoinApprovalRequest(t, node, "join.example", tx.ID)
+	body, _ = json.Marshal(ok)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/domains/join.example/join-requests/"+tx.ID+"/approve", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -224,6 +224,24 @@ func extractTxDomain(tx interface{}) string {
 		return v.TrustDomain
 	case ModerationActionTransaction:
 		return v.TrustDomain
+	case NameRegistrationTransaction:
+		return v.TrustDomain
+	case LienTransaction:
+		return v.TrustDomain
+	case SuccessionTransaction:
+		return v.TrustDomain
+	case MisbehaviorReportTransaction:
+		return v.TrustDomain
+	case DomainJoinTransaction:
+		return v.TrustDomain
+	case TransferApprovalTransaction:
+		return v.TrustDomain
+	case TitleRestructureTransaction:
+		return v.TrustDomain
+	case TitleDisputeTransaction:
+		return v.TrustDomain
+	case CustomTransaction:
+		return v.TrustDomain
 	}
 	return ""
 }
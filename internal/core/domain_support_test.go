@@ -872,7 +872,7 @@ func TestQueryOtherDomain_FallsBackToSubdomainNodes(t *testing.T) {
 
 	// Query for parent domain (example.com) - no node manages it directly
 	// but subnode1 manages api.example.com
-	result, err := node.QueryOtherDomain("example.com", "identity", "test")
+	result, err := node.QueryOtherDomain(context.Background(), "example.com", "identity", "test")
 	if err != nil {
 		t.Fatalf("Expected success via subdomain fallback, got error: %v", err)
 	}
@@ -927,7 +927,7 @@ func TestQueryOtherDomain_PrefersParentOverSubdomain(t *testing.T) {
 	node.updateDomainRegistry("subnode", []string{"api.example.com"})
 
 	// Query for sub.example.com - should try example.com first (parent walking)
-	_, err := node.QueryOtherDomain("sub.example.com", "identity", "test")
+	_, err := node.QueryOtherDomain(context.Background(), "sub.example.com", "identity", "test")
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -1439,7 +1439,7 @@ func TestCrossDomainQueryDelegation_ViaSubdomain(t *testing.T) {
 
 	// Query for example.com (no direct or parent node exists)
 	// Should fall back to subdomain node
-	result, err := node.QueryOtherDomain("example.com", "identity", "testquid1234567")
+	result, err := node.QueryOtherDomain(context.Background(), "example.com", "identity", "testquid1234567")
 	if err != nil {
 		t.Fatalf("Expected query to succeed via subdomain delegation, got error: %v", err)
 	}
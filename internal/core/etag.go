@@ -0,0 +1,170 @@
+// Conditional GETs for chain-derived reads.
+//
+// Blocks, registry lookups and trust queries change only when the
+// chain grows or a registry is mutated, so their ETag is derived
+// from that state instead of from the response body: the chain
+// length, a counter bumped on every registry mutation, and the
+// process start time (the counter restarts at zero). A matching
+// If-None-Match is answered with 304 before the handler runs, so
+// polling clients cost neither the query nor the serialization.
+//
+// Trust scores also decay and expire with time, so trust reads add
+// a time window to the tag equal to the trust cache TTL; the node
+// already serves trust results that old.
+//
+// The tag is taken before the handler runs. If state moves while
+// the handler is reading, the client holds an older tag for a
+// newer body and simply refetches next time; it never keeps a
+// stale body.
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// etagPathPrefixes are the read endpoints whose responses depend
+// only on the chain and the block-derived registries. Paths are
+// matched after the /api, /api/v1 or /api/v2 prefix is stripped.
+var etagPathPrefixes = []string{
+	"/blocks",
+	"/identity/",
+	"/title/",
+	"/trust/",
+	"/registry/",
+	"/owners/",
+	"/filters/",
+}
+
+// etagExcludedPrefixes carve out reads under etagPathPrefixes that
+// also depend on the mempool, tentative blocks, or live streams.
+var etagExcludedPrefixes = []string{
+	"/blocks/stream",
+	"/blocks/tentative/",
+	"/blocks/quarantine",
+}
+
+// etagTimeBoundPrefix marks reads whose result also depends on the
+// clock (edge expiry and decay).
+const etagTimeBoundPrefix = "/trust/"
+
+// bumpRegistryVersion invalidates every outstanding ETag.
+func (node *QuidnugNode) bumpRegistryVersion() {
+	node.registryVersion.Add(1)
+}
+
+// stateETag is the current weak ETag for chain-derived reads. A
+// non-zero window adds the current time window to the tag.
+func (node *QuidnugNode) stateETag(window time.Duration) string {
+	node.BlockchainMutex.RLock()
+	height := len(node.Blockchain)
+	node.BlockchainMutex.RUnlock()
+	tag := strconv.FormatInt(node.processStartedAt.UnixNano(), 36) +
+		"-" + strconv.Itoa(height) +
+		"-" + strconv.FormatUint(node.registryVersion.Load(), 10)
+	if window > 0 {
+		tag += "-" + strconv.FormatInt(time.Now().UnixNano()/int64(window), 36)
+	}
+	return `W/"` + tag + `"`
+}
+
+// trustETagWindow is how long a trust read's ETag stays valid.
+func (node *QuidnugNode) trustETagWindow() time.Duration {
+	if node.TrustCache != nil && node.TrustCache.ttl > 0 {
+		return node.TrustCache.ttl
+	}
+	return config.DefaultTrustCacheTTL
+}
+
+// etagPath strips the API prefix from a request path.
+func etagPath(r *http.Request) string {
+	path := r.URL.Path
+	for _, prefix := range []string{"/api/v1", "/api/v2", "/api"} {
+		if strings.HasPrefix(path, prefix+"/") {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
+
+// etagEligible reports whether r is a read whose response is keyed
+// by stateETag.
+func etagEligible(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if includeTentative(r) {
+		return false
+	}
+	path := etagPath(r)
+	if strings.HasSuffix(path, "/pending-transfer") {
+		return false
+	}
+	for _, p := range etagExcludedPrefixes {
+		if strings.HasPrefix(path, p) {
+			return false
+		}
+	}
+	for _, p := range etagPathPrefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches implements the weak comparison If-None-Match uses.
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// etagResponseWriter drops the ETag from non-2xx responses, which
+// are not representations of the resource.
+type etagResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *etagResponseWriter) WriteHeader(code int) {
+	if code < 200 || code > 299 {
+		w.Header().Del("ETag")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *etagResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ETagMiddleware serves conditional GETs for chain-derived reads.
+// A gateway holds no chain of its own, so it never tags responses.
+func (node *QuidnugNode) ETagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if node.Gateway != nil || !etagEligible(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var window time.Duration
+		if strings.HasPrefix(etagPath(r), etagTimeBoundPrefix) {
+			window = node.trustETagWindow()
+		}
+		etag := node.stateETag(window)
+		w.Header().Set("ETag", etag)
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(&etagResponseWriter{ResponseWriter: w}, r)
+	})
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	node := newTestNode()
+	appendArchiveTestBlocks(node, 2)
+	handler := node.ETagMiddleware(setupTestRouter(node))
+
+	get := func(path, inm string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/v1/identity/0000000000000001", "")
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first read: %d etag=%q", rr.Code, etag)
+	}
+	if rr := get("/api/v1/identity/0000000000000001", etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("conditional read: got %d", rr.Code)
+	}
+
+	node.bumpRegistryVersion()
+	if rr := get("/api/v1/identity/0000000000000001", etag); rr.Code != http.StatusOK {
+		t.Fatalf("after registry change: got %d", rr.Code)
+	}
+
+	etag = get("/api/v1/blocks", "").Header().Get("ETag")
+	appendArchiveTestBlocks(node, 1)
+	if rr := get("/api/v1/blocks", etag); rr.Code != http.StatusOK {
+		t.Fatalf("after new block: got %d", rr.Code)
+	}
+
+	if rr := get("/api/v1/identity/ffffffffffffffff", ""); rr.Code != http.StatusNotFound || rr.Header().Get("ETag") != "" {
+		t.Fatalf("not found should carry no ETag: %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	for _, path := range []string{"/api/v1/nodes", "/api/v1/identity/0000000000000001?include_tentative=true", "/api/v1/blocks/tentative/test.domain.com"} {
+		if get(path, "").Header().Get("ETag") != "" {
+			t.Errorf("%s should not be tagged", path)
+		}
+	}
+}
+
+func TestStateETag_TrustWindow(t *testing.T) {
+	node := newTestNode()
+	if node.stateETag(0) == node.stateETag(node.trustETagWindow()) {
+		t.Fatal("time-windowed tag should differ from the plain tag")
+	}
+	if !etagMatches(`"x", W/"y"`, `W/"y"`) || etagMatches(`"x"`, `W/"y"`) {
+		t.Fatal("weak comparison mismatch")
+	}
+}
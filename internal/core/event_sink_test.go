@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink collects what a publisher sends.
+type recordingSink struct{ got chan []SinkMessage }
+
+func (s *recordingSink) Publish(msgs []SinkMessage) error { s.got <- msgs; return nil }
+func (s *recordingSink) Close() error                     { return nil }
+
+func TestEventPublisher_JSON(t *testing.T) {
+	sink := &recordingSink{got: make(chan []SinkMessage, 1)}
+	p, err := NewEventPublisher(sink, "qn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	p.Enqueue(watchTestBlock(3))
+	select {
+	case msgs := <-sink.got:
+		if len(msgs) != 3 {
+			t.Fatalf("expected 3 messages, got %d", len(msgs))
+		}
+		want := []string{"qn.block", "qn.trust", "qn.title"}
+		for i, m := range msgs {
+			if m.Topic != want[i] || m.Key != "test.domain.com" {
+				t.Errorf("message %d: topic %s key %s", i, m.Topic, m.Key)
+			}
+		}
+		var ev ChainEvent
+		if err := json.Unmarshal(msgs[2].Value, &ev); err != nil || ev.AssetID != "asset-1" {
+			t.Errorf("title event %+v, err %v", ev, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing published")
+	}
+}
+
+func TestEventPublisher_BadFormat(t *testing.T) {
+	if _, err := NewEventPublisher(&recordingSink{}, "qn", "xml"); err == nil {
+		t.Error("expected unknown format to be rejected")
+	}
+}
+
+// avroReader decodes the primitives encodeChainEventAvro writes.
+type avroReader struct{ b []byte }
+
+func (r *avroReader) long() int64 {
+	u, n := binary.Uvarint(r.b)
+	r.b = r.b[n:]
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (r *avroReader) str() string {
+	n := r.long()
+	s := string(r.b[:n])
+	r.b = r.b[n:]
+	return s
+}
+
+func TestEncodeChainEventAvro(t *testing.T) {
+	ev := blockEvents(watchTestBlock(-2))[1]
+	msg := encodeChainEventAvro(ev)
+
+	if msg[0] != 0xc3 || msg[1] != 0x01 {
+		t.Fatalf("missing single-object marker: % x", msg[:2])
+	}
+	if fp := binary.LittleEndian.Uint64(msg[2:10]); fp != avroFingerprint([]byte(ChainEventAvroSchema)) {
+		t.Errorf("fingerprint %x", fp)
+	}
+	r := &avroReader{b: msg[10:]}
+	if r.str() != ChainEventTrust || r.long() != -2 || r.str() != "h" || r.str() != "test.domain.com" ||
+		r.str() != "t1" || r.str() != string(TxTypeTrust) {
+		t.Fatal("header fields did not round-trip")
+	}
+	var quids []string
+	for n := r.long(); n != 0; n = r.long() {
+		for ; n > 0; n-- {
+			quids = append(quids, r.str())
+		}
+	}
+	if len(quids) != 2 || r.str() != "" || r.long() != ev.Timestamp || r.str() != string(ev.Transaction) || len(r.b) != 0 {
+		t.Errorf("tail fields did not round-trip: quids %v, %d bytes left", quids, len(r.b))
+	}
+}
+
+func TestAvroFingerprint(t *testing.T) {
+	// From the Avro specification's test vectors.
+	if fp := avroFingerprint([]byte(`"int"`)); fp != 0x7275d51a3f395c8f {
+		t.Errorf(`fingerprint of "int" = %x`, fp)
+	}
+}
+
+func TestKafkaRESTSink_Publish(t *testing.T) {
+	type produce struct {
+		path, contentType string
+		records           []kafkaRecord
+	}
+	got := make(chan produce, 4)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		got <- produce{r.URL.Path, r.Header.Get("Content-Type"), body.Records}
+	}))
+	t.Cleanup(proxy.Close)
+
+	sink, err := NewKafkaRESTSink(proxy.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.Publish([]SinkMessage{
+		{Topic: "qn.trust", Key: "d", Value: []byte("1")},
+		{Topic: "qn.title", Key: "d", Value: []byte("2")},
+		{Topic: "qn.trust", Key: "d", Value: []byte("3")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := <-got, <-got
+	if first.path != "/topics/qn.trust" || len(first.records) != 2 || second.path != "/topics/qn.title" {
+		t.Fatalf("unexpected requests %+v %+v", first, second)
+	}
+	if first.contentType != "application/vnd.kafka.binary.v2+json" {
+		t.Errorf("content type %q", first.contentType)
+	}
+	if v, _ := base64.StdEncoding.DecodeString(first.records[1].Value); string(v) != "3" {
+		t.Errorf("second trust record = %q", v)
+	}
+
+	if _, err := NewKafkaRESTSink("kafka://broker:9092"); err == nil {
+		t.Error("expected non-HTTP URL to be rejected")
+	}
+}
+
+// fakeNATSServer accepts one client, checks its CONNECT carries
+// wantConnect and reports each PUB as "subject payload".
+func fakeNATSServer(t *testing.T, wantConnect ...string) (addr string, pubs <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan string, 8)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch f := strings.Fields(line); f[0] {
+			case "CONNECT":
+				for _, want := range wantConnect {
+					if !strings.Contains(line, want) {
+						fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+						return
+					}
+				}
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				var n int
+				fmt.Sscan(f[2], &n)
+				payload := make([]byte, n+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				ch <- f[1] + " " + string(payload[:n])
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestNATSSink_Publish(t *testing.T) {
+	addr, pubs := fakeNATSServer(t, `"user":"svc"`, `"pass":"s3cret"`)
+	sink, err := NewNATSSink("nats://svc:s3cret@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sink.Close() })
+
+	err = sink.Publish([]SinkMessage{{Topic: "qn.block", Value: []byte(`{"a":1}`)}, {Topic: "qn.trust", Value: []byte("x")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`qn.block {"a":1}`, "qn.trust x"} {
+		select {
+		case got := <-pubs:
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("publish not received")
+		}
+	}
+}
+
+func TestNATSSink_AuthRejected(t *testing.T) {
+	addr, _ := fakeNATSServer(t, `"auth_token":"right"`)
+	sink, err := NewNATSSink("nats://wrong@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Publish([]SinkMessage{{Topic: "qn.block", Value: []byte("x")}}); err == nil {
+		t.Error("expected handshake to fail")
+	}
+}
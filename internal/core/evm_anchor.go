@@ -0,0 +1,555 @@
+// Package core — evm_anchor.go
+//
+// EVM anchoring: an external timestamp for a domain's chain.
+//
+// With evm_anchor_rpc_url set, every EVMAnchorInterval the node
+// takes each configured domain's latest block and the domain's
+// state root after it (domainStateRoot), and calls
+//
+//	anchor(bytes32 domain, uint64 height, bytes32 blockHash, bytes32 stateRoot)
+//
+// on the configured contract, where domain is keccak256 of the
+// domain name. A domain with no new block since its last anchor is
+// skipped. Transactions are sent with eth_sendTransaction, so key
+// custody stays with the RPC endpoint; the next pass fetches each
+// pending transaction's receipt and records the EVM block that
+// included it.
+//
+// Once an anchor is confirmed, anyone can check that the domain had
+// BlockHash at Height no later than that EVM block, independently of
+// this node: the proof from GET /domains/{name}/evm-anchor carries
+// the transaction hash and calldata to look up on the EVM chain.
+// Blocks below an anchored height are covered by following PrevHash
+// links down from the anchored block, so a history rewritten after
+// the anchor no longer matches it.
+//
+// Anchors are kept in evm_anchors.json in the data directory so the
+// proofs outlive restarts.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+const (
+	// evmAnchorsKept bounds the anchors kept per domain; the oldest
+	// are dropped first.
+	evmAnchorsKept = 1024
+
+	// evmAnchorRPCTimeout bounds one JSON-RPC call.
+	evmAnchorRPCTimeout = 30 * time.Second
+
+	// evmAnchorSnapshotAttempts bounds the retries when a block
+	// commits while a domain's state root is being computed.
+	evmAnchorSnapshotAttempts = 3
+
+	// evmAnchorSignature is the contract function anchors call.
+	evmAnchorSignature = "anchor(bytes32,uint64,bytes32,bytes32)"
+)
+
+// Anchor statuses.
+const (
+	EVMAnchorPending   = "pending"
+	EVMAnchorConfirmed = "confirmed"
+	EVMAnchorFailed    = "failed"
+)
+
+// EVMAnchor is one published anchor and, once confirmed, its proof:
+// the EVM transaction TxHash, sent to Contract with Calldata, was
+// included in EVM block EVMBlockNumber on chain ChainID.
+type EVMAnchor struct {
+	TrustDomain    string `json:"trustDomain"`
+	Height         int64  `json:"height"`
+	BlockHash      string `json:"blockHash"`
+	StateRoot      string `json:"stateRoot"`
+	ChainID        uint64 `json:"chainId"`
+	Contract       string `json:"contract"`
+	From           string `json:"from"`
+	Calldata       string `json:"calldata"`
+	TxHash         string `json:"txHash"`
+	SubmittedAt    int64  `json:"submittedAt"`
+	Status         string `json:"status"`
+	EVMBlockNumber uint64 `json:"evmBlockNumber,omitempty"`
+	EVMBlockHash   string `json:"evmBlockHash,omitempty"`
+}
+
+// EVMAnchorer publishes anchors and keeps the record of them.
+type EVMAnchorer struct {
+	rpcURL   string
+	contract string
+	from     string
+	domains  []string
+	client   *http.Client
+	path     string
+
+	mu      sync.RWMutex
+	chainID uint64
+	anchors map[string][]EVMAnchor // domain -> oldest first
+}
+
+// persistedEVMAnchors is the evm_anchors.json format.
+type persistedEVMAnchors struct {
+	Anchors map[string][]EVMAnchor `json:"anchors"`
+}
+
+// newEVMAnchorer builds the anchorer from cfg, or returns nil when
+// evm_anchor_rpc_url is unset.
+func newEVMAnchorer(cfg *config.Config) (*EVMAnchorer, error) {
+	if cfg.EVMAnchorRPCURL == "" {
+		return nil, nil
+	}
+	contract, err := parseEVMAddress(cfg.EVMAnchorContract)
+	if err != nil {
+		return nil, fmt.Errorf("evm_anchor_contract: %w", err)
+	}
+	from, err := parseEVMAddress(cfg.EVMAnchorFrom)
+	if err != nil {
+		return nil, fmt.Errorf("evm_anchor_from: %w", err)
+	}
+	if len(cfg.EVMAnchorDomains) == 0 {
+		return nil, errors.New("evm_anchor_rpc_url requires evm_anchor_domains")
+	}
+	a := &EVMAnchorer{
+		rpcURL:   cfg.EVMAnchorRPCURL,
+		contract: contract,
+		from:     from,
+		domains:  cfg.EVMAnchorDomains,
+		client:   &http.Client{Timeout: evmAnchorRPCTimeout},
+		anchors:  make(map[string][]EVMAnchor),
+	}
+	if cfg.DataDir != "" {
+		a.path = filepath.Join(cfg.DataDir, "evm_anchors.json")
+		if err := a.load(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// parseEVMAddress checks a 0x-prefixed 20-byte hex address and
+// returns it lowercased.
+func parseEVMAddress(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "0x") || len(s) != 42 {
+		return "", fmt.Errorf("%q is not a 0x-prefixed 20-byte address", s)
+	}
+	if _, err := hex.DecodeString(s[2:]); err != nil {
+		return "", fmt.Errorf("%q is not a 0x-prefixed 20-byte address", s)
+	}
+	return s, nil
+}
+
+// List returns the domain's anchors, oldest first.
+func (a *EVMAnchorer) List(domain string) []EVMAnchor {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]EVMAnchor(nil), a.anchors[domain]...)
+}
+
+// Proof returns the earliest confirmed anchor at or above height,
+// the one that covers the block there. height <= 0 returns the
+// latest confirmed anchor.
+func (a *EVMAnchorer) Proof(domain string, height int64) (EVMAnchor, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	list := a.anchors[domain]
+	if height <= 0 {
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].Status == EVMAnchorConfirmed {
+				return list[i], true
+			}
+		}
+		return EVMAnchor{}, false
+	}
+	for _, anchor := range list {
+		if anchor.Status == EVMAnchorConfirmed && anchor.Height >= height {
+			return anchor, true
+		}
+	}
+	return EVMAnchor{}, false
+}
+
+// last returns the domain's newest anchor that has not failed.
+func (a *EVMAnchorer) last(domain string) (EVMAnchor, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	list := a.anchors[domain]
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].Status != EVMAnchorFailed {
+			return list[i], true
+		}
+	}
+	return EVMAnchor{}, false
+}
+
+// add records a new anchor, dropping the domain's oldest beyond
+// evmAnchorsKept.
+func (a *EVMAnchorer) add(anchor EVMAnchor) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := append(a.anchors[anchor.TrustDomain], anchor)
+	if len(list) > evmAnchorsKept {
+		list = list[len(list)-evmAnchorsKept:]
+	}
+	a.anchors[anchor.TrustDomain] = list
+}
+
+// pending returns every anchor still awaiting its receipt.
+func (a *EVMAnchorer) pending() []EVMAnchor {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var out []EVMAnchor
+	for _, list := range a.anchors {
+		for _, anchor := range list {
+			if anchor.Status == EVMAnchorPending {
+				out = append(out, anchor)
+			}
+		}
+	}
+	return out
+}
+
+// update replaces the anchor with the same domain and TxHash.
+func (a *EVMAnchorer) update(anchor EVMAnchor) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := a.anchors[anchor.TrustDomain]
+	for i := range list {
+		if list[i].TxHash == anchor.TxHash {
+			list[i] = anchor
+			return
+		}
+	}
+}
+
+// load reads evm_anchors.json; a missing file is a clean start.
+func (a *EVMAnchorer) load() error {
+	raw, err := safeio.ReadFile(a.path)
+	if err != nil {
+		return nil
+	}
+	var doc persistedEVMAnchors
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", a.path, err)
+	}
+	if doc.Anchors != nil {
+		a.anchors = doc.Anchors
+	}
+	return nil
+}
+
+// save writes evm_anchors.json. No-op without a data directory.
+func (a *EVMAnchorer) save() error {
+	if a.path == "" {
+		return nil
+	}
+	a.mu.RLock()
+	body, err := json.MarshalIndent(persistedEVMAnchors{Anchors: a.anchors}, "", "  ")
+	a.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return safeio.WriteFileMode(a.path, append(body, '\n'), 0o600)
+}
+
+// evmAnchorCalldata ABI-encodes the anchor call.
+func evmAnchorCalldata(domain string, height int64, blockHash, stateRoot string) ([]byte, error) {
+	if height < 0 {
+		return nil, fmt.Errorf("negative height %d", height)
+	}
+	blockWord, err := evmWord(blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("block hash: %w", err)
+	}
+	rootWord, err := evmWord(stateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("state root: %w", err)
+	}
+	var heightWord [32]byte
+	binary.BigEndian.PutUint64(heightWord[24:], uint64(height))
+
+	out := make([]byte, 0, 4+4*32)
+	out = append(out, keccak256([]byte(evmAnchorSignature))[:4]...)
+	out = append(out, keccak256([]byte(domain))...)
+	out = append(out, heightWord[:]...)
+	out = append(out, blockWord[:]...)
+	out = append(out, rootWord[:]...)
+	return out, nil
+}
+
+// evmWord decodes a hex digest into a bytes32 word; an empty
+// string, as for an empty state root, is the zero word.
+func evmWord(h string) ([32]byte, error) {
+	var word [32]byte
+	if h == "" {
+		return word, nil
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(h, "0x"))
+	if err != nil {
+		return word, err
+	}
+	if len(raw) != 32 {
+		return word, fmt.Errorf("want 32 bytes, got %d", len(raw))
+	}
+	copy(word[:], raw)
+	return word, nil
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// rpc makes one JSON-RPC call and decodes its result into out.
+func (a *EVMAnchorer) rpc(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d", method, resp.StatusCode)
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if envelope.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, envelope.Error.Message, envelope.Error.Code)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+// parseHexQuantity decodes a JSON-RPC hex quantity such as "0x1a".
+func parseHexQuantity(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
+
+// chain returns the EVM chain ID, fetched once.
+func (a *EVMAnchorer) chain(ctx context.Context) (uint64, error) {
+	a.mu.RLock()
+	id := a.chainID
+	a.mu.RUnlock()
+	if id != 0 {
+		return id, nil
+	}
+	var hexID string
+	if err := a.rpc(ctx, "eth_chainId", nil, &hexID); err != nil {
+		return 0, err
+	}
+	id, err := parseHexQuantity(hexID)
+	if err != nil {
+		return 0, fmt.Errorf("eth_chainId: %w", err)
+	}
+	a.mu.Lock()
+	a.chainID = id
+	a.mu.Unlock()
+	return id, nil
+}
+
+// publish sends the anchor transaction for one domain snapshot and
+// returns the pending anchor.
+func (a *EVMAnchorer) publish(ctx context.Context, domain string, height int64, blockHash, stateRoot string) (EVMAnchor, error) {
+	calldata, err := evmAnchorCalldata(domain, height, blockHash, stateRoot)
+	if err != nil {
+		return EVMAnchor{}, err
+	}
+	chainID, err := a.chain(ctx)
+	if err != nil {
+		return EVMAnchor{}, err
+	}
+	data := "0x" + hex.EncodeToString(calldata)
+	var txHash string
+	err = a.rpc(ctx, "eth_sendTransaction", []interface{}{map[string]string{
+		"from": a.from,
+		"to":   a.contract,
+		"data": data,
+	}}, &txHash)
+	if err != nil {
+		return EVMAnchor{}, err
+	}
+	return EVMAnchor{
+		TrustDomain: domain,
+		Height:      height,
+		BlockHash:   blockHash,
+		StateRoot:   stateRoot,
+		ChainID:     chainID,
+		Contract:    a.contract,
+		From:        a.from,
+		Calldata:    data,
+		TxHash:      txHash,
+		SubmittedAt: time.Now().Unix(),
+		Status:      EVMAnchorPending,
+	}, nil
+}
+
+// confirm fetches a pending anchor's receipt. It reports false while
+// the transaction is not yet mined.
+func (a *EVMAnchorer) confirm(ctx context.Context, anchor EVMAnchor) (EVMAnchor, bool, error) {
+	var receipt *struct {
+		Status      string `json:"status"`
+		BlockNumber string `json:"blockNumber"`
+		BlockHash   string `json:"blockHash"`
+	}
+	if err := a.rpc(ctx, "eth_getTransactionReceipt", []interface{}{anchor.TxHash}, &receipt); err != nil {
+		return anchor, false, err
+	}
+	if receipt == nil || receipt.BlockNumber == "" {
+		return anchor, false, nil
+	}
+	number, err := parseHexQuantity(receipt.BlockNumber)
+	if err != nil {
+		return anchor, false, fmt.Errorf("receipt block number: %w", err)
+	}
+	anchor.EVMBlockNumber = number
+	anchor.EVMBlockHash = receipt.BlockHash
+	anchor.Status = EVMAnchorConfirmed
+	if receipt.Status == "0x0" {
+		anchor.Status = EVMAnchorFailed
+	}
+	return anchor, true, nil
+}
+
+// latestDomainBlock returns the domain's newest committed block.
+func (node *QuidnugNode) latestDomainBlock(domain string) (Block, bool) {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	for i := len(node.Blockchain) - 1; i >= 0; i-- {
+		if node.Blockchain[i].TrustProof.TrustDomain == domain {
+			return node.Blockchain[i], true
+		}
+	}
+	return Block{}, false
+}
+
+// evmAnchorSnapshot returns the domain's latest block with the
+// state root after it. The root is computed without holding the
+// chain lock, so a block committing meanwhile makes it retry.
+func (node *QuidnugNode) evmAnchorSnapshot(domain string) (Block, string, error) {
+	for attempt := 0; attempt < evmAnchorSnapshotAttempts; attempt++ {
+		block, ok := node.latestDomainBlock(domain)
+		if !ok {
+			return Block{}, "", fmt.Errorf("no blocks in domain %s", domain)
+		}
+		root, err := node.domainStateRoot(domain)
+		if err != nil {
+			return Block{}, "", err
+		}
+		if after, _ := node.latestDomainBlock(domain); after.Hash == block.Hash {
+			return block, root, nil
+		}
+	}
+	return Block{}, "", fmt.Errorf("domain %s kept moving while its state root was computed", domain)
+}
+
+// anchorToEVM runs one pass: confirm pending anchors, then anchor
+// each domain that has moved since its last anchor.
+func (node *QuidnugNode) anchorToEVM(ctx context.Context) {
+	a := node.EVMAnchors
+	changed := false
+	for _, anchor := range a.pending() {
+		updated, done, err := a.confirm(ctx, anchor)
+		if err != nil {
+			logger.Warn("EVM anchor receipt lookup failed", "txHash", anchor.TxHash, "error", err)
+			continue
+		}
+		if done {
+			a.update(updated)
+			changed = true
+			logger.Info("EVM anchor mined", "domain", updated.TrustDomain, "height", updated.Height,
+				"status", updated.Status, "evmBlock", updated.EVMBlockNumber)
+		}
+	}
+
+	for _, domain := range a.domains {
+		block, root, err := node.evmAnchorSnapshot(domain)
+		if err != nil {
+			logger.Debug("Skipping EVM anchor", "domain", domain, "error", err)
+			continue
+		}
+		if last, ok := a.last(domain); ok && last.Height >= block.Index {
+			continue
+		}
+		anchor, err := a.publish(ctx, domain, block.Index, block.Hash, root)
+		if err != nil {
+			logger.Warn("EVM anchor failed", "domain", domain, "height", block.Index, "error", err)
+			continue
+		}
+		a.add(anchor)
+		changed = true
+		logger.Info("EVM anchor sent", "domain", domain, "height", block.Index, "txHash", anchor.TxHash)
+	}
+
+	if changed {
+		if err := a.save(); err != nil {
+			logger.Warn("Failed to save EVM anchors", "error", err)
+		}
+	}
+}
+
+// runEVMAnchorLoop anchors every interval until ctx is done.
+// No-op when anchoring is not configured.
+func (node *QuidnugNode) runEVMAnchorLoop(ctx context.Context, interval time.Duration) {
+	if node.EVMAnchors == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = config.DefaultEVMAnchorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			node.anchorToEVM(ctx)
+		}
+	}
+}
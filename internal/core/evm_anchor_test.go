@@ -0,0 +1,180 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// fakeEVMRPC answers the JSON-RPC calls the anchorer makes and
+// records the calldata it was sent.
+type fakeEVMRPC struct {
+	mu    sync.Mutex
+	sent  []string
+	mined bool
+}
+
+func (f *fakeEVMRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result interface{}
+	switch req.Method {
+	case "eth_chainId":
+		result = "0xaa36a7"
+	case "eth_sendTransaction":
+		var tx map[string]string
+		_ = json.Unmarshal(req.Params[0], &tx)
+		f.sent = append(f.sent, tx["data"])
+		result = "0xfeed"
+	case "eth_getTransactionReceipt":
+		if f.mined {
+			result = map[string]string{"status": "0x1", "blockNumber": "0x10", "blockHash": "0xbeef"}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+}
+
+func TestKeccak256(t *testing.T) {
+	got := hex.EncodeToString(keccak256(nil))
+	if got != "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470" {
+		t.Fatalf("keccak256(\"\") = %s", got)
+	}
+}
+
+func TestEVMAnchorCalldata(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	data, err := evmAnchorCalldata("a.local", 258, hash, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4+4*32 {
+		t.Fatalf("calldata length = %d", len(data))
+	}
+	if string(data[4:36]) != string(keccak256([]byte("a.local"))) {
+		t.Error("domain word is not keccak256 of the name")
+	}
+	if data[66] != 1 || data[67] != 2 {
+		t.Errorf("height word = %x", data[36:68])
+	}
+	if hex.EncodeToString(data[68:100]) != hash {
+		t.Error("block hash word mismatch")
+	}
+	for _, b := range data[100:] {
+		if b != 0 {
+			t.Fatal("empty state root should encode as the zero word")
+		}
+	}
+	if _, err := evmAnchorCalldata("a.local", 1, "abcd", ""); err == nil {
+		t.Error("short block hash accepted")
+	}
+}
+
+func TestNewEVMAnchorer_Config(t *testing.T) {
+	if a, err := newEVMAnchorer(&config.Config{}); a != nil || err != nil {
+		t.Fatalf("unconfigured anchorer = %v, %v", a, err)
+	}
+	cfg := &config.Config{
+		EVMAnchorRPCURL:   "http://127.0.0.1:8545",
+		EVMAnchorContract: "0x1234",
+		EVMAnchorFrom:     "0x" + strings.Repeat("11", 20),
+		EVMAnchorDomains:  []string{"a.local"},
+	}
+	if _, err := newEVMAnchorer(cfg); err == nil {
+		t.Error("malformed contract address accepted")
+	}
+	cfg.EVMAnchorContract = "0x" + strings.Repeat("22", 20)
+	cfg.EVMAnchorDomains = nil
+	if _, err := newEVMAnchorer(cfg); err == nil {
+		t.Error("anchorer without domains accepted")
+	}
+}
+
+func TestEVMAnchor_PublishConfirmAndProof(t *testing.T) {
+	rpc := &fakeEVMRPC{}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = -1
+	cfg := &config.Config{
+		EVMAnchorRPCURL:   srv.URL,
+		EVMAnchorContract: "0x" + strings.Repeat("22", 20),
+		EVMAnchorFrom:     "0x" + strings.Repeat("11", 20),
+		EVMAnchorDomains:  []string{"anchor.local"},
+		DataDir:           t.TempDir(),
+	}
+	anchorer, err := newEVMAnchorer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.EVMAnchors = anchorer
+
+	block, err := node.SyntheticTrustBlock("anchor.local", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+
+	node.anchorToEVM(t.Context())
+	list := anchorer.List("anchor.local")
+	if len(list) != 1 || list[0].Status != EVMAnchorPending {
+		t.Fatalf("anchors after first pass = %+v", list)
+	}
+	root, _ := node.domainStateRoot("anchor.local")
+	want, _ := evmAnchorCalldata("anchor.local", block.Index, block.Hash, root)
+	if len(rpc.sent) != 1 || rpc.sent[0] != "0x"+hex.EncodeToString(want) {
+		t.Fatalf("sent calldata = %v", rpc.sent)
+	}
+	if list[0].ChainID != 11155111 {
+		t.Errorf("chain id = %d", list[0].ChainID)
+	}
+	if _, ok := anchorer.Proof("anchor.local", 0); ok {
+		t.Error("pending anchor served as a proof")
+	}
+
+	rpc.mu.Lock()
+	rpc.mined = true
+	rpc.mu.Unlock()
+	node.anchorToEVM(t.Context())
+	if len(rpc.sent) != 1 {
+		t.Errorf("unchanged domain anchored again: %d sends", len(rpc.sent))
+	}
+	anchor, ok := anchorer.Proof("anchor.local", block.Index)
+	if !ok || anchor.Status != EVMAnchorConfirmed || anchor.EVMBlockNumber != 16 {
+		t.Fatalf("proof = %+v, %v", anchor, ok)
+	}
+	if _, ok := anchorer.Proof("anchor.local", block.Index+1); ok {
+		t.Error("proof returned for a height above every anchor")
+	}
+
+	reloaded, err := newEVMAnchorer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reloaded.Proof("anchor.local", 0); !ok || got.TxHash != anchor.TxHash {
+		t.Errorf("anchors not persisted: %+v", got)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/domains/anchor.local/evm-anchor?height=1", nil)
+	req = mux.SetURLVars(req, map[string]string{"name": "anchor.local"})
+	rr := httptest.NewRecorder()
+	node.GetEVMAnchorProofHandler(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"txHash":"0xfeed"`) {
+		t.Fatalf("proof handler = %d %s", rr.Code, rr.Body.String())
+	}
+}
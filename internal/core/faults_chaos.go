@@ -0,0 +1,117 @@
+//go:build chaos
+
+package core
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FaultInjectionCompiled reports whether this binary was built with
+// the chaos tag.
+const FaultInjectionCompiled = true
+
+// InjectFaults wraps the node's peer client in a fault transport
+// running cfg, replacing any schedule injected earlier. Partition
+// offsets count from this call. A zero config removes injection.
+//
+// Call it before the node starts talking to peers; the client's
+// transport is swapped without a lock.
+func (node *QuidnugNode) InjectFaults(cfg FaultConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	base := node.httpClient.Transport
+	if ft, ok := base.(*faultTransport); ok {
+		base = ft.base
+	}
+	if cfg.IsZero() {
+		node.httpClient.Transport = base
+		return nil
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	node.httpClient.Transport = &faultTransport{
+		base:  base,
+		cfg:   cfg,
+		start: time.Now(),
+		rng:   rand.New(rand.NewSource(cfg.Seed)), // #nosec G404 -- reproducible faults, not security
+	}
+	logger.Warn("Fault injection enabled on peer transport",
+		"dropRate", cfg.DropRate,
+		"latency", cfg.Latency,
+		"jitter", cfg.Jitter,
+		"partitions", len(cfg.Partitions))
+	return nil
+}
+
+// faultTransport applies a FaultConfig in front of a real transport.
+type faultTransport struct {
+	base  http.RoundTripper
+	cfg   FaultConfig
+	start time.Time
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if t.partitioned(host, time.Since(t.start)) {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%w: partitioned from %s", ErrFaultInjected, host)
+	}
+
+	t.mu.Lock()
+	drop := t.cfg.DropRate > 0 && t.rng.Float64() < t.cfg.DropRate
+	delay := t.cfg.Latency
+	if t.cfg.Jitter > 0 {
+		delay += time.Duration(t.rng.Int63n(int64(t.cfg.Jitter) + 1))
+	}
+	t.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+	if drop {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%w: dropped request to %s", ErrFaultInjected, host)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *faultTransport) partitioned(host string, elapsed time.Duration) bool {
+	for _, p := range t.cfg.Partitions {
+		if elapsed < p.Start || (p.End != 0 && elapsed >= p.End) {
+			continue
+		}
+		if len(p.Peers) == 0 {
+			return true
+		}
+		for _, peer := range p.Peers {
+			if peer == host {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closeRequestBody honors the RoundTripper contract of closing the
+// body even when the request is never sent.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
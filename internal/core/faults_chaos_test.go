@@ -0,0 +1,116 @@
+//go:build chaos
+
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func faultTestPeers(t *testing.T) (node *QuidnugNode, a, b *httptest.Server) {
+	t.Helper()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	a, b = httptest.NewServer(ok), httptest.NewServer(ok)
+	t.Cleanup(a.Close)
+	t.Cleanup(b.Close)
+	node = newTestNode()
+	// httptest servers listen on loopback, which safedial refuses.
+	node.httpClient.Transport = http.DefaultTransport
+	return node, a, b
+}
+
+func faultGet(node *QuidnugNode, url string) error {
+	resp, err := node.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestInjectFaults_DropAll(t *testing.T) {
+	node, a, _ := faultTestPeers(t)
+	if err := node.InjectFaults(FaultConfig{DropRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := faultGet(node, a.URL); !errors.Is(err, ErrFaultInjected) {
+		t.Fatalf("got %v, want ErrFaultInjected", err)
+	}
+
+	// A zero config restores the real transport.
+	if err := node.InjectFaults(FaultConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := faultGet(node, a.URL); err != nil {
+		t.Fatalf("after clearing faults: %v", err)
+	}
+}
+
+func TestInjectFaults_DropRateIsSeeded(t *testing.T) {
+	outcomes := func() string {
+		node, a, _ := faultTestPeers(t)
+		if err := node.InjectFaults(FaultConfig{DropRate: 0.5, Seed: 42}); err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		for i := 0; i < 32; i++ {
+			if faultGet(node, a.URL) != nil {
+				sb.WriteByte('x')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		return sb.String()
+	}
+	first, second := outcomes(), outcomes()
+	if first != second {
+		t.Fatalf("same seed, different drops:\n%s\n%s", first, second)
+	}
+	if !strings.Contains(first, "x") || !strings.Contains(first, ".") {
+		t.Fatalf("drop rate 0.5 dropped all or nothing: %s", first)
+	}
+}
+
+func TestInjectFaults_PartitionSchedule(t *testing.T) {
+	node, a, b := faultTestPeers(t)
+	cut := strings.TrimPrefix(a.URL, "http://")
+	err := node.InjectFaults(FaultConfig{Partitions: []FaultPartition{
+		{Start: 0, End: 150 * time.Millisecond, Peers: []string{cut}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := faultGet(node, a.URL); !errors.Is(err, ErrFaultInjected) {
+		t.Fatalf("partitioned peer reachable: %v", err)
+	}
+	if err := faultGet(node, b.URL); err != nil {
+		t.Fatalf("unpartitioned peer: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := faultGet(node, a.URL); err != nil {
+		t.Fatalf("partition did not heal: %v", err)
+	}
+}
+
+func TestInjectFaults_LatencyHonorsContext(t *testing.T) {
+	node, a, _ := faultTestPeers(t)
+	if err := node.InjectFaults(FaultConfig{Latency: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	start := time.Now()
+	resp, err := node.httpClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request beat the injected latency")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("cancellation ignored during injected latency")
+	}
+}
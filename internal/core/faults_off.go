@@ -0,0 +1,14 @@
+//go:build !chaos
+
+package core
+
+// FaultInjectionCompiled reports whether this binary was built with
+// the chaos tag.
+const FaultInjectionCompiled = false
+
+// InjectFaults always fails in builds without the chaos tag, so a
+// fault schedule meant for a test network can never take effect on
+// a production binary by accident.
+func (node *QuidnugNode) InjectFaults(FaultConfig) error {
+	return ErrFaultsNotCompiled
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func TestLoadFaultConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faults.yaml")
+	body := "drop_rate: 0.25\nlatency: 20ms\nseed: 3\npartitions:\n  - {start: 1s, end: 5s, peers: [\"10.0.0.2:8080\"]}\n"
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadFaultConfig(path)
+	if err != nil {
+		t.Fatalf("LoadFaultConfig: %v", err)
+	}
+	if c.DropRate != 0.25 || c.Latency != 20*time.Millisecond || len(c.Partitions) != 1 || c.Partitions[0].End != 5*time.Second {
+		t.Fatalf("unexpected config %+v", c)
+	}
+}
+
+func TestFaultConfigValidate(t *testing.T) {
+	bad := []FaultConfig{
+		{DropRate: 1.5},
+		{Latency: -time.Second},
+		{Partitions: []FaultPartition{{Start: 5 * time.Second, End: time.Second}}},
+	}
+	for _, c := range bad {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", c)
+		}
+	}
+	open := FaultConfig{Partitions: []FaultPartition{{Start: time.Second}}}
+	if err := open.Validate(); err != nil {
+		t.Errorf("never-healing partition rejected: %v", err)
+	}
+}
+
+func TestInjectFaults_RequiresChaosBuild(t *testing.T) {
+	if FaultInjectionCompiled {
+		t.Skip("chaos build")
+	}
+	err := newTestNode().InjectFaults(FaultConfig{DropRate: 1})
+	if !errors.Is(err, ErrFaultsNotCompiled) {
+		t.Fatalf("got %v, want ErrFaultsNotCompiled", err)
+	}
+}
+
+func TestNewQuidnugNode_FaultFileRefusedWithoutChaos(t *testing.T) {
+	if FaultInjectionCompiled {
+		t.Skip("chaos build")
+	}
+	path := filepath.Join(t.TempDir(), "faults.yaml")
+	if err := os.WriteFile(path, []byte("drop_rate: 0.1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewQuidnugNode(&config.Config{DataDir: t.TempDir(), FaultInjectionFile: path})
+	if !errors.Is(err, ErrFaultsNotCompiled) {
+		t.Fatalf("got %v, want ErrFaultsNotCompiled", err)
+	}
+}
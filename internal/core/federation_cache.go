@@ -0,0 +1,196 @@
+// Caching and rate limiting for cross-domain queries.
+//
+// A domain query this node cannot answer locally is forwarded to the
+// nodes managing that domain (QueryOtherDomain). Popular lookups used
+// to cost a remote round trip each time, and a remote outage turned
+// every one into an error. Successful answers are now cached per
+// (domain, type, param): within FederationCacheTTL they are served
+// without asking; after that the node asks again, and if every remote
+// node fails, or the domain has used up its per-minute query budget,
+// it falls back to the cached answer for up to FederationCacheMaxStale
+// longer, marked stale.
+//
+// The handler reports where an answer came from in the
+// X-Federation-Cache header (MISS, HIT or STALE) with its Age in
+// seconds; a stale answer also carries Warning: 110.
+package core
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/ratelimit"
+)
+
+// federationCacheMaxEntries bounds the cache; the least recently
+// used answers are evicted first.
+const federationCacheMaxEntries = 4096
+
+// federationCacheHeader names where a forwarded answer came from.
+const federationCacheHeader = "X-Federation-Cache"
+
+// ErrFederationRateLimited is returned when a domain is over its
+// forwarded-query budget and no cached answer can stand in.
+var ErrFederationRateLimited = errors.New("cross-domain query rate exceeded")
+
+// Where a forwarded answer came from.
+const (
+	FederationMiss  = "MISS"
+	FederationHit   = "HIT"
+	FederationStale = "STALE"
+)
+
+// FederatedResult is a forwarded answer and its provenance. Age is
+// how long ago a remote node gave it; 0 for a fresh answer.
+type FederatedResult struct {
+	Data   interface{}
+	Source string
+	Age    time.Duration
+}
+
+type federationKey struct {
+	domain, queryType, param string
+}
+
+type federationEntry struct {
+	key      federationKey
+	data     interface{}
+	storedAt time.Time
+}
+
+// FederationCache holds forwarded answers and the per-domain query
+// budget. A nil *FederationCache caches nothing and limits nothing.
+type FederationCache struct {
+	ttl      time.Duration
+	maxStale time.Duration
+	limiter  *ratelimit.KeyedLimiter // nil = unlimited
+
+	mu      sync.Mutex
+	entries map[federationKey]*list.Element
+	lru     *list.List // front is most recently used; values are *federationEntry
+}
+
+// NewFederationCache returns a cache serving answers for ttl and,
+// when remotes fail or are rate limited, maxStale beyond it.
+// queriesPerMinute caps forwarded queries per domain (0 = no cap).
+// It returns nil when ttl and queriesPerMinute are both 0.
+func NewFederationCache(ttl, maxStale time.Duration, queriesPerMinute int) *FederationCache {
+	if ttl <= 0 && queriesPerMinute <= 0 {
+		return nil
+	}
+	c := &FederationCache{
+		ttl:      ttl,
+		maxStale: maxStale,
+		entries:  make(map[federationKey]*list.Element),
+		lru:      list.New(),
+	}
+	if queriesPerMinute > 0 {
+		c.limiter = ratelimit.NewKeyedLimiter(queriesPerMinute, 0)
+	}
+	return c
+}
+
+// lookup returns the cached answer for key and its age.
+func (c *FederationCache) lookup(key federationKey, now time.Time) (interface{}, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	e := el.Value.(*federationEntry)
+	age := now.Sub(e.storedAt)
+	if age > c.ttl+c.maxStale {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, 0, false
+	}
+	c.lru.MoveToFront(el)
+	return e.data, age, true
+}
+
+func (c *FederationCache) store(key federationKey, data interface{}, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*federationEntry)
+		e.data, e.storedAt = data, now
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&federationEntry{key: key, data: data, storedAt: now})
+	for c.lru.Len() > federationCacheMaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*federationEntry).key)
+	}
+}
+
+// Len returns the number of cached answers.
+func (c *FederationCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// FederatedQuery is QueryOtherDomain with the answer's provenance.
+func (node *QuidnugNode) FederatedQuery(ctx context.Context, domainName, queryType, queryParam string) (FederatedResult, error) {
+	c := node.FederationCache
+	if c == nil {
+		data, err := node.forwardDomainQuery(ctx, domainName, queryType, queryParam)
+		return FederatedResult{Data: data, Source: FederationMiss}, err
+	}
+
+	key := federationKey{domainName, queryType, queryParam}
+	now := time.Now()
+	cached, age, ok := c.lookup(key, now)
+	if ok && age <= c.ttl {
+		return FederatedResult{Data: cached, Source: FederationHit, Age: age}, nil
+	}
+	stale := func(cause error) (FederatedResult, error) {
+		if ok {
+			logger.Debug("Serving stale cross-domain answer",
+				"domain", domainName, "queryType", queryType, "age", age, "cause", cause)
+			return FederatedResult{Data: cached, Source: FederationStale, Age: age}, nil
+		}
+		return FederatedResult{}, cause
+	}
+
+	if !c.limiter.Allow(domainName) {
+		return stale(fmt.Errorf("%w for domain %s", ErrFederationRateLimited, domainName))
+	}
+	data, err := node.forwardDomainQuery(ctx, domainName, queryType, queryParam)
+	if err != nil {
+		if ctx.Err() != nil {
+			return FederatedResult{}, err
+		}
+		return stale(err)
+	}
+	c.store(key, data, time.Now())
+	return FederatedResult{Data: data, Source: FederationMiss}, nil
+}
+
+// writeFederationHeaders annotates a response with where its
+// forwarded answer came from.
+func writeFederationHeaders(w http.ResponseWriter, res FederatedResult) {
+	w.Header().Set(federationCacheHeader, res.Source)
+	if res.Source == FederationMiss {
+		return
+	}
+	w.Header().Set("Age", strconv.FormatInt(int64(res.Age/time.Second), 10))
+	if res.Source == FederationStale {
+		w.Header().Set("Warning", `110 - "Response is Stale"`)
+	}
+}
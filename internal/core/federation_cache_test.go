@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// federationTestRemote serves identity queries for remote.domain.com
+// and counts them.
+func federationTestRemote(t *testing.T, node *QuidnugNode) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"quidId": r.URL.Query().Get("param")})
+	}))
+	node.KnownNodesMutex.Lock()
+	node.KnownNodes["remote_node"] = Node{
+		ID:           "remote_node",
+		Address:      server.Listener.Addr().String(),
+		TrustDomains: []string{"remote.domain.com"},
+	}
+	node.KnownNodesMutex.Unlock()
+	return server, &calls
+}
+
+// backdateFederationEntries ages every cached answer by d.
+func backdateFederationEntries(c *FederationCache, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, el := range c.entries {
+		el.Value.(*federationEntry).storedAt = el.Value.(*federationEntry).storedAt.Add(-d)
+	}
+}
+
+func TestFederatedQuery_CachesAndServesStale(t *testing.T) {
+	node := newTestNode()
+	node.FederationCache = NewFederationCache(time.Minute, time.Hour, 0)
+	server, calls := federationTestRemote(t, node)
+	ctx := context.Background()
+
+	res, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", "q1")
+	if err != nil || res.Source != FederationMiss {
+		t.Fatalf("first query: %+v, %v", res, err)
+	}
+	res, err = node.FederatedQuery(ctx, "remote.domain.com", "identity", "q1")
+	if err != nil || res.Source != FederationHit || calls.Load() != 1 {
+		t.Fatalf("second query should be a cache hit: %+v, %v (calls=%d)", res, err, calls.Load())
+	}
+	if _, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", "q2"); err != nil || calls.Load() != 2 {
+		t.Fatalf("a different param should miss: %v (calls=%d)", err, calls.Load())
+	}
+
+	// Past the TTL the node asks again; with the remote gone it falls
+	// back to the cached answer.
+	backdateFederationEntries(node.FederationCache, 2*time.Minute)
+	server.Close()
+	res, err = node.FederatedQuery(ctx, "remote.domain.com", "identity", "q1")
+	if err != nil || res.Source != FederationStale || res.Age < 2*time.Minute {
+		t.Fatalf("expected a stale answer, got %+v, %v", res, err)
+	}
+	if m, _ := res.Data.(map[string]interface{}); m["quidId"] != "q1" {
+		t.Errorf("stale answer has the wrong data: %v", res.Data)
+	}
+
+	// Past TTL+maxStale the entry is gone and the failure surfaces.
+	backdateFederationEntries(node.FederationCache, 2*time.Hour)
+	if _, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", "q1"); err == nil {
+		t.Error("expected an error once the entry is too stale")
+	}
+}
+
+func TestFederatedQuery_RateLimit(t *testing.T) {
+	node := newTestNode()
+	node.FederationCache = NewFederationCache(time.Minute, time.Hour, 2)
+	server, calls := federationTestRemote(t, node)
+	defer server.Close()
+	ctx := context.Background()
+
+	for _, p := range []string{"a", "b"} {
+		if _, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", "c"); !errors.Is(err, ErrFederationRateLimited) {
+		t.Fatalf("got %v, want ErrFederationRateLimited", err)
+	}
+	// Over budget, an expired answer still stands in.
+	backdateFederationEntries(node.FederationCache, 2*time.Minute)
+	res, err := node.FederatedQuery(ctx, "remote.domain.com", "identity", "a")
+	if err != nil || res.Source != FederationStale {
+		t.Fatalf("expected a stale answer under the rate limit, got %+v, %v", res, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 remote calls, got %d", calls.Load())
+	}
+}
+
+func TestQueryDomainHandler_FederationHeaders(t *testing.T) {
+	node := newTestNode()
+	node.FederationCache = NewFederationCache(time.Minute, time.Hour, 1)
+	server, _ := federationTestRemote(t, node)
+	defer server.Close()
+	router := setupTestRouter(node)
+
+	get := func(param string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/domains/remote.domain.com/query?type=identity&param="+param, nil))
+		return w
+	}
+	if w := get("q1"); w.Code != http.StatusOK || w.Header().Get(federationCacheHeader) != FederationMiss {
+		t.Fatalf("first: %d %q", w.Code, w.Header().Get(federationCacheHeader))
+	}
+	if w := get("q1"); w.Header().Get(federationCacheHeader) != FederationHit || w.Header().Get("Age") == "" {
+		t.Errorf("second: %q age=%q", w.Header().Get(federationCacheHeader), w.Header().Get("Age"))
+	}
+	if w := get("q2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("over budget: expected 429, got %d", w.Code)
+	}
+	backdateFederationEntries(node.FederationCache, 2*time.Minute)
+	w := get("q1")
+	if w.Code != http.StatusOK || w.Header().Get(federationCacheHeader) != FederationStale || w.Header().Get("Warning") == "" {
+		t.Errorf("stale: %d %q warning=%q", w.Code, w.Header().Get(federationCacheHeader), w.Header().Get("Warning"))
+	}
+}
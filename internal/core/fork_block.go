@@ -57,11 +57,12 @@ const (
 // adding their names here explicitly — an operator can't
 // invent arbitrary flag names.
 var ForkSupportedFeatures = map[string]bool{
-	"enable_nonce_ledger":     true,
-	"enable_push_gossip":      true,
-	"enable_lazy_epoch_probe": true,
-	"enable_kofk_bootstrap":   true,
-	"require_tx_tree_root":    true, // future H2
+	"enable_nonce_ledger":        true,
+	"enable_push_gossip":         true,
+	"enable_lazy_epoch_probe":    true,
+	"enable_kofk_bootstrap":      true,
+	"require_tx_tree_root":       true, // future H2
+	"require_canonical_tx_order": true,
 }
 
 // ----- Wire types ----------------------------------------------------------
@@ -339,7 +340,7 @@ func (node *QuidnugNode) applyForkBlockFromBlock(f ForkBlock, block Block) {
 	}
 	node.BlockchainMutex.RUnlock()
 
-	if err := node.ValidateForkBlock(f, currentHeight, time.Now()); err != nil {
+	if err := node.ValidateForkBlock(f, currentHeight, nowTime()); err != nil {
 		logger.Warn("Rejected fork-block in Trusted block",
 			"blockIndex", block.Index,
 			"feature", f.Feature,
@@ -389,6 +390,11 @@ func (node *QuidnugNode) maybeActivateForks(domain string, height int64) {
 // features added here must also be added to
 // ForkSupportedFeatures.
 func (node *QuidnugNode) activateFeature(feature string) {
+	// Protocol versions are per domain and read straight from the
+	// active forks; see domainProtocolVersion.
+	if _, ok := protocolFeatureVersion(feature); ok {
+		return
+	}
 	switch feature {
 	case "enable_nonce_ledger":
 		node.NonceLedgerEnforce = true
@@ -407,6 +413,10 @@ func (node *QuidnugNode) activateFeature(feature string) {
 		// MUST carry a non-empty TransactionsRoot. ValidateBlock
 		// consults this flag and rejects on empty root.
 		node.RequireTxTreeRoot = true
+	case "require_canonical_tx_order":
+		// From this activation, incoming blocks must list their
+		// transactions in canonical order (tx_order.go).
+		node.RequireCanonicalTxOrder = true
 	default:
 		logger.Warn("activateFeature: unknown feature", "feature", feature)
 	}
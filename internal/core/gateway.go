@@ -0,0 +1,378 @@
+// API gateway mode.
+//
+// A node started with node_role: gateway holds no chain and runs
+// no consensus. It mounts only /health, /info and a fixed set of
+// read queries, and answers those by proxying to backend
+// validators, so it can sit in a DMZ without exposing block
+// production, peer gossip, writes or admin endpoints.
+//
+// Every proxied query is scoped to the configured gateway_domains:
+// the domain comes from the path or ?domain= where the query takes
+// one, and from the response's trustDomain for identity and title
+// lookups. Out-of-scope requests get 403 and out-of-scope records
+// 404.
+//
+// Backends prove their answers. The gateway asks for a signed
+// response (X-Quidnug-Sign-Response); any node signs the request
+// URI, status and body with its node key and returns the key and
+// quid alongside. The gateway checks the signature, that the quid
+// derives from the key and, when gateway_backend_quids is set,
+// that the quid is pinned. A response that fails any check is
+// treated like an unreachable backend: the gateway fails over to
+// the next one. Verified 200 responses are cached for
+// gateway_cache_ttl.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// Response-signing headers.
+const (
+	HeaderSignResponse      = "X-Quidnug-Sign-Response"
+	HeaderResponseNodeQuid  = "X-Quidnug-Node-Quid"
+	HeaderResponsePublicKey = "X-Quidnug-Node-Public-Key"
+	HeaderResponseSignature = "X-Quidnug-Response-Signature"
+)
+
+// gatewayCacheMaxEntries bounds the gateway response cache.
+const gatewayCacheMaxEntries = 10000
+
+// ErrUnverifiedResponse means a backend response was unsigned,
+// badly signed, or signed by a quid that is not pinned.
+var ErrUnverifiedResponse = errors.New("gateway: backend response failed verification")
+
+// responseSignable is what a signed response commits to.
+func responseSignable(requestURI string, status int, body []byte) []byte {
+	head := requestURI + "\n" + strconv.Itoa(status) + "\n"
+	return append([]byte(head), body...)
+}
+
+// bufferedResponse holds a handler's response so it can be signed
+// before anything is sent.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// ResponseSigningMiddleware signs GET responses with the node key
+// when the request carries X-Quidnug-Sign-Response. Other requests
+// pass through untouched.
+func (node *QuidnugNode) ResponseSigningMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderSignResponse) == "" || r.Method != http.MethodGet || node.PrivateKey == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buf := &bufferedResponse{header: w.Header()}
+		next.ServeHTTP(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		sig, err := node.SignData(responseSignable(r.URL.RequestURI(), buf.status, buf.body.Bytes()))
+		if err == nil {
+			w.Header().Set(HeaderResponseNodeQuid, node.NodeID)
+			w.Header().Set(HeaderResponsePublicKey, node.GetPublicKeyHex())
+			w.Header().Set(HeaderResponseSignature, hex.EncodeToString(sig))
+		}
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(buf.body.Bytes())
+	})
+}
+
+// verifySignedResponse checks a backend response against the
+// request URI it answers. pins, when non-empty, restricts which
+// node quids may sign.
+func verifySignedResponse(h http.Header, requestURI string, status int, body []byte, pins map[string]struct{}) (string, error) {
+	quid := h.Get(HeaderResponseNodeQuid)
+	pub := h.Get(HeaderResponsePublicKey)
+	sig := h.Get(HeaderResponseSignature)
+	if quid == "" || pub == "" || sig == "" {
+		return "", fmt.Errorf("%w: unsigned", ErrUnverifiedResponse)
+	}
+	if QuidIDFromPublicKeyHex(pub) != quid {
+		return "", fmt.Errorf("%w: quid %s does not match its key", ErrUnverifiedResponse, quid)
+	}
+	if len(pins) > 0 {
+		if _, ok := pins[quid]; !ok {
+			return "", fmt.Errorf("%w: quid %s is not pinned", ErrUnverifiedResponse, quid)
+		}
+	}
+	if !VerifySignature(pub, responseSignable(requestURI, status, body), sig) {
+		return "", fmt.Errorf("%w: bad signature from %s", ErrUnverifiedResponse, quid)
+	}
+	return quid, nil
+}
+
+// gatewayResponse is a verified backend response.
+type gatewayResponse struct {
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// Gateway is the proxy state of a gateway node. Safe for
+// concurrent use.
+type Gateway struct {
+	domains  []string
+	backends *ReplicaUpstreams
+	pins     map[string]struct{}
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]gatewayResponse
+}
+
+// newGateway returns the gateway for a gateway-role config and nil
+// for any other role.
+func newGateway(cfg *config.Config) (*Gateway, error) {
+	if cfg.NodeRole != NodeRoleGateway {
+		return nil, nil
+	}
+	if len(cfg.GatewayDomains) == 0 {
+		return nil, errors.New("gateway: gateway_domains is required")
+	}
+	if len(cfg.GatewayBackends) == 0 {
+		return nil, errors.New("gateway: gateway_backends is required")
+	}
+	for _, addr := range cfg.GatewayBackends {
+		if host, _, err := net.SplitHostPort(addr); err != nil || host == "" {
+			return nil, fmt.Errorf("gateway backend %q: want host:port", addr)
+		}
+	}
+	pins := make(map[string]struct{}, len(cfg.GatewayBackendQuids))
+	for _, q := range cfg.GatewayBackendQuids {
+		if !IsValidQuidID(q) {
+			return nil, fmt.Errorf("gateway: invalid backend quid %q", q)
+		}
+		pins[q] = struct{}{}
+	}
+	return &Gateway{
+		domains:  append([]string(nil), cfg.GatewayDomains...),
+		backends: NewReplicaUpstreams(cfg.GatewayBackends),
+		pins:     pins,
+		ttl:      cfg.GatewayCacheTTL,
+		cache:    make(map[string]gatewayResponse),
+	}, nil
+}
+
+// Domains returns the domain patterns the gateway serves.
+func (g *Gateway) Domains() []string {
+	return append([]string(nil), g.domains...)
+}
+
+// allows reports whether domain is in the gateway's scope.
+func (g *Gateway) allows(domain string) bool {
+	for _, pattern := range g.domains {
+		if MatchDomainPattern(domain, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Gateway) cached(key string) (gatewayResponse, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	resp, ok := g.cache[key]
+	if !ok {
+		return gatewayResponse{}, false
+	}
+	if time.Now().After(resp.expires) {
+		delete(g.cache, key)
+		return gatewayResponse{}, false
+	}
+	return resp, true
+}
+
+// store caches resp. When the cache is full, expired entries are
+// dropped first; if it is still full the response is not cached.
+func (g *Gateway) store(key string, resp gatewayResponse) {
+	if g.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	resp.expires = now.Add(g.ttl)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.cache) >= gatewayCacheMaxEntries {
+		for k, e := range g.cache {
+			if now.After(e.expires) {
+				delete(g.cache, k)
+			}
+		}
+		if len(g.cache) >= gatewayCacheMaxEntries {
+			return
+		}
+	}
+	g.cache[key] = resp
+}
+
+// gatewayBackendURI maps a gateway request onto the backend's v1
+// API, keeping the query string.
+func gatewayBackendURI(r *http.Request) string {
+	path := r.URL.Path
+	for _, prefix := range []string{"/api/v1", "/api"} {
+		if strings.HasPrefix(path, prefix+"/") {
+			path = path[len(prefix):]
+			break
+		}
+	}
+	uri := "/api/v1" + path
+	if r.URL.RawQuery != "" {
+		uri += "?" + r.URL.RawQuery
+	}
+	return uri
+}
+
+// fetchFromBackend asks one backend for requestURI and verifies
+// the signed response.
+func (node *QuidnugNode) fetchFromBackend(ctx context.Context, addr, requestURI string) (gatewayResponse, error) {
+	safeAddr, err := node.validatePeerAddress(addr)
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+safeAddr.String()+requestURI, nil) // #nosec -- URL built from sanitized address
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	req.Header.Set(HeaderSignResponse, "1")
+	req.Header.Set("Accept", "application/json")
+	resp, err := node.httpClient.Do(req) // #nosec -- URL built from sanitized address; transport enforces safedial
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	if resp.StatusCode >= 500 {
+		return gatewayResponse{}, fmt.Errorf("backend %s: status %d", addr, resp.StatusCode)
+	}
+	if _, err := verifySignedResponse(resp.Header, requestURI, resp.StatusCode, body, node.Gateway.pins); err != nil {
+		return gatewayResponse{}, err
+	}
+	return gatewayResponse{status: resp.StatusCode, contentType: resp.Header.Get("Content-Type"), body: body}, nil
+}
+
+// gatewayFetch returns the verified response for requestURI from
+// the cache or the first backend that produces one, failing over
+// through the backend list.
+func (node *QuidnugNode) gatewayFetch(ctx context.Context, requestURI string) (gatewayResponse, error) {
+	g := node.Gateway
+	if resp, ok := g.cached(requestURI); ok {
+		return resp, nil
+	}
+	var lastErr error
+	for range g.backends.List() {
+		addr := g.backends.Active()
+		resp, err := node.fetchFromBackend(ctx, addr, requestURI)
+		if err == nil {
+			if resp.status == http.StatusOK {
+				g.store(requestURI, resp)
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return gatewayResponse{}, ctx.Err()
+		}
+		lastErr = err
+		next := g.backends.Failover(addr)
+		logger.Warn("Gateway backend failed; failing over", "backend", addr, "next", next, "error", err)
+	}
+	return gatewayResponse{}, lastErr
+}
+
+// responseTrustDomain extracts data.trustDomain from a success
+// envelope.
+func responseTrustDomain(body []byte) string {
+	var env struct {
+		Data struct {
+			TrustDomain string `json:"trustDomain"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return ""
+	}
+	return env.Data.TrustDomain
+}
+
+// gatewayProxy serves a query route through the backends.
+// domainOf names the request's domain; when it is nil the domain
+// is read from the response instead.
+func (node *QuidnugNode) gatewayProxy(domainOf func(*http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if domainOf != nil && !node.Gateway.allows(domainOf(r)) {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "domain is not served by this gateway")
+			return
+		}
+		resp, err := node.gatewayFetch(r.Context(), gatewayBackendURI(r))
+		if err != nil {
+			WriteError(w, http.StatusBadGateway, "BAD_GATEWAY", "no backend returned a verified response")
+			return
+		}
+		if domainOf == nil && resp.status == http.StatusOK && !node.Gateway.allows(responseTrustDomain(resp.body)) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+			return
+		}
+		if resp.contentType != "" {
+			w.Header().Set("Content-Type", resp.contentType)
+		}
+		w.WriteHeader(resp.status)
+		_, _ = w.Write(resp.body)
+	}
+}
+
+// registerGatewayRoutes mounts the gateway's surface: local health
+// and info, and the proxied read queries.
+func (node *QuidnugNode) registerGatewayRoutes(router *mux.Router) {
+	pathDomain := func(r *http.Request) string { return mux.Vars(r)["name"] }
+	queryDomain := func(r *http.Request) string {
+		if d := r.URL.Query().Get("domain"); d != "" {
+			return d
+		}
+		return "default"
+	}
+
+	router.HandleFunc("/health", node.HealthCheckHandler).Methods("GET")
+	router.HandleFunc("/info", node.GetInfoHandler).Methods("GET")
+
+	router.HandleFunc("/domains/{name}/query", node.gatewayProxy(pathDomain)).Methods("GET")
+	router.HandleFunc("/domains/{name}/stats", node.gatewayProxy(pathDomain)).Methods("GET")
+	router.HandleFunc("/trust/{observer}/{target}", node.gatewayProxy(queryDomain)).Methods("GET")
+	router.HandleFunc("/identity/{quidId}", node.gatewayProxy(nil)).Methods("GET")
+	router.HandleFunc("/title/{assetId}", node.gatewayProxy(nil)).Methods("GET")
+}
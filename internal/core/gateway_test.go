@@ -0,0 +1,163 @@
+package core
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// newGatewayBackend serves a test node's v1 API with response
+// signing, counting requests.
+func newGatewayBackend(t *testing.T) (*QuidnugNode, *httptest.Server, *int32) {
+	t.Helper()
+	backend := newTestNode()
+	var calls int32
+	router := backend.ResponseSigningMiddleware(setupTestRouter(backend))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return backend, srv, &calls
+}
+
+func newTestGatewayNode(t *testing.T, domains []string, pins []string, backends ...string) (*QuidnugNode, http.Handler) {
+	t.Helper()
+	gw, err := newGateway(&config.Config{
+		NodeRole:            NodeRoleGateway,
+		GatewayDomains:      domains,
+		GatewayBackends:     backends,
+		GatewayBackendQuids: pins,
+		GatewayCacheTTL:     time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("newGateway: %v", err)
+	}
+	node := newTestNode()
+	node.Role = NodeRoleGateway
+	node.Gateway = gw
+	node.PrivateAddrAllowList.Set(append(currentAllowList(node), backends...))
+	router := mux.NewRouter()
+	node.registerGatewayRoutes(router.PathPrefix("/api/v1").Subrouter())
+	return node, router
+}
+
+func gatewayGet(h http.Handler, path string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestNewGateway(t *testing.T) {
+	if g, err := newGateway(&config.Config{NodeRole: NodeRoleValidator}); g != nil || err != nil {
+		t.Fatalf("validator: got %v, %v", g, err)
+	}
+	if _, err := newGateway(&config.Config{NodeRole: NodeRoleGateway, GatewayBackends: []string{"a:1"}}); err == nil {
+		t.Fatal("expected error without gateway domains")
+	}
+	if _, err := newGateway(&config.Config{NodeRole: NodeRoleGateway, GatewayDomains: []string{"d"}, GatewayBackends: []string{"no-port"}}); err == nil {
+		t.Fatal("expected error for backend without port")
+	}
+}
+
+func TestResponseSigningMiddleware(t *testing.T) {
+	node := newTestNode()
+	handler := node.ResponseSigningMiddleware(setupTestRouter(node))
+
+	if rr := gatewayGet(handler, "/api/v1/identity/0000000000000001"); rr.Header().Get(HeaderResponseSignature) != "" {
+		t.Fatal("response signed without being asked")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/identity/0000000000000001", nil)
+	req.Header.Set(HeaderSignResponse, "1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	quid, err := verifySignedResponse(rr.Header(), "/api/v1/identity/0000000000000001", rr.Code, rr.Body.Bytes(), nil)
+	if err != nil || quid != node.NodeID {
+		t.Fatalf("verify: quid=%q err=%v", quid, err)
+	}
+	if _, err := verifySignedResponse(rr.Header(), "/api/v1/identity/0000000000000002", rr.Code, rr.Body.Bytes(), nil); err == nil {
+		t.Fatal("signature should not verify for a different request")
+	}
+}
+
+func TestGateway_ProxiesScopedQueries(t *testing.T) {
+	_, srv, calls := newGatewayBackend(t)
+	_, gw := newTestGatewayNode(t, []string{"test.domain.com"}, nil, strings.TrimPrefix(srv.URL, "http://"))
+
+	rr := gatewayGet(gw, "/api/v1/identity/0000000000000001")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Test Truster") {
+		t.Fatalf("identity via gateway: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := gatewayGet(gw, "/api/v1/identity/0000000000000001"); rr.Code != http.StatusOK {
+		t.Fatalf("cached identity: %d", rr.Code)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected one backend call with caching, got %d", got)
+	}
+
+	if rr := gatewayGet(gw, "/api/v1/domains/other.domain.com/stats"); rr.Code != http.StatusForbidden {
+		t.Fatalf("out-of-scope domain: got %d", rr.Code)
+	}
+	if rr := gatewayGet(gw, "/api/v1/trust/0000000000000001/0000000000000002"); rr.Code != http.StatusForbidden {
+		t.Fatalf("default domain is out of scope: got %d", rr.Code)
+	}
+	if rr := gatewayGet(gw, "/api/v1/trust/0000000000000001/0000000000000002?domain=test.domain.com"); rr.Code == http.StatusForbidden || rr.Code == http.StatusBadGateway {
+		t.Fatalf("in-scope trust query: got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := gatewayGet(gw, "/api/v1/blocks"); rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("blocks must not be exposed, got %d", rr.Code)
+	}
+}
+
+func TestGateway_HidesOutOfScopeRecords(t *testing.T) {
+	_, srv, _ := newGatewayBackend(t)
+	_, gw := newTestGatewayNode(t, []string{"other.domain.com"}, nil, strings.TrimPrefix(srv.URL, "http://"))
+
+	if rr := gatewayGet(gw, "/api/v1/identity/0000000000000001"); rr.Code != http.StatusNotFound {
+		t.Fatalf("identity in another domain: got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGateway_RejectsUnverifiedBackends(t *testing.T) {
+	unsigned := newTestNode()
+	plain := httptest.NewServer(setupTestRouter(unsigned))
+	defer plain.Close()
+
+	_, gw := newTestGatewayNode(t, []string{"test.domain.com"}, nil, strings.TrimPrefix(plain.URL, "http://"))
+	if rr := gatewayGet(gw, "/api/v1/identity/0000000000000001"); rr.Code != http.StatusBadGateway {
+		t.Fatalf("unsigned backend: got %d", rr.Code)
+	}
+
+	_, srv, _ := newGatewayBackend(t)
+	otherQuid := hex.EncodeToString([]byte("notpinnd"))
+	_, gw = newTestGatewayNode(t, []string{"test.domain.com"}, []string{otherQuid}, strings.TrimPrefix(srv.URL, "http://"))
+	if rr := gatewayGet(gw, "/api/v1/identity/0000000000000001"); rr.Code != http.StatusBadGateway {
+		t.Fatalf("unpinned backend: got %d", rr.Code)
+	}
+}
+
+func TestGateway_FailsOverToVerifiedBackend(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	backend, srv, _ := newGatewayBackend(t)
+	healthy := strings.TrimPrefix(srv.URL, "http://")
+
+	node, gw := newTestGatewayNode(t, []string{"test.domain.com"}, []string{backend.NodeID},
+		strings.TrimPrefix(down.URL, "http://"), healthy)
+	if rr := gatewayGet(gw, "/api/v1/identity/0000000000000001"); rr.Code != http.StatusOK {
+		t.Fatalf("failover: got %d %s", rr.Code, rr.Body.String())
+	}
+	if node.Gateway.backends.Active() != healthy {
+		t.Fatalf("active backend = %q, want %q", node.Gateway.backends.Active(), healthy)
+	}
+}
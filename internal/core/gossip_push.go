@@ -645,6 +645,9 @@ func (node *QuidnugNode) sortedForwardPeers(excludes ...string) []Node {
 		if node.PeerScoreboard != nil && node.PeerScoreboard.IsQuarantined(n.ID) {
 			continue
 		}
+		if node.peerBanned(n.ID) {
+			continue
+		}
 		peers = append(peers, n)
 	}
 	node.KnownNodesMutex.RUnlock()
@@ -686,7 +689,7 @@ func (node *QuidnugNode) postGossipPush(target Node, path string, body []byte, k
 		return
 	}
 	endpoint := "http://" + target.Address + path
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(node.lifetime(), "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		logger.Debug("gossip-push: NewRequest failed", "error", err)
 		return
@@ -478,7 +478,7 @@ func TestBroadcastDomainInfo(t *testing.T) {
 	node.KnownNodes["node2"] = Node{ID: "node2", Address: address}
 	node.KnownNodesMutex.Unlock()
 
-	node.BroadcastDomainInfo()
+	node.BroadcastDomainInfo(context.Background())
 
 	time.Sleep(200 * time.Millisecond)
 
@@ -512,7 +512,7 @@ func TestBroadcastDomainInfo_NoSelfSend(t *testing.T) {
 	node.KnownNodes["other"] = Node{ID: "other", Address: address}
 	node.KnownNodesMutex.Unlock()
 
-	node.BroadcastDomainInfo()
+	node.BroadcastDomainInfo(context.Background())
 
 	time.Sleep(200 * time.Millisecond)
 
@@ -670,7 +670,7 @@ func TestGossipIntegration_MultiNode(t *testing.T) {
 	node3.KnownNodesMutex.Unlock()
 
 	// Node1 broadcasts its domain info
-	node1.BroadcastDomainInfo()
+	node1.BroadcastDomainInfo(context.Background())
 
 	// Wait for gossip to propagate
 	time.Sleep(500 * time.Millisecond)
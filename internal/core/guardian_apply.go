@@ -16,7 +16,7 @@ func (node *QuidnugNode) applyGuardianSetUpdate(u GuardianSetUpdate, block Block
 	if node.NonceLedger == nil {
 		return
 	}
-	if err := ValidateGuardianSetUpdate(node.NonceLedger, u, time.Now()); err != nil {
+	if err := ValidateGuardianSetUpdate(node.NonceLedger, u, nowTime()); err != nil {
 		logger.Warn("Rejected guardian set update in Trusted block",
 			"blockIndex", block.Index, "subject", u.SubjectQuid, "error", err)
 		return
@@ -45,7 +45,7 @@ func (node *QuidnugNode) applyGuardianRecoveryInit(a GuardianRecoveryInit, block
 	if node.NonceLedger == nil {
 		return
 	}
-	if err := ValidateGuardianRecoveryInit(node.NonceLedger, a, time.Now()); err != nil {
+	if err := ValidateGuardianRecoveryInit(node.NonceLedger, a, nowTime()); err != nil {
 		logger.Warn("Rejected guardian recovery init in Trusted block",
 			"blockIndex", block.Index, "subject", a.SubjectQuid, "error", err)
 		return
@@ -93,7 +93,7 @@ func (node *QuidnugNode) applyGuardianRecoveryVeto(v GuardianRecoveryVeto, block
 	if node.NonceLedger == nil {
 		return
 	}
-	if err := ValidateGuardianRecoveryVeto(node.NonceLedger, v, time.Now()); err != nil {
+	if err := ValidateGuardianRecoveryVeto(node.NonceLedger, v, nowTime()); err != nil {
 		logger.Warn("Rejected guardian recovery veto in Trusted block",
 			"blockIndex", block.Index, "subject", v.SubjectQuid, "error", err)
 		return
@@ -125,7 +125,7 @@ func (node *QuidnugNode) applyGuardianRecoveryCommit(c GuardianRecoveryCommit, b
 	if node.NonceLedger == nil {
 		return
 	}
-	if err := ValidateGuardianRecoveryCommit(node.NonceLedger, c, time.Now()); err != nil {
+	if err := ValidateGuardianRecoveryCommit(node.NonceLedger, c, nowTime()); err != nil {
 		logger.Warn("Rejected guardian recovery commit in Trusted block",
 			"blockIndex", block.Index, "subject", c.SubjectQuid, "error", err)
 		return
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+)
+
+// Guardians named at identity creation.
+//
+// The recovery flow itself (a threshold of guardians co-signing a
+// GuardianRecoveryInit that binds a new key, the delay, veto and
+// commit) lives in guardian.go and guardian_validation.go. Until now
+// a quid could only get guardians through a separate
+// GuardianSetUpdate after registering. A first IDENTITY transaction
+// may instead carry the set: the identity's own signature stands in
+// for the set update's primary signature, and every named guardian
+// still consents on-chain, over the identity with Signature and
+// GuardianConsents cleared. Once registered, the set changes only
+// through GuardianSetUpdate, which also needs the current guardians.
+
+// ErrGuardianNotAtCreation rejects guardians on an identity update.
+var ErrGuardianNotAtCreation = errors.New("guardian: an identity names guardians only at creation; replace them with a guardian set update")
+
+// IdentityGuardianSignableBytes returns the bytes each guardian named
+// in tx signs to consent.
+func IdentityGuardianSignableBytes(tx IdentityTransaction) ([]byte, error) {
+	tx.Signature = ""
+	tx.GuardianConsents = nil
+	return txSignableBytes(tx)
+}
+
+// validateIdentityGuardians checks the guardian set an identity
+// transaction declares. isUpdate is true when the quid is already
+// registered.
+func validateIdentityGuardians(l *NonceLedger, tx IdentityTransaction, isUpdate bool) error {
+	if tx.Guardians == nil {
+		if len(tx.GuardianConsents) > 0 {
+			return ErrGuardianEmptySet
+		}
+		return nil
+	}
+	if isUpdate {
+		return ErrGuardianNotAtCreation
+	}
+	if err := validateGuardianSetShape(*tx.Guardians); err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrGuardianSetNotFound
+	}
+	if !l.GuardianSetOf(tx.QuidID).Empty() {
+		return ErrGuardianNotAtCreation
+	}
+	signable, err := IdentityGuardianSignableBytes(tx)
+	if err != nil {
+		return err
+	}
+	return verifyNewGuardianConsents(l, *tx.Guardians, signable, tx.GuardianConsents)
+}
+
+// installIdentityGuardians records the guardian set of a newly
+// registered identity. A set already in the ledger wins.
+func (node *QuidnugNode) installIdentityGuardians(tx IdentityTransaction) {
+	if node.NonceLedger == nil || tx.Guardians == nil {
+		return
+	}
+	if !node.NonceLedger.GuardianSetOf(tx.QuidID).Empty() {
+		return
+	}
+	set := *tx.Guardians
+	set.Guardians = append([]GuardianRef(nil), set.Guardians...)
+	node.NonceLedger.setGuardianSet(tx.QuidID, &set)
+	logger.Info("Installed guardian set from identity registration",
+		"subject", tx.QuidID,
+		"guardians", len(set.Guardians),
+		"threshold", set.Threshold)
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestIdentityGuardians_InstalledAtCreation(t *testing.T) {
+	node := newTestNode()
+	subject, g1, g2 := newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)
+	node.NonceLedger.SetSignerKey(g1.QuidID, 0, g1.PubHex)
+	node.NonceLedger.SetSignerKey(g2.QuidID, 0, g2.PubHex)
+
+	build := func(nonce int64, consenting ...*testNodeActor) IdentityTransaction {
+		tx := IdentityTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          "identity-" + subject.QuidID,
+				Type:        TxTypeIdentity,
+				TrustDomain: "test.domain.com",
+				Timestamp:   nowUnix(),
+				PublicKey:   subject.PubHex,
+			},
+			QuidID:      subject.QuidID,
+			Name:        "subject",
+			Creator:     subject.QuidID,
+			UpdateNonce: nonce,
+			Guardians: &GuardianSet{
+				Guardians:     []GuardianRef{{Quid: g1.QuidID}, {Quid: g2.QuidID}},
+				Threshold:     2,
+				RecoveryDelay: MinRecoveryDelay,
+			},
+		}
+		consentSignable, _ := IdentityGuardianSignableBytes(tx)
+		for _, g := range consenting {
+			tx.GuardianConsents = append(tx.GuardianConsents, GuardianSignature{
+				GuardianQuid: g.QuidID,
+				Signature:    signIEEE1363(g.Priv, consentSignable),
+			})
+		}
+		signable, _ := json.Marshal(tx)
+		tx.Signature = signIEEE1363(subject.Priv, signable)
+		return tx
+	}
+
+	if node.ValidateIdentityTransaction(build(1, g1)) {
+		t.Fatal("identity naming an unconsenting guardian accepted")
+	}
+	tx := build(1, g1, g2)
+	if !node.ValidateIdentityTransaction(tx) {
+		t.Fatal("identity with consenting guardians rejected")
+	}
+	node.updateIdentityRegistry(tx)
+	set := node.NonceLedger.GuardianSetOf(subject.QuidID)
+	if set.Empty() || set.Threshold != 2 {
+		t.Fatalf("guardian set not installed: %+v", set)
+	}
+
+	// Later changes go through GuardianSetUpdate.
+	if node.ValidateIdentityTransaction(build(2, g1, g2)) {
+		t.Error("identity update replacing guardians accepted")
+	}
+	if err := validateIdentityGuardians(node.NonceLedger, build(2, g1, g2), true); !errors.Is(err, ErrGuardianNotAtCreation) {
+		t.Errorf("err = %v, want ErrGuardianNotAtCreation", err)
+	}
+}
@@ -161,7 +161,7 @@ func (node *QuidnugNode) applyGuardianResignation(r GuardianResignation, block B
 	if node.NonceLedger == nil {
 		return
 	}
-	if err := ValidateGuardianResignation(node.NonceLedger, r, time.Now()); err != nil {
+	if err := ValidateGuardianResignation(node.NonceLedger, r, nowTime()); err != nil {
 		logger.Warn("Rejected guardian resignation in Trusted block",
 			"blockIndex", block.Index,
 			"guardian", r.GuardianQuid,
@@ -172,7 +172,7 @@ func (node *QuidnugNode) applyGuardianResignation(r GuardianResignation, block B
 	}
 	node.NonceLedger.storeGuardianResignation(r)
 	guardianResignationsTotal.WithLabelValues(r.SubjectQuid).Inc()
-	if node.NonceLedger.GuardianSetIsWeakened(r.SubjectQuid, time.Now()) {
+	if node.NonceLedger.GuardianSetIsWeakened(r.SubjectQuid, nowTime()) {
 		guardianSetWeakened.WithLabelValues(r.SubjectQuid).Inc()
 	}
 
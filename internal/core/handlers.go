@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"strconv"
@@ -36,6 +37,7 @@ func (node *QuidnugNode) registerAPIRoutes(router *mux.Router) {
 	router.HandleFunc("/nodes", node.GetNodesHandler).Methods("GET")
 	// Phase 4e — peer-quality scoring surface.
 	router.HandleFunc("/peers", node.GetPeersHandler).Methods("GET")
+	router.HandleFunc("/peers/circuits", node.GetPeerCircuitsHandler).Methods("GET")
 	router.HandleFunc("/peers/{nodeQuid}", node.GetPeerByQuidHandler).Methods("GET")
 
 	// Transaction endpoints
@@ -43,20 +45,54 @@ func (node *QuidnugNode) registerAPIRoutes(router *mux.Router) {
 	router.HandleFunc("/transactions/trust", node.CreateTrustTransactionHandler).Methods("POST")
 	router.HandleFunc("/transactions/identity", node.CreateIdentityTransactionHandler).Methods("POST")
 	router.HandleFunc("/transactions/title", node.CreateTitleTransactionHandler).Methods("POST")
+	router.HandleFunc("/transactions/atomic-group", node.CreateAtomicGroupHandler).Methods("POST")
 
 	// Blockchain endpoints
 	router.HandleFunc("/blocks", node.GetBlocksHandler).Methods("GET")
+	router.HandleFunc("/archive/blocks/{domain}/{hash}", node.GetArchivedBlockHandler).Methods("GET")
+	router.HandleFunc("/archive/snapshots", node.ListRegistrySnapshotsHandler).Methods("GET")
+	router.HandleFunc("/archive/snapshots/{at}", node.GetRegistrySnapshotHandler).Methods("GET")
+	router.HandleFunc("/blocks/stream", node.StreamBlocksHandler).Methods("GET")
+	router.HandleFunc("/stream", node.StreamChainEventsHandler).Methods("GET")
+	router.HandleFunc("/watches", node.CreateWatchHandler).Methods("POST")
+	router.HandleFunc("/watches/{id}", node.GetWatchHandler).Methods("GET")
+	router.HandleFunc("/watches/{id}", node.DeleteWatchHandler).Methods("DELETE")
+	router.HandleFunc("/watches/{id}/renew", node.RenewWatchHandler).Methods("POST")
+	router.HandleFunc("/watches/{id}/stream", node.StreamWatchHandler).Methods("GET")
 
 	// Trust domain endpoints
 	router.HandleFunc("/domains", node.GetDomainsHandler).Methods("GET")
 	router.HandleFunc("/domains", node.RegisterDomainHandler).Methods("POST")
 	router.HandleFunc("/domains/top", node.GetTopDomainsHandler).Methods("GET")
 	router.HandleFunc("/domains/{name}/query", node.QueryDomainHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/stats", node.GetDomainStatsHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/join", node.RequestDomainJoinHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/join-requests", node.ListDomainJoinRequestsHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/join-requests", node.SubmitDomainJoinRequestHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/join-requests/{id}/approve", node.ApproveDomainJoinHandler).Methods("POST")
+	router.HandleFunc("/transactions/domain-join", node.CreateDomainJoinTransactionHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/checkpoints", node.ListCheckpointsHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/checkpoints/latest", node.GetLatestCheckpointHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/checkpoint-signatures", node.SubmitCheckpointSignaturesHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/control", node.GetDomainControlHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/control-challenges", node.CreateDomainControlChallengeHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/control-challenges/{token}/validate", node.ValidateDomainControlChallengeHandler).Methods("POST")
+	router.HandleFunc("/transactions/domain-control", node.CreateDomainControlTransactionHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/evm-anchors", node.ListEVMAnchorsHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/evm-anchor", node.GetEVMAnchorProofHandler).Methods("GET")
+	router.HandleFunc("/transactions/misbehavior", node.CreateMisbehaviorReportHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/misbehavior", node.GetMisbehaviorReportsHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/asset-classes", node.ListAssetClassesHandler).Methods("GET")
+	router.HandleFunc("/domains/{name}/asset-classes", node.RegisterAssetClassHandler).Methods("POST")
+	router.HandleFunc("/domains/{name}/asset-classes/{class}", node.GetAssetClassHandler).Methods("GET")
+	router.HandleFunc("/anomalies", node.GetAnomaliesHandler).Methods("GET")
+	router.HandleFunc("/anomalies/suspect-edges", node.GetSuspectEdgesHandler).Methods("GET")
 
 	// Registry query endpoints
 	router.HandleFunc("/registry/trust", node.QueryTrustRegistryHandler).Methods("GET")
 	router.HandleFunc("/registry/identity", node.QueryIdentityRegistryHandler).Methods("GET")
 	router.HandleFunc("/registry/title", node.QueryTitleRegistryHandler).Methods("GET")
+	router.HandleFunc("/owners/{quidId}/assets", node.GetOwnedAssetsHandler).Methods("GET")
 
 	// Event streaming endpoints
 	router.HandleFunc("/events", node.CreateEventTransactionHandler).Methods("POST")
@@ -64,10 +100,24 @@ func (node *QuidnugNode) registerAPIRoutes(router *mux.Router) {
 	router.HandleFunc("/streams/{subjectId}", node.GetEventStreamHandler).Methods("GET")
 	router.HandleFunc("/streams/{subjectId}/events", node.GetStreamEventsHandler).Methods("GET")
 
+	// Document notarization (notarization.go)
+	router.HandleFunc("/notarize", node.NotarizeHandler).Methods("POST")
+	router.HandleFunc("/notarize", node.ListNotarizationsHandler).Methods("GET")
+	router.HandleFunc("/notarize/verify", node.VerifyNotarizationHandler).Methods("POST")
+	router.HandleFunc("/notarize/{id}", node.GetNotarizationReceiptHandler).Methods("GET")
+
 	// QDP-0015 content moderation.
 	router.HandleFunc("/moderation/actions", node.CreateModerationActionHandler).Methods("POST")
 	router.HandleFunc("/moderation/actions/{targetType}/{targetId}", node.GetModerationActionsHandler).Methods("GET")
 
+	// Human-readable names
+	router.HandleFunc("/transactions/name", node.CreateNameRegistrationHandler).Methods("POST")
+	router.HandleFunc("/names", node.CreateNameRegistrationHandler).Methods("POST")
+	router.HandleFunc("/names/{name}", node.ResolveNameHandler).Methods("GET")
+	router.HandleFunc("/quids/{quidId}/names", node.GetQuidNamesHandler).Methods("GET")
+	router.HandleFunc("/quids/{quidId}/challenge", node.IssueQuidChallengeHandler).Methods("POST")
+	router.HandleFunc("/quids/{quidId}/verify", node.VerifyQuidChallengeHandler).Methods("POST")
+
 	// QDP-0018 operator audit log.
 	router.HandleFunc("/audit/head", node.AuditHeadHandler).Methods("GET")
 	router.HandleFunc("/audit/entries", node.AuditEntriesHandler).Methods("GET")
@@ -87,22 +137,71 @@ func (node *QuidnugNode) registerAPIRoutes(router *mux.Router) {
 	router.HandleFunc("/ipfs/pin", node.PinToIPFSHandler).Methods("POST")
 	router.HandleFunc("/ipfs/{cid}", node.GetFromIPFSHandler).Methods("GET")
 
+	// Content-addressed attachments
+	router.HandleFunc("/blobs", node.UploadBlobHandler).Methods("POST")
+	router.HandleFunc("/blobs/{digest}", node.GetBlobHandler).Methods("GET")
+	router.HandleFunc("/blobs/{digest}/meta", node.GetBlobMetaHandler).Methods("GET")
+
 	// Node domain advertisement endpoints
 	router.HandleFunc("/node/domains", node.GetNodeDomainsHandler).Methods("GET")
 	router.HandleFunc("/node/domains", node.UpdateNodeDomainsHandler).Methods("POST")
 
 	// Gossip endpoints
 	router.HandleFunc("/gossip/domains", node.ReceiveDomainGossipHandler).Methods("POST")
+	router.HandleFunc("/mempool/{domain}/ids", node.GetMempoolTxIDsHandler).Methods("GET")
+	router.HandleFunc("/mempool/{domain}/transactions", node.FetchMempoolTxsHandler).Methods("POST")
+	router.HandleFunc("/inv", node.ReceiveInventoryHandler).Methods("POST")
+	router.HandleFunc("/getdata", node.GetDataHandler).Methods("POST")
 
 	// API spec endpoints
 	router.HandleFunc("/info", node.GetInfoHandler).Methods("GET")
 	router.HandleFunc("/quids", node.CreateQuidHandler).Methods("POST")
+	router.HandleFunc("/wallet/quids/{quidId}/sign", node.WalletSignHandler).Methods("POST")
+	router.HandleFunc("/wallet/quids/{quidId}", node.WalletRemoveHandler).Methods("DELETE")
 	router.HandleFunc("/trust/query", node.RelationalTrustQueryHandler).Methods("POST")
+	router.HandleFunc("/trust/query/batch", node.RelationalTrustBatchHandler).Methods("POST")
+	router.HandleFunc("/trust/anchors", node.GetTrustAnchorsHandler).Methods("GET")
+	router.HandleFunc("/trust/anchored", node.AnchoredTrustHandler).Methods("POST")
 	router.HandleFunc("/trust/edges/{quidId}", node.GetTrustEdgesHandler).Methods("GET")
+	router.HandleFunc("/trust/edges/{truster}/{trustee}/evidence", node.GetTrustEvidenceHandler).Methods("GET")
+	router.HandleFunc("/trust/graph/export", node.ExportTrustGraphHandler).Methods("GET")
+	router.HandleFunc("/trust/{observer}/top", node.GetTopTrustedHandler).Methods("GET")
 	router.HandleFunc("/trust/{observer}/{target}", node.GetTrustHandler).Methods("GET")
 	router.HandleFunc("/identity/{quidId}", node.GetIdentityHandler).Methods("GET")
+	router.HandleFunc("/identity/{quidId}/sybil-score", node.GetSybilScoreHandler).Methods("GET")
+	router.HandleFunc("/identity/{quidId}/succession", node.GetSuccessionHandler).Methods("GET")
+	router.HandleFunc("/transactions/succession", node.CreateSuccessionTransactionHandler).Methods("POST")
 	router.HandleFunc("/title/{assetId}", node.GetTitleHandler).Methods("GET")
+	router.HandleFunc("/title/{assetId}/liens", node.GetTitleLiensHandler).Methods("GET")
+	router.HandleFunc("/title/{assetId}/lineage", node.GetTitleLineageHandler).Methods("GET")
+	router.HandleFunc("/title/{assetId}/disputes", node.GetTitleDisputesHandler).Methods("GET")
+	router.HandleFunc("/titles/expired", node.ListTitleExpiriesHandler).Methods("GET")
+	router.HandleFunc("/transactions/lien", node.CreateLienTransactionHandler).Methods("POST")
+	router.HandleFunc("/transactions/title-restructure", node.CreateTitleRestructureHandler).Methods("POST")
+	router.HandleFunc("/transactions/title-dispute", node.CreateTitleDisputeHandler).Methods("POST")
+	router.HandleFunc("/title/{assetId}/pending-transfer", node.GetPendingTransferHandler).Methods("GET")
+	router.HandleFunc("/transfers/{txId}", node.GetConditionalTransferHandler).Methods("GET")
+	router.HandleFunc("/transactions/transfer-approval", node.CreateTransferApprovalHandler).Methods("POST")
 	router.HandleFunc("/blocks/tentative/{domain}", node.GetTentativeBlocksHandler).Methods("GET")
+	router.HandleFunc("/blocks/quarantine", node.ListQuarantinedBlocksHandler).Methods("GET")
+	router.HandleFunc("/blocks/quarantine/{hash}", node.GetQuarantinedBlockHandler).Methods("GET")
+	router.HandleFunc("/blocks/quarantine/{hash}/{action}", node.ReviewQuarantinedBlockHandler).Methods("POST")
+	router.HandleFunc("/blocks/{hash}/timestamp", node.GetBlockTimestampHandler).Methods("GET")
+	router.HandleFunc("/filters/registry/{registry}", node.GetRegistryFilterHandler).Methods("GET")
+	router.HandleFunc("/filters/block/{index}", node.GetBlockFilterHandler).Methods("GET")
+	router.HandleFunc("/identity-conflicts", node.ListIdentityConflictsHandler).Methods("GET")
+	router.HandleFunc("/identity-conflicts/{id}/ack", node.AcknowledgeIdentityConflictHandler).Methods("POST")
+
+	// Application-defined GENERIC transaction types.
+	router.HandleFunc("/custom-types", node.ListCustomTxTypesHandler).Methods("GET")
+	router.HandleFunc("/custom-types", node.RegisterCustomTxTypeHandler).Methods("POST")
+	router.HandleFunc("/custom-types/{name}", node.GetCustomTxTypeHandler).Methods("GET")
+	router.HandleFunc("/custom-types/{name}/transactions", node.ListCustomTransactionsHandler).Methods("GET")
+	router.HandleFunc("/transactions/custom", node.CreateCustomTransactionHandler).Methods("POST")
+	router.HandleFunc("/transactions/custom/{txId}", node.GetCustomTransactionHandler).Methods("GET")
+
+	// Operator endpoints.
+	node.registerAdminRoutes(router)
 }
 
 // StartServer starts the HTTP server for API endpoints
@@ -125,48 +224,7 @@ const (
 // if both are set, the server listens with TLS. Otherwise it falls back to
 // plaintext HTTP and logs a warning.
 func (node *QuidnugNode) StartServerWithConfig(port string, rateLimitPerMinute int, maxBodySizeBytes int64) error {
-	router := mux.NewRouter()
-
-	// Human-readable landing page and crawler hints at the root.
-	// Registered first; the /api/* subrouters below claim everything
-	// else by path prefix.
-	router.HandleFunc("/", node.RootHandler).Methods("GET")
-	router.HandleFunc("/robots.txt", node.RobotsHandler).Methods("GET")
-
-	// Register versioned routes under /api/v1
-	v1Router := router.PathPrefix("/api/v1").Subrouter()
-	node.registerAPIRoutes(v1Router)
-
-	// Register backward-compatible routes under /api
-	apiRouter := router.PathPrefix("/api").Subrouter()
-	node.registerAPIRoutes(apiRouter)
-
-	// v2 surface: new-protocol endpoints (QDP-0002 guardians +
-	// QDP-0003 cross-domain gossip). Kept under /api/v2 so existing
-	// v1 clients don't accidentally depend on them before the
-	// protocol work is stabilized.
-	v2Router := router.PathPrefix("/api/v2").Subrouter()
-	node.registerGuardianRoutes(v2Router)
-	node.registerCrossDomainRoutes(v2Router)
-	node.RegisterDiscoveryRoutes(v2Router)
-	node.RegisterDNSAttestationRoutes(v2Router)
-
-	// Apply middleware chain (outermost to innermost processing order):
-	//   RateLimit -> BodySizeLimit -> CORS -> NodeAuth -> Metrics -> SecurityHeaders -> RequestID -> Router
-	//
-	// CORS sits outer than NodeAuth so OPTIONS preflights are
-	// answered before the signature check fires (preflights
-	// have no body, so they would always fail NodeAuth). It
-	// sits inner than RateLimit + BodySize so a flood of
-	// preflights still gets bounded.
-	rateLimiter := ratelimit.New(rateLimitPerMinute)
-	handler := RequestIDMiddleware(router)
-	handler = SecurityHeadersMiddleware(handler)
-	handler = MetricsMiddleware(handler)
-	handler = NodeAuthMiddleware(handler)
-	handler = CORSMiddleware(handler)
-	handler = BodySizeLimitMiddleware(maxBodySizeBytes)(handler)
-	handler = RateLimitMiddleware(rateLimiter)(handler)
+	handler := node.NewHTTPHandler(rateLimitPerMinute, maxBodySizeBytes)
 
 	// Create HTTP server and store reference for graceful shutdown. All
 	// timeouts are set explicitly so that slow clients cannot hold a
@@ -192,6 +250,87 @@ func (node *QuidnugNode) StartServerWithConfig(port string, rateLimitPerMinute i
 	return node.Server.ListenAndServe()
 }
 
+// NewHTTPHandler builds the node's full HTTP surface: every route
+// plus the middleware chain. StartServerWithConfig serves it; tools
+// that run nodes in-process (simnet) mount it on their own
+// listeners.
+func (node *QuidnugNode) NewHTTPHandler(rateLimitPerMinute int, maxBodySizeBytes int64) http.Handler {
+	router := mux.NewRouter()
+
+	if node.Gateway != nil {
+		// Gateway mode: only the proxied query surface is mounted;
+		// no root page, writes, gossip, admin or v2 endpoints.
+		node.registerGatewayRoutes(router.PathPrefix("/api/v1").Subrouter())
+		node.registerGatewayRoutes(router.PathPrefix("/api").Subrouter())
+	} else {
+		// Human-readable landing page and crawler hints at the root.
+		// Registered first; the /api/* subrouters below claim everything
+		// else by path prefix.
+		router.HandleFunc("/", node.RootHandler).Methods("GET")
+		router.HandleFunc("/robots.txt", node.RobotsHandler).Methods("GET")
+
+		// Operator dashboard, rendered client-side from /api/v1.
+		router.HandleFunc("/ui", node.DashboardHandler).Methods("GET")
+		router.HandleFunc("/ui/", node.DashboardHandler).Methods("GET")
+		router.HandleFunc("/ui/app.js", node.DashboardScriptHandler).Methods("GET")
+
+		// Register versioned routes under /api/v1
+		v1Router := router.PathPrefix("/api/v1").Subrouter()
+		node.registerAPIRoutes(v1Router)
+
+		// Register backward-compatible routes under /api
+		apiRouter := router.PathPrefix("/api").Subrouter()
+		node.registerAPIRoutes(apiRouter)
+
+		// v2 surface: new-protocol endpoints (QDP-0002 guardians +
+		// QDP-0003 cross-domain gossip). Kept under /api/v2 so existing
+		// v1 clients don't accidentally depend on them before the
+		// protocol work is stabilized.
+		v2Router := router.PathPrefix("/api/v2").Subrouter()
+		node.registerGuardianRoutes(v2Router)
+		node.registerCrossDomainRoutes(v2Router)
+		node.RegisterDiscoveryRoutes(v2Router)
+		node.RegisterDNSAttestationRoutes(v2Router)
+	}
+
+	// Apply middleware chain (outermost to innermost processing order):
+	//   PeerBan -> RateLimit -> BodySizeLimit -> CORS -> NodeAuth -> OIDCAuth -> Metrics -> SecurityHeaders -> RequestID -> Compression -> WireFormat -> PayloadValidation -> ReplicaWriteGuard -> ETag -> ResponseSigning -> Router
+	//
+	// CORS sits outer than NodeAuth so OPTIONS preflights are
+	// answered before the signature check fires (preflights
+	// have no body, so they would always fail NodeAuth). It
+	// sits inner than RateLimit + BodySize so a flood of
+	// preflights still gets bounded. OIDCAuth sits with it for the
+	// same reason.
+	//
+	// ETag sits outer than ResponseSigning so a 304 is answered
+	// before anything is rendered or signed; Compression sits outer
+	// than both so signatures cover the uncompressed body.
+	// WireFormat sits just inside Compression so CBOR bodies are
+	// compressed too, and outside PayloadValidation so validation
+	// and every handler only ever see JSON.
+	//
+	// PeerBan is outermost so banned peers never reach the rate
+	// limiter and the limiter's 429s count against known peers.
+	rateLimiter := ratelimit.New(rateLimitPerMinute)
+	handler := node.ResponseSigningMiddleware(router)
+	handler = node.ETagMiddleware(handler)
+	handler = node.ReplicaWriteGuardMiddleware(handler)
+	handler = PayloadValidationMiddleware(handler)
+	handler = WireFormatMiddleware(handler)
+	handler = CompressionMiddleware(handler)
+	handler = RequestIDMiddleware(handler)
+	handler = SecurityHeadersMiddleware(handler)
+	handler = MetricsMiddleware(handler)
+	handler = node.OIDCAuthMiddleware(handler)
+	handler = NodeAuthMiddleware(handler)
+	handler = NewCORSMiddleware(node.CORSPolicy)(handler)
+	handler = BodySizeLimitMiddleware(maxBodySizeBytes)(handler)
+	handler = RateLimitMiddleware(rateLimiter)(handler)
+	handler = node.PeerBanMiddleware(handler)
+	return handler
+}
+
 // HealthCheckHandler handles health check requests
 func (node *QuidnugNode) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	WriteSuccess(w, map[string]interface{}{
@@ -214,9 +353,13 @@ func (node *QuidnugNode) GetNodesHandler(w http.ResponseWriter, r *http.Request)
 	node.KnownNodesMutex.RUnlock()
 
 	paginatedNodes, total := paginateSlice(nodesList, params)
+	entries := make([]nodeListEntry, len(paginatedNodes))
+	for i, n := range paginatedNodes {
+		entries[i] = nodeListEntry{Node: n, PeerScore: node.nodePeerScore(n.ID)}
+	}
 
 	WriteSuccess(w, map[string]interface{}{
-		"data": paginatedNodes,
+		"data": entries,
 		"pagination": PaginationMeta{
 			Limit:  params.Limit,
 			Offset: params.Offset,
@@ -225,6 +368,50 @@ func (node *QuidnugNode) GetNodesHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// nodeListEntry is a known node as GetNodesHandler lists it: the
+// Node fields plus this node's score for it, when it has one.
+type nodeListEntry struct {
+	Node
+	PeerScore *nodePeerScore `json:"peerScore,omitempty"`
+}
+
+// nodePeerScore summarizes a peer's scoreboard record.
+type nodePeerScore struct {
+	Composite         float64    `json:"composite"`
+	Quarantined       bool       `json:"quarantined"`
+	Banned            bool       `json:"banned"`
+	BannedUntil       *time.Time `json:"bannedUntil,omitempty"`
+	BanReason         string     `json:"banReason,omitempty"`
+	InvalidBlocks     int        `json:"invalidBlocks"`
+	MalformedTxs      int        `json:"malformedTxs"`
+	ExcessiveRequests int        `json:"excessiveRequests"`
+}
+
+// nodePeerScore returns the score summary for a peer, or nil when
+// scoring is off or the peer has no record yet.
+func (node *QuidnugNode) nodePeerScore(nodeQuid string) *nodePeerScore {
+	if node.PeerScoreboard == nil {
+		return nil
+	}
+	snap := node.PeerScoreboard.SnapshotOne(nodeQuid)
+	if snap == nil {
+		return nil
+	}
+	out := &nodePeerScore{
+		Composite:         snap.Composite,
+		Quarantined:       snap.Quarantined,
+		Banned:            time.Now().Before(snap.BannedUntil),
+		InvalidBlocks:     snap.InvalidBlocks,
+		MalformedTxs:      snap.MalformedTxs,
+		ExcessiveRequests: snap.ExcessiveRequests,
+	}
+	if out.Banned {
+		out.BannedUntil = &snap.BannedUntil
+		out.BanReason = snap.BanReason
+	}
+	return out
+}
+
 // GetTransactionsHandler returns pending transactions
 func (node *QuidnugNode) GetTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
@@ -258,9 +445,9 @@ func (node *QuidnugNode) CreateTrustTransactionHandler(w http.ResponseWriter, r
 		tx.Timestamp = time.Now().Unix()
 	}
 
-	txID, err := node.AddTrustTransaction(tx)
+	txID, err := node.addTrustTransaction(r.Context(), tx)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		writeTxRejection(w, err)
 		return
 	}
 
@@ -283,9 +470,9 @@ func (node *QuidnugNode) CreateIdentityTransactionHandler(w http.ResponseWriter,
 		tx.Timestamp = time.Now().Unix()
 	}
 
-	txID, err := node.AddIdentityTransaction(tx)
+	txID, err := node.addIdentityTransaction(r.Context(), tx)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		writeTxRejection(w, err)
 		return
 	}
 
@@ -308,9 +495,9 @@ func (node *QuidnugNode) CreateTitleTransactionHandler(w http.ResponseWriter, r
 		tx.Timestamp = time.Now().Unix()
 	}
 
-	txID, err := node.AddTitleTransaction(tx)
+	txID, err := node.addTitleTransaction(r.Context(), tx)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		writeTxRejection(w, err)
 		return
 	}
 
@@ -325,18 +512,66 @@ func (node *QuidnugNode) CreateTitleTransactionHandler(w http.ResponseWriter, r
 func (node *QuidnugNode) GetBlocksHandler(w http.ResponseWriter, r *http.Request) {
 	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
 
+	if cur, ok, err := parsePageCursor(r, node.chainHeight()); ok {
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		node.writeBlockCursorPage(w, r, cur, params.Limit)
+		return
+	}
+
 	node.BlockchainMutex.RLock()
-	paginatedBlocks, total := paginateSlice(node.Blockchain, params)
+	page, total := paginateSlice(node.Blockchain, params)
+	paginatedBlocks := make([]Block, len(page))
+	copy(paginatedBlocks, page)
 	node.BlockchainMutex.RUnlock()
 
-	WriteSuccess(w, map[string]interface{}{
-		"data": paginatedBlocks,
-		"pagination": PaginationMeta{
-			Limit:  params.Limit,
-			Offset: params.Offset,
-			Total:  total,
-		},
-	})
+	writeStreamedOffsetPage(w, paginatedBlocks, params, total, node.blockHydrator(r.Context()))
+}
+
+
+// writeBlockCursorPage serves one page of the chain up to the
+// cursor's anchored height. The chain only grows, so the scan is a
+// true snapshot.
+func (node *QuidnugNode) writeBlockCursorPage(w http.ResponseWriter, r *http.Request, cur PageCursor, limit int) {
+	from := int64(0)
+	if cur.After != "" {
+		after, err := strconv.ParseInt(cur.After, 10, 64)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", ErrInvalidCursor.Error())
+			return
+		}
+		from = after + 1
+	}
+
+	blocks, _ := node.blocksFrom(from, limit+1)
+	for i, block := range blocks {
+		if block.Index > cur.Height {
+			blocks = blocks[:i]
+			break
+		}
+	}
+	meta := CursorMeta{Limit: limit, Height: cur.Height, HasMore: len(blocks) > limit}
+	if meta.HasMore {
+		blocks = blocks[:limit]
+		meta.NextCursor = encodePageCursor(PageCursor{
+			Height: cur.Height,
+			After:  strconv.FormatInt(blocks[len(blocks)-1].Index, 10),
+		})
+	}
+	blockKey := func(b Block) string { return strconv.FormatInt(b.Index, 10) }
+	writeStreamedCursorPage(w, blocks, blockKey, meta, node.blockHydrator(r.Context()))
+}
+
+// chainHeight returns the index of the latest trusted block.
+func (node *QuidnugNode) chainHeight() int64 {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	if len(node.Blockchain) == 0 {
+		return 0
+	}
+	return node.Blockchain[len(node.Blockchain)-1].Index
 }
 
 // GetDomainsHandler returns the list of trust domains
@@ -385,6 +620,21 @@ func (node *QuidnugNode) RegisterDomainHandler(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// GetDomainStatsHandler returns per-domain analytics: height,
+// tx counts by type, validators with this node's trust in them,
+// mempool depth, tentative blocks, block interval and trust-graph
+// size.
+func (node *QuidnugNode) GetDomainStatsHandler(w http.ResponseWriter, r *http.Request) {
+	domainName := mux.Vars(r)["name"]
+
+	report, ok := node.GetDomainStatsReport(r.Context(), domainName)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Trust domain not found")
+		return
+	}
+	WriteSuccess(w, report)
+}
+
 // QueryDomainHandler handles domain queries
 func (node *QuidnugNode) QueryDomainHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -421,7 +671,7 @@ func (node *QuidnugNode) QueryDomainHandler(w http.ResponseWriter, r *http.Reque
 			observer := parts[0]
 			target := parts[1]
 
-			trustLevel, trustPath, err := node.ComputeRelationalTrust(observer, target, DefaultTrustMaxDepth)
+			trustLevel, trustPath, err := node.ComputeRelationalTrust(r.Context(), observer, target, DefaultTrustMaxDepth)
 			if err != nil {
 				logger.Warn("Trust computation exceeded resource limits",
 					"observer", observer,
@@ -452,6 +702,9 @@ func (node *QuidnugNode) QueryDomainHandler(w http.ResponseWriter, r *http.Reque
 			}
 			result = title
 
+		case "control":
+			result = node.DomainControlRegistry.Status(domainName, time.Now())
+
 		default:
 			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Unknown query type")
 			return
@@ -462,7 +715,11 @@ func (node *QuidnugNode) QueryDomainHandler(w http.ResponseWriter, r *http.Reque
 		WriteSuccess(w, result)
 	} else {
 		// Forward query to other domains
-		result, err := node.QueryOtherDomain(domainName, queryType, queryParam)
+		result, err := node.FederatedQuery(r.Context(), domainName, queryType, queryParam)
+		if errors.Is(err, ErrFederationRateLimited) {
+			WriteError(w, http.StatusTooManyRequests, "RATE_LIMITED", err.Error())
+			return
+		}
 		if err != nil {
 			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
 			return
@@ -471,7 +728,8 @@ func (node *QuidnugNode) QueryDomainHandler(w http.ResponseWriter, r *http.Reque
 		// caller's request even though the data came from a
 		// peer.
 		node.IncrementDomainQueryCount(domainName)
-		WriteSuccess(w, result)
+		writeFederationHeaders(w, result)
+		WriteSuccess(w, result.Data)
 	}
 }
 
@@ -493,7 +751,7 @@ func (node *QuidnugNode) QueryTrustRegistryHandler(w http.ResponseWriter, r *htt
 			}
 		}
 
-		trustLevel, trustPath, err := node.ComputeRelationalTrust(observer, target, maxDepth)
+		trustLevel, trustPath, err := node.ComputeRelationalTrust(r.Context(), observer, target, maxDepth)
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"observer", observer,
@@ -526,6 +784,11 @@ func (node *QuidnugNode) QueryTrustRegistryHandler(w http.ResponseWriter, r *htt
 			"trustee":     trustee,
 			"trust_level": trustLevel,
 		})
+	} else if truster != "" && includeTentative(r) {
+		WriteSuccess(w, map[string]interface{}{
+			"truster":       truster,
+			"relationships": node.GetTrustTiers(truster),
+		})
 	} else if truster != "" {
 		node.TrustRegistryMutex.RLock()
 		relationships := node.TrustRegistry[truster]
@@ -536,36 +799,7 @@ func (node *QuidnugNode) QueryTrustRegistryHandler(w http.ResponseWriter, r *htt
 			"relationships": relationships,
 		})
 	} else {
-		params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
-
-		node.TrustRegistryMutex.RLock()
-		type TrustEntry struct {
-			Truster    string  `json:"truster"`
-			Trustee    string  `json:"trustee"`
-			TrustLevel float64 `json:"trust_level"`
-		}
-		var entries []TrustEntry
-		for truster, relationships := range node.TrustRegistry {
-			for trustee, level := range relationships {
-				entries = append(entries, TrustEntry{
-					Truster:    truster,
-					Trustee:    trustee,
-					TrustLevel: level,
-				})
-			}
-		}
-		node.TrustRegistryMutex.RUnlock()
-
-		paginatedEntries, total := paginateSlice(entries, params)
-
-		WriteSuccess(w, map[string]interface{}{
-			"data": paginatedEntries,
-			"pagination": PaginationMeta{
-				Limit:  params.Limit,
-				Offset: params.Offset,
-				Total:  total,
-			},
-		})
+		writeRegistryPage(w, r, node.chainHeight(), node.trustRegistryPage, trustRegistryEntryKey)
 	}
 }
 
@@ -573,6 +807,15 @@ func (node *QuidnugNode) QueryTrustRegistryHandler(w http.ResponseWriter, r *htt
 func (node *QuidnugNode) QueryIdentityRegistryHandler(w http.ResponseWriter, r *http.Request) {
 	quidID := r.URL.Query().Get("quid_id")
 
+	if includeTentative(r) {
+		if quidID != "" {
+			node.writeTieredEntity(w, "quidId", quidID, node.GetIdentityTiers(quidID), "Identity not found")
+		} else {
+			writeTieredPage(w, r, node.ListTieredIdentities())
+		}
+		return
+	}
+
 	if quidID != "" {
 		identity, exists := node.GetQuidIdentity(quidID)
 		if !exists {
@@ -581,40 +824,8 @@ func (node *QuidnugNode) QueryIdentityRegistryHandler(w http.ResponseWriter, r *
 		}
 		WriteSuccess(w, identity)
 	} else {
-		params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
-
-		node.IdentityRegistryMutex.RLock()
-		type IdentityEntry struct {
-			QuidID   string                 `json:"quid_id"`
-			Identity map[string]interface{} `json:"identity"`
-		}
-		var entries []IdentityEntry
-		for quidID, identity := range node.IdentityRegistry {
-			identityMap := map[string]interface{}{
-				"quidId":      identity.QuidID,
-				"name":        identity.Name,
-				"description": identity.Description,
-				"attributes":  identity.Attributes,
-				"creator":     identity.Creator,
-				"updateNonce": identity.UpdateNonce,
-			}
-			entries = append(entries, IdentityEntry{
-				QuidID:   quidID,
-				Identity: identityMap,
-			})
-		}
-		node.IdentityRegistryMutex.RUnlock()
-
-		paginatedEntries, total := paginateSlice(entries, params)
-
-		WriteSuccess(w, map[string]interface{}{
-			"data": paginatedEntries,
-			"pagination": PaginationMeta{
-				Limit:  params.Limit,
-				Offset: params.Offset,
-				Total:  total,
-			},
-		})
+		writeRegistryPage(w, r, node.chainHeight(), node.identityRegistryPage,
+			func(e identityRegistryEntry) string { return e.QuidID })
 	}
 }
 
@@ -642,6 +853,19 @@ func (node *QuidnugNode) GetInfoHandler(w http.ResponseWriter, r *http.Request)
 		"managedDomains": managedDomains,
 		"blockHeight":    blockHeight,
 		"version":        QuidnugVersion,
+		"role":           node.Role,
+	}
+	caps := node.Capabilities()
+	body["roles"] = caps.Roles
+	body["apiVersions"] = caps.APIVersions
+	body["supportedDomains"] = caps.SupportedDomains
+	body["maxBlockHeight"] = caps.MaxBlockHeight
+	body["protocolVersion"] = caps.ProtocolVersion
+	body["minProtocolVersion"] = caps.MinProtocolVersion
+	body["features"] = caps.Features
+	body["encodings"] = caps.Encodings
+	if node.ReplicaUpstreams != nil {
+		body["upstream"] = node.ReplicaUpstreams.Active()
 	}
 	if node.OperatorQuidID != "" {
 		body["operatorQuid"] = map[string]interface{}{
@@ -655,7 +879,8 @@ func (node *QuidnugNode) GetInfoHandler(w http.ResponseWriter, r *http.Request)
 // CreateQuidHandler creates a new quid identity
 func (node *QuidnugNode) CreateQuidHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Metadata map[string]interface{} `json:"metadata"`
+		Metadata   map[string]interface{} `json:"metadata"`
+		Passphrase string                 `json:"passphrase"`
 	}
 
 	if r.Body != nil && r.ContentLength > 0 {
@@ -665,6 +890,12 @@ func (node *QuidnugNode) CreateQuidHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// With a passphrase the key stays on the node; see wallet.go.
+	if req.Passphrase != "" {
+		node.createCustodiedQuid(w, req.Passphrase)
+		return
+	}
+
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate key pair")
@@ -707,28 +938,41 @@ func (node *QuidnugNode) GetTrustHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	includeUnverified := includeUnverifiedStr == "true"
+	crossDomain := r.URL.Query().Get("crossDomain") == "true"
+	context := r.URL.Query().Get("context")
+	if err := validateContextQuery(context, nil); err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	scope := trustQueryScope(domain, crossDomain, context, nil)
+	maxMillis, err := parseMaxMillis(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	ctx, cancel := node.trustQueryContext(r, maxMillis)
+	defer cancel()
 
 	if includeUnverified {
-		result, err := node.ComputeRelationalTrustEnhanced(observer, target, maxDepth, true)
+		result, err := node.ComputeScopedTrustEnhanced(ctx, observer, target, scope, maxDepth, true)
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"observer", observer,
 				"target", target,
 				"error", err)
-			// Return partial result with warning header
-			w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
 		}
+		// Return partial result with warning header
+		result.Truncated = markTrustTruncated(w, err)
 		result.Domain = domain
+		result.CrossDomain = crossDomain
 		WriteSuccess(w, result)
 	} else {
-		trustLevel, trustPath, err := node.ComputeRelationalTrust(observer, target, maxDepth)
+		trustLevel, trustPath, err := node.ComputeScopedTrust(ctx, observer, target, scope, maxDepth)
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"observer", observer,
 				"target", target,
 				"error", err)
-			// Return partial result with warning header
-			w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
 		}
 
 		pathDepth := 0
@@ -737,18 +981,55 @@ func (node *QuidnugNode) GetTrustHandler(w http.ResponseWriter, r *http.Request)
 		}
 
 		result := RelationalTrustResult{
-			Observer:   observer,
-			Target:     target,
-			TrustLevel: trustLevel,
-			TrustPath:  trustPath,
-			PathDepth:  pathDepth,
-			Domain:     domain,
+			Observer:    observer,
+			Target:      target,
+			TrustLevel:  trustLevel,
+			TrustPath:   trustPath,
+			PathDepth:   pathDepth,
+			Domain:      domain,
+			CrossDomain: crossDomain,
+			Truncated:   markTrustTruncated(w, err),
 		}
 
 		WriteSuccess(w, result)
 	}
 }
 
+// GetTopTrustedHandler returns the quids the observer trusts most,
+// transitively. Query params: k, maxDepth, domain.
+func (node *QuidnugNode) GetTopTrustedHandler(w http.ResponseWriter, r *http.Request) {
+	observer := mux.Vars(r)["observer"]
+	q := r.URL.Query()
+
+	k := DefaultTrustTopK
+	if v := q.Get("k"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > MaxTrustTopK {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST",
+				"k must be between 1 and "+strconv.Itoa(MaxTrustTopK))
+			return
+		}
+		k = parsed
+	}
+
+	maxDepth := DefaultTrustMaxDepth
+	if v := q.Get("maxDepth"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			maxDepth = parsed
+		}
+	}
+
+	results, err := node.TopTrustedQuids(r.Context(), observer, k, maxDepth, q.Get("domain"))
+	if err != nil {
+		logger.Warn("Trust computation exceeded resource limits",
+			"observer", observer,
+			"error", err)
+		w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
+	}
+
+	WriteSuccess(w, results)
+}
+
 // RelationalTrustQueryHandler handles POST requests for relational trust queries
 func (node *QuidnugNode) RelationalTrustQueryHandler(w http.ResponseWriter, r *http.Request) {
 	var query RelationalTrustQuery
@@ -771,27 +1052,64 @@ func (node *QuidnugNode) RelationalTrustQueryHandler(w http.ResponseWriter, r *h
 		maxDepth = DefaultTrustMaxDepth
 	}
 
+	var overlay map[string]float64
+	if query.Overlay != nil {
+		if query.IncludeUnverified {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "overlay cannot be combined with includeUnverified")
+			return
+		}
+		var err error
+		overlay, err = node.verifyTrustOverlay(query.Observer, *query.Overlay)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrOverlaySignature), errors.Is(err, ErrQuidKeyUnknown):
+				WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+			default:
+				WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			}
+			return
+		}
+	}
+
+	if err := validateContextQuery(query.Context, query.ContextWeights); err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	scope := trustQueryScope(domain, query.CrossDomain, query.Context, query.ContextWeights)
+	if query.MaxMillis < 0 {
+		WriteFieldError(w, "BAD_REQUEST", "maxMillis must not be negative", []string{"maxMillis"})
+		return
+	}
+	ctx, cancel := node.trustQueryContext(r, query.MaxMillis)
+	defer cancel()
+
 	if query.IncludeUnverified {
-		result, err := node.ComputeRelationalTrustEnhanced(query.Observer, query.Target, maxDepth, true)
+		result, err := node.ComputeScopedTrustEnhanced(ctx, query.Observer, query.Target, scope, maxDepth, true)
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"observer", query.Observer,
 				"target", query.Target,
 				"error", err)
-			// Return partial result with warning header
-			w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
 		}
+		// Return partial result with warning header
+		result.Truncated = markTrustTruncated(w, err)
 		result.Domain = domain
+		result.CrossDomain = query.CrossDomain
 		WriteSuccess(w, result)
 	} else {
-		trustLevel, trustPath, err := node.ComputeRelationalTrust(query.Observer, query.Target, maxDepth)
+		var trustLevel float64
+		var trustPath []string
+		var err error
+		if overlay != nil {
+			trustLevel, trustPath, err = node.ComputeRelationalTrustWithOverlay(ctx, query.Observer, query.Target, scope, maxDepth, overlay)
+		} else {
+			trustLevel, trustPath, err = node.ComputeScopedTrust(ctx, query.Observer, query.Target, scope, maxDepth)
+		}
 		if err != nil {
 			logger.Warn("Trust computation exceeded resource limits",
 				"observer", query.Observer,
 				"target", query.Target,
 				"error", err)
-			// Return partial result with warning header
-			w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
 		}
 
 		pathDepth := 0
@@ -800,66 +1118,711 @@ func (node *QuidnugNode) RelationalTrustQueryHandler(w http.ResponseWriter, r *h
 		}
 
 		result := RelationalTrustResult{
-			Observer:   query.Observer,
-			Target:     query.Target,
-			TrustLevel: trustLevel,
-			TrustPath:  trustPath,
-			PathDepth:  pathDepth,
-			Domain:     domain,
+			Observer:    query.Observer,
+			Target:      query.Target,
+			TrustLevel:  trustLevel,
+			TrustPath:   trustPath,
+			PathDepth:   pathDepth,
+			Domain:      domain,
+			CrossDomain: query.CrossDomain,
+			Truncated:   markTrustTruncated(w, err),
 		}
 
 		WriteSuccess(w, result)
 	}
 }
 
-// GetIdentityHandler returns identity information for a quid
-func (node *QuidnugNode) GetIdentityHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	quidID := vars["quidId"]
-
-	identity, exists := node.GetQuidIdentity(quidID)
-	if !exists {
-		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Identity not found")
+// RelationalTrustBatchHandler answers many relational trust queries
+// in one request. Targets sharing an observer are computed from a
+// single traversal.
+func (node *QuidnugNode) RelationalTrustBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var query RelationalTrustBatchQuery
+	if err := DecodeJSONBody(w, r, &query); err != nil {
 		return
 	}
 
-	WriteSuccess(w, identity)
-}
+	pairs := make([]TrustBatchPair, 0, len(query.Targets)+len(query.Pairs))
+	if len(query.Targets) > 0 {
+		if query.Observer == "" {
+			WriteFieldError(w, "MISSING_PARAMETERS", "observer is required with targets", []string{"observer"})
+			return
+		}
+		for _, target := range query.Targets {
+			pairs = append(pairs, TrustBatchPair{Observer: query.Observer, Target: target})
+		}
+	}
+	pairs = append(pairs, query.Pairs...)
 
-// GetTitleHandler returns ownership information for an asset
-func (node *QuidnugNode) GetTitleHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	assetID := vars["assetId"]
+	if len(pairs) == 0 {
+		WriteFieldError(w, "MISSING_PARAMETERS", "targets or pairs are required", []string{"targets", "pairs"})
+		return
+	}
+	if len(pairs) > MaxTrustBatchSize {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST",
+			"batch exceeds "+strconv.Itoa(MaxTrustBatchSize)+" queries")
+		return
+	}
+	for _, p := range pairs {
+		if p.Observer == "" || p.Target == "" {
+			WriteFieldError(w, "MISSING_PARAMETERS", "every pair needs observer and target", []string{"pairs"})
+			return
+		}
+	}
 
-	title, exists := node.GetAssetOwnership(assetID)
-	if !exists {
-		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title not found")
+	domain := query.Domain
+	if domain == "" {
+		domain = "default"
+	}
+	if err := validateContextQuery(query.Context, query.ContextWeights); err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	scope := trustQueryScope(domain, query.CrossDomain, query.Context, query.ContextWeights)
+	if query.MaxMillis < 0 {
+		WriteFieldError(w, "BAD_REQUEST", "maxMillis must not be negative", []string{"maxMillis"})
 		return
 	}
+	ctx, cancel := node.trustQueryContext(r, query.MaxMillis)
+	defer cancel()
+
+	// Group by observer, remembering each pair's slot so the
+	// response keeps request order.
+	var observers []string
+	byObserver := make(map[string][]int)
+	for i, p := range pairs {
+		if _, seen := byObserver[p.Observer]; !seen {
+			observers = append(observers, p.Observer)
+		}
+		byObserver[p.Observer] = append(byObserver[p.Observer], i)
+	}
+
+	results := make([]RelationalTrustResult, len(pairs))
+	for _, observer := range observers {
+		slots := byObserver[observer]
+		targets := make([]string, len(slots))
+		for j, i := range slots {
+			targets[j] = pairs[i].Target
+		}
+		batch, err := node.ComputeScopedTrustBatch(ctx, observer, targets, scope, query.MaxDepth)
+		if err != nil {
+			logger.Warn("Trust computation exceeded resource limits",
+				"observer", observer,
+				"targets", len(targets),
+				"error", err)
+		}
+		truncated := markTrustTruncated(w, err)
+		for j, i := range slots {
+			batch[j].Domain = domain
+			batch[j].CrossDomain = query.CrossDomain
+			batch[j].Truncated = truncated
+			results[i] = batch[j]
+		}
+	}
 
-	WriteSuccess(w, title)
+	WriteSuccess(w, results)
 }
 
-// GetTentativeBlocksHandler returns tentative blocks for a domain
-func (node *QuidnugNode) GetTentativeBlocksHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	domain := vars["domain"]
+// includeTentative reports whether the caller asked for state
+// from tentative blocks alongside trusted state.
+func includeTentative(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get("include_tentative"))
+	return v
+}
 
-	blocks := node.GetTentativeBlocks(domain)
+// writeTieredEntity writes every tier of a single entity, or a
+// 404 when neither the registry nor a tentative block has it.
+func (node *QuidnugNode) writeTieredEntity(w http.ResponseWriter, keyField, key string, tiers []TieredEntity, notFound string) {
+	if len(tiers) == 0 {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", notFound)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		keyField:  key,
+		"entries": tiers,
+	})
+}
 
+// writeTieredPage paginates a tiered listing in the same envelope
+// the plain registry listings use.
+func writeTieredPage(w http.ResponseWriter, r *http.Request, entries []TieredEntity) {
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	page, total := paginateSlice(entries, params)
 	WriteSuccess(w, map[string]interface{}{
-		"domain": domain,
-		"blocks": blocks,
+		"data": page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
 	})
 }
 
-// GetTrustEdgesHandler returns trust edges for a quid with provenance
-func (node *QuidnugNode) GetTrustEdgesHandler(w http.ResponseWriter, r *http.Request) {
+// GetIdentityHandler returns identity information for a quid
+func (node *QuidnugNode) GetIdentityHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	quidId := vars["quidId"]
+	quidID := vars["quidId"]
 
-	includeUnverifiedStr := r.URL.Query().Get("includeUnverified")
-	includeUnverified := includeUnverifiedStr == "true"
+	if includeTentative(r) {
+		node.writeTieredEntity(w, "quidId", quidID, node.GetIdentityTiers(quidID), "Identity not found")
+		return
+	}
+
+	identity, exists := node.GetQuidIdentity(quidID)
+	if !exists {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Identity not found")
+		return
+	}
+
+	WriteSuccess(w, identity)
+}
+
+// GetSybilScoreHandler returns the sybil score breakdown for a quid.
+// Quids with no identity record still score, low.
+func (node *QuidnugNode) GetSybilScoreHandler(w http.ResponseWriter, r *http.Request) {
+	quidID := mux.Vars(r)["quidId"]
+	if !IsValidQuidID(quidID) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid quid ID")
+		return
+	}
+	WriteSuccess(w, node.ComputeSybilScore(quidID))
+}
+
+// GetSuccessionHandler returns a quid's succession plan, its last
+// signed activity, and its succession if one has happened.
+func (node *QuidnugNode) GetSuccessionHandler(w http.ResponseWriter, r *http.Request) {
+	quidID := mux.Vars(r)["quidId"]
+	status, ok := node.GetSuccessionStatus(quidID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Identity not found")
+		return
+	}
+	WriteSuccess(w, status)
+}
+
+// CreateSuccessionTransactionHandler accepts a signed
+// SuccessionTransaction and queues it for block inclusion.
+func (node *QuidnugNode) CreateSuccessionTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	var tx SuccessionTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddSuccessionTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":            txID,
+		"subjectQuid":   tx.SubjectQuid,
+		"successorQuid": tx.SuccessorQuid,
+	})
+}
+
+// GetTitleHandler returns ownership information for an asset
+func (node *QuidnugNode) GetTitleHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	assetID := vars["assetId"]
+
+	if includeTentative(r) {
+		node.writeTieredEntity(w, "assetId", assetID, node.GetTitleTiers(assetID), "Title not found")
+		return
+	}
+
+	title, exists := node.GetAssetOwnership(assetID)
+	if !exists {
+		if node.isTitleRetired(assetID) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title retired by a split or merge; see its lineage")
+			return
+		}
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title not found")
+		return
+	}
+
+	// Active encumbrances ride alongside the ownership stakes so
+	// a buyer sees both in one read.
+	encumbrances := node.GetActiveLiens(assetID)
+	if encumbrances == nil {
+		encumbrances = []LienRecord{}
+	}
+	var expiry *TitleExpiry
+	if e, ok := node.GetTitleExpiry(assetID); ok {
+		expiry = &e
+	}
+	disputes := node.GetTitleDisputes(assetID, true)
+	if disputes == nil {
+		disputes = []DisputeRecord{}
+	}
+	WriteSuccess(w, struct {
+		TitleTransaction
+		Stakes       TitleStakes     `json:"stakes"`
+		Encumbrances []LienRecord    `json:"encumbrances"`
+		Expiry       *TitleExpiry    `json:"expiry,omitempty"`
+		Disputed     bool            `json:"disputed"`
+		Disputes     []DisputeRecord `json:"disputes"`
+	}{title, groupStakes(title.Owners, time.Now().Unix()), encumbrances, expiry, len(disputes) > 0, disputes})
+}
+
+// ListTitleExpiriesHandler lists titles block processing has
+// lapsed or reverted, most recent first, with the usual
+// limit/offset.
+func (node *QuidnugNode) ListTitleExpiriesHandler(w http.ResponseWriter, r *http.Request) {
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	page, total := paginateSlice(node.ListTitleExpiries(), params)
+	WriteSuccess(w, map[string]interface{}{
+		"expiries": page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
+}
+
+// GetTitleLiensHandler returns the active liens on an asset.
+func (node *QuidnugNode) GetTitleLiensHandler(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	if _, exists := node.GetAssetOwnership(assetID); !exists {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title not found")
+		return
+	}
+
+	liens := node.GetActiveLiens(assetID)
+	if liens == nil {
+		liens = []LienRecord{}
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"assetId": assetID,
+		"liens":   liens,
+	})
+}
+
+// GetTitleDisputesHandler returns the disputes on an asset, oldest
+// first. Resolved ones are included unless ?open=true.
+func (node *QuidnugNode) GetTitleDisputesHandler(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	if _, exists := node.GetAssetOwnership(assetID); !exists {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title not found")
+		return
+	}
+
+	disputes := node.GetTitleDisputes(assetID, r.URL.Query().Get("open") == "true")
+	if disputes == nil {
+		disputes = []DisputeRecord{}
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"assetId":  assetID,
+		"disputes": disputes,
+	})
+}
+
+// GetTitleLineageHandler returns the split/merge provenance of an
+// asset, live or retired. A title that never took part in one gets
+// empty ancestor and descendant lists.
+func (node *QuidnugNode) GetTitleLineageHandler(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	lineage, ok := node.GetTitleLineage(assetID)
+	if !ok {
+		if _, exists := node.GetAssetOwnership(assetID); !exists {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", "Title not found")
+			return
+		}
+		lineage = TitleLineage{AssetID: assetID, Ancestors: []string{}, Descendants: []string{}}
+	}
+	WriteSuccess(w, lineage)
+}
+
+// CreateTitleRestructureHandler accepts a signed
+// TitleRestructureTransaction (split or merge) and queues it for
+// block inclusion.
+func (node *QuidnugNode) CreateTitleRestructureHandler(w http.ResponseWriter, r *http.Request) {
+	var tx TitleRestructureTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddTitleRestructureTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":        txID,
+		"operation": tx.Operation,
+		"parents":   len(tx.Parents),
+		"children":  len(tx.Children),
+	})
+}
+
+// CreateTitleDisputeHandler accepts a signed
+// TitleDisputeTransaction (filing or resolution) and queues it for
+// block inclusion.
+func (node *QuidnugNode) CreateTitleDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	var tx TitleDisputeTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddTitleDisputeTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":                txID,
+		"assetId":           tx.AssetID,
+		"resolvesDisputeId": tx.ResolvesDisputeID,
+	})
+}
+
+// GetPendingTransferHandler returns the conditional transfer, if
+// any, currently holding an asset in escrow.
+func (node *QuidnugNode) GetPendingTransferHandler(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	ct, ok := node.GetPendingTransferForAsset(assetID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "No pending transfer for asset")
+		return
+	}
+	WriteSuccess(w, ct)
+}
+
+// GetConditionalTransferHandler returns the escrow state of a
+// conditional transfer by its title tx ID, including settled ones.
+func (node *QuidnugNode) GetConditionalTransferHandler(w http.ResponseWriter, r *http.Request) {
+	txID := mux.Vars(r)["txId"]
+
+	ct, ok := node.GetConditionalTransfer(txID)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Conditional transfer not found")
+		return
+	}
+	WriteSuccess(w, ct)
+}
+
+// CreateTransferApprovalHandler accepts a signed
+// TransferApprovalTransaction and queues it for block inclusion.
+func (node *QuidnugNode) CreateTransferApprovalHandler(w http.ResponseWriter, r *http.Request) {
+	var tx TransferApprovalTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddTransferApprovalTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":           txID,
+		"transferTxId": tx.TransferTxID,
+		"approverQuid": tx.ApproverQuid,
+		"decision":     tx.Decision,
+	})
+}
+
+// CreateLienTransactionHandler accepts a signed LienTransaction
+// (new lien or release) and queues it for block inclusion.
+func (node *QuidnugNode) CreateLienTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	var tx LienTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddLienTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":             txID,
+		"assetId":        tx.AssetID,
+		"lienholderQuid": tx.LienholderQuid,
+		"releasesLienId": tx.ReleasesLienID,
+	})
+}
+
+// GetTentativeBlocksHandler returns tentative blocks for a domain
+func (node *QuidnugNode) GetTentativeBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domain := vars["domain"]
+
+	blocks := node.GetTentativeBlocks(domain)
+
+	WriteSuccess(w, map[string]interface{}{
+		"domain": domain,
+		"blocks": blocks,
+	})
+}
+
+// ListQuarantinedBlocksHandler lists untrusted blocks held for
+// review, optionally filtered by ?domain=.
+func (node *QuidnugNode) ListQuarantinedBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	domain := r.URL.Query().Get("domain")
+	WriteSuccess(w, map[string]interface{}{
+		"blocks": node.ListQuarantinedBlocks(domain),
+	})
+}
+
+// GetQuarantinedBlockHandler returns one quarantined block in full.
+func (node *QuidnugNode) GetQuarantinedBlockHandler(w http.ResponseWriter, r *http.Request) {
+	hash := mux.Vars(r)["hash"]
+	qb, ok := node.GetQuarantinedBlock(hash)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Quarantined block not found")
+		return
+	}
+	WriteSuccess(w, qb)
+}
+
+// ReviewQuarantinedBlockHandler accepts or purges a quarantined
+// block. The body is a QuarantineReviewRequest signed by the
+// operator key; its action and hash must match the path.
+func (node *QuidnugNode) ReviewQuarantinedBlockHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req QuarantineReviewRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if req.Action != vars["action"] || req.BlockHash != vars["hash"] {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Signed action and block hash must match the request path")
+		return
+	}
+
+	if err := node.ReviewQuarantinedBlock(req); err != nil {
+		switch {
+		case errors.Is(err, ErrQuarantinedBlockNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"blockHash": req.BlockHash,
+		"action":    req.Action,
+	})
+}
+
+// ListIdentityConflictsHandler lists competing identity updates the
+// node rejected, newest first. Query params: quidId,
+// includeAcknowledged (default false), limit, offset.
+func (node *QuidnugNode) ListIdentityConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	conflicts := node.ListIdentityConflicts(q.Get("quidId"), q.Get("includeAcknowledged") == "true")
+
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	page, total := paginateSlice(conflicts, params)
+	WriteSuccess(w, map[string]interface{}{
+		"conflicts": page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
+}
+
+// AcknowledgeIdentityConflictHandler marks a conflict reviewed. The
+// body is an IdentityConflictAckRequest signed by the operator key;
+// its conflict ID must match the path.
+func (node *QuidnugNode) AcknowledgeIdentityConflictHandler(w http.ResponseWriter, r *http.Request) {
+	var req IdentityConflictAckRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if req.ConflictID != mux.Vars(r)["id"] {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Signed conflict ID must match the request path")
+		return
+	}
+
+	if err := node.AcknowledgeIdentityConflict(req); err != nil {
+		switch {
+		case errors.Is(err, ErrIdentityConflictNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"conflictId":   req.ConflictID,
+		"acknowledged": true,
+	})
+}
+
+// ListCustomTxTypesHandler lists registered custom transaction types.
+func (node *QuidnugNode) ListCustomTxTypesHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{
+		"types": node.ListCustomTxTypes(),
+	})
+}
+
+// RegisterCustomTxTypeHandler registers a custom transaction type.
+// The body is a CustomTxTypeRequest signed by the operator key.
+func (node *QuidnugNode) RegisterCustomTxTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var req CustomTxTypeRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+
+	if err := node.RegisterCustomTxTypeSigned(req); err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrCustomTxTypeExists):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+
+	def, _ := node.GetCustomTxType(req.Type.Name)
+	WriteSuccessWithStatus(w, http.StatusCreated, def)
+}
+
+// GetCustomTxTypeHandler returns one registered custom type.
+func (node *QuidnugNode) GetCustomTxTypeHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	def, ok := node.GetCustomTxType(name)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Custom transaction type not registered")
+		return
+	}
+	WriteSuccess(w, def)
+}
+
+// ListAssetClassesHandler lists the asset classes of a domain.
+func (node *QuidnugNode) ListAssetClassesHandler(w http.ResponseWriter, r *http.Request) {
+	domain := mux.Vars(r)["name"]
+	WriteSuccess(w, map[string]interface{}{
+		"domain":  domain,
+		"classes": node.ListAssetClasses(domain),
+	})
+}
+
+// RegisterAssetClassHandler registers an asset class for the domain
+// in the path. The body is an AssetClassRequest signed by the
+// operator key, naming the same domain.
+func (node *QuidnugNode) RegisterAssetClassHandler(w http.ResponseWriter, r *http.Request) {
+	var req AssetClassRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	domain := mux.Vars(r)["name"]
+	if req.Class.Domain != domain {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "class domain does not match the path")
+		return
+	}
+
+	if err := node.RegisterAssetClassSigned(req); err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrAssetClassExists):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+
+	class, _ := node.GetAssetClass(domain, req.Class.Name)
+	WriteSuccessWithStatus(w, http.StatusCreated, class)
+}
+
+// GetAssetClassHandler returns one asset class of a domain.
+func (node *QuidnugNode) GetAssetClassHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	class, ok := node.GetAssetClass(vars["name"], vars["class"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Asset class not registered")
+		return
+	}
+	WriteSuccess(w, class)
+}
+
+// ListCustomTransactionsHandler returns committed transactions of a
+// custom type in commit order. Query params: subjectId, plus the
+// usual limit/offset.
+func (node *QuidnugNode) ListCustomTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	txs := node.ListCustomTransactions(name, r.URL.Query().Get("subjectId"))
+
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	page, total := paginateSlice(txs, params)
+	WriteSuccess(w, map[string]interface{}{
+		"customType":   name,
+		"transactions": page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
+}
+
+// CreateCustomTransactionHandler accepts a signed CustomTransaction
+// and queues it for block inclusion.
+func (node *QuidnugNode) CreateCustomTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	var tx CustomTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddCustomTransaction(tx)
+	if err != nil {
+		if errors.Is(err, ErrCustomTxTypeNotFound) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":         txID,
+		"customType": tx.CustomType,
+		"signer":     tx.Signer,
+		"subjectId":  tx.SubjectID,
+	})
+}
+
+// GetCustomTransactionHandler returns one committed custom
+// transaction by ID.
+func (node *QuidnugNode) GetCustomTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	tx, ok := node.GetCustomTransaction(mux.Vars(r)["txId"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Custom transaction not found")
+		return
+	}
+	WriteSuccess(w, tx)
+}
+
+// GetTrustEdgesHandler returns trust edges for a quid with provenance
+func (node *QuidnugNode) GetTrustEdgesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quidId := vars["quidId"]
+
+	includeUnverifiedStr := r.URL.Query().Get("includeUnverified")
+	includeUnverified := includeUnverifiedStr == "true"
 
 	edges := node.GetTrustEdges(quidId, includeUnverified)
 
@@ -870,6 +1833,58 @@ func (node *QuidnugNode) GetTrustEdgesHandler(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// GetTrustEvidenceHandler returns the evidence references recorded
+// for the edge truster -> trustee.
+func (node *QuidnugNode) GetTrustEvidenceHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	truster, trustee := vars["truster"], vars["trustee"]
+	if !IsValidQuidID(truster) || !IsValidQuidID(trustee) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid quid ID")
+		return
+	}
+
+	record, ok := node.GetTrustEvidence(truster, trustee)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "No evidence recorded for this edge")
+		return
+	}
+	WriteSuccess(w, record)
+}
+
+// ExportTrustGraphHandler streams the filtered trust graph as
+// GraphML, DOT, or JSON-LD. The body is the raw document, not the
+// usual JSON envelope, so it can be saved and opened directly.
+func (node *QuidnugNode) ExportTrustGraphHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := negotiateTrustGraphFormat(q.Get("format"), r.Header.Get("Accept"))
+	if format == "" {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "format must be one of graphml, dot, jsonld")
+		return
+	}
+
+	filter := TrustGraphFilter{
+		Domain:       q.Get("domain"),
+		VerifiedOnly: q.Get("verifiedOnly") == "true",
+	}
+	if s := q.Get("minTrust"); s != "" {
+		minTrust, err := strconv.ParseFloat(s, 64)
+		if err != nil || minTrust < 0 || minTrust > 1 {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "minTrust must be a number in [0, 1]")
+			return
+		}
+		filter.MinTrust = minTrust
+	}
+
+	edges := node.ExportTrustGraph(filter)
+
+	w.Header().Set("Content-Type", trustGraphContentTypes[format])
+	w.Header().Set("X-API-Version", "1.0")
+	if err := writeTrustGraph(w, format, edges); err != nil {
+		logger.Warn("Trust graph export failed", "format", format, "error", err)
+	}
+}
+
 // CreateEventTransactionHandler handles event transaction creation
 func (node *QuidnugNode) CreateEventTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	var tx EventTransaction
@@ -895,9 +1910,9 @@ func (node *QuidnugNode) CreateEventTransactionHandler(w http.ResponseWriter, r
 		node.EventStreamMutex.RUnlock()
 	}
 
-	txID, err := node.AddEventTransaction(tx)
+	txID, err := node.addEventTransaction(r.Context(), tx)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		writeTxRejection(w, err)
 		return
 	}
 
@@ -1075,6 +2090,67 @@ func (node *QuidnugNode) CreateModerationActionHandler(w http.ResponseWriter, r
 	})
 }
 
+// CreateNameRegistrationHandler accepts a signed
+// NameRegistrationTransaction and queues it for block inclusion.
+func (node *QuidnugNode) CreateNameRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	var tx NameRegistrationTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddNameRegistrationTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":         txID,
+		"name":       tx.Name,
+		"ownerQuid":  tx.OwnerQuid,
+		"targetQuid": tx.TargetQuid,
+		"release":    tx.Release,
+	})
+}
+
+// ResolveNameHandler resolves a fully-qualified name such as
+// "alice.example.com" to the quid it currently points at.
+func (node *QuidnugNode) ResolveNameHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" || len(name) > MaxSupportedDomainLength || !ValidateStringField(name, MaxSupportedDomainLength) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid name")
+		return
+	}
+
+	rec, ok := node.ResolveName(name)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Name not registered")
+		return
+	}
+	WriteSuccess(w, rec)
+}
+
+// GetQuidNamesHandler lists every active name resolving to a quid
+// (reverse lookup).
+func (node *QuidnugNode) GetQuidNamesHandler(w http.ResponseWriter, r *http.Request) {
+	quidID := mux.Vars(r)["quidId"]
+	if !IsValidQuidID(quidID) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid quid ID")
+		return
+	}
+
+	names := []NameRecord{}
+	if node.NameRegistry != nil {
+		if found := node.NameRegistry.namesFor(quidID); found != nil {
+			names = found
+		}
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"quidId": quidID,
+		"names":  names,
+	})
+}
+
 // GetModerationActionsHandler returns every moderation action
 // in the registry for a given (targetType, targetId), together
 // with the current effective scope. Useful for clients and
@@ -1115,6 +2191,15 @@ func (node *QuidnugNode) QueryTitleRegistryHandler(w http.ResponseWriter, r *htt
 	assetID := r.URL.Query().Get("asset_id")
 	ownerID := r.URL.Query().Get("owner_id")
 
+	if includeTentative(r) && ownerID == "" {
+		if assetID != "" {
+			node.writeTieredEntity(w, "assetId", assetID, node.GetTitleTiers(assetID), "Title not found")
+		} else {
+			writeTieredPage(w, r, node.ListTieredTitles())
+		}
+		return
+	}
+
 	if assetID != "" {
 		title, exists := node.GetAssetOwnership(assetID)
 		if !exists {
@@ -1125,21 +2210,25 @@ func (node *QuidnugNode) QueryTitleRegistryHandler(w http.ResponseWriter, r *htt
 	} else if ownerID != "" {
 		params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
 
-		node.TitleRegistryMutex.RLock()
-		var ownedAssets []map[string]interface{}
-		for assetID, title := range node.TitleRegistry {
-			for _, stake := range title.Owners {
-				if stake.OwnerID == ownerID {
-					ownedAssets = append(ownedAssets, map[string]interface{}{
-						"asset_id":   assetID,
-						"percentage": stake.Percentage,
-						"stake_type": stake.StakeType,
-					})
-					break
-				}
+		owned := node.GetOwnedAssets(ownerID)
+		ownedAssets := make([]map[string]interface{}, 0, len(owned))
+		for _, a := range owned {
+			ownedAssets = append(ownedAssets, map[string]interface{}{
+				"asset_id":   a.AssetID,
+				"percentage": a.Percentage,
+				"stake_type": a.StakeType,
+				"expires_at": a.ExpiresAt,
+			})
+		}
+
+		if cur, ok, err := parsePageCursor(r, node.chainHeight()); ok {
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+				return
 			}
+			writeCursorPage(w, ownedAssets, func(m map[string]interface{}) string { return m["asset_id"].(string) }, cur, params.Limit)
+			return
 		}
-		node.TitleRegistryMutex.RUnlock()
 
 		paginatedAssets, total := paginateSlice(ownedAssets, params)
 
@@ -1152,33 +2241,42 @@ func (node *QuidnugNode) QueryTitleRegistryHandler(w http.ResponseWriter, r *htt
 			},
 		})
 	} else {
-		params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+		writeRegistryPage(w, r, node.chainHeight(), node.titleRegistryPage,
+			func(e titleRegistryEntry) string { return e.AssetID })
+	}
+}
 
-		node.TitleRegistryMutex.RLock()
-		type TitleEntry struct {
-			AssetID string      `json:"asset_id"`
-			Title   interface{} `json:"title"`
-		}
-		var entries []TitleEntry
-		for assetID, title := range node.TitleRegistry {
-			entries = append(entries, TitleEntry{
-				AssetID: assetID,
-				Title:   title,
-			})
-		}
-		node.TitleRegistryMutex.RUnlock()
+// GetOwnedAssetsHandler lists the assets a quid holds a stake in,
+// served from the owner index rather than a registry scan.
+func (node *QuidnugNode) GetOwnedAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	ownerID := mux.Vars(r)["quidId"]
+	if !IsValidQuidID(ownerID) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid quid ID")
+		return
+	}
 
-		paginatedEntries, total := paginateSlice(entries, params)
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	assets := node.GetOwnedAssets(ownerID)
 
-		WriteSuccess(w, map[string]interface{}{
-			"data": paginatedEntries,
-			"pagination": PaginationMeta{
-				Limit:  params.Limit,
-				Offset: params.Offset,
-				Total:  total,
-			},
-		})
+	if cur, ok, err := parsePageCursor(r, node.chainHeight()); ok {
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		writeCursorPage(w, assets, func(a OwnedAsset) string { return a.AssetID }, cur, params.Limit)
+		return
 	}
+
+	page, total := paginateSlice(assets, params)
+	WriteSuccess(w, map[string]interface{}{
+		"ownerId": ownerID,
+		"data":    page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
 }
 
 // CreateNodeAdvertisementHandler accepts a signed
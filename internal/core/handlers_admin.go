@@ -0,0 +1,261 @@
+// Package core — handlers_admin.go
+//
+// Operator endpoints under /admin. Mutating endpoints require an
+// admin-signed request (see verifyAdminSigned); read-only
+// diagnostics are open like the other query endpoints, except the
+// runtime ones in diagnostics.go, which expose process internals,
+// and /admin/verify, whose full-chain scan is too costly to leave
+// open. Those take the signature in headers (requireAdminHeaders).
+package core
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// registerAdminRoutes mounts the /admin endpoints. Called from
+// registerAPIRoutes.
+func (node *QuidnugNode) registerAdminRoutes(router *mux.Router) {
+	router.Handle("/admin/verify", node.requireAdminHeaders(http.HandlerFunc(node.VerifyInvariantsHandler))).Methods("GET")
+	router.HandleFunc("/admin/trust-provenance", node.GetTrustProvenanceReportHandler).Methods("GET")
+	router.HandleFunc("/admin/trust-provenance/backfill", node.BackfillTrustProvenanceHandler).Methods("POST")
+	router.HandleFunc("/admin/state-rebuild", node.GetStateRebuildReportHandler).Methods("GET")
+	router.HandleFunc("/admin/state-rebuild", node.RebuildStateHandler).Methods("POST")
+	router.HandleFunc("/admin/anomalies/clear-suspect", node.ClearSuspectEdgeHandler).Methods("POST")
+	router.HandleFunc("/admin/acceptance-policy", node.GetAcceptancePolicyHandler).Methods("GET")
+	router.HandleFunc("/admin/acceptance-policy", node.UpdateAcceptancePolicyHandler).Methods("POST")
+	router.HandleFunc("/admin/peers", node.GetManualPeersHandler).Methods("GET")
+	router.HandleFunc("/admin/peers", node.AddManualPeerHandler).Methods("POST")
+	router.HandleFunc("/admin/peers", node.RemoveManualPeerHandler).Methods("DELETE")
+	router.HandleFunc("/admin/import", node.ListImportJobsHandler).Methods("GET")
+	router.HandleFunc("/admin/import", node.StartImportHandler).Methods("POST")
+	router.HandleFunc("/admin/import/{id}", node.GetImportJobHandler).Methods("GET")
+	router.HandleFunc("/admin/log-level", node.GetLogLevelHandler).Methods("GET")
+	router.HandleFunc("/admin/log-level", node.UpdateLogLevelHandler).Methods("PUT")
+	node.registerDiagnosticsRoutes(router)
+}
+
+// VerifyInvariantsHandler runs the registry invariant checks and
+// returns the InvariantReport. A report with violations is still a
+// 200; callers inspect violations. Query param check selects a
+// comma-separated subset of invariants.
+func (node *QuidnugNode) VerifyInvariantsHandler(w http.ResponseWriter, r *http.Request) {
+	var checks []string
+	if q := r.URL.Query().Get("check"); q != "" {
+		for _, name := range strings.Split(q, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				checks = append(checks, name)
+			}
+		}
+	}
+	report, err := node.VerifyInvariants(checks...)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	WriteSuccess(w, report)
+}
+
+// GetTrustProvenanceReportHandler returns the report of the last
+// provenance backfill. Before the first run, report is null.
+func (node *QuidnugNode) GetTrustProvenanceReportHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{
+		"report": node.LastTrustProvenanceReport(),
+	})
+}
+
+// BackfillTrustProvenanceHandler rescans the chain and rebuilds
+// trust edge provenance. The body is a TrustProvenanceBackfillRequest
+// signed by the operator key. The scan runs within the request, so
+// a client that disconnects cancels it.
+func (node *QuidnugNode) BackfillTrustProvenanceHandler(w http.ResponseWriter, r *http.Request) {
+	var req TrustProvenanceBackfillRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	report, err := node.TriggerTrustProvenanceBackfill(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrProvenanceBackfillRunning):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+		}
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"report": report,
+	})
+}
+
+// GetStateRebuildReportHandler returns the report of the last state
+// rebuild. Before the first run, report is null.
+func (node *QuidnugNode) GetStateRebuildReportHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{
+		"report": node.LastStateRebuildReport(),
+	})
+}
+
+// RebuildStateHandler wipes the chain-derived registries and replays
+// the chain into them. The body is an admin-signed
+// StateRebuildRequest. The response waits for the replay to finish.
+func (node *QuidnugNode) RebuildStateHandler(w http.ResponseWriter, r *http.Request) {
+	var req StateRebuildRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	report, err := node.TriggerStateRebuild(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrStateRebuildRunning):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"report": report,
+	})
+}
+
+// acceptancePolicyView is the JSON shape of the running policy. Rules
+// is null while the threshold pair is in effect.
+func acceptancePolicyView(p *AcceptancePolicy) map[string]interface{} {
+	view := map[string]interface{}{"rules": nil}
+	if p != nil {
+		view["rules"] = p.Rules()
+	}
+	return view
+}
+
+// GetAcceptancePolicyHandler returns the block acceptance policy in
+// effect.
+func (node *QuidnugNode) GetAcceptancePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, acceptancePolicyView(node.GetAcceptancePolicy()))
+}
+
+// UpdateAcceptancePolicyHandler replaces the block acceptance policy.
+// The body is an admin-signed AcceptancePolicyUpdateRequest.
+func (node *QuidnugNode) UpdateAcceptancePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var req AcceptancePolicyUpdateRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	p, err := node.UpdateAcceptancePolicy(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+	WriteSuccess(w, acceptancePolicyView(p))
+}
+
+// GetManualPeersHandler lists the peers added through the admin API.
+func (node *QuidnugNode) GetManualPeersHandler(w http.ResponseWriter, r *http.Request) {
+	peers := node.ManualPeers()
+	WriteSuccess(w, map[string]interface{}{
+		"peers": peers,
+		"count": len(peers),
+	})
+}
+
+// AddManualPeerHandler connects to a peer and pins it. The body is
+// an admin-signed ManualPeerAddRequest. The response waits for the
+// handshake and returns the new KnownNodes entry.
+func (node *QuidnugNode) AddManualPeerHandler(w http.ResponseWriter, r *http.Request) {
+	var req ManualPeerAddRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	peer, err := node.AddManualPeer(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrManualPeerAddress):
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case peer.ID != "":
+			WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		default:
+			WriteError(w, http.StatusBadGateway, "PEER_REJECTED", err.Error())
+		}
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, peer)
+}
+
+// RemoveManualPeerHandler unpins and drops a manual peer. The body
+// is an admin-signed ManualPeerRemoveRequest.
+func (node *QuidnugNode) RemoveManualPeerHandler(w http.ResponseWriter, r *http.Request) {
+	var req ManualPeerRemoveRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	peer, err := node.RemoveManualPeer(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrManualPeerNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case peer.Address != "":
+			WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+	WriteSuccess(w, peer)
+}
+
+// StartImportHandler starts a bulk import. The body is an
+// admin-signed ImportRequest; the response is the job, whose
+// progress GET /admin/import/{id} reports.
+func (node *QuidnugNode) StartImportHandler(w http.ResponseWriter, r *http.Request) {
+	var req ImportRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	job, err := node.StartImport(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		case errors.Is(err, ErrImportJobNotFound):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case errors.Is(err, ErrImportJobRunning):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusAccepted, job)
+}
+
+// ListImportJobsHandler lists the node's import jobs, newest first.
+func (node *QuidnugNode) ListImportJobsHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{"jobs": node.ListImportJobs()})
+}
+
+// GetImportJobHandler reports one import job.
+func (node *QuidnugNode) GetImportJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := node.GetImportJob(mux.Vars(r)["id"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Import job not found")
+		return
+	}
+	WriteSuccess(w, job)
+}
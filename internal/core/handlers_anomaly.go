@@ -0,0 +1,72 @@
+// Package core — handlers_anomaly.go
+//
+// Trust-graph anomaly findings and suspect edges; see anomaly.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+)
+
+func writeAnomalyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAnomalyDetectionDisabled):
+		WriteError(w, http.StatusNotFound, "ANOMALY_DETECTION_DISABLED", err.Error())
+	case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	default:
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
+
+// GetAnomaliesHandler lists recent findings, newest first. Query
+// params kind and domain filter; limit defaults to 100.
+func (node *QuidnugNode) GetAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	if node.Anomalies == nil {
+		writeAnomalyError(w, ErrAnomalyDetectionDisabled)
+		return
+	}
+	q := r.URL.Query()
+	limit := parseInt(q.Get("limit"), 100)
+	if limit <= 0 || limit > MaxAnomalyEvents {
+		limit = MaxAnomalyEvents
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"anomalies": node.Anomalies.Events(q.Get("kind"), q.Get("domain"), limit),
+	})
+}
+
+// GetSuspectEdgesHandler lists edges currently marked suspect,
+// optionally only those touching the quid query param.
+func (node *QuidnugNode) GetSuspectEdgesHandler(w http.ResponseWriter, r *http.Request) {
+	if node.Anomalies == nil {
+		writeAnomalyError(w, ErrAnomalyDetectionDisabled)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"edges":    node.Anomalies.SuspectEdges(r.URL.Query().Get("quid")),
+		"discount": node.Anomalies.discount,
+	})
+}
+
+// ClearSuspectEdgeHandler unmarks one suspect edge. The body is an
+// admin-signed AnomalyClearRequest.
+func (node *QuidnugNode) ClearSuspectEdgeHandler(w http.ResponseWriter, r *http.Request) {
+	var req AnomalyClearRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	cleared, err := node.ClearSuspectEdge(req)
+	if err != nil {
+		writeAnomalyError(w, err)
+		return
+	}
+	if !cleared {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Edge is not marked suspect")
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"truster": req.Truster,
+		"trustee": req.Trustee,
+	})
+}
@@ -0,0 +1,104 @@
+// Package core — handlers_blobs.go
+//
+// Upload and download of content-addressed attachment blobs; see
+// blobs.go and internal/blobstore.
+package core
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/internal/blobstore"
+)
+
+// writeBlobError maps blob store errors onto HTTP statuses.
+func writeBlobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBlobStoreDisabled):
+		WriteError(w, http.StatusNotFound, "BLOB_STORE_DISABLED", err.Error())
+	case errors.Is(err, blobstore.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, blobstore.ErrInvalidDigest), errors.Is(err, blobstore.ErrEmpty):
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	default:
+		logger.Error("Blob store operation failed", "error", err)
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Blob store operation failed")
+	}
+}
+
+// UploadBlobHandler stores the raw request body and returns its
+// metadata, including the digest transactions should reference. The
+// request's Content-Type is recorded and served back on download.
+func (node *QuidnugNode) UploadBlobHandler(w http.ResponseWriter, r *http.Request) {
+	if node.Blobs == nil {
+		writeBlobError(w, ErrBlobStoreDisabled)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Blob exceeds the maximum body size")
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Failed to read request body")
+		return
+	}
+
+	contentType := "application/octet-stream"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if _, _, err := mime.ParseMediaType(ct); err != nil {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid Content-Type")
+			return
+		}
+		contentType = ct
+	}
+
+	meta, err := node.Blobs.Put(r.Context(), data, contentType)
+	if err != nil {
+		writeBlobError(w, err)
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, meta)
+}
+
+// GetBlobHandler serves a blob's content. Blobs are user-supplied,
+// so the response is sandboxed against being rendered as a page of
+// this origin.
+func (node *QuidnugNode) GetBlobHandler(w http.ResponseWriter, r *http.Request) {
+	if node.Blobs == nil {
+		writeBlobError(w, ErrBlobStoreDisabled)
+		return
+	}
+	meta, data, err := node.Blobs.Get(r.Context(), mux.Vars(r)["digest"])
+	if err != nil {
+		writeBlobError(w, err)
+		return
+	}
+	contentType := meta.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// GetBlobMetaHandler returns a blob's metadata without its content.
+func (node *QuidnugNode) GetBlobMetaHandler(w http.ResponseWriter, r *http.Request) {
+	if node.Blobs == nil {
+		writeBlobError(w, ErrBlobStoreDisabled)
+		return
+	}
+	meta, err := node.Blobs.Stat(mux.Vars(r)["digest"])
+	if err != nil {
+		writeBlobError(w, err)
+		return
+	}
+	WriteSuccess(w, meta)
+}
@@ -0,0 +1,38 @@
+// Package core — handlers_block_timestamp.go
+//
+// Endpoint serving RFC 3161 block timestamps; see
+// block_timestamp.go.
+package core
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetBlockTimestampHandler returns the TSA token for a block.
+// ?format=der returns the raw DER token, ready for
+// `openssl ts -verify -token_in`.
+func (node *QuidnugNode) GetBlockTimestampHandler(w http.ResponseWriter, r *http.Request) {
+	if node.BlockTimestamps == nil {
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "block timestamping not enabled")
+		return
+	}
+	ts, ok := node.BlockTimestamps.Get(mux.Vars(r)["hash"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "no timestamp for block")
+		return
+	}
+	if r.URL.Query().Get("format") != "der" {
+		WriteSuccess(w, ts)
+		return
+	}
+	der, err := base64.StdEncoding.DecodeString(ts.Token)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "stored token is corrupt")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(der)
+}
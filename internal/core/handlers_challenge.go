@@ -0,0 +1,82 @@
+// Package core — handlers_challenge.go
+//
+// HTTP endpoints for challenge-response proof of quid control; see
+// quid_challenge.go for the protocol.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// maxChallengeAudienceLength bounds the audience a relying
+// application may attach to a challenge.
+const maxChallengeAudienceLength = 256
+
+// IssueQuidChallengeHandler issues a challenge for the quid in the
+// path. The optional body {"audience": "..."} names the relying
+// application.
+func (node *QuidnugNode) IssueQuidChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Audience string `json:"audience"`
+	}
+	if r.ContentLength > 0 {
+		if err := DecodeJSONBody(w, r, &req); err != nil {
+			return
+		}
+	}
+	if len(req.Audience) > maxChallengeAudienceLength {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "audience is too long")
+		return
+	}
+
+	challenge, err := node.IssueQuidChallenge(mux.Vars(r)["quidId"], req.Audience)
+	if err != nil {
+		if errors.Is(err, ErrQuidKeyUnknown) {
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue challenge")
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, challenge)
+}
+
+// VerifyQuidChallengeHandler checks a signed answer to a challenge.
+// The body is {"challengeId": "...", "signature": "<hex r||s>"}. A
+// signature that does not verify is a 401; either way the challenge
+// is used up.
+func (node *QuidnugNode) VerifyQuidChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeID string `json:"challengeId"`
+		Signature   string `json:"signature"`
+	}
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if req.ChallengeID == "" || req.Signature == "" {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "challengeId and signature are required")
+		return
+	}
+
+	challenge, err := node.VerifyQuidChallenge(mux.Vars(r)["quidId"], req.ChallengeID, req.Signature)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrChallengeNotFound), errors.Is(err, ErrQuidKeyUnknown):
+			WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case errors.Is(err, ErrChallengeSignature):
+			WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+		default:
+			WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify challenge")
+		}
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"verified":    true,
+		"quidId":      challenge.QuidID,
+		"audience":    challenge.Audience,
+		"challengeId": challenge.ChallengeID,
+	})
+}
@@ -0,0 +1,69 @@
+// Package core — handlers_checkpoint.go
+//
+// Endpoints for validator-quorum checkpoints; see checkpoint.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ListCheckpointsHandler lists the domain's committed checkpoints,
+// oldest first.
+func (node *QuidnugNode) ListCheckpointsHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{
+		"checkpoints": node.Checkpoints.List(mux.Vars(r)["name"]),
+	})
+}
+
+// GetLatestCheckpointHandler returns the domain's latest checkpoint
+// with the validator set that signed it, which is what a light
+// client needs to start syncing from the checkpoint instead of
+// genesis.
+func (node *QuidnugNode) GetLatestCheckpointHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	cp, ok := node.Checkpoints.Latest(name)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "no checkpoint for domain")
+		return
+	}
+	node.TrustDomainsMutex.RLock()
+	domain := node.TrustDomains[name]
+	node.TrustDomainsMutex.RUnlock()
+	_, _, required := checkpointApprovals(domain, cp)
+	WriteSuccess(w, map[string]interface{}{
+		"checkpoint":          cp,
+		"validators":          domain.Validators,
+		"validatorPublicKeys": domain.ValidatorPublicKeys,
+		"requiredWeight":      required,
+	})
+}
+
+// SubmitCheckpointSignaturesHandler accepts a checkpoint with the
+// signatures a validator has collected and merges them into the
+// pool.
+func (node *QuidnugNode) SubmitCheckpointSignaturesHandler(w http.ResponseWriter, r *http.Request) {
+	var tx CheckpointTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+	if tx.TrustDomain != mux.Vars(r)["name"] {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "trustDomain does not match the path")
+		return
+	}
+	status, err := node.SubmitCheckpointSignatures(tx)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCheckpointStale):
+			WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+		case errors.Is(err, ErrCheckpointPoolFull):
+			WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+		default:
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		}
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusAccepted, status)
+}
@@ -169,7 +169,7 @@ func (node *QuidnugNode) DiscoveryQuidsHandler(w http.ResponseWriter, r *http.Re
 	if observer != "" && IsValidQuidID(observer) {
 		trustWeights = make(map[string]float64, len(entries))
 		for _, s := range entries {
-			level, _, err := node.ComputeRelationalTrust(observer, s.QuidID, DefaultTrustMaxDepth)
+			level, _, err := node.ComputeRelationalTrust(r.Context(), observer, s.QuidID, DefaultTrustMaxDepth)
 			if err == nil && level > 0 {
 				trustWeights[s.QuidID] = level
 			}
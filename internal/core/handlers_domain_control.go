@@ -0,0 +1,81 @@
+// Package core — handlers_domain_control.go
+//
+// Endpoints for domain control validation; see domain_control.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDomainControlHandler returns the domain's current control
+// attestation, if any.
+func (node *QuidnugNode) GetDomainControlHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, node.DomainControlRegistry.Status(mux.Vars(r)["name"], time.Now()))
+}
+
+// CreateDomainControlChallengeHandler issues a challenge. Body:
+// {"controllerQuid": "...", "method": "dns-01" | "http-01"}.
+func (node *QuidnugNode) CreateDomainControlChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ControllerQuid string `json:"controllerQuid"`
+		Method         string `json:"method"`
+	}
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	ch, err := node.IssueDomainControlChallenge(mux.Vars(r)["name"], req.ControllerQuid, req.Method)
+	if err != nil {
+		writeDomainControlError(w, err)
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, ch)
+}
+
+// ValidateDomainControlChallengeHandler checks a challenge's proof
+// and, on success, returns the submitted attestation.
+func (node *QuidnugNode) ValidateDomainControlChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tx, err := node.ValidateDomainControlChallenge(r.Context(), vars["name"], vars["token"])
+	if err != nil {
+		writeDomainControlError(w, err)
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, tx)
+}
+
+// CreateDomainControlTransactionHandler is the peer relay for
+// validator-signed attestations.
+func (node *QuidnugNode) CreateDomainControlTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	var tx DomainControlTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+	txID, err := node.AddDomainControlTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":          txID,
+		"trustDomain": tx.TrustDomain,
+	})
+}
+
+func writeDomainControlError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrDomainControlNotValidator):
+		WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+	case errors.Is(err, ErrDomainControlNoChallenge):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, ErrDomainControlPoolFull):
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+	case errors.Is(err, ErrDomainControlUnproven):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	default:
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
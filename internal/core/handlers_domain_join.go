@@ -0,0 +1,110 @@
+// Package core — handlers_domain_join.go
+//
+// Endpoints for the validator join workflow; see domain_join.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// writeDomainJoinError maps join workflow errors onto HTTP statuses.
+func writeDomainJoinError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	case errors.Is(err, ErrDomainJoinNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, ErrDomainJoinNotValidator):
+		WriteError(w, http.StatusConflict, "CONFLICT", err.Error())
+	case errors.Is(err, ErrDomainJoinPoolFull):
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+	default:
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
+
+// RequestDomainJoinHandler starts a join of this node to the named
+// domain. The body is a DomainJoinRequest signed by the operator
+// key, with domain set to the path's name. The chain sync runs
+// within the request.
+func (node *QuidnugNode) RequestDomainJoinHandler(w http.ResponseWriter, r *http.Request) {
+	var req DomainJoinRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	req.Domain = mux.Vars(r)["name"]
+	status, err := node.RequestDomainJoin(r.Context(), req)
+	if err != nil {
+		writeDomainJoinError(w, err)
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusAccepted, status)
+}
+
+// ListDomainJoinRequestsHandler lists the join requests still
+// collecting approvals on this node.
+func (node *QuidnugNode) ListDomainJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{
+		"requests": node.ListDomainJoinRequests(mux.Vars(r)["name"]),
+	})
+}
+
+// SubmitDomainJoinRequestHandler accepts a candidate-signed
+// DomainJoinTransaction, from the candidate or from a validator
+// forwarding approvals, and merges it into the pool.
+func (node *QuidnugNode) SubmitDomainJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	var tx DomainJoinTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+	if tx.TrustDomain != mux.Vars(r)["name"] {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "trustDomain does not match the path")
+		return
+	}
+	status, err := node.SubmitDomainJoinRequest(tx)
+	if err != nil {
+		writeDomainJoinError(w, err)
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusAccepted, status)
+}
+
+// ApproveDomainJoinHandler signs a pooled request as this node's
+// validator. The body is a DomainJoinApprovalRequest signed by the
+// operator key, with domain and requestId set to the path's values.
+func (node *QuidnugNode) ApproveDomainJoinHandler(w http.ResponseWriter, r *http.Request) {
+	var req DomainJoinApprovalRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	vars := mux.Vars(r)
+	req.Domain = vars["name"]
+	req.RequestID = vars["id"]
+	status, err := node.ApproveDomainJoin(req)
+	if err != nil {
+		writeDomainJoinError(w, err)
+		return
+	}
+	WriteSuccess(w, status)
+}
+
+// CreateDomainJoinTransactionHandler admits a quorum-approved
+// DomainJoinTransaction relayed by a peer.
+func (node *QuidnugNode) CreateDomainJoinTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	var tx DomainJoinTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+	txID, err := node.AddDomainJoinTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":       txID,
+		"nodeQuid": tx.NodeQuid,
+	})
+}
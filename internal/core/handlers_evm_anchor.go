@@ -0,0 +1,54 @@
+// Package core — handlers_evm_anchor.go
+//
+// Endpoints for EVM anchors; see evm_anchor.go.
+package core
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListEVMAnchorsHandler lists the domain's anchors, oldest first,
+// pending and failed ones included.
+func (node *QuidnugNode) ListEVMAnchorsHandler(w http.ResponseWriter, r *http.Request) {
+	if node.EVMAnchors == nil {
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "EVM anchoring not enabled")
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"anchors": node.EVMAnchors.List(mux.Vars(r)["name"]),
+	})
+}
+
+// GetEVMAnchorProofHandler returns the confirmed anchor covering
+// ?height (the earliest at or above it), or the latest confirmed
+// anchor when height is omitted. domainHash is the bytes32 the
+// contract received for the domain name.
+func (node *QuidnugNode) GetEVMAnchorProofHandler(w http.ResponseWriter, r *http.Request) {
+	if node.EVMAnchors == nil {
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "EVM anchoring not enabled")
+		return
+	}
+	name := mux.Vars(r)["name"]
+	var height int64
+	if v := r.URL.Query().Get("height"); v != "" {
+		h, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || h < 0 {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "height must be a non-negative integer")
+			return
+		}
+		height = h
+	}
+	anchor, ok := node.EVMAnchors.Proof(name, height)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "no confirmed anchor covers that height")
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"anchor":     anchor,
+		"domainHash": "0x" + hex.EncodeToString(keccak256([]byte(name))),
+	})
+}
@@ -40,7 +40,7 @@ func (node *QuidnugNode) UpdateNodeDomainsHandler(w http.ResponseWriter, r *http
 	node.SupportedDomains = req.Domains
 
 	// Trigger gossip broadcast when domains change
-	go node.BroadcastDomainInfo()
+	go node.BroadcastDomainInfo(node.lifetime())
 
 	WriteSuccess(w, map[string]interface{}{
 		"nodeId":  node.NodeID,
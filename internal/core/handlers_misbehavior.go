@@ -0,0 +1,43 @@
+// Package core — handlers_misbehavior.go
+//
+// Submission and listing of validator misbehavior reports; see
+// misbehavior.go.
+package core
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateMisbehaviorReportHandler accepts a signed
+// MisbehaviorReportTransaction and queues it for block inclusion.
+func (node *QuidnugNode) CreateMisbehaviorReportHandler(w http.ResponseWriter, r *http.Request) {
+	var tx MisbehaviorReportTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.AddMisbehaviorReportTransaction(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"id":            txID,
+		"reporterQuid":  tx.ReporterQuid,
+		"validatorQuid": tx.ValidatorQuid,
+		"kind":          tx.Kind,
+	})
+}
+
+// GetMisbehaviorReportsHandler returns the accepted misbehavior
+// reports for a domain and the validators removed because of them.
+func (node *QuidnugNode) GetMisbehaviorReportsHandler(w http.ResponseWriter, r *http.Request) {
+	reports, removals := node.GetMisbehaviorReports(mux.Vars(r)["name"])
+	WriteSuccess(w, map[string]interface{}{
+		"reports":  reports,
+		"removals": removals,
+	})
+}
@@ -0,0 +1,89 @@
+// Package core — handlers_notarization.go
+//
+// Endpoints for document notarization; see notarization.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NotarizeHandler accepts a signed NOTARIZATION event and queues it
+// for block inclusion. The receipt is available from
+// /notarize/{id} once the event is committed.
+func (node *QuidnugNode) NotarizeHandler(w http.ResponseWriter, r *http.Request) {
+	var tx EventTransaction
+	if err := DecodeJSONBody(w, r, &tx); err != nil {
+		return
+	}
+
+	txID, err := node.Notarize(tx)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	WriteSuccessWithStatus(w, http.StatusAccepted, map[string]interface{}{
+		"id":     txID,
+		"status": "pending",
+	})
+}
+
+// GetNotarizationReceiptHandler returns the signed receipt for a
+// committed notarization, or 202 while it is still pending.
+func (node *QuidnugNode) GetNotarizationReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	receipt, err := node.NotarizationReceipt(r.Context(), id)
+	switch {
+	case errors.Is(err, ErrNotarizationPending):
+		WriteSuccessWithStatus(w, http.StatusAccepted, map[string]interface{}{
+			"id":     id,
+			"status": "pending",
+		})
+	case errors.Is(err, ErrNotarizationNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Notarization not found")
+	case err != nil:
+		WriteError(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+	default:
+		WriteSuccess(w, receipt)
+	}
+}
+
+// ListNotarizationsHandler lists the committed notarizations of a
+// document, oldest first. Requires ?documentHash=.
+func (node *QuidnugNode) ListNotarizationsHandler(w http.ResponseWriter, r *http.Request) {
+	documentHash := r.URL.Query().Get("documentHash")
+	if documentHash == "" {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "documentHash is required")
+		return
+	}
+	notarizations := node.NotarizationsForDocument(documentHash)
+	if notarizations == nil {
+		notarizations = []Notarization{}
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"documentHash":  documentHash,
+		"notarizations": notarizations,
+	})
+}
+
+// VerifyNotarizationRequest is the body of /notarize/verify.
+// DocumentHash is optional; when set it must match the receipt.
+type VerifyNotarizationRequest struct {
+	Receipt      NotarizationReceipt `json:"receipt"`
+	DocumentHash string              `json:"documentHash,omitempty"`
+}
+
+// VerifyNotarizationHandler checks a notarization receipt. A
+// receipt that fails verification is still a 200 with valid=false
+// and the reason.
+func (node *QuidnugNode) VerifyNotarizationHandler(w http.ResponseWriter, r *http.Request) {
+	var req VerifyNotarizationRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	WriteSuccess(w, node.VerifyNotarizationReceipt(r.Context(), req.Receipt, req.DocumentHash))
+}
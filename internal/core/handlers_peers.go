@@ -11,7 +11,11 @@
 //     score record exists (peer was admitted but no
 //     interactions have been recorded).
 //
-// Both endpoints are read-only and unauthenticated, matching
+//   GET /api/v1/peers/circuits
+//     Returns the outbound circuit breaker state for every peer
+//     host this node has called (peer_breaker.go).
+//
+// All three endpoints are read-only and unauthenticated, matching
 // the rest of the /api/v1 surface. Operators concerned about
 // fingerprint exposure should put the node behind an
 // authenticated reverse proxy.
@@ -58,3 +62,19 @@ func (node *QuidnugNode) GetPeerByQuidHandler(w http.ResponseWriter, r *http.Req
 	}
 	WriteSuccess(w, snap)
 }
+
+// GetPeerCircuitsHandler serves the outbound breaker states.
+func (node *QuidnugNode) GetPeerCircuitsHandler(w http.ResponseWriter, r *http.Request) {
+	if node.peerGuard == nil {
+		WriteSuccess(w, map[string]interface{}{
+			"circuits": []any{},
+			"note":     "circuit breakers disabled",
+		})
+		return
+	}
+	circuits := node.peerGuard.Status()
+	WriteSuccess(w, map[string]interface{}{
+		"circuits": circuits,
+		"count":    len(circuits),
+	})
+}
@@ -107,6 +107,7 @@ Full scoreboard: <a href="/api/v1/peers"><code>/api/v1/peers</code></a>.</p>
   <li><a href="/api/v1/nodes"><code>GET /api/v1/nodes</code></a> known peer nodes</li>
   <li><a href="/api/v1/blocks"><code>GET /api/v1/blocks</code></a> recent blocks</li>
   <li><a href="/metrics"><code>GET /metrics</code></a> Prometheus metrics</li>
+  <li><a href="/ui"><code>GET /ui</code></a> live operator dashboard</li>
 </ul>
 
 <h2>Quick start</h2>
@@ -222,7 +223,7 @@ func (node *QuidnugNode) RootHandler(w http.ResponseWriter, r *http.Request) {
 	if peerCount > 0 {
 		parts := make([]string, 0, len(sourceTally))
 		// Stable ordering for human readability.
-		for _, k := range []string{"static", "lan", "gossip", "connected", "unknown"} {
+		for _, k := range []string{"static", "manual", "lan", "gossip", "connected", "unknown"} {
 			if n, ok := sourceTally[k]; ok && n > 0 {
 				parts = append(parts, fmt.Sprintf("%d %s", n, k))
 			}
@@ -230,7 +231,7 @@ func (node *QuidnugNode) RootHandler(w http.ResponseWriter, r *http.Request) {
 		// Catch any sources we didn't enumerate above.
 		for k, n := range sourceTally {
 			switch k {
-			case "static", "lan", "gossip", "connected", "unknown":
+			case "static", "manual", "lan", "gossip", "connected", "unknown":
 				continue
 			}
 			if n > 0 {
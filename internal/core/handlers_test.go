@@ -229,6 +229,7 @@ func TestGetTrustHandler(t *testing.T) {
 		node.TrustRegistry["0000000000000001"] = make(map[string]float64)
 	}
 	node.TrustRegistry["0000000000000001"]["0000000000000002"] = 0.85
+	node.recordScopedTrustLevelLocked("0000000000000001", "0000000000000002", "test.domain.com", "", 0.85)
 	node.TrustRegistryMutex.Unlock()
 
 	t.Run("existing trust relationship", func(t *testing.T) {
@@ -337,13 +338,8 @@ func TestGetTrustHandler(t *testing.T) {
 	})
 
 	t.Run("maxDepth query parameter", func(t *testing.T) {
-		node.TrustRegistryMutex.Lock()
-		node.TrustRegistry["0000000000000001"]["000000000000001b"] = 0.9
-		if node.TrustRegistry["000000000000001b"] == nil {
-			node.TrustRegistry["000000000000001b"] = make(map[string]float64)
-		}
-		node.TrustRegistry["000000000000001b"]["000000000000001c"] = 0.9
-		node.TrustRegistryMutex.Unlock()
+		setTestTrust(node, "0000000000000001", "000000000000001b", 0.9)
+		setTestTrust(node, "000000000000001b", "000000000000001c", 0.9)
 
 		req := httptest.NewRequest("GET", "/api/trust/0000000000000001/000000000000001c?maxDepth=1", nil)
 		w := httptest.NewRecorder()
@@ -1906,6 +1902,7 @@ func TestRelationalTrustQueryHandler(t *testing.T) {
 		node.TrustRegistry["0000000000000014"] = make(map[string]float64)
 	}
 	node.TrustRegistry["0000000000000014"]["0000000000000015"] = 0.75
+	node.recordScopedTrustLevelLocked("0000000000000014", "0000000000000015", "test.domain", "", 0.75)
 	node.TrustRegistryMutex.Unlock()
 
 	t.Run("valid query with observer and target", func(t *testing.T) {
@@ -2005,16 +2002,8 @@ func TestRelationalTrustQueryHandler(t *testing.T) {
 	})
 
 	t.Run("query with maxDepth parameter", func(t *testing.T) {
-		node.TrustRegistryMutex.Lock()
-		if node.TrustRegistry["0000000000000016"] == nil {
-			node.TrustRegistry["0000000000000016"] = make(map[string]float64)
-		}
-		node.TrustRegistry["0000000000000016"]["0000000000000017"] = 0.8
-		if node.TrustRegistry["0000000000000017"] == nil {
-			node.TrustRegistry["0000000000000017"] = make(map[string]float64)
-		}
-		node.TrustRegistry["0000000000000017"]["0000000000000018"] = 0.8
-		node.TrustRegistryMutex.Unlock()
+		setTestTrust(node, "0000000000000016", "0000000000000017", 0.8)
+		setTestTrust(node, "0000000000000017", "0000000000000018", 0.8)
 
 		body := bytes.NewBufferString(`{"observer":"0000000000000016","target":"0000000000000018","maxDepth":1}`)
 		req := httptest.NewRequest("POST", "/api/trust/query", body)
@@ -2077,3 +2066,99 @@ func TestRelationalTrustQueryHandler(t *testing.T) {
 		}
 	})
 }
+
+// getCursorPage fetches url and decodes a cursor-mode listing.
+func getCursorPage(t *testing.T, router *mux.Router, url string) ([]json.RawMessage, CursorMeta) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d: %s", url, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Data       []json.RawMessage `json:"data"`
+			Pagination CursorMeta        `json:"pagination"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Data.Data, resp.Data.Pagination
+}
+
+func TestGetBlocksHandlerCursor(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	for i := 0; i < 4; i++ {
+		appendTestBlock(node, "test.domain.com")
+	}
+	anchor := node.chainHeight()
+
+	var seen []int64
+	url := "/api/v1/blocks?limit=2&cursor="
+	for {
+		page, meta := getCursorPage(t, router, url)
+		for _, raw := range page {
+			var b Block
+			json.Unmarshal(raw, &b)
+			seen = append(seen, b.Index)
+		}
+		// Blocks appended mid-scan are past the anchor.
+		appendTestBlock(node, "test.domain.com")
+		if meta.Height != anchor {
+			t.Fatalf("cursor lost its anchor: %d != %d", meta.Height, anchor)
+		}
+		if !meta.HasMore {
+			break
+		}
+		url = "/api/v1/blocks?limit=2&cursor=" + meta.NextCursor
+	}
+	if int64(len(seen)) != anchor+1 || seen[len(seen)-1] != anchor {
+		t.Errorf("expected blocks 0..%d exactly once, got %v", anchor, seen)
+	}
+}
+
+func TestQueryIdentityRegistryCursor(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	node.IdentityRegistryMutex.RLock()
+	want := len(node.IdentityRegistry)
+	node.IdentityRegistryMutex.RUnlock()
+
+	counts := make(map[string]int)
+	url := "/api/v1/registry/identity?limit=1&cursor="
+	for {
+		page, meta := getCursorPage(t, router, url)
+		for _, raw := range page {
+			var e struct {
+				QuidID string `json:"quid_id"`
+			}
+			json.Unmarshal(raw, &e)
+			counts[e.QuidID]++
+		}
+		// An insert that sorts before the cursor must not shift
+		// later pages.
+		node.IdentityRegistryMutex.Lock()
+		node.IdentityRegistry["0000000000000000"] = IdentityTransaction{QuidID: "0000000000000000"}
+		node.IdentityRegistryMutex.Unlock()
+		if !meta.HasMore {
+			break
+		}
+		url = "/api/v1/registry/identity?limit=1&cursor=" + meta.NextCursor
+	}
+	for id, n := range counts {
+		if n != 1 {
+			t.Errorf("%s returned %d times", id, n)
+		}
+	}
+	if len(counts) != want {
+		t.Errorf("expected %d identities, got %d", want, len(counts))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/registry/identity?cursor=not-a-cursor", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad cursor: expected 400, got %d", w.Code)
+	}
+}
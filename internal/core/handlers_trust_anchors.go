@@ -0,0 +1,60 @@
+// Package core — handlers_trust_anchors.go
+//
+// Anchored trust queries; see trust_anchors.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+)
+
+// GetTrustAnchorsHandler lists the anchors the node's own trust
+// decisions merge, the node itself first.
+func (node *QuidnugNode) GetTrustAnchorsHandler(w http.ResponseWriter, r *http.Request) {
+	anchors := append([]TrustAnchor{{Quid: node.NodeID, Weight: 1}}, node.TrustAnchors...)
+	WriteSuccess(w, map[string]interface{}{
+		"anchors":      anchors,
+		"callerWeight": node.TrustAnchorCallerWeight,
+	})
+}
+
+// AnchoredTrustHandler computes merged trust in a target. The body
+// is an AnchoredTrustRequest.
+func (node *QuidnugNode) AnchoredTrustHandler(w http.ResponseWriter, r *http.Request) {
+	var req AnchoredTrustRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if !IsValidQuidID(req.Target) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid target quid ID")
+		return
+	}
+	maxDepth := req.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+
+	var extra []TrustAnchor
+	if req.AsQuid != "" {
+		authenticated, _ := AuthenticatedQuid(r.Context())
+		anchor, err := node.callerAnchor(req, authenticated)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrAnchorProof), errors.Is(err, ErrChallengeNotFound),
+				errors.Is(err, ErrChallengeSignature), errors.Is(err, ErrQuidKeyUnknown):
+				WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+			default:
+				WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify challenge")
+			}
+			return
+		}
+		extra = append(extra, anchor)
+	}
+
+	result, err := node.ComputeAnchoredTrust(r.Context(), node.NodeID, req.Target, maxDepth, extra...)
+	if err != nil {
+		logger.Warn("Anchored trust computation exceeded resource limits", "target", req.Target, "error", err)
+		w.Header().Set("X-Trust-Computation-Warning", "resource limits exceeded, partial result returned")
+	}
+	WriteSuccess(w, result)
+}
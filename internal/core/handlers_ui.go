@@ -0,0 +1,265 @@
+package core
+
+import (
+	"net/http"
+)
+
+// dashboardPage is the operator dashboard served at /ui. It is a
+// static shell; dashboardScript fills it from the public JSON API,
+// so the dashboard shows exactly what any other client would see
+// and needs no server-side state of its own.
+const dashboardPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Quidnug node dashboard</title>
+<style>
+  :root { color-scheme: light dark; }
+  body { font-family: system-ui, -apple-system, "Segoe UI", Roboto, sans-serif; line-height: 1.4; max-width: 1100px; margin: 1.5rem auto; padding: 0 1rem; }
+  h1 { font-size: 1.4rem; margin-bottom: 0.25rem; }
+  h2 { font-size: 1.05rem; margin-top: 1.75rem; border-bottom: 1px solid #ccc8; padding-bottom: 0.25rem; }
+  .lede { color: #666; margin-top: 0; }
+  dl.facts { display: grid; grid-template-columns: max-content 1fr; gap: 0.3rem 1rem; }
+  dl.facts dt { font-weight: 600; color: #444; }
+  dl.facts dd { margin: 0; }
+  table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+  th, td { text-align: left; padding: 0.3rem 0.5rem; border-bottom: 1px solid #ccc6; vertical-align: top; }
+  .mono, td.mono, dl.facts dd { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; word-break: break-all; }
+  .tier { font-size: 0.75rem; padding: 0.05rem 0.4rem; border-radius: 3px; }
+  .tier-trusted { background: #2e7d3233; }
+  .tier-tentative { background: #f9a82533; }
+  .error { color: #c62828; }
+  form { display: flex; flex-wrap: wrap; gap: 0.5rem; align-items: end; }
+  label { display: flex; flex-direction: column; font-size: 0.8rem; color: #666; }
+  input { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; padding: 0.25rem; }
+  @media (prefers-color-scheme: dark) {
+    body { background: #1a1a1a; color: #e6e6e6; }
+    .lede, dl.facts dt, label { color: #aaa; }
+    h2 { border-color: #444; }
+    a { color: #8ab4f8; }
+  }
+</style>
+</head>
+<body>
+<h1>Quidnug node dashboard</h1>
+<p class="lede">Live view of this node from its <a href="/api/v1/info">JSON API</a>. Refreshes every 10 seconds. <span id="status"></span></p>
+
+<h2>Node</h2>
+<dl class="facts" id="node-info"></dl>
+
+<h2>Trust domains</h2>
+<table>
+  <thead><tr><th>Domain</th><th>Chain height</th><th>Blocks</th><th>Tentative</th><th>Mempool</th></tr></thead>
+  <tbody id="domains"></tbody>
+</table>
+<p>Mempool depth (all domains): <strong id="mempool">-</strong></p>
+
+<h2>Recent blocks</h2>
+<table>
+  <thead><tr><th>Tier</th><th>Domain</th><th>Index</th><th>Time</th><th>Txs</th><th>Hash</th></tr></thead>
+  <tbody id="blocks"></tbody>
+</table>
+
+<h2>Peers</h2>
+<table>
+  <thead><tr><th>Node</th><th>Address</th><th>Roles</th><th>Domains</th></tr></thead>
+  <tbody id="peers"></tbody>
+</table>
+
+<h2>Trust path</h2>
+<form id="trust-form">
+  <label>Observer quid<input name="observer" size="18" required pattern="[a-f0-9]{16}"></label>
+  <label>Target quid<input name="target" size="18" required pattern="[a-f0-9]{16}"></label>
+  <label>Domain<input name="domain" size="24" placeholder="default"></label>
+  <button type="submit">Query</button>
+</form>
+<div id="trust-result"></div>
+
+<script src="/ui/app.js"></script>
+</body>
+</html>
+`
+
+// dashboardScript drives dashboardPage. It only issues GETs against
+// /api/v1 and writes through textContent, never innerHTML, so data
+// from the chain cannot inject markup.
+const dashboardScript = `"use strict";
+(function () {
+  var API = "/api/v1";
+  var RECENT_BLOCKS = 20;
+
+  function get(path) {
+    return fetch(API + path, { headers: { Accept: "application/json" } })
+      .then(function (r) { return r.json(); })
+      .then(function (body) {
+        if (!body.success) { throw new Error((body.error && body.error.message) || "request failed"); }
+        return body.data;
+      });
+  }
+
+  function el(tag, text, cls) {
+    var e = document.createElement(tag);
+    if (text !== undefined && text !== null) { e.textContent = String(text); }
+    if (cls) { e.className = cls; }
+    return e;
+  }
+
+  function row(cells) {
+    var tr = document.createElement("tr");
+    cells.forEach(function (c) { tr.appendChild(c instanceof Node ? c : el("td", c)); });
+    return tr;
+  }
+
+  function replace(id, children) {
+    var target = document.getElementById(id);
+    while (target.firstChild) { target.removeChild(target.firstChild); }
+    children.forEach(function (c) { target.appendChild(c); });
+  }
+
+  function fact(list, name, value) {
+    list.push(el("dt", name));
+    list.push(el("dd", value === undefined || value === "" ? "-" : value));
+  }
+
+  function short(hash) { return hash ? hash.slice(0, 16) + "..." : "-"; }
+
+  function when(ts) {
+    if (!ts) { return "-"; }
+    // Block timestamps are Unix seconds or nanoseconds.
+    var ms = ts > 1e14 ? ts / 1e6 : ts * 1000;
+    return new Date(ms).toISOString().replace("T", " ").slice(0, 19);
+  }
+
+  function blockRow(b, tier) {
+    var tierCell = el("td");
+    tierCell.appendChild(el("span", tier, "tier tier-" + tier));
+    return row([
+      tierCell,
+      (b.trustProof && b.trustProof.trustDomain) || "-",
+      b.index,
+      when(b.timestamp),
+      (b.transactions || []).length,
+      el("td", short(b.hash), "mono")
+    ]);
+  }
+
+  function loadInfo() {
+    return get("/info").then(function (info) {
+      var facts = [];
+      fact(facts, "Node quid", info.nodeQuid);
+      fact(facts, "Version", info.version);
+      fact(facts, "Roles", (info.roles || [info.role]).join(", "));
+      fact(facts, "Block height", info.blockHeight);
+      if (info.upstream) { fact(facts, "Upstream", info.upstream); }
+      if (info.operatorQuid) { fact(facts, "Operator quid", info.operatorQuid.id); }
+      fact(facts, "Managed domains", (info.managedDomains || []).join(", "));
+      replace("node-info", facts);
+    });
+  }
+
+  function loadDomains() {
+    return get("/domains").then(function (data) {
+      var names = (data.domains || []).map(function (d) { return d.name; }).sort();
+      return Promise.all(names.map(function (name) {
+        var path = encodeURIComponent(name);
+        return Promise.all([
+          get("/domains/" + path + "/stats").catch(function () { return {}; }),
+          get("/blocks/tentative/" + path).catch(function () { return { blocks: [] }; })
+        ]).then(function (r) { return { name: name, stats: r[0], tentative: r[1].blocks || [] }; });
+      }));
+    }).then(function (domains) {
+      replace("domains", domains.map(function (d) {
+        return row([el("td", d.name, "mono"), d.stats.blockHeight, d.stats.blockCount,
+          d.stats.tentativeBlockCount, d.stats.mempoolDepth]);
+      }));
+      return domains;
+    });
+  }
+
+  function loadBlocks(domains) {
+    return get("/blocks?limit=1").then(function (first) {
+      var total = first.pagination.total;
+      var offset = Math.max(0, total - RECENT_BLOCKS);
+      return get("/blocks?limit=" + RECENT_BLOCKS + "&offset=" + offset);
+    }).then(function (page) {
+      var rows = [];
+      domains.forEach(function (d) {
+        d.tentative.forEach(function (b) { rows.push({ b: b, tier: "tentative" }); });
+      });
+      (page.data || []).slice().reverse().forEach(function (b) { rows.push({ b: b, tier: "trusted" }); });
+      replace("blocks", rows.map(function (r) { return blockRow(r.b, r.tier); }));
+    });
+  }
+
+  function loadMempool() {
+    return get("/transactions?limit=1").then(function (page) {
+      document.getElementById("mempool").textContent = page.pagination.total;
+    });
+  }
+
+  function loadPeers() {
+    return get("/nodes?limit=100").then(function (page) {
+      replace("peers", (page.data || []).map(function (n) {
+        return row([el("td", n.id, "mono"), el("td", n.address, "mono"),
+          (n.roles || ["validator"]).join(", "), (n.trustDomains || []).join(", ")]);
+      }));
+    });
+  }
+
+  function refresh() {
+    var status = document.getElementById("status");
+    Promise.all([loadInfo(), loadMempool(), loadPeers(), loadDomains().then(loadBlocks)])
+      .then(function () {
+        status.className = "";
+        status.textContent = "Updated " + new Date().toLocaleTimeString() + ".";
+      })
+      .catch(function (err) {
+        status.className = "error";
+        status.textContent = "Refresh failed: " + err.message;
+      });
+  }
+
+  document.getElementById("trust-form").addEventListener("submit", function (ev) {
+    ev.preventDefault();
+    var f = ev.target;
+    var path = "/trust/" + encodeURIComponent(f.observer.value) + "/" + encodeURIComponent(f.target.value);
+    if (f.domain.value) { path += "?domain=" + encodeURIComponent(f.domain.value); }
+    get(path).then(function (res) {
+      var facts = [];
+      fact(facts, "Trust level", res.trustLevel);
+      fact(facts, "Path", (res.trustPath || []).join(" -> ") || "no path");
+      fact(facts, "Depth", res.pathDepth);
+      fact(facts, "Domain", res.domain);
+      var dl = el("dl", null, "facts");
+      facts.forEach(function (c) { dl.appendChild(c); });
+      replace("trust-result", [dl]);
+    }).catch(function (err) {
+      replace("trust-result", [el("p", err.message, "error")]);
+    });
+  });
+
+  refresh();
+  setInterval(refresh, 10000);
+})();
+`
+
+// dashboardCSP keeps the dashboard to its own script and the
+// node's API.
+const dashboardCSP = "default-src 'none'; script-src 'self'; style-src 'unsafe-inline'; connect-src 'self'; img-src 'self'; base-uri 'none'; form-action 'self'"
+
+// DashboardHandler serves the operator dashboard at /ui.
+func (node *QuidnugNode) DashboardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", dashboardCSP)
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(dashboardPage))
+}
+
+// DashboardScriptHandler serves the dashboard's script.
+func (node *QuidnugNode) DashboardScriptHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(dashboardScript))
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDashboardHandlers(t *testing.T) {
+	node := newTestNode()
+	router := mux.NewRouter()
+	router.HandleFunc("/ui", node.DashboardHandler).Methods("GET")
+	router.HandleFunc("/ui/app.js", node.DashboardScriptHandler).Methods("GET")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("dashboard: %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `<script src="/ui/app.js">`) || rr.Header().Get("Content-Security-Policy") == "" {
+		t.Fatal("dashboard should load its script under a CSP")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/app.js", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/javascript") {
+		t.Fatalf("script: %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	// Every endpoint the dashboard reads must exist on the API.
+	api := setupTestRouter(node)
+	for _, path := range []string{"/info", "/domains", "/transactions?limit=1", "/nodes?limit=100", "/blocks?limit=1", "/domains/test.domain.com/stats", "/blocks/tentative/test.domain.com"} {
+		rr := httptest.NewRecorder()
+		api.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1"+path, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("GET %s: %d", path, rr.Code)
+		}
+	}
+	if strings.Contains(body, "innerHTML") {
+		t.Error("dashboard script must not write innerHTML")
+	}
+}
@@ -0,0 +1,90 @@
+// Package core — handlers_wallet.go
+//
+// HTTP surface of the optional custodial wallet. Every request
+// carries the quid's passphrase in its body; nothing here works
+// unless the node runs with wallet_enabled.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/internal/wallet"
+)
+
+// writeWalletError maps wallet errors onto HTTP statuses.
+func writeWalletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrWalletDisabled):
+		WriteError(w, http.StatusNotFound, "WALLET_DISABLED", err.Error())
+	case errors.Is(err, wallet.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, wallet.ErrPassphrase):
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+	default:
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
+
+// createCustodiedQuid is the passphrase branch of CreateQuidHandler.
+func (node *QuidnugNode) createCustodiedQuid(w http.ResponseWriter, passphrase string) {
+	entry, err := node.CreateCustodiedQuid(passphrase)
+	if err != nil {
+		if errors.Is(err, ErrWalletDisabled) || errors.Is(err, wallet.ErrWeakPassphrase) {
+			writeWalletError(w, err)
+			return
+		}
+		logger.Error("Failed to create custodied quid", "error", err)
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store key pair")
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"quidId":    entry.QuidID,
+		"publicKey": entry.PublicKey,
+		"created":   entry.Created,
+		"custodial": true,
+	})
+}
+
+// WalletSignHandler signs a transaction with a custodied quid's key.
+// The body is a WalletSignRequest; the response holds the signed
+// transaction, ready to POST to the matching /transactions endpoint.
+func (node *QuidnugNode) WalletSignHandler(w http.ResponseWriter, r *http.Request) {
+	var req WalletSignRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if len(req.Transaction) == 0 {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "transaction is required")
+		return
+	}
+	tx, err := node.SignWithCustodiedQuid(mux.Vars(r)["quidId"], req)
+	if err != nil {
+		writeWalletError(w, err)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"transaction": tx,
+	})
+}
+
+// WalletRemoveHandler deletes a custodied key. The body carries the
+// passphrase, which must unlock the key.
+func (node *QuidnugNode) WalletRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	quidID := mux.Vars(r)["quidId"]
+	if err := node.RemoveCustodiedQuid(quidID, req.Passphrase); err != nil {
+		writeWalletError(w, err)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"quidId":  quidID,
+		"removed": true,
+	})
+}
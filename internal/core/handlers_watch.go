@@ -0,0 +1,79 @@
+// Package core — handlers_watch.go
+//
+// Quid and asset watch lists; see watchlist.go.
+package core
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateWatchHandler registers a watch list, counted against the
+// caller's address. The response carries the webhook secret; it is
+// not shown again.
+func (node *QuidnugNode) CreateWatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req WatchRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	watch, secret, err := node.Watches.Add(getClientIP(r), req)
+	if errors.Is(err, ErrWatchLimit) || errors.Is(err, ErrWatchClientLimit) {
+		WriteError(w, http.StatusTooManyRequests, "TOO_MANY_WATCHES", err.Error())
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	WriteSuccessWithStatus(w, http.StatusCreated, map[string]interface{}{
+		"watch":  watch,
+		"secret": secret,
+	})
+}
+
+// GetWatchHandler returns a watch list.
+func (node *QuidnugNode) GetWatchHandler(w http.ResponseWriter, r *http.Request) {
+	watch, ok := node.Watches.Get(mux.Vars(r)["id"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Watch not found")
+		return
+	}
+	WriteSuccess(w, watch)
+}
+
+// RenewWatchHandler extends a watch list for another WatchTTL.
+func (node *QuidnugNode) RenewWatchHandler(w http.ResponseWriter, r *http.Request) {
+	watch, ok := node.Watches.Renew(mux.Vars(r)["id"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Watch not found")
+		return
+	}
+	WriteSuccess(w, watch)
+}
+
+// DeleteWatchHandler removes a watch list and stops its deliveries.
+func (node *QuidnugNode) DeleteWatchHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !node.Watches.Remove(id) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Watch not found")
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{"id": id, "deleted": true})
+}
+
+// StreamWatchHandler streams a watch's deliveries, historical then
+// live. Query params as for /blocks/stream: fromHeight, format.
+func (node *QuidnugNode) StreamWatchHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := node.Watches.entry(mux.Vars(r)["id"])
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Watch not found")
+		return
+	}
+	height, sse, ok := parseChainStreamParams(w, r)
+	if !ok {
+		return
+	}
+	node.streamWatch(w, r, e, height, sse)
+}
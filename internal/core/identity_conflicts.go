@@ -0,0 +1,232 @@
+// Identity update conflict detection.
+//
+// Identity updates are ordered by UpdateNonce. Two different updates
+// carrying the same nonce for the same quid (typically produced on
+// two branches, or submitted to two nodes at once) used to race:
+// both could pass validation while neither was committed, and
+// updateIdentityRegistry kept whichever was applied last. Now the
+// first one wins everywhere and the second is rejected:
+//
+//   - AddIdentityTransaction refuses an update whose nonce is
+//     already claimed by a different pending update;
+//   - ValidateBlockTiered rejects a block carrying a second update
+//     for a (quid, nonce) already claimed earlier in the block or
+//     on the chain;
+//   - updateIdentityRegistry refuses to overwrite a committed update
+//     with a different one of the same or a lower nonce (a guard for
+//     paths that commit without re-validating, such as accepting a
+//     quarantined block).
+//
+// Every rejection is recorded in a bounded, in-memory
+// IdentityConflictLog (lost on restart, like BlockQuarantine) that
+// operators list and acknowledge through the admin endpoints.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultIdentityConflictLogSize bounds the log. On overflow the
+// oldest conflict is evicted.
+const DefaultIdentityConflictLogSize = 512
+
+// Stages at which a conflict can be detected.
+const (
+	IdentityConflictStageMempool = "mempool"
+	IdentityConflictStageBlock   = "block"
+	IdentityConflictStageApply   = "apply"
+)
+
+var ErrIdentityConflictNotFound = errors.New("identity conflict: not found")
+
+// IdentityConflict is two identity updates competing for the same
+// (quid, UpdateNonce). Kept is the one in force; Rejected lost.
+type IdentityConflict struct {
+	ID           string              `json:"id"`
+	QuidID       string              `json:"quidId"`
+	UpdateNonce  int64               `json:"updateNonce"`
+	Kept         IdentityTransaction `json:"kept"`
+	Rejected     IdentityTransaction `json:"rejected"`
+	Stage        string              `json:"stage"`
+	BlockHash    string              `json:"blockHash,omitempty"`
+	BlockIndex   int64               `json:"blockIndex,omitempty"`
+	DetectedAt   int64               `json:"detectedAt"`
+	Acknowledged bool                `json:"acknowledged"`
+}
+
+// identityConflictID is stable for a (kept, rejected) pair so the
+// same conflict seen at several stages is logged once.
+func identityConflictID(kept, rejected IdentityTransaction) string {
+	sum := sha256.Sum256([]byte(kept.QuidID + "\x00" +
+		strconv.FormatInt(rejected.UpdateNonce, 10) + "\x00" +
+		kept.ID + "\x00" + rejected.ID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// IdentityConflictLog is a bounded FIFO of detected conflicts.
+// Owns its own lock.
+type IdentityConflictLog struct {
+	mu        sync.RWMutex
+	maxSize   int
+	order     []string
+	conflicts map[string]*IdentityConflict
+}
+
+// NewIdentityConflictLog constructs an empty log holding at most
+// maxSize conflicts. maxSize <= 0 selects the default.
+func NewIdentityConflictLog(maxSize int) *IdentityConflictLog {
+	if maxSize <= 0 {
+		maxSize = DefaultIdentityConflictLogSize
+	}
+	return &IdentityConflictLog{
+		maxSize:   maxSize,
+		conflicts: make(map[string]*IdentityConflict),
+	}
+}
+
+// add stores c unless a conflict with the same ID is already held.
+// Returns false for a duplicate.
+func (l *IdentityConflictLog) add(c IdentityConflict) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.conflicts[c.ID]; exists {
+		return false
+	}
+	for len(l.order) >= l.maxSize {
+		oldest := l.order[0]
+		l.order = l.order[1:]
+		delete(l.conflicts, oldest)
+	}
+	l.conflicts[c.ID] = &c
+	l.order = append(l.order, c.ID)
+	return true
+}
+
+// list returns conflicts newest first, optionally limited to one
+// quid and to unacknowledged entries.
+func (l *IdentityConflictLog) list(quidID string, includeAcknowledged bool) []IdentityConflict {
+	l.mu.RLock()
+	out := make([]IdentityConflict, 0, len(l.conflicts))
+	for _, c := range l.conflicts {
+		if quidID != "" && c.QuidID != quidID {
+			continue
+		}
+		if c.Acknowledged && !includeAcknowledged {
+			continue
+		}
+		out = append(out, *c)
+	}
+	l.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DetectedAt != out[j].DetectedAt {
+			return out[i].DetectedAt > out[j].DetectedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// acknowledge marks a conflict reviewed.
+func (l *IdentityConflictLog) acknowledge(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.conflicts[id]
+	if !ok {
+		return false
+	}
+	c.Acknowledged = true
+	return true
+}
+
+// recordIdentityConflict logs that rejected lost to kept.
+func (node *QuidnugNode) recordIdentityConflict(kept, rejected IdentityTransaction, stage string, block *Block) {
+	c := IdentityConflict{
+		ID:          identityConflictID(kept, rejected),
+		QuidID:      rejected.QuidID,
+		UpdateNonce: rejected.UpdateNonce,
+		Kept:        kept,
+		Rejected:    rejected,
+		Stage:       stage,
+		DetectedAt:  time.Now().Unix(),
+	}
+	if block != nil {
+		c.BlockHash = block.Hash
+		c.BlockIndex = block.Index
+	}
+	if node.IdentityConflicts == nil || !node.IdentityConflicts.add(c) {
+		return
+	}
+	identityConflictsTotal.WithLabelValues(stage).Inc()
+	logger.Warn("Identity update conflict",
+		"quidId", c.QuidID,
+		"updateNonce", c.UpdateNonce,
+		"keptTxId", kept.ID,
+		"rejectedTxId", rejected.ID,
+		"stage", stage)
+}
+
+// pendingIdentityConflict returns a pending update that already
+// claims tx's (quid, nonce) with a different transaction. Caller
+// holds PendingTxsMutex.
+func (node *QuidnugNode) pendingIdentityConflict(tx IdentityTransaction) (IdentityTransaction, bool) {
+	for _, pending := range node.PendingTxs {
+		other, ok := pending.(IdentityTransaction)
+		if !ok || other.QuidID != tx.QuidID || other.ID == tx.ID {
+			continue
+		}
+		if other.UpdateNonce == tx.UpdateNonce {
+			return other, true
+		}
+	}
+	return IdentityTransaction{}, false
+}
+
+// committedIdentityConflict reports whether tx claims the nonce of
+// the committed identity for its quid with a different transaction.
+func (node *QuidnugNode) committedIdentityConflict(tx IdentityTransaction) (IdentityTransaction, bool) {
+	node.IdentityRegistryMutex.RLock()
+	existing, exists := node.IdentityRegistry[tx.QuidID]
+	node.IdentityRegistryMutex.RUnlock()
+	if !exists || existing.ID == tx.ID || existing.UpdateNonce != tx.UpdateNonce {
+		return IdentityTransaction{}, false
+	}
+	return existing, true
+}
+
+// ListIdentityConflicts returns recorded conflicts, newest first.
+func (node *QuidnugNode) ListIdentityConflicts(quidID string, includeAcknowledged bool) []IdentityConflict {
+	if node.IdentityConflicts == nil {
+		return nil
+	}
+	return node.IdentityConflicts.list(quidID, includeAcknowledged)
+}
+
+// IdentityConflictAckRequest is the admin-signed body for
+// acknowledging a conflict. Signature covers the JSON encoding of
+// the request with Signature empty.
+type IdentityConflictAckRequest struct {
+	ConflictID string `json:"conflictId"`
+	Timestamp  int64  `json:"timestamp"`
+	PublicKey  string `json:"publicKey"`
+	Signature  string `json:"signature"`
+}
+
+// AcknowledgeIdentityConflict applies an admin-signed acknowledgement.
+func (node *QuidnugNode) AcknowledgeIdentityConflict(req IdentityConflictAckRequest) error {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return err
+	}
+	if node.IdentityConflicts == nil || !node.IdentityConflicts.acknowledge(req.ConflictID) {
+		return ErrIdentityConflictNotFound
+	}
+	logger.Info("Acknowledged identity conflict", "conflictId", req.ConflictID)
+	return nil
+}
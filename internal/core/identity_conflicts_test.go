@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// identityUpdate builds a signed update of the seeded identity
+// 0000000000000001 (creator 0000000000000006, UpdateNonce 1).
+func identityUpdate(node *QuidnugNode, id, name string, nonce int64) IdentityTransaction {
+	return signIdentityTx(node, IdentityTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          id,
+			Type:        TxTypeIdentity,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		QuidID:      "0000000000000001",
+		Name:        name,
+		Creator:     "0000000000000006",
+		UpdateNonce: nonce,
+	})
+}
+
+func TestIdentityConflict_MempoolRejectsSecondClaim(t *testing.T) {
+	node := newTestNode()
+	if _, err := node.AddIdentityTransaction(identityUpdate(node, "upd-a", "Alice", 2)); err != nil {
+		t.Fatalf("first update rejected: %v", err)
+	}
+	if _, err := node.AddIdentityTransaction(identityUpdate(node, "upd-b", "Mallory", 2)); err == nil {
+		t.Fatal("second update with the same nonce should be rejected")
+	}
+	if _, err := node.AddIdentityTransaction(identityUpdate(node, "upd-c", "Alice 2", 3)); err != nil {
+		t.Fatalf("next-nonce update rejected: %v", err)
+	}
+
+	conflicts := node.ListIdentityConflicts("0000000000000001", false)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d", len(conflicts))
+	}
+	c := conflicts[0]
+	if c.Stage != IdentityConflictStageMempool || c.Kept.ID != "upd-a" || c.Rejected.ID != "upd-b" || c.UpdateNonce != 2 {
+		t.Fatalf("unexpected conflict %+v", c)
+	}
+}
+
+func TestIdentityConflict_BlockValidationRejectsSecond(t *testing.T) {
+	node := newTestNode()
+	domain := node.TrustDomains["test.domain.com"]
+	domain.ValidatorPublicKeys = map[string]string{node.NodeID: node.GetPublicKeyHex()}
+	node.TrustDomains["test.domain.com"] = domain
+
+	block := Block{
+		Index:     1,
+		Timestamp: time.Now().Unix(),
+		Transactions: []interface{}{
+			identityUpdate(node, "upd-a", "Alice", 2),
+			identityUpdate(node, "upd-b", "Mallory", 2),
+		},
+		TrustProof: TrustProof{
+			TrustDomain:    "test.domain.com",
+			ValidatorID:    node.NodeID,
+			ValidationTime: time.Now().Unix(),
+		},
+		PrevHash: node.Blockchain[0].Hash,
+	}
+	signBlock(node, &block)
+
+	if got := node.ValidateBlockTiered(block); got != BlockInvalid {
+		t.Fatalf("expected BlockInvalid, got %v", got)
+	}
+	conflicts := node.ListIdentityConflicts("", false)
+	if len(conflicts) != 1 || conflicts[0].Stage != IdentityConflictStageBlock || conflicts[0].BlockHash != block.Hash {
+		t.Fatalf("expected one block-stage conflict, got %+v", conflicts)
+	}
+
+	// Dropping the second update makes the block acceptable.
+	block.Transactions = block.Transactions[:1]
+	signBlock(node, &block)
+	if got := node.ValidateBlockTiered(block); got != BlockTrusted {
+		t.Fatalf("expected BlockTrusted, got %v", got)
+	}
+}
+
+func TestIdentityConflict_ApplyDoesNotOverwrite(t *testing.T) {
+	node := newTestNode()
+	node.updateIdentityRegistry(identityUpdate(node, "upd-a", "Alice", 2))
+	node.updateIdentityRegistry(identityUpdate(node, "upd-b", "Mallory", 2))
+	node.updateIdentityRegistry(identityUpdate(node, "upd-old", "Stale", 1))
+
+	identity, _ := node.GetQuidIdentity("0000000000000001")
+	if identity.ID != "upd-a" {
+		t.Fatalf("expected first update to stay in force, got %s", identity.ID)
+	}
+	conflicts := node.ListIdentityConflicts("", false)
+	if len(conflicts) != 1 || conflicts[0].Stage != IdentityConflictStageApply {
+		t.Fatalf("expected one apply-stage conflict (stale update is not one), got %+v", conflicts)
+	}
+}
+
+func TestIdentityConflictHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	node.updateIdentityRegistry(identityUpdate(node, "upd-a", "Alice", 2))
+	node.updateIdentityRegistry(identityUpdate(node, "upd-b", "Mallory", 2))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/identity-conflicts?quidId=0000000000000001", nil))
+	var listResp struct {
+		Data struct {
+			Conflicts []IdentityConflict `json:"conflicts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listResp); err != nil || len(listResp.Data.Conflicts) != 1 {
+		t.Fatalf("list: %d %s", rr.Code, rr.Body.String())
+	}
+	id := listResp.Data.Conflicts[0].ID
+
+	req := IdentityConflictAckRequest{ConflictID: id, Timestamp: time.Now().Unix(), PublicKey: node.GetPublicKeyHex()}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+
+	stale := req
+	stale.Timestamp -= int64(2 * AdminRequestMaxSkew / time.Second)
+	body, _ := json.Marshal(stale)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/identity-conflicts/"+id+"/ack", bytes.NewReader(body)))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("stale ack: expected 403, got %d", rr.Code)
+	}
+
+	body, _ = json.Marshal(req)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/identity-conflicts/"+id+"/ack", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ack: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if open := node.ListIdentityConflicts("", false); len(open) != 0 {
+		t.Fatalf("acknowledged conflict still listed as open: %+v", open)
+	}
+	if all := node.ListIdentityConflicts("", true); len(all) != 1 || !all[0].Acknowledged {
+		t.Fatalf("expected acknowledged conflict with includeAcknowledged, got %+v", all)
+	}
+}
@@ -637,8 +637,8 @@ func TestMultiNodeTrustComputation(t *testing.T) {
 	}
 
 	// Compute trust A -> C on both nodes
-	trust0, path0, err0 := node0.ComputeRelationalTrust(quidA, quidC, 5)
-	trust1, path1, err1 := node1.ComputeRelationalTrust(quidA, quidC, 5)
+	trust0, path0, err0 := node0.ComputeRelationalTrust(context.Background(), quidA, quidC, 5)
+	trust1, path1, err1 := node1.ComputeRelationalTrust(context.Background(), quidA, quidC, 5)
 
 	if err0 != nil || err1 != nil {
 		t.Logf("Trust computation errors: node0=%v, node1=%v", err0, err1)
@@ -740,7 +740,7 @@ func TestConcurrentMultiNodeOperations(t *testing.T) {
 				default:
 					observer := fmt.Sprintf("%016x", (idx%10)+1)
 					target := fmt.Sprintf("%016x", ((idx+5)%10)+1)
-					n.ComputeRelationalTrust(observer, target, 3)
+					n.ComputeRelationalTrust(context.Background(), observer, target, 3)
 					time.Sleep(5 * time.Millisecond)
 				}
 			}
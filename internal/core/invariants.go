@@ -0,0 +1,309 @@
+// Registry invariant checks.
+//
+// The registries are derived state: every identity, title and trust
+// edge a node serves should follow from the blocks it holds. Bugs in
+// the apply path, a bad snapshot restore or hand-edited storage can
+// break that silently. VerifyInvariants re-derives what it can from
+// the chain and reports every place the two disagree, naming the
+// block involved so an operator can go straight to it. It is
+// read-only and safe to run against a live node.
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Invariant names, as reported in InvariantViolation.Invariant and
+// accepted by VerifyInvariants.
+const (
+	// InvariantChainLinkage: within each domain, every block follows
+	// its predecessor (Index+1, PrevHash) and hashes to its Hash.
+	InvariantChainLinkage = "chain_linkage"
+	// InvariantOwnershipTotal: every title's owner shares sum to
+	// 100% (1.0, or 100.0 in the legacy form).
+	InvariantOwnershipTotal = "ownership_total"
+	// InvariantIdentityNonce: successive IDENTITY transactions for a
+	// quid carry strictly increasing UpdateNonces in chain order.
+	InvariantIdentityNonce = "identity_nonce"
+	// InvariantTrustProvenance: every trust edge in the registry was
+	// written by a TRUST transaction in some block.
+	InvariantTrustProvenance = "trust_provenance"
+)
+
+// AllInvariants lists every check in the order they run.
+var AllInvariants = []string{
+	InvariantChainLinkage,
+	InvariantOwnershipTotal,
+	InvariantIdentityNonce,
+	InvariantTrustProvenance,
+}
+
+// InvariantViolation is one broken invariant. Block is the block
+// that introduced the violation, when one can be identified.
+type InvariantViolation struct {
+	Invariant string          `json:"invariant"`
+	Subject   string          `json:"subject"`
+	Detail    string          `json:"detail"`
+	Block     *SourceBlockRef `json:"block,omitempty"`
+}
+
+// InvariantReport is the outcome of VerifyInvariants.
+type InvariantReport struct {
+	CheckedAt int64 `json:"checkedAt"`
+	Blocks    int   `json:"blocks"`
+	// PrunedBlocks are header-only. Their transactions cannot be
+	// re-read, so trust edges they wrote count as Unverifiable
+	// rather than as violations.
+	PrunedBlocks int `json:"prunedBlocks"`
+	// Checked is how many items each invariant examined: blocks,
+	// titles, identity transactions, trust edges.
+	Checked      map[string]int       `json:"checked"`
+	Unverifiable int                  `json:"unverifiable"`
+	Violations   []InvariantViolation `json:"violations"`
+}
+
+// OK reports whether no invariant was violated.
+func (r *InvariantReport) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// VerifyInvariants runs the named invariant checks (all of them when
+// none are named) against the node's current state. Unknown names
+// are an error.
+func (node *QuidnugNode) VerifyInvariants(invariants ...string) (*InvariantReport, error) {
+	if len(invariants) == 0 {
+		invariants = AllInvariants
+	}
+	want := make(map[string]bool, len(invariants))
+	for _, name := range invariants {
+		known := false
+		for _, k := range AllInvariants {
+			known = known || k == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown invariant %q", name)
+		}
+		want[name] = true
+	}
+
+	// Registries are copied before the chain. A block is appended
+	// before its transactions are applied, so every registry entry
+	// in the copy has its block in the later chain snapshot; the
+	// reverse order could flag an edge written in between.
+	trust := node.snapshotTrustRegistry()
+	titles := node.snapshotTitleRegistry()
+
+	node.BlockchainMutex.RLock()
+	chain := make([]Block, len(node.Blockchain))
+	copy(chain, node.Blockchain)
+	node.BlockchainMutex.RUnlock()
+
+	report := &InvariantReport{
+		CheckedAt:  nowUnix(),
+		Blocks:     len(chain),
+		Checked:    make(map[string]int, len(want)),
+		Violations: []InvariantViolation{},
+	}
+	for _, b := range chain {
+		if b.Pruned {
+			report.PrunedBlocks++
+		}
+	}
+
+	if want[InvariantChainLinkage] {
+		checkChainLinkage(report, chain)
+	}
+	if want[InvariantOwnershipTotal] {
+		node.checkOwnershipTotals(report, titles)
+	}
+	if want[InvariantIdentityNonce] {
+		checkIdentityNonces(report, chain)
+	}
+	if want[InvariantTrustProvenance] {
+		node.checkTrustProvenance(report, chain, trust)
+	}
+	return report, nil
+}
+
+func (r *InvariantReport) violate(invariant, subject string, block *Block, format string, args ...interface{}) {
+	v := InvariantViolation{
+		Invariant: invariant,
+		Subject:   subject,
+		Detail:    fmt.Sprintf(format, args...),
+	}
+	if block != nil {
+		ref := blockRef(*block)
+		v.Block = &ref
+	}
+	r.Violations = append(r.Violations, v)
+}
+
+// checkChainLinkage applies the per-domain link rule ValidateBlock
+// enforces on receipt. The first block held for a domain anchors on
+// a chain this node never saw, so only its hash is checked. Pruned
+// headers keep their Hash but not the transactions it covers.
+func checkChainLinkage(r *InvariantReport, chain []Block) {
+	prev := make(map[string]*Block)
+	for i := range chain {
+		b := &chain[i]
+		r.Checked[InvariantChainLinkage]++
+		domain := b.TrustProof.TrustDomain
+		if p, ok := prev[domain]; ok {
+			if b.Index != p.Index+1 {
+				r.violate(InvariantChainLinkage, domain, b,
+					"index %d follows index %d", b.Index, p.Index)
+			}
+			if b.PrevHash != p.Hash {
+				r.violate(InvariantChainLinkage, domain, b,
+					"prevHash %s does not match hash %s of block %d", b.PrevHash, p.Hash, p.Index)
+			}
+		}
+		if !b.Pruned {
+			if h := calculateBlockHash(*b); h != b.Hash {
+				r.violate(InvariantChainLinkage, domain, b,
+					"content hashes to %s", h)
+			}
+		}
+		prev[domain] = b
+	}
+}
+
+func (node *QuidnugNode) checkOwnershipTotals(r *InvariantReport, titles map[string]TitleTransaction) {
+	assets := make([]string, 0, len(titles))
+	for id := range titles {
+		assets = append(assets, id)
+	}
+	sort.Strings(assets)
+
+	for _, id := range assets {
+		r.Checked[InvariantOwnershipTotal]++
+		total, ok := ownershipTotal(titles[id].Owners)
+		if ok {
+			continue
+		}
+		v := InvariantViolation{
+			Invariant: InvariantOwnershipTotal,
+			Subject:   id,
+			Detail:    fmt.Sprintf("owner shares sum to %g", total),
+			Block:     node.EntitySources.lookup(titleSourceKey(id)),
+		}
+		r.Violations = append(r.Violations, v)
+	}
+}
+
+// checkIdentityNonces walks IDENTITY transactions in chain order,
+// the order they were applied, so a violation names the block that
+// replayed or rewound a quid's nonce.
+func checkIdentityNonces(r *InvariantReport, chain []Block) {
+	last := make(map[string]int64)
+	for i := range chain {
+		b := &chain[i]
+		for _, raw := range blockTxsOfType(*b, TxTypeIdentity) {
+			var tx IdentityTransaction
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				continue
+			}
+			r.Checked[InvariantIdentityNonce]++
+			if prev, seen := last[tx.QuidID]; seen && tx.UpdateNonce <= prev {
+				r.violate(InvariantIdentityNonce, tx.QuidID, b,
+					"updateNonce %d does not exceed previous %d (tx %s)", tx.UpdateNonce, prev, tx.ID)
+			}
+			last[tx.QuidID] = tx.UpdateNonce
+		}
+	}
+}
+
+// checkTrustProvenance looks for each registry edge among the TRUST
+// transactions of the chain and of held tentative blocks, whose
+// edges are promoted once their validator becomes trusted. An
+// untraced edge from this node's own quid is local configuration
+// (validator peering) rather than chain state, so it is not flagged.
+func (node *QuidnugNode) checkTrustProvenance(r *InvariantReport, chain []Block, trust map[string]map[string]float64) {
+	node.TentativeBlocksMutex.RLock()
+	for _, held := range node.TentativeBlocks {
+		chain = append(chain, held...)
+	}
+	node.TentativeBlocksMutex.RUnlock()
+
+	written := make(map[[2]string]bool)
+	for _, b := range chain {
+		for _, raw := range blockTxsOfType(b, TxTypeTrust) {
+			var tx TrustTransaction
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				continue
+			}
+			written[[2]string{tx.Truster, tx.Trustee}] = true
+		}
+	}
+
+	trusters := make([]string, 0, len(trust))
+	for t := range trust {
+		trusters = append(trusters, t)
+	}
+	sort.Strings(trusters)
+
+	for _, truster := range trusters {
+		trustees := make([]string, 0, len(trust[truster]))
+		for t := range trust[truster] {
+			trustees = append(trustees, t)
+		}
+		sort.Strings(trustees)
+		for _, trustee := range trustees {
+			r.Checked[InvariantTrustProvenance]++
+			if written[[2]string{truster, trustee}] || truster == node.NodeID {
+				continue
+			}
+			if r.PrunedBlocks > 0 {
+				r.Unverifiable++
+				continue
+			}
+			r.violate(InvariantTrustProvenance, truster+" -> "+trustee, nil,
+				"trust level %g has no TRUST transaction in any block", trust[truster][trustee])
+		}
+	}
+}
+
+// blockTxsOfType returns the JSON of each transaction in block of
+// txType. Locally sealed blocks hold typed transactions and received
+// ones hold decoded maps; both re-marshal to the same wire form.
+func blockTxsOfType(block Block, txType TransactionType) [][]byte {
+	var out [][]byte
+	for _, txInterface := range block.Transactions {
+		raw, err := json.Marshal(txInterface)
+		if err != nil {
+			continue
+		}
+		var base BaseTransaction
+		if err := json.Unmarshal(raw, &base); err != nil || base.Type != txType {
+			continue
+		}
+		out = append(out, raw)
+	}
+	return out
+}
+
+func (node *QuidnugNode) snapshotTrustRegistry() map[string]map[string]float64 {
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+	out := make(map[string]map[string]float64, len(node.TrustRegistry))
+	for truster, edges := range node.TrustRegistry {
+		m := make(map[string]float64, len(edges))
+		for trustee, level := range edges {
+			m[trustee] = level
+		}
+		out[truster] = m
+	}
+	return out
+}
+
+func (node *QuidnugNode) snapshotTitleRegistry() map[string]TitleTransaction {
+	node.TitleRegistryMutex.RLock()
+	defer node.TitleRegistryMutex.RUnlock()
+	out := make(map[string]TitleTransaction, len(node.TitleRegistry))
+	for id, title := range node.TitleRegistry {
+		out[id] = title
+	}
+	return out
+}
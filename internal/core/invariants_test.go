@@ -0,0 +1,193 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func violationsOf(r *InvariantReport, invariant string) []InvariantViolation {
+	var out []InvariantViolation
+	for _, v := range r.Violations {
+		if v.Invariant == invariant {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func TestVerifyInvariants_RecordedChainIsClean(t *testing.T) {
+	producer, _, _ := recordReplayChain(t)
+
+	report, err := producer.VerifyInvariants()
+	if err != nil {
+		t.Fatalf("VerifyInvariants: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("clean chain reported violations: %+v", report.Violations)
+	}
+	for _, name := range []string{InvariantChainLinkage, InvariantIdentityNonce, InvariantTrustProvenance} {
+		if report.Checked[name] == 0 {
+			t.Errorf("%s examined nothing", name)
+		}
+	}
+}
+
+func TestVerifyInvariants_BrokenLinkNamesBlock(t *testing.T) {
+	producer, _, blocks := recordReplayChain(t)
+
+	tampered := blocks[len(blocks)-1]
+	producer.BlockchainMutex.Lock()
+	last := &producer.Blockchain[len(producer.Blockchain)-1]
+	last.PrevHash = "deadbeef"
+	last.Hash = calculateBlockHash(*last)
+	producer.BlockchainMutex.Unlock()
+
+	report, _ := producer.VerifyInvariants(InvariantChainLinkage)
+	got := violationsOf(report, InvariantChainLinkage)
+	if len(got) != 1 {
+		t.Fatalf("want 1 linkage violation, got %+v", report.Violations)
+	}
+	if got[0].Block == nil || got[0].Block.Index != tampered.Index {
+		t.Errorf("violation should reference block %d: %+v", tampered.Index, got[0].Block)
+	}
+}
+
+func TestVerifyInvariants_TamperedContentFailsHash(t *testing.T) {
+	producer, _, _ := recordReplayChain(t)
+
+	producer.BlockchainMutex.Lock()
+	producer.Blockchain[len(producer.Blockchain)-1].Timestamp++
+	producer.BlockchainMutex.Unlock()
+
+	report, _ := producer.VerifyInvariants(InvariantChainLinkage)
+	if len(violationsOf(report, InvariantChainLinkage)) != 1 {
+		t.Fatalf("want 1 hash violation, got %+v", report.Violations)
+	}
+}
+
+func TestVerifyInvariants_OwnershipTotal(t *testing.T) {
+	node := newTestNode()
+	node.TitleRegistry["asset-ok"] = TitleTransaction{AssetID: "asset-ok", Owners: []OwnershipStake{
+		{OwnerID: "a", Percentage: 0.25}, {OwnerID: "b", Percentage: 0.75},
+	}}
+	node.TitleRegistry["asset-legacy"] = TitleTransaction{AssetID: "asset-legacy", Owners: []OwnershipStake{
+		{OwnerID: "a", Percentage: 100},
+	}}
+	node.TitleRegistry["asset-short"] = TitleTransaction{AssetID: "asset-short", Owners: []OwnershipStake{
+		{OwnerID: "a", Percentage: 0.5},
+	}}
+
+	report, _ := node.VerifyInvariants(InvariantOwnershipTotal)
+	got := violationsOf(report, InvariantOwnershipTotal)
+	if len(got) != 1 || got[0].Subject != "asset-short" {
+		t.Fatalf("want only asset-short flagged, got %+v", report.Violations)
+	}
+	if report.Checked[InvariantOwnershipTotal] != len(node.TitleRegistry) {
+		t.Errorf("checked %d titles, want %d", report.Checked[InvariantOwnershipTotal], len(node.TitleRegistry))
+	}
+}
+
+func TestVerifyInvariants_RepeatedIdentityNonce(t *testing.T) {
+	producer, other, _ := recordReplayChain(t)
+
+	producer.BlockchainMutex.Lock()
+	prev := producer.Blockchain[len(producer.Blockchain)-1]
+	replayed := Block{
+		Index:     prev.Index + 1,
+		Timestamp: prev.Timestamp,
+		Transactions: []interface{}{signIdentityTx(other, IdentityTransaction{
+			BaseTransaction: BaseTransaction{ID: "id-again", Type: TxTypeIdentity, TrustDomain: prev.TrustProof.TrustDomain},
+			QuidID:          other.NodeID,
+			Name:            "renamed",
+			Creator:         other.NodeID,
+			UpdateNonce:     1,
+		})},
+		TrustProof: prev.TrustProof,
+		PrevHash:   prev.Hash,
+	}
+	replayed.Hash = calculateBlockHash(replayed)
+	producer.Blockchain = append(producer.Blockchain, replayed)
+	producer.BlockchainMutex.Unlock()
+
+	report, _ := producer.VerifyInvariants()
+	got := violationsOf(report, InvariantIdentityNonce)
+	if len(got) != 1 || got[0].Subject != other.NodeID {
+		t.Fatalf("want one nonce violation for %s, got %+v", other.NodeID, report.Violations)
+	}
+	if got[0].Block == nil || got[0].Block.Hash != replayed.Hash {
+		t.Errorf("violation should reference the replaying block: %+v", got[0].Block)
+	}
+	if len(report.Violations) != 1 {
+		t.Errorf("appended block should otherwise be consistent: %+v", report.Violations)
+	}
+}
+
+func TestVerifyInvariants_UntracedTrustEdge(t *testing.T) {
+	producer, other, _ := recordReplayChain(t)
+
+	producer.TrustRegistryMutex.Lock()
+	producer.TrustRegistry[other.NodeID] = map[string]float64{"0000000000000042": 0.9}
+	producer.TrustRegistryMutex.Unlock()
+
+	report, _ := producer.VerifyInvariants(InvariantTrustProvenance)
+	got := violationsOf(report, InvariantTrustProvenance)
+	if len(got) != 1 || got[0].Subject != other.NodeID+" -> 0000000000000042" {
+		t.Fatalf("want the injected edge flagged, got %+v", report.Violations)
+	}
+
+	// Once history is pruned the edge may have come from a block
+	// whose transactions are gone.
+	producer.BlockchainMutex.Lock()
+	producer.Blockchain[1].Pruned = true
+	producer.BlockchainMutex.Unlock()
+	report, _ = producer.VerifyInvariants(InvariantTrustProvenance)
+	if !report.OK() || report.Unverifiable != 1 {
+		t.Fatalf("pruned chain: want 1 unverifiable and no violations, got %d / %+v", report.Unverifiable, report.Violations)
+	}
+}
+
+func TestVerifyInvariants_UnknownInvariant(t *testing.T) {
+	if _, err := newTestNode().VerifyInvariants("bogus"); err == nil {
+		t.Fatal("want error for unknown invariant")
+	}
+}
+
+func TestVerifyInvariantsHandler(t *testing.T) {
+	node := newTestNode()
+	node.TitleRegistry["asset-short"] = TitleTransaction{AssetID: "asset-short", Owners: []OwnershipStake{
+		{OwnerID: "a", Percentage: 0.5},
+	}}
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/verify?check=ownership_total", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("unsigned request: status %d, want 403", rr.Code)
+	}
+
+	req = adminGet(t, node, "/api/v1/admin/verify?check=ownership_total", "")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Data InvariantReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data.Violations) != 1 || body.Data.Checked[InvariantChainLinkage] != 0 {
+		t.Errorf("unexpected report: %+v", body.Data)
+	}
+
+	req = adminGet(t, node, "/api/v1/admin/verify?check=bogus", "")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown check: status %d, want 400", rr.Code)
+	}
+}
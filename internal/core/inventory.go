@@ -0,0 +1,460 @@
+// Package core — inventory.go
+//
+// Inventory-based relay (inv/getdata).
+//
+// BroadcastTransaction used to POST every transaction body to every
+// validator of its domain, and a trusted block only reached peers
+// when their next block-sync pass pulled it. Both now go out as
+// inventory: the node queues (kind, ID) pairs per domain and, after
+// invFlushDelay or once invBatchMax are queued, POSTs them in one
+// announcement to each validator of the domain
+// (POST /api/v1/inv). The receiver drops what it already holds or
+// is already fetching and asks the announcer for the rest in one
+// batched request (POST /api/v1/getdata). Fetched transactions go
+// through the usual Add*Transaction admission, fetched blocks
+// through ReceiveBlock; both are then announced onward, so relay
+// works hop by hop while each body crosses each link at most once.
+//
+// A peer that answers the announcement with 404 either predates
+// inventory relay or doesn't know this node well enough to fetch
+// from it; it gets the queued transaction bodies pushed the old
+// way, and picks blocks up through block sync as before.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Inventory item kinds.
+const (
+	InvKindTx    = "tx"
+	InvKindBlock = "block"
+)
+
+const (
+	// invBatchMax caps the items in one announcement or getdata
+	// request; reaching it flushes the queue at once.
+	invBatchMax = 256
+
+	// invFlushDelay is how long announcements queue before going
+	// out, so a burst of transactions shares one request per peer.
+	invFlushDelay = 50 * time.Millisecond
+
+	// invFetchRetention is how long an item being fetched from one
+	// announcer is not requested from another.
+	invFetchRetention = 30 * time.Second
+)
+
+// InvItem names one transaction or block by ID (blocks by hash).
+type InvItem struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// InvAnnouncement is the body of POST /inv.
+type InvAnnouncement struct {
+	NodeQuid string    `json:"nodeQuid"`
+	Domain   string    `json:"domain"`
+	Items    []InvItem `json:"items"`
+}
+
+// GetDataRequest is the body of POST /getdata.
+type GetDataRequest struct {
+	Domain string    `json:"domain"`
+	Items  []InvItem `json:"items"`
+}
+
+// GetDataResponse carries the requested items this node still
+// holds. Blocks come in chain order.
+type GetDataResponse struct {
+	Domain       string      `json:"domain"`
+	Transactions []MempoolTx `json:"transactions"`
+	Blocks       []Block     `json:"blocks"`
+}
+
+// invEntry is a queued announcement. Transactions keep their body
+// and route for peers that need the direct push.
+type invEntry struct {
+	item   InvItem
+	txType string
+	body   []byte
+}
+
+// inventoryRelay holds the outgoing announcement queue and the
+// items currently being fetched.
+type inventoryRelay struct {
+	mu       sync.Mutex
+	outbox   map[string][]invEntry
+	queued   int
+	flushing bool
+	fetching map[string]time.Time
+}
+
+func newInventoryRelay() *inventoryRelay {
+	return &inventoryRelay{
+		outbox:   make(map[string][]invEntry),
+		fetching: make(map[string]time.Time),
+	}
+}
+
+// takeLocked empties the outbox and returns what was in it.
+func (r *inventoryRelay) takeLocked() map[string][]invEntry {
+	batch := r.outbox
+	r.outbox = make(map[string][]invEntry)
+	r.queued = 0
+	return batch
+}
+
+// claimFetch marks the unclaimed items as being fetched and returns
+// them.
+func (r *inventoryRelay) claimFetch(items []InvItem, now time.Time) []InvItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, at := range r.fetching {
+		if now.Sub(at) > invFetchRetention {
+			delete(r.fetching, key)
+		}
+	}
+	claimed := make([]InvItem, 0, len(items))
+	for _, it := range items {
+		key := it.Kind + ":" + it.ID
+		if _, busy := r.fetching[key]; busy {
+			continue
+		}
+		r.fetching[key] = now
+		claimed = append(claimed, it)
+	}
+	return claimed
+}
+
+// releaseFetch lets items be fetched again, e.g. from another
+// announcer after this one failed to deliver them.
+func (r *inventoryRelay) releaseFetch(items []InvItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, it := range items {
+		delete(r.fetching, it.Kind+":"+it.ID)
+	}
+}
+
+// queueInventory adds an announcement for domain, scheduling a
+// flush. Nodes built without a relay push transactions directly.
+func (node *QuidnugNode) queueInventory(domain string, e invEntry) {
+	r := node.inv
+	if r == nil {
+		if e.item.Kind == InvKindTx {
+			for _, peer := range node.inventoryPeers(domain) {
+				go node.broadcastToNode(node.lifetime(), peer, e.txType, e.body)
+			}
+		}
+		return
+	}
+	r.mu.Lock()
+	r.outbox[domain] = append(r.outbox[domain], e)
+	r.queued++
+	if r.queued >= invBatchMax {
+		batch := r.takeLocked()
+		r.mu.Unlock()
+		go node.flushInventory(batch)
+		return
+	}
+	if !r.flushing {
+		r.flushing = true
+		time.AfterFunc(invFlushDelay, func() {
+			r.mu.Lock()
+			batch := r.takeLocked()
+			r.flushing = false
+			r.mu.Unlock()
+			node.flushInventory(batch)
+		})
+	}
+	r.mu.Unlock()
+}
+
+// announceBlockInventory queues a trusted block's hash for its
+// domain's validators.
+func (node *QuidnugNode) announceBlockInventory(block Block) {
+	if block.Hash == "" {
+		return
+	}
+	node.queueInventory(block.TrustProof.TrustDomain, invEntry{item: InvItem{Kind: InvKindBlock, ID: block.Hash}})
+}
+
+// inventoryPeers returns the validators of domain worth announcing
+// to.
+func (node *QuidnugNode) inventoryPeers(domain string) []Node {
+	var peers []Node
+	for _, n := range node.GetTrustDomainNodes(domain) {
+		if n.ID == node.NodeID || node.peerBanned(n.ID) {
+			continue
+		}
+		peers = append(peers, n)
+	}
+	return peers
+}
+
+// flushInventory sends each domain's queued items to its peers in
+// batches of invBatchMax.
+func (node *QuidnugNode) flushInventory(batch map[string][]invEntry) {
+	for domain, entries := range batch {
+		peers := node.inventoryPeers(domain)
+		if len(peers) == 0 {
+			continue
+		}
+		for start := 0; start < len(entries); start += invBatchMax {
+			end := start + invBatchMax
+			if end > len(entries) {
+				end = len(entries)
+			}
+			chunk := entries[start:end]
+			for _, peer := range peers {
+				go node.sendInventory(peer, domain, chunk)
+			}
+		}
+	}
+}
+
+// sendInventory announces entries to one peer, falling back to
+// pushing the transaction bodies when the peer answers 404.
+func (node *QuidnugNode) sendInventory(peer Node, domain string, entries []invEntry) {
+	safeAddr, err := node.validatePeerAddress(peer.Address)
+	if err != nil {
+		logger.Warn("Refusing inventory announcement to invalid peer address",
+			"targetNodeId", peer.ID, "targetAddress", peer.Address, "error", err)
+		return
+	}
+	ann := InvAnnouncement{NodeQuid: node.NodeID, Domain: domain, Items: make([]InvItem, len(entries))}
+	for i, e := range entries {
+		ann.Items[i] = e.item
+	}
+	body, err := json.Marshal(ann)
+	if err != nil {
+		logger.Error("Failed to marshal inventory announcement", "error", err)
+		return
+	}
+
+	path := "/api/v1/inv"
+	req, err := http.NewRequestWithContext(node.lifetime(), "POST", "http://"+safeAddr.String()+path, bytes.NewReader(body)) // #nosec -- URL built from sanitized address
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := GetNodeAuthSecret(); secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(NodeSignatureHeader, SignRequest("POST", path, body, secret, timestamp))
+		req.Header.Set(NodeTimestampHeader, strconv.FormatInt(timestamp, 10))
+	}
+	resp, err := node.httpClient.Do(req) // #nosec -- request URL built from sanitized address; transport also enforces safeDialContext
+	if err != nil {
+		logger.Debug("Failed to announce inventory", "targetNodeId", peer.ID, "error", err)
+		node.recordPeerScore(peer.ID, EventClassBroadcast, false, "inv dial: "+err.Error())
+		return
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		for _, e := range entries {
+			if e.item.Kind == InvKindTx && e.body != nil {
+				node.broadcastToNode(node.lifetime(), peer, e.txType, e.body)
+			}
+		}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		node.recordPeerScore(peer.ID, EventClassBroadcast, true, "")
+	default:
+		node.recordPeerScore(peer.ID, EventClassBroadcast, false, fmt.Sprintf("inv status %d", resp.StatusCode))
+	}
+}
+
+// missingInventory returns the announced items this node holds
+// neither pending nor on chain.
+func (node *QuidnugNode) missingInventory(domain string, items []InvItem) []InvItem {
+	var haveTx, haveBlock map[string]bool
+	missing := make([]InvItem, 0, len(items))
+	for _, it := range items {
+		if it.ID == "" {
+			continue
+		}
+		switch it.Kind {
+		case InvKindTx:
+			if haveTx == nil {
+				haveTx = node.recentlySealedTxIDs(domain)
+				for _, id := range node.pendingTxIDs(domain) {
+					haveTx[id] = true
+				}
+			}
+			if haveTx[it.ID] {
+				continue
+			}
+		case InvKindBlock:
+			if haveBlock == nil {
+				haveBlock = node.blockHashes()
+			}
+			if haveBlock[it.ID] {
+				continue
+			}
+		default:
+			continue
+		}
+		missing = append(missing, it)
+	}
+	return missing
+}
+
+// blockHashes returns the hashes on the local chain.
+func (node *QuidnugNode) blockHashes() map[string]bool {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	hashes := make(map[string]bool, len(node.Blockchain))
+	for _, b := range node.Blockchain {
+		hashes[b.Hash] = true
+	}
+	return hashes
+}
+
+// fetchInventory requests the missing announced items from the
+// announcer and applies what comes back.
+func (node *QuidnugNode) fetchInventory(peer Node, ann InvAnnouncement) {
+	items := node.missingInventory(ann.Domain, ann.Items)
+	if node.inv != nil {
+		items = node.inv.claimFetch(items, time.Now())
+	}
+	if len(items) == 0 {
+		return
+	}
+	var resp GetDataResponse
+	err := node.peerJSONRequest(context.Background(), peer, "POST", "/api/v1/getdata",
+		GetDataRequest{Domain: ann.Domain, Items: items}, &resp)
+	if err != nil {
+		if node.inv != nil {
+			node.inv.releaseFetch(items)
+		}
+		node.recordPeerScore(peer.ID, EventClassQuery, false, "getdata: "+err.Error())
+		logger.Debug("Inventory fetch failed", "nodeQuid", peer.ID, "domain", ann.Domain, "error", err)
+		return
+	}
+	node.recordPeerScore(peer.ID, EventClassQuery, true, "")
+
+	wanted := make(map[string]bool, len(items))
+	for _, it := range items {
+		wanted[it.Kind+":"+it.ID] = true
+	}
+	for _, b := range resp.Blocks {
+		if !wanted[InvKindBlock+":"+b.Hash] || b.TrustProof.TrustDomain != ann.Domain {
+			continue
+		}
+		acceptance, err := node.ReceiveBlock(b)
+		if err != nil {
+			note := fmt.Sprintf("announced block %d rejected: %v", b.Index, err)
+			if acceptance == BlockInvalid {
+				node.reportPeerOffense(peer.ID, PeerOffenseInvalidBlock, note)
+			} else {
+				node.recordPeerScore(peer.ID, EventClassValidation, false, note)
+			}
+		}
+	}
+	for _, mt := range resp.Transactions {
+		var base BaseTransaction
+		if err := json.Unmarshal(mt.Tx, &base); err != nil || !wanted[InvKindTx+":"+base.ID] || pendingTxDomain(base) != ann.Domain {
+			continue
+		}
+		if _, err := node.admitSyncedTransaction(mt.Type, mt.Tx); err != nil {
+			logger.Debug("Announced transaction not admitted",
+				"domain", ann.Domain, "txId", base.ID, "type", mt.Type, "error", err)
+		}
+	}
+}
+
+// getData returns the listed items this node holds for domain.
+func (node *QuidnugNode) getData(domain string, items []InvItem) (GetDataResponse, error) {
+	out := GetDataResponse{Domain: domain, Transactions: []MempoolTx{}, Blocks: []Block{}}
+	var txIDs []string
+	blockHashes := make(map[string]bool)
+	for _, it := range items {
+		switch it.Kind {
+		case InvKindTx:
+			txIDs = append(txIDs, it.ID)
+		case InvKindBlock:
+			blockHashes[it.ID] = true
+		}
+	}
+	if len(txIDs) > 0 {
+		txs, err := node.pendingTxsByID(domain, txIDs)
+		if err != nil {
+			return out, err
+		}
+		out.Transactions = txs
+	}
+	if len(blockHashes) > 0 {
+		node.BlockchainMutex.RLock()
+		for _, b := range node.Blockchain {
+			if blockHashes[b.Hash] && !b.Pruned && b.TrustProof.TrustDomain == domain {
+				out.Blocks = append(out.Blocks, b)
+			}
+		}
+		node.BlockchainMutex.RUnlock()
+	}
+	return out, nil
+}
+
+// ReceiveInventoryHandler accepts an announcement and fetches the
+// missing items in the background. Announcements from peers this
+// node has no address for get a 404, so the sender pushes instead.
+func (node *QuidnugNode) ReceiveInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	var ann InvAnnouncement
+	if err := DecodeJSONBody(w, r, &ann); err != nil {
+		return
+	}
+	if len(ann.Items) > invBatchMax {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST",
+			fmt.Sprintf("at most %d items per announcement", invBatchMax))
+		return
+	}
+	if ann.Domain == "" || !node.IsDomainSupported(ann.Domain) {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "domain not supported by this node")
+		return
+	}
+	node.KnownNodesMutex.RLock()
+	peer, known := node.KnownNodes[ann.NodeQuid]
+	node.KnownNodesMutex.RUnlock()
+	if !known || peer.Address == "" || ann.NodeQuid == node.NodeID {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "announcing node is not a known peer")
+		return
+	}
+	if node.peerBanned(peer.ID) {
+		WriteError(w, http.StatusForbidden, "FORBIDDEN", "peer is banned")
+		return
+	}
+	go node.fetchInventory(peer, ann)
+	WriteSuccessWithStatus(w, http.StatusAccepted, map[string]interface{}{"items": len(ann.Items)})
+}
+
+// GetDataHandler serves the bodies of announced items.
+func (node *QuidnugNode) GetDataHandler(w http.ResponseWriter, r *http.Request) {
+	var req GetDataRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if len(req.Items) > invBatchMax {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST",
+			fmt.Sprintf("at most %d items per request", invBatchMax))
+		return
+	}
+	if req.Domain == "" || !node.IsDomainSupported(req.Domain) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "domain not supported by this node")
+		return
+	}
+	resp, err := node.getData(req.Domain, req.Items)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	WriteSuccess(w, resp)
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func postInventory(t *testing.T, node *QuidnugNode, ann InvAnnouncement) int {
+	t.Helper()
+	body, _ := json.Marshal(ann)
+	w := httptest.NewRecorder()
+	setupTestRouter(node).ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/inv", bytes.NewReader(body)))
+	return w.Code
+}
+
+func TestInventoryRelay_FetchesAnnouncedTransaction(t *testing.T) {
+	src := newTestNode()
+	tx := signTrustTx(src, TrustTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "trust-inv-1",
+			Type:        TxTypeTrust,
+			TrustDomain: "test.domain.com",
+			Timestamp:   1_700_000_000,
+		},
+		Truster:    src.NodeID,
+		Trustee:    "abcdef1234567890",
+		TrustLevel: 0.8,
+		Nonce:      1,
+	})
+	txID, err := src.AddTrustTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var getdata int32
+	router := setupTestRouter(src)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/getdata" {
+			atomic.AddInt32(&getdata, 1)
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	dst := newTestNode()
+	dst.KnownNodesMutex.Lock()
+	dst.KnownNodes[src.NodeID] = Node{ID: src.NodeID, Address: strings.TrimPrefix(srv.URL, "http://")}
+	dst.KnownNodesMutex.Unlock()
+
+	ann := InvAnnouncement{
+		NodeQuid: src.NodeID,
+		Domain:   "test.domain.com",
+		Items:    []InvItem{{Kind: InvKindTx, ID: txID}},
+	}
+	if code := postInventory(t, dst, ann); code != http.StatusAccepted {
+		t.Fatalf("announce got %d", code)
+	}
+	if !waitUntil(2*time.Second, func() bool { return len(dst.pendingTxIDs("test.domain.com")) == 1 }) {
+		t.Fatal("announced transaction never admitted")
+	}
+
+	// Already held: a repeat announcement fetches nothing.
+	postInventory(t, dst, ann)
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&getdata); n != 1 {
+		t.Errorf("getdata requests = %d, want 1", n)
+	}
+}
+
+func TestReceiveInventoryHandler_UnknownPeer(t *testing.T) {
+	node := newTestNode()
+	code := postInventory(t, node, InvAnnouncement{
+		NodeQuid: "stranger00000001",
+		Domain:   "test.domain.com",
+		Items:    []InvItem{{Kind: InvKindTx, ID: "x"}},
+	})
+	if code != http.StatusNotFound {
+		t.Errorf("unknown announcer got %d, want 404", code)
+	}
+}
+
+func TestBroadcastTransaction_BatchesAnnouncements(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		anns  []InvAnnouncement
+		posts int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/inv" {
+			atomic.AddInt32(&posts, 1)
+			return
+		}
+		var ann InvAnnouncement
+		_ = json.NewDecoder(r.Body).Decode(&ann)
+		mu.Lock()
+		anns = append(anns, ann)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	node := newTestNode()
+	node.KnownNodes["inv_peer"] = Node{ID: "inv_peer", Address: server.Listener.Addr().String()}
+	node.TrustDomains["inv.domain.com"] = TrustDomain{Name: "inv.domain.com", ValidatorNodes: []string{"inv_peer"}}
+
+	for _, id := range []string{"inv-a", "inv-b", "inv-c"} {
+		node.BroadcastTransaction(TrustTransaction{
+			BaseTransaction: BaseTransaction{ID: id, Type: TxTypeTrust, TrustDomain: "inv.domain.com"},
+		})
+	}
+	waitUntil(time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(anns) > 0
+	})
+	time.Sleep(2 * invFlushDelay)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(anns) != 1 || len(anns[0].Items) != 3 || anns[0].NodeQuid != node.NodeID {
+		t.Fatalf("announcements = %+v", anns)
+	}
+	if n := atomic.LoadInt32(&posts); n != 0 {
+		t.Errorf("%d bodies pushed to an inventory-capable peer", n)
+	}
+}
+
+func TestGetData_Blocks(t *testing.T) {
+	node := newTestNode()
+	node.BlockchainMutex.Lock()
+	node.Blockchain = append(node.Blockchain,
+		Block{Index: 1, Hash: "h1", TrustProof: TrustProof{TrustDomain: "test.domain.com"}},
+		Block{Index: 2, Hash: "h2", TrustProof: TrustProof{TrustDomain: "test.domain.com"}, Pruned: true},
+		Block{Index: 1, Hash: "h3", TrustProof: TrustProof{TrustDomain: "other.domain"}},
+	)
+	node.BlockchainMutex.Unlock()
+
+	resp, err := node.getData("test.domain.com", []InvItem{
+		{Kind: InvKindBlock, ID: "h1"},
+		{Kind: InvKindBlock, ID: "h2"},
+		{Kind: InvKindBlock, ID: "h3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Blocks) != 1 || resp.Blocks[0].Hash != "h1" {
+		t.Errorf("blocks = %+v", resp.Blocks)
+	}
+	if missing := node.missingInventory("test.domain.com", []InvItem{
+		{Kind: InvKindBlock, ID: "h1"},
+		{Kind: InvKindBlock, ID: "h9"},
+	}); len(missing) != 1 || missing[0].ID != "h9" {
+		t.Errorf("missing = %+v", missing)
+	}
+}
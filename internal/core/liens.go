@@ -0,0 +1,427 @@
+// Package core — title liens and encumbrances.
+//
+// A LIEN transaction lets a third party (a lender, a tax
+// authority, a court) record an encumbrance against an asset in
+// the title registry. Recording one needs the asset's current
+// owners to co-sign it in Signatures, weighted by stake the way the
+// title's transfer policy weighs a transfer, so no quid can
+// encumber an asset it has no claim on. While a lien is active,
+// any transfer of
+// that asset must carry the lienholder's co-signature in the
+// title transaction's Signatures map, alongside the previous
+// owners' signatures. The lienholder lifts the lien with a
+// second LIEN transaction that names it in ReleasesLienID.
+//
+// Companion file structure mirrors moderation.go:
+//
+//   - types.go          : TxTypeLien const
+//   - liens.go          : this file — struct, registry, validator
+//   - transactions.go   : AddLienTransaction (mempool)
+//   - validation.go     : dispatch + title co-signature check
+//   - registry.go       : dispatch into updateLienRegistry
+//   - handlers.go       : POST submit handler + encumbrances on title reads
+//   - node.go           : LienRegistry field + init
+//
+// Expiry is judged against the timestamp of the transaction
+// being validated, not wall-clock time, so every node reaches the
+// same verdict when replaying a block.
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// MaxLienDescriptionLength bounds the free-text description to
+// keep chain growth in check.
+const MaxLienDescriptionLength = 1024
+
+// LienTransaction records or releases an encumbrance on an asset.
+type LienTransaction struct {
+	BaseTransaction
+
+	AssetID        string `json:"assetId"`
+	LienholderQuid string `json:"lienholderQuid"`
+
+	// Kind is a short free-form classifier ("mortgage", "tax",
+	// "judgment", ...). Informational only.
+	Kind        string `json:"kind,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// ExpiresAt, when non-zero, is the Unix time after which the
+	// lien stops encumbering transfers without an explicit
+	// release.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+
+	// ReleasesLienID, when set, turns this transaction into a
+	// release of the named lien. The remaining descriptive fields
+	// are ignored.
+	ReleasesLienID string `json:"releasesLienId,omitempty"`
+
+	Nonce int64 `json:"nonce"`
+
+	// Signatures holds the current owners' consent to a new lien,
+	// keyed by owner quid. Owners sign the tx with Signature,
+	// PublicKey and Signatures cleared. Unused on a release.
+	Signatures map[string]string `json:"signatures,omitempty"`
+}
+
+// LienRecord is the registry view of an active lien. PublicKey is
+// retained so transfer co-signatures can be verified without an
+// identity-registry lookup for the lienholder.
+type LienRecord struct {
+	LienID         string `json:"lienId"`
+	AssetID        string `json:"assetId"`
+	TrustDomain    string `json:"trustDomain"`
+	LienholderQuid string `json:"lienholderQuid"`
+	PublicKey      string `json:"publicKey"`
+	Kind           string `json:"kind,omitempty"`
+	Description    string `json:"description,omitempty"`
+	RecordedAt     int64  `json:"recordedAt"`
+	ExpiresAt      int64  `json:"expiresAt,omitempty"`
+}
+
+// activeAt reports whether the lien still encumbers at ts.
+func (l LienRecord) activeAt(ts int64) bool {
+	return l.ExpiresAt == 0 || l.ExpiresAt > ts
+}
+
+// LienRegistry indexes unreleased liens by asset.
+type LienRegistry struct {
+	mu sync.RWMutex
+
+	// byAsset maps assetID → lienID → record. Released liens are
+	// removed; expired ones stay until released and are filtered
+	// on read.
+	byAsset map[string]map[string]LienRecord
+
+	// byID indexes every lien ever recorded, including released
+	// ones, so a release can't be replayed against a new lien
+	// that happens to reuse an ID.
+	byID map[string]LienRecord
+
+	// released marks lien IDs that have been lifted.
+	released map[string]bool
+
+	// nonces tracks the highest accepted nonce per lienholder.
+	nonces map[string]int64
+}
+
+// NewLienRegistry constructs an empty registry.
+func NewLienRegistry() *LienRegistry {
+	return &LienRegistry{
+		byAsset:  make(map[string]map[string]LienRecord),
+		byID:     make(map[string]LienRecord),
+		released: make(map[string]bool),
+		nonces:   make(map[string]int64),
+	}
+}
+
+// currentNonce returns the highest accepted nonce for a
+// lienholder (0 if none).
+func (r *LienRegistry) currentNonce(lienholder string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nonces[lienholder]
+}
+
+// get returns a lien by ID and whether it is still unreleased.
+func (r *LienRegistry) get(lienID string) (LienRecord, bool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.byID[lienID]
+	return rec, ok, ok && !r.released[lienID]
+}
+
+// activeFor returns the liens on assetID that are unreleased and
+// unexpired at ts, ordered by recording time.
+func (r *LienRegistry) activeFor(assetID string, ts int64) []LienRecord {
+	r.mu.RLock()
+	var out []LienRecord
+	for _, rec := range r.byAsset[assetID] {
+		if rec.activeAt(ts) {
+			out = append(out, rec)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RecordedAt != out[j].RecordedAt {
+			return out[i].RecordedAt < out[j].RecordedAt
+		}
+		return out[i].LienID < out[j].LienID
+	})
+	return out
+}
+
+// apply commits a validated lien or release. Idempotent on
+// replay.
+func (r *LienRegistry) apply(tx LienTransaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx.Nonce > r.nonces[tx.LienholderQuid] {
+		r.nonces[tx.LienholderQuid] = tx.Nonce
+	}
+
+	if tx.ReleasesLienID != "" {
+		rec, ok := r.byID[tx.ReleasesLienID]
+		if !ok {
+			return
+		}
+		r.released[tx.ReleasesLienID] = true
+		if liens := r.byAsset[rec.AssetID]; liens != nil {
+			delete(liens, tx.ReleasesLienID)
+			if len(liens) == 0 {
+				delete(r.byAsset, rec.AssetID)
+			}
+		}
+		return
+	}
+
+	if _, seen := r.byID[tx.ID]; seen {
+		return
+	}
+	rec := LienRecord{
+		LienID:         tx.ID,
+		AssetID:        tx.AssetID,
+		TrustDomain:    tx.TrustDomain,
+		LienholderQuid: tx.LienholderQuid,
+		PublicKey:      tx.PublicKey,
+		Kind:           tx.Kind,
+		Description:    tx.Description,
+		RecordedAt:     tx.Timestamp,
+		ExpiresAt:      tx.ExpiresAt,
+	}
+	r.byID[tx.ID] = rec
+	if r.byAsset[tx.AssetID] == nil {
+		r.byAsset[tx.AssetID] = make(map[string]LienRecord)
+	}
+	r.byAsset[tx.AssetID][tx.ID] = rec
+}
+
+// GetActiveLiens returns the encumbrances currently in force on
+// an asset.
+func (node *QuidnugNode) GetActiveLiens(assetID string) []LienRecord {
+	if node.LienRegistry == nil {
+		return nil
+	}
+	return node.LienRegistry.activeFor(assetID, nowUnix())
+}
+
+// updateLienRegistry commits a validated LienTransaction. Called
+// from processBlockTransactions once the containing block has
+// been accepted.
+func (node *QuidnugNode) updateLienRegistry(tx LienTransaction) {
+	if node.LienRegistry == nil {
+		return
+	}
+	node.LienRegistry.apply(tx)
+	logger.Debug("Updated lien registry",
+		"txId", tx.ID,
+		"assetId", tx.AssetID,
+		"lienholder", tx.LienholderQuid,
+		"releases", tx.ReleasesLienID,
+		"nonce", tx.Nonce)
+}
+
+// ValidateLienTransaction enforces the lien rules. Returns false
+// on any violation; every failure is logged at Warn level.
+func (node *QuidnugNode) ValidateLienTransaction(tx LienTransaction) bool {
+	// 1. Domain must exist + be supported.
+	if tx.TrustDomain == "" {
+		logger.Warn("Lien missing trust domain", "txId", tx.ID)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Lien from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Lien trust domain not supported by this node",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Lienholder + signer consistency.
+	if !IsValidQuidID(tx.LienholderQuid) {
+		logger.Warn("Lien has invalid LienholderQuid",
+			"lienholder", tx.LienholderQuid, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Lien missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	computedQuid := QuidIDFromPublicKeyHex(tx.PublicKey)
+	if computedQuid == "" || !node.signsFor(tx.LienholderQuid, tx.PublicKey, TxTypeLien, tx.delegationTime()) {
+		logger.Warn("Lien LienholderQuid does not match signing public key",
+			"expected", tx.LienholderQuid, "computed", computedQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 3. Shape: a release names an unreleased lien held by the
+	// same lienholder; a new lien names an existing title.
+	if tx.ReleasesLienID != "" {
+		if node.LienRegistry == nil {
+			logger.Warn("Lien release without a registry", "txId", tx.ID)
+			return false
+		}
+		prior, exists, active := node.LienRegistry.get(tx.ReleasesLienID)
+		if !exists || !active {
+			logger.Warn("Lien release names unknown or already-released lien",
+				"releasesLienId", tx.ReleasesLienID, "txId", tx.ID)
+			return false
+		}
+		if prior.LienholderQuid != tx.LienholderQuid {
+			logger.Warn("Lien release from a different lienholder",
+				"priorLienholder", prior.LienholderQuid,
+				"lienholder", tx.LienholderQuid, "txId", tx.ID)
+			return false
+		}
+	} else {
+		if tx.AssetID == "" || !ValidateStringField(tx.AssetID, MaxNameLength) {
+			logger.Warn("Lien has invalid asset id", "assetId", tx.AssetID, "txId", tx.ID)
+			return false
+		}
+		title, exists := node.GetAssetOwnership(tx.AssetID)
+		if !exists {
+			logger.Warn("Lien against unknown asset", "assetId", tx.AssetID, "txId", tx.ID)
+			return false
+		}
+		if !node.verifyLienOwnerSignatures(tx, title) {
+			return false
+		}
+		if !ValidateStringField(tx.Kind, MaxNameLength) {
+			logger.Warn("Lien kind too long or contains control characters", "txId", tx.ID)
+			return false
+		}
+		if !ValidateStringField(tx.Description, MaxLienDescriptionLength) {
+			logger.Warn("Lien description too long or contains control characters", "txId", tx.ID)
+			return false
+		}
+		if tx.ExpiresAt != 0 && tx.ExpiresAt <= tx.Timestamp {
+			logger.Warn("Lien expires before it is recorded",
+				"expiresAt", tx.ExpiresAt, "timestamp", tx.Timestamp, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 4. Nonce strictly monotonic per lienholder.
+	if tx.Nonce <= 0 {
+		logger.Warn("Lien has non-positive nonce", "nonce", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+	if node.LienRegistry != nil {
+		prev := node.LienRegistry.currentNonce(tx.LienholderQuid)
+		if tx.Nonce <= prev {
+			logger.Warn("Lien nonce must be strictly greater than previous",
+				"previous", prev, "provided", tx.Nonce, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 5. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Lien marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Lien signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	return true
+}
+
+// verifyLienOwnerSignatures checks that a new lien carries the
+// consent of the asset's current owners: their signatures in
+// tx.Signatures must satisfy the title's transfer policy as a
+// transfer would. The small-transfer relaxation doesn't apply,
+// since a lien moves no stake and would otherwise need no one.
+func (node *QuidnugNode) verifyLienOwnerSignatures(tx LienTransaction, title TitleTransaction) bool {
+	txCopy := tx
+	txCopy.Signature = ""
+	txCopy.PublicKey = ""
+	txCopy.Signatures = nil
+	ownerSignableData, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Failed to marshal lien for owner signature verification", "txId", tx.ID, "error", err)
+		return false
+	}
+
+	policy := TitleTransferPolicy{Kind: TransferPolicyAll}
+	if title.TransferPolicy != nil {
+		policy = *title.TransferPolicy
+	}
+	policy.SmallTransferStake = 0
+	eligible := stakeShares(title.Owners)
+	signed := make(map[string]bool, len(tx.Signatures))
+	for signer, sig := range tx.Signatures {
+		if sig == "" {
+			continue
+		}
+		if _, owner := eligible[signer]; !owner && !(policy.Kind == TransferPolicyDesignated && signer == policy.Trustee) {
+			continue
+		}
+		if !node.verifyAsQuid(signer, TxTypeLien, tx.delegationTime(), ownerSignableData, sig) {
+			logger.Warn("Invalid owner co-signature on lien",
+				"assetId", tx.AssetID, "signer", signer, "txId", tx.ID)
+			return false
+		}
+		signed[signer] = true
+	}
+	if !policy.satisfied(title.Owners, title.Owners, signed) {
+		logger.Warn("Lien lacks the owners' consent",
+			"assetId", tx.AssetID, "policy", policy.Kind, "signers", len(signed), "txId", tx.ID)
+		return false
+	}
+	return true
+}
+
+// verifyLienholderCoSignatures checks that every lien active on
+// the asset at the transfer's timestamp has been acknowledged by
+// its lienholder in tx.Signatures. Lienholders sign the same
+// payload previous owners do: the tx with Signature, PublicKey
+// and Signatures cleared.
+func (node *QuidnugNode) verifyLienholderCoSignatures(tx TitleTransaction) bool {
+	if node.LienRegistry == nil {
+		return true
+	}
+	liens := node.LienRegistry.activeFor(tx.AssetID, tx.Timestamp)
+	if len(liens) == 0 {
+		return true
+	}
+	txCopy := tx
+	txCopy.Signature = ""
+	txCopy.PublicKey = ""
+	txCopy.Signatures = nil
+	ownerSignableData, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Failed to marshal transaction for lienholder signature verification", "txId", tx.ID, "error", err)
+		return false
+	}
+	for _, lien := range liens {
+		sig, ok := tx.Signatures[lien.LienholderQuid]
+		if !ok || sig == "" {
+			logger.Warn("Transfer of encumbered asset missing lienholder co-signature",
+				"assetId", tx.AssetID, "lienId", lien.LienID,
+				"lienholder", lien.LienholderQuid, "txId", tx.ID)
+			return false
+		}
+		if !VerifySignature(lien.PublicKey, ownerSignableData, sig) &&
+			!node.verifyAsQuid(lien.LienholderQuid, TxTypeTitle, tx.delegationTime(), ownerSignableData, sig) {
+			logger.Warn("Invalid lienholder co-signature on transfer",
+				"assetId", tx.AssetID, "lienId", lien.LienID,
+				"lienholder", lien.LienholderQuid, "txId", tx.ID)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,263 @@
+// LIEN tests: validation, release, and the lienholder
+// co-signature requirement on transfers of encumbered titles.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type lienTestFixture struct {
+	node       *QuidnugNode
+	seller     *testNodeActor
+	buyer      *testNodeActor
+	lienholder *testNodeActor
+	assetID    string
+}
+
+// newLienTestFixture registers seller and buyer identities and
+// gives the seller sole title to an asset.
+func newLienTestFixture(t *testing.T) *lienTestFixture {
+	t.Helper()
+	f := &lienTestFixture{
+		node:       newTestNode(),
+		seller:     newTestNodeActor(t),
+		buyer:      newTestNodeActor(t),
+		lienholder: newTestNodeActor(t),
+		assetID:    "asset-house-001",
+	}
+	for _, a := range []*testNodeActor{f.seller, f.buyer} {
+		f.node.IdentityRegistry[a.QuidID] = IdentityTransaction{
+			BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: a.PubHex},
+			QuidID:          a.QuidID,
+		}
+	}
+	f.node.TitleRegistry[f.assetID] = TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-1", TrustDomain: "test.domain.com"},
+		AssetID:         f.assetID,
+		Owners:          []OwnershipStake{{OwnerID: f.seller.QuidID, Percentage: 1.0}},
+	}
+	return f
+}
+
+func (a *testNodeActor) signLien(tx LienTransaction) LienTransaction {
+	tx.PublicKey = a.PubHex
+	tx.LienholderQuid = a.QuidID
+	tx.Signature = ""
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+func baselineLien(assetID string, nonce int64) LienTransaction {
+	return LienTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "lien-" + assetID,
+			Type:        TxTypeLien,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		AssetID: assetID,
+		Kind:    "mortgage",
+		Nonce:   nonce,
+	}
+}
+
+// lien builds a new lien by the fixture's lienholder, co-signed by
+// the given owners.
+func (f *lienTestFixture) lien(nonce int64, owners ...*testNodeActor) LienTransaction {
+	tx := baselineLien(f.assetID, nonce)
+	tx.LienholderQuid = f.lienholder.QuidID
+	ownerSignable, _ := json.Marshal(tx)
+	tx.Signatures = map[string]string{}
+	for _, o := range owners {
+		tx.Signatures[o.QuidID] = signIEEE1363(o.Priv, ownerSignable)
+	}
+	return f.lienholder.signLien(tx)
+}
+
+// transfer builds a seller→buyer title transfer signed by the
+// seller, optionally co-signed by extra signers.
+func (f *lienTestFixture) transfer(coSigners ...*testNodeActor) TitleTransaction {
+	tx := TitleTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "transfer-1",
+			Type:        TxTypeTitle,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		AssetID:        f.assetID,
+		Owners:         []OwnershipStake{{OwnerID: f.buyer.QuidID, Percentage: 1.0}},
+		PreviousOwners: []OwnershipStake{{OwnerID: f.seller.QuidID, Percentage: 1.0}},
+	}
+	ownerSignable, _ := json.Marshal(tx)
+	tx.Signatures = map[string]string{
+		f.seller.QuidID: signIEEE1363(f.seller.Priv, ownerSignable),
+	}
+	for _, c := range coSigners {
+		tx.Signatures[c.QuidID] = signIEEE1363(c.Priv, ownerSignable)
+	}
+	tx.PublicKey = f.seller.PubHex
+	issuerSignable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(f.seller.Priv, issuerSignable)
+	return tx
+}
+
+func TestValidateLien_Baseline(t *testing.T) {
+	f := newLienTestFixture(t)
+	tx := f.lien(1, f.seller)
+	if !f.node.ValidateLienTransaction(tx) {
+		t.Fatal("baseline lien rejected")
+	}
+}
+
+func TestValidateLien_RequiresOwnerConsent(t *testing.T) {
+	f := newLienTestFixture(t)
+	if f.node.ValidateLienTransaction(f.lien(1)) {
+		t.Error("lien the owner hasn't signed should be rejected")
+	}
+	if f.node.ValidateLienTransaction(f.lien(1, f.buyer)) {
+		t.Error("lien signed only by a non-owner should be rejected")
+	}
+
+	// The lienholder signing in the owner's name.
+	forged := f.lien(1, f.lienholder)
+	forged.Signatures[f.seller.QuidID] = forged.Signatures[f.lienholder.QuidID]
+	delete(forged.Signatures, f.lienholder.QuidID)
+	if f.node.ValidateLienTransaction(f.lienholder.signLien(forged)) {
+		t.Error("lien with a forged owner signature should be rejected")
+	}
+}
+
+func TestValidateLien_OwnerConsentWeightedByStake(t *testing.T) {
+	f := newLienTestFixture(t)
+	title := f.node.TitleRegistry[f.assetID]
+	title.Owners = []OwnershipStake{
+		{OwnerID: f.seller.QuidID, Percentage: 0.6},
+		{OwnerID: f.buyer.QuidID, Percentage: 0.4},
+	}
+	f.node.TitleRegistry[f.assetID] = title
+
+	if f.node.ValidateLienTransaction(f.lien(1, f.seller)) {
+		t.Error("lien signed by one of two owners accepted under the default all-owners policy")
+	}
+	if !f.node.ValidateLienTransaction(f.lien(1, f.seller, f.buyer)) {
+		t.Error("lien signed by every owner rejected")
+	}
+
+	title.TransferPolicy = &TitleTransferPolicy{Kind: TransferPolicyStakeMajority, SmallTransferStake: 0.5}
+	f.node.TitleRegistry[f.assetID] = title
+	if !f.node.ValidateLienTransaction(f.lien(1, f.seller)) {
+		t.Error("lien signed by the majority owner rejected under stake-majority")
+	}
+	if f.node.ValidateLienTransaction(f.lien(1, f.buyer)) {
+		t.Error("lien signed by the minority owner accepted under stake-majority")
+	}
+}
+
+func TestValidateLien_RejectsUnknownAsset(t *testing.T) {
+	f := newLienTestFixture(t)
+	tx := f.lienholder.signLien(baselineLien("no-such-asset", 1))
+	if f.node.ValidateLienTransaction(tx) {
+		t.Error("lien against unknown asset should be rejected")
+	}
+}
+
+func TestValidateLien_RejectsReleaseByOtherQuid(t *testing.T) {
+	f := newLienTestFixture(t)
+	lien := f.lienholder.signLien(baselineLien(f.assetID, 1))
+	f.node.updateLienRegistry(lien)
+
+	release := LienTransaction{
+		BaseTransaction: BaseTransaction{ID: "release-1", TrustDomain: "test.domain.com", Timestamp: time.Now().Unix()},
+		ReleasesLienID:  lien.ID,
+		Nonce:           1,
+	}
+	if f.node.ValidateLienTransaction(f.seller.signLien(release)) {
+		t.Error("release by a non-lienholder should be rejected")
+	}
+	release.Nonce = 2
+	if !f.node.ValidateLienTransaction(f.lienholder.signLien(release)) {
+		t.Fatal("release by the lienholder should be accepted")
+	}
+}
+
+func TestTitleTransfer_RequiresLienholderCoSignature(t *testing.T) {
+	f := newLienTestFixture(t)
+
+	if !f.node.ValidateTitleTransaction(f.transfer()) {
+		t.Fatal("unencumbered transfer should be valid")
+	}
+
+	f.node.updateLienRegistry(f.lienholder.signLien(baselineLien(f.assetID, 1)))
+
+	if f.node.ValidateTitleTransaction(f.transfer()) {
+		t.Fatal("transfer of encumbered asset without lienholder co-signature should be rejected")
+	}
+	if !f.node.ValidateTitleTransaction(f.transfer(f.lienholder)) {
+		t.Fatal("transfer co-signed by the lienholder should be valid")
+	}
+}
+
+func TestTitleTransfer_ReleasedLienNoLongerEncumbers(t *testing.T) {
+	f := newLienTestFixture(t)
+	lien := f.lienholder.signLien(baselineLien(f.assetID, 1))
+	f.node.updateLienRegistry(lien)
+	f.node.updateLienRegistry(f.lienholder.signLien(LienTransaction{
+		BaseTransaction: BaseTransaction{ID: "release-1", TrustDomain: "test.domain.com", Timestamp: time.Now().Unix()},
+		ReleasesLienID:  lien.ID,
+		Nonce:           2,
+	}))
+
+	if got := f.node.GetActiveLiens(f.assetID); len(got) != 0 {
+		t.Fatalf("expected no active liens after release, got %d", len(got))
+	}
+	if !f.node.ValidateTitleTransaction(f.transfer()) {
+		t.Fatal("transfer after release should be valid")
+	}
+}
+
+func TestTitleTransfer_ExpiredLienNoLongerEncumbers(t *testing.T) {
+	f := newLienTestFixture(t)
+	lien := baselineLien(f.assetID, 1)
+	lien.Timestamp = time.Now().Add(-2 * time.Hour).Unix()
+	lien.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	f.node.updateLienRegistry(f.lienholder.signLien(lien))
+
+	if !f.node.ValidateTitleTransaction(f.transfer()) {
+		t.Fatal("transfer after lien expiry should be valid")
+	}
+}
+
+func TestGetTitleHandler_ReportsEncumbrances(t *testing.T) {
+	f := newLienTestFixture(t)
+	f.node.updateLienRegistry(f.lienholder.signLien(baselineLien(f.assetID, 1)))
+	router := setupTestRouter(f.node)
+
+	req := httptest.NewRequest("GET", "/api/v1/title/"+f.assetID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data struct {
+			AssetID      string           `json:"assetId"`
+			Owners       []OwnershipStake `json:"owners"`
+			Encumbrances []LienRecord     `json:"encumbrances"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.AssetID != f.assetID || len(resp.Data.Owners) != 1 {
+		t.Errorf("ownership fields missing from response: %+v", resp.Data)
+	}
+	if len(resp.Data.Encumbrances) != 1 || resp.Data.Encumbrances[0].LienholderQuid != f.lienholder.QuidID {
+		t.Errorf("expected one encumbrance by lienholder, got %+v", resp.Data.Encumbrances)
+	}
+}
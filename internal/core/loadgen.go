@@ -0,0 +1,104 @@
+// Synthetic workloads for benchmarks and load tests.
+//
+// The hot paths worth measuring only show their cost at scale: a
+// relational trust query over tens of thousands of quids, or
+// validating a block that carries thousands of signed transactions.
+// These helpers build that state directly, bypassing the mempool
+// and gossip, so the benchmarks in this package and `quidnug-cli
+// loadtest` measure the same thing. They are meant for throwaway nodes.
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+)
+
+// SyntheticQuid returns the ID of the i-th synthetic quid.
+func SyntheticQuid(i int) string {
+	return fmt.Sprintf("5e%014x", i)
+}
+
+// SeedSyntheticTrustGraph adds quids SyntheticQuid(0) through
+// SyntheticQuid(n-1) to the trust registry, each trusting degree
+// others picked by a seeded RNG at levels in [0.5, 1). The same
+// arguments always build the same graph.
+func (node *QuidnugNode) SeedSyntheticTrustGraph(n, degree int, seed int64) {
+	rng := rand.New(rand.NewSource(seed)) // #nosec G404 -- reproducible graph, not security
+	node.TrustRegistryMutex.Lock()
+	for i := 0; i < n; i++ {
+		truster := SyntheticQuid(i)
+		edges := node.TrustRegistry[truster]
+		if edges == nil {
+			edges = make(map[string]float64, degree)
+			node.TrustRegistry[truster] = edges
+		}
+		for d := 0; d < degree && n > 1; d++ {
+			j := rng.Intn(n - 1)
+			if j >= i {
+				j++
+			}
+			edges[SyntheticQuid(j)] = 0.5 + rng.Float64()/2
+		}
+	}
+	node.TrustRegistryMutex.Unlock()
+	node.invalidateTrustView()
+	if node.TrustCache != nil {
+		node.TrustCache.Invalidate()
+	}
+	node.bumpRegistryVersion()
+}
+
+// SyntheticTrustBlock seals a block of n TRUST transactions between
+// synthetic quids, each signed by the node, on top of the node's
+// chain for domain. The block is returned, not added, so it can be
+// validated repeatedly. The domain is registered with the node as
+// sole validator if it is not known yet. Pending transactions for
+// domain are sealed into the block along with the synthetic ones.
+func (node *QuidnugNode) SyntheticTrustBlock(domain string, n int) (*Block, error) {
+	node.TrustDomainsMutex.Lock()
+	if _, ok := node.TrustDomains[domain]; !ok {
+		node.TrustDomains[domain] = TrustDomain{
+			Name:                domain,
+			ValidatorNodes:      []string{node.NodeID},
+			TrustThreshold:      0.75,
+			Validators:          map[string]float64{node.NodeID: 1.0},
+			ValidatorPublicKeys: map[string]string{node.NodeID: node.GetPublicKeyHex()},
+		}
+	}
+	node.TrustDomainsMutex.Unlock()
+
+	now := nowUnix()
+	txs := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		tx := TrustTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          fmt.Sprintf("synthetic-trust-%d", i),
+				Type:        TxTypeTrust,
+				TrustDomain: domain,
+				Timestamp:   now,
+				PublicKey:   node.GetPublicKeyHex(),
+			},
+			Truster:    SyntheticQuid(i),
+			Trustee:    SyntheticQuid(i + 1),
+			TrustLevel: 0.8,
+			Nonce:      1,
+		}
+		signable, err := json.Marshal(tx)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := node.SignData(signable)
+		if err != nil {
+			return nil, err
+		}
+		tx.Signature = hex.EncodeToString(sig)
+		txs = append(txs, tx)
+	}
+
+	node.PendingTxsMutex.Lock()
+	node.PendingTxs = append(node.PendingTxs, txs...)
+	node.PendingTxsMutex.Unlock()
+	return node.GenerateBlock(domain)
+}
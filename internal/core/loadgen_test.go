@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSyntheticTrustBlock_ValidatesAsTrusted(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	block, err := node.SyntheticTrustBlock("bench.local", 25)
+	if err != nil {
+		t.Fatalf("SyntheticTrustBlock: %v", err)
+	}
+	if len(block.Transactions) != 25 {
+		t.Fatalf("block holds %d transactions, want 25", len(block.Transactions))
+	}
+	if got := node.ValidateBlockTiered(*block); got != BlockTrusted {
+		t.Fatalf("synthetic block validated as %v", got)
+	}
+}
+
+func TestSeedSyntheticTrustGraph_Deterministic(t *testing.T) {
+	a, b := newTestNode(), newTestNode()
+	a.SeedSyntheticTrustGraph(200, 4, 9)
+	b.SeedSyntheticTrustGraph(200, 4, 9)
+	for i := 0; i < 200; i++ {
+		q := SyntheticQuid(i)
+		if fmt.Sprint(a.TrustRegistry[q]) != fmt.Sprint(b.TrustRegistry[q]) {
+			t.Fatalf("edges of %s differ between runs", q)
+		}
+	}
+	if _, _, err := a.ComputeRelationalTrust(context.Background(), SyntheticQuid(0), SyntheticQuid(199), DefaultTrustMaxDepth); err != nil {
+		t.Fatalf("trust query on synthetic graph: %v", err)
+	}
+}
+
+// BenchmarkComputeRelationalTrust_SyntheticGraph queries across
+// graphs large enough that the search, not setup, dominates. The
+// trust cache is disabled so every iteration walks the graph.
+func BenchmarkComputeRelationalTrust_SyntheticGraph(b *testing.B) {
+	for _, size := range []int{1_000, 10_000, 50_000} {
+		b.Run(fmt.Sprintf("quids=%d", size), func(b *testing.B) {
+			node := newTestNode()
+			node.TrustCache = nil
+			node.SeedSyntheticTrustGraph(size, 8, 1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				target := SyntheticQuid((i*7919 + 1) % size)
+				_, _, _ = node.ComputeRelationalTrust(context.Background(), SyntheticQuid(i%size), target, DefaultTrustMaxDepth)
+			}
+		})
+	}
+}
+
+// BenchmarkValidateBlockTiered covers the receive path's per-block
+// cost: hash, validator signature, and one signature check per
+// transaction.
+func BenchmarkValidateBlockTiered(b *testing.B) {
+	for _, txs := range []int{100, 1_000, 5_000} {
+		b.Run(fmt.Sprintf("txs=%d", txs), func(b *testing.B) {
+			node, _ := NewQuidnugNode(nil)
+			block, err := node.SyntheticTrustBlock("bench.local", txs)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if node.ValidateBlockTiered(*block) != BlockTrusted {
+					b.Fatal("synthetic block rejected")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkHTTPHandler_Parallel drives the full handler stack,
+// middleware included, from GOMAXPROCS goroutines.
+func BenchmarkHTTPHandler_Parallel(b *testing.B) {
+	node := newTestNode()
+	node.SeedSyntheticTrustGraph(5_000, 8, 1)
+	handler := node.NewHTTPHandler(1<<30, 1<<20)
+
+	for name, path := range map[string]string{
+		"info":  "/api/v1/info",
+		"trust": "/api/v1/trust/" + SyntheticQuid(0) + "/" + SyntheticQuid(4_321),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					rr := httptest.NewRecorder()
+					handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+					if rr.Code != http.StatusOK {
+						b.Errorf("%s: status %d", path, rr.Code)
+						return
+					}
+				}
+			})
+		})
+	}
+}
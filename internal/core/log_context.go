@@ -0,0 +1,55 @@
+// Request-scoped logging.
+//
+// RequestIDMiddleware tags each API request with an ID, but the code
+// a request reaches logged through the package logger and lost it, so
+// a submission could not be followed from intake to gossip to the
+// block that sealed it. The middleware now also puts a logger carrying
+// "requestId" in the request context; transaction intake adds "txId"
+// and "txType" to it, and broadcasts keep it (and forward the ID in
+// X-Request-ID, so the receiving peer logs under the same ID). Block
+// processing logs under "blockIndex" and "blockHash" and names each
+// transaction it applies, which links a txId to its block.
+package core
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerContextKey struct{}
+
+// withLogger returns ctx carrying l.
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// loggerFrom returns the logger ctx carries, or the package logger.
+// Either is safe to use from any goroutine while SetLogger runs: the
+// package logger is never reassigned, only its handler is swapped.
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return logger
+}
+
+// detachLog returns parent carrying ctx's request ID and logger.
+// Work that outlives a request (gossip) runs under the node's
+// lifetime but should still log under the request that started it.
+func detachLog(parent, ctx context.Context) context.Context {
+	if id := GetRequestID(ctx); id != "" {
+		parent = context.WithValue(parent, RequestIDContextKey, id)
+	}
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		parent = withLogger(parent, l)
+	}
+	return parent
+}
+
+// blockLogger returns the logger for work on block.
+func blockLogger(block Block) *slog.Logger {
+	return logger.With(
+		"blockIndex", block.Index,
+		"blockHash", block.Hash,
+		"domain", block.TrustProof.TrustDomain)
+}
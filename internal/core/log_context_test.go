@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logCapture collects JSON log lines; broadcasts log from their own
+// goroutines, hence the lock.
+type logCapture struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (c *logCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+// find returns the first record whose msg is msg.
+func (c *logCapture) find(msg string) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range strings.Split(c.buf.String(), "\n") {
+		var rec map[string]interface{}
+		if json.Unmarshal([]byte(line), &rec) == nil && rec["msg"] == msg {
+			return rec
+		}
+	}
+	return nil
+}
+
+// captureLogs routes the package logger into a logCapture for the
+// rest of the test. newTestNode resets the logger, so call it first.
+func captureLogs(t *testing.T) *logCapture {
+	t.Helper()
+	c := &logCapture{}
+	prev := logHandler()
+	SetLogger(slog.New(slog.NewJSONHandler(c, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { setLogHandler(prev) })
+	return c
+}
+
+func TestRequestLogging_TransactionIntake(t *testing.T) {
+	node := newTestNode()
+	logs := captureLogs(t)
+	handler := RequestIDMiddleware(setupTestRouter(node))
+
+	submit := func(tx TrustTransaction) {
+		body, _ := json.Marshal(signTrustTx(node, tx))
+		req := httptest.NewRequest("POST", "/api/v1/transactions/trust", bytes.NewReader(body))
+		req.Header.Set("X-Request-ID", "req-"+tx.ID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+	}
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "trace-ok", Type: TxTypeTrust, TrustDomain: "test.domain.com", Timestamp: 1000000},
+		Truster:         "0000000000000001",
+		Trustee:         "0000000000000002",
+		TrustLevel:      0.5,
+		Nonce:           1,
+	}
+	submit(tx)
+	tx.ID, tx.TrustLevel = "trace-bad", 3
+	submit(tx)
+
+	for msg, want := range map[string]string{
+		"Added trust transaction to pending pool": "trace-ok",
+		"Rejected trust transaction":              "trace-bad",
+	} {
+		rec := logs.find(msg)
+		if rec == nil {
+			t.Errorf("no %q record", msg)
+			continue
+		}
+		if rec["requestId"] != "req-"+want || rec["txId"] != want || rec["txType"] != string(TxTypeTrust) {
+			t.Errorf("%q: unexpected fields %v", msg, rec)
+		}
+	}
+}
+
+func TestRequestLogging_BroadcastForwardsRequestID(t *testing.T) {
+	node := newTestNode()
+	got := make(chan string, 1)
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get("X-Request-ID")
+	}))
+	defer peer.Close()
+
+	reqCtx := context.WithValue(context.Background(), RequestIDContextKey, "req-fwd")
+	ctx := detachLog(node.lifetime(), reqCtx)
+	node.broadcastToNode(ctx, Node{ID: "peer", Address: peer.Listener.Addr().String()}, "trust", []byte(`{}`))
+	if id := <-got; id != "req-fwd" {
+		t.Errorf("peer saw X-Request-ID %q, want req-fwd", id)
+	}
+}
+
+func TestRequestLogging_BlockProcessing(t *testing.T) {
+	node := newTestNode()
+	logs := captureLogs(t)
+	block := Block{
+		Index: 7,
+		Hash:  "blockhash-7",
+		Transactions: []interface{}{TrustTransaction{
+			BaseTransaction: BaseTransaction{ID: "sealed-tx", Type: TxTypeTrust, TrustDomain: "test.domain.com"},
+			Truster:         "0000000000000001",
+			Trustee:         "0000000000000002",
+			TrustLevel:      0.5,
+		}},
+		TrustProof: TrustProof{TrustDomain: "test.domain.com"},
+	}
+	node.processBlockTransactions(block)
+
+	rec := logs.find("Applying transaction")
+	if rec == nil || rec["txId"] != "sealed-tx" || rec["blockHash"] != "blockhash-7" || rec["blockIndex"] != float64(7) {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+// Broadcast goroutines log through loggerFrom while tests and
+// embedders replace the package logger; run with -race.
+func TestLoggerFrom_ConcurrentSetLogger(t *testing.T) {
+	logs := captureLogs(t)
+	derived := loggerFrom(context.Background()).With("txId", "tx-1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				loggerFrom(context.Background()).Debug("concurrent log")
+				derived.Debug("concurrent derived log")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		SetLogger(slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	wg.Wait()
+
+	derived.Info("after swap")
+	if rec := logs.find("after swap"); rec == nil || rec["txId"] != "tx-1" {
+		t.Fatalf("derived logger lost its attributes across a swap: %v", rec)
+	}
+}
@@ -0,0 +1,187 @@
+// Log outputs and the runtime log level.
+//
+// The package logger used to be a JSON handler on stdout whose level
+// was fixed at startup. initLogSinks now builds it from the log_*
+// settings (stdout, stderr, a rotating file, syslog; JSON or text)
+// via internal/logsink, and every output filters on logLevel, so
+// PUT /admin/log-level can turn on debug logging on a running node
+// and turn it off again without a restart.
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/logsink"
+)
+
+// logLevel is the level every log output filters at.
+var logLevel = new(slog.LevelVar)
+
+// logRoot holds the handler behind the package logger. The logger
+// variable itself is never reassigned: initLogger, initLogSinks and
+// SetLogger swap the handler here, so the goroutines logging through
+// logger (and loggers derived from it with With) don't race with them.
+var logRoot atomic.Pointer[slog.Handler]
+
+// setLogHandler makes h the handler behind the package logger.
+func setLogHandler(h slog.Handler) {
+	if sh, ok := h.(*swapHandler); ok && sh.ops == nil {
+		// The package logger's own handler; it already follows logRoot.
+		return
+	}
+	logRoot.Store(&h)
+}
+
+// logHandler returns the handler behind the package logger.
+func logHandler() slog.Handler {
+	return *logRoot.Load()
+}
+
+// swapHandler resolves every record against the current logRoot,
+// replaying the attributes and groups added to it with With and
+// WithGroup. The resolved handler is cached until logRoot changes.
+type swapHandler struct {
+	ops    []func(slog.Handler) slog.Handler
+	cached atomic.Pointer[swapResolved]
+}
+
+type swapResolved struct {
+	root, h slog.Handler
+}
+
+func (s *swapHandler) resolve() slog.Handler {
+	root := logHandler()
+	if c := s.cached.Load(); c != nil && c.root == root {
+		return c.h
+	}
+	h := root
+	for _, op := range s.ops {
+		h = op(h)
+	}
+	s.cached.Store(&swapResolved{root: root, h: h})
+	return h
+}
+
+func (s *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.resolve().Enabled(ctx, level)
+}
+
+func (s *swapHandler) Handle(ctx context.Context, r slog.Record) error {
+	return s.resolve().Handle(ctx, r)
+}
+
+func (s *swapHandler) with(op func(slog.Handler) slog.Handler) *swapHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(s.ops), len(s.ops)+1)
+	copy(ops, s.ops)
+	return &swapHandler{ops: append(ops, op)}
+}
+
+func (s *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return s.with(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (s *swapHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	return s.with(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+// initLogSinks points the package logger at the outputs cfg names.
+// The returned Closer flushes and closes them at shutdown.
+func initLogSinks(cfg *config.Config) (io.Closer, error) {
+	level, err := logsink.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	logLevel.Set(level)
+
+	file := cfg.LogFile
+	if file == "" && cfg.DataDir != "" {
+		file = filepath.Join(cfg.DataDir, "quidnug.log")
+	}
+	h, closer, err := logsink.New(logsink.Config{
+		Format:         cfg.LogFormat,
+		Outputs:        cfg.LogOutputs,
+		File:           file,
+		FileMaxSizeMB:  cfg.LogFileMaxSizeMB,
+		FileMaxBackups: cfg.LogFileMaxBackups,
+		SyslogAddress:  cfg.LogSyslogAddress,
+	}, logLevel)
+	if err != nil {
+		return nil, err
+	}
+	setLogHandler(h)
+	return closer, nil
+}
+
+// LogLevelUpdateRequest is the admin-signed body of
+// PUT /admin/log-level.
+type LogLevelUpdateRequest struct {
+	Level     string `json:"level"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// SetLogLevel applies an admin-signed log level change.
+func (node *QuidnugNode) SetLogLevel(req LogLevelUpdateRequest) (slog.Level, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return 0, err
+	}
+	level, err := logsink.ParseLevel(req.Level)
+	if err != nil {
+		return 0, err
+	}
+	prev := logLevel.Level()
+	logLevel.Set(level)
+	logger.Warn("Log level changed", "from", prev.String(), "to", level.String())
+	return level, nil
+}
+
+// GetLogLevelHandler returns the current log level.
+func (node *QuidnugNode) GetLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	WriteSuccess(w, map[string]interface{}{"level": logLevelName(logLevel.Level())})
+}
+
+// UpdateLogLevelHandler changes the log level. The body is an
+// admin-signed LogLevelUpdateRequest.
+func (node *QuidnugNode) UpdateLogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelUpdateRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	level, err := node.SetLogLevel(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAdminKey), errors.Is(err, ErrAdminSignature), errors.Is(err, ErrAdminStale):
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", err.Error())
+		default:
+			WriteFieldError(w, "BAD_REQUEST", err.Error(), []string{"level"})
+		}
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{"level": logLevelName(level)})
+}
+
+// logLevelName is the lower-case name config and the API use.
+func logLevelName(l slog.Level) string {
+	switch l {
+	case slog.LevelDebug:
+		return "debug"
+	case slog.LevelWarn:
+		return "warn"
+	case slog.LevelError:
+		return "error"
+	}
+	return "info"
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func signLogLevelUpdate(t *testing.T, node *QuidnugNode, req LogLevelUpdateRequest) LogLevelUpdateRequest {
+	t.Helper()
+	req.Timestamp = time.Now().Unix()
+	req.PublicKey = node.GetPublicKeyHex()
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+func TestLogLevelHandlers(t *testing.T) {
+	node := newTestNode()
+	defer logLevel.Set(slog.LevelInfo)
+	router := setupTestRouter(node)
+
+	put := func(req LogLevelUpdateRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PUT", "/api/v1/admin/log-level", bytes.NewReader(body)))
+		return w
+	}
+
+	if w := put(LogLevelUpdateRequest{Level: "debug", Timestamp: time.Now().Unix(), PublicKey: "00"}); w.Code != http.StatusForbidden {
+		t.Errorf("unsigned: expected 403, got %d", w.Code)
+	}
+	if w := put(signLogLevelUpdate(t, node, LogLevelUpdateRequest{Level: "loud"})); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown level: expected 400, got %d", w.Code)
+	}
+	if w := put(signLogLevelUpdate(t, node, LogLevelUpdateRequest{Level: "debug"})); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !logger.Enabled(t.Context(), slog.LevelDebug) {
+		t.Error("debug logging should now be enabled")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/log-level", nil))
+	if !strings.Contains(w.Body.String(), `"level":"debug"`) {
+		t.Errorf("GET: %s", w.Body.String())
+	}
+}
+
+func TestInitLogSinks_FileOutput(t *testing.T) {
+	prev := logHandler()
+	defer func() { setLogHandler(prev); logLevel.Set(slog.LevelInfo) }()
+
+	dir := t.TempDir()
+	closer, err := initLogSinks(&config.Config{
+		LogLevel:   "warn",
+		LogFormat:  "text",
+		LogOutputs: []string{"file"},
+		DataDir:    dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("not written")
+	logger.Warn("written", "key", "value")
+	closer.Close()
+
+	got, err := os.ReadFile(filepath.Join(dir, "quidnug.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "not written") || !strings.Contains(string(got), "msg=written key=value") {
+		t.Errorf("unexpected log file: %s", got)
+	}
+
+	if _, err := initLogSinks(&config.Config{LogOutputs: []string{"carrier-pigeon"}}); err == nil {
+		t.Error("expected an error for an unknown output")
+	}
+}
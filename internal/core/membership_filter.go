@@ -0,0 +1,212 @@
+// Membership filters for light peers.
+//
+// A light client deciding which node to ask about a quid or asset
+// would otherwise have to issue the query and see. The node instead
+// publishes Golomb-coded sets (pkg/gcs) over what it knows: one per
+// registry, covering every quid or asset currently in it, and one
+// per block, covering everything the block's transactions touch.
+// A miss is definitive; a hit means "ask", wrong about once in
+// gcs.DefaultM lookups.
+//
+// Items are prefixed by kind, "quid:" or "asset:", so the same
+// identifier used as both cannot collide. Registry filters are keyed
+// by the registry name and the ETag state they were built from, and
+// cached until that state moves; block filters are keyed by the
+// block hash, as in BIP-158, and built on request.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/pkg/gcs"
+)
+
+// Membership item prefixes.
+const (
+	MembershipQuidPrefix  = "quid:"
+	MembershipAssetPrefix = "asset:"
+)
+
+// Registries with a membership filter.
+const (
+	MembershipRegistryIdentity = "identity"
+	MembershipRegistryTitle    = "title"
+	MembershipRegistryTrust    = "trust"
+)
+
+// MembershipFilter is the wire form of a Golomb-coded set. Key is
+// hex and Filter base64; N, P and M are what gcs.Filter needs to
+// decode it.
+type MembershipFilter struct {
+	Registry   string `json:"registry,omitempty"`
+	BlockIndex int64  `json:"blockIndex,omitempty"`
+	BlockHash  string `json:"blockHash,omitempty"`
+	// Height is the chain length the filter reflects.
+	Height int64  `json:"height"`
+	N      uint32 `json:"n"`
+	P      uint8  `json:"p"`
+	M      uint64 `json:"m"`
+	Key    string `json:"key"`
+	Filter string `json:"filter"`
+}
+
+// membershipFilters caches registry filters by the state tag they
+// were built at.
+type membershipFilters struct {
+	mu      sync.Mutex
+	byName  map[string]MembershipFilter
+	builtAt map[string]string
+}
+
+func newMembershipFilter(f *gcs.Filter) MembershipFilter {
+	return MembershipFilter{
+		N:      f.N,
+		P:      f.P,
+		M:      f.M,
+		Key:    hex.EncodeToString(f.Key[:]),
+		Filter: base64.StdEncoding.EncodeToString(f.Data),
+	}
+}
+
+// registryMembers lists the items in the named registry; ok is false
+// for an unknown name.
+func (node *QuidnugNode) registryMembers(registry string) (items [][]byte, ok bool) {
+	switch registry {
+	case MembershipRegistryIdentity:
+		node.IdentityRegistryMutex.RLock()
+		for quid := range node.IdentityRegistry {
+			items = append(items, []byte(MembershipQuidPrefix+quid))
+		}
+		node.IdentityRegistryMutex.RUnlock()
+	case MembershipRegistryTitle:
+		node.TitleRegistryMutex.RLock()
+		for asset := range node.TitleRegistry {
+			items = append(items, []byte(MembershipAssetPrefix+asset))
+		}
+		node.TitleRegistryMutex.RUnlock()
+	case MembershipRegistryTrust:
+		node.TrustRegistryMutex.RLock()
+		for truster, edges := range node.TrustRegistry {
+			items = append(items, []byte(MembershipQuidPrefix+truster))
+			for trustee := range edges {
+				items = append(items, []byte(MembershipQuidPrefix+trustee))
+			}
+		}
+		node.TrustRegistryMutex.RUnlock()
+	default:
+		return nil, false
+	}
+	return items, true
+}
+
+// RegistryMembershipFilter returns the filter over the named
+// registry, rebuilding it if the registries or chain have moved
+// since it was last built.
+func (node *QuidnugNode) RegistryMembershipFilter(registry string) (MembershipFilter, bool) {
+	state := node.stateETag(0)
+	c := &node.membershipFilters
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.builtAt[registry] == state {
+		return c.byName[registry], true
+	}
+
+	items, ok := node.registryMembers(registry)
+	if !ok {
+		return MembershipFilter{}, false
+	}
+	var key [gcs.KeySize]byte
+	sum := sha256.Sum256([]byte(node.NodeID + "/" + registry + "/" + state))
+	copy(key[:], sum[:])
+	out := newMembershipFilter(gcs.Build(key, gcs.DefaultP, gcs.DefaultM, items))
+	out.Registry = registry
+	node.BlockchainMutex.RLock()
+	out.Height = int64(len(node.Blockchain))
+	node.BlockchainMutex.RUnlock()
+
+	if c.byName == nil {
+		c.byName = make(map[string]MembershipFilter)
+		c.builtAt = make(map[string]string)
+	}
+	c.byName[registry] = out
+	c.builtAt[registry] = state
+	return out, true
+}
+
+// blockMembershipFilter builds the filter over the quids and assets
+// block's transactions touch.
+func blockMembershipFilter(block Block) MembershipFilter {
+	var items [][]byte
+	for _, txInterface := range block.Transactions {
+		raw, err := json.Marshal(txInterface)
+		if err != nil {
+			continue
+		}
+		var touches txTouches
+		if json.Unmarshal(raw, &touches) != nil {
+			continue
+		}
+		for _, q := range touches.quids() {
+			items = append(items, []byte(MembershipQuidPrefix+q))
+		}
+		if a := touches.asset(); a != "" {
+			items = append(items, []byte(MembershipAssetPrefix+a))
+		}
+	}
+	var key [gcs.KeySize]byte
+	if h, err := hex.DecodeString(block.Hash); err == nil {
+		copy(key[:], h)
+	}
+	out := newMembershipFilter(gcs.Build(key, gcs.DefaultP, gcs.DefaultM, items))
+	out.BlockIndex = block.Index
+	out.BlockHash = block.Hash
+	out.Height = block.Index + 1
+	return out
+}
+
+// GetRegistryFilterHandler serves GET /filters/registry/{registry}.
+func (node *QuidnugNode) GetRegistryFilterHandler(w http.ResponseWriter, r *http.Request) {
+	registry := mux.Vars(r)["registry"]
+	f, ok := node.RegistryMembershipFilter(registry)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "unknown registry; want identity, title or trust")
+		return
+	}
+	WriteSuccess(w, f)
+}
+
+// GetBlockFilterHandler serves GET /filters/block/{index}.
+func (node *QuidnugNode) GetBlockFilterHandler(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.ParseInt(mux.Vars(r)["index"], 10, 64)
+	if err != nil || index < 0 {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "index must be a non-negative integer")
+		return
+	}
+	node.BlockchainMutex.RLock()
+	var block Block
+	found := index < int64(len(node.Blockchain)) && node.Blockchain[index].Index == index
+	if found {
+		block = node.Blockchain[index]
+	}
+	node.BlockchainMutex.RUnlock()
+	if !found {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "block not found")
+		return
+	}
+	if block.Pruned {
+		one := []Block{block}
+		node.hydrateBlocks(r.Context(), one)
+		if block = one[0]; block.Pruned {
+			WriteError(w, http.StatusGone, "BLOCK_PRUNED", "block body has been pruned and is not archived")
+			return
+		}
+	}
+	WriteSuccess(w, blockMembershipFilter(block))
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quidnug/quidnug/pkg/gcs"
+)
+
+// decodeMembershipFilter turns the wire form back into a gcs.Filter.
+func decodeMembershipFilter(t *testing.T, mf MembershipFilter) *gcs.Filter {
+	t.Helper()
+	f := &gcs.Filter{N: mf.N, P: mf.P, M: mf.M}
+	key, err := hex.DecodeString(mf.Key)
+	if err != nil || len(key) != gcs.KeySize {
+		t.Fatalf("bad key %q", mf.Key)
+	}
+	copy(f.Key[:], key)
+	if f.Data, err = base64.StdEncoding.DecodeString(mf.Filter); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func mustMatch(t *testing.T, f *gcs.Filter, item string) bool {
+	t.Helper()
+	ok, err := f.Match([]byte(item))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestRegistryMembershipFilter(t *testing.T) {
+	node := newTestNode()
+	node.IdentityRegistry["a1b2c3d4e5f60001"] = IdentityTransaction{QuidID: "a1b2c3d4e5f60001"}
+	node.TrustRegistry["a1b2c3d4e5f60002"] = map[string]float64{"a1b2c3d4e5f60003": 0.5}
+
+	mf, ok := node.RegistryMembershipFilter(MembershipRegistryIdentity)
+	if !ok {
+		t.Fatal("identity registry should have a filter")
+	}
+	f := decodeMembershipFilter(t, mf)
+	if !mustMatch(t, f, MembershipQuidPrefix+"a1b2c3d4e5f60001") {
+		t.Error("known identity not matched")
+	}
+	if mustMatch(t, f, MembershipQuidPrefix+"a1b2c3d4e5f60002") {
+		t.Error("quid absent from the identity registry matched")
+	}
+	if mustMatch(t, f, MembershipAssetPrefix+"a1b2c3d4e5f60001") {
+		t.Error("asset prefix should not match a quid entry")
+	}
+
+	mf, _ = node.RegistryMembershipFilter(MembershipRegistryTrust)
+	f = decodeMembershipFilter(t, mf)
+	for _, q := range []string{"a1b2c3d4e5f60002", "a1b2c3d4e5f60003"} {
+		if !mustMatch(t, f, MembershipQuidPrefix+q) {
+			t.Errorf("trust edge end %s not matched", q)
+		}
+	}
+
+	if _, ok := node.RegistryMembershipFilter("nonsense"); ok {
+		t.Error("unknown registry should not have a filter")
+	}
+}
+
+func TestRegistryMembershipFilter_RebuildsOnMutation(t *testing.T) {
+	node := newTestNode()
+	first, _ := node.RegistryMembershipFilter(MembershipRegistryTitle)
+	again, _ := node.RegistryMembershipFilter(MembershipRegistryTitle)
+	if first != again {
+		t.Error("unchanged registry should serve the cached filter")
+	}
+
+	node.TitleRegistryMutex.Lock()
+	node.TitleRegistry["asset-filter-1"] = TitleTransaction{AssetID: "asset-filter-1"}
+	node.TitleRegistryMutex.Unlock()
+	node.bumpRegistryVersion()
+
+	mf, _ := node.RegistryMembershipFilter(MembershipRegistryTitle)
+	if mf.N != first.N+1 || mf.Key == first.Key {
+		t.Fatalf("expected a rebuilt filter with one more item, got N=%d (was %d)", mf.N, first.N)
+	}
+	if !mustMatch(t, decodeMembershipFilter(t, mf), MembershipAssetPrefix+"asset-filter-1") {
+		t.Error("new asset not matched")
+	}
+}
+
+func TestGetBlockFilterHandler(t *testing.T) {
+	node := newTestNode()
+	node.BlockchainMutex.Lock()
+	block := Block{
+		Index: node.Blockchain[len(node.Blockchain)-1].Index + 1,
+		Hash:  "00ff00ff00ff00ff00ff00ff00ff00ff",
+		Transactions: []interface{}{
+			TrustTransaction{Truster: "a1b2c3d4e5f60010", Trustee: "a1b2c3d4e5f60011", TrustLevel: 0.7},
+			TitleTransaction{AssetID: "asset-in-block", Owners: []OwnershipStake{{OwnerID: "a1b2c3d4e5f60012", Percentage: 100}}},
+		},
+	}
+	node.Blockchain = append(node.Blockchain, block)
+	node.BlockchainMutex.Unlock()
+	router := setupTestRouter(node)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/filters/block/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data MembershipFilter `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.BlockHash != block.Hash || resp.Data.Key != block.Hash {
+		t.Errorf("filter should be keyed by the block hash: %+v", resp.Data)
+	}
+	f := decodeMembershipFilter(t, resp.Data)
+	for _, item := range []string{
+		MembershipQuidPrefix + "a1b2c3d4e5f60010",
+		MembershipQuidPrefix + "a1b2c3d4e5f60011",
+		MembershipQuidPrefix + "a1b2c3d4e5f60012",
+		MembershipAssetPrefix + "asset-in-block",
+	} {
+		if !mustMatch(t, f, item) {
+			t.Errorf("%s not matched", item)
+		}
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/filters/block/99":        http.StatusNotFound,
+		"/api/v1/filters/block/x":         http.StatusBadRequest,
+		"/api/v1/filters/registry/trust":  http.StatusOK,
+		"/api/v1/filters/registry/blocks": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
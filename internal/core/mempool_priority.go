@@ -0,0 +1,96 @@
+// Mempool priority lanes.
+//
+// When a domain has more pending transactions than fit in one block
+// (block_limits.go), the block used to take them in canonical order,
+// so which ones waited came down to how their type names sorted. A
+// burst of bulk trust attestations could hold back identity updates
+// and title transfers, which usually have someone waiting on them.
+//
+// GenerateBlock now ranks the candidates before filling the block:
+//
+//	priority = type weight + MempoolCreatorTrustWeight × creator trust
+//
+// The type weight comes from mempool_type_priorities (unlisted types
+// weigh 1). Creator trust is the relational trust in the creator
+// FilterTransactionsForBlock already computed, so ranking costs no
+// extra trust queries. One sender's transactions of one type are
+// ranked together at the highest priority among them, and equal
+// priorities keep canonical order, so a sender's nonces stay in
+// sequence across blocks.
+//
+// Priority only decides what makes it into a block. The block itself
+// is still sealed in canonical order.
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// mempoolTypePriorities converts configured type weights, keyed by
+// type name in any case, to the node's lookup table.
+func mempoolTypePriorities(weights map[string]float64) map[TransactionType]float64 {
+	if len(weights) == 0 {
+		return nil
+	}
+	out := make(map[TransactionType]float64, len(weights))
+	for name, w := range weights {
+		out[TransactionType(strings.ToUpper(name))] = w
+	}
+	return out
+}
+
+// txPriority is the inclusion priority of a transaction of txType
+// whose creator this node trusts at creatorTrust.
+func (node *QuidnugNode) txPriority(txType TransactionType, creatorTrust float64) float64 {
+	p := 1.0
+	if w, ok := node.MempoolTypePriorities[txType]; ok {
+		p = w
+	}
+	if node.MempoolCreatorTrustWeight > 0 {
+		p += node.MempoolCreatorTrustWeight * creatorTrust
+	}
+	return p
+}
+
+// rankByPriority returns txs ordered highest priority first, ties in
+// canonical order. creatorTrust[i] is the trust in txs[i]'s creator.
+func (node *QuidnugNode) rankByPriority(txs []interface{}, creatorTrust []float64) []interface{} {
+	type ranked struct {
+		tx       interface{}
+		key      txOrderKey
+		priority float64
+	}
+	rs := make([]ranked, len(txs))
+	for i, tx := range txs {
+		key := canonicalTxKey(tx)
+		var trust float64
+		if i < len(creatorTrust) {
+			trust = creatorTrust[i]
+		}
+		rs[i] = ranked{tx: tx, key: key, priority: node.txPriority(TransactionType(key.txType), trust)}
+	}
+	// A lane is one sender's transactions of one type.
+	type lane struct{ txType, sender string }
+	lanes := make(map[lane]float64)
+	for _, r := range rs {
+		l := lane{r.key.txType, r.key.sender}
+		if p, ok := lanes[l]; !ok || r.priority > p {
+			lanes[l] = r.priority
+		}
+	}
+	for i := range rs {
+		rs[i].priority = lanes[lane{rs[i].key.txType, rs[i].key.sender}]
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].priority != rs[j].priority {
+			return rs[i].priority > rs[j].priority
+		}
+		return rs[i].key.less(rs[j].key)
+	})
+	out := make([]interface{}, len(rs))
+	for i, r := range rs {
+		out[i] = r.tx
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package core
+
+import "testing"
+
+func TestRankByPriority_TypeWeights(t *testing.T) {
+	node := newTestNode()
+	// Canonical order alone would seal IDENTITY before TRUST.
+	node.MempoolTypePriorities = mempoolTypePriorities(map[string]float64{"trust": 3})
+	node.BlockMaxTransactions = 1
+
+	identity := IdentityTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "id-1",
+			Type:        TxTypeIdentity,
+			TrustDomain: "test.domain.com",
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		QuidID:      "0000000000000009",
+		Creator:     node.NodeID,
+		UpdateNonce: 1,
+	}
+	node.PendingTxs = []interface{}{orderTestTrust(node, "t-1", 1), identity}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 || canonicalTxKey(block.Transactions[0]).id != "t-1" {
+		t.Fatalf("expected the weighted trust transaction to go first, got %+v", block.Transactions)
+	}
+}
+
+func TestRankByPriority_CreatorTrust(t *testing.T) {
+	node := newTestNode()
+	node.MempoolCreatorTrustWeight = 1
+	a := TrustTransaction{BaseTransaction: BaseTransaction{ID: "a", Type: TxTypeTrust, PublicKey: "aa"}}
+	b := TrustTransaction{BaseTransaction: BaseTransaction{ID: "b", Type: TxTypeTrust, PublicKey: "bb"}}
+
+	for _, tc := range []struct {
+		trust []float64
+		first string
+	}{
+		{[]float64{0.1, 0.9}, "b"},
+		{[]float64{0.9, 0.1}, "a"},
+	} {
+		got := node.rankByPriority([]interface{}{a, b}, tc.trust)
+		if id := canonicalTxKey(got[0]).id; id != tc.first {
+			t.Errorf("trust %v: %s ranked first, want %s", tc.trust, id, tc.first)
+		}
+	}
+}
+
+func TestRankByPriority_LaneKeepsNonceOrder(t *testing.T) {
+	node := newTestNode()
+	node.MempoolCreatorTrustWeight = 1
+	other := TrustTransaction{BaseTransaction: BaseTransaction{ID: "o", Type: TxTypeTrust, PublicKey: "bb"}}
+	first := orderTestTrust(node, "t-1", 1)
+	second := orderTestTrust(node, "t-2", 2)
+
+	// The later nonce carries the higher trust; the whole lane is
+	// lifted with it and keeps its order.
+	got := node.rankByPriority([]interface{}{second, other, first}, []float64{0.9, 0.5, 0.1})
+	var ids []string
+	for _, tx := range got {
+		ids = append(ids, canonicalTxKey(tx).id)
+	}
+	if ids[0] != "t-1" || ids[1] != "t-2" || ids[2] != "o" {
+		t.Fatalf("order = %v, want [t-1 t-2 o]", ids)
+	}
+}
@@ -0,0 +1,466 @@
+// Package core — mempool_sync.go
+//
+// Pending-transaction synchronization between peers.
+//
+// Transactions reach other validators only through the fire-and-
+// forget push in BroadcastTransaction, so a node that was down or
+// had not yet joined when a transaction went out never sees it
+// until a block seals it. Every mempool_sync_interval the node
+// now, per supported trust domain:
+//
+//  1. asks up to mempoolSyncPeers of the domain's best-scoring
+//     peers for the IDs in their pending pool
+//     (GET /api/v1/mempool/{domain}/ids);
+//  2. drops the IDs it already holds, pending or recently sealed;
+//  3. fetches the rest in batches of mempoolSyncBatch
+//     (POST /api/v1/mempool/{domain}/transactions) and admits each
+//     one through the same Add*Transaction path as a client
+//     submission, so signatures, nonces and rate limits all apply.
+//
+// Only the types BroadcastTransaction gossips are exchanged, and a
+// served transaction whose ID or domain doesn't match the request
+// is discarded.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// mempoolSyncPeers is how many peers one domain pass asks.
+	mempoolSyncPeers = 3
+
+	// mempoolSyncBatch caps the IDs in one fetch request.
+	mempoolSyncBatch = 256
+
+	// mempoolSyncSealedWindow is how many recent blocks are
+	// checked for already-sealed IDs before fetching.
+	mempoolSyncSealedWindow = 256
+)
+
+// MempoolTxIDsResponse is the body of GET /mempool/{domain}/ids.
+type MempoolTxIDsResponse struct {
+	Domain string   `json:"domain"`
+	IDs    []string `json:"ids"`
+}
+
+// MempoolFetchRequest is the body of POST /mempool/{domain}/transactions.
+type MempoolFetchRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// MempoolTx is one pending transaction with the type segment of its
+// /transactions/{type} route.
+type MempoolTx struct {
+	Type string          `json:"type"`
+	Tx   json.RawMessage `json:"tx"`
+}
+
+// MempoolFetchResponse is the reply to a MempoolFetchRequest. IDs
+// the peer no longer holds are left out.
+type MempoolFetchResponse struct {
+	Domain       string      `json:"domain"`
+	Transactions []MempoolTx `json:"transactions"`
+}
+
+// pendingTxDomain is the domain a pending transaction syncs under.
+func pendingTxDomain(base BaseTransaction) string {
+	if base.TrustDomain == "" {
+		return "default"
+	}
+	return base.TrustDomain
+}
+
+// pendingTxIDs returns the sorted IDs of the gossipable pending
+// transactions in domain.
+func (node *QuidnugNode) pendingTxIDs(domain string) []string {
+	node.PendingTxsMutex.RLock()
+	defer node.PendingTxsMutex.RUnlock()
+	ids := make([]string, 0)
+	for _, tx := range node.PendingTxs {
+		base, _, ok := txGossipRoute(tx)
+		// Atomic groups travel whole (atomic_group.go).
+		if !ok || base.ID == "" || base.AtomicGroup != nil || pendingTxDomain(base) != domain {
+			continue
+		}
+		ids = append(ids, base.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pendingTxsByID returns the pending transactions in domain whose
+// IDs are listed, in the same order.
+func (node *QuidnugNode) pendingTxsByID(domain string, ids []string) ([]MempoolTx, error) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	node.PendingTxsMutex.RLock()
+	found := make(map[string]MempoolTx, len(ids))
+	for _, tx := range node.PendingTxs {
+		base, txType, ok := txGossipRoute(tx)
+		if !ok || !want[base.ID] || pendingTxDomain(base) != domain {
+			continue
+		}
+		raw, err := json.Marshal(tx)
+		if err != nil {
+			node.PendingTxsMutex.RUnlock()
+			return nil, fmt.Errorf("marshal pending transaction %s: %w", base.ID, err)
+		}
+		found[base.ID] = MempoolTx{Type: txType, Tx: raw}
+	}
+	node.PendingTxsMutex.RUnlock()
+
+	out := make([]MempoolTx, 0, len(found))
+	for _, id := range ids {
+		if tx, ok := found[id]; ok {
+			out = append(out, tx)
+			delete(found, id)
+		}
+	}
+	return out, nil
+}
+
+// recentlySealedTxIDs returns the transaction IDs in the last
+// mempoolSyncSealedWindow blocks of domain.
+func (node *QuidnugNode) recentlySealedTxIDs(domain string) map[string]bool {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	sealed := make(map[string]bool)
+	seen := 0
+	for i := len(node.Blockchain) - 1; i >= 0 && seen < mempoolSyncSealedWindow; i-- {
+		b := node.Blockchain[i]
+		if b.TrustProof.TrustDomain != domain {
+			continue
+		}
+		seen++
+		for _, tx := range b.Transactions {
+			if id := canonicalTxKey(tx).id; id != "" {
+				sealed[id] = true
+			}
+		}
+	}
+	return sealed
+}
+
+// admitSyncedTx decodes raw as T and hands it to add.
+func admitSyncedTx[T any](raw json.RawMessage, add func(T) (string, error)) (string, error) {
+	var tx T
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+	return add(tx)
+}
+
+// admitSyncedTransaction admits a transaction fetched from a peer's
+// pending pool through the admission path for its type.
+func (node *QuidnugNode) admitSyncedTransaction(txType string, raw json.RawMessage) (string, error) {
+	switch txType {
+	case "trust":
+		return admitSyncedTx(raw, node.AddTrustTransaction)
+	case "identity":
+		return admitSyncedTx(raw, node.AddIdentityTransaction)
+	case "title":
+		return admitSyncedTx(raw, node.AddTitleTransaction)
+	case "event":
+		return admitSyncedTx(raw, node.AddEventTransaction)
+	case "node-advertisement":
+		return admitSyncedTx(raw, node.AddNodeAdvertisementTransaction)
+	case "moderation":
+		return admitSyncedTx(raw, node.AddModerationActionTransaction)
+	case "name":
+		return admitSyncedTx(raw, node.AddNameRegistrationTransaction)
+	case "lien":
+		return admitSyncedTx(raw, node.AddLienTransaction)
+	case "succession":
+		return admitSyncedTx(raw, node.AddSuccessionTransaction)
+	case "misbehavior":
+		return admitSyncedTx(raw, node.AddMisbehaviorReportTransaction)
+	case "domain-join":
+		return admitSyncedTx(raw, node.AddDomainJoinTransaction)
+	case "checkpoint":
+		return admitSyncedTx(raw, node.AddCheckpointTransaction)
+	case "domain-control":
+		return admitSyncedTx(raw, node.AddDomainControlTransaction)
+	case "transfer-approval":
+		return admitSyncedTx(raw, node.AddTransferApprovalTransaction)
+	case "title-restructure":
+		return admitSyncedTx(raw, node.AddTitleRestructureTransaction)
+	case "title-dispute":
+		return admitSyncedTx(raw, node.AddTitleDisputeTransaction)
+	case "custom":
+		return admitSyncedTx(raw, node.AddCustomTransaction)
+	case "dsr":
+		return admitSyncedTx(raw, node.AddDataSubjectRequestTransaction)
+	case "consent-grant":
+		return admitSyncedTx(raw, node.AddConsentGrantTransaction)
+	case "consent-withdraw":
+		return admitSyncedTx(raw, node.AddConsentWithdrawTransaction)
+	case "processing-restriction":
+		return admitSyncedTx(raw, node.AddProcessingRestrictionTransaction)
+	case "dsr-compliance":
+		return admitSyncedTx(raw, node.AddDSRComplianceTransaction)
+	default:
+		return "", fmt.Errorf("unsupported transaction type %q", txType)
+	}
+}
+
+// mempoolSyncPeersFor returns the best-scoring peers to sync domain
+// with: its known validators plus any peer advertising it.
+func (node *QuidnugNode) mempoolSyncPeersFor(domain string) []Node {
+	candidates := append(node.GetTrustDomainNodes(domain), node.findNodesForExactDomain(domain)...)
+	seen := make(map[string]bool, len(candidates))
+	peers := make([]Node, 0, len(candidates))
+	for _, n := range candidates {
+		if seen[n.ID] || n.ID == node.NodeID || n.Address == "" {
+			continue
+		}
+		seen[n.ID] = true
+		if !n.servesBlocks() || !n.protocolCompatible() || node.peerBanned(n.ID) {
+			continue
+		}
+		peers = append(peers, n)
+	}
+	peers = node.preferByScore(peers)
+	if len(peers) > mempoolSyncPeers {
+		peers = peers[:mempoolSyncPeers]
+	}
+	return peers
+}
+
+// mempoolSyncDomains returns the domains this node holds a pool for.
+func (node *QuidnugNode) mempoolSyncDomains() []string {
+	node.TrustDomainsMutex.RLock()
+	domains := make([]string, 0, len(node.TrustDomains))
+	for name := range node.TrustDomains {
+		domains = append(domains, name)
+	}
+	node.TrustDomainsMutex.RUnlock()
+	sort.Strings(domains)
+	out := domains[:0]
+	for _, name := range domains {
+		if node.IsDomainSupported(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// runMempoolSyncLoop syncs pending pools with peers every interval,
+// starting shortly after boot so a restarted node catches up before
+// its next block. A non-positive interval disables it.
+func (node *QuidnugNode) runMempoolSyncLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	first := 5 * time.Second
+	if first > interval {
+		first = interval
+	}
+	timer := time.NewTimer(first)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		node.syncMempoolOnce(ctx)
+		timer.Reset(interval)
+	}
+}
+
+// syncMempoolOnce runs one pass over every supported domain and
+// returns how many transactions were admitted.
+func (node *QuidnugNode) syncMempoolOnce(ctx context.Context) int {
+	admitted := 0
+	for _, domain := range node.mempoolSyncDomains() {
+		for _, peer := range node.mempoolSyncPeersFor(domain) {
+			if ctx.Err() != nil {
+				return admitted
+			}
+			n, err := node.syncMempoolWithPeer(ctx, domain, peer)
+			admitted += n
+			if err != nil {
+				node.recordPeerScore(peer.ID, EventClassQuery, false, fmt.Sprintf("mempool sync %s: %v", domain, err))
+				logger.Debug("Mempool sync with peer failed",
+					"domain", domain, "nodeQuid", peer.ID, "error", err)
+				continue
+			}
+			node.recordPeerScore(peer.ID, EventClassQuery, true, "")
+		}
+	}
+	if admitted > 0 {
+		logger.Info("Mempool sync admitted transactions", "count", admitted)
+	}
+	return admitted
+}
+
+// syncMempoolWithPeer fetches and admits the transactions peer holds
+// for domain that this node lacks. Transactions that fail admission
+// are skipped; only transport and decoding problems are errors.
+func (node *QuidnugNode) syncMempoolWithPeer(ctx context.Context, domain string, peer Node) (int, error) {
+	var ids MempoolTxIDsResponse
+	if err := node.peerJSONRequest(ctx, peer, "GET", "/api/v1/mempool/"+url.PathEscape(domain)+"/ids", nil, &ids); err != nil {
+		return 0, err
+	}
+	if len(ids.IDs) == 0 {
+		return 0, nil
+	}
+
+	have := node.recentlySealedTxIDs(domain)
+	for _, id := range node.pendingTxIDs(domain) {
+		have[id] = true
+	}
+	missing := make([]string, 0)
+	for _, id := range ids.IDs {
+		if id != "" && !have[id] {
+			have[id] = true
+			missing = append(missing, id)
+		}
+	}
+
+	admitted := 0
+	for start := 0; start < len(missing); start += mempoolSyncBatch {
+		end := start + mempoolSyncBatch
+		if end > len(missing) {
+			end = len(missing)
+		}
+		batch := missing[start:end]
+		var fetched MempoolFetchResponse
+		if err := node.peerJSONRequest(ctx, peer, "POST", "/api/v1/mempool/"+url.PathEscape(domain)+"/transactions",
+			MempoolFetchRequest{IDs: batch}, &fetched); err != nil {
+			return admitted, err
+		}
+		requested := make(map[string]bool, len(batch))
+		for _, id := range batch {
+			requested[id] = true
+		}
+		for _, mt := range fetched.Transactions {
+			var base BaseTransaction
+			if err := json.Unmarshal(mt.Tx, &base); err != nil || !requested[base.ID] || pendingTxDomain(base) != domain {
+				continue
+			}
+			delete(requested, base.ID)
+			if _, err := node.admitSyncedTransaction(mt.Type, mt.Tx); err != nil {
+				logger.Debug("Synced transaction not admitted",
+					"domain", domain, "txId", base.ID, "type", mt.Type, "error", err)
+				continue
+			}
+			admitted++
+		}
+	}
+	return admitted, nil
+}
+
+// peerJSONRequest calls a peer's JSON endpoint and decodes the data
+// field of the response envelope into out. Bodies travel as CBOR
+// when wireFormatFor picks it for the peer.
+func (node *QuidnugNode) peerJSONRequest(ctx context.Context, peer Node, method, path string, body, out interface{}) error {
+	safeAddr, err := node.validatePeerAddress(peer.Address)
+	if err != nil {
+		return err
+	}
+	format := node.wireFormatFor(peer)
+	var (
+		reqBody     io.Reader
+		contentType string
+	)
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		if raw, contentType, err = encodeWireBody(format, raw); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(raw)
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, method, "http://"+safeAddr.String()+path, reqBody) // #nosec -- URL built from sanitized address
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	setWireAccept(req, format)
+	setSyncAcceptEncoding(req)
+	resp, err := node.httpClient.Do(req) // #nosec -- request URL built from sanitized address; transport also enforces safeDialContext
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status %d", resp.StatusCode)
+	}
+	raw, err := readSyncBody(resp, syncEndpointLabel(path), 16<<20)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if raw, err = decodeWireBody(resp.Header, raw); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	var envelope struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !envelope.Success {
+		return fmt.Errorf("unsuccessful response from node")
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// GetMempoolTxIDsHandler lists the IDs of the domain's pending
+// transactions.
+func (node *QuidnugNode) GetMempoolTxIDsHandler(w http.ResponseWriter, r *http.Request) {
+	domain := mux.Vars(r)["domain"]
+	if !node.IsDomainSupported(domain) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "domain not supported by this node")
+		return
+	}
+	WriteSuccess(w, MempoolTxIDsResponse{Domain: domain, IDs: node.pendingTxIDs(domain)})
+}
+
+// FetchMempoolTxsHandler returns the listed pending transactions of
+// the domain.
+func (node *QuidnugNode) FetchMempoolTxsHandler(w http.ResponseWriter, r *http.Request) {
+	domain := mux.Vars(r)["domain"]
+	if !node.IsDomainSupported(domain) {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "domain not supported by this node")
+		return
+	}
+	var req MempoolFetchRequest
+	if err := DecodeJSONBody(w, r, &req); err != nil {
+		return
+	}
+	if len(req.IDs) > mempoolSyncBatch {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST",
+			fmt.Sprintf("at most %d ids per request", mempoolSyncBatch))
+		return
+	}
+	txs, err := node.pendingTxsByID(domain, req.IDs)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	WriteSuccess(w, MempoolFetchResponse{Domain: domain, Transactions: txs})
+}
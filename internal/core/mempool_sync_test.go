@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSyncMempool_FetchesMissingTransactions(t *testing.T) {
+	src := newTestNode()
+	tx := signTrustTx(src, TrustTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "trust-sync-1",
+			Type:        TxTypeTrust,
+			TrustDomain: "test.domain.com",
+			Timestamp:   1_700_000_000,
+		},
+		Truster:    src.NodeID,
+		Trustee:    "abcdef1234567890",
+		TrustLevel: 0.8,
+		Nonce:      1,
+	})
+	txID, err := src.AddTrustTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(setupTestRouter(src))
+	t.Cleanup(srv.Close)
+
+	dst := newTestNode()
+	dst.KnownNodesMutex.Lock()
+	dst.KnownNodes[src.NodeID] = Node{
+		ID:           src.NodeID,
+		Address:      strings.TrimPrefix(srv.URL, "http://"),
+		TrustDomains: []string{"test.domain.com"},
+	}
+	dst.KnownNodesMutex.Unlock()
+
+	if n := dst.syncMempoolOnce(t.Context()); n != 1 {
+		t.Fatalf("first pass admitted %d, want 1", n)
+	}
+	if ids := dst.pendingTxIDs("test.domain.com"); len(ids) != 1 || ids[0] != txID {
+		t.Fatalf("pending = %v, want [%s]", ids, txID)
+	}
+	if n := dst.syncMempoolOnce(t.Context()); n != 0 {
+		t.Errorf("second pass admitted %d, want 0", n)
+	}
+}
+
+func TestSyncMempool_SkipsSealedTransactions(t *testing.T) {
+	node := newTestNode()
+	node.BlockchainMutex.Lock()
+	node.Blockchain = append(node.Blockchain, Block{
+		Index:        1,
+		TrustProof:   TrustProof{TrustDomain: "test.domain.com"},
+		Transactions: []interface{}{map[string]interface{}{"id": "sealed-1", "type": "TRUST"}},
+	})
+	node.BlockchainMutex.Unlock()
+
+	sealed := node.recentlySealedTxIDs("test.domain.com")
+	if !sealed["sealed-1"] {
+		t.Errorf("sealed = %v", sealed)
+	}
+	if len(node.recentlySealedTxIDs("other.domain")) != 0 {
+		t.Error("other domain's blocks counted")
+	}
+}
+
+func TestFetchMempoolTxsHandler(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	tx := signTrustTx(node, TrustTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "trust-sync-1",
+			Type:        TxTypeTrust,
+			TrustDomain: "test.domain.com",
+			Timestamp:   1_700_000_000,
+		},
+		Truster:    node.NodeID,
+		Trustee:    "abcdef1234567890",
+		TrustLevel: 0.5,
+		Nonce:      1,
+	})
+	txID, err := node.AddTrustTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(MempoolFetchRequest{IDs: []string{"unknown", txID}})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/mempool/test.domain.com/transactions", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data MempoolFetchResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Transactions) != 1 || resp.Data.Transactions[0].Type != "trust" {
+		t.Fatalf("transactions = %+v", resp.Data.Transactions)
+	}
+
+	ids := make([]string, mempoolSyncBatch+1)
+	body, _ = json.Marshal(MempoolFetchRequest{IDs: ids})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/mempool/test.domain.com/transactions", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch got %d", w.Code)
+	}
+}
@@ -136,6 +136,38 @@ var (
 		Name: "quidnug_block_missing_tx_root_rejected_total",
 		Help: "Blocks rejected post-fork for empty TransactionsRoot.",
 	})
+	blockNonCanonicalOrderRejectedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "quidnug_block_non_canonical_order_rejected_total",
+		Help: "Blocks rejected post-fork for transactions out of canonical order.",
+	})
+	identityConflictsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_identity_conflicts_total",
+		Help: "Identity updates rejected for reusing a claimed UpdateNonce, by detection stage.",
+	}, []string{"stage"})
+	blocksPrunedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_blocks_pruned_total",
+		Help: "Blocks moved to the block archive and reduced to headers, by domain.",
+	}, []string{"domain"})
+	blockArchiveFetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_block_archive_fetch_total",
+		Help: "Pruned blocks fetched back from the block archive, by result.",
+	}, []string{"result"})
+	replicaUpstreamFailoversTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_replica_upstream_failovers_total",
+		Help: "Replica switches away from a failing upstream, by the upstream left.",
+	}, []string{"upstream"})
+	blockProductionDeferredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_block_production_deferred_total",
+		Help: "Block generation attempts skipped because the node is behind on a domain, by domain and reason.",
+	}, []string{"domain", "reason"})
+	blockOversizeRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quidnug_block_oversize_rejected_total",
+		Help: "Received blocks held as untrusted for exceeding the domain's block limits, by limit (transactions|bytes).",
+	}, []string{"limit"})
+	blockProductionBackpressure = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "quidnug_block_production_backpressure",
+		Help: "1 while block generation in a domain is held back, 0 otherwise.",
+	}, []string{"domain"})
 )
 
 // RecordBlockGenerated records a block generation event
@@ -191,3 +223,11 @@ func RecordRateLimitDenial(layer string) {
 func UpdateConnectedNodesGauge(count int) {
 	connectedNodesGauge.Set(float64(count))
 }
+
+// peerRequestsRejected counts outbound peer requests the
+// per-peer guard refused before dialing, by reason ("circuit-open"
+// or "busy"). See peer_breaker.go.
+var peerRequestsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "quidnug_peer_requests_rejected_total",
+	Help: "Outbound peer requests refused by the per-peer circuit breaker or concurrency limit, by reason.",
+}, []string{"reason"})
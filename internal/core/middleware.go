@@ -18,6 +18,7 @@ import (
 	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/quidnug/quidnug/internal/config"
 	"github.com/quidnug/quidnug/internal/ratelimit"
 )
 
@@ -195,12 +196,103 @@ func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) err
 	return nil
 }
 
+// CORSPolicy is the cross-origin policy NewCORSMiddleware applies.
+// An empty AllowedOrigins denies every cross-origin request; "*"
+// allows any origin.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// corsExposedHeaders are the response headers browser clients may
+// read: request tracing, conditional GETs and signed responses.
+const corsExposedHeaders = "ETag, X-Request-ID, " + HeaderResponseNodeQuid + ", " + HeaderResponsePublicKey + ", " + HeaderResponseSignature
+
+// newCORSPolicy builds the node's policy from config, filling in
+// the default methods, headers and max age where unset.
+func newCORSPolicy(cfg *config.Config) CORSPolicy {
+	p := CORSPolicy{
+		AllowedOrigins:   append([]string(nil), cfg.CORSAllowedOrigins...),
+		AllowedMethods:   append([]string(nil), cfg.CORSAllowedMethods...),
+		AllowedHeaders:   append([]string(nil), cfg.CORSAllowedHeaders...),
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+	if len(p.AllowedMethods) == 0 {
+		p.AllowedMethods = config.DefaultCORSAllowedMethods
+	}
+	if len(p.AllowedHeaders) == 0 {
+		p.AllowedHeaders = config.DefaultCORSAllowedHeaders
+	}
+	if p.MaxAge == 0 {
+		p.MaxAge = config.DefaultCORSMaxAge
+	}
+	if p.AllowCredentials && originAllowed("*", p.AllowedOrigins) {
+		logger.Warn("CORS credentials are never allowed for a wildcard origin; ignoring cors_allow_credentials")
+		p.AllowCredentials = false
+	}
+	return p
+}
+
+// serve writes the CORS headers for r and reports whether r was a
+// preflight that has been answered.
+func (p CORSPolicy) serve(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	// Always advertise that we may serve different bodies
+	// based on Origin so caches don't merge entries across
+	// origins.
+	w.Header().Add("Vary", "Origin")
+	if origin != "" && len(p.AllowedOrigins) > 0 && originAllowed(origin, p.AllowedOrigins) {
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+		h.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+		h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+		if p.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+	if r.Method == http.MethodOptions {
+		// Preflight; short-circuit the rest of the chain.
+		// Even when the origin is not allowed we return 204
+		// without CORS headers, which makes the browser
+		// reject the request cleanly rather than letting
+		// it through.
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	return false
+}
+
+// NewCORSMiddleware applies policy to every request. Preflight
+// OPTIONS requests are answered with 204 and short-circuit the
+// rest of the chain.
+//
+// Wired into the middleware chain outer than NodeAuth so
+// preflights for POST endpoints (which would otherwise fail
+// the signature check on a body-less OPTIONS request) are
+// answered cleanly.
+func NewCORSMiddleware(policy CORSPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy.serve(w, r) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // CORSMiddleware applies cross-origin headers based on the
 // EXPLORER_CORS_ORIGINS env var: a comma-separated list of
 // allowed Origin values, or "*" to allow any origin. Default
-// is empty (deny). Preflight OPTIONS requests are answered
-// with 204 + the appropriate headers and short-circuit the
-// rest of the chain.
+// is empty (deny), with the default methods and headers. Nodes
+// use NewCORSMiddleware with the configured policy; this form
+// remains for callers that mount the API without a config.
 //
 // QDP-0025 §10.2: the Quidnug Explorer SPA hosted on a
 // different origin than the node (e.g., explorer.quidnug.com
@@ -211,38 +303,15 @@ func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) err
 // origins.
 //
 // Example: EXPLORER_CORS_ORIGINS="http://localhost:5173,http://localhost:5174,https://explorer.quidnug.com"
-//
-// Wired into the middleware chain outer than NodeAuth so
-// preflights for POST endpoints (which would otherwise fail
-// the signature check on a body-less OPTIONS request) are
-// answered cleanly.
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		allowed := loadCORSOrigins()
-		origin := r.Header.Get("Origin")
-		// Always advertise that we may serve different bodies
-		// based on Origin so caches don't merge entries across
-		// origins.
-		w.Header().Add("Vary", "Origin")
-		if origin != "" && len(allowed) > 0 && originAllowed(origin, allowed) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			// Headers the explorer's API client emits:
-			// Authorization (for the workspace API token),
-			// Content-Type (for POST bodies), X-Request-ID
-			// (request tracing).
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-			// Cache preflight result for 5 minutes so a busy
-			// explorer session doesn't preflight every request.
-			w.Header().Set("Access-Control-Max-Age", "300")
+		policy := CORSPolicy{
+			AllowedOrigins: loadCORSOrigins(),
+			AllowedMethods: config.DefaultCORSAllowedMethods,
+			AllowedHeaders: config.DefaultCORSAllowedHeaders,
+			MaxAge:         config.DefaultCORSMaxAge,
 		}
-		if r.Method == http.MethodOptions {
-			// Preflight; short-circuit the rest of the chain.
-			// Even when the origin is not allowed we return 204
-			// without CORS headers, which makes the browser
-			// reject the request cleanly rather than letting
-			// it through.
-			w.WriteHeader(http.StatusNoContent)
+		if policy.serve(w, r) {
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -309,7 +378,8 @@ type contextKey string
 
 const RequestIDContextKey contextKey = "requestID"
 
-// RequestIDMiddleware generates a UUID for each request and adds it to context and response header
+// RequestIDMiddleware generates a UUID for each request and adds it to context and response header.
+// The context also carries a logger tagged with the ID (see loggerFrom).
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("X-Request-ID")
@@ -320,6 +390,7 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("X-Request-ID", requestID)
 
 		ctx := context.WithValue(r.Context(), RequestIDContextKey, requestID)
+		ctx = withLogger(ctx, logger.With("requestId", requestID))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -363,6 +434,12 @@ func (w *statusResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer
+// so streaming handlers can flush and adjust deadlines.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Validation helpers
 
 var quidIDRegex = regexp.MustCompile(`^[a-f0-9]{16}$`)
@@ -422,7 +499,8 @@ func NodeAuthMiddleware(next http.Handler) http.Handler {
 func isNodeToNodeEndpoint(path string) bool {
 	return strings.Contains(path, "/transactions/trust") ||
 		strings.Contains(path, "/transactions/identity") ||
-		strings.Contains(path, "/transactions/title")
+		strings.Contains(path, "/transactions/title") ||
+		strings.HasSuffix(path, "/inv")
 }
 
 // verifyNodeAuth verifies the authentication of a node-to-node request.
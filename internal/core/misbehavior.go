@@ -0,0 +1,456 @@
+// Package core — validator misbehavior reports.
+//
+// A MISBEHAVIOR_REPORT carries signed blocks proving a validator
+// broke the rules:
+//
+//   - equivocation: two different blocks for the same domain and
+//     height, both signed by the validator;
+//   - invalid-block: one signed block with a fault any node can
+//     see from the block alone (a transaction for another domain,
+//     a transaction without a type or ID, or transactions out of
+//     canonical order once that fork is active).
+//
+// Faults that depend on registry state, such as a replayed nonce,
+// are deliberately not reportable: state moves on, and an honest
+// block judged against later state could look invalid.
+//
+// Applying a report cuts the reporter's direct trust in the
+// offender to zero, if the reporter had any. When reporters that
+// are themselves validators of the domain hold at least
+// ValidatorRemovalQuorum of the remaining validator weight, the
+// offender is removed from the domain's validator set.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go                : TxTypeMisbehaviorReport const
+//   - misbehavior.go          : this file — struct, registry, validator
+//   - transactions.go         : AddMisbehaviorReportTransaction (mempool)
+//   - validation.go           : block dispatch
+//   - registry.go             : dispatch into applyMisbehaviorReport
+//   - handlers_misbehavior.go : submit + list endpoints
+//   - node.go                 : MisbehaviorRegistry field + init
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Misbehavior kinds.
+const (
+	MisbehaviorEquivocation = "equivocation"
+	MisbehaviorInvalidBlock = "invalid-block"
+)
+
+// ValidatorRemovalQuorum is the fraction of the other validators'
+// weight whose reports remove an offending validator.
+const ValidatorRemovalQuorum = 2.0 / 3.0
+
+// MisbehaviorReportTransaction accuses ValidatorQuid of signing
+// the blocks in Evidence. ReporterQuid signs the transaction.
+type MisbehaviorReportTransaction struct {
+	BaseTransaction
+
+	ReporterQuid  string  `json:"reporterQuid"`
+	ValidatorQuid string  `json:"validatorQuid"`
+	Kind          string  `json:"kind"`
+	Evidence      []Block `json:"evidence"`
+
+	Nonce int64 `json:"nonce"`
+}
+
+// MisbehaviorRecord is the registry view of an accepted report.
+type MisbehaviorRecord struct {
+	ReportID      string `json:"reportId"`
+	TrustDomain   string `json:"trustDomain"`
+	ReporterQuid  string `json:"reporterQuid"`
+	ValidatorQuid string `json:"validatorQuid"`
+	Kind          string `json:"kind"`
+	Reason        string `json:"reason"`
+	BlockIndex    int64  `json:"blockIndex"`
+	ReportedAt    int64  `json:"reportedAt"`
+}
+
+// ValidatorRemoval records a validator dropped from a domain by
+// report quorum.
+type ValidatorRemoval struct {
+	TrustDomain   string   `json:"trustDomain"`
+	ValidatorQuid string   `json:"validatorQuid"`
+	Reporters     []string `json:"reporters"`
+	RemovedAt     int64    `json:"removedAt"`
+}
+
+// MisbehaviorRegistry indexes accepted reports.
+type MisbehaviorRegistry struct {
+	mu sync.RWMutex
+
+	// byOffender maps domain → offender → reporter → record. A
+	// reporter counts once per offender; the entry is cleared when
+	// the offender is removed so a later rejoin starts clean.
+	byOffender map[string]map[string]map[string]MisbehaviorRecord
+
+	// history keeps every accepted report, including those
+	// cleared by a removal, per domain in acceptance order.
+	history map[string][]MisbehaviorRecord
+
+	removals map[string][]ValidatorRemoval
+
+	// nonces tracks the highest accepted nonce per reporter.
+	nonces map[string]int64
+}
+
+// NewMisbehaviorRegistry constructs an empty registry.
+func NewMisbehaviorRegistry() *MisbehaviorRegistry {
+	return &MisbehaviorRegistry{
+		byOffender: make(map[string]map[string]map[string]MisbehaviorRecord),
+		history:    make(map[string][]MisbehaviorRecord),
+		removals:   make(map[string][]ValidatorRemoval),
+		nonces:     make(map[string]int64),
+	}
+}
+
+// currentNonce returns the highest accepted nonce for a reporter
+// (0 if none).
+func (r *MisbehaviorRegistry) currentNonce(reporter string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nonces[reporter]
+}
+
+// reported reports whether reporter already has a standing report
+// against offender in domain.
+func (r *MisbehaviorRegistry) reported(domain, offender, reporter string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.byOffender[domain][offender][reporter]
+	return ok
+}
+
+// record stores rec and returns the reporters now standing against
+// the offender. Idempotent on replay.
+func (r *MisbehaviorRegistry) record(rec MisbehaviorRecord, nonce int64) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if nonce > r.nonces[rec.ReporterQuid] {
+		r.nonces[rec.ReporterQuid] = nonce
+	}
+	if r.byOffender[rec.TrustDomain] == nil {
+		r.byOffender[rec.TrustDomain] = make(map[string]map[string]MisbehaviorRecord)
+	}
+	reports := r.byOffender[rec.TrustDomain][rec.ValidatorQuid]
+	if reports == nil {
+		reports = make(map[string]MisbehaviorRecord)
+		r.byOffender[rec.TrustDomain][rec.ValidatorQuid] = reports
+	}
+	if _, seen := reports[rec.ReporterQuid]; !seen {
+		reports[rec.ReporterQuid] = rec
+		r.history[rec.TrustDomain] = append(r.history[rec.TrustDomain], rec)
+	}
+	reporters := make([]string, 0, len(reports))
+	for q := range reports {
+		reporters = append(reporters, q)
+	}
+	sort.Strings(reporters)
+	return reporters
+}
+
+// remove clears the standing reports against an offender and logs
+// the removal.
+func (r *MisbehaviorRegistry) remove(removal ValidatorRemoval) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byOffender[removal.TrustDomain], removal.ValidatorQuid)
+	r.removals[removal.TrustDomain] = append(r.removals[removal.TrustDomain], removal)
+}
+
+// forDomain returns copies of a domain's report history and
+// removals.
+func (r *MisbehaviorRegistry) forDomain(domain string) ([]MisbehaviorRecord, []ValidatorRemoval) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reports := append([]MisbehaviorRecord{}, r.history[domain]...)
+	removals := append([]ValidatorRemoval{}, r.removals[domain]...)
+	return reports, removals
+}
+
+// GetMisbehaviorReports returns the accepted reports and validator
+// removals for a domain.
+func (node *QuidnugNode) GetMisbehaviorReports(domain string) ([]MisbehaviorRecord, []ValidatorRemoval) {
+	if node.MisbehaviorRegistry == nil {
+		return []MisbehaviorRecord{}, []ValidatorRemoval{}
+	}
+	return node.MisbehaviorRegistry.forDomain(domain)
+}
+
+// blockSignedBy reports whether block carries a valid signature by
+// validatorID under pubKey.
+func blockSignedBy(block Block, validatorID, pubKey string) bool {
+	proof := block.TrustProof
+	if proof.ValidatorID != validatorID || len(proof.ValidatorSigs) == 0 {
+		return false
+	}
+	if proof.ValidatorPublicKey != "" && proof.ValidatorPublicKey != pubKey {
+		return false
+	}
+	data := GetBlockSignableData(block)
+	return data != nil && VerifySignature(pubKey, data, proof.ValidatorSigs[0])
+}
+
+// blockFault returns why block is invalid judging by its own
+// content alone, or "" if it is not.
+func blockFault(block Block, requireCanonicalOrder bool) string {
+	domain := block.TrustProof.TrustDomain
+	for i, txInterface := range block.Transactions {
+		raw, err := json.Marshal(txInterface)
+		if err != nil {
+			return fmt.Sprintf("transaction %d does not encode", i)
+		}
+		var base BaseTransaction
+		if err := json.Unmarshal(raw, &base); err != nil || base.Type == "" || base.ID == "" {
+			return fmt.Sprintf("transaction %d is malformed", i)
+		}
+		txDomain := base.TrustDomain
+		if txDomain == "" {
+			txDomain = "default"
+		}
+		if txDomain != domain {
+			return fmt.Sprintf("transaction %s belongs to domain %q", base.ID, txDomain)
+		}
+	}
+	if requireCanonicalOrder && !isCanonicalTxOrder(block.Transactions) {
+		return "transactions out of canonical order"
+	}
+	return ""
+}
+
+// checkMisbehaviorEvidence verifies the evidence against the
+// offender's registered key and returns a description of the
+// fault.
+func (node *QuidnugNode) checkMisbehaviorEvidence(tx MisbehaviorReportTransaction, pubKey string) (string, error) {
+	for i, b := range tx.Evidence {
+		if b.TrustProof.TrustDomain != tx.TrustDomain {
+			return "", fmt.Errorf("evidence block %d is for domain %q", i, b.TrustProof.TrustDomain)
+		}
+		if !blockSignedBy(b, tx.ValidatorQuid, pubKey) {
+			return "", fmt.Errorf("evidence block %d is not signed by %s", i, tx.ValidatorQuid)
+		}
+	}
+	switch tx.Kind {
+	case MisbehaviorEquivocation:
+		if len(tx.Evidence) != 2 {
+			return "", fmt.Errorf("equivocation needs exactly two blocks")
+		}
+		a, b := tx.Evidence[0], tx.Evidence[1]
+		if a.Index != b.Index {
+			return "", fmt.Errorf("evidence blocks are at heights %d and %d", a.Index, b.Index)
+		}
+		if bytes.Equal(GetBlockSignableData(a), GetBlockSignableData(b)) {
+			return "", fmt.Errorf("evidence blocks are the same block")
+		}
+		return fmt.Sprintf("signed two blocks at height %d", a.Index), nil
+	case MisbehaviorInvalidBlock:
+		if len(tx.Evidence) != 1 {
+			return "", fmt.Errorf("invalid-block needs exactly one block")
+		}
+		fault := blockFault(tx.Evidence[0], node.RequireCanonicalTxOrder)
+		if fault == "" {
+			return "", fmt.Errorf("evidence block has no detectable fault")
+		}
+		return fault, nil
+	default:
+		return "", fmt.Errorf("unknown misbehavior kind %q", tx.Kind)
+	}
+}
+
+// ValidateMisbehaviorReportTransaction enforces the report rules.
+// Returns false on any violation; every failure is logged at Warn
+// level.
+func (node *QuidnugNode) ValidateMisbehaviorReportTransaction(tx MisbehaviorReportTransaction) bool {
+	// 1. Domain must exist + be supported, and the accused must
+	// currently validate it.
+	node.TrustDomainsMutex.RLock()
+	domain, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists || !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Misbehavior report for unknown or unsupported domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	pubKey := domain.ValidatorPublicKeys[tx.ValidatorQuid]
+	if _, isValidator := domain.Validators[tx.ValidatorQuid]; !isValidator || pubKey == "" {
+		logger.Warn("Misbehavior report against a non-validator",
+			"validator", tx.ValidatorQuid, "domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Reporter + signer consistency.
+	if !IsValidQuidID(tx.ReporterQuid) || tx.ReporterQuid == tx.ValidatorQuid {
+		logger.Warn("Misbehavior report has invalid ReporterQuid",
+			"reporter", tx.ReporterQuid, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Misbehavior report missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	if !node.signsFor(tx.ReporterQuid, tx.PublicKey, TxTypeMisbehaviorReport, tx.delegationTime()) {
+		logger.Warn("Misbehavior report ReporterQuid does not match signing public key",
+			"reporter", tx.ReporterQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 3. One standing report per reporter and offender.
+	if node.MisbehaviorRegistry != nil &&
+		node.MisbehaviorRegistry.reported(tx.TrustDomain, tx.ValidatorQuid, tx.ReporterQuid) {
+		logger.Warn("Reporter already reported this validator",
+			"reporter", tx.ReporterQuid, "validator", tx.ValidatorQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 4. Evidence proves the fault.
+	if _, err := node.checkMisbehaviorEvidence(tx, pubKey); err != nil {
+		logger.Warn("Misbehavior report evidence rejected", "txId", tx.ID, "error", err)
+		return false
+	}
+
+	// 5. Nonce strictly monotonic per reporter.
+	if tx.Nonce <= 0 {
+		logger.Warn("Misbehavior report has non-positive nonce", "nonce", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+	if node.MisbehaviorRegistry != nil {
+		if prev := node.MisbehaviorRegistry.currentNonce(tx.ReporterQuid); tx.Nonce <= prev {
+			logger.Warn("Misbehavior report nonce must be strictly greater than previous",
+				"previous", prev, "provided", tx.Nonce, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 6. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Misbehavior report marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Misbehavior report signature invalid", "txId", tx.ID)
+		return false
+	}
+	return true
+}
+
+// applyMisbehaviorReport commits a validated report. Called from
+// processBlockTransactions once the containing block has been
+// accepted.
+func (node *QuidnugNode) applyMisbehaviorReport(tx MisbehaviorReportTransaction, block Block) {
+	if node.MisbehaviorRegistry == nil {
+		return
+	}
+	node.TrustDomainsMutex.RLock()
+	domain := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	reason, _ := node.checkMisbehaviorEvidence(tx, domain.ValidatorPublicKeys[tx.ValidatorQuid])
+
+	var height int64
+	if len(tx.Evidence) > 0 {
+		height = tx.Evidence[0].Index
+	}
+	reporters := node.MisbehaviorRegistry.record(MisbehaviorRecord{
+		ReportID:      tx.ID,
+		TrustDomain:   tx.TrustDomain,
+		ReporterQuid:  tx.ReporterQuid,
+		ValidatorQuid: tx.ValidatorQuid,
+		Kind:          tx.Kind,
+		Reason:        reason,
+		BlockIndex:    height,
+		ReportedAt:    tx.Timestamp,
+	}, tx.Nonce)
+
+	node.TrustRegistryMutex.Lock()
+	if _, ok := node.TrustRegistry[tx.ReporterQuid][tx.ValidatorQuid]; ok {
+		node.TrustRegistry[tx.ReporterQuid][tx.ValidatorQuid] = 0
+		node.zeroScopedTrustLevelsLocked(tx.ReporterQuid, tx.ValidatorQuid)
+		node.invalidateTrustFor(tx.ReporterQuid)
+	}
+	node.TrustRegistryMutex.Unlock()
+
+	logger.Warn("Validator misbehavior reported",
+		"domain", tx.TrustDomain, "validator", tx.ValidatorQuid,
+		"reporter", tx.ReporterQuid, "kind", tx.Kind, "reason", reason)
+
+	node.maybeRemoveValidator(tx.TrustDomain, tx.ValidatorQuid, reporters, block.Timestamp)
+}
+
+// maybeRemoveValidator drops offender from the domain's validator
+// set once validator reporters reach ValidatorRemovalQuorum of the
+// other validators' weight.
+func (node *QuidnugNode) maybeRemoveValidator(domainName, offender string, reporters []string, at int64) {
+	node.TrustDomainsMutex.Lock()
+	domain, ok := node.TrustDomains[domainName]
+	if !ok {
+		node.TrustDomainsMutex.Unlock()
+		return
+	}
+	if _, isValidator := domain.Validators[offender]; !isValidator {
+		node.TrustDomainsMutex.Unlock()
+		return
+	}
+	var total, reported float64
+	for id, w := range domain.Validators {
+		if id != offender {
+			total += w
+		}
+	}
+	var counted []string
+	for _, q := range reporters {
+		if w, isValidator := domain.Validators[q]; isValidator && q != offender {
+			reported += w
+			counted = append(counted, q)
+		}
+	}
+	if total <= 0 || reported < total*ValidatorRemovalQuorum {
+		node.TrustDomainsMutex.Unlock()
+		return
+	}
+
+	// Copy the maps: readers hold TrustDomain values whose maps
+	// they iterate without the lock.
+	validators := make(map[string]float64, len(domain.Validators))
+	for id, w := range domain.Validators {
+		if id != offender {
+			validators[id] = w
+		}
+	}
+	keys := make(map[string]string, len(domain.ValidatorPublicKeys))
+	for id, k := range domain.ValidatorPublicKeys {
+		if id != offender {
+			keys[id] = k
+		}
+	}
+	nodes := make([]string, 0, len(domain.ValidatorNodes))
+	for _, id := range domain.ValidatorNodes {
+		if id != offender {
+			nodes = append(nodes, id)
+		}
+	}
+	domain.Validators = validators
+	domain.ValidatorPublicKeys = keys
+	domain.ValidatorNodes = nodes
+	node.TrustDomains[domainName] = domain
+	node.TrustDomainsMutex.Unlock()
+
+	node.MisbehaviorRegistry.remove(ValidatorRemoval{
+		TrustDomain:   domainName,
+		ValidatorQuid: offender,
+		Reporters:     counted,
+		RemovedAt:     at,
+	})
+	logger.Warn("Removed validator from domain by misbehavior quorum",
+		"domain", domainName, "validator", offender,
+		"reporters", len(counted), "reportedWeight", reported, "totalWeight", total)
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// signedTestBlock returns a block for domain at index, signed by
+// validator.
+func signedTestBlock(t *testing.T, validator *QuidnugNode, domain string, index int64, txs []interface{}) Block {
+	t.Helper()
+	b := Block{
+		Index:        index,
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: txs,
+		TrustProof: TrustProof{
+			TrustDomain:        domain,
+			ValidatorID:        validator.NodeID,
+			ValidatorPublicKey: validator.GetPublicKeyHex(),
+		},
+	}
+	sig, err := validator.SignData(GetBlockSignableData(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.TrustProof.ValidatorSigs = []string{hex.EncodeToString(sig)}
+	b.Hash = calculateBlockHash(b)
+	return b
+}
+
+func signedMisbehaviorReport(t *testing.T, reporter *QuidnugNode, offender, kind string, evidence ...Block) MisbehaviorReportTransaction {
+	t.Helper()
+	tx := MisbehaviorReportTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "report-" + reporter.NodeID,
+			Type:        TxTypeMisbehaviorReport,
+			TrustDomain: "slash.example",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   reporter.GetPublicKeyHex(),
+		},
+		ReporterQuid:  reporter.NodeID,
+		ValidatorQuid: offender,
+		Kind:          kind,
+		Evidence:      evidence,
+		Nonce:         1,
+	}
+	data, _ := json.Marshal(tx)
+	sig, err := reporter.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	return tx
+}
+
+func TestMisbehaviorReport_QuorumRemovesValidator(t *testing.T) {
+	node := newTestNode()
+	offender, r1, r2 := newTestNode(), newTestNode(), newTestNode()
+	domain := TrustDomain{
+		Name:                "slash.example",
+		TrustThreshold:      0.75,
+		Validators:          map[string]float64{},
+		ValidatorPublicKeys: map[string]string{},
+	}
+	for _, v := range []*QuidnugNode{node, offender, r1, r2} {
+		domain.ValidatorNodes = append(domain.ValidatorNodes, v.NodeID)
+		domain.Validators[v.NodeID] = 1.0
+		domain.ValidatorPublicKeys[v.NodeID] = v.GetPublicKeyHex()
+	}
+	node.TrustDomains[domain.Name] = domain
+	node.TrustRegistry[r1.NodeID] = map[string]float64{offender.NodeID: 0.8}
+
+	a := signedTestBlock(t, offender, "slash.example", 5, []interface{}{})
+	b := signedTestBlock(t, offender, "slash.example", 5, []interface{}{})
+	equivocation := signedMisbehaviorReport(t, r1, offender.NodeID, MisbehaviorEquivocation, a, b)
+	if _, err := node.AddMisbehaviorReportTransaction(equivocation); err != nil {
+		t.Fatalf("equivocation report rejected: %v", err)
+	}
+	node.processBlockTransactions(Block{Index: 1, Transactions: []interface{}{equivocation}, TrustProof: TrustProof{TrustDomain: "slash.example"}})
+
+	if got := node.TrustRegistry[r1.NodeID][offender.NodeID]; got != 0 {
+		t.Errorf("reporter trust in offender = %v, want 0", got)
+	}
+	if _, still := node.TrustDomains["slash.example"].Validators[offender.NodeID]; !still {
+		t.Fatal("one report of three validators removed the offender")
+	}
+	if node.ValidateMisbehaviorReportTransaction(equivocation) {
+		t.Error("second report from the same reporter validates")
+	}
+
+	foreign := signedTestBlock(t, offender, "slash.example", 6, []interface{}{
+		TrustTransaction{BaseTransaction: BaseTransaction{ID: "t1", Type: TxTypeTrust, TrustDomain: "other.example"}},
+	})
+	invalid := signedMisbehaviorReport(t, r2, offender.NodeID, MisbehaviorInvalidBlock, foreign)
+	if !node.ValidateMisbehaviorReportTransaction(invalid) {
+		t.Fatal("invalid-block report rejected")
+	}
+	node.processBlockTransactions(Block{Index: 2, Transactions: []interface{}{invalid}, TrustProof: TrustProof{TrustDomain: "slash.example"}})
+
+	after := node.TrustDomains["slash.example"]
+	if _, still := after.Validators[offender.NodeID]; still {
+		t.Fatal("offender survived two of three reports")
+	}
+	if _, still := after.ValidatorPublicKeys[offender.NodeID]; still || len(after.ValidatorNodes) != 3 {
+		t.Fatalf("offender not fully removed: %+v", after)
+	}
+	reports, removals := node.GetMisbehaviorReports("slash.example")
+	if len(reports) != 2 || len(removals) != 1 || len(removals[0].Reporters) != 2 {
+		t.Fatalf("reports=%+v removals=%+v", reports, removals)
+	}
+}
+
+func TestMisbehaviorReport_RejectsWeakEvidence(t *testing.T) {
+	node := newTestNode()
+	offender, reporter := newTestNode(), newTestNode()
+	node.TrustDomains["slash.example"] = TrustDomain{
+		Name:                "slash.example",
+		ValidatorNodes:      []string{node.NodeID, offender.NodeID},
+		Validators:          map[string]float64{node.NodeID: 1, offender.NodeID: 1},
+		ValidatorPublicKeys: map[string]string{node.NodeID: node.GetPublicKeyHex(), offender.NodeID: offender.GetPublicKeyHex()},
+	}
+
+	a := signedTestBlock(t, offender, "slash.example", 5, []interface{}{})
+	cases := map[string]MisbehaviorReportTransaction{
+		"same block twice": signedMisbehaviorReport(t, reporter, offender.NodeID, MisbehaviorEquivocation, a, a),
+		"different heights": signedMisbehaviorReport(t, reporter, offender.NodeID, MisbehaviorEquivocation,
+			a, signedTestBlock(t, offender, "slash.example", 6, []interface{}{})),
+		"signed by someone else": signedMisbehaviorReport(t, reporter, offender.NodeID, MisbehaviorEquivocation,
+			a, signedTestBlock(t, reporter, "slash.example", 5, []interface{}{})),
+		"valid block": signedMisbehaviorReport(t, reporter, offender.NodeID, MisbehaviorInvalidBlock, a),
+		"not a validator": signedMisbehaviorReport(t, reporter, reporter.NodeID, MisbehaviorInvalidBlock,
+			signedTestBlock(t, reporter, "slash.example", 5, []interface{}{map[string]interface{}{"type": "TRUST"}})),
+	}
+	for name, tx := range cases {
+		if node.ValidateMisbehaviorReportTransaction(tx) {
+			t.Errorf("%s: report validated", name)
+		}
+	}
+}
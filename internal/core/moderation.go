@@ -29,7 +29,6 @@
 package core
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -407,7 +406,7 @@ func (node *QuidnugNode) ValidateModerationActionTransaction(tx ModerationAction
 	// Self-sign consistency — the signing pubkey must derive
 	// to ModeratorQuid.
 	computedQuid := QuidIDFromPublicKeyHex(tx.PublicKey)
-	if computedQuid == "" || computedQuid != tx.ModeratorQuid {
+	if computedQuid == "" || !node.signsFor(tx.ModeratorQuid, tx.PublicKey, TxTypeModerationAction, tx.delegationTime()) {
 		logger.Warn("Moderation action ModeratorQuid does not match signing public key",
 			"expected", tx.ModeratorQuid, "computed", computedQuid, "txId", tx.ID)
 		return false
@@ -518,7 +517,7 @@ func (node *QuidnugNode) ValidateModerationActionTransaction(tx ModerationAction
 	// 12. Signature verifies.
 	txCopy := tx
 	txCopy.Signature = ""
-	signable, err := json.Marshal(txCopy)
+	signable, err := txSignableBytes(txCopy)
 	if err != nil {
 		logger.Error("Moderation action marshal for signature failed",
 			"txId", tx.ID, "err", err)
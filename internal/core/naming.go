@@ -0,0 +1,327 @@
+// Package core — human-readable quid names.
+//
+// This file implements the NAME_REGISTRATION transaction type
+// and the name registry behind /api/v1/names/{name}. A name is a
+// DNS-style label scoped to the trust domain it is registered
+// in: "alice" registered in "example.com" is the fully-qualified
+// name "alice.example.com". Each fully-qualified name maps to
+// exactly one target quid and is controlled by exactly one owner
+// quid (the signer of the first registration).
+//
+// Companion file structure mirrors moderation.go:
+//
+//   - types.go          : TxTypeNameRegistration const
+//   - naming.go         : this file — struct, registry, validator
+//   - transactions.go   : AddNameRegistrationTransaction (mempool)
+//   - validation.go     : dispatch into ValidateNameRegistrationTransaction
+//   - registry.go       : dispatch into updateNameRegistry
+//   - handlers.go       : POST submit handler + GET resolver
+//   - node.go           : NameRegistry field + init
+//
+// Uniqueness is enforced at two points. Validation rejects a
+// registration for a name already owned by a different quid, and
+// the registry's upsert re-checks ownership on apply, so when two
+// competing first-claims race into the same block only the first
+// one in block order takes effect.
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxNameLabelLength is the DNS label limit; names longer than
+// this are rejected outright.
+const MaxNameLabelLength = 63
+
+// nameLabelRegex accepts lowercase DNS-style labels: letters,
+// digits and inner hyphens. Uppercase is rejected rather than
+// folded so that exactly one spelling of each name can exist on
+// chain.
+var nameLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// NameRegistrationTransaction claims, updates, or releases a
+// human-readable name within a trust domain.
+type NameRegistrationTransaction struct {
+	BaseTransaction
+
+	// Name is the fully-qualified name: a single label followed
+	// by "." and the tx's TrustDomain, e.g. "alice.example.com".
+	Name string `json:"name"`
+
+	// OwnerQuid controls the name and must match the signing key.
+	OwnerQuid string `json:"ownerQuid"`
+
+	// TargetQuid is the quid the name resolves to. Defaults to
+	// the owner in the common "name myself" case but may point
+	// elsewhere (an org naming one of its service quids).
+	TargetQuid string `json:"targetQuid"`
+
+	// Release, when true, gives up the name. TargetQuid is
+	// ignored on release.
+	Release bool `json:"release,omitempty"`
+
+	// Nonce is strictly monotonic per name, preventing replay of
+	// an older update.
+	Nonce int64 `json:"nonce"`
+}
+
+// NameRecord is the resolved state of a registered name.
+type NameRecord struct {
+	Name         string `json:"name"`
+	TrustDomain  string `json:"trustDomain"`
+	OwnerQuid    string `json:"ownerQuid"`
+	TargetQuid   string `json:"targetQuid"`
+	Nonce        int64  `json:"nonce"`
+	TxID         string `json:"txId"`
+	RegisteredAt int64  `json:"registeredAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+}
+
+// NameRegistry indexes every active name. Released names are
+// dropped from the index but their last nonce is retained, so a
+// re-claim must still advance the nonce.
+type NameRegistry struct {
+	mu sync.RWMutex
+
+	// records keyed by fully-qualified name.
+	records map[string]NameRecord
+
+	// nonces tracks the highest applied nonce per name, including
+	// released names.
+	nonces map[string]int64
+}
+
+// NewNameRegistry constructs an empty registry.
+func NewNameRegistry() *NameRegistry {
+	return &NameRegistry{
+		records: make(map[string]NameRecord),
+		nonces:  make(map[string]int64),
+	}
+}
+
+// lookup returns the active record for a name.
+func (r *NameRegistry) lookup(name string) (NameRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.records[name]
+	return rec, ok
+}
+
+// currentNonce returns the highest applied nonce for a name (0
+// if the name has never been registered).
+func (r *NameRegistry) currentNonce(name string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nonces[name]
+}
+
+// namesFor returns every active name resolving to targetQuid,
+// sorted by name.
+func (r *NameRegistry) namesFor(targetQuid string) []NameRecord {
+	r.mu.RLock()
+	var out []NameRecord
+	for _, rec := range r.records {
+		if rec.TargetQuid == targetQuid {
+			out = append(out, rec)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// apply commits a validated registration. Returns false when the
+// tx lost a race: the name is owned by someone else, or the nonce
+// has already been passed.
+func (r *NameRegistry) apply(tx NameRegistrationTransaction) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx.Nonce <= r.nonces[tx.Name] {
+		return false
+	}
+	existing, exists := r.records[tx.Name]
+	if exists && existing.OwnerQuid != tx.OwnerQuid {
+		return false
+	}
+	r.nonces[tx.Name] = tx.Nonce
+
+	if tx.Release {
+		delete(r.records, tx.Name)
+		return true
+	}
+
+	rec := NameRecord{
+		Name:         tx.Name,
+		TrustDomain:  tx.TrustDomain,
+		OwnerQuid:    tx.OwnerQuid,
+		TargetQuid:   tx.TargetQuid,
+		Nonce:        tx.Nonce,
+		TxID:         tx.ID,
+		RegisteredAt: tx.Timestamp,
+		UpdatedAt:    tx.Timestamp,
+	}
+	if exists {
+		rec.RegisteredAt = existing.RegisteredAt
+	}
+	r.records[tx.Name] = rec
+	return true
+}
+
+// ResolveName returns the active record for a fully-qualified
+// name. Lookup is case-insensitive; stored names are lowercase.
+func (node *QuidnugNode) ResolveName(name string) (NameRecord, bool) {
+	if node.NameRegistry == nil {
+		return NameRecord{}, false
+	}
+	return node.NameRegistry.lookup(strings.ToLower(name))
+}
+
+// updateNameRegistry commits a validated NameRegistrationTransaction.
+// Called from processBlockTransactions once the containing block
+// has been accepted.
+func (node *QuidnugNode) updateNameRegistry(tx NameRegistrationTransaction) {
+	if node.NameRegistry == nil {
+		return
+	}
+	if !node.NameRegistry.apply(tx) {
+		logger.Warn("Name registration lost ownership race, skipped",
+			"txId", tx.ID, "name", tx.Name, "owner", tx.OwnerQuid, "nonce", tx.Nonce)
+		return
+	}
+	logger.Debug("Updated name registry",
+		"txId", tx.ID,
+		"name", tx.Name,
+		"owner", tx.OwnerQuid,
+		"target", tx.TargetQuid,
+		"release", tx.Release,
+		"nonce", tx.Nonce)
+}
+
+// splitScopedName checks that name is exactly one label under
+// domain and returns the label.
+func splitScopedName(name, domain string) (string, error) {
+	suffix := "." + domain
+	if domain == "" || !strings.HasSuffix(name, suffix) {
+		return "", fmt.Errorf("name %q is not scoped to domain %q", name, domain)
+	}
+	label := strings.TrimSuffix(name, suffix)
+	if len(label) == 0 || len(label) > MaxNameLabelLength {
+		return "", fmt.Errorf("name label must be 1..%d characters", MaxNameLabelLength)
+	}
+	if !nameLabelRegex.MatchString(label) {
+		return "", fmt.Errorf("name label %q must be lowercase letters, digits or inner hyphens", label)
+	}
+	return label, nil
+}
+
+// ValidateNameRegistrationTransaction enforces the naming rules.
+// Returns false on any violation; every failure is logged at
+// Warn level.
+func (node *QuidnugNode) ValidateNameRegistrationTransaction(tx NameRegistrationTransaction) bool {
+	// 1. Domain must exist + be supported.
+	if tx.TrustDomain == "" {
+		logger.Warn("Name registration missing trust domain", "txId", tx.ID)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Name registration from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Name registration trust domain not supported by this node",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Name shape: one lowercase label under the tx domain.
+	if len(tx.Name) > MaxSupportedDomainLength {
+		logger.Warn("Name registration name too long", "length", len(tx.Name), "txId", tx.ID)
+		return false
+	}
+	if _, err := splitScopedName(tx.Name, tx.TrustDomain); err != nil {
+		logger.Warn("Name registration has invalid name",
+			"name", tx.Name, "err", err, "txId", tx.ID)
+		return false
+	}
+
+	// 3. Owner + signer consistency.
+	if !IsValidQuidID(tx.OwnerQuid) {
+		logger.Warn("Name registration has invalid OwnerQuid",
+			"owner", tx.OwnerQuid, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Name registration missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	computedQuid := QuidIDFromPublicKeyHex(tx.PublicKey)
+	if computedQuid == "" || !node.signsFor(tx.OwnerQuid, tx.PublicKey, TxTypeNameRegistration, tx.delegationTime()) {
+		logger.Warn("Name registration OwnerQuid does not match signing public key",
+			"expected", tx.OwnerQuid, "computed", computedQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 4. Target quid, unless releasing.
+	if !tx.Release && !IsValidQuidID(tx.TargetQuid) {
+		logger.Warn("Name registration has invalid TargetQuid",
+			"target", tx.TargetQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 5. Uniqueness: the name must be unclaimed or already ours.
+	// Releasing requires an existing claim.
+	if node.NameRegistry != nil {
+		existing, exists := node.NameRegistry.lookup(tx.Name)
+		if exists && existing.OwnerQuid != tx.OwnerQuid {
+			logger.Warn("Name registration conflicts with existing owner",
+				"name", tx.Name, "owner", existing.OwnerQuid,
+				"claimant", tx.OwnerQuid, "txId", tx.ID)
+			return false
+		}
+		if tx.Release && !exists {
+			logger.Warn("Name registration releases an unregistered name",
+				"name", tx.Name, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 6. Nonce strictly monotonic per name.
+	if tx.Nonce <= 0 {
+		logger.Warn("Name registration has non-positive nonce",
+			"nonce", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+	if node.NameRegistry != nil {
+		prev := node.NameRegistry.currentNonce(tx.Name)
+		if tx.Nonce <= prev {
+			logger.Warn("Name registration nonce must be strictly greater than previous",
+				"previous", prev, "provided", tx.Nonce, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 7. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Name registration marshal for signature failed",
+			"txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Name registration signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,226 @@
+// NAME_REGISTRATION tests: validation rules, registry apply
+// semantics (uniqueness + release), and the resolver endpoint.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signName populates PublicKey / OwnerQuid and signs the tx.
+func (a *testNodeActor) signName(tx NameRegistrationTransaction) NameRegistrationTransaction {
+	tx.PublicKey = a.PubHex
+	tx.OwnerQuid = a.QuidID
+	tx.Signature = ""
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+// baselineName returns a well-formed claim of label under
+// test.domain.com pointing at the actor itself.
+func baselineName(actor *testNodeActor, label string, nonce int64) NameRegistrationTransaction {
+	return NameRegistrationTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "name-" + label,
+			Type:        TxTypeNameRegistration,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		Name:       label + ".test.domain.com",
+		TargetQuid: actor.QuidID,
+		Nonce:      nonce,
+	}
+}
+
+func TestValidateNameRegistration_Baseline(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	tx := actor.signName(baselineName(actor, "alice", 1))
+	if !node.ValidateNameRegistrationTransaction(tx) {
+		t.Fatal("baseline valid name registration rejected")
+	}
+}
+
+func TestValidateNameRegistration_RejectsBadNames(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	for _, name := range []string{
+		"alice.other.com",        // wrong domain
+		"Alice.test.domain.com",  // uppercase
+		"a.b.test.domain.com",    // nested label
+		"-alice.test.domain.com", // leading hyphen
+		".test.domain.com",       // empty label
+		"test.domain.com",        // the domain itself
+		"al ice.test.domain.com", // space
+	} {
+		tx := baselineName(actor, "x", 1)
+		tx.Name = name
+		tx = actor.signName(tx)
+		if node.ValidateNameRegistrationTransaction(tx) {
+			t.Errorf("name %q should be rejected", name)
+		}
+	}
+}
+
+func TestValidateNameRegistration_RejectsMismatchedSigner(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	other := newTestNodeActor(t)
+	tx := actor.signName(baselineName(actor, "alice", 1))
+	tx.PublicKey = other.PubHex
+	if node.ValidateNameRegistrationTransaction(tx) {
+		t.Error("registration with mismatched owner/pubkey should be rejected")
+	}
+}
+
+func TestValidateNameRegistration_UniquenessAcrossOwners(t *testing.T) {
+	node := newTestNode()
+	alice := newTestNodeActor(t)
+	mallory := newTestNodeActor(t)
+
+	first := alice.signName(baselineName(alice, "alice", 1))
+	node.updateNameRegistry(first)
+
+	steal := mallory.signName(baselineName(mallory, "alice", 2))
+	if node.ValidateNameRegistrationTransaction(steal) {
+		t.Fatal("claim of a name owned by another quid should be rejected")
+	}
+
+	// The owner may repoint it with a higher nonce.
+	update := baselineName(alice, "alice", 2)
+	update.TargetQuid = mallory.QuidID
+	update = alice.signName(update)
+	if !node.ValidateNameRegistrationTransaction(update) {
+		t.Fatal("owner update should be accepted")
+	}
+	node.updateNameRegistry(update)
+	rec, ok := node.ResolveName("ALICE.test.domain.com")
+	if !ok || rec.TargetQuid != mallory.QuidID {
+		t.Fatalf("expected name to resolve to updated target, got %+v ok=%v", rec, ok)
+	}
+	if rec.RegisteredAt != first.Timestamp {
+		t.Errorf("RegisteredAt should be preserved across updates")
+	}
+}
+
+func TestValidateNameRegistration_RejectsStaleNonce(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	node.updateNameRegistry(actor.signName(baselineName(actor, "alice", 3)))
+
+	tx := actor.signName(baselineName(actor, "alice", 3))
+	if node.ValidateNameRegistrationTransaction(tx) {
+		t.Error("replayed nonce should be rejected")
+	}
+}
+
+func TestNameRegistry_ReleaseAndReclaim(t *testing.T) {
+	node := newTestNode()
+	alice := newTestNodeActor(t)
+	bob := newTestNodeActor(t)
+
+	node.updateNameRegistry(alice.signName(baselineName(alice, "shared", 1)))
+
+	release := baselineName(alice, "shared", 2)
+	release.Release = true
+	release = alice.signName(release)
+	if !node.ValidateNameRegistrationTransaction(release) {
+		t.Fatal("owner release should be accepted")
+	}
+	node.updateNameRegistry(release)
+	if _, ok := node.ResolveName("shared.test.domain.com"); ok {
+		t.Fatal("released name should not resolve")
+	}
+
+	// Re-claim by someone else must advance past the retained nonce.
+	stale := bob.signName(baselineName(bob, "shared", 1))
+	if node.ValidateNameRegistrationTransaction(stale) {
+		t.Error("re-claim with stale nonce should be rejected")
+	}
+	fresh := bob.signName(baselineName(bob, "shared", 3))
+	if !node.ValidateNameRegistrationTransaction(fresh) {
+		t.Fatal("re-claim with fresh nonce should be accepted")
+	}
+}
+
+func TestNameRegistry_ApplyFirstClaimWins(t *testing.T) {
+	node := newTestNode()
+	alice := newTestNodeActor(t)
+	bob := newTestNodeActor(t)
+
+	// Both claims validate against empty state (same block).
+	a := alice.signName(baselineName(alice, "race", 1))
+	b := bob.signName(baselineName(bob, "race", 2))
+	if !node.ValidateNameRegistrationTransaction(a) || !node.ValidateNameRegistrationTransaction(b) {
+		t.Fatal("both claims should validate against empty state")
+	}
+	node.updateNameRegistry(a)
+	node.updateNameRegistry(b)
+
+	rec, ok := node.ResolveName("race.test.domain.com")
+	if !ok || rec.OwnerQuid != alice.QuidID {
+		t.Fatalf("first claim in block order should win, got %+v", rec)
+	}
+}
+
+func TestResolveNameHandler(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	node.updateNameRegistry(actor.signName(baselineName(actor, "alice", 1)))
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest("GET", "/api/v1/names/alice.test.domain.com", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Success bool       `json:"success"`
+		Data    NameRecord `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.TargetQuid != actor.QuidID {
+		t.Errorf("expected target %s, got %s", actor.QuidID, resp.Data.TargetQuid)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/names/nobody.test.domain.com", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unregistered name, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/quids/"+actor.QuidID+"/names", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for reverse lookup, got %d", w.Code)
+	}
+}
+
+func TestAddNameRegistrationTransaction_QueuesSignedTx(t *testing.T) {
+	node := newTestNode()
+	actor := newTestNodeActor(t)
+	tx := actor.signName(baselineName(actor, "queued", 1))
+
+	id, err := node.AddNameRegistrationTransaction(tx)
+	if err != nil {
+		t.Fatalf("AddNameRegistrationTransaction: %v", err)
+	}
+	if id != tx.ID {
+		t.Errorf("signed tx ID should be preserved, got %s", id)
+	}
+
+	node.PendingTxsMutex.RLock()
+	defer node.PendingTxsMutex.RUnlock()
+	if len(node.PendingTxs) != 1 {
+		t.Fatalf("expected 1 pending tx, got %d", len(node.PendingTxs))
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,9 +28,12 @@ import (
 //     reachability, new-peer count, total-known count, and a
 //     cap-5 list of failure tags. Per-seed WARN logs continue
 //     to fire too.
-func (node *QuidnugNode) DiscoverNodes(ctx context.Context, seedNodes []string) {
-	logger.Info("Starting node discovery", "seedNodes", len(seedNodes))
-	if len(seedNodes) == 0 {
+//   - With dnsDiscovery set, each cycle's seeds come from the
+//     known domains' DNS records when they publish any, and from
+//     seedNodes otherwise (dns_discovery.go).
+func (node *QuidnugNode) DiscoverNodes(ctx context.Context, seedNodes []string, dnsDiscovery bool) {
+	logger.Info("Starting node discovery", "seedNodes", len(seedNodes), "dnsDiscovery", dnsDiscovery)
+	if len(seedNodes) == 0 && !dnsDiscovery {
 		logger.Info("Node discovery idle (no seeds configured)")
 		<-ctx.Done()
 		logger.Info("Node discovery stopped")
@@ -54,7 +58,7 @@ func (node *QuidnugNode) DiscoverNodes(ctx context.Context, seedNodes []string)
 			return
 		default:
 		}
-		res := node.discoverFromSeeds(ctx, seedNodes)
+		res := node.discoverFromSeeds(ctx, node.discoverySeeds(ctx, seedNodes, dnsDiscovery))
 		logDiscoveryCycle(res, i == 0)
 		if res.SeedsReachable > 0 {
 			reachedAny = true
@@ -90,7 +94,7 @@ func (node *QuidnugNode) DiscoverNodes(ctx context.Context, seedNodes []string)
 			logger.Info("Node discovery stopped")
 			return
 		case <-ticker.C:
-			res := node.discoverFromSeeds(ctx, seedNodes)
+			res := node.discoverFromSeeds(ctx, node.discoverySeeds(ctx, seedNodes, dnsDiscovery))
 			logDiscoveryCycle(res, false)
 		}
 	}
@@ -241,13 +245,18 @@ func (node *QuidnugNode) discoverFromSeeds(ctx context.Context, seedNodes []stri
 			} else {
 				discoveredNode.TrustDomains = domains
 			}
+			// First-hand capabilities from the handshake beat
+			// whatever the seed relayed.
+			if len(verdict.Capabilities.Roles) > 0 {
+				discoveredNode.PeerCapabilities = verdict.Capabilities
+			}
 
 			node.KnownNodesMutex.Lock()
 			_, existed := node.KnownNodes[discoveredNode.ID]
-			// Don't clobber a static-source entry with a
+			// Don't clobber a static or manual entry with a
 			// gossip-source entry; the operator's explicit
 			// listing wins.
-			if existing, ok := node.KnownNodes[discoveredNode.ID]; ok && existing.ConnectionStatus == "static" {
+			if existing, ok := node.KnownNodes[discoveredNode.ID]; ok && isPinnedPeer(existing.ConnectionStatus) {
 				node.KnownNodesMutex.Unlock()
 			} else {
 				discoveredNode.LastSeen = time.Now().Unix()
@@ -274,8 +283,9 @@ func (node *QuidnugNode) discoverFromSeeds(ctx context.Context, seedNodes []stri
 		}
 	}
 
-	// Refresh domain info for all known nodes
+	// Refresh domain info and capabilities for all known nodes
 	node.refreshKnownNodeDomains(ctx)
+	node.refreshKnownNodeCapabilities(ctx)
 	node.KnownNodesMutex.RLock()
 	res.TotalKnown = len(node.KnownNodes)
 	node.KnownNodesMutex.RUnlock()
@@ -396,7 +406,9 @@ func (node *QuidnugNode) refreshKnownNodeDomains(ctx context.Context) {
 	}
 }
 
-// GetTrustDomainNodes gets nodes from a specific trust domain
+// GetTrustDomainNodes gets the known validator nodes of a specific
+// trust domain. Peers advertising a role that refuses writes
+// (replica, gateway) are left out.
 func (node *QuidnugNode) GetTrustDomainNodes(domainName string) []Node {
 	var domainNodes []Node
 
@@ -412,7 +424,7 @@ func (node *QuidnugNode) GetTrustDomainNodes(domainName string) []Node {
 	defer node.KnownNodesMutex.RUnlock()
 
 	for _, validatorID := range domain.ValidatorNodes {
-		if knownNode, exists := node.KnownNodes[validatorID]; exists {
+		if knownNode, exists := node.KnownNodes[validatorID]; exists && knownNode.HasRole(NodeRoleValidator) {
 			domainNodes = append(domainNodes, knownNode)
 		}
 	}
@@ -420,83 +432,160 @@ func (node *QuidnugNode) GetTrustDomainNodes(domainName string) []Node {
 	return domainNodes
 }
 
-// BroadcastTransaction broadcasts a transaction to other nodes in the trust domain
-//
-// ENG-77: this switch must include every concrete transaction type
-// the rest of the codebase emits, otherwise transactions of unlisted
-// types fall through to the default branch and are silently dropped
-// from the broadcast pipeline (every load-gen heartbeat fires the
-// warning when EVENTs are dropped). The companion switches in
-// GenerateBlock (block_operations.go) and ValidateBlockTiered
-// (validation.go) were updated previously to know about EventTransaction;
-// this is the third site that was missed at the time.
-func (node *QuidnugNode) BroadcastTransaction(tx interface{}) {
-	var domainName string
-	var txType string
-
+// txGossipRoute returns the base fields of a pending transaction
+// and the /api/transactions/{txType} path it is gossiped on. ok is
+// false for types that are not gossiped.
+func txGossipRoute(tx interface{}) (base BaseTransaction, txType string, ok bool) {
 	switch t := tx.(type) {
 	case TrustTransaction:
-		domainName = t.TrustDomain
-		txType = "trust"
+		return t.BaseTransaction, "trust", true
 	case IdentityTransaction:
-		domainName = t.TrustDomain
-		txType = "identity"
+		return t.BaseTransaction, "identity", true
 	case TitleTransaction:
-		domainName = t.TrustDomain
-		txType = "title"
+		return t.BaseTransaction, "title", true
 	case EventTransaction:
-		domainName = t.TrustDomain
-		txType = "event"
+		return t.BaseTransaction, "event", true
 	case NodeAdvertisementTransaction:
-		domainName = t.TrustDomain
-		txType = "node-advertisement"
+		return t.BaseTransaction, "node-advertisement", true
 	case ModerationActionTransaction:
-		domainName = t.TrustDomain
-		txType = "moderation"
+		return t.BaseTransaction, "moderation", true
+	case NameRegistrationTransaction:
+		return t.BaseTransaction, "name", true
+	case LienTransaction:
+		return t.BaseTransaction, "lien", true
+	case SuccessionTransaction:
+		return t.BaseTransaction, "succession", true
+	case MisbehaviorReportTransaction:
+		return t.BaseTransaction, "misbehavior", true
+	case DomainJoinTransaction:
+		return t.BaseTransaction, "domain-join", true
+	case CheckpointTransaction:
+		return t.BaseTransaction, "checkpoint", true
+	case DomainControlTransaction:
+		return t.BaseTransaction, "domain-control", true
+	case TransferApprovalTransaction:
+		return t.BaseTransaction, "transfer-approval", true
+	case TitleRestructureTransaction:
+		return t.BaseTransaction, "title-restructure", true
+	case TitleDisputeTransaction:
+		return t.BaseTransaction, "title-dispute", true
+	case CustomTransaction:
+		return t.BaseTransaction, "custom", true
 	case DataSubjectRequestTransaction:
-		domainName = t.TrustDomain
-		txType = "dsr"
+		return t.BaseTransaction, "dsr", true
 	case ConsentGrantTransaction:
-		domainName = t.TrustDomain
-		txType = "consent-grant"
+		return t.BaseTransaction, "consent-grant", true
 	case ConsentWithdrawTransaction:
-		domainName = t.TrustDomain
-		txType = "consent-withdraw"
+		return t.BaseTransaction, "consent-withdraw", true
 	case ProcessingRestrictionTransaction:
-		domainName = t.TrustDomain
-		txType = "processing-restriction"
+		return t.BaseTransaction, "processing-restriction", true
 	case DSRComplianceTransaction:
-		domainName = t.TrustDomain
-		txType = "dsr-compliance"
+		return t.BaseTransaction, "dsr-compliance", true
 	default:
-		logger.Warn("Cannot broadcast unknown transaction type",
+		return BaseTransaction{}, "", false
+	}
+}
+
+// BroadcastTransaction broadcasts a transaction to other nodes in the trust domain
+//
+// ENG-77: txGossipRoute's switch must include every concrete transaction type
+// the rest of the codebase emits, otherwise transactions of unlisted
+// types fall through to the default branch and are silently dropped
+// from the broadcast pipeline (every load-gen heartbeat fires the
+// warning when EVENTs are dropped). The companion switches in
+// GenerateBlock (block_operations.go) and ValidateBlockTiered
+// (validation.go) were updated previously to know about EventTransaction;
+// this is the third site that was missed at the time.
+func (node *QuidnugNode) BroadcastTransaction(tx interface{}) {
+	node.broadcastTransaction(context.Background(), tx)
+}
+
+// broadcastTransaction is BroadcastTransaction logging under the
+// request ctx carries. ctx is not used for cancellation.
+func (node *QuidnugNode) broadcastTransaction(ctx context.Context, tx interface{}) {
+	base, txType, ok := txGossipRoute(tx)
+	if !ok {
+		loggerFrom(ctx).Warn("Cannot broadcast unknown transaction type",
 			"type", fmt.Sprintf("%T", tx))
 		return
 	}
-
+	domainName := base.TrustDomain
 	if domainName == "" {
 		domainName = "default"
 	}
 
-	domainNodes := node.GetTrustDomainNodes(domainName)
+	txLog := loggerFrom(ctx).With("txId", base.ID, "txType", txType)
 
 	txJSON, err := json.Marshal(tx)
 	if err != nil {
-		logger.Error("Failed to marshal transaction for broadcast", "error", err)
+		txLog.Error("Failed to marshal transaction for broadcast", "error", err)
 		return
 	}
 
-	for _, targetNode := range domainNodes {
-		if targetNode.ID == node.NodeID {
-			continue
-		}
+	// Peers re-broadcast whatever they admit, and admission only
+	// rejects nonces already committed, so without this a pending
+	// transaction circulates until a block includes it.
+	if !node.markBroadcast(sha256.Sum256(txJSON), time.Now()) {
+		return
+	}
 
-		go node.broadcastToNode(targetNode, txType, txJSON)
+	// Peers are sent the ID and fetch the body if they lack it
+	// (inventory.go). Without an ID there is nothing to fetch by.
+	if base.ID != "" {
+		node.queueInventory(domainName, invEntry{
+			item:   InvItem{Kind: InvKindTx, ID: base.ID},
+			txType: txType,
+			body:   txJSON,
+		})
+		txLog.Debug("Queued transaction for inventory gossip", "domain", domainName)
+		return
+	}
+	bctx := withLogger(detachLog(node.lifetime(), ctx), txLog)
+	for _, targetNode := range node.inventoryPeers(domainName) {
+		go node.broadcastToNode(bctx, targetNode, txType, txJSON)
+	}
+}
+
+// lifetime is the context of the node's background loops, or
+// context.Background for a node that was never started. Broadcasts
+// run under it rather than under the request that admitted the
+// transaction: a client hanging up must not cut the gossip short,
+// but shutting the node down should.
+func (node *QuidnugNode) lifetime() context.Context {
+	if ctx := node.lifetimeCtx.Load(); ctx != nil {
+		return *ctx
 	}
+	return context.Background()
+}
+
+// broadcastSeenRetention bounds how long a broadcast digest is
+// remembered. A transaction is normally sealed well within it.
+const broadcastSeenRetention = 10 * time.Minute
+
+// markBroadcast records a transaction digest and reports whether it
+// is new, pruning expired digests as it goes.
+func (node *QuidnugNode) markBroadcast(digest [32]byte, at time.Time) bool {
+	node.broadcastSeenMu.Lock()
+	defer node.broadcastSeenMu.Unlock()
+	if node.broadcastSeen == nil {
+		node.broadcastSeen = make(map[[32]byte]int64)
+	}
+	if _, ok := node.broadcastSeen[digest]; ok {
+		return false
+	}
+	cutoff := at.Add(-broadcastSeenRetention).Unix()
+	for d, ts := range node.broadcastSeen {
+		if ts < cutoff {
+			delete(node.broadcastSeen, d)
+		}
+	}
+	node.broadcastSeen[digest] = at.Unix()
+	return true
 }
 
 // broadcastToNode sends a transaction to a single node (fire-and-forget)
-func (node *QuidnugNode) broadcastToNode(targetNode Node, txType string, txJSON []byte) {
+func (node *QuidnugNode) broadcastToNode(ctx context.Context, targetNode Node, txType string, txJSON []byte) {
+	log := loggerFrom(ctx)
 	// SSRF gate: same pattern as queryNode. safeAddr is a distinct
 	// type so the taint flow shows the sanitization step.
 	// ENG-79: use node-method variant so admitted-with-allow_private
@@ -504,7 +593,7 @@ func (node *QuidnugNode) broadcastToNode(targetNode Node, txType string, txJSON
 	// admitted on a private subnet) don't get rejected at this dial.
 	safeAddr, err := node.validatePeerAddress(targetNode.Address)
 	if err != nil {
-		logger.Warn("Refusing broadcast to invalid peer address",
+		log.Warn("Refusing broadcast to invalid peer address",
 			"targetNodeId", targetNode.ID,
 			"targetAddress", targetNode.Address,
 			"error", err)
@@ -514,44 +603,53 @@ func (node *QuidnugNode) broadcastToNode(targetNode Node, txType string, txJSON
 	path := fmt.Sprintf("/api/transactions/%s", txType)
 	endpoint := fmt.Sprintf("http://%s%s", safeAddr.String(), path)
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(txJSON)) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
+	body, contentType, err := encodeWireBody(node.wireFormatFor(targetNode), txJSON)
 	if err != nil {
-		logger.Warn("Failed to create broadcast request",
+		body, contentType = txJSON, "application/json"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body)) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
+	if err != nil {
+		log.Warn("Failed to create broadcast request",
 			"targetNodeId", targetNode.ID,
 			"targetAddress", safeAddr.String(),
 			"error", err)
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	if id := GetRequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
 
 	// Add authentication headers if secret is configured
 	if secret := GetNodeAuthSecret(); secret != "" {
 		timestamp := time.Now().Unix()
-		signature := SignRequest("POST", path, txJSON, secret, timestamp)
+		signature := SignRequest("POST", path, body, secret, timestamp)
 		req.Header.Set(NodeSignatureHeader, signature)
 		req.Header.Set(NodeTimestampHeader, strconv.FormatInt(timestamp, 10))
 	}
 
 	resp, err := node.httpClient.Do(req) // #nosec -- request URL built from sanitized address; transport also enforces safeDialContext
 	if err != nil {
-		logger.Warn("Failed to broadcast transaction to node",
+		log.Warn("Failed to broadcast transaction to node",
 			"targetNodeId", targetNode.ID,
 			"targetAddress", targetNode.Address,
 			"error", err)
-		node.recordPeerScore(targetNode.ID, EventClassBroadcast, false, "dial: "+err.Error())
+		if ctx.Err() == nil {
+			node.recordPeerScore(targetNode.ID, EventClassBroadcast, false, "dial: "+err.Error())
+		}
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		logger.Debug("Successfully broadcast transaction to node",
+		log.Debug("Successfully broadcast transaction to node",
 			"targetNodeId", targetNode.ID,
 			"targetAddress", targetNode.Address,
 			"status", resp.StatusCode)
 		node.recordPeerScore(targetNode.ID, EventClassBroadcast, true, "")
 	} else {
 		body, _ := io.ReadAll(resp.Body)
-		logger.Warn("Node rejected broadcast transaction",
+		log.Warn("Node rejected broadcast transaction",
 			"targetNodeId", targetNode.ID,
 			"targetAddress", targetNode.Address,
 			"status", resp.StatusCode,
@@ -563,7 +661,16 @@ func (node *QuidnugNode) broadcastToNode(targetNode Node, txType string, txJSON
 
 // QueryOtherDomain queries other trust domains with hierarchical domain walking.
 // First tries exact match and parent domains, then falls back to subdomain nodes.
-func (node *QuidnugNode) QueryOtherDomain(domainName, queryType, queryParam string) (interface{}, error) {
+// Once ctx is done it stops trying further nodes and returns ctx's error.
+// Answers are cached and rate limited per domain; see federation_cache.go.
+func (node *QuidnugNode) QueryOtherDomain(ctx context.Context, domainName, queryType, queryParam string) (interface{}, error) {
+	res, err := node.FederatedQuery(ctx, domainName, queryType, queryParam)
+	return res.Data, err
+}
+
+// forwardDomainQuery is QueryOtherDomain without the cache: it asks
+// the domain's nodes in score order until one answers.
+func (node *QuidnugNode) forwardDomainQuery(ctx context.Context, domainName, queryType, queryParam string) (interface{}, error) {
 	// First try exact match and parent domains (walking up the hierarchy)
 	domainManagers := node.findNodesForDomainWithHierarchy(domainName)
 
@@ -590,10 +697,13 @@ func (node *QuidnugNode) QueryOtherDomain(domainName, queryType, queryParam stri
 
 	var lastErr error
 	for _, targetNode := range domainManagers {
-		result, err := node.queryNode(targetNode, domainName, queryType, queryParam)
+		result, err := node.queryNode(ctx, targetNode, domainName, queryType, queryParam)
 		if err == nil {
 			return result, nil
 		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		lastErr = err
 		logger.Debug("Failed to query node, trying next",
 			"targetNodeId", targetNode.ID,
@@ -619,7 +729,7 @@ func (node *QuidnugNode) preferByScore(nodes []Node) []Node {
 	}
 	ranked := make([]rankedPeer, 0, len(nodes))
 	for _, n := range nodes {
-		if node.PeerScoreboard.IsQuarantined(n.ID) {
+		if node.PeerScoreboard.IsQuarantined(n.ID) || node.PeerScoreboard.IsBanned(n.ID) {
 			continue
 		}
 		ranked = append(ranked, rankedPeer{
@@ -762,7 +872,7 @@ func (node *QuidnugNode) runDomainGossip(ctx context.Context, interval time.Dura
 		logger.Info("Domain gossip loop stopped before initial broadcast")
 		return
 	case <-time.After(5 * time.Second):
-		node.BroadcastDomainInfo()
+		node.BroadcastDomainInfo(ctx)
 	}
 
 	ticker := time.NewTicker(interval)
@@ -778,7 +888,7 @@ func (node *QuidnugNode) runDomainGossip(ctx context.Context, interval time.Dura
 			logger.Info("Domain gossip loop stopped")
 			return
 		case <-ticker.C:
-			node.BroadcastDomainInfo()
+			node.BroadcastDomainInfo(ctx)
 		case <-cleanupTicker.C:
 			node.cleanupGossipSeen()
 		}
@@ -786,7 +896,7 @@ func (node *QuidnugNode) runDomainGossip(ctx context.Context, interval time.Dura
 }
 
 // BroadcastDomainInfo creates and sends a domain gossip message to all known nodes
-func (node *QuidnugNode) BroadcastDomainInfo() {
+func (node *QuidnugNode) BroadcastDomainInfo(ctx context.Context) {
 	gossip := node.createDomainGossip()
 	if gossip == nil {
 		return
@@ -818,7 +928,7 @@ func (node *QuidnugNode) BroadcastDomainInfo() {
 	logger.Debug("Broadcasting domain info", "domains", gossip.Domains, "ttl", gossip.TTL, "targetNodes", len(nodes))
 
 	for _, targetNode := range nodes {
-		go node.sendDomainGossip(targetNode, gossipJSON)
+		go node.sendDomainGossip(ctx, targetNode, gossipJSON)
 	}
 }
 
@@ -853,7 +963,7 @@ func (node *QuidnugNode) createDomainGossip() *DomainGossip {
 }
 
 // sendDomainGossip sends a gossip message to a single node
-func (node *QuidnugNode) sendDomainGossip(targetNode Node, gossipJSON []byte) {
+func (node *QuidnugNode) sendDomainGossip(ctx context.Context, targetNode Node, gossipJSON []byte) {
 	// SSRF gate (same pattern as queryNode/broadcastToNode).
 	// ENG-79: use node-method variant so the per-peer allow_private
 	// override is honored here too.
@@ -867,7 +977,7 @@ func (node *QuidnugNode) sendDomainGossip(targetNode Node, gossipJSON []byte) {
 	path := "/api/v1/gossip/domains"
 	endpoint := fmt.Sprintf("http://%s%s", safeAddr.String(), path)
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(gossipJSON)) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(gossipJSON)) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
 	if err != nil {
 		logger.Debug("Failed to create gossip request", "targetNodeId", targetNode.ID, "error", err)
 		return
@@ -993,8 +1103,9 @@ func (node *QuidnugNode) forwardDomainGossip(gossip DomainGossip) {
 		"hopCount", forwardGossip.HopCount,
 		"targetNodes", len(nodes))
 
+	ctx := node.lifetime()
 	for _, targetNode := range nodes {
-		go node.sendDomainGossip(targetNode, gossipJSON)
+		go node.sendDomainGossip(ctx, targetNode, gossipJSON)
 	}
 }
 
@@ -1030,7 +1141,7 @@ func (node *QuidnugNode) cleanupGossipSeen() {
 }
 
 // queryNode performs an HTTP GET query to a specific node
-func (node *QuidnugNode) queryNode(targetNode Node, domainName, queryType, queryParam string) (interface{}, error) {
+func (node *QuidnugNode) queryNode(ctx context.Context, targetNode Node, domainName, queryType, queryParam string) (interface{}, error) {
 	// SSRF gate: validate the peer-advertised address before
 	// composing a URL with it. The httpClient's safeDialContext
 	// is the authoritative defense, but we sanitize here too so
@@ -1059,7 +1170,7 @@ func (node *QuidnugNode) queryNode(targetNode Node, domainName, queryType, query
 		"queryType", queryType,
 		"queryParam", queryParam)
 
-	req, err := http.NewRequest("GET", endpoint, nil) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil) // #nosec -- endpoint built from sanitized address (see ValidatePeerAddress + safeDialContext)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for node %s: %w", safeAddr.String(), err)
 	}
@@ -1075,7 +1186,10 @@ func (node *QuidnugNode) queryNode(targetNode Node, domainName, queryType, query
 
 	resp, err := node.httpClient.Do(req) // #nosec -- request URL built from sanitized address; transport also enforces safeDialContext
 	if err != nil {
-		node.recordPeerScore(targetNode.ID, EventClassQuery, false, "dial: "+err.Error())
+		// A caller that gave up is not the peer's fault.
+		if ctx.Err() == nil {
+			node.recordPeerScore(targetNode.ID, EventClassQuery, false, "dial: "+err.Error())
+		}
 		return nil, fmt.Errorf("failed to connect to node %s: %w", safeAddr.String(), err)
 	}
 	defer func() { _ = resp.Body.Close() }()
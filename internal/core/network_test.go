@@ -1,7 +1,9 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -61,9 +63,58 @@ func TestBroadcastTransaction(t *testing.T) {
 		}
 	})
 
-	t.Run("broadcasts identity transaction", func(t *testing.T) {
+	t.Run("does not re-broadcast an echoed transaction", func(t *testing.T) {
+		var receivedCount int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&receivedCount, 1)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		node.KnownNodesMutex.Lock()
+		node.KnownNodes["test_node_echo"] = Node{
+			ID:           "test_node_echo",
+			Address:      server.Listener.Addr().String(),
+			TrustDomains: []string{"echo.domain.com"},
+		}
+		node.KnownNodesMutex.Unlock()
+
+		node.TrustDomainsMutex.Lock()
+		node.TrustDomains["echo.domain.com"] = TrustDomain{
+			Name:           "echo.domain.com",
+			ValidatorNodes: []string{"test_node_echo"},
+		}
+		node.TrustDomainsMutex.Unlock()
+
+		tx := TrustTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          "tx_echo_test",
+				Type:        TxTypeTrust,
+				TrustDomain: "echo.domain.com",
+				Timestamp:   time.Now().Unix(),
+			},
+			Truster:    "quid_truster_002",
+			Trustee:    "quid_trustee_002",
+			TrustLevel: 0.4,
+		}
+
+		node.BroadcastTransaction(tx)
+		node.BroadcastTransaction(tx)
+
+		time.Sleep(100 * time.Millisecond)
+
+		if got := atomic.LoadInt32(&receivedCount); got != 1 {
+			t.Errorf("Expected 1 broadcast, got %d", got)
+		}
+	})
+
+	t.Run("pushes identity transaction to a peer without inventory relay", func(t *testing.T) {
 		var receivedPath string
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/inv" {
+				http.NotFound(w, r)
+				return
+			}
 			receivedPath = r.URL.Path
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(map[string]string{"status": "success"})
@@ -105,9 +156,13 @@ func TestBroadcastTransaction(t *testing.T) {
 		}
 	})
 
-	t.Run("broadcasts title transaction", func(t *testing.T) {
+	t.Run("pushes title transaction to a peer without inventory relay", func(t *testing.T) {
 		var receivedPath string
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/inv" {
+				http.NotFound(w, r)
+				return
+			}
 			receivedPath = r.URL.Path
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(map[string]string{"status": "success"})
@@ -246,7 +301,7 @@ func TestQueryOtherDomain(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		result, err := node.QueryOtherDomain("remote.domain.com", "identity", "test_quid")
+		result, err := node.QueryOtherDomain(context.Background(), "remote.domain.com", "identity", "test_quid")
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -262,7 +317,7 @@ func TestQueryOtherDomain(t *testing.T) {
 	})
 
 	t.Run("returns error when no nodes manage domain", func(t *testing.T) {
-		_, err := node.QueryOtherDomain("nonexistent.domain.com", "identity", "test")
+		_, err := node.QueryOtherDomain(context.Background(), "nonexistent.domain.com", "identity", "test")
 		if err == nil {
 			t.Error("Expected error for unknown domain, got nil")
 		}
@@ -297,7 +352,7 @@ func TestQueryOtherDomain(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		result, err := node.QueryOtherDomain("failover.domain.com", "identity", "test")
+		result, err := node.QueryOtherDomain(context.Background(), "failover.domain.com", "identity", "test")
 		if err != nil {
 			t.Fatalf("Expected success after failover, got error: %v", err)
 		}
@@ -325,11 +380,44 @@ func TestQueryOtherDomain(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		_, err := node.QueryOtherDomain("allfail.domain.com", "identity", "test")
+		_, err := node.QueryOtherDomain(context.Background(), "allfail.domain.com", "identity", "test")
 		if err == nil {
 			t.Error("Expected error when all nodes fail, got nil")
 		}
 	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		var callCount int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&callCount, 1)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		}))
+		defer server.Close()
+
+		node.KnownNodesMutex.Lock()
+		node.KnownNodes["cancel_node_a"] = Node{
+			ID:           "cancel_node_a",
+			Address:      server.Listener.Addr().String(),
+			TrustDomains: []string{"cancel.domain.com"},
+		}
+		node.KnownNodes["cancel_node_b"] = Node{
+			ID:           "cancel_node_b",
+			Address:      server.Listener.Addr().String(),
+			TrustDomains: []string{"cancel.domain.com"},
+		}
+		node.KnownNodesMutex.Unlock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := node.QueryOtherDomain(ctx, "cancel.domain.com", "identity", "test")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if n := atomic.LoadInt32(&callCount); n != 0 {
+			t.Errorf("Expected no requests after cancellation, got %d", n)
+		}
+	})
 }
 
 func TestDomainHierarchyWalking(t *testing.T) {
@@ -351,7 +439,7 @@ func TestDomainHierarchyWalking(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		result, err := node.QueryOtherDomain("sub.domain.com", "identity", "test")
+		result, err := node.QueryOtherDomain(context.Background(), "sub.domain.com", "identity", "test")
 		if err != nil {
 			t.Fatalf("Expected to find parent domain node, got error: %v", err)
 		}
@@ -382,7 +470,7 @@ func TestDomainHierarchyWalking(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		result, err := node.QueryOtherDomain("deep.sub.domain.com", "identity", "test")
+		result, err := node.QueryOtherDomain(context.Background(), "deep.sub.domain.com", "identity", "test")
 		if err != nil {
 			t.Fatalf("Expected to find root domain node, got error: %v", err)
 		}
@@ -424,7 +512,7 @@ func TestDomainHierarchyWalking(t *testing.T) {
 		}
 		node.KnownNodesMutex.Unlock()
 
-		result, err := node.QueryOtherDomain("sub.prefer.com", "identity", "test")
+		result, err := node.QueryOtherDomain(context.Background(), "sub.prefer.com", "identity", "test")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -444,7 +532,7 @@ func TestDomainHierarchyWalking(t *testing.T) {
 		node.KnownNodes = make(map[string]Node)
 		node.KnownNodesMutex.Unlock()
 
-		_, err := node.QueryOtherDomain("totally.unknown.tld", "identity", "test")
+		_, err := node.QueryOtherDomain(context.Background(), "totally.unknown.tld", "identity", "test")
 		if err == nil {
 			t.Error("Expected error for completely unknown domain hierarchy")
 		}
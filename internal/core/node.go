@@ -16,16 +16,21 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/quidnug/quidnug/internal/audit"
+	"github.com/quidnug/quidnug/internal/blobstore"
+	"github.com/quidnug/quidnug/internal/blockarchive"
 	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/graphstore"
 	"github.com/quidnug/quidnug/internal/ipfsclient"
+	"github.com/quidnug/quidnug/internal/logsink"
 	"github.com/quidnug/quidnug/internal/ratelimit"
 	"github.com/quidnug/quidnug/internal/safeio"
+	"github.com/quidnug/quidnug/internal/wallet"
 )
 
 // Lock ordering to prevent deadlocks:
@@ -50,35 +55,37 @@ import (
 //   - Release locks as soon as the protected data is no longer needed
 //   - Use RLock for read-only access to enable concurrent readers
 //   - Never call external code (HTTP requests, etc.) while holding a lock
-//   - When computing trust (ComputeRelationalTrust), only TrustRegistryMutex is held briefly for reads
+//   - ComputeRelationalTrust reads a published copy-on-write view of the
+//     trust graph (trust_view.go) and takes no registry lock while searching
 
 // Package-level logger. Initialized to a safe default so that code called
 // outside main() (unit tests, library use) never panics on a nil logger.
-// initLogger replaces it with the configured handler during startup.
-var logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-	Level: slog.LevelInfo,
-}))
-
-// initLogger initializes the structured logger based on the log level
-func initLogger(logLevel string) {
-	var level slog.Level
-	switch strings.ToLower(logLevel) {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn", "warning":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
+// initLogger replaces its handler with the configured one during startup
+// (see logRoot in logging.go).
+var logger = func() *slog.Logger {
+	setLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	return slog.New(&swapHandler{})
+}()
+
+// initLogger resets the logger to JSON on stdout at level (info
+// when unrecognized). Run uses initLogSinks (logging.go) instead.
+func initLogger(level string) {
+	l, _ := logsink.ParseLevel(level)
+	logLevel.Set(l)
 
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
+		Level: logLevel,
 	})
-	logger = slog.New(handler)
+	setLogHandler(handler)
+}
+
+// SetLogger replaces the package logger. Embedders that run nodes
+// in-process (simnet) use it to keep node logs off stdout. Safe to
+// call while nodes are running.
+func SetLogger(l *slog.Logger) {
+	setLogHandler(l.Handler())
 }
 
 // QuidnugNode is the main server structure
@@ -128,6 +135,10 @@ type QuidnugNode struct {
 	// every private range globally.
 	PrivateAddrAllowList *PrivateAddrAllowList
 
+	// manualPeers are the peers added through the admin API,
+	// reconnected at boot. See peer_manual.go.
+	manualPeers *manualPeerStore
+
 	// PeerAdmit is the snapshot of admit-pipeline thresholds
 	// captured at boot. Used by every peer source (gossip,
 	// static, mDNS) so the gating policy is uniform.
@@ -147,6 +158,11 @@ type QuidnugNode struct {
 	PeerQuarantineThreshold float64
 	PeerEvictionThreshold   float64
 
+	// PeerBanThreshold and PeerBanDuration govern offense-driven
+	// bans; see peer_ban.go.
+	PeerBanThreshold float64
+	PeerBanDuration  time.Duration
+
 	// opReputationCache memoizes operatorReputation()
 	// aggregates with a 5-minute TTL so the admit hot path
 	// doesn't walk the trust graph on every peer interaction.
@@ -171,6 +187,42 @@ type QuidnugNode struct {
 	// seconds" on the landing page.
 	processStartedAt time.Time
 
+	// registryVersion counts registry mutations; together with the
+	// chain length it keys ETags on read endpoints. See etag.go.
+	registryVersion atomic.Uint64
+
+	// trustViewState publishes the copy-on-write trust graph that
+	// relational trust queries read. See trust_view.go.
+	trustViewState trustViewState
+
+	// trustTrackers keeps the node's own best-path tables for hot
+	// trust depths. See trust_incremental.go.
+	trustTrackers trustTrackers
+
+	// membershipFilters caches the per-registry Golomb-coded sets
+	// served to light peers. See membership_filter.go.
+	membershipFilters membershipFilters
+
+	// provenanceBackfill serializes admin-triggered rescans of trust
+	// edge provenance. See trust_provenance.go.
+	provenanceBackfill provenanceBackfillState
+
+	// bulkImports tracks /admin/import jobs (bulk_import.go).
+	bulkImports importJobs
+
+	// lifetimeCtx is the context StartBackground was given; sends
+	// that outlive the request that caused them use it (see
+	// lifetime in network.go).
+	lifetimeCtx atomic.Pointer[context.Context]
+
+	// applyMu serializes applying blocks to the registries, so a
+	// state rebuild never interleaves with a live commit. See
+	// state_rebuild.go.
+	applyMu sync.Mutex
+	// rebuild guards against concurrent rebuilds and keeps the last
+	// report.
+	rebuild stateRebuildState
+
 	// State registries
 	TrustRegistry      map[string]map[string]float64
 	TrustNonceRegistry map[string]map[string]int64
@@ -186,6 +238,22 @@ type QuidnugNode struct {
 	// TrustRegistryMutex. Zero = no timestamp known (treat as
 	// "decay disabled" for that edge).
 	TrustEdgeTimestampRegistry map[string]map[string]int64
+	// TrustEdgeDomainRegistry records the trust domain of the
+	// TRUST transaction that last set each edge, for per-domain
+	// graph export. Guarded by TrustRegistryMutex.
+	TrustEdgeDomainRegistry map[string]map[string]string
+	// TrustEdgeDomainLevels records, per edge, the level each trust
+	// domain last set (truster → trustee → domain → level), for
+	// domain-scoped queries. Guarded by TrustRegistryMutex.
+	TrustEdgeDomainLevels map[string]map[string]map[string]float64
+	// TrustEdgeContextLevels records, per edge, the level last set
+	// under each context tag, per domain and across domains. Guarded
+	// by TrustRegistryMutex.
+	TrustEdgeContextLevels map[string]map[string]map[trustContextKey]float64
+	// TrustEvidenceRegistry holds the evidence references of the
+	// TRUST transaction that last set each edge. Edges without
+	// evidence have no entry. Guarded by TrustRegistryMutex.
+	TrustEvidenceRegistry map[string]map[string]TrustEdgeEvidence
 	IdentityRegistry   map[string]IdentityTransaction
 	TitleRegistry      map[string]TitleTransaction
 
@@ -201,6 +269,149 @@ type QuidnugNode struct {
 	// lock; no QuidnugNode-level mutex needed.
 	ModerationRegistry *ModerationRegistry
 
+	// Human-readable name registry (NAME_REGISTRATION). Owns its
+	// own internal lock.
+	NameRegistry *NameRegistry
+
+	// Title lien registry (LIEN). Owns its own internal lock.
+	LienRegistry *LienRegistry
+
+	// Signed activity and completed successions (SUCCESSION). Owns
+	// its own internal lock.
+	SuccessionRegistry *SuccessionRegistry
+
+	// Validator misbehavior reports (MISBEHAVIOR_REPORT). Owns its
+	// own internal lock.
+	MisbehaviorRegistry *MisbehaviorRegistry
+
+	// Validator-quorum checkpoints (CHECKPOINT) and the state roots
+	// this node computed at checkpoint heights. Owns its own
+	// internal lock.
+	Checkpoints *CheckpointRegistry
+	// CheckpointInterval is the per-domain block height interval at
+	// which validators sign checkpoints; <= 0 disables signing.
+	CheckpointInterval int64
+
+	// Domain control attestations (DOMAIN_CONTROL) and the
+	// challenges this node has issued. Owns its own internal lock.
+	DomainControlRegistry *DomainControlRegistry
+
+	// EVMAnchors publishes domain block hashes and state roots to
+	// an EVM contract and keeps the proofs. Nil unless
+	// evm_anchor_rpc_url is set.
+	EVMAnchors *EVMAnchorer
+
+	// BlockTimestamps holds RFC 3161 tokens for committed blocks.
+	// Nil unless timestamp_authority_url is set.
+	BlockTimestamps *BlockTimestamper
+
+	// Conditional title transfers awaiting time locks or
+	// approvals. Owns its own internal lock.
+	EscrowRegistry *EscrowRegistry
+
+	// What happened to titles that passed their ExpiryDate. Owns
+	// its own internal lock.
+	TitleExpiries *TitleExpiryRegistry
+
+	// Title split/merge lineage (TITLE_RESTRUCTURE). Owns its own
+	// internal lock.
+	TitleLineage *TitleLineageRegistry
+
+	// Title disputes (TITLE_DISPUTE) and the former owners that
+	// give standing to file them. Owns its own internal lock.
+	TitleDisputes *TitleDisputeRegistry
+
+	// Committed document notarizations (NOTARIZATION events),
+	// for receipts. Owns its own internal lock.
+	Notarizations *NotarizationRegistry
+
+	// Incremental per-domain chain statistics for
+	// /domains/{name}/stats. Owns its own internal lock.
+	DomainAnalytics *DomainAnalytics
+
+	// Which trusted block last wrote each identity, title, and
+	// trust edge, for tiered reads. Owns its own internal lock.
+	EntitySources *EntitySourceIndex
+
+	// Untrusted blocks held for manual review. Owns its own
+	// internal lock.
+	BlockQuarantine *BlockQuarantine
+
+	// Wakes /blocks/stream subscribers when the trusted chain
+	// grows. Owns its own internal lock.
+	BlockFeed *BlockFeed
+
+	// Registered quid and asset watch lists. Owns its own
+	// internal lock.
+	Watches *WatchRegistry
+
+	// Publishes committed blocks' chain events to Kafka or NATS;
+	// nil unless event_sink is configured.
+	EventPublisher *EventPublisher
+
+	// Operator-registered validation hooks per transaction type.
+	// Owns its own internal lock.
+	TxHooks *TxValidationHooks
+
+	// Application-registered custom transaction types and the
+	// GENERIC transactions committed under them. Owns its own
+	// internal lock.
+	CustomTxRegistry *CustomTxRegistry
+
+	// Per-domain asset classes constraining identities and titles
+	// that name them. Owns its own internal lock.
+	AssetClasses *AssetClassRegistry
+
+	// Competing identity updates detected for the same
+	// (quid, UpdateNonce). Owns its own internal lock.
+	IdentityConflicts *IdentityConflictLog
+
+	// Reverse index from owner quid to the assets they hold a stake
+	// in, maintained by updateTitleRegistry. Owns its own lock.
+	OwnerIndex *OwnerIndex
+
+	// Off-node store for pruned blocks; nil disables pruning.
+	// BlockRetention is the node-wide number of full blocks kept
+	// per domain (0 = keep all).
+	BlockArchive   blockarchive.Archive
+	BlockRetention int
+
+	// Custodied quid keys; nil unless wallet_enabled. See wallet.go.
+	Wallet *wallet.Store
+
+	// Outstanding proof-of-control challenges. Owns its own
+	// internal lock.
+	QuidChallenges *ChallengeStore
+
+	// Bearer-token authentication; nil unless oidc_issuer is set.
+	// See oidc_auth.go.
+	OIDCAuth *OIDCAuth
+
+	// Content-addressed attachments; nil unless blob_store_enabled.
+	// Unreferenced blobs older than BlobGCGrace are collected. See
+	// blobs.go.
+	Blobs       *blobstore.Store
+	BlobGCGrace time.Duration
+
+	// Trust-graph anomaly detection; nil unless
+	// anomaly_detection_enabled. See anomaly.go.
+	Anomalies *AnomalyDetector
+
+	// Role is NodeRoleValidator, NodeRoleReplica or NodeRoleGateway.
+	// A replica has ReplicaUpstreams set and follows those validators
+	// read-only; a gateway has Gateway set and proxies queries.
+	Role             string
+	ReplicaUpstreams *ReplicaUpstreams
+	Gateway          *Gateway
+
+	// Cross-origin policy for browser clients, from the cors_*
+	// config.
+	CORSPolicy CORSPolicy
+
+	// Optional external trust graph backend; nil means the
+	// in-memory TrustRegistry answers path queries alone.
+	TrustGraphStore graphstore.Store
+
 	// QDP-0017: privacy registry (consent / restriction / DSR).
 	// Owns its own internal lock.
 	PrivacyRegistry *PrivacyRegistry
@@ -241,6 +452,29 @@ type QuidnugNode struct {
 	// HTTP client for network communication
 	httpClient *http.Client
 
+	// peerGuard is the per-peer breaker under httpClient; nil when
+	// both breakers and concurrency limits are off. See
+	// peer_breaker.go.
+	peerGuard *peerGuardTransport
+
+	// broadcastSeen holds digests of recently broadcast transactions
+	// so a transaction echoed back by peers is not gossiped again.
+	broadcastSeenMu sync.Mutex
+	broadcastSeen   map[[32]byte]int64
+
+	// inv queues inventory announcements and tracks in-flight
+	// getdata fetches. See inventory.go.
+	inv *inventoryRelay
+
+	// wireFormat is the body encoding requested from peers on the
+	// block and transaction exchange endpoints. See wire_format.go.
+	wireFormat string
+
+	// domainJoins holds DOMAIN_JOIN requests still collecting
+	// validator approvals, keyed by transaction ID.
+	domainJoinsMu sync.Mutex
+	domainJoins   map[string]DomainJoinTransaction
+
 	// Domain registry - reverse index from domain to node IDs for efficient lookup
 	DomainRegistry map[string][]string
 
@@ -270,6 +504,57 @@ type QuidnugNode struct {
 	// Threshold configuration
 	DistrustThreshold         float64 // Below this, block is 'untrusted' (default 0.0)
 	TransactionTrustThreshold float64 // Minimum trust to include tx in block (default 0.0)
+	// SybilMinScore is the minimum sybil score (sybil.go) a
+	// transaction's creator needs for block inclusion; 0 disables.
+	SybilMinScore float64
+
+	// TrustAnchors are observers merged with the node's own view in
+	// its trust decisions; TrustAnchorCallerWeight weighs an API
+	// caller asking for trust as themselves. See trust_anchors.go.
+	TrustAnchors            []TrustAnchor
+	TrustAnchorCallerWeight float64
+
+	// acceptancePolicy, when set, replaces the threshold pair in
+	// ValidateTrustProofTiered. See acceptance_policy.go.
+	acceptancePolicy atomic.Pointer[AcceptancePolicy]
+
+	// BlockValidationWorkers bounds the goroutines that check a
+	// block's transactions in parallel; 0 means GOMAXPROCS.
+	BlockValidationWorkers int
+
+	// BlockProductionMaxTentative and BlockProductionMaxSyncLag hold
+	// back block generation in a domain the node has fallen behind
+	// on; zero or negative disables a check. See
+	// block_backpressure.go.
+	BlockProductionMaxTentative int
+	BlockProductionMaxSyncLag   int
+	backpressureMu              sync.Mutex
+	backpressured               map[string]backpressureState
+
+	// BlockMaxTransactions and BlockMaxBytes are the node-wide
+	// per-block limits; zero or negative means no limit. See
+	// block_limits.go.
+	BlockMaxTransactions int
+	BlockMaxBytes        int
+
+	// MempoolTypePriorities and MempoolCreatorTrustWeight rank
+	// pending transactions for block inclusion. See
+	// mempool_priority.go.
+	MempoolTypePriorities     map[TransactionType]float64
+	MempoolCreatorTrustWeight float64
+
+	// TrustQueryMaxMillis caps the time budget of one trust query
+	// over the API; 0 means no cap. See trust_budget.go.
+	TrustQueryMaxMillis int
+
+	// FederationCache caches and rate limits queries forwarded to
+	// other domains; nil forwards every query. See
+	// federation_cache.go.
+	FederationCache *FederationCache
+
+	// startConfig is the configuration the node was built from,
+	// reported (secrets blanked) in the diagnostics bundle.
+	startConfig *config.Config
 
 	// Domain restriction configuration
 	SupportedDomains        []string // Empty = all domains allowed
@@ -279,6 +564,10 @@ type QuidnugNode struct {
 	// Trust computation cache
 	TrustCache *TrustCache
 
+	// Hot-target tracking for trust precomputation; nil when
+	// TrustPrecomputeTargets is 0.
+	TrustPrecompute *TrustPrecompute
+
 	// Gossip protocol state
 	GossipSeen      map[string]int64 // messageId -> timestamp of when seen
 	GossipSeenMutex sync.RWMutex
@@ -330,6 +619,12 @@ type QuidnugNode struct {
 	// TransactionsRoot are rejected. Before activation the
 	// field is optional and producers emit it in shadow mode.
 	RequireTxTreeRoot bool
+
+	// When true (activated via QDP-0009 fork for
+	// `require_canonical_tx_order`), incoming blocks whose
+	// transactions are not in canonical order are rejected.
+	// Producers sort regardless.
+	RequireCanonicalTxOrder bool
 }
 
 // Run starts the Quidnug node's main loop: loads configuration, initializes
@@ -341,45 +636,115 @@ func Run() {
 	cfg := config.LoadConfig()
 
 	// Initialize structured logger
-	initLogger(cfg.LogLevel)
+	logSinks, err := initLogSinks(cfg)
+	if err != nil {
+		initLogger(cfg.LogLevel)
+		logger.Error("Invalid log output configuration", "error", err)
+		os.Exit(1)
+	}
+	defer logSinks.Close()
 
 	// Create cancellable context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// WaitGroup for background goroutines
+	var wg sync.WaitGroup
+
+	// Set up signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigChan
+		logger.Info("Received shutdown signal", "signal", sig.String())
+		cancel()
+	}()
+
+	if len(cfg.Tenants) > 0 {
+		runTenants(ctx, cancel, cfg, &wg)
+		return
+	}
+
 	// Initialize node
 	quidnugNode, err := NewQuidnugNode(cfg)
 	if err != nil {
 		logger.Error("Failed to initialize quidnug node", "error", err)
 		os.Exit(1)
 	}
+	quidnugNode.StartBackground(ctx, cfg, &wg)
+
+	// Start HTTP server (non-blocking)
+	serverErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := quidnugNode.StartServerWithConfig(cfg.Port, cfg.RateLimitPerMinute, cfg.MaxBodySizeBytes); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// Wait for shutdown signal or server error
+	select {
+	case <-ctx.Done():
+		logger.Info("Initiating graceful shutdown...")
+	case err := <-serverErr:
+		logger.Error("Server failed", "error", err)
+		cancel()
+	}
+
+	// Graceful shutdown sequence
+	quidnugNode.Shutdown(ctx, cfg)
+
+	// Wait for all goroutines to finish
+	logger.Info("Waiting for background goroutines to finish...")
+	wg.Wait()
+
+	logger.Info("Shutdown complete")
+}
+
+// StartBackground loads the node's persisted pending transactions
+// and peers and starts its background loops (discovery, sync, block
+// generation, persistence, schedulers) on wg. They stop when ctx is
+// cancelled.
+func (node *QuidnugNode) StartBackground(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup) {
+	node.lifetimeCtx.Store(&ctx)
 
 	// Configure HTTP client timeout from config
-	quidnugNode.SetHTTPClientTimeout(cfg.HTTPClientTimeout)
+	node.SetHTTPClientTimeout(cfg.HTTPClientTimeout)
 
 	// Load persisted pending transactions
-	if err := quidnugNode.LoadPendingTransactions(cfg.DataDir); err != nil {
+	if err := node.LoadPendingTransactions(cfg.DataDir); err != nil {
 		logger.Warn("Failed to load pending transactions", "error", err)
 	}
 
-	// WaitGroup for background goroutines
-	var wg sync.WaitGroup
+	// Load the peer table saved by the last run; the peers are
+	// re-contacted below, healthiest first.
+	persistedPeers, err := node.LoadKnownNodes(cfg.DataDir)
+	if err != nil {
+		logger.Warn("Failed to load persisted peers", "error", err)
+	}
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Discover other nodes via seeds (with context), or via the
+	// domains' DNS records when cfg.DNSDiscovery is set.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		node.DiscoverNodes(ctx, cfg.SeedNodes, cfg.DNSDiscovery)
+	}()
 
+	wg.Add(1)
 	go func() {
-		sig := <-sigChan
-		logger.Info("Received shutdown signal", "signal", sig.String())
-		cancel()
+		defer wg.Done()
+		node.reconnectPersistedPeers(ctx, persistedPeers)
 	}()
 
-	// Discover other nodes via seeds (with context)
+	// Peers added through POST /api/v1/admin/peers before the
+	// last shutdown.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.DiscoverNodes(ctx, cfg.SeedNodes)
+		node.restoreManualPeers(ctx)
 	}()
 
 	// Static peers from operator-managed peers_file. Idempotent
@@ -387,7 +752,7 @@ func Run() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runStaticPeerLoop(ctx, cfg.PeersFile, quidnugNode.PeerAdmit)
+		node.runStaticPeerLoop(ctx, cfg.PeersFile, node.PeerAdmit)
 	}()
 
 	// LAN discovery via mDNS. Off by default; opt-in for home,
@@ -397,12 +762,12 @@ func Run() {
 	go func() {
 		defer wg.Done()
 		port, _ := strconv.Atoi(cfg.Port)
-		quidnugNode.runLANPeerLoop(
+		node.runLANPeerLoop(
 			ctx,
 			cfg.LANDiscovery,
 			cfg.LANServiceName,
 			port,
-			quidnugNode.PeerAdmit,
+			node.PeerAdmit,
 		)
 	}()
 
@@ -413,7 +778,7 @@ func Run() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runPeerScorePersistLoop(ctx, peerScoreboardPath(cfg), cfg.PeerScorePersistInterval)
+		node.runPeerScorePersistLoop(ctx, peerScoreboardPath(cfg), cfg.PeerScorePersistInterval)
 	}()
 
 	// Peer eviction + quarantine loop. Walks KnownNodes every
@@ -425,7 +790,7 @@ func Run() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runPeerEvictionLoop(
+		node.runPeerEvictionLoop(
 			ctx,
 			cfg.PeerQuarantineThreshold,
 			cfg.PeerEvictionThreshold,
@@ -441,7 +806,7 @@ func Run() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runPeerReattestLoop(ctx, cfg.PeerReattestationInterval)
+		node.runPeerReattestLoop(ctx, cfg.PeerReattestationInterval)
 	}()
 
 	// ENG-75: snapshot blockchain + trust domains to data_dir
@@ -451,68 +816,123 @@ func Run() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runStatePersistLoop(ctx, cfg.DataDir)
+		node.runStatePersistLoop(ctx, cfg.DataDir)
 	}()
 
-	// ENG-78: pull-based block sync between admitted peers.
-	// Every 30s, walk KnownNodes and ask each peer for blocks
-	// beyond our tip via GET /api/v1/blocks. Returned blocks
-	// flow through ReceiveBlock, the same validation pipeline
-	// as locally-mined blocks. Quarantined peers are skipped.
+	// Block archival: move full blocks beyond each domain's
+	// retention window into the block archive, keeping headers.
+	// No-op when no archive backend is configured.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runBlockSyncLoop(ctx)
+		node.runBlockPruneLoop(ctx, cfg.BlockPruneInterval)
 	}()
 
-	// Start block generation for managed trust domains (with context)
+	// Signed registry snapshots pushed to the same archive for
+	// point-in-time historical queries.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runBlockGeneration(ctx, cfg.BlockInterval)
+		node.runRegistrySnapshotLoop(ctx, cfg.RegistrySnapshotInterval)
 	}()
 
+	switch {
+	case node.Gateway != nil:
+		// Gateway: holds no chain; every query is proxied to the
+		// configured backends, so there is nothing to sync.
+	case node.IsReplica():
+		// Read replica: follow the configured upstream validators
+		// only, with failover; no peer fan-out sync and no block
+		// generation.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runReplicaSyncLoop(ctx, cfg.ReplicaSyncInterval)
+		}()
+	default:
+		// ENG-78: pull-based block sync between admitted peers.
+		// Every 30s, walk KnownNodes and ask each peer for blocks
+		// beyond our tip via GET /api/v1/blocks. Returned blocks
+		// flow through ReceiveBlock, the same validation pipeline
+		// as locally-mined blocks. Quarantined peers are skipped.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runBlockSyncLoop(ctx)
+		}()
+
+		// Start block generation for managed trust domains (with context)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runBlockGeneration(ctx, cfg.BlockInterval)
+		}()
+
+		// Pull pending transactions this node missed (restart,
+		// late join) from peers in each supported domain. No-op
+		// when cfg.MempoolSyncInterval is not positive.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runMempoolSyncLoop(ctx, cfg.MempoolSyncInterval)
+		}()
+	}
+
 	// Start domain gossip loop (with context)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runDomainGossip(ctx, cfg.DomainGossipInterval)
+		node.runDomainGossip(ctx, cfg.DomainGossipInterval)
 	}()
 
 	// Start tentative-block GC loop (QDP-0001 §6.4)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		quidnugNode.runTentativeBlockGC(ctx, DefaultTentativeGCInterval, DefaultTentativeBlockMaxAge)
+		node.runTentativeBlockGC(ctx, DefaultTentativeGCInterval, DefaultTentativeBlockMaxAge)
 	}()
 
-	// Start HTTP server (non-blocking)
-	serverErr := make(chan error, 1)
+	// Delete attachment blobs no transaction references.
+	if node.Blobs != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runBlobGCLoop(ctx, DefaultBlobGCInterval)
+		}()
+	}
+
+	// Keep the node's most-queried trust paths warm.
+	if node.TrustPrecompute != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node.runTrustPrecompute(ctx, DefaultTrustPrecomputeInterval)
+		}()
+	}
+
+	// Mirror the chain into PostgreSQL for analytics. Idempotent
+	// no-op when cfg.SQLExportURL is empty.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := quidnugNode.StartServerWithConfig(cfg.Port, cfg.RateLimitPerMinute, cfg.MaxBodySizeBytes); err != nil && err != http.ErrServerClosed {
-			serverErr <- err
-		}
+		node.runSQLExportLoop(ctx, cfg.SQLExportURL)
 	}()
 
-	// Wait for shutdown signal or server error
-	select {
-	case <-ctx.Done():
-		logger.Info("Initiating graceful shutdown...")
-	case err := <-serverErr:
-		logger.Error("Server failed", "error", err)
-		cancel()
-	}
-
-	// Graceful shutdown sequence
-	quidnugNode.Shutdown(ctx, cfg)
-
-	// Wait for all goroutines to finish
-	logger.Info("Waiting for background goroutines to finish...")
-	wg.Wait()
+	// Anchor configured domains to an EVM chain. No-op unless
+	// cfg.EVMAnchorRPCURL is set.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		node.runEVMAnchorLoop(ctx, cfg.EVMAnchorInterval)
+	}()
 
-	logger.Info("Shutdown complete")
+	// Timestamp committed blocks with an RFC 3161 authority. No-op
+	// unless cfg.TimestampAuthorityURL is set.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		node.runBlockTimestampLoop(ctx)
+	}()
 }
 
 // runBlockGeneration runs the block generation loop with context cancellation support
@@ -560,6 +980,18 @@ func (node *QuidnugNode) Shutdown(ctx context.Context, cfg *config.Config) {
 	if err := node.SaveTrustDomains(cfg.DataDir); err != nil {
 		logger.Error("Failed to save trust domains on shutdown", "error", err)
 	}
+	if err := node.SaveTentativeBlocks(cfg.DataDir); err != nil {
+		logger.Error("Failed to save tentative blocks on shutdown", "error", err)
+	}
+	if err := node.SaveKnownNodes(cfg.DataDir); err != nil {
+		logger.Error("Failed to save known nodes on shutdown", "error", err)
+	}
+
+	if node.EventPublisher != nil {
+		if err := node.EventPublisher.Close(); err != nil {
+			logger.Error("Failed to close event sink", "error", err)
+		}
+	}
 }
 
 // NewQuidnugNode initializes a new quidnug node.
@@ -590,6 +1022,10 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 	if cfg.DomainGossipTTL <= 0 {
 		cfg.DomainGossipTTL = config.DefaultDomainGossipTTL
 	}
+	// Zero means unset; negative disables checkpoint signing
+	if cfg.CheckpointInterval == 0 {
+		cfg.CheckpointInterval = config.DefaultCheckpointInterval
+	}
 	// Load (or create-and-persist) the per-process ECDSA keypair.
 	// ENG-75: prior versions generated a fresh key on every boot,
 	// so NodeID changed across restarts and silently invalidated
@@ -654,6 +1090,77 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		ipfsClient = &ipfsclient.NoOpIPFSClient{}
 	}
 
+	trustGraphStore, err := newTrustGraphStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	blockArchive, err := newBlockArchive(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	custody, err := newWallet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcAuth, err := newOIDCAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	acceptancePolicy, err := CompileAcceptancePolicy(cfg.BlockAcceptancePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	trustAnchors, err := newTrustAnchors(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := newBlobStore(cfg, ipfsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	eventPublisher, err := newEventPublisher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	evmAnchors, err := newEVMAnchorer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	blockTimestamps, err := newBlockTimestamper(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaUpstreams, err := newReplicaUpstreams(cfg)
+	if err != nil {
+		return nil, err
+	}
+	gateway, err := newGateway(cfg)
+	if err != nil {
+		return nil, err
+	}
+	role := NodeRoleValidator
+	switch {
+	case replicaUpstreams != nil:
+		role = NodeRoleReplica
+	case gateway != nil:
+		role = NodeRoleGateway
+	}
+
+	var trustPrecompute *TrustPrecompute
+	if cfg.TrustPrecomputeTargets > 0 {
+		trustPrecompute = NewTrustPrecompute(cfg.TrustPrecomputeTargets)
+	}
+
 	node := &QuidnugNode{
 		NodeID:                    nodeID,
 		OperatorQuidID:            operatorQuidID,
@@ -670,12 +1177,54 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		TrustNonceRegistry:            make(map[string]map[string]int64),
 		TrustExpiryRegistry:           make(map[string]map[string]int64),
 		TrustEdgeTimestampRegistry:    make(map[string]map[string]int64),
+		TrustEdgeDomainRegistry:       make(map[string]map[string]string),
+		TrustEdgeDomainLevels:         make(map[string]map[string]map[string]float64),
+		TrustEdgeContextLevels:        make(map[string]map[string]map[trustContextKey]float64),
+		TrustEvidenceRegistry:         make(map[string]map[string]TrustEdgeEvidence),
 		IdentityRegistry:          make(map[string]IdentityTransaction),
 		TitleRegistry:             make(map[string]TitleTransaction),
 		EventStreamRegistry:       make(map[string]*EventStream),
 		EventRegistry:             make(map[string][]EventTransaction),
 		NodeAdvertisementRegistry: NewNodeAdvertisementRegistry(),
 		ModerationRegistry:        NewModerationRegistry(),
+		NameRegistry:              NewNameRegistry(),
+		LienRegistry:              NewLienRegistry(),
+		SuccessionRegistry:        NewSuccessionRegistry(),
+		MisbehaviorRegistry:       NewMisbehaviorRegistry(),
+		Checkpoints:               NewCheckpointRegistry(),
+		CheckpointInterval:        cfg.CheckpointInterval,
+		DomainControlRegistry:     NewDomainControlRegistry(),
+		EVMAnchors:                evmAnchors,
+		BlockTimestamps:           blockTimestamps,
+		EscrowRegistry:            NewEscrowRegistry(),
+		TitleExpiries:             NewTitleExpiryRegistry(),
+		TitleLineage:              NewTitleLineageRegistry(),
+		TitleDisputes:             NewTitleDisputeRegistry(),
+		Notarizations:             NewNotarizationRegistry(),
+		DomainAnalytics:           NewDomainAnalytics(),
+		EntitySources:             NewEntitySourceIndex(),
+		BlockQuarantine:           NewBlockQuarantine(DefaultBlockQuarantineMaxSize),
+		BlockFeed:                 NewBlockFeed(),
+		Watches:                   NewWatchRegistry(),
+		EventPublisher:            eventPublisher,
+		TxHooks:                   NewTxValidationHooks(),
+		CustomTxRegistry:          NewCustomTxRegistry(),
+		AssetClasses:              NewAssetClassRegistry(),
+		IdentityConflicts:         NewIdentityConflictLog(DefaultIdentityConflictLogSize),
+		OwnerIndex:                NewOwnerIndex(),
+		BlockArchive:              blockArchive,
+		BlockRetention:            cfg.BlockRetention,
+		Wallet:                    custody,
+		QuidChallenges:            NewChallengeStore(DefaultChallengeTTL, DefaultMaxChallenges),
+		OIDCAuth:                  oidcAuth,
+		Blobs:                     blobs,
+		BlobGCGrace:               cfg.BlobGCGrace,
+		Anomalies:                 newAnomalyDetector(cfg),
+		Role:                      role,
+		ReplicaUpstreams:          replicaUpstreams,
+		Gateway:                   gateway,
+		CORSPolicy:                newCORSPolicy(cfg),
+		TrustGraphStore:           trustGraphStore,
 		PrivacyRegistry:           NewPrivacyRegistry(),
 		DNSAttestationRegistry:    NewDNSAttestationRegistry(),
 		BlindKeyRegistry:          NewBlindKeyRegistry(),
@@ -689,10 +1238,26 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		DomainRegistry:            make(map[string][]string),
 		DistrustThreshold:         0.0,
 		TransactionTrustThreshold: 0.0,
+		SybilMinScore:             cfg.SybilMinScore,
+		TrustAnchors:              trustAnchors,
+		TrustAnchorCallerWeight:   cfg.TrustAnchorCallerWeight,
+		BlockValidationWorkers:    cfg.BlockValidationWorkers,
+
+		BlockProductionMaxTentative: cfg.BlockProductionMaxTentative,
+		BlockProductionMaxSyncLag:   cfg.BlockProductionMaxSyncLag,
+		BlockMaxTransactions:        cfg.BlockMaxTransactions,
+		BlockMaxBytes:               cfg.BlockMaxBytes,
+		MempoolTypePriorities:       mempoolTypePriorities(cfg.MempoolTypePriorities),
+		MempoolCreatorTrustWeight:   cfg.MempoolCreatorTrustWeight,
+
+		TrustQueryMaxMillis:       cfg.TrustQueryMaxMillis,
+		FederationCache:           NewFederationCache(cfg.FederationCacheTTL, cfg.FederationCacheMaxStale, cfg.FederationQueriesPerMinute),
+		startConfig:               cfg,
 		SupportedDomains:          cfg.SupportedDomains,
 		AllowDomainRegistration:   cfg.AllowDomainRegistration,
 		RequireParentDomainAuth:   cfg.RequireParentDomainAuth,
-		TrustCache:                NewTrustCache(cfg.TrustCacheTTL),
+		TrustCache:                NewBoundedTrustCache(cfg.TrustCacheTTL, cfg.TrustCacheMaxEntries),
+		TrustPrecompute:           trustPrecompute,
 		GossipSeen:                make(map[string]int64),
 		GossipTTL:                 cfg.DomainGossipTTL,
 		NonceLedger:               NewNonceLedger(),
@@ -718,6 +1283,8 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		),
 		PeerQuarantineThreshold: cfg.PeerQuarantineThreshold,
 		PeerEvictionThreshold:   cfg.PeerEvictionThreshold,
+		PeerBanThreshold:        cfg.PeerBanThreshold,
+		PeerBanDuration:         cfg.PeerBanDuration,
 		DomainQueryCounts:       &sync.Map{},
 		processStartedAt:        time.Now(),
 	}
@@ -738,6 +1305,42 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 			ExpectContinueTimeout: 1 * time.Second,
 		},
 	}
+	if cfg.PeerProxyURL != "" {
+		if err := node.usePeerProxy(cfg.PeerProxyURL); err != nil {
+			return nil, err
+		}
+	}
+	wireFormat, err := parseWireFormat(cfg.PeerWireFormat)
+	if err != nil {
+		return nil, err
+	}
+	node.wireFormat = wireFormat
+	if cfg.PeerBreakerFailures > 0 || cfg.PeerMaxConcurrentRequests > 0 {
+		slotWait := cfg.HTTPClientTimeout
+		if slotWait <= 0 {
+			slotWait = node.httpClient.Timeout
+		}
+		node.peerGuard = newPeerGuardTransport(node.httpClient.Transport,
+			cfg.PeerBreakerFailures, cfg.PeerBreakerCooldown,
+			cfg.PeerMaxConcurrentRequests, slotWait)
+		node.httpClient.Transport = node.peerGuard
+	}
+	if cfg.FaultInjectionFile != "" {
+		faults, err := LoadFaultConfig(cfg.FaultInjectionFile)
+		if err != nil {
+			return nil, fmt.Errorf("load fault schedule from %q: %w", cfg.FaultInjectionFile, err)
+		}
+		if err := node.InjectFaults(faults); err != nil {
+			return nil, err
+		}
+	}
+
+	if replicaUpstreams != nil {
+		node.PrivateAddrAllowList.Set(append(currentAllowList(node), replicaUpstreams.List()...))
+	}
+	if gateway != nil {
+		node.PrivateAddrAllowList.Set(append(currentAllowList(node), gateway.backends.List()...))
+	}
 
 	// Seed the node's own epoch-0 signing key into the ledger so that a
 	// self-rotation anchor can be verified. Other signers' keys are
@@ -796,6 +1399,13 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		"node_id": nodeID,
 	}, "node initialized")
 
+	manualPeers, err := newManualPeerStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("load manual peers: %w", err)
+	}
+	node.manualPeers = manualPeers
+	node.inv = newInventoryRelay()
+
 	// Rehydrate peer scores from disk if a previous run wrote
 	// peer_scores.json. Missing file is fine.
 	if node.PeerScoreboard != nil {
@@ -804,6 +1414,8 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 		}
 	}
 
+	node.SetAcceptancePolicy(acceptancePolicy)
+
 	// ENG-75: rehydrate blockchain + trust domains from
 	// data_dir snapshots. Missing files are silent (clean
 	// boot); corrupt files fail loudly so operators don't
@@ -814,6 +1426,14 @@ func NewQuidnugNode(cfg *config.Config) (*QuidnugNode, error) {
 	if err := node.LoadTrustDomains(cfg.DataDir); err != nil {
 		return nil, fmt.Errorf("load trust domains: %w", err)
 	}
+	if err := node.LoadTentativeBlocks(cfg.DataDir, DefaultTentativeBlockMaxAge); err != nil {
+		return nil, fmt.Errorf("load tentative blocks: %w", err)
+	}
+	if cfg.RebuildStateOnStart {
+		if _, err := node.RebuildState(context.Background(), StateRebuildOptions{FreezeClock: true}); err != nil {
+			return nil, fmt.Errorf("rebuild state: %w", err)
+		}
+	}
 
 	if logger != nil {
 		fields := []any{"nodeId", nodeID}
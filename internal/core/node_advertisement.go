@@ -10,7 +10,6 @@ package core
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -224,7 +223,7 @@ func (node *QuidnugNode) updateNodeAdvertisementRegistry(tx NodeAdvertisementTra
 	if node.NodeAdvertisementRegistry == nil {
 		return
 	}
-	node.NodeAdvertisementRegistry.upsert(tx, time.Now())
+	node.NodeAdvertisementRegistry.upsert(tx, nowTime())
 	logger.Debug("Updated node advertisement registry",
 		"nodeQuid", tx.NodeQuid,
 		"operatorQuid", tx.OperatorQuid,
@@ -332,13 +331,13 @@ func (node *QuidnugNode) ValidateNodeAdvertisementTransaction(tx NodeAdvertiseme
 	}
 
 	// 4. Expiry sanity.
-	nowNano := time.Now().UnixNano()
-	if tx.ExpiresAt <= nowNano {
+	now := nowNano()
+	if tx.ExpiresAt <= now {
 		logger.Warn("Node advertisement already expired",
-			"expiresAt", tx.ExpiresAt, "now", nowNano, "txId", tx.ID)
+			"expiresAt", tx.ExpiresAt, "now", now, "txId", tx.ID)
 		return false
 	}
-	maxExpiry := nowNano + MaxAdvertisementTTL.Nanoseconds()
+	maxExpiry := now + MaxAdvertisementTTL.Nanoseconds()
 	if tx.ExpiresAt > maxExpiry {
 		logger.Warn("Node advertisement TTL exceeds maximum",
 			"expiresAt", tx.ExpiresAt, "max", maxExpiry, "txId", tx.ID)
@@ -409,7 +408,7 @@ func (node *QuidnugNode) ValidateNodeAdvertisementTransaction(tx NodeAdvertiseme
 	// Signature cleared.
 	txCopy := tx
 	txCopy.Signature = ""
-	signableData, err := json.Marshal(txCopy)
+	signableData, err := txSignableBytes(txCopy)
 	if err != nil {
 		logger.Error("Node advertisement marshal for signature failed",
 			"txId", tx.ID, "err", err)
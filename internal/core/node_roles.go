@@ -0,0 +1,157 @@
+// Node roles and capability advertisement.
+//
+// Every node reports what it is and what it can serve in its
+// GET /api/v1/info response: its roles, the API versions it
+// mounts, the domain patterns it accepts and its highest block
+// index. Peers learn these through the admission handshake and
+// refresh them alongside domain info, and they travel on Node in
+// /nodes listings. Peers that predate roles report none and are
+// treated as validators, which is what every node used to be.
+//
+// The roles decide who gets what: transactions are only broadcast
+// to validators (replicas and gateways refuse writes), and block
+// sync skips peers that don't hold a chain.
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Node roles. A node has exactly one of validator, replica or
+// gateway, plus archive when a block archive is configured.
+const (
+	NodeRoleValidator = "validator"
+	NodeRoleReplica   = "replica"
+	NodeRoleArchive   = "archive"
+	NodeRoleGateway   = "gateway"
+)
+
+// SupportedAPIVersions are the API path versions this node mounts.
+var SupportedAPIVersions = []string{"v1", "v2"}
+
+// PeerCapabilities is what a node advertises about itself. It is
+// embedded in Node, so the fields serialize inline.
+type PeerCapabilities struct {
+	Roles            []string `json:"roles,omitempty"`
+	APIVersions      []string `json:"apiVersions,omitempty"`
+	SupportedDomains []string `json:"supportedDomains,omitempty"`
+	MaxBlockHeight   int64    `json:"maxBlockHeight,omitempty"`
+
+	// Protocol version window and known fork features; see
+	// protocol_version.go. Empty for peers that predate
+	// negotiation.
+	ProtocolVersion    int      `json:"protocolVersion,omitempty"`
+	MinProtocolVersion int      `json:"minProtocolVersion,omitempty"`
+	Features           []string `json:"features,omitempty"`
+
+	// Body encodings the peer accepts and serves on the block and
+	// transaction exchange endpoints; see wire_format.go. Empty
+	// means JSON only.
+	Encodings []string `json:"encodings,omitempty"`
+}
+
+// HasRole reports whether the capabilities include role. An empty
+// role list is a pre-roles peer and counts as a validator.
+func (c PeerCapabilities) HasRole(role string) bool {
+	if len(c.Roles) == 0 {
+		return role == NodeRoleValidator
+	}
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// servesBlocks reports whether the peer holds a chain that block
+// sync can pull from.
+func (c PeerCapabilities) servesBlocks() bool {
+	return c.HasRole(NodeRoleValidator) || c.HasRole(NodeRoleReplica) || c.HasRole(NodeRoleArchive)
+}
+
+// Roles returns this node's roles: its primary role, then archive
+// when a block archive is configured.
+func (node *QuidnugNode) Roles() []string {
+	role := node.Role
+	if role == "" {
+		role = NodeRoleValidator
+	}
+	roles := []string{role}
+	if node.BlockArchive != nil {
+		roles = append(roles, NodeRoleArchive)
+	}
+	return roles
+}
+
+// maxBlockHeight returns the highest block index in the chain.
+// Indexes are per domain, so the tail is not necessarily it.
+func (node *QuidnugNode) maxBlockHeight() int64 {
+	node.BlockchainMutex.RLock()
+	defer node.BlockchainMutex.RUnlock()
+	var max int64
+	for i := range node.Blockchain {
+		if node.Blockchain[i].Index > max {
+			max = node.Blockchain[i].Index
+		}
+	}
+	return max
+}
+
+// Capabilities returns what this node advertises to its peers.
+func (node *QuidnugNode) Capabilities() PeerCapabilities {
+	domains := append([]string(nil), node.SupportedDomains...)
+	if node.Gateway != nil {
+		domains = node.Gateway.Domains()
+	}
+	return PeerCapabilities{
+		Roles:            node.Roles(),
+		APIVersions:      append([]string(nil), SupportedAPIVersions...),
+		SupportedDomains: domains,
+		MaxBlockHeight:   node.maxBlockHeight(),
+
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		Features:           SupportedFeatures(),
+
+		Encodings: append([]string(nil), SupportedWireFormats...),
+	}
+}
+
+// refreshKnownNodeCapabilities re-runs the /info handshake against
+// every known peer with an address and stores what each reports.
+// Unreachable peers keep their previous capabilities.
+func (node *QuidnugNode) refreshKnownNodeCapabilities(ctx context.Context) {
+	node.KnownNodesMutex.RLock()
+	targets := make(map[string]string, len(node.KnownNodes))
+	for id, n := range node.KnownNodes {
+		if n.Address != "" && id != node.NodeID {
+			targets[id] = n.Address
+		}
+	}
+	node.KnownNodesMutex.RUnlock()
+
+	for id, addr := range targets {
+		if ctx.Err() != nil {
+			return
+		}
+		safeAddr, err := node.validatePeerAddress(addr)
+		if err != nil {
+			continue
+		}
+		hsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		info, err := node.peerInfoHandshake(hsCtx, safeAddr.String())
+		cancel()
+		if err != nil || info.NodeQuid != id {
+			logger.Debug("Failed to refresh peer capabilities", "nodeId", id, "error", err)
+			continue
+		}
+		node.KnownNodesMutex.Lock()
+		if existing, ok := node.KnownNodes[id]; ok {
+			existing.PeerCapabilities = info.Capabilities
+			node.KnownNodes[id] = existing
+		}
+		node.KnownNodesMutex.Unlock()
+	}
+}
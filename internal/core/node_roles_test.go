@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/blockarchive"
+)
+
+func TestPeerCapabilities_HasRole(t *testing.T) {
+	legacy := PeerCapabilities{}
+	if !legacy.HasRole(NodeRoleValidator) || legacy.HasRole(NodeRoleReplica) {
+		t.Fatal("a peer without roles should count as a validator only")
+	}
+	gw := PeerCapabilities{Roles: []string{NodeRoleGateway}}
+	if gw.HasRole(NodeRoleValidator) || gw.servesBlocks() {
+		t.Fatal("a gateway is neither a validator nor a block source")
+	}
+	replica := PeerCapabilities{Roles: []string{NodeRoleReplica, NodeRoleArchive}}
+	if !replica.servesBlocks() || !replica.HasRole(NodeRoleArchive) {
+		t.Fatal("replica with archive should serve blocks")
+	}
+}
+
+func TestNodeJSONCarriesCapabilities(t *testing.T) {
+	n := Node{ID: "n1", PeerCapabilities: PeerCapabilities{Roles: []string{NodeRoleReplica}, MaxBlockHeight: 7}}
+	raw, _ := json.Marshal(n)
+	if !strings.Contains(string(raw), `"roles":["replica"]`) || !strings.Contains(string(raw), `"maxBlockHeight":7`) {
+		t.Fatalf("capabilities not inlined: %s", raw)
+	}
+	var back Node
+	if err := json.Unmarshal(raw, &back); err != nil || !back.HasRole(NodeRoleReplica) {
+		t.Fatalf("round trip lost roles: %+v %v", back, err)
+	}
+}
+
+func TestNodeRolesAndInfo(t *testing.T) {
+	node := newTestNode()
+	if got := node.Roles(); len(got) != 1 || got[0] != NodeRoleValidator {
+		t.Fatalf("Roles = %v", got)
+	}
+	node.BlockArchive = blockarchive.NewMemoryArchive()
+	appendArchiveTestBlocks(node, 3)
+
+	rr := httptest.NewRecorder()
+	setupTestRouter(node).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/info", nil))
+	var resp struct {
+		Data struct {
+			PeerCapabilities
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	caps := resp.Data.PeerCapabilities
+	if !caps.HasRole(NodeRoleValidator) || !caps.HasRole(NodeRoleArchive) {
+		t.Fatalf("info roles = %v", caps.Roles)
+	}
+	if caps.MaxBlockHeight != 3 || len(caps.APIVersions) != len(SupportedAPIVersions) {
+		t.Fatalf("info capabilities = %+v", caps)
+	}
+}
+
+func TestGetTrustDomainNodes_SkipsNonValidators(t *testing.T) {
+	node := newTestNode()
+	node.KnownNodes["v"] = Node{ID: "v", Address: "v.example:8080"}
+	node.KnownNodes["r"] = Node{ID: "r", Address: "r.example:8080",
+		PeerCapabilities: PeerCapabilities{Roles: []string{NodeRoleReplica}}}
+	node.TrustDomains["roles.domain.com"] = TrustDomain{
+		Name:           "roles.domain.com",
+		ValidatorNodes: []string{"v", "r"},
+	}
+	nodes := node.GetTrustDomainNodes("roles.domain.com")
+	if len(nodes) != 1 || nodes[0].ID != "v" {
+		t.Fatalf("expected only the validator, got %+v", nodes)
+	}
+}
+
+func TestRefreshKnownNodeCapabilities(t *testing.T) {
+	peer := newTestNode()
+	peer.Role = NodeRoleReplica
+	srv := httptest.NewServer(setupTestRouter(peer))
+	defer srv.Close()
+
+	node := newTestNode()
+	node.KnownNodes[peer.NodeID] = Node{ID: peer.NodeID, Address: strings.TrimPrefix(srv.URL, "http://")}
+	node.refreshKnownNodeCapabilities(context.Background())
+
+	got := node.KnownNodes[peer.NodeID]
+	if !got.HasRole(NodeRoleReplica) || got.HasRole(NodeRoleValidator) {
+		t.Fatalf("expected replica role after refresh, got %v", got.Roles)
+	}
+}
@@ -129,7 +129,7 @@ func newTestNode() *QuidnugNode {
 	}
 
 	// Add a test title for transfer testing
-	node.TitleRegistry["0000000000000003"] = TitleTransaction{
+	node.updateTitleRegistry(TitleTransaction{
 		BaseTransaction: BaseTransaction{
 			ID:          "tx_title_001",
 			Type:        TxTypeTitle,
@@ -143,7 +143,7 @@ func newTestNode() *QuidnugNode {
 			{OwnerID: "0000000000000005", Percentage: 40.0},
 		},
 		Signatures: make(map[string]string),
-	}
+	})
 
 	return node
 }
@@ -163,6 +163,7 @@ func setTestTrust(node *QuidnugNode, truster, trustee string, level float64) {
 	}
 	node.TrustRegistry[truster][trustee] = level
 	node.TrustRegistryMutex.Unlock()
+	node.markTrustDirty(truster)
 	if node.TrustCache != nil {
 		node.TrustCache.Invalidate()
 	}
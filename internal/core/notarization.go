@@ -0,0 +1,333 @@
+// Package core — notarization.go
+//
+// Notarization of external documents. A quid notarizes a document
+// by signing an EventTransaction on its own stream whose EventType
+// is NOTARIZATION and whose payload carries the document's hash
+// (the document itself never touches the chain):
+//
+//	{"documentHash": "<hex>", "hashAlgorithm": "sha256"}
+//
+// Once the event lands in a block the node records it, and
+// /notarize/{id} serves a receipt: the block reference, the event
+// as committed, a Merkle inclusion proof against the block's
+// TransactionsRoot (merkle.go), all signed by this node. Anyone
+// holding the receipt can check it offline; /notarize/verify does
+// the same checks and also confirms the block is on this node's
+// chain.
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotarizationEventType is the event type of a notarization.
+const NotarizationEventType = "NOTARIZATION"
+
+// notarizationHashLengths maps each accepted hash algorithm to the
+// hex length of its digest.
+var notarizationHashLengths = map[string]int{
+	"sha256":   64,
+	"sha512":   128,
+	"sha3-256": 64,
+}
+
+// Notarization errors.
+var (
+	ErrNotarizationInvalid  = errors.New("invalid notarization")
+	ErrNotarizationNotFound = errors.New("notarization not found")
+	ErrNotarizationPending  = errors.New("notarization not yet in a block")
+)
+
+// Notarization is a committed notarization event.
+type Notarization struct {
+	ID            string         `json:"id"`
+	DocumentHash  string         `json:"documentHash"`
+	HashAlgorithm string         `json:"hashAlgorithm"`
+	QuidID        string         `json:"quidId"`
+	TrustDomain   string         `json:"trustDomain"`
+	NotarizedAt   int64          `json:"notarizedAt"`
+	Block         SourceBlockRef `json:"block"`
+	// TxIndex is the event's position in the block.
+	TxIndex int `json:"txIndex"`
+}
+
+// NotarizationReceipt is the signed proof of a notarization. The
+// node signs the canonical JSON of the receipt with Signature
+// cleared.
+type NotarizationReceipt struct {
+	Notarization
+	TransactionsRoot string `json:"transactionsRoot,omitempty"`
+	// Transaction is the event as committed; its leaf hash and
+	// MerkleProof reconstruct TransactionsRoot.
+	Transaction   json.RawMessage    `json:"transaction"`
+	MerkleProof   []MerkleProofFrame `json:"merkleProof"`
+	IssuedAt      int64              `json:"issuedAt"`
+	NodeID        string             `json:"nodeId"`
+	NodePublicKey string             `json:"nodePublicKey"`
+	Signature     string             `json:"signature"`
+}
+
+// NotarizationVerification is the outcome of checking a receipt.
+type NotarizationVerification struct {
+	Valid bool `json:"valid"`
+	// SignatureValid: the node signature verifies.
+	SignatureValid bool `json:"signatureValid"`
+	// InclusionValid: the event hashes into TransactionsRoot and
+	// carries the receipt's document hash.
+	InclusionValid bool `json:"inclusionValid"`
+	// OnChain: this node holds the referenced block.
+	OnChain bool   `json:"onChain"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// NotarizationRegistry indexes committed notarizations by ID and
+// by document hash. Owns its own lock.
+type NotarizationRegistry struct {
+	mu     sync.RWMutex
+	byID   map[string]Notarization
+	byHash map[string][]string
+}
+
+// NewNotarizationRegistry constructs an empty registry.
+func NewNotarizationRegistry() *NotarizationRegistry {
+	return &NotarizationRegistry{
+		byID:   make(map[string]Notarization),
+		byHash: make(map[string][]string),
+	}
+}
+
+func (r *NotarizationRegistry) record(n Notarization) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, seen := r.byID[n.ID]; !seen {
+		r.byHash[n.DocumentHash] = append(r.byHash[n.DocumentHash], n.ID)
+	}
+	r.byID[n.ID] = n
+}
+
+func (r *NotarizationRegistry) get(id string) (Notarization, bool) {
+	if r == nil {
+		return Notarization{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n, ok := r.byID[id]
+	return n, ok
+}
+
+// forHash returns the notarizations of a document, oldest first.
+func (r *NotarizationRegistry) forHash(documentHash string) []Notarization {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Notarization
+	for _, id := range r.byHash[documentHash] {
+		out = append(out, r.byID[id])
+	}
+	return out
+}
+
+// notarizationPayload extracts and checks the document hash of a
+// notarization event. The hash comes back lowercased.
+func notarizationPayload(tx EventTransaction) (documentHash, algorithm string, err error) {
+	if tx.EventType != NotarizationEventType {
+		return "", "", fmt.Errorf("%w: eventType must be %s", ErrNotarizationInvalid, NotarizationEventType)
+	}
+	if tx.SubjectType != "QUID" {
+		return "", "", fmt.Errorf("%w: subjectType must be QUID", ErrNotarizationInvalid)
+	}
+	documentHash, _ = tx.Payload["documentHash"].(string)
+	algorithm, _ = tx.Payload["hashAlgorithm"].(string)
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	want, ok := notarizationHashLengths[algorithm]
+	if !ok {
+		return "", "", fmt.Errorf("%w: unsupported hashAlgorithm %q", ErrNotarizationInvalid, algorithm)
+	}
+	documentHash = strings.ToLower(documentHash)
+	if len(documentHash) != want || !isHex(documentHash) {
+		return "", "", fmt.Errorf("%w: documentHash must be %d hex characters for %s", ErrNotarizationInvalid, want, algorithm)
+	}
+	return documentHash, algorithm, nil
+}
+
+// observeNotarization records a committed notarization event.
+// Called from processBlockTransactions; malformed ones are skipped.
+func (node *QuidnugNode) observeNotarization(tx EventTransaction, block Block, txIdx int) {
+	if node.Notarizations == nil {
+		return
+	}
+	documentHash, algorithm, err := notarizationPayload(tx)
+	if err != nil {
+		logger.Debug("Skipping malformed notarization event", "txId", tx.ID, "error", err)
+		return
+	}
+	node.Notarizations.record(Notarization{
+		ID:            tx.ID,
+		DocumentHash:  documentHash,
+		HashAlgorithm: algorithm,
+		QuidID:        tx.SubjectID,
+		TrustDomain:   tx.TrustDomain,
+		NotarizedAt:   tx.Timestamp,
+		Block:         blockRef(block),
+		TxIndex:       txIdx,
+	})
+}
+
+// Notarize admits a signed notarization event into the pending
+// pool and returns its ID.
+func (node *QuidnugNode) Notarize(tx EventTransaction) (string, error) {
+	if _, _, err := notarizationPayload(tx); err != nil {
+		return "", err
+	}
+	return node.AddEventTransaction(tx)
+}
+
+// GetNotarization returns a committed notarization.
+func (node *QuidnugNode) GetNotarization(id string) (Notarization, bool) {
+	return node.Notarizations.get(id)
+}
+
+// NotarizationsForDocument returns every committed notarization of
+// a document hash, oldest first.
+func (node *QuidnugNode) NotarizationsForDocument(documentHash string) []Notarization {
+	return node.Notarizations.forHash(strings.ToLower(documentHash))
+}
+
+// notarizationPending reports whether id is still in the pending
+// pool.
+func (node *QuidnugNode) notarizationPending(id string) bool {
+	node.PendingTxsMutex.RLock()
+	defer node.PendingTxsMutex.RUnlock()
+	for _, tx := range node.PendingTxs {
+		if ev, ok := tx.(EventTransaction); ok && ev.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// findChainBlock returns the block on this node's chain matching
+// ref, hydrating a pruned header from the archive.
+func (node *QuidnugNode) findChainBlock(ctx context.Context, ref SourceBlockRef) (Block, bool) {
+	node.BlockchainMutex.RLock()
+	var block Block
+	found := false
+	for i := len(node.Blockchain) - 1; i >= 0; i-- {
+		b := node.Blockchain[i]
+		if b.Index == ref.Index && b.Hash == ref.Hash {
+			block, found = b, true
+			break
+		}
+	}
+	node.BlockchainMutex.RUnlock()
+	if found && block.Pruned {
+		full, err := node.fetchArchivedBlock(ctx, block)
+		if err != nil {
+			return Block{}, false
+		}
+		block = full
+	}
+	return block, found
+}
+
+// NotarizationReceipt builds and signs the receipt for a committed
+// notarization. Returns ErrNotarizationPending while it is still in
+// the pending pool.
+func (node *QuidnugNode) NotarizationReceipt(ctx context.Context, id string) (NotarizationReceipt, error) {
+	n, ok := node.GetNotarization(id)
+	if !ok {
+		if node.notarizationPending(id) {
+			return NotarizationReceipt{}, ErrNotarizationPending
+		}
+		return NotarizationReceipt{}, ErrNotarizationNotFound
+	}
+	block, ok := node.findChainBlock(ctx, n.Block)
+	if !ok || n.TxIndex >= len(block.Transactions) {
+		return NotarizationReceipt{}, fmt.Errorf("block %d (%s) for notarization %s is unavailable", n.Block.Index, n.Block.Hash, id)
+	}
+	raw, err := canonicalTxBytes(block.Transactions[n.TxIndex])
+	if err != nil {
+		return NotarizationReceipt{}, err
+	}
+	proof, err := MerkleProof(block.Transactions, n.TxIndex)
+	if err != nil {
+		return NotarizationReceipt{}, err
+	}
+
+	receipt := NotarizationReceipt{
+		Notarization:     n,
+		TransactionsRoot: block.TransactionsRoot,
+		Transaction:      raw,
+		MerkleProof:      proof,
+		IssuedAt:         time.Now().Unix(),
+		NodeID:           node.NodeID,
+		NodePublicKey:    node.GetPublicKeyHex(),
+	}
+	signable, err := canonicalTxBytes(receipt)
+	if err != nil {
+		return NotarizationReceipt{}, err
+	}
+	sig, err := node.SignData(signable)
+	if err != nil {
+		return NotarizationReceipt{}, err
+	}
+	receipt.Signature = hex.EncodeToString(sig)
+	return receipt, nil
+}
+
+// VerifyNotarizationReceipt checks a receipt's node signature and
+// inclusion proof, that the committed event notarizes the
+// receipt's document hash, and whether the block is on this
+// node's chain. documentHash, when non-empty, must match the
+// receipt.
+func (node *QuidnugNode) VerifyNotarizationReceipt(ctx context.Context, receipt NotarizationReceipt, documentHash string) NotarizationVerification {
+	var v NotarizationVerification
+
+	signable := receipt
+	signable.Signature = ""
+	if b, err := canonicalTxBytes(signable); err == nil {
+		v.SignatureValid = VerifySignature(receipt.NodePublicKey, b, receipt.Signature)
+	}
+
+	v.InclusionValid = func() bool {
+		if receipt.TransactionsRoot == "" ||
+			VerifyTransactionInclusion(receipt.Transaction, receipt.MerkleProof, receipt.TransactionsRoot) != nil {
+			return false
+		}
+		var ev EventTransaction
+		if err := json.Unmarshal(receipt.Transaction, &ev); err != nil || ev.ID != receipt.ID {
+			return false
+		}
+		committed, _, err := notarizationPayload(ev)
+		return err == nil && committed == receipt.DocumentHash && ev.SubjectID == receipt.QuidID
+	}()
+
+	if block, ok := node.findChainBlock(ctx, receipt.Block); ok {
+		v.OnChain = block.TransactionsRoot == receipt.TransactionsRoot
+	}
+
+	switch {
+	case documentHash != "" && !strings.EqualFold(documentHash, receipt.DocumentHash):
+		v.Reason = "document hash does not match the receipt"
+	case !v.SignatureValid:
+		v.Reason = "node signature invalid"
+	case !v.InclusionValid:
+		v.Reason = "transaction is not included under the receipt's transactions root"
+	case !v.OnChain:
+		v.Reason = "block is not on this node's chain"
+	default:
+		v.Valid = true
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// notarizationEvent builds a NOTARIZATION event for the test
+// node's own quid, signed by the node.
+func notarizationEvent(t *testing.T, node *QuidnugNode, documentHash string) EventTransaction {
+	t.Helper()
+	tx := EventTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "notary-1",
+			Type:        TxTypeEvent,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   node.GetPublicKeyHex(),
+		},
+		SubjectID:   "0000000000000001",
+		SubjectType: "QUID",
+		Sequence:    1,
+		EventType:   NotarizationEventType,
+		Payload:     map[string]interface{}{"documentHash": documentHash, "hashAlgorithm": "sha256"},
+	}
+	signable, _ := json.Marshal(tx)
+	sig, err := node.SignData(signable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	return tx
+}
+
+// commitNotarization puts tx into a block on node's chain.
+func commitNotarization(t *testing.T, node *QuidnugNode, tx EventTransaction) Block {
+	t.Helper()
+	block := Block{
+		Index:        1,
+		Timestamp:    tx.Timestamp,
+		Transactions: []interface{}{TrustTransaction{BaseTransaction: BaseTransaction{ID: "other"}}, tx},
+		TrustProof:   TrustProof{TrustDomain: "test.domain.com"},
+		Hash:         "block-1",
+	}
+	root, err := MerkleRoot(block.Transactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.TransactionsRoot = root
+	node.BlockchainMutex.Lock()
+	node.Blockchain = append(node.Blockchain, block)
+	node.BlockchainMutex.Unlock()
+	node.processBlockTransactions(block)
+	return block
+}
+
+func TestNotarization_ReceiptVerifies(t *testing.T) {
+	node := newTestNode()
+	sum := sha256.Sum256([]byte("contract of sale"))
+	documentHash := hex.EncodeToString(sum[:])
+
+	if _, err := node.Notarize(notarizationEvent(t, node, "not-a-hash")); !errors.Is(err, ErrNotarizationInvalid) {
+		t.Fatalf("malformed hash: err = %v", err)
+	}
+	tx := notarizationEvent(t, node, documentHash)
+	id, err := node.Notarize(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.NotarizationReceipt(context.Background(), id); !errors.Is(err, ErrNotarizationPending) {
+		t.Fatalf("receipt before commit: err = %v", err)
+	}
+
+	commitNotarization(t, node, tx)
+	receipt, err := node.NotarizationReceipt(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.DocumentHash != documentHash || receipt.Block.Hash != "block-1" || receipt.TxIndex != 1 {
+		t.Errorf("receipt = %+v", receipt)
+	}
+	if v := node.VerifyNotarizationReceipt(context.Background(), receipt, documentHash); !v.Valid {
+		t.Fatalf("verification = %+v", v)
+	}
+	if v := node.VerifyNotarizationReceipt(context.Background(), receipt, hex.EncodeToString(make([]byte, 32))); v.Valid {
+		t.Error("receipt verified against another document")
+	}
+
+	forged := receipt
+	forged.DocumentHash = hex.EncodeToString(make([]byte, 32))
+	if v := node.VerifyNotarizationReceipt(context.Background(), forged, ""); v.Valid || v.SignatureValid {
+		t.Errorf("forged receipt verification = %+v", v)
+	}
+
+	// A receipt for a block this node doesn't hold still checks
+	// out offline but is not on the chain.
+	other := newTestNode()
+	if v := other.VerifyNotarizationReceipt(context.Background(), receipt, ""); v.Valid || !v.SignatureValid || !v.InclusionValid || v.OnChain {
+		t.Errorf("verification on another chain = %+v", v)
+	}
+}
+
+func TestNotarizationHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	sum := sha256.Sum256([]byte("deed"))
+	documentHash := hex.EncodeToString(sum[:])
+	tx := notarizationEvent(t, node, documentHash)
+
+	body, _ := json.Marshal(tx)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/notarize", bytes.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("notarize: expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/notarize/notary-1", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("pending receipt: expected 202, got %d", rr.Code)
+	}
+
+	commitNotarization(t, node, tx)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/notarize/notary-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("receipt: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data NotarizationReceipt `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ = json.Marshal(VerifyNotarizationRequest{Receipt: resp.Data, DocumentHash: documentHash})
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/notarize/verify", bytes.NewReader(body)))
+	var verified struct {
+		Data NotarizationVerification `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &verified); err != nil || !verified.Data.Valid {
+		t.Fatalf("verify over HTTP: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/notarize?documentHash="+documentHash, nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"notary-1"`)) {
+		t.Errorf("list by document: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/notarize/unknown", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown: expected 404, got %d", rr.Code)
+	}
+}
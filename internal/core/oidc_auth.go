@@ -0,0 +1,136 @@
+// Package core — oidc_auth.go
+//
+// Bearer-token authentication against an enterprise OIDC provider.
+// With oidc_issuer set, a request carrying Authorization: Bearer
+// <jwt> has the token verified (internal/oidc.Verifier) and the quid
+// it maps to attached to the request context, where handlers read it
+// with AuthenticatedQuid. An invalid token is always a 401; a missing
+// one is only a 401 under oidc_required, and then only for client
+// writes, since reads serve public chain data and peers do not hold
+// IdP tokens.
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/oidc"
+)
+
+// AuthenticatedQuidContextKey holds the quid ID a verified bearer
+// token maps to.
+const AuthenticatedQuidContextKey contextKey = "authenticatedQuid"
+
+// oidcPeerPaths are non-GET endpoints peers call, matched after the
+// API prefix. They never need a bearer token.
+var oidcPeerPaths = []string{
+	"/gossip/",
+	"/anchor-gossip",
+	"/domain-fingerprints",
+}
+
+// OIDCAuth is the node's bearer-token policy.
+type OIDCAuth struct {
+	Verifier *oidc.Verifier
+	Required bool
+}
+
+// newOIDCAuth builds the policy from config. Nil means the feature
+// is off.
+func newOIDCAuth(cfg *config.Config) (*OIDCAuth, error) {
+	if cfg.OIDCIssuer == "" {
+		return nil, nil
+	}
+	verifier, err := oidc.NewVerifier(oidc.VerifierConfig{
+		Issuer:    cfg.OIDCIssuer,
+		Audience:  cfg.OIDCAudience,
+		QuidClaim: cfg.OIDCQuidClaim,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCAuth{Verifier: verifier, Required: cfg.OIDCRequired}, nil
+}
+
+// AuthenticatedQuid returns the quid the request's bearer token was
+// verified for, if any.
+func AuthenticatedQuid(ctx context.Context) (string, bool) {
+	quid, ok := ctx.Value(AuthenticatedQuidContextKey).(string)
+	return quid, ok && quid != ""
+}
+
+// OIDCAuthMiddleware verifies bearer tokens and enforces
+// oidc_required. It is a pass-through when OIDC is not configured.
+func (node *QuidnugNode) OIDCAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := node.OIDCAuth
+		if auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			claims, err := auth.Verifier.Verify(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				logger.Debug("Rejected bearer token", "path", r.URL.Path, "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid bearer token")
+				return
+			}
+			ctx := context.WithValue(r.Context(), AuthenticatedQuidContextKey, claims.QuidID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if auth.Required && oidcProtected(r) && !nodeAuthSigned(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Bearer token required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// oidcProtected reports whether oidc_required applies to r: any
+// method that can change state, except the peer endpoints.
+func oidcProtected(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	path := stripAPIPrefix(r.URL.Path)
+	for _, peer := range oidcPeerPaths {
+		if strings.HasPrefix(path, peer) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeAuthSigned reports whether r carries a valid node-to-node
+// signature, which is how peers relaying transactions identify
+// themselves in place of a bearer token. The body is restored for
+// the handler.
+func nodeAuthSigned(r *http.Request) bool {
+	secret := GetNodeAuthSecret()
+	signature := r.Header.Get(NodeSignatureHeader)
+	if secret == "" || signature == "" {
+		return false
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(NodeTimestampHeader), 10, 64)
+	if err != nil {
+		return false
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return VerifyRequest(r.Method, r.URL.Path, body, secret, timestamp, signature)
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/oidc"
+)
+
+// newTestOIDCAuth starts an IdP serving one ES256 key and returns a
+// required-mode policy against it, plus a token minted for quid.
+func newTestOIDCAuth(t *testing.T, quid string) (*OIDCAuth, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwks" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kty": "EC", "kid": "k1", "crv": "P-256",
+				"x": b64.EncodeToString(key.PublicKey.X.FillBytes(make([]byte, 32))),
+				"y": b64.EncodeToString(key.PublicKey.Y.FillBytes(make([]byte, 32))),
+			}}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/jwks"})
+	}))
+	t.Cleanup(srv.Close)
+
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "k1"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss": srv.URL, "sub": "alice", "quidnug_quid": quid,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	verifier, err := oidc.NewVerifier(oidc.VerifierConfig{Issuer: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &OIDCAuth{Verifier: verifier, Required: true}, input + "." + b64.EncodeToString(sig)
+}
+
+func TestOIDCAuthMiddleware(t *testing.T) {
+	node := newTestNode()
+	auth, token := newTestOIDCAuth(t, "0123456789abcdef")
+	node.OIDCAuth = auth
+
+	var gotQuid string
+	handler := node.OIDCAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuid, _ = AuthenticatedQuid(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name, method, path, bearer string
+		want                       int
+		wantQuid                   string
+	}{
+		{"write without token", "POST", "/api/v1/transactions/trust", "", http.StatusUnauthorized, ""},
+		{"read without token", "GET", "/api/v1/blocks", "", http.StatusOK, ""},
+		{"peer gossip without token", "POST", "/api/v2/gossip/push-anchor", "", http.StatusOK, ""},
+		{"write with token", "POST", "/api/v1/transactions/trust", token, http.StatusOK, "0123456789abcdef"},
+		{"read with token", "GET", "/api/v1/blocks", token, http.StatusOK, "0123456789abcdef"},
+		{"invalid token", "GET", "/api/v1/blocks", token + "x", http.StatusUnauthorized, ""},
+	}
+	for _, tc := range cases {
+		gotQuid = ""
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader("{}"))
+		if tc.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.bearer)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want || gotQuid != tc.wantQuid {
+			t.Errorf("%s: status %d quid %q, want %d %q", tc.name, rec.Code, gotQuid, tc.want, tc.wantQuid)
+		}
+	}
+}
+
+func TestOIDCAuthMiddleware_NodeAuthRelay(t *testing.T) {
+	node := newTestNode()
+	auth, _ := newTestOIDCAuth(t, "0123456789abcdef")
+	node.OIDCAuth = auth
+	ResetNodeAuthConfigForTesting()
+	t.Setenv("NODE_AUTH_SECRET", "relay-secret")
+	defer ResetNodeAuthConfigForTesting()
+	handler := node.OIDCAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := []byte(`{"type":"TRUST"}`)
+	ts := time.Now().Unix()
+	req := httptest.NewRequest("POST", "/api/transactions/trust", strings.NewReader(string(body)))
+	req.Header.Set(NodeSignatureHeader, SignRequest("POST", "/api/transactions/trust", body, "relay-secret", ts))
+	req.Header.Set(NodeTimestampHeader, strconv.FormatInt(ts, 10))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("signed relay: status %d, want 200", rec.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/transactions/trust", strings.NewReader(string(body)))
+	req.Header.Set(NodeSignatureHeader, "forged")
+	req.Header.Set(NodeTimestampHeader, strconv.FormatInt(ts, 10))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("forged relay: status %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,213 @@
+// Package core — organization quids with delegated signers.
+//
+// An IDENTITY transaction with Kind "organization" declares a quid
+// that acts through people rather than a single key. Its Signers
+// list names the quids allowed to sign on its behalf, each with
+// the transaction types it may sign (its roles) and the window in
+// which the authorization holds. The organization maintains the
+// list on-chain with ordinary identity updates signed by its own
+// key; a higher UpdateNonce replaces the whole list.
+//
+// Validators that bind a transaction to a quid ("the signing key
+// must derive to LienholderQuid", "the signer must own the
+// subject", a co-signature keyed by owner quid) go through
+// signsFor and verifyAsQuid, which accept the quid's own key or
+// the key of any signer authorized for that transaction type at
+// the transaction's delegation time. Trust, identity and title
+// transfers name quids that need not have a registered key, so
+// their validators use keySignsFor, which binds the signer only
+// when the truster, creator or previous owner has one. That is its timestamp, but
+// the signer chooses the timestamp, so it only counts within
+// DelegatedSignerMaxSkew of the containing block's timestamp, or
+// of the node's clock at mempool admission; an expired delegate
+// cannot backdate past its window. Judging against the block
+// rather than wall-clock time keeps replays deterministic.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdentityKindOrganization marks an identity as an organization.
+const IdentityKindOrganization = "organization"
+
+// OrgRoleAll authorizes a delegated signer for every transaction
+// type.
+const OrgRoleAll = "*"
+
+// MaxDelegatedSigners bounds an organization's signer list.
+const MaxDelegatedSigners = 64
+
+// DelegatedSignerMaxSkew bounds how far a transaction's timestamp
+// may stray from the block (or node) time when judging a delegated
+// signer's window.
+const DelegatedSignerMaxSkew = 10 * time.Minute
+
+// ErrOrganizationInvalid wraps every organization shape error.
+var ErrOrganizationInvalid = errors.New("organization: invalid identity")
+
+// DelegatedSigner authorizes Quid to sign for an organization.
+// Roles lists the transaction types it may sign ("TRUST", "TITLE",
+// ...), or OrgRoleAll. ValidFrom and ValidUntil are Unix seconds;
+// zero leaves that end open.
+type DelegatedSigner struct {
+	Quid       string   `json:"quid"`
+	Roles      []string `json:"roles"`
+	ValidFrom  int64    `json:"validFrom,omitempty"`
+	ValidUntil int64    `json:"validUntil,omitempty"`
+}
+
+// activeAt reports whether the authorization holds at unix time at.
+func (s DelegatedSigner) activeAt(at int64) bool {
+	if s.ValidFrom != 0 && at < s.ValidFrom {
+		return false
+	}
+	return s.ValidUntil == 0 || at < s.ValidUntil
+}
+
+// delegationTime is the unix time delegated signers of tx are
+// judged at: its timestamp, clamped to within DelegatedSignerMaxSkew
+// of the containing block's timestamp or, outside a block, of now.
+func (tx BaseTransaction) delegationTime() int64 {
+	ref := tx.blockTime
+	if ref == 0 {
+		ref = nowUnix()
+	}
+	skew := int64(DelegatedSignerMaxSkew / time.Second)
+	switch {
+	case tx.Timestamp < ref-skew:
+		return ref - skew
+	case tx.Timestamp > ref+skew:
+		return ref + skew
+	}
+	return tx.Timestamp
+}
+
+// allows reports whether the signer's roles cover txType.
+func (s DelegatedSigner) allows(txType TransactionType) bool {
+	for _, r := range s.Roles {
+		if r == OrgRoleAll || r == string(txType) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOrganization checks the Kind and Signers fields of an
+// identity transaction.
+func validateOrganization(tx IdentityTransaction) error {
+	switch tx.Kind {
+	case "":
+		if len(tx.Signers) > 0 {
+			return fmt.Errorf("%w: only organizations have delegated signers", ErrOrganizationInvalid)
+		}
+		return nil
+	case IdentityKindOrganization:
+	default:
+		return fmt.Errorf("%w: unknown identity kind %q", ErrOrganizationInvalid, tx.Kind)
+	}
+	if len(tx.Signers) > MaxDelegatedSigners {
+		return fmt.Errorf("%w: more than %d signers", ErrOrganizationInvalid, MaxDelegatedSigners)
+	}
+	seen := make(map[string]bool, len(tx.Signers))
+	for i, s := range tx.Signers {
+		if !IsValidQuidID(s.Quid) || s.Quid == tx.QuidID {
+			return fmt.Errorf("%w: signer %d has invalid quid %q", ErrOrganizationInvalid, i, s.Quid)
+		}
+		if seen[s.Quid] {
+			return fmt.Errorf("%w: signer %s listed twice", ErrOrganizationInvalid, s.Quid)
+		}
+		seen[s.Quid] = true
+		if s.ValidFrom < 0 || s.ValidUntil < 0 || (s.ValidUntil != 0 && s.ValidUntil <= s.ValidFrom) {
+			return fmt.Errorf("%w: signer %s has an empty validity window", ErrOrganizationInvalid, s.Quid)
+		}
+		if len(s.Roles) == 0 {
+			return fmt.Errorf("%w: signer %s has no roles", ErrOrganizationInvalid, s.Quid)
+		}
+		for _, r := range s.Roles {
+			if r != OrgRoleAll && !ValidateStringField(r, MaxEventTypeLength) {
+				return fmt.Errorf("%w: signer %s has invalid role %q", ErrOrganizationInvalid, s.Quid, r)
+			}
+		}
+	}
+	return nil
+}
+
+// authorizedSigners returns the signers acting for org on txType
+// at unix time at; nil when org is not an organization.
+func (node *QuidnugNode) authorizedSigners(org string, txType TransactionType, at int64) []DelegatedSigner {
+	node.IdentityRegistryMutex.RLock()
+	identity, ok := node.IdentityRegistry[org]
+	node.IdentityRegistryMutex.RUnlock()
+	if !ok || identity.Kind != IdentityKindOrganization {
+		return nil
+	}
+	if at == 0 {
+		at = nowUnix()
+	}
+	var out []DelegatedSigner
+	for _, s := range identity.Signers {
+		if s.activeAt(at) && s.allows(txType) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// signsFor reports whether publicKey may sign a txType transaction
+// as principal at unix time at: it is principal's own key, the key
+// of a signer principal has authorized, or that of the successor
+// that took principal over (succession.go).
+func (node *QuidnugNode) signsFor(principal, publicKey string, txType TransactionType, at int64) bool {
+	signer := QuidIDFromPublicKeyHex(publicKey)
+	if signer == "" {
+		return false
+	}
+	if signer == principal {
+		return true
+	}
+	if successor, ok := node.successorOf(principal); ok && successor == signer {
+		return true
+	}
+	for _, s := range node.authorizedSigners(principal, txType, at) {
+		if s.Quid == signer {
+			return true
+		}
+	}
+	return false
+}
+
+// keySignsFor reports whether publicKey may sign a txType
+// transaction for principal at unix time at: it is principal's
+// registered key, or signsFor accepts it. A principal with no
+// registered key has nothing to bind the signer to, so any key
+// passes, as before organizations existed.
+func (node *QuidnugNode) keySignsFor(principal, publicKey string, txType TransactionType, at int64) bool {
+	key, ok := node.quidPublicKey(principal)
+	if !ok {
+		return true
+	}
+	return key == publicKey || node.signsFor(principal, publicKey, txType, at)
+}
+
+// verifyAsQuid checks sig over data as a signature by quid, made
+// with its registered key, that of a signer it has authorized for
+// txType at unix time at, or that of its successor.
+func (node *QuidnugNode) verifyAsQuid(quid string, txType TransactionType, at int64, data []byte, sig string) bool {
+	if key, ok := node.quidPublicKey(quid); ok && VerifySignature(key, data, sig) {
+		return true
+	}
+	for _, s := range node.authorizedSigners(quid, txType, at) {
+		if key, ok := node.quidPublicKey(s.Quid); ok && VerifySignature(key, data, sig) {
+			return true
+		}
+	}
+	if successor, ok := node.successorOf(quid); ok {
+		if key, ok := node.quidPublicKey(successor); ok && VerifySignature(key, data, sig) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOrganization_SignsFor(t *testing.T) {
+	node := newTestNode()
+	org, clerk, former, outsider := newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)
+	node.IdentityRegistry[org.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{PublicKey: org.PubHex},
+		QuidID:          org.QuidID,
+		Kind:            IdentityKindOrganization,
+		Signers: []DelegatedSigner{
+			{Quid: clerk.QuidID, Roles: []string{string(TxTypeLien)}, ValidFrom: 1000},
+			{Quid: former.QuidID, Roles: []string{OrgRoleAll}, ValidUntil: 2000},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		signer *testNodeActor
+		txType TransactionType
+		at     int64
+		want   bool
+	}{
+		{"own key", org, TxTypeTitle, 5000, true},
+		{"clerk in role", clerk, TxTypeLien, 5000, true},
+		{"clerk out of role", clerk, TxTypeTitle, 5000, false},
+		{"clerk before window", clerk, TxTypeLien, 999, false},
+		{"former inside window", former, TxTypeTitle, 1999, true},
+		{"former after window", former, TxTypeTitle, 2000, false},
+		{"outsider", outsider, TxTypeLien, 5000, false},
+	}
+	for _, c := range cases {
+		if got := node.signsFor(org.QuidID, c.signer.PubHex, c.txType, c.at); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// An ordinary quid has nobody signing for it.
+	if node.signsFor(clerk.QuidID, org.PubHex, TxTypeLien, 5000) {
+		t.Error("org key accepted for an ordinary quid")
+	}
+}
+
+func TestOrganization_DelegateCoSignsTransfer(t *testing.T) {
+	f := newTransferPolicyFixture(t, nil)
+	// a becomes an organization with d as its title signer.
+	identity := f.node.IdentityRegistry[f.a.QuidID]
+	identity.Kind = IdentityKindOrganization
+	identity.Signers = []DelegatedSigner{{Quid: f.d.QuidID, Roles: []string{string(TxTypeTitle)}}}
+	f.node.IdentityRegistry[f.a.QuidID] = identity
+
+	toT := []OwnershipStake{{OwnerID: f.t.QuidID, Percentage: 1.0}}
+	tx := f.transfer(toT, f.b, f.c, f.d)
+	tx.Signatures[f.a.QuidID] = tx.Signatures[f.d.QuidID]
+	delete(tx.Signatures, f.d.QuidID)
+	if !f.node.ValidateTitleTransaction(f.resign(tx, f.b)) {
+		t.Error("transfer co-signed by the organization's delegate rejected")
+	}
+
+	identity.Signers[0].Roles = []string{string(TxTypeEvent)}
+	f.node.IdentityRegistry[f.a.QuidID] = identity
+	if f.node.ValidateTitleTransaction(f.resign(tx, f.b)) {
+		t.Error("transfer co-signed by a delegate without the TITLE role accepted")
+	}
+}
+
+func TestOrganization_Validate(t *testing.T) {
+	org := "a1b2c3d4e5f60718"
+	for _, tx := range []IdentityTransaction{
+		{QuidID: org, Kind: "company"},
+		{QuidID: org, Signers: []DelegatedSigner{{Quid: "0000000000000001", Roles: []string{OrgRoleAll}}}},
+		{QuidID: org, Kind: IdentityKindOrganization, Signers: []DelegatedSigner{{Quid: org, Roles: []string{OrgRoleAll}}}},
+		{QuidID: org, Kind: IdentityKindOrganization, Signers: []DelegatedSigner{{Quid: "0000000000000001"}}},
+		{QuidID: org, Kind: IdentityKindOrganization, Signers: []DelegatedSigner{{Quid: "0000000000000001", Roles: []string{OrgRoleAll}, ValidFrom: 10, ValidUntil: 10}}},
+		{QuidID: org, Kind: IdentityKindOrganization, Signers: []DelegatedSigner{
+			{Quid: "0000000000000001", Roles: []string{OrgRoleAll}},
+			{Quid: "0000000000000001", Roles: []string{string(TxTypeTrust)}},
+		}},
+	} {
+		if err := validateOrganization(tx); !errors.Is(err, ErrOrganizationInvalid) {
+			t.Errorf("%+v: err = %v", tx, err)
+		}
+	}
+}
+
+func TestOrganization_BackdatedExpiredDelegate(t *testing.T) {
+	f := newTransferPolicyFixture(t, nil)
+	expired := nowUnix() - 3600
+	identity := f.node.IdentityRegistry[f.a.QuidID]
+	identity.Kind = IdentityKindOrganization
+	identity.Signers = []DelegatedSigner{{Quid: f.d.QuidID, Roles: []string{string(TxTypeTitle)}, ValidUntil: expired}}
+	f.node.IdentityRegistry[f.a.QuidID] = identity
+
+	// d's authorization lapsed an hour ago; the transfer claims to
+	// predate that.
+	tx := f.transfer([]OwnershipStake{{OwnerID: f.t.QuidID, Percentage: 1.0}}, f.b, f.c, f.d)
+	tx.Timestamp = expired - 60
+	tx = f.cosign(tx, f.b, f.c, f.d)
+	tx.Signatures[f.a.QuidID] = tx.Signatures[f.d.QuidID]
+	delete(tx.Signatures, f.d.QuidID)
+	tx = f.resign(tx, f.b)
+
+	if f.node.ValidateTitleTransaction(tx) {
+		t.Error("backdated transfer by an expired delegate admitted")
+	}
+	tx.blockTime = nowUnix()
+	if f.node.ValidateTitleTransaction(tx) {
+		t.Error("backdated transfer by an expired delegate accepted in a current block")
+	}
+	tx.blockTime = expired - 30
+	if !f.node.ValidateTitleTransaction(tx) {
+		t.Error("transfer in a block sealed inside the delegate's window rejected")
+	}
+}
+
+// newOrgNode registers org as an organization with clerk authorized
+// for txType.
+func newOrgNode(org, clerk *testNodeActor, txType TransactionType) *QuidnugNode {
+	node := newTestNode()
+	node.IdentityRegistry[org.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: org.PubHex},
+		QuidID:          org.QuidID,
+		Creator:         org.QuidID,
+		UpdateNonce:     1,
+		Kind:            IdentityKindOrganization,
+		Signers:         []DelegatedSigner{{Quid: clerk.QuidID, Roles: []string{string(txType)}}},
+	}
+	return node
+}
+
+func TestOrganization_DelegateSignsTrust(t *testing.T) {
+	org, clerk, outsider := newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)
+	node := newOrgNode(org, clerk, TxTypeTrust)
+	trust := func(signer *testNodeActor) TrustTransaction {
+		tx := TrustTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          "trust-1",
+				Type:        TxTypeTrust,
+				TrustDomain: "test.domain.com",
+				Timestamp:   nowUnix(),
+				PublicKey:   signer.PubHex,
+			},
+			Truster:    org.QuidID,
+			Trustee:    "0000000000000002",
+			TrustLevel: 0.5,
+			Nonce:      1,
+		}
+		data, _ := json.Marshal(tx)
+		tx.Signature = signIEEE1363(signer.Priv, data)
+		return tx
+	}
+
+	if !node.ValidateTrustTransaction(trust(org)) {
+		t.Error("trust signed with the organization's own key rejected")
+	}
+	if !node.ValidateTrustTransaction(trust(clerk)) {
+		t.Error("trust signed by the organization's delegate rejected")
+	}
+	if node.ValidateTrustTransaction(trust(outsider)) {
+		t.Error("trust signed for the organization by an outsider accepted")
+	}
+}
+
+func TestOrganization_DelegateSignsIdentity(t *testing.T) {
+	org, clerk, outsider := newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)
+	node := newOrgNode(org, clerk, TxTypeIdentity)
+	update := func(signer *testNodeActor) IdentityTransaction {
+		tx := node.IdentityRegistry[org.QuidID]
+		tx.ID = "identity-2"
+		tx.Type = TxTypeIdentity
+		tx.Timestamp = nowUnix()
+		tx.PublicKey = signer.PubHex
+		tx.Name = "Example Org"
+		tx.UpdateNonce = 2
+		data, _ := json.Marshal(tx)
+		tx.Signature = signIEEE1363(signer.Priv, data)
+		return tx
+	}
+
+	if !node.ValidateIdentityTransaction(update(clerk)) {
+		t.Error("identity update signed by the organization's delegate rejected")
+	}
+	if node.ValidateIdentityTransaction(update(outsider)) {
+		t.Error("identity update signed for the organization by an outsider accepted")
+	}
+}
+
+func TestOrganization_DelegateIssuesTransfer(t *testing.T) {
+	f := newTransferPolicyFixture(t, nil)
+	// a becomes an organization with d as its title signer.
+	identity := f.node.IdentityRegistry[f.a.QuidID]
+	identity.Kind = IdentityKindOrganization
+	identity.Signers = []DelegatedSigner{{Quid: f.d.QuidID, Roles: []string{string(TxTypeTitle)}}}
+	f.node.IdentityRegistry[f.a.QuidID] = identity
+
+	tx := f.transfer([]OwnershipStake{{OwnerID: f.t.QuidID, Percentage: 1.0}}, f.b, f.c, f.d)
+	tx.Signatures[f.a.QuidID] = tx.Signatures[f.d.QuidID]
+	delete(tx.Signatures, f.d.QuidID)
+
+	if !f.node.ValidateTitleTransaction(f.resign(tx, f.d)) {
+		t.Error("transfer issued by the organization's delegate rejected")
+	}
+	if f.node.ValidateTitleTransaction(f.resign(tx, f.t)) {
+		t.Error("transfer issued by someone other than a previous owner accepted")
+	}
+}
@@ -0,0 +1,102 @@
+// Ownership reverse index.
+//
+// "Which assets does X own?" used to be answered by scanning every
+// title in TitleRegistry. OwnerIndex keeps owner → asset → stake in
+// step with the registry: updateTitleRegistry replaces an asset's
+// whole owner set on every committed title, so owners dropped by a
+// transfer disappear from the index in the same call. Like the
+// registry it is rebuilt by block replay on restart.
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// OwnedAsset is one asset an owner holds a stake in.
+type OwnedAsset struct {
+	AssetID    string  `json:"assetId"`
+	Percentage float64 `json:"percentage"`
+	StakeType  string  `json:"stakeType,omitempty"`
+	ExpiresAt  int64   `json:"expiresAt,omitempty"`
+}
+
+// OwnerIndex maps owner quids to their stakes. Owns its own lock.
+type OwnerIndex struct {
+	mu      sync.RWMutex
+	byOwner map[string]map[string]OwnedAsset // owner → asset → stake
+	byAsset map[string][]string              // asset → current owners
+}
+
+// NewOwnerIndex constructs an empty index.
+func NewOwnerIndex() *OwnerIndex {
+	return &OwnerIndex{
+		byOwner: make(map[string]map[string]OwnedAsset),
+		byAsset: make(map[string][]string),
+	}
+}
+
+// replace sets the owners of assetID to owners, removing the asset
+// from anyone no longer listed. When an owner appears more than
+// once the first stake is kept, matching the title registry query.
+func (x *OwnerIndex) replace(assetID string, owners []OwnershipStake) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, ownerID := range x.byAsset[assetID] {
+		if assets, ok := x.byOwner[ownerID]; ok {
+			delete(assets, assetID)
+			if len(assets) == 0 {
+				delete(x.byOwner, ownerID)
+			}
+		}
+	}
+	delete(x.byAsset, assetID)
+
+	current := make([]string, 0, len(owners))
+	for _, stake := range owners {
+		if stake.OwnerID == "" {
+			continue
+		}
+		assets, ok := x.byOwner[stake.OwnerID]
+		if !ok {
+			assets = make(map[string]OwnedAsset)
+			x.byOwner[stake.OwnerID] = assets
+		}
+		if _, dup := assets[assetID]; dup {
+			continue
+		}
+		assets[assetID] = OwnedAsset{
+			AssetID:    assetID,
+			Percentage: stake.Percentage,
+			StakeType:  stake.StakeType,
+			ExpiresAt:  stake.ExpiresAt,
+		}
+		current = append(current, stake.OwnerID)
+	}
+	if len(current) > 0 {
+		x.byAsset[assetID] = current
+	}
+}
+
+// assetsOf returns ownerID's stakes sorted by asset ID.
+func (x *OwnerIndex) assetsOf(ownerID string) []OwnedAsset {
+	x.mu.RLock()
+	assets := x.byOwner[ownerID]
+	out := make([]OwnedAsset, 0, len(assets))
+	for _, a := range assets {
+		out = append(out, a)
+	}
+	x.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].AssetID < out[j].AssetID })
+	return out
+}
+
+// GetOwnedAssets returns the assets ownerID holds a stake in,
+// sorted by asset ID.
+func (node *QuidnugNode) GetOwnedAssets(ownerID string) []OwnedAsset {
+	if node.OwnerIndex == nil {
+		return []OwnedAsset{}
+	}
+	return node.OwnerIndex.assetsOf(ownerID)
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOwnerIndex_FollowsTransfers(t *testing.T) {
+	node := newTestNode()
+
+	// Seeded title 0000000000000003 is split 60/40 between 04 and 05.
+	if got := node.GetOwnedAssets("0000000000000004"); len(got) != 1 || got[0].Percentage != 60 {
+		t.Fatalf("seeded stake missing: %+v", got)
+	}
+
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx_title_002", Type: TxTypeTitle},
+		AssetID:         "0000000000000003",
+		Owners: []OwnershipStake{
+			{OwnerID: "0000000000000005", Percentage: 100, StakeType: "full"},
+		},
+	})
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx_title_003", Type: TxTypeTitle},
+		AssetID:         "00000000000000a1",
+		Owners:          []OwnershipStake{{OwnerID: "0000000000000005", Percentage: 50}},
+	})
+
+	if got := node.GetOwnedAssets("0000000000000004"); len(got) != 0 {
+		t.Fatalf("previous owner still indexed: %+v", got)
+	}
+	got := node.GetOwnedAssets("0000000000000005")
+	if len(got) != 2 || got[0].AssetID != "0000000000000003" || got[0].Percentage != 100 || got[0].StakeType != "full" || got[1].AssetID != "00000000000000a1" {
+		t.Fatalf("unexpected assets for new owner: %+v", got)
+	}
+}
+
+func TestGetOwnedAssetsHandler(t *testing.T) {
+	node := newTestNode()
+	for _, id := range []string{"00000000000000a1", "00000000000000a2", "00000000000000a3"} {
+		node.updateTitleRegistry(TitleTransaction{
+			BaseTransaction: BaseTransaction{ID: "tx-" + id, Type: TxTypeTitle},
+			AssetID:         id,
+			Owners:          []OwnershipStake{{OwnerID: "0000000000000004", Percentage: 100}},
+		})
+	}
+	router := setupTestRouter(node)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/owners/0000000000000004/assets?limit=2&offset=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Data       []OwnedAsset   `json:"data"`
+			Pagination PaginationMeta `json:"pagination"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Pagination.Total != 4 || len(resp.Data.Data) != 2 || resp.Data.Data[0].AssetID != "00000000000000a1" {
+		t.Fatalf("unexpected page: %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/owners/not-a-quid/assets", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid quid: expected 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,314 @@
+// Inbound payload validation.
+//
+// PayloadValidationMiddleware checks JSON request bodies before any
+// handler decodes them. Every POST/PUT/PATCH body under /api gets
+// structural limits (nesting depth, keys per object); the write
+// endpoints listed in payloadSchemas are additionally checked
+// against a JSON Schema, and free-form attribute maps get tighter
+// size and depth bounds. Failures come back as a 400 with one entry
+// per offending field, so a client sees every problem at once
+// instead of whichever check in the validation path tripped first.
+//
+// The middleware is a cheap first gate, not the source of truth:
+// handlers and Validate*Transaction still enforce everything, and
+// bodies that are not valid JSON are passed through for the handler
+// to reject in its usual way.
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/quidnug/quidnug/internal/jsonschema"
+)
+
+// Structural limits applied to every JSON request body.
+const (
+	// MaxPayloadDepth bounds object/array nesting anywhere in a body.
+	MaxPayloadDepth = 32
+	// MaxPayloadObjectKeys bounds the keys in any single object.
+	MaxPayloadObjectKeys = 1024
+)
+
+// Limits for free-form attribute maps (identity attributes, custom
+// transaction payloads).
+const (
+	MaxAttributeDepth = 4
+	MaxAttributeCount = 64
+	MaxAttributeBytes = 16 * 1024
+)
+
+// payloadSpec is what the middleware checks for one route.
+type payloadSpec struct {
+	schema *jsonschema.Schema
+	// attributeFields name top-level fields holding free-form maps
+	// that get the attribute limits.
+	attributeFields []string
+}
+
+// payloadSchemas maps a POST route, relative to the /api or
+// /api/v1 prefix, to its checks. Schemas only describe fields the
+// handler reads; unknown fields are still rejected by
+// DecodeJSONBody.
+var payloadSchemas = map[string]payloadSpec{
+	"/transactions/trust": {schema: jsonschema.MustCompile(`{
+		"type": "object",
+		"required": ["truster", "trustee", "trustLevel"],
+		"properties": {
+			"truster": {"type": "string", "maxLength": 64},
+			"trustee": {"type": "string", "maxLength": 64},
+			"trustLevel": {"type": "number", "minimum": 0, "maximum": 1},
+			"trustDomain": {"type": "string", "maxLength": 253},
+			"nonce": {"type": "integer", "minimum": 0},
+			"description": {"type": "string", "maxLength": 4096},
+			"validUntil": {"type": "integer", "minimum": 0},
+			"evidence": {
+				"type": "array",
+				"maxItems": 16,
+				"items": {
+					"type": "object",
+					"required": ["type", "value"],
+					"properties": {
+						"type": {"enum": ["document-hash", "url", "credential"]},
+						"value": {"type": "string", "maxLength": 2048},
+						"label": {"type": "string", "maxLength": 256}
+					}
+				}
+			}
+		}
+	}`)},
+	"/transactions/identity": {
+		schema: jsonschema.MustCompile(`{
+			"type": "object",
+			"required": ["quidId"],
+			"properties": {
+				"quidId": {"type": "string", "maxLength": 64},
+				"name": {"type": "string", "maxLength": 256},
+				"description": {"type": "string", "maxLength": 4096},
+				"attributes": {"type": ["object", "null"]},
+				"creator": {"type": "string", "maxLength": 64},
+				"updateNonce": {"type": "integer", "minimum": 0},
+				"homeDomain": {"type": "string", "maxLength": 253},
+				"trustDomain": {"type": "string", "maxLength": 253}
+			}
+		}`),
+		attributeFields: []string{"attributes"},
+	},
+	"/transactions/title": {schema: jsonschema.MustCompile(`{
+		"type": "object",
+		"required": ["assetId", "owners"],
+		"properties": {
+			"assetId": {"type": "string", "minLength": 1, "maxLength": 256},
+			"owners": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 256,
+				"items": {
+					"type": "object",
+					"required": ["ownerId", "percentage"],
+					"properties": {
+						"ownerId": {"type": "string", "maxLength": 64},
+						"percentage": {"type": "number", "minimum": 0, "maximum": 100},
+						"stakeType": {"type": "string", "maxLength": 256},
+						"expiresAt": {"type": "integer", "minimum": 0}
+					}
+				}
+			},
+			"signatures": {"type": ["object", "null"], "maxProperties": 256},
+			"titleType": {"type": "string", "maxLength": 256},
+			"trustDomain": {"type": "string", "maxLength": 253}
+		}
+	}`)},
+	"/events": {schema: jsonschema.MustCompile(`{
+		"type": "object",
+		"required": ["subjectId", "subjectType", "eventType"],
+		"properties": {
+			"subjectId": {"type": "string", "maxLength": 256},
+			"subjectType": {"enum": ["QUID", "TITLE"]},
+			"eventType": {"type": "string", "minLength": 1, "maxLength": 256},
+			"sequence": {"type": "integer", "minimum": 0},
+			"payload": {"type": ["object", "null"]},
+			"payloadCid": {"type": "string", "maxLength": 256},
+			"trustDomain": {"type": "string", "maxLength": 253}
+		}
+	}`)},
+	"/transactions/custom": {
+		schema: jsonschema.MustCompile(`{
+			"type": "object",
+			"required": ["customType", "signer", "payload"],
+			"properties": {
+				"customType": {"type": "string", "maxLength": 64},
+				"signer": {"type": "string", "maxLength": 64},
+				"subjectId": {"type": "string", "maxLength": 256},
+				"payload": {"type": "object"},
+				"nonce": {"type": "integer", "minimum": 0},
+				"trustDomain": {"type": "string", "maxLength": 253}
+			}
+		}`),
+		attributeFields: []string{"payload"},
+	},
+	"/trust/query/batch": {schema: jsonschema.MustCompile(`{
+		"type": "object",
+		"properties": {
+			"targets": {"type": "array", "maxItems": 500, "items": {"type": "string"}},
+			"pairs": {"type": "array", "maxItems": 500},
+			"maxDepth": {"type": "integer", "minimum": 0}
+		}
+	}`)},
+}
+
+// PayloadValidationMiddleware rejects JSON request bodies that break
+// the structural limits or their route's schema.
+func PayloadValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !payloadValidationApplies(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Payload Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var decoded interface{}
+		if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &decoded) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var violations []jsonschema.FieldError
+		checkPayloadStructure(decoded, "", 0, &violations)
+		if spec, ok := payloadSchemas[apiRouteSuffix(r.URL.Path)]; ok {
+			if spec.schema != nil {
+				violations = append(violations, spec.schema.Validate(decoded)...)
+			}
+			if obj, isObj := decoded.(map[string]interface{}); isObj {
+				for _, field := range spec.attributeFields {
+					checkAttributeMap(obj[field], field, &violations)
+				}
+			}
+		}
+		if len(violations) > 0 {
+			WriteFieldViolations(w, "INVALID_PAYLOAD", "Request body failed validation", violations)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// payloadValidationApplies limits the middleware to JSON write
+// requests under /api.
+func payloadValidationApplies(r *http.Request) bool {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		return false
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// apiRouteSuffix strips the /api/v1 or /api prefix.
+func apiRouteSuffix(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/v1/"); ok {
+		return "/" + rest
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/"); ok {
+		return "/" + rest
+	}
+	return path
+}
+
+// checkPayloadStructure enforces MaxPayloadDepth and
+// MaxPayloadObjectKeys. It stops descending at the first violation
+// on each branch.
+func checkPayloadStructure(v interface{}, path string, depth int, out *[]jsonschema.FieldError) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if depth >= MaxPayloadDepth {
+			*out = append(*out, jsonschema.FieldError{Path: path, Message: "nested too deeply (max " + strconv.Itoa(MaxPayloadDepth) + ")"})
+			return
+		}
+		if len(val) > MaxPayloadObjectKeys {
+			*out = append(*out, jsonschema.FieldError{Path: path, Message: "too many keys (max " + strconv.Itoa(MaxPayloadObjectKeys) + ")"})
+			return
+		}
+		for k, child := range val {
+			checkPayloadStructure(child, joinFieldPath(path, k), depth+1, out)
+		}
+	case []interface{}:
+		if depth >= MaxPayloadDepth {
+			*out = append(*out, jsonschema.FieldError{Path: path, Message: "nested too deeply (max " + strconv.Itoa(MaxPayloadDepth) + ")"})
+			return
+		}
+		for i, child := range val {
+			checkPayloadStructure(child, path+"["+strconv.Itoa(i)+"]", depth+1, out)
+		}
+	}
+}
+
+// checkAttributeMap applies the attribute limits to a free-form map.
+// Absent or null fields pass; type errors are left to the schema.
+func checkAttributeMap(v interface{}, field string, out *[]jsonschema.FieldError) {
+	attrs, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(attrs) > MaxAttributeCount {
+		*out = append(*out, jsonschema.FieldError{Path: field, Message: "too many attributes (max " + strconv.Itoa(MaxAttributeCount) + ")"})
+	}
+	if d := valueDepth(attrs); d > MaxAttributeDepth {
+		*out = append(*out, jsonschema.FieldError{Path: field, Message: "nested too deeply (max " + strconv.Itoa(MaxAttributeDepth) + ")"})
+	}
+	if encoded, err := json.Marshal(attrs); err == nil && len(encoded) > MaxAttributeBytes {
+		*out = append(*out, jsonschema.FieldError{Path: field, Message: "too large (max " + strconv.Itoa(MaxAttributeBytes) + " bytes)"})
+	}
+}
+
+// valueDepth is the container nesting depth of v; scalars are 0.
+func valueDepth(v interface{}) int {
+	deepest := 0
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, child := range val {
+			if d := valueDepth(child); d > deepest {
+				deepest = d
+			}
+		}
+	case []interface{}:
+		for _, child := range val {
+			if d := valueDepth(child); d > deepest {
+				deepest = d
+			}
+		}
+	default:
+		return 0
+	}
+	return deepest + 1
+}
+
+func joinFieldPath(base, name string) string {
+	if base == "" {
+		return name
+	}
+	return base + "." + name
+}
@@ -42,9 +42,10 @@ import (
 //     i.e. a peer that some other node told us about.
 //   - PeerSourceLAN: discovered via mDNS on the local segment.
 //   - PeerSourceStatic: listed in operator's peers_file.
+//   - PeerSourceManual: added through POST /api/v1/admin/peers.
 //
-// allow_private overrides are honored ONLY for Static + LAN
-// sources (the operator explicitly chose to trust those).
+// allow_private overrides are honored ONLY for Static, LAN and
+// Manual sources (the operator explicitly chose to trust those).
 type PeerSource string
 
 const (
@@ -52,12 +53,13 @@ const (
 	PeerSourceGossip PeerSource = "gossip"
 	PeerSourceLAN    PeerSource = "lan"
 	PeerSourceStatic PeerSource = "static"
+	PeerSourceManual PeerSource = "manual"
 )
 
 // allowsPrivate reports whether per-peer allow_private overrides
 // are honored for this source.
 func (s PeerSource) allowsPrivate() bool {
-	return s == PeerSourceStatic || s == PeerSourceLAN
+	return s == PeerSourceStatic || s == PeerSourceLAN || s == PeerSourceManual
 }
 
 // PeerCandidate is the input to AdmitPeer. Address is required;
@@ -82,6 +84,12 @@ type PeerAdmitConfig struct {
 	HandshakeTimeout          time.Duration
 }
 
+// gatesActive reports whether any admission gate is configured,
+// i.e. whether AdmitPeer needs the candidate's handshake.
+func (cfg PeerAdmitConfig) gatesActive() bool {
+	return cfg.RequireAdvertisement || cfg.MinOperatorTrust > 0 || cfg.MinOperatorReputation > 0
+}
+
 // PeerVerdict is what AdmitPeer returns on success. Rejections
 // surface as errors instead, with reason embedded in the message.
 type PeerVerdict struct {
@@ -91,6 +99,9 @@ type PeerVerdict struct {
 	AdmittedAt   time.Time
 	HasAd        bool
 	OpTrustEdge  float64 // weight of OperatorQuid → NodeQuid TRUST edge
+	// Capabilities the peer reported in the handshake; zero when
+	// the handshake was skipped (no gates configured).
+	Capabilities PeerCapabilities
 }
 
 // AdmitPeer runs the four-stage admission pipeline. Returns a
@@ -147,9 +158,8 @@ func (node *QuidnugNode) AdmitPeer(ctx context.Context, c PeerCandidate, cfg Pee
 		Source:       c.Source,
 		AdmittedAt:   time.Now(),
 	}
-	gatesActive := cfg.RequireAdvertisement || cfg.MinOperatorTrust > 0 || cfg.MinOperatorReputation > 0
 	_ = safe // referenced inside the gatesActive branch
-	if gatesActive {
+	if cfg.gatesActive() {
 		hsCtx, cancel := context.WithTimeout(ctx, cfg.HandshakeTimeout)
 		defer cancel()
 		info, err := node.peerInfoHandshake(hsCtx, safe.String())
@@ -174,8 +184,20 @@ func (node *QuidnugNode) AdmitPeer(ctx context.Context, c PeerCandidate, cfg Pee
 			return nil, fmt.Errorf("admit %s: handshake %s: OperatorQuid mismatch (pinned %s, served %s)",
 				c.Source, c.Address, c.OperatorQuid, info.OperatorQuid)
 		}
+		if !info.Capabilities.protocolCompatible() {
+			min, max := info.Capabilities.protocolWindow()
+			return nil, fmt.Errorf("admit %s: handshake %s: protocol versions %d-%d do not overlap ours %d-%d",
+				c.Source, c.Address, min, max, MinProtocolVersion, ProtocolVersion)
+		}
 		verdict.NodeQuid = info.NodeQuid
 		verdict.OperatorQuid = info.OperatorQuid
+		verdict.Capabilities = info.Capabilities
+	}
+
+	// A banned peer stays out until its ban lapses, whichever
+	// source offers it (peer_ban.go).
+	if node.peerBanned(verdict.NodeQuid) {
+		return nil, fmt.Errorf("admit %s: %s: peer %s is banned", c.Source, c.Address, verdict.NodeQuid)
 	}
 
 	// Stage 3: NodeAdvertisement lookup.
@@ -228,6 +250,7 @@ func (node *QuidnugNode) AdmitPeer(ctx context.Context, c PeerCandidate, cfg Pee
 type peerInfoResponse struct {
 	NodeQuid     string `json:"nodeQuid"`
 	OperatorQuid string `json:"-"` // assembled from operatorQuid.id below
+	Capabilities PeerCapabilities
 }
 
 type peerInfoEnvelope struct {
@@ -237,11 +260,12 @@ type peerInfoEnvelope struct {
 		OperatorQuid struct {
 			ID string `json:"id"`
 		} `json:"operatorQuid"`
+		PeerCapabilities
 	} `json:"data"`
 }
 
 // peerInfoHandshake calls GET /api/v1/info on the candidate and
-// extracts NodeQuid + OperatorQuid.
+// extracts NodeQuid, OperatorQuid and advertised capabilities.
 func (node *QuidnugNode) peerInfoHandshake(ctx context.Context, hostPort string) (peerInfoResponse, error) {
 	url := fmt.Sprintf("http://%s/api/v1/info", hostPort)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil) // #nosec G107 -- hostPort is sanitized via ValidatePeerAddress in caller; transport also enforces safedial
@@ -268,6 +292,7 @@ func (node *QuidnugNode) peerInfoHandshake(ctx context.Context, hostPort string)
 	out := peerInfoResponse{
 		NodeQuid:     env.Data.NodeQuid,
 		OperatorQuid: env.Data.OperatorQuid.ID,
+		Capabilities: env.Data.PeerCapabilities,
 	}
 	if out.NodeQuid == "" {
 		return out, fmt.Errorf("response missing nodeQuid")
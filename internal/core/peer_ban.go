@@ -0,0 +1,189 @@
+// Package core — peer_ban.go
+//
+// Offense tracking and temporary bans on top of the peer
+// scoreboard (peer_score.go).
+//
+// An offense is traffic from a peer that fails our checks outright:
+// a block that doesn't validate, a transaction POST we reject as
+// malformed, or a request over the rate limit. Each one increments
+// the peer's decaying offense counter, which the composite score
+// subtracts at PeerScoreWeights.Offense per offense. When an
+// offense leaves the composite below peer_ban_threshold the peer is
+// banned for peer_ban_duration: no broadcasts, block sync or push
+// gossip go to it, it is not re-admitted, and its requests get a
+// 403. Bans lapse on their own; quarantine (peer_eviction.go) is
+// the gentler, score-driven step before it.
+//
+// Inbound requests carry no peer identity, so the HTTP-side
+// offenses are attributed by matching the client IP against the
+// host part of KnownNodes addresses. Peers known only by hostname
+// are not matched.
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PeerOffense is the kind of misbehavior being reported.
+type PeerOffense string
+
+const (
+	PeerOffenseInvalidBlock      PeerOffense = "invalid-block"
+	PeerOffenseMalformedTx       PeerOffense = "malformed-tx"
+	PeerOffenseExcessiveRequests PeerOffense = "excessive-requests"
+)
+
+// RecordOffense applies one offense to the peer's score and
+// returns the resulting composite. Invalid blocks and malformed
+// transactions also count as validation failures.
+func (sb *PeerScoreboard) RecordOffense(nodeQuid string, kind PeerOffense, note string) float64 {
+	if nodeQuid == "" {
+		return 0.5
+	}
+	p := sb.getOrCreate(nodeQuid)
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch kind {
+	case PeerOffenseInvalidBlock:
+		p.InvalidBlocks++
+	case PeerOffenseMalformedTx:
+		p.MalformedTxs++
+	case PeerOffenseExcessiveRequests:
+		p.ExcessiveRequests++
+	default:
+		return p.compositeLocked(sb.weights)
+	}
+	if kind != PeerOffenseExcessiveRequests {
+		p.Validation.applyDecay(now)
+		p.Validation.Failures++
+	}
+	p.Offenses.applyDecay(now)
+	p.Offenses.Failures++
+	p.LastUpdated = now
+	p.recordEventLocked(PeerScoreEvent{
+		Timestamp: now,
+		Class:     "offense",
+		Note:      strings.TrimSpace(string(kind) + " " + note),
+	})
+	return p.compositeLocked(sb.weights)
+}
+
+// Ban bans the peer until the given time, replacing any earlier
+// ban.
+func (sb *PeerScoreboard) Ban(nodeQuid string, until time.Time, reason string) {
+	if nodeQuid == "" {
+		return
+	}
+	p := sb.getOrCreate(nodeQuid)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.BannedUntil = until
+	p.BanReason = reason
+}
+
+// Unban lifts the peer's ban, reporting whether one was active.
+func (sb *PeerScoreboard) Unban(nodeQuid string) bool {
+	p := sb.lookup(nodeQuid)
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	active := time.Now().Before(p.BannedUntil)
+	p.BannedUntil = time.Time{}
+	p.BanReason = ""
+	return active
+}
+
+// IsBanned reports whether the peer is under an unexpired ban.
+func (sb *PeerScoreboard) IsBanned(nodeQuid string) bool {
+	p := sb.lookup(nodeQuid)
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return time.Now().Before(p.BannedUntil)
+}
+
+// peerBanned is IsBanned with the nil tolerance of recordPeerScore.
+func (node *QuidnugNode) peerBanned(nodeQuid string) bool {
+	if node == nil || node.PeerScoreboard == nil || nodeQuid == "" {
+		return false
+	}
+	return node.PeerScoreboard.IsBanned(nodeQuid)
+}
+
+// reportPeerOffense records an offense and bans the peer when its
+// composite falls below PeerBanThreshold.
+func (node *QuidnugNode) reportPeerOffense(nodeQuid string, kind PeerOffense, note string) {
+	if node == nil || node.PeerScoreboard == nil || nodeQuid == "" {
+		return
+	}
+	composite := node.PeerScoreboard.RecordOffense(nodeQuid, kind, note)
+	if node.PeerBanThreshold <= 0 || composite >= node.PeerBanThreshold || node.PeerScoreboard.IsBanned(nodeQuid) {
+		return
+	}
+	duration := node.PeerBanDuration
+	if duration <= 0 {
+		duration = time.Hour
+	}
+	reason := fmt.Sprintf("%s with composite %.2f below ban threshold %.2f", kind, composite, node.PeerBanThreshold)
+	node.PeerScoreboard.Ban(nodeQuid, time.Now().Add(duration), reason)
+	logger.Warn("Peer banned",
+		"nodeQuid", nodeQuid,
+		"offense", kind,
+		"composite", composite,
+		"duration", duration)
+}
+
+// peerForRemoteIP returns the ID of the known node whose address
+// host is ip, or "" when there is none.
+func (node *QuidnugNode) peerForRemoteIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	node.KnownNodesMutex.RLock()
+	defer node.KnownNodesMutex.RUnlock()
+	for id, n := range node.KnownNodes {
+		if host, _, err := net.SplitHostPort(n.Address); err == nil && host == ip {
+			return id
+		}
+	}
+	return ""
+}
+
+// PeerBanMiddleware refuses requests from banned peers and reports
+// rate-limited requests and rejected transaction POSTs from known
+// peers as offenses. It wraps the rate limiter so it sees its 429s.
+func (node *QuidnugNode) PeerBanMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if node.PeerScoreboard == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		peer := node.peerForRemoteIP(getClientIP(r))
+		if peer == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if node.PeerScoreboard.IsBanned(peer) {
+			WriteError(w, http.StatusForbidden, "FORBIDDEN", "peer is banned")
+			return
+		}
+		wrapped := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+		switch {
+		case wrapped.statusCode == http.StatusTooManyRequests:
+			node.reportPeerOffense(peer, PeerOffenseExcessiveRequests, r.URL.Path)
+		case wrapped.statusCode == http.StatusBadRequest && r.Method == http.MethodPost &&
+			strings.Contains(r.URL.Path, "/transactions/"):
+			node.reportPeerOffense(peer, PeerOffenseMalformedTx, r.URL.Path)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newPeerBanTestNode(t *testing.T) *QuidnugNode {
+	t.Helper()
+	node := newTestNode()
+	node.PeerScoreboard = NewPeerScoreboard(DefaultPeerScoreWeights(), "", 0)
+	node.PeerBanThreshold = 0.15
+	node.PeerBanDuration = time.Hour
+	node.KnownNodesMutex.Lock()
+	node.KnownNodes["badpeer"] = Node{ID: "badpeer", Address: "203.0.113.7:8080"}
+	node.KnownNodes["goodpeer"] = Node{ID: "goodpeer", Address: "203.0.113.8:8080"}
+	node.KnownNodesMutex.Unlock()
+	return node
+}
+
+func TestReportPeerOffense_BansBelowThreshold(t *testing.T) {
+	node := newPeerBanTestNode(t)
+
+	node.reportPeerOffense("badpeer", PeerOffenseInvalidBlock, "block 3")
+	if node.peerBanned("badpeer") {
+		t.Fatal("banned after a single offense")
+	}
+	for i := 0; i < 20 && !node.peerBanned("badpeer"); i++ {
+		node.reportPeerOffense("badpeer", PeerOffenseMalformedTx, "")
+	}
+	if !node.peerBanned("badpeer") {
+		t.Fatalf("not banned; composite = %v", node.PeerScoreboard.Composite("badpeer"))
+	}
+
+	routed := node.preferByScore([]Node{{ID: "badpeer"}, {ID: "goodpeer"}})
+	if len(routed) != 1 || routed[0].ID != "goodpeer" {
+		t.Errorf("routing kept a banned peer: %v", routed)
+	}
+	if !node.PeerScoreboard.Unban("badpeer") || node.peerBanned("badpeer") {
+		t.Error("Unban did not lift the ban")
+	}
+}
+
+func TestReportPeerOffense_DisabledAtZeroThreshold(t *testing.T) {
+	node := newPeerBanTestNode(t)
+	node.PeerBanThreshold = 0
+	for i := 0; i < 50; i++ {
+		node.reportPeerOffense("badpeer", PeerOffenseExcessiveRequests, "")
+	}
+	if node.peerBanned("badpeer") {
+		t.Error("banned with banning disabled")
+	}
+	if snap := node.PeerScoreboard.SnapshotOne("badpeer"); snap == nil || snap.ExcessiveRequests != 50 {
+		t.Errorf("offenses not counted: %+v", snap)
+	}
+}
+
+func TestPeerBanMiddleware(t *testing.T) {
+	node := newPeerBanTestNode(t)
+	status := http.StatusTooManyRequests
+	handler := node.PeerBanMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	serve := func(method, path, remote string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remote
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	serve("GET", "/api/v1/nodes", "203.0.113.7:5555")
+	status = http.StatusBadRequest
+	serve("POST", "/api/v1/transactions/trust", "203.0.113.7:5555")
+	serve("GET", "/api/v1/nodes", "198.51.100.1:5555")
+
+	snap := node.PeerScoreboard.SnapshotOne("badpeer")
+	if snap == nil || snap.ExcessiveRequests != 1 || snap.MalformedTxs != 1 {
+		t.Fatalf("offenses = %+v", snap)
+	}
+
+	node.PeerScoreboard.Ban("badpeer", time.Now().Add(time.Minute), "test")
+	status = http.StatusOK
+	if code := serve("GET", "/api/v1/nodes", "203.0.113.7:5555"); code != http.StatusForbidden {
+		t.Errorf("banned peer got %d", code)
+	}
+	if code := serve("GET", "/api/v1/nodes", "203.0.113.8:5555"); code != http.StatusOK {
+		t.Errorf("other peer got %d", code)
+	}
+}
+
+func TestGetNodesHandler_IncludesPeerScore(t *testing.T) {
+	node := newPeerBanTestNode(t)
+	node.PeerScoreboard.Ban("badpeer", time.Now().Add(time.Minute), "test")
+
+	rr := httptest.NewRecorder()
+	node.GetNodesHandler(rr, httptest.NewRequest("GET", "/api/v1/nodes", nil))
+	var resp struct {
+		Data struct {
+			Data []nodeListEntry `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, n := range resp.Data.Data {
+		switch n.ID {
+		case "badpeer":
+			found = true
+			if n.PeerScore == nil || !n.PeerScore.Banned || n.PeerScore.BanReason != "test" {
+				t.Errorf("badpeer score = %+v", n.PeerScore)
+			}
+		case "goodpeer":
+			if n.PeerScore != nil {
+				t.Errorf("unscored peer has score %+v", n.PeerScore)
+			}
+		}
+	}
+	if !found {
+		t.Error("badpeer missing from node list")
+	}
+}
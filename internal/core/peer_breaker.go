@@ -0,0 +1,283 @@
+// Package core — peer_breaker.go
+//
+// Per-peer circuit breakers and concurrency limits for outbound
+// requests.
+//
+// Every peer-facing call (discovery, domain queries, broadcasts,
+// block sync, gossip) goes through node.httpClient, whose Timeout
+// (http_client_timeout) bounds a single request. That alone still
+// lets a dead peer cost each caller a full timeout, and lets a
+// slow one soak up an unbounded number of in-flight requests.
+// peerGuardTransport sits under the client and keys state by the
+// request's host:port:
+//
+//   - After peer_breaker_failures consecutive failures (transport
+//     errors or 5xx responses) the peer's circuit opens and its
+//     requests fail at once with ErrPeerCircuitOpen. Once
+//     peer_breaker_cooldown has passed, one request is let through
+//     as a probe; its success closes the circuit, its failure
+//     re-opens it for another cooldown.
+//   - At most peer_max_concurrent_requests requests per peer are in
+//     flight. Further requests wait for a slot until their context
+//     ends or the slot wait elapses, then fail with ErrPeerBusy. A
+//     slot is held until the response body is closed.
+//
+// Requests the caller cancels don't count against the peer. A zero
+// failure threshold or concurrency limit turns that half off.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPeerCircuitOpen is returned for requests to a peer whose
+	// circuit breaker is open.
+	ErrPeerCircuitOpen = errors.New("peer circuit open")
+
+	// ErrPeerBusy is returned when no concurrency slot for the peer
+	// freed up in time.
+	ErrPeerBusy = errors.New("peer concurrency limit reached")
+)
+
+// PeerCircuitState is a circuit breaker state.
+type PeerCircuitState string
+
+const (
+	PeerCircuitClosed   PeerCircuitState = "closed"
+	PeerCircuitOpen     PeerCircuitState = "open"
+	PeerCircuitHalfOpen PeerCircuitState = "half-open"
+)
+
+// PeerCircuitStatus is a point-in-time view of one peer's breaker.
+type PeerCircuitStatus struct {
+	Host                string           `json:"host"`
+	State               PeerCircuitState `json:"state"`
+	ConsecutiveFailures int              `json:"consecutiveFailures"`
+	OpenedAt            *time.Time       `json:"openedAt,omitempty"`
+	InFlight            int              `json:"inFlight"`
+}
+
+// peerCircuit is the per-host breaker and slot pool.
+type peerCircuit struct {
+	mu       sync.Mutex
+	state    PeerCircuitState
+	failures int
+	openedAt time.Time
+	slots    chan struct{} // nil when concurrency is unlimited
+}
+
+// peerGuardTransport applies the per-peer guards around base.
+type peerGuardTransport struct {
+	base          http.RoundTripper
+	failures      int
+	cooldown      time.Duration
+	maxConcurrent int
+	slotWait      time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*peerCircuit
+	now      func() time.Time
+}
+
+// newPeerGuardTransport wraps base. slotWait bounds how long a
+// request waits for a concurrency slot when its context has no
+// earlier deadline.
+func newPeerGuardTransport(base http.RoundTripper, failures int, cooldown time.Duration, maxConcurrent int, slotWait time.Duration) *peerGuardTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &peerGuardTransport{
+		base:          base,
+		failures:      failures,
+		cooldown:      cooldown,
+		maxConcurrent: maxConcurrent,
+		slotWait:      slotWait,
+		circuits:      make(map[string]*peerCircuit),
+		now:           time.Now,
+	}
+}
+
+func (t *peerGuardTransport) circuit(host string) *peerCircuit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.circuits[host]
+	if !ok {
+		c = &peerCircuit{state: PeerCircuitClosed}
+		if t.maxConcurrent > 0 {
+			c.slots = make(chan struct{}, t.maxConcurrent)
+		}
+		t.circuits[host] = c
+	}
+	return c
+}
+
+// admit reports whether a request may go out, moving an open
+// circuit whose cooldown has passed to half-open for one probe.
+func (t *peerGuardTransport) admit(c *peerCircuit) bool {
+	if t.failures <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case PeerCircuitOpen:
+		if t.now().Sub(c.openedAt) < t.cooldown {
+			return false
+		}
+		c.state = PeerCircuitHalfOpen
+		return true
+	case PeerCircuitHalfOpen:
+		// The probe is still out.
+		return false
+	default:
+		return true
+	}
+}
+
+// record applies a request outcome to the breaker.
+func (t *peerGuardTransport) record(host string, c *peerCircuit, ok bool) {
+	if t.failures <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		c.state = PeerCircuitClosed
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.state == PeerCircuitHalfOpen || c.failures >= t.failures {
+		if c.state == PeerCircuitClosed {
+			logger.Warn("Peer circuit opened", "host", host, "consecutiveFailures", c.failures)
+		}
+		c.state = PeerCircuitOpen
+		c.openedAt = t.now()
+	}
+}
+
+// acquire takes a concurrency slot for req's peer.
+func (t *peerGuardTransport) acquire(req *http.Request, c *peerCircuit) error {
+	if c.slots == nil {
+		return nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	timer := time.NewTimer(t.slotWait)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return ErrPeerBusy
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *peerGuardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	c := t.circuit(host)
+	if !t.admit(c) {
+		peerRequestsRejected.WithLabelValues("circuit-open").Inc()
+		return nil, fmt.Errorf("%s: %w", host, ErrPeerCircuitOpen)
+	}
+	if err := t.acquire(req, c); err != nil {
+		if errors.Is(err, ErrPeerBusy) {
+			peerRequestsRejected.WithLabelValues("busy").Inc()
+		}
+		// Nothing was sent; a half-open circuit must not wait
+		// forever on a probe that never ran.
+		t.abandonProbe(c)
+		return nil, fmt.Errorf("%s: %w", host, err)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		if req.Context().Err() == nil {
+			t.record(host, c, false)
+		} else {
+			t.abandonProbe(c)
+		}
+		t.release(c)
+		return nil, err
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.record(host, c, false)
+	default:
+		t.record(host, c, true)
+	}
+	if c.slots != nil {
+		resp.Body = &slotReleasingBody{ReadCloser: resp.Body, release: func() { t.release(c) }}
+	}
+	return resp, nil
+}
+
+// release frees a concurrency slot taken by acquire.
+func (t *peerGuardTransport) release(c *peerCircuit) {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+// abandonProbe re-opens a half-open circuit whose probe never got
+// an answer, so the next request after the cooldown probes again.
+func (t *peerGuardTransport) abandonProbe(c *peerCircuit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == PeerCircuitHalfOpen {
+		c.state = PeerCircuitOpen
+	}
+}
+
+// Status returns every tracked peer's breaker state.
+func (t *peerGuardTransport) Status() []PeerCircuitStatus {
+	t.mu.Lock()
+	hosts := make(map[string]*peerCircuit, len(t.circuits))
+	for host, c := range t.circuits {
+		hosts[host] = c
+	}
+	t.mu.Unlock()
+	out := make([]PeerCircuitStatus, 0, len(hosts))
+	for host, c := range hosts {
+		c.mu.Lock()
+		st := PeerCircuitStatus{
+			Host:                host,
+			State:               c.state,
+			ConsecutiveFailures: c.failures,
+			InFlight:            len(c.slots),
+		}
+		if c.state != PeerCircuitClosed {
+			openedAt := c.openedAt
+			st.OpenedAt = &openedAt
+		}
+		c.mu.Unlock()
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+// slotReleasingBody frees the peer's concurrency slot on Close.
+type slotReleasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *slotReleasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
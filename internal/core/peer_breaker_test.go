@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPeerGuard_BreakerOpensAndRecovers(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	guard := newPeerGuardTransport(srv.Client().Transport, 3, time.Minute, 0, time.Second)
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+	client := &http.Client{Transport: guard}
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrPeerCircuitOpen) {
+		t.Fatalf("err = %v, want ErrPeerCircuitOpen", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("open circuit let a request through: %d hits", hits.Load())
+	}
+
+	// A failed probe after the cooldown re-opens the circuit.
+	now = now.Add(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := get(); !errors.Is(err, ErrPeerCircuitOpen) {
+		t.Fatalf("after failed probe err = %v", err)
+	}
+
+	// A successful one closes it.
+	now = now.Add(time.Minute)
+	failing.Store(false)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	status := guard.Status()
+	if len(status) != 1 || status[0].State != PeerCircuitClosed || status[0].ConsecutiveFailures != 0 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestPeerGuard_ConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	guard := newPeerGuardTransport(srv.Client().Transport, 0, 0, 1, 50*time.Millisecond)
+	client := &http.Client{Transport: guard}
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for s := guard.Status(); len(s) == 0 || s[0].InFlight == 0; s = guard.Status() {
+		if time.Now().After(deadline) {
+			t.Fatal("first request never took its slot")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrPeerBusy) {
+		t.Fatalf("err = %v, want ErrPeerBusy", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled request err = %v", err)
+	}
+
+	release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if got := guard.Status()[0].InFlight; got != 0 {
+		t.Errorf("slot not released: %d in flight", got)
+	}
+}
@@ -121,6 +121,16 @@ func (node *QuidnugNode) evaluatePeerScores(
 			if composite < evictionThreshold {
 				since := node.PeerScoreboard.MarkBelowEviction(p.ID, true)
 				if !since.IsZero() && time.Since(since) >= evictionGrace {
+					if p.Source == connectionStatusManual {
+						// Pinned through the admin API;
+						// only a DELETE removes it.
+						logger.Warn("Manual peer is below eviction threshold (pinned — remove it via DELETE /api/v1/admin/peers if unwanted)",
+							"nodeQuid", p.ID,
+							"composite", composite,
+							"threshold", evictionThreshold,
+							"belowSince", since.Format(time.RFC3339))
+						continue
+					}
 					if isStatic && staticImmune {
 						// Operator-listed peer; we
 						// don't auto-evict but we do
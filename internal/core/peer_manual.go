@@ -0,0 +1,348 @@
+// Package core — peer_manual.go
+//
+// Manual peers: connections an operator asks for at runtime.
+//
+// peers_file covers peers known ahead of time; this covers the
+// "connect to that node, now" case without editing a file. An
+// admin-signed POST /api/v1/admin/peers runs the address through
+// the same admit pipeline as every other source, completes the
+// /api/v1/info handshake even when no admit gates are configured
+// (the node must learn who it is talking to), fetches the peer's
+// domains, and adds it to KnownNodes as a "manual" peer. DELETE
+// removes it again.
+//
+// Manual peers are pinned: the score-driven eviction loop never
+// drops them (it logs instead, as for static peers), and gossip
+// or LAN discovery never overwrite their entry. They are kept in
+// manual_peers.json in the data directory and re-connected at
+// boot, so a restart doesn't lose them. A peer that fails to
+// reconnect at boot stays on the list and is retried next boot;
+// removing it is an explicit DELETE.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// connectionStatusManual tags manual peers in KnownNodes.
+const connectionStatusManual = "manual"
+
+// ErrManualPeerNotFound is returned when removing a peer that was
+// not added manually.
+var ErrManualPeerNotFound = errors.New("manual peer not found")
+
+// ErrManualPeerAddress is returned for an add request without an
+// address.
+var ErrManualPeerAddress = errors.New("address is required")
+
+// ManualPeer is one operator-added peer as persisted.
+type ManualPeer struct {
+	Address      string `json:"address"`
+	NodeQuid     string `json:"nodeQuid,omitempty"`
+	OperatorQuid string `json:"operatorQuid,omitempty"`
+	AllowPrivate bool   `json:"allowPrivate,omitempty"`
+	AddedAt      int64  `json:"addedAt"`
+}
+
+// ManualPeerAddRequest is the admin-signed body of
+// POST /admin/peers. OperatorQuid, when set, pins the operator
+// the peer must serve in its handshake. AllowPrivate lets the
+// address sit in a private range, as allow_private does in
+// peers_file. Signature covers the JSON encoding with Signature
+// empty.
+type ManualPeerAddRequest struct {
+	Address      string `json:"address"`
+	OperatorQuid string `json:"operatorQuid,omitempty"`
+	AllowPrivate bool   `json:"allowPrivate,omitempty"`
+	Timestamp    int64  `json:"timestamp"`
+	PublicKey    string `json:"publicKey"`
+	Signature    string `json:"signature"`
+}
+
+// ManualPeerRemoveRequest is the admin-signed body of
+// DELETE /admin/peers. Either field identifies the peer.
+type ManualPeerRemoveRequest struct {
+	Address   string `json:"address,omitempty"`
+	NodeQuid  string `json:"nodeQuid,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// manualPeerStore holds the manual peers, keyed by address.
+type manualPeerStore struct {
+	mu    sync.Mutex
+	path  string
+	peers map[string]ManualPeer
+}
+
+// persistedManualPeers is the manual_peers.json format.
+type persistedManualPeers struct {
+	Peers []ManualPeer `json:"peers"`
+}
+
+// newManualPeerStore loads manual_peers.json from the data
+// directory. Without a data directory the list lives in memory.
+func newManualPeerStore(cfg *config.Config) (*manualPeerStore, error) {
+	s := &manualPeerStore{peers: make(map[string]ManualPeer)}
+	if cfg == nil || cfg.DataDir == "" {
+		return s, nil
+	}
+	s.path = filepath.Join(cfg.DataDir, "manual_peers.json")
+	raw, err := safeio.ReadFile(s.path)
+	if err != nil {
+		// Missing file: no manual peers yet.
+		return s, nil
+	}
+	var doc persistedManualPeers
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	for _, p := range doc.Peers {
+		s.peers[p.Address] = p
+	}
+	return s, nil
+}
+
+// list returns the peers sorted by address.
+func (s *manualPeerStore) list() []ManualPeer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listLocked()
+}
+
+func (s *manualPeerStore) listLocked() []ManualPeer {
+	out := make([]ManualPeer, 0, len(s.peers))
+	for _, p := range s.peers {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// put adds or replaces a peer and saves the list.
+func (s *manualPeerStore) put(p ManualPeer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.peers[p.Address] = p
+	return s.saveLocked()
+}
+
+// remove deletes the peer with the given address or NodeQuid and
+// saves the list.
+func (s *manualPeerStore) remove(address, nodeQuid string) (ManualPeer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for addr, p := range s.peers {
+		if (address != "" && addr == address) || (nodeQuid != "" && p.NodeQuid == nodeQuid) {
+			delete(s.peers, addr)
+			return p, s.saveLocked()
+		}
+	}
+	return ManualPeer{}, ErrManualPeerNotFound
+}
+
+func (s *manualPeerStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	body, err := json.MarshalIndent(persistedManualPeers{Peers: s.listLocked()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safeio.WriteFileMode(s.path, append(body, '\n'), 0o600)
+}
+
+// isPinnedPeer reports whether a KnownNodes entry was put there by
+// the operator (peers_file or the admin API) and so must not be
+// replaced by a discovered one.
+func isPinnedPeer(connectionStatus string) bool {
+	return connectionStatus == "static" || connectionStatus == connectionStatusManual
+}
+
+// ManualPeers returns the operator-added peers.
+func (node *QuidnugNode) ManualPeers() []ManualPeer {
+	if node.manualPeers == nil {
+		return []ManualPeer{}
+	}
+	return node.manualPeers.list()
+}
+
+// AddManualPeer verifies req, connects to the peer and records it
+// so it is reconnected after a restart.
+func (node *QuidnugNode) AddManualPeer(ctx context.Context, req ManualPeerAddRequest) (Node, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return Node{}, err
+	}
+	req.Address = strings.TrimSpace(req.Address)
+	if req.Address == "" {
+		return Node{}, ErrManualPeerAddress
+	}
+	peer := ManualPeer{
+		Address:      req.Address,
+		OperatorQuid: req.OperatorQuid,
+		AllowPrivate: req.AllowPrivate,
+		AddedAt:      time.Now().Unix(),
+	}
+	n, err := node.connectManualPeer(ctx, peer)
+	if err != nil {
+		return Node{}, err
+	}
+	peer.NodeQuid = n.ID
+	if node.manualPeers != nil {
+		if err := node.manualPeers.put(peer); err != nil {
+			return n, fmt.Errorf("peer connected but not saved: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// RemoveManualPeer verifies req and forgets the manual peer it
+// names, dropping it from KnownNodes unless another source has
+// since claimed the entry.
+func (node *QuidnugNode) RemoveManualPeer(req ManualPeerRemoveRequest) (ManualPeer, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return ManualPeer{}, err
+	}
+	if req.Address == "" && req.NodeQuid == "" {
+		return ManualPeer{}, errors.New("address or nodeQuid is required")
+	}
+	if node.manualPeers == nil {
+		return ManualPeer{}, ErrManualPeerNotFound
+	}
+	peer, err := node.manualPeers.remove(req.Address, req.NodeQuid)
+	if errors.Is(err, ErrManualPeerNotFound) {
+		return ManualPeer{}, err
+	}
+	if peer.NodeQuid != "" {
+		node.KnownNodesMutex.Lock()
+		if cur, ok := node.KnownNodes[peer.NodeQuid]; ok && cur.ConnectionStatus == connectionStatusManual {
+			delete(node.KnownNodes, peer.NodeQuid)
+		}
+		node.KnownNodesMutex.Unlock()
+	}
+	if peer.AllowPrivate && node.PrivateAddrAllowList != nil {
+		tokens := currentAllowList(node)
+		kept := tokens[:0]
+		for _, t := range tokens {
+			if t != peer.Address {
+				kept = append(kept, t)
+			}
+		}
+		node.PrivateAddrAllowList.Set(kept)
+	}
+	logger.Info("Removed manual peer", "address", peer.Address, "nodeQuid", peer.NodeQuid)
+	if err != nil {
+		return peer, fmt.Errorf("peer removed but list not saved: %w", err)
+	}
+	return peer, nil
+}
+
+// connectManualPeer admits the peer, fetches its domains and adds
+// it to KnownNodes.
+func (node *QuidnugNode) connectManualPeer(ctx context.Context, peer ManualPeer) (Node, error) {
+	verdict, err := node.AdmitPeer(ctx, PeerCandidate{
+		Address:      peer.Address,
+		NodeQuid:     peer.NodeQuid,
+		OperatorQuid: peer.OperatorQuid,
+		Source:       PeerSourceManual,
+		AllowPrivate: peer.AllowPrivate,
+	}, node.PeerAdmit)
+	if err != nil {
+		return Node{}, err
+	}
+
+	// With every admit gate off AdmitPeer skips the handshake,
+	// but a manual peer is only useful once we know its quid.
+	caps := verdict.Capabilities
+	nodeQuid := verdict.NodeQuid
+	if !node.PeerAdmit.gatesActive() {
+		timeout := node.PeerAdmit.HandshakeTimeout
+		if timeout == 0 {
+			timeout = 5 * time.Second
+		}
+		hsCtx, cancel := context.WithTimeout(ctx, timeout)
+		info, err := node.peerInfoHandshake(hsCtx, peer.Address)
+		cancel()
+		if err != nil {
+			node.recordPeerScore(peer.NodeQuid, EventClassHandshake, false, fmt.Sprintf("handshake to %s: %v", peer.Address, err))
+			return Node{}, fmt.Errorf("admit %s: handshake %s: %w", PeerSourceManual, peer.Address, err)
+		}
+		if nodeQuid != "" && info.NodeQuid != nodeQuid {
+			return Node{}, fmt.Errorf("admit %s: handshake %s: NodeQuid mismatch (expected %s, served %s)",
+				PeerSourceManual, peer.Address, nodeQuid, info.NodeQuid)
+		}
+		if peer.OperatorQuid != "" && info.OperatorQuid != "" && info.OperatorQuid != peer.OperatorQuid {
+			return Node{}, fmt.Errorf("admit %s: handshake %s: OperatorQuid mismatch (pinned %s, served %s)",
+				PeerSourceManual, peer.Address, peer.OperatorQuid, info.OperatorQuid)
+		}
+		if !info.Capabilities.protocolCompatible() {
+			min, max := info.Capabilities.protocolWindow()
+			return Node{}, fmt.Errorf("admit %s: handshake %s: protocol versions %d-%d do not overlap ours %d-%d",
+				PeerSourceManual, peer.Address, min, max, MinProtocolVersion, ProtocolVersion)
+		}
+		node.recordPeerScore(info.NodeQuid, EventClassHandshake, true, "")
+		nodeQuid = info.NodeQuid
+		caps = info.Capabilities
+	}
+	if nodeQuid == "" {
+		return Node{}, fmt.Errorf("admit %s: %s did not report a node quid", PeerSourceManual, peer.Address)
+	}
+	if nodeQuid == node.NodeID {
+		return Node{}, fmt.Errorf("admit %s: %s is this node", PeerSourceManual, peer.Address)
+	}
+	if node.peerBanned(nodeQuid) {
+		return Node{}, fmt.Errorf("admit %s: %s: peer %s is banned", PeerSourceManual, peer.Address, nodeQuid)
+	}
+
+	domains, err := node.fetchNodeDomains(ctx, peer.Address)
+	if err != nil {
+		logger.Warn("Failed to fetch domains from manual peer",
+			"nodeQuid", nodeQuid, "address", peer.Address, "error", err)
+	}
+	n := Node{
+		ID:               nodeQuid,
+		Address:          peer.Address,
+		TrustDomains:     domains,
+		LastSeen:         time.Now().Unix(),
+		ConnectionStatus: connectionStatusManual,
+		PeerCapabilities: caps,
+	}
+	node.KnownNodesMutex.Lock()
+	node.KnownNodes[nodeQuid] = n
+	node.KnownNodesMutex.Unlock()
+	node.updateDomainRegistry(nodeQuid, domains)
+
+	logger.Info("Added manual peer",
+		"nodeQuid", nodeQuid, "operatorQuid", verdict.OperatorQuid,
+		"address", peer.Address, "domains", domains)
+	return n, nil
+}
+
+// restoreManualPeers reconnects the saved manual peers at boot.
+func (node *QuidnugNode) restoreManualPeers(ctx context.Context) {
+	for _, peer := range node.ManualPeers() {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := node.connectManualPeer(ctx, peer); err != nil {
+			logger.Warn("Manual peer reconnect failed; will retry next boot",
+				"address", peer.Address, "error", err)
+		}
+	}
+}
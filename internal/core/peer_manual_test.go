@@ -0,0 +1,161 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func manualPeerServer(t *testing.T, nodeQuid string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]any
+		switch r.URL.Path {
+		case "/api/v1/info":
+			data = map[string]any{"nodeQuid": nodeQuid}
+		case "/api/v1/node/domains":
+			data = map[string]any{"nodeId": nodeQuid, "domains": []string{"manual.example"}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func signManualPeerAdd(t *testing.T, node *QuidnugNode, req ManualPeerAddRequest) ManualPeerAddRequest {
+	t.Helper()
+	req.Timestamp = time.Now().Unix()
+	req.PublicKey = node.GetPublicKeyHex()
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+func signManualPeerRemove(t *testing.T, node *QuidnugNode, req ManualPeerRemoveRequest) ManualPeerRemoveRequest {
+	t.Helper()
+	req.Timestamp = time.Now().Unix()
+	req.PublicKey = node.GetPublicKeyHex()
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	return req
+}
+
+func TestManualPeer_AddPersistRemove(t *testing.T) {
+	dir := t.TempDir()
+	node := newTestNode()
+	store, err := newManualPeerStore(&config.Config{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.manualPeers = store
+	srv := manualPeerServer(t, "manualpeer000001")
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	// The add runs the handshake even with every admit gate off.
+	n, err := node.AddManualPeer(t.Context(), signManualPeerAdd(t, node, ManualPeerAddRequest{Address: addr}))
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if n.ID != "manualpeer000001" || n.ConnectionStatus != connectionStatusManual {
+		t.Fatalf("entry = %+v", n)
+	}
+	node.KnownNodesMutex.RLock()
+	known := node.KnownNodes["manualpeer000001"]
+	node.KnownNodesMutex.RUnlock()
+	if len(known.TrustDomains) != 1 || known.TrustDomains[0] != "manual.example" {
+		t.Errorf("domains = %v", known.TrustDomains)
+	}
+
+	reloaded, err := newManualPeerStore(&config.Config{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peers := reloaded.list(); len(peers) != 1 || peers[0].NodeQuid != "manualpeer000001" || peers[0].Address != addr {
+		t.Fatalf("persisted = %+v", peers)
+	}
+
+	removed, err := node.RemoveManualPeer(signManualPeerRemove(t, node, ManualPeerRemoveRequest{NodeQuid: "manualpeer000001"}))
+	if err != nil || removed.Address != addr {
+		t.Fatalf("remove: %+v, %v", removed, err)
+	}
+	node.KnownNodesMutex.RLock()
+	_, still := node.KnownNodes["manualpeer000001"]
+	node.KnownNodesMutex.RUnlock()
+	if still {
+		t.Error("removed peer still in KnownNodes")
+	}
+	if _, err := node.RemoveManualPeer(signManualPeerRemove(t, node, ManualPeerRemoveRequest{Address: addr})); !errors.Is(err, ErrManualPeerNotFound) {
+		t.Errorf("second remove err = %v", err)
+	}
+}
+
+func TestManualPeer_RejectsUnsignedAndUnreachable(t *testing.T) {
+	node := newTestNode()
+	node.manualPeers, _ = newManualPeerStore(nil)
+
+	req := signManualPeerAdd(t, node, ManualPeerAddRequest{Address: "127.0.0.1:1"})
+	tampered := req
+	tampered.AllowPrivate = true
+	if _, err := node.AddManualPeer(t.Context(), tampered); !errors.Is(err, ErrAdminSignature) {
+		t.Errorf("tampered err = %v", err)
+	}
+	if _, err := node.AddManualPeer(t.Context(), req); err == nil {
+		t.Error("unreachable peer was added")
+	}
+	if len(node.ManualPeers()) != 0 {
+		t.Errorf("failed add was recorded: %v", node.ManualPeers())
+	}
+}
+
+func TestManualPeer_PinnedAgainstGossip(t *testing.T) {
+	node := newTestNode()
+	node.manualPeers, _ = newManualPeerStore(nil)
+	srv := manualPeerServer(t, "manualpeer000002")
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	if _, err := node.AddManualPeer(t.Context(), signManualPeerAdd(t, node, ManualPeerAddRequest{Address: addr})); err != nil {
+		t.Fatal(err)
+	}
+
+	node.PeerScoreboard = NewPeerScoreboard(DefaultPeerScoreWeights(), "", 0)
+	for i := 0; i < 30; i++ {
+		node.PeerScoreboard.Record("manualpeer000002", EventClassValidation, false, "")
+	}
+	node.PeerScoreboard.MarkBelowEviction("manualpeer000002", true)
+	node.evaluatePeerScores(0, 0.99, 0, false)
+
+	node.KnownNodesMutex.RLock()
+	n, ok := node.KnownNodes["manualpeer000002"]
+	node.KnownNodesMutex.RUnlock()
+	if !ok || n.ConnectionStatus != connectionStatusManual {
+		t.Errorf("manual peer evicted: %+v, %v", n, ok)
+	}
+}
+
+func TestAddManualPeerHandler_Forbidden(t *testing.T) {
+	node := newTestNode()
+	body, _ := json.Marshal(ManualPeerAddRequest{Address: "peer.example:8080", Timestamp: time.Now().Unix(), PublicKey: "00"})
+	rr := httptest.NewRecorder()
+	node.AddManualPeerHandler(rr, httptest.NewRequest("POST", "/api/v1/admin/peers", strings.NewReader(string(body))))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+}
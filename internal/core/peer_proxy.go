@@ -0,0 +1,97 @@
+// Package core — peer_proxy.go
+//
+// Outbound proxy support for node-to-node traffic.
+//
+// With peer_proxy_url set, node.httpClient sends every peer request
+// through that proxy: an HTTP(S) proxy via CONNECT, or a SOCKS5
+// proxy such as a local Tor daemon. This lets nodes behind egress
+// proxies participate, and lets privacy-sensitive operators keep
+// their address out of peers' logs.
+//
+// The proxy changes what the dial filter in safedial.go sees: the
+// node dials the proxy, not the peer. The proxy's own host:port is
+// put on the private allow-list, since it usually lives on loopback
+// or the LAN. Peer addresses that are IP literals are still checked
+// against the blocked ranges before a request is handed to the
+// proxy; hostnames go to the proxy unresolved, so a SOCKS proxy does
+// the DNS lookup and none leaks from the node. Filtering what those
+// names resolve to is then up to the proxy.
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// parsePeerProxyURL validates a peer_proxy_url value.
+func parsePeerProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported peer proxy scheme %q (want http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("peer proxy URL %q has no host", raw)
+	}
+	return u, nil
+}
+
+// peerProxyAddr returns the host:port the transport dials to reach
+// the proxy, filling in the scheme's default port.
+func peerProxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "1080"
+	switch u.Scheme {
+	case "http":
+		port = "80"
+	case "https":
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// peerProxyFunc returns an http.Transport Proxy func that sends
+// every request through proxy after refusing targets the dial
+// filter would have refused without it.
+func peerProxyFunc(proxy *url.URL, allow *PrivateAddrAllowList) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Hostname()
+		if allow.Has(req.URL.Host) {
+			return proxy, nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			if isBlockedIP(ip) {
+				return nil, fmt.Errorf("safedial: refused address %s (blocked range)", ip)
+			}
+		} else if !allowPrivatePeers() && (strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost")) {
+			return nil, fmt.Errorf("safedial: refused host %q (loopback name)", host)
+		}
+		return proxy, nil
+	}
+}
+
+// usePeerProxy routes the node's peer client through the proxy at
+// raw. It must run before anything wraps the client's transport.
+func (node *QuidnugNode) usePeerProxy(raw string) error {
+	proxy, err := parsePeerProxyURL(raw)
+	if err != nil {
+		return err
+	}
+	transport, ok := node.httpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("peer proxy: unexpected transport %T", node.httpClient.Transport)
+	}
+	node.PrivateAddrAllowList.Set(append(currentAllowList(node), peerProxyAddr(proxy)))
+	transport.Proxy = peerProxyFunc(proxy, node.PrivateAddrAllowList)
+	logger.Info("Routing peer traffic through proxy", "scheme", proxy.Scheme, "proxy", proxy.Redacted())
+	return nil
+}
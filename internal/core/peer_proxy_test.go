@@ -0,0 +1,57 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePeerProxyURL(t *testing.T) {
+	for raw, wantAddr := range map[string]string{
+		"socks5h://127.0.0.1:9050": "127.0.0.1:9050",
+		"socks5://proxy.internal":  "proxy.internal:1080",
+		"http://proxy.internal":    "proxy.internal:80",
+		"https://user:pw@[::1]":    "[::1]:443",
+	} {
+		u, err := parsePeerProxyURL(raw)
+		if err != nil {
+			t.Errorf("parsePeerProxyURL(%q): %v", raw, err)
+			continue
+		}
+		if got := peerProxyAddr(u); got != wantAddr {
+			t.Errorf("peerProxyAddr(%q) = %q, want %q", raw, got, wantAddr)
+		}
+	}
+	for _, raw := range []string{"ftp://proxy:21", "socks5://", "127.0.0.1:9050"} {
+		if _, err := parsePeerProxyURL(raw); err == nil {
+			t.Errorf("parsePeerProxyURL(%q) accepted", raw)
+		}
+	}
+}
+
+func TestUsePeerProxy_RoutesPeerRequests(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	node := newTestNode()
+	if err := node.usePeerProxy(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !node.PrivateAddrAllowList.Has(strings.TrimPrefix(proxy.URL, "http://")) {
+		t.Error("proxy address not on the private allow-list")
+	}
+
+	resp, err := node.httpClient.Get("http://peer.example:8080/api/v1/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(proxied) != 1 || proxied[0] != "http://peer.example:8080/api/v1/health" {
+		t.Errorf("proxy saw %v", proxied)
+	}
+}
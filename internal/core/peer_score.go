@@ -124,6 +124,18 @@ type PeerScore struct {
 	SignatureFails int `json:"signatureFails"`
 	AdRevocations  int `json:"adRevocations"`
 
+	// Offenses decays like the class counters (only Failures is
+	// used); the per-kind totals are cumulative. See peer_ban.go.
+	Offenses          EventCounter `json:"offenses"`
+	InvalidBlocks     int          `json:"invalidBlocks"`
+	MalformedTxs      int          `json:"malformedTxs"`
+	ExcessiveRequests int          `json:"excessiveRequests"`
+
+	// BannedUntil is when the peer's current ban lapses; zero
+	// or past means not banned.
+	BannedUntil time.Time `json:"bannedUntil,omitempty"`
+	BanReason   string    `json:"banReason,omitempty"`
+
 	// Bounded ring buffer of recent events. Used by the
 	// /api/v1/peers/{nodeQuid} endpoint and by `quidnug-cli
 	// peer show` for diagnostics.
@@ -160,6 +172,10 @@ type PeerScoreWeights struct {
 	ForkClaim     float64
 	SignatureFail float64
 	AdRevocation  float64
+
+	// Offense is subtracted per decayed offense (invalid block,
+	// malformed transaction, rate-limited request).
+	Offense float64
 }
 
 // DefaultPeerScoreWeights returns the audit-document defaults.
@@ -176,6 +192,8 @@ func DefaultPeerScoreWeights() PeerScoreWeights {
 		ForkClaim:     0.20,
 		SignatureFail: 0.10,
 		AdRevocation:  0.30,
+
+		Offense: 0.02,
 	}
 }
 
@@ -204,6 +222,13 @@ type PeerScoreSnapshot struct {
 	SignatureFails int `json:"signatureFails"`
 	AdRevocations  int `json:"adRevocations"`
 
+	Offenses          EventCounter `json:"offenses"`
+	InvalidBlocks     int          `json:"invalidBlocks"`
+	MalformedTxs      int          `json:"malformedTxs"`
+	ExcessiveRequests int          `json:"excessiveRequests"`
+	BannedUntil       time.Time    `json:"bannedUntil,omitempty"`
+	BanReason         string       `json:"banReason,omitempty"`
+
 	Quarantined        bool      `json:"quarantined"`
 	QuarantinedAt      time.Time `json:"quarantinedAt,omitempty"`
 	QuarantineReason   string    `json:"quarantineReason,omitempty"`
@@ -310,6 +335,14 @@ func (s *PeerScore) compositeLocked(w PeerScoreWeights) float64 {
 	severe := w.ForkClaim*float64(s.ForkClaims) +
 		w.SignatureFail*float64(s.SignatureFails) +
 		w.AdRevocation*float64(s.AdRevocations)
+	if !s.Offenses.LastTick.IsZero() {
+		hl := s.Offenses.HalfLife
+		if hl <= 0 {
+			hl = DefaultEventHalfLife
+		}
+		factor := math.Exp2(-float64(now.Sub(s.Offenses.LastTick)) / float64(hl))
+		severe += w.Offense * s.Offenses.Failures * factor
+	}
 	c := weighted - severe
 	if c < 0 {
 		c = 0
@@ -336,6 +369,12 @@ func (s *PeerScore) snapshotLocked(w PeerScoreWeights) PeerScoreSnapshot {
 		ForkClaims:         s.ForkClaims,
 		SignatureFails:     s.SignatureFails,
 		AdRevocations:      s.AdRevocations,
+		Offenses:           s.Offenses,
+		InvalidBlocks:      s.InvalidBlocks,
+		MalformedTxs:       s.MalformedTxs,
+		ExcessiveRequests:  s.ExcessiveRequests,
+		BannedUntil:        s.BannedUntil,
+		BanReason:          s.BanReason,
 		Quarantined:        s.Quarantined,
 		QuarantinedAt:      s.QuarantinedAt,
 		QuarantineReason:   s.QuarantineReason,
@@ -762,6 +801,12 @@ func (sb *PeerScoreboard) LoadFrom(path string) error {
 			ForkClaims:         s.ForkClaims,
 			SignatureFails:     s.SignatureFails,
 			AdRevocations:      s.AdRevocations,
+			Offenses:           s.Offenses,
+			InvalidBlocks:      s.InvalidBlocks,
+			MalformedTxs:       s.MalformedTxs,
+			ExcessiveRequests:  s.ExcessiveRequests,
+			BannedUntil:        s.BannedUntil,
+			BanReason:          s.BanReason,
 			Quarantined:        s.Quarantined,
 			QuarantinedAt:      s.QuarantinedAt,
 			QuarantineReason:   s.QuarantineReason,
@@ -0,0 +1,215 @@
+// Package core — peer_store.go
+//
+// Persistent peer table.
+//
+// KnownNodes used to start empty on every boot, so a restarted node
+// knew nobody until discovery had walked the seeds again, and a node
+// whose seeds were down stayed alone. The table is now snapshotted
+// to data_dir/known_nodes.json alongside the chain (same ticker,
+// same shutdown flush), each entry carrying its roles, last-seen
+// time and composite score at save time.
+//
+// At boot the snapshot goes back into KnownNodes, minus peers not
+// seen for persistedPeerMaxAge, banned peers and this node itself,
+// and the peers are then re-admitted and re-contacted healthiest
+// first: the live scoreboard's composite (peer_scores.json) when
+// there is one, else the saved one. A peer that fails admission
+// under the current policy is dropped; one that is merely down is
+// kept and scored, so the eviction loop decides its fate. Static
+// and manual peers are not part of the snapshot; peers_file and
+// manual_peers.json restore those.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// persistedPeerMaxAge is how long since a peer was last seen before
+// a saved entry is no longer worth restoring.
+const persistedPeerMaxAge = 7 * 24 * time.Hour
+
+// persistedPeer is one saved KnownNodes entry.
+type persistedPeer struct {
+	Node
+	Composite float64 `json:"composite"`
+}
+
+// knownNodesSnapshot is the on-disk shape for KnownNodes.
+type knownNodesSnapshot struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	SavedAt       int64           `json:"savedAt"`
+	Nodes         []persistedPeer `json:"nodes"`
+}
+
+// SaveKnownNodes snapshots the discovered peers to
+// data_dir/known_nodes.json.
+func (node *QuidnugNode) SaveKnownNodes(dataDir string) error {
+	if dataDir == "" {
+		return nil
+	}
+	node.KnownNodesMutex.RLock()
+	peers := make([]persistedPeer, 0, len(node.KnownNodes))
+	for _, n := range node.KnownNodes {
+		if n.ID == node.NodeID || n.Address == "" || isPinnedPeer(n.ConnectionStatus) {
+			continue
+		}
+		peers = append(peers, persistedPeer{Node: n})
+	}
+	node.KnownNodesMutex.RUnlock()
+	for i := range peers {
+		peers[i].Composite = node.peerComposite(peers[i].ID, 0.5)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+
+	snap := knownNodesSnapshot{
+		SchemaVersion: stateSchemaVersion,
+		SavedAt:       time.Now().UnixNano(),
+		Nodes:         peers,
+	}
+	raw, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal known nodes: %w", err)
+	}
+	raw = append(raw, '\n')
+	return safeio.WriteFileMode(filepath.Join(dataDir, "known_nodes.json"), raw, 0o600)
+}
+
+// LoadKnownNodes reads data_dir/known_nodes.json back into
+// KnownNodes without replacing entries already there, and returns
+// the restored peers healthiest first for reconnectPersistedPeers.
+// Missing file is silent.
+func (node *QuidnugNode) LoadKnownNodes(dataDir string) ([]Node, error) {
+	if dataDir == "" {
+		return nil, nil
+	}
+	path := filepath.Join(dataDir, "known_nodes.json")
+	raw, err := safeio.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	var snap knownNodesSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+	if snap.SchemaVersion != stateSchemaVersion {
+		return nil, fmt.Errorf("known_nodes schema %d not supported", snap.SchemaVersion)
+	}
+
+	cutoff := time.Now().Add(-persistedPeerMaxAge).Unix()
+	restored := make([]persistedPeer, 0, len(snap.Nodes))
+	dropped := 0
+	node.KnownNodesMutex.Lock()
+	for _, p := range snap.Nodes {
+		if p.ID == "" || p.ID == node.NodeID || p.Address == "" || p.LastSeen < cutoff || node.peerBanned(p.ID) {
+			dropped++
+			continue
+		}
+		if _, exists := node.KnownNodes[p.ID]; exists {
+			continue
+		}
+		node.KnownNodes[p.ID] = p.Node
+		restored = append(restored, p)
+	}
+	node.KnownNodesMutex.Unlock()
+	for _, p := range restored {
+		node.updateDomainRegistry(p.ID, p.TrustDomains)
+	}
+
+	for i := range restored {
+		restored[i].Composite = node.peerComposite(restored[i].ID, restored[i].Composite)
+	}
+	sort.SliceStable(restored, func(i, j int) bool {
+		if restored[i].Composite != restored[j].Composite {
+			return restored[i].Composite > restored[j].Composite
+		}
+		return restored[i].LastSeen > restored[j].LastSeen
+	})
+	nodes := make([]Node, len(restored))
+	for i, p := range restored {
+		nodes[i] = p.Node
+	}
+	if logger != nil {
+		logger.Info("Loaded persisted peers",
+			"path", path, "peers", len(nodes), "dropped", dropped)
+	}
+	return nodes, nil
+}
+
+// peerComposite is the scoreboard's composite for a peer it has a
+// record of, else fallback.
+func (node *QuidnugNode) peerComposite(nodeQuid string, fallback float64) float64 {
+	if node.PeerScoreboard == nil || node.PeerScoreboard.lookup(nodeQuid) == nil {
+		return fallback
+	}
+	return node.PeerScoreboard.Composite(nodeQuid)
+}
+
+// reconnectPersistedPeers contacts the restored peers in order,
+// refreshing their domains and re-running admission. Peers the
+// admit pipeline now refuses are removed; unreachable ones stay,
+// with the failed query scored.
+func (node *QuidnugNode) reconnectPersistedPeers(ctx context.Context, peers []Node) {
+	reachable := 0
+	for _, p := range peers {
+		if ctx.Err() != nil {
+			return
+		}
+		source, allowPrivate := PeerSourceGossip, false
+		if p.ConnectionStatus == string(PeerSourceLAN) {
+			source, allowPrivate = PeerSourceLAN, true
+			if node.PrivateAddrAllowList != nil {
+				node.PrivateAddrAllowList.Set(append(currentAllowList(node), p.Address))
+			}
+		}
+
+		// Reachability first, so a peer that is only down isn't
+		// mistaken for one that fails admission.
+		domains, err := node.fetchNodeDomains(ctx, p.Address)
+		if err != nil {
+			node.recordPeerScore(p.ID, EventClassQuery, false, fmt.Sprintf("reconnect to %s: %v", p.Address, err))
+			logger.Debug("Persisted peer unreachable",
+				"nodeQuid", p.ID, "address", p.Address, "error", err)
+			continue
+		}
+		node.recordPeerScore(p.ID, EventClassQuery, true, "")
+
+		verdict, err := node.AdmitPeer(ctx, PeerCandidate{
+			Address:      p.Address,
+			NodeQuid:     p.ID,
+			Source:       source,
+			AllowPrivate: allowPrivate,
+		}, node.PeerAdmit)
+		node.KnownNodesMutex.Lock()
+		cur, ok := node.KnownNodes[p.ID]
+		switch {
+		case !ok || isPinnedPeer(cur.ConnectionStatus):
+		case err != nil:
+			delete(node.KnownNodes, p.ID)
+		default:
+			cur.TrustDomains = domains
+			cur.LastSeen = time.Now().Unix()
+			if len(verdict.Capabilities.Roles) > 0 {
+				cur.PeerCapabilities = verdict.Capabilities
+			}
+			node.KnownNodes[p.ID] = cur
+		}
+		node.KnownNodesMutex.Unlock()
+		if err != nil {
+			logger.Info("Dropped persisted peer that no longer passes admission",
+				"nodeQuid", p.ID, "address", p.Address, "error", err)
+			continue
+		}
+		reachable++
+		node.updateDomainRegistry(p.ID, domains)
+	}
+	if len(peers) > 0 {
+		logger.Info("Reconnected persisted peers", "peers", len(peers), "reachable", reachable)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKnownNodes_SaveLoadHealthiestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Unix()
+	src := newTestNode()
+	src.PeerScoreboard = NewPeerScoreboard(DefaultPeerScoreWeights(), "", 0)
+	src.KnownNodesMutex.Lock()
+	src.KnownNodes["weak"] = Node{ID: "weak", Address: "203.0.113.1:8080", LastSeen: now, ConnectionStatus: "gossip"}
+	src.KnownNodes["strong"] = Node{ID: "strong", Address: "203.0.113.2:8080", LastSeen: now, ConnectionStatus: "gossip",
+		PeerCapabilities: PeerCapabilities{Roles: []string{"validator"}}}
+	src.KnownNodes["stale"] = Node{ID: "stale", Address: "203.0.113.3:8080", LastSeen: now - int64(8*24*3600), ConnectionStatus: "gossip"}
+	src.KnownNodes["pinned"] = Node{ID: "pinned", Address: "203.0.113.4:8080", LastSeen: now, ConnectionStatus: "static"}
+	src.KnownNodes["noaddr"] = Node{ID: "noaddr", LastSeen: now, ConnectionStatus: "discovered-via-gossip"}
+	src.KnownNodesMutex.Unlock()
+	for i := 0; i < 10; i++ {
+		src.PeerScoreboard.Record("weak", EventClassQuery, false, "")
+		src.PeerScoreboard.Record("strong", EventClassQuery, true, "")
+	}
+	if err := src.SaveKnownNodes(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestNode()
+	peers, err := dst.LoadKnownNodes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range peers {
+		ids = append(ids, p.ID)
+	}
+	if strings.Join(ids, ",") != "strong,weak" {
+		t.Fatalf("restored = %v, want strong,weak", ids)
+	}
+	dst.KnownNodesMutex.RLock()
+	strong := dst.KnownNodes["strong"]
+	_, pinned := dst.KnownNodes["pinned"]
+	dst.KnownNodesMutex.RUnlock()
+	if len(strong.Roles) != 1 || strong.LastSeen != now {
+		t.Errorf("strong = %+v", strong)
+	}
+	if pinned {
+		t.Error("static peer was snapshotted")
+	}
+}
+
+func TestReconnectPersistedPeers(t *testing.T) {
+	node := newTestNode()
+	srv := manualPeerServer(t, "livepeer00000001")
+	live := strings.TrimPrefix(srv.URL, "http://")
+	node.KnownNodesMutex.Lock()
+	node.KnownNodes["livepeer00000001"] = Node{ID: "livepeer00000001", Address: live, ConnectionStatus: "gossip"}
+	node.KnownNodes["downpeer00000001"] = Node{ID: "downpeer00000001", Address: "127.0.0.1:1", ConnectionStatus: "gossip"}
+	node.KnownNodesMutex.Unlock()
+
+	node.reconnectPersistedPeers(t.Context(), []Node{
+		{ID: "livepeer00000001", Address: live, ConnectionStatus: "gossip"},
+		{ID: "downpeer00000001", Address: "127.0.0.1:1", ConnectionStatus: "gossip"},
+	})
+	node.KnownNodesMutex.RLock()
+	livePeer := node.KnownNodes["livepeer00000001"]
+	_, downKept := node.KnownNodes["downpeer00000001"]
+	node.KnownNodesMutex.RUnlock()
+	if len(livePeer.TrustDomains) != 1 || livePeer.LastSeen == 0 {
+		t.Errorf("live peer not refreshed: %+v", livePeer)
+	}
+	if !downKept {
+		t.Error("unreachable peer dropped")
+	}
+
+	// Under a stricter policy the reachable peer no longer passes.
+	node.PeerAdmit.RequireAdvertisement = true
+	node.reconnectPersistedPeers(t.Context(), []Node{{ID: "livepeer00000001", Address: live, ConnectionStatus: "gossip"}})
+	node.KnownNodesMutex.RLock()
+	_, liveKept := node.KnownNodes["livepeer00000001"]
+	node.KnownNodesMutex.RUnlock()
+	if liveKept {
+		t.Error("peer failing admission was kept")
+	}
+}
@@ -150,6 +150,7 @@ func (node *QuidnugNode) applyStaticPeers(
 			Address:          e.Address,
 			LastSeen:         time.Now().Unix(),
 			ConnectionStatus: "static",
+			PeerCapabilities: v.Capabilities,
 		}
 		node.KnownNodesMutex.Unlock()
 		logger.Info("Admitted static peer",
@@ -288,8 +289,8 @@ func (node *QuidnugNode) runLANPeerLoop(
 				continue
 			}
 			node.KnownNodesMutex.Lock()
-			// Don't clobber a static-source entry.
-			if existing, ok := node.KnownNodes[verdict.NodeQuid]; ok && existing.ConnectionStatus == "static" {
+			// Don't clobber a static or manual entry.
+			if existing, ok := node.KnownNodes[verdict.NodeQuid]; ok && isPinnedPeer(existing.ConnectionStatus) {
 				node.KnownNodesMutex.Unlock()
 				continue
 			}
@@ -298,6 +299,7 @@ func (node *QuidnugNode) runLANPeerLoop(
 				Address:          peer.Address,
 				LastSeen:         time.Now().Unix(),
 				ConnectionStatus: "lan",
+				PeerCapabilities: verdict.Capabilities,
 			}
 			node.KnownNodesMutex.Unlock()
 			logger.Info("Admitted LAN peer",
@@ -15,7 +15,6 @@
 package core
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -738,10 +737,10 @@ func (node *QuidnugNode) validatePrivacyNonce(actorQuid string, txType Transacti
 
 // verifyPrivacyTxSignature is a shared signature-check helper.
 // Receives the tx by value, clears the Signature field on a
-// typed copy, and re-marshals the struct directly so key order
-// exactly matches what the signer produced. Using a map
-// round-trip here reorders keys alphabetically and silently
-// breaks verification.
+// typed copy, and encodes it per its schema version. Version 1
+// re-marshals the struct directly so key order exactly matches
+// what the signer produced; a map round-trip there reorders keys
+// alphabetically and silently breaks verification.
 func verifyPrivacyTxSignature(tx interface{}, sig, pubkey, txID, kind string) bool {
 	if sig == "" {
 		logger.Warn(kind+" missing signature", "txId", txID)
@@ -752,19 +751,19 @@ func verifyPrivacyTxSignature(tx interface{}, sig, pubkey, txID, kind string) bo
 	switch v := tx.(type) {
 	case DataSubjectRequestTransaction:
 		v.Signature = ""
-		signable, err = json.Marshal(v)
+		signable, err = txSignableBytes(v)
 	case ConsentGrantTransaction:
 		v.Signature = ""
-		signable, err = json.Marshal(v)
+		signable, err = txSignableBytes(v)
 	case ConsentWithdrawTransaction:
 		v.Signature = ""
-		signable, err = json.Marshal(v)
+		signable, err = txSignableBytes(v)
 	case ProcessingRestrictionTransaction:
 		v.Signature = ""
-		signable, err = json.Marshal(v)
+		signable, err = txSignableBytes(v)
 	case DSRComplianceTransaction:
 		v.Signature = ""
-		signable, err = json.Marshal(v)
+		signable, err = txSignableBytes(v)
 	default:
 		logger.Error(kind+" unknown tx type for signature verification",
 			"txId", txID, "type", fmt.Sprintf("%T", tx))
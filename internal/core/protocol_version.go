@@ -0,0 +1,157 @@
+// Package core — protocol_version.go
+//
+// Protocol version negotiation for blue/green upgrades.
+//
+// Each node speaks a window of protocol versions,
+// [MinProtocolVersion, ProtocolVersion], and reports it in the
+// /info handshake together with the fork features it knows. Peers
+// whose windows do not overlap are not admitted or synced from.
+//
+// Each domain runs at one version at a time. It starts at 1, and
+// moves to N when a FORK_BLOCK for the feature "protocol_vN"
+// activates at its ForkHeight (fork_block.go), so the switch is a
+// block height a validator quorum signed rather than a wall-clock
+// flag day. Until then, upgraded (green) nodes keep producing
+// blocks the old (blue) ones accept; MinForkNoticeBlocks gives the
+// remaining operators time to upgrade before the height.
+//
+// Blocks carry the version in their header. A version-1 block
+// leaves the field empty, so it hashes and signs exactly as it did
+// before the field existed.
+//
+// Version history:
+//
+//	1: original protocol.
+//	2: block headers commit to ProtocolVersion.
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ProtocolVersion is the newest protocol version this node
+	// speaks.
+	ProtocolVersion = 2
+
+	// MinProtocolVersion is the oldest protocol version this node
+	// still speaks.
+	MinProtocolVersion = 1
+
+	// protocolFeaturePrefix names the fork features that move a
+	// domain to a protocol version: "protocol_v2", "protocol_v3"...
+	protocolFeaturePrefix = "protocol_v"
+)
+
+func init() {
+	for v := MinProtocolVersion + 1; v <= ProtocolVersion; v++ {
+		ForkSupportedFeatures[protocolFeature(v)] = true
+	}
+}
+
+// protocolFeature returns the fork feature that activates version
+// v.
+func protocolFeature(v int) string {
+	return protocolFeaturePrefix + strconv.Itoa(v)
+}
+
+// protocolFeatureVersion parses a protocol fork feature name.
+func protocolFeatureVersion(feature string) (int, bool) {
+	if !strings.HasPrefix(feature, protocolFeaturePrefix) {
+		return 0, false
+	}
+	v, err := strconv.Atoi(strings.TrimPrefix(feature, protocolFeaturePrefix))
+	if err != nil || v < 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// blockProtocolVersion returns the version a block was produced
+// under; an empty header field means version 1.
+func blockProtocolVersion(block Block) int {
+	if block.ProtocolVersion < 1 {
+		return 1
+	}
+	return block.ProtocolVersion
+}
+
+// headerProtocolVersion is the header value for a block at version
+// v: empty for version 1.
+func headerProtocolVersion(v int) int {
+	if v <= 1 {
+		return 0
+	}
+	return v
+}
+
+// domainProtocolVersion returns the version the domain runs at:
+// the highest protocol fork activated for it, or 1.
+func (node *QuidnugNode) domainProtocolVersion(domain string) int {
+	version := 1
+	if node.forks == nil {
+		return version
+	}
+	node.forks.mu.RLock()
+	defer node.forks.mu.RUnlock()
+	for feature := range node.forks.active[domain] {
+		if v, ok := protocolFeatureVersion(feature); ok && v > version {
+			version = v
+		}
+	}
+	return version
+}
+
+// checkBlockProtocolVersion rejects a block whose version differs
+// from its domain's current one, or that this node cannot speak.
+func (node *QuidnugNode) checkBlockProtocolVersion(block Block) error {
+	got := blockProtocolVersion(block)
+	if got > ProtocolVersion {
+		return fmt.Errorf("block protocol version %d is newer than this node's %d; upgrade required", got, ProtocolVersion)
+	}
+	want := node.domainProtocolVersion(block.TrustProof.TrustDomain)
+	if got != want {
+		return fmt.Errorf("block protocol version %d, domain %s runs at %d", got, block.TrustProof.TrustDomain, want)
+	}
+	return nil
+}
+
+// SupportedFeatures lists the fork features this node can
+// activate, sorted.
+func SupportedFeatures() []string {
+	features := make([]string, 0, len(ForkSupportedFeatures))
+	for f, ok := range ForkSupportedFeatures {
+		if ok {
+			features = append(features, f)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// protocolWindow returns the version window a peer reported. A
+// peer that reported none predates negotiation and speaks 1.
+func (c PeerCapabilities) protocolWindow() (min, max int) {
+	max = c.ProtocolVersion
+	if max < 1 {
+		max = 1
+	}
+	min = c.MinProtocolVersion
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		min = max
+	}
+	return min, max
+}
+
+// protocolCompatible reports whether the peer's version window
+// overlaps this node's.
+func (c PeerCapabilities) protocolCompatible() bool {
+	min, max := c.protocolWindow()
+	return min <= ProtocolVersion && max >= MinProtocolVersion
+}
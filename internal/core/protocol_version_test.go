@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestProtocolVersion_DomainUpgrade(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	node.CheckpointInterval = -1
+
+	block, err := node.SyntheticTrustBlock("proto.local", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.ProtocolVersion != 0 {
+		t.Fatalf("version-1 block header = %d, want empty", block.ProtocolVersion)
+	}
+	if err := node.checkBlockProtocolVersion(*block); err != nil {
+		t.Fatal(err)
+	}
+
+	stamped := *block
+	stamped.ProtocolVersion = 2
+	if calculateBlockHash(stamped) == block.Hash {
+		t.Error("hash does not commit to the protocol version")
+	}
+	if err := node.checkBlockProtocolVersion(stamped); err == nil {
+		t.Error("version-2 block accepted before the domain upgraded")
+	}
+
+	node.forks.storePending(ForkBlock{TrustDomain: "proto.local", Feature: protocolFeature(2), ForkHeight: 1})
+	node.maybeActivateForks("proto.local", 1)
+	if v := node.domainProtocolVersion("proto.local"); v != 2 {
+		t.Fatalf("domain version after fork = %d", v)
+	}
+	if v := node.domainProtocolVersion("other.local"); v != 1 {
+		t.Errorf("unrelated domain moved to %d", v)
+	}
+	if err := node.checkBlockProtocolVersion(*block); err == nil {
+		t.Error("version-1 block accepted after the upgrade")
+	}
+	if err := node.checkBlockProtocolVersion(stamped); err != nil {
+		t.Error(err)
+	}
+
+	next, err := node.SyntheticTrustBlock("proto.local", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ProtocolVersion != 2 {
+		t.Errorf("block after upgrade stamped %d", next.ProtocolVersion)
+	}
+
+	stamped.ProtocolVersion = ProtocolVersion + 1
+	if err := node.checkBlockProtocolVersion(stamped); err == nil {
+		t.Error("block from a newer protocol accepted")
+	}
+}
+
+func TestPeerCapabilities_ProtocolCompatible(t *testing.T) {
+	cases := []struct {
+		name string
+		caps PeerCapabilities
+		want bool
+	}{
+		{"pre-negotiation peer", PeerCapabilities{}, true},
+		{"same window", PeerCapabilities{ProtocolVersion: ProtocolVersion, MinProtocolVersion: MinProtocolVersion}, true},
+		{"newer overlapping", PeerCapabilities{ProtocolVersion: ProtocolVersion + 1, MinProtocolVersion: ProtocolVersion}, true},
+		{"too new", PeerCapabilities{ProtocolVersion: ProtocolVersion + 2, MinProtocolVersion: ProtocolVersion + 1}, false},
+	}
+	for _, c := range cases {
+		if got := c.caps.protocolCompatible(); got != c.want {
+			t.Errorf("%s: compatible = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
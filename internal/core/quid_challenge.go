@@ -0,0 +1,170 @@
+// Challenge-response proof of quid control.
+//
+// A relying application that wants to know whether a user controls
+// a quid asks the node for a challenge, has the user sign it with
+// the quid's key, and hands the signature back to the node:
+//
+//	POST /quids/{quidId}/challenge  {"audience": "app.example"}
+//	  -> QuidChallenge
+//	POST /quids/{quidId}/verify     {"challengeId": ..., "signature": ...}
+//	  -> verified, or 401
+//
+// The signature covers the JSON encoding of the QuidChallenge
+// exactly as issued, the same sign-the-JSON convention transactions
+// use, so any quid SDK can answer a challenge. It is checked against
+// the quid's current key: the key of its latest anchor epoch when
+// the nonce ledger has one, otherwise the key on its IDENTITY
+// record. Challenges are single-use, expire after a few minutes and
+// live only in memory.
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultChallengeTTL is how long an issued challenge can be
+	// answered.
+	DefaultChallengeTTL = 5 * time.Minute
+
+	// DefaultMaxChallenges bounds outstanding challenges; issuing
+	// past it drops the oldest.
+	DefaultMaxChallenges = 10000
+)
+
+// Challenge errors.
+var (
+	ErrQuidKeyUnknown     = errors.New("challenge: quid has no registered public key")
+	ErrChallengeNotFound  = errors.New("challenge: unknown, expired or already used")
+	ErrChallengeSignature = errors.New("challenge: signature does not verify")
+)
+
+// QuidChallenge is what the quid's key signs. Audience is whatever
+// the relying application asked for, echoed so a signature for one
+// application cannot be replayed to another.
+type QuidChallenge struct {
+	ChallengeID string `json:"challengeId"`
+	QuidID      string `json:"quidId"`
+	Nonce       string `json:"nonce"`
+	Audience    string `json:"audience,omitempty"`
+	IssuedAt    int64  `json:"issuedAt"`
+	ExpiresAt   int64  `json:"expiresAt"`
+}
+
+// ChallengeStore holds outstanding challenges.
+type ChallengeStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	order   []string
+	pending map[string]QuidChallenge
+}
+
+// NewChallengeStore returns an empty store. Non-positive ttl or max
+// select the defaults.
+func NewChallengeStore(ttl time.Duration, max int) *ChallengeStore {
+	if ttl <= 0 {
+		ttl = DefaultChallengeTTL
+	}
+	if max <= 0 {
+		max = DefaultMaxChallenges
+	}
+	return &ChallengeStore{ttl: ttl, max: max, pending: make(map[string]QuidChallenge)}
+}
+
+// issue creates and records a challenge for quidID.
+func (s *ChallengeStore) issue(quidID, audience string) (QuidChallenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return QuidChallenge{}, err
+	}
+	now := time.Now()
+	c := QuidChallenge{
+		ChallengeID: uuid.New().String(),
+		QuidID:      quidID,
+		Nonce:       hex.EncodeToString(nonce),
+		Audience:    audience,
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(s.ttl).Unix(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// order is oldest first, so expired entries sit at its head.
+	for len(s.order) > 0 {
+		head, ok := s.pending[s.order[0]]
+		if ok && head.ExpiresAt > now.Unix() && len(s.order) < s.max {
+			break
+		}
+		delete(s.pending, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.pending[c.ChallengeID] = c
+	s.order = append(s.order, c.ChallengeID)
+	return c, nil
+}
+
+// take removes and returns an unexpired challenge. A challenge is
+// gone after one take, whether or not its answer verifies.
+func (s *ChallengeStore) take(id string) (QuidChallenge, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.pending[id]
+	if !ok {
+		return QuidChallenge{}, false
+	}
+	delete(s.pending, id)
+	return c, c.ExpiresAt > time.Now().Unix()
+}
+
+// quidPublicKey returns the key a quid currently signs with.
+func (node *QuidnugNode) quidPublicKey(quidID string) (string, bool) {
+	if node.NonceLedger != nil {
+		if key, ok := node.NonceLedger.GetSignerKey(quidID, node.NonceLedger.CurrentEpoch(quidID)); ok && key != "" {
+			return key, true
+		}
+	}
+	node.IdentityRegistryMutex.RLock()
+	identity, ok := node.IdentityRegistry[quidID]
+	node.IdentityRegistryMutex.RUnlock()
+	if !ok || identity.PublicKey == "" {
+		return "", false
+	}
+	return identity.PublicKey, true
+}
+
+// IssueQuidChallenge issues a challenge for a quid with a known key.
+func (node *QuidnugNode) IssueQuidChallenge(quidID, audience string) (QuidChallenge, error) {
+	if _, ok := node.quidPublicKey(quidID); !ok {
+		return QuidChallenge{}, ErrQuidKeyUnknown
+	}
+	return node.QuidChallenges.issue(quidID, audience)
+}
+
+// VerifyQuidChallenge consumes challengeID and checks that signature
+// is quidID's signature over it.
+func (node *QuidnugNode) VerifyQuidChallenge(quidID, challengeID, signature string) (QuidChallenge, error) {
+	c, ok := node.QuidChallenges.take(challengeID)
+	if !ok || c.QuidID != quidID {
+		return QuidChallenge{}, ErrChallengeNotFound
+	}
+	key, ok := node.quidPublicKey(quidID)
+	if !ok {
+		return QuidChallenge{}, ErrQuidKeyUnknown
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return QuidChallenge{}, err
+	}
+	if !VerifySignature(key, data, signature) {
+		return QuidChallenge{}, ErrChallengeSignature
+	}
+	return c, nil
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuidChallenge_RoundTrip(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewReader(raw)))
+		return w
+	}
+
+	w := post("/api/v1/quids/0000000000000001/challenge", map[string]string{"audience": "app.example"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("issue: status %d %s", w.Code, w.Body.String())
+	}
+	var issued struct {
+		Data QuidChallenge `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &issued)
+	c := issued.Data
+	if c.QuidID != "0000000000000001" || c.Audience != "app.example" || len(c.Nonce) != 64 {
+		t.Fatalf("issued %+v", c)
+	}
+
+	// The test identity is registered with the node's own key.
+	data, _ := json.Marshal(c)
+	sig, _ := node.SignData(data)
+	answer := map[string]string{"challengeId": c.ChallengeID, "signature": hex.EncodeToString(sig)}
+
+	if w := post("/api/v1/quids/0000000000000002/verify", answer); w.Code != http.StatusNotFound {
+		t.Fatalf("answer under another quid: status %d", w.Code)
+	}
+	// That attempt used the challenge up.
+	if w := post("/api/v1/quids/0000000000000001/verify", answer); w.Code != http.StatusNotFound {
+		t.Fatalf("reused challenge: status %d", w.Code)
+	}
+
+	c, _ = node.IssueQuidChallenge("0000000000000001", "")
+	data, _ = json.Marshal(c)
+	sig, _ = node.SignData(data)
+	bad := map[string]string{"challengeId": c.ChallengeID, "signature": hex.EncodeToString(sig[:63]) + "00"}
+	if w := post("/api/v1/quids/0000000000000001/verify", bad); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status %d", w.Code)
+	}
+
+	c, _ = node.IssueQuidChallenge("0000000000000001", "")
+	data, _ = json.Marshal(c)
+	sig, _ = node.SignData(data)
+	w = post("/api/v1/quids/0000000000000001/verify", map[string]string{"challengeId": c.ChallengeID, "signature": hex.EncodeToString(sig)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify: status %d %s", w.Code, w.Body.String())
+	}
+
+	if w := post("/api/v1/quids/00000000000000ff/challenge", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("challenge for unknown quid: status %d", w.Code)
+	}
+}
+
+func TestChallengeStore_ExpiryAndBound(t *testing.T) {
+	s := NewChallengeStore(time.Minute, 3)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		c, err := s.issue("q", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ChallengeID)
+	}
+	if len(s.pending) != 3 {
+		t.Fatalf("holding %d challenges, want 3", len(s.pending))
+	}
+	if _, ok := s.take(ids[0]); ok {
+		t.Fatal("oldest challenge survived eviction")
+	}
+	if _, ok := s.take(ids[4]); !ok {
+		t.Fatal("newest challenge missing")
+	}
+
+	s.mu.Lock()
+	c := s.pending[ids[3]]
+	c.ExpiresAt = time.Now().Unix() - 1
+	s.pending[ids[3]] = c
+	s.mu.Unlock()
+	if _, ok := s.take(ids[3]); ok {
+		t.Fatal("expired challenge accepted")
+	}
+}
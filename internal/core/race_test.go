@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -265,7 +266,7 @@ func TestConcurrentTrustComputation(t *testing.T) {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			trust, _, err := node.ComputeRelationalTrust(entities[0], entities[4], 5)
+			trust, _, err := node.ComputeRelationalTrust(context.Background(), entities[0], entities[4], 5)
 			results[idx] = trust
 			errors[idx] = err
 		}(i)
@@ -737,7 +738,7 @@ func TestConcurrentMixedOperations(t *testing.T) {
 			default:
 				observer := fmt.Sprintf("%016x", 1)
 				target := fmt.Sprintf("%016x", 10)
-				node.ComputeRelationalTrust(observer, target, 5)
+				node.ComputeRelationalTrust(context.Background(), observer, target, 5)
 			}
 		}
 	}()
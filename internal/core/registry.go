@@ -1,6 +1,8 @@
 package core
 
 import (
+	"container/list"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,20 +13,55 @@ import (
 // ErrTrustGraphTooLarge is returned when trust computation exceeds resource limits
 var ErrTrustGraphTooLarge = errors.New("trust graph too large: resource limits exceeded")
 
-// TrustCache provides thread-safe caching for trust computations
+// trustCacheAnyQuid is the dependency recorded for entries whose
+// explored subgraph is unknown (external-store and enhanced
+// results). Every edge update invalidates them.
+const trustCacheAnyQuid = "*"
+
+// trustCacheRef identifies one entry across both result maps.
+type trustCacheRef struct {
+	enhanced bool
+	key      string
+}
+
+// TrustCache provides thread-safe caching for trust computations.
+//
+// Entries expire after ttl and, when maxEntries is positive, the
+// least recently used entry is evicted once the combined basic and
+// enhanced count exceeds it. Each entry also records the quids
+// whose outbound edges the computation read, so an edge update
+// from truster X only drops results that actually looked at X.
 type TrustCache struct {
 	entries         map[string]TrustCacheEntry
 	enhancedEntries map[string]EnhancedTrustCacheEntry
 	mu              sync.RWMutex
 	ttl             time.Duration
+
+	maxEntries int
+	lru        *list.List // front is most recently used; values are trustCacheRef
+	lruIndex   map[trustCacheRef]*list.Element
+	deps       map[string]map[trustCacheRef]struct{} // quid -> entries that read its edges
+	entryDeps  map[trustCacheRef][]string
 }
 
 // NewTrustCache creates a new TrustCache with the specified TTL
+// and no entry bound.
 func NewTrustCache(ttl time.Duration) *TrustCache {
+	return NewBoundedTrustCache(ttl, 0)
+}
+
+// NewBoundedTrustCache creates a TrustCache that holds at most
+// maxEntries results (0 = unbounded), evicting least recently used.
+func NewBoundedTrustCache(ttl time.Duration, maxEntries int) *TrustCache {
 	return &TrustCache{
 		entries:         make(map[string]TrustCacheEntry),
 		enhancedEntries: make(map[string]EnhancedTrustCacheEntry),
 		ttl:             ttl,
+		maxEntries:      maxEntries,
+		lru:             list.New(),
+		lruIndex:        make(map[trustCacheRef]*list.Element),
+		deps:            make(map[string]map[trustCacheRef]struct{}),
+		entryDeps:       make(map[trustCacheRef][]string),
 	}
 }
 
@@ -36,21 +73,35 @@ func NewTrustCache(ttl time.Duration) *TrustCache {
 // its store-second boundary, and the cache was effectively useless
 // for short-lived testing (which is most of the cache tests).
 func (c *TrustCache) Get(key string) (float64, []string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	ref := trustCacheRef{key: key}
 	entry, exists := c.entries[key]
-	if !exists || time.Now().UnixNano() > entry.ExpiresAt {
+	if !exists {
+		return 0, nil, false
+	}
+	if time.Now().UnixNano() > entry.ExpiresAt {
+		c.removeLocked(ref)
 		return 0, nil, false
 	}
+	c.touchLocked(ref)
 	// Return a copy of the path to prevent mutation
 	pathCopy := make([]string, len(entry.TrustPath))
 	copy(pathCopy, entry.TrustPath)
 	return entry.TrustLevel, pathCopy, true
 }
 
-// Set stores a trust computation result in the cache
+// Set stores a trust computation result in the cache. The entry
+// is invalidated by any edge update; use SetWithDeps when the set
+// of quids the computation read is known.
 func (c *TrustCache) Set(key string, trustLevel float64, trustPath []string) {
+	c.SetWithDeps(key, trustLevel, trustPath, nil)
+}
+
+// SetWithDeps stores a result along with the quids whose outbound
+// edges were read to produce it. A nil deps slice means unknown.
+func (c *TrustCache) SetWithDeps(key string, trustLevel float64, trustPath []string, deps []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -58,23 +109,32 @@ func (c *TrustCache) Set(key string, trustLevel float64, trustPath []string) {
 	pathCopy := make([]string, len(trustPath))
 	copy(pathCopy, trustPath)
 
+	ref := trustCacheRef{key: key}
+	c.removeLocked(ref)
 	c.entries[key] = TrustCacheEntry{
 		TrustLevel: trustLevel,
 		TrustPath:  pathCopy,
 		ExpiresAt:  time.Now().Add(c.ttl).UnixNano(),
 	}
+	c.trackLocked(ref, deps)
 }
 
 // GetEnhanced retrieves a cached enhanced trust computation result.
 // See Get for the rationale behind UnixNano precision.
 func (c *TrustCache) GetEnhanced(key string) (EnhancedTrustResult, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	ref := trustCacheRef{enhanced: true, key: key}
 	entry, exists := c.enhancedEntries[key]
-	if !exists || time.Now().UnixNano() > entry.ExpiresAt {
+	if !exists {
+		return EnhancedTrustResult{}, false
+	}
+	if time.Now().UnixNano() > entry.ExpiresAt {
+		c.removeLocked(ref)
 		return EnhancedTrustResult{}, false
 	}
+	c.touchLocked(ref)
 	// Return a copy to prevent mutation
 	result := entry.Result
 	result.TrustPath = make([]string, len(entry.Result.TrustPath))
@@ -96,10 +156,13 @@ func (c *TrustCache) SetEnhanced(key string, result EnhancedTrustResult) {
 	resultCopy.VerificationGaps = make([]VerificationGap, len(result.VerificationGaps))
 	copy(resultCopy.VerificationGaps, result.VerificationGaps)
 
+	ref := trustCacheRef{enhanced: true, key: key}
+	c.removeLocked(ref)
 	c.enhancedEntries[key] = EnhancedTrustCacheEntry{
 		Result:    resultCopy,
 		ExpiresAt: time.Now().Add(c.ttl).UnixNano(),
 	}
+	c.trackLocked(ref, nil)
 }
 
 // Invalidate clears all cached entries
@@ -109,6 +172,31 @@ func (c *TrustCache) Invalidate() {
 
 	c.entries = make(map[string]TrustCacheEntry)
 	c.enhancedEntries = make(map[string]EnhancedTrustCacheEntry)
+	c.lru.Init()
+	c.lruIndex = make(map[trustCacheRef]*list.Element)
+	c.deps = make(map[string]map[trustCacheRef]struct{})
+	c.entryDeps = make(map[trustCacheRef][]string)
+}
+
+// InvalidateQuid drops every entry that read the outbound edges of
+// quid, plus entries with unknown dependencies. Call it whenever an
+// edge whose truster is quid is added, changed, or removed. Returns
+// the number of entries dropped.
+func (c *TrustCache) InvalidateQuid(quid string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var refs []trustCacheRef
+	for ref := range c.deps[quid] {
+		refs = append(refs, ref)
+	}
+	for ref := range c.deps[trustCacheAnyQuid] {
+		refs = append(refs, ref)
+	}
+	for _, ref := range refs {
+		c.removeLocked(ref)
+	}
+	return len(refs)
 }
 
 // Size returns the number of entries in both caches
@@ -118,6 +206,53 @@ func (c *TrustCache) Size() (basic int, enhanced int) {
 	return len(c.entries), len(c.enhancedEntries)
 }
 
+// trackLocked indexes a freshly stored entry and enforces the
+// size bound. Caller holds c.mu.
+func (c *TrustCache) trackLocked(ref trustCacheRef, deps []string) {
+	if deps == nil {
+		deps = []string{trustCacheAnyQuid}
+	}
+	for _, q := range deps {
+		if c.deps[q] == nil {
+			c.deps[q] = make(map[trustCacheRef]struct{})
+		}
+		c.deps[q][ref] = struct{}{}
+	}
+	c.entryDeps[ref] = deps
+	c.lruIndex[ref] = c.lru.PushFront(ref)
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeLocked(c.lru.Back().Value.(trustCacheRef))
+	}
+}
+
+// touchLocked marks an entry as most recently used.
+func (c *TrustCache) touchLocked(ref trustCacheRef) {
+	if el, ok := c.lruIndex[ref]; ok {
+		c.lru.MoveToFront(el)
+	}
+}
+
+// removeLocked drops an entry and its index records, if present.
+func (c *TrustCache) removeLocked(ref trustCacheRef) {
+	if ref.enhanced {
+		delete(c.enhancedEntries, ref.key)
+	} else {
+		delete(c.entries, ref.key)
+	}
+	if el, ok := c.lruIndex[ref]; ok {
+		c.lru.Remove(el)
+		delete(c.lruIndex, ref)
+	}
+	for _, q := range c.entryDeps[ref] {
+		delete(c.deps[q], ref)
+		if len(c.deps[q]) == 0 {
+			delete(c.deps, q)
+		}
+	}
+	delete(c.entryDeps, ref)
+}
+
 // makeTrustCacheKey creates a cache key for basic trust computation
 func makeTrustCacheKey(observer, target string, maxDepth int) string {
 	return fmt.Sprintf("%s:%s:%d", observer, target, maxDepth)
@@ -128,30 +263,81 @@ func makeEnhancedTrustCacheKey(observer, target string, maxDepth int, includeUnv
 	return fmt.Sprintf("%s:%s:%d:%t", observer, target, maxDepth, includeUnverified)
 }
 
+// scopedCacheKey suffixes a trust cache key with the scope a
+// computation was limited to; global keys are unchanged.
+func scopedCacheKey(key string, scope TrustScope) string {
+	if scope.global() {
+		return key
+	}
+	return key + "@" + scope.key()
+}
+
 // processBlockTransactions processes transactions in a block to update registries
 func (node *QuidnugNode) processBlockTransactions(block Block) {
+	node.applyMu.Lock()
+	defer node.applyMu.Unlock()
+	node.applyBlockTransactions(block)
+}
+
+// applyBlockTransactions is processBlockTransactions with applyMu
+// already held.
+func (node *QuidnugNode) applyBlockTransactions(block Block) {
+	defer node.bumpRegistryVersion()
+	// Trust queries keep reading the previous view until the whole
+	// block is applied.
+	node.trustViewState.applying.Add(1)
+	defer func() {
+		node.trustViewState.applying.Add(-1)
+		node.publishTrustView()
+	}()
+
+	// Incremental per-domain stats; replays of an already-counted
+	// height are skipped so reloads don't double count.
+	countStats := node.DomainAnalytics != nil && node.DomainAnalytics.observeBlock(block)
+	blockDomain := block.TrustProof.TrustDomain
+	source := blockRef(block)
+	blog := blockLogger(block)
+
+	// Time locks, deadlines and title expiries that passed before
+	// this block take effect ahead of its transactions.
+	node.settleConditionalTransfers(blockDomain, block.Timestamp)
+	node.expireTitles(blockDomain, block.Timestamp)
+
 	for txIdx, txInterface := range block.Transactions {
 		txJson, err := json.Marshal(txInterface)
 		if err != nil {
-			logger.Error("Failed to marshal transaction in block", "blockIndex", block.Index, "error", err)
+			blog.Error("Failed to marshal transaction in block", "txIndex", txIdx, "error", err)
 			continue
 		}
 
 		// Determine transaction type
 		var baseTx BaseTransaction
 		if err := json.Unmarshal(txJson, &baseTx); err != nil {
-			logger.Error("Failed to unmarshal base transaction", "blockIndex", block.Index, "error", err)
+			blog.Error("Failed to unmarshal base transaction", "txIndex", txIdx, "error", err)
 			continue
 		}
+		blog.Debug("Applying transaction", "txId", baseTx.ID, "txType", baseTx.Type)
+		if countStats {
+			node.DomainAnalytics.observeTx(blockDomain, baseTx.Type)
+		}
+		node.observeSignedActivity(baseTx)
 
 		switch baseTx.Type {
 		case TxTypeTrust:
 			var tx TrustTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal trust transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal trust transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updateTrustRegistry(tx)
+			node.mirrorTrustEdge(tx)
+			node.observeTrustAnomalies(tx, block)
+			if node.EntitySources != nil {
+				node.EntitySources.record(trustSourceKey(tx.Truster, tx.Trustee), source)
+			}
+			if countStats {
+				node.DomainAnalytics.observeTrustEdge(blockDomain, tx.Truster, tx.Trustee)
+			}
 			// QDP-0014: credit both ends of the edge in the
 			// per-domain quid index for discovery queries.
 			if node.QuidDomainIndex != nil {
@@ -162,10 +348,16 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeIdentity:
 			var tx IdentityTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal identity transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal identity transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updateIdentityRegistry(tx)
+			if node.Anomalies != nil {
+				node.Anomalies.observeQuid(tx.QuidID, tx.Timestamp)
+			}
+			if node.EntitySources != nil {
+				node.EntitySources.record(identitySourceKey(tx.QuidID), source)
+			}
 			if node.QuidDomainIndex != nil {
 				node.QuidDomainIndex.observe(
 					tx.TrustDomain, tx.QuidID, tx.Timestamp)
@@ -174,10 +366,18 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeTitle:
 			var tx TitleTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal title transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal title transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
-			node.updateTitleRegistry(tx)
+			if tx.Conditions != nil {
+				node.holdConditionalTransfer(tx, block)
+			} else {
+				node.updateTitleRegistry(tx)
+				if node.EntitySources != nil {
+					node.EntitySources.record(titleSourceKey(tx.AssetID), source)
+				}
+				node.observeTitleAnomalies(tx, block)
+			}
 			// Credit each owner in the domain's index — all
 			// are "active participants" from the tx's domain's
 			// perspective.
@@ -191,10 +391,13 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeEvent:
 			var tx EventTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal event transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal event transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updateEventStreamRegistry(tx)
+			if tx.EventType == NotarizationEventType {
+				node.observeNotarization(tx, block, txIdx)
+			}
 			// For events the signer is derived from PublicKey
 			// (consortium block-production machinery already
 			// computes this; reuse the derivation).
@@ -209,7 +412,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeNodeAdvertisement:
 			var tx NodeAdvertisementTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal node-advertisement transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal node-advertisement transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updateNodeAdvertisementRegistry(tx)
@@ -221,7 +424,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeModerationAction:
 			var tx ModerationActionTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal moderation-action transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal moderation-action transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updateModerationRegistry(tx)
@@ -230,10 +433,114 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 					tx.TrustDomain, tx.ModeratorQuid, tx.Timestamp)
 			}
 
+		case TxTypeNameRegistration:
+			var tx NameRegistrationTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal name-registration transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateNameRegistry(tx)
+			if node.QuidDomainIndex != nil {
+				node.QuidDomainIndex.observe(
+					tx.TrustDomain, tx.OwnerQuid, tx.Timestamp)
+			}
+
+		case TxTypeLien:
+			var tx LienTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal lien transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateLienRegistry(tx)
+			if node.QuidDomainIndex != nil {
+				node.QuidDomainIndex.observe(
+					tx.TrustDomain, tx.LienholderQuid, tx.Timestamp)
+			}
+
+		case TxTypeSuccession:
+			var tx SuccessionTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal succession transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateSuccessionRegistry(tx)
+
+		case TxTypeMisbehaviorReport:
+			var tx MisbehaviorReportTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal misbehavior-report transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.applyMisbehaviorReport(tx, block)
+
+		case TxTypeDomainJoin:
+			var tx DomainJoinTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal domain-join transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.applyDomainJoin(tx)
+
+		case TxTypeCheckpoint:
+			var tx CheckpointTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal checkpoint transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.applyCheckpoint(tx)
+
+		case TxTypeDomainControl:
+			var tx DomainControlTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal domain-control transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.applyDomainControl(tx)
+
+		case TxTypeTransferApproval:
+			var tx TransferApprovalTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal transfer-approval transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateEscrowRegistry(tx, block)
+
+		case TxTypeTitleRestructure:
+			var tx TitleRestructureTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal title-restructure transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.applyTitleRestructure(tx)
+
+		case TxTypeTitleDispute:
+			var tx TitleDisputeTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal title-dispute transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateTitleDisputeRegistry(tx)
+			if node.QuidDomainIndex != nil {
+				node.QuidDomainIndex.observe(
+					tx.TrustDomain, tx.SignerQuid, tx.Timestamp)
+			}
+
+		case TxTypeGeneric:
+			var tx CustomTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				blog.Error("Failed to unmarshal custom transaction", "txIndex", txIdx, "error", err)
+				continue
+			}
+			node.updateCustomTxRegistry(tx)
+			if node.QuidDomainIndex != nil {
+				node.QuidDomainIndex.observe(
+					tx.TrustDomain, tx.Signer, tx.Timestamp)
+			}
+
 		case TxTypeDataSubjectRequest:
 			var tx DataSubjectRequestTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal DSR transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal DSR transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updatePrivacyRegistryDSR(tx)
@@ -241,7 +548,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeConsentGrant:
 			var tx ConsentGrantTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal consent-grant transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal consent-grant transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updatePrivacyRegistryGrant(tx)
@@ -249,7 +556,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeConsentWithdraw:
 			var tx ConsentWithdrawTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal consent-withdraw transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal consent-withdraw transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updatePrivacyRegistryWithdraw(tx)
@@ -257,7 +564,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeProcessingRestriction:
 			var tx ProcessingRestrictionTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal processing-restriction transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal processing-restriction transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updatePrivacyRegistryRestriction(tx)
@@ -265,7 +572,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeDSRCompliance:
 			var tx DSRComplianceTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal DSR-compliance transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal DSR-compliance transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.updatePrivacyRegistryCompliance(tx)
@@ -273,7 +580,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeAnchor:
 			var tx AnchorTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal anchor transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal anchor transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyAnchorFromBlock(tx.Anchor, block)
@@ -285,7 +592,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeGuardianSetUpdate:
 			var tx GuardianSetUpdateTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal guardian-set-update transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal guardian-set-update transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyGuardianSetUpdate(tx.Update, block)
@@ -294,7 +601,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeGuardianRecoveryInit:
 			var tx GuardianRecoveryInitTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal guardian-recovery-init transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal guardian-recovery-init transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyGuardianRecoveryInit(tx.Init, block)
@@ -303,7 +610,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeGuardianRecoveryVeto:
 			var tx GuardianRecoveryVetoTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal guardian-recovery-veto transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal guardian-recovery-veto transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyGuardianRecoveryVeto(tx.Veto, block)
@@ -312,7 +619,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeGuardianRecoveryCommit:
 			var tx GuardianRecoveryCommitTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal guardian-recovery-commit transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal guardian-recovery-commit transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyGuardianRecoveryCommit(tx.Commit, block)
@@ -321,7 +628,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeGuardianResign:
 			var tx GuardianResignationTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal guardian-resign transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal guardian-resign transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyGuardianResignation(tx.Resignation, block)
@@ -330,7 +637,7 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 		case TxTypeForkBlock:
 			var tx ForkBlockTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
-				logger.Error("Failed to unmarshal fork-block transaction", "blockIndex", block.Index, "error", err)
+				blog.Error("Failed to unmarshal fork-block transaction", "txIndex", txIdx, "error", err)
 				continue
 			}
 			node.applyForkBlockFromBlock(tx.Fork, block)
@@ -341,6 +648,8 @@ func (node *QuidnugNode) processBlockTransactions(block Block) {
 	// been processed, check whether any pending forks for this
 	// domain have reached their ForkHeight.
 	node.maybeActivateForks(block.TrustProof.TrustDomain, block.Index)
+
+	node.recordCheckpointRoot(block)
 }
 
 // updateTrustRegistry updates the trust registry with a trust transaction
@@ -382,10 +691,18 @@ func (node *QuidnugNode) updateTrustRegistry(tx TrustTransaction) {
 		node.TrustEdgeTimestampRegistry[tx.Truster][tx.Trustee] = tx.Timestamp
 	}
 
-	// Invalidate trust cache since trust graph has changed
-	if node.TrustCache != nil {
-		node.TrustCache.Invalidate()
+	if node.TrustEdgeDomainRegistry != nil {
+		if _, exists := node.TrustEdgeDomainRegistry[tx.Truster]; !exists {
+			node.TrustEdgeDomainRegistry[tx.Truster] = make(map[string]string)
+		}
+		node.TrustEdgeDomainRegistry[tx.Truster][tx.Trustee] = tx.TrustDomain
 	}
+	node.recordScopedTrustLevelLocked(tx.Truster, tx.Trustee, tx.TrustDomain, tx.Context, tx.TrustLevel)
+
+	node.recordTrustEvidence(tx)
+
+	// Only results that read the truster's edges are affected.
+	node.invalidateTrustFor(tx.Truster)
 
 	logger.Debug("Updated trust registry",
 		"truster", tx.Truster,
@@ -397,6 +714,17 @@ func (node *QuidnugNode) updateTrustRegistry(tx TrustTransaction) {
 // updateIdentityRegistry updates the identity registry with an identity transaction
 func (node *QuidnugNode) updateIdentityRegistry(tx IdentityTransaction) {
 	node.IdentityRegistryMutex.Lock()
+	existing, exists := node.IdentityRegistry[tx.QuidID]
+	if exists && existing.ID != tx.ID && tx.UpdateNonce <= existing.UpdateNonce {
+		node.IdentityRegistryMutex.Unlock()
+		if tx.UpdateNonce == existing.UpdateNonce {
+			node.recordIdentityConflict(existing, tx, IdentityConflictStageApply, nil)
+		} else {
+			logger.Debug("Ignoring stale identity update",
+				"quidId", tx.QuidID, "updateNonce", tx.UpdateNonce, "currentNonce", existing.UpdateNonce)
+		}
+		return
+	}
 	node.IdentityRegistry[tx.QuidID] = tx
 	node.IdentityRegistryMutex.Unlock()
 
@@ -410,6 +738,9 @@ func (node *QuidnugNode) updateIdentityRegistry(tx IdentityTransaction) {
 			node.NonceLedger.SetSignerKey(tx.QuidID, 0, tx.PublicKey)
 		}
 	}
+	if !exists {
+		node.installIdentityGuardians(tx)
+	}
 
 	logger.Debug("Updated identity registry", "quidId", tx.QuidID, "name", tx.Name)
 }
@@ -419,8 +750,17 @@ func (node *QuidnugNode) updateTitleRegistry(tx TitleTransaction) {
 	node.TitleRegistryMutex.Lock()
 	defer node.TitleRegistryMutex.Unlock()
 
+	// Remember who held the title being replaced: former owners
+	// keep standing to dispute it (title_dispute.go).
+	if prev, exists := node.TitleRegistry[tx.AssetID]; exists && node.TitleDisputes != nil {
+		node.TitleDisputes.noteFormerOwners(tx.AssetID, prev.Owners)
+	}
+
 	// Add or update title
 	node.TitleRegistry[tx.AssetID] = tx
+	if node.OwnerIndex != nil {
+		node.OwnerIndex.replace(tx.AssetID, tx.Owners)
+	}
 
 	logger.Debug("Updated title registry", "assetId", tx.AssetID, "ownerCount", len(tx.Owners))
 }
@@ -451,20 +791,7 @@ func (node *QuidnugNode) GetTrustLevel(truster, trustee string) float64 {
 // quid. Edges whose ValidUntil has passed (QDP-0022) are filtered
 // out so that downstream trust computation naturally ignores them.
 func (node *QuidnugNode) GetDirectTrustees(quidID string) map[string]float64 {
-	node.TrustRegistryMutex.RLock()
-	defer node.TrustRegistryMutex.RUnlock()
-
-	result := make(map[string]float64)
-	if trustMap, exists := node.TrustRegistry[quidID]; exists {
-		for trustee, level := range trustMap {
-			// QDP-0022: skip expired edges.
-			if !node.isTrustEdgeValidLocked(quidID, trustee) {
-				continue
-			}
-			result[trustee] = level
-		}
-	}
-	return result
+	return node.currentTrustView().trustees(quidID, nowUnix())
 }
 
 // ComputeRelationalTrust computes transitive trust from observer to target through the trust graph.
@@ -479,29 +806,90 @@ func (node *QuidnugNode) GetDirectTrustees(quidID string) map[string]float64 {
 //   - float64: the maximum trust level found (0 if no path exists)
 //   - []string: the path of quid IDs for the best trust path
 //   - error: ErrTrustGraphTooLarge if resource limits exceeded, nil otherwise
-func (node *QuidnugNode) ComputeRelationalTrust(observer, target string, maxDepth int) (float64, []string, error) {
+func (node *QuidnugNode) ComputeRelationalTrust(ctx context.Context, observer, target string, maxDepth int) (float64, []string, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+	if node.TrustPrecompute != nil && observer == node.NodeID && observer != target {
+		node.TrustPrecompute.Record(target, maxDepth)
+	}
+	return node.computeRelationalTrust(ctx, observer, target, maxDepth)
+}
+
+// computeRelationalTrust is ComputeRelationalTrust without query
+// tracking, so background precomputation doesn't count as demand.
+func (node *QuidnugNode) computeRelationalTrust(ctx context.Context, observer, target string, maxDepth int) (float64, []string, error) {
+	return node.computeTrustInScope(ctx, observer, target, maxDepth, TrustScope{})
+}
+
+// computeTrustInScope computes relational trust over the edges in
+// scope.
+func (node *QuidnugNode) computeTrustInScope(ctx context.Context, observer, target string, maxDepth int, scope TrustScope) (float64, []string, error) {
 	start := time.Now()
 	defer func() {
 		trustComputationDuration.Observe(time.Since(start).Seconds())
 	}()
 
-	if maxDepth <= 0 {
-		maxDepth = DefaultTrustMaxDepth
-	}
-
 	// Same entity has full trust in itself
 	if observer == target {
 		return 1.0, []string{observer}, nil
 	}
 
 	// Check cache first
+	cacheKey := scopedCacheKey(makeTrustCacheKey(observer, target, maxDepth), scope)
 	if node.TrustCache != nil {
-		cacheKey := makeTrustCacheKey(observer, target, maxDepth)
 		if trustLevel, trustPath, found := node.TrustCache.Get(cacheKey); found {
 			return trustLevel, trustPath, nil
 		}
 	}
 
+	// The node's own view at a tracked depth comes from its trust
+	// tracker (trust_incremental.go).
+	if scope.global() {
+		if trust, path, deps, view, ok := node.trackedTrust(observer, target, maxDepth); ok {
+			if node.TrustCache != nil && node.trustViewCurrent(view) {
+				node.TrustCache.SetWithDeps(cacheKey, trust, path, deps)
+			}
+			return trust, path, nil
+		}
+	}
+
+	// Delegate to an external graph store when one is configured.
+	// The store holds the global graph only.
+	if scope.global() {
+		if trust, path, ok := node.bestPathFromStore(ctx, observer, target, maxDepth); ok {
+			if node.TrustCache != nil {
+				node.TrustCache.Set(cacheKey, trust, path)
+			}
+			return trust, path, nil
+		}
+	}
+
+	bestTrust, bestPath, expanded, view, err := node.searchRelationalTrust(ctx, observer, target, maxDepth, scope, nil)
+	if err != nil {
+		return bestTrust, bestPath, err
+	}
+
+	// Cache successful result (no error), unless edges changed while
+	// searching an older view.
+	if node.TrustCache != nil && node.trustViewCurrent(view) {
+		node.TrustCache.SetWithDeps(cacheKey, bestTrust, bestPath, expanded)
+	}
+
+	return bestTrust, bestPath, nil
+}
+
+// trustCancelCheckInterval is how many quids a trust search expands
+// between checks of its context.
+const trustCancelCheckInterval = 64
+
+// searchRelationalTrust runs the trust BFS over the current view,
+// limited to the edges in scope. overlay,
+// when non-nil, replaces the observer's own outbound edges for the
+// trustees it names (a level of 0 drops the edge). It also returns
+// the quids whose edges were read and the view searched, for cache
+// bookkeeping. A cancelled ctx stops the search with ctx.Err().
+func (node *QuidnugNode) searchRelationalTrust(ctx context.Context, observer, target string, maxDepth int, scope TrustScope, overlay map[string]float64) (float64, []string, []string, *trustView, error) {
 	type searchState struct {
 		quid  string
 		path  []string
@@ -520,33 +908,50 @@ func (node *QuidnugNode) ComputeRelationalTrust(observer, target string, maxDept
 
 	bestTrust := 0.0
 	var bestPath []string
+	// Quids whose edges were read; the cache entry depends on them.
+	var expanded []string
+
+	// One view for the whole search, so a block applied mid-query
+	// can't mix old and new edges into a path.
+	view := node.currentTrustView()
+	now := nowUnix()
+	forEachTrustee := func(quid string, fn func(string, float64)) {
+		view.forEachTrustee(quid, now, fn)
+	}
+	if !scope.global() {
+		forEachTrustee = func(quid string, fn func(string, float64)) {
+			for trustee, level := range node.scopeTrustees(quid, view.trustees(quid, now), scope) {
+				fn(trustee, level)
+			}
+		}
+	}
 
 	for len(queue) > 0 {
 		// Check resource limits - return best result found so far with error
 		if len(queue) > MaxTrustQueueSize {
-			return bestTrust, bestPath, ErrTrustGraphTooLarge
+			return bestTrust, bestPath, expanded, view, ErrTrustGraphTooLarge
 		}
 		if len(visited) > MaxTrustVisitedSize {
-			return bestTrust, bestPath, ErrTrustGraphTooLarge
+			return bestTrust, bestPath, expanded, view, ErrTrustGraphTooLarge
+		}
+		if len(expanded)%trustCancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return bestTrust, bestPath, expanded, view, err
+			}
 		}
 
 		current := queue[0]
 		queue = queue[1:]
 
-		trustees := node.GetDirectTrustees(current.quid)
+		expanded = append(expanded, current.quid)
 
-		for trustee, edgeTrust := range trustees {
+		visit := func(trustee string, edgeTrust float64) {
 			// Skip if trustee is already in current path (cycle avoidance)
-			inPath := false
 			for _, p := range current.path {
 				if p == trustee {
-					inPath = true
-					break
+					return
 				}
 			}
-			if inPath {
-				continue
-			}
 
 			// Calculate multiplicative trust decay
 			pathTrust := current.trust * edgeTrust
@@ -562,7 +967,7 @@ func (node *QuidnugNode) ComputeRelationalTrust(observer, target string, maxDept
 					bestTrust = pathTrust
 					bestPath = newPath
 				}
-				continue
+				return
 			}
 
 			// Continue BFS if within depth limit and not already visited
@@ -576,15 +981,24 @@ func (node *QuidnugNode) ComputeRelationalTrust(observer, target string, maxDept
 				})
 			}
 		}
-	}
 
-	// Cache successful result (no error)
-	if node.TrustCache != nil {
-		cacheKey := makeTrustCacheKey(observer, target, maxDepth)
-		node.TrustCache.Set(cacheKey, bestTrust, bestPath)
+		if overlay == nil || current.quid != observer {
+			forEachTrustee(current.quid, visit)
+			continue
+		}
+		forEachTrustee(current.quid, func(trustee string, edgeTrust float64) {
+			if _, replaced := overlay[trustee]; !replaced {
+				visit(trustee, edgeTrust)
+			}
+		})
+		for trustee, edgeTrust := range overlay {
+			if edgeTrust > 0 {
+				visit(trustee, edgeTrust)
+			}
+		}
 	}
 
-	return bestTrust, bestPath, nil
+	return bestTrust, bestPath, expanded, view, nil
 }
 
 // GetQuidIdentity returns quid identity information
@@ -737,6 +1151,11 @@ func (node *QuidnugNode) AddVerifiedTrustEdge(edge TrustEdge) {
 	}
 	edge.Verified = true
 	node.VerifiedTrustEdges[edge.Truster][edge.Trustee] = edge
+	if edge.Domain != "" {
+		node.recordScopedTrustLevelLocked(edge.Truster, edge.Trustee, edge.Domain, edge.Context, edge.TrustLevel)
+	}
+	node.invalidateTrustFor(edge.Truster)
+	node.bumpRegistryVersion()
 
 	logger.Debug("Added verified trust edge",
 		"truster", edge.Truster,
@@ -755,6 +1174,8 @@ func (node *QuidnugNode) AddUnverifiedTrustEdge(edge TrustEdge) {
 	}
 	edge.Verified = false
 	node.UnverifiedTrustRegistry[edge.Truster][edge.Trustee] = edge
+	node.invalidateTrustFor(edge.Truster)
+	node.bumpRegistryVersion()
 
 	logger.Debug("Added unverified trust edge",
 		"truster", edge.Truster,
@@ -817,6 +1238,8 @@ func (node *QuidnugNode) DemoteTrustEdge(truster, trustee string) {
 		}
 	}
 	node.TrustRegistryMutex.Unlock()
+	node.unmirrorTrustEdge(truster, trustee)
+	node.invalidateTrustFor(truster)
 
 	if !found {
 		return
@@ -879,7 +1302,20 @@ func (node *QuidnugNode) GetTrustEdges(quidID string, includeUnverified bool) ma
 // Results are cached with TTL-based expiration for performance.
 // Returns ErrTrustGraphTooLarge if resource limits are exceeded.
 func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
+	ctx context.Context,
+	observer, target string,
+	maxDepth int,
+	includeUnverified bool,
+) (EnhancedTrustResult, error) {
+	return node.ComputeScopedTrustEnhanced(ctx, observer, target, TrustScope{}, maxDepth, includeUnverified)
+}
+
+// ComputeScopedTrustEnhanced is ComputeRelationalTrustEnhanced limited
+// to scope; the zero scope searches the global graph.
+func (node *QuidnugNode) ComputeScopedTrustEnhanced(
+	ctx context.Context,
 	observer, target string,
+	scope TrustScope,
 	maxDepth int,
 	includeUnverified bool,
 ) (EnhancedTrustResult, error) {
@@ -903,8 +1339,8 @@ func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
 	}
 
 	// Check cache first
+	cacheKey := scopedCacheKey(makeEnhancedTrustCacheKey(observer, target, maxDepth, includeUnverified), scope)
 	if node.TrustCache != nil {
-		cacheKey := makeEnhancedTrustCacheKey(observer, target, maxDepth, includeUnverified)
 		if result, found := node.TrustCache.GetEnhanced(cacheKey); found {
 			return result, nil
 		}
@@ -934,6 +1370,7 @@ func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
 	var bestPath []string
 	bestUnverifiedHops := 0
 	var bestGaps []VerificationGap
+	expanded := 0
 
 	for len(queue) > 0 {
 		// Check resource limits - return best result found so far with error
@@ -943,11 +1380,20 @@ func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
 		if len(visited) > MaxTrustVisitedSize {
 			return buildEnhancedResult(observer, target, bestTrust, bestPath, bestUnverifiedHops, bestGaps), ErrTrustGraphTooLarge
 		}
+		if expanded%trustCancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return buildEnhancedResult(observer, target, bestTrust, bestPath, bestUnverifiedHops, bestGaps), err
+			}
+		}
+		expanded++
 
 		current := queue[0]
 		queue = queue[1:]
 
 		edges := node.GetTrustEdges(current.quid, includeUnverified)
+		if !scope.global() {
+			edges = node.scopeTrustEdges(current.quid, edges, scope)
+		}
 
 		for trustee, edge := range edges {
 			// Skip if trustee is already in current path (cycle avoidance)
@@ -966,18 +1412,21 @@ func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
 			var newUnverifiedHops int
 			var newGaps []VerificationGap
 
+			// Edges flagged by the anomaly detector count for less.
+			edgeWeight := edge.TrustLevel * node.suspectEdgeFactor(current.quid, trustee)
+
 			if edge.Verified {
-				effectiveTrust = current.trust * edge.TrustLevel
+				effectiveTrust = current.trust * edgeWeight
 				newUnverifiedHops = current.unverifiedHops
 				newGaps = current.gaps
 			} else {
 				// Discount by validator trust (use reduced depth to limit recursion)
-				validatorTrust, _, err := node.ComputeRelationalTrust(observer, edge.ValidatorQuid, DefaultTrustMaxDepth)
+				validatorTrust, _, err := node.ComputeRelationalTrust(ctx, observer, edge.ValidatorQuid, DefaultTrustMaxDepth)
 				if err != nil {
 					// On resource exhaustion in nested call, use zero trust for this edge
 					validatorTrust = 0
 				}
-				effectiveTrust = current.trust * edge.TrustLevel * validatorTrust
+				effectiveTrust = current.trust * edgeWeight * validatorTrust
 				newUnverifiedHops = current.unverifiedHops + 1
 
 				// Create verification gap entry
@@ -1026,7 +1475,6 @@ func (node *QuidnugNode) ComputeRelationalTrustEnhanced(
 
 	// Cache successful result (no error)
 	if node.TrustCache != nil {
-		cacheKey := makeEnhancedTrustCacheKey(observer, target, maxDepth, includeUnverified)
 		node.TrustCache.SetEnhanced(cacheKey, result)
 	}
 
@@ -1103,6 +1551,8 @@ func (node *QuidnugNode) ExtractTrustEdgesFromBlock(block Block, verified bool)
 			ValidatorQuid: block.TrustProof.ValidatorID,
 			Verified:      verified,
 			Timestamp:     tx.Timestamp,
+			Domain:        tx.TrustDomain,
+			Context:       tx.Context,
 		}
 		edges = append(edges, edge)
 	}
@@ -1,13 +1,14 @@
 package core
 
 import (
+	"context"
 	"testing"
 )
 
 func TestComputeRelationalTrustEnhanced_SameEntity(t *testing.T) {
 	node := newTestNode()
 
-	result, err := node.ComputeRelationalTrustEnhanced("1111111111111111", "1111111111111111", 5, true)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "1111111111111111", "1111111111111111", 5, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -38,7 +39,7 @@ func TestComputeRelationalTrustEnhanced_VerifiedOnlyPath(t *testing.T) {
 		TrustLevel: 0.9,
 	})
 
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, false)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -83,7 +84,7 @@ func TestComputeRelationalTrustEnhanced_MixedPathWithGaps(t *testing.T) {
 		TrustLevel: 0.5,
 	})
 
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, true)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -127,7 +128,7 @@ func TestComputeRelationalTrustEnhanced_UnverifiedDiscounting(t *testing.T) {
 	})
 
 	// No trust path from A to V, so validator trust is 0
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, true)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -150,7 +151,7 @@ func TestComputeRelationalTrustEnhanced_IncludeUnverifiedFalse(t *testing.T) {
 	})
 
 	// With includeUnverified=false, should find no path
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, false)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -187,7 +188,7 @@ func TestComputeRelationalTrustEnhanced_LowConfidenceMultipleGaps(t *testing.T)
 		TrustLevel: 1.0,
 	})
 
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, true)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "cccccccccccccccc", 5, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -213,7 +214,7 @@ func TestComputeRelationalTrustEnhanced_DirectVerifiedTrust(t *testing.T) {
 		TrustLevel: 0.75,
 	})
 
-	result, err := node.ComputeRelationalTrustEnhanced("aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, true)
+	result, err := node.ComputeRelationalTrustEnhanced(context.Background(), "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb", 5, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -26,7 +27,7 @@ func TestComputeRelationalTrust_ResourceLimits(t *testing.T) {
 	node.TrustRegistryMutex.Unlock()
 
 	// Search for a non-existent node to force exploration of all direct connections
-	_, _, err := node.ComputeRelationalTrust(observer, "ffff000000000000", 10)
+	_, _, err := node.ComputeRelationalTrust(context.Background(), observer, "ffff000000000000", 10)
 
 	if err == nil {
 		t.Error("Expected ErrTrustGraphTooLarge error for large graph, got nil")
@@ -51,7 +52,7 @@ func TestComputeRelationalTrust_NormalGraphSucceeds(t *testing.T) {
 	}
 	node.TrustRegistryMutex.Unlock()
 
-	trustLevel, path, err := node.ComputeRelationalTrust("c0a100de00000000", "c0a100de00000005", 10)
+	trustLevel, path, err := node.ComputeRelationalTrust(context.Background(), "c0a100de00000000", "c0a100de00000005", 10)
 
 	if err != nil {
 		t.Errorf("Expected no error for normal graph, got: %v", err)
@@ -96,7 +97,7 @@ func TestComputeRelationalTrustEnhanced_ResourceLimits(t *testing.T) {
 	node.TrustRegistryMutex.Unlock()
 
 	// Search for a non-existent node to force exploration of all direct connections
-	_, err := node.ComputeRelationalTrustEnhanced(observer, "ffff000000000000", 10, false)
+	_, err := node.ComputeRelationalTrustEnhanced(context.Background(), observer, "ffff000000000000", 10, false)
 
 	if err == nil {
 		t.Error("Expected ErrTrustGraphTooLarge error for large graph, got nil")
@@ -134,7 +135,7 @@ func TestComputeRelationalTrust_PartialResultOnLimit(t *testing.T) {
 	node.TrustRegistryMutex.Unlock()
 
 	// Query for a target in the dense subgraph
-	trustLevel, _, err := node.ComputeRelationalTrust("11b105e00e00bef0", "11b1de05e0000064", 10)
+	trustLevel, _, err := node.ComputeRelationalTrust(context.Background(), "11b105e00e00bef0", "11b1de05e0000064", 10)
 
 	// Should hit resource limit
 	if err == nil {
@@ -195,7 +196,7 @@ func BenchmarkComputeRelationalTrust_BoundedMemory(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		// This should either complete or hit resource limits, but not OOM
-		node.ComputeRelationalTrust("be0c000de0000000", "be0c000de0000032", DefaultTrustMaxDepth)
+		node.ComputeRelationalTrust(context.Background(), "be0c000de0000000", "be0c000de0000032", DefaultTrustMaxDepth)
 	}
 }
 
@@ -229,6 +230,34 @@ func BenchmarkComputeRelationalTrustEnhanced_BoundedMemory(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		node.ComputeRelationalTrustEnhanced("be0ce00000000000", "be0ce00000000032", DefaultTrustMaxDepth, false)
+		node.ComputeRelationalTrustEnhanced(context.Background(), "be0ce00000000000", "be0ce00000000032", DefaultTrustMaxDepth, false)
+	}
+}
+
+func TestComputeRelationalTrust_Cancelled(t *testing.T) {
+	node := newTestNode()
+	node.TrustRegistryMutex.Lock()
+	for i := 0; i < 10; i++ {
+		truster := fmt.Sprintf("ca5ce1de000000%02x", i)
+		node.TrustRegistry[truster] = map[string]float64{fmt.Sprintf("ca5ce1de000000%02x", i+1): 0.9}
+	}
+	node.TrustRegistryMutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := node.ComputeRelationalTrust(ctx, "ca5ce1de00000000", "ca5ce1de00000005", 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ComputeRelationalTrust: got %v, want context.Canceled", err)
+	}
+	if _, err := node.ComputeRelationalTrustEnhanced(ctx, "ca5ce1de00000000", "ca5ce1de00000005", 10, true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ComputeRelationalTrustEnhanced: got %v, want context.Canceled", err)
+	}
+	if _, err := node.ComputeRelationalTrustBatch(ctx, "ca5ce1de00000000", []string{"ca5ce1de00000005"}, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ComputeRelationalTrustBatch: got %v, want context.Canceled", err)
+	}
+
+	// A cancelled search must not leave a cached zero behind.
+	trust, _, err := node.ComputeRelationalTrust(context.Background(), "ca5ce1de00000000", "ca5ce1de00000005", 10)
+	if err != nil || trust == 0 {
+		t.Fatalf("after cancellation: trust=%v err=%v", trust, err)
 	}
 }
@@ -0,0 +1,177 @@
+// Paged registry listings.
+//
+// GET /registry/{trust,identity,title} without a filter lists the
+// whole registry a page at a time. Pages are selected over sorted
+// keys, and only the selected entries are copied out, so a listing
+// costs one key per entry rather than a full copy of the registry.
+// Offset and cursor pages share the same ascending key order.
+package core
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// trustRegistryEntry is one edge in a trust registry listing.
+type trustRegistryEntry struct {
+	Truster    string  `json:"truster"`
+	Trustee    string  `json:"trustee"`
+	TrustLevel float64 `json:"trust_level"`
+}
+
+// trustRegistryEntryKey is the edge's position in listing order.
+func trustRegistryEntryKey(e trustRegistryEntry) string {
+	return e.Truster + ":" + e.Trustee
+}
+
+// trustRegistryPage selects a page of edges ordered by
+// "truster:trustee". With cur nil it skips params.Offset edges and
+// reports the total; otherwise it resumes after cur.After and fills
+// in the cursor metadata.
+func (node *QuidnugNode) trustRegistryPage(params PaginationParams, cur *PageCursor) ([]trustRegistryEntry, int, CursorMeta) {
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+
+	// Sorting "truster:" rather than truster keeps groups in the
+	// same order as their edges' keys.
+	prefixes := make([]string, 0, len(node.TrustRegistry))
+	total := 0
+	for truster, edges := range node.TrustRegistry {
+		prefixes = append(prefixes, truster+":")
+		total += len(edges)
+	}
+	sort.Strings(prefixes)
+
+	skip := params.Offset
+	want := params.Limit
+	if cur != nil {
+		skip = 0
+		want = params.Limit + 1 // one extra to learn HasMore
+	}
+	page := make([]trustRegistryEntry, 0, min(want, total))
+	for _, prefix := range prefixes {
+		if len(page) == want {
+			break
+		}
+		truster := strings.TrimSuffix(prefix, ":")
+		edges := node.TrustRegistry[truster]
+		after := ""
+		if cur != nil && cur.After != "" {
+			switch {
+			case strings.HasPrefix(cur.After, prefix):
+				after = cur.After[len(prefix):]
+			case prefix < cur.After:
+				continue
+			}
+		}
+		if skip >= len(edges) {
+			skip -= len(edges)
+			continue
+		}
+		trustees := sortedKeys(edges)
+		if after != "" {
+			trustees = trustees[sort.SearchStrings(trustees, after+"\x00"):]
+		}
+		trustees = trustees[skip:]
+		skip = 0
+		for _, trustee := range trustees {
+			if len(page) == want {
+				break
+			}
+			page = append(page, trustRegistryEntry{Truster: truster, Trustee: trustee, TrustLevel: edges[trustee]})
+		}
+	}
+
+	var meta CursorMeta
+	if cur != nil {
+		meta = CursorMeta{Limit: params.Limit, Height: cur.Height, HasMore: len(page) > params.Limit}
+		if meta.HasMore {
+			page = page[:params.Limit]
+			meta.NextCursor = encodePageCursor(PageCursor{Height: cur.Height, After: trustRegistryEntryKey(page[len(page)-1])})
+		}
+	}
+	return page, total, meta
+}
+
+// identityRegistryEntry is one identity in an identity registry
+// listing.
+type identityRegistryEntry struct {
+	QuidID   string                 `json:"quid_id"`
+	Identity map[string]interface{} `json:"identity"`
+}
+
+// identityRegistryPage selects a page of identities ordered by quid
+// ID; see trustRegistryPage for the offset/cursor split.
+func (node *QuidnugNode) identityRegistryPage(params PaginationParams, cur *PageCursor) ([]identityRegistryEntry, int, CursorMeta) {
+	node.IdentityRegistryMutex.RLock()
+	defer node.IdentityRegistryMutex.RUnlock()
+
+	keys, total, meta := pageRegistryKeys(sortedKeys(node.IdentityRegistry), params, cur)
+	page := make([]identityRegistryEntry, 0, len(keys))
+	for _, quidID := range keys {
+		identity := node.IdentityRegistry[quidID]
+		page = append(page, identityRegistryEntry{
+			QuidID: quidID,
+			Identity: map[string]interface{}{
+				"quidId":      identity.QuidID,
+				"name":        identity.Name,
+				"description": identity.Description,
+				"attributes":  identity.Attributes,
+				"creator":     identity.Creator,
+				"updateNonce": identity.UpdateNonce,
+			},
+		})
+	}
+	return page, total, meta
+}
+
+// titleRegistryEntry is one title in a title registry listing.
+type titleRegistryEntry struct {
+	AssetID string      `json:"asset_id"`
+	Title   interface{} `json:"title"`
+}
+
+// titleRegistryPage selects a page of titles ordered by asset ID;
+// see trustRegistryPage for the offset/cursor split.
+func (node *QuidnugNode) titleRegistryPage(params PaginationParams, cur *PageCursor) ([]titleRegistryEntry, int, CursorMeta) {
+	node.TitleRegistryMutex.RLock()
+	defer node.TitleRegistryMutex.RUnlock()
+
+	keys, total, meta := pageRegistryKeys(sortedKeys(node.TitleRegistry), params, cur)
+	page := make([]titleRegistryEntry, 0, len(keys))
+	for _, assetID := range keys {
+		page = append(page, titleRegistryEntry{AssetID: assetID, Title: node.TitleRegistry[assetID]})
+	}
+	return page, total, meta
+}
+
+// pageRegistryKeys picks a page out of sorted keys by offset, or
+// after cur.After when cur is set.
+func pageRegistryKeys(keys []string, params PaginationParams, cur *PageCursor) ([]string, int, CursorMeta) {
+	if cur != nil {
+		page, meta := paginateByKey(keys, func(k string) string { return k }, *cur, params.Limit)
+		return page, len(keys), meta
+	}
+	page, total := paginateSlice(keys, params)
+	return page, total, CursorMeta{}
+}
+
+// writeRegistryPage serves a registry listing page in offset or
+// cursor mode, streamed.
+func writeRegistryPage[T any](w http.ResponseWriter, r *http.Request, height int64,
+	pageFn func(PaginationParams, *PageCursor) ([]T, int, CursorMeta), key func(T) string) {
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	cur, ok, err := parsePageCursor(r, height)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if ok {
+		page, _, meta := pageFn(params, &cur)
+		writeStreamedCursorPage(w, page, key, meta, nil)
+		return
+	}
+	page, total, _ := pageFn(params, nil)
+	writeStreamedOffsetPage(w, page, params, total, nil)
+}
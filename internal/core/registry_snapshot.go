@@ -0,0 +1,326 @@
+// Signed registry snapshots in the block archive.
+//
+// Pruned blocks answer "what was in block N", but reconstructing
+// "what did the registries look like at time T" from them means
+// replaying history. When a block archive is configured the node
+// therefore also pushes a signed copy of its committed trust,
+// identity and title registries every RegistrySnapshotInterval.
+// Snapshots are keyed by creation time, so a historical query
+// fetches the newest snapshot at or before the requested instant.
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/quidnug/quidnug/internal/blockarchive"
+)
+
+// RegistrySnapshotSchemaVersion is the snapshot wire format.
+const RegistrySnapshotSchemaVersion = 1
+
+const registrySnapshotKeyPrefix = "snapshots/registry/"
+
+// Errors from registry snapshot operations.
+var (
+	ErrArchiveDisabled           = errors.New("block archive not configured")
+	ErrRegistrySnapshotNotFound  = errors.New("registry snapshot: none at or before the requested time")
+	ErrRegistrySnapshotSignature = errors.New("registry snapshot: signature verification failed")
+)
+
+// RegistrySnapshot is a signed point-in-time copy of the committed
+// registries. Signature covers the JSON encoding with Signature
+// empty, made with the producing node's key.
+type RegistrySnapshot struct {
+	SchemaVersion    int                            `json:"schemaVersion"`
+	ProducerID       string                         `json:"producerId"`
+	PublicKey        string                         `json:"publicKey"`
+	CreatedAt        int64                          `json:"createdAt"`
+	ChainHeight      int64                          `json:"chainHeight"`
+	TipHash          string                         `json:"tipHash"`
+	TrustRegistry    map[string]map[string]float64  `json:"trustRegistry"`
+	IdentityRegistry map[string]IdentityTransaction `json:"identityRegistry"`
+	TitleRegistry    map[string]TitleTransaction    `json:"titleRegistry"`
+	Signature        string                         `json:"signature"`
+}
+
+// RegistrySnapshotInfo describes an archived snapshot without its
+// contents.
+type RegistrySnapshotInfo struct {
+	CreatedAt int64  `json:"createdAt"`
+	Key       string `json:"key"`
+}
+
+func registrySnapshotKey(createdAt int64) string {
+	return fmt.Sprintf("%s%020d.json.gz", registrySnapshotKeyPrefix, createdAt)
+}
+
+// buildRegistrySnapshot copies the registries and signs the result.
+func (node *QuidnugNode) buildRegistrySnapshot() (RegistrySnapshot, error) {
+	snap := RegistrySnapshot{
+		SchemaVersion:    RegistrySnapshotSchemaVersion,
+		ProducerID:       node.NodeID,
+		PublicKey:        node.GetPublicKeyHex(),
+		CreatedAt:        time.Now().Unix(),
+		TrustRegistry:    make(map[string]map[string]float64),
+		IdentityRegistry: make(map[string]IdentityTransaction),
+		TitleRegistry:    make(map[string]TitleTransaction),
+	}
+
+	node.BlockchainMutex.RLock()
+	if n := len(node.Blockchain); n > 0 {
+		snap.ChainHeight = node.Blockchain[n-1].Index
+		snap.TipHash = node.Blockchain[n-1].Hash
+	}
+	node.BlockchainMutex.RUnlock()
+
+	node.TrustRegistryMutex.RLock()
+	for truster, edges := range node.TrustRegistry {
+		cp := make(map[string]float64, len(edges))
+		for trustee, level := range edges {
+			cp[trustee] = level
+		}
+		snap.TrustRegistry[truster] = cp
+	}
+	node.TrustRegistryMutex.RUnlock()
+
+	node.IdentityRegistryMutex.RLock()
+	for id, identity := range node.IdentityRegistry {
+		snap.IdentityRegistry[id] = identity
+	}
+	node.IdentityRegistryMutex.RUnlock()
+
+	node.TitleRegistryMutex.RLock()
+	for id, title := range node.TitleRegistry {
+		snap.TitleRegistry[id] = title
+	}
+	node.TitleRegistryMutex.RUnlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return RegistrySnapshot{}, err
+	}
+	sig, err := node.SignData(data)
+	if err != nil {
+		return RegistrySnapshot{}, err
+	}
+	snap.Signature = hex.EncodeToString(sig)
+	return snap, nil
+}
+
+// PublishRegistrySnapshot signs the current registries and writes
+// them to the block archive.
+func (node *QuidnugNode) PublishRegistrySnapshot(ctx context.Context) (RegistrySnapshotInfo, error) {
+	if node.BlockArchive == nil {
+		return RegistrySnapshotInfo{}, ErrArchiveDisabled
+	}
+	snap, err := node.buildRegistrySnapshot()
+	if err != nil {
+		return RegistrySnapshotInfo{}, err
+	}
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return RegistrySnapshotInfo{}, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return RegistrySnapshotInfo{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return RegistrySnapshotInfo{}, err
+	}
+
+	info := RegistrySnapshotInfo{CreatedAt: snap.CreatedAt, Key: registrySnapshotKey(snap.CreatedAt)}
+	ctx, cancel := context.WithTimeout(ctx, blockArchiveTimeout)
+	defer cancel()
+	if err := node.BlockArchive.Put(ctx, info.Key, buf.Bytes()); err != nil {
+		return RegistrySnapshotInfo{}, err
+	}
+	logger.Info("Published registry snapshot", "key", info.Key, "chainHeight", snap.ChainHeight)
+	return info, nil
+}
+
+// ListRegistrySnapshots returns archived snapshots, oldest first.
+func (node *QuidnugNode) ListRegistrySnapshots(ctx context.Context) ([]RegistrySnapshotInfo, error) {
+	if node.BlockArchive == nil {
+		return nil, ErrArchiveDisabled
+	}
+	keys, err := node.BlockArchive.List(ctx, registrySnapshotKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RegistrySnapshotInfo, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, registrySnapshotKeyPrefix), ".json.gz")
+		createdAt, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, RegistrySnapshotInfo{CreatedAt: createdAt, Key: key})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out, nil
+}
+
+// FetchRegistrySnapshot returns the newest archived snapshot created
+// at or before at (Unix seconds), after verifying its signature.
+func (node *QuidnugNode) FetchRegistrySnapshot(ctx context.Context, at int64) (RegistrySnapshot, error) {
+	infos, err := node.ListRegistrySnapshots(ctx)
+	if err != nil {
+		return RegistrySnapshot{}, err
+	}
+	idx := sort.Search(len(infos), func(i int) bool { return infos[i].CreatedAt > at }) - 1
+	if idx < 0 {
+		return RegistrySnapshot{}, ErrRegistrySnapshotNotFound
+	}
+
+	data, err := node.BlockArchive.Get(ctx, infos[idx].Key)
+	if err != nil {
+		return RegistrySnapshot{}, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return RegistrySnapshot{}, fmt.Errorf("registry snapshot %s: %w", infos[idx].Key, err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return RegistrySnapshot{}, fmt.Errorf("registry snapshot %s: %w", infos[idx].Key, err)
+	}
+	var snap RegistrySnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return RegistrySnapshot{}, fmt.Errorf("registry snapshot %s: %w", infos[idx].Key, err)
+	}
+
+	signable := snap
+	signable.Signature = ""
+	signed, err := json.Marshal(signable)
+	if err != nil || !VerifySignature(snap.PublicKey, signed, snap.Signature) {
+		return RegistrySnapshot{}, ErrRegistrySnapshotSignature
+	}
+	return snap, nil
+}
+
+// runRegistrySnapshotLoop publishes a snapshot every interval until
+// ctx is cancelled.
+func (node *QuidnugNode) runRegistrySnapshotLoop(ctx context.Context, interval time.Duration) {
+	if node.BlockArchive == nil || interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if _, err := node.PublishRegistrySnapshot(ctx); err != nil {
+				logger.Warn("Registry snapshot publish failed", "error", err)
+			}
+		}
+	}
+}
+
+// writeArchiveError maps archive errors onto API responses.
+func writeArchiveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrArchiveDisabled):
+		WriteError(w, http.StatusServiceUnavailable, "ARCHIVE_UNAVAILABLE", err.Error())
+	case errors.Is(err, ErrRegistrySnapshotNotFound), errors.Is(err, blockarchive.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, ErrRegistrySnapshotSignature), errors.Is(err, ErrArchivedBlockMismatch):
+		WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	default:
+		logger.Warn("Block archive request failed", "error", err)
+		WriteError(w, http.StatusServiceUnavailable, "ARCHIVE_UNAVAILABLE", "block archive request failed")
+	}
+}
+
+// ListRegistrySnapshotsHandler lists archived registry snapshots.
+func (node *QuidnugNode) ListRegistrySnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	infos, err := node.ListRegistrySnapshots(r.Context())
+	if err != nil {
+		writeArchiveError(w, err)
+		return
+	}
+	params := ParsePaginationParams(r, DefaultPaginationLimit, MaxPaginationLimit)
+	page, total := paginateSlice(infos, params)
+	WriteSuccess(w, map[string]interface{}{
+		"data": page,
+		"pagination": PaginationMeta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
+}
+
+// GetRegistrySnapshotHandler returns the registries as of {at}
+// (Unix seconds, or "latest").
+func (node *QuidnugNode) GetRegistrySnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	atParam := mux.Vars(r)["at"]
+	at := time.Now().Unix()
+	if atParam != "latest" {
+		v, err := strconv.ParseInt(atParam, 10, 64)
+		if err != nil || v < 0 {
+			WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "at must be Unix seconds or 'latest'")
+			return
+		}
+		at = v
+	}
+	snap, err := node.FetchRegistrySnapshot(r.Context(), at)
+	if err != nil {
+		writeArchiveError(w, err)
+		return
+	}
+	WriteSuccess(w, snap)
+}
+
+// GetArchivedBlockHandler fetches a pruned block's body by domain
+// and hash. Blocks still held in full are served from the chain.
+func (node *QuidnugNode) GetArchivedBlockHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	domain, hash := vars["domain"], vars["hash"]
+
+	var header *Block
+	node.BlockchainMutex.RLock()
+	for i := len(node.Blockchain) - 1; i >= 0; i-- {
+		b := node.Blockchain[i]
+		if b.Hash == hash && b.TrustProof.TrustDomain == domain {
+			header = &b
+			break
+		}
+	}
+	node.BlockchainMutex.RUnlock()
+
+	if header == nil {
+		WriteError(w, http.StatusNotFound, "NOT_FOUND", "Block not found")
+		return
+	}
+	if !header.Pruned {
+		WriteSuccess(w, header)
+		return
+	}
+	if node.BlockArchive == nil {
+		writeArchiveError(w, ErrArchiveDisabled)
+		return
+	}
+	block, err := node.fetchArchivedBlock(r.Context(), *header)
+	if err != nil {
+		writeArchiveError(w, err)
+		return
+	}
+	WriteSuccess(w, block)
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/blockarchive"
+)
+
+func TestRegistrySnapshot_PublishAndFetch(t *testing.T) {
+	node := newTestNode()
+	archive := blockarchive.NewMemoryArchive()
+	node.BlockArchive = archive
+
+	info, err := node.PublishRegistrySnapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := node.FetchRegistrySnapshot(context.Background(), info.CreatedAt)
+	if err != nil {
+		t.Fatalf("FetchRegistrySnapshot: %v", err)
+	}
+	if snap.ProducerID != node.NodeID || snap.CreatedAt != info.CreatedAt {
+		t.Fatalf("unexpected snapshot header %+v", snap)
+	}
+	if _, ok := snap.IdentityRegistry["0000000000000001"]; !ok {
+		t.Fatal("identity registry missing from snapshot")
+	}
+	if _, ok := snap.TitleRegistry["0000000000000003"]; !ok {
+		t.Fatal("title registry missing from snapshot")
+	}
+
+	if _, err := node.FetchRegistrySnapshot(context.Background(), info.CreatedAt-1); !errors.Is(err, ErrRegistrySnapshotNotFound) {
+		t.Fatalf("expected ErrRegistrySnapshotNotFound before the first snapshot, got %v", err)
+	}
+
+	// Tamper with the stored snapshot.
+	snap.TrustRegistry["0000000000000009"] = map[string]float64{"0000000000000001": 1}
+	raw, _ := json.Marshal(snap)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(raw)
+	_ = zw.Close()
+	if err := archive.Put(context.Background(), info.Key, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.FetchRegistrySnapshot(context.Background(), info.CreatedAt); !errors.Is(err, ErrRegistrySnapshotSignature) {
+		t.Fatalf("expected ErrRegistrySnapshotSignature, got %v", err)
+	}
+}
+
+func TestArchiveHandlers(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/snapshots", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without archive: expected 503, got %d", rr.Code)
+	}
+
+	node.BlockArchive = blockarchive.NewMemoryArchive()
+	node.BlockRetention = 1
+	appendArchiveTestBlocks(node, 2)
+	if _, err := node.PruneBlocks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	info, err := node.PublishRegistrySnapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/snapshots", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(info.Key)) {
+		t.Fatalf("list: %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, at := range []string{"latest", strconv.FormatInt(info.CreatedAt, 10)} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/snapshots/"+at, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("get %s: expected 200, got %d: %s", at, rr.Code, rr.Body.String())
+		}
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/snapshots/yesterday", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("bad at: expected 400, got %d", rr.Code)
+	}
+
+	pruned := node.Blockchain[1]
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/blocks/test.domain.com/"+pruned.Hash, nil))
+	var resp struct {
+		Data Block `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("archived block: %d %s", rr.Code, rr.Body.String())
+	}
+	if resp.Data.Pruned || len(resp.Data.Transactions) != 1 {
+		t.Fatalf("expected full archived body, got %+v", resp.Data)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/archive/blocks/test.domain.com/unknown", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown block: expected 404, got %d", rr.Code)
+	}
+}
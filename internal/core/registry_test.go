@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -37,7 +38,7 @@ func TestGetDirectTrustees(t *testing.T) {
 func TestComputeRelationalTrust_SameEntity(t *testing.T) {
 	node := newTestNode()
 
-	trust, path, err := node.ComputeRelationalTrust("aaaa111111111111", "aaaa111111111111", 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "aaaa111111111111", 5)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -59,7 +60,7 @@ func TestComputeRelationalTrust_DirectTrust(t *testing.T) {
 		"bbbb222222222222": 0.8,
 	}
 
-	trust, path, err := node.ComputeRelationalTrust("aaaa111111111111", "bbbb222222222222", 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "bbbb222222222222", 5)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -86,7 +87,7 @@ func TestComputeRelationalTrust_TwoHopWithDecay(t *testing.T) {
 		"cccc333333333333": 0.5,
 	}
 
-	trust, path, err := node.ComputeRelationalTrust("aaaa111111111111", "cccc333333333333", 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "cccc333333333333", 5)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -110,7 +111,7 @@ func TestComputeRelationalTrust_NoPath(t *testing.T) {
 	node := newTestNode()
 
 	// A has no trust relationships
-	trust, path, err := node.ComputeRelationalTrust("aaaa111111111111", "bbbb222222222222", 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "bbbb222222222222", 5)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -141,7 +142,7 @@ func TestComputeRelationalTrust_CycleHandling(t *testing.T) {
 	}
 
 	// Should find A -> B -> D with trust 0.8 * 0.9 = 0.72
-	trust, path, err := node.ComputeRelationalTrust("aaaa111111111111", "dddd444444444444", 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "dddd444444444444", 5)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -167,7 +168,7 @@ func TestComputeRelationalTrust_DepthLimit(t *testing.T) {
 	node.TrustRegistry["dddd444444444444"] = map[string]float64{"eeee555555555555": 0.9}
 
 	// With maxDepth=2, should not reach E (4 hops away)
-	trust, path, _ := node.ComputeRelationalTrust("aaaa111111111111", "eeee555555555555", 2)
+	trust, path, _ := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "eeee555555555555", 2)
 
 	if trust != 0.0 {
 		t.Errorf("Expected trust 0.0 with maxDepth=2, got %f", trust)
@@ -178,7 +179,7 @@ func TestComputeRelationalTrust_DepthLimit(t *testing.T) {
 	}
 
 	// With maxDepth=4, should reach E
-	trust, path, _ = node.ComputeRelationalTrust("aaaa111111111111", "eeee555555555555", 4)
+	trust, path, _ = node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "eeee555555555555", 4)
 
 	expected := 0.9 * 0.9 * 0.9 * 0.9
 	if !floatEquals(trust, expected, 0.0001) {
@@ -201,7 +202,7 @@ func TestComputeRelationalTrust_DefaultDepth(t *testing.T) {
 	node.TrustRegistry["eeee555555555555"] = map[string]float64{"ffff666666666666": 0.9}
 
 	// With maxDepth=0 (default 5), should reach F
-	trust, path, _ := node.ComputeRelationalTrust("aaaa111111111111", "ffff666666666666", 0)
+	trust, path, _ := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "ffff666666666666", 0)
 
 	if trust == 0.0 {
 		t.Errorf("Expected non-zero trust with default depth, got 0")
@@ -229,7 +230,7 @@ func TestComputeRelationalTrust_BestPathSelection(t *testing.T) {
 		"dddd444444444444": 0.9,
 	}
 
-	trust, path, _ := node.ComputeRelationalTrust("aaaa111111111111", "dddd444444444444", 5)
+	trust, path, _ := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "dddd444444444444", 5)
 
 	expected := 0.9 * 0.9
 	if trust != expected {
@@ -254,7 +255,7 @@ func TestComputeRelationalTrust_Distrust(t *testing.T) {
 		"cccc333333333333": 0.3,
 	}
 
-	trust, _, _ := node.ComputeRelationalTrust("aaaa111111111111", "cccc333333333333", 5)
+	trust, _, _ := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "cccc333333333333", 5)
 
 	expected := 0.8 * 0.3
 	if trust != expected {
@@ -659,7 +660,7 @@ func TestComputeRelationalTrust_LongerPathBetterTrust(t *testing.T) {
 		"dddd444444444444": 0.9,
 	}
 
-	trust, path, _ := node.ComputeRelationalTrust("aaaa111111111111", "dddd444444444444", 5)
+	trust, path, _ := node.ComputeRelationalTrust(context.Background(), "aaaa111111111111", "dddd444444444444", 5)
 
 	expected := 0.9 * 0.9 * 0.9
 	if !floatEquals(trust, expected, 0.0001) {
@@ -0,0 +1,89 @@
+// Machine-readable reasons for rejected transactions.
+//
+// The Validate* functions answer yes or no and log why, which left
+// API clients with "invalid trust transaction" and nothing to act
+// on. The trust, identity, event and title checks now return a
+// *TxRejection naming the rule that failed (Code) and, where there
+// is one, the offending field; the Validate* wrappers keep their
+// boolean form for block validation. Submission handlers put Code
+// in the error envelope's "code" and Field in its "fields".
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Rejection codes. Clients may switch on these; the messages that
+// go with them are for people and may change.
+const (
+	RejectUnknownDomain      = "UNKNOWN_DOMAIN"
+	RejectMissingField       = "MISSING_FIELD"
+	RejectInvalidField       = "INVALID_FIELD"
+	RejectInvalidQuidID      = "INVALID_QUID_ID"
+	RejectInvalidNonce       = "INVALID_NONCE"
+	RejectNonceTooLow        = "NONCE_TOO_LOW"
+	RejectNonceReplay        = "NONCE_REPLAY"
+	RejectInvalidTrustLevel  = "INVALID_TRUST_LEVEL"
+	RejectAlreadyExpired     = "ALREADY_EXPIRED"
+	RejectMissingSignature   = "MISSING_SIGNATURE"
+	RejectBadSignature       = "BAD_SIGNATURE"
+	RejectUnknownSubject     = "UNKNOWN_SUBJECT"
+	RejectNotOwner           = "NOT_OWNER"
+	RejectCreatorMismatch    = "CREATOR_MISMATCH"
+	RejectImmutableField     = "IMMUTABLE_FIELD"
+	RejectAssetClass         = "ASSET_CLASS"
+	RejectSequenceTooLow     = "SEQUENCE_TOO_LOW"
+	RejectPayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	RejectOwnershipSum       = "OWNERSHIP_SUM"
+	RejectInvalidStake       = "INVALID_STAKE"
+	RejectTitleRetired       = "TITLE_RETIRED"
+	RejectOwnersMismatch     = "PREVIOUS_OWNERS_MISMATCH"
+	RejectTitleExpired       = "TITLE_EXPIRED"
+	RejectTitleFrozen        = "TITLE_FROZEN"
+	RejectMissingCoSignature = "MISSING_COSIGNATURE"
+	RejectEscrowConflict     = "ESCROW_CONFLICT"
+	RejectAntiSpam           = "ANTI_SPAM"
+	RejectHook               = "HOOK_REJECTED"
+	RejectEncoding           = "INVALID_ENCODING"
+)
+
+// TxRejection is why a transaction was refused.
+type TxRejection struct {
+	Code    string
+	Field   string // JSON name of the offending field; "" when none
+	Message string
+	Err     error // underlying cause, if any
+}
+
+func (e *TxRejection) Error() string { return e.Message }
+
+func (e *TxRejection) Unwrap() error { return e.Err }
+
+// reject builds a rejection with a formatted message.
+func reject(code, field, format string, args ...interface{}) *TxRejection {
+	return &TxRejection{Code: code, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// rejectErr builds a rejection around cause, prefixing its message.
+func rejectErr(code, field, prefix string, cause error) *TxRejection {
+	return &TxRejection{Code: code, Field: field, Message: prefix + ": " + cause.Error(), Err: cause}
+}
+
+// writeTxRejection writes err from a transaction submission: its
+// rejection code when it has one, 429 for rate limiting, and
+// BAD_REQUEST otherwise.
+func writeTxRejection(w http.ResponseWriter, err error) {
+	var rej *TxRejection
+	switch {
+	case errors.As(err, &rej) && rej.Field != "":
+		WriteFieldError(w, rej.Code, err.Error(), []string{rej.Field})
+	case errors.As(err, &rej):
+		WriteError(w, http.StatusBadRequest, rej.Code, err.Error())
+	case errors.Is(err, ErrRateLimited):
+		WriteError(w, http.StatusTooManyRequests, "RATE_LIMITED", err.Error())
+	default:
+		WriteError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckTrustTransaction_Codes(t *testing.T) {
+	node := newTestNode()
+	base := TrustTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTrust, TrustDomain: "test.domain.com", Timestamp: 1000000},
+		Truster:         "0000000000000001",
+		Trustee:         "0000000000000002",
+		TrustLevel:      0.5,
+		Nonce:           1,
+	}
+
+	// Each case breaks base before signing; "tampered" changes it after.
+	cases := []struct {
+		name      string
+		mutate    func(*TrustTransaction)
+		wantCode  string
+		wantField string
+	}{
+		{"unknown domain", func(tx *TrustTransaction) { tx.TrustDomain = "nowhere.example" }, RejectUnknownDomain, "trustDomain"},
+		{"zero nonce", func(tx *TrustTransaction) { tx.Nonce = 0 }, RejectInvalidNonce, "nonce"},
+		{"trust level", func(tx *TrustTransaction) { tx.TrustLevel = 1.5 }, RejectInvalidTrustLevel, "trustLevel"},
+		{"bad trustee", func(tx *TrustTransaction) { tx.Trustee = "not-a-quid" }, RejectInvalidQuidID, "trustee"},
+		{"tampered", nil, RejectBadSignature, "signature"},
+	}
+	for _, c := range cases {
+		tx := base
+		if c.mutate != nil {
+			c.mutate(&tx)
+		}
+		tx = signTrustTx(node, tx)
+		if c.mutate == nil {
+			tx.TrustLevel = 0.9
+		}
+		err := node.checkTrustTransaction(tx)
+		var rej *TxRejection
+		if !errors.As(err, &rej) {
+			t.Errorf("%s: got %v, want a rejection", c.name, err)
+			continue
+		}
+		if rej.Code != c.wantCode || rej.Field != c.wantField {
+			t.Errorf("%s: got %s/%s, want %s/%s", c.name, rej.Code, rej.Field, c.wantCode, c.wantField)
+		}
+		if node.ValidateTrustTransaction(tx) {
+			t.Errorf("%s: ValidateTrustTransaction should still reject", c.name)
+		}
+	}
+
+	var rej *TxRejection
+	if err := node.checkTrustTransaction(base); !errors.As(err, &rej) || rej.Code != RejectMissingSignature {
+		t.Errorf("unsigned: got %v, want %s", err, RejectMissingSignature)
+	}
+	if err := node.checkTrustTransaction(signTrustTx(node, base)); err != nil {
+		t.Errorf("valid transaction rejected: %v", err)
+	}
+}
+
+func TestCheckTitleTransaction_OwnershipSum(t *testing.T) {
+	node := newTestNode()
+	node.IdentityRegistry[node.NodeID] = IdentityTransaction{BaseTransaction: BaseTransaction{PublicKey: node.GetPublicKeyHex()}, QuidID: node.NodeID}
+	tx := signTitleTx(node, TitleTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: 1000000},
+		AssetID:         "asset-half-owned",
+		Owners:          []OwnershipStake{{OwnerID: node.NodeID, Percentage: 0.5}},
+	})
+	var rej *TxRejection
+	if err := node.checkTitleTransaction(tx); !errors.As(err, &rej) || rej.Code != RejectOwnershipSum {
+		t.Errorf("got %v, want %s", err, RejectOwnershipSum)
+	}
+}
+
+func TestCreateTrustTransactionHandler_RejectionEnvelope(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	body, _ := json.Marshal(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", Timestamp: 1000000},
+		Truster:         "0000000000000001",
+		Trustee:         "0000000000000002",
+		TrustLevel:      2,
+		Nonce:           1,
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/transactions/trust", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Error struct {
+			Code    string   `json:"code"`
+			Message string   `json:"message"`
+			Fields  []string `json:"fields"`
+		} `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Error.Code != RejectInvalidTrustLevel || len(resp.Error.Fields) != 1 || resp.Error.Fields[0] != "trustLevel" {
+		t.Errorf("unexpected envelope: %s", w.Body.String())
+	}
+}
+
+func TestWriteTxRejection_RateLimited(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeTxRejection(w, ErrRateLimited)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", w.Code)
+	}
+}
@@ -0,0 +1,402 @@
+// Deterministic block replay.
+//
+// ReplayBlocks feeds a sequence of blocks through ValidateBlockTiered
+// and processBlockTransactions on fresh nodes, several times over,
+// and compares a digest of the resulting registries. Every run uses
+// the same frozen clock, so any difference between runs is
+// nondeterminism in validation or state application (map iteration
+// leaking into state, wall-clock reads, goroutine ordering). Panics
+// are recovered per block and reported with the block and stage
+// that raised them, which is what malformed interface{}
+// transactions tend to produce.
+//
+// The input is either a recorded chain (the data of /api/v1/blocks)
+// or blocks from FuzzBlocks. Recorded validators are registered and
+// trusted up front so their blocks replay as Trusted; unsigned fuzz
+// blocks validate as Invalid and only reach processBlockTransactions
+// with ReplayOptions.ProcessAll.
+//
+// The clock override is package-wide: do not replay in a process
+// that is also running a live node.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultReplayRuns is how many times ReplayBlocks replays the input
+// when ReplayOptions.Runs is unset.
+const DefaultReplayRuns = 3
+
+// replayMu serializes replays: they share the package clock.
+var replayMu sync.Mutex
+
+// ReplayOptions controls ReplayBlocks.
+type ReplayOptions struct {
+	// Runs is how many independent replays to compare.
+	Runs int
+	// Clock is the frozen "now" for every run. Zero uses the newest
+	// block timestamp, so expiry checks see the chain's own era.
+	Clock time.Time
+	// ProcessAll applies every block's transactions, not only those
+	// of blocks that validate as Trusted. Fuzzing uses it to reach
+	// processBlockTransactions with malformed transactions.
+	ProcessAll bool
+}
+
+// ReplayPanic is a panic recovered while replaying one block.
+type ReplayPanic struct {
+	Run        int    `json:"run"`
+	BlockIndex int64  `json:"blockIndex"`
+	BlockHash  string `json:"blockHash"`
+	Stage      string `json:"stage"` // "validate" or "process"
+	Value      string `json:"value"`
+	Stack      string `json:"stack,omitempty"`
+}
+
+// ReplayRun is the outcome of one replay.
+type ReplayRun struct {
+	Digest    string `json:"digest"`
+	Trusted   int    `json:"trusted"`
+	Tentative int    `json:"tentative"`
+	Untrusted int    `json:"untrusted"`
+	Invalid   int    `json:"invalid"`
+}
+
+// ReplayReport is the outcome of ReplayBlocks.
+type ReplayReport struct {
+	Blocks        int           `json:"blocks"`
+	Clock         int64         `json:"clock"`
+	Runs          []ReplayRun   `json:"runs"`
+	Deterministic bool          `json:"deterministic"`
+	Panics        []ReplayPanic `json:"panics,omitempty"`
+}
+
+// OK reports whether every run matched and none panicked.
+func (r *ReplayReport) OK() bool {
+	return r.Deterministic && len(r.Panics) == 0
+}
+
+// ReplayBlocks replays blocks opts.Runs times and reports whether
+// the runs agree.
+func ReplayBlocks(blocks []Block, opts ReplayOptions) (*ReplayReport, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("replay: no blocks")
+	}
+	runs := opts.Runs
+	if runs <= 0 {
+		runs = DefaultReplayRuns
+	}
+	clock := opts.Clock
+	if clock.IsZero() {
+		var newest int64
+		for _, b := range blocks {
+			if b.Timestamp > newest {
+				newest = b.Timestamp
+			}
+		}
+		clock = time.Unix(newest, 0)
+	}
+
+	replayMu.Lock()
+	defer replayMu.Unlock()
+	previous := clockOverrideNano.Load()
+	setTestClockNano(clock.UnixNano())
+	defer setTestClockNano(previous)
+
+	report := &ReplayReport{Blocks: len(blocks), Clock: clock.Unix(), Deterministic: true}
+	for i := 0; i < runs; i++ {
+		run, panics, err := replayOnce(i, blocks, opts.ProcessAll)
+		if err != nil {
+			return nil, err
+		}
+		report.Runs = append(report.Runs, run)
+		report.Panics = append(report.Panics, panics...)
+		if run.Digest != report.Runs[0].Digest {
+			report.Deterministic = false
+		}
+	}
+	return report, nil
+}
+
+func replayOnce(run int, blocks []Block, processAll bool) (ReplayRun, []ReplayPanic, error) {
+	node, err := NewQuidnugNode(nil)
+	if err != nil {
+		return ReplayRun{}, nil, fmt.Errorf("replay: %w", err)
+	}
+	// Blocks are fed in directly; nothing should leave the process.
+	node.KnownNodes = map[string]Node{}
+	node.registerReplayValidators(blocks)
+
+	var out ReplayRun
+	var panics []ReplayPanic
+	guard := func(b Block, stage string, fn func()) (ok bool) {
+		defer func() {
+			if v := recover(); v != nil {
+				panics = append(panics, ReplayPanic{
+					Run: run, BlockIndex: b.Index, BlockHash: b.Hash, Stage: stage,
+					Value: fmt.Sprint(v), Stack: string(debug.Stack()),
+				})
+				ok = false
+			}
+		}()
+		fn()
+		return true
+	}
+
+	for _, b := range blocks {
+		if b.TrustProof.TrustDomain == "genesis" {
+			continue
+		}
+		acceptance := BlockInvalid
+		if !guard(b, "validate", func() { acceptance = node.ValidateBlockTiered(b) }) {
+			acceptance = BlockInvalid
+		}
+		switch acceptance {
+		case BlockTrusted:
+			out.Trusted++
+		case BlockTentative:
+			out.Tentative++
+		case BlockUntrusted:
+			out.Untrusted++
+		default:
+			out.Invalid++
+		}
+		if acceptance != BlockTrusted && !processAll {
+			continue
+		}
+		if acceptance == BlockTrusted {
+			node.BlockchainMutex.Lock()
+			node.Blockchain = append(node.Blockchain, b)
+			node.BlockchainMutex.Unlock()
+		}
+		guard(b, "process", func() { node.processBlockTransactions(b) })
+	}
+
+	out.Digest, err = node.replayDigest()
+	return out, panics, err
+}
+
+// registerReplayValidators registers every self-consistent
+// validator seen in blocks for its domain and has node trust it
+// fully, so a recorded chain replays as Trusted.
+func (node *QuidnugNode) registerReplayValidators(blocks []Block) {
+	var validators []string
+	node.TrustDomainsMutex.Lock()
+	for _, b := range blocks {
+		p := b.TrustProof
+		if p.TrustDomain == "" || p.TrustDomain == "genesis" || p.ValidatorID == "" {
+			continue
+		}
+		if QuidIDFromPublicKeyHex(p.ValidatorPublicKey) != p.ValidatorID {
+			continue
+		}
+		d, ok := node.TrustDomains[p.TrustDomain]
+		if !ok {
+			d = TrustDomain{
+				Name:                p.TrustDomain,
+				TrustThreshold:      0.75,
+				Validators:          map[string]float64{},
+				ValidatorPublicKeys: map[string]string{},
+			}
+		}
+		if _, known := d.ValidatorPublicKeys[p.ValidatorID]; !known {
+			d.ValidatorNodes = append(d.ValidatorNodes, p.ValidatorID)
+			d.Validators[p.ValidatorID] = 1.0
+			d.ValidatorPublicKeys[p.ValidatorID] = p.ValidatorPublicKey
+			validators = append(validators, p.ValidatorID)
+		}
+		node.TrustDomains[p.TrustDomain] = d
+	}
+	node.TrustDomainsMutex.Unlock()
+
+	for _, v := range validators {
+		node.AddVerifiedTrustEdge(TrustEdge{Truster: node.NodeID, Trustee: v, TrustLevel: 1.0})
+	}
+}
+
+// replayState is the registry state compared across replays. The
+// replaying node's own edges are left out: its key differs per run.
+type replayState struct {
+	Trust      map[string]map[string]float64  `json:"trust"`
+	TrustNonce map[string]map[string]int64    `json:"trustNonce"`
+	Identity   map[string]IdentityTransaction `json:"identity"`
+	Title      map[string]TitleTransaction    `json:"title"`
+	Events     map[string][]string            `json:"events"`
+}
+
+// replayDigest hashes the node's registries. encoding/json sorts
+// map keys, so equal state always yields the same digest.
+func (node *QuidnugNode) replayDigest() (string, error) {
+	st := replayState{
+		Trust:      map[string]map[string]float64{},
+		TrustNonce: map[string]map[string]int64{},
+		Identity:   map[string]IdentityTransaction{},
+		Title:      map[string]TitleTransaction{},
+		Events:     map[string][]string{},
+	}
+
+	node.TrustRegistryMutex.RLock()
+	for truster, edges := range node.TrustRegistry {
+		if truster == node.NodeID {
+			continue
+		}
+		st.Trust[truster] = edges
+	}
+	for truster, nonces := range node.TrustNonceRegistry {
+		st.TrustNonce[truster] = nonces
+	}
+	node.TrustRegistryMutex.RUnlock()
+
+	node.IdentityRegistryMutex.RLock()
+	for id, tx := range node.IdentityRegistry {
+		st.Identity[id] = tx
+	}
+	node.IdentityRegistryMutex.RUnlock()
+
+	node.TitleRegistryMutex.RLock()
+	for id, tx := range node.TitleRegistry {
+		st.Title[id] = tx
+	}
+	node.TitleRegistryMutex.RUnlock()
+
+	node.EventStreamMutex.RLock()
+	for subject, events := range node.EventRegistry {
+		ids := make([]string, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		st.Events[subject] = ids
+	}
+	node.EventStreamMutex.RUnlock()
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return "", fmt.Errorf("replay: digest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fuzzTxTypes are the transaction types FuzzBlocks draws from, plus
+// values no handler knows.
+var fuzzTxTypes = []TransactionType{
+	TxTypeTrust, TxTypeIdentity, TxTypeTitle, TxTypeEvent,
+	TxTypeNodeAdvertisement, TxTypeModerationAction, TxTypeNameRegistration,
+	TxTypeLien, TxTypeGeneric, TxTypeTransferApproval, TxTypeTitleRestructure,
+	TxTypeTitleDispute, "", "UNKNOWN",
+}
+
+// fuzzFields are the JSON fields the transaction types read. Each
+// fuzzed transaction gets a random subset with random values.
+var fuzzFields = []string{
+	"id", "trustDomain", "timestamp", "publicKey", "signature",
+	"truster", "trustee", "trustLevel", "nonce",
+	"quidId", "name", "updateNonce", "creator", "homeDomain",
+	"assetId", "owners", "previousOwners", "conditions",
+	"subjectId", "subjectType", "eventType", "payload", "sequence",
+	"nodeQuid", "operatorQuid", "endpoints", "expiresAt",
+}
+
+// FuzzBlocks generates n structurally random blocks from seed. The
+// same seed always yields the same blocks. Transactions arrive as
+// decoded JSON, as they do from a peer, with fields of arbitrary
+// type: the point is to exercise the interface{} decoding paths.
+func FuzzBlocks(seed int64, n int) []Block {
+	rng := rand.New(rand.NewSource(seed)) // #nosec G404 -- reproducible fuzz input
+	quids := make([]string, 6)
+	for i := range quids {
+		quids[i] = fmt.Sprintf("%016x", rng.Uint64())
+	}
+
+	blocks := make([]Block, 0, n)
+	for i := 0; i < n; i++ {
+		txs := make([]interface{}, rng.Intn(8))
+		for j := range txs {
+			txs[j] = fuzzTx(rng, quids)
+		}
+		b := Block{
+			Index:        int64(i + 1),
+			Timestamp:    1_700_000_000 + int64(i)*60,
+			Transactions: txs,
+			TrustProof: TrustProof{
+				TrustDomain: "fuzz.local",
+				ValidatorID: quids[rng.Intn(len(quids))],
+			},
+		}
+		b.Hash = calculateBlockHash(b)
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+func fuzzTx(rng *rand.Rand, quids []string) interface{} {
+	// Occasionally not an object at all.
+	if rng.Intn(20) == 0 {
+		return fuzzValue(rng, quids, 0)
+	}
+	tx := map[string]interface{}{"type": fuzzTxTypes[rng.Intn(len(fuzzTxTypes))]}
+	for _, f := range fuzzFields {
+		if rng.Intn(3) == 0 {
+			tx[f] = fuzzValue(rng, quids, 0)
+		}
+	}
+	return tx
+}
+
+func fuzzValue(rng *rand.Rand, quids []string, depth int) interface{} {
+	kinds := 8
+	if depth > 2 {
+		kinds = 6
+	}
+	switch rng.Intn(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return rng.Intn(2) == 0
+	case 2:
+		return float64(rng.Int63n(1<<53)) * []float64{1, -1, 1e-9}[rng.Intn(3)]
+	case 3:
+		return rng.Float64()
+	case 4:
+		return quids[rng.Intn(len(quids))]
+	case 5:
+		return ""
+	case 6:
+		arr := make([]interface{}, rng.Intn(4))
+		for i := range arr {
+			arr[i] = fuzzValue(rng, quids, depth+1)
+		}
+		return arr
+	default:
+		obj := map[string]interface{}{}
+		for i := rng.Intn(4); i > 0; i-- {
+			obj[fuzzFields[rng.Intn(len(fuzzFields))]] = fuzzValue(rng, quids, depth+1)
+		}
+		return obj
+	}
+}
+
+// SortBlocksForReplay orders blocks by timestamp, then domain and
+// index, the order a node would have received a recorded chain in.
+func SortBlocksForReplay(blocks []Block) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp < b.Timestamp
+		}
+		if a.TrustProof.TrustDomain != b.TrustProof.TrustDomain {
+			return a.TrustProof.TrustDomain < b.TrustProof.TrustDomain
+		}
+		return a.Index < b.Index
+	})
+}
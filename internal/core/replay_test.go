@@ -0,0 +1,131 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// recordReplayChain has a producer seal two blocks in a fresh
+// domain: both identities, then a trust edge between them.
+func recordReplayChain(t *testing.T) (producer, other *QuidnugNode, blocks []Block) {
+	t.Helper()
+	producer, _ = NewQuidnugNode(nil)
+	other, _ = NewQuidnugNode(nil)
+	const domain = "replay.test"
+	producer.TrustDomains[domain] = TrustDomain{
+		Name:                domain,
+		ValidatorNodes:      []string{producer.NodeID},
+		TrustThreshold:      0.75,
+		Validators:          map[string]float64{producer.NodeID: 1.0},
+		ValidatorPublicKeys: map[string]string{producer.NodeID: producer.GetPublicKeyHex()},
+	}
+
+	now := time.Now().Unix()
+	seal := func(txs ...interface{}) {
+		producer.PendingTxs = txs
+		block, err := producer.GenerateBlock(domain)
+		if err != nil {
+			t.Fatalf("GenerateBlock: %v", err)
+		}
+		if err := producer.AddBlock(*block); err != nil {
+			t.Fatalf("AddBlock: %v", err)
+		}
+		blocks = append(blocks, *block)
+	}
+	identity := func(n *QuidnugNode) IdentityTransaction {
+		return signIdentityTx(n, IdentityTransaction{
+			BaseTransaction: BaseTransaction{ID: "id-" + n.NodeID, Type: TxTypeIdentity, TrustDomain: domain, Timestamp: now},
+			QuidID:          n.NodeID,
+			Name:            n.NodeID,
+			Creator:         n.NodeID,
+			UpdateNonce:     1,
+		})
+	}
+	seal(identity(producer), identity(other))
+	seal(signTrustTx(producer, TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "trust-1", Type: TxTypeTrust, TrustDomain: domain, Timestamp: now},
+		Truster:         producer.NodeID,
+		Trustee:         other.NodeID,
+		TrustLevel:      0.7,
+		Nonce:           1,
+	}))
+	return producer, other, blocks
+}
+
+func TestReplayBlocks_RecordedChainIsDeterministic(t *testing.T) {
+	_, _, blocks := recordReplayChain(t)
+
+	report, err := ReplayBlocks(blocks, ReplayOptions{Runs: 3})
+	if err != nil {
+		t.Fatalf("ReplayBlocks: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("replay not clean: %+v", report)
+	}
+	for i, run := range report.Runs {
+		if run.Trusted != 2 || run.Invalid != 0 {
+			t.Fatalf("run %d: expected 2 trusted blocks, got %+v", i, run)
+		}
+	}
+	if clockOverrideNano.Load() != 0 {
+		t.Fatal("replay left the clock frozen")
+	}
+}
+
+func TestReplayBlocks_DigestReflectsState(t *testing.T) {
+	_, _, blocks := recordReplayChain(t)
+
+	full, err := ReplayBlocks(blocks, ReplayOptions{Runs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := ReplayBlocks(blocks[:1], ReplayOptions{Runs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full.Runs[0].Digest == partial.Runs[0].Digest {
+		t.Fatal("dropping the trust block should change the registry digest")
+	}
+}
+
+func TestFuzzBlocks_IsReproducible(t *testing.T) {
+	a, b := FuzzBlocks(42, 20), FuzzBlocks(42, 20)
+	for i := range a {
+		if a[i].Hash != b[i].Hash {
+			t.Fatalf("block %d differs between generations", i)
+		}
+	}
+	if FuzzBlocks(43, 1)[0].Hash == a[0].Hash {
+		t.Fatal("different seeds should yield different blocks")
+	}
+}
+
+func TestReplayBlocks_FuzzedBlocksDoNotPanic(t *testing.T) {
+	report, err := ReplayBlocks(FuzzBlocks(1, 200), ReplayOptions{Runs: 2, ProcessAll: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range report.Panics {
+		t.Errorf("panic in %s of block %d: %s\n%s", p.Stage, p.BlockIndex, p.Value, p.Stack)
+	}
+	if !report.Deterministic {
+		t.Errorf("fuzzed replay diverged: %+v", report.Runs)
+	}
+}
+
+// FuzzProcessBlockTransactions drives the same paths from Go's
+// fuzzer, with FuzzBlocks seeds as the input.
+func FuzzProcessBlockTransactions(f *testing.F) {
+	for _, seed := range []int64{0, 1, 2, 3} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		report, err := ReplayBlocks(FuzzBlocks(seed, 5), ReplayOptions{Runs: 2, ProcessAll: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.OK() {
+			t.Fatalf("seed %d: %+v", seed, report)
+		}
+	})
+}
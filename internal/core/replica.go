@@ -0,0 +1,218 @@
+// Read replica mode.
+//
+// A node started with node_role: replica is a read-only follower.
+// It never runs block generation and refuses every client write
+// with 403 READ_ONLY_REPLICA; its chain advances only by pulling
+// blocks from the configured upstream validators, which go through
+// the same ReceiveBlock validation as any other peer-served block.
+// Queries are answered from the local registries, so operators can
+// put any number of replicas behind a load balancer to absorb read
+// traffic.
+//
+// Upstreams are operator-configured, so they go on the private
+// address allow-list the same way allow_private static peers do.
+// The replica follows one upstream at a time. When a sync against
+// it fails the next upstream in the list takes over, wrapping
+// around, and the replica stays there until that one fails too.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// ErrNoReplicaUpstreams means a replica was configured without any
+// upstream to follow.
+var ErrNoReplicaUpstreams = errors.New("replica: no upstreams configured")
+
+// ReplicaUpstreams is the ordered upstream list of a replica and
+// the index of the one currently followed. Safe for concurrent use.
+type ReplicaUpstreams struct {
+	mu     sync.Mutex
+	addrs  []string
+	active int
+}
+
+// NewReplicaUpstreams returns a list that starts on addrs[0].
+func NewReplicaUpstreams(addrs []string) *ReplicaUpstreams {
+	return &ReplicaUpstreams{addrs: append([]string(nil), addrs...)}
+}
+
+// Active returns the upstream currently followed, or "" when the
+// list is empty.
+func (u *ReplicaUpstreams) Active() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.addrs) == 0 {
+		return ""
+	}
+	return u.addrs[u.active]
+}
+
+// Failover moves off failed and returns the new active upstream.
+// It is a no-op when failed is no longer active, so concurrent
+// failures against the same upstream advance the list only once.
+func (u *ReplicaUpstreams) Failover(failed string) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.addrs) == 0 {
+		return ""
+	}
+	if u.addrs[u.active] == failed {
+		u.active = (u.active + 1) % len(u.addrs)
+	}
+	return u.addrs[u.active]
+}
+
+// List returns a copy of the upstream addresses in order.
+func (u *ReplicaUpstreams) List() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.addrs...)
+}
+
+// newReplicaUpstreams validates the configured role and returns the
+// upstream list for a replica, or nil for any other role.
+func newReplicaUpstreams(cfg *config.Config) (*ReplicaUpstreams, error) {
+	switch cfg.NodeRole {
+	case "", NodeRoleValidator, NodeRoleGateway:
+		return nil, nil
+	case NodeRoleReplica:
+		if len(cfg.ReplicaUpstreams) == 0 {
+			return nil, ErrNoReplicaUpstreams
+		}
+		for _, addr := range cfg.ReplicaUpstreams {
+			if host, _, err := net.SplitHostPort(addr); err != nil || host == "" {
+				return nil, fmt.Errorf("replica upstream %q: want host:port", addr)
+			}
+		}
+		return NewReplicaUpstreams(cfg.ReplicaUpstreams), nil
+	default:
+		return nil, fmt.Errorf("unknown node role %q", cfg.NodeRole)
+	}
+}
+
+// IsReplica reports whether the node runs as a read-only replica.
+func (node *QuidnugNode) IsReplica() bool {
+	return node.Role == NodeRoleReplica
+}
+
+// syncFromUpstream pulls new blocks from the active upstream,
+// failing over through the rest of the list until one sync
+// succeeds. It returns the last error when every upstream failed.
+func (node *QuidnugNode) syncFromUpstream(ctx context.Context) error {
+	if node.ReplicaUpstreams == nil {
+		return ErrNoReplicaUpstreams
+	}
+	var lastErr error
+	for range node.ReplicaUpstreams.List() {
+		addr := node.ReplicaUpstreams.Active()
+		err := node.pullBlocksFromPeer(ctx, "", addr)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+		next := node.ReplicaUpstreams.Failover(addr)
+		replicaUpstreamFailoversTotal.WithLabelValues(addr).Inc()
+		logger.Warn("Replica upstream sync failed; failing over",
+			"upstream", addr, "next", next, "error", err)
+	}
+	return fmt.Errorf("replica: every upstream failed: %w", lastErr)
+}
+
+// runReplicaSyncLoop keeps a replica's chain in step with its
+// upstream, syncing once at start and then every interval.
+func (node *QuidnugNode) runReplicaSyncLoop(ctx context.Context, interval time.Duration) {
+	if !node.IsReplica() || interval <= 0 {
+		return
+	}
+	logger.Info("Starting replica sync loop",
+		"upstreams", node.ReplicaUpstreams.List(), "interval", interval)
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		if err := node.syncFromUpstream(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Replica sync failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+		}
+	}
+}
+
+// replicaWritablePaths are the non-GET endpoints a replica still
+// serves, matched after the /api, /api/v1 or /api/v2 prefix. Trust
+// queries and mempool fetches are POSTed but read-only; gossip only
+// refreshes peer metadata; quarantine review and conflict acks are
+// local operator actions.
+var replicaWritablePaths = []string{
+	"/trust/query",
+	"/trust/query/batch",
+	"/mempool/",
+	"/gossip/",
+	"/anchor-gossip",
+	"/domain-fingerprints",
+	"/blocks/quarantine/",
+	"/identity-conflicts/",
+}
+
+// replicaAllowsWrite reports whether path may be served to a
+// non-GET request on a replica.
+func replicaAllowsWrite(path string) bool {
+	path = stripAPIPrefix(path)
+	for _, allowed := range replicaWritablePaths {
+		if strings.HasSuffix(allowed, "/") {
+			if strings.HasPrefix(path, allowed) {
+				return true
+			}
+		} else if path == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// stripAPIPrefix removes a leading /api/v1, /api/v2 or /api.
+func stripAPIPrefix(path string) string {
+	for _, prefix := range []string{"/api/v1", "/api/v2", "/api"} {
+		if strings.HasPrefix(path, prefix+"/") {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
+
+// ReplicaWriteGuardMiddleware rejects client writes on a replica.
+// It passes everything through on a validator.
+func (node *QuidnugNode) ReplicaWriteGuardMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !node.IsReplica() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if replicaAllowsWrite(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		WriteError(w, http.StatusForbidden, "READ_ONLY_REPLICA",
+			"this node is a read replica; submit writes to a validator")
+	})
+}
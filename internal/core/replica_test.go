@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func TestNewReplicaUpstreams(t *testing.T) {
+	if u, err := newReplicaUpstreams(&config.Config{NodeRole: NodeRoleValidator}); u != nil || err != nil {
+		t.Fatalf("validator: got %v, %v", u, err)
+	}
+	if _, err := newReplicaUpstreams(&config.Config{NodeRole: NodeRoleReplica}); !errors.Is(err, ErrNoReplicaUpstreams) {
+		t.Fatalf("replica without upstreams: got %v", err)
+	}
+	if _, err := newReplicaUpstreams(&config.Config{NodeRole: NodeRoleReplica, ReplicaUpstreams: []string{"no-port"}}); err == nil {
+		t.Fatal("expected error for upstream without port")
+	}
+	if _, err := newReplicaUpstreams(&config.Config{NodeRole: "archive"}); err == nil {
+		t.Fatal("expected error for unknown role")
+	}
+}
+
+func TestReplicaUpstreams_Failover(t *testing.T) {
+	u := NewReplicaUpstreams([]string{"a:1", "b:1", "c:1"})
+	if u.Active() != "a:1" {
+		t.Fatalf("Active = %q", u.Active())
+	}
+	if next := u.Failover("a:1"); next != "b:1" {
+		t.Fatalf("Failover(a) = %q, want b:1", next)
+	}
+	// A stale failure report does not skip past the new upstream.
+	if next := u.Failover("a:1"); next != "b:1" {
+		t.Fatalf("stale Failover(a) = %q, want b:1", next)
+	}
+	u.Failover("b:1")
+	if next := u.Failover("c:1"); next != "a:1" {
+		t.Fatalf("Failover should wrap, got %q", next)
+	}
+}
+
+func TestReplica_SyncFailsOverToHealthyUpstream(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	healthyCalls := 0
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyCalls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"data": []Block{}},
+		})
+	}))
+	defer healthy.Close()
+
+	downAddr := strings.TrimPrefix(down.URL, "http://")
+	healthyAddr := strings.TrimPrefix(healthy.URL, "http://")
+
+	node := newTestNode()
+	node.Role = NodeRoleReplica
+	node.ReplicaUpstreams = NewReplicaUpstreams([]string{downAddr, healthyAddr})
+
+	if err := node.syncFromUpstream(context.Background()); err != nil {
+		t.Fatalf("syncFromUpstream: %v", err)
+	}
+	if node.ReplicaUpstreams.Active() != healthyAddr || healthyCalls != 1 {
+		t.Fatalf("expected failover to healthy upstream, active=%q calls=%d",
+			node.ReplicaUpstreams.Active(), healthyCalls)
+	}
+
+	healthy.Close()
+	if err := node.syncFromUpstream(context.Background()); err == nil {
+		t.Fatal("expected error when every upstream is down")
+	}
+}
+
+func TestReplicaWriteGuardMiddleware(t *testing.T) {
+	node := newTestNode()
+	handler := node.ReplicaWriteGuardMiddleware(setupTestRouter(node))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+
+	// Validators are unaffected.
+	if rr := do(http.MethodPost, "/api/v1/transactions/trust", "{}"); rr.Code == http.StatusForbidden {
+		t.Fatalf("validator write rejected: %s", rr.Body.String())
+	}
+
+	node.Role = NodeRoleReplica
+	for _, path := range []string{"/api/v1/transactions/trust", "/api/transactions/identity", "/api/v1/domains", "/api/v2/guardian/set-update"} {
+		rr := do(http.MethodPost, path, "{}")
+		if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "READ_ONLY_REPLICA") {
+			t.Errorf("POST %s on replica: got %d %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodGet, "/api/v1/blocks", ""); rr.Code != http.StatusOK {
+		t.Errorf("GET /blocks on replica: got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/api/v1/trust/query", "{}"); rr.Code == http.StatusForbidden {
+		t.Errorf("trust query on replica rejected: %s", rr.Body.String())
+	}
+}
@@ -2,9 +2,14 @@
 package core
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sort"
 	"strconv"
+
+	"github.com/quidnug/quidnug/internal/jsonschema"
 )
 
 // Pagination constants
@@ -24,6 +29,9 @@ type PaginationMeta struct {
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
 	Total  int `json:"total"`
+	// Truncated is set when a streamed page hit the response size
+	// cap; Limit is then the number of items actually returned.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // ParsePaginationParams extracts limit and offset from query parameters
@@ -74,6 +82,91 @@ func paginateSlice[T any](items []T, params PaginationParams) ([]T, int) {
 	return items[params.Offset:end], total
 }
 
+// ErrInvalidCursor is returned for a cursor this node didn't issue.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// PageCursor is the decoded form of an opaque pagination cursor.
+// Listings are ordered by a stable key and each page resumes
+// strictly after After, so entries present for the whole scan are
+// returned exactly once no matter what changes between requests.
+// Height is the chain height the scan was anchored to: block
+// listings stop there, and registry listings echo it so clients
+// can tell which state the scan began from.
+type PageCursor struct {
+	Height int64  `json:"h"`
+	After  string `json:"a,omitempty"`
+}
+
+// CursorMeta is the pagination block for cursor-mode responses.
+type CursorMeta struct {
+	Limit      int    `json:"limit"`
+	Height     int64  `json:"height"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func encodePageCursor(c PageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(s string) (PageCursor, error) {
+	var c PageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.Height < 0 {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// parsePageCursor reports whether the request asked for cursor
+// pagination (a "cursor" query param, empty for the first page)
+// and decodes it. A first page is anchored at height.
+func parsePageCursor(r *http.Request, height int64) (PageCursor, bool, error) {
+	q := r.URL.Query()
+	if !q.Has("cursor") {
+		return PageCursor{}, false, nil
+	}
+	raw := q.Get("cursor")
+	if raw == "" {
+		return PageCursor{Height: height}, true, nil
+	}
+	c, err := decodePageCursor(raw)
+	return c, true, err
+}
+
+// paginateByKey returns the page of items (sorted ascending by key)
+// that follows cur.After, plus the metadata for the next request.
+func paginateByKey[T any](items []T, key func(T) string, cur PageCursor, limit int) ([]T, CursorMeta) {
+	start := 0
+	if cur.After != "" {
+		start = sort.Search(len(items), func(i int) bool { return key(items[i]) > cur.After })
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	meta := CursorMeta{Limit: limit, Height: cur.Height, HasMore: end < len(items)}
+	if meta.HasMore {
+		meta.NextCursor = encodePageCursor(PageCursor{Height: cur.Height, After: key(items[end-1])})
+	}
+	if start == end {
+		return []T{}, meta
+	}
+	return items[start:end], meta
+}
+
+// writeCursorPage sorts items by key and writes the page after cur
+// in the same envelope as offset listings.
+func writeCursorPage[T any](w http.ResponseWriter, items []T, key func(T) string, cur PageCursor, limit int) {
+	sort.Slice(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+	page, meta := paginateByKey(items, key, cur, limit)
+	WriteSuccess(w, map[string]interface{}{
+		"data":       page,
+		"pagination": meta,
+	})
+}
+
 // encodeEnvelope encodes v to w and logs (without panicking) if
 // the write fails. Almost all encode failures here come from the
 // client disconnecting mid-response; that's not a server fault
@@ -140,3 +233,29 @@ func WriteFieldError(w http.ResponseWriter, code string, message string, fields
 		},
 	})
 }
+
+// WriteFieldViolations writes a field validation error response
+// with a per-field message. "fields" lists the offending paths as
+// WriteFieldError does; "violations" pairs each with its reason.
+func WriteFieldViolations(w http.ResponseWriter, code string, message string, violations []jsonschema.FieldError) {
+	fields := make([]string, 0, len(violations))
+	seen := make(map[string]bool, len(violations))
+	for _, v := range violations {
+		if !seen[v.Path] {
+			seen[v.Path] = true
+			fields = append(fields, v.Path)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-API-Version", "1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	encodeEnvelope(w, map[string]interface{}{
+		"success": false,
+		"error": map[string]interface{}{
+			"code":       code,
+			"message":    message,
+			"fields":     fields,
+			"violations": violations,
+		},
+	})
+}
@@ -0,0 +1,168 @@
+// Streamed list responses.
+//
+// WriteSuccess marshals its whole payload before the first byte goes
+// out, so a page of large blocks (or a registry listing built as one
+// slice) sits in memory twice: once as values, once as JSON. Listing
+// handlers instead open the usual envelope, encode the data array one
+// element at a time, and let net/http chunk the body. Clients see the
+// same {"success":true,"data":{"data":[...],"pagination":{...}}}
+// document.
+//
+// A stream also stops taking elements once maxListResponseBytes have
+// been written. The pagination block is written last, so it can say
+// where the page actually ended.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// maxListResponseBytes caps one streamed list response. The element
+// that crosses the cap is still written whole. A var so tests can
+// lower it.
+var maxListResponseBytes int64 = 32 << 20
+
+// listStreamFlushBytes is how much is buffered between flushes.
+const listStreamFlushBytes = 64 << 10
+
+// listStream writes a success envelope with a streamed data array.
+type listStream struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	enc       *json.Encoder
+	count     int
+	written   int64
+	unflushed int64
+	err       error
+}
+
+// startListStream sends the headers and opens the envelope.
+func startListStream(w http.ResponseWriter) *listStream {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-API-Version", "1.0")
+	s := &listStream{w: w, rc: http.NewResponseController(w)}
+	s.enc = json.NewEncoder(s)
+	s.writeRaw(`{"success":true,"data":{"data":[`)
+	return s
+}
+
+// Write counts bytes on their way to the response.
+func (s *listStream) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.written += int64(n)
+	s.unflushed += int64(n)
+	return n, err
+}
+
+func (s *listStream) writeRaw(str string) {
+	if s.err == nil {
+		_, s.err = s.Write([]byte(str))
+	}
+}
+
+// full reports whether the byte budget is spent or the client has
+// gone away; callers stop adding elements once it is true.
+func (s *listStream) full() bool {
+	return s.err != nil || (s.count > 0 && s.written >= maxListResponseBytes)
+}
+
+// add encodes one element. It returns false, writing nothing, when
+// the stream is full.
+func (s *listStream) add(v interface{}) bool {
+	if s.full() {
+		return false
+	}
+	if s.count > 0 {
+		s.writeRaw(",")
+	}
+	if s.err == nil {
+		s.err = s.enc.Encode(v)
+	}
+	if s.err != nil {
+		return false
+	}
+	s.count++
+	if s.unflushed >= listStreamFlushBytes {
+		s.unflushed = 0
+		_ = s.rc.Flush()
+	}
+	return true
+}
+
+// finish closes the data array and writes the pagination block.
+func (s *listStream) finish(pagination interface{}) {
+	s.writeRaw(`],"pagination":`)
+	if s.err == nil {
+		s.err = s.enc.Encode(pagination)
+	}
+	s.writeRaw("}}\n")
+	if s.err != nil && logger != nil {
+		logger.Debug("response: streamed list aborted", "items", s.count, "err", s.err)
+	}
+}
+
+// streamPage adds page's elements until the stream is full. prepare,
+// if set, runs on each element just before it is encoded (e.g. to
+// load a pruned block body); the element is zeroed once written so
+// whatever prepare loaded can be collected.
+func streamPage[T any](s *listStream, page []T, prepare func(*T)) {
+	var zero T
+	for i := range page {
+		if s.full() {
+			return
+		}
+		if prepare != nil {
+			prepare(&page[i])
+		}
+		s.add(page[i])
+		page[i] = zero
+	}
+}
+
+// writeStreamedOffsetPage streams page and closes it with offset
+// pagination. If the byte budget cut the page short, Limit reports
+// how many elements were sent and Truncated is set, so the next
+// request starts at Offset+Limit.
+func writeStreamedOffsetPage[T any](w http.ResponseWriter, page []T, params PaginationParams, total int, prepare func(*T)) {
+	s := startListStream(w)
+	streamPage(s, page, prepare)
+	meta := PaginationMeta{Limit: params.Limit, Offset: params.Offset, Total: total}
+	if s.count < len(page) {
+		meta.Limit, meta.Truncated = s.count, true
+	}
+	s.finish(meta)
+}
+
+// writeStreamedCursorPage streams page and closes it with cursor
+// pagination. meta describes the page as selected; if the byte
+// budget cut it short, the next cursor resumes after the last
+// element actually sent.
+func writeStreamedCursorPage[T any](w http.ResponseWriter, page []T, key func(T) string, meta CursorMeta, prepare func(*T)) {
+	// Keys are taken up front: elements are zeroed as they stream.
+	keys := make([]string, len(page))
+	for i := range page {
+		keys[i] = key(page[i])
+	}
+	s := startListStream(w)
+	streamPage(s, page, prepare)
+	if s.count < len(page) && s.count > 0 {
+		meta.HasMore = true
+		meta.NextCursor = encodePageCursor(PageCursor{Height: meta.Height, After: keys[s.count-1]})
+	}
+	s.finish(meta)
+}
+
+// sortedKeys returns m's keys in ascending order. Registry listings
+// page over keys and look up only the selected entries, instead of
+// copying every entry to throw most of them away. Caller holds the
+// lock guarding m.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetBlocksHandler_TruncatesAtByteBudget(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	for i := 0; i < 6; i++ {
+		appendTestBlock(node, "test.domain.com")
+	}
+	defer func(old int64) { maxListResponseBytes = old }(maxListResponseBytes)
+	maxListResponseBytes = 1 // every block after the first crosses it
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/blocks?limit=5&offset=1", nil))
+	var resp struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Data       []Block        `json:"data"`
+			Pagination PaginationMeta `json:"pagination"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v\n%s", err, w.Body.String())
+	}
+	meta := resp.Data.Pagination
+	if !resp.Success || len(resp.Data.Data) != 1 || !meta.Truncated || meta.Limit != 1 || meta.Offset != 1 {
+		t.Fatalf("got %d blocks, pagination %+v", len(resp.Data.Data), meta)
+	}
+	if resp.Data.Data[0].Index != 1 {
+		t.Fatalf("first block index = %d, want 1", resp.Data.Data[0].Index)
+	}
+
+	// Cursor mode resumes right after the last block sent.
+	var seen []int64
+	url := "/api/v1/blocks?limit=4&cursor="
+	for {
+		page, cm := getCursorPage(t, router, url)
+		if len(page) != 1 {
+			t.Fatalf("cursor page held %d blocks under a 1-byte budget", len(page))
+		}
+		var b Block
+		_ = json.Unmarshal(page[0], &b)
+		seen = append(seen, b.Index)
+		if !cm.HasMore {
+			break
+		}
+		url = "/api/v1/blocks?limit=4&cursor=" + cm.NextCursor
+	}
+	if want := node.chainHeight() + 1; int64(len(seen)) != want {
+		t.Fatalf("walked %v, want %d blocks", seen, want)
+	}
+}
+
+func TestQueryTrustRegistry_OffsetAndCursorAgree(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 3; j++ {
+			setTestTrust(node, fmt.Sprintf("c00000000000000%d", i), fmt.Sprintf("d00000000000000%d", j), 0.5)
+		}
+	}
+
+	var byOffset []trustRegistryEntry
+	total := -1
+	for offset := 0; ; offset += 4 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/api/v1/registry/trust?limit=4&offset=%d", offset), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("offset %d: status %d", offset, w.Code)
+		}
+		var resp struct {
+			Data struct {
+				Data       []trustRegistryEntry `json:"data"`
+				Pagination PaginationMeta       `json:"pagination"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		total = resp.Data.Pagination.Total
+		if len(resp.Data.Data) == 0 {
+			break
+		}
+		byOffset = append(byOffset, resp.Data.Data...)
+	}
+
+	var byCursor []trustRegistryEntry
+	url := "/api/v1/registry/trust?limit=4&cursor="
+	for {
+		page, meta := getCursorPage(t, router, url)
+		for _, raw := range page {
+			var e trustRegistryEntry
+			_ = json.Unmarshal(raw, &e)
+			byCursor = append(byCursor, e)
+		}
+		if !meta.HasMore {
+			break
+		}
+		url = "/api/v1/registry/trust?limit=4&cursor=" + meta.NextCursor
+	}
+
+	if len(byOffset) != total || fmt.Sprint(byOffset) != fmt.Sprint(byCursor) {
+		t.Fatalf("total %d\noffset: %v\ncursor: %v", total, byOffset, byCursor)
+	}
+	for i := 1; i < len(byOffset); i++ {
+		if trustRegistryEntryKey(byOffset[i-1]) >= trustRegistryEntryKey(byOffset[i]) {
+			t.Fatalf("listing out of order at %d: %v", i, byOffset)
+		}
+	}
+}
@@ -24,6 +24,7 @@
 package core
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -258,7 +259,7 @@ func TestReviewsFullFlow_InProcess(t *testing.T) {
 	}
 
 	// Trust edge should be queryable
-	trustLevel, _, err := node.ComputeRelationalTrust(alice.QuidID, veteran.QuidID, DefaultTrustMaxDepth)
+	trustLevel, _, err := node.ComputeRelationalTrust(context.Background(), alice.QuidID, veteran.QuidID, DefaultTrustMaxDepth)
 	if err != nil {
 		t.Fatalf("ComputeRelationalTrust: %v", err)
 	}
@@ -0,0 +1,246 @@
+// SQL export: a PostgreSQL mirror of the chain for analytics.
+//
+// With sql_export_url set, the node keeps a set of quidnug_* tables
+// in that database in step with its trusted chain, so BI tools can
+// query blocks, transactions, trust edges, identities and titles
+// with plain SQL. The node owns the schema (sqlExportSchema, created
+// if missing) and is its only writer.
+//
+// Each block is written as one multi-statement query, which
+// PostgreSQL runs as a single transaction: the mirror never holds
+// half a block. The exporter resumes from the highest block_index
+// already in quidnug_blocks, so it catches up after a restart or an
+// outage and a fresh database fills from genesis. Rows are keyed so
+// that re-exporting a block changes nothing.
+//
+// quidnug_transactions holds every transaction with its full JSON.
+// The trust_edges, identities and titles tables hold the latest
+// state per key as set by the newest committed TRUST, IDENTITY or
+// TITLE transaction; a conditional title transfer shows its new
+// owners once the transaction commits, before escrow settles.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/pgwire"
+)
+
+// SQL export tuning.
+const (
+	// sqlExportRetryMin and sqlExportRetryMax bound the backoff
+	// after a connection or write failure.
+	sqlExportRetryMin = time.Second
+	sqlExportRetryMax = time.Minute
+	// sqlExportConnectTimeout bounds connecting and authenticating.
+	sqlExportConnectTimeout = 15 * time.Second
+)
+
+// sqlExportSchema creates the mirror tables.
+const sqlExportSchema = `
+CREATE TABLE IF NOT EXISTS quidnug_blocks (
+	block_index  BIGINT PRIMARY KEY,
+	hash         TEXT NOT NULL,
+	prev_hash    TEXT NOT NULL,
+	trust_domain TEXT NOT NULL,
+	timestamp    BIGINT NOT NULL,
+	tx_count     INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS quidnug_transactions (
+	tx_id        TEXT PRIMARY KEY,
+	block_index  BIGINT NOT NULL REFERENCES quidnug_blocks(block_index),
+	position     INTEGER NOT NULL,
+	tx_type      TEXT NOT NULL,
+	trust_domain TEXT NOT NULL,
+	timestamp    BIGINT NOT NULL,
+	signer_quid  TEXT NOT NULL,
+	body         JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS quidnug_transactions_type ON quidnug_transactions (tx_type, block_index);
+CREATE INDEX IF NOT EXISTS quidnug_transactions_signer ON quidnug_transactions (signer_quid);
+CREATE TABLE IF NOT EXISTS quidnug_trust_edges (
+	truster      TEXT NOT NULL,
+	trustee      TEXT NOT NULL,
+	trust_domain TEXT NOT NULL,
+	context      TEXT NOT NULL,
+	trust_level  DOUBLE PRECISION NOT NULL,
+	nonce        BIGINT NOT NULL,
+	valid_until  BIGINT NOT NULL,
+	tx_id        TEXT NOT NULL,
+	block_index  BIGINT NOT NULL,
+	PRIMARY KEY (truster, trustee, trust_domain, context)
+);
+CREATE INDEX IF NOT EXISTS quidnug_trust_edges_trustee ON quidnug_trust_edges (trustee);
+CREATE TABLE IF NOT EXISTS quidnug_identities (
+	quid_id      TEXT PRIMARY KEY,
+	name         TEXT NOT NULL,
+	kind         TEXT NOT NULL,
+	creator      TEXT NOT NULL,
+	trust_domain TEXT NOT NULL,
+	update_nonce BIGINT NOT NULL,
+	attributes   JSONB,
+	tx_id        TEXT NOT NULL,
+	block_index  BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS quidnug_titles (
+	asset_id     TEXT PRIMARY KEY,
+	title_type   TEXT NOT NULL,
+	trust_domain TEXT NOT NULL,
+	owners       JSONB NOT NULL,
+	expiry_date  BIGINT NOT NULL,
+	tx_id        TEXT NOT NULL,
+	block_index  BIGINT NOT NULL
+);
+`
+
+// runSQLExportLoop mirrors the chain into the database at rawURL
+// until ctx is done. No-op when rawURL is empty.
+func (node *QuidnugNode) runSQLExportLoop(ctx context.Context, rawURL string) {
+	if rawURL == "" {
+		return
+	}
+	backoff := sqlExportRetryMin
+	for {
+		err := node.exportToSQL(ctx, rawURL)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("SQL export interrupted", "error", err, "retryIn", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > sqlExportRetryMax {
+			backoff = sqlExportRetryMax
+		}
+	}
+}
+
+// exportToSQL runs one session: connect, ensure the schema, then
+// write blocks from where the mirror left off until an error or ctx
+// is done.
+func (node *QuidnugNode) exportToSQL(ctx context.Context, rawURL string) error {
+	connectCtx, cancel := context.WithTimeout(ctx, sqlExportConnectTimeout)
+	conn, err := pgwire.Connect(connectCtx, rawURL)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Exec(sqlExportSchema); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+	rows, err := conn.Query("SELECT MAX(block_index) FROM quidnug_blocks")
+	if err != nil {
+		return err
+	}
+	var height int64
+	if len(rows) == 1 && rows[0][0] != nil {
+		last, err := strconv.ParseInt(*rows[0][0], 10, 64)
+		if err != nil {
+			return err
+		}
+		height = last + 1
+	}
+	logger.Info("SQL export connected", "fromHeight", height)
+
+	for {
+		var changed <-chan struct{}
+		if node.BlockFeed != nil {
+			changed = node.BlockFeed.Changed()
+		}
+		blocks, next := node.blocksFrom(height, blockStreamChunk)
+		for _, block := range blocks {
+			if err := conn.Exec(sqlExportStatements(block)); err != nil {
+				return fmt.Errorf("block %d: %w", block.Index, err)
+			}
+		}
+		height = next
+		if len(blocks) == blockStreamChunk {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// sqlExportStatements returns the statements that write block into
+// the mirror.
+func sqlExportStatements(block Block) string {
+	q := pgwire.Quote
+	idx := strconv.FormatInt(block.Index, 10)
+	domain := block.TrustProof.TrustDomain
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO quidnug_blocks VALUES (%s, %s, %s, %s, %d, %d) ON CONFLICT (block_index) DO NOTHING;\n",
+		idx, q(block.Hash), q(block.PrevHash), q(domain), block.Timestamp, len(block.Transactions))
+
+	for i, txInterface := range block.Transactions {
+		raw, err := json.Marshal(txInterface)
+		if err != nil {
+			continue
+		}
+		var base BaseTransaction
+		if json.Unmarshal(raw, &base) != nil {
+			continue
+		}
+		signer := ""
+		if base.PublicKey != "" {
+			signer = QuidIDFromPublicKeyHex(base.PublicKey)
+		}
+		fmt.Fprintf(&b, "INSERT INTO quidnug_transactions VALUES (%s, %s, %d, %s, %s, %d, %s, %s) ON CONFLICT (tx_id) DO NOTHING;\n",
+			q(base.ID), idx, i, q(string(base.Type)), q(domain), base.Timestamp, q(signer), pgwire.QuoteJSON(raw))
+
+		switch base.Type {
+		case TxTypeTrust:
+			var tx TrustTransaction
+			if json.Unmarshal(raw, &tx) != nil {
+				continue
+			}
+			fmt.Fprintf(&b, "INSERT INTO quidnug_trust_edges VALUES (%s, %s, %s, %s, %s, %d, %d, %s, %s) "+
+				"ON CONFLICT (truster, trustee, trust_domain, context) DO UPDATE SET trust_level = EXCLUDED.trust_level, "+
+				"nonce = EXCLUDED.nonce, valid_until = EXCLUDED.valid_until, tx_id = EXCLUDED.tx_id, block_index = EXCLUDED.block_index;\n",
+				q(tx.Truster), q(tx.Trustee), q(domain), q(tx.Context),
+				strconv.FormatFloat(tx.TrustLevel, 'g', -1, 64), tx.Nonce, tx.ValidUntil, q(tx.ID), idx)
+		case TxTypeIdentity:
+			var tx IdentityTransaction
+			if json.Unmarshal(raw, &tx) != nil {
+				continue
+			}
+			attrs := "NULL"
+			if len(tx.Attributes) > 0 {
+				if a, err := json.Marshal(tx.Attributes); err == nil {
+					attrs = pgwire.QuoteJSON(a)
+				}
+			}
+			fmt.Fprintf(&b, "INSERT INTO quidnug_identities VALUES (%s, %s, %s, %s, %s, %d, %s, %s, %s) "+
+				"ON CONFLICT (quid_id) DO UPDATE SET name = EXCLUDED.name, kind = EXCLUDED.kind, "+
+				"update_nonce = EXCLUDED.update_nonce, attributes = EXCLUDED.attributes, tx_id = EXCLUDED.tx_id, block_index = EXCLUDED.block_index;\n",
+				q(tx.QuidID), q(tx.Name), q(tx.Kind), q(tx.Creator), q(domain), tx.UpdateNonce, attrs, q(tx.ID), idx)
+		case TxTypeTitle:
+			var tx TitleTransaction
+			if json.Unmarshal(raw, &tx) != nil {
+				continue
+			}
+			owners, err := json.Marshal(tx.Owners)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&b, "INSERT INTO quidnug_titles VALUES (%s, %s, %s, %s, %d, %s, %s) "+
+				"ON CONFLICT (asset_id) DO UPDATE SET title_type = EXCLUDED.title_type, owners = EXCLUDED.owners, "+
+				"expiry_date = EXCLUDED.expiry_date, tx_id = EXCLUDED.tx_id, block_index = EXCLUDED.block_index;\n",
+				q(tx.AssetID), q(tx.TitleType), q(domain), pgwire.QuoteJSON(owners), tx.ExpiryDate, q(tx.ID), idx)
+		}
+	}
+	return b.String()
+}
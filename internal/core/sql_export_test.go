@@ -0,0 +1,39 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLExportStatements(t *testing.T) {
+	block := watchTestBlock(9)
+	block.Transactions = append(block.Transactions, IdentityTransaction{
+		BaseTransaction: BaseTransaction{ID: "t3", Type: TxTypeIdentity},
+		QuidID:          watchQuidC,
+		Name:            "O'Brien",
+		Attributes:      map[string]interface{}{"role": "notary"},
+	})
+	sql := sqlExportStatements(block)
+	lines := strings.Split(strings.TrimSpace(sql), "\n")
+
+	want := []string{
+		"INSERT INTO quidnug_blocks VALUES (9, 'h',",
+		"INSERT INTO quidnug_transactions VALUES ('t1', 9, 0, 'TRUST',",
+		"INSERT INTO quidnug_trust_edges VALUES ('" + watchQuidA + "', '" + watchQuidB + "', 'test.domain.com', '', 0.5,",
+		"INSERT INTO quidnug_transactions VALUES ('t2', 9, 1, 'TITLE',",
+		"INSERT INTO quidnug_titles VALUES ('asset-1', '', 'test.domain.com', '[{\"ownerId\":\"" + watchQuidB + "\",\"percentage\":100}]'::jsonb,",
+		"INSERT INTO quidnug_transactions VALUES ('t3', 9, 2, 'IDENTITY',",
+		"INSERT INTO quidnug_identities VALUES ('" + watchQuidC + "', 'O''Brien', '', '', 'test.domain.com', 0, '{\"role\":\"notary\"}'::jsonb,",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d statements, got %d:\n%s", len(want), len(lines), sql)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("statement %d:\n got  %s\n want %s...", i, lines[i], prefix)
+		}
+		if !strings.Contains(lines[i], " ON CONFLICT (") {
+			t.Errorf("statement %d is not idempotent: %s", i, lines[i])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package core — stake types.
+//
+// OwnershipStake.StakeType used to be free text that nothing read.
+// It now names one of four kinds of interest in an asset:
+//
+//   - ownership: a share of the asset itself. Ownership stakes
+//     alone make up the 1.0 (or legacy 100.0) total, sign
+//     transfers, and never expire. An empty type, or any label
+//     other than the three below, is an ownership stake, so
+//     titles written with free-text types (the v1.0 vectors use
+//     "full-ownership" and "tenant") keep their meaning.
+//   - lease: the right to use the asset until ExpiresAt, which is
+//     mandatory and must lie after the title's timestamp.
+//   - usufruct: the right to use the asset and take its fruits,
+//     for life unless ExpiresAt is set.
+//   - lien: a security interest recorded on the title itself. The
+//     LIEN transaction (liens.go) is the enforceable form; this
+//     stake type lets a title carry one it was issued with.
+//
+// Non-ownership stakes sit alongside the owners in Owners, count
+// for nothing in the total, and are split out by type in the title
+// API (TitleStakes).
+package core
+
+import (
+	"fmt"
+)
+
+// Stake types.
+const (
+	StakeTypeOwnership = "ownership"
+	StakeTypeLease     = "lease"
+	StakeTypeLien      = "lien"
+	StakeTypeUsufruct  = "usufruct"
+)
+
+// isOwnership reports whether the stake is a share of the asset.
+func (s OwnershipStake) isOwnership() bool {
+	switch s.StakeType {
+	case StakeTypeLease, StakeTypeLien, StakeTypeUsufruct:
+		return false
+	}
+	return true
+}
+
+// expiredAt reports whether a time-limited stake has run out.
+func (s OwnershipStake) expiredAt(now int64) bool {
+	return s.ExpiresAt > 0 && s.ExpiresAt <= now
+}
+
+// validateStakes checks the stake-type rules for a title issued at
+// timestamp.
+func validateStakes(owners []OwnershipStake, timestamp int64) error {
+	owned := 0
+	for _, s := range owners {
+		if !s.isOwnership() && s.Percentage <= 0 {
+			return fmt.Errorf("%s of %s must have a positive share", s.StakeType, s.OwnerID)
+		}
+		switch s.StakeType {
+		case StakeTypeLease:
+			if s.ExpiresAt <= timestamp {
+				return fmt.Errorf("lease of %s needs an expiry after the title's timestamp", s.OwnerID)
+			}
+		case StakeTypeUsufruct, StakeTypeLien:
+			if s.ExpiresAt != 0 && s.ExpiresAt <= timestamp {
+				return fmt.Errorf("%s of %s expires before the title's timestamp", s.StakeType, s.OwnerID)
+			}
+		default:
+			if s.ExpiresAt != 0 {
+				return fmt.Errorf("ownership stake of %s cannot expire", s.OwnerID)
+			}
+			owned++
+		}
+	}
+	if owned == 0 {
+		return fmt.Errorf("title has no ownership stake")
+	}
+	return nil
+}
+
+// TitleStakes is a title's stakes split by type. Leases,
+// usufructs and liens that have expired are listed in Expired
+// rather than under their type.
+type TitleStakes struct {
+	Ownership []OwnershipStake `json:"ownership"`
+	Leases    []OwnershipStake `json:"leases"`
+	Usufructs []OwnershipStake `json:"usufructs"`
+	Liens     []OwnershipStake `json:"liens"`
+	Expired   []OwnershipStake `json:"expired"`
+}
+
+// groupStakes splits owners by type as of now.
+func groupStakes(owners []OwnershipStake, now int64) TitleStakes {
+	g := TitleStakes{
+		Ownership: []OwnershipStake{},
+		Leases:    []OwnershipStake{},
+		Usufructs: []OwnershipStake{},
+		Liens:     []OwnershipStake{},
+		Expired:   []OwnershipStake{},
+	}
+	for _, s := range owners {
+		switch {
+		case s.isOwnership():
+			g.Ownership = append(g.Ownership, s)
+		case s.expiredAt(now):
+			g.Expired = append(g.Expired, s)
+		case s.StakeType == StakeTypeLease:
+			g.Leases = append(g.Leases, s)
+		case s.StakeType == StakeTypeUsufruct:
+			g.Usufructs = append(g.Usufructs, s)
+		case s.StakeType == StakeTypeLien:
+			g.Liens = append(g.Liens, s)
+		}
+	}
+	return g
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateStakes(t *testing.T) {
+	const at = 1_700_000_000
+	owner := OwnershipStake{OwnerID: "a", Percentage: 1}
+	cases := []struct {
+		name   string
+		stakes []OwnershipStake
+		ok     bool
+	}{
+		{"plain owner", []OwnershipStake{owner}, true},
+		{"legacy label is ownership", []OwnershipStake{{OwnerID: "a", Percentage: 1, StakeType: "full-ownership"}}, true},
+		{"lease with expiry", []OwnershipStake{owner, {OwnerID: "b", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: at + 1}}, true},
+		{"lease without expiry", []OwnershipStake{owner, {OwnerID: "b", Percentage: 1, StakeType: StakeTypeLease}}, false},
+		{"lease already over", []OwnershipStake{owner, {OwnerID: "b", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: at}}, false},
+		{"lifetime usufruct", []OwnershipStake{owner, {OwnerID: "b", Percentage: 0.5, StakeType: StakeTypeUsufruct}}, true},
+		{"zero-share lien", []OwnershipStake{owner, {OwnerID: "b", StakeType: StakeTypeLien}}, false},
+		{"expiring ownership", []OwnershipStake{{OwnerID: "a", Percentage: 1, ExpiresAt: at + 1}}, false},
+		{"lessees only", []OwnershipStake{{OwnerID: "b", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: at + 1}}, false},
+	}
+	for _, c := range cases {
+		if err := validateStakes(c.stakes, at); (err == nil) != c.ok {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+	}
+}
+
+func TestOwnershipTotal_ExcludesLeases(t *testing.T) {
+	stakes := []OwnershipStake{
+		{OwnerID: "a", Percentage: 0.6},
+		{OwnerID: "b", Percentage: 0.4, StakeType: StakeTypeOwnership},
+		{OwnerID: "c", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: 1},
+	}
+	if total, ok := ownershipTotal(stakes); !ok {
+		t.Errorf("total %v with a lease alongside full ownership", total)
+	}
+	if _, signs := stakeShares(stakes)["c"]; signs {
+		t.Error("lessee counted as a transfer signer")
+	}
+}
+
+func TestGetTitleHandler_GroupsStakes(t *testing.T) {
+	node := newTestNode()
+	node.updateTitleRegistry(TitleTransaction{
+		AssetID: "apt-3f",
+		Owners: []OwnershipStake{
+			{OwnerID: "landlord", Percentage: 1},
+			{OwnerID: "tenant", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: nowUnix() + 3600},
+			{OwnerID: "old-tenant", Percentage: 1, StakeType: StakeTypeLease, ExpiresAt: 1},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	setupTestRouter(node).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/title/apt-3f", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	var resp struct {
+		Data struct {
+			Stakes TitleStakes `json:"stakes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	s := resp.Data.Stakes
+	if len(s.Ownership) != 1 || len(s.Leases) != 1 || s.Leases[0].OwnerID != "tenant" ||
+		len(s.Expired) != 1 || s.Expired[0].OwnerID != "old-tenant" {
+		t.Errorf("stakes = %+v", s)
+	}
+}
@@ -23,8 +23,15 @@
 //     trust_domains.json    Snapshot of TrustDomains + the
 //                           DomainRegistry index. Same lifecycle
 //                           as blockchain.json.
+//     tentative_blocks.json Blocks held for trust promotion.
+//                           Same lifecycle; on load, blocks past
+//                           the tentative max age, already on the
+//                           chain, or failing the cryptographic
+//                           checks are dropped.
+//     known_nodes.json      The discovered peer table; see
+//                           peer_store.go. Same lifecycle.
 //
-// All three writes use safeio.WriteFileMode for atomic write +
+// All of these writes use safeio.WriteFileMode for atomic write +
 // 0600 permissions. Schema versioned; future bumps are graceful
 // (load fails, fall back to defaults).
 package core
@@ -168,6 +175,13 @@ type trustDomainsSnapshot struct {
 	DomainRegistry map[string][]string      `json:"domainRegistry"`
 }
 
+// tentativeBlocksSnapshot is the on-disk shape for TentativeBlocks.
+type tentativeBlocksSnapshot struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	SavedAt       int64              `json:"savedAt"`
+	Blocks        map[string][]Block `json:"blocks"` // keyed by trust domain
+}
+
 // LoadBlockchain reads data_dir/blockchain.json into the node.
 // Replaces the genesis-only chain populated by NewQuidnugNode.
 // Missing file is silent (clean start).
@@ -227,10 +241,24 @@ func (node *QuidnugNode) LoadBlockchain(dataDir string) error {
 	// virtue of its empty Transactions slice; we don't special-
 	// case index 0 so that any future genesis-payload gets the
 	// same treatment as live blocks.
+	// Pruned blocks persist as headers only; their bodies are
+	// fetched back from the block archive for the replay.
 	totalTxReplayed := 0
 	for i := range snap.Blocks {
-		totalTxReplayed += len(snap.Blocks[i].Transactions)
-		node.processBlockTransactions(snap.Blocks[i])
+		block := snap.Blocks[i]
+		if block.Pruned {
+			full, err := node.fetchArchivedBlock(context.Background(), block)
+			if err != nil {
+				if logger != nil {
+					logger.Warn("Cannot replay pruned block; registry state will be incomplete",
+						"index", block.Index, "domain", block.TrustProof.TrustDomain, "error", err)
+				}
+				continue
+			}
+			block = full
+		}
+		totalTxReplayed += len(block.Transactions)
+		node.processBlockTransactions(block)
 	}
 
 	if logger != nil {
@@ -344,6 +372,88 @@ func (node *QuidnugNode) SaveTrustDomains(dataDir string) error {
 	return safeio.WriteFileMode(filepath.Join(dataDir, "trust_domains.json"), raw, 0o600)
 }
 
+// SaveTentativeBlocks snapshots TentativeBlocks to
+// data_dir/tentative_blocks.json.
+func (node *QuidnugNode) SaveTentativeBlocks(dataDir string) error {
+	if dataDir == "" {
+		return nil
+	}
+	node.TentativeBlocksMutex.RLock()
+	held := make(map[string][]Block, len(node.TentativeBlocks))
+	for domain, blocks := range node.TentativeBlocks {
+		if len(blocks) == 0 {
+			continue
+		}
+		cp := make([]Block, len(blocks))
+		copy(cp, blocks)
+		held[domain] = cp
+	}
+	node.TentativeBlocksMutex.RUnlock()
+	snap := tentativeBlocksSnapshot{
+		SchemaVersion: stateSchemaVersion,
+		SavedAt:       time.Now().UnixNano(),
+		Blocks:        held,
+	}
+	raw, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tentative blocks: %w", err)
+	}
+	raw = append(raw, '\n')
+	return safeio.WriteFileMode(filepath.Join(dataDir, "tentative_blocks.json"), raw, 0o600)
+}
+
+// LoadTentativeBlocks reads data_dir/tentative_blocks.json back into
+// TentativeBlocks. Blocks older than maxAge would be pruned by the
+// next GC pass anyway, so they are skipped here, as are blocks that
+// reached the chain before shutdown and any that no longer pass
+// ValidateBlockCryptographic. Call after LoadBlockchain. Missing
+// file is silent.
+func (node *QuidnugNode) LoadTentativeBlocks(dataDir string, maxAge time.Duration) error {
+	if dataDir == "" {
+		return nil
+	}
+	path := filepath.Join(dataDir, "tentative_blocks.json")
+	raw, err := safeio.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var snap tentativeBlocksSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return fmt.Errorf("parse %q: %w", path, err)
+	}
+	if snap.SchemaVersion != stateSchemaVersion {
+		return fmt.Errorf("tentative_blocks schema %d not supported", snap.SchemaVersion)
+	}
+
+	onChain := make(map[string]bool)
+	node.BlockchainMutex.RLock()
+	for _, b := range node.Blockchain {
+		onChain[b.Hash] = true
+	}
+	node.BlockchainMutex.RUnlock()
+
+	cutoff := time.Now().Add(-maxAge).Unix()
+	loaded, dropped := 0, 0
+	for _, blocks := range snap.Blocks {
+		for _, block := range blocks {
+			if block.Timestamp < cutoff || onChain[block.Hash] || !node.ValidateBlockCryptographic(block) {
+				dropped++
+				continue
+			}
+			if err := node.StoreTentativeBlock(block); err != nil {
+				dropped++
+				continue
+			}
+			loaded++
+		}
+	}
+	if logger != nil {
+		logger.Info("Loaded persisted tentative blocks",
+			"path", path, "blocks", loaded, "dropped", dropped)
+	}
+	return nil
+}
+
 // runStatePersistLoop periodically snapshots blockchain + trust
 // domains to data_dir. Same shape as runPeerScorePersistLoop.
 // Final flush on ctx cancel preserves the latest state across
@@ -363,6 +473,14 @@ func (node *QuidnugNode) runStatePersistLoop(ctx context.Context, dataDir string
 			logger.Warn("Trust-domain snapshot failed",
 				"reason", reason, "error", err)
 		}
+		if err := node.SaveTentativeBlocks(dataDir); err != nil && logger != nil {
+			logger.Warn("Tentative-block snapshot failed",
+				"reason", reason, "error", err)
+		}
+		if err := node.SaveKnownNodes(dataDir); err != nil && logger != nil {
+			logger.Warn("Known-node snapshot failed",
+				"reason", reason, "error", err)
+		}
 	}
 	// Initial flush on a tiny delay so the boot path can settle
 	// any genesis-replacement actions before the snapshot runs.
@@ -150,6 +150,45 @@ func TestSaveLoadTrustDomains_RoundTrip(t *testing.T) {
 	}
 }
 
+// TestSaveLoadTentativeBlocks_RoundTrip: blocks awaiting trust
+// promotion survive a restart; tampered ones don't come back, and
+// none do once they're past the tentative max age.
+func TestSaveLoadTentativeBlocks_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	a := newTestNode()
+	block, err := a.SyntheticTrustBlock("tentative.example.com", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *block
+	tampered.Hash = strings.Repeat("0", 64)
+	for _, b := range []Block{*block, tampered} {
+		if err := a.StoreTentativeBlock(b); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+	if err := a.SaveTentativeBlocks(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	b := newTestNode()
+	if err := b.LoadTentativeBlocks(dir, DefaultTentativeBlockMaxAge); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	held := b.GetTentativeBlocks("tentative.example.com")
+	if len(held) != 1 || held[0].Hash != block.Hash {
+		t.Fatalf("expected only the intact block after reload, got %d", len(held))
+	}
+
+	c := newTestNode()
+	if err := c.LoadTentativeBlocks(dir, -time.Hour); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if held := c.GetTentativeBlocks("tentative.example.com"); len(held) != 0 {
+		t.Fatalf("expired blocks reloaded: %d", len(held))
+	}
+}
+
 // TestLoadBlockchain_MissingFileIsSilent: starting fresh with
 // no on-disk snapshot is a clean boot, not an error.
 func TestLoadBlockchain_MissingFileIsSilent(t *testing.T) {
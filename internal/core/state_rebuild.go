@@ -0,0 +1,452 @@
+// Chain-derived state rebuild.
+//
+// Registries are updated block by block as the chain grows and are
+// never reconciled against it, so a bug in an apply path, a crash
+// between commit and apply, or an operator poking at state leaves
+// them silently wrong. RebuildState clears every registry the chain
+// determines and replays the whole chain into them in order, pruned
+// blocks fetched back from the archive, under the same lock a live
+// commit takes.
+//
+// The registries cleared are those written only by
+// applyBlockTransactions: trust (levels, nonces, expiry, timestamps,
+// domains, evidence), identities, titles, events and every per-type
+// registry (advertisements, moderation, names, liens, succession,
+// misbehavior, escrow, privacy, DNS attestations, blind keys, groups,
+// committed custom transactions), plus the indexes built alongside
+// them. State with other inputs is kept: the nonce ledger (also fed
+// by gossip anchors), tiered trust edges, forks (activation flips
+// node config), anomaly marks (operator-reviewed) and registered
+// custom transaction types.
+//
+// At startup (rebuild_state_on_start) the clock is pinned to each
+// block's timestamp while it replays, so time-dependent apply paths
+// give the same result on every node. An on-demand rebuild of a
+// serving node runs on the live clock, and reads that arrive while
+// it runs may see the registries partly rebuilt.
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStateRebuildRunning means a rebuild was requested while another
+// was still replaying.
+var ErrStateRebuildRunning = errors.New("state rebuild: already running")
+
+// StateRebuildRequest is the admin-signed body that triggers an
+// on-demand rebuild. Signature covers the JSON encoding of the
+// request with Signature empty.
+type StateRebuildRequest struct {
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// StateRebuildOptions controls RebuildState.
+type StateRebuildOptions struct {
+	// FreezeClock pins the clock to each block's timestamp while it
+	// is applied. Only safe before the node serves traffic: the
+	// clock is package-wide.
+	FreezeClock bool
+}
+
+// StateRebuildReport summarizes one rebuild.
+type StateRebuildReport struct {
+	StartedAt   int64 `json:"startedAt"`
+	CompletedAt int64 `json:"completedAt"`
+	// Blocks replayed, transactions applied, and pruned blocks whose
+	// archived body could not be fetched and were skipped.
+	Blocks        int `json:"blocks"`
+	Transactions  int `json:"transactions"`
+	SkippedBlocks int `json:"skippedBlocks"`
+	// DigestBefore and DigestAfter hash the trust, identity, title
+	// and event registries (see replayDigest). Drifted is set when
+	// they differ, i.e. the serving state did not match the chain.
+	DigestBefore string `json:"digestBefore"`
+	DigestAfter  string `json:"digestAfter"`
+	Drifted      bool   `json:"drifted"`
+}
+
+// stateRebuildState lets only one rebuild run at a time and keeps
+// the last report for GET.
+type stateRebuildState struct {
+	running atomic.Bool
+	last    atomic.Pointer[StateRebuildReport]
+}
+
+// TriggerStateRebuild verifies an admin-signed request and rebuilds
+// on the live clock.
+func (node *QuidnugNode) TriggerStateRebuild(ctx context.Context, req StateRebuildRequest) (*StateRebuildReport, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return nil, err
+	}
+	return node.RebuildState(ctx, StateRebuildOptions{})
+}
+
+// LastStateRebuildReport returns the report of the most recent
+// rebuild, or nil if none has run since startup.
+func (node *QuidnugNode) LastStateRebuildReport() *StateRebuildReport {
+	return node.rebuild.last.Load()
+}
+
+// RebuildState clears the chain-derived registries and replays the
+// chain into them. Live commits wait until it finishes. ctx only
+// bounds archive fetches: once the registries are cleared the replay
+// always runs to the end, since stopping early would leave them
+// emptier than before.
+func (node *QuidnugNode) RebuildState(ctx context.Context, opts StateRebuildOptions) (*StateRebuildReport, error) {
+	if !node.rebuild.running.CompareAndSwap(false, true) {
+		return nil, ErrStateRebuildRunning
+	}
+	defer node.rebuild.running.Store(false)
+
+	report := &StateRebuildReport{StartedAt: nowUnix()}
+
+	node.applyMu.Lock()
+	defer node.applyMu.Unlock()
+
+	// Taken under applyMu, so no block commits between the snapshot
+	// and the replay without being applied after it.
+	node.BlockchainMutex.RLock()
+	chain := make([]Block, len(node.Blockchain))
+	copy(chain, node.Blockchain)
+	node.BlockchainMutex.RUnlock()
+
+	before, err := node.replayDigest()
+	if err != nil {
+		return nil, err
+	}
+	report.DigestBefore = before
+
+	node.resetDerivedState()
+	node.replayChain(ctx, chain, opts.FreezeClock, report)
+
+	after, err := node.replayDigest()
+	if err != nil {
+		return nil, err
+	}
+	report.DigestAfter = after
+	report.Drifted = before != after
+	report.CompletedAt = nowUnix()
+	node.rebuild.last.Store(report)
+
+	log := logger.Info
+	if report.Drifted {
+		log = logger.Warn
+	}
+	log("Rebuilt state from chain",
+		"blocks", report.Blocks,
+		"transactions", report.Transactions,
+		"skippedBlocks", report.SkippedBlocks,
+		"drifted", report.Drifted)
+	return report, nil
+}
+
+// replayChain applies chain in order, pinning the clock to each
+// block's timestamp when freezeClock is set. Caller holds applyMu.
+func (node *QuidnugNode) replayChain(ctx context.Context, chain []Block, freezeClock bool, report *StateRebuildReport) {
+	if freezeClock {
+		replayMu.Lock()
+		previous := clockOverrideNano.Load()
+		defer func() {
+			setTestClockNano(previous)
+			replayMu.Unlock()
+		}()
+	}
+	for i := range chain {
+		block := chain[i]
+		if block.Pruned {
+			full, err := node.fetchArchivedBlock(ctx, block)
+			if err != nil {
+				logger.Warn("Cannot replay pruned block; rebuilt state will be incomplete",
+					"index", block.Index, "domain", block.TrustProof.TrustDomain, "error", err)
+				report.SkippedBlocks++
+				continue
+			}
+			block = full
+		}
+		if freezeClock {
+			setTestClockNano(time.Unix(block.Timestamp, 0).UnixNano())
+		}
+		node.applyBlockTransactions(block)
+		report.Blocks++
+		report.Transactions += len(block.Transactions)
+	}
+}
+
+// resetDerivedState empties the registries applyBlockTransactions
+// writes. Caller holds applyMu.
+func (node *QuidnugNode) resetDerivedState() {
+	node.TrustRegistryMutex.Lock()
+	node.TrustRegistry = make(map[string]map[string]float64)
+	node.TrustNonceRegistry = make(map[string]map[string]int64)
+	node.TrustExpiryRegistry = make(map[string]map[string]int64)
+	node.TrustEdgeTimestampRegistry = make(map[string]map[string]int64)
+	node.TrustEdgeDomainRegistry = make(map[string]map[string]string)
+	node.TrustEdgeDomainLevels = make(map[string]map[string]map[string]float64)
+	node.TrustEdgeContextLevels = make(map[string]map[string]map[trustContextKey]float64)
+	node.TrustEvidenceRegistry = make(map[string]map[string]TrustEdgeEvidence)
+	node.TrustRegistryMutex.Unlock()
+	node.invalidateTrustView()
+	if node.TrustCache != nil {
+		node.TrustCache.Invalidate()
+	}
+
+	node.IdentityRegistryMutex.Lock()
+	node.IdentityRegistry = make(map[string]IdentityTransaction)
+	node.IdentityRegistryMutex.Unlock()
+
+	node.TitleRegistryMutex.Lock()
+	node.TitleRegistry = make(map[string]TitleTransaction)
+	node.TitleRegistryMutex.Unlock()
+
+	node.EventStreamMutex.Lock()
+	node.EventStreamRegistry = make(map[string]*EventStream)
+	node.EventRegistry = make(map[string][]EventTransaction)
+	node.EventStreamMutex.Unlock()
+
+	for _, r := range []interface{ reset() }{
+		node.NodeAdvertisementRegistry,
+		node.ModerationRegistry,
+		node.NameRegistry,
+		node.LienRegistry,
+		node.SuccessionRegistry,
+		node.MisbehaviorRegistry,
+		node.Checkpoints,
+		node.DomainControlRegistry,
+		node.EscrowRegistry,
+		node.TitleExpiries,
+		node.TitleLineage,
+		node.TitleDisputes,
+		node.Notarizations,
+		node.DomainAnalytics,
+		node.EntitySources,
+		node.CustomTxRegistry,
+		node.OwnerIndex,
+		node.PrivacyRegistry,
+		node.DNSAttestationRegistry,
+		node.BlindKeyRegistry,
+		node.GroupRegistry,
+		node.QuidDomainIndex,
+	} {
+		r.reset()
+	}
+	node.bumpRegistryVersion()
+}
+
+// The reset methods below empty a registry in place, so holders of
+// the pointer see the rebuilt state. Each is a no-op on nil.
+
+func (r *NodeAdvertisementRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewNodeAdvertisementRegistry()
+	r.mu.Lock()
+	r.ads, r.lastPublished = fresh.ads, fresh.lastPublished
+	r.mu.Unlock()
+}
+
+func (r *ModerationRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewModerationRegistry()
+	r.mu.Lock()
+	r.actions, r.byID, r.nonces = fresh.actions, fresh.byID, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *NameRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewNameRegistry()
+	r.mu.Lock()
+	r.records, r.nonces = fresh.records, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *LienRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewLienRegistry()
+	r.mu.Lock()
+	r.byAsset, r.byID, r.released, r.nonces = fresh.byAsset, fresh.byID, fresh.released, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *SuccessionRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewSuccessionRegistry()
+	r.mu.Lock()
+	r.lastActive, r.succeeded = fresh.lastActive, fresh.succeeded
+	r.mu.Unlock()
+}
+
+func (r *MisbehaviorRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewMisbehaviorRegistry()
+	r.mu.Lock()
+	r.byOffender, r.history, r.removals, r.nonces = fresh.byOffender, fresh.history, fresh.removals, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *EscrowRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewEscrowRegistry()
+	r.mu.Lock()
+	r.transfers, r.pendingByAsset = fresh.transfers, fresh.pendingByAsset
+	r.mu.Unlock()
+}
+
+func (r *TitleExpiryRegistry) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.byAsset = make(map[string]TitleExpiry)
+	r.mu.Unlock()
+}
+
+func (r *TitleLineageRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewTitleLineageRegistry()
+	r.mu.Lock()
+	r.records, r.createdBy, r.retiredBy = fresh.records, fresh.createdBy, fresh.retiredBy
+	r.mu.Unlock()
+}
+
+func (r *TitleDisputeRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewTitleDisputeRegistry()
+	r.mu.Lock()
+	r.byID, r.byAsset, r.formerOwners, r.nonces = fresh.byID, fresh.byAsset, fresh.formerOwners, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *NotarizationRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewNotarizationRegistry()
+	r.mu.Lock()
+	r.byID, r.byHash = fresh.byID, fresh.byHash
+	r.mu.Unlock()
+}
+
+func (a *DomainAnalytics) reset() {
+	if a == nil {
+		return
+	}
+	fresh := NewDomainAnalytics()
+	a.mu.Lock()
+	a.domains = fresh.domains
+	a.mu.Unlock()
+}
+
+func (x *EntitySourceIndex) reset() {
+	if x == nil {
+		return
+	}
+	fresh := NewEntitySourceIndex()
+	x.mu.Lock()
+	x.sources = fresh.sources
+	x.mu.Unlock()
+}
+
+// reset drops committed custom transactions; registered types stay.
+func (r *CustomTxRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewCustomTxRegistry()
+	r.mu.Lock()
+	r.txs, r.byType, r.bySubject, r.nonces = fresh.txs, fresh.byType, fresh.bySubject, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (x *OwnerIndex) reset() {
+	if x == nil {
+		return
+	}
+	fresh := NewOwnerIndex()
+	x.mu.Lock()
+	x.byOwner, x.byAsset = fresh.byOwner, fresh.byAsset
+	x.mu.Unlock()
+}
+
+func (r *PrivacyRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewPrivacyRegistry()
+	r.mu.Lock()
+	r.consentGrants, r.withdrawnGrants = fresh.consentGrants, fresh.withdrawnGrants
+	r.activeRestrictions, r.dsrRequests = fresh.activeRestrictions, fresh.dsrRequests
+	r.dsrCompletions, r.nonces = fresh.dsrCompletions, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *DNSAttestationRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewDNSAttestationRegistry()
+	r.mu.Lock()
+	r.attestations, r.byDomain, r.byRoot = fresh.attestations, fresh.byDomain, fresh.byRoot
+	r.revocations, r.renewals = fresh.revocations, fresh.renewals
+	r.claims, r.challenges, r.nonceRegistry = fresh.claims, fresh.challenges, fresh.nonceRegistry
+	r.delegations, r.delegationsByAttRef = fresh.delegations, fresh.delegationsByAttRef
+	r.delegationRevocations = fresh.delegationRevocations
+	r.mu.Unlock()
+}
+
+func (r *BlindKeyRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewBlindKeyRegistry()
+	r.mu.Lock()
+	r.byFingerprint, r.byElection, r.nonces = fresh.byFingerprint, fresh.byElection, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (r *GroupRegistry) reset() {
+	if r == nil {
+		return
+	}
+	fresh := NewGroupRegistry()
+	r.mu.Lock()
+	r.groups, r.latestEpoch, r.epochHistory = fresh.groups, fresh.latestEpoch, fresh.epochHistory
+	r.keyPackages, r.encryptedRecords = fresh.keyPackages, fresh.encryptedRecords
+	r.invites, r.recoveries, r.nonces = fresh.invites, fresh.recoveries, fresh.nonces
+	r.mu.Unlock()
+}
+
+func (x *QuidDomainIndex) reset() {
+	if x == nil {
+		return
+	}
+	fresh := NewQuidDomainIndex()
+	x.mu.Lock()
+	x.stats = fresh.stats
+	x.mu.Unlock()
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRebuildState_RestoresChainState(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	block, err := node.SyntheticTrustBlock("rebuild.local", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	var tx TrustTransaction
+	raw, _ := json.Marshal(block.Transactions[0])
+	_ = json.Unmarshal(raw, &tx)
+
+	clean, err := node.RebuildState(context.Background(), StateRebuildOptions{FreezeClock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clean.Drifted || clean.Transactions != 5 || clean.Blocks != 2 {
+		t.Fatalf("rebuild of consistent state: %+v", clean)
+	}
+	if clockOverrideNano.Load() != 0 {
+		t.Fatal("clock left frozen")
+	}
+
+	// Drift the registries away from the chain.
+	setTestTrust(node, "e000000000000001", "e000000000000002", 0.4)
+	setTestTrust(node, tx.Truster, tx.Trustee, 0.01)
+
+	report, err := node.RebuildState(context.Background(), StateRebuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Drifted || report.DigestAfter != clean.DigestAfter {
+		t.Fatalf("rebuild of drifted state: %+v", report)
+	}
+	if level := node.GetTrustLevel(tx.Truster, tx.Trustee); level != tx.TrustLevel {
+		t.Errorf("edge level %v, chain says %v", level, tx.TrustLevel)
+	}
+	if _, ok := node.TrustRegistry["e000000000000001"]; ok {
+		t.Error("edge with no block survived the rebuild")
+	}
+	if node.LastStateRebuildReport() != report {
+		t.Error("last report not kept")
+	}
+
+	node.rebuild.running.Store(true)
+	if _, err := node.RebuildState(context.Background(), StateRebuildOptions{}); !errors.Is(err, ErrStateRebuildRunning) {
+		t.Fatalf("overlapping rebuild: err = %v", err)
+	}
+}
+
+func TestRebuildStateHandler(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	post := func(req StateRebuildRequest) int {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/state-rebuild", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	req := StateRebuildRequest{Timestamp: time.Now().Unix(), PublicKey: node.GetPublicKeyHex()}
+	if code := post(req); code != http.StatusForbidden {
+		t.Fatalf("unsigned request: status %d", code)
+	}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	if code := post(req); code != http.StatusOK {
+		t.Fatalf("signed request: status %d", code)
+	}
+	if node.LastStateRebuildReport() == nil {
+		t.Fatal("report not recorded")
+	}
+}
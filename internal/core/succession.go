@@ -0,0 +1,339 @@
+// Package core — dead-man switch succession for identities.
+//
+// An IDENTITY transaction may carry a SuccessionPlan naming a
+// successor quid and an inactivity window. If the chain then shows
+// no transaction signed by the identity's quid for that long, the
+// successor can publish a SUCCESSION transaction. From then on the
+// successor signs for the quid wherever signsFor and verifyAsQuid
+// are consulted, and when the plan says so the quid's title stakes
+// move to the successor in the same step.
+//
+// Activity is the latest timestamp of any committed transaction
+// whose signing key derives to the quid (or, failing that, the
+// identity's own registration). Both the activity record and the
+// inactivity check use transaction timestamps, so every node that
+// replays the chain reaches the same verdict. The original key is
+// not revoked: an owner who turns up later can still act, and can
+// name a new successor with an identity update.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go          : TxTypeSuccession const, IdentityTransaction.Succession
+//   - succession.go     : this file — structs, registry, validator, apply
+//   - transactions.go   : AddSuccessionTransaction (mempool)
+//   - validation.go     : block dispatch + plan check on identities
+//   - registry.go       : activity tracking + dispatch into updateSuccessionRegistry
+//   - handlers.go       : POST submit handler + GET succession status
+//   - node.go           : SuccessionRegistry field + init
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bounds on a plan's inactivity window, in seconds.
+const (
+	MinSuccessionInactivity = int64(30 * 24 * time.Hour / time.Second)
+	MaxSuccessionInactivity = int64(10 * 365 * 24 * time.Hour / time.Second)
+)
+
+// ErrSuccessionPlanInvalid wraps every plan shape error.
+var ErrSuccessionPlanInvalid = errors.New("succession: invalid plan")
+
+// SuccessionPlan names who takes over a quid after
+// InactivitySeconds without signed activity. TransferTitles moves
+// the quid's title stakes to the successor on succession.
+type SuccessionPlan struct {
+	Successor         string `json:"successor"`
+	InactivitySeconds int64  `json:"inactivitySeconds"`
+	TransferTitles    bool   `json:"transferTitles,omitempty"`
+}
+
+func (p SuccessionPlan) validate(subject string) error {
+	if !IsValidQuidID(p.Successor) || p.Successor == subject {
+		return fmt.Errorf("%w: successor %q", ErrSuccessionPlanInvalid, p.Successor)
+	}
+	if p.InactivitySeconds < MinSuccessionInactivity || p.InactivitySeconds > MaxSuccessionInactivity {
+		return fmt.Errorf("%w: inactivity window must be between %d and %d seconds",
+			ErrSuccessionPlanInvalid, MinSuccessionInactivity, MaxSuccessionInactivity)
+	}
+	return nil
+}
+
+// SuccessionTransaction hands SubjectQuid to SuccessorQuid. Signed
+// by the successor.
+type SuccessionTransaction struct {
+	BaseTransaction
+	SubjectQuid   string `json:"subjectQuid"`
+	SuccessorQuid string `json:"successorQuid"`
+}
+
+// SuccessionRecord is a completed succession. Titles lists the
+// assets whose stakes moved.
+type SuccessionRecord struct {
+	SubjectQuid   string   `json:"subjectQuid"`
+	SuccessorQuid string   `json:"successorQuid"`
+	TxID          string   `json:"txId"`
+	SucceededAt   int64    `json:"succeededAt"`
+	Titles        []string `json:"titles,omitempty"`
+}
+
+// SuccessionRegistry tracks signed activity per quid and completed
+// successions.
+type SuccessionRegistry struct {
+	mu sync.RWMutex
+
+	// lastActive maps quid → latest timestamp of a committed
+	// transaction it signed.
+	lastActive map[string]int64
+
+	// succeeded maps subject quid → its succession.
+	succeeded map[string]SuccessionRecord
+}
+
+// NewSuccessionRegistry constructs an empty registry.
+func NewSuccessionRegistry() *SuccessionRegistry {
+	return &SuccessionRegistry{
+		lastActive: make(map[string]int64),
+		succeeded:  make(map[string]SuccessionRecord),
+	}
+}
+
+func (r *SuccessionRegistry) observe(quid string, ts int64) {
+	r.mu.Lock()
+	if ts > r.lastActive[quid] {
+		r.lastActive[quid] = ts
+	}
+	r.mu.Unlock()
+}
+
+func (r *SuccessionRegistry) lastActivity(quid string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastActive[quid]
+}
+
+func (r *SuccessionRegistry) get(subject string) (SuccessionRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.succeeded[subject]
+	return rec, ok
+}
+
+// observeSignedActivity credits a committed transaction to the quid
+// its signing key derives to.
+func (node *QuidnugNode) observeSignedActivity(tx BaseTransaction) {
+	if node.SuccessionRegistry == nil || tx.PublicKey == "" || tx.Timestamp == 0 {
+		return
+	}
+	if quid := QuidIDFromPublicKeyHex(tx.PublicKey); quid != "" {
+		node.SuccessionRegistry.observe(quid, tx.Timestamp)
+	}
+}
+
+// lastSignedActivity returns the latest evidence that quid is
+// alive: its newest signed transaction or its identity record,
+// whichever is later.
+func (node *QuidnugNode) lastSignedActivity(quid string, identity IdentityTransaction) int64 {
+	last := identity.Timestamp
+	if node.SuccessionRegistry != nil {
+		if ts := node.SuccessionRegistry.lastActivity(quid); ts > last {
+			last = ts
+		}
+	}
+	return last
+}
+
+// successorOf returns the quid that has succeeded to subject, if
+// any.
+func (node *QuidnugNode) successorOf(subject string) (string, bool) {
+	if node.SuccessionRegistry == nil {
+		return "", false
+	}
+	rec, ok := node.SuccessionRegistry.get(subject)
+	return rec.SuccessorQuid, ok
+}
+
+// SuccessionStatus is the GET view of a quid's succession.
+type SuccessionStatus struct {
+	QuidID       string            `json:"quidId"`
+	Plan         *SuccessionPlan   `json:"plan,omitempty"`
+	LastActivity int64             `json:"lastActivity"`
+	EligibleAt   int64             `json:"eligibleAt,omitempty"`
+	Succession   *SuccessionRecord `json:"succession,omitempty"`
+}
+
+// GetSuccessionStatus reports quidID's plan, its last signed
+// activity, and when (or whether) its successor took over.
+func (node *QuidnugNode) GetSuccessionStatus(quidID string) (SuccessionStatus, bool) {
+	identity, ok := node.GetQuidIdentity(quidID)
+	if !ok {
+		return SuccessionStatus{}, false
+	}
+	status := SuccessionStatus{
+		QuidID:       quidID,
+		Plan:         identity.Succession,
+		LastActivity: node.lastSignedActivity(quidID, identity),
+	}
+	if identity.Succession != nil {
+		status.EligibleAt = status.LastActivity + identity.Succession.InactivitySeconds
+	}
+	if node.SuccessionRegistry != nil {
+		if rec, done := node.SuccessionRegistry.get(quidID); done {
+			status.Succession = &rec
+		}
+	}
+	return status, true
+}
+
+// ValidateSuccessionTransaction checks that tx's successor is the
+// one the subject named and that the subject has been inactive for
+// the whole window by tx's timestamp.
+func (node *QuidnugNode) ValidateSuccessionTransaction(tx SuccessionTransaction) bool {
+	// 1. Domain must exist.
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Succession from unknown trust domain", "domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Subject has a plan naming this successor and hasn't been
+	// succeeded already.
+	if !IsValidQuidID(tx.SubjectQuid) || !IsValidQuidID(tx.SuccessorQuid) {
+		logger.Warn("Succession has invalid quid",
+			"subject", tx.SubjectQuid, "successor", tx.SuccessorQuid, "txId", tx.ID)
+		return false
+	}
+	node.IdentityRegistryMutex.RLock()
+	identity, exists := node.IdentityRegistry[tx.SubjectQuid]
+	node.IdentityRegistryMutex.RUnlock()
+	if !exists || identity.Succession == nil {
+		logger.Warn("Succession for a quid without a succession plan", "subject", tx.SubjectQuid, "txId", tx.ID)
+		return false
+	}
+	if identity.Succession.Successor != tx.SuccessorQuid {
+		logger.Warn("Succession names a different successor than the plan",
+			"planned", identity.Succession.Successor, "successor", tx.SuccessorQuid, "txId", tx.ID)
+		return false
+	}
+	if _, done := node.successorOf(tx.SubjectQuid); done {
+		logger.Warn("Succession for an already succeeded quid", "subject", tx.SubjectQuid, "txId", tx.ID)
+		return false
+	}
+
+	// 3. Signed by (or for) the successor.
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Succession missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	if !node.signsFor(tx.SuccessorQuid, tx.PublicKey, TxTypeSuccession, tx.delegationTime()) {
+		logger.Warn("Succession not signed by the successor",
+			"successor", tx.SuccessorQuid, "txId", tx.ID)
+		return false
+	}
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Failed to marshal succession for signature verification", "txId", tx.ID, "error", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Invalid signature in succession", "txId", tx.ID)
+		return false
+	}
+
+	// 4. The inactivity window has run out by tx's timestamp, and
+	// that timestamp isn't in the future.
+	if tx.Timestamp <= 0 || tx.Timestamp > nowUnix()+int64(AnchorMaxFutureSkew/time.Second) {
+		logger.Warn("Succession timestamp out of range", "timestamp", tx.Timestamp, "txId", tx.ID)
+		return false
+	}
+	last := node.lastSignedActivity(tx.SubjectQuid, identity)
+	if tx.Timestamp-last < identity.Succession.InactivitySeconds {
+		logger.Warn("Succession before the subject's inactivity window ran out",
+			"subject", tx.SubjectQuid,
+			"lastActivity", last,
+			"eligibleAt", last+identity.Succession.InactivitySeconds,
+			"txId", tx.ID)
+		return false
+	}
+
+	return node.passesTxHooks(TxTypeSuccession, tx.TrustDomain, tx.ID, tx)
+}
+
+// updateSuccessionRegistry applies a committed succession and, when
+// the plan says so, moves the subject's title stakes.
+func (node *QuidnugNode) updateSuccessionRegistry(tx SuccessionTransaction) {
+	if node.SuccessionRegistry == nil {
+		return
+	}
+	identity, ok := node.GetQuidIdentity(tx.SubjectQuid)
+	if !ok || identity.Succession == nil || identity.Succession.Successor != tx.SuccessorQuid {
+		return
+	}
+	rec := SuccessionRecord{
+		SubjectQuid:   tx.SubjectQuid,
+		SuccessorQuid: tx.SuccessorQuid,
+		TxID:          tx.ID,
+		SucceededAt:   tx.Timestamp,
+	}
+	r := node.SuccessionRegistry
+	r.mu.Lock()
+	if _, done := r.succeeded[tx.SubjectQuid]; done {
+		r.mu.Unlock()
+		return
+	}
+	r.succeeded[tx.SubjectQuid] = rec
+	r.mu.Unlock()
+
+	if identity.Succession.TransferTitles {
+		rec.Titles = node.transferTitlesToSuccessor(tx.SubjectQuid, tx.SuccessorQuid)
+		r.mu.Lock()
+		r.succeeded[tx.SubjectQuid] = rec
+		r.mu.Unlock()
+	}
+	logger.Info("Quid succeeded",
+		"subject", tx.SubjectQuid, "successor", tx.SuccessorQuid, "titles", len(rec.Titles), "txId", tx.ID)
+}
+
+// transferTitlesToSuccessor replaces subject with successor in the
+// owners of every title subject holds a stake in, merging stakes
+// when the successor already owns part of the asset.
+func (node *QuidnugNode) transferTitlesToSuccessor(subject, successor string) []string {
+	var moved []string
+	for _, owned := range node.GetOwnedAssets(subject) {
+		node.TitleRegistryMutex.Lock()
+		title, ok := node.TitleRegistry[owned.AssetID]
+		if !ok {
+			node.TitleRegistryMutex.Unlock()
+			continue
+		}
+		owners := make([]OwnershipStake, 0, len(title.Owners))
+		at := make(map[string]int, len(title.Owners))
+		for _, stake := range title.Owners {
+			if stake.OwnerID == subject {
+				stake.OwnerID = successor
+			}
+			if i, dup := at[stake.OwnerID]; dup && owners[i].StakeType == stake.StakeType {
+				owners[i].Percentage += stake.Percentage
+				continue
+			}
+			at[stake.OwnerID] = len(owners)
+			owners = append(owners, stake)
+		}
+		title.Owners = owners
+		node.TitleRegistry[owned.AssetID] = title
+		if node.OwnerIndex != nil {
+			node.OwnerIndex.replace(owned.AssetID, owners)
+		}
+		node.TitleRegistryMutex.Unlock()
+		moved = append(moved, owned.AssetID)
+	}
+	return moved
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSuccession_AfterInactivity(t *testing.T) {
+	node := newTestNode()
+	subject, successor, coOwner := newTestNodeActor(t), newTestNodeActor(t), newTestNodeActor(t)
+	now := nowUnix()
+	registered := now - MinSuccessionInactivity - 3600
+
+	register := func(a *testNodeActor, ts int64, plan *SuccessionPlan) {
+		node.IdentityRegistry[a.QuidID] = IdentityTransaction{
+			BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: a.PubHex, Timestamp: ts},
+			QuidID:          a.QuidID,
+			Succession:      plan,
+		}
+	}
+	register(subject, registered, &SuccessionPlan{
+		Successor:         successor.QuidID,
+		InactivitySeconds: MinSuccessionInactivity,
+		TransferTitles:    true,
+	})
+	register(successor, registered, nil)
+	register(coOwner, registered, nil)
+
+	owners := []OwnershipStake{
+		{OwnerID: subject.QuidID, Percentage: 0.5},
+		{OwnerID: coOwner.QuidID, Percentage: 0.5},
+	}
+	node.TitleRegistry["asset-estate"] = TitleTransaction{AssetID: "asset-estate", Owners: owners}
+	node.OwnerIndex.replace("asset-estate", owners)
+
+	succession := func(signer *testNodeActor, subjectQuid string, ts int64) SuccessionTransaction {
+		tx := SuccessionTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          "succession-1",
+				Type:        TxTypeSuccession,
+				TrustDomain: "test.domain.com",
+				Timestamp:   ts,
+				PublicKey:   signer.PubHex,
+			},
+			SubjectQuid:   subjectQuid,
+			SuccessorQuid: successor.QuidID,
+		}
+		signable, _ := json.Marshal(tx)
+		tx.Signature = signIEEE1363(signer.Priv, signable)
+		return tx
+	}
+
+	if node.ValidateSuccessionTransaction(succession(successor, subject.QuidID, registered+MinSuccessionInactivity-60)) {
+		t.Error("succession inside the inactivity window accepted")
+	}
+	if node.ValidateSuccessionTransaction(succession(coOwner, subject.QuidID, now)) {
+		t.Error("succession signed by someone other than the successor accepted")
+	}
+	if node.ValidateSuccessionTransaction(succession(successor, coOwner.QuidID, now)) {
+		t.Error("succession of a quid without a plan accepted")
+	}
+	tx := succession(successor, subject.QuidID, now)
+	if !node.ValidateSuccessionTransaction(tx) {
+		t.Fatal("succession after the inactivity window rejected")
+	}
+
+	node.updateSuccessionRegistry(tx)
+	if !node.signsFor(subject.QuidID, successor.PubHex, TxTypeLien, now) {
+		t.Error("successor cannot sign for the subject")
+	}
+	got := node.TitleRegistry["asset-estate"].Owners
+	if len(got) != 2 || got[0].OwnerID != successor.QuidID || got[0].Percentage != 0.5 {
+		t.Errorf("title owners after succession: %+v", got)
+	}
+	if assets := node.GetOwnedAssets(subject.QuidID); len(assets) != 0 {
+		t.Errorf("subject still indexed as owner: %+v", assets)
+	}
+	if node.ValidateSuccessionTransaction(succession(successor, subject.QuidID, now)) {
+		t.Error("second succession accepted")
+	}
+
+	status, _ := node.GetSuccessionStatus(subject.QuidID)
+	if status.Succession == nil || len(status.Succession.Titles) != 1 {
+		t.Errorf("status: %+v", status)
+	}
+}
+
+func TestSuccession_SealedAndApplied(t *testing.T) {
+	node := newTestNode()
+	node.TransactionTrustThreshold = 0
+	subject, successor := newTestNodeActor(t), newTestNodeActor(t)
+	now := nowUnix()
+	registered := now - MinSuccessionInactivity - 3600
+	node.IdentityRegistry[subject.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: subject.PubHex, Timestamp: registered},
+		QuidID:          subject.QuidID,
+		Succession:      &SuccessionPlan{Successor: successor.QuidID, InactivitySeconds: MinSuccessionInactivity, TransferTitles: true},
+	}
+	node.IdentityRegistry[successor.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: successor.PubHex, Timestamp: registered},
+		QuidID:          successor.QuidID,
+	}
+	owners := []OwnershipStake{{OwnerID: subject.QuidID, Percentage: 1.0}}
+	node.TitleRegistry["asset-estate"] = TitleTransaction{AssetID: "asset-estate", Owners: owners}
+	node.OwnerIndex.replace("asset-estate", owners)
+
+	tx := SuccessionTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "succession-1",
+			Type:        TxTypeSuccession,
+			TrustDomain: "test.domain.com",
+			Timestamp:   now,
+			PublicKey:   successor.PubHex,
+		},
+		SubjectQuid:   subject.QuidID,
+		SuccessorQuid: successor.QuidID,
+	}
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(successor.Priv, signable)
+	if _, err := node.AddSuccessionTransaction(tx); err != nil {
+		t.Fatalf("AddSuccessionTransaction: %v", err)
+	}
+
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 || canonicalTxKey(block.Transactions[0]).id != "succession-1" {
+		t.Fatalf("succession not sealed: %+v", block.Transactions)
+	}
+	if len(node.PendingTxs) != 0 {
+		t.Fatalf("expected an empty mempool, got %d pending", len(node.PendingTxs))
+	}
+
+	node.processBlockTransactions(*block)
+	if got := node.TitleRegistry["asset-estate"].Owners; len(got) != 1 || got[0].OwnerID != successor.QuidID {
+		t.Errorf("title owners after the block: %+v", got)
+	}
+	if status, _ := node.GetSuccessionStatus(subject.QuidID); status.Succession == nil {
+		t.Error("succession not recorded from the block")
+	}
+}
+
+func TestSuccession_ActivityResetsWindow(t *testing.T) {
+	node := newTestNode()
+	subject, successor := newTestNodeActor(t), newTestNodeActor(t)
+	now := nowUnix()
+	node.IdentityRegistry[subject.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: subject.PubHex, Timestamp: now - 2*MinSuccessionInactivity},
+		QuidID:          subject.QuidID,
+		Succession:      &SuccessionPlan{Successor: successor.QuidID, InactivitySeconds: MinSuccessionInactivity},
+	}
+	// A transaction the subject signed a day ago.
+	node.observeSignedActivity(BaseTransaction{PublicKey: subject.PubHex, Timestamp: now - 86400})
+
+	tx := SuccessionTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeSuccession, TrustDomain: "test.domain.com", Timestamp: now, PublicKey: successor.PubHex},
+		SubjectQuid:     subject.QuidID,
+		SuccessorQuid:   successor.QuidID,
+	}
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(successor.Priv, signable)
+	if node.ValidateSuccessionTransaction(tx) {
+		t.Error("succession accepted despite recent signed activity")
+	}
+}
+
+func TestSuccessionPlan_Validate(t *testing.T) {
+	subject := "a1b2c3d4e5f60718"
+	for _, p := range []SuccessionPlan{
+		{Successor: subject, InactivitySeconds: MinSuccessionInactivity},
+		{Successor: "nothex", InactivitySeconds: MinSuccessionInactivity},
+		{Successor: "0000000000000001", InactivitySeconds: 3600},
+		{Successor: "0000000000000001", InactivitySeconds: MaxSuccessionInactivity + 1},
+	} {
+		if err := p.validate(subject); err == nil {
+			t.Errorf("%+v validated", p)
+		}
+	}
+}
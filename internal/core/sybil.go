@@ -0,0 +1,193 @@
+// Sybil-resistance scoring for new identities.
+//
+// Quids cost nothing to mint, so a single actor can stand up
+// thousands of them. The score estimates how much it cost to get a
+// quid where it is, from three signals an attacker can't fake in
+// bulk:
+//
+//   - age: how long the quid has been active on the chain;
+//   - connectivity: trust it has received from long-standing quids,
+//     combined so several independent vouches count for more than
+//     one;
+//   - anchoring: whether it owns a live DNS attestation, which
+//     costs real money and a verifiable domain.
+//
+// Scores run from 0 (brand new, unvouched, unanchored) to 1. They
+// are served at GET /identity/{quidId}/sybil-score and, with
+// sybil_min_score set, gate block inclusion in
+// FilterTransactionsForBlock: a fresh quid gets its transactions
+// into blocks once an established quid vouches for it, not before.
+package core
+
+import (
+	"time"
+)
+
+// Sybil score weights and thresholds.
+const (
+	// SybilMatureAge is the age at which a quid earns the full age
+	// component and counts as long-standing for connectivity.
+	SybilMatureAge = 30 * 24 * time.Hour
+
+	SybilAgeWeight          = 0.4
+	SybilConnectivityWeight = 0.4
+	SybilAnchorWeight       = 0.2
+)
+
+// SybilScore is the breakdown for one quid.
+type SybilScore struct {
+	QuidID string  `json:"quidId"`
+	Score  float64 `json:"score"`
+	// FirstSeen is the Unix time (seconds) of the quid's first
+	// transaction in any domain; zero if it has none.
+	FirstSeen int64 `json:"firstSeen"`
+	// AgeScore, Connectivity and Anchored are the components
+	// before weighting.
+	AgeScore     float64 `json:"ageScore"`
+	Connectivity float64 `json:"connectivity"`
+	Anchored     bool    `json:"anchored"`
+	// Vouchers are the long-standing quids trusting this one.
+	Vouchers []string `json:"vouchers"`
+}
+
+// quidFirstSeen returns the earliest first-seen time (seconds)
+// recorded for quid across all domains, or 0.
+func (x *QuidDomainIndex) quidFirstSeen(quid string) int64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var first int64
+	for _, bucket := range x.stats {
+		if entry, ok := bucket[quid]; ok && entry.FirstSeen > 0 {
+			if first == 0 || entry.FirstSeen < first {
+				first = entry.FirstSeen
+			}
+		}
+	}
+	return first / 1e9
+}
+
+// hasActiveAttestationFor reports whether quid owns a DNS
+// attestation that is neither revoked nor expired at nowNs.
+func (r *DNSAttestationRegistry) hasActiveAttestationFor(quid string, nowNs int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for id, att := range r.attestations {
+		if att.OwnerQuid != quid || len(r.revocations[id]) > 0 {
+			continue
+		}
+		if att.ValidUntil == 0 || att.ValidUntil > nowNs {
+			return true
+		}
+	}
+	return false
+}
+
+// sybilScorer computes scores against one snapshot of the clock
+// and memoizes quid ages, so scoring a batch of transactions looks
+// each truster up once.
+type sybilScorer struct {
+	node  *QuidnugNode
+	now   time.Time
+	ages  map[string]int64
+	cache map[string]SybilScore
+}
+
+func (node *QuidnugNode) newSybilScorer() *sybilScorer {
+	return &sybilScorer{
+		node:  node,
+		now:   time.Now(),
+		ages:  make(map[string]int64),
+		cache: make(map[string]SybilScore),
+	}
+}
+
+func (s *sybilScorer) firstSeen(quid string) int64 {
+	if first, ok := s.ages[quid]; ok {
+		return first
+	}
+	var first int64
+	if s.node.QuidDomainIndex != nil {
+		first = s.node.QuidDomainIndex.quidFirstSeen(quid)
+	}
+	s.ages[quid] = first
+	return first
+}
+
+func (s *sybilScorer) longStanding(quid string) bool {
+	first := s.firstSeen(quid)
+	return first > 0 && s.now.Sub(time.Unix(first, 0)) >= SybilMatureAge
+}
+
+func (s *sybilScorer) score(quid string) SybilScore {
+	if cached, ok := s.cache[quid]; ok {
+		return cached
+	}
+	out := SybilScore{QuidID: quid, FirstSeen: s.firstSeen(quid), Vouchers: []string{}}
+
+	if out.FirstSeen > 0 {
+		age := s.now.Sub(time.Unix(out.FirstSeen, 0))
+		out.AgeScore = clamp01(float64(age) / float64(SybilMatureAge))
+	}
+
+	// Incoming edges have no index of their own, so collect them
+	// under the lock and judge the trusters afterwards.
+	type inbound struct {
+		truster string
+		level   float64
+	}
+	var edges []inbound
+	s.node.TrustRegistryMutex.RLock()
+	for truster, trustees := range s.node.TrustRegistry {
+		if level, ok := trustees[quid]; ok && level > 0 && truster != quid &&
+			s.node.isTrustEdgeValidLocked(truster, quid) {
+			edges = append(edges, inbound{truster, level})
+		}
+	}
+	s.node.TrustRegistryMutex.RUnlock()
+
+	untrusted := 1.0
+	for _, e := range edges {
+		if !s.longStanding(e.truster) {
+			continue
+		}
+		untrusted *= 1 - clamp01(e.level)
+		out.Vouchers = append(out.Vouchers, e.truster)
+	}
+	out.Connectivity = 1 - untrusted
+
+	if s.node.DNSAttestationRegistry != nil {
+		out.Anchored = s.node.DNSAttestationRegistry.hasActiveAttestationFor(quid, s.now.UnixNano())
+	}
+
+	out.Score = SybilAgeWeight*out.AgeScore + SybilConnectivityWeight*out.Connectivity
+	if out.Anchored {
+		out.Score += SybilAnchorWeight
+	}
+	s.cache[quid] = out
+	return out
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// ComputeSybilScore returns the cost/novelty score for a quid.
+func (node *QuidnugNode) ComputeSybilScore(quid string) SybilScore {
+	return node.newSybilScorer().score(quid)
+}
+
+// passesSybilGate reports whether creator's transactions may enter a
+// block under the node's SybilMinScore. The node's own quid is
+// exempt so a fresh validator can still publish its advertisement.
+func (s *sybilScorer) passesSybilGate(creator string) bool {
+	if s.node.SybilMinScore <= 0 || creator == s.node.NodeID {
+		return true
+	}
+	return s.score(creator).Score >= s.node.SybilMinScore
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSybilScore_Components(t *testing.T) {
+	node := newTestNode()
+	now := time.Now().Unix()
+	old, fresh := "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb"
+
+	node.QuidDomainIndex.observe("test.domain.com", old, now-60*24*3600)
+	node.QuidDomainIndex.observe("test.domain.com", fresh, now-3600)
+
+	score := node.ComputeSybilScore(fresh)
+	if score.Connectivity != 0 || score.Anchored || score.Score > 0.01 {
+		t.Fatalf("fresh unvouched quid: %+v", score)
+	}
+	if got := node.ComputeSybilScore(old); got.AgeScore != 1 || !floatEquals(got.Score, SybilAgeWeight, 1e-9) {
+		t.Fatalf("established quid: %+v", got)
+	}
+
+	// A vouch from another fresh quid doesn't count; two from
+	// long-standing quids combine.
+	second := "cccccccccccccccc"
+	node.QuidDomainIndex.observe("other.domain.com", second, now-90*24*3600)
+	node.TrustRegistry["dddddddddddddddd"] = map[string]float64{fresh: 1.0}
+	node.QuidDomainIndex.observe("test.domain.com", "dddddddddddddddd", now)
+	node.TrustRegistry[old] = map[string]float64{fresh: 0.5}
+	node.TrustRegistry[second] = map[string]float64{fresh: 0.5}
+
+	score = node.ComputeSybilScore(fresh)
+	if !floatEquals(score.Connectivity, 0.75, 1e-9) || len(score.Vouchers) != 2 {
+		t.Fatalf("vouched quid: %+v", score)
+	}
+
+	node.DNSAttestationRegistry.admitAttestation(DNSAttestationTransaction{
+		BaseTransaction: BaseTransaction{ID: "att-1"},
+		Domain:          "example.com",
+		OwnerQuid:       fresh,
+		ValidUntil:      time.Now().Add(time.Hour).UnixNano(),
+	})
+	if anchored := node.ComputeSybilScore(fresh); !anchored.Anchored || anchored.Score < score.Score+SybilAnchorWeight-1e-9 {
+		t.Fatalf("anchored quid: %+v", anchored)
+	}
+}
+
+func TestSybilScore_GatesBlockInclusion(t *testing.T) {
+	node := newTestNode()
+	node.SybilMinScore = 0.2
+	now := time.Now().Unix()
+	voucher, fresh := "aaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb"
+	node.QuidDomainIndex.observe("test.domain.com", voucher, now-60*24*3600)
+
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "t1", Type: TxTypeTrust, TrustDomain: "test.domain.com", Timestamp: now},
+		Truster:         fresh,
+		Trustee:         voucher,
+		TrustLevel:      0.5,
+	}
+	if got := node.FilterTransactionsForBlock([]interface{}{tx}, "test.domain.com"); len(got) != 0 {
+		t.Fatal("unvouched new quid's transaction included")
+	}
+
+	node.TrustRegistry[voucher] = map[string]float64{fresh: 0.8}
+	if got := node.FilterTransactionsForBlock([]interface{}{tx}, "test.domain.com"); len(got) != 1 {
+		t.Fatal("vouched quid's transaction filtered out")
+	}
+
+	rec := httptest.NewRecorder()
+	setupTestRouter(node).ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/identity/"+fresh+"/sybil-score", nil))
+	var resp struct {
+		Data SybilScore `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != 200 {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Data.QuidID != fresh || !floatEquals(resp.Data.Connectivity, 0.8, 1e-9) {
+		t.Errorf("handler returned %+v", resp.Data)
+	}
+}
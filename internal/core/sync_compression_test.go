@@ -0,0 +1,81 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadSyncBody(t *testing.T) {
+	payload := strings.Repeat(`{"type":"TRUST","trustLevel":0.8},`, 200)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(payload))
+	_ = zw.Close()
+
+	resp := func(enc string, body []byte) *http.Response {
+		h := http.Header{}
+		if enc != "" {
+			h.Set("Content-Encoding", enc)
+		}
+		return &http.Response{Header: h, Body: io.NopCloser(bytes.NewReader(body))}
+	}
+
+	got, err := readSyncBody(resp("gzip", gz.Bytes()), "test", 1<<20)
+	if err != nil || string(got) != payload {
+		t.Fatalf("gzip: %v, %d bytes", err, len(got))
+	}
+	if got, err := readSyncBody(resp("", []byte(payload)), "test", 1<<20); err != nil || string(got) != payload {
+		t.Fatalf("identity: %v", err)
+	}
+	if got, _ := readSyncBody(resp("gzip", gz.Bytes()), "test", 10); len(got) != 10 {
+		t.Errorf("limit not applied to decoded size: %d bytes", len(got))
+	}
+	if _, err := readSyncBody(resp("zstd", []byte("x")), "test", 1<<20); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+}
+
+func TestPeerJSONRequest_NegotiatesCompression(t *testing.T) {
+	for _, enc := range []string{"gzip", "deflate"} {
+		t.Run(enc, func(t *testing.T) {
+			var offered atomic.Value
+			srv := httptest.NewServer(CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				offered.Store(r.Header.Get("Accept-Encoding"))
+				WriteSuccess(w, MempoolTxIDsResponse{Domain: "d", IDs: []string{strings.Repeat("a", 64)}})
+			})))
+			t.Cleanup(srv.Close)
+			// Restrict the peer to one codec by rewriting what it sees.
+			srv.Config.Handler = stripAcceptEncodingTo(enc, srv.Config.Handler)
+
+			node := newTestNode()
+			var out MempoolTxIDsResponse
+			peer := Node{ID: "peer", Address: strings.TrimPrefix(srv.URL, "http://")}
+			if err := node.peerJSONRequest(t.Context(), peer, "GET", "/api/v1/mempool/d/ids", nil, &out); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := offered.Load().(string); got != enc {
+				t.Errorf("server saw Accept-Encoding %q", got)
+			}
+			if len(out.IDs) != 1 {
+				t.Errorf("decoded %+v", out)
+			}
+		})
+	}
+}
+
+// stripAcceptEncodingTo checks the client offered enc and narrows
+// the header to it.
+func stripAcceptEncodingTo(enc string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept-Encoding"), enc) {
+			r.Header.Set("Accept-Encoding", enc)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
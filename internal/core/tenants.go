@@ -0,0 +1,222 @@
+// Package core — tenants.go
+//
+// Multi-tenant hosting: with cfg.Tenants set, one process runs a
+// QuidnugNode per tenant, each with its own node key, chain, domains
+// and data directory, behind a single HTTP server. A request reaches
+// a tenant by its Host header or, failing that, by path prefix
+// (/t/acme/api/v1/... is acme's /api/v1/...). Requests that match no
+// tenant get a 404; there is no default node.
+//
+// Host routing is the better fit for tenants that peer with other
+// nodes: peers address a node as host:port, and a path-prefixed
+// tenant is only reachable to peers that were given the prefix as
+// part of its address.
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// tenantNamePattern keeps tenant names usable as directory names
+// and archive key segments.
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Tenant is one hosted node.
+type Tenant struct {
+	Name   string
+	Config *config.Config
+	Node   *QuidnugNode
+
+	hosts  []string
+	prefix string
+}
+
+// validateTenants checks tenant names are unique and well formed and
+// that no two tenants claim the same host or overlapping prefixes.
+func validateTenants(tenants []config.TenantConfig) error {
+	names := make(map[string]bool)
+	hosts := make(map[string]string)
+	var prefixes []string
+	for _, t := range tenants {
+		if !tenantNamePattern.MatchString(t.Name) {
+			return fmt.Errorf("tenant name %q: want lowercase letters, digits and hyphens", t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("tenant %q is configured twice", t.Name)
+		}
+		names[t.Name] = true
+		if len(t.Hosts) == 0 && t.PathPrefix == "" {
+			return fmt.Errorf("tenant %q: set hosts or path_prefix", t.Name)
+		}
+		for _, h := range t.Hosts {
+			h = strings.ToLower(strings.TrimSpace(h))
+			if h == "" {
+				return fmt.Errorf("tenant %q: empty host", t.Name)
+			}
+			if other, taken := hosts[h]; taken {
+				return fmt.Errorf("tenant %q: host %s already belongs to %q", t.Name, h, other)
+			}
+			hosts[h] = t.Name
+		}
+		if p := t.PathPrefix; p != "" {
+			if !strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/") {
+				return fmt.Errorf("tenant %q: path_prefix %q must start with / and not end with one", t.Name, p)
+			}
+			for _, other := range prefixes {
+				if matchesPrefix(p, other) || matchesPrefix(other, p) {
+					return fmt.Errorf("tenant %q: path_prefix %s overlaps %s", t.Name, p, other)
+				}
+			}
+			prefixes = append(prefixes, p)
+		}
+	}
+	return nil
+}
+
+// matchesPrefix reports whether path is prefix or lies beneath it.
+func matchesPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// NewTenants builds a node for every tenant in cfg.Tenants.
+func NewTenants(cfg *config.Config) ([]*Tenant, error) {
+	if err := validateTenants(cfg.Tenants); err != nil {
+		return nil, err
+	}
+	out := make([]*Tenant, 0, len(cfg.Tenants))
+	for _, tc := range cfg.Tenants {
+		tcfg := cfg.ForTenant(tc)
+		node, err := NewQuidnugNode(tcfg)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", tc.Name, err)
+		}
+		hosts := make([]string, 0, len(tc.Hosts))
+		for _, h := range tc.Hosts {
+			hosts = append(hosts, strings.ToLower(strings.TrimSpace(h)))
+		}
+		out = append(out, &Tenant{Name: tc.Name, Config: tcfg, Node: node, hosts: hosts, prefix: tc.PathPrefix})
+	}
+	return out, nil
+}
+
+// TenantRouter sends each request to the HTTP handler of the tenant
+// it addresses.
+type TenantRouter struct {
+	byHost   map[string]http.Handler
+	prefixes []tenantPrefix // longest first
+}
+
+type tenantPrefix struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewTenantRouter builds every tenant's handler (see NewHTTPHandler)
+// and routes between them.
+func NewTenantRouter(tenants []*Tenant, rateLimitPerMinute int, maxBodySizeBytes int64) *TenantRouter {
+	tr := &TenantRouter{byHost: make(map[string]http.Handler)}
+	for _, t := range tenants {
+		handler := t.Node.NewHTTPHandler(rateLimitPerMinute, maxBodySizeBytes)
+		for _, h := range t.hosts {
+			tr.byHost[h] = handler
+		}
+		if t.prefix != "" {
+			tr.prefixes = append(tr.prefixes, tenantPrefix{t.prefix, http.StripPrefix(t.prefix, handler)})
+		}
+	}
+	sort.Slice(tr.prefixes, func(i, j int) bool {
+		return len(tr.prefixes[i].prefix) > len(tr.prefixes[j].prefix)
+	})
+	return tr
+}
+
+func (tr *TenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := strings.ToLower(r.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if handler, ok := tr.byHost[host]; ok {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	for _, p := range tr.prefixes {
+		if matchesPrefix(r.URL.Path, p.prefix) {
+			p.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	WriteError(w, http.StatusNotFound, "UNKNOWN_TENANT", "No tenant is served at this host or path")
+}
+
+// runTenants is Run for a multi-tenant process: it starts every
+// tenant's background loops, serves them all on cfg.Port, and shuts
+// each node down once ctx is cancelled or the server fails.
+func runTenants(ctx context.Context, cancel context.CancelFunc, cfg *config.Config, wg *sync.WaitGroup) {
+	tenants, err := NewTenants(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize tenants", "error", err)
+		os.Exit(1)
+	}
+	for _, t := range tenants {
+		logger.Info("Starting tenant", "tenant", t.Name, "nodeId", t.Node.NodeID, "hosts", t.hosts, "pathPrefix", t.prefix)
+		t.Node.StartBackground(ctx, t.Config, wg)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           NewTenantRouter(tenants, cfg.RateLimitPerMinute, cfg.MaxBodySizeBytes),
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Same TLS switch as StartServerWithConfig.
+		certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+		logger.Info("Starting multi-tenant server", "port", cfg.Port, "tenants", len(tenants), "tls", certFile != "" && keyFile != "")
+		var err error
+		if certFile != "" && keyFile != "" {
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Info("Initiating graceful shutdown...")
+	case err := <-serverErr:
+		logger.Error("Server failed", "error", err)
+		cancel()
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown error", "error", err)
+	}
+	for _, t := range tenants {
+		logger.Info("Shutting down tenant", "tenant", t.Name)
+		t.Node.Shutdown(ctx, t.Config)
+	}
+
+	logger.Info("Waiting for background goroutines to finish...")
+	wg.Wait()
+	logger.Info("Shutdown complete")
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+func TestTenantRouter(t *testing.T) {
+	cfg := &config.Config{
+		DataDir:          t.TempDir(),
+		SupportedDomains: []string{"shared.example"},
+		Tenants: []config.TenantConfig{
+			{Name: "acme", Hosts: []string{"Acme.Example"}},
+			{Name: "globex", PathPrefix: "/t/globex", SupportedDomains: []string{"globex.example"}},
+		},
+	}
+	tenants, err := NewTenants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenants[0].Node.NodeID == tenants[1].Node.NodeID {
+		t.Fatal("tenants share a node key")
+	}
+	if !strings.HasSuffix(tenants[1].Config.DataDir, "tenants/globex") || tenants[1].Config.SupportedDomains[0] != "globex.example" {
+		t.Errorf("globex config = %+v", tenants[1].Config)
+	}
+	router := NewTenantRouter(tenants, config.DefaultRateLimitPerMinute, config.DefaultMaxBodySizeBytes)
+
+	nodeQuid := func(req *http.Request) (int, string) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp struct {
+			Data struct {
+				NodeQuid string `json:"nodeQuid"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp.Data.NodeQuid
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/info", nil)
+	req.Host = "acme.example:8080"
+	if code, quid := nodeQuid(req); code != http.StatusOK || quid != tenants[0].Node.NodeID {
+		t.Errorf("by host: %d %s", code, quid)
+	}
+	if code, quid := nodeQuid(httptest.NewRequest(http.MethodGet, "/t/globex/api/v1/info", nil)); code != http.StatusOK || quid != tenants[1].Node.NodeID {
+		t.Errorf("by prefix: %d %s", code, quid)
+	}
+	if code, _ := nodeQuid(httptest.NewRequest(http.MethodGet, "/t/globexx/api/v1/info", nil)); code != http.StatusNotFound {
+		t.Errorf("unknown prefix: expected 404, got %d", code)
+	}
+	if code, _ := nodeQuid(httptest.NewRequest(http.MethodGet, "/api/v1/info", nil)); code != http.StatusNotFound {
+		t.Errorf("no tenant: expected 404, got %d", code)
+	}
+}
+
+func TestValidateTenants(t *testing.T) {
+	for name, tenants := range map[string][]config.TenantConfig{
+		"bad name":        {{Name: "Acme", Hosts: []string{"a"}}},
+		"duplicate name":  {{Name: "a", Hosts: []string{"a"}}, {Name: "a", Hosts: []string{"b"}}},
+		"no selector":     {{Name: "a"}},
+		"shared host":     {{Name: "a", Hosts: []string{"x.example"}}, {Name: "b", Hosts: []string{"X.example"}}},
+		"trailing slash":  {{Name: "a", PathPrefix: "/t/a/"}},
+		"nested prefixes": {{Name: "a", PathPrefix: "/t"}, {Name: "b", PathPrefix: "/t/b"}},
+		"relative prefix": {{Name: "a", PathPrefix: "t/a"}},
+	} {
+		if err := validateTenants(tenants); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	if err := validateTenants([]config.TenantConfig{{Name: "a", PathPrefix: "/t/a"}, {Name: "ab", PathPrefix: "/t/ab"}}); err != nil {
+		t.Errorf("sibling prefixes rejected: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+// Tentative-chain reads for registry queries.
+//
+// Registries only reflect trusted blocks; tentative blocks sit in
+// TentativeBlocks until a validator's trust crosses the domain
+// threshold. Consumers that want a "pending" view (a marketplace
+// showing an in-flight title sale, say) can pass
+// include_tentative=true to the identity, title, and trust read
+// endpoints. Every returned entity is then wrapped in a
+// TieredEntity naming its acceptance tier and the block it came
+// from.
+//
+// The tentative side is derived on demand by decoding the held
+// blocks. TentativeBlocks is small and bounded by the GC in
+// tentative_gc.go, so a per-request decode is cheap compared with
+// maintaining a second, speculative copy of every registry.
+package core
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// Acceptance tiers reported on tiered reads.
+const (
+	AcceptanceTierTrusted   = "trusted"
+	AcceptanceTierTentative = "tentative"
+)
+
+// SourceBlockRef identifies the block an entity was read from.
+type SourceBlockRef struct {
+	Domain string `json:"domain"`
+	Index  int64  `json:"index"`
+	Hash   string `json:"hash"`
+}
+
+// TieredEntity is one registry entity annotated with its tier.
+// SourceBlock is nil for trusted state seeded outside a block
+// (genesis, tests, snapshot restores that predate the index).
+type TieredEntity struct {
+	Key         string          `json:"key"`
+	Tier        string          `json:"tier"`
+	SourceBlock *SourceBlockRef `json:"sourceBlock,omitempty"`
+	Entity      interface{}     `json:"entity"`
+}
+
+// EntitySourceIndex remembers which trusted block last wrote each
+// identity, title, and trust edge. Owns its own lock.
+type EntitySourceIndex struct {
+	mu      sync.RWMutex
+	sources map[string]SourceBlockRef
+}
+
+// NewEntitySourceIndex constructs an empty index.
+func NewEntitySourceIndex() *EntitySourceIndex {
+	return &EntitySourceIndex{sources: make(map[string]SourceBlockRef)}
+}
+
+func identitySourceKey(quidID string) string { return "identity:" + quidID }
+func titleSourceKey(assetID string) string   { return "title:" + assetID }
+func trustSourceKey(truster, trustee string) string {
+	return "trust:" + truster + ":" + trustee
+}
+
+func blockRef(block Block) SourceBlockRef {
+	return SourceBlockRef{
+		Domain: block.TrustProof.TrustDomain,
+		Index:  block.Index,
+		Hash:   block.Hash,
+	}
+}
+
+// record notes that block wrote the entity at key.
+func (x *EntitySourceIndex) record(key string, ref SourceBlockRef) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.sources[key] = ref
+}
+
+// lookup returns the block that last wrote key, or nil.
+func (x *EntitySourceIndex) lookup(key string) *SourceBlockRef {
+	if x == nil {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	ref, ok := x.sources[key]
+	if !ok {
+		return nil
+	}
+	return &ref
+}
+
+// tentativeTx is one decoded transaction from a tentative block.
+type tentativeTx struct {
+	raw []byte
+	ref SourceBlockRef
+}
+
+// tentativeTxsOfType decodes every transaction of txType held in
+// tentative blocks, ordered by domain then block index so later
+// writes follow earlier ones.
+func (node *QuidnugNode) tentativeTxsOfType(txType TransactionType) []tentativeTx {
+	node.TentativeBlocksMutex.RLock()
+	var blocks []Block
+	for _, held := range node.TentativeBlocks {
+		blocks = append(blocks, held...)
+	}
+	node.TentativeBlocksMutex.RUnlock()
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].TrustProof.TrustDomain != blocks[j].TrustProof.TrustDomain {
+			return blocks[i].TrustProof.TrustDomain < blocks[j].TrustProof.TrustDomain
+		}
+		return blocks[i].Index < blocks[j].Index
+	})
+
+	var out []tentativeTx
+	for _, block := range blocks {
+		for _, txInterface := range block.Transactions {
+			raw, err := json.Marshal(txInterface)
+			if err != nil {
+				continue
+			}
+			var base BaseTransaction
+			if err := json.Unmarshal(raw, &base); err != nil || base.Type != txType {
+				continue
+			}
+			out = append(out, tentativeTx{raw: raw, ref: blockRef(block)})
+		}
+	}
+	return out
+}
+
+// GetIdentityTiers returns the trusted identity for quidID (if
+// any) followed by each tentative update to it, in block order.
+func (node *QuidnugNode) GetIdentityTiers(quidID string) []TieredEntity {
+	var out []TieredEntity
+	if identity, ok := node.GetQuidIdentity(quidID); ok {
+		out = append(out, TieredEntity{
+			Key:         quidID,
+			Tier:        AcceptanceTierTrusted,
+			SourceBlock: node.EntitySources.lookup(identitySourceKey(quidID)),
+			Entity:      identity,
+		})
+	}
+	for _, t := range node.tentativeTxsOfType(TxTypeIdentity) {
+		var tx IdentityTransaction
+		if err := json.Unmarshal(t.raw, &tx); err != nil || tx.QuidID != quidID {
+			continue
+		}
+		ref := t.ref
+		out = append(out, TieredEntity{Key: quidID, Tier: AcceptanceTierTentative, SourceBlock: &ref, Entity: tx})
+	}
+	return out
+}
+
+// GetTitleTiers returns the trusted title for assetID (if any)
+// followed by each tentative rewrite of it, in block order.
+func (node *QuidnugNode) GetTitleTiers(assetID string) []TieredEntity {
+	var out []TieredEntity
+	if title, ok := node.GetAssetOwnership(assetID); ok {
+		out = append(out, TieredEntity{
+			Key:         assetID,
+			Tier:        AcceptanceTierTrusted,
+			SourceBlock: node.EntitySources.lookup(titleSourceKey(assetID)),
+			Entity:      title,
+		})
+	}
+	for _, t := range node.tentativeTxsOfType(TxTypeTitle) {
+		var tx TitleTransaction
+		if err := json.Unmarshal(t.raw, &tx); err != nil || tx.AssetID != assetID {
+			continue
+		}
+		ref := t.ref
+		out = append(out, TieredEntity{Key: assetID, Tier: AcceptanceTierTentative, SourceBlock: &ref, Entity: tx})
+	}
+	return out
+}
+
+// ListTieredIdentities returns every trusted identity plus every
+// tentative identity transaction, trusted entries first.
+func (node *QuidnugNode) ListTieredIdentities() []TieredEntity {
+	node.IdentityRegistryMutex.RLock()
+	out := make([]TieredEntity, 0, len(node.IdentityRegistry))
+	for quidID, identity := range node.IdentityRegistry {
+		out = append(out, TieredEntity{Key: quidID, Tier: AcceptanceTierTrusted, Entity: identity})
+	}
+	node.IdentityRegistryMutex.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	for i := range out {
+		out[i].SourceBlock = node.EntitySources.lookup(identitySourceKey(out[i].Key))
+	}
+
+	for _, t := range node.tentativeTxsOfType(TxTypeIdentity) {
+		var tx IdentityTransaction
+		if err := json.Unmarshal(t.raw, &tx); err != nil {
+			continue
+		}
+		ref := t.ref
+		out = append(out, TieredEntity{Key: tx.QuidID, Tier: AcceptanceTierTentative, SourceBlock: &ref, Entity: tx})
+	}
+	return out
+}
+
+// ListTieredTitles returns every trusted title plus every
+// tentative title transaction, trusted entries first.
+func (node *QuidnugNode) ListTieredTitles() []TieredEntity {
+	node.TitleRegistryMutex.RLock()
+	out := make([]TieredEntity, 0, len(node.TitleRegistry))
+	for assetID, title := range node.TitleRegistry {
+		out = append(out, TieredEntity{Key: assetID, Tier: AcceptanceTierTrusted, Entity: title})
+	}
+	node.TitleRegistryMutex.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	for i := range out {
+		out[i].SourceBlock = node.EntitySources.lookup(titleSourceKey(out[i].Key))
+	}
+
+	for _, t := range node.tentativeTxsOfType(TxTypeTitle) {
+		var tx TitleTransaction
+		if err := json.Unmarshal(t.raw, &tx); err != nil {
+			continue
+		}
+		ref := t.ref
+		out = append(out, TieredEntity{Key: tx.AssetID, Tier: AcceptanceTierTentative, SourceBlock: &ref, Entity: tx})
+	}
+	return out
+}
+
+// GetTrustTiers returns truster's trusted outbound edges plus any
+// tentative trust transactions it signed. Keys are trustee quids.
+func (node *QuidnugNode) GetTrustTiers(truster string) []TieredEntity {
+	node.TrustRegistryMutex.RLock()
+	out := make([]TieredEntity, 0, len(node.TrustRegistry[truster]))
+	for trustee, level := range node.TrustRegistry[truster] {
+		out = append(out, TieredEntity{
+			Key:  trustee,
+			Tier: AcceptanceTierTrusted,
+			Entity: map[string]interface{}{
+				"truster":     truster,
+				"trustee":     trustee,
+				"trust_level": level,
+			},
+		})
+	}
+	node.TrustRegistryMutex.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	for i := range out {
+		out[i].SourceBlock = node.EntitySources.lookup(trustSourceKey(truster, out[i].Key))
+	}
+
+	for _, t := range node.tentativeTxsOfType(TxTypeTrust) {
+		var tx TrustTransaction
+		if err := json.Unmarshal(t.raw, &tx); err != nil || tx.Truster != truster {
+			continue
+		}
+		ref := t.ref
+		out = append(out, TieredEntity{Key: tx.Trustee, Tier: AcceptanceTierTentative, SourceBlock: &ref, Entity: tx})
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+// Tiered read tests: trusted source-block tracking, tentative
+// overlay, and the include_tentative query parameter.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func tieredTitleTx(assetID, owner string) TitleTransaction {
+	return TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-" + owner, Type: TxTypeTitle, TrustDomain: "test.domain.com"},
+		AssetID:         assetID,
+		Owners:          []OwnershipStake{{OwnerID: owner, Percentage: 1.0}},
+	}
+}
+
+func TestTitleTiers_TrustedAndTentative(t *testing.T) {
+	node := newTestNode()
+	node.processBlockTransactions(Block{
+		Index:        1,
+		Hash:         "trusted-hash",
+		Transactions: []interface{}{tieredTitleTx("asset-1", "0000000000000001")},
+		TrustProof:   TrustProof{TrustDomain: "test.domain.com"},
+	})
+	if err := node.StoreTentativeBlock(Block{
+		Index:        2,
+		Hash:         "tentative-hash",
+		Transactions: []interface{}{tieredTitleTx("asset-1", "0000000000000002")},
+		TrustProof:   TrustProof{TrustDomain: "test.domain.com"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiers := node.GetTitleTiers("asset-1")
+	if len(tiers) != 2 {
+		t.Fatalf("expected trusted + tentative entries, got %d", len(tiers))
+	}
+	if tiers[0].Tier != AcceptanceTierTrusted || tiers[0].SourceBlock == nil || tiers[0].SourceBlock.Hash != "trusted-hash" {
+		t.Errorf("unexpected trusted entry: %+v", tiers[0])
+	}
+	if tiers[1].Tier != AcceptanceTierTentative || tiers[1].SourceBlock.Index != 2 {
+		t.Errorf("unexpected tentative entry: %+v", tiers[1])
+	}
+	pending := tiers[1].Entity.(TitleTransaction)
+	if pending.Owners[0].OwnerID != "0000000000000002" {
+		t.Errorf("tentative entry should carry the pending owner, got %+v", pending.Owners)
+	}
+
+	// The trusted registry is untouched by the tentative block.
+	if title, _ := node.GetAssetOwnership("asset-1"); title.Owners[0].OwnerID != "0000000000000001" {
+		t.Error("tentative block leaked into the trusted registry")
+	}
+}
+
+func TestTrustTiers_TentativeEdge(t *testing.T) {
+	node := newTestNode()
+	node.StoreTentativeBlock(Block{
+		Index: 5,
+		Hash:  "h5",
+		Transactions: []interface{}{TrustTransaction{
+			BaseTransaction: BaseTransaction{Type: TxTypeTrust, TrustDomain: "test.domain.com"},
+			Truster:         "0000000000000001",
+			Trustee:         "0000000000000002",
+			TrustLevel:      0.5,
+		}},
+		TrustProof: TrustProof{TrustDomain: "test.domain.com"},
+	})
+
+	tiers := node.GetTrustTiers("0000000000000001")
+	if len(tiers) != 1 || tiers[0].Tier != AcceptanceTierTentative || tiers[0].Key != "0000000000000002" {
+		t.Fatalf("unexpected tiers: %+v", tiers)
+	}
+}
+
+func TestGetTitleHandler_IncludeTentative(t *testing.T) {
+	node := newTestNode()
+	node.StoreTentativeBlock(Block{
+		Index:        1,
+		Hash:         "h1",
+		Transactions: []interface{}{tieredTitleTx("asset-pending", "0000000000000002")},
+		TrustProof:   TrustProof{TrustDomain: "test.domain.com"},
+	})
+	router := setupTestRouter(node)
+
+	// Without the flag, a title that exists only tentatively is 404.
+	req := httptest.NewRequest("GET", "/api/v1/title/asset-pending", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without include_tentative, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/title/asset-pending?include_tentative=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with include_tentative, got %d", w.Code)
+	}
+	var resp struct {
+		Data struct {
+			AssetID string         `json:"assetId"`
+			Entries []TieredEntity `json:"entries"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Data.Entries) != 1 || resp.Data.Entries[0].Tier != AcceptanceTierTentative {
+		t.Errorf("unexpected entries: %+v", resp.Data.Entries)
+	}
+}
+
+func TestQueryIdentityRegistry_IncludeTentativeListing(t *testing.T) {
+	node := newTestNode()
+	node.IdentityRegistry["0000000000000001"] = IdentityTransaction{QuidID: "0000000000000001"}
+	node.StoreTentativeBlock(Block{
+		Index: 1,
+		Hash:  "h1",
+		Transactions: []interface{}{IdentityTransaction{
+			BaseTransaction: BaseTransaction{Type: TxTypeIdentity, TrustDomain: "test.domain.com"},
+			QuidID:          "0000000000000002",
+		}},
+		TrustProof: TrustProof{TrustDomain: "test.domain.com"},
+	})
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest("GET", "/api/v1/registry/identity?include_tentative=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data struct {
+			Data []TieredEntity `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	entries := resp.Data.Data
+	if len(entries) < 2 {
+		t.Fatalf("expected trusted and tentative entries, got %d", len(entries))
+	}
+	last := entries[len(entries)-1]
+	if last.Tier != AcceptanceTierTentative || last.Key != "0000000000000002" {
+		t.Errorf("tentative identity should be listed last, got %+v", last)
+	}
+	for _, e := range entries[:len(entries)-1] {
+		if e.Tier != AcceptanceTierTrusted {
+			t.Errorf("expected trusted entries before tentative ones, got %+v", e)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Package core — title disputes.
+//
+// A TITLE_DISPUTE transaction lets a party with standing contest a
+// title: a former owner of the asset, or a lienholder with an
+// active lien on it. An open dispute is shown on every title read,
+// and when the filer names an arbiter it may also freeze the
+// title: while it stays open, ValidateTitleTransaction rejects any
+// rewrite of the asset and a split or merge of it is refused.
+//
+// A second TITLE_DISPUTE naming the first in ResolvesDisputeID
+// closes it. The filer may withdraw; the arbiter may uphold or
+// dismiss. Freezing requires an arbiter so that a title can't be
+// held hostage by a filer who never withdraws.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go          : TxTypeTitleDispute const
+//   - title_dispute.go  : this file — struct, registry, validator
+//   - transactions.go   : AddTitleDisputeTransaction (mempool)
+//   - validation.go     : dispatch + frozen-title check
+//   - registry.go       : dispatch into updateTitleDisputeRegistry,
+//     former owners noted in updateTitleRegistry
+//   - handlers.go       : POST submit handler + disputes on title reads
+//   - node.go           : TitleDisputes field + init
+package core
+
+import "sync"
+
+// MaxDisputeDescriptionLength bounds the free-text description.
+const MaxDisputeDescriptionLength = 2048
+
+// Dispute resolutions.
+const (
+	DisputeWithdrawn = "withdrawn"
+	DisputeUpheld    = "upheld"
+	DisputeDismissed = "dismissed"
+)
+
+// Dispute states.
+const (
+	DisputeOpen     = "open"
+	DisputeResolved = "resolved"
+)
+
+// TitleDisputeTransaction files or resolves a dispute against a
+// title.
+type TitleDisputeTransaction struct {
+	BaseTransaction
+
+	// SignerQuid files the dispute, or resolves one as its filer
+	// or arbiter.
+	SignerQuid string `json:"signerQuid"`
+	AssetID    string `json:"assetId,omitempty"`
+
+	// Grounds is a short free-form classifier ("fraud",
+	// "boundary", "unpaid-consideration", ...). Informational.
+	Grounds     string `json:"grounds,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// ArbiterQuid may uphold or dismiss the dispute.
+	ArbiterQuid string `json:"arbiterQuid,omitempty"`
+	// BlocksTransfer freezes the title while the dispute is open.
+	// Requires ArbiterQuid.
+	BlocksTransfer bool `json:"blocksTransfer,omitempty"`
+
+	// ResolvesDisputeID, when set, closes the named dispute with
+	// Resolution. The filing fields are ignored.
+	ResolvesDisputeID string `json:"resolvesDisputeId,omitempty"`
+	Resolution        string `json:"resolution,omitempty"`
+
+	Nonce int64 `json:"nonce"`
+}
+
+// DisputeRecord is the registry view of a dispute.
+type DisputeRecord struct {
+	DisputeID      string `json:"disputeId"`
+	AssetID        string `json:"assetId"`
+	TrustDomain    string `json:"trustDomain"`
+	FilerQuid      string `json:"filerQuid"`
+	ArbiterQuid    string `json:"arbiterQuid,omitempty"`
+	Grounds        string `json:"grounds,omitempty"`
+	Description    string `json:"description,omitempty"`
+	BlocksTransfer bool   `json:"blocksTransfer"`
+	FiledAt        int64  `json:"filedAt"`
+
+	Status       string `json:"status"`
+	Resolution   string `json:"resolution,omitempty"`
+	ResolvedBy   string `json:"resolvedBy,omitempty"`
+	ResolvedAt   int64  `json:"resolvedAt,omitempty"`
+	ResolutionID string `json:"resolutionId,omitempty"`
+}
+
+// TitleDisputeRegistry indexes disputes by asset and tracks who
+// has owned each asset, for standing.
+type TitleDisputeRegistry struct {
+	mu sync.RWMutex
+
+	byID    map[string]*DisputeRecord
+	byAsset map[string][]string // assetID → dispute IDs, filing order
+
+	// formerOwners maps assetID → quids that held a stake in a
+	// title since replaced.
+	formerOwners map[string]map[string]bool
+
+	// nonces tracks the highest accepted nonce per signer.
+	nonces map[string]int64
+}
+
+// NewTitleDisputeRegistry constructs an empty registry.
+func NewTitleDisputeRegistry() *TitleDisputeRegistry {
+	return &TitleDisputeRegistry{
+		byID:         make(map[string]*DisputeRecord),
+		byAsset:      make(map[string][]string),
+		formerOwners: make(map[string]map[string]bool),
+		nonces:       make(map[string]int64),
+	}
+}
+
+func (r *TitleDisputeRegistry) currentNonce(signer string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nonces[signer]
+}
+
+// get returns a copy of a dispute.
+func (r *TitleDisputeRegistry) get(disputeID string) (DisputeRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.byID[disputeID]
+	if !ok {
+		return DisputeRecord{}, false
+	}
+	return *rec, true
+}
+
+// forAsset returns the disputes on assetID in filing order,
+// optionally only the open ones.
+func (r *TitleDisputeRegistry) forAsset(assetID string, openOnly bool) []DisputeRecord {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []DisputeRecord
+	for _, id := range r.byAsset[assetID] {
+		rec := r.byID[id]
+		if openOnly && rec.Status != DisputeOpen {
+			continue
+		}
+		out = append(out, *rec)
+	}
+	return out
+}
+
+// noteFormerOwners records the owners of a title being replaced.
+func (r *TitleDisputeRegistry) noteFormerOwners(assetID string, owners []OwnershipStake) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	set := r.formerOwners[assetID]
+	if set == nil {
+		set = make(map[string]bool)
+		r.formerOwners[assetID] = set
+	}
+	for _, s := range owners {
+		if s.OwnerID != "" {
+			set[s.OwnerID] = true
+		}
+	}
+}
+
+func (r *TitleDisputeRegistry) wasOwner(assetID, quid string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.formerOwners[assetID][quid]
+}
+
+// apply commits a validated filing or resolution. Idempotent on
+// replay.
+func (r *TitleDisputeRegistry) apply(tx TitleDisputeTransaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx.Nonce > r.nonces[tx.SignerQuid] {
+		r.nonces[tx.SignerQuid] = tx.Nonce
+	}
+
+	if tx.ResolvesDisputeID != "" {
+		rec, ok := r.byID[tx.ResolvesDisputeID]
+		if !ok || rec.Status != DisputeOpen {
+			return
+		}
+		rec.Status = DisputeResolved
+		rec.Resolution = tx.Resolution
+		rec.ResolvedBy = tx.SignerQuid
+		rec.ResolvedAt = tx.Timestamp
+		rec.ResolutionID = tx.ID
+		return
+	}
+
+	if _, seen := r.byID[tx.ID]; seen {
+		return
+	}
+	r.byID[tx.ID] = &DisputeRecord{
+		DisputeID:      tx.ID,
+		AssetID:        tx.AssetID,
+		TrustDomain:    tx.TrustDomain,
+		FilerQuid:      tx.SignerQuid,
+		ArbiterQuid:    tx.ArbiterQuid,
+		Grounds:        tx.Grounds,
+		Description:    tx.Description,
+		BlocksTransfer: tx.BlocksTransfer,
+		FiledAt:        tx.Timestamp,
+		Status:         DisputeOpen,
+	}
+	r.byAsset[tx.AssetID] = append(r.byAsset[tx.AssetID], tx.ID)
+}
+
+// GetTitleDisputes returns the disputes on an asset, oldest first;
+// openOnly drops resolved ones.
+func (node *QuidnugNode) GetTitleDisputes(assetID string, openOnly bool) []DisputeRecord {
+	return node.TitleDisputes.forAsset(assetID, openOnly)
+}
+
+// GetTitleDispute returns one dispute by ID.
+func (node *QuidnugNode) GetTitleDispute(disputeID string) (DisputeRecord, bool) {
+	if node.TitleDisputes == nil {
+		return DisputeRecord{}, false
+	}
+	return node.TitleDisputes.get(disputeID)
+}
+
+// titleFrozenBy returns an open dispute freezing assetID, if any.
+func (node *QuidnugNode) titleFrozenBy(assetID string) (DisputeRecord, bool) {
+	for _, d := range node.TitleDisputes.forAsset(assetID, true) {
+		if d.BlocksTransfer {
+			return d, true
+		}
+	}
+	return DisputeRecord{}, false
+}
+
+// hasDisputeStanding reports whether quid may dispute the title of
+// assetID as of ts: it is a previous owner, or holds an active
+// lien on the asset.
+func (node *QuidnugNode) hasDisputeStanding(assetID, quid string, current TitleTransaction, ts int64) bool {
+	for _, s := range current.PreviousOwners {
+		if s.OwnerID == quid {
+			return true
+		}
+	}
+	if node.TitleDisputes != nil && node.TitleDisputes.wasOwner(assetID, quid) {
+		return true
+	}
+	if node.LienRegistry != nil {
+		for _, l := range node.LienRegistry.activeFor(assetID, ts) {
+			if l.LienholderQuid == quid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateTitleDisputeRegistry commits a validated
+// TitleDisputeTransaction. Called from processBlockTransactions
+// once the containing block has been accepted.
+func (node *QuidnugNode) updateTitleDisputeRegistry(tx TitleDisputeTransaction) {
+	if node.TitleDisputes == nil {
+		return
+	}
+	node.TitleDisputes.apply(tx)
+	logger.Debug("Updated title dispute registry",
+		"txId", tx.ID,
+		"assetId", tx.AssetID,
+		"signer", tx.SignerQuid,
+		"resolves", tx.ResolvesDisputeID,
+		"nonce", tx.Nonce)
+}
+
+// ValidateTitleDisputeTransaction enforces the dispute rules.
+// Returns false on any violation; every failure is logged at Warn
+// level.
+func (node *QuidnugNode) ValidateTitleDisputeTransaction(tx TitleDisputeTransaction) bool {
+	// 1. Domain must exist + be supported.
+	if tx.TrustDomain == "" {
+		logger.Warn("Title dispute missing trust domain", "txId", tx.ID)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Title dispute from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Title dispute trust domain not supported by this node",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Signer consistency.
+	if !IsValidQuidID(tx.SignerQuid) {
+		logger.Warn("Title dispute has invalid SignerQuid", "signer", tx.SignerQuid, "txId", tx.ID)
+		return false
+	}
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Title dispute missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	if !node.signsFor(tx.SignerQuid, tx.PublicKey, TxTypeTitleDispute, tx.delegationTime()) {
+		logger.Warn("Title dispute SignerQuid does not match signing public key",
+			"signer", tx.SignerQuid, "txId", tx.ID)
+		return false
+	}
+	if node.TitleDisputes == nil {
+		logger.Warn("Title dispute without a registry", "txId", tx.ID)
+		return false
+	}
+
+	// 3. Shape: a resolution closes an open dispute by its filer
+	// (withdrawn) or arbiter (upheld/dismissed); a filing needs
+	// standing against an existing title.
+	if tx.ResolvesDisputeID != "" {
+		prior, exists := node.TitleDisputes.get(tx.ResolvesDisputeID)
+		if !exists || prior.Status != DisputeOpen {
+			logger.Warn("Title dispute resolution names unknown or closed dispute",
+				"resolvesDisputeId", tx.ResolvesDisputeID, "txId", tx.ID)
+			return false
+		}
+		switch {
+		case tx.Resolution == DisputeWithdrawn && tx.SignerQuid == prior.FilerQuid:
+		case (tx.Resolution == DisputeUpheld || tx.Resolution == DisputeDismissed) &&
+			prior.ArbiterQuid != "" && tx.SignerQuid == prior.ArbiterQuid:
+		default:
+			logger.Warn("Title dispute resolution not permitted for this signer",
+				"resolution", tx.Resolution, "signer", tx.SignerQuid,
+				"filer", prior.FilerQuid, "arbiter", prior.ArbiterQuid, "txId", tx.ID)
+			return false
+		}
+	} else {
+		if tx.AssetID == "" || !ValidateStringField(tx.AssetID, MaxNameLength) {
+			logger.Warn("Title dispute has invalid asset id", "assetId", tx.AssetID, "txId", tx.ID)
+			return false
+		}
+		current, exists := node.GetAssetOwnership(tx.AssetID)
+		if !exists {
+			logger.Warn("Title dispute against unknown asset", "assetId", tx.AssetID, "txId", tx.ID)
+			return false
+		}
+		if !node.hasDisputeStanding(tx.AssetID, tx.SignerQuid, current, tx.Timestamp) {
+			logger.Warn("Title dispute filer has no standing",
+				"assetId", tx.AssetID, "signer", tx.SignerQuid, "txId", tx.ID)
+			return false
+		}
+		for _, d := range node.TitleDisputes.forAsset(tx.AssetID, true) {
+			if d.FilerQuid == tx.SignerQuid {
+				logger.Warn("Title dispute filer already has an open dispute on the asset",
+					"assetId", tx.AssetID, "disputeId", d.DisputeID, "txId", tx.ID)
+				return false
+			}
+		}
+		if !ValidateStringField(tx.Grounds, MaxNameLength) ||
+			!ValidateStringField(tx.Description, MaxDisputeDescriptionLength) {
+			logger.Warn("Title dispute grounds or description too long or contains control characters", "txId", tx.ID)
+			return false
+		}
+		if tx.ArbiterQuid != "" {
+			if tx.ArbiterQuid == tx.SignerQuid {
+				logger.Warn("Title dispute filer cannot arbitrate their own dispute", "txId", tx.ID)
+				return false
+			}
+			if _, ok := node.quidPublicKey(tx.ArbiterQuid); !ok {
+				logger.Warn("Title dispute arbiter has no registered key",
+					"arbiter", tx.ArbiterQuid, "txId", tx.ID)
+				return false
+			}
+		}
+		if tx.BlocksTransfer && tx.ArbiterQuid == "" {
+			logger.Warn("Title dispute freezing the title must name an arbiter", "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 4. Nonce strictly monotonic per signer.
+	if tx.Nonce <= 0 {
+		logger.Warn("Title dispute has non-positive nonce", "nonce", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+	if prev := node.TitleDisputes.currentNonce(tx.SignerQuid); tx.Nonce <= prev {
+		logger.Warn("Title dispute nonce must be strictly greater than previous",
+			"previous", prev, "provided", tx.Nonce, "txId", tx.ID)
+		return false
+	}
+
+	// 5. Signature verifies.
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Title dispute marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Title dispute signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	return node.passesTxHooks(TxTypeTitleDispute, tx.TrustDomain, tx.ID, tx)
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func signDispute(a *testNodeActor, tx TitleDisputeTransaction) TitleDisputeTransaction {
+	tx.BaseTransaction.Type = TxTypeTitleDispute
+	tx.TrustDomain = "test.domain.com"
+	if tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	tx.SignerQuid = a.QuidID
+	tx.PublicKey = a.PubHex
+	signable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(a.Priv, signable)
+	return tx
+}
+
+// aliceTakesParcel moves parcel-1 wholly to alice, returning a
+// signed transfer onward to bob for the frozen-title checks.
+func aliceTakesParcel(f *restructureFixture) TitleTransaction {
+	f.node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-2", TrustDomain: "test.domain.com"},
+		AssetID:         "parcel-1",
+		TitleType:       "land",
+		Owners:          []OwnershipStake{{OwnerID: f.alice.QuidID, Percentage: 1}},
+	})
+	current, _ := f.node.GetAssetOwnership("parcel-1")
+	transfer := TitleTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: time.Now().Unix()},
+		AssetID:         "parcel-1",
+		TitleType:       "land",
+		Owners:          []OwnershipStake{{OwnerID: f.bob.QuidID, Percentage: 1}},
+		PreviousOwners:  current.Owners,
+	}
+	ownerSignable, _ := json.Marshal(transfer)
+	transfer.Signatures = map[string]string{f.alice.QuidID: signIEEE1363(f.alice.Priv, ownerSignable)}
+	transfer.PublicKey = f.alice.PubHex
+	signable, _ := json.Marshal(transfer)
+	transfer.Signature = signIEEE1363(f.alice.Priv, signable)
+	return transfer
+}
+
+func TestTitleDispute_FileFreezeAndResolve(t *testing.T) {
+	f := newRestructureFixture(t)
+	carol := newTestNodeActor(t)
+	f.node.IdentityRegistry[carol.QuidID] = IdentityTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: carol.PubHex},
+		QuidID:          carol.QuidID,
+	}
+
+	// Carol never held the parcel.
+	if f.node.ValidateTitleDisputeTransaction(signDispute(carol, TitleDisputeTransaction{AssetID: "parcel-1", Nonce: 1})) {
+		t.Fatal("dispute without standing accepted")
+	}
+
+	transfer := aliceTakesParcel(f)
+	if !f.node.ValidateTitleTransaction(transfer) {
+		t.Fatal("transfer of an undisputed title rejected")
+	}
+
+	// Bob, a former owner, may dispute; freezing needs an arbiter.
+	unfrozen := signDispute(f.bob, TitleDisputeTransaction{AssetID: "parcel-1", BlocksTransfer: true, Nonce: 1})
+	if f.node.ValidateTitleDisputeTransaction(unfrozen) {
+		t.Error("freezing dispute without an arbiter accepted")
+	}
+	filing := signDispute(f.bob, TitleDisputeTransaction{
+		BaseTransaction: BaseTransaction{ID: "dispute-1"},
+		AssetID:         "parcel-1",
+		Grounds:         "fraud",
+		ArbiterQuid:     carol.QuidID,
+		BlocksTransfer:  true,
+		Nonce:           1,
+	})
+	if !f.node.ValidateTitleDisputeTransaction(filing) {
+		t.Fatal("dispute by a former owner rejected")
+	}
+	f.node.updateTitleDisputeRegistry(filing)
+
+	if f.node.ValidateTitleTransaction(transfer) {
+		t.Error("transfer of a frozen title accepted")
+	}
+	again := signDispute(f.bob, TitleDisputeTransaction{AssetID: "parcel-1", Nonce: 2})
+	if f.node.ValidateTitleDisputeTransaction(again) {
+		t.Error("second open dispute by the same filer accepted")
+	}
+
+	// Only the arbiter upholds or dismisses.
+	byFiler := signDispute(f.bob, TitleDisputeTransaction{ResolvesDisputeID: "dispute-1", Resolution: DisputeDismissed, Nonce: 2})
+	if f.node.ValidateTitleDisputeTransaction(byFiler) {
+		t.Error("filer dismissed their own dispute")
+	}
+	dismissal := signDispute(carol, TitleDisputeTransaction{
+		BaseTransaction:   BaseTransaction{ID: "resolution-1"},
+		ResolvesDisputeID: "dispute-1",
+		Resolution:        DisputeDismissed,
+		Nonce:             1,
+	})
+	if !f.node.ValidateTitleDisputeTransaction(dismissal) {
+		t.Fatal("dismissal by the arbiter rejected")
+	}
+	f.node.updateTitleDisputeRegistry(dismissal)
+
+	d, ok := f.node.GetTitleDispute("dispute-1")
+	if !ok || d.Status != DisputeResolved || d.ResolvedBy != carol.QuidID {
+		t.Errorf("dispute after dismissal = %+v", d)
+	}
+	if open := f.node.GetTitleDisputes("parcel-1", true); len(open) != 0 {
+		t.Errorf("open disputes after dismissal = %+v", open)
+	}
+	if !f.node.ValidateTitleTransaction(transfer) {
+		t.Error("transfer still blocked after the dispute was dismissed")
+	}
+}
+
+func TestTitleDisputeRegistry_ResetClearsStanding(t *testing.T) {
+	r := NewTitleDisputeRegistry()
+	r.noteFormerOwners("parcel-1", []OwnershipStake{{OwnerID: "q1", Percentage: 1}})
+	r.apply(TitleDisputeTransaction{BaseTransaction: BaseTransaction{ID: "d1"}, AssetID: "parcel-1", SignerQuid: "q1", Nonce: 1})
+	if !r.wasOwner("parcel-1", "q1") || len(r.forAsset("parcel-1", true)) != 1 {
+		t.Fatal("registry did not record the filing")
+	}
+	r.reset()
+	if r.wasOwner("parcel-1", "q1") || len(r.forAsset("parcel-1", false)) != 0 || r.currentNonce("q1") != 0 {
+		t.Error("reset left state behind")
+	}
+}
@@ -0,0 +1,245 @@
+// Package core — title expiry.
+//
+// TitleTransaction.ExpiryDate used to be stored and never read. A
+// title now says what happens when it passes, in ExpiryAction:
+//
+//   - "lapse" (the default): the title stays in the registry,
+//     marked lapsed, and can no longer be transferred. A fresh
+//     title for the asset clears the mark.
+//   - "revert": ownership returns to the title's PreviousOwners
+//     (a fixed-term transfer), and the reverted title no longer
+//     expires. A title with no PreviousOwners lapses instead.
+//
+// Expiry is applied while processing blocks: before a block's
+// transactions, every title of the block's domain whose ExpiryDate
+// is at or before the block timestamp has its action applied, gets
+// a TitleExpiry record, and raises a title_expired chain event to
+// the event sink. Judging against block time rather than the local
+// clock means every node replaying the chain rewrites the same
+// titles at the same point. Transfer validation does not wait for
+// the next block: a transfer whose timestamp is at or past the
+// current title's ExpiryDate is rejected outright.
+//
+// Companion files:
+//
+//   - types.go        : TitleTransaction.ExpiryAction
+//   - validation.go   : expired-title and expiry-action checks
+//   - event_sink.go   : EnqueueEvents for events outside a block
+//   - handlers.go     : expiry state on the title read, expired list
+//   - registry.go     : expiry pass ahead of each block's transactions
+//   - node.go         : TitleExpiries field + init
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Title expiry actions.
+const (
+	TitleExpiryLapse  = "lapse"
+	TitleExpiryRevert = "revert"
+)
+
+// ChainEventTitleExpired is the event kind for an expired title.
+// It is raised while applying a block but is not carried by one;
+// its Timestamp is the block's, so a replay raises an identical
+// event.
+const ChainEventTitleExpired = "title_expired"
+
+// TitleExpiry records what happened to a title at expiry.
+type TitleExpiry struct {
+	AssetID     string `json:"assetId"`
+	TitleTxID   string `json:"titleTxId"`
+	TrustDomain string `json:"trustDomain"`
+	ExpiryDate  int64  `json:"expiryDate"`
+	ProcessedAt int64  `json:"processedAt"`
+	// Action is what was done: lapse or revert.
+	Action string           `json:"action"`
+	Owners []OwnershipStake `json:"owners"`
+	// RevertedTo holds the restored owners on a revert.
+	RevertedTo []OwnershipStake `json:"revertedTo,omitempty"`
+}
+
+// TitleExpiryRegistry holds the latest expiry record per asset.
+// Owns its own lock.
+type TitleExpiryRegistry struct {
+	mu      sync.RWMutex
+	byAsset map[string]TitleExpiry
+}
+
+// NewTitleExpiryRegistry constructs an empty registry.
+func NewTitleExpiryRegistry() *TitleExpiryRegistry {
+	return &TitleExpiryRegistry{byAsset: make(map[string]TitleExpiry)}
+}
+
+func (r *TitleExpiryRegistry) get(assetID string) (TitleExpiry, bool) {
+	if r == nil {
+		return TitleExpiry{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.byAsset[assetID]
+	return e, ok
+}
+
+// handled reports whether the title committed as titleTxID has
+// already been expired.
+func (r *TitleExpiryRegistry) handled(assetID, titleTxID string) bool {
+	e, ok := r.get(assetID)
+	return ok && e.TitleTxID == titleTxID
+}
+
+func (r *TitleExpiryRegistry) record(e TitleExpiry) {
+	r.mu.Lock()
+	r.byAsset[e.AssetID] = e
+	r.mu.Unlock()
+}
+
+// list returns every record, most recently processed first.
+func (r *TitleExpiryRegistry) list() []TitleExpiry {
+	r.mu.RLock()
+	out := make([]TitleExpiry, 0, len(r.byAsset))
+	for _, e := range r.byAsset {
+		out = append(out, e)
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ProcessedAt != out[j].ProcessedAt {
+			return out[i].ProcessedAt > out[j].ProcessedAt
+		}
+		return out[i].AssetID < out[j].AssetID
+	})
+	return out
+}
+
+// titleExpired reports whether a title has passed its ExpiryDate
+// at unix time at.
+func titleExpired(t TitleTransaction, at int64) bool {
+	return t.ExpiryDate > 0 && t.ExpiryDate <= at
+}
+
+// validateTitleExpiry checks a title's ExpiryDate, which must
+// fall after its timestamp (protocol spec §4.4 rule 4), and its
+// ExpiryAction.
+func validateTitleExpiry(tx TitleTransaction) error {
+	if tx.ExpiryDate != 0 && tx.ExpiryDate <= tx.Timestamp {
+		return fmt.Errorf("expiryDate %d is not after the title's timestamp", tx.ExpiryDate)
+	}
+	switch tx.ExpiryAction {
+	case "", TitleExpiryLapse:
+		return nil
+	case TitleExpiryRevert:
+		if tx.ExpiryDate == 0 {
+			return fmt.Errorf("expiryAction %q needs an expiryDate", tx.ExpiryAction)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown expiryAction %q", tx.ExpiryAction)
+	}
+}
+
+// GetTitleExpiry returns the expiry record for an asset's current
+// title, if it has expired.
+func (node *QuidnugNode) GetTitleExpiry(assetID string) (TitleExpiry, bool) {
+	e, ok := node.TitleExpiries.get(assetID)
+	if !ok {
+		return TitleExpiry{}, false
+	}
+	title, exists := node.GetAssetOwnership(assetID)
+	if !exists || title.ID != e.TitleTxID {
+		return TitleExpiry{}, false
+	}
+	return e, true
+}
+
+// isTitleLapsed reports whether an asset's current title has
+// lapsed. A reverted title is live again.
+func (node *QuidnugNode) isTitleLapsed(assetID string) bool {
+	e, ok := node.GetTitleExpiry(assetID)
+	return ok && e.Action == TitleExpiryLapse
+}
+
+// ListTitleExpiries returns every asset's latest expiry record.
+func (node *QuidnugNode) ListTitleExpiries() []TitleExpiry {
+	if node.TitleExpiries == nil {
+		return []TitleExpiry{}
+	}
+	return node.TitleExpiries.list()
+}
+
+// expireTitles applies the expiry action to every title of domain
+// past its ExpiryDate at now, the timestamp of the block being
+// processed, and publishes an event for each. Returns the number
+// expired.
+func (node *QuidnugNode) expireTitles(domain string, now int64) int {
+	if node.TitleExpiries == nil {
+		return 0
+	}
+	node.TitleRegistryMutex.RLock()
+	var due []TitleTransaction
+	for _, t := range node.TitleRegistry {
+		if inBlockDomain(t.TrustDomain, domain) && titleExpired(t, now) && !node.TitleExpiries.handled(t.AssetID, t.ID) {
+			due = append(due, t)
+		}
+	}
+	node.TitleRegistryMutex.RUnlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].AssetID < due[j].AssetID })
+
+	events := make([]ChainEvent, 0, len(due))
+	for _, t := range due {
+		e := TitleExpiry{
+			AssetID:     t.AssetID,
+			TitleTxID:   t.ID,
+			TrustDomain: t.TrustDomain,
+			ExpiryDate:  t.ExpiryDate,
+			ProcessedAt: now,
+			Action:      TitleExpiryLapse,
+			Owners:      t.Owners,
+		}
+		if t.ExpiryAction == TitleExpiryRevert && len(t.PreviousOwners) > 0 {
+			e.Action = TitleExpiryRevert
+			e.RevertedTo = t.PreviousOwners
+			reverted := t
+			reverted.Owners = t.PreviousOwners
+			reverted.PreviousOwners = t.Owners
+			reverted.ExpiryDate = 0
+			reverted.ExpiryAction = ""
+			node.updateTitleRegistry(reverted)
+		}
+		node.TitleExpiries.record(e)
+		logger.Info("Title expired",
+			"assetId", t.AssetID, "txId", t.ID, "expiryDate", t.ExpiryDate, "action", e.Action)
+		events = append(events, titleExpiryEvent(e))
+	}
+	if len(events) > 0 && node.EventPublisher != nil {
+		node.EventPublisher.EnqueueEvents(events)
+	}
+	return len(due)
+}
+
+// titleExpiryEvent builds the chain event for an expiry.
+func titleExpiryEvent(e TitleExpiry) ChainEvent {
+	quids := make([]string, 0, len(e.Owners)+len(e.RevertedTo))
+	seen := make(map[string]bool)
+	for _, s := range append(append([]OwnershipStake(nil), e.Owners...), e.RevertedTo...) {
+		if s.OwnerID != "" && !seen[s.OwnerID] {
+			seen[s.OwnerID] = true
+			quids = append(quids, s.OwnerID)
+		}
+	}
+	sort.Strings(quids)
+	raw, _ := json.Marshal(e)
+	return ChainEvent{
+		Kind:        ChainEventTitleExpired,
+		TrustDomain: e.TrustDomain,
+		TxID:        e.TitleTxID,
+		TxType:      TxTypeTitle,
+		Quids:       quids,
+		AssetID:     e.AssetID,
+		Timestamp:   e.ProcessedAt,
+		Transaction: raw,
+	}
+}
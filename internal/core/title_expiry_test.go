@@ -0,0 +1,155 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExpireTitles_LapseAndRevert(t *testing.T) {
+	node := newTestNode()
+	sink := &recordingSink{got: make(chan []SinkMessage, 1)}
+	p, err := NewEventPublisher(sink, "qn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	node.EventPublisher = p
+
+	seller := []OwnershipStake{{OwnerID: "seller", Percentage: 1}}
+	buyer := []OwnershipStake{{OwnerID: "buyer", Percentage: 1}}
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "t-lapse", TrustDomain: "test.domain.com"},
+		AssetID:         "permit-1",
+		Owners:          buyer,
+		ExpiryDate:      1000,
+	})
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "t-revert", TrustDomain: "test.domain.com"},
+		AssetID:         "boat-1",
+		Owners:          buyer,
+		PreviousOwners:  seller,
+		ExpiryDate:      1000,
+		ExpiryAction:    TitleExpiryRevert,
+	})
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "t-live", TrustDomain: "test.domain.com"},
+		AssetID:         "car-1",
+		Owners:          buyer,
+		ExpiryDate:      5000,
+	})
+
+	if n := node.expireTitles("test.domain.com", 999); n != 0 {
+		t.Fatalf("expired %d titles before their date", n)
+	}
+	if n := node.expireTitles("test.domain.com", 1000); n != 2 {
+		t.Fatalf("expired %d titles, want 2", n)
+	}
+	if n := node.expireTitles("test.domain.com", 1001); n != 0 {
+		t.Errorf("expired %d titles again", n)
+	}
+
+	if !node.isTitleLapsed("permit-1") {
+		t.Error("permit-1 not lapsed")
+	}
+	boat, _ := node.GetAssetOwnership("boat-1")
+	if !areOwnershipStakesEqual(boat.Owners, seller) || boat.ExpiryDate != 0 {
+		t.Errorf("boat-1 after revert = %+v", boat)
+	}
+	if node.isTitleLapsed("boat-1") {
+		t.Error("reverted title reported as lapsed")
+	}
+
+	select {
+	case msgs := <-sink.got:
+		if len(msgs) != 2 || msgs[0].Topic != "qn."+ChainEventTitleExpired {
+			t.Fatalf("published %+v", msgs)
+		}
+		var ev ChainEvent
+		if err := json.Unmarshal(msgs[0].Value, &ev); err != nil || ev.AssetID != "boat-1" || len(ev.Quids) != 2 {
+			t.Errorf("event %+v, err %v", ev, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing published")
+	}
+
+	// A new title for the lapsed asset clears the mark.
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "t-renewed"},
+		AssetID:         "permit-1",
+		Owners:          buyer,
+	})
+	if node.isTitleLapsed("permit-1") {
+		t.Error("renewed title still lapsed")
+	}
+}
+
+// TestExpireTitles_FollowsBlockTime checks expiry waits for a block
+// of the title's domain stamped at or past the expiry date, however
+// far the local clock has run ahead.
+func TestExpireTitles_FollowsBlockTime(t *testing.T) {
+	node := newTestNode()
+	node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "t-lease", TrustDomain: "test.domain.com"},
+		AssetID:         "boat-2",
+		Owners:          []OwnershipStake{{OwnerID: "lessee", Percentage: 1}},
+		PreviousOwners:  []OwnershipStake{{OwnerID: "lessor", Percentage: 1}},
+		ExpiryDate:      1000,
+		ExpiryAction:    TitleExpiryRevert,
+	})
+	setTestClockNano(5000 * int64(time.Second))
+	defer resetTestClock()
+
+	node.processBlockTransactions(blockAt(999))
+	other := blockAt(1000)
+	other.TrustProof.TrustDomain = "other.domain.com"
+	node.processBlockTransactions(other)
+	if boat, _ := node.GetAssetOwnership("boat-2"); boat.Owners[0].OwnerID != "lessee" {
+		t.Fatalf("title reverted before a block of its domain reached the expiry date: %+v", boat)
+	}
+
+	node.processBlockTransactions(blockAt(1000))
+	boat, _ := node.GetAssetOwnership("boat-2")
+	if boat.Owners[0].OwnerID != "lessor" {
+		t.Fatalf("title not reverted at block time: %+v", boat)
+	}
+	if e, ok := node.GetTitleExpiry("boat-2"); !ok || e.ProcessedAt != 1000 {
+		t.Errorf("expiry record = %+v, want one processed at block time", e)
+	}
+}
+
+func TestValidateTitle_RejectsTransferOfExpiredTitle(t *testing.T) {
+	f := newRestructureFixture(t)
+	current, _ := f.node.GetAssetOwnership("parcel-1")
+
+	transfer := TitleTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTitle, TrustDomain: "test.domain.com", Timestamp: time.Now().Unix()},
+		AssetID:         "parcel-1",
+		Owners:          []OwnershipStake{{OwnerID: f.alice.QuidID, Percentage: 1}},
+		PreviousOwners:  current.Owners,
+	}
+	ownerSignable, _ := json.Marshal(transfer)
+	transfer.Signatures = map[string]string{
+		f.alice.QuidID: signIEEE1363(f.alice.Priv, ownerSignable),
+		f.bob.QuidID:   signIEEE1363(f.bob.Priv, ownerSignable),
+	}
+	transfer.PublicKey = f.alice.PubHex
+	signable, _ := json.Marshal(transfer)
+	transfer.Signature = signIEEE1363(f.alice.Priv, signable)
+	if !f.node.ValidateTitleTransaction(transfer) {
+		t.Fatal("transfer of a live title rejected")
+	}
+
+	current.ExpiryDate = transfer.Timestamp - 60
+	f.node.updateTitleRegistry(current)
+	if f.node.ValidateTitleTransaction(transfer) {
+		t.Error("transfer of an expired title accepted")
+	}
+
+	if err := validateTitleExpiry(TitleTransaction{ExpiryAction: TitleExpiryRevert}); err == nil {
+		t.Error("revert without an expiry date accepted")
+	}
+	if err := validateTitleExpiry(TitleTransaction{ExpiryAction: "burn", ExpiryDate: 1}); err == nil {
+		t.Error("unknown expiry action accepted")
+	}
+}
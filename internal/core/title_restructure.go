@@ -0,0 +1,563 @@
+// Package core — title splits and merges.
+//
+// A TITLE_RESTRUCTURE transaction retires one or more titles and
+// creates new ones in their place, recording the lineage. A split
+// turns one parent into several children (subdividing a parcel, a
+// bulk lot into units); a merge turns several parents into one
+// child. Before this, applications faked both with fresh titles
+// and lost the link between old and new assets.
+//
+// Stakes are conserved. The parts on the "many" side carry a
+// Fraction of the whole, summing to 1; for every owner,
+//
+//	Σ children  fraction × stake  ==  Σ parents  fraction × stake
+//
+// with stakes normalized to fractions first, so the legacy 100.0
+// scale mixes with the 1.0 one. A child that lists no owners
+// inherits them: a split child gets the parent's stakes, a merge
+// child the fraction-weighted combination of the parents'.
+//
+// Every owner of every parent co-signs in Signatures, over the tx
+// with Signature, PublicKey and Signatures cleared, whatever the
+// parent's transfer policy says. Parents must be in the
+// transaction's domain and free of active liens and pending
+// conditional transfers. Children must be new asset IDs, and a
+// retired asset ID can never be reissued, so a restructure can't
+// be replayed.
+//
+// Companion file structure mirrors liens.go:
+//
+//   - types.go             : TxTypeTitleRestructure const
+//   - title_restructure.go : this file — struct, registry, validator
+//   - transactions.go      : AddTitleRestructureTransaction (mempool)
+//   - validation.go        : dispatch + retired-asset check on titles
+//   - registry.go          : dispatch into applyTitleRestructure
+//   - handlers.go          : POST submit handler + lineage read
+//   - node.go              : TitleLineage field + init
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Restructure operations.
+const (
+	TitleRestructureSplit = "split"
+	TitleRestructureMerge = "merge"
+)
+
+// MaxTitleRestructureParts bounds the parts on either side of a
+// split or merge.
+const MaxTitleRestructureParts = 64
+
+// stakeTolerance is the slack allowed when comparing summed
+// fractions and stakes.
+const stakeTolerance = 1e-6
+
+// TitlePart is one asset on either side of a restructure.
+type TitlePart struct {
+	AssetID string `json:"assetId"`
+
+	// Fraction is the share of the whole this part stands for. It
+	// is required on the side with several parts (split children,
+	// merge parents) and ignored on the single side.
+	Fraction float64 `json:"fraction,omitempty"`
+
+	// Owners and TitleType apply to children only. Empty Owners
+	// inherit from the parents; empty TitleType inherits the
+	// parents' type when they agree on one.
+	Owners    []OwnershipStake `json:"owners,omitempty"`
+	TitleType string           `json:"titleType,omitempty"`
+}
+
+// TitleRestructureTransaction splits or merges titles.
+type TitleRestructureTransaction struct {
+	BaseTransaction
+
+	Operation string      `json:"operation"`
+	Parents   []TitlePart `json:"parents"`
+	Children  []TitlePart `json:"children"`
+
+	// Signatures holds a co-signature from every owner of every
+	// parent, keyed by owner quid.
+	Signatures map[string]string `json:"signatures"`
+}
+
+// TitleLineageRecord is the registry view of an applied
+// restructure. Children carry the owners they were created with.
+type TitleLineageRecord struct {
+	TxID        string      `json:"txId"`
+	Operation   string      `json:"operation"`
+	TrustDomain string      `json:"trustDomain"`
+	Parents     []TitlePart `json:"parents"`
+	Children    []TitlePart `json:"children"`
+	Timestamp   int64       `json:"timestamp"`
+}
+
+// TitleLineage is the provenance of one asset: the restructure
+// that created it, the one that retired it, and every asset
+// before and after it.
+type TitleLineage struct {
+	AssetID     string              `json:"assetId"`
+	Retired     bool                `json:"retired"`
+	CreatedBy   *TitleLineageRecord `json:"createdBy,omitempty"`
+	RetiredBy   *TitleLineageRecord `json:"retiredBy,omitempty"`
+	Ancestors   []string            `json:"ancestors"`
+	Descendants []string            `json:"descendants"`
+}
+
+// TitleLineageRegistry indexes applied restructures by asset.
+type TitleLineageRegistry struct {
+	mu sync.RWMutex
+
+	// records maps restructure tx ID → record.
+	records map[string]TitleLineageRecord
+
+	// createdBy and retiredBy map an asset ID to the restructure
+	// that created or retired it.
+	createdBy map[string]string
+	retiredBy map[string]string
+}
+
+// NewTitleLineageRegistry constructs an empty registry.
+func NewTitleLineageRegistry() *TitleLineageRegistry {
+	return &TitleLineageRegistry{
+		records:   make(map[string]TitleLineageRecord),
+		createdBy: make(map[string]string),
+		retiredBy: make(map[string]string),
+	}
+}
+
+// isRetired reports whether assetID was consumed by a restructure.
+func (r *TitleLineageRegistry) isRetired(assetID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.retiredBy[assetID]
+	return ok
+}
+
+// known reports whether assetID appears in any restructure.
+func (r *TitleLineageRegistry) known(assetID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, created := r.createdBy[assetID]
+	_, retired := r.retiredBy[assetID]
+	return created || retired
+}
+
+// record stores an applied restructure. It returns false if the
+// tx was already recorded.
+func (r *TitleLineageRegistry) record(rec TitleLineageRecord) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, seen := r.records[rec.TxID]; seen {
+		return false
+	}
+	r.records[rec.TxID] = rec
+	for _, p := range rec.Parents {
+		r.retiredBy[p.AssetID] = rec.TxID
+	}
+	for _, c := range rec.Children {
+		r.createdBy[c.AssetID] = rec.TxID
+	}
+	return true
+}
+
+// lineage walks the records around assetID.
+func (r *TitleLineageRegistry) lineage(assetID string) TitleLineage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := TitleLineage{AssetID: assetID, Ancestors: []string{}, Descendants: []string{}}
+	if id, ok := r.createdBy[assetID]; ok {
+		rec := r.records[id]
+		out.CreatedBy = &rec
+	}
+	if id, ok := r.retiredBy[assetID]; ok {
+		rec := r.records[id]
+		out.RetiredBy = &rec
+		out.Retired = true
+	}
+	out.Ancestors = r.walkLocked(assetID, r.createdBy, func(rec TitleLineageRecord) []TitlePart { return rec.Parents })
+	out.Descendants = r.walkLocked(assetID, r.retiredBy, func(rec TitleLineageRecord) []TitlePart { return rec.Children })
+	return out
+}
+
+// walkLocked collects every asset reachable from start through
+// link, sorted.
+func (r *TitleLineageRegistry) walkLocked(start string, link map[string]string, next func(TitleLineageRecord) []TitlePart) []string {
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	out := []string{}
+	for len(queue) > 0 {
+		asset := queue[0]
+		queue = queue[1:]
+		id, ok := link[asset]
+		if !ok {
+			continue
+		}
+		for _, p := range next(r.records[id]) {
+			if !seen[p.AssetID] {
+				seen[p.AssetID] = true
+				out = append(out, p.AssetID)
+				queue = append(queue, p.AssetID)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// GetTitleLineage returns an asset's provenance, and false when
+// the asset never took part in a restructure.
+func (node *QuidnugNode) GetTitleLineage(assetID string) (TitleLineage, bool) {
+	if node.TitleLineage == nil || !node.TitleLineage.known(assetID) {
+		return TitleLineage{}, false
+	}
+	return node.TitleLineage.lineage(assetID), true
+}
+
+// isTitleRetired reports whether assetID was consumed by a split
+// or merge.
+func (node *QuidnugNode) isTitleRetired(assetID string) bool {
+	return node.TitleLineage != nil && node.TitleLineage.isRetired(assetID)
+}
+
+// normalizedStakes maps owner → fraction of the asset, scaling a
+// legacy 100.0-based stake list down to 1.0.
+func normalizedStakes(owners []OwnershipStake) map[string]float64 {
+	total, _ := ownershipTotal(owners)
+	out := make(map[string]float64, len(owners))
+	if total <= 0 {
+		return out
+	}
+	for _, s := range owners {
+		if s.isOwnership() {
+			out[s.OwnerID] += s.Percentage / total
+		}
+	}
+	return out
+}
+
+// resolveTitleRestructure returns the children with owners and
+// title types filled in from the parents where they were left
+// empty. parents are the current titles, in tx.Parents order.
+func resolveTitleRestructure(tx TitleRestructureTransaction, parents []TitleTransaction) []TitlePart {
+	children := make([]TitlePart, len(tx.Children))
+	copy(children, tx.Children)
+
+	titleType := parents[0].TitleType
+	for _, p := range parents[1:] {
+		if p.TitleType != titleType {
+			titleType = ""
+		}
+	}
+
+	var inherited []OwnershipStake
+	if tx.Operation == TitleRestructureSplit {
+		inherited = parents[0].Owners
+	} else {
+		combined := make(map[string]float64)
+		for i, p := range parents {
+			for owner, share := range normalizedStakes(p.Owners) {
+				combined[owner] += tx.Parents[i].Fraction * share
+			}
+		}
+		owners := make([]string, 0, len(combined))
+		for owner := range combined {
+			owners = append(owners, owner)
+		}
+		sort.Strings(owners)
+		for _, owner := range owners {
+			inherited = append(inherited, OwnershipStake{OwnerID: owner, Percentage: combined[owner]})
+		}
+	}
+
+	for i := range children {
+		if len(children[i].Owners) == 0 {
+			children[i].Owners = append([]OwnershipStake(nil), inherited...)
+		}
+		if children[i].TitleType == "" {
+			children[i].TitleType = titleType
+		}
+	}
+	return children
+}
+
+// checkStakeConservation verifies the fraction rules and that no
+// owner gains or loses value across the restructure.
+func checkStakeConservation(tx TitleRestructureTransaction, parents []TitleTransaction, children []TitlePart) error {
+	many := tx.Children
+	if tx.Operation == TitleRestructureMerge {
+		many = tx.Parents
+	}
+	var sum float64
+	for _, p := range many {
+		if !(p.Fraction > 0 && p.Fraction <= 1) {
+			return fmt.Errorf("fraction of %q must be in (0, 1]", p.AssetID)
+		}
+		sum += p.Fraction
+	}
+	if math.Abs(sum-1) > stakeTolerance {
+		return fmt.Errorf("fractions sum to %v, want 1", sum)
+	}
+
+	weight := func(parts []TitlePart, i int) float64 {
+		if len(parts) == 1 {
+			return 1
+		}
+		return parts[i].Fraction
+	}
+	before := make(map[string]float64)
+	for i, p := range parents {
+		for owner, share := range normalizedStakes(p.Owners) {
+			before[owner] += weight(tx.Parents, i) * share
+		}
+	}
+	after := make(map[string]float64)
+	for i, c := range children {
+		for owner, share := range normalizedStakes(c.Owners) {
+			after[owner] += weight(children, i) * share
+		}
+	}
+	for owner, v := range before {
+		if math.Abs(after[owner]-v) > stakeTolerance {
+			return fmt.Errorf("owner %s holds %v before and %v after", owner, v, after[owner])
+		}
+	}
+	for owner, v := range after {
+		if _, ok := before[owner]; !ok && v > stakeTolerance {
+			return fmt.Errorf("owner %s gains a stake without holding one before", owner)
+		}
+	}
+	return nil
+}
+
+// restructureParents returns the current titles of tx's parents,
+// or false if there are none or any is missing.
+func (node *QuidnugNode) restructureParents(tx TitleRestructureTransaction) ([]TitleTransaction, bool) {
+	if len(tx.Parents) == 0 {
+		return nil, false
+	}
+	node.TitleRegistryMutex.RLock()
+	defer node.TitleRegistryMutex.RUnlock()
+	out := make([]TitleTransaction, len(tx.Parents))
+	for i, p := range tx.Parents {
+		title, ok := node.TitleRegistry[p.AssetID]
+		if !ok {
+			return nil, false
+		}
+		out[i] = title
+	}
+	return out, true
+}
+
+// ValidateTitleRestructureTransaction enforces the split and merge
+// rules. Returns false on any violation; every failure is logged
+// at Warn level.
+func (node *QuidnugNode) ValidateTitleRestructureTransaction(tx TitleRestructureTransaction) bool {
+	// 1. Domain must exist + be supported.
+	if tx.TrustDomain == "" {
+		logger.Warn("Title restructure missing trust domain", "txId", tx.ID)
+		return false
+	}
+	node.TrustDomainsMutex.RLock()
+	_, domainExists := node.TrustDomains[tx.TrustDomain]
+	node.TrustDomainsMutex.RUnlock()
+	if !domainExists {
+		logger.Warn("Title restructure from unknown trust domain",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+	if !node.IsDomainSupported(tx.TrustDomain) {
+		logger.Warn("Title restructure trust domain not supported by this node",
+			"domain", tx.TrustDomain, "txId", tx.ID)
+		return false
+	}
+
+	// 2. Shape: one parent and several children for a split,
+	// the reverse for a merge; every asset ID distinct.
+	var okShape bool
+	switch tx.Operation {
+	case TitleRestructureSplit:
+		okShape = len(tx.Parents) == 1 && len(tx.Children) >= 2 && len(tx.Children) <= MaxTitleRestructureParts
+	case TitleRestructureMerge:
+		okShape = len(tx.Children) == 1 && len(tx.Parents) >= 2 && len(tx.Parents) <= MaxTitleRestructureParts
+	}
+	if !okShape {
+		logger.Warn("Title restructure has invalid operation or part counts",
+			"operation", tx.Operation, "parents", len(tx.Parents),
+			"children", len(tx.Children), "txId", tx.ID)
+		return false
+	}
+	seen := make(map[string]bool, len(tx.Parents)+len(tx.Children))
+	for _, p := range append(append([]TitlePart(nil), tx.Parents...), tx.Children...) {
+		if p.AssetID == "" || !ValidateStringField(p.AssetID, MaxNameLength) || seen[p.AssetID] {
+			logger.Warn("Title restructure has invalid or repeated asset id",
+				"assetId", p.AssetID, "txId", tx.ID)
+			return false
+		}
+		seen[p.AssetID] = true
+	}
+
+	// 3. Parents: live titles in this domain, unencumbered and not
+	// held by a pending conditional transfer.
+	parents, ok := node.restructureParents(tx)
+	if !ok {
+		logger.Warn("Title restructure names an unknown or retired parent", "txId", tx.ID)
+		return false
+	}
+	for _, p := range parents {
+		if p.TrustDomain != tx.TrustDomain {
+			logger.Warn("Title restructure parent is in another trust domain",
+				"assetId", p.AssetID, "parentDomain", p.TrustDomain, "txId", tx.ID)
+			return false
+		}
+		if node.LienRegistry != nil && len(node.LienRegistry.activeFor(p.AssetID, tx.Timestamp)) > 0 {
+			logger.Warn("Title restructure of an encumbered asset; release its liens first",
+				"assetId", p.AssetID, "txId", tx.ID)
+			return false
+		}
+		if d, frozen := node.titleFrozenBy(p.AssetID); frozen {
+			logger.Warn("Title restructure of an asset frozen by an open dispute",
+				"assetId", p.AssetID, "disputeId", d.DisputeID, "txId", tx.ID)
+			return false
+		}
+		if _, locked := node.GetPendingTransferForAsset(p.AssetID); locked {
+			logger.Warn("Title restructure of an asset locked by a pending conditional transfer",
+				"assetId", p.AssetID, "txId", tx.ID)
+			return false
+		}
+	}
+
+	// 4. Children: fresh asset IDs, valid owners and types.
+	children := resolveTitleRestructure(tx, parents)
+	for _, c := range children {
+		if _, exists := node.GetAssetOwnership(c.AssetID); exists || node.isTitleRetired(c.AssetID) {
+			logger.Warn("Title restructure child reuses an existing or retired asset id",
+				"assetId", c.AssetID, "txId", tx.ID)
+			return false
+		}
+		if !ValidateStringField(c.TitleType, MaxNameLength) {
+			logger.Warn("Title restructure child has invalid title type", "assetId", c.AssetID, "txId", tx.ID)
+			return false
+		}
+		if err := validateStakes(c.Owners, tx.Timestamp); err != nil {
+			logger.Warn("Title restructure child has invalid stakes",
+				"assetId", c.AssetID, "txId", tx.ID, "error", err)
+			return false
+		}
+		if total, ok := ownershipTotal(c.Owners); !ok {
+			logger.Warn("Title restructure child shares don't equal 1.0 (or 100.0 legacy)",
+				"assetId", c.AssetID, "totalShare", total, "txId", tx.ID)
+			return false
+		}
+		node.IdentityRegistryMutex.RLock()
+		for _, stake := range c.Owners {
+			if _, exists := node.IdentityRegistry[stake.OwnerID]; !exists || !IsValidQuidID(stake.OwnerID) {
+				node.IdentityRegistryMutex.RUnlock()
+				logger.Warn("Title restructure child owner not found in identity registry",
+					"ownerId", stake.OwnerID, "assetId", c.AssetID, "txId", tx.ID)
+				return false
+			}
+		}
+		node.IdentityRegistryMutex.RUnlock()
+	}
+
+	// 5. Stakes conserved.
+	if err := checkStakeConservation(tx, parents, children); err != nil {
+		logger.Warn("Title restructure does not conserve ownership stakes",
+			"txId", tx.ID, "error", err)
+		return false
+	}
+
+	// 6. Issuer signature.
+	if tx.Signature == "" || tx.PublicKey == "" {
+		logger.Warn("Title restructure missing signature or public key", "txId", tx.ID)
+		return false
+	}
+	txCopy := tx
+	txCopy.Signature = ""
+	signable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Title restructure marshal for signature failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	if !VerifySignature(tx.PublicKey, signable, tx.Signature) {
+		logger.Warn("Title restructure signature invalid", "txId", tx.ID)
+		return false
+	}
+
+	// 7. Every parent owner co-signs.
+	txCopy.PublicKey = ""
+	txCopy.Signatures = nil
+	ownerSignable, err := txSignableBytes(txCopy)
+	if err != nil {
+		logger.Error("Title restructure marshal for owner signatures failed", "txId", tx.ID, "err", err)
+		return false
+	}
+	for _, p := range parents {
+		for _, stake := range p.Owners {
+			sig := tx.Signatures[stake.OwnerID]
+			if sig == "" || !node.verifyAsQuid(stake.OwnerID, TxTypeTitleRestructure, tx.delegationTime(), ownerSignable, sig) {
+				logger.Warn("Title restructure missing or invalid owner co-signature",
+					"assetId", p.AssetID, "ownerId", stake.OwnerID, "txId", tx.ID)
+				return false
+			}
+		}
+	}
+
+	return node.passesTxHooks(TxTypeTitleRestructure, tx.TrustDomain, tx.ID, tx)
+}
+
+// applyTitleRestructure commits a validated restructure: the
+// children enter the title registry, the parents leave it, and
+// the lineage is recorded. Called from processBlockTransactions
+// once the containing block has been accepted; idempotent on
+// replay.
+func (node *QuidnugNode) applyTitleRestructure(tx TitleRestructureTransaction) {
+	if node.TitleLineage == nil {
+		return
+	}
+	parents, ok := node.restructureParents(tx)
+	if !ok {
+		return
+	}
+	children := resolveTitleRestructure(tx, parents)
+	if !node.TitleLineage.record(TitleLineageRecord{
+		TxID:        tx.ID,
+		Operation:   tx.Operation,
+		TrustDomain: tx.TrustDomain,
+		Parents:     tx.Parents,
+		Children:    children,
+		Timestamp:   tx.Timestamp,
+	}) {
+		return
+	}
+
+	for _, c := range children {
+		node.updateTitleRegistry(TitleTransaction{
+			BaseTransaction: tx.BaseTransaction,
+			AssetID:         c.AssetID,
+			Owners:          c.Owners,
+			TitleType:       c.TitleType,
+		})
+	}
+	node.TitleRegistryMutex.Lock()
+	for _, p := range tx.Parents {
+		delete(node.TitleRegistry, p.AssetID)
+		if node.OwnerIndex != nil {
+			node.OwnerIndex.replace(p.AssetID, nil)
+		}
+	}
+	node.TitleRegistryMutex.Unlock()
+
+	logger.Debug("Applied title restructure",
+		"txId", tx.ID,
+		"operation", tx.Operation,
+		"parents", len(tx.Parents),
+		"children", len(children))
+}
@@ -0,0 +1,195 @@
+// TITLE_RESTRUCTURE tests: split and merge validation, stake
+// conservation, owner co-signatures, and lineage after apply.
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type restructureFixture struct {
+	node  *QuidnugNode
+	alice *testNodeActor
+	bob   *testNodeActor
+}
+
+// newRestructureFixture gives alice 60% and bob 40% of parcel-1.
+func newRestructureFixture(t *testing.T) *restructureFixture {
+	t.Helper()
+	f := &restructureFixture{node: newTestNode(), alice: newTestNodeActor(t), bob: newTestNodeActor(t)}
+	for _, a := range []*testNodeActor{f.alice, f.bob} {
+		f.node.IdentityRegistry[a.QuidID] = IdentityTransaction{
+			BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: a.PubHex},
+			QuidID:          a.QuidID,
+		}
+	}
+	f.node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-1", TrustDomain: "test.domain.com"},
+		AssetID:         "parcel-1",
+		TitleType:       "land",
+		Owners: []OwnershipStake{
+			{OwnerID: f.alice.QuidID, Percentage: 0.6},
+			{OwnerID: f.bob.QuidID, Percentage: 0.4},
+		},
+	})
+	return f
+}
+
+// sign co-signs tx by each owner and issues it from the first.
+func (f *restructureFixture) sign(tx TitleRestructureTransaction, owners ...*testNodeActor) TitleRestructureTransaction {
+	tx.BaseTransaction.Type = TxTypeTitleRestructure
+	tx.TrustDomain = "test.domain.com"
+	if tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	ownerSignable, _ := json.Marshal(tx)
+	tx.Signatures = map[string]string{}
+	for _, o := range owners {
+		tx.Signatures[o.QuidID] = signIEEE1363(o.Priv, ownerSignable)
+	}
+	tx.PublicKey = owners[0].PubHex
+	issuerSignable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(owners[0].Priv, issuerSignable)
+	return tx
+}
+
+func splitParcel(children ...TitlePart) TitleRestructureTransaction {
+	return TitleRestructureTransaction{
+		BaseTransaction: BaseTransaction{ID: "split-1"},
+		Operation:       TitleRestructureSplit,
+		Parents:         []TitlePart{{AssetID: "parcel-1"}},
+		Children:        children,
+	}
+}
+
+func TestValidateTitleRestructure_SplitInherits(t *testing.T) {
+	f := newRestructureFixture(t)
+	tx := f.sign(splitParcel(
+		TitlePart{AssetID: "parcel-1a", Fraction: 0.5},
+		TitlePart{AssetID: "parcel-1b", Fraction: 0.5},
+	), f.alice, f.bob)
+	if !f.node.ValidateTitleRestructureTransaction(tx) {
+		t.Fatal("valid split rejected")
+	}
+
+	f.node.applyTitleRestructure(tx)
+	if _, ok := f.node.GetAssetOwnership("parcel-1"); ok {
+		t.Error("parent still in the title registry")
+	}
+	child, ok := f.node.GetAssetOwnership("parcel-1b")
+	if !ok || child.TitleType != "land" || !areOwnershipStakesEqual(child.Owners, []OwnershipStake{
+		{OwnerID: f.alice.QuidID, Percentage: 0.6},
+		{OwnerID: f.bob.QuidID, Percentage: 0.4},
+	}) {
+		t.Errorf("child = %+v", child)
+	}
+	if f.node.ValidateTitleRestructureTransaction(tx) {
+		t.Error("replayed split accepted")
+	}
+
+	// The retired parent can't come back as a plain title.
+	reissue := TitleTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com"},
+		AssetID:         "parcel-1",
+		Owners:          []OwnershipStake{{OwnerID: f.alice.QuidID, Percentage: 1}},
+	}
+	if f.node.ValidateTitleTransaction(reissue) {
+		t.Error("retired asset reissued")
+	}
+}
+
+func TestValidateTitleRestructure_StakeConservation(t *testing.T) {
+	f := newRestructureFixture(t)
+	alice := []OwnershipStake{{OwnerID: f.alice.QuidID, Percentage: 1}}
+	bob := []OwnershipStake{{OwnerID: f.bob.QuidID, Percentage: 100}}
+
+	// 60/40 of the land, one child each: conserved.
+	ok := f.sign(splitParcel(
+		TitlePart{AssetID: "a", Fraction: 0.6, Owners: alice},
+		TitlePart{AssetID: "b", Fraction: 0.4, Owners: bob},
+	), f.alice, f.bob)
+	if !f.node.ValidateTitleRestructureTransaction(ok) {
+		t.Error("conserving split rejected")
+	}
+
+	// Halves: alice drops from 0.6 to 0.5.
+	skewed := f.sign(splitParcel(
+		TitlePart{AssetID: "a", Fraction: 0.5, Owners: alice},
+		TitlePart{AssetID: "b", Fraction: 0.5, Owners: bob},
+	), f.alice, f.bob)
+	if f.node.ValidateTitleRestructureTransaction(skewed) {
+		t.Error("stake-shifting split accepted")
+	}
+
+	short := f.sign(splitParcel(
+		TitlePart{AssetID: "a", Fraction: 0.5},
+		TitlePart{AssetID: "b", Fraction: 0.4},
+	), f.alice, f.bob)
+	if f.node.ValidateTitleRestructureTransaction(short) {
+		t.Error("fractions summing to 0.9 accepted")
+	}
+}
+
+func TestValidateTitleRestructure_RequiresEveryOwner(t *testing.T) {
+	f := newRestructureFixture(t)
+	tx := f.sign(splitParcel(
+		TitlePart{AssetID: "a", Fraction: 0.5},
+		TitlePart{AssetID: "b", Fraction: 0.5},
+	), f.alice)
+	if f.node.ValidateTitleRestructureTransaction(tx) {
+		t.Error("split without bob's co-signature accepted")
+	}
+}
+
+func TestTitleRestructure_MergeAndLineage(t *testing.T) {
+	f := newRestructureFixture(t)
+	f.node.updateTitleRegistry(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-2", TrustDomain: "test.domain.com"},
+		AssetID:         "parcel-2",
+		TitleType:       "land",
+		Owners:          []OwnershipStake{{OwnerID: f.alice.QuidID, Percentage: 1}},
+	})
+	merge := f.sign(TitleRestructureTransaction{
+		BaseTransaction: BaseTransaction{ID: "merge-1"},
+		Operation:       TitleRestructureMerge,
+		Parents:         []TitlePart{{AssetID: "parcel-1", Fraction: 0.5}, {AssetID: "parcel-2", Fraction: 0.5}},
+		Children:        []TitlePart{{AssetID: "estate"}},
+	}, f.alice, f.bob)
+	if !f.node.ValidateTitleRestructureTransaction(merge) {
+		t.Fatal("valid merge rejected")
+	}
+	f.node.applyTitleRestructure(merge)
+
+	estate, ok := f.node.GetAssetOwnership("estate")
+	if !ok {
+		t.Fatal("merged title missing")
+	}
+	stakes := normalizedStakes(estate.Owners)
+	if d := stakes[f.alice.QuidID] - 0.8; d > stakeTolerance || d < -stakeTolerance {
+		t.Errorf("alice holds %v of the estate, want 0.8", stakes[f.alice.QuidID])
+	}
+
+	router := setupTestRouter(f.node)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/title/parcel-2/lineage", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("lineage status %d", w.Code)
+	}
+	var resp struct {
+		Data TitleLineage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Data.Retired || len(resp.Data.Descendants) != 1 || resp.Data.Descendants[0] != "estate" {
+		t.Errorf("lineage = %+v", resp.Data)
+	}
+
+	lineage, _ := f.node.GetTitleLineage("estate")
+	if len(lineage.Ancestors) != 2 || lineage.CreatedBy == nil || lineage.CreatedBy.TxID != "merge-1" {
+		t.Errorf("estate lineage = %+v", lineage)
+	}
+}
@@ -0,0 +1,228 @@
+// Package core — title transfer policies (M-of-N owner approval).
+//
+// By default every previous owner must co-sign a title transfer.
+// A TitleTransaction may instead record a TransferPolicy, which
+// then governs the next transfer of the title it creates:
+//
+//   - "all"             every previous owner signs (the default)
+//   - "stake-majority"  signers hold more than half the stake
+//   - "stake-threshold" signers hold at least RequiredStake
+//   - "designated"      the Trustee quid signs alone
+//
+// SmallTransferStake relaxes any of these for small moves: when
+// less than that fraction of the stake changes hands, signatures
+// from the owners giving up stake are enough. Each transfer may
+// record a fresh policy for the new owners, who must then all
+// co-sign it, since it binds them rather than the sellers; a
+// transfer without one leaves the title on the default.
+//
+// Companion files:
+//
+//   - types.go      : TitleTransaction.TransferPolicy
+//   - validation.go : ValidateTitleTransaction calls checkTransferSignatures
+package core
+
+import (
+	"fmt"
+)
+
+// Transfer policy kinds.
+const (
+	TransferPolicyAll            = "all"
+	TransferPolicyStakeMajority  = "stake-majority"
+	TransferPolicyStakeThreshold = "stake-threshold"
+	TransferPolicyDesignated     = "designated"
+)
+
+// TitleTransferPolicy says whose signatures a transfer of the title
+// needs. The zero value is TransferPolicyAll.
+type TitleTransferPolicy struct {
+	Kind string `json:"kind"`
+	// RequiredStake is the stake fraction, in (0, 1], the signers
+	// must hold under stake-threshold.
+	RequiredStake float64 `json:"requiredStake,omitempty"`
+	// Trustee is the quid that signs under designated.
+	Trustee string `json:"trustee,omitempty"`
+	// SmallTransferStake, in [0, 1), is the moved-stake fraction
+	// below which the sellers' signatures suffice. Zero disables it.
+	SmallTransferStake float64 `json:"smallTransferStake,omitempty"`
+}
+
+// validate checks the policy's shape.
+func (p *TitleTransferPolicy) validate() error {
+	switch p.Kind {
+	case "", TransferPolicyAll, TransferPolicyStakeMajority:
+	case TransferPolicyStakeThreshold:
+		if p.RequiredStake <= 0 || p.RequiredStake > 1 {
+			return fmt.Errorf("requiredStake must be in (0, 1]")
+		}
+	case TransferPolicyDesignated:
+		if !IsValidQuidID(p.Trustee) {
+			return fmt.Errorf("designated policy needs a valid trustee quid")
+		}
+	default:
+		return fmt.Errorf("unknown transfer policy kind %q", p.Kind)
+	}
+	if p.Kind != TransferPolicyStakeThreshold && p.RequiredStake != 0 {
+		return fmt.Errorf("requiredStake applies to %s only", TransferPolicyStakeThreshold)
+	}
+	if p.Kind != TransferPolicyDesignated && p.Trustee != "" {
+		return fmt.Errorf("trustee applies to %s only", TransferPolicyDesignated)
+	}
+	if p.SmallTransferStake < 0 || p.SmallTransferStake >= 1 {
+		return fmt.Errorf("smallTransferStake must be in [0, 1)")
+	}
+	return nil
+}
+
+// stakeShares returns each owner's fraction of the ownership stake,
+// whichever scale (fraction or percent) the stakes use.
+func stakeShares(owners []OwnershipStake) map[string]float64 {
+	total, _ := ownershipTotal(owners)
+	shares := make(map[string]float64, len(owners))
+	if total <= 0 {
+		return shares
+	}
+	for _, s := range owners {
+		if s.isOwnership() {
+			shares[s.OwnerID] += s.Percentage / total
+		}
+	}
+	return shares
+}
+
+// satisfied reports whether signatures from signed meet the policy
+// for a transfer from previous to next owners.
+func (p *TitleTransferPolicy) satisfied(previous, next []OwnershipStake, signed map[string]bool) bool {
+	before, after := stakeShares(previous), stakeShares(next)
+
+	if p.SmallTransferStake > 0 {
+		moved := 0.0
+		sellersSigned := true
+		for owner, share := range before {
+			if drop := share - after[owner]; drop > 1e-9 {
+				moved += drop
+				sellersSigned = sellersSigned && signed[owner]
+			}
+		}
+		if moved < p.SmallTransferStake && sellersSigned {
+			return true
+		}
+	}
+
+	signedStake := 0.0
+	for owner, share := range before {
+		if signed[owner] {
+			signedStake += share
+		}
+	}
+	switch p.Kind {
+	case TransferPolicyStakeMajority:
+		return signedStake > 0.5+1e-9
+	case TransferPolicyStakeThreshold:
+		return signedStake >= p.RequiredStake-1e-9
+	case TransferPolicyDesignated:
+		return signed[p.Trustee]
+	default:
+		for owner := range before {
+			if !signed[owner] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// checkTransferSignatures verifies the co-signatures on a transfer
+// and checks them against policy (nil meaning every previous owner
+// must sign). A transfer recording a policy of its own also needs
+// every new owner's signature. A signature that is present must
+// verify, whether or not the policy needs it.
+func (node *QuidnugNode) checkTransferSignatures(tx TitleTransaction, policy *TitleTransferPolicy) bool {
+	txCopyForOwners := tx
+	txCopyForOwners.Signature = ""
+	txCopyForOwners.PublicKey = ""
+	txCopyForOwners.Signatures = nil
+	ownerSignableData, err := txSignableBytes(txCopyForOwners)
+	if err != nil {
+		logger.Error("Failed to marshal transaction for owner signature verification", "txId", tx.ID, "error", err)
+		return false
+	}
+
+	if policy == nil {
+		policy = &TitleTransferPolicy{Kind: TransferPolicyAll}
+	}
+	eligible := make(map[string]bool, len(tx.PreviousOwners)+1)
+	for _, stake := range tx.PreviousOwners {
+		eligible[stake.OwnerID] = true
+	}
+	if policy.Kind == TransferPolicyDesignated {
+		eligible[policy.Trustee] = true
+	}
+	newOwners := stakeShares(tx.Owners)
+	if tx.TransferPolicy != nil {
+		for owner := range newOwners {
+			eligible[owner] = true
+		}
+	}
+
+	signed := make(map[string]bool, len(tx.Signatures))
+	for signer, sig := range tx.Signatures {
+		if !eligible[signer] || sig == "" {
+			continue
+		}
+		if _, ok := node.quidPublicKey(signer); !ok {
+			logger.Warn("Transfer co-signer has no registered key", "signer", signer, "txId", tx.ID)
+			return false
+		}
+		if !node.verifyAsQuid(signer, TxTypeTitle, tx.delegationTime(), ownerSignableData, sig) {
+			logger.Warn("Invalid transfer co-signature", "signer", signer, "txId", tx.ID)
+			return false
+		}
+		signed[signer] = true
+	}
+
+	if !policy.satisfied(tx.PreviousOwners, tx.Owners, signed) {
+		logger.Warn("Transfer signatures do not satisfy the title's transfer policy",
+			"assetId", tx.AssetID, "policy", policy.Kind, "signers", len(signed), "txId", tx.ID)
+		return false
+	}
+	// Otherwise a seller could hand the buyers a title only the
+	// seller's own trustee can move.
+	if tx.TransferPolicy != nil {
+		for owner := range newOwners {
+			if !signed[owner] {
+				logger.Warn("New owner has not consented to the transfer's policy",
+					"assetId", tx.AssetID, "owner", owner, "txId", tx.ID)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// signsForTitleHolder reports whether tx's issuer key signs for one
+// of its previous owners or, under a designated policy, the trustee.
+// Holders without a registered key don't bind the issuer; when none
+// has one, any issuer passes.
+func (node *QuidnugNode) signsForTitleHolder(tx TitleTransaction, policy *TitleTransferPolicy) bool {
+	holders := make([]string, 0, len(tx.PreviousOwners)+1)
+	for _, stake := range tx.PreviousOwners {
+		holders = append(holders, stake.OwnerID)
+	}
+	if policy != nil && policy.Kind == TransferPolicyDesignated {
+		holders = append(holders, policy.Trustee)
+	}
+	at := tx.delegationTime()
+	bound := false
+	for _, holder := range holders {
+		if _, ok := node.quidPublicKey(holder); !ok {
+			continue
+		}
+		if node.keySignsFor(holder, tx.PublicKey, TxTypeTitle, at) {
+			return true
+		}
+		bound = true
+	}
+	return !bound
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type transferPolicyFixture struct {
+	node          *QuidnugNode
+	a, b, c, d, t *testNodeActor
+	assetID       string
+}
+
+// newTransferPolicyFixture gives a, b and c a 60/35/5 title under
+// policy; d is a buyer and t a possible trustee.
+func newTransferPolicyFixture(tt *testing.T, policy *TitleTransferPolicy) *transferPolicyFixture {
+	tt.Helper()
+	f := &transferPolicyFixture{
+		node: newTestNode(),
+		a:    newTestNodeActor(tt), b: newTestNodeActor(tt), c: newTestNodeActor(tt),
+		d: newTestNodeActor(tt), t: newTestNodeActor(tt),
+		assetID: "asset-shared-001",
+	}
+	for _, actor := range []*testNodeActor{f.a, f.b, f.c, f.d, f.t} {
+		f.node.IdentityRegistry[actor.QuidID] = IdentityTransaction{
+			BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com", PublicKey: actor.PubHex},
+			QuidID:          actor.QuidID,
+		}
+	}
+	f.node.TitleRegistry[f.assetID] = TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "title-1", TrustDomain: "test.domain.com"},
+		AssetID:         f.assetID,
+		Owners:          f.owners(),
+		TransferPolicy:  policy,
+	}
+	return f
+}
+
+func (f *transferPolicyFixture) owners() []OwnershipStake {
+	return []OwnershipStake{
+		{OwnerID: f.a.QuidID, Percentage: 0.6},
+		{OwnerID: f.b.QuidID, Percentage: 0.35},
+		{OwnerID: f.c.QuidID, Percentage: 0.05},
+	}
+}
+
+// transfer builds a transfer to owners, co-signed by signers and
+// issued by the first of them.
+func (f *transferPolicyFixture) transfer(owners []OwnershipStake, signers ...*testNodeActor) TitleTransaction {
+	tx := TitleTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "transfer-1",
+			Type:        TxTypeTitle,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		AssetID:        f.assetID,
+		Owners:         owners,
+		PreviousOwners: f.owners(),
+	}
+	return f.cosign(tx, signers...)
+}
+
+// cosign replaces tx's co-signatures with ones by signers, the
+// first of whom issues it.
+func (f *transferPolicyFixture) cosign(tx TitleTransaction, signers ...*testNodeActor) TitleTransaction {
+	tx.Signature, tx.PublicKey, tx.Signatures = "", "", nil
+	ownerSignable, _ := json.Marshal(tx)
+	tx.Signatures = map[string]string{}
+	for _, s := range signers {
+		tx.Signatures[s.QuidID] = signIEEE1363(s.Priv, ownerSignable)
+	}
+	return f.resign(tx, signers[0])
+}
+
+// resign replaces tx's issuer signature with one by issuer.
+func (f *transferPolicyFixture) resign(tx TitleTransaction, issuer *testNodeActor) TitleTransaction {
+	tx.PublicKey = issuer.PubHex
+	tx.Signature = ""
+	issuerSignable, _ := json.Marshal(tx)
+	tx.Signature = signIEEE1363(issuer.Priv, issuerSignable)
+	return tx
+}
+
+func TestTransferPolicy_Kinds(t *testing.T) {
+	toD := func(f *transferPolicyFixture) []OwnershipStake {
+		return []OwnershipStake{{OwnerID: f.d.QuidID, Percentage: 1.0}}
+	}
+
+	f := newTransferPolicyFixture(t, nil)
+	if f.node.ValidateTitleTransaction(f.transfer(toD(f), f.a, f.b)) {
+		t.Error("default policy: transfer without every owner accepted")
+	}
+	if !f.node.ValidateTitleTransaction(f.transfer(toD(f), f.a, f.b, f.c)) {
+		t.Error("default policy: transfer signed by every owner rejected")
+	}
+
+	f = newTransferPolicyFixture(t, &TitleTransferPolicy{Kind: TransferPolicyStakeMajority})
+	if !f.node.ValidateTitleTransaction(f.transfer(toD(f), f.a)) {
+		t.Error("majority: 60% signer rejected")
+	}
+	if f.node.ValidateTitleTransaction(f.transfer(toD(f), f.b, f.c)) {
+		t.Error("majority: 40% of signers accepted")
+	}
+
+	f = newTransferPolicyFixture(t, &TitleTransferPolicy{Kind: TransferPolicyStakeThreshold, RequiredStake: 0.4})
+	if !f.node.ValidateTitleTransaction(f.transfer(toD(f), f.b, f.c)) {
+		t.Error("threshold: 40% of signers rejected")
+	}
+
+	f = newTransferPolicyFixture(t, nil)
+	title := f.node.TitleRegistry[f.assetID]
+	title.TransferPolicy = &TitleTransferPolicy{Kind: TransferPolicyDesignated, Trustee: f.t.QuidID}
+	f.node.TitleRegistry[f.assetID] = title
+	if !f.node.ValidateTitleTransaction(f.transfer(toD(f), f.t)) {
+		t.Error("designated: trustee alone rejected")
+	}
+	if f.node.ValidateTitleTransaction(f.transfer(toD(f), f.a, f.b)) {
+		t.Error("designated: owners without the trustee accepted")
+	}
+}
+
+func TestTransferPolicy_NewOwnersConsent(t *testing.T) {
+	f := newTransferPolicyFixture(t, nil)
+	toD := []OwnershipStake{{OwnerID: f.d.QuidID, Percentage: 1.0}}
+
+	// The sellers name a trustee of their own for the buyer's title.
+	tx := f.transfer(toD, f.a, f.b, f.c)
+	tx.TransferPolicy = &TitleTransferPolicy{Kind: TransferPolicyDesignated, Trustee: f.a.QuidID}
+	if f.node.ValidateTitleTransaction(f.cosign(tx, f.a, f.b, f.c)) {
+		t.Error("policy imposed on the buyer without their signature accepted")
+	}
+	if !f.node.ValidateTitleTransaction(f.cosign(tx, f.a, f.b, f.c, f.d)) {
+		t.Error("policy co-signed by the buyer rejected")
+	}
+}
+
+func TestTransferPolicy_SmallTransfer(t *testing.T) {
+	f := newTransferPolicyFixture(t, &TitleTransferPolicy{SmallTransferStake: 0.1})
+	sellC := []OwnershipStake{
+		{OwnerID: f.a.QuidID, Percentage: 0.6},
+		{OwnerID: f.b.QuidID, Percentage: 0.35},
+		{OwnerID: f.d.QuidID, Percentage: 0.05},
+	}
+	if !f.node.ValidateTitleTransaction(f.transfer(sellC, f.c)) {
+		t.Error("5% sale signed by its seller rejected")
+	}
+	if f.node.ValidateTitleTransaction(f.transfer(sellC, f.a)) {
+		t.Error("5% sale without its seller accepted")
+	}
+	sellB := []OwnershipStake{
+		{OwnerID: f.a.QuidID, Percentage: 0.6},
+		{OwnerID: f.d.QuidID, Percentage: 0.35},
+		{OwnerID: f.c.QuidID, Percentage: 0.05},
+	}
+	if f.node.ValidateTitleTransaction(f.transfer(sellB, f.b)) {
+		t.Error("35% sale accepted under the small-transfer rule")
+	}
+
+	// A signature the policy doesn't need must still verify.
+	tx := f.transfer(sellC, f.c, f.a)
+	tx.Signatures[f.a.QuidID] = tx.Signatures[f.c.QuidID]
+	if f.node.ValidateTitleTransaction(tx) {
+		t.Error("transfer with a bad extra co-signature accepted")
+	}
+}
+
+func TestTransferPolicy_Validate(t *testing.T) {
+	for _, p := range []TitleTransferPolicy{
+		{Kind: "quorum"},
+		{Kind: TransferPolicyStakeThreshold, RequiredStake: 1.5},
+		{Kind: TransferPolicyDesignated},
+		{Kind: TransferPolicyStakeMajority, Trustee: "0000000000000001"},
+		{SmallTransferStake: 1},
+	} {
+		if err := p.validate(); err == nil {
+			t.Errorf("%+v validated", p)
+		}
+	}
+}
@@ -2,15 +2,21 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/quidnug/quidnug/internal/ratelimit"
 )
 
+// ErrRateLimited is wrapped by the error admitWriteOrReject returns
+// on a denial.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
 // admitWriteOrReject consults the QDP-0016 multi-layer write
 // limiter and returns a Go error describing the denial (if
 // any) so mempool-admission functions can fail-fast uniformly.
@@ -25,12 +31,25 @@ func (node *QuidnugNode) admitWriteOrReject(keys ratelimit.ActorKeys) error {
 	}
 	if got := node.WriteLimiter.AdmitWrite(keys); !got.Allowed {
 		RecordRateLimitDenial(string(got.Layer))
-		return fmt.Errorf("rate limit exceeded at %s layer", got.Layer)
+		return fmt.Errorf("%w at %s layer", ErrRateLimited, got.Layer)
 	}
 	return nil
 }
 
+// AddTrustTransaction adds a trust transaction to the pending pool
 func (node *QuidnugNode) AddTrustTransaction(tx TrustTransaction) (string, error) {
+	return node.addTrustTransaction(context.Background(), tx)
+}
+
+// addTrustTransaction is AddTrustTransaction logging under the
+// request ctx carries (log_context.go).
+func (node *QuidnugNode) addTrustTransaction(ctx context.Context, tx TrustTransaction) (string, error) {
+	// Group members are admitted together (atomic_group.go).
+	if err := loneGroupMember(tx.BaseTransaction); err != nil {
+		RecordTransactionProcessed("trust", false)
+		return "", err
+	}
+
 	// Auto-fill of Timestamp / Type / Nonce is a test and server-side
 	// convenience. If the transaction is already signed, these fields
 	// are part of the signable data and mutating them would silently
@@ -83,6 +102,7 @@ func (node *QuidnugNode) AddTrustTransaction(tx TrustTransaction) (string, error
 		hash := sha256.Sum256(txData)
 		tx.ID = hex.EncodeToString(hash[:])
 	}
+	txLog := loggerFrom(ctx).With("txId", tx.ID, "txType", TxTypeTrust)
 
 	// QDP-0016 multi-layer rate limit at mempool admission.
 	// Scoped to the signing quid + target domain; IP / operator
@@ -95,10 +115,18 @@ func (node *QuidnugNode) AddTrustTransaction(tx TrustTransaction) (string, error
 		return "", err
 	}
 
+	if err := node.admitAntiSpamOrReject("trust", tx.TrustDomain, tx.Truster, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("trust", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
 	// Validate the transaction
-	if !node.ValidateTrustTransaction(tx) {
+	if err := node.checkTrustTransaction(tx); err != nil {
 		RecordTransactionProcessed("trust", false)
-		return "", fmt.Errorf("invalid trust transaction")
+		txLog.Info("Rejected trust transaction", "error", err)
+		return "", fmt.Errorf("invalid trust transaction: %w", err)
 	}
 
 	// QDP-0001 nonce-ledger check. In shadow mode (enforce=false) we
@@ -110,10 +138,10 @@ func (node *QuidnugNode) AddTrustTransaction(tx TrustTransaction) (string, error
 			nonceReplayRejections.WithLabelValues(nonceRejectionReason(err), fmt.Sprintf("%t", node.NonceLedgerEnforce)).Inc()
 			if node.NonceLedgerEnforce {
 				RecordTransactionProcessed("trust", false)
-				return "", fmt.Errorf("nonce ledger rejected transaction: %w", err)
+				return "", rejectErr(RejectNonceReplay, "nonce", "nonce ledger rejected transaction", err)
 			}
-			logger.Warn("Nonce ledger would reject trust transaction (shadow mode)",
-				"txId", tx.ID, "truster", tx.Truster, "domain", tx.TrustDomain,
+			txLog.Warn("Nonce ledger would reject trust transaction (shadow mode)",
+				"truster", tx.Truster, "domain", tx.TrustDomain,
 				"nonce", tx.Nonce, "reason", err)
 		}
 	}
@@ -139,14 +167,26 @@ func (node *QuidnugNode) AddTrustTransaction(tx TrustTransaction) (string, error
 	UpdatePendingTransactionsGauge(len(node.PendingTxs))
 
 	// Broadcast to other nodes in the same trust domain
-	go node.BroadcastTransaction(tx)
+	go node.broadcastTransaction(ctx, tx)
 
-	logger.Info("Added trust transaction to pending pool", "txId", tx.ID, "domain", tx.TrustDomain)
+	txLog.Info("Added trust transaction to pending pool", "domain", tx.TrustDomain)
 	return tx.ID, nil
 }
 
 // AddIdentityTransaction adds an identity transaction to the pending pool
 func (node *QuidnugNode) AddIdentityTransaction(tx IdentityTransaction) (string, error) {
+	return node.addIdentityTransaction(context.Background(), tx)
+}
+
+// addIdentityTransaction is AddIdentityTransaction logging under the
+// request ctx carries.
+func (node *QuidnugNode) addIdentityTransaction(ctx context.Context, tx IdentityTransaction) (string, error) {
+	// Group members are admitted together (atomic_group.go).
+	if err := loneGroupMember(tx.BaseTransaction); err != nil {
+		RecordTransactionProcessed("identity", false)
+		return "", err
+	}
+
 	// Set timestamp if not set
 	if tx.Timestamp == 0 {
 		tx.Timestamp = time.Now().Unix()
@@ -176,16 +216,38 @@ func (node *QuidnugNode) AddIdentityTransaction(tx IdentityTransaction) (string,
 		hash := sha256.Sum256(txData)
 		tx.ID = hex.EncodeToString(hash[:])
 	}
+	txLog := loggerFrom(ctx).With("txId", tx.ID, "txType", TxTypeIdentity)
+
+	if err := node.admitAntiSpamOrReject("identity", tx.TrustDomain, tx.Creator, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("identity", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if err := node.requireAssetClass(tx.TrustDomain, tx.AssetClass); err != nil {
+		RecordTransactionProcessed("identity", false)
+		return "", err
+	}
 
 	// Validate the transaction
-	if !node.ValidateIdentityTransaction(tx) {
+	if err := node.checkIdentityTransaction(tx); err != nil {
 		RecordTransactionProcessed("identity", false)
-		return "", fmt.Errorf("invalid identity transaction")
+		txLog.Info("Rejected identity transaction", "error", err)
+		return "", fmt.Errorf("invalid identity transaction: %w", err)
 	}
 
 	node.PendingTxsMutex.Lock()
 	defer node.PendingTxsMutex.Unlock()
 
+	// First update to claim an UpdateNonce wins (identity_conflicts.go).
+	if kept, conflict := node.pendingIdentityConflict(tx); conflict {
+		node.recordIdentityConflict(kept, tx, IdentityConflictStageMempool, nil)
+		RecordTransactionProcessed("identity", false)
+		return "", fmt.Errorf("identity update nonce %d for %s already claimed by pending transaction %s",
+			tx.UpdateNonce, tx.QuidID, kept.ID)
+	}
+
 	// Add transaction to pending pool
 	node.PendingTxs = append(node.PendingTxs, tx)
 
@@ -194,14 +256,26 @@ func (node *QuidnugNode) AddIdentityTransaction(tx IdentityTransaction) (string,
 	UpdatePendingTransactionsGauge(len(node.PendingTxs))
 
 	// Broadcast to other nodes in the same trust domain
-	go node.BroadcastTransaction(tx)
+	go node.broadcastTransaction(ctx, tx)
 
-	logger.Info("Added identity transaction to pending pool", "txId", tx.ID, "quidId", tx.QuidID, "domain", tx.TrustDomain)
+	txLog.Info("Added identity transaction to pending pool", "quidId", tx.QuidID, "domain", tx.TrustDomain)
 	return tx.ID, nil
 }
 
 // AddEventTransaction adds an event transaction to the pending pool
 func (node *QuidnugNode) AddEventTransaction(tx EventTransaction) (string, error) {
+	return node.addEventTransaction(context.Background(), tx)
+}
+
+// addEventTransaction is AddEventTransaction logging under the
+// request ctx carries.
+func (node *QuidnugNode) addEventTransaction(ctx context.Context, tx EventTransaction) (string, error) {
+	// Group members are admitted together (atomic_group.go).
+	if err := loneGroupMember(tx.BaseTransaction); err != nil {
+		RecordTransactionProcessed("event", false)
+		return "", err
+	}
+
 	// Set timestamp if not set
 	if tx.Timestamp == 0 {
 		tx.Timestamp = time.Now().Unix()
@@ -241,6 +315,7 @@ func (node *QuidnugNode) AddEventTransaction(tx EventTransaction) (string, error
 		hash := sha256.Sum256(txData)
 		tx.ID = hex.EncodeToString(hash[:])
 	}
+	txLog := loggerFrom(ctx).With("txId", tx.ID, "txType", TxTypeEvent)
 
 	// QDP-0016 rate-limit. The signer's quid is derived from
 	// PublicKey; if the tx is unsigned yet (internal callers)
@@ -257,10 +332,18 @@ func (node *QuidnugNode) AddEventTransaction(tx EventTransaction) (string, error
 		return "", err
 	}
 
+	if err := node.admitAntiSpamOrReject("event", tx.TrustDomain, signerQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("event", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
 	// Validate the transaction
-	if !node.ValidateEventTransaction(tx) {
+	if err := node.checkEventTransaction(tx); err != nil {
 		RecordTransactionProcessed("event", false)
-		return "", fmt.Errorf("invalid event transaction")
+		txLog.Info("Rejected event transaction", "error", err)
+		return "", fmt.Errorf("invalid event transaction: %w", err)
 	}
 
 	node.PendingTxsMutex.Lock()
@@ -274,14 +357,26 @@ func (node *QuidnugNode) AddEventTransaction(tx EventTransaction) (string, error
 	UpdatePendingTransactionsGauge(len(node.PendingTxs))
 
 	// Broadcast to other nodes in the same trust domain
-	go node.BroadcastTransaction(tx)
+	go node.broadcastTransaction(ctx, tx)
 
-	logger.Info("Added event transaction to pending pool", "txId", tx.ID, "subjectId", tx.SubjectID, "domain", tx.TrustDomain)
+	txLog.Info("Added event transaction to pending pool", "subjectId", tx.SubjectID, "domain", tx.TrustDomain)
 	return tx.ID, nil
 }
 
 // AddTitleTransaction adds a title transaction to the pending pool
 func (node *QuidnugNode) AddTitleTransaction(tx TitleTransaction) (string, error) {
+	return node.addTitleTransaction(context.Background(), tx)
+}
+
+// addTitleTransaction is AddTitleTransaction logging under the
+// request ctx carries.
+func (node *QuidnugNode) addTitleTransaction(ctx context.Context, tx TitleTransaction) (string, error) {
+	// Group members are admitted together (atomic_group.go).
+	if err := loneGroupMember(tx.BaseTransaction); err != nil {
+		RecordTransactionProcessed("title", false)
+		return "", err
+	}
+
 	// Set timestamp if not set
 	if tx.Timestamp == 0 {
 		tx.Timestamp = time.Now().Unix()
@@ -307,11 +402,25 @@ func (node *QuidnugNode) AddTitleTransaction(tx TitleTransaction) (string, error
 		hash := sha256.Sum256(txData)
 		tx.ID = hex.EncodeToString(hash[:])
 	}
+	txLog := loggerFrom(ctx).With("txId", tx.ID, "txType", TxTypeTitle)
+
+	if err := node.admitAntiSpamOrReject("title", tx.TrustDomain, titleStakeQuid(tx), tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("title", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if err := node.requireAssetClass(tx.TrustDomain, tx.AssetClass); err != nil {
+		RecordTransactionProcessed("title", false)
+		return "", err
+	}
 
 	// Validate the transaction
-	if !node.ValidateTitleTransaction(tx) {
+	if err := node.checkTitleTransaction(tx); err != nil {
 		RecordTransactionProcessed("title", false)
-		return "", fmt.Errorf("invalid title transaction")
+		txLog.Info("Rejected title transaction", "error", err)
+		return "", fmt.Errorf("invalid title transaction: %w", err)
 	}
 
 	node.PendingTxsMutex.Lock()
@@ -325,9 +434,9 @@ func (node *QuidnugNode) AddTitleTransaction(tx TitleTransaction) (string, error
 	UpdatePendingTransactionsGauge(len(node.PendingTxs))
 
 	// Broadcast to other nodes in the same trust domain
-	go node.BroadcastTransaction(tx)
+	go node.broadcastTransaction(ctx, tx)
 
-	logger.Info("Added title transaction to pending pool", "txId", tx.ID, "assetId", tx.AssetID, "domain", tx.TrustDomain)
+	txLog.Info("Added title transaction to pending pool", "assetId", tx.AssetID, "domain", tx.TrustDomain)
 	return tx.ID, nil
 }
 
@@ -364,6 +473,13 @@ func (node *QuidnugNode) AddNodeAdvertisementTransaction(tx NodeAdvertisementTra
 		tx.ID = hex.EncodeToString(hash[:])
 	}
 
+	if err := node.admitAntiSpamOrReject("node_advertisement", tx.TrustDomain, tx.NodeQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("node_advertisement", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
 	if !node.ValidateNodeAdvertisementTransaction(tx) {
 		RecordTransactionProcessed("node_advertisement", false)
 		return "", fmt.Errorf("invalid node advertisement transaction")
@@ -443,6 +559,13 @@ func (node *QuidnugNode) AddModerationActionTransaction(tx ModerationActionTrans
 		return "", err
 	}
 
+	if err := node.admitAntiSpamOrReject("moderation_action", tx.TrustDomain, tx.ModeratorQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("moderation_action", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
 	if !node.ValidateModerationActionTransaction(tx) {
 		RecordTransactionProcessed("moderation_action", false)
 		return "", fmt.Errorf("invalid moderation action transaction")
@@ -482,6 +605,590 @@ func (node *QuidnugNode) AddModerationActionTransaction(tx ModerationActionTrans
 	return tx.ID, nil
 }
 
+// AddNameRegistrationTransaction admits a NAME_REGISTRATION
+// transaction into the pending pool. Signed/unsigned auto-fill
+// follows AddModerationActionTransaction; an unsigned tx gets the
+// name's next nonce.
+func (node *QuidnugNode) AddNameRegistrationTransaction(tx NameRegistrationTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeNameRegistration
+	}
+
+	if !signed && tx.Nonce == 0 && node.NameRegistry != nil {
+		tx.Nonce = node.NameRegistry.currentNonce(tx.Name) + 1
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			Name        string
+			OwnerQuid   string
+			TargetQuid  string
+			Release     bool
+			TrustDomain string
+			Nonce       int64
+			Timestamp   int64
+		}{
+			Name:        tx.Name,
+			OwnerQuid:   tx.OwnerQuid,
+			TargetQuid:  tx.TargetQuid,
+			Release:     tx.Release,
+			TrustDomain: tx.TrustDomain,
+			Nonce:       tx.Nonce,
+			Timestamp:   tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.OwnerQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("name_registration", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("name_registration", tx.TrustDomain, tx.OwnerQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("name_registration", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateNameRegistrationTransaction(tx) {
+		RecordTransactionProcessed("name_registration", false)
+		return "", fmt.Errorf("invalid name registration transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("name_registration", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added name registration to pending pool",
+		"txId", tx.ID,
+		"name", tx.Name,
+		"owner", tx.OwnerQuid,
+		"target", tx.TargetQuid,
+		"release", tx.Release,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddLienTransaction admits a LIEN transaction (new lien or
+// release) into the pending pool. Signed/unsigned auto-fill
+// follows AddModerationActionTransaction.
+func (node *QuidnugNode) AddLienTransaction(tx LienTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeLien
+	}
+
+	if !signed && tx.Nonce == 0 && node.LienRegistry != nil {
+		tx.Nonce = node.LienRegistry.currentNonce(tx.LienholderQuid) + 1
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			AssetID        string
+			LienholderQuid string
+			ReleasesLienID string
+			TrustDomain    string
+			Nonce          int64
+			Timestamp      int64
+		}{
+			AssetID:        tx.AssetID,
+			LienholderQuid: tx.LienholderQuid,
+			ReleasesLienID: tx.ReleasesLienID,
+			TrustDomain:    tx.TrustDomain,
+			Nonce:          tx.Nonce,
+			Timestamp:      tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.LienholderQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("lien", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("lien", tx.TrustDomain, tx.LienholderQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("lien", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateLienTransaction(tx) {
+		RecordTransactionProcessed("lien", false)
+		return "", fmt.Errorf("invalid lien transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("lien", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added lien to pending pool",
+		"txId", tx.ID,
+		"assetId", tx.AssetID,
+		"lienholder", tx.LienholderQuid,
+		"releases", tx.ReleasesLienID,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddSuccessionTransaction admits a SUCCESSION transaction into the
+// pending pool. Signed/unsigned auto-fill follows
+// AddLienTransaction.
+func (node *QuidnugNode) AddSuccessionTransaction(tx SuccessionTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeSuccession
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			SubjectQuid   string
+			SuccessorQuid string
+			TrustDomain   string
+			Timestamp     int64
+		}{
+			SubjectQuid:   tx.SubjectQuid,
+			SuccessorQuid: tx.SuccessorQuid,
+			TrustDomain:   tx.TrustDomain,
+			Timestamp:     tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.SuccessorQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("succession", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("succession", tx.TrustDomain, tx.SuccessorQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("succession", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateSuccessionTransaction(tx) {
+		RecordTransactionProcessed("succession", false)
+		return "", fmt.Errorf("invalid succession transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("succession", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added succession to pending pool",
+		"txId", tx.ID,
+		"subject", tx.SubjectQuid,
+		"successor", tx.SuccessorQuid,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddMisbehaviorReportTransaction admits a MISBEHAVIOR_REPORT into
+// the pending pool. Signed/unsigned auto-fill follows
+// AddModerationActionTransaction.
+func (node *QuidnugNode) AddMisbehaviorReportTransaction(tx MisbehaviorReportTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeMisbehaviorReport
+	}
+
+	if !signed && tx.Nonce == 0 && node.MisbehaviorRegistry != nil {
+		tx.Nonce = node.MisbehaviorRegistry.currentNonce(tx.ReporterQuid) + 1
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			ReporterQuid  string
+			ValidatorQuid string
+			Kind          string
+			TrustDomain   string
+			Nonce         int64
+			Timestamp     int64
+		}{
+			ReporterQuid:  tx.ReporterQuid,
+			ValidatorQuid: tx.ValidatorQuid,
+			Kind:          tx.Kind,
+			TrustDomain:   tx.TrustDomain,
+			Nonce:         tx.Nonce,
+			Timestamp:     tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.ReporterQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("misbehavior", false)
+		return "", err
+	}
+
+	if !node.ValidateMisbehaviorReportTransaction(tx) {
+		RecordTransactionProcessed("misbehavior", false)
+		return "", fmt.Errorf("invalid misbehavior report")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("misbehavior", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added misbehavior report to pending pool",
+		"txId", tx.ID,
+		"reporter", tx.ReporterQuid,
+		"validator", tx.ValidatorQuid,
+		"kind", tx.Kind,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddTransferApprovalTransaction admits a TRANSFER_APPROVAL for
+// a pending conditional title transfer into the pending pool.
+func (node *QuidnugNode) AddTransferApprovalTransaction(tx TransferApprovalTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeTransferApproval
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			TransferTxID string
+			ApproverQuid string
+			Decision     string
+			TrustDomain  string
+			Timestamp    int64
+		}{
+			TransferTxID: tx.TransferTxID,
+			ApproverQuid: tx.ApproverQuid,
+			Decision:     tx.Decision,
+			TrustDomain:  tx.TrustDomain,
+			Timestamp:    tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.ApproverQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("transfer_approval", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("transfer_approval", tx.TrustDomain, tx.ApproverQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("transfer_approval", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateTransferApprovalTransaction(tx) {
+		RecordTransactionProcessed("transfer_approval", false)
+		return "", fmt.Errorf("invalid transfer approval transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("transfer_approval", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added transfer approval to pending pool",
+		"txId", tx.ID,
+		"transferTxId", tx.TransferTxID,
+		"approver", tx.ApproverQuid,
+		"decision", tx.Decision,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddTitleRestructureTransaction admits a TITLE_RESTRUCTURE (split
+// or merge) into the pending pool. Signed/unsigned auto-fill
+// follows AddTransferApprovalTransaction; the rate-limit actor is
+// the issuing key's quid.
+func (node *QuidnugNode) AddTitleRestructureTransaction(tx TitleRestructureTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeTitleRestructure
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			Operation   string
+			Parents     []TitlePart
+			Children    []TitlePart
+			TrustDomain string
+			Timestamp   int64
+		}{
+			Operation:   tx.Operation,
+			Parents:     tx.Parents,
+			Children:    tx.Children,
+			TrustDomain: tx.TrustDomain,
+			Timestamp:   tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	issuer := QuidIDFromPublicKeyHex(tx.PublicKey)
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   issuer,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("title_restructure", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("title_restructure", tx.TrustDomain, issuer, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("title_restructure", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateTitleRestructureTransaction(tx) {
+		RecordTransactionProcessed("title_restructure", false)
+		return "", fmt.Errorf("invalid title restructure transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("title_restructure", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added title restructure to pending pool",
+		"txId", tx.ID,
+		"operation", tx.Operation,
+		"parents", len(tx.Parents),
+		"children", len(tx.Children),
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddTitleDisputeTransaction admits a TITLE_DISPUTE (filing or
+// resolution) into the pending pool. Signed/unsigned auto-fill
+// follows AddLienTransaction.
+func (node *QuidnugNode) AddTitleDisputeTransaction(tx TitleDisputeTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeTitleDispute
+	}
+
+	if !signed && tx.Nonce == 0 && node.TitleDisputes != nil {
+		tx.Nonce = node.TitleDisputes.currentNonce(tx.SignerQuid) + 1
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			AssetID           string
+			SignerQuid        string
+			ResolvesDisputeID string
+			TrustDomain       string
+			Nonce             int64
+			Timestamp         int64
+		}{
+			AssetID:           tx.AssetID,
+			SignerQuid:        tx.SignerQuid,
+			ResolvesDisputeID: tx.ResolvesDisputeID,
+			TrustDomain:       tx.TrustDomain,
+			Nonce:             tx.Nonce,
+			Timestamp:         tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.SignerQuid,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("title_dispute", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("title_dispute", tx.TrustDomain, tx.SignerQuid, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("title_dispute", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateTitleDisputeTransaction(tx) {
+		RecordTransactionProcessed("title_dispute", false)
+		return "", fmt.Errorf("invalid title dispute transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("title_dispute", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added title dispute to pending pool",
+		"txId", tx.ID,
+		"assetId", tx.AssetID,
+		"signer", tx.SignerQuid,
+		"resolves", tx.ResolvesDisputeID,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
+// AddCustomTransaction admits a GENERIC transaction of a
+// registered custom type into the pending pool. Signed/unsigned
+// auto-fill follows AddLienTransaction.
+func (node *QuidnugNode) AddCustomTransaction(tx CustomTransaction) (string, error) {
+	signed := tx.Signature != ""
+
+	if !signed && tx.Timestamp == 0 {
+		tx.Timestamp = time.Now().Unix()
+	}
+	if !signed {
+		tx.Type = TxTypeGeneric
+	}
+
+	if _, known := node.CustomTxRegistry.lookup(tx.CustomType); !known {
+		RecordTransactionProcessed("custom", false)
+		return "", fmt.Errorf("%w: %q", ErrCustomTxTypeNotFound, tx.CustomType)
+	}
+
+	if !signed && tx.Nonce == 0 {
+		tx.Nonce = node.CustomTxRegistry.currentNonce(tx.Signer) + 1
+	}
+
+	if !signed && tx.ID == "" {
+		txData, _ := json.Marshal(struct {
+			CustomType  string
+			Signer      string
+			SubjectID   string
+			Payload     map[string]interface{}
+			TrustDomain string
+			Nonce       int64
+			Timestamp   int64
+		}{
+			CustomType:  tx.CustomType,
+			Signer:      tx.Signer,
+			SubjectID:   tx.SubjectID,
+			Payload:     tx.Payload,
+			TrustDomain: tx.TrustDomain,
+			Nonce:       tx.Nonce,
+			Timestamp:   tx.Timestamp,
+		})
+		hash := sha256.Sum256(txData)
+		tx.ID = hex.EncodeToString(hash[:])
+	}
+
+	if err := node.admitWriteOrReject(ratelimit.ActorKeys{
+		Quid:   tx.Signer,
+		Domain: tx.TrustDomain,
+	}); err != nil {
+		RecordTransactionProcessed("custom", false)
+		return "", err
+	}
+
+	if err := node.admitAntiSpamOrReject("custom", tx.TrustDomain, tx.Signer, tx); err != nil {
+		return "", err
+	}
+	if err := node.admitBlockFitOrReject("custom", tx.TrustDomain, tx); err != nil {
+		return "", err
+	}
+
+	if !node.ValidateCustomTransaction(tx) {
+		RecordTransactionProcessed("custom", false)
+		return "", fmt.Errorf("invalid custom transaction")
+	}
+
+	node.PendingTxsMutex.Lock()
+	defer node.PendingTxsMutex.Unlock()
+
+	node.PendingTxs = append(node.PendingTxs, tx)
+	RecordTransactionProcessed("custom", true)
+	UpdatePendingTransactionsGauge(len(node.PendingTxs))
+
+	go node.BroadcastTransaction(tx)
+
+	logger.Info("Added custom transaction to pending pool",
+		"txId", tx.ID,
+		"customType", tx.CustomType,
+		"signer", tx.Signer,
+		"subjectId", tx.SubjectID,
+		"domain", tx.TrustDomain)
+	return tx.ID, nil
+}
+
 // addPrivacyTxToPool is the shared mempool admission path used
 // by every QDP-0017 tx type. Calls the caller-supplied
 // validator; on success appends to PendingTxs and broadcasts.
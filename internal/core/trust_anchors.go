@@ -0,0 +1,163 @@
+// Trust anchors: whose point of view the node's trust decisions take.
+//
+// Relational trust always has an observer, and for the node's own
+// decisions (which validators' blocks to accept, whose transactions
+// to put in blocks) that observer used to be the node quid alone. An
+// operator can now name extra anchors in trust_anchors, typically
+// the organization quid that actually holds the relationships:
+//
+//	trust_anchors:
+//	  - quid: "a1b2c3d4e5f60718"
+//	    weight: 0.9
+//
+// Anchored trust in a target is the best weight × trust(anchor,
+// target) over the anchors, the node itself included at weight 1.
+// Taking the best rather than an average means adding an anchor can
+// only widen what the node trusts, never dilute a relationship the
+// node already has.
+//
+// POST /trust/anchored computes the same merge for an API caller.
+// A caller who proves control of a quid, by a bearer token mapped to
+// it or by answering a quid challenge, is added as one more anchor
+// at trust_anchor_caller_weight, so the answer is "trust as me,
+// informed by this node's anchors".
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/quidnug/quidnug/internal/config"
+)
+
+// ErrAnchorProof means a caller asked for trust as a quid without
+// proving control of it.
+var ErrAnchorProof = errors.New("trust anchors: caller has not proven control of the quid")
+
+// TrustAnchor is an observer whose trust the node adopts, scaled by
+// Weight.
+type TrustAnchor struct {
+	Quid   string  `json:"quid"`
+	Weight float64 `json:"weight"`
+}
+
+// AnchorTrust is one anchor's contribution to an anchored result.
+type AnchorTrust struct {
+	TrustAnchor
+	TrustLevel float64  `json:"trustLevel"`
+	Weighted   float64  `json:"weighted"`
+	TrustPath  []string `json:"trustPath"`
+}
+
+// AnchoredTrustResult is the merged trust in Target. Anchor is the
+// anchor that produced TrustLevel; empty when no anchor reaches the
+// target.
+type AnchoredTrustResult struct {
+	Target     string        `json:"target"`
+	TrustLevel float64       `json:"trustLevel"`
+	Anchor     string        `json:"anchor"`
+	TrustPath  []string      `json:"trustPath"`
+	Anchors    []AnchorTrust `json:"anchors"`
+}
+
+// newTrustAnchors validates the configured anchors.
+func newTrustAnchors(cfg *config.Config) ([]TrustAnchor, error) {
+	var out []TrustAnchor
+	for i, a := range cfg.TrustAnchors {
+		if !IsValidQuidID(a.Quid) {
+			return nil, fmt.Errorf("trust_anchors[%d]: invalid quid %q", i, a.Quid)
+		}
+		if a.Weight <= 0 || a.Weight > 1 {
+			return nil, fmt.Errorf("trust_anchors[%d]: weight must be in (0, 1]", i)
+		}
+		out = append(out, TrustAnchor{Quid: a.Quid, Weight: a.Weight})
+	}
+	return out, nil
+}
+
+// ComputeAnchoredTrust merges trust in target from primary (weight
+// 1), the configured anchors, and extra. A quid listed more than once
+// keeps its highest weight. Like ComputeRelationalTrust, a resource
+// limit error comes back with the best result found.
+func (node *QuidnugNode) ComputeAnchoredTrust(ctx context.Context, primary, target string, maxDepth int, extra ...TrustAnchor) (AnchoredTrustResult, error) {
+	anchors := []TrustAnchor{{Quid: primary, Weight: 1}}
+	anchors = append(anchors, node.TrustAnchors...)
+	anchors = append(anchors, extra...)
+
+	seen := make(map[string]int, len(anchors))
+	var merged []TrustAnchor
+	for _, a := range anchors {
+		if a.Quid == "" || a.Weight <= 0 {
+			continue
+		}
+		if i, ok := seen[a.Quid]; ok {
+			if a.Weight > merged[i].Weight {
+				merged[i].Weight = a.Weight
+			}
+			continue
+		}
+		seen[a.Quid] = len(merged)
+		merged = append(merged, a)
+	}
+
+	result := AnchoredTrustResult{Target: target, TrustPath: []string{}, Anchors: []AnchorTrust{}}
+	var firstErr error
+	for _, a := range merged {
+		level, path, err := node.ComputeRelationalTrust(ctx, a.Quid, target, maxDepth)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if path == nil {
+			path = []string{}
+		}
+		contribution := AnchorTrust{TrustAnchor: a, TrustLevel: level, Weighted: level * a.Weight, TrustPath: path}
+		result.Anchors = append(result.Anchors, contribution)
+		if contribution.Weighted > result.TrustLevel {
+			result.TrustLevel = contribution.Weighted
+			result.Anchor = a.Quid
+			result.TrustPath = path
+		}
+	}
+	return result, firstErr
+}
+
+// nodeTrustIn is the node's own trust in target for acceptance
+// decisions: trust from observer merged with the configured anchors.
+func (node *QuidnugNode) nodeTrustIn(observer, target string) (float64, []string, error) {
+	if len(node.TrustAnchors) == 0 {
+		return node.ComputeRelationalTrust(context.Background(), observer, target, DefaultTrustMaxDepth)
+	}
+	result, err := node.ComputeAnchoredTrust(context.Background(), observer, target, DefaultTrustMaxDepth)
+	return result.TrustLevel, result.TrustPath, err
+}
+
+// AnchoredTrustRequest is the body of POST /trust/anchored. AsQuid,
+// when set, adds the caller's quid as an anchor; it must match the
+// caller's bearer token or come with an answered challenge.
+type AnchoredTrustRequest struct {
+	Target      string `json:"target"`
+	MaxDepth    int    `json:"maxDepth,omitempty"`
+	AsQuid      string `json:"asQuid,omitempty"`
+	ChallengeID string `json:"challengeId,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+}
+
+// callerAnchor checks the caller's proof of control over req.AsQuid
+// and returns it as an anchor. authenticated is the quid the request's
+// bearer token maps to, if any.
+func (node *QuidnugNode) callerAnchor(req AnchoredTrustRequest, authenticated string) (TrustAnchor, error) {
+	if req.AsQuid != authenticated {
+		if req.ChallengeID == "" || req.Signature == "" {
+			return TrustAnchor{}, ErrAnchorProof
+		}
+		if _, err := node.VerifyQuidChallenge(req.AsQuid, req.ChallengeID, req.Signature); err != nil {
+			return TrustAnchor{}, err
+		}
+	}
+	weight := node.TrustAnchorCallerWeight
+	if weight <= 0 {
+		weight = 1
+	}
+	return TrustAnchor{Quid: req.AsQuid, Weight: weight}, nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustAnchors_WidenBlockTrust(t *testing.T) {
+	node := newTestNode()
+	validator, org := newTestNode(), "0a0a0a0a0a0a0a0a"
+	node.TrustDomains["anchors.example"] = TrustDomain{
+		Name:                "anchors.example",
+		ValidatorNodes:      []string{validator.NodeID},
+		TrustThreshold:      0.5,
+		Validators:          map[string]float64{validator.NodeID: 1},
+		ValidatorPublicKeys: map[string]string{validator.NodeID: validator.GetPublicKeyHex()},
+	}
+	node.TrustRegistry[org] = map[string]float64{validator.NodeID: 0.8}
+	block := signedTestBlock(t, validator, "anchors.example", 1, []interface{}{})
+
+	if got := node.ValidateTrustProofTiered(block); got != BlockUntrusted {
+		t.Fatalf("without anchors: got %v, want untrusted", got)
+	}
+
+	// 0.8 scaled by 0.9 is 0.72, above the threshold.
+	node.TrustAnchors = []TrustAnchor{{Quid: org, Weight: 0.9}}
+	if got := node.ValidateTrustProofTiered(block); got != BlockTrusted {
+		t.Fatalf("with anchor: got %v, want trusted", got)
+	}
+	result, err := node.ComputeAnchoredTrust(context.Background(), node.NodeID, validator.NodeID, DefaultTrustMaxDepth)
+	if err != nil || result.Anchor != org || !floatEquals(result.TrustLevel, 0.72, 0.0001) {
+		t.Fatalf("anchored result %+v, %v", result, err)
+	}
+
+	// 0.8 scaled by 0.5 is 0.4: tentative.
+	node.TrustAnchors[0].Weight = 0.5
+	if got := node.ValidateTrustProofTiered(block); got != BlockTentative {
+		t.Fatalf("low-weight anchor: got %v, want tentative", got)
+	}
+}
+
+func TestAnchoredTrustHandler_CallerProof(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	caller, target := "0000000000000001", "0b0b0b0b0b0b0b0b"
+	node.TrustRegistry[caller] = map[string]float64{target: 0.6}
+	node.TrustAnchorCallerWeight = 0.5
+
+	post := func(req AnchoredTrustRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/trust/anchored", bytes.NewReader(body)))
+		return rec
+	}
+	var resp struct {
+		Data AnchoredTrustResult `json:"data"`
+	}
+
+	rec := post(AnchoredTrustRequest{Target: target})
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || resp.Data.TrustLevel != 0 {
+		t.Fatalf("node view: status %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := post(AnchoredTrustRequest{Target: target, AsQuid: caller}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unproven caller: status %d", rec.Code)
+	}
+
+	// The test identity is registered with the node's own key.
+	c, _ := node.IssueQuidChallenge(caller, "")
+	data, _ := json.Marshal(c)
+	sig, _ := node.SignData(data)
+	rec = post(AnchoredTrustRequest{Target: target, AsQuid: caller, ChallengeID: c.ChallengeID, Signature: hex.EncodeToString(sig)})
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("proven caller: status %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Data.Anchor != caller || !floatEquals(resp.Data.TrustLevel, 0.3, 0.0001) || len(resp.Data.Anchors) != 2 {
+		t.Fatalf("proven caller result %+v", resp.Data)
+	}
+}
@@ -0,0 +1,213 @@
+// Batch relational trust queries.
+//
+// Scoring many counterparties from one observer is the common
+// case (marketplaces, feeds), and running the single-pair BFS once
+// per target repeats the same traversal. ComputeRelationalTrustBatch
+// walks the observer's neighbourhood once and reads every requested
+// target off that walk.
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// MaxTrustBatchSize caps the number of results one batch request
+// may ask for.
+const MaxTrustBatchSize = 500
+
+// ComputeRelationalTrustBatch returns the relational trust from
+// observer to each target, in targets order. Results match what
+// ComputeRelationalTrust would return per pair; cached results are
+// reused and fresh ones are cached. On ErrTrustGraphTooLarge the
+// best results found so far are returned and nothing is cached.
+func (node *QuidnugNode) ComputeRelationalTrustBatch(ctx context.Context, observer string, targets []string, maxDepth int) ([]RelationalTrustResult, error) {
+	return node.computeTrustBatch(ctx, observer, targets, maxDepth, TrustScope{})
+}
+
+// ComputeScopedTrustBatch is ComputeRelationalTrustBatch limited to
+// scope, matching ComputeScopedTrust per pair. The zero scope searches
+// the global graph.
+func (node *QuidnugNode) ComputeScopedTrustBatch(ctx context.Context, observer string, targets []string, scope TrustScope, maxDepth int) ([]RelationalTrustResult, error) {
+	return node.computeTrustBatch(ctx, observer, targets, maxDepth, scope)
+}
+
+func (node *QuidnugNode) computeTrustBatch(ctx context.Context, observer string, targets []string, maxDepth int, scope TrustScope) ([]RelationalTrustResult, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+
+	results := make([]RelationalTrustResult, len(targets))
+	pending := make(map[string]bool)
+	for i, target := range targets {
+		results[i] = RelationalTrustResult{Observer: observer, Target: target}
+		if node.TrustPrecompute != nil && scope.global() && observer == node.NodeID && observer != target {
+			node.TrustPrecompute.Record(target, maxDepth)
+		}
+		if observer == target {
+			results[i].TrustLevel = 1.0
+			results[i].TrustPath = []string{observer}
+			continue
+		}
+		if node.TrustCache != nil {
+			if trust, path, ok := node.TrustCache.Get(scopedCacheKey(makeTrustCacheKey(observer, target, maxDepth), scope)); ok {
+				results[i].TrustLevel = trust
+				results[i].TrustPath = path
+				continue
+			}
+		}
+		pending[target] = true
+	}
+
+	// Targets the node's trust tracker covers skip the search.
+	if len(pending) > 0 && scope.global() {
+		tracked := make(map[string]trustBatchHit)
+		for target := range pending {
+			trust, path, deps, view, ok := node.trackedTrust(observer, target, maxDepth)
+			if !ok {
+				break
+			}
+			tracked[target] = trustBatchHit{trust: trust, path: path}
+			delete(pending, target)
+			if node.TrustCache != nil && node.trustViewCurrent(view) {
+				node.TrustCache.SetWithDeps(makeTrustCacheKey(observer, target, maxDepth), trust, path, deps)
+			}
+		}
+		for i := range results {
+			if hit, ok := tracked[results[i].Target]; ok {
+				results[i].TrustLevel = hit.trust
+				results[i].TrustPath = hit.path
+			}
+		}
+	}
+
+	var searchErr error
+	if len(pending) > 0 {
+		var found map[string]trustBatchHit
+		if node.TrustGraphStore != nil && scope.global() {
+			// An external store answers one pair per query, so there
+			// is no shared traversal to exploit.
+			found = make(map[string]trustBatchHit, len(pending))
+			for target := range pending {
+				trust, path, err := node.computeRelationalTrust(ctx, observer, target, maxDepth)
+				if err != nil {
+					searchErr = err
+				}
+				found[target] = trustBatchHit{trust: trust, path: path}
+			}
+		} else {
+			found, searchErr = node.searchTrustTargets(ctx, observer, pending, maxDepth, scope)
+		}
+		for i := range results {
+			if hit, ok := found[results[i].Target]; ok && pending[results[i].Target] {
+				results[i].TrustLevel = hit.trust
+				results[i].TrustPath = hit.path
+			}
+		}
+	}
+
+	for i := range results {
+		if len(results[i].TrustPath) > 1 {
+			results[i].PathDepth = len(results[i].TrustPath) - 1
+		}
+	}
+	return results, searchErr
+}
+
+type trustBatchHit struct {
+	trust float64
+	path  []string
+}
+
+// searchTrustTargets runs the ComputeRelationalTrust BFS once from
+// observer and records the best path to every target, caching each.
+func (node *QuidnugNode) searchTrustTargets(ctx context.Context, observer string, targets map[string]bool, maxDepth int, scope TrustScope) (map[string]trustBatchHit, error) {
+	best, expanded, err := node.walkTrust(ctx, observer, maxDepth, scope, func(q string) bool { return targets[q] })
+	if err != nil {
+		return best, err
+	}
+	if node.TrustCache != nil {
+		for target := range targets {
+			hit := best[target]
+			node.TrustCache.SetWithDeps(scopedCacheKey(makeTrustCacheKey(observer, target, maxDepth), scope), hit.trust, hit.path, expanded)
+		}
+	}
+	return best, nil
+}
+
+// walkTrust is the multi-target form of the ComputeRelationalTrust
+// BFS. It returns the best path to every quid accepted by want and
+// the quids whose edges were read. Unlike the single-target search,
+// reached targets are expanded too, since one target can lie on the
+// best path to another. The walk is limited to the edges in scope
+// and stops with ctx.Err() once ctx is cancelled.
+func (node *QuidnugNode) walkTrust(ctx context.Context, observer string, maxDepth int, scope TrustScope, want func(string) bool) (map[string]trustBatchHit, []string, error) {
+	start := time.Now()
+	defer func() {
+		trustComputationDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	type searchState struct {
+		quid  string
+		path  []string
+		trust float64
+	}
+
+	queue := []searchState{{quid: observer, path: []string{observer}, trust: 1.0}}
+	visited := map[string]bool{observer: true}
+	best := make(map[string]trustBatchHit)
+	var expanded []string
+
+	for len(queue) > 0 {
+		if len(queue) > MaxTrustQueueSize || len(visited) > MaxTrustVisitedSize {
+			return best, expanded, ErrTrustGraphTooLarge
+		}
+		if len(expanded)%trustCancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return best, expanded, err
+			}
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+		expanded = append(expanded, current.quid)
+
+		for trustee, edgeTrust := range node.directTrusteesInScope(current.quid, scope) {
+			inPath := false
+			for _, p := range current.path {
+				if p == trustee {
+					inPath = true
+					break
+				}
+			}
+			if inPath {
+				continue
+			}
+
+			pathTrust := current.trust * edgeTrust
+			newPath := make([]string, len(current.path)+1)
+			copy(newPath, current.path)
+			newPath[len(current.path)] = trustee
+
+			if want(trustee) && pathTrust > best[trustee].trust {
+				best[trustee] = trustBatchHit{trust: pathTrust, path: newPath}
+			}
+
+			if len(current.path) < maxDepth && !visited[trustee] {
+				visited[trustee] = true
+				queue = append(queue, searchState{quid: trustee, path: newPath, trust: pathTrust})
+			}
+		}
+	}
+	return best, expanded, nil
+}
+
+// directTrusteesInScope is GetDirectTrustees limited to scope, at
+// the levels set there.
+func (node *QuidnugNode) directTrusteesInScope(quid string, scope TrustScope) map[string]float64 {
+	trustees := node.GetDirectTrustees(quid)
+	if scope.global() {
+		return trustees
+	}
+	return node.scopeTrustees(quid, trustees, scope)
+}
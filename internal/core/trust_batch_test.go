@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// seedBatchGraph builds a small graph where one target sits on the
+// best path to another.
+func seedBatchGraph(node *QuidnugNode) {
+	node.TrustRegistry["b000000000000001"] = map[string]float64{
+		"b000000000000002": 0.9,
+		"b000000000000003": 0.5,
+	}
+	node.TrustRegistry["b000000000000002"] = map[string]float64{"b000000000000003": 0.9}
+	node.TrustRegistry["b000000000000003"] = map[string]float64{"b000000000000004": 0.8}
+}
+
+func TestComputeRelationalTrustBatch_MatchesSinglePair(t *testing.T) {
+	node := newTestNode()
+	seedBatchGraph(node)
+	targets := []string{"b000000000000003", "b000000000000004", "b000000000000002", "b000000000000001", "b00000000000dead"}
+
+	batch, err := node.ComputeRelationalTrustBatch(context.Background(), "b000000000000001", targets, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reference := newTestNode()
+	reference.TrustCache = nil
+	seedBatchGraph(reference)
+	for i, target := range targets {
+		trust, path, _ := reference.ComputeRelationalTrust(context.Background(), "b000000000000001", target, 4)
+		if batch[i].Target != target || batch[i].TrustLevel != trust || strings.Join(batch[i].TrustPath, ">") != strings.Join(path, ">") {
+			t.Errorf("target %s: batch %v via %v, single %v via %v", target, batch[i].TrustLevel, batch[i].TrustPath, trust, path)
+		}
+	}
+	if batch[1].PathDepth != len(batch[1].TrustPath)-1 || batch[3].PathDepth != 0 {
+		t.Errorf("unexpected path depths: %+v", batch)
+	}
+
+	// The traversal filled the cache for every computed target,
+	// including the unreachable one.
+	if basic, _ := node.TrustCache.Size(); basic != 4 {
+		t.Errorf("expected 4 cached results, got %d", basic)
+	}
+}
+
+func TestRelationalTrustBatchHandler(t *testing.T) {
+	node := newTestNode()
+	seedBatchGraph(node)
+	router := setupTestRouter(node)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/trust/query/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"observer":"b000000000000001","targets":["b000000000000004","b000000000000002"],
+		"pairs":[{"observer":"b000000000000002","target":"b000000000000004"}],"domain":"market.example","crossDomain":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []RelationalTrustResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(resp.Data))
+	}
+	if resp.Data[0].Target != "b000000000000004" || resp.Data[1].Target != "b000000000000002" ||
+		resp.Data[2].Observer != "b000000000000002" {
+		t.Errorf("results not in request order: %+v", resp.Data)
+	}
+	if resp.Data[1].TrustLevel != 0.9 || resp.Data[2].Domain != "market.example" {
+		t.Errorf("unexpected result fields: %+v", resp.Data)
+	}
+
+	if w := post(`{"targets":["b000000000000002"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("targets without observer: expected 400, got %d", w.Code)
+	}
+	if w := post(`{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: expected 400, got %d", w.Code)
+	}
+	many := make([]string, MaxTrustBatchSize+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("%q", fmt.Sprintf("c%015d", i))
+	}
+	if w := post(`{"observer":"b000000000000001","targets":[` + strings.Join(many, ",") + `]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch: expected 400, got %d", w.Code)
+	}
+}
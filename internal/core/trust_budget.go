@@ -0,0 +1,63 @@
+// Time budgets for relational trust queries.
+//
+// maxDepth and the graph size limits bound how much of the graph a
+// trust search explores, but not how long it takes: a dense
+// neighbourhood a few hops deep can keep one query busy for
+// seconds. API queries therefore run under a deadline as well: the
+// caller's maxMillis, capped by TrustQueryMaxMillis, or the cap alone
+// when the caller names none. A search that runs out returns the
+// best path it found with Truncated set, as one stopped by
+// ErrTrustGraphTooLarge does.
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// trustWarningHeader tells the caller a trust result is partial.
+const trustWarningHeader = "X-Trust-Computation-Warning"
+
+// trustQueryContext is the context a trust query made over r runs
+// under, given the caller's maxMillis (0 when they named none).
+func (node *QuidnugNode) trustQueryContext(r *http.Request, maxMillis int) (context.Context, context.CancelFunc) {
+	budget := maxMillis
+	if limit := node.TrustQueryMaxMillis; limit > 0 && (budget <= 0 || budget > limit) {
+		budget = limit
+	}
+	if budget <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), time.Duration(budget)*time.Millisecond)
+}
+
+// parseMaxMillis reads the maxMillis query parameter; 0 means absent.
+func parseMaxMillis(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("maxMillis")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("maxMillis must be a positive integer")
+	}
+	return n, nil
+}
+
+// markTrustTruncated reports whether err from a trust search means
+// its result is partial, and if so says why in the warning header.
+func markTrustTruncated(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		w.Header().Set(trustWarningHeader, "time budget exhausted, partial result returned")
+	default:
+		w.Header().Set(trustWarningHeader, "resource limits exceeded, partial result returned")
+	}
+	return true
+}
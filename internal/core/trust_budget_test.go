@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTrustQueryContextBudget(t *testing.T) {
+	node := newTestNode()
+	node.TrustQueryMaxMillis = 500
+	r := httptest.NewRequest("GET", "/", nil)
+
+	cases := []struct {
+		maxMillis int
+		want      time.Duration
+	}{
+		{0, 500 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+		{10000, 500 * time.Millisecond},
+	}
+	for _, c := range cases {
+		ctx, cancel := node.trustQueryContext(r, c.maxMillis)
+		deadline, ok := ctx.Deadline()
+		cancel()
+		if !ok {
+			t.Fatalf("maxMillis=%d: no deadline", c.maxMillis)
+		}
+		if left := time.Until(deadline); left > c.want || left < c.want-100*time.Millisecond {
+			t.Errorf("maxMillis=%d: deadline in %v, want about %v", c.maxMillis, left, c.want)
+		}
+	}
+
+	node.TrustQueryMaxMillis = 0
+	ctx, cancel := node.trustQueryContext(r, 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("no cap and no maxMillis should mean no deadline")
+	}
+}
+
+func TestComputeRelationalTrust_DeadlineTruncates(t *testing.T) {
+	node := newTestNode()
+	node.TrustRegistry["b0d9e70000000001"] = map[string]float64{"b0d9e70000000002": 0.8}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, _, err := node.ComputeRelationalTrust(ctx, "b0d9e70000000001", "b0d9e70000000002", 3)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	w := httptest.NewRecorder()
+	if !markTrustTruncated(w, err) {
+		t.Fatal("deadline should mark the result truncated")
+	}
+	if got := w.Header().Get(trustWarningHeader); got != "time budget exhausted, partial result returned" {
+		t.Errorf("warning header = %q", got)
+	}
+	if markTrustTruncated(httptest.NewRecorder(), nil) {
+		t.Error("nil error should not mark the result truncated")
+	}
+}
+
+func TestGetTrustHandlerMaxMillis(t *testing.T) {
+	node := newTestNode()
+	node.TrustRegistry["b0d9e70000000001"] = map[string]float64{"b0d9e70000000002": 0.8}
+	router := setupTestRouter(node)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/trust/b0d9e70000000001/b0d9e70000000002?maxMillis=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("maxMillis=abc: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/trust/b0d9e70000000001/b0d9e70000000002?domain=test.domain.com&crossDomain=true&maxMillis=1000", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data RelationalTrustResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.TrustLevel != 0.8 || resp.Data.Truncated {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -190,7 +191,7 @@ func TestComputeRelationalTrust_CacheHit(t *testing.T) {
 	node.TrustRegistry[aID] = map[string]float64{bID: 0.8}
 
 	// First call - should compute
-	trust1, path1, err := node.ComputeRelationalTrust(node.NodeID, bID, 5)
+	trust1, path1, err := node.ComputeRelationalTrust(context.Background(), node.NodeID, bID, 5)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -205,7 +206,7 @@ func TestComputeRelationalTrust_CacheHit(t *testing.T) {
 	}
 
 	// Second call - should hit cache
-	trust2, path2, err := node.ComputeRelationalTrust(node.NodeID, bID, 5)
+	trust2, path2, err := node.ComputeRelationalTrust(context.Background(), node.NodeID, bID, 5)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -226,7 +227,7 @@ func TestComputeRelationalTrust_CacheInvalidatedOnTrustUpdate(t *testing.T) {
 	node.TrustRegistry[node.NodeID] = map[string]float64{aID: 0.5}
 
 	// Compute and cache
-	trust1, _, _ := node.ComputeRelationalTrust(node.NodeID, aID, 5)
+	trust1, _, _ := node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 5)
 
 	// Verify cache is populated
 	basic, _ := node.TrustCache.Size()
@@ -249,7 +250,7 @@ func TestComputeRelationalTrust_CacheInvalidatedOnTrustUpdate(t *testing.T) {
 	}
 
 	// Recompute - should get new value
-	trust2, _, _ := node.ComputeRelationalTrust(node.NodeID, aID, 5)
+	trust2, _, _ := node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 5)
 	if trust2 != 0.9 {
 		t.Errorf("Expected trust 0.9 after update, got %f", trust2)
 	}
@@ -276,7 +277,7 @@ func TestComputeRelationalTrustEnhanced_CacheHit(t *testing.T) {
 	}
 
 	// First call
-	result1, err := node.ComputeRelationalTrustEnhanced(node.NodeID, aID, 5, false)
+	result1, err := node.ComputeRelationalTrustEnhanced(context.Background(), node.NodeID, aID, 5, false)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -288,7 +289,7 @@ func TestComputeRelationalTrustEnhanced_CacheHit(t *testing.T) {
 	}
 
 	// Second call - should hit cache
-	result2, err := node.ComputeRelationalTrustEnhanced(node.NodeID, aID, 5, false)
+	result2, err := node.ComputeRelationalTrustEnhanced(context.Background(), node.NodeID, aID, 5, false)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -305,10 +306,10 @@ func TestComputeRelationalTrust_DifferentMaxDepthDifferentCacheKeys(t *testing.T
 	node.TrustRegistry[node.NodeID] = map[string]float64{aID: 0.8}
 
 	// Call with depth 3
-	node.ComputeRelationalTrust(node.NodeID, aID, 3)
+	node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 3)
 
 	// Call with depth 5
-	node.ComputeRelationalTrust(node.NodeID, aID, 5)
+	node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 5)
 
 	// Should have 2 cache entries
 	basic, _ := node.TrustCache.Size()
@@ -327,10 +328,10 @@ func TestComputeRelationalTrustEnhanced_DifferentIncludeUnverifiedDifferentCache
 	}
 
 	// Call with includeUnverified=false
-	node.ComputeRelationalTrustEnhanced(node.NodeID, aID, 5, false)
+	node.ComputeRelationalTrustEnhanced(context.Background(), node.NodeID, aID, 5, false)
 
 	// Call with includeUnverified=true
-	node.ComputeRelationalTrustEnhanced(node.NodeID, aID, 5, true)
+	node.ComputeRelationalTrustEnhanced(context.Background(), node.NodeID, aID, 5, true)
 
 	// Should have 2 cache entries
 	_, enhanced := node.TrustCache.Size()
@@ -343,7 +344,7 @@ func TestComputeRelationalTrust_SelfTrustNotCached(t *testing.T) {
 	node := newTestNode()
 
 	// Self-trust should return immediately without caching
-	trust, path, err := node.ComputeRelationalTrust(node.NodeID, node.NodeID, 5)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), node.NodeID, node.NodeID, 5)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -408,7 +409,7 @@ func BenchmarkComputeRelationalTrust_WithCache(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		node.ComputeRelationalTrust(node.NodeID, target, 5)
+		node.ComputeRelationalTrust(context.Background(), node.NodeID, target, 5)
 	}
 }
 
@@ -434,7 +435,7 @@ func BenchmarkComputeRelationalTrust_WithoutCache(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		node.ComputeRelationalTrust(node.NodeID, target, 5)
+		node.ComputeRelationalTrust(context.Background(), node.NodeID, target, 5)
 	}
 }
 
@@ -462,7 +463,7 @@ func BenchmarkComputeRelationalTrust_RepeatedQueries(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		node.ComputeRelationalTrust(observer, target, 5)
+		node.ComputeRelationalTrust(context.Background(), observer, target, 5)
 	}
 }
 
@@ -489,3 +490,71 @@ func TestLoadConfigTrustCacheTTLDefault(t *testing.T) {
 		t.Errorf("Expected default TrustCacheTTL %v, got %v", config.DefaultTrustCacheTTL, cfg.TrustCacheTTL)
 	}
 }
+
+func TestTrustCache_LRUEviction(t *testing.T) {
+	cache := NewBoundedTrustCache(60*time.Second, 2)
+
+	cache.Set("a", 0.1, nil)
+	cache.Set("b", 0.2, nil)
+	cache.Get("a") // a is now more recent than b
+	cache.Set("c", 0.3, nil)
+
+	if _, _, ok := cache.Get("b"); ok {
+		t.Error("least recently used entry should have been evicted")
+	}
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Error("recently read entry should survive eviction")
+	}
+	cache.SetEnhanced("e", EnhancedTrustResult{})
+	if basic, enhanced := cache.Size(); basic+enhanced != 2 {
+		t.Errorf("bound covers both maps, got %d+%d entries", basic, enhanced)
+	}
+}
+
+func TestTrustCache_InvalidateQuid(t *testing.T) {
+	cache := NewTrustCache(60 * time.Second)
+
+	cache.SetWithDeps("x", 0.5, nil, []string{"q1", "q2"})
+	cache.SetWithDeps("y", 0.5, nil, []string{"q3"})
+	cache.Set("unknown", 0.5, nil)
+	cache.SetEnhanced("enh", EnhancedTrustResult{})
+
+	if n := cache.InvalidateQuid("q2"); n != 3 {
+		t.Errorf("expected x plus the two unknown-dependency entries, dropped %d", n)
+	}
+	if _, _, ok := cache.Get("y"); !ok {
+		t.Error("entry that never read q2 should survive")
+	}
+	if _, _, ok := cache.Get("x"); ok {
+		t.Error("entry that read q2 should be gone")
+	}
+	// Re-setting must not leave stale index records behind.
+	cache.SetWithDeps("y", 0.6, nil, []string{"q4"})
+	if n := cache.InvalidateQuid("q3"); n != 0 {
+		t.Errorf("stale dependency still indexed, dropped %d", n)
+	}
+}
+
+func TestComputeRelationalTrust_TargetedInvalidation(t *testing.T) {
+	node := newTestNode()
+
+	aID := "aaaaaaaaaaaaaaaa"
+	bID := "bbbbbbbbbbbbbbbb"
+	otherID := "cccccccccccccccc"
+	node.TrustRegistry[node.NodeID] = map[string]float64{aID: 0.5}
+	node.TrustRegistry[otherID] = map[string]float64{bID: 0.5}
+
+	node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 3)
+	node.ComputeRelationalTrust(context.Background(), otherID, bID, 3)
+
+	// otherID's subgraph never reaches node.NodeID, so only the
+	// first result should be dropped.
+	node.updateTrustRegistry(TrustTransaction{Truster: node.NodeID, Trustee: aID, TrustLevel: 0.9})
+
+	if _, _, ok := node.TrustCache.Get(makeTrustCacheKey(otherID, bID, 3)); !ok {
+		t.Error("unrelated result should stay cached")
+	}
+	if trust, _, _ := node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 3); trust != 0.9 {
+		t.Errorf("expected recomputed trust 0.9, got %f", trust)
+	}
+}
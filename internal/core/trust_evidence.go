@@ -0,0 +1,129 @@
+// Evidence attached to trust edges.
+//
+// A TRUST transaction may cite what the truster's claim rests on: a
+// hash of a document it reviewed, a URL, or the ID of a credential
+// it checked. The references travel inside the signed transaction,
+// so they are anchored in the block that records the edge and cannot
+// be swapped afterwards; the node only checks their shape, never
+// fetches or interprets them. The latest transaction for an edge
+// determines its evidence, the same way it determines its level.
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// Evidence kinds a TrustEvidence may carry.
+const (
+	EvidenceDocumentHash = "document-hash"
+	EvidenceURL          = "url"
+	EvidenceCredential   = "credential"
+)
+
+// Evidence limits.
+const (
+	MaxTrustEvidence       = 16
+	MaxEvidenceURLLength   = 2048
+	MaxEvidenceValueLength = 256
+	MaxEvidenceLabelLength = 256
+)
+
+// documentHashPattern accepts "sha256:" or "sha512:" followed by a
+// lowercase hex digest of the right length.
+var documentHashPattern = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
+// TrustEvidence is one reference substantiating a trust edge.
+type TrustEvidence struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+// TrustEdgeEvidence is the evidence on record for an edge and the
+// transaction that supplied it.
+type TrustEdgeEvidence struct {
+	Truster  string          `json:"truster"`
+	Trustee  string          `json:"trustee"`
+	TxID     string          `json:"txId"`
+	Evidence []TrustEvidence `json:"evidence"`
+}
+
+// validateTrustEvidence checks the size and format of every
+// reference.
+func validateTrustEvidence(evidence []TrustEvidence) error {
+	if len(evidence) > MaxTrustEvidence {
+		return fmt.Errorf("at most %d evidence references allowed, got %d", MaxTrustEvidence, len(evidence))
+	}
+	for i, ev := range evidence {
+		if ev.Label != "" && !ValidateStringField(ev.Label, MaxEvidenceLabelLength) {
+			return fmt.Errorf("evidence[%d]: invalid label", i)
+		}
+		switch ev.Type {
+		case EvidenceDocumentHash:
+			if !documentHashPattern.MatchString(ev.Value) {
+				return fmt.Errorf("evidence[%d]: document hash must be sha256:<hex> or sha512:<hex>", i)
+			}
+		case EvidenceURL:
+			if len(ev.Value) > MaxEvidenceURLLength {
+				return fmt.Errorf("evidence[%d]: url too long", i)
+			}
+			u, err := url.Parse(ev.Value)
+			if err != nil || u.Host == "" && u.Scheme != "ipfs" {
+				return fmt.Errorf("evidence[%d]: url must be absolute", i)
+			}
+			switch u.Scheme {
+			case "https", "http", "ipfs":
+			default:
+				return fmt.Errorf("evidence[%d]: url scheme %q not allowed", i, u.Scheme)
+			}
+		case EvidenceCredential:
+			if ev.Value == "" || !ValidateStringField(ev.Value, MaxEvidenceValueLength) {
+				return fmt.Errorf("evidence[%d]: invalid credential id", i)
+			}
+		default:
+			return fmt.Errorf("evidence[%d]: unknown type %q", i, ev.Type)
+		}
+	}
+	return nil
+}
+
+// recordTrustEvidence replaces the evidence on tx's edge. Callers
+// hold TrustRegistryMutex.
+func (node *QuidnugNode) recordTrustEvidence(tx TrustTransaction) {
+	if node.TrustEvidenceRegistry == nil {
+		return
+	}
+	if len(tx.Evidence) == 0 {
+		if edges, ok := node.TrustEvidenceRegistry[tx.Truster]; ok {
+			delete(edges, tx.Trustee)
+			if len(edges) == 0 {
+				delete(node.TrustEvidenceRegistry, tx.Truster)
+			}
+		}
+		return
+	}
+	if _, exists := node.TrustEvidenceRegistry[tx.Truster]; !exists {
+		node.TrustEvidenceRegistry[tx.Truster] = make(map[string]TrustEdgeEvidence)
+	}
+	node.TrustEvidenceRegistry[tx.Truster][tx.Trustee] = TrustEdgeEvidence{
+		Truster:  tx.Truster,
+		Trustee:  tx.Trustee,
+		TxID:     tx.ID,
+		Evidence: append([]TrustEvidence(nil), tx.Evidence...),
+	}
+}
+
+// GetTrustEvidence returns the evidence on record for the edge
+// truster -> trustee.
+func (node *QuidnugNode) GetTrustEvidence(truster, trustee string) (TrustEdgeEvidence, bool) {
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+	rec, ok := node.TrustEvidenceRegistry[truster][trustee]
+	if !ok {
+		return TrustEdgeEvidence{}, false
+	}
+	rec.Evidence = append([]TrustEvidence(nil), rec.Evidence...)
+	return rec, true
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDocHash = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestValidateTrustEvidence(t *testing.T) {
+	good := []TrustEvidence{
+		{Type: EvidenceDocumentHash, Value: testDocHash, Label: "signed contract"},
+		{Type: EvidenceURL, Value: "https://example.com/audit.pdf"},
+		{Type: EvidenceURL, Value: "ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"},
+		{Type: EvidenceCredential, Value: "urn:uuid:3978344f-8596-4c3a-a978-8fcaba3903c5"},
+	}
+	if err := validateTrustEvidence(good); err != nil {
+		t.Fatalf("valid evidence rejected: %v", err)
+	}
+
+	bad := map[string]TrustEvidence{
+		"unknown type":      {Type: "photo", Value: "x"},
+		"short hash":        {Type: EvidenceDocumentHash, Value: "sha256:abcd"},
+		"uppercase hash":    {Type: EvidenceDocumentHash, Value: strings.ToUpper(testDocHash)},
+		"relative url":      {Type: EvidenceURL, Value: "/audit.pdf"},
+		"javascript url":    {Type: EvidenceURL, Value: "javascript:alert(1)"},
+		"empty credential":  {Type: EvidenceCredential, Value: ""},
+		"control in label":  {Type: EvidenceCredential, Value: "c1", Label: "a\x00b"},
+		"oversized url":     {Type: EvidenceURL, Value: "https://example.com/" + strings.Repeat("a", MaxEvidenceURLLength)},
+		"oversized cred id": {Type: EvidenceCredential, Value: strings.Repeat("c", MaxEvidenceValueLength+1)},
+	}
+	for name, ev := range bad {
+		if err := validateTrustEvidence([]TrustEvidence{ev}); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	many := make([]TrustEvidence, MaxTrustEvidence+1)
+	for i := range many {
+		many[i] = TrustEvidence{Type: EvidenceCredential, Value: "c"}
+	}
+	if err := validateTrustEvidence(many); err == nil {
+		t.Error("too many references accepted")
+	}
+}
+
+func TestValidateTrustTransaction_Evidence(t *testing.T) {
+	node := newTestNode()
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{Type: TxTypeTrust, TrustDomain: "default", Timestamp: 1000},
+		Truster:         node.NodeID,
+		Trustee:         "1234567890abcdef",
+		TrustLevel:      0.8,
+		Nonce:           1,
+		Evidence:        []TrustEvidence{{Type: EvidenceDocumentHash, Value: testDocHash}},
+	}
+	if !node.ValidateTrustTransaction(signTrustTx(node, tx)) {
+		t.Fatal("transaction with valid evidence rejected")
+	}
+
+	tx.Evidence = []TrustEvidence{{Type: EvidenceURL, Value: "ftp://example.com/x"}}
+	if node.ValidateTrustTransaction(signTrustTx(node, tx)) {
+		t.Fatal("transaction with invalid evidence accepted")
+	}
+}
+
+func TestGetTrustEvidenceHandler(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	truster, trustee := "0123456789abcdef", "fedcba9876543210"
+
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx-1", Type: TxTypeTrust},
+		Truster:         truster,
+		Trustee:         trustee,
+		TrustLevel:      0.9,
+		Nonce:           1,
+		Evidence:        []TrustEvidence{{Type: EvidenceCredential, Value: "cred-7"}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/trust/edges/"+truster+"/"+trustee+"/evidence", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data TrustEdgeEvidence `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.TxID != "tx-1" || len(resp.Data.Evidence) != 1 || resp.Data.Evidence[0].Value != "cred-7" {
+		t.Fatalf("unexpected evidence: %+v", resp.Data)
+	}
+
+	// A later transaction without evidence clears it.
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "tx-2", Type: TxTypeTrust},
+		Truster:         truster,
+		Trustee:         trustee,
+		TrustLevel:      0.5,
+		Nonce:           2,
+	})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("after clearing: status %d, want 404", rec.Code)
+	}
+}
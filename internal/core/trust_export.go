@@ -0,0 +1,304 @@
+// Trust graph export for offline analysis.
+//
+// GET /api/v1/trust/graph/export dumps the trust network in one
+// response so analysts can load it into Gephi, Neo4j, or Graphviz
+// without paging through /registry/trust. Three formats:
+//
+//   - GraphML (application/graphml+xml): Gephi, yEd, Neo4j APOC.
+//   - DOT (text/vnd.graphviz): Graphviz.
+//   - JSON-LD (application/ld+json): linked-data tooling; default.
+//
+// The format comes from ?format= when present, else from Accept.
+// Filters: ?domain=, ?minTrust=, ?verifiedOnly=true.
+package core
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Trust graph export formats.
+const (
+	TrustGraphFormatGraphML = "graphml"
+	TrustGraphFormatDOT     = "dot"
+	TrustGraphFormatJSONLD  = "jsonld"
+)
+
+// trustGraphContentTypes maps each format to its response type.
+var trustGraphContentTypes = map[string]string{
+	TrustGraphFormatGraphML: "application/graphml+xml",
+	TrustGraphFormatDOT:     "text/vnd.graphviz",
+	TrustGraphFormatJSONLD:  "application/ld+json",
+}
+
+// TrustGraphFilter narrows an export.
+type TrustGraphFilter struct {
+	Domain       string
+	MinTrust     float64
+	VerifiedOnly bool
+}
+
+// negotiateTrustGraphFormat picks an export format from an
+// explicit format parameter or, failing that, an Accept header.
+// Returns "" for an unrecognized explicit format.
+func negotiateTrustGraphFormat(format, accept string) string {
+	if format != "" {
+		format = strings.ToLower(format)
+		if format == "json-ld" {
+			format = TrustGraphFormatJSONLD
+		}
+		if _, ok := trustGraphContentTypes[format]; ok {
+			return format
+		}
+		return ""
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		for f, ct := range trustGraphContentTypes {
+			if mediaType == ct {
+				return f
+			}
+		}
+	}
+	return TrustGraphFormatJSONLD
+}
+
+// ExportTrustGraph collects every unexpired edge passing filter,
+// sorted by (truster, trustee). Verified edges come from the trust
+// registry; unverified ones from blocks by untrusted validators.
+// When both exist for a pair the verified edge wins.
+func (node *QuidnugNode) ExportTrustGraph(filter TrustGraphFilter) []TrustEdge {
+	type pair struct{ truster, trustee string }
+	edges := make(map[pair]TrustEdge)
+
+	node.TrustRegistryMutex.RLock()
+	for truster, trustees := range node.TrustRegistry {
+		for trustee, level := range trustees {
+			if !node.isTrustEdgeValidLocked(truster, trustee) {
+				continue
+			}
+			edge := TrustEdge{
+				Truster:    truster,
+				Trustee:    trustee,
+				TrustLevel: level,
+				Verified:   true,
+			}
+			if meta, ok := node.VerifiedTrustEdges[truster][trustee]; ok {
+				edge.SourceBlock = meta.SourceBlock
+				edge.ValidatorQuid = meta.ValidatorQuid
+			}
+			edge.Domain = node.TrustEdgeDomainRegistry[truster][trustee]
+			edge.Timestamp = node.TrustEdgeTimestampRegistry[truster][trustee]
+			edges[pair{truster, trustee}] = edge
+		}
+	}
+	node.TrustRegistryMutex.RUnlock()
+
+	if !filter.VerifiedOnly {
+		var unverified []TrustEdge
+		node.UnverifiedRegistryMutex.RLock()
+		for _, trustees := range node.UnverifiedTrustRegistry {
+			for _, edge := range trustees {
+				unverified = append(unverified, edge)
+			}
+		}
+		node.UnverifiedRegistryMutex.RUnlock()
+		for _, edge := range unverified {
+			key := pair{edge.Truster, edge.Trustee}
+			if _, hasVerified := edges[key]; hasVerified {
+				continue
+			}
+			if !node.IsTrustEdgeValid(edge.Truster, edge.Trustee) {
+				continue
+			}
+			edges[key] = edge
+		}
+	}
+
+	out := make([]TrustEdge, 0, len(edges))
+	for _, edge := range edges {
+		if filter.Domain != "" && edge.Domain != filter.Domain {
+			continue
+		}
+		if edge.TrustLevel < filter.MinTrust {
+			continue
+		}
+		out = append(out, edge)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Truster != out[j].Truster {
+			return out[i].Truster < out[j].Truster
+		}
+		return out[i].Trustee < out[j].Trustee
+	})
+	return out
+}
+
+// trustGraphNodes returns the distinct quids touched by edges.
+func trustGraphNodes(edges []TrustEdge) []string {
+	seen := make(map[string]struct{})
+	for _, e := range edges {
+		seen[e.Truster] = struct{}{}
+		seen[e.Trustee] = struct{}{}
+	}
+	nodes := make([]string, 0, len(seen))
+	for q := range seen {
+		nodes = append(nodes, q)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// writeTrustGraph encodes edges in format to w.
+func writeTrustGraph(w io.Writer, format string, edges []TrustEdge) error {
+	switch format {
+	case TrustGraphFormatGraphML:
+		return writeTrustGraphML(w, edges)
+	case TrustGraphFormatDOT:
+		return writeTrustGraphDOT(w, edges)
+	case TrustGraphFormatJSONLD:
+		return writeTrustGraphJSONLD(w, edges)
+	default:
+		return fmt.Errorf("unsupported trust graph format %q", format)
+	}
+}
+
+// GraphML document types. Only the subset needed for a directed,
+// attributed graph.
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeTrustGraphML(w io.Writer, edges []TrustEdge) error {
+	doc := graphMLDoc{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "trustLevel", For: "edge", AttrName: "trustLevel", AttrType: "double"},
+			{ID: "verified", For: "edge", AttrName: "verified", AttrType: "boolean"},
+			{ID: "domain", For: "edge", AttrName: "domain", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "trust", EdgeDefault: "directed"},
+	}
+	for _, q := range trustGraphNodes(edges) {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: q})
+	}
+	for _, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.Truster,
+			Target: e.Trustee,
+			Data: []graphMLData{
+				{Key: "trustLevel", Value: strconv.FormatFloat(e.TrustLevel, 'f', -1, 64)},
+				{Key: "verified", Value: strconv.FormatBool(e.Verified)},
+				{Key: "domain", Value: e.Domain},
+			},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+func writeTrustGraphDOT(w io.Writer, edges []TrustEdge) error {
+	var b strings.Builder
+	b.WriteString("digraph trust {\n")
+	for _, q := range trustGraphNodes(edges) {
+		fmt.Fprintf(&b, "  %s;\n", strconv.Quote(q))
+	}
+	for _, e := range edges {
+		style := "solid"
+		if !e.Verified {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [weight=%s, label=%s, style=%s, domain=%s];\n",
+			strconv.Quote(e.Truster),
+			strconv.Quote(e.Trustee),
+			strconv.FormatFloat(e.TrustLevel, 'f', -1, 64),
+			strconv.Quote(strconv.FormatFloat(e.TrustLevel, 'f', 2, 64)),
+			style,
+			strconv.Quote(e.Domain))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// trustGraphJSONLDContext maps the export's terms onto IRIs.
+// Quids are expressed as urn:quid: IRIs.
+var trustGraphJSONLDContext = map[string]interface{}{
+	"@vocab":     "https://quidnug.io/ns/trust#",
+	"Quid":       "Quid",
+	"TrustEdge":  "TrustEdge",
+	"truster":    map[string]string{"@type": "@id"},
+	"trustee":    map[string]string{"@type": "@id"},
+	"trustLevel": map[string]string{"@type": "http://www.w3.org/2001/XMLSchema#double"},
+	"verified":   map[string]string{"@type": "http://www.w3.org/2001/XMLSchema#boolean"},
+}
+
+func quidIRI(q string) string { return "urn:quid:" + q }
+
+func writeTrustGraphJSONLD(w io.Writer, edges []TrustEdge) error {
+	graph := make([]map[string]interface{}, 0)
+	for _, q := range trustGraphNodes(edges) {
+		graph = append(graph, map[string]interface{}{
+			"@id":   quidIRI(q),
+			"@type": "Quid",
+		})
+	}
+	for _, e := range edges {
+		entry := map[string]interface{}{
+			"@type":      "TrustEdge",
+			"truster":    quidIRI(e.Truster),
+			"trustee":    quidIRI(e.Trustee),
+			"trustLevel": e.TrustLevel,
+			"verified":   e.Verified,
+		}
+		if e.Domain != "" {
+			entry["domain"] = e.Domain
+		}
+		graph = append(graph, entry)
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"@context": trustGraphJSONLDContext,
+		"@graph":   graph,
+	})
+}
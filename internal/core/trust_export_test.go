@@ -0,0 +1,150 @@
+// Trust graph export tests: filters, format negotiation, and the
+// shape of each encoder's output.
+package core
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// seedExportGraph gives node two verified edges in different
+// domains and one unverified edge.
+func seedExportGraph(node *QuidnugNode) {
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "test.domain.com"},
+		Truster:         "aaaa000000000001",
+		Trustee:         "aaaa000000000002",
+		TrustLevel:      0.9,
+	})
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "other.domain.com"},
+		Truster:         "aaaa000000000002",
+		Trustee:         "aaaa000000000003",
+		TrustLevel:      0.3,
+	})
+	node.AddUnverifiedTrustEdge(TrustEdge{
+		Truster:    "aaaa000000000003",
+		Trustee:    "aaaa000000000004",
+		TrustLevel: 0.7,
+		Domain:     "test.domain.com",
+	})
+}
+
+func exportedPairs(edges []TrustEdge) []string {
+	var out []string
+	for _, e := range edges {
+		if strings.HasPrefix(e.Truster, "aaaa") {
+			out = append(out, e.Truster[12:]+">"+e.Trustee[12:])
+		}
+	}
+	return out
+}
+
+func TestExportTrustGraph_Filters(t *testing.T) {
+	node := newTestNode()
+	seedExportGraph(node)
+
+	cases := []struct {
+		name   string
+		filter TrustGraphFilter
+		want   string
+	}{
+		{"all", TrustGraphFilter{}, "0001>0002,0002>0003,0003>0004"},
+		{"domain", TrustGraphFilter{Domain: "test.domain.com"}, "0001>0002,0003>0004"},
+		{"minTrust", TrustGraphFilter{MinTrust: 0.5}, "0001>0002,0003>0004"},
+		{"verifiedOnly", TrustGraphFilter{VerifiedOnly: true}, "0001>0002,0002>0003"},
+	}
+	for _, tc := range cases {
+		got := strings.Join(exportedPairs(node.ExportTrustGraph(tc.filter)), ",")
+		if got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNegotiateTrustGraphFormat(t *testing.T) {
+	cases := []struct{ format, accept, want string }{
+		{"", "", TrustGraphFormatJSONLD},
+		{"GraphML", "", TrustGraphFormatGraphML},
+		{"json-ld", "", TrustGraphFormatJSONLD},
+		{"", "text/html, text/vnd.graphviz;q=0.9", TrustGraphFormatDOT},
+		{"dot", "application/graphml+xml", TrustGraphFormatDOT},
+		{"csv", "", ""},
+	}
+	for _, tc := range cases {
+		if got := negotiateTrustGraphFormat(tc.format, tc.accept); got != tc.want {
+			t.Errorf("format=%q accept=%q: got %q, want %q", tc.format, tc.accept, got, tc.want)
+		}
+	}
+}
+
+func TestExportTrustGraphHandler_Formats(t *testing.T) {
+	node := newTestNode()
+	seedExportGraph(node)
+	router := setupTestRouter(node)
+
+	get := func(query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/trust/graph/export"+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("?format=graphml&verifiedOnly=true", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/graphml+xml" {
+		t.Fatalf("graphml: code=%d type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var doc graphMLDoc
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("graphml does not parse: %v", err)
+	}
+	if doc.Graph.EdgeDefault != "directed" || len(doc.Graph.Edges) == 0 {
+		t.Errorf("unexpected graphml graph: %+v", doc.Graph)
+	}
+
+	w = get("", "text/vnd.graphviz")
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "digraph trust {") ||
+		!strings.Contains(body, `"aaaa000000000003" -> "aaaa000000000004"`) ||
+		!strings.Contains(body, "style=dashed") {
+		t.Errorf("unexpected DOT output:\n%s", body)
+	}
+
+	w = get("?domain=other.domain.com", "")
+	if w.Header().Get("Content-Type") != "application/ld+json" {
+		t.Fatalf("jsonld content type = %q", w.Header().Get("Content-Type"))
+	}
+	var ld struct {
+		Context map[string]interface{}   `json:"@context"`
+		Graph   []map[string]interface{} `json:"@graph"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &ld); err != nil {
+		t.Fatalf("jsonld: %v", err)
+	}
+	edges := 0
+	for _, item := range ld.Graph {
+		if item["@type"] == "TrustEdge" {
+			edges++
+			if item["truster"] != "urn:quid:aaaa000000000002" {
+				t.Errorf("unexpected edge %v", item)
+			}
+		}
+	}
+	if edges != 1 {
+		t.Errorf("expected 1 edge in other.domain.com, got %d", edges)
+	}
+
+	if w = get("?format=csv", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format should 400, got %d", w.Code)
+	}
+	if w = get("?minTrust=2", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("out-of-range minTrust should 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,92 @@
+// Optional external trust graph store.
+//
+// With graph_store_backend=neo4j the node keeps its in-memory
+// TrustRegistry (validation and every other reader still use it)
+// and also mirrors each edge write into a graphstore.Store.
+// ComputeRelationalTrust then asks the store for the best path
+// before falling back to the in-memory BFS, so a store outage
+// degrades to the default behavior instead of failing queries.
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/graphstore"
+)
+
+// DefaultGraphStoreTimeout bounds each call to an external store.
+const DefaultGraphStoreTimeout = 5 * time.Second
+
+// newTrustGraphStore builds the configured store. A nil store
+// with a nil error means the in-memory default.
+func newTrustGraphStore(cfg *config.Config) (graphstore.Store, error) {
+	switch cfg.GraphStoreBackend {
+	case "", graphstore.BackendMemory:
+		return nil, nil
+	case graphstore.BackendNeo4j:
+		if cfg.Neo4jURL == "" {
+			return nil, fmt.Errorf("graph store backend %q requires neo4j_url", cfg.GraphStoreBackend)
+		}
+		return graphstore.NewNeo4jStore(cfg.Neo4jURL, cfg.Neo4jDatabase, cfg.Neo4jUsername, cfg.Neo4jPassword,
+			&http.Client{Timeout: DefaultGraphStoreTimeout}), nil
+	default:
+		return nil, fmt.Errorf("unknown graph store backend %q", cfg.GraphStoreBackend)
+	}
+}
+
+// mirrorTrustEdge copies a committed TRUST edge into the external
+// store. Failures are logged; the in-memory registry stays
+// authoritative.
+func (node *QuidnugNode) mirrorTrustEdge(tx TrustTransaction) {
+	if node.TrustGraphStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultGraphStoreTimeout)
+	defer cancel()
+	err := node.TrustGraphStore.UpsertEdge(ctx, graphstore.Edge{
+		Truster:    tx.Truster,
+		Trustee:    tx.Trustee,
+		Level:      tx.TrustLevel,
+		ValidUntil: tx.ValidUntil,
+		Domain:     tx.TrustDomain,
+	})
+	if err != nil {
+		logger.Warn("Failed to mirror trust edge to graph store",
+			"truster", tx.Truster, "trustee", tx.Trustee, "error", err)
+	}
+}
+
+// unmirrorTrustEdge removes an edge from the external store.
+func (node *QuidnugNode) unmirrorTrustEdge(truster, trustee string) {
+	if node.TrustGraphStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultGraphStoreTimeout)
+	defer cancel()
+	if err := node.TrustGraphStore.RemoveEdge(ctx, truster, trustee); err != nil {
+		logger.Warn("Failed to remove trust edge from graph store",
+			"truster", truster, "trustee", trustee, "error", err)
+	}
+}
+
+// bestPathFromStore asks the external store for the best path.
+// ok is false when no store is configured or the store errored,
+// in which case the caller should run the in-memory search.
+func (node *QuidnugNode) bestPathFromStore(ctx context.Context, observer, target string, maxDepth int) (float64, []string, bool) {
+	if node.TrustGraphStore == nil {
+		return 0, nil, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultGraphStoreTimeout)
+	defer cancel()
+	trust, path, err := node.TrustGraphStore.BestPath(ctx, observer, target, maxDepth, nowUnix())
+	if err != nil {
+		logger.Warn("Graph store trust query failed; falling back to in-memory search",
+			"observer", observer, "target", target, "error", err)
+		return 0, nil, false
+	}
+	return trust, path, true
+}
@@ -0,0 +1,91 @@
+// External graph store tests: edge mirroring, path delegation, and
+// fallback to the in-memory search when the store fails.
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/graphstore"
+)
+
+// failingStore errors on every query so fallback can be observed.
+type failingStore struct{ *graphstore.MemoryStore }
+
+func (failingStore) BestPath(context.Context, string, string, int, int64) (float64, []string, error) {
+	return 0, nil, graphstore.ErrUnavailable
+}
+
+func TestNewTrustGraphStore(t *testing.T) {
+	if s, err := newTrustGraphStore(&config.Config{}); s != nil || err != nil {
+		t.Errorf("empty backend should mean in-memory, got %v, %v", s, err)
+	}
+	if _, err := newTrustGraphStore(&config.Config{GraphStoreBackend: "neo4j"}); err == nil {
+		t.Error("neo4j without a URL should be rejected")
+	}
+	if _, err := newTrustGraphStore(&config.Config{GraphStoreBackend: "dgraph"}); err == nil {
+		t.Error("unknown backend should be rejected")
+	}
+	s, err := newTrustGraphStore(&config.Config{GraphStoreBackend: "neo4j", Neo4jURL: "http://localhost:7474"})
+	if err != nil || s == nil {
+		t.Errorf("neo4j store should build, got %v, %v", s, err)
+	}
+}
+
+func TestTrustGraphStore_MirrorsCommittedEdges(t *testing.T) {
+	node := newTestNode()
+	store := graphstore.NewMemoryStore()
+	node.TrustGraphStore = store
+
+	node.processBlockTransactions(Block{
+		Index: 1,
+		Transactions: []interface{}{TrustTransaction{
+			BaseTransaction: BaseTransaction{Type: TxTypeTrust, TrustDomain: "test.domain.com"},
+			Truster:         "1111000000000001",
+			Trustee:         "1111000000000002",
+			TrustLevel:      0.6,
+		}},
+		TrustProof: TrustProof{TrustDomain: "test.domain.com"},
+	})
+	out, _ := store.Trustees(context.Background(), "1111000000000001", 0)
+	if out["1111000000000002"] != 0.6 {
+		t.Fatalf("edge not mirrored: %v", out)
+	}
+
+	node.DemoteTrustEdge("1111000000000001", "1111000000000002")
+	out, _ = store.Trustees(context.Background(), "1111000000000001", 0)
+	if len(out) != 0 {
+		t.Errorf("demoted edge should leave the store: %v", out)
+	}
+}
+
+func TestComputeRelationalTrust_DelegatesToStore(t *testing.T) {
+	node := newTestNode()
+	node.TrustCache = nil
+	store := graphstore.NewMemoryStore()
+	node.TrustGraphStore = store
+
+	// Edge exists only in the store, so a result proves delegation.
+	store.UpsertEdge(context.Background(), graphstore.Edge{Truster: "2222000000000001", Trustee: "2222000000000002", Level: 0.4})
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "2222000000000001", "2222000000000002", 3)
+	if err != nil || trust != 0.4 || len(path) != 2 {
+		t.Fatalf("expected store result, got %v %v %v", trust, path, err)
+	}
+}
+
+func TestComputeRelationalTrust_FallsBackWhenStoreFails(t *testing.T) {
+	node := newTestNode()
+	node.TrustCache = nil
+	node.TrustGraphStore = failingStore{graphstore.NewMemoryStore()}
+	node.updateTrustRegistry(TrustTransaction{
+		Truster:    "3333000000000001",
+		Trustee:    "3333000000000002",
+		TrustLevel: 0.7,
+	})
+
+	trust, _, err := node.ComputeRelationalTrust(context.Background(), "3333000000000001", "3333000000000002", 3)
+	if err != nil || trust != 0.7 {
+		t.Fatalf("expected in-memory fallback result 0.7, got %v (%v)", trust, err)
+	}
+}
@@ -0,0 +1,351 @@
+// Incremental relational trust from the node's own quid.
+//
+// With precomputation on (trust_precompute.go), every edge update
+// still drops the cached hot results that read the truster's edges,
+// and refilling each one used to mean a fresh BFS. For each maxDepth
+// in the hot set the node now keeps a trustTracker instead: the
+// hop-bounded best-path table from its own quid, i.e. Bellman-Ford
+// over -log(trust) edge weights, held as trust products. An edge
+// update marks its truster; the next lookup re-reads that truster's
+// edges and relaxes only the entries downstream of it, layer by
+// layer, and the cache is refilled from the table.
+//
+// The table is exact: its answer is the best path within maxDepth.
+// The BFS keeps only the first path it reaches each intermediate
+// quid by, so for the node's own quid the tracked answer can be
+// higher than an untracked one, never lower.
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// trustHop is the best trust reaching a quid in at most k hops. via
+// is the previous quid on that path, or "" when the path is the one
+// already found within k-1 hops.
+type trustHop struct {
+	trust float64
+	via   string
+}
+
+// trustTracker is the best-path table from observer to every quid
+// within maxDepth hops. Not safe for concurrent use; trustTrackers
+// serializes access.
+type trustTracker struct {
+	observer string
+	maxDepth int
+	// layers[k][q] is the best trust reaching q in at most k hops.
+	layers []map[string]trustHop
+	// out holds the edges read so far, by truster, and in is its
+	// reverse. Only quids reached within maxDepth-1 hops are read.
+	out map[string]map[string]float64
+	in  map[string]map[string]struct{}
+	// expiresAt is the earliest expiry among the edges read; the
+	// table is rebuilt once it passes. 0 means none expire.
+	expiresAt int64
+}
+
+// newTrustTracker builds the table from view.
+func newTrustTracker(ctx context.Context, view *trustView, observer string, maxDepth int) (*trustTracker, error) {
+	t := &trustTracker{
+		observer: observer,
+		maxDepth: maxDepth,
+		layers:   make([]map[string]trustHop, maxDepth+1),
+		out:      make(map[string]map[string]float64),
+		in:       make(map[string]map[string]struct{}),
+	}
+	for k := range t.layers {
+		t.layers[k] = make(map[string]trustHop)
+	}
+	t.layers[0][observer] = trustHop{trust: 1.0}
+	now := nowUnix()
+	t.read(view, observer, now)
+
+	changed := map[string]struct{}{observer: {}}
+	for k := 1; k <= maxDepth; k++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		if changed, err = t.relax(view, k, changed, nil, now); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// read loads quid's unexpired edges from view into out and in.
+func (t *trustTracker) read(view *trustView, quid string, now int64) {
+	shard := view.shards[trustShardOf(quid)]
+	exp := shard.expiry[quid]
+	edges := make(map[string]float64, len(shard.edges[quid]))
+	for trustee, level := range shard.edges[quid] {
+		if until := exp[trustee]; until != 0 {
+			if until <= now {
+				continue
+			}
+			if t.expiresAt == 0 || until < t.expiresAt {
+				t.expiresAt = until
+			}
+		}
+		edges[trustee] = level
+		if t.in[trustee] == nil {
+			t.in[trustee] = make(map[string]struct{})
+		}
+		t.in[trustee][quid] = struct{}{}
+	}
+	t.out[quid] = edges
+}
+
+// unread drops quid's edges from out and in, returning them.
+func (t *trustTracker) unread(quid string) map[string]float64 {
+	edges := t.out[quid]
+	for trustee := range edges {
+		delete(t.in[trustee], quid)
+		if len(t.in[trustee]) == 0 {
+			delete(t.in, trustee)
+		}
+	}
+	delete(t.out, quid)
+	return edges
+}
+
+// relax recomputes layer k for the quids that layer k-1 changes in
+// prev can reach, plus extra, reading the edges of quids that newly
+// enter a layer below maxDepth. It returns the quids whose layer k
+// entry changed.
+func (t *trustTracker) relax(view *trustView, k int, prev, extra map[string]struct{}, now int64) (map[string]struct{}, error) {
+	cands := make(map[string]struct{}, len(extra))
+	for q := range extra {
+		cands[q] = struct{}{}
+	}
+	for q := range prev {
+		cands[q] = struct{}{}
+		for trustee := range t.out[q] {
+			cands[trustee] = struct{}{}
+		}
+	}
+
+	changed := make(map[string]struct{})
+	below, layer := t.layers[k-1], t.layers[k]
+	for v := range cands {
+		// Carried over first, so a tie keeps the shorter path and a
+		// cycle (which can only tie, trust being at most 1) is
+		// never taken.
+		best := trustHop{trust: below[v].trust}
+		for p := range t.in[v] {
+			if h, ok := below[p]; ok {
+				if trust := h.trust * t.out[p][v]; trust > best.trust {
+					best = trustHop{trust: trust, via: p}
+				}
+			}
+		}
+		old, had := layer[v]
+		switch {
+		case best.trust <= 0 && had:
+			delete(layer, v)
+		case best.trust <= 0:
+			continue
+		case had && old == best:
+			continue
+		default:
+			layer[v] = best
+		}
+		changed[v] = struct{}{}
+		if k < t.maxDepth {
+			if _, read := t.out[v]; !read {
+				t.read(view, v, now)
+				if len(t.out) > MaxTrustVisitedSize {
+					return nil, ErrTrustGraphTooLarge
+				}
+			}
+		}
+	}
+	return changed, nil
+}
+
+// update re-reads the edges of the given trusters from view and
+// repairs the entries downstream of them.
+func (t *trustTracker) update(view *trustView, trusters map[string]struct{}) error {
+	now := nowUnix()
+	// Trustees whose best in-edge may have changed. They are
+	// rechecked at every layer: whether a truster's edges count at
+	// layer k depends on where the truster itself ends up.
+	touched := make(map[string]struct{})
+	for u := range trusters {
+		if _, read := t.out[u]; !read {
+			// Never reached, so nothing in the table used its edges.
+			continue
+		}
+		for trustee := range t.unread(u) {
+			touched[trustee] = struct{}{}
+		}
+		t.read(view, u, now)
+		for trustee := range t.out[u] {
+			touched[trustee] = struct{}{}
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	var changed map[string]struct{}
+	for k := 1; k <= t.maxDepth; k++ {
+		var err error
+		if changed, err = t.relax(view, k, changed, touched, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// best returns the trust and path to target, or 0 and nil when
+// target is out of reach.
+func (t *trustTracker) best(target string) (float64, []string) {
+	h, ok := t.layers[t.maxDepth][target]
+	if !ok {
+		return 0, nil
+	}
+	path := []string{target}
+	q := target
+	for k := t.maxDepth; k > 0; k-- {
+		if via := t.layers[k][q].via; via != "" {
+			path = append(path, via)
+			q = via
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return h.trust, path
+}
+
+// reached lists the quids whose edges the table read, for cache
+// dependencies.
+func (t *trustTracker) reached() []string {
+	out := make([]string, 0, len(t.out))
+	for q := range t.out {
+		out = append(out, q)
+	}
+	return out
+}
+
+// trustTrackers holds the node's trackers, one per tracked maxDepth.
+// dirtyMu guards dirty and, like trustViewState.dirtyMu, is never
+// held while taking another lock, so edge writers can mark trusters
+// under TrustRegistryMutex.
+type trustTrackers struct {
+	mu      sync.Mutex
+	byDepth map[int]*trustTracker
+
+	dirtyMu sync.Mutex
+	dirty   map[string]struct{}
+}
+
+// markTrackedTruster records that truster's edges changed.
+func (node *QuidnugNode) markTrackedTruster(truster string) {
+	s := &node.trustTrackers
+	s.dirtyMu.Lock()
+	if s.dirty == nil {
+		s.dirty = make(map[string]struct{})
+	}
+	s.dirty[truster] = struct{}{}
+	s.dirtyMu.Unlock()
+}
+
+// trackTrustDepths keeps a tracker for each of depths and drops the
+// rest. A depth whose neighbourhood exceeds the search limits is
+// left untracked until the next call.
+func (node *QuidnugNode) trackTrustDepths(ctx context.Context, depths map[int]bool) {
+	s := &node.trustTrackers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for d := range s.byDepth {
+		if !depths[d] {
+			delete(s.byDepth, d)
+		}
+	}
+	view := node.syncTrustTrackersLocked()
+	for d := range depths {
+		if s.byDepth[d] != nil {
+			continue
+		}
+		t, err := newTrustTracker(ctx, view, node.NodeID, d)
+		if err != nil {
+			logger.Debug("Trust tracker not built", "maxDepth", d, "error", err)
+			continue
+		}
+		if s.byDepth == nil {
+			s.byDepth = make(map[int]*trustTracker)
+		}
+		s.byDepth[d] = t
+	}
+}
+
+// syncTrustTrackersLocked applies pending edge changes to every
+// tracker and returns the view they now reflect. Caller holds
+// trustTrackers.mu.
+func (node *QuidnugNode) syncTrustTrackersLocked() *trustView {
+	s := &node.trustTrackers
+	s.dirtyMu.Lock()
+	dirty := s.dirty
+	s.dirty = nil
+	s.dirtyMu.Unlock()
+
+	view := node.currentTrustView()
+	if len(s.byDepth) == 0 {
+		return view
+	}
+	now := nowUnix()
+	for d, t := range s.byDepth {
+		var err error
+		if t.expiresAt != 0 && t.expiresAt <= now {
+			t, err = newTrustTracker(context.Background(), view, node.NodeID, d)
+		} else if len(dirty) > 0 {
+			err = t.update(view, dirty)
+		}
+		if err != nil {
+			logger.Debug("Trust tracker dropped", "maxDepth", d, "error", err)
+			delete(s.byDepth, d)
+			continue
+		}
+		s.byDepth[d] = t
+	}
+	// A block still being applied keeps readers on the older view;
+	// revisit these trusters once it is published.
+	if len(dirty) > 0 && !node.trustViewCurrent(view) {
+		s.dirtyMu.Lock()
+		if s.dirty == nil {
+			s.dirty = make(map[string]struct{})
+		}
+		for q := range dirty {
+			s.dirty[q] = struct{}{}
+		}
+		s.dirtyMu.Unlock()
+	}
+	return view
+}
+
+// trackedTrust answers observer→target from a tracker when one
+// covers the query, returning the quids the answer depends on and
+// the view it reflects. ok is false when no tracker applies.
+func (node *QuidnugNode) trackedTrust(observer, target string, maxDepth int) (trust float64, path, deps []string, view *trustView, ok bool) {
+	if node.TrustPrecompute == nil || observer != node.NodeID || node.TrustGraphStore != nil {
+		return 0, nil, nil, nil, false
+	}
+	s := &node.trustTrackers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byDepth[maxDepth] == nil {
+		return 0, nil, nil, nil, false
+	}
+	view = node.syncTrustTrackersLocked()
+	t := s.byDepth[maxDepth]
+	if t == nil {
+		return 0, nil, nil, nil, false
+	}
+	trust, path = t.best(target)
+	return trust, path, t.reached(), view, true
+}
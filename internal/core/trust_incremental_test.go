@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// bruteForceTrust is the best product over simple paths of at most
+// maxDepth hops, by exhaustive search.
+func bruteForceTrust(edges map[string]map[string]float64, observer, target string, maxDepth int) float64 {
+	best := 0.0
+	onPath := map[string]bool{observer: true}
+	var walk func(q string, trust float64, depth int)
+	walk = func(q string, trust float64, depth int) {
+		if depth == maxDepth {
+			return
+		}
+		for next, level := range edges[q] {
+			if onPath[next] {
+				continue
+			}
+			t := trust * level
+			if next == target {
+				if t > best {
+					best = t
+				}
+				continue
+			}
+			onPath[next] = true
+			walk(next, t, depth+1)
+			onPath[next] = false
+		}
+	}
+	walk(observer, 1.0, 0)
+	return best
+}
+
+func pathTrust(edges map[string]map[string]float64, path []string) float64 {
+	trust := 1.0
+	for i := 1; i < len(path); i++ {
+		trust *= edges[path[i-1]][path[i]]
+	}
+	return trust
+}
+
+func TestTrustTracker_MatchesExhaustiveSearchUnderUpdates(t *testing.T) {
+	node := newTestNode()
+	node.TrustPrecompute = NewTrustPrecompute(4)
+	rng := rand.New(rand.NewSource(7))
+	quids := []string{node.NodeID}
+	for i := 1; i < 24; i++ {
+		quids = append(quids, fmt.Sprintf("1c0000000000%04x", i))
+	}
+	setEdge := func(truster, trustee string, level float64) {
+		node.updateTrustRegistry(TrustTransaction{Truster: truster, Trustee: trustee, TrustLevel: level})
+	}
+	for i := 0; i < 60; i++ {
+		a, b := quids[rng.Intn(len(quids))], quids[rng.Intn(len(quids))]
+		if a != b {
+			setEdge(a, b, float64(1+rng.Intn(10))/10)
+		}
+	}
+
+	const depth = 4
+	node.trackTrustDepths(context.Background(), map[int]bool{depth: true})
+	check := func(round int) {
+		t.Helper()
+		for _, target := range quids[1:] {
+			trust, path, _, _, ok := node.trackedTrust(node.NodeID, target, depth)
+			if !ok {
+				t.Fatalf("round %d: no tracker", round)
+			}
+			want := bruteForceTrust(node.TrustRegistry, node.NodeID, target, depth)
+			if !floatEquals(trust, want, 1e-9) {
+				t.Fatalf("round %d: trust to %s = %v, want %v", round, target, trust, want)
+			}
+			if want > 0 {
+				if len(path) < 2 || len(path)-1 > depth || path[0] != node.NodeID || path[len(path)-1] != target {
+					t.Fatalf("round %d: bad path %v", round, path)
+				}
+				if !floatEquals(pathTrust(node.TrustRegistry, path), trust, 1e-9) {
+					t.Fatalf("round %d: path %v does not carry trust %v", round, path, trust)
+				}
+			}
+		}
+	}
+	check(0)
+
+	for round := 1; round <= 40; round++ {
+		for i := 0; i < 3; i++ {
+			a, b := quids[rng.Intn(len(quids))], quids[rng.Intn(len(quids))]
+			if a == b {
+				continue
+			}
+			level := float64(rng.Intn(11)) / 10 // 0 drops the edge's contribution
+			setEdge(a, b, level)
+		}
+		check(round)
+	}
+}
+
+func TestTrustTracker_BeatsFirstFoundPath(t *testing.T) {
+	node := newTestNode()
+	node.TrustPrecompute = NewTrustPrecompute(4)
+	b, c, target := "1c00000000000b0b", "1c00000000000c0c", "1c0000000000feed"
+	node.TrustRegistry[node.NodeID] = map[string]float64{b: 0.1, c: 0.9}
+	node.TrustRegistry[c] = map[string]float64{b: 0.9}
+	node.TrustRegistry[b] = map[string]float64{target: 1.0}
+	node.invalidateTrustView()
+
+	node.trackTrustDepths(context.Background(), map[int]bool{3: true})
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), node.NodeID, target, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatEquals(trust, 0.81, 1e-9) || len(path) != 4 || path[1] != c {
+		t.Errorf("got %v via %v, want 0.81 via %s", trust, path, c)
+	}
+}
+
+func TestPrecomputeHotTrust_RefillsFromTracker(t *testing.T) {
+	node := newTestNode()
+	node.TrustPrecompute = NewTrustPrecompute(4)
+	a, b := "1c000000000000aa", "1c000000000000bb"
+	node.TrustRegistry[node.NodeID] = map[string]float64{a: 0.5}
+	node.TrustRegistry[a] = map[string]float64{b: 0.5}
+	node.invalidateTrustView()
+	node.ComputeRelationalTrust(context.Background(), node.NodeID, b, 3)
+
+	node.precomputeHotTrust(context.Background())
+	if node.trustTrackers.byDepth[3] == nil {
+		t.Fatal("expected a tracker for the hot depth")
+	}
+
+	node.updateTrustRegistry(TrustTransaction{Truster: a, Trustee: b, TrustLevel: 0.8})
+	if _, _, ok := node.TrustCache.Get(makeTrustCacheKey(node.NodeID, b, 3)); ok {
+		t.Fatal("edge update should drop the cached result")
+	}
+	if n := node.precomputeHotTrust(context.Background()); n != 1 {
+		t.Fatalf("expected one refreshed target, got %d", n)
+	}
+	trust, path, ok := node.TrustCache.Get(makeTrustCacheKey(node.NodeID, b, 3))
+	if !ok || !floatEquals(trust, 0.4, 1e-9) || len(path) != 3 {
+		t.Errorf("expected warm entry 0.4 over 2 hops, got %v %v (cached=%v)", trust, path, ok)
+	}
+
+	// Dropping the depth from the hot set drops its tracker.
+	node.trackTrustDepths(context.Background(), map[int]bool{})
+	if _, _, _, _, ok := node.trackedTrust(node.NodeID, b, 3); ok {
+		t.Error("tracker should be gone")
+	}
+}
@@ -0,0 +1,99 @@
+// Private trust overlays.
+//
+// A client that keeps some of its relationships off chain can still
+// get trust answers that reflect them: a POST /trust/query may carry
+// an overlay, the observer's own direct edges signed with the
+// observer's key. For that one computation the overlay replaces the
+// observer's on-chain edges to the trustees it names, and a level of
+// 0 hides an on-chain edge. Nothing in an overlay is stored, cached
+// or gossiped.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Overlay bounds.
+const (
+	// MaxTrustOverlayEdges caps the edges one overlay may carry.
+	MaxTrustOverlayEdges = 256
+	// TrustOverlayMaxSkew is how far an overlay's timestamp may be
+	// from the node's clock, which bounds replay of a captured one.
+	TrustOverlayMaxSkew = 5 * time.Minute
+)
+
+// Overlay errors.
+var (
+	ErrOverlayInvalid   = errors.New("trust overlay: invalid")
+	ErrOverlaySignature = errors.New("trust overlay: signature does not verify")
+)
+
+// OverlayEdge is one direct edge from the observer.
+type OverlayEdge struct {
+	Trustee    string  `json:"trustee"`
+	TrustLevel float64 `json:"trustLevel"`
+}
+
+// TrustOverlay is a set of the observer's direct edges, signed by
+// the observer's current key over the overlay with Signature empty.
+type TrustOverlay struct {
+	Edges     []OverlayEdge `json:"edges"`
+	Timestamp int64         `json:"timestamp"`
+	Signature string        `json:"signature"`
+}
+
+// verifyTrustOverlay checks o against observer's key and returns its
+// edges as a trustee→level map.
+func (node *QuidnugNode) verifyTrustOverlay(observer string, o TrustOverlay) (map[string]float64, error) {
+	if len(o.Edges) == 0 || len(o.Edges) > MaxTrustOverlayEdges {
+		return nil, fmt.Errorf("%w: between 1 and %d edges required", ErrOverlayInvalid, MaxTrustOverlayEdges)
+	}
+	skew := time.Since(time.Unix(o.Timestamp, 0))
+	if skew > TrustOverlayMaxSkew || skew < -TrustOverlayMaxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside the accepted window", ErrOverlayInvalid)
+	}
+	edges := make(map[string]float64, len(o.Edges))
+	for _, e := range o.Edges {
+		if !IsValidQuidID(e.Trustee) || e.Trustee == observer {
+			return nil, fmt.Errorf("%w: bad trustee %q", ErrOverlayInvalid, e.Trustee)
+		}
+		if e.TrustLevel < 0 || e.TrustLevel > 1 {
+			return nil, fmt.Errorf("%w: trust level for %s out of range", ErrOverlayInvalid, e.Trustee)
+		}
+		edges[e.Trustee] = e.TrustLevel
+	}
+
+	key, ok := node.quidPublicKey(observer)
+	if !ok {
+		return nil, ErrQuidKeyUnknown
+	}
+	signable := o
+	signable.Signature = ""
+	data, err := json.Marshal(signable)
+	if err != nil {
+		return nil, err
+	}
+	if !VerifySignature(key, data, o.Signature) {
+		return nil, ErrOverlaySignature
+	}
+	return edges, nil
+}
+
+// ComputeRelationalTrustWithOverlay is ComputeRelationalTrust with
+// the observer's direct edges patched by overlay, over the edges in
+// scope. It bypasses the trust cache and any external graph store in
+// both directions.
+func (node *QuidnugNode) ComputeRelationalTrustWithOverlay(ctx context.Context, observer, target string, scope TrustScope, maxDepth int, overlay map[string]float64) (float64, []string, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+	if observer == target {
+		return 1.0, []string{observer}, nil
+	}
+	trust, path, _, _, err := node.searchRelationalTrust(ctx, observer, target, maxDepth, scope, overlay)
+	return trust, path, err
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTrustQuery_Overlay(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	// The test identity is registered with the node's own key.
+	observer, a, b, target := "0000000000000001", "0a0a0a0a0a0a0a0a", "0b0b0b0b0b0b0b0b", "0c0c0c0c0c0c0c0c"
+	node.TrustRegistry[observer] = map[string]float64{a: 0.5}
+	node.TrustRegistry[a] = map[string]float64{target: 0.8}
+	node.TrustRegistry[b] = map[string]float64{target: 0.9}
+
+	sign := func(edges ...OverlayEdge) *TrustOverlay {
+		o := TrustOverlay{Edges: edges, Timestamp: time.Now().Unix()}
+		data, _ := json.Marshal(o)
+		sig, err := node.SignData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.Signature = hex.EncodeToString(sig)
+		return &o
+	}
+	query := func(overlay *TrustOverlay) (int, RelationalTrustResult) {
+		body, _ := json.Marshal(RelationalTrustQuery{Observer: observer, Target: target, Overlay: overlay})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/trust/query", bytes.NewReader(body)))
+		var resp struct {
+			Data RelationalTrustResult `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Data
+	}
+
+	if code, got := query(nil); code != http.StatusOK || !floatEquals(got.TrustLevel, 0.4, 0.0001) {
+		t.Fatalf("on chain: %d %+v", code, got)
+	}
+	if code, got := query(sign(OverlayEdge{Trustee: b, TrustLevel: 0.9})); code != http.StatusOK || !floatEquals(got.TrustLevel, 0.81, 0.0001) || got.TrustPath[1] != b {
+		t.Fatalf("private edge: %d %+v", code, got)
+	}
+	if code, got := query(sign(OverlayEdge{Trustee: a, TrustLevel: 0})); code != http.StatusOK || got.TrustLevel != 0 {
+		t.Fatalf("hidden edge: %d %+v", code, got)
+	}
+	// Overlay results are not cached over the on-chain answer.
+	if _, got := query(nil); !floatEquals(got.TrustLevel, 0.4, 0.0001) {
+		t.Fatalf("on chain after overlay: %+v", got)
+	}
+
+	forged := sign(OverlayEdge{Trustee: b, TrustLevel: 0.9})
+	forged.Edges[0].TrustLevel = 1
+	if code, _ := query(forged); code != http.StatusUnauthorized {
+		t.Fatalf("forged overlay: status %d", code)
+	}
+	if code, _ := query(sign(OverlayEdge{Trustee: b, TrustLevel: 1.5})); code != http.StatusBadRequest {
+		t.Fatalf("out of range level: status %d", code)
+	}
+}
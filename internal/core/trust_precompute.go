@@ -0,0 +1,173 @@
+// Relational trust precomputation.
+//
+// Most relational trust queries a node serves are asked from its
+// own quid, and a small set of targets dominates. TrustPrecompute
+// counts those queries and a background loop keeps the hottest
+// (target, maxDepth) pairs warm in TrustCache: whenever an edge
+// update or TTL expiry drops one of them, it is recomputed before
+// the next caller has to pay for the search.
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTrustPrecomputeInterval is how often the precompute loop
+// checks hot targets even without an edge-update wakeup, so TTL
+// expiry is also covered.
+const DefaultTrustPrecomputeInterval = 5 * time.Second
+
+// trustPrecomputeTrackFactor bounds how many distinct targets are
+// counted, as a multiple of the hot-set size. Past that, counts are
+// halved and zeroes dropped, which also ages out stale interest.
+const trustPrecomputeTrackFactor = 16
+
+// TrustQueryTarget is one tracked (target, maxDepth) pair.
+type TrustQueryTarget struct {
+	Target   string `json:"target"`
+	MaxDepth int    `json:"maxDepth"`
+	Queries  uint64 `json:"queries"`
+}
+
+type trustQueryKey struct {
+	target   string
+	maxDepth int
+}
+
+// TrustPrecompute tracks query frequency for the node's own quid.
+// Owns its own lock.
+type TrustPrecompute struct {
+	mu     sync.Mutex
+	limit  int
+	counts map[trustQueryKey]uint64
+	wake   chan struct{}
+}
+
+// NewTrustPrecompute keeps up to limit targets warm.
+func NewTrustPrecompute(limit int) *TrustPrecompute {
+	return &TrustPrecompute{
+		limit:  limit,
+		counts: make(map[trustQueryKey]uint64),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Record counts one query for (target, maxDepth).
+func (p *TrustPrecompute) Record(target string, maxDepth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.counts[trustQueryKey{target, maxDepth}]++
+	if len(p.counts) > p.limit*trustPrecomputeTrackFactor {
+		for k, n := range p.counts {
+			if n /= 2; n == 0 {
+				delete(p.counts, k)
+			} else {
+				p.counts[k] = n
+			}
+		}
+	}
+}
+
+// Hot returns the most frequently queried pairs, at most limit,
+// highest count first.
+func (p *TrustPrecompute) Hot() []TrustQueryTarget {
+	p.mu.Lock()
+	out := make([]TrustQueryTarget, 0, len(p.counts))
+	for k, n := range p.counts {
+		out = append(out, TrustQueryTarget{Target: k.target, MaxDepth: k.maxDepth, Queries: n})
+	}
+	p.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Queries != out[j].Queries {
+			return out[i].Queries > out[j].Queries
+		}
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return out[i].MaxDepth < out[j].MaxDepth
+	})
+	if len(out) > p.limit {
+		out = out[:p.limit]
+	}
+	return out
+}
+
+// notify wakes the precompute loop without blocking.
+func (p *TrustPrecompute) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// invalidateTrustFor drops cached results that read truster's
+// edges and nudges precomputation to refill the hot ones from the
+// trust trackers (trust_incremental.go).
+func (node *QuidnugNode) invalidateTrustFor(truster string) {
+	node.markTrustDirty(truster)
+	if node.TrustPrecompute != nil {
+		node.markTrackedTruster(truster)
+	}
+	if node.TrustCache == nil {
+		return
+	}
+	if node.TrustCache.InvalidateQuid(truster) > 0 && node.TrustPrecompute != nil {
+		node.TrustPrecompute.notify()
+	}
+}
+
+// precomputeHotTrust recomputes hot targets missing from the cache
+// and returns how many were refreshed. Each hot maxDepth gets a
+// tracker, so a refill after an edge update only repairs the part
+// of the table the update reached.
+func (node *QuidnugNode) precomputeHotTrust(ctx context.Context) int {
+	if node.TrustPrecompute == nil || node.TrustCache == nil {
+		return 0
+	}
+	hotSet := node.TrustPrecompute.Hot()
+	depths := make(map[int]bool)
+	for _, hot := range hotSet {
+		depths[hot.MaxDepth] = true
+	}
+	node.trackTrustDepths(ctx, depths)
+
+	refreshed := 0
+	for _, hot := range hotSet {
+		key := makeTrustCacheKey(node.NodeID, hot.Target, hot.MaxDepth)
+		if _, _, ok := node.TrustCache.Get(key); ok {
+			continue
+		}
+		if _, _, err := node.computeRelationalTrust(ctx, node.NodeID, hot.Target, hot.MaxDepth); err != nil {
+			logger.Debug("Trust precompute hit resource limits",
+				"target", hot.Target, "maxDepth", hot.MaxDepth, "error", err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
+
+// runTrustPrecompute keeps hot trust paths warm until ctx is done.
+// Launched from Run() when precomputation is enabled.
+func (node *QuidnugNode) runTrustPrecompute(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultTrustPrecomputeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-node.TrustPrecompute.wake:
+		}
+		node.precomputeHotTrust(ctx)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrustPrecompute_HotOrderingAndLimit(t *testing.T) {
+	p := NewTrustPrecompute(2)
+	for i := 0; i < 3; i++ {
+		p.Record("t1", 5)
+	}
+	p.Record("t2", 5)
+	p.Record("t2", 5)
+	p.Record("t3", 5)
+
+	hot := p.Hot()
+	if len(hot) != 2 || hot[0].Target != "t1" || hot[1].Target != "t2" {
+		t.Fatalf("unexpected hot set: %+v", hot)
+	}
+}
+
+func TestTrustPrecompute_TrackingIsBounded(t *testing.T) {
+	p := NewTrustPrecompute(1)
+	for i := 0; i < 10; i++ {
+		p.Record("hot", 5)
+	}
+	for i := 0; i < 3*trustPrecomputeTrackFactor; i++ {
+		p.Record(string(rune('a'+i%26))+string(rune('a'+i/26)), 5)
+	}
+	if n := len(p.counts); n > trustPrecomputeTrackFactor+1 {
+		t.Errorf("tracked %d targets, want at most %d", n, trustPrecomputeTrackFactor+1)
+	}
+	if hot := p.Hot(); len(hot) != 1 || hot[0].Target != "hot" {
+		t.Errorf("frequent target should survive decay: %+v", hot)
+	}
+}
+
+func TestPrecomputeHotTrust_RefillsAfterInvalidation(t *testing.T) {
+	node := newTestNode()
+	node.TrustPrecompute = NewTrustPrecompute(4)
+
+	aID := "aaaaaaaaaaaaaaaa"
+	node.TrustRegistry[node.NodeID] = map[string]float64{aID: 0.5}
+	node.ComputeRelationalTrust(context.Background(), node.NodeID, aID, 3)
+
+	// Queries from other observers are not tracked.
+	node.ComputeRelationalTrust(context.Background(), aID, node.NodeID, 3)
+	if hot := node.TrustPrecompute.Hot(); len(hot) != 1 {
+		t.Fatalf("expected one hot target, got %+v", hot)
+	}
+
+	if n := node.precomputeHotTrust(context.Background()); n != 0 {
+		t.Errorf("warm entry should not be recomputed, refreshed %d", n)
+	}
+
+	node.updateTrustRegistry(TrustTransaction{Truster: node.NodeID, Trustee: aID, TrustLevel: 0.8})
+	select {
+	case <-node.TrustPrecompute.wake:
+	default:
+		t.Error("edge update should wake the precompute loop")
+	}
+	if n := node.precomputeHotTrust(context.Background()); n != 1 {
+		t.Fatalf("expected one refreshed target, got %d", n)
+	}
+	trust, _, ok := node.TrustCache.Get(makeTrustCacheKey(node.NodeID, aID, 3))
+	if !ok || trust != 0.8 {
+		t.Errorf("expected warm entry with trust 0.8, got %v (cached=%v)", trust, ok)
+	}
+}
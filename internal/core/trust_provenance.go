@@ -0,0 +1,168 @@
+// Trust edge provenance backfill.
+//
+// Edges applied from main-chain blocks land in TrustRegistry as a
+// bare level; only edges that went through the tiered path
+// (AddVerifiedTrustEdge, tentative promotion, quarantine accept)
+// also get a TrustEdge in VerifiedTrustEdges carrying SourceBlock
+// and ValidatorQuid. Nodes that predate the TrustEdge model, or that
+// rebuilt their registries by replaying blockchain.json, therefore
+// hold edges with no provenance.
+//
+// BackfillTrustProvenance rescans the chain, pruned history
+// included, and rebuilds VerifiedTrustEdges from it: every edge in
+// TrustRegistry is credited to the last block that wrote it. Edges
+// no block accounts for (seeded locally, or written by a block whose
+// archived body could not be fetched) are reported and left alone.
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrProvenanceBackfillRunning means a backfill was requested while
+// another was still scanning.
+var ErrProvenanceBackfillRunning = errors.New("trust provenance: backfill already running")
+
+// TrustProvenanceBackfillRequest is the admin-signed body that
+// triggers a backfill. Signature covers the JSON encoding of the
+// request with Signature empty.
+type TrustProvenanceBackfillRequest struct {
+	Timestamp int64  `json:"timestamp"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// TrustProvenanceReport summarizes one backfill run.
+type TrustProvenanceReport struct {
+	StartedAt   int64 `json:"startedAt"`
+	CompletedAt int64 `json:"completedAt"`
+	// Blocks scanned, and how many of them stayed pruned headers
+	// because their archived body could not be fetched.
+	Blocks       int `json:"blocks"`
+	PrunedBlocks int `json:"prunedBlocks"`
+	// Edges in TrustRegistry at the end of the scan, how many were
+	// given provenance, and how many no scanned block wrote.
+	Edges     int `json:"edges"`
+	Rebuilt   int `json:"rebuilt"`
+	Unsourced int `json:"unsourced"`
+}
+
+// provenanceBackfillState lets only one backfill scan at a time and
+// keeps the last report for GET.
+type provenanceBackfillState struct {
+	running atomic.Bool
+	last    atomic.Pointer[TrustProvenanceReport]
+}
+
+// TriggerTrustProvenanceBackfill verifies an admin-signed request
+// and runs the backfill.
+func (node *QuidnugNode) TriggerTrustProvenanceBackfill(ctx context.Context, req TrustProvenanceBackfillRequest) (*TrustProvenanceReport, error) {
+	signable := req
+	signable.Signature = ""
+	if err := node.verifyAdminSigned(req.PublicKey, req.Timestamp, req.Signature, signable); err != nil {
+		return nil, err
+	}
+	return node.BackfillTrustProvenance(ctx)
+}
+
+// LastTrustProvenanceReport returns the report of the most recent
+// completed backfill, or nil if none has run since startup.
+func (node *QuidnugNode) LastTrustProvenanceReport() *TrustProvenanceReport {
+	return node.provenanceBackfill.last.Load()
+}
+
+// BackfillTrustProvenance rebuilds VerifiedTrustEdges from the
+// chain. Blocks are scanned in chain order so a later TRUST
+// transaction for the same edge replaces an earlier one. The scan
+// works on a copy of the chain; registries are locked only for the
+// final rebuild. Cancelling ctx stops the scan without touching the
+// registries.
+func (node *QuidnugNode) BackfillTrustProvenance(ctx context.Context) (*TrustProvenanceReport, error) {
+	if !node.provenanceBackfill.running.CompareAndSwap(false, true) {
+		return nil, ErrProvenanceBackfillRunning
+	}
+	defer node.provenanceBackfill.running.Store(false)
+
+	report := &TrustProvenanceReport{StartedAt: nowUnix()}
+
+	node.BlockchainMutex.RLock()
+	chain := make([]Block, len(node.Blockchain))
+	copy(chain, node.Blockchain)
+	node.BlockchainMutex.RUnlock()
+	report.Blocks = len(chain)
+
+	latest := make(map[[2]string]TrustEdge)
+	hydrate := node.blockHydrator(ctx)
+	for i := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := chain[i]
+		if block.Pruned && hydrate != nil {
+			hydrate(&block)
+		}
+		if block.Pruned {
+			report.PrunedBlocks++
+			continue
+		}
+		for _, raw := range blockTxsOfType(block, TxTypeTrust) {
+			var tx TrustTransaction
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				continue
+			}
+			latest[[2]string{tx.Truster, tx.Trustee}] = TrustEdge{
+				Truster:       tx.Truster,
+				Trustee:       tx.Trustee,
+				SourceBlock:   block.Hash,
+				ValidatorQuid: block.TrustProof.ValidatorID,
+				Verified:      true,
+				Timestamp:     tx.Timestamp,
+				Domain:        tx.TrustDomain,
+			}
+		}
+	}
+
+	var touched []string
+	node.TrustRegistryMutex.Lock()
+	for truster, trustees := range node.TrustRegistry {
+		changed := false
+		for trustee, level := range trustees {
+			report.Edges++
+			edge, ok := latest[[2]string{truster, trustee}]
+			if !ok {
+				report.Unsourced++
+				continue
+			}
+			// The registry level wins: a tentative promotion or a
+			// direct write may have landed after the block.
+			edge.TrustLevel = level
+			if node.VerifiedTrustEdges[truster] == nil {
+				node.VerifiedTrustEdges[truster] = make(map[string]TrustEdge)
+			}
+			node.VerifiedTrustEdges[truster][trustee] = edge
+			report.Rebuilt++
+			changed = true
+		}
+		if changed {
+			touched = append(touched, truster)
+		}
+	}
+	for _, truster := range touched {
+		node.invalidateTrustFor(truster)
+	}
+	node.TrustRegistryMutex.Unlock()
+	node.bumpRegistryVersion()
+
+	report.CompletedAt = nowUnix()
+	node.provenanceBackfill.last.Store(report)
+	logger.Info("Backfilled trust edge provenance",
+		"blocks", report.Blocks,
+		"prunedBlocks", report.PrunedBlocks,
+		"edges", report.Edges,
+		"rebuilt", report.Rebuilt,
+		"unsourced", report.Unsourced)
+	return report, nil
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackfillTrustProvenance_CreditsLastBlock(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	block, err := node.SyntheticTrustBlock("provenance.local", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node.AddBlock(*block); err != nil {
+		t.Fatalf("AddBlock: %v", err)
+	}
+	setTestTrust(node, "e000000000000001", "e000000000000002", 0.4)
+
+	report, err := node.BackfillTrustProvenance(context.Background())
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if report.Rebuilt != 5 || report.Unsourced != report.Edges-5 || report.Unsourced == 0 {
+		t.Fatalf("report %+v", report)
+	}
+
+	var tx TrustTransaction
+	raw, _ := json.Marshal(block.Transactions[0])
+	_ = json.Unmarshal(raw, &tx)
+	edges := node.GetTrustEdges(tx.Truster, false)
+	edge, ok := edges[tx.Trustee]
+	if !ok || !edge.Verified || edge.SourceBlock != block.Hash || edge.ValidatorQuid != block.TrustProof.ValidatorID {
+		t.Fatalf("edge %s -> %s: %+v (found %v)", tx.Truster, tx.Trustee, edge, ok)
+	}
+	if edge.TrustLevel != tx.TrustLevel || edge.Domain != "provenance.local" {
+		t.Fatalf("edge lost its level or domain: %+v", edge)
+	}
+	if _, ok := node.GetTrustEdges("e000000000000001", false)["e000000000000002"]; ok {
+		t.Fatal("edge with no block was given provenance")
+	}
+	if node.LastTrustProvenanceReport() != report {
+		t.Fatal("last report not kept")
+	}
+
+	node.provenanceBackfill.running.Store(true)
+	if _, err := node.BackfillTrustProvenance(context.Background()); !errors.Is(err, ErrProvenanceBackfillRunning) {
+		t.Fatalf("overlapping backfill: err = %v", err)
+	}
+}
+
+func TestBackfillTrustProvenanceHandler(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	post := func(req TrustProvenanceBackfillRequest) int {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/trust-provenance/backfill", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	req := TrustProvenanceBackfillRequest{Timestamp: time.Now().Unix(), PublicKey: node.GetPublicKeyHex()}
+	if code := post(req); code != http.StatusForbidden {
+		t.Fatalf("unsigned request: status %d", code)
+	}
+	data, _ := json.Marshal(req)
+	sig, err := node.SignData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Signature = hex.EncodeToString(sig)
+	if code := post(req); code != http.StatusOK {
+		t.Fatalf("signed request: status %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/trust-provenance", nil))
+	var resp struct {
+		Data struct {
+			Report *TrustProvenanceReport `json:"report"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.Report == nil {
+		t.Fatalf("GET report: %v %s", err, w.Body.String())
+	}
+}
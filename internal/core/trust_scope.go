@@ -0,0 +1,266 @@
+// Domain- and context-scoped trust.
+//
+// A TRUST transaction is recorded in a trust domain, but the global
+// registry keeps one level per edge whatever the domain, so trust
+// granted in "cars.example.com" used to count in "default" queries
+// too. TrustEdgeDomainLevels keeps the level each domain set, and
+// the query endpoints compute over the edges of the requested domain
+// ("default" when none is named). Edges recorded without a domain,
+// and edges predating the per-domain record, belong to "default".
+//
+// Queries opt into the global graph with crossDomain; there every
+// edge carries the level last set in any domain. The node's own
+// decisions (validator trust, anchors, sybil scoring) keep using the
+// global graph.
+//
+// Within a domain, a TRUST transaction may also carry a context tag
+// ("mechanical-repair", "payments"), since trusting someone to fix a
+// car says little about trusting them with money. A query can then
+// filter to one context, or weight contexts: an edge counts at its
+// best level × weight over the weighted contexts it was set in, with
+// "" standing for untagged edges.
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultTrustDomain is the domain of edges recorded without one.
+const defaultTrustDomain = "default"
+
+// trustContextPattern is the shape of a context tag.
+var trustContextPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// ValidTrustContext reports whether tag is a well-formed context tag.
+func ValidTrustContext(tag string) bool {
+	return trustContextPattern.MatchString(tag)
+}
+
+// TrustScope limits a trust computation. The zero value is the global
+// graph.
+type TrustScope struct {
+	// Domain limits the walk to edges set in that domain.
+	Domain string
+	// Context limits the walk to edges set under that tag.
+	Context string
+	// ContextWeights, when Context is empty, weights each edge by the
+	// contexts it was set in; contexts not listed count for nothing.
+	ContextWeights map[string]float64
+}
+
+func (s TrustScope) global() bool {
+	return s.Domain == "" && !s.contextual()
+}
+
+func (s TrustScope) contextual() bool {
+	return s.Context != "" || len(s.ContextWeights) > 0
+}
+
+// key identifies the scope in trust cache keys.
+func (s TrustScope) key() string {
+	var b strings.Builder
+	b.WriteString(s.Domain)
+	b.WriteString("#")
+	b.WriteString(s.Context)
+	contexts := make([]string, 0, len(s.ContextWeights))
+	for c := range s.ContextWeights {
+		contexts = append(contexts, c)
+	}
+	sort.Strings(contexts)
+	for _, c := range contexts {
+		fmt.Fprintf(&b, ",%s=%g", c, s.ContextWeights[c])
+	}
+	return b.String()
+}
+
+// trustContextKey addresses an edge's level under one context tag,
+// within a domain or, with Domain empty, across all of them.
+type trustContextKey struct {
+	Domain  string
+	Context string
+}
+
+// trustScopeDomain normalizes a recorded or requested domain.
+func trustScopeDomain(domain string) string {
+	if domain == "" {
+		return defaultTrustDomain
+	}
+	return domain
+}
+
+// recordScopedTrustLevelLocked records level as the edge's level in
+// domain and under context. Caller holds TrustRegistryMutex.
+func (node *QuidnugNode) recordScopedTrustLevelLocked(truster, trustee, domain, context string, level float64) {
+	if node.TrustEdgeDomainLevels != nil {
+		byTrustee, ok := node.TrustEdgeDomainLevels[truster]
+		if !ok {
+			byTrustee = make(map[string]map[string]float64)
+			node.TrustEdgeDomainLevels[truster] = byTrustee
+		}
+		if byTrustee[trustee] == nil {
+			byTrustee[trustee] = make(map[string]float64)
+		}
+		byTrustee[trustee][trustScopeDomain(domain)] = level
+	}
+	if node.TrustEdgeContextLevels != nil {
+		byTrustee, ok := node.TrustEdgeContextLevels[truster]
+		if !ok {
+			byTrustee = make(map[string]map[trustContextKey]float64)
+			node.TrustEdgeContextLevels[truster] = byTrustee
+		}
+		if byTrustee[trustee] == nil {
+			byTrustee[trustee] = make(map[trustContextKey]float64)
+		}
+		byTrustee[trustee][trustContextKey{Domain: trustScopeDomain(domain), Context: context}] = level
+		byTrustee[trustee][trustContextKey{Context: context}] = level
+	}
+}
+
+// zeroScopedTrustLevelsLocked sets the edge to 0 in every domain and
+// context. Caller holds TrustRegistryMutex.
+func (node *QuidnugNode) zeroScopedTrustLevelsLocked(truster, trustee string) {
+	for domain := range node.TrustEdgeDomainLevels[truster][trustee] {
+		node.TrustEdgeDomainLevels[truster][trustee][domain] = 0
+	}
+	for key := range node.TrustEdgeContextLevels[truster][trustee] {
+		node.TrustEdgeContextLevels[truster][trustee][key] = 0
+	}
+}
+
+// scopedTrustLevelLocked returns the edge's level in scope given its
+// global level, and whether the edge exists there. Caller holds
+// TrustRegistryMutex for reading.
+func (node *QuidnugNode) scopedTrustLevelLocked(truster, trustee string, global float64, scope TrustScope) (float64, bool) {
+	level := global
+	if scope.Domain != "" {
+		levels, recorded := node.TrustEdgeDomainLevels[truster][trustee]
+		if recorded {
+			var ok bool
+			if level, ok = levels[trustScopeDomain(scope.Domain)]; !ok {
+				return 0, false
+			}
+		} else if trustScopeDomain(scope.Domain) != defaultTrustDomain {
+			return 0, false
+		}
+	}
+	if !scope.contextual() {
+		return level, true
+	}
+
+	// Edges with no context record count as untagged.
+	contexts := node.TrustEdgeContextLevels[truster][trustee]
+	levelIn := func(context string) (float64, bool) {
+		if contexts == nil {
+			return level, context == ""
+		}
+		l, ok := contexts[trustContextKey{Domain: scope.Domain, Context: context}]
+		return l, ok
+	}
+	if scope.Context != "" {
+		return levelIn(scope.Context)
+	}
+	best, found := 0.0, false
+	for context, weight := range scope.ContextWeights {
+		if l, ok := levelIn(context); ok {
+			found = true
+			if l*weight > best {
+				best = l * weight
+			}
+		}
+	}
+	return best, found
+}
+
+// scopeTrustees narrows quid's global trustees to scope, in place.
+func (node *QuidnugNode) scopeTrustees(quid string, trustees map[string]float64, scope TrustScope) map[string]float64 {
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+	for trustee, global := range trustees {
+		if level, ok := node.scopedTrustLevelLocked(quid, trustee, global, scope); ok {
+			trustees[trustee] = level
+		} else {
+			delete(trustees, trustee)
+		}
+	}
+	return trustees
+}
+
+// scopeTrustEdges narrows GetTrustEdges output to scope, in place.
+// Verified edges take their level in scope; unverified edges are
+// kept when recorded in its domain and context.
+func (node *QuidnugNode) scopeTrustEdges(quid string, edges map[string]TrustEdge, scope TrustScope) map[string]TrustEdge {
+	node.TrustRegistryMutex.RLock()
+	defer node.TrustRegistryMutex.RUnlock()
+	for trustee, edge := range edges {
+		if !edge.Verified {
+			keep := scope.Domain == "" || trustScopeDomain(edge.Domain) == trustScopeDomain(scope.Domain)
+			switch {
+			case scope.Context != "":
+				keep = keep && edge.Context == scope.Context
+			case len(scope.ContextWeights) > 0:
+				weight, ok := scope.ContextWeights[edge.Context]
+				keep = keep && ok
+				edge.TrustLevel *= weight
+			}
+			if keep {
+				edges[trustee] = edge
+			} else {
+				delete(edges, trustee)
+			}
+			continue
+		}
+		if level, ok := node.scopedTrustLevelLocked(quid, trustee, edge.TrustLevel, scope); ok {
+			edge.TrustLevel = level
+			edges[trustee] = edge
+		} else {
+			delete(edges, trustee)
+		}
+	}
+	return edges
+}
+
+// trustQueryScope is the scope an API trust query computes over: the
+// named domain or "default", or the global graph when the caller
+// opted into crossDomain, narrowed by any context filter or weights.
+func trustQueryScope(domain string, crossDomain bool, context string, weights map[string]float64) TrustScope {
+	scope := TrustScope{Context: context, ContextWeights: weights}
+	if !crossDomain {
+		scope.Domain = trustScopeDomain(domain)
+	}
+	return scope
+}
+
+// validateContextQuery checks a query's context filter and weights.
+func validateContextQuery(context string, weights map[string]float64) error {
+	if context != "" && len(weights) > 0 {
+		return fmt.Errorf("context and contextWeights are mutually exclusive")
+	}
+	if context != "" && !ValidTrustContext(context) {
+		return fmt.Errorf("invalid context %q", context)
+	}
+	for c, w := range weights {
+		if c != "" && !ValidTrustContext(c) {
+			return fmt.Errorf("invalid context %q", c)
+		}
+		if w < 0 || w > 1 {
+			return fmt.Errorf("weight for context %q must be in [0, 1]", c)
+		}
+	}
+	return nil
+}
+
+// ComputeScopedTrust is ComputeRelationalTrust limited to scope. The
+// zero scope searches the global graph.
+func (node *QuidnugNode) ComputeScopedTrust(ctx context.Context, observer, target string, scope TrustScope, maxDepth int) (float64, []string, error) {
+	if scope.global() {
+		return node.ComputeRelationalTrust(ctx, observer, target, maxDepth)
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+	return node.computeTrustInScope(ctx, observer, target, maxDepth, scope)
+}
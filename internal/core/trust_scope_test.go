@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScopedTrust_DomainsDoNotLeak(t *testing.T) {
+	node := newTestNode()
+	a, b, c := "e000000000000001", "e000000000000002", "e000000000000003"
+	trust := func(domain, truster, trustee string, level float64) {
+		node.updateTrustRegistry(TrustTransaction{
+			BaseTransaction: BaseTransaction{TrustDomain: domain},
+			Truster:         truster, Trustee: trustee, TrustLevel: level,
+		})
+	}
+	trust("cars.example.com", a, b, 0.9)
+	trust("cars.example.com", b, c, 0.8)
+	// Set later, so the global graph carries 0.2 for a→b.
+	trust("default", a, b, 0.2)
+
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, TrustScope{Domain: "cars.example.com"}, 0); !floatEquals(got, 0.72, 0.0001) {
+		t.Errorf("cars: got %v, want 0.72", got)
+	}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, TrustScope{Domain: "default"}, 0); got != 0 {
+		t.Errorf("default must not see the cars b→c edge: got %v", got)
+	}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, TrustScope{}, 0); !floatEquals(got, 0.16, 0.0001) {
+		t.Errorf("global: got %v, want 0.16", got)
+	}
+
+	batch, _ := node.ComputeScopedTrustBatch(context.Background(), a, []string{b, c}, TrustScope{Domain: "cars.example.com"}, 0)
+	if !floatEquals(batch[0].TrustLevel, 0.9, 0.0001) || !floatEquals(batch[1].TrustLevel, 0.72, 0.0001) {
+		t.Errorf("scoped batch: %+v", batch)
+	}
+
+	node.AddVerifiedTrustEdge(TrustEdge{Truster: a, Trustee: b, TrustLevel: 0.2, Domain: "default"})
+	node.AddVerifiedTrustEdge(TrustEdge{Truster: b, Trustee: c, TrustLevel: 0.8, Domain: "cars.example.com"})
+	enhanced, _ := node.ComputeScopedTrustEnhanced(context.Background(), a, c, TrustScope{Domain: "cars.example.com"}, 0, false)
+	if !floatEquals(enhanced.TrustLevel, 0.72, 0.0001) {
+		t.Errorf("scoped enhanced: got %v, want 0.72", enhanced.TrustLevel)
+	}
+}
+
+func TestRelationalTrustQueryHandler_CrossDomain(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "cars.example.com"},
+		Truster:         "e000000000000001", Trustee: "e000000000000002", TrustLevel: 0.9,
+	})
+
+	query := func(body string) RelationalTrustResult {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/trust/query", bytes.NewBufferString(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", body, rec.Code)
+		}
+		var resp struct {
+			Data RelationalTrustResult `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	if got := query(`{"observer":"e000000000000001","target":"e000000000000002"}`); got.TrustLevel != 0 || got.Domain != "default" {
+		t.Errorf("default scope: %+v", got)
+	}
+	if got := query(`{"observer":"e000000000000001","target":"e000000000000002","crossDomain":true}`); got.TrustLevel != 0.9 || !got.CrossDomain {
+		t.Errorf("cross domain: %+v", got)
+	}
+	if got := query(`{"observer":"e000000000000001","target":"e000000000000002","domain":"cars.example.com"}`); got.TrustLevel != 0.9 {
+		t.Errorf("cars scope: %+v", got)
+	}
+}
+
+func TestScopedTrust_Context(t *testing.T) {
+	node := newTestNode()
+	a, b, c := "e000000000000001", "e000000000000002", "e000000000000003"
+	trust := func(context, truster, trustee string, level float64) {
+		node.updateTrustRegistry(TrustTransaction{
+			Truster: truster, Trustee: trustee, TrustLevel: level, Context: context,
+		})
+	}
+	trust("mechanical-repair", a, b, 0.9)
+	trust("payments", a, b, 0.3)
+	trust("", b, c, 0.5)
+
+	scope := func(context string, weights map[string]float64) TrustScope {
+		return trustQueryScope("", false, context, weights)
+	}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, b, scope("mechanical-repair", nil), 0); !floatEquals(got, 0.9, 0.0001) {
+		t.Errorf("repair: got %v, want 0.9", got)
+	}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, b, scope("payments", nil), 0); !floatEquals(got, 0.3, 0.0001) {
+		t.Errorf("payments: got %v, want 0.3", got)
+	}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, scope("payments", nil), 0); got != 0 {
+		t.Errorf("untagged b→c must not match a context filter: got %v", got)
+	}
+
+	// Repair at half weight (0.45) beats payments at full (0.3); the
+	// untagged hop counts at 0.8.
+	weights := map[string]float64{"mechanical-repair": 0.5, "payments": 1, "": 0.8}
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, scope("", weights), 0); !floatEquals(got, 0.45*0.4, 0.0001) {
+		t.Errorf("weighted: got %v, want %v", got, 0.45*0.4)
+	}
+	delete(weights, "")
+	if got, _, _ := node.ComputeScopedTrust(context.Background(), a, c, scope("", weights), 0); got != 0 {
+		t.Errorf("unweighted untagged hop must not count: got %v", got)
+	}
+
+	if err := validateContextQuery("payments", map[string]float64{"payments": 1}); err == nil {
+		t.Error("context with contextWeights accepted")
+	}
+	if err := validateContextQuery("", map[string]float64{"Payments!": 1}); err == nil {
+		t.Error("malformed context accepted")
+	}
+}
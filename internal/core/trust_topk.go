@@ -0,0 +1,62 @@
+// Top-K trusted quids from an observer's point of view.
+//
+// Discovery and recommendation callers want "who does this quid
+// trust most, transitively" without exporting the whole graph.
+// TopTrustedQuids walks the observer's neighbourhood once (the
+// same BFS the batch query uses) and ranks everything it reached.
+package core
+
+import (
+	"context"
+	"sort"
+)
+
+// Bounds for top-K queries.
+const (
+	DefaultTrustTopK = 20
+	MaxTrustTopK     = 200
+)
+
+// TopTrustedQuids returns up to k quids with the highest relational
+// trust from observer within maxDepth hops, best first, ties broken
+// by quid ID. The observer itself and zero-trust quids are omitted.
+// A non-empty domain limits the walk to edges set in that domain.
+// On ErrTrustGraphTooLarge the ranking covers what was reached.
+func (node *QuidnugNode) TopTrustedQuids(ctx context.Context, observer string, k, maxDepth int, domain string) ([]RelationalTrustResult, error) {
+	if k <= 0 {
+		k = DefaultTrustTopK
+	}
+	if k > MaxTrustTopK {
+		k = MaxTrustTopK
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultTrustMaxDepth
+	}
+
+	best, _, err := node.walkTrust(ctx, observer, maxDepth, TrustScope{Domain: domain}, func(string) bool { return true })
+
+	ranked := make([]RelationalTrustResult, 0, len(best))
+	for quid, hit := range best {
+		if quid == observer || hit.trust <= 0 {
+			continue
+		}
+		ranked = append(ranked, RelationalTrustResult{
+			Observer:   observer,
+			Target:     quid,
+			TrustLevel: hit.trust,
+			TrustPath:  hit.path,
+			PathDepth:  len(hit.path) - 1,
+			Domain:     domain,
+		})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].TrustLevel != ranked[j].TrustLevel {
+			return ranked[i].TrustLevel > ranked[j].TrustLevel
+		}
+		return ranked[i].Target < ranked[j].Target
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked, err
+}
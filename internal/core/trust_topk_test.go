@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTopTrustedQuids(t *testing.T) {
+	node := newTestNode()
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "a.example"},
+		Truster:         "d000000000000001", Trustee: "d000000000000002", TrustLevel: 0.9,
+	})
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "b.example"},
+		Truster:         "d000000000000001", Trustee: "d000000000000003", TrustLevel: 0.6,
+	})
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "a.example"},
+		Truster:         "d000000000000002", Trustee: "d000000000000004", TrustLevel: 0.5,
+	})
+	node.updateTrustRegistry(TrustTransaction{
+		BaseTransaction: BaseTransaction{TrustDomain: "a.example"},
+		Truster:         "d000000000000002", Trustee: "d000000000000001", TrustLevel: 1.0,
+	})
+
+	top, err := node.TopTrustedQuids(context.Background(), "d000000000000001", 2, 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].Target != "d000000000000002" || top[1].Target != "d000000000000003" {
+		t.Fatalf("unexpected ranking: %+v", top)
+	}
+
+	top, _ = node.TopTrustedQuids(context.Background(), "d000000000000001", 10, 3, "a.example")
+	if len(top) != 2 || top[1].Target != "d000000000000004" || top[1].PathDepth != 2 {
+		t.Errorf("domain filter should skip the b.example edge: %+v", top)
+	}
+	for _, r := range top {
+		if r.Target == "d000000000000001" {
+			t.Error("observer must not rank itself")
+		}
+	}
+
+	if top, _ := node.TopTrustedQuids(context.Background(), "d000000000000001", 10, 1, ""); len(top) != 2 {
+		t.Errorf("depth 1 should reach only direct trustees: %+v", top)
+	}
+}
+
+func TestGetTopTrustedHandler(t *testing.T) {
+	node := newTestNode()
+	node.TrustRegistry["d000000000000001"] = map[string]float64{"d000000000000002": 0.7}
+	router := setupTestRouter(node)
+
+	req := httptest.NewRequest("GET", "/api/v1/trust/d000000000000001/top?k=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []RelationalTrustResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].TrustLevel != 0.7 {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/trust/d000000000000001/top?k=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("k=0: expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,232 @@
+// Copy-on-write trust graph for readers.
+//
+// A relational trust query expands many quids, and it used to take
+// TrustRegistryMutex once per hop. Applying a block takes the write
+// lock once per TRUST transaction, and Go's RWMutex queues new
+// readers behind a waiting writer, so queries and block application
+// ended up taking turns hop by hop.
+//
+// Queries now walk an immutable trustView loaded with one atomic
+// read. Writers mark the trusters they touch; the next publish
+// copies only those trusters' edges, and only the shards holding them,
+// sharing everything else with the previous view. A view is published when a block finishes
+// applying, so queries see a block's trust edges all at once. Edges
+// written outside a block (gossip, promotions, tests) are picked up
+// by the next query.
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// trustViewShards is how many pieces the view is split into. A
+// publish re-copies the top-level maps of only the shards holding a
+// changed truster.
+const trustViewShards = 64
+
+// trustView is an immutable snapshot of TrustRegistry plus the
+// edge expiries needed to filter it. Shards and inner maps are
+// shared between views and must never be written once published.
+type trustView struct {
+	shards [trustViewShards]*trustShard
+}
+
+type trustShard struct {
+	edges  map[string]map[string]float64
+	expiry map[string]map[string]int64
+}
+
+func newTrustShard(sizeHint int) *trustShard {
+	return &trustShard{
+		edges:  make(map[string]map[string]float64, sizeHint),
+		expiry: make(map[string]map[string]int64),
+	}
+}
+
+// trustShardOf maps a quid to its shard (FNV-1a).
+func trustShardOf(quid string) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(quid); i++ {
+		h ^= uint32(quid[i])
+		h *= 16777619
+	}
+	return int(h % trustViewShards)
+}
+
+// forEachTrustee calls fn for each of quid's edges that has not
+// expired at now.
+func (v *trustView) forEachTrustee(quid string, now int64, fn func(trustee string, level float64)) {
+	shard := v.shards[trustShardOf(quid)]
+	exp := shard.expiry[quid]
+	for trustee, level := range shard.edges[quid] {
+		if until := exp[trustee]; until != 0 && until <= now {
+			continue
+		}
+		fn(trustee, level)
+	}
+}
+
+// trustees returns a fresh map of quid's unexpired edges.
+func (v *trustView) trustees(quid string, now int64) map[string]float64 {
+	out := make(map[string]float64, len(v.shards[trustShardOf(quid)].edges[quid]))
+	v.forEachTrustee(quid, now, func(trustee string, level float64) {
+		out[trustee] = level
+	})
+	return out
+}
+
+// trustViewState is the publishing side of the view. mu serializes
+// publishers; dirtyMu guards dirty and is never held while taking
+// another lock, so writers may mark trusters while holding
+// TrustRegistryMutex.
+type trustViewState struct {
+	current atomic.Pointer[trustView]
+	// pending is set when dirty is non-empty or a full rebuild is
+	// due; readers check it before trusting current.
+	pending atomic.Bool
+	// applying counts blocks in processBlockTransactions. While it
+	// is non-zero readers keep the last published view.
+	applying atomic.Int32
+	// published counts publishes, for tests and metrics.
+	published atomic.Uint64
+
+	mu      sync.Mutex
+	dirtyMu sync.Mutex
+	dirty   map[string]struct{}
+	rebuild bool
+}
+
+// markTrustDirty records that truster's edges changed.
+func (node *QuidnugNode) markTrustDirty(truster string) {
+	s := &node.trustViewState
+	s.dirtyMu.Lock()
+	if s.dirty == nil {
+		s.dirty = make(map[string]struct{})
+	}
+	s.dirty[truster] = struct{}{}
+	s.pending.Store(true)
+	s.dirtyMu.Unlock()
+}
+
+// invalidateTrustView forces the next publish to copy the whole
+// registry, for bulk loads that don't go through the per-edge
+// writers.
+func (node *QuidnugNode) invalidateTrustView() {
+	s := &node.trustViewState
+	s.dirtyMu.Lock()
+	s.rebuild = true
+	s.pending.Store(true)
+	s.dirtyMu.Unlock()
+}
+
+// currentTrustView returns the view trust queries should read,
+// publishing first if edges changed since the last one and no block
+// is mid-application.
+func (node *QuidnugNode) currentTrustView() *trustView {
+	s := &node.trustViewState
+	v := s.current.Load()
+	if v == nil || (s.pending.Load() && s.applying.Load() == 0) {
+		v = node.publishTrustView()
+	}
+	return v
+}
+
+// trustViewCurrent reports whether v is still the latest view with
+// no unpublished edits, i.e. whether results computed from it may
+// be cached.
+func (node *QuidnugNode) trustViewCurrent(v *trustView) bool {
+	s := &node.trustViewState
+	return s.current.Load() == v && !s.pending.Load()
+}
+
+// publishTrustView builds and installs a new view if anything
+// changed, and returns the current one.
+func (node *QuidnugNode) publishTrustView() *trustView {
+	s := &node.trustViewState
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.current.Load()
+	s.dirtyMu.Lock()
+	dirty, rebuild := s.dirty, s.rebuild || prev == nil
+	s.dirty, s.rebuild = nil, false
+	s.pending.Store(false)
+	s.dirtyMu.Unlock()
+	if !rebuild && len(dirty) == 0 {
+		return prev
+	}
+
+	next := &trustView{}
+	node.TrustRegistryMutex.RLock()
+	if rebuild {
+		for i := range next.shards {
+			next.shards[i] = newTrustShard(len(node.TrustRegistry) / trustViewShards)
+		}
+		for truster := range node.TrustRegistry {
+			node.copyTrusterLocked(next.shards[trustShardOf(truster)], truster)
+		}
+	} else {
+		next.shards = prev.shards
+		var cloned [trustViewShards]bool
+		for truster := range dirty {
+			i := trustShardOf(truster)
+			if !cloned[i] {
+				next.shards[i] = prev.shards[i].clone()
+				cloned[i] = true
+			}
+			delete(next.shards[i].edges, truster)
+			delete(next.shards[i].expiry, truster)
+			node.copyTrusterLocked(next.shards[i], truster)
+		}
+	}
+	node.TrustRegistryMutex.RUnlock()
+
+	s.current.Store(next)
+	s.published.Add(1)
+	return next
+}
+
+// clone copies the shard's top-level maps; the per-truster maps
+// they point to are shared.
+func (sh *trustShard) clone() *trustShard {
+	out := &trustShard{
+		edges:  make(map[string]map[string]float64, len(sh.edges)+1),
+		expiry: make(map[string]map[string]int64, len(sh.expiry)),
+	}
+	for truster, edges := range sh.edges {
+		out.edges[truster] = edges
+	}
+	for truster, exp := range sh.expiry {
+		out.expiry[truster] = exp
+	}
+	return out
+}
+
+// copyTrusterLocked copies truster's edges and non-zero expiries
+// into shard. Caller holds TrustRegistryMutex.
+func (node *QuidnugNode) copyTrusterLocked(v *trustShard, truster string) {
+	edges := node.TrustRegistry[truster]
+	if len(edges) == 0 {
+		return
+	}
+	cp := make(map[string]float64, len(edges))
+	for trustee, level := range edges {
+		cp[trustee] = level
+	}
+	v.edges[truster] = cp
+
+	var exp map[string]int64
+	for trustee, until := range node.TrustExpiryRegistry[truster] {
+		if until == 0 {
+			continue
+		}
+		if exp == nil {
+			exp = make(map[string]int64)
+		}
+		exp[trustee] = until
+	}
+	if exp != nil {
+		v.expiry[truster] = exp
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestTrustView_PublishSharesUntouchedShards(t *testing.T) {
+	node := newTestNode()
+	node.SeedSyntheticTrustGraph(500, 4, 1)
+	before := node.currentTrustView()
+
+	truster := SyntheticQuid(7)
+	node.updateTrustRegistry(TrustTransaction{Truster: truster, Trustee: SyntheticQuid(8), TrustLevel: 0.3})
+	after := node.currentTrustView()
+	if after == before {
+		t.Fatal("edge write did not publish a new view")
+	}
+
+	touched := trustShardOf(truster)
+	for i := range after.shards {
+		shared := after.shards[i] == before.shards[i]
+		if i == touched && shared {
+			t.Fatalf("shard %d holds the changed truster but was not copied", i)
+		}
+		if i != touched && !shared {
+			t.Fatalf("shard %d was copied though nothing in it changed", i)
+		}
+	}
+	if got := before.trustees(truster, nowUnix())[SyntheticQuid(8)]; got == 0.3 {
+		t.Fatal("published view was mutated in place")
+	}
+	if got := after.trustees(truster, nowUnix())[SyntheticQuid(8)]; got != 0.3 {
+		t.Fatalf("new view has level %v, want 0.3", got)
+	}
+}
+
+func TestTrustView_BlockAppliesAtomically(t *testing.T) {
+	node := newTestNode()
+	setTestTrust(node, "a000000000000001", "a000000000000002", 0.9)
+	if trust, _, _ := node.ComputeRelationalTrust(context.Background(), "a000000000000001", "a000000000000003", 0); trust != 0 {
+		t.Fatalf("trust before block = %v, want 0", trust)
+	}
+
+	// Mid-block, the second hop exists in the registry but queries
+	// still read the pre-block view.
+	node.trustViewState.applying.Add(1)
+	node.updateTrustRegistry(TrustTransaction{Truster: "a000000000000002", Trustee: "a000000000000003", TrustLevel: 0.5})
+	if trust, _, _ := node.ComputeRelationalTrust(context.Background(), "a000000000000001", "a000000000000003", 0); trust != 0 {
+		t.Fatalf("trust mid-block = %v, want 0", trust)
+	}
+	node.trustViewState.applying.Add(-1)
+	node.publishTrustView()
+
+	trust, path, _ := node.ComputeRelationalTrust(context.Background(), "a000000000000001", "a000000000000003", 0)
+	if trust != 0.45 || len(path) != 3 {
+		t.Fatalf("trust after block = %v via %v, want 0.45 over 2 hops", trust, path)
+	}
+}
+
+func TestTrustView_SkipsExpiredEdges(t *testing.T) {
+	node := newTestNode()
+	node.updateTrustRegistry(TrustTransaction{Truster: "b000000000000001", Trustee: "b000000000000002", TrustLevel: 0.8, ValidUntil: nowUnix() - 1})
+	node.updateTrustRegistry(TrustTransaction{Truster: "b000000000000001", Trustee: "b000000000000003", TrustLevel: 0.7, ValidUntil: nowUnix() + 3600})
+
+	got := node.GetDirectTrustees("b000000000000001")
+	if _, ok := got["b000000000000002"]; ok || got["b000000000000003"] != 0.7 || len(got) != 1 {
+		t.Fatalf("GetDirectTrustees = %v, want only the unexpired edge", got)
+	}
+}
+
+func TestTrustView_ConcurrentQueriesAndWrites(t *testing.T) {
+	node := newTestNode()
+	node.SeedSyntheticTrustGraph(300, 4, 2)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				_, _, _ = node.ComputeRelationalTrust(context.Background(), SyntheticQuid(w), SyntheticQuid(299-i), 0)
+			}
+		}(w)
+	}
+	for i := 0; i < 200; i++ {
+		node.updateTrustRegistry(TrustTransaction{Truster: SyntheticQuid(i), Trustee: SyntheticQuid(i + 1), TrustLevel: 0.9})
+	}
+	wg.Wait()
+
+	if trust, _, _ := node.ComputeRelationalTrust(context.Background(), SyntheticQuid(10), SyntheticQuid(11), 1); trust != 0.9 {
+		t.Fatalf("final direct trust = %v, want 0.9", trust)
+	}
+}
@@ -42,6 +42,12 @@ func nowNano() int64 {
 	return time.Now().UnixNano()
 }
 
+// nowTime returns the current time. Tests and replays can freeze
+// it via setTestClockNano.
+func nowTime() time.Time {
+	return time.Unix(0, nowNano())
+}
+
 // nowUnix returns the current time in Unix seconds. Tests can
 // freeze this via setTestClockNano.
 func nowUnix() int64 {
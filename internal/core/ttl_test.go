@@ -10,6 +10,7 @@
 package core
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -205,7 +206,7 @@ func TestComputeRelationalTrust_SkipsExpiredEdge(t *testing.T) {
 	seedTrustEdge(node, "A", "B", 1.0, future)
 	seedTrustEdge(node, "B", "C", 1.0, past)
 
-	trust, _, err := node.ComputeRelationalTrust("A", "C", 3)
+	trust, _, err := node.ComputeRelationalTrust(context.Background(), "A", "C", 3)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -221,7 +222,7 @@ func TestComputeRelationalTrust_HonorsFutureExpiry(t *testing.T) {
 	seedTrustEdge(node, "A", "B", 0.9, future)
 	seedTrustEdge(node, "B", "C", 0.8, future)
 
-	trust, path, err := node.ComputeRelationalTrust("A", "C", 3)
+	trust, path, err := node.ComputeRelationalTrust(context.Background(), "A", "C", 3)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -0,0 +1,166 @@
+// Per-transaction-type validation hooks.
+//
+// Domain operators embedding the node can register extra checks
+// for a transaction type, optionally scoped to one trust domain
+// (e.g. "titles in vehicles.example must be of type VEHICLE", or
+// "identities in kyc.example must carry a country attribute").
+// Hooks run at the end of ValidateTrustTransaction,
+// ValidateIdentityTransaction, ValidateTitleTransaction and
+// ValidateEventTransaction, after the built-in checks pass, so
+// they gate both mempool admission and block validation.
+//
+// Hooks are local policy: a peer without the same hooks will still
+// accept what this node rejects, so they belong on the validators
+// of the domain they constrain.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Registration errors.
+var (
+	ErrTxHookInvalid   = errors.New("tx hook: name and check are required")
+	ErrTxHookDuplicate = errors.New("tx hook: name already registered for this type")
+)
+
+// TxValidationHook is one registered check. Check receives the
+// typed transaction value (TrustTransaction, IdentityTransaction,
+// TitleTransaction, EventTransaction, or CustomTransaction for
+// TxTypeGeneric) and returns a non-nil error to reject it. An
+// empty Domain applies to every domain.
+type TxValidationHook struct {
+	Name   string
+	TxType TransactionType
+	Domain string
+	Check  func(tx interface{}) error
+}
+
+// TxValidationHooks is the hook registry. Owns its own lock.
+type TxValidationHooks struct {
+	mu    sync.RWMutex
+	hooks map[TransactionType][]TxValidationHook
+}
+
+// NewTxValidationHooks creates an empty registry.
+func NewTxValidationHooks() *TxValidationHooks {
+	return &TxValidationHooks{hooks: make(map[TransactionType][]TxValidationHook)}
+}
+
+// Register adds a hook. Names are unique per transaction type.
+func (h *TxValidationHooks) Register(hook TxValidationHook) error {
+	if hook.Name == "" || hook.Check == nil || hook.TxType == "" {
+		return ErrTxHookInvalid
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, existing := range h.hooks[hook.TxType] {
+		if existing.Name == hook.Name {
+			return ErrTxHookDuplicate
+		}
+	}
+	h.hooks[hook.TxType] = append(h.hooks[hook.TxType], hook)
+	return nil
+}
+
+// Unregister removes a hook and reports whether it existed.
+func (h *TxValidationHooks) Unregister(txType TransactionType, name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hooks := h.hooks[txType]
+	for i, existing := range hooks {
+		if existing.Name == name {
+			h.hooks[txType] = append(hooks[:i:i], hooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Run applies every matching hook in registration order and
+// returns the first rejection.
+func (h *TxValidationHooks) Run(txType TransactionType, domain string, tx interface{}) error {
+	h.mu.RLock()
+	hooks := h.hooks[txType]
+	h.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if hook.Domain != "" && hook.Domain != domain {
+			continue
+		}
+		if err := hook.Check(tx); err != nil {
+			return fmt.Errorf("%s: %w", hook.Name, err)
+		}
+	}
+	return nil
+}
+
+// RegisterTxValidationHook adds a hook to the node's registry.
+func (node *QuidnugNode) RegisterTxValidationHook(hook TxValidationHook) error {
+	return node.TxHooks.Register(hook)
+}
+
+// passesTxHooks runs the registered hooks for a transaction and
+// logs the rejection reason.
+func (node *QuidnugNode) passesTxHooks(txType TransactionType, domain, txID string, tx interface{}) bool {
+	return node.checkTxHooks(txType, domain, txID, tx) == nil
+}
+
+// checkTxHooks is passesTxHooks with the failing hook's reason.
+func (node *QuidnugNode) checkTxHooks(txType TransactionType, domain, txID string, tx interface{}) error {
+	if node.TxHooks == nil {
+		return nil
+	}
+	if err := node.TxHooks.Run(txType, domain, tx); err != nil {
+		logger.Warn("Transaction rejected by validation hook",
+			"txType", txType, "domain", domain, "txId", txID, "error", err)
+		return rejectErr(RejectHook, "", "rejected by validation hook", err)
+	}
+	return nil
+}
+
+// RequireIdentityAttributes returns a hook rejecting identities in
+// domain that lack any of the given attribute keys.
+func RequireIdentityAttributes(name, domain string, keys ...string) TxValidationHook {
+	return TxValidationHook{
+		Name:   name,
+		TxType: TxTypeIdentity,
+		Domain: domain,
+		Check: func(tx interface{}) error {
+			identity, ok := tx.(IdentityTransaction)
+			if !ok {
+				return nil
+			}
+			for _, key := range keys {
+				if _, present := identity.Attributes[key]; !present {
+					return fmt.Errorf("missing required attribute %q", key)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// RequireTitleType returns a hook rejecting titles in domain whose
+// TitleType is not one of allowed.
+func RequireTitleType(name, domain string, allowed ...string) TxValidationHook {
+	return TxValidationHook{
+		Name:   name,
+		TxType: TxTypeTitle,
+		Domain: domain,
+		Check: func(tx interface{}) error {
+			title, ok := tx.(TitleTransaction)
+			if !ok {
+				return nil
+			}
+			for _, t := range allowed {
+				if title.TitleType == t {
+					return nil
+				}
+			}
+			return fmt.Errorf("title type %q not allowed", title.TitleType)
+		},
+	}
+}
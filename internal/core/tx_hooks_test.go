@@ -0,0 +1,74 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxValidationHooks_Registry(t *testing.T) {
+	h := NewTxValidationHooks()
+	ok := func(interface{}) error { return nil }
+
+	if err := h.Register(TxValidationHook{Name: "x", TxType: TxTypeTitle}); !errors.Is(err, ErrTxHookInvalid) {
+		t.Errorf("hook without Check should be rejected, got %v", err)
+	}
+	if err := h.Register(TxValidationHook{Name: "x", TxType: TxTypeTitle, Check: ok}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Register(TxValidationHook{Name: "x", TxType: TxTypeTitle, Check: ok}); !errors.Is(err, ErrTxHookDuplicate) {
+		t.Errorf("duplicate name should be rejected, got %v", err)
+	}
+	if err := h.Register(TxValidationHook{Name: "x", TxType: TxTypeIdentity, Check: ok}); err != nil {
+		t.Errorf("same name on another type should be allowed, got %v", err)
+	}
+	if !h.Unregister(TxTypeTitle, "x") || h.Unregister(TxTypeTitle, "x") {
+		t.Error("unregister should succeed exactly once")
+	}
+}
+
+func TestValidateIdentityTransaction_RunsHooks(t *testing.T) {
+	node := newTestNode()
+	if err := node.RegisterTxValidationHook(RequireIdentityAttributes("kyc-country", "test.domain.com", "country")); err != nil {
+		t.Fatal(err)
+	}
+
+	identity := func(attrs map[string]interface{}) IdentityTransaction {
+		return signIdentityTx(node, IdentityTransaction{
+			BaseTransaction: BaseTransaction{
+				ID:          "tx_identity_hooked",
+				Type:        TxTypeIdentity,
+				TrustDomain: "test.domain.com",
+				Timestamp:   1000000,
+			},
+			QuidID:      "000000000000000b",
+			Name:        "Hooked",
+			Creator:     "00000000000000cc",
+			UpdateNonce: 1,
+			Attributes:  attrs,
+		})
+	}
+
+	if node.ValidateIdentityTransaction(identity(nil)) {
+		t.Error("identity missing the required attribute should be rejected")
+	}
+	if !node.ValidateIdentityTransaction(identity(map[string]interface{}{"country": "NZ"})) {
+		t.Error("identity with the required attribute should pass")
+	}
+
+	// Hooks scoped to another domain don't apply.
+	node.TxHooks.Unregister(TxTypeIdentity, "kyc-country")
+	node.RegisterTxValidationHook(RequireIdentityAttributes("other", "other.example", "country"))
+	if !node.ValidateIdentityTransaction(identity(nil)) {
+		t.Error("hook for another domain should not apply")
+	}
+}
+
+func TestRequireTitleType(t *testing.T) {
+	hook := RequireTitleType("vehicles", "vehicles.example", "VEHICLE")
+	if err := hook.Check(TitleTransaction{TitleType: "VEHICLE"}); err != nil {
+		t.Errorf("allowed type rejected: %v", err)
+	}
+	if err := hook.Check(TitleTransaction{TitleType: "LAND"}); err == nil {
+		t.Error("disallowed type accepted")
+	}
+}
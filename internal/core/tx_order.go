@@ -0,0 +1,125 @@
+// Canonical intra-block transaction order.
+//
+// GenerateBlock used to seal transactions in whatever order they
+// sat in the mempool, so two validators holding the same set could
+// produce blocks that apply it differently. Blocks are now sealed
+// in a canonical order:
+//
+//	type, sender, nonce, tx ID
+//
+// compared as strings, strings, integers and strings respectively.
+// The sender is the quid of the signing public key; the nonce is
+// whichever of nonce / updateNonce / sequence the transaction type
+// carries (0 if none). Producers always sort. Receivers enforce the
+// order once the `require_canonical_tx_order` fork (QDP-0009) is
+// active, so blocks sealed by older producers stay acceptable until
+// the network has upgraded.
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// txOrderKey is the canonical sort key of one block transaction.
+type txOrderKey struct {
+	txType string
+	sender string
+	nonce  int64
+	id     string
+}
+
+func (a txOrderKey) less(b txOrderKey) bool {
+	if a.txType != b.txType {
+		return a.txType < b.txType
+	}
+	if a.sender != b.sender {
+		return a.sender < b.sender
+	}
+	if a.nonce != b.nonce {
+		return a.nonce < b.nonce
+	}
+	return a.id < b.id
+}
+
+// txOrderNonceFields are the per-type counters, in lookup order.
+var txOrderNonceFields = []string{"nonce", "updateNonce", "sequence"}
+
+// canonicalTxKey derives the sort key for a transaction in either
+// its typed form (mempool) or its decoded-JSON form (received
+// block). Both marshal to the same JSON, so both yield the same key.
+func canonicalTxKey(tx interface{}) txOrderKey {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return txOrderKey{}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return txOrderKey{}
+	}
+
+	var key txOrderKey
+	_ = json.Unmarshal(fields["type"], &key.txType)
+	_ = json.Unmarshal(fields["id"], &key.id)
+	var pubKey string
+	if json.Unmarshal(fields["publicKey"], &pubKey) == nil && pubKey != "" {
+		key.sender = QuidIDFromPublicKeyHex(pubKey)
+	}
+	for _, name := range txOrderNonceFields {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var n json.Number
+		if dec.Decode(&n) != nil {
+			continue
+		}
+		if v, err := n.Int64(); err == nil {
+			key.nonce = v
+			break
+		}
+	}
+	return key
+}
+
+// sortTransactionsCanonical orders txs in place.
+func sortTransactionsCanonical(txs []interface{}) {
+	keys := make([]txOrderKey, len(txs))
+	for i, tx := range txs {
+		keys[i] = canonicalTxKey(tx)
+	}
+	sort.Stable(canonicalTxSorter{txs: txs, keys: keys})
+}
+
+// isCanonicalTxOrder reports whether txs are already in canonical
+// order.
+func isCanonicalTxOrder(txs []interface{}) bool {
+	if len(txs) < 2 {
+		return true
+	}
+	prev := canonicalTxKey(txs[0])
+	for _, tx := range txs[1:] {
+		key := canonicalTxKey(tx)
+		if key.less(prev) {
+			return false
+		}
+		prev = key
+	}
+	return true
+}
+
+// canonicalTxSorter sorts transactions by precomputed keys.
+type canonicalTxSorter struct {
+	txs  []interface{}
+	keys []txOrderKey
+}
+
+func (s canonicalTxSorter) Len() int           { return len(s.txs) }
+func (s canonicalTxSorter) Less(i, j int) bool { return s.keys[i].less(s.keys[j]) }
+func (s canonicalTxSorter) Swap(i, j int) {
+	s.txs[i], s.txs[j] = s.txs[j], s.txs[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// orderTestTrust builds a signed trust tx from the node.
+func orderTestTrust(node *QuidnugNode, id string, nonce int64) TrustTransaction {
+	return signTrustTx(node, TrustTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          id,
+			Type:        TxTypeTrust,
+			TrustDomain: "test.domain.com",
+			Timestamp:   time.Now().Unix(),
+		},
+		Truster:    node.NodeID,
+		Trustee:    "0000000000000002",
+		TrustLevel: 0.5,
+		Nonce:      nonce,
+	})
+}
+
+func TestCanonicalTxKey_TypedAndDecodedAgree(t *testing.T) {
+	node := newTestNode()
+	tx := orderTestTrust(node, "t-1", 7)
+
+	data, _ := json.Marshal(tx)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	typed, generic := canonicalTxKey(tx), canonicalTxKey(decoded)
+	if typed != generic {
+		t.Fatalf("keys differ: %+v vs %+v", typed, generic)
+	}
+	if typed.txType != string(TxTypeTrust) || typed.sender != node.NodeID || typed.nonce != 7 || typed.id != "t-1" {
+		t.Fatalf("unexpected key %+v", typed)
+	}
+}
+
+func TestSortTransactionsCanonical(t *testing.T) {
+	node := newTestNode()
+	event := signEventTx(node, EventTransaction{
+		BaseTransaction: BaseTransaction{ID: "e-1", Type: TxTypeEvent, TrustDomain: "test.domain.com"},
+		SubjectID:       "0000000000000001",
+		SubjectType:     "QUID",
+		Sequence:        1,
+		EventType:       "note",
+	})
+	txs := []interface{}{
+		orderTestTrust(node, "t-b", 2),
+		orderTestTrust(node, "t-c", 10),
+		event,
+		orderTestTrust(node, "t-a", 2),
+	}
+	sortTransactionsCanonical(txs)
+
+	var got []string
+	for _, tx := range txs {
+		got = append(got, canonicalTxKey(tx).id)
+	}
+	// EVENT < TRUST; within a sender, nonce 2 < 10 numerically and
+	// equal nonces fall back to tx ID.
+	want := []string{"e-1", "t-a", "t-b", "t-c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+	if !isCanonicalTxOrder(txs) {
+		t.Fatal("sorted slice should be canonical")
+	}
+	txs[0], txs[3] = txs[3], txs[0]
+	if isCanonicalTxOrder(txs) {
+		t.Fatal("swapped slice should not be canonical")
+	}
+}
+
+func TestGenerateBlock_SealsCanonicalOrder(t *testing.T) {
+	node := newTestNode()
+	node.PendingTxs = []interface{}{
+		orderTestTrust(node, "t-3", 3),
+		orderTestTrust(node, "t-1", 1),
+		orderTestTrust(node, "t-2", 2),
+	}
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if len(block.Transactions) != 3 || !isCanonicalTxOrder(block.Transactions) {
+		t.Fatalf("block not sealed in canonical order: %+v", block.Transactions)
+	}
+}
+
+func TestValidateBlockTiered_EnforcesOrderAfterFork(t *testing.T) {
+	node := newTestNode()
+	domain := node.TrustDomains["test.domain.com"]
+	domain.ValidatorPublicKeys = map[string]string{node.NodeID: node.GetPublicKeyHex()}
+	node.TrustDomains["test.domain.com"] = domain
+	node.PendingTxs = []interface{}{
+		orderTestTrust(node, "t-1", 1),
+		orderTestTrust(node, "t-2", 2),
+	}
+	block, err := node.GenerateBlock("test.domain.com")
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+
+	// Re-seal with the transactions swapped.
+	swapped := *block
+	swapped.Transactions = []interface{}{block.Transactions[1], block.Transactions[0]}
+	if root, err := MerkleRoot(swapped.Transactions); err == nil {
+		swapped.TransactionsRoot = root
+	}
+	signBlock(node, &swapped)
+
+	if got := node.ValidateBlockTiered(swapped); got != BlockTrusted {
+		t.Fatalf("pre-fork: expected BlockTrusted, got %v", got)
+	}
+
+	node.activateFeature("require_canonical_tx_order")
+	if !node.RequireCanonicalTxOrder {
+		t.Fatal("fork activation should set RequireCanonicalTxOrder")
+	}
+	if got := node.ValidateBlockTiered(swapped); got != BlockInvalid {
+		t.Fatalf("post-fork: expected BlockInvalid, got %v", got)
+	}
+	if got := node.ValidateBlockTiered(*block); got != BlockTrusted {
+		t.Fatalf("post-fork: canonical block expected BlockTrusted, got %v", got)
+	}
+}
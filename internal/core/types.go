@@ -25,6 +25,40 @@ const (
 	// read time to honor DMCA / court orders / GDPR erasure /
 	// CSAM takedowns / operator policy.
 	TxTypeModerationAction TransactionType = "MODERATION_ACTION"
+	// TxTypeNameRegistration claims, updates, or releases a
+	// human-readable name scoped to a trust domain, resolving to
+	// a quid. See naming.go.
+	TxTypeNameRegistration TransactionType = "NAME_REGISTRATION"
+	// TxTypeLien records or releases a third-party encumbrance
+	// on a title. See liens.go.
+	TxTypeLien TransactionType = "LIEN"
+	// TxTypeSuccession hands an inactive quid to the successor it
+	// named. See succession.go.
+	TxTypeSuccession TransactionType = "SUCCESSION"
+	// TxTypeTransferApproval approves or rejects a pending
+	// conditional title transfer. See conditional_transfer.go.
+	TxTypeTransferApproval TransactionType = "TRANSFER_APPROVAL"
+	// TxTypeTitleRestructure splits one title into several or
+	// merges several into one, recording lineage. See
+	// title_restructure.go.
+	TxTypeTitleRestructure TransactionType = "TITLE_RESTRUCTURE"
+	// TxTypeTitleDispute files or resolves a dispute against a
+	// title. See title_dispute.go.
+	TxTypeTitleDispute TransactionType = "TITLE_DISPUTE"
+	// TxTypeDomainJoin admits a node to a domain's validator set
+	// under a quorum of existing validators. See domain_join.go.
+	TxTypeDomainJoin TransactionType = "DOMAIN_JOIN"
+	// TxTypeMisbehaviorReport submits signed-block evidence that a
+	// validator equivocated or signed an invalid block. See
+	// misbehavior.go.
+	TxTypeMisbehaviorReport TransactionType = "MISBEHAVIOR_REPORT"
+	// TxTypeCheckpoint records a block hash and state root that a
+	// quorum of the domain's validators co-signed. See checkpoint.go.
+	TxTypeCheckpoint TransactionType = "CHECKPOINT"
+	// TxTypeDomainControl records that a validator saw the trust
+	// domain's DNS name prove control for a quid. See
+	// domain_control.go.
+	TxTypeDomainControl TransactionType = "DOMAIN_CONTROL"
 	// QDP-0017 data-subject-rights transactions.
 	//
 	// TxTypeDataSubjectRequest is a signed request from a
@@ -134,6 +168,23 @@ type BaseTransaction struct {
 	Timestamp   int64           `json:"timestamp"`
 	Signature   string          `json:"signature"`
 	PublicKey   string          `json:"publicKey"`
+	// PoWStamp is an optional proof-of-work stamp required by
+	// domains with an anti-spam policy (see antispam.go). It is
+	// part of the signable data; omitted when empty so existing
+	// signatures are unaffected.
+	PoWStamp string `json:"powStamp,omitempty"`
+	// SchemaVersion selects how the signable bytes are encoded
+	// (see tx_schema.go). Omitted for version 1, the original
+	// encoding.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// AtomicGroup marks the transaction as a member of a group
+	// that is sealed and applied together or not at all (see
+	// atomic_group.go). Signed with the rest; omitted otherwise.
+	AtomicGroup *AtomicGroupRef `json:"atomicGroup,omitempty"`
+	// blockTime is the timestamp of the block the transaction is
+	// being validated in (set by ValidateBlockTiered), or zero when
+	// it is validated for the mempool. Not part of the wire form.
+	blockTime int64
 }
 
 // TrustTransaction establishes trust between entities with a specific trust level
@@ -145,6 +196,13 @@ type TrustTransaction struct {
 	Nonce       int64   `json:"nonce"`
 	Description string  `json:"description,omitempty"`
 	ValidUntil  int64   `json:"validUntil,omitempty"`
+
+	// Evidence cites what the claim rests on; see trust_evidence.go.
+	Evidence []TrustEvidence `json:"evidence,omitempty"`
+
+	// Context tags the activity the trust covers, e.g. "payments";
+	// see trust_scope.go.
+	Context string `json:"context,omitempty"`
 }
 
 // IdentityTransaction declares or defines a quid in the system
@@ -164,6 +222,27 @@ type IdentityTransaction struct {
 	// primary domain. Optional for backward compatibility with
 	// identity records written before H4.
 	HomeDomain string `json:"homeDomain,omitempty"`
+
+	// Kind is empty for an ordinary quid or "organization"; an
+	// organization names who may sign for it in Signers. See
+	// organization.go.
+	Kind    string            `json:"kind,omitempty"`
+	Signers []DelegatedSigner `json:"signers,omitempty"`
+
+	// Guardians, on a first registration only, installs the quid's
+	// recovery guardians; each consents in GuardianConsents. See
+	// guardian_identity.go.
+	Guardians        *GuardianSet        `json:"guardians,omitempty"`
+	GuardianConsents []GuardianSignature `json:"guardianConsents,omitempty"`
+
+	// Succession names who takes over the quid after a stretch of
+	// inactivity. See succession.go.
+	Succession *SuccessionPlan `json:"succession,omitempty"`
+
+	// AssetClass, when the quid stands for an asset, names the
+	// domain's class whose attribute rules it follows. Fixed at
+	// first registration. See asset_class.go.
+	AssetClass string `json:"assetClass,omitempty"`
 }
 
 // OwnershipStake represents a single ownership claim
@@ -171,6 +250,9 @@ type OwnershipStake struct {
 	OwnerID    string  `json:"ownerId"`
 	Percentage float64 `json:"percentage"`
 	StakeType  string  `json:"stakeType,omitempty"`
+	// ExpiresAt (Unix seconds) ends a lease, usufruct or lien
+	// stake; leases require it. See stake_types.go.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
 }
 
 // TitleTransaction defines ownership relationships between quids
@@ -182,6 +264,19 @@ type TitleTransaction struct {
 	Signatures     map[string]string `json:"signatures"`
 	ExpiryDate     int64             `json:"expiryDate,omitempty"`
 	TitleType      string            `json:"titleType,omitempty"`
+	// ExpiryAction says what happens at ExpiryDate: "lapse" (the
+	// default) or "revert" to PreviousOwners. See title_expiry.go.
+	ExpiryAction string `json:"expiryAction,omitempty"`
+	// Conditions, when set, hold the transfer in escrow after
+	// commit until its time lock and approvals resolve. See
+	// conditional_transfer.go.
+	Conditions *TransferConditions `json:"conditions,omitempty"`
+	// TransferPolicy, when set, says whose co-signatures the next
+	// transfer of this title needs. See title_transfer_policy.go.
+	TransferPolicy *TitleTransferPolicy `json:"transferPolicy,omitempty"`
+	// AssetClass names the domain's class whose title and transfer
+	// rules apply; it can't change after issue. See asset_class.go.
+	AssetClass string `json:"assetClass,omitempty"`
 }
 
 // EventTransaction represents an event in an append-only stream for a quid or title
@@ -603,6 +698,17 @@ type Block struct {
 	// reject blocks with empty root; pre-activation receivers
 	// ignore the field.
 	TransactionsRoot string `json:"transactionsRoot,omitempty"`
+
+	// Pruned marks a header whose transactions were moved to the
+	// block archive (see block_archive.go). Not part of the hash
+	// or signable data; Hash and TransactionsRoot still commit to
+	// the archived body.
+	Pruned bool `json:"pruned,omitempty"`
+
+	// ProtocolVersion is the protocol version the block was
+	// produced under; empty means 1. Part of the hash and
+	// signable data when set. See protocol_version.go.
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
 }
 
 // TrustProof implements the proof of trust system
@@ -624,6 +730,10 @@ type Node struct {
 	IsValidator      bool     `json:"isValidator"`
 	LastSeen         int64    `json:"lastSeen"`
 	ConnectionStatus string   `json:"connectionStatus"`
+
+	// What the node advertised in its /info handshake; empty for
+	// peers not yet handshaken and for pre-roles nodes.
+	PeerCapabilities
 }
 
 // TrustDomain represents a domain that this node manages or interacts with
@@ -684,6 +794,25 @@ type TrustDomain struct {
 	// governance authority here. Empty unless
 	// ParentDelegationMode == "delegated" or "inherit".
 	DelegatedFrom string `json:"delegatedFrom,omitempty"`
+
+	// AntiSpam is the optional admission-cost policy for this
+	// domain (proof-of-work or bond-asset stake). Nil means no
+	// policy. See antispam.go.
+	AntiSpam *AntiSpamPolicy `json:"antiSpam,omitempty"`
+
+	// BlockRetention overrides the node's block_retention for
+	// this domain: how many of its newest full blocks stay in
+	// the chain before older ones are archived. Zero inherits
+	// the node setting.
+	BlockRetention int `json:"blockRetention,omitempty"`
+
+	// MaxBlockTransactions and MaxBlockBytes override the node's
+	// block_max_transactions and block_max_bytes for this domain.
+	// Every validator and receiver applies the domain's values, so
+	// set them here rather than per node when the domain's blocks
+	// must be held to one limit. Zero inherits the node setting.
+	MaxBlockTransactions int `json:"maxBlockTransactions,omitempty"`
+	MaxBlockBytes        int `json:"maxBlockBytes,omitempty"`
 }
 
 // Governance role constants for QDP-0012.
@@ -766,13 +895,46 @@ func (td *TrustDomain) GovernorQuorumWeight() float64 {
 	return total * q
 }
 
-// RelationalTrustQuery represents a query for trust between two quids
+// RelationalTrustQuery represents a query for trust between two quids.
+// Trust is computed over edges set in Domain ("default" when empty)
+// unless CrossDomain asks for the global graph, and may be filtered by
+// Context or weighted by ContextWeights; see trust_scope.go.
+// Overlay, when set, patches the observer's direct edges for this query
+// only; see trust_overlay.go.
 type RelationalTrustQuery struct {
-	Observer          string `json:"observer"`
-	Target            string `json:"target"`
-	Domain            string `json:"domain,omitempty"`
-	MaxDepth          int    `json:"maxDepth,omitempty"`
-	IncludeUnverified bool   `json:"includeUnverified,omitempty"`
+	Observer          string             `json:"observer"`
+	Target            string             `json:"target"`
+	Domain            string             `json:"domain,omitempty"`
+	CrossDomain       bool               `json:"crossDomain,omitempty"`
+	Context           string             `json:"context,omitempty"`
+	ContextWeights    map[string]float64 `json:"contextWeights,omitempty"`
+	MaxDepth          int                `json:"maxDepth,omitempty"`
+	IncludeUnverified bool               `json:"includeUnverified,omitempty"`
+	Overlay           *TrustOverlay      `json:"overlay,omitempty"`
+	// MaxMillis is the caller's time budget; see trust_budget.go.
+	MaxMillis int `json:"maxMillis,omitempty"`
+}
+
+// TrustBatchPair is one (observer, target) pair in a batch query.
+type TrustBatchPair struct {
+	Observer string `json:"observer"`
+	Target   string `json:"target"`
+}
+
+// RelationalTrustBatchQuery asks for many relational trust results
+// at once: every entry in Targets from Observer, plus any explicit
+// Pairs. Results come back in that order.
+type RelationalTrustBatchQuery struct {
+	Observer       string             `json:"observer,omitempty"`
+	Targets        []string           `json:"targets,omitempty"`
+	Pairs          []TrustBatchPair   `json:"pairs,omitempty"`
+	Domain         string             `json:"domain,omitempty"`
+	CrossDomain    bool               `json:"crossDomain,omitempty"`
+	Context        string             `json:"context,omitempty"`
+	ContextWeights map[string]float64 `json:"contextWeights,omitempty"`
+	MaxDepth       int                `json:"maxDepth,omitempty"`
+	// MaxMillis budgets the whole batch.
+	MaxMillis int `json:"maxMillis,omitempty"`
 }
 
 // RelationalTrustResult represents the result of a relational trust query
@@ -783,6 +945,13 @@ type RelationalTrustResult struct {
 	TrustPath  []string `json:"trustPath,omitempty"`
 	PathDepth  int      `json:"pathDepth"`
 	Domain     string   `json:"domain,omitempty"`
+	// CrossDomain is set when the result was computed over edges
+	// from every domain rather than Domain alone.
+	CrossDomain bool `json:"crossDomain,omitempty"`
+	// Truncated is set when the search stopped early, on its time
+	// budget or the graph size limits; TrustLevel is then the best
+	// found before it stopped.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // BlockAcceptance represents the tiered acceptance level of a block
@@ -804,6 +973,8 @@ type TrustEdge struct {
 	ValidatorQuid string  `json:"validatorQuid"` // Quid of validator who signed the block
 	Verified      bool    `json:"verified"`      // True if from a trusted validator
 	Timestamp     int64   `json:"timestamp"`
+	Domain        string  `json:"domain,omitempty"` // Trust domain of the recording TRUST tx
+	Context       string  `json:"context,omitempty"` // Context tag of the recording TRUST tx
 }
 
 // EnhancedTrustResult extends RelationalTrustResult with provenance
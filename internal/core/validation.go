@@ -12,6 +12,12 @@ import (
 
 // ValidateTrustTransaction validates a trust transaction
 func (node *QuidnugNode) ValidateTrustTransaction(tx TrustTransaction) bool {
+	return node.checkTrustTransaction(tx) == nil
+}
+
+// checkTrustTransaction is ValidateTrustTransaction with the reason
+// for a rejection.
+func (node *QuidnugNode) checkTrustTransaction(tx TrustTransaction) error {
 	// Check if transaction belongs to a known trust domain
 	node.TrustDomainsMutex.RLock()
 	_, domainExists := node.TrustDomains[tx.TrustDomain]
@@ -19,13 +25,13 @@ func (node *QuidnugNode) ValidateTrustTransaction(tx TrustTransaction) bool {
 
 	if !domainExists && tx.TrustDomain != "" {
 		logger.Warn("Trust transaction from unknown trust domain", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectUnknownDomain, "trustDomain", "unknown trust domain %q", tx.TrustDomain)
 	}
 
 	// Validate nonce is present and positive
 	if tx.Nonce <= 0 {
 		logger.Warn("Invalid nonce: must be positive", "nonce", tx.Nonce, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidNonce, "nonce", "nonce must be positive")
 	}
 
 	// Check nonce against registry for replay protection
@@ -45,19 +51,19 @@ func (node *QuidnugNode) ValidateTrustTransaction(tx TrustTransaction) bool {
 			"truster", tx.Truster,
 			"trustee", tx.Trustee,
 			"txId", tx.ID)
-		return false
+		return reject(RejectNonceTooLow, "nonce", "nonce %d must be greater than the current nonce %d", tx.Nonce, currentNonce)
 	}
 
 	// Verify trust level is not NaN or Inf
 	if math.IsNaN(tx.TrustLevel) || math.IsInf(tx.TrustLevel, 0) {
 		logger.Warn("Invalid trust level: NaN or Inf", "trustLevel", tx.TrustLevel, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidTrustLevel, "trustLevel", "trust level must be a finite number")
 	}
 
 	// Verify trust level is in valid range (0.0 to 1.0)
 	if tx.TrustLevel < 0.0 || tx.TrustLevel > 1.0 {
 		logger.Warn("Invalid trust level", "trustLevel", tx.TrustLevel, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidTrustLevel, "trustLevel", "trust level %v is outside [0, 1]", tx.TrustLevel)
 	}
 
 	// QDP-0022: reject edges that are already expired at
@@ -72,30 +78,40 @@ func (node *QuidnugNode) ValidateTrustTransaction(tx TrustTransaction) bool {
 		if tx.ValidUntil <= refTime {
 			logger.Warn("Trust transaction already expired at submission",
 				"validUntil", tx.ValidUntil, "refTime", refTime, "txId", tx.ID)
-			return false
+			return reject(RejectAlreadyExpired, "validUntil", "validUntil %d is not after %d", tx.ValidUntil, refTime)
 		}
 	}
 
 	// Validate quid ID formats
 	if tx.Truster != "" && !IsValidQuidID(tx.Truster) {
 		logger.Warn("Invalid truster quid ID format", "truster", tx.Truster, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidQuidID, "truster", "truster %q is not a valid quid ID", tx.Truster)
 	}
 
 	if tx.Trustee != "" && !IsValidQuidID(tx.Trustee) {
 		logger.Warn("Invalid trustee quid ID format", "trustee", tx.Trustee, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidQuidID, "trustee", "trustee %q is not a valid quid ID", tx.Trustee)
 	}
 
 	// Validate string field lengths and control characters
 	if tx.TrustDomain != "" && !ValidateStringField(tx.TrustDomain, MaxDomainLength) {
 		logger.Warn("Invalid trust domain: too long or contains control characters", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "trustDomain", "trust domain is too long or contains control characters")
 	}
 
 	if tx.Description != "" && !ValidateStringField(tx.Description, MaxDescriptionLength) {
 		logger.Warn("Invalid description: too long or contains control characters", "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "description", "description is too long or contains control characters")
+	}
+
+	if tx.Context != "" && !ValidTrustContext(tx.Context) {
+		logger.Warn("Invalid trust context tag", "context", tx.Context, "txId", tx.ID)
+		return reject(RejectInvalidField, "context", "unknown trust context tag %q", tx.Context)
+	}
+
+	if err := validateTrustEvidence(tx.Evidence); err != nil {
+		logger.Warn("Invalid trust evidence", "txId", tx.ID, "error", err)
+		return rejectErr(RejectInvalidField, "evidence", "invalid evidence", err)
 	}
 
 	// Check if truster exists in identity registry
@@ -119,28 +135,40 @@ func (node *QuidnugNode) ValidateTrustTransaction(tx TrustTransaction) bool {
 	// Verify signature
 	if tx.Signature == "" || tx.PublicKey == "" {
 		logger.Warn("Missing signature or public key in trust transaction", "txId", tx.ID)
-		return false
+		return reject(RejectMissingSignature, "signature", "signature and public key are required")
 	}
 
 	// Get signable data (transaction with signature field cleared)
 	txCopy := tx
 	txCopy.Signature = ""
-	signableData, err := json.Marshal(txCopy)
+	signableData, err := txSignableBytes(txCopy)
 	if err != nil {
 		logger.Error("Failed to marshal transaction for signature verification", "txId", tx.ID, "error", err)
-		return false
+		return rejectErr(RejectEncoding, "", "cannot encode transaction for signing", err)
 	}
 
 	if !VerifySignature(tx.PublicKey, signableData, tx.Signature) {
 		logger.Warn("Invalid signature in trust transaction", "txId", tx.ID)
-		return false
+		return reject(RejectBadSignature, "signature", "signature does not verify against the public key")
 	}
 
-	return true
+	// Verify signer is the truster, or signs for it
+	if !node.keySignsFor(tx.Truster, tx.PublicKey, TxTypeTrust, tx.delegationTime()) {
+		logger.Warn("Signer is not the truster", "truster", tx.Truster, "txId", tx.ID)
+		return reject(RejectNotOwner, "publicKey", "signer is not the truster and does not sign for it")
+	}
+
+	return node.checkTxHooks(TxTypeTrust, tx.TrustDomain, tx.ID, tx)
 }
 
 // ValidateIdentityTransaction validates an identity transaction
 func (node *QuidnugNode) ValidateIdentityTransaction(tx IdentityTransaction) bool {
+	return node.checkIdentityTransaction(tx) == nil
+}
+
+// checkIdentityTransaction is ValidateIdentityTransaction with the reason
+// for a rejection.
+func (node *QuidnugNode) checkIdentityTransaction(tx IdentityTransaction) error {
 	// Check if transaction belongs to a known trust domain
 	node.TrustDomainsMutex.RLock()
 	_, domainExists := node.TrustDomains[tx.TrustDomain]
@@ -148,34 +176,39 @@ func (node *QuidnugNode) ValidateIdentityTransaction(tx IdentityTransaction) boo
 
 	if !domainExists && tx.TrustDomain != "" {
 		logger.Warn("Identity transaction from unknown trust domain", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectUnknownDomain, "trustDomain", "unknown trust domain %q", tx.TrustDomain)
 	}
 
 	// Validate quid ID formats
 	if tx.QuidID != "" && !IsValidQuidID(tx.QuidID) {
 		logger.Warn("Invalid quid ID format", "quidId", tx.QuidID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidQuidID, "quidId", "quidId %q is not a valid quid ID", tx.QuidID)
 	}
 
 	if tx.Creator != "" && !IsValidQuidID(tx.Creator) {
 		logger.Warn("Invalid creator quid ID format", "creator", tx.Creator, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidQuidID, "creator", "creator %q is not a valid quid ID", tx.Creator)
 	}
 
 	// Validate string field lengths and control characters
 	if tx.TrustDomain != "" && !ValidateStringField(tx.TrustDomain, MaxDomainLength) {
 		logger.Warn("Invalid trust domain: too long or contains control characters", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "trustDomain", "trust domain is too long or contains control characters")
 	}
 
 	if tx.Name != "" && !ValidateStringField(tx.Name, MaxNameLength) {
 		logger.Warn("Invalid name: too long or contains control characters", "quidId", tx.QuidID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "name", "name is too long or contains control characters")
 	}
 
 	if tx.Description != "" && !ValidateStringField(tx.Description, MaxDescriptionLength) {
 		logger.Warn("Invalid description: too long or contains control characters", "quidId", tx.QuidID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "description", "description is too long or contains control characters")
+	}
+
+	if err := validateOrganization(tx); err != nil {
+		logger.Warn("Invalid organization identity", "quidId", tx.QuidID, "txId", tx.ID, "error", err)
+		return rejectErr(RejectInvalidField, "", "invalid organization identity", err)
 	}
 
 	// Check if this is an update to an existing identity
@@ -191,7 +224,7 @@ func (node *QuidnugNode) ValidateIdentityTransaction(tx IdentityTransaction) boo
 				"providedNonce", tx.UpdateNonce,
 				"currentNonce", existingIdentity.UpdateNonce,
 				"txId", tx.ID)
-			return false
+			return reject(RejectNonceTooLow, "updateNonce", "updateNonce %d must be greater than the current %d", tx.UpdateNonce, existingIdentity.UpdateNonce)
 		}
 
 		// Also verify that the creator is the same as original creator
@@ -201,31 +234,70 @@ func (node *QuidnugNode) ValidateIdentityTransaction(tx IdentityTransaction) boo
 				"originalCreator", existingIdentity.Creator,
 				"quidId", tx.QuidID,
 				"txId", tx.ID)
-			return false
+			return reject(RejectCreatorMismatch, "creator", "creator %q does not match the identity's creator", tx.Creator)
+		}
+
+		if err := node.checkIdentityAssetClass(tx, &existingIdentity); err != nil {
+			logger.Warn("Identity update violates asset class rules", "quidId", tx.QuidID, "txId", tx.ID, "error", err)
+			return rejectErr(RejectAssetClass, "assetClass", "asset class rules", err)
+		}
+
+		if tx.Kind != existingIdentity.Kind {
+			logger.Warn("Identity update changes identity kind",
+				"providedKind", tx.Kind,
+				"originalKind", existingIdentity.Kind,
+				"quidId", tx.QuidID,
+				"txId", tx.ID)
+			return reject(RejectImmutableField, "kind", "an identity's kind cannot change")
 		}
 	}
 
+	if !exists {
+		if err := node.checkIdentityAssetClass(tx, nil); err != nil {
+			logger.Warn("Identity violates asset class rules", "quidId", tx.QuidID, "txId", tx.ID, "error", err)
+			return rejectErr(RejectAssetClass, "assetClass", "asset class rules", err)
+		}
+	}
+
+	if tx.Succession != nil {
+		if err := tx.Succession.validate(tx.QuidID); err != nil {
+			logger.Warn("Invalid succession plan", "quidId", tx.QuidID, "txId", tx.ID, "error", err)
+			return rejectErr(RejectInvalidField, "succession", "invalid succession plan", err)
+		}
+	}
+
+	if err := validateIdentityGuardians(node.NonceLedger, tx, exists); err != nil {
+		logger.Warn("Invalid guardians on identity", "quidId", tx.QuidID, "txId", tx.ID, "error", err)
+		return rejectErr(RejectInvalidField, "guardians", "invalid guardians", err)
+	}
+
 	// Verify signature
 	if tx.Signature == "" || tx.PublicKey == "" {
 		logger.Warn("Missing signature or public key in identity transaction", "txId", tx.ID, "quidId", tx.QuidID)
-		return false
+		return reject(RejectMissingSignature, "signature", "signature and public key are required")
 	}
 
 	// Get signable data (transaction with signature field cleared)
 	txCopy := tx
 	txCopy.Signature = ""
-	signableData, err := json.Marshal(txCopy)
+	signableData, err := txSignableBytes(txCopy)
 	if err != nil {
 		logger.Error("Failed to marshal transaction for signature verification", "txId", tx.ID, "error", err)
-		return false
+		return rejectErr(RejectEncoding, "", "cannot encode transaction for signing", err)
 	}
 
 	if !VerifySignature(tx.PublicKey, signableData, tx.Signature) {
 		logger.Warn("Invalid signature in identity transaction", "txId", tx.ID, "quidId", tx.QuidID)
-		return false
+		return reject(RejectBadSignature, "signature", "signature does not verify against the public key")
 	}
 
-	return true
+	// Verify signer is the creator, or signs for it
+	if !node.keySignsFor(tx.Creator, tx.PublicKey, TxTypeIdentity, tx.delegationTime()) {
+		logger.Warn("Signer is not the identity's creator", "creator", tx.Creator, "quidId", tx.QuidID, "txId", tx.ID)
+		return reject(RejectNotOwner, "publicKey", "signer is not the creator and does not sign for it")
+	}
+
+	return node.checkTxHooks(TxTypeIdentity, tx.TrustDomain, tx.ID, tx)
 }
 
 // MaxEventTypeLength is the maximum length for event type field
@@ -236,10 +308,23 @@ const MaxPayloadSize = 64 * 1024
 
 // ValidateEventTransaction validates an event transaction
 func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
+	return node.checkEventTransaction(tx) == nil
+}
+
+// checkEventTransaction is ValidateEventTransaction with the reason
+// for a rejection.
+func (node *QuidnugNode) checkEventTransaction(tx EventTransaction) error {
+	return node.checkEventTransactionIn(tx, nil)
+}
+
+// checkEventTransactionIn is checkEventTransaction for a member of an
+// atomic group; groupIdentities, the identities the group creates,
+// count as registered (atomic_group.go).
+func (node *QuidnugNode) checkEventTransactionIn(tx EventTransaction, groupIdentities map[string]IdentityTransaction) error {
 	// Validate TrustDomain is not empty
 	if tx.TrustDomain == "" {
 		logger.Warn("Event transaction missing trust domain", "txId", tx.ID)
-		return false
+		return reject(RejectMissingField, "trustDomain", "trustDomain is required")
 	}
 
 	// Check if transaction belongs to a known trust domain
@@ -249,19 +334,19 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 
 	if !domainExists {
 		logger.Warn("Event transaction from unknown trust domain", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectUnknownDomain, "trustDomain", "unknown trust domain %q", tx.TrustDomain)
 	}
 
 	// Validate SubjectID is present
 	if tx.SubjectID == "" {
 		logger.Warn("Event transaction missing subject ID", "txId", tx.ID)
-		return false
+		return reject(RejectMissingField, "subjectId", "subjectId is required")
 	}
 
 	// Validate SubjectType must be "QUID" or "TITLE"
 	if tx.SubjectType != "QUID" && tx.SubjectType != "TITLE" {
 		logger.Warn("Invalid subject type: must be 'QUID' or 'TITLE'", "subjectType", tx.SubjectType, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "subjectType", "subjectType must be QUID or TITLE")
 	}
 
 	// Subject ID format: QUID subjects must be valid 16-hex quids;
@@ -270,25 +355,25 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 		if !IsValidQuidID(tx.SubjectID) {
 			logger.Warn("Invalid subject ID format (QUID must be 16 hex chars)",
 				"subjectId", tx.SubjectID, "txId", tx.ID)
-			return false
+			return reject(RejectInvalidQuidID, "subjectId", "subjectId %q is not a valid quid ID", tx.SubjectID)
 		}
 	} else {
 		if !ValidateStringField(tx.SubjectID, MaxNameLength) {
 			logger.Warn("Invalid subject ID: too long or contains control characters",
 				"subjectId", tx.SubjectID, "txId", tx.ID)
-			return false
+			return reject(RejectInvalidField, "subjectId", "subjectId is too long or contains control characters")
 		}
 	}
 
 	// Validate EventType (not empty, max 64 chars)
 	if tx.EventType == "" {
 		logger.Warn("Event type is empty", "txId", tx.ID)
-		return false
+		return reject(RejectMissingField, "eventType", "eventType is required")
 	}
 
 	if len(tx.EventType) > MaxEventTypeLength {
 		logger.Warn("Event type exceeds max length", "length", len(tx.EventType), "max", MaxEventTypeLength, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "eventType", "eventType is longer than %d characters", MaxEventTypeLength)
 	}
 
 	// Validate payload - either Payload or PayloadCID must be provided
@@ -297,13 +382,13 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 
 	if !hasPayload && !hasPayloadCID {
 		logger.Warn("Event transaction missing payload: either Payload or PayloadCID required", "txId", tx.ID)
-		return false
+		return reject(RejectMissingField, "payload", "payload or payloadCid is required")
 	}
 
 	// If PayloadCID provided, validate CID format
 	if hasPayloadCID && !ipfsclient.IsValidCID(tx.PayloadCID) {
 		logger.Warn("Invalid payload CID format", "payloadCid", tx.PayloadCID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "payloadCid", "payloadCid %q is not a valid CID", tx.PayloadCID)
 	}
 
 	// Validate Payload size (max 64KB when serialized)
@@ -311,11 +396,11 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 		payloadBytes, err := json.Marshal(tx.Payload)
 		if err != nil {
 			logger.Warn("Failed to marshal payload for size check", "txId", tx.ID, "error", err)
-			return false
+			return rejectErr(RejectEncoding, "payload", "cannot encode payload", err)
 		}
 		if len(payloadBytes) > MaxPayloadSize {
 			logger.Warn("Payload exceeds max size", "size", len(payloadBytes), "max", MaxPayloadSize, "txId", tx.ID)
-			return false
+			return reject(RejectPayloadTooLarge, "payload", "payload is %d bytes; the limit is %d", len(payloadBytes), MaxPayloadSize)
 		}
 	}
 
@@ -327,10 +412,13 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 		node.IdentityRegistryMutex.RLock()
 		identity, exists := node.IdentityRegistry[tx.SubjectID]
 		node.IdentityRegistryMutex.RUnlock()
+		if !exists {
+			identity, exists = groupIdentities[tx.SubjectID]
+		}
 
 		if !exists {
 			logger.Warn("Subject QUID not found in identity registry", "subjectId", tx.SubjectID, "txId", tx.ID)
-			return false
+			return reject(RejectUnknownSubject, "subjectId", "subject quid %s is not a registered identity", tx.SubjectID)
 		}
 		subjectIdentity = identity
 	} else {
@@ -340,7 +428,7 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 
 		if !exists {
 			logger.Warn("Subject TITLE not found in title registry", "subjectId", tx.SubjectID, "txId", tx.ID)
-			return false
+			return reject(RejectUnknownSubject, "subjectId", "subject title %s is not registered", tx.SubjectID)
 		}
 		title = t
 	}
@@ -357,44 +445,44 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 				"currentSequence", stream.LatestSequence,
 				"subjectId", tx.SubjectID,
 				"txId", tx.ID)
-			return false
+			return reject(RejectSequenceTooLow, "sequence", "sequence %d must be greater than the current %d", tx.Sequence, stream.LatestSequence)
 		}
 	} else {
 		if tx.Sequence != 0 && tx.Sequence != 1 {
 			logger.Warn("Invalid sequence for new stream: must be 0 or 1",
 				"providedSequence", tx.Sequence,
 				"txId", tx.ID)
-			return false
+			return reject(RejectInvalidField, "sequence", "a new stream starts at sequence 0 or 1")
 		}
 	}
 
 	// Verify signature
 	if tx.Signature == "" || tx.PublicKey == "" {
 		logger.Warn("Missing signature or public key in event transaction", "txId", tx.ID)
-		return false
+		return reject(RejectMissingSignature, "signature", "signature and public key are required")
 	}
 
 	txCopy := tx
 	txCopy.Signature = ""
-	signableData, err := json.Marshal(txCopy)
+	signableData, err := txSignableBytes(txCopy)
 	if err != nil {
 		logger.Error("Failed to marshal transaction for signature verification", "txId", tx.ID, "error", err)
-		return false
+		return rejectErr(RejectEncoding, "", "cannot encode transaction for signing", err)
 	}
 
 	if !VerifySignature(tx.PublicKey, signableData, tx.Signature) {
 		logger.Warn("Invalid signature in event transaction", "txId", tx.ID)
-		return false
+		return reject(RejectBadSignature, "signature", "signature does not verify against the public key")
 	}
 
-	// Verify signer is the subject owner
+	// Verify signer is the subject owner, or signs for it
 	if tx.SubjectType == "QUID" {
-		if subjectIdentity.PublicKey != tx.PublicKey {
+		if subjectIdentity.PublicKey != tx.PublicKey && !node.signsFor(tx.SubjectID, tx.PublicKey, TxTypeEvent, tx.delegationTime()) {
 			logger.Warn("Signer is not the subject owner",
 				"txId", tx.ID,
 				"subjectId", tx.SubjectID,
 				"subjectType", tx.SubjectType)
-			return false
+			return reject(RejectNotOwner, "publicKey", "signer is not the subject quid and does not sign for it")
 		}
 	} else {
 		isOwner := false
@@ -403,7 +491,8 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 			ownerIdentity, exists := node.IdentityRegistry[stake.OwnerID]
 			node.IdentityRegistryMutex.RUnlock()
 
-			if exists && ownerIdentity.PublicKey == tx.PublicKey {
+			if exists && ownerIdentity.PublicKey == tx.PublicKey ||
+				node.signsFor(stake.OwnerID, tx.PublicKey, TxTypeEvent, tx.delegationTime()) {
 				isOwner = true
 				break
 			}
@@ -414,15 +503,45 @@ func (node *QuidnugNode) ValidateEventTransaction(tx EventTransaction) bool {
 				"txId", tx.ID,
 				"subjectId", tx.SubjectID,
 				"subjectType", tx.SubjectType)
-			return false
+			return reject(RejectNotOwner, "publicKey", "signer is not an owner of the subject title")
 		}
 	}
 
-	return true
+	return node.checkTxHooks(TxTypeEvent, tx.TrustDomain, tx.ID, tx)
+}
+
+// ownershipTotal sums the ownership stakes' shares (leases and
+// other non-ownership stakes excluded) and reports whether they
+// make up the whole asset. We accept 100.0 as a legacy alias for
+// 1.0 for compatibility with pre-v1.0 clients and tests.
+func ownershipTotal(owners []OwnershipStake) (float64, bool) {
+	var total float64
+	for _, stake := range owners {
+		if stake.isOwnership() {
+			total += stake.Percentage
+		}
+	}
+	const fracTolerance = 1e-6
+	matchesFraction := total > 1.0-fracTolerance && total < 1.0+fracTolerance
+	matchesPercent := total > 100.0-fracTolerance && total < 100.0+fracTolerance
+	return total, matchesFraction || matchesPercent
 }
 
 // ValidateTitleTransaction validates a title transaction
 func (node *QuidnugNode) ValidateTitleTransaction(tx TitleTransaction) bool {
+	return node.checkTitleTransaction(tx) == nil
+}
+
+// checkTitleTransaction is ValidateTitleTransaction with the reason
+// for a rejection.
+func (node *QuidnugNode) checkTitleTransaction(tx TitleTransaction) error {
+	return node.checkTitleTransactionIn(tx, nil)
+}
+
+// checkTitleTransactionIn is checkTitleTransaction for a member of an
+// atomic group, whose owners may be identities the group itself
+// creates (groupIdentities).
+func (node *QuidnugNode) checkTitleTransactionIn(tx TitleTransaction, groupIdentities map[string]IdentityTransaction) error {
 	// Check if transaction belongs to a known trust domain
 	node.TrustDomainsMutex.RLock()
 	_, domainExists := node.TrustDomains[tx.TrustDomain]
@@ -430,7 +549,7 @@ func (node *QuidnugNode) ValidateTitleTransaction(tx TitleTransaction) bool {
 
 	if !domainExists && tx.TrustDomain != "" {
 		logger.Warn("Title transaction from unknown trust domain", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectUnknownDomain, "trustDomain", "unknown trust domain %q", tx.TrustDomain)
 	}
 
 	// AssetID is a free-form asset identifier per v1.0 spec
@@ -438,30 +557,39 @@ func (node *QuidnugNode) ValidateTitleTransaction(tx TitleTransaction) bool {
 	// "asset-sku-000001"). No quid-format check here.
 	if tx.AssetID == "" {
 		logger.Warn("Title transaction missing asset id", "txId", tx.ID)
-		return false
+		return reject(RejectMissingField, "assetId", "assetId is required")
 	}
 	if !ValidateStringField(tx.AssetID, MaxNameLength) {
 		logger.Warn("Invalid asset id: too long or contains control characters",
 			"assetId", tx.AssetID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "assetId", "assetId is too long or contains control characters")
+	}
+	// A title retired by a split or merge lives on only in its
+	// children (title_restructure.go).
+	if node.isTitleRetired(tx.AssetID) {
+		logger.Warn("Title transaction for an asset retired by a split or merge",
+			"assetId", tx.AssetID, "txId", tx.ID)
+		return reject(RejectTitleRetired, "assetId", "asset %s was retired by a split or merge", tx.AssetID)
 	}
 
 	// Validate owner quid ID formats
 	for _, stake := range tx.Owners {
 		if stake.OwnerID != "" && !IsValidQuidID(stake.OwnerID) {
 			logger.Warn("Invalid owner quid ID format", "ownerId", stake.OwnerID, "txId", tx.ID)
-			return false
+			return reject(RejectInvalidQuidID, "owners", "owner %q is not a valid quid ID", stake.OwnerID)
 		}
 	}
 
-	// Every listed owner must exist in the identity registry.
+	// Every listed owner must exist in the identity registry, or be
+	// created alongside the title.
 	node.IdentityRegistryMutex.RLock()
 	for _, stake := range tx.Owners {
-		if _, exists := node.IdentityRegistry[stake.OwnerID]; !exists {
+		_, inGroup := groupIdentities[stake.OwnerID]
+		if _, exists := node.IdentityRegistry[stake.OwnerID]; !exists && !inGroup {
 			node.IdentityRegistryMutex.RUnlock()
 			logger.Warn("Owner quid not found in identity registry",
 				"ownerId", stake.OwnerID, "assetId", tx.AssetID, "txId", tx.ID)
-			return false
+			return reject(RejectUnknownSubject, "owners", "owner %s is not a registered identity", stake.OwnerID)
 		}
 	}
 	node.IdentityRegistryMutex.RUnlock()
@@ -469,52 +597,62 @@ func (node *QuidnugNode) ValidateTitleTransaction(tx TitleTransaction) bool {
 	// Validate string field lengths and control characters
 	if tx.TrustDomain != "" && !ValidateStringField(tx.TrustDomain, MaxDomainLength) {
 		logger.Warn("Invalid trust domain: too long or contains control characters", "domain", tx.TrustDomain, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "trustDomain", "trust domain is too long or contains control characters")
 	}
 
 	if tx.TitleType != "" && !ValidateStringField(tx.TitleType, MaxNameLength) {
 		logger.Warn("Invalid title type: too long or contains control characters", "assetId", tx.AssetID, "txId", tx.ID)
-		return false
+		return reject(RejectInvalidField, "titleType", "titleType is too long or contains control characters")
 	}
 
-	// Verify total ownership shares sum to 1.0 (v1.0 spec uses
-	// fractional shares in the wire form). We accept 100.0 as a
-	// legacy alias for 1.0 for compatibility with pre-v1.0 clients
-	// and tests.
-	var totalPercentage float64
-	for _, stake := range tx.Owners {
-		totalPercentage += stake.Percentage
+	// Class rules (asset_class.go) against the title being replaced.
+	node.TitleRegistryMutex.RLock()
+	replaced, hasTitle := node.TitleRegistry[tx.AssetID]
+	node.TitleRegistryMutex.RUnlock()
+	var current *TitleTransaction
+	if hasTitle {
+		current = &replaced
+	}
+	if err := node.checkTitleAssetClass(tx, current); err != nil {
+		logger.Warn("Title violates asset class rules", "assetId", tx.AssetID, "txId", tx.ID, "error", err)
+		return rejectErr(RejectAssetClass, "assetClass", "asset class rules", err)
 	}
 
-	const fracTolerance = 1e-6
-	matchesFraction := totalPercentage > 1.0-fracTolerance && totalPercentage < 1.0+fracTolerance
-	matchesPercent := totalPercentage > 100.0-fracTolerance && totalPercentage < 100.0+fracTolerance
-	if !matchesFraction && !matchesPercent {
+	// Leases, usufructs and liens follow their own rules and stay
+	// out of the total (stake_types.go).
+	if err := validateStakes(tx.Owners, tx.Timestamp); err != nil {
+		logger.Warn("Invalid stakes on title", "assetId", tx.AssetID, "txId", tx.ID, "error", err)
+		return rejectErr(RejectInvalidStake, "owners", "invalid stakes", err)
+	}
+
+	// Verify total ownership shares sum to 1.0 (v1.0 spec uses
+	// fractional shares in the wire form).
+	if totalPercentage, ok := ownershipTotal(tx.Owners); !ok {
 		logger.Warn("Total ownership shares don't equal 1.0 (or 100.0 legacy)",
 			"totalShare", totalPercentage,
 			"assetId", tx.AssetID,
 			"txId", tx.ID)
-		return false
+		return reject(RejectOwnershipSum, "owners", "ownership shares total %v; they must total 1.0", totalPercentage)
 	}
 
 	// Verify main signature from issuer
 	if tx.Signature == "" || tx.PublicKey == "" {
 		logger.Warn("Missing signature or public key in title transaction", "txId", tx.ID, "assetId", tx.AssetID)
-		return false
+		return reject(RejectMissingSignature, "signature", "signature and public key are required")
 	}
 
 	// Get signable data for issuer (transaction with main signature cleared)
 	txCopyForIssuer := tx
 	txCopyForIssuer.Signature = ""
-	issuerSignableData, err := json.Marshal(txCopyForIssuer)
+	issuerSignableData, err := txSignableBytes(txCopyForIssuer)
 	if err != nil {
 		logger.Error("Failed to marshal transaction for issuer signature verification", "txId", tx.ID, "error", err)
-		return false
+		return rejectErr(RejectEncoding, "", "cannot encode transaction for signing", err)
 	}
 
 	if !VerifySignature(tx.PublicKey, issuerSignableData, tx.Signature) {
 		logger.Warn("Invalid issuer signature in title transaction", "txId", tx.ID, "assetId", tx.AssetID)
-		return false
+		return reject(RejectBadSignature, "signature", "signature does not verify against the public key")
 	}
 
 	// If this is a transfer (has previous owners), verify previous owners' signatures
@@ -524,57 +662,78 @@ func (node *QuidnugNode) ValidateTitleTransaction(tx TitleTransaction) bool {
 		currentTitle, exists := node.TitleRegistry[tx.AssetID]
 		node.TitleRegistryMutex.RUnlock()
 
+		var policy *TitleTransferPolicy
 		if exists {
 			if !areOwnershipStakesEqual(tx.PreviousOwners, currentTitle.Owners) {
 				logger.Warn("Previous owners don't match current title", "assetId", tx.AssetID, "txId", tx.ID)
-				return false
+				return reject(RejectOwnersMismatch, "previousOwners", "previousOwners do not match the current title")
+			}
+			// An expired title can't be transferred (title_expiry.go).
+			if node.isTitleLapsed(tx.AssetID) || titleExpired(currentTitle, tx.Timestamp) {
+				logger.Warn("Transfer of an expired title",
+					"assetId", tx.AssetID, "expiryDate", currentTitle.ExpiryDate, "txId", tx.ID)
+				return reject(RejectTitleExpired, "assetId", "title %s has expired and cannot be transferred", tx.AssetID)
 			}
+			// Nor can one frozen by an open dispute (title_dispute.go).
+			if d, frozen := node.titleFrozenBy(tx.AssetID); frozen {
+				logger.Warn("Transfer of a title frozen by an open dispute",
+					"assetId", tx.AssetID, "disputeId", d.DisputeID, "txId", tx.ID)
+				return reject(RejectTitleFrozen, "assetId", "title %s is frozen by open dispute %s", tx.AssetID, d.DisputeID)
+			}
+			policy = currentTitle.TransferPolicy
 		}
 
-		// Get signable data for owners (transaction with all signatures and issuer pubkey cleared)
-		txCopyForOwners := tx
-		txCopyForOwners.Signature = ""
-		txCopyForOwners.PublicKey = ""
-		txCopyForOwners.Signatures = nil
-		ownerSignableData, err := json.Marshal(txCopyForOwners)
-		if err != nil {
-			logger.Error("Failed to marshal transaction for owner signature verification", "txId", tx.ID, "error", err)
-			return false
+		// Co-signatures as the current title's transfer policy
+		// requires (title_transfer_policy.go).
+		if !node.checkTransferSignatures(tx, policy) {
+			return reject(RejectMissingCoSignature, "signatures", "transfer lacks the signatures the title's transfer policy requires")
 		}
 
-		// Verify each previous owner's signature
-		for _, stake := range tx.PreviousOwners {
-			// Get previous owner's public key from identity registry
-			node.IdentityRegistryMutex.RLock()
-			ownerIdentity, ownerExists := node.IdentityRegistry[stake.OwnerID]
-			node.IdentityRegistryMutex.RUnlock()
+		// The issuer is a previous owner, the policy's trustee, or
+		// signs for one of them.
+		if !node.signsForTitleHolder(tx, policy) {
+			logger.Warn("Title transfer issuer is not a previous owner", "assetId", tx.AssetID, "txId", tx.ID)
+			return reject(RejectNotOwner, "publicKey", "issuer is not a previous owner or the trustee and does not sign for one")
+		}
+	}
 
-			if !ownerExists {
-				logger.Warn("Previous owner not found in identity registry", "ownerId", stake.OwnerID, "txId", tx.ID)
-				return false
-			}
+	if err := validateTitleExpiry(tx); err != nil {
+		logger.Warn("Title transaction has invalid expiry", "assetId", tx.AssetID, "txId", tx.ID, "error", err)
+		return rejectErr(RejectInvalidField, "expiryDate", "invalid expiry", err)
+	}
 
-			if ownerIdentity.PublicKey == "" {
-				logger.Warn("Previous owner has no public key", "ownerId", stake.OwnerID, "txId", tx.ID)
-				return false
+	// A policy recorded here governs the title's next transfer.
+	if tx.TransferPolicy != nil {
+		if err := tx.TransferPolicy.validate(); err != nil {
+			logger.Warn("Title transaction has invalid transfer policy",
+				"assetId", tx.AssetID, "txId", tx.ID, "error", err)
+			return rejectErr(RejectInvalidField, "transferPolicy", "invalid transfer policy", err)
+		}
+		if tx.TransferPolicy.Kind == TransferPolicyDesignated {
+			node.IdentityRegistryMutex.RLock()
+			_, trusteeExists := node.IdentityRegistry[tx.TransferPolicy.Trustee]
+			node.IdentityRegistryMutex.RUnlock()
+			if !trusteeExists {
+				logger.Warn("Transfer policy trustee not found in identity registry",
+					"trustee", tx.TransferPolicy.Trustee, "txId", tx.ID)
+				return reject(RejectUnknownSubject, "transferPolicy", "transfer policy trustee %s is not a registered identity", tx.TransferPolicy.Trustee)
 			}
+		}
+	}
 
-			// Get the signature for this owner
-			ownerSig, hasSig := tx.Signatures[stake.OwnerID]
-			if !hasSig || ownerSig == "" {
-				logger.Warn("Missing signature from previous owner", "ownerId", stake.OwnerID, "txId", tx.ID)
-				return false
-			}
+	// Any rewrite of an encumbered title needs every active
+	// lienholder's co-signature (liens.go).
+	if !node.verifyLienholderCoSignatures(tx) {
+		return reject(RejectMissingCoSignature, "signatures", "an active lienholder has not co-signed")
+	}
 
-			// Verify the owner's signature
-			if !VerifySignature(ownerIdentity.PublicKey, ownerSignableData, ownerSig) {
-				logger.Warn("Invalid signature from previous owner", "ownerId", stake.OwnerID, "txId", tx.ID)
-				return false
-			}
-		}
+	// Escrow: well-formed conditions, and no rewrite of an asset
+	// held by a pending conditional transfer.
+	if !node.validateTitleEscrowRules(tx) {
+		return reject(RejectEscrowConflict, "", "escrow conditions are malformed or the asset is held by a pending conditional transfer")
 	}
 
-	return true
+	return node.checkTxHooks(TxTypeTitle, tx.TrustDomain, tx.ID, tx)
 }
 
 // ValidateBlockCryptographic validates only cryptographic aspects (hash, signatures, chain).
@@ -648,6 +807,13 @@ func (node *QuidnugNode) ValidateBlockCryptographic(block Block) bool {
 		}
 	}
 
+	// Blocks at or below the domain's latest checkpoint are final;
+	// no competing block may take their place (see checkpoint.go).
+	if node.Checkpoints != nil && block.Index <= node.Checkpoints.finalHeight(domain) {
+		logger.Debug("Block below domain checkpoint", "blockIndex", block.Index, "domain", domain)
+		return false
+	}
+
 	// Verify the block hash
 	if calculateBlockHash(block) != block.Hash {
 		return false
@@ -744,6 +910,18 @@ func (node *QuidnugNode) ValidateBlockTiered(block Block) BlockAcceptance {
 		return BlockInvalid
 	}
 
+	if err := node.checkBlockProtocolVersion(block); err != nil {
+		logger.Warn("Block protocol version rejected",
+			"blockIndex", block.Index,
+			"domain", block.TrustProof.TrustDomain,
+			"error", err)
+		return BlockInvalid
+	}
+
+	// Over the limits is judged after the full checks below; the
+	// node-wide limits are local, so it only costs the block trust.
+	overLimit, overLimitErr := node.checkBlockLimits(block)
+
 	// QDP-0010 / H2: after the `require_tx_tree_root` fork has
 	// been activated on this node, blocks missing
 	// TransactionsRoot are rejected as malformed. Before
@@ -753,6 +931,15 @@ func (node *QuidnugNode) ValidateBlockTiered(block Block) BlockAcceptance {
 		return BlockInvalid
 	}
 
+	// After the `require_canonical_tx_order` fork, transactions
+	// must appear in the order GenerateBlock seals them in.
+	if node.RequireCanonicalTxOrder && !isCanonicalTxOrder(block.Transactions) {
+		blockNonCanonicalOrderRejectedTotal.Inc()
+		logger.Warn("Block transactions not in canonical order",
+			"blockIndex", block.Index, "hash", block.Hash)
+		return BlockInvalid
+	}
+
 	// Validate all transactions in the block.
 	//
 	// Each transaction in block.Transactions arrives as a generic
@@ -765,6 +952,25 @@ func (node *QuidnugNode) ValidateBlockTiered(block Block) BlockAcceptance {
 	// is malformed (a peer mis-encoded a transaction or an attacker
 	// is probing the parser): we return BlockInvalid rather than
 	// silently treating the zero-valued struct as a valid tx.
+	//
+	// blockIdentities tracks the identity update claimed per quid
+	// so far in this block; a later one reusing its UpdateNonce is
+	// a conflict (identity_conflicts.go).
+	//
+	// Decoding and the in-block identity checks run in order here;
+	// the per-transaction validators, which carry the signature
+	// checks, are collected and run in parallel afterwards.
+	//
+	// groups collects atomic-group members (atomic_group.go); each
+	// group must be in the block whole, and its titles and events
+	// may rest on identities it creates.
+	//
+	// Each decoded transaction carries the block's timestamp, which
+	// bounds the time delegated signers are judged at
+	// (organization.go).
+	blockIdentities := make(map[string]IdentityTransaction)
+	groups := newBlockAtomicGroups()
+	checks := make([]func() bool, 0, len(block.Transactions))
 	for _, txInterface := range block.Transactions {
 		txJson, err := json.Marshal(txInterface)
 		if err != nil {
@@ -776,8 +982,12 @@ func (node *QuidnugNode) ValidateBlockTiered(block Block) BlockAcceptance {
 		if err := json.Unmarshal(txJson, &baseTx); err != nil {
 			return BlockInvalid
 		}
-
-		var isValid bool
+		if !supportedTxSchemaVersion(baseTx.SchemaVersion) {
+			logger.Warn("Transaction schema version not supported",
+				"txId", baseTx.ID, "schemaVersion", baseTx.SchemaVersion)
+			return BlockInvalid
+		}
+		groups.add(baseTx)
 
 		switch baseTx.Type {
 		case TxTypeTrust:
@@ -785,89 +995,217 @@ func (node *QuidnugNode) ValidateBlockTiered(block Block) BlockAcceptance {
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateTrustTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateTrustTransaction(tx) })
 
 		case TxTypeIdentity:
 			var tx IdentityTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateIdentityTransaction(tx)
+			tx.blockTime = block.Timestamp
+			if kept, claimed := blockIdentities[tx.QuidID]; claimed && kept.ID != tx.ID && tx.UpdateNonce <= kept.UpdateNonce {
+				if tx.UpdateNonce == kept.UpdateNonce {
+					node.recordIdentityConflict(kept, tx, IdentityConflictStageBlock, &block)
+				}
+				return BlockInvalid
+			}
+			if kept, conflict := node.committedIdentityConflict(tx); conflict {
+				node.recordIdentityConflict(kept, tx, IdentityConflictStageBlock, &block)
+				return BlockInvalid
+			}
+			checks = append(checks, func() bool { return node.ValidateIdentityTransaction(tx) })
+			blockIdentities[tx.QuidID] = tx
+			groups.addIdentity(tx)
 
 		case TxTypeTitle:
 			var tx TitleTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateTitleTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool {
+				return node.checkTitleTransactionIn(tx, groups.identities(tx.BaseTransaction)) == nil
+			})
 
 		case TxTypeEvent:
 			var tx EventTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateEventTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool {
+				return node.checkEventTransactionIn(tx, groups.identities(tx.BaseTransaction)) == nil
+			})
 
 		case TxTypeNodeAdvertisement:
 			var tx NodeAdvertisementTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateNodeAdvertisementTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateNodeAdvertisementTransaction(tx) })
 
 		case TxTypeModerationAction:
 			var tx ModerationActionTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateModerationActionTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateModerationActionTransaction(tx) })
+
+		case TxTypeNameRegistration:
+			var tx NameRegistrationTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateNameRegistrationTransaction(tx) })
+
+		case TxTypeLien:
+			var tx LienTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateLienTransaction(tx) })
+
+		case TxTypeSuccession:
+			var tx SuccessionTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateSuccessionTransaction(tx) })
+
+		case TxTypeMisbehaviorReport:
+			var tx MisbehaviorReportTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateMisbehaviorReportTransaction(tx) })
+
+		case TxTypeDomainJoin:
+			var tx DomainJoinTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateDomainJoinTransaction(tx) })
+
+		case TxTypeCheckpoint:
+			var tx CheckpointTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateCheckpointTransaction(tx) })
+
+		case TxTypeDomainControl:
+			var tx DomainControlTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateDomainControlTransaction(tx) })
+
+		case TxTypeGeneric:
+			var tx CustomTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.validateCustomTransaction(tx, false) })
+
+		case TxTypeTransferApproval:
+			var tx TransferApprovalTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateTransferApprovalTransaction(tx) })
+
+		case TxTypeTitleRestructure:
+			var tx TitleRestructureTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateTitleRestructureTransaction(tx) })
+
+		case TxTypeTitleDispute:
+			var tx TitleDisputeTransaction
+			if err := json.Unmarshal(txJson, &tx); err != nil {
+				return BlockInvalid
+			}
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateTitleDisputeTransaction(tx) })
 
 		case TxTypeDataSubjectRequest:
 			var tx DataSubjectRequestTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateDataSubjectRequestTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateDataSubjectRequestTransaction(tx) })
 
 		case TxTypeConsentGrant:
 			var tx ConsentGrantTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateConsentGrantTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateConsentGrantTransaction(tx) })
 
 		case TxTypeConsentWithdraw:
 			var tx ConsentWithdrawTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateConsentWithdrawTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateConsentWithdrawTransaction(tx) })
 
 		case TxTypeProcessingRestriction:
 			var tx ProcessingRestrictionTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateProcessingRestrictionTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateProcessingRestrictionTransaction(tx) })
 
 		case TxTypeDSRCompliance:
 			var tx DSRComplianceTransaction
 			if err := json.Unmarshal(txJson, &tx); err != nil {
 				return BlockInvalid
 			}
-			isValid = node.ValidateDSRComplianceTransaction(tx)
+			tx.blockTime = block.Timestamp
+			checks = append(checks, func() bool { return node.ValidateDSRComplianceTransaction(tx) })
 
 		default:
-			isValid = false
-		}
-
-		if !isValid {
 			return BlockInvalid
 		}
 	}
+	if err := groups.check(); err != nil {
+		logger.Warn("Block carries an invalid atomic group",
+			"blockIndex", block.Index, "hash", block.Hash, "error", err)
+		return BlockInvalid
+	}
+	if !runChecksParallel(checks, node.BlockValidationWorkers) {
+		return BlockInvalid
+	}
 
 	// Trust validation (subjective - different nodes may have different views)
-	return node.ValidateTrustProofTiered(block)
+	acceptance := node.ValidateTrustProofTiered(block)
+	if overLimitErr != nil && acceptance != BlockInvalid {
+		blockOversizeRejectedTotal.WithLabelValues(overLimit).Inc()
+		logger.Warn("Block exceeds block limits; holding it as untrusted",
+			"blockIndex", block.Index,
+			"domain", block.TrustProof.TrustDomain,
+			"error", overLimitErr)
+		return BlockUntrusted
+	}
+	return acceptance
 }
 
 // ValidateBlock validates a block (backward compatibility wrapper).
@@ -952,8 +1290,8 @@ func (node *QuidnugNode) ValidateTrustProofTiered(block Block) BlockAcceptance {
 		return BlockTrusted
 	}
 
-	// Node-relative trust validation: compute relational trust from this node to the validator
-	trustLevel, _, err := node.ComputeRelationalTrust(node.NodeID, proof.ValidatorID, DefaultTrustMaxDepth)
+	// Node-relative trust validation: compute relational trust from this node (and its anchors) to the validator
+	trustLevel, trustPath, err := node.nodeTrustIn(node.NodeID, proof.ValidatorID)
 	if err != nil {
 		logger.Warn("Trust computation exceeded resource limits during block validation",
 			"validator", proof.ValidatorID,
@@ -962,6 +1300,11 @@ func (node *QuidnugNode) ValidateTrustProofTiered(block Block) BlockAcceptance {
 		// Use partial result - trustLevel contains best found so far
 	}
 
+	// An operator policy, when installed, replaces the threshold pair.
+	if p := node.GetAcceptancePolicy(); p != nil {
+		return node.acceptByPolicy(p, block, domain, trustLevel, trustPath)
+	}
+
 	// Return tier based on trust level
 	if trustLevel >= domain.TrustThreshold {
 		return BlockTrusted
@@ -1028,7 +1371,7 @@ func (node *QuidnugNode) ValidateTrustProof(proof TrustProof) bool {
 	}
 
 	// Compute relational trust (error is ignored, partial result used)
-	trustLevel, _, _ := node.ComputeRelationalTrust(node.NodeID, proof.ValidatorID, DefaultTrustMaxDepth)
+	trustLevel, _, _ := node.nodeTrustIn(node.NodeID, proof.ValidatorID)
 
 	return trustLevel >= domain.TrustThreshold
 }
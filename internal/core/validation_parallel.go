@@ -0,0 +1,63 @@
+// Parallel transaction checks for block validation.
+//
+// Verifying a block is dominated by one ECDSA verify per
+// transaction signature, plus one per owner signature on title
+// transfers, and each is independent of the others. ValidateBlockTiered
+// decodes the block in order and hands the per-transaction
+// validators to runChecksParallel, which spreads them over a bounded
+// pool and stops handing out work as soon as one fails.
+package core
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minParallelChecks is the smallest batch worth starting goroutines
+// for; below it checks run inline.
+const minParallelChecks = 4
+
+// runChecksParallel reports whether every check passed, running them
+// on up to workers goroutines (GOMAXPROCS when workers <= 0). After
+// the first failure no new checks start; ones already running finish
+// and their results are ignored.
+func runChecksParallel(checks []func() bool, workers int) bool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(checks) {
+		workers = len(checks)
+	}
+	if workers <= 1 || len(checks) < minParallelChecks {
+		for _, check := range checks {
+			if !check() {
+				return false
+			}
+		}
+		return true
+	}
+
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(checks) {
+					return
+				}
+				if !checks[i]() {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return !failed.Load()
+}
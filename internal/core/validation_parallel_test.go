@@ -0,0 +1,82 @@
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunChecksParallel(t *testing.T) {
+	pass := func() bool { return true }
+	fail := func() bool { return false }
+
+	for _, workers := range []int{0, 1, 4} {
+		if !runChecksParallel([]func() bool{pass, pass, pass, pass, pass}, workers) {
+			t.Errorf("workers=%d: all-passing checks reported failure", workers)
+		}
+		if runChecksParallel([]func() bool{pass, pass, fail, pass, pass}, workers) {
+			t.Errorf("workers=%d: failing check not reported", workers)
+		}
+		if !runChecksParallel(nil, workers) {
+			t.Errorf("workers=%d: empty batch should pass", workers)
+		}
+	}
+}
+
+func TestRunChecksParallel_StopsAfterFailure(t *testing.T) {
+	var ran atomic.Int32
+	checks := make([]func() bool, 1000)
+	for i := range checks {
+		checks[i] = func() bool {
+			ran.Add(1)
+			return i != 0
+		}
+	}
+	if runChecksParallel(checks, 2) {
+		t.Fatal("failure at index 0 not reported")
+	}
+	if n := ran.Load(); n >= int32(len(checks)) {
+		t.Fatalf("ran all %d checks after an early failure", n)
+	}
+}
+
+func TestValidateBlockTiered_ParallelWorkers(t *testing.T) {
+	node, _ := NewQuidnugNode(nil)
+	node.BlockValidationWorkers = 4
+	block, err := node.SyntheticTrustBlock("parallel.local", 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := node.ValidateBlockTiered(*block); got != BlockTrusted {
+		t.Fatalf("validated as %v with 4 workers", got)
+	}
+
+	// A transaction signed by the wrong key fails the whole block.
+	node.TrustDomainsMutex.Lock()
+	td := node.TrustDomains["parallel.local"]
+	node.TrustDomainsMutex.Unlock()
+	other, _ := NewQuidnugNode(nil)
+	other.TrustDomainsMutex.Lock()
+	other.TrustDomains["parallel.local"] = td
+	other.TrustDomainsMutex.Unlock()
+	forged := signTrustTx(other, TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "forged", Type: TxTypeTrust, TrustDomain: "parallel.local", Timestamp: nowUnix()},
+		Truster:         SyntheticQuid(1),
+		Trustee:         SyntheticQuid(2),
+		TrustLevel:      0.9,
+		Nonce:           1,
+	})
+	forged.PublicKey = node.GetPublicKeyHex()
+	node.PendingTxsMutex.Lock()
+	node.PendingTxs = append(node.PendingTxs, forged)
+	node.PendingTxsMutex.Unlock()
+	bad, err := node.SyntheticTrustBlock("parallel.local", 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bad.Transactions) != 65 {
+		t.Skipf("block producer dropped the forged tx (%d txs); nothing to check", len(bad.Transactions))
+	}
+	if got := node.ValidateBlockTiered(*bad); got != BlockInvalid {
+		t.Fatalf("block with a forged signature validated as %v", got)
+	}
+}
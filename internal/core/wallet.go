@@ -0,0 +1,188 @@
+// Server-side signing for custodied quids.
+//
+// With wallet_enabled set, a quid created through POST /quids with a
+// passphrase keeps its private key on the node (see internal/wallet)
+// instead of being handed out once and forgotten. The owner later
+// sends an unsigned transaction and the passphrase; the node unlocks
+// the key, signs exactly the way a client SDK would, and returns the
+// signed transaction for the client to submit as usual. Keys are
+// unlocked per request and never cached.
+package core
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/wallet"
+)
+
+// ErrWalletDisabled means a custody operation was requested on a
+// node without wallet_enabled.
+var ErrWalletDisabled = errors.New("wallet: custody is disabled on this node")
+
+// newWallet opens the configured key store. A nil store with a nil
+// error means custody is off.
+func newWallet(cfg *config.Config) (*wallet.Store, error) {
+	if !cfg.WalletEnabled {
+		return nil, nil
+	}
+	dir := cfg.WalletDir
+	if dir == "" {
+		if cfg.DataDir == "" {
+			return nil, fmt.Errorf("wallet_enabled requires wallet_dir or data_dir")
+		}
+		dir = filepath.Join(cfg.DataDir, "wallet")
+	}
+	return wallet.Open(dir, 0)
+}
+
+// WalletSignRequest asks the node to sign Transaction with a
+// custodied quid's key. For a TITLE transfer, AsOwner adds the
+// quid's previous-owner signature to Signatures instead of signing
+// as issuer; owners sign before the issuer does.
+type WalletSignRequest struct {
+	Passphrase  string          `json:"passphrase"`
+	Transaction json.RawMessage `json:"transaction"`
+	AsOwner     bool            `json:"asOwner,omitempty"`
+}
+
+// CreateCustodiedQuid generates a quid and keeps its key in the
+// wallet under passphrase.
+func (node *QuidnugNode) CreateCustodiedQuid(passphrase string) (wallet.Entry, error) {
+	if node.Wallet == nil {
+		return wallet.Entry{}, ErrWalletDisabled
+	}
+	if len(passphrase) < wallet.MinPassphraseLength {
+		return wallet.Entry{}, wallet.ErrWeakPassphrase
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return wallet.Entry{}, fmt.Errorf("wallet: generate key: %w", err)
+	}
+	pub := elliptic.Marshal(key.PublicKey.Curve, key.PublicKey.X, key.PublicKey.Y)
+	hash := sha256.Sum256(pub)
+	quidID := hex.EncodeToString(hash[:])[:16]
+	if err := node.Wallet.Put(quidID, hex.EncodeToString(pub), key, passphrase); err != nil {
+		return wallet.Entry{}, err
+	}
+	logger.Info("Created custodied quid", "quidId", quidID)
+	return node.Wallet.Get(quidID)
+}
+
+// RemoveCustodiedQuid deletes a custodied key. The quid itself lives
+// on; whoever removes it should already hold the key elsewhere or
+// accept losing the quid.
+func (node *QuidnugNode) RemoveCustodiedQuid(quidID, passphrase string) error {
+	if node.Wallet == nil {
+		return ErrWalletDisabled
+	}
+	if err := node.Wallet.Remove(quidID, passphrase); err != nil {
+		return err
+	}
+	logger.Info("Removed custodied quid", "quidId", quidID)
+	return nil
+}
+
+// SignWithCustodiedQuid signs req.Transaction with quidID's key and
+// returns the signed transaction. TRUST, IDENTITY, TITLE and EVENT
+// transactions are supported; the signable form is the transaction
+// with PublicKey set to the quid's key and Signature empty, the
+// same form the validators check.
+func (node *QuidnugNode) SignWithCustodiedQuid(quidID string, req WalletSignRequest) (interface{}, error) {
+	if node.Wallet == nil {
+		return nil, ErrWalletDisabled
+	}
+	var base BaseTransaction
+	if err := json.Unmarshal(req.Transaction, &base); err != nil {
+		return nil, fmt.Errorf("wallet: decode transaction: %w", err)
+	}
+	if req.AsOwner && base.Type != TxTypeTitle {
+		return nil, fmt.Errorf("wallet: asOwner applies only to %s transactions", TxTypeTitle)
+	}
+
+	entry, err := node.Wallet.Get(quidID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := node.Wallet.Unlock(quidID, req.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	sign := func(v interface{}) (string, error) {
+		data, err := txSignableBytes(v)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(signDigest(key, data)), nil
+	}
+
+	switch base.Type {
+	case TxTypeTrust:
+		var tx TrustTransaction
+		return signCustodied(req.Transaction, &tx, &tx.BaseTransaction, entry.PublicKey, sign)
+	case TxTypeIdentity:
+		var tx IdentityTransaction
+		return signCustodied(req.Transaction, &tx, &tx.BaseTransaction, entry.PublicKey, sign)
+	case TxTypeEvent:
+		var tx EventTransaction
+		return signCustodied(req.Transaction, &tx, &tx.BaseTransaction, entry.PublicKey, sign)
+	case TxTypeTitle:
+		var tx TitleTransaction
+		if !req.AsOwner {
+			return signCustodied(req.Transaction, &tx, &tx.BaseTransaction, entry.PublicKey, sign)
+		}
+		if err := json.Unmarshal(req.Transaction, &tx); err != nil {
+			return nil, fmt.Errorf("wallet: decode transaction: %w", err)
+		}
+		signable := tx
+		signable.Signature = ""
+		signable.PublicKey = ""
+		signable.Signatures = nil
+		sig, err := sign(signable)
+		if err != nil {
+			return nil, err
+		}
+		if tx.Signatures == nil {
+			tx.Signatures = make(map[string]string)
+		}
+		tx.Signatures[quidID] = sig
+		return tx, nil
+	default:
+		return nil, fmt.Errorf("wallet: cannot sign %q transactions", base.Type)
+	}
+}
+
+// signCustodied decodes raw into tx, whose embedded BaseTransaction
+// is base, and signs it as publicKey.
+func signCustodied(raw json.RawMessage, tx interface{}, base *BaseTransaction, publicKey string, sign func(interface{}) (string, error)) (interface{}, error) {
+	if err := json.Unmarshal(raw, tx); err != nil {
+		return nil, fmt.Errorf("wallet: decode transaction: %w", err)
+	}
+	base.PublicKey = publicKey
+	base.Signature = ""
+	sig, err := sign(tx)
+	if err != nil {
+		return nil, err
+	}
+	base.Signature = sig
+	return tx, nil
+}
+
+// signDigest produces the 64-byte r||s signature SignData makes for
+// the node key, with key instead.
+func signDigest(key *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	r, s := SignRFC6979(key, digest[:])
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/wallet"
+)
+
+const testWalletPassphrase = "a long enough passphrase"
+
+func newWalletTestNode(t *testing.T) *QuidnugNode {
+	t.Helper()
+	node := newTestNode()
+	store, err := wallet.Open(t.TempDir(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Wallet = store
+	return node
+}
+
+func TestCustodiedQuid_SignsVerifiableTransactions(t *testing.T) {
+	node := newWalletTestNode(t)
+	router := setupTestRouter(node)
+
+	body, _ := json.Marshal(map[string]string{"passphrase": testWalletPassphrase})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/quids", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			QuidID    string `json:"quidId"`
+			PublicKey string `json:"publicKey"`
+			Custodial bool   `json:"custodial"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	quid := created.Data
+	if !quid.Custodial || len(quid.QuidID) != 16 {
+		t.Fatalf("create response %+v", quid)
+	}
+
+	unsigned, _ := json.Marshal(TrustTransaction{
+		BaseTransaction: BaseTransaction{ID: "t1", Type: TxTypeTrust, TrustDomain: "test.domain.com", Timestamp: nowUnix()},
+		Truster:         quid.QuidID,
+		Trustee:         "0000000000000001",
+		TrustLevel:      0.8,
+		Nonce:           1,
+	})
+	body, _ = json.Marshal(WalletSignRequest{Passphrase: testWalletPassphrase, Transaction: unsigned})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/wallet/quids/"+quid.QuidID+"/sign", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("sign: status %d %s", w.Code, w.Body.String())
+	}
+	var signed struct {
+		Data struct {
+			Transaction TrustTransaction `json:"transaction"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &signed)
+	tx := signed.Data.Transaction
+	if tx.PublicKey != quid.PublicKey {
+		t.Fatalf("signed with %q, want the custodied key", tx.PublicKey)
+	}
+	signable := tx
+	signable.Signature = ""
+	data, _ := json.Marshal(signable)
+	if !VerifySignature(tx.PublicKey, data, tx.Signature) {
+		t.Fatal("signature does not verify against the transaction")
+	}
+
+	// A title transfer signed as previous owner.
+	title, _ := json.Marshal(TitleTransaction{
+		BaseTransaction: BaseTransaction{ID: "a1", Type: TxTypeTitle, TrustDomain: "test.domain.com"},
+		AssetID:         "asset-1",
+		PreviousOwners:  []OwnershipStake{{OwnerID: quid.QuidID, Percentage: 100}},
+	})
+	out, err := node.SignWithCustodiedQuid(quid.QuidID, WalletSignRequest{Passphrase: testWalletPassphrase, Transaction: title, AsOwner: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerTx := out.(TitleTransaction)
+	ownerSignable := ownerTx
+	ownerSignable.Signatures = nil
+	data, _ = json.Marshal(ownerSignable)
+	if ownerTx.Signature != "" || !VerifySignature(quid.PublicKey, data, ownerTx.Signatures[quid.QuidID]) {
+		t.Fatalf("owner signature missing or invalid: %+v", ownerTx)
+	}
+
+	if _, err := node.SignWithCustodiedQuid(quid.QuidID, WalletSignRequest{Passphrase: "not the passphrase", Transaction: unsigned}); !errors.Is(err, wallet.ErrPassphrase) {
+		t.Fatalf("wrong passphrase: err = %v", err)
+	}
+}
+
+func TestCustodiedQuid_DisabledByDefault(t *testing.T) {
+	node := newTestNode()
+	router := setupTestRouter(node)
+
+	body, _ := json.Marshal(map[string]string{"passphrase": testWalletPassphrase})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/quids", bytes.NewReader(body)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("custodial create with wallet off: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/quids", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("plain create: status %d", w.Code)
+	}
+}
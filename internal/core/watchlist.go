@@ -0,0 +1,364 @@
+// Watch lists: server-side filtered change feeds.
+//
+// A client registers the quids and assets it cares about with
+// POST /watches and gets back a watch ID and a webhook secret. For
+// every committed block that touches one of them the node delivers
+// the matching chain events (see chain_events.go): to the watch's
+// webhookUrl if it has one, and to anyone following
+// GET /watches/{id}/stream, which replays from fromHeight first. The
+// client never has to stream the whole chain and filter it.
+//
+// Webhook bodies are signed with HMAC-SHA256 under the watch secret
+// in the X-Quidnug-Watch-Signature header ("sha256=<hex>") so a
+// receiver can tell a delivery from a forgery. Webhooks only fire for
+// blocks committed while the node runs, never for chain replay at
+// startup, and go through the node's outbound HTTP client with its
+// private-address filter. The ID is an unguessable capability: it is
+// all that is needed to read the stream, renew or delete the watch.
+//
+// A watch lapses WatchTTL after it was created or last renewed
+// (POST /watches/{id}/renew), so abandoned ones free their slot, and
+// one client address may hold at most MaxWatchesPerClient of the
+// node's MaxWatches, so no single caller can take them all.
+//
+// Watches live in memory and do not survive a restart.
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Watch list limits.
+const (
+	// MaxWatches bounds the watches a node holds.
+	MaxWatches = 1000
+	// MaxWatchesPerClient bounds the watches one client address
+	// holds.
+	MaxWatchesPerClient = 20
+	// MaxWatchTargets bounds the quids plus assets of one watch.
+	MaxWatchTargets = 256
+	// WatchTTL is how long a watch lives after it is created or
+	// renewed.
+	WatchTTL = 24 * time.Hour
+)
+
+var (
+	// ErrWatchInvalid wraps every watch request shape error.
+	ErrWatchInvalid = errors.New("watch: invalid request")
+	// ErrWatchLimit means the node already holds MaxWatches.
+	ErrWatchLimit = errors.New("watch: too many watches")
+	// ErrWatchClientLimit means the client already holds
+	// MaxWatchesPerClient.
+	ErrWatchClientLimit = errors.New("watch: too many watches for this client")
+)
+
+// WatchRequest is the body of POST /watches. Kinds limits delivery
+// to those chain event kinds; empty means all.
+type WatchRequest struct {
+	Quids      []string `json:"quids,omitempty"`
+	Assets     []string `json:"assets,omitempty"`
+	Kinds      []string `json:"kinds,omitempty"`
+	WebhookURL string   `json:"webhookUrl,omitempty"`
+}
+
+// Watch is a registered watch list.
+type Watch struct {
+	ID         string   `json:"id"`
+	Quids      []string `json:"quids"`
+	Assets     []string `json:"assets"`
+	Kinds      []string `json:"kinds,omitempty"`
+	WebhookURL string   `json:"webhookUrl,omitempty"`
+	CreatedAt  int64    `json:"createdAt"`
+	ExpiresAt  int64    `json:"expiresAt"`
+}
+
+// WatchDelivery is what a watch receives for one block: the events
+// that touched its targets, the block event first.
+type WatchDelivery struct {
+	WatchID    string       `json:"watchId"`
+	BlockIndex int64        `json:"blockIndex"`
+	Events     []ChainEvent `json:"events"`
+}
+
+type watchEntry struct {
+	Watch
+	secret string
+	client string
+	quids  map[string]bool
+	assets map[string]bool
+	kinds  map[string]bool
+}
+
+// match returns the events of one block that concern w, or nil.
+// The block event is included, narrowed to w's quids, when any
+// transaction matched and w wants block events.
+func (w *watchEntry) match(events []ChainEvent) []ChainEvent {
+	var out []ChainEvent
+	touched := map[string]bool{}
+	for _, ev := range events {
+		if ev.Kind == ChainEventBlock {
+			continue
+		}
+		hit := w.assets[ev.AssetID]
+		for _, q := range ev.Quids {
+			if w.quids[q] {
+				touched[q] = true
+				hit = true
+			}
+		}
+		if hit && w.wants(ev.Kind) {
+			out = append(out, ev)
+		}
+	}
+	if len(events) == 0 || (len(out) == 0 && len(touched) == 0) {
+		return out
+	}
+	if w.wants(ChainEventBlock) {
+		head := events[0]
+		head.Quids = nil
+		for q := range touched {
+			head.Quids = append(head.Quids, q)
+		}
+		sort.Strings(head.Quids)
+		out = append([]ChainEvent{head}, out...)
+	}
+	return out
+}
+
+func (w *watchEntry) wants(kind string) bool {
+	return len(w.kinds) == 0 || w.kinds[kind]
+}
+
+// expired reports whether the watch has lapsed at now.
+func (w *watchEntry) expired(now int64) bool {
+	return now >= w.ExpiresAt
+}
+
+// WatchRegistry holds the registered watches. Owns its own lock.
+type WatchRegistry struct {
+	mu      sync.RWMutex
+	watches map[string]*watchEntry
+}
+
+// NewWatchRegistry creates an empty registry.
+func NewWatchRegistry() *WatchRegistry {
+	return &WatchRegistry{watches: make(map[string]*watchEntry)}
+}
+
+// newWatchEntry validates req and builds an entry for it.
+func newWatchEntry(req WatchRequest) (*watchEntry, error) {
+	if len(req.Quids)+len(req.Assets) == 0 {
+		return nil, fmt.Errorf("%w: name at least one quid or asset", ErrWatchInvalid)
+	}
+	if len(req.Quids)+len(req.Assets) > MaxWatchTargets {
+		return nil, fmt.Errorf("%w: more than %d quids and assets", ErrWatchInvalid, MaxWatchTargets)
+	}
+	e := &watchEntry{
+		quids:  make(map[string]bool, len(req.Quids)),
+		assets: make(map[string]bool, len(req.Assets)),
+		kinds:  make(map[string]bool, len(req.Kinds)),
+	}
+	for _, q := range req.Quids {
+		if !IsValidQuidID(q) {
+			return nil, fmt.Errorf("%w: invalid quid %q", ErrWatchInvalid, q)
+		}
+		e.quids[q] = true
+	}
+	for _, a := range req.Assets {
+		if a == "" || !ValidateStringField(a, MaxNameLength) {
+			return nil, fmt.Errorf("%w: invalid asset %q", ErrWatchInvalid, a)
+		}
+		e.assets[a] = true
+	}
+	for _, k := range req.Kinds {
+		if !chainEventKinds[k] {
+			return nil, fmt.Errorf("%w: unknown event kind %q", ErrWatchInvalid, k)
+		}
+		e.kinds[k] = true
+	}
+	if req.WebhookURL != "" {
+		u, err := url.Parse(req.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: webhookUrl must be an absolute http(s) URL", ErrWatchInvalid)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	e.secret = hex.EncodeToString(secret)
+	e.Watch = Watch{
+		ID:         uuid.New().String(),
+		Quids:      sortedKeys(e.quids),
+		Assets:     sortedKeys(e.assets),
+		Kinds:      sortedKeys(e.kinds),
+		WebhookURL: req.WebhookURL,
+		CreatedAt:  nowUnix(),
+	}
+	e.ExpiresAt = e.CreatedAt + int64(WatchTTL/time.Second)
+	if len(e.Kinds) == 0 {
+		e.Kinds = nil
+	}
+	return e, nil
+}
+
+// Add registers a watch for req on behalf of client (its address)
+// and returns it with its webhook secret. Lapsed watches are
+// dropped first so they don't count against either limit.
+func (r *WatchRegistry) Add(client string, req WatchRequest) (Watch, string, error) {
+	e, err := newWatchEntry(req)
+	if err != nil {
+		return Watch{}, "", err
+	}
+	e.client = client
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := nowUnix()
+	held := 0
+	for id, w := range r.watches {
+		switch {
+		case w.expired(now):
+			delete(r.watches, id)
+		case w.client == client:
+			held++
+		}
+	}
+	if len(r.watches) >= MaxWatches {
+		return Watch{}, "", ErrWatchLimit
+	}
+	if held >= MaxWatchesPerClient {
+		return Watch{}, "", ErrWatchClientLimit
+	}
+	r.watches[e.ID] = e
+	return e.Watch, e.secret, nil
+}
+
+// Get returns the watch with id.
+func (r *WatchRegistry) Get(id string) (Watch, bool) {
+	e, ok := r.entry(id)
+	if !ok {
+		return Watch{}, false
+	}
+	return e.Watch, true
+}
+
+// Renew extends the watch with id to WatchTTL from now and returns
+// it. A lapsed watch can't be renewed.
+func (r *WatchRegistry) Renew(id string) (Watch, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := nowUnix()
+	e, ok := r.watches[id]
+	if !ok || e.expired(now) {
+		return Watch{}, false
+	}
+	e.ExpiresAt = now + int64(WatchTTL/time.Second)
+	return e.Watch, true
+}
+
+// Remove deletes the watch with id, reporting whether it existed.
+func (r *WatchRegistry) Remove(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.watches[id]
+	delete(r.watches, id)
+	return ok
+}
+
+func (r *WatchRegistry) entry(id string) (*watchEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.watches[id]
+	if !ok || e.expired(nowUnix()) {
+		return nil, false
+	}
+	return e, true
+}
+
+// notifyWatchers posts a committed block's matching events to every
+// watch with a webhook.
+func (node *QuidnugNode) notifyWatchers(block Block) {
+	if node.Watches == nil {
+		return
+	}
+	node.Watches.mu.RLock()
+	var hooked []*watchEntry
+	now := nowUnix()
+	for _, e := range node.Watches.watches {
+		if e.WebhookURL != "" && !e.expired(now) {
+			hooked = append(hooked, e)
+		}
+	}
+	node.Watches.mu.RUnlock()
+	if len(hooked) == 0 {
+		return
+	}
+
+	events := blockEvents(block)
+	for _, e := range hooked {
+		if matched := e.match(events); len(matched) > 0 {
+			go node.postWatchDelivery(e, WatchDelivery{WatchID: e.ID, BlockIndex: block.Index, Events: matched})
+		}
+	}
+}
+
+// signWatchDelivery returns the X-Quidnug-Watch-Signature value for
+// body under secret.
+func signWatchDelivery(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (node *QuidnugNode) postWatchDelivery(e *watchEntry, d WatchDelivery) {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Quidnug-Watch-Signature", signWatchDelivery(e.secret, body))
+	client := node.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warn("Watch webhook failed", "watchId", e.ID, "blockIndex", d.BlockIndex, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Warn("Watch webhook rejected", "watchId", e.ID, "blockIndex", d.BlockIndex, "status", resp.StatusCode)
+	}
+}
+
+// streamWatch streams the watch's deliveries from height on; one
+// line or SSE "watch" event per block that matched.
+func (node *QuidnugNode) streamWatch(w http.ResponseWriter, r *http.Request, e *watchEntry, height int64, sse bool) {
+	node.streamChain(w, r, height, sse, func(block Block) []streamItem {
+		matched := e.match(blockEvents(block))
+		if len(matched) == 0 {
+			return nil
+		}
+		return []streamItem{{event: "watch", data: WatchDelivery{WatchID: e.ID, BlockIndex: block.Index, Events: matched}}}
+	})
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	watchQuidA = "00000000000000a1"
+	watchQuidB = "00000000000000b2"
+	watchQuidC = "00000000000000c3"
+)
+
+// watchTestBlock builds a block with a trust edge A→B and a title
+// transfer of asset-1 from C to B.
+func watchTestBlock(index int64) Block {
+	return Block{
+		Index:      index,
+		Hash:       "h",
+		Timestamp:  nowUnix(),
+		TrustProof: TrustProof{TrustDomain: "test.domain.com"},
+		Transactions: []interface{}{
+			TrustTransaction{
+				BaseTransaction: BaseTransaction{ID: "t1", Type: TxTypeTrust},
+				Truster:         watchQuidA,
+				Trustee:         watchQuidB,
+				TrustLevel:      0.5,
+			},
+			TitleTransaction{
+				BaseTransaction: BaseTransaction{ID: "t2", Type: TxTypeTitle},
+				AssetID:         "asset-1",
+				Owners:          []OwnershipStake{{OwnerID: watchQuidB, Percentage: 100}},
+				PreviousOwners:  []OwnershipStake{{OwnerID: watchQuidC, Percentage: 100}},
+			},
+		},
+	}
+}
+
+func TestBlockEvents(t *testing.T) {
+	events := blockEvents(watchTestBlock(5))
+	if len(events) != 3 {
+		t.Fatalf("expected block + 2 tx events, got %d", len(events))
+	}
+	if events[0].Kind != ChainEventBlock || len(events[0].Quids) != 3 {
+		t.Errorf("block event = %+v", events[0])
+	}
+	if events[1].Kind != ChainEventTrust || len(events[1].Quids) != 2 {
+		t.Errorf("trust event = %+v", events[1])
+	}
+	if events[2].Kind != ChainEventTitle || events[2].AssetID != "asset-1" {
+		t.Errorf("title event = %+v", events[2])
+	}
+}
+
+func TestWatch_Match(t *testing.T) {
+	events := blockEvents(watchTestBlock(5))
+	cases := []struct {
+		name  string
+		req   WatchRequest
+		kinds []string
+	}{
+		{"quid in trust only", WatchRequest{Quids: []string{watchQuidA}}, []string{"block", "trust"}},
+		{"quid in both", WatchRequest{Quids: []string{watchQuidB}}, []string{"block", "trust", "title"}},
+		{"asset", WatchRequest{Assets: []string{"asset-1"}}, []string{"block", "title"}},
+		{"kind filter", WatchRequest{Quids: []string{watchQuidB}, Kinds: []string{"title"}}, []string{"title"}},
+		{"untouched", WatchRequest{Quids: []string{"00000000000000d4"}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := newWatchEntry(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := e.match(events)
+			if len(got) != len(tc.kinds) {
+				t.Fatalf("expected %v, got %+v", tc.kinds, got)
+			}
+			for i, k := range tc.kinds {
+				if got[i].Kind != k {
+					t.Errorf("event %d: expected %s, got %s", i, k, got[i].Kind)
+				}
+			}
+		})
+	}
+
+	// The block event names only the watched quids.
+	e, _ := newWatchEntry(WatchRequest{Quids: []string{watchQuidA}})
+	if head := e.match(events)[0]; len(head.Quids) != 1 || head.Quids[0] != watchQuidA {
+		t.Errorf("block event quids = %v", head.Quids)
+	}
+}
+
+func TestWatchRegistry_Validate(t *testing.T) {
+	r := NewWatchRegistry()
+	bad := []WatchRequest{
+		{},
+		{Quids: []string{"not-a-quid"}},
+		{Quids: []string{watchQuidA}, Kinds: []string{"nope"}},
+		{Quids: []string{watchQuidA}, WebhookURL: "ftp://example.com/hook"},
+	}
+	for i, req := range bad {
+		if _, _, err := r.Add("192.0.2.1", req); err == nil {
+			t.Errorf("case %d: expected rejection", i)
+		}
+	}
+	w, secret, err := r.Add("192.0.2.1", WatchRequest{Quids: []string{watchQuidA}})
+	if err != nil || secret == "" || w.ID == "" {
+		t.Fatalf("add: %v", err)
+	}
+	if _, ok := r.Get(w.ID); !ok {
+		t.Error("watch not found after add")
+	}
+	if !r.Remove(w.ID) || r.Remove(w.ID) {
+		t.Error("remove should succeed once")
+	}
+}
+
+func TestWatchRegistry_PerClientLimit(t *testing.T) {
+	r := NewWatchRegistry()
+	req := WatchRequest{Quids: []string{watchQuidA}}
+	for i := 0; i < MaxWatchesPerClient; i++ {
+		if _, _, err := r.Add("192.0.2.1", req); err != nil {
+			t.Fatalf("watch %d: %v", i, err)
+		}
+	}
+	if _, _, err := r.Add("192.0.2.1", req); !errors.Is(err, ErrWatchClientLimit) {
+		t.Fatalf("expected per-client limit, got %v", err)
+	}
+	// Other clients still get watches.
+	if _, _, err := r.Add("198.51.100.7", req); err != nil {
+		t.Fatalf("second client refused: %v", err)
+	}
+}
+
+func TestWatchRegistry_ExpiryAndRenewal(t *testing.T) {
+	defer resetTestClock()
+	start := time.Now()
+	setTestClockNano(start.UnixNano())
+	r := NewWatchRegistry()
+	req := WatchRequest{Quids: []string{watchQuidA}}
+	kept, _, _ := r.Add("192.0.2.1", req)
+	lapsed, _, _ := r.Add("192.0.2.1", req)
+
+	setTestClockNano(start.Add(WatchTTL - time.Minute).UnixNano())
+	renewed, ok := r.Renew(kept.ID)
+	if !ok || renewed.ExpiresAt <= kept.ExpiresAt {
+		t.Fatalf("renew = %+v, %v", renewed, ok)
+	}
+
+	setTestClockNano(start.Add(WatchTTL).UnixNano())
+	if _, ok := r.Get(lapsed.ID); ok {
+		t.Error("watch still served after its TTL")
+	}
+	if _, ok := r.Renew(lapsed.ID); ok {
+		t.Error("lapsed watch renewed")
+	}
+	if _, ok := r.Get(kept.ID); !ok {
+		t.Error("renewed watch lapsed")
+	}
+
+	// A lapsed watch no longer counts against its client.
+	for i := 1; i < MaxWatchesPerClient; i++ {
+		if _, _, err := r.Add("192.0.2.1", req); err != nil {
+			t.Fatalf("watch %d after expiry: %v", i, err)
+		}
+	}
+}
+
+func TestWatch_Webhook(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- r
+		bodies <- body
+	}))
+	t.Cleanup(hook.Close)
+
+	node := newTestNode()
+	// httptest servers listen on loopback, which safedial refuses.
+	node.httpClient.Transport = http.DefaultTransport
+	_, secret, err := node.Watches.Add("192.0.2.1", WatchRequest{Assets: []string{"asset-1"}, WebhookURL: hook.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.notifyWatchers(watchTestBlock(7))
+
+	select {
+	case r := <-got:
+		body := <-bodies
+		if sig := r.Header.Get("X-Quidnug-Watch-Signature"); sig != signWatchDelivery(secret, body) {
+			t.Errorf("bad signature %q", sig)
+		}
+		var d WatchDelivery
+		if err := json.Unmarshal(body, &d); err != nil {
+			t.Fatal(err)
+		}
+		if d.BlockIndex != 7 || len(d.Events) != 2 || d.Events[1].AssetID != "asset-1" {
+			t.Errorf("delivery = %+v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+}
+
+func TestWatch_StreamEndpoint(t *testing.T) {
+	node := newTestNode()
+	srv := httptest.NewServer(setupTestRouter(node))
+	t.Cleanup(srv.Close)
+
+	body, _ := json.Marshal(WatchRequest{Quids: []string{watchQuidC}})
+	resp, err := http.Post(srv.URL+"/api/v1/watches", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		Data struct {
+			Watch Watch `json:"watch"`
+		} `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.Data.Watch.ID == "" {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/watches/"+created.Data.Watch.ID+"/stream?fromHeight=1", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	// An untouched block, then one transferring C's title.
+	appendTestBlock(node, "test.domain.com")
+	node.BlockchainMutex.Lock()
+	block := watchTestBlock(node.Blockchain[len(node.Blockchain)-1].Index + 1)
+	node.Blockchain = append(node.Blockchain, block)
+	node.BlockchainMutex.Unlock()
+	node.announceBlock()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d WatchDelivery
+	if err := json.Unmarshal([]byte(line), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.BlockIndex != block.Index || len(d.Events) != 2 || d.Events[1].TxID != "t2" {
+		t.Errorf("delivery = %+v", d)
+	}
+}
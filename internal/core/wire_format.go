@@ -0,0 +1,195 @@
+// Package core — wire_format.go
+//
+// Binary wire format for peer exchange.
+//
+// Block pages, getdata answers and mempool batches are JSON, which
+// spends much of its size on repeated field names and quoting. A
+// node can now carry those bodies as CBOR (RFC 8949) instead. The
+// handshake's capabilities list the encodings a node serves
+// ("encodings": ["json","cbor"]); a node configured with
+// peer_wire_format: cbor asks for CBOR only from peers that list
+// it, and speaks JSON to everyone else, so old and new nodes mix
+// freely.
+//
+// Nothing past the middleware knows about CBOR. WireFormatMiddleware
+// transcodes a CBOR request body to JSON before validation and the
+// handlers run, and re-encodes the JSON response as CBOR when the
+// caller's Accept asks for it. Only the exchange paths are
+// eligible, and never a response the caller wants signed, since the
+// signature covers the JSON bytes. NodeAuth signatures cover the
+// body as sent, CBOR or not.
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/quidnug/quidnug/internal/cborjson"
+)
+
+// Wire format names, as configured and as advertised in
+// PeerCapabilities.Encodings.
+const (
+	WireFormatJSON = "json"
+	WireFormatCBOR = "cbor"
+)
+
+// SupportedWireFormats is what this node serves on the exchange
+// endpoints.
+var SupportedWireFormats = []string{WireFormatJSON, WireFormatCBOR}
+
+// parseWireFormat validates the peer_wire_format setting. Empty
+// means JSON.
+func parseWireFormat(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", WireFormatJSON:
+		return WireFormatJSON, nil
+	case WireFormatCBOR:
+		return WireFormatCBOR, nil
+	}
+	return "", fmt.Errorf("unknown peer wire format %q (want json or cbor)", s)
+}
+
+// acceptsEncoding reports whether the peer advertised enc.
+func (c PeerCapabilities) acceptsEncoding(enc string) bool {
+	for _, e := range c.Encodings {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
+
+// wireFormatFor picks the encoding to use with a peer: the
+// configured one if the peer advertised it, else JSON.
+func (node *QuidnugNode) wireFormatFor(peer Node) string {
+	if node.wireFormat == WireFormatCBOR && peer.acceptsEncoding(WireFormatCBOR) {
+		return WireFormatCBOR
+	}
+	return WireFormatJSON
+}
+
+// wireFormatForQuid is wireFormatFor for a peer known only by quid.
+func (node *QuidnugNode) wireFormatForQuid(nodeQuid string) string {
+	if node.wireFormat != WireFormatCBOR {
+		return WireFormatJSON
+	}
+	node.KnownNodesMutex.RLock()
+	peer := node.KnownNodes[nodeQuid]
+	node.KnownNodesMutex.RUnlock()
+	return node.wireFormatFor(peer)
+}
+
+// encodeWireBody converts a JSON request body to format and returns
+// it with its Content-Type.
+func encodeWireBody(format string, jsonBody []byte) ([]byte, string, error) {
+	if format != WireFormatCBOR {
+		return jsonBody, "application/json", nil
+	}
+	raw, err := cborjson.FromJSON(jsonBody)
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, cborjson.ContentType, nil
+}
+
+// setWireAccept asks for a response in format.
+func setWireAccept(req *http.Request, format string) {
+	if format == WireFormatCBOR {
+		req.Header.Set("Accept", cborjson.ContentType+", application/json;q=0.5")
+	}
+}
+
+// decodeWireBody returns a response body as JSON, transcoding it
+// when the peer answered in CBOR.
+func decodeWireBody(h http.Header, body []byte) ([]byte, error) {
+	if !isCBORContentType(h.Get("Content-Type")) {
+		return body, nil
+	}
+	return cborjson.ToJSON(body)
+}
+
+func isCBORContentType(ct string) bool {
+	mediaType, _, _ := strings.Cut(ct, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), cborjson.ContentType)
+}
+
+// acceptsCBOR reports whether an Accept header lists CBOR with a
+// non-zero weight.
+func acceptsCBOR(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(fields[0]), cborjson.ContentType) {
+			continue
+		}
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if w, err := strconv.ParseFloat(v, 64); err == nil && w <= 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isWireExchangePath reports whether path, after the API prefix,
+// is one of the block and transaction exchange endpoints.
+func isWireExchangePath(path string) bool {
+	p := stripAPIPrefix(path)
+	return p == "/blocks" || p == "/getdata" ||
+		strings.HasPrefix(p, "/mempool/") || strings.HasPrefix(p, "/transactions/")
+}
+
+// WireFormatMiddleware translates CBOR bodies on the exchange
+// endpoints to and from the JSON the handlers speak.
+func WireFormatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWireExchangePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+
+		if r.Body != nil && isCBORContentType(r.Header.Get("Content-Type")) {
+			raw, err := io.ReadAll(r.Body)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Failed to read request body")
+				return
+			}
+			body, err := cborjson.ToJSON(raw)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid CBOR body: "+err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		if !acceptsCBOR(r.Header.Get("Accept")) || r.Header.Get(HeaderSignResponse) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buf := &bufferedResponse{header: w.Header()}
+		next.ServeHTTP(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		out := buf.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && len(out) > 0 {
+			if enc, err := cborjson.FromJSON(out); err == nil {
+				out = enc
+				w.Header().Set("Content-Type", cborjson.ContentType)
+			}
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(out)
+	})
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quidnug/quidnug/internal/cborjson"
+)
+
+func TestWireFormatMiddleware_BlocksAsCBOR(t *testing.T) {
+	node := newTestNode()
+	router := wireTestHandler(node)
+
+	req := httptest.NewRequest("GET", "/api/v1/blocks?limit=10", nil)
+	req.Header.Set("Accept", cborjson.ContentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != cborjson.ContentType {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var env struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Data []Block `json:"data"`
+		} `json:"data"`
+	}
+	if err := cborjson.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatal(err)
+	}
+	if !env.Success || len(env.Data.Data) != 1 || env.Data.Data[0].Index != 0 {
+		t.Errorf("decoded %+v", env)
+	}
+
+	// Plain requests and signed responses stay JSON.
+	for _, h := range []map[string]string{
+		{},
+		{"Accept": cborjson.ContentType, HeaderSignResponse: "1"},
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/blocks", nil)
+		for k, v := range h {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("headers %v: Content-Type = %q", h, ct)
+		}
+	}
+}
+
+func TestWireFormatMiddleware_CBORRequestBody(t *testing.T) {
+	node := newTestNode()
+	body, err := cborjson.Marshal(MempoolFetchRequest{IDs: []string{"nope"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/api/v1/mempool/test.domain.com/transactions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", cborjson.ContentType)
+	w := httptest.NewRecorder()
+	wireTestHandler(node).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/getdata", strings.NewReader("\xff\xff"))
+	req.Header.Set("Content-Type", cborjson.ContentType)
+	w = httptest.NewRecorder()
+	wireTestHandler(node).ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed CBOR got %d", w.Code)
+	}
+}
+
+func TestPeerJSONRequest_NegotiatesCBOR(t *testing.T) {
+	src := newTestNode()
+	var cborRequests int32
+	router := wireTestHandler(src)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") == cborjson.ContentType && acceptsCBOR(r.Header.Get("Accept")) {
+			atomic.AddInt32(&cborRequests, 1)
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	dst := newTestNode()
+	dst.wireFormat = WireFormatCBOR
+	req := MempoolFetchRequest{IDs: []string{"nope"}}
+	var resp MempoolFetchResponse
+
+	// A peer that never advertised CBOR gets JSON.
+	legacy := Node{ID: src.NodeID, Address: addr}
+	if err := dst.peerJSONRequest(t.Context(), legacy, "POST", "/api/v1/mempool/test.domain.com/transactions", req, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&cborRequests); n != 0 {
+		t.Fatalf("%d CBOR requests to a JSON-only peer", n)
+	}
+
+	peer := legacy
+	peer.PeerCapabilities = src.Capabilities()
+	if err := dst.peerJSONRequest(t.Context(), peer, "POST", "/api/v1/mempool/test.domain.com/transactions", req, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&cborRequests); n != 1 {
+		t.Errorf("CBOR requests = %d, want 1", n)
+	}
+	if resp.Domain != "test.domain.com" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestGetInfoHandler_AdvertisesEncodings(t *testing.T) {
+	node := newTestNode()
+	w := httptest.NewRecorder()
+	wireTestHandler(node).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/info", nil))
+	var env struct {
+		Data PeerCapabilities `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatal(err)
+	}
+	if !env.Data.acceptsEncoding(WireFormatCBOR) || !env.Data.acceptsEncoding(WireFormatJSON) {
+		t.Errorf("encodings = %v", env.Data.Encodings)
+	}
+}
+
+func TestParseWireFormat(t *testing.T) {
+	for in, want := range map[string]string{"": WireFormatJSON, "JSON": WireFormatJSON, " cbor ": WireFormatCBOR} {
+		if got, err := parseWireFormat(in); err != nil || got != want {
+			t.Errorf("parseWireFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := parseWireFormat("protobuf"); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if acceptsCBOR("application/cbor;q=0, application/json") || !acceptsCBOR("application/json;q=0.5, application/cbor") {
+		t.Error("acceptsCBOR misreads Accept weights")
+	}
+}
+
+// wireTestHandler is the node's full handler, middleware included.
+func wireTestHandler(node *QuidnugNode) http.Handler {
+	return node.NewHTTPHandler(10000, 1<<20)
+}
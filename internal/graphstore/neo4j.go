@@ -0,0 +1,217 @@
+package graphstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxNeo4jPathDepth caps variable-length path expansion. Cypher
+// cannot parameterize the bound, and past six hops the product of
+// sub-1.0 trust levels is negligible while the query cost is not.
+const MaxNeo4jPathDepth = 6
+
+// Neo4jStore implements Store against Neo4j's HTTP transactional
+// endpoint (POST /db/{database}/tx/commit). Quids are :Quid nodes
+// keyed by id; edges are :TRUSTS relationships carrying level,
+// validUntil, and domain.
+type Neo4jStore struct {
+	baseURL    string
+	database   string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewNeo4jStore creates a store for the Neo4j server at baseURL
+// (e.g. http://localhost:7474). An empty database selects "neo4j".
+func NewNeo4jStore(baseURL, database, username, password string, httpClient *http.Client) *Neo4jStore {
+	if database == "" {
+		database = "neo4j"
+	}
+	return &Neo4jStore{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		database:   database,
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+	}
+}
+
+type cypherStatement struct {
+	Statement  string                 `json:"statement"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type cypherResponse struct {
+	Results []struct {
+		Columns []string `json:"columns"`
+		Data    []struct {
+			Row []json.RawMessage `json:"row"`
+		} `json:"data"`
+	} `json:"results"`
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// run executes one Cypher statement in an auto-commit transaction
+// and returns its result rows.
+func (s *Neo4jStore) run(ctx context.Context, statement string, params map[string]interface{}) ([][]json.RawMessage, error) {
+	if s.httpClient == nil || s.baseURL == "" {
+		return nil, ErrNotConfigured
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"statements": []cypherStatement{{Statement: statement, Parameters: params}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cypher request: %w", err)
+	}
+
+	reqURL := s.baseURL + "/db/" + s.database + "/tx/commit"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%w: status %d: %s", ErrUnavailable, resp.StatusCode, string(msg))
+	}
+
+	var out cypherResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode cypher response: %w", err)
+	}
+	if len(out.Errors) > 0 {
+		return nil, fmt.Errorf("neo4j %s: %s", out.Errors[0].Code, out.Errors[0].Message)
+	}
+	if len(out.Results) == 0 {
+		return nil, nil
+	}
+	rows := make([][]json.RawMessage, 0, len(out.Results[0].Data))
+	for _, d := range out.Results[0].Data {
+		rows = append(rows, d.Row)
+	}
+	return rows, nil
+}
+
+// UpsertEdge implements Store.
+func (s *Neo4jStore) UpsertEdge(ctx context.Context, e Edge) error {
+	_, err := s.run(ctx, `
+MERGE (a:Quid {id: $truster})
+MERGE (b:Quid {id: $trustee})
+MERGE (a)-[r:TRUSTS]->(b)
+SET r.level = $level, r.validUntil = $validUntil, r.domain = $domain`,
+		map[string]interface{}{
+			"truster":    e.Truster,
+			"trustee":    e.Trustee,
+			"level":      e.Level,
+			"validUntil": e.ValidUntil,
+			"domain":     e.Domain,
+		})
+	return err
+}
+
+// RemoveEdge implements Store.
+func (s *Neo4jStore) RemoveEdge(ctx context.Context, truster, trustee string) error {
+	_, err := s.run(ctx, `
+MATCH (:Quid {id: $truster})-[r:TRUSTS]->(:Quid {id: $trustee})
+DELETE r`,
+		map[string]interface{}{"truster": truster, "trustee": trustee})
+	return err
+}
+
+// Trustees implements Store.
+func (s *Neo4jStore) Trustees(ctx context.Context, truster string, now int64) (map[string]float64, error) {
+	rows, err := s.run(ctx, `
+MATCH (:Quid {id: $truster})-[r:TRUSTS]->(b:Quid)
+WHERE r.validUntil = 0 OR r.validUntil > $now
+RETURN b.id, r.level`,
+		map[string]interface{}{"truster": truster, "now": now})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if len(row) != 2 {
+			continue
+		}
+		var id string
+		var level float64
+		if json.Unmarshal(row[0], &id) != nil || json.Unmarshal(row[1], &level) != nil {
+			continue
+		}
+		out[id] = level
+	}
+	return out, nil
+}
+
+// BestPath implements Store. maxDepth is clamped to
+// MaxNeo4jPathDepth.
+func (s *Neo4jStore) BestPath(ctx context.Context, observer, target string, maxDepth int, now int64) (float64, []string, error) {
+	if maxDepth <= 0 {
+		return 0, nil, ErrInvalidDepth
+	}
+	if observer == target {
+		return 1.0, []string{observer}, nil
+	}
+	if maxDepth > MaxNeo4jPathDepth {
+		maxDepth = MaxNeo4jPathDepth
+	}
+	// The depth bound is an int we control, so formatting it into
+	// the statement is safe.
+	statement := fmt.Sprintf(`
+MATCH p = (o:Quid {id: $observer})-[:TRUSTS*1..%d]->(t:Quid {id: $target})
+WHERE all(r IN relationships(p) WHERE r.validUntil = 0 OR r.validUntil > $now)
+  AND all(n IN nodes(p) WHERE single(m IN nodes(p) WHERE m = n))
+WITH p, reduce(acc = 1.0, r IN relationships(p) | acc * r.level) AS trust
+RETURN trust, [n IN nodes(p) | n.id] AS path
+ORDER BY trust DESC
+LIMIT 1`, maxDepth)
+
+	rows, err := s.run(ctx, statement, map[string]interface{}{
+		"observer": observer,
+		"target":   target,
+		"now":      now,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) != 2 {
+		return 0, nil, nil
+	}
+	var trust float64
+	var path []string
+	if err := json.Unmarshal(rows[0][0], &trust); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode path trust: %w", err)
+	}
+	if err := json.Unmarshal(rows[0][1], &path); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode path: %w", err)
+	}
+	return trust, path, nil
+}
+
+// Close implements Store.
+func (s *Neo4jStore) Close() error {
+	if s.httpClient != nil {
+		s.httpClient.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package graphstore defines a pluggable backend for the trust
+// graph. The node's in-memory TrustRegistry remains the default and
+// the source of truth for validation; a Store mirrors it and can
+// answer trust-path queries when the graph outgrows in-memory BFS.
+//
+// Two implementations ship here: MemoryStore, a map-backed store
+// used in tests and as a reference for the query semantics, and
+// Neo4jStore, which speaks Neo4j's HTTP transactional Cypher API so
+// no driver dependency is needed.
+package graphstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Backend names accepted in configuration.
+const (
+	BackendMemory = "memory"
+	BackendNeo4j  = "neo4j"
+)
+
+// Package-level errors for graph store operations.
+var (
+	ErrNotConfigured = errors.New("graph store not configured")
+	ErrUnavailable   = errors.New("graph store unavailable")
+	ErrInvalidDepth  = errors.New("graph store: maxDepth must be positive")
+)
+
+// Edge is one directed trust edge as mirrored into a store.
+// ValidUntil is Unix seconds; zero means no expiry.
+type Edge struct {
+	Truster    string  `json:"truster"`
+	Trustee    string  `json:"trustee"`
+	Level      float64 `json:"level"`
+	ValidUntil int64   `json:"validUntil"`
+	Domain     string  `json:"domain,omitempty"`
+}
+
+// Store is a trust graph backend. Path semantics match the node's
+// ComputeRelationalTrust: trust along a path is the product of its
+// edge levels, cycles are not followed, and the best path is the
+// one with maximal product within maxDepth hops. Edges whose
+// ValidUntil is non-zero and <= now are ignored.
+type Store interface {
+	// UpsertEdge creates or replaces the (truster, trustee) edge.
+	UpsertEdge(ctx context.Context, e Edge) error
+	// RemoveEdge deletes the (truster, trustee) edge if present.
+	RemoveEdge(ctx context.Context, truster, trustee string) error
+	// Trustees returns truster's live outbound edges.
+	Trustees(ctx context.Context, truster string, now int64) (map[string]float64, error)
+	// BestPath returns the maximal-trust path from observer to
+	// target, or (0, nil) when none exists within maxDepth.
+	BestPath(ctx context.Context, observer, target string, maxDepth int, now int64) (float64, []string, error)
+	// Close releases backend resources.
+	Close() error
+}
+
+// MemoryStore implements Store with in-process maps.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	edges map[string]map[string]Edge
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{edges: make(map[string]map[string]Edge)}
+}
+
+func live(e Edge, now int64) bool {
+	return e.ValidUntil == 0 || e.ValidUntil > now
+}
+
+// UpsertEdge implements Store.
+func (s *MemoryStore) UpsertEdge(_ context.Context, e Edge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.edges[e.Truster]; !ok {
+		s.edges[e.Truster] = make(map[string]Edge)
+	}
+	s.edges[e.Truster][e.Trustee] = e
+	return nil
+}
+
+// RemoveEdge implements Store.
+func (s *MemoryStore) RemoveEdge(_ context.Context, truster, trustee string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if out, ok := s.edges[truster]; ok {
+		delete(out, trustee)
+		if len(out) == 0 {
+			delete(s.edges, truster)
+		}
+	}
+	return nil
+}
+
+// Trustees implements Store.
+func (s *MemoryStore) Trustees(_ context.Context, truster string, now int64) (map[string]float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]float64, len(s.edges[truster]))
+	for trustee, e := range s.edges[truster] {
+		if live(e, now) {
+			out[trustee] = e.Level
+		}
+	}
+	return out, nil
+}
+
+// BestPath implements Store with an exhaustive depth-first search
+// over simple paths. Fine for the graph sizes MemoryStore is meant
+// for; large graphs belong in a real graph database.
+func (s *MemoryStore) BestPath(_ context.Context, observer, target string, maxDepth int, now int64) (float64, []string, error) {
+	if maxDepth <= 0 {
+		return 0, nil, ErrInvalidDepth
+	}
+	if observer == target {
+		return 1.0, []string{observer}, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	best := 0.0
+	var bestPath []string
+	onPath := map[string]bool{observer: true}
+	path := []string{observer}
+
+	var walk func(quid string, trust float64)
+	walk = func(quid string, trust float64) {
+		// Deterministic order so ties resolve the same way.
+		trustees := make([]string, 0, len(s.edges[quid]))
+		for t := range s.edges[quid] {
+			trustees = append(trustees, t)
+		}
+		sort.Strings(trustees)
+		for _, trustee := range trustees {
+			e := s.edges[quid][trustee]
+			if onPath[trustee] || !live(e, now) {
+				continue
+			}
+			pathTrust := trust * e.Level
+			if trustee == target {
+				if pathTrust > best {
+					best = pathTrust
+					bestPath = append(append([]string(nil), path...), trustee)
+				}
+				continue
+			}
+			if len(path) < maxDepth {
+				onPath[trustee] = true
+				path = append(path, trustee)
+				walk(trustee, pathTrust)
+				path = path[:len(path)-1]
+				delete(onPath, trustee)
+			}
+		}
+	}
+	walk(observer, 1.0)
+	return best, bestPath, nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error { return nil }
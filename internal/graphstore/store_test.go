@@ -0,0 +1,163 @@
+package graphstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func seedMemory(t *testing.T) *MemoryStore {
+	t.Helper()
+	s := NewMemoryStore()
+	ctx := context.Background()
+	for _, e := range []Edge{
+		{Truster: "a", Trustee: "b", Level: 0.9},
+		{Truster: "b", Trustee: "d", Level: 0.5},
+		{Truster: "a", Trustee: "c", Level: 0.8},
+		{Truster: "c", Trustee: "d", Level: 0.9},
+		{Truster: "d", Trustee: "a", Level: 1.0}, // cycle back to the observer
+	} {
+		if err := s.UpsertEdge(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestMemoryStore_BestPath(t *testing.T) {
+	s := seedMemory(t)
+	ctx := context.Background()
+
+	trust, path, err := s.BestPath(ctx, "a", "d", 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(path, ">") != "a>c>d" || trust < 0.719 || trust > 0.721 {
+		t.Errorf("got %v via %v, want 0.72 via a>c>d", trust, path)
+	}
+
+	if trust, _, _ := s.BestPath(ctx, "a", "d", 1, 0); trust != 0 {
+		t.Errorf("depth 1 should not reach d, got %v", trust)
+	}
+	if _, _, err := s.BestPath(ctx, "a", "d", 0, 0); !errors.Is(err, ErrInvalidDepth) {
+		t.Errorf("expected ErrInvalidDepth, got %v", err)
+	}
+}
+
+func TestMemoryStore_ExpiryAndRemoval(t *testing.T) {
+	s := seedMemory(t)
+	ctx := context.Background()
+
+	// Expire the strongest route; the weaker one should win.
+	s.UpsertEdge(ctx, Edge{Truster: "c", Trustee: "d", Level: 0.9, ValidUntil: 100})
+	_, path, _ := s.BestPath(ctx, "a", "d", 3, 200)
+	if strings.Join(path, ">") != "a>b>d" {
+		t.Errorf("expired edge should be skipped, got %v", path)
+	}
+
+	s.RemoveEdge(ctx, "b", "d")
+	if trust, _, _ := s.BestPath(ctx, "a", "d", 3, 200); trust != 0 {
+		t.Errorf("no live path should remain, got %v", trust)
+	}
+	out, _ := s.Trustees(ctx, "c", 200)
+	if len(out) != 0 {
+		t.Errorf("expired edge listed as trustee: %v", out)
+	}
+}
+
+// fakeNeo4j answers tx/commit requests with canned rows and
+// records the statements it saw.
+func fakeNeo4j(t *testing.T, rows [][]interface{}) (*httptest.Server, *[]cypherStatement) {
+	t.Helper()
+	var seen []cypherStatement
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/trust/tx/commit" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if u, p, ok := r.BasicAuth(); !ok || u != "neo" || p != "secret" {
+			t.Errorf("missing basic auth")
+		}
+		var req struct {
+			Statements []cypherStatement `json:"statements"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		seen = append(seen, req.Statements...)
+
+		data := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			data = append(data, map[string]interface{}{"row": row})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{{"columns": []string{}, "data": data}},
+			"errors":  []interface{}{},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func TestNeo4jStore_BestPath(t *testing.T) {
+	srv, seen := fakeNeo4j(t, [][]interface{}{{0.72, []string{"a", "c", "d"}}})
+	s := NewNeo4jStore(srv.URL+"/", "trust", "neo", "secret", srv.Client())
+
+	trust, path, err := s.BestPath(context.Background(), "a", "d", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trust != 0.72 || strings.Join(path, ">") != "a>c>d" {
+		t.Errorf("got %v via %v", trust, path)
+	}
+	if len(*seen) != 1 || !strings.Contains((*seen)[0].Statement, "[:TRUSTS*1..6]") {
+		t.Errorf("depth should clamp to %d: %+v", MaxNeo4jPathDepth, *seen)
+	}
+	if (*seen)[0].Parameters["observer"] != "a" {
+		t.Errorf("observer not passed as a parameter: %+v", (*seen)[0].Parameters)
+	}
+}
+
+func TestNeo4jStore_UpsertAndTrustees(t *testing.T) {
+	srv, seen := fakeNeo4j(t, [][]interface{}{{"b", 0.9}})
+	s := NewNeo4jStore(srv.URL, "trust", "neo", "secret", srv.Client())
+	ctx := context.Background()
+
+	if err := s.UpsertEdge(ctx, Edge{Truster: "a", Trustee: "b", Level: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains((*seen)[0].Statement, "MERGE (a)-[r:TRUSTS]->(b)") {
+		t.Errorf("unexpected upsert statement: %s", (*seen)[0].Statement)
+	}
+
+	out, err := s.Trustees(ctx, "a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["b"] != 0.9 {
+		t.Errorf("unexpected trustees: %v", out)
+	}
+}
+
+func TestNeo4jStore_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []interface{}{},
+			"errors":  []map[string]string{{"code": "Neo.ClientError.Statement.SyntaxError", "message": "bad"}},
+		})
+	}))
+	defer srv.Close()
+	s := NewNeo4jStore(srv.URL, "", "", "", srv.Client())
+	if err := s.RemoveEdge(context.Background(), "a", "b"); err == nil || !strings.Contains(err.Error(), "SyntaxError") {
+		t.Errorf("expected cypher error, got %v", err)
+	}
+
+	down := NewNeo4jStore("http://127.0.0.1:1", "", "", "", &http.Client{})
+	if err := down.RemoveEdge(context.Background(), "a", "b"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+	if err := NewNeo4jStore("", "", "", "", nil).RemoveEdge(context.Background(), "a", "b"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
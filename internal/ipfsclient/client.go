@@ -141,6 +141,40 @@ func (c *HTTPIPFSClient) Get(ctx context.Context, cid string) ([]byte, error) {
 	return data, nil
 }
 
+// Unpin releases a pin so the IPFS node may garbage-collect the
+// content. Content that is not pinned is not an error.
+func (c *HTTPIPFSClient) Unpin(ctx context.Context, cid string) error {
+	if c.httpClient == nil {
+		return ErrIPFSNotConfigured
+	}
+
+	if !IsValidCID(cid) {
+		return ErrInvalidCID
+	}
+
+	reqURL := c.gatewayURL + "/api/v0/pin/rm?arg=" + url.QueryEscape(cid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIPFSUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if strings.Contains(string(body), "not pinned") {
+			return nil
+		}
+		return fmt.Errorf("%w: status %d: %s", ErrIPFSUnavailable, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 // IsAvailable checks if the IPFS service is configured and reachable
 func (c *HTTPIPFSClient) IsAvailable() bool {
 	if c.httpClient == nil || c.gatewayURL == "" {
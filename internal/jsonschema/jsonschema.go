@@ -0,0 +1,382 @@
+// Package jsonschema validates decoded JSON values against a small,
+// dependency-free subset of JSON Schema (draft 2020-12 keyword
+// names). It covers what payload schemas for custom transaction
+// types and request bodies actually use:
+//
+//   - type (a name or a list of names), enum, const
+//   - object: properties, required, additionalProperties (bool or
+//     schema), minProperties, maxProperties
+//   - array: items, minItems, maxItems, uniqueItems
+//   - string: minLength, maxLength (in runes), pattern (RE2)
+//   - number: minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//
+// Unknown keywords ($schema, title, description, format, ...) are
+// ignored, so schemas written for a full validator still compile.
+// Values must be what encoding/json produces when decoding into
+// interface{}: map[string]interface{}, []interface{}, string,
+// float64, bool or nil.
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema wraps every compile failure.
+var ErrInvalidSchema = errors.New("jsonschema: invalid schema")
+
+// FieldError is one validation failure. Path is a dotted path from
+// the root ("" for the root itself, "items[2].name" for nested
+// values).
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Schema is a compiled schema. Safe for concurrent use.
+type Schema struct {
+	types      []string
+	enum       []interface{}
+	constVal   interface{}
+	hasConst   bool
+	properties map[string]*Schema
+	required   []string
+	// additional is nil when any extra property is allowed;
+	// noAdditional rejects every extra property.
+	additional   *Schema
+	noAdditional bool
+	items        *Schema
+	pattern      *regexp.Regexp
+
+	minLength, maxLength         *int
+	minItems, maxItems           *int
+	minProperties, maxProperties *int
+	uniqueItems                  bool
+	minimum, maximum             *float64
+	exclusiveMin, exclusiveMax   *float64
+}
+
+// rawSchema is the wire shape accepted by Compile.
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Enum                 []interface{}              `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Pattern              *string                    `json:"pattern"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	UniqueItems          bool                       `json:"uniqueItems"`
+	MinProperties        *int                       `json:"minProperties"`
+	MaxProperties        *int                       `json:"maxProperties"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Compile parses and checks a schema document.
+func Compile(data []byte) (*Schema, error) {
+	return compile(data, "")
+}
+
+// MustCompile is Compile for schemas known at build time. Panics
+// on error.
+func MustCompile(data string) *Schema {
+	s, err := Compile([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func compile(data []byte, at string) (*Schema, error) {
+	fail := func(format string, args ...interface{}) error {
+		where := at
+		if where == "" {
+			where = "root"
+		}
+		return fmt.Errorf("%w: %s: %s", ErrInvalidSchema, where, fmt.Sprintf(format, args...))
+	}
+
+	if string(data) == "true" {
+		return &Schema{}, nil
+	}
+	var raw rawSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fail("%v", err)
+	}
+
+	s := &Schema{
+		enum:          raw.Enum,
+		required:      raw.Required,
+		minLength:     raw.MinLength,
+		maxLength:     raw.MaxLength,
+		minItems:      raw.MinItems,
+		maxItems:      raw.MaxItems,
+		uniqueItems:   raw.UniqueItems,
+		minProperties: raw.MinProperties,
+		maxProperties: raw.MaxProperties,
+		minimum:       raw.Minimum,
+		maximum:       raw.Maximum,
+		exclusiveMin:  raw.ExclusiveMinimum,
+		exclusiveMax:  raw.ExclusiveMaximum,
+	}
+
+	if len(raw.Type) > 0 {
+		var one string
+		if err := json.Unmarshal(raw.Type, &one); err == nil {
+			s.types = []string{one}
+		} else if err := json.Unmarshal(raw.Type, &s.types); err != nil {
+			return nil, fail("type must be a string or an array of strings")
+		}
+		for _, t := range s.types {
+			if !knownTypes[t] {
+				return nil, fail("unknown type %q", t)
+			}
+		}
+	}
+
+	if len(raw.Const) > 0 {
+		if err := json.Unmarshal(raw.Const, &s.constVal); err != nil {
+			return nil, fail("const: %v", err)
+		}
+		s.hasConst = true
+	}
+
+	for _, bound := range []*int{raw.MinLength, raw.MaxLength, raw.MinItems, raw.MaxItems, raw.MinProperties, raw.MaxProperties} {
+		if bound != nil && *bound < 0 {
+			return nil, fail("length and count bounds must be non-negative")
+		}
+	}
+
+	if raw.Pattern != nil {
+		re, err := regexp.Compile(*raw.Pattern)
+		if err != nil {
+			return nil, fail("pattern: %v", err)
+		}
+		s.pattern = re
+	}
+
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(raw.Properties))
+		for name, sub := range raw.Properties {
+			compiled, err := compile(sub, joinPath(at, name))
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	if len(raw.AdditionalProperties) > 0 {
+		switch string(raw.AdditionalProperties) {
+		case "false":
+			s.noAdditional = true
+		case "true":
+		default:
+			compiled, err := compile(raw.AdditionalProperties, joinPath(at, "*"))
+			if err != nil {
+				return nil, err
+			}
+			s.additional = compiled
+		}
+	}
+
+	if len(raw.Items) > 0 {
+		compiled, err := compile(raw.Items, at+"[]")
+		if err != nil {
+			return nil, err
+		}
+		s.items = compiled
+	}
+
+	return s, nil
+}
+
+// Validate checks v and returns every failure found, ordered by
+// path. A nil result means v conforms.
+func (s *Schema) Validate(v interface{}) []FieldError {
+	var errs []FieldError
+	s.validate(v, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func (s *Schema) validate(v interface{}, path string, errs *[]FieldError) {
+	add := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !s.matchesType(v) {
+		add("expected %s, got %s", typeList(s.types), typeOf(v))
+		return
+	}
+	if s.hasConst && !reflect.DeepEqual(v, s.constVal) {
+		add("must equal %v", s.constVal)
+	}
+	if len(s.enum) > 0 {
+		found := false
+		for _, allowed := range s.enum {
+			if reflect.DeepEqual(v, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %v", s.enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.minLength != nil && n < *s.minLength {
+			add("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			add("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			add("must match pattern %s", s.pattern.String())
+		}
+
+	case float64:
+		if s.minimum != nil && val < *s.minimum {
+			add("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && val > *s.maximum {
+			add("must be <= %v", *s.maximum)
+		}
+		if s.exclusiveMin != nil && val <= *s.exclusiveMin {
+			add("must be > %v", *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && val >= *s.exclusiveMax {
+			add("must be < %v", *s.exclusiveMax)
+		}
+
+	case []interface{}:
+		if s.minItems != nil && len(val) < *s.minItems {
+			add("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(val) > *s.maxItems {
+			add("must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := range val {
+				for j := i + 1; j < len(val); j++ {
+					if reflect.DeepEqual(val[i], val[j]) {
+						add("items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range val {
+				s.items.validate(item, path+"["+strconv.Itoa(i)+"]", errs)
+			}
+		}
+
+	case map[string]interface{}:
+		if s.minProperties != nil && len(val) < *s.minProperties {
+			add("must have at least %d properties", *s.minProperties)
+		}
+		if s.maxProperties != nil && len(val) > *s.maxProperties {
+			add("must have at most %d properties", *s.maxProperties)
+		}
+		for _, name := range s.required {
+			if _, ok := val[name]; !ok {
+				*errs = append(*errs, FieldError{Path: joinPath(path, name), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if sub, ok := s.properties[k]; ok {
+				sub.validate(val[k], joinPath(path, k), errs)
+				continue
+			}
+			if s.noAdditional {
+				*errs = append(*errs, FieldError{Path: joinPath(path, k), Message: "is not allowed"})
+			} else if s.additional != nil {
+				s.additional.validate(val[k], joinPath(path, k), errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) matchesType(v interface{}) bool {
+	actual := typeOf(v)
+	for _, t := range s.types {
+		if t == actual {
+			return true
+		}
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value. Whole float64s
+// report as "integer".
+func typeOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeList(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return fmt.Sprintf("one of %v", types)
+}
+
+func joinPath(base, name string) string {
+	if base == "" {
+		return name
+	}
+	return base + "." + name
+}
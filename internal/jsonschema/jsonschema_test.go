@@ -0,0 +1,121 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+const vehicleSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["vin", "odometer"],
+	"additionalProperties": false,
+	"properties": {
+		"vin": {"type": "string", "pattern": "^[A-HJ-NPR-Z0-9]{17}$"},
+		"odometer": {"type": "integer", "minimum": 0},
+		"fuel": {"enum": ["petrol", "diesel", "electric"]},
+		"owners": {
+			"type": "array",
+			"maxItems": 3,
+			"uniqueItems": true,
+			"items": {"type": "string", "minLength": 1}
+		}
+	}
+}`
+
+func TestValidateAccepts(t *testing.T) {
+	s := MustCompile(vehicleSchema)
+	v := decode(t, `{"vin":"1HGCM82633A004352","odometer":1200,"fuel":"diesel","owners":["a","b"]}`)
+	if errs := s.Validate(v); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateReportsEveryFailureWithPaths(t *testing.T) {
+	s := MustCompile(vehicleSchema)
+	v := decode(t, `{"vin":"short","odometer":-1.5,"fuel":"coal","owners":["a","a",""],"color":"red"}`)
+	errs := s.Validate(v)
+
+	want := map[string]bool{
+		"color":     false,
+		"fuel":      false,
+		"odometer":  false,
+		"owners":    false,
+		"owners[2]": false,
+		"vin":       false,
+	}
+	for _, e := range errs {
+		if _, ok := want[e.Path]; !ok {
+			t.Errorf("unexpected error %v", e)
+			continue
+		}
+		want[e.Path] = true
+	}
+	for path, seen := range want {
+		if !seen {
+			t.Errorf("missing error for %q (got %v)", path, errs)
+		}
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i-1].Path > errs[i].Path {
+			t.Fatalf("errors not ordered by path: %v", errs)
+		}
+	}
+}
+
+func TestValidateRequiredAndTypes(t *testing.T) {
+	s := MustCompile(vehicleSchema)
+	errs := s.Validate(decode(t, `{}`))
+	if len(errs) != 2 || errs[0].Path != "odometer" || errs[1].Path != "vin" {
+		t.Fatalf("expected two required errors, got %v", errs)
+	}
+	errs = s.Validate(decode(t, `[1]`))
+	if len(errs) != 1 || errs[0].Path != "" || errs[0].Error() != "expected object, got array" {
+		t.Fatalf("expected root type error, got %v", errs)
+	}
+}
+
+func TestNumberAcceptsIntegers(t *testing.T) {
+	s := MustCompile(`{"type":["number","null"],"exclusiveMaximum":10}`)
+	for _, ok := range []string{`3`, `3.5`, `null`} {
+		if errs := s.Validate(decode(t, ok)); errs != nil {
+			t.Errorf("%s: unexpected %v", ok, errs)
+		}
+	}
+	if errs := s.Validate(decode(t, `10`)); len(errs) != 1 {
+		t.Errorf("expected exclusiveMaximum failure, got %v", errs)
+	}
+}
+
+func TestAdditionalPropertiesSchema(t *testing.T) {
+	s := MustCompile(`{"type":"object","additionalProperties":{"type":"string","maxLength":3},"maxProperties":2}`)
+	errs := s.Validate(decode(t, `{"a":"ok","b":"toolong","c":1}`))
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+}
+
+func TestCompileRejectsBadSchemas(t *testing.T) {
+	for _, bad := range []string{
+		`{"type":"float"}`,
+		`{"type":7}`,
+		`{"pattern":"("}`,
+		`{"minLength":-1}`,
+		`{"properties":{"x":{"type":"bogus"}}}`,
+		`[]`,
+	} {
+		if _, err := Compile([]byte(bad)); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: expected ErrInvalidSchema, got %v", bad, err)
+		}
+	}
+}
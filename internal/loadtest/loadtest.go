@@ -0,0 +1,310 @@
+// Package loadtest drives a node's hot paths under concurrency and
+// reports throughput and latency.
+//
+// Each workload runs for a fixed wall-clock duration on a pool of
+// workers against state built by the core synthetic helpers:
+//
+//   - trust: ComputeRelationalTrust between random quids of a
+//     seeded synthetic graph, with the trust cache off.
+//   - block: ValidateBlockTiered on one sealed block of BlockTxs
+//     signed transactions.
+//   - http:  GET /info and /trust/{a}/{b} through the node's full
+//     handler stack, in-process or against a live node (Target).
+//
+// Reports can be saved as a baseline and compared later; Compare
+// flags any workload whose throughput fell by more than a
+// tolerance. Numbers are only comparable on the same host.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/core"
+)
+
+// Workload names.
+const (
+	WorkloadTrust = "trust"
+	WorkloadBlock = "block"
+	WorkloadHTTP  = "http"
+)
+
+// AllWorkloads lists every workload in run order.
+var AllWorkloads = []string{WorkloadTrust, WorkloadBlock, WorkloadHTTP}
+
+// Options configures a run. Zero fields take the Default values.
+type Options struct {
+	Workloads   []string      `json:"workloads"`
+	Duration    time.Duration `json:"duration"`
+	Concurrency int           `json:"concurrency"`
+	GraphSize   int           `json:"graphSize"`
+	Degree      int           `json:"degree"`
+	BlockTxs    int           `json:"blockTxs"`
+	Seed        int64         `json:"seed"`
+	// Target is a node base URL for the http workload. Empty runs
+	// the handler in-process against the synthetic graph.
+	Target string `json:"target,omitempty"`
+}
+
+// Defaults for Options.
+const (
+	DefaultDuration  = 5 * time.Second
+	DefaultGraphSize = 10_000
+	DefaultDegree    = 8
+	DefaultBlockTxs  = 1_000
+)
+
+func (o *Options) fill() error {
+	if len(o.Workloads) == 0 {
+		o.Workloads = AllWorkloads
+	}
+	for _, w := range o.Workloads {
+		if w != WorkloadTrust && w != WorkloadBlock && w != WorkloadHTTP {
+			return fmt.Errorf("loadtest: unknown workload %q", w)
+		}
+	}
+	if o.Duration <= 0 {
+		o.Duration = DefaultDuration
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if o.GraphSize <= 1 {
+		o.GraphSize = DefaultGraphSize
+	}
+	if o.Degree <= 0 {
+		o.Degree = DefaultDegree
+	}
+	if o.BlockTxs <= 0 {
+		o.BlockTxs = DefaultBlockTxs
+	}
+	return nil
+}
+
+// Result is one workload's measurements.
+type Result struct {
+	Workload  string        `json:"workload"`
+	Ops       int           `json:"ops"`
+	Errors    int           `json:"errors"`
+	OpsPerSec float64       `json:"opsPerSec"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+}
+
+// Report is a full run, with enough about the host to tell whether
+// two reports are comparable.
+type Report struct {
+	Options   Options   `json:"options"`
+	Results   []Result  `json:"results"`
+	GoVersion string    `json:"goVersion"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	CPUs      int       `json:"cpus"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Run executes opts.Workloads in order.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.fill(); err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Options:   opts,
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		StartedAt: time.Now().UTC(),
+	}
+	for _, name := range opts.Workloads {
+		op, err := newOp(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		res := measure(ctx, name, opts, op)
+		report.Results = append(report.Results, res)
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+	}
+	return report, nil
+}
+
+// op is one unit of work. Each worker gets its own rng.
+type op func(rng *rand.Rand) error
+
+func newOp(name string, opts Options) (op, error) {
+	node, err := core.NewQuidnugNode(nil)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case WorkloadTrust:
+		node.TrustCache = nil
+		node.SeedSyntheticTrustGraph(opts.GraphSize, opts.Degree, opts.Seed)
+		return func(rng *rand.Rand) error {
+			from, to := randomPair(rng, opts.GraphSize)
+			_, _, err := node.ComputeRelationalTrust(context.Background(), from, to, core.DefaultTrustMaxDepth)
+			return err
+		}, nil
+
+	case WorkloadBlock:
+		block, err := node.SyntheticTrustBlock("loadtest.local", opts.BlockTxs)
+		if err != nil {
+			return nil, err
+		}
+		return func(*rand.Rand) error {
+			if got := node.ValidateBlockTiered(*block); got != core.BlockTrusted {
+				return fmt.Errorf("synthetic block validated as %v", got)
+			}
+			return nil
+		}, nil
+
+	default: // WorkloadHTTP
+		if opts.Target != "" {
+			return remoteHTTPOp(opts), nil
+		}
+		node.SeedSyntheticTrustGraph(opts.GraphSize, opts.Degree, opts.Seed)
+		handler := node.NewHTTPHandler(1<<30, 1<<20)
+		return func(rng *rand.Rand) error {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, httpPath(rng, opts.GraphSize), nil))
+			if rr.Code != http.StatusOK {
+				return fmt.Errorf("status %d", rr.Code)
+			}
+			return nil
+		}, nil
+	}
+}
+
+func remoteHTTPOp(opts Options) op {
+	base := strings.TrimRight(opts.Target, "/")
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency},
+	}
+	return func(rng *rand.Rand) error {
+		resp, err := client.Get(base + httpPath(rng, opts.GraphSize))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// httpPath alternates a cheap read with a trust query so the mix
+// covers both middleware overhead and a graph walk.
+func httpPath(rng *rand.Rand, graphSize int) string {
+	if rng.Intn(2) == 0 {
+		return "/api/v1/info"
+	}
+	from, to := randomPair(rng, graphSize)
+	return "/api/v1/trust/" + from + "/" + to
+}
+
+func randomPair(rng *rand.Rand, n int) (string, string) {
+	i := rng.Intn(n)
+	j := rng.Intn(n - 1)
+	if j >= i {
+		j++
+	}
+	return core.SyntheticQuid(i), core.SyntheticQuid(j)
+}
+
+func measure(ctx context.Context, name string, opts Options, fn op) Result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	latencies := make([][]time.Duration, opts.Concurrency)
+	errs := make([]int, opts.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(opts.Seed + int64(w))) // #nosec G404 -- workload sampling, not security
+			for ctx.Err() == nil {
+				t := time.Now()
+				if err := fn(rng); err != nil {
+					errs[w]++
+				}
+				latencies[w] = append(latencies[w], time.Since(t))
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var all []time.Duration
+	res := Result{Workload: name}
+	for w := range latencies {
+		all = append(all, latencies[w]...)
+		res.Errors += errs[w]
+	}
+	res.Ops = len(all)
+	if res.Ops == 0 {
+		return res
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	res.OpsPerSec = float64(res.Ops) / elapsed.Seconds()
+	res.P50 = all[res.Ops*50/100]
+	res.P90 = all[res.Ops*90/100]
+	res.P99 = all[res.Ops*99/100]
+	res.Max = all[res.Ops-1]
+	return res
+}
+
+// Regression is a workload whose throughput dropped past tolerance.
+type Regression struct {
+	Workload  string  `json:"workload"`
+	Baseline  float64 `json:"baselineOpsPerSec"`
+	Current   float64 `json:"currentOpsPerSec"`
+	DropRatio float64 `json:"dropRatio"`
+}
+
+// ErrNoCommonWorkloads is returned by Compare when the reports
+// share no workload.
+var ErrNoCommonWorkloads = errors.New("loadtest: baseline and run share no workload")
+
+// Compare returns the workloads in current whose ops/sec fell more
+// than tolerance (0.2 = 20%) below baseline.
+func Compare(baseline, current *Report, tolerance float64) ([]Regression, error) {
+	base := make(map[string]float64, len(baseline.Results))
+	for _, r := range baseline.Results {
+		base[r.Workload] = r.OpsPerSec
+	}
+	var out []Regression
+	common := 0
+	for _, r := range current.Results {
+		b, ok := base[r.Workload]
+		if !ok || b <= 0 {
+			continue
+		}
+		common++
+		drop := 1 - r.OpsPerSec/b
+		if drop > tolerance {
+			out = append(out, Regression{Workload: r.Workload, Baseline: b, Current: r.OpsPerSec, DropRatio: drop})
+		}
+	}
+	if common == 0 {
+		return nil, ErrNoCommonWorkloads
+	}
+	return out, nil
+}
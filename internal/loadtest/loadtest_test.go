@@ -0,0 +1,88 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/core"
+)
+
+func TestRun_AllWorkloads(t *testing.T) {
+	report, err := Run(context.Background(), Options{
+		Duration:    150 * time.Millisecond,
+		Concurrency: 2,
+		GraphSize:   500,
+		BlockTxs:    20,
+		Seed:        3,
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Results) != len(AllWorkloads) {
+		t.Fatalf("got %d results, want %d", len(report.Results), len(AllWorkloads))
+	}
+	for _, r := range report.Results {
+		if r.Ops == 0 || r.Errors != 0 || r.OpsPerSec <= 0 {
+			t.Errorf("%s: ops=%d errors=%d ops/s=%.1f", r.Workload, r.Ops, r.Errors, r.OpsPerSec)
+		}
+		if r.P50 > r.P99 || r.P99 > r.Max {
+			t.Errorf("%s: percentiles out of order: %+v", r.Workload, r)
+		}
+	}
+}
+
+func TestRun_RemoteTarget(t *testing.T) {
+	node, err := core.NewQuidnugNode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(node.NewHTTPHandler(1<<30, 1<<20))
+	defer srv.Close()
+
+	report, err := Run(context.Background(), Options{
+		Workloads:   []string{WorkloadHTTP},
+		Duration:    100 * time.Millisecond,
+		Concurrency: 2,
+		GraphSize:   100,
+		Target:      srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r := report.Results[0]; r.Ops == 0 || r.Errors != 0 {
+		t.Fatalf("remote http: ops=%d errors=%d", r.Ops, r.Errors)
+	}
+}
+
+func TestRun_UnknownWorkload(t *testing.T) {
+	if _, err := Run(context.Background(), Options{Workloads: []string{"nope"}}); err == nil {
+		t.Fatal("want error for unknown workload")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Results: []Result{
+		{Workload: WorkloadTrust, OpsPerSec: 1000},
+		{Workload: WorkloadBlock, OpsPerSec: 10},
+	}}
+	current := &Report{Results: []Result{
+		{Workload: WorkloadTrust, OpsPerSec: 900},
+		{Workload: WorkloadBlock, OpsPerSec: 5},
+		{Workload: WorkloadHTTP, OpsPerSec: 1},
+	}}
+	regs, err := Compare(baseline, current, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regs) != 1 || regs[0].Workload != WorkloadBlock || regs[0].DropRatio != 0.5 {
+		t.Fatalf("unexpected regressions %+v", regs)
+	}
+
+	other := &Report{Results: []Result{{Workload: WorkloadHTTP, OpsPerSec: 1}}}
+	if _, err := Compare(baseline, other, 0.2); !errors.Is(err, ErrNoCommonWorkloads) {
+		t.Fatalf("got %v, want ErrNoCommonWorkloads", err)
+	}
+}
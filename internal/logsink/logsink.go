@@ -0,0 +1,145 @@
+// Package logsink builds the node's slog handler from its logging
+// configuration: one or more outputs (stdout, stderr, a size-rotated
+// file, syslog) sharing one format (JSON lines or logfmt-style text)
+// and one adjustable level.
+//
+// syslog covers journald as well: journald listens on the local
+// syslog socket, so an empty address sends records to whichever of
+// the two the host runs.
+package logsink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Output names.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config selects where records go and how they look.
+type Config struct {
+	Format  string   // FormatJSON (default) or FormatText
+	Outputs []string // defaults to stdout
+
+	File           string // path for OutputFile
+	FileMaxSizeMB  int    // rotate past this size; 0 = never
+	FileMaxBackups int    // rotated files kept; 0 = keep only the live file
+
+	SyslogAddress string // "" = local socket; else "udp://host:port" or "tcp://host:port"
+	SyslogTag     string // defaults to "quidnug"
+}
+
+// ParseLevel maps a level name to a slog.Level. Unknown names are an
+// error so an admin typo is refused rather than silently ignored.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// New returns a handler writing to every configured output at level,
+// and a Closer releasing the files and connections it opened.
+func New(cfg Config, level slog.Leveler) (slog.Handler, io.Closer, error) {
+	newHandler := func(w io.Writer) slog.Handler {
+		opts := &slog.HandlerOptions{Level: level}
+		if cfg.Format == FormatText {
+			return slog.NewTextHandler(w, opts)
+		}
+		return slog.NewJSONHandler(w, opts)
+	}
+	switch cfg.Format {
+	case "", FormatJSON, FormatText:
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{OutputStdout}
+	}
+	var (
+		handlers []slog.Handler
+		closers  closeAll
+	)
+	fail := func(err error) (slog.Handler, io.Closer, error) {
+		closers.Close()
+		return nil, nil, err
+	}
+	seen := make(map[string]bool)
+	for _, out := range outputs {
+		out = strings.ToLower(strings.TrimSpace(out))
+		if seen[out] {
+			continue
+		}
+		seen[out] = true
+		switch out {
+		case OutputStdout:
+			handlers = append(handlers, newHandler(os.Stdout))
+		case OutputStderr:
+			handlers = append(handlers, newHandler(os.Stderr))
+		case OutputFile:
+			if cfg.File == "" {
+				return fail(errors.New("log output \"file\" needs a file path"))
+			}
+			f, err := OpenRotatingFile(cfg.File, int64(cfg.FileMaxSizeMB)<<20, cfg.FileMaxBackups)
+			if err != nil {
+				return fail(err)
+			}
+			closers = append(closers, f)
+			handlers = append(handlers, newHandler(f))
+		case OutputSyslog:
+			tag := cfg.SyslogTag
+			if tag == "" {
+				tag = "quidnug"
+			}
+			h, c, err := newSyslogHandler(cfg.SyslogAddress, tag, newHandler)
+			if err != nil {
+				return fail(err)
+			}
+			closers = append(closers, c)
+			handlers = append(handlers, h)
+		default:
+			return fail(fmt.Errorf("unknown log output %q", out))
+		}
+	}
+	if len(handlers) == 1 {
+		return handlers[0], closers, nil
+	}
+	return fanout(handlers), closers, nil
+}
+
+// closeAll closes each member, returning the first error.
+type closeAll []io.Closer
+
+func (c closeAll) Close() error {
+	var first error
+	for _, cl := range c {
+		if err := cl.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,119 @@
+package logsink
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileRotatesAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "node.log")
+	f, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	want := map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	}
+	for p, content := range want {
+		got, err := os.ReadFile(p)
+		if err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v; want %q", filepath.Base(p), got, err, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("only two backups should be kept")
+	}
+}
+
+func TestRotatingFileAppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	os.WriteFile(path, []byte("old\n"), 0o600)
+	f, err := OpenRotatingFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new\n"))
+	f.Close()
+	if got, _ := os.ReadFile(path); string(got) != "old\nnew\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNewFileOutputHonorsLevelAndFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	h, closer, err := New(Config{Format: FormatText, Outputs: []string{"file"}, File: path}, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(h).With("node", "n1")
+	l.Info("dropped")
+	l.Warn("kept")
+	level.Set(slog.LevelDebug)
+	l.Debug("now visible")
+	closer.Close()
+
+	got, _ := os.ReadFile(path)
+	s := string(got)
+	if strings.Contains(s, "dropped") || !strings.Contains(s, "msg=kept node=n1") || !strings.Contains(s, "now visible") {
+		t.Errorf("unexpected log file:\n%s", s)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"format":      {Format: "xml"},
+		"output":      {Outputs: []string{"kafka"}},
+		"file path":   {Outputs: []string{"file"}},
+		"syslog addr": {Outputs: []string{"syslog"}, SyslogAddress: "host:514"},
+	} {
+		if _, _, err := New(cfg, slog.LevelInfo); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFanoutWritesEveryOutput(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := OpenRotatingFile(filepath.Join(dir, "a.log"), 0, 0)
+	b, _ := OpenRotatingFile(filepath.Join(dir, "b.log"), 0, 0)
+	l := slog.New(fanout{
+		slog.NewJSONHandler(a, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewJSONHandler(b, &slog.HandlerOptions{Level: slog.LevelError}),
+	}).With("requestId", "r1")
+	l.Info("info line")
+	l.Error("error line")
+	a.Close()
+	b.Close()
+
+	ga, _ := os.ReadFile(filepath.Join(dir, "a.log"))
+	gb, _ := os.ReadFile(filepath.Join(dir, "b.log"))
+	if strings.Count(string(ga), `"requestId":"r1"`) != 2 {
+		t.Errorf("a.log: %s", ga)
+	}
+	if strings.Contains(string(gb), "info line") || !strings.Contains(string(gb), "error line") {
+		t.Errorf("b.log: %s", gb)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel("WARNING"); err != nil || l != slog.LevelWarn {
+		t.Errorf("WARNING = %v, %v", l, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
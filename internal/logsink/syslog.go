@@ -0,0 +1,80 @@
+//go:build !windows && !plan9
+
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+// newSyslogHandler dials syslog at addr and returns a handler that
+// formats each record with newHandler and sends it at the syslog
+// severity matching its level.
+func newSyslogHandler(addr, tag string, newHandler func(io.Writer) slog.Handler) (slog.Handler, io.Closer, error) {
+	network, raddr := "", ""
+	if addr != "" {
+		var ok bool
+		network, raddr, ok = strings.Cut(addr, "://")
+		if !ok || (network != "udp" && network != "tcp") || raddr == "" {
+			return nil, nil, fmt.Errorf("syslog address %q: want udp://host:port or tcp://host:port", addr)
+		}
+	}
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("syslog: %w", err)
+	}
+	s := &syslogSink{w: w}
+	return &syslogHandler{sink: s, inner: newHandler(&s.buf)}, w, nil
+}
+
+// syslogSink is the buffer every derived handler formats into and
+// the connection it is flushed to; mu covers both.
+type syslogSink struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	w   *syslog.Writer
+}
+
+type syslogHandler struct {
+	sink  *syslogSink
+	inner slog.Handler
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	if err := h.inner.Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := strings.TrimRight(s.buf.String(), "\n")
+	switch {
+	case r.Level >= slog.LevelError:
+		return s.w.Err(msg)
+	case r.Level >= slog.LevelWarn:
+		return s.w.Warning(msg)
+	case r.Level >= slog.LevelInfo:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{sink: h.sink, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{sink: h.sink, inner: h.inner.WithGroup(name)}
+}
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package logsink
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// newSyslogHandler fails: the standard library has no syslog client
+// on this platform.
+func newSyslogHandler(string, string, func(io.Writer) slog.Handler) (slog.Handler, io.Closer, error) {
+	return nil, nil, errors.New("syslog output is not supported on this platform")
+}
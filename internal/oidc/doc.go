@@ -40,14 +40,17 @@
 //
 // This package is a scaffold: it defines the wire shapes and
 // lifecycle, and provides a simple in-memory binding store + HTTP
-// handlers. Production deployments should:
+// handlers. The node's API middleware uses Verifier on its own to
+// accept bearer tokens from an enterprise IdP (see oidc_issuer in
+// the node config). Production deployments should:
 //
 //   - Replace the in-memory BindingStore with a database-backed
 //     implementation (Postgres preferred).
-//   - Use an external OIDC verification library (coreos/go-oidc) for
-//     the discovery + JWKS + ID-token validation step. This package
-//     validates structure but trusts the caller to verify signatures
-//     using the standard coreos/go-oidc Verifier.
+//   - Verify ID tokens before handing them to the Bridge, which
+//     trusts its caller. Verifier does the discovery + JWKS +
+//     signature + issuer/audience/expiry checks for RS256 and ES256
+//     tokens; flows that need nonce binding should use a full OIDC
+//     client library (coreos/go-oidc) instead.
 //   - Run the HSM signer behind a short-lived session key cache so
 //     per-request HSM round-trips don't dominate latency.
 package oidc
@@ -0,0 +1,418 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQuidClaim is the token claim the Verifier reads a quid ID
+// from when VerifierConfig.QuidClaim is empty.
+const DefaultQuidClaim = "quidnug_quid"
+
+// Tuning for the Verifier. The JWKS is re-fetched when a token names
+// a key we have not seen, but no more often than jwksMinRefresh so a
+// stream of junk kids cannot turn the node into a load generator
+// against the IdP.
+const (
+	defaultLeeway     = time.Minute
+	jwksMinRefresh    = time.Minute
+	maxDiscoveryBytes = 1 << 20
+)
+
+// Token verification errors. Verify wraps them with detail; match
+// with errors.Is.
+var (
+	ErrTokenMalformed = errors.New("oidc: malformed token")
+	ErrTokenSignature = errors.New("oidc: token signature does not verify")
+	ErrTokenExpired   = errors.New("oidc: token expired or not yet valid")
+	ErrTokenClaims    = errors.New("oidc: token issuer or audience mismatch")
+	ErrNoQuid         = errors.New("oidc: token subject does not map to a quid")
+)
+
+var quidIDPattern = regexp.MustCompile(`^[a-f0-9]{16}$`)
+
+// VerifierConfig configures a Verifier.
+type VerifierConfig struct {
+	// Issuer is the OIDC issuer URL. Its discovery document at
+	// Issuer + "/.well-known/openid-configuration" names the JWKS.
+	Issuer string
+	// Audience, when set, must appear in the token's aud claim.
+	Audience string
+	// QuidClaim names the claim carrying the caller's quid ID.
+	// Defaults to DefaultQuidClaim.
+	QuidClaim string
+	// Bindings, when set, is consulted for tokens without QuidClaim.
+	Bindings BindingStore
+	// Leeway is the clock skew allowed on exp and nbf. Defaults to
+	// one minute.
+	Leeway time.Duration
+	// HTTPClient fetches discovery and JWKS documents. Defaults to a
+	// client with a ten-second timeout.
+	HTTPClient *http.Client
+}
+
+// Claims is the verified subset of a token the node acts on.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Email     string
+	Name      string
+	QuidID    string
+	ExpiresAt time.Time
+}
+
+// Verifier checks JWT bearer tokens issued by a single OIDC provider
+// and resolves each to the quid it acts for. RS256 and ES256 are
+// accepted, which covers every mainstream IdP's default.
+//
+// A subject maps to a quid by convention: the IdP puts the quid ID in
+// a custom claim (QuidClaim, usually populated from a user attribute),
+// or, failing that, the (issuer, sub) pair has a Binding in the
+// configured store. A sub that is itself a quid ID also maps to that
+// quid, for IdPs whose subjects are provisioned that way.
+type Verifier struct {
+	cfg    VerifierConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]crypto.PublicKey
+	refreshed time.Time
+}
+
+// NewVerifier returns a Verifier for cfg. The provider is not
+// contacted until the first token arrives, so a node can start while
+// its IdP is unreachable.
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.Issuer == "" {
+		return nil, errors.New("oidc: issuer is required")
+	}
+	if cfg.QuidClaim == "" {
+		cfg.QuidClaim = DefaultQuidClaim
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = defaultLeeway
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Verifier{cfg: cfg, client: client}, nil
+}
+
+// Issuer returns the configured issuer URL.
+func (v *Verifier) Issuer() string {
+	return v.cfg.Issuer
+}
+
+// tokenHeader is the JOSE header of a compact JWS.
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks raw's signature, issuer, audience and validity window,
+// and resolves its quid.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected three segments", ErrTokenMalformed)
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrTokenMalformed, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrTokenMalformed, err)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrTokenMalformed, err)
+	}
+	return v.checkClaims(claims)
+}
+
+// checkClaims validates the registered claims and maps the subject.
+func (v *Verifier) checkClaims(claims map[string]interface{}) (*Claims, error) {
+	str := func(name string) string {
+		s, _ := claims[name].(string)
+		return s
+	}
+	out := &Claims{
+		Issuer:  strings.TrimRight(str("iss"), "/"),
+		Subject: str("sub"),
+		Email:   str("email"),
+		Name:    str("name"),
+	}
+	if out.Issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q", ErrTokenClaims, out.Issuer)
+	}
+	if v.cfg.Audience != "" && !hasAudience(claims["aud"], v.cfg.Audience) {
+		return nil, fmt.Errorf("%w: audience", ErrTokenClaims)
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing exp", ErrTokenMalformed)
+	}
+	out.ExpiresAt = time.Unix(int64(exp), 0)
+	if now.After(out.ExpiresAt.Add(v.cfg.Leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrTokenExpired
+	}
+
+	if out.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrTokenMalformed)
+	}
+	quid, err := v.resolveQuid(out.Subject, str(v.cfg.QuidClaim))
+	if err != nil {
+		return nil, err
+	}
+	out.QuidID = quid
+	return out, nil
+}
+
+// resolveQuid applies the subject-to-quid convention.
+func (v *Verifier) resolveQuid(subject, claimed string) (string, error) {
+	if claimed != "" {
+		if !quidIDPattern.MatchString(claimed) {
+			return "", fmt.Errorf("%w: claim %s is not a quid ID", ErrNoQuid, v.cfg.QuidClaim)
+		}
+		return claimed, nil
+	}
+	if v.cfg.Bindings != nil {
+		b, err := v.cfg.Bindings.Get(v.cfg.Issuer, subject)
+		if err != nil {
+			return "", fmt.Errorf("oidc: binding lookup: %w", err)
+		}
+		if b != nil {
+			return b.QuidID, nil
+		}
+	}
+	if quidIDPattern.MatchString(subject) {
+		return subject, nil
+	}
+	return "", ErrNoQuid
+}
+
+// hasAudience reports whether aud, a string or array of strings,
+// contains want.
+func hasAudience(aud interface{}, want string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == want
+	case []interface{}:
+		for _, item := range a {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifySignature checks a JWS signature over signingInput.
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 with a non-RSA key", ErrTokenSignature)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrTokenSignature
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: ES256 with a non-P-256 key", ErrTokenSignature)
+		}
+		if len(sig) != 64 {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrTokenSignature, alg)
+	}
+	return nil
+}
+
+// key returns the signing key for kid, refreshing the JWKS when kid
+// is unknown.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if k, ok := v.lookup(kid); ok {
+		return k, nil
+	}
+	if !v.refreshed.IsZero() && time.Since(v.refreshed) < jwksMinRefresh {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrTokenSignature, kid)
+	}
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := v.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrTokenSignature, kid)
+}
+
+// lookup finds kid in the cached key set. A token without a kid
+// matches only a single-key set. Callers hold v.mu.
+func (v *Verifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+// refresh fetches the discovery document once and the JWKS every
+// time. Callers hold v.mu.
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.refreshed = time.Now()
+	if v.jwksURI == "" {
+		var doc struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, v.cfg.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+			return fmt.Errorf("oidc: discovery: %w", err)
+		}
+		if strings.TrimRight(doc.Issuer, "/") != v.cfg.Issuer || doc.JWKSURI == "" {
+			return fmt.Errorf("oidc: discovery document for %q is inconsistent", v.cfg.Issuer)
+		}
+		v.jwksURI = doc.JWKSURI
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.jwksURI, &set); err != nil {
+		return fmt.Errorf("oidc: jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if k, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = k
+		}
+	}
+	v.keys = keys
+	return nil
+}
+
+// getJSON GETs url and decodes its JSON body into out.
+func (v *Verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDiscoveryBytes)).Decode(out)
+}
+
+// jsonWebKey is one entry of a JWKS (RFC 7517), RSA or EC only.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("unacceptable RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC point not on curve")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeSegment base64url-decodes a JWS segment and unmarshals it.
+func decodeSegment(seg string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
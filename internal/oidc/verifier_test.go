@@ -0,0 +1,148 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testIdP serves a discovery document and a one-key JWKS, and signs
+// ES256 tokens with that key.
+type testIdP struct {
+	srv *httptest.Server
+	key *ecdsa.PrivateKey
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   idp.srv.URL,
+			"jwks_uri": idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "EC", "kid": "k1", "use": "sig", "crv": "P-256",
+				"x": b64.EncodeToString(key.PublicKey.X.FillBytes(make([]byte, 32))),
+				"y": b64.EncodeToString(key.PublicKey.Y.FillBytes(make([]byte, 32))),
+			}},
+		})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (idp *testIdP) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, idp.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func (idp *testIdP) claims(extra map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"iss": idp.srv.URL,
+		"sub": "user-42",
+		"aud": []string{"quidnug-api"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func TestVerifier_MapsQuidClaim(t *testing.T) {
+	idp := newTestIdP(t)
+	v, err := NewVerifier(VerifierConfig{Issuer: idp.srv.URL, Audience: "quidnug-api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok := idp.sign(t, "k1", idp.claims(map[string]interface{}{DefaultQuidClaim: "0123456789abcdef"}))
+	claims, err := v.Verify(context.Background(), tok)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.QuidID != "0123456789abcdef" || claims.Subject != "user-42" {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+func TestVerifier_FallsBackToBinding(t *testing.T) {
+	idp := newTestIdP(t)
+	store := NewMemoryBindingStore()
+	_ = store.Create(Binding{Issuer: idp.srv.URL, Subject: "user-42", QuidID: "fedcba9876543210"})
+	v, _ := NewVerifier(VerifierConfig{Issuer: idp.srv.URL, Bindings: store})
+
+	claims, err := v.Verify(context.Background(), idp.sign(t, "k1", idp.claims(nil)))
+	if err != nil || claims.QuidID != "fedcba9876543210" {
+		t.Fatalf("bound subject: %+v, %v", claims, err)
+	}
+	tok := idp.sign(t, "k1", idp.claims(map[string]interface{}{"sub": "unbound"}))
+	if _, err := v.Verify(context.Background(), tok); !errors.Is(err, ErrNoQuid) {
+		t.Fatalf("unbound subject: err = %v, want ErrNoQuid", err)
+	}
+}
+
+func TestVerifier_Rejects(t *testing.T) {
+	idp := newTestIdP(t)
+	v, _ := NewVerifier(VerifierConfig{Issuer: idp.srv.URL, Audience: "quidnug-api"})
+	quid := map[string]interface{}{DefaultQuidClaim: "0123456789abcdef"}
+	with := func(k string, val interface{}) map[string]interface{} {
+		c := idp.claims(quid)
+		c[k] = val
+		return c
+	}
+
+	good := idp.sign(t, "k1", idp.claims(quid))
+	tampered := good[:len(good)-4] + "AAAA"
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", idp.sign(t, "k1", with("exp", time.Now().Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"not yet valid", idp.sign(t, "k1", with("nbf", time.Now().Add(time.Hour).Unix())), ErrTokenExpired},
+		{"wrong issuer", idp.sign(t, "k1", with("iss", "https://evil.example")), ErrTokenClaims},
+		{"wrong audience", idp.sign(t, "k1", with("aud", "other")), ErrTokenClaims},
+		{"bad quid claim", idp.sign(t, "k1", with(DefaultQuidClaim, "not-a-quid")), ErrNoQuid},
+		{"tampered signature", tampered, ErrTokenSignature},
+		{"unknown kid", idp.sign(t, "k2", idp.claims(quid)), ErrTokenSignature},
+		{"garbage", "not.a.jwt", ErrTokenMalformed},
+	}
+	for _, tc := range cases {
+		if _, err := v.Verify(context.Background(), tc.token); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package pgwire
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is one backend session authenticating the client with
+// the password it holds.
+type fakeServer struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	password string
+	params   map[string]string
+}
+
+func (s *fakeServer) send(typ byte, payload []byte) {
+	msg := []byte{typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(payload)+4))
+	s.conn.Write(append(msg, payload...))
+}
+
+func (s *fakeServer) recv() (byte, []byte) {
+	var head [5]byte
+	if _, err := io.ReadFull(s.r, head[:]); err != nil {
+		return 0, nil
+	}
+	p := make([]byte, binary.BigEndian.Uint32(head[1:])-4)
+	io.ReadFull(s.r, p)
+	return head[0], p
+}
+
+func authMsg(code uint32, data string) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, code), data...)
+}
+
+func (s *fakeServer) startup(auth string) bool {
+	var n [4]byte
+	io.ReadFull(s.r, n[:])
+	body := make([]byte, binary.BigEndian.Uint32(n[:])-4)
+	io.ReadFull(s.r, body)
+	kv := strings.Split(string(body[4:]), "\x00")
+	s.params = map[string]string{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.params[kv[i]] = kv[i+1]
+	}
+
+	switch auth {
+	case "md5":
+		s.send('R', authMsg(authMD5, "salt"))
+		_, p := s.recv()
+		if strings.TrimRight(string(p), "\x00") != md5Password(s.params["user"], s.password, []byte("salt")) {
+			s.send('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"))
+			return false
+		}
+	case "scram":
+		s.send('R', authMsg(authSASL, "SCRAM-SHA-256\x00\x00"))
+		_, p := s.recv()
+		clientFirst := string(p[len("SCRAM-SHA-256\x00")+4:])
+		clientBare := strings.TrimPrefix(clientFirst, "n,,")
+		nonce := scramAttrs(clientBare)["r"] + "srv"
+		salt := []byte("pepper")
+		serverFirst := "r=" + nonce + ",s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+		s.send('R', authMsg(authSASLContinue, serverFirst))
+
+		_, p = s.recv()
+		final := string(p)
+		withoutProof := final[:strings.Index(final, ",p=")]
+		salted, _ := pbkdf2.Key(sha256.New, s.password, salt, 4096, 32)
+		authMessage := clientBare + "," + serverFirst + "," + withoutProof
+		clientKey := hmacSHA256(salted, "Client Key")
+		storedKey := sha256.Sum256(clientKey)
+		proof, _ := base64.StdEncoding.DecodeString(scramAttrs(final)["p"])
+		sig := hmacSHA256(storedKey[:], authMessage)
+		for i := range sig {
+			sig[i] ^= proof[i]
+		}
+		if got := sha256.Sum256(sig); !hmac.Equal(got[:], storedKey[:]) {
+			s.send('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"))
+			return false
+		}
+		serverSig := hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)
+		s.send('R', authMsg(authSASLFinal, "v="+base64.StdEncoding.EncodeToString(serverSig)))
+	}
+	s.send('R', authMsg(authOK, ""))
+	s.send('S', []byte("server_version\x0016.0\x00"))
+	s.send('Z', []byte("I"))
+	return true
+}
+
+func dataRow(cols ...*string) []byte {
+	p := binary.BigEndian.AppendUint16(nil, uint16(len(cols)))
+	for _, c := range cols {
+		if c == nil {
+			p = binary.BigEndian.AppendUint32(p, 0xffffffff)
+			continue
+		}
+		p = binary.BigEndian.AppendUint32(p, uint32(len(*c)))
+		p = append(p, *c...)
+	}
+	return p
+}
+
+// startFakeServer serves one connection and returns its URL and the
+// queries it received.
+func startFakeServer(t *testing.T, auth, password string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	queries := make(chan string, 8)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := &fakeServer{conn: conn, r: bufio.NewReader(conn), password: password}
+		if !s.startup(auth) {
+			return
+		}
+		for {
+			typ, p := s.recv()
+			if typ != 'Q' {
+				return
+			}
+			q := strings.TrimRight(string(p), "\x00")
+			queries <- q
+			switch {
+			case strings.HasPrefix(q, "SELECT"):
+				one := "1"
+				s.send('T', []byte{0, 2})
+				s.send('D', dataRow(&one, nil))
+				s.send('C', []byte("SELECT 1\x00"))
+			case strings.HasPrefix(q, "BAD"):
+				s.send('E', []byte("SERROR\x00C42601\x00Msyntax error\x00\x00"))
+			default:
+				s.send('C', []byte("INSERT 0 1\x00"))
+			}
+			s.send('Z', []byte("I"))
+		}
+	}()
+	return "postgres://quidnug:" + password + "@" + ln.Addr().String() + "/analytics", queries
+}
+
+func connect(t *testing.T, url string) (*Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return Connect(ctx, url)
+}
+
+func TestConnectAndQuery(t *testing.T) {
+	for _, auth := range []string{"trust", "md5", "scram"} {
+		t.Run(auth, func(t *testing.T) {
+			url, queries := startFakeServer(t, auth, "s3cret")
+			c, err := connect(t, url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			rows, err := c.Query("SELECT 1, NULL")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 || *rows[0][0] != "1" || rows[0][1] != nil {
+				t.Errorf("unexpected rows %v", rows)
+			}
+			<-queries
+
+			var pgErr *Error
+			if err := c.Exec("BAD"); !errors.As(err, &pgErr) || pgErr.Code != "42601" {
+				t.Errorf("expected syntax error, got %v", err)
+			}
+			<-queries
+			if err := c.Exec("INSERT INTO t VALUES (1)"); err != nil {
+				t.Errorf("session unusable after error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConnect_WrongPassword(t *testing.T) {
+	for _, auth := range []string{"md5", "scram"} {
+		url, _ := startFakeServer(t, auth, "right")
+		url = strings.Replace(url, ":right@", ":wrong@", 1)
+		var pgErr *Error
+		if _, err := connect(t, url); !errors.As(err, &pgErr) || pgErr.Code != "28P01" {
+			t.Errorf("%s: expected authentication failure, got %v", auth, err)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := Quote("it's\x00"); got != `'it''s'` {
+		t.Errorf("Quote = %s", got)
+	}
+	if got := QuoteJSON([]byte(`{"a":"x\u0000","b":"\\u0000"}`)); got != `'{"a":"x\ufffd","b":"\\u0000"}'::jsonb` {
+		t.Errorf("QuoteJSON = %s", got)
+	}
+}
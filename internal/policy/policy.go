@@ -0,0 +1,512 @@
+// Package policy compiles and evaluates small boolean rule
+// expressions, a dependency-free subset of CEL, for operator-written
+// acceptance policies such as
+//
+//	trust >= 0.7 && pathLength <= 2 && verified
+//
+// The language has three value kinds (number, string, bool) and:
+//
+//   - literals: 0.7, 2, "abc", true, false
+//   - variables declared by the caller (letters, digits, '_' and '.')
+//   - comparison: == != < <= > >= (ordering on numbers and strings)
+//   - logic: && || ! (also the words and, or, not), short-circuiting
+//   - list membership: validator in ["a1b2...", "c3d4..."]
+//   - parentheses
+//
+// Expressions are type-checked against the declared variables at
+// compile time, so a typo or a "trust && 1" fails when the policy is
+// loaded rather than on the first block it judges.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidExpr wraps every compile failure.
+var ErrInvalidExpr = errors.New("policy: invalid expression")
+
+// Kind is a value type.
+type Kind int
+
+// Value kinds.
+const (
+	Number Kind = iota + 1
+	String
+	Bool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Bool:
+		return "bool"
+	}
+	return "unknown"
+}
+
+// Env supplies variable values at evaluation. Numbers may be any Go
+// integer or float type.
+type Env map[string]interface{}
+
+// Expr is a compiled boolean expression. Safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the source the expression was compiled from.
+func (e *Expr) String() string { return e.src }
+
+// Compile parses src and type-checks it against vars. The result
+// must be a bool.
+func Compile(src string, vars map[string]Kind) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExpr, err)
+	}
+	p := &parser{toks: toks, vars: vars}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	if err == nil && root.kind() != Bool {
+		err = fmt.Errorf("expression is a %s, not a bool", root.kind())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExpr, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval evaluates the expression. It fails only when env is missing
+// a variable the expression reads or holds a value of the wrong
+// kind.
+func (e *Expr) Eval(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// --- lexer ---
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+var wordOps = map[string]string{"and": "&&", "or": "||", "not": "!", "in": "in"}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i})
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for j < len(src) && rune(src[j]) != c {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			toks = append(toks, token{tokString, b.String(), i})
+			i = j + 1
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_' || src[j] == '.') {
+				j++
+			}
+			word := src[i:j]
+			if op, ok := wordOps[strings.ToLower(word)]; ok {
+				toks = append(toks, token{tokOp, op, i})
+			} else {
+				toks = append(toks, token{tokIdent, word, i})
+			}
+			i = j
+		default:
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch two {
+			case "&&", "||", "==", "!=", "<=", ">=":
+				toks = append(toks, token{tokOp, two, i})
+				i += 2
+				continue
+			}
+			switch c {
+			case '<', '>', '!', '(', ')', '[', ']', ',':
+				toks = append(toks, token{tokOp, string(c), i})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+// --- parser ---
+
+type parser struct {
+	toks []token
+	at   int
+	vars map[string]Kind
+}
+
+func (p *parser) peek() token { return p.toks[p.at] }
+
+func (p *parser) next() token {
+	t := p.toks[p.at]
+	if t.kind != tokEOF {
+		p.at++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.at++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left, err = newLogic("||", left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	for err == nil && p.accept("&&") {
+		var right node
+		if right, err = p.parseNot(); err == nil {
+			left, err = newLogic("&&", left, right)
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != Bool {
+			return nil, fmt.Errorf("! needs a bool, got %s", operand.kind())
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return newCompare(t.text, left, right)
+	case "in":
+		p.next()
+		return p.parseIn(left)
+	}
+	return left, nil
+}
+
+func (p *parser) parseIn(left node) (node, error) {
+	if !p.accept("[") {
+		return nil, fmt.Errorf("in needs a [list] at offset %d", p.peek().pos)
+	}
+	var items []interface{}
+	for !p.accept("]") {
+		if len(items) > 0 && !p.accept(",") {
+			return nil, fmt.Errorf("expected , or ] at offset %d", p.peek().pos)
+		}
+		item, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		lit, ok := item.(literal)
+		if !ok || lit.kind() != left.kind() {
+			return nil, fmt.Errorf("in list items must be %s literals", left.kind())
+		}
+		items = append(items, lit.v)
+	}
+	return inNode{left, items}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q at offset %d", t.text, t.pos)
+		}
+		return literal{f}, nil
+	case tokString:
+		return literal{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		}
+		k, ok := p.vars[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q at offset %d", t.text, t.pos)
+		}
+		return variable{t.text, k}, nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, fmt.Errorf("missing ) at offset %d", p.peek().pos)
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+// --- evaluation ---
+
+type node interface {
+	kind() Kind
+	eval(env Env) (interface{}, error)
+}
+
+type literal struct{ v interface{} }
+
+func (l literal) kind() Kind {
+	switch l.v.(type) {
+	case float64:
+		return Number
+	case string:
+		return String
+	}
+	return Bool
+}
+
+func (l literal) eval(Env) (interface{}, error) { return l.v, nil }
+
+type variable struct {
+	name string
+	k    Kind
+}
+
+func (v variable) kind() Kind { return v.k }
+
+func (v variable) eval(env Env) (interface{}, error) {
+	raw, ok := env[v.name]
+	if !ok {
+		return nil, fmt.Errorf("policy: variable %q not set", v.name)
+	}
+	var out interface{}
+	switch v.k {
+	case Number:
+		switch n := raw.(type) {
+		case float64:
+			out = n
+		case float32:
+			out = float64(n)
+		case int:
+			out = float64(n)
+		case int64:
+			out = float64(n)
+		case int32:
+			out = float64(n)
+		case uint64:
+			out = float64(n)
+		}
+	case String:
+		if s, ok := raw.(string); ok {
+			out = s
+		}
+	case Bool:
+		if b, ok := raw.(bool); ok {
+			out = b
+		}
+	}
+	if out == nil {
+		return nil, fmt.Errorf("policy: variable %q is %T, want %s", v.name, raw, v.k)
+	}
+	return out, nil
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) kind() Kind { return Bool }
+
+func (n notNode) eval(env Env) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !v.(bool), nil
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func newLogic(op string, left, right node) (node, error) {
+	if left.kind() != Bool || right.kind() != Bool {
+		return nil, fmt.Errorf("%s needs bools, got %s and %s", op, left.kind(), right.kind())
+	}
+	return logicNode{op, left, right}, nil
+}
+
+func (n logicNode) kind() Kind { return Bool }
+
+func (n logicNode) eval(env Env) (interface{}, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !l.(bool) || n.op == "||" && l.(bool) {
+		return l, nil
+	}
+	return n.right.eval(env)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func newCompare(op string, left, right node) (node, error) {
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("cannot compare %s %s %s", left.kind(), op, right.kind())
+	}
+	if left.kind() == Bool && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("bools support only == and !=, not %s", op)
+	}
+	return compareNode{op, left, right}, nil
+}
+
+func (n compareNode) kind() Kind { return Bool }
+
+func (n compareNode) eval(env Env) (interface{}, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	var c int
+	switch lv := l.(type) {
+	case float64:
+		rv := r.(float64)
+		c = cmpOrdered(lv, rv)
+	case string:
+		c = strings.Compare(lv, r.(string))
+	case bool:
+		if lv != r.(bool) {
+			c = 1
+		}
+	}
+	switch n.op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func cmpOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type inNode struct {
+	operand node
+	items   []interface{}
+}
+
+func (n inNode) kind() Kind { return Bool }
+
+func (n inNode) eval(env Env) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range n.items {
+		if item == v {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+)
+
+var testVars = map[string]Kind{
+	"trust":      Number,
+	"pathLength": Number,
+	"verified":   Bool,
+	"validator":  String,
+}
+
+func TestEval(t *testing.T) {
+	env := Env{"trust": 0.75, "pathLength": 2, "verified": true, "validator": "a1"}
+	cases := map[string]bool{
+		`trust >= 0.7 && pathLength <= 2 && verified`:   true,
+		`trust >= 0.8 || validator == "a1"`:             true,
+		`trust > 0.5 AND NOT verified`:                  false,
+		`!(pathLength > 1)`:                             false,
+		`validator in ["b2", 'a1']`:                     true,
+		`pathLength in [1, 3]`:                          false,
+		`verified == true && validator < "b"`:           true,
+		`trust >= 0.7 && (pathLength == 1 || verified)`: true,
+	}
+	for src, want := range cases {
+		expr, err := Compile(src, testVars)
+		if err != nil {
+			t.Errorf("%s: compile: %v", src, err)
+			continue
+		}
+		got, err := expr.Eval(env)
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", src, got, err, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`trust >= `,
+		`trust`,
+		`trusted >= 0.7`,
+		`trust && verified`,
+		`trust == "high"`,
+		`verified < true`,
+		`validator in [1]`,
+		`(verified`,
+		`validator == "open`,
+		`trust >= 0.7 verified`,
+		`trust # 1`,
+	} {
+		if _, err := Compile(src, testVars); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("%s: err = %v, want ErrInvalidExpr", src, err)
+		}
+	}
+}
+
+func TestEvalShortCircuitsAndChecksEnv(t *testing.T) {
+	expr, err := Compile(`verified || trust > 0.5`, testVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := expr.Eval(Env{"verified": true}); err != nil || !ok {
+		t.Errorf("short circuit: %v, %v", ok, err)
+	}
+	if _, err := expr.Eval(Env{"verified": false}); err == nil {
+		t.Error("missing variable not reported")
+	}
+	if _, err := expr.Eval(Env{"verified": false, "trust": "high"}); err == nil {
+		t.Error("mistyped variable not reported")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build chaos
+
+package simnet
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_PartitionHeals(t *testing.T) {
+	sc, err := LoadScenario(filepath.Join("testdata", "partition.yaml"))
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	report, err := Run(ctx, sc)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Converged {
+		t.Fatalf("network did not converge after the partition healed: heights %v", report.Heights)
+	}
+}
@@ -0,0 +1,193 @@
+package simnet
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/quidnug/quidnug/pkg/client"
+)
+
+// Report summarizes a scenario run.
+type Report struct {
+	Scenario     string              `json:"scenario"`
+	Nodes        int                 `json:"nodes"`
+	Transactions int                 `json:"transactions"`
+	BlocksSealed int                 `json:"blocksSealed"`
+	Heights      []int               `json:"heights"`
+	Converged    bool                `json:"converged"`
+	Expectations []ExpectationResult `json:"expectations,omitempty"`
+	Duration     time.Duration       `json:"duration"`
+}
+
+// ExpectationResult is one expectation checked on one node.
+type ExpectationResult struct {
+	Expectation
+	Node   int     `json:"node"`
+	Trust  float64 `json:"trust"`
+	Passed bool    `json:"passed"`
+}
+
+// Failed reports whether the network diverged or any expectation
+// failed.
+func (r *Report) Failed() bool {
+	if !r.Converged {
+		return true
+	}
+	for _, x := range r.Expectations {
+		if !x.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes sc on a fresh network and returns its report. The
+// error is reserved for harness failures; a diverged network or
+// failed expectation is reported through Report.Failed.
+func Run(ctx context.Context, sc *Scenario) (*Report, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	network, err := Start(sc.Nodes, sc.Domain)
+	if err != nil {
+		return nil, err
+	}
+	defer network.Close()
+	if sc.Faults != nil {
+		if err := network.InjectFaults(*sc.Faults); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &runner{sc: sc, net: network, report: &Report{Scenario: sc.Name, Nodes: sc.Nodes}, nonces: map[[2]string]int64{}}
+	if err := r.run(ctx); err != nil {
+		return nil, err
+	}
+	r.report.Heights = network.Heights()
+	r.report.Converged = network.Converged()
+	r.report.Duration = time.Since(start)
+	return r.report, nil
+}
+
+type runner struct {
+	sc      *Scenario
+	net     *Network
+	report  *Report
+	clients []*client.Client
+	quids   map[string]*client.Quid
+	nonces  map[[2]string]int64
+	next    int
+}
+
+func (r *runner) run(ctx context.Context) error {
+	for i := range r.net.Nodes {
+		c, err := r.net.Client(i)
+		if err != nil {
+			return err
+		}
+		r.clients = append(r.clients, c)
+	}
+
+	r.quids = make(map[string]*client.Quid, len(r.sc.Identities))
+	for _, name := range r.sc.Identities {
+		q, err := client.GenerateQuid()
+		if err != nil {
+			return err
+		}
+		r.quids[name] = q
+		if _, err := r.client().RegisterIdentity(ctx, q, client.IdentityParams{Domain: r.sc.Domain, Name: name}); err != nil {
+			return fmt.Errorf("simnet: register %s: %w", name, err)
+		}
+	}
+	if err := r.seal(ctx, len(r.sc.Identities)); err != nil {
+		return err
+	}
+
+	for _, e := range r.sc.Trust {
+		if err := r.grant(ctx, e); err != nil {
+			return err
+		}
+	}
+	if err := r.seal(ctx, len(r.sc.Trust)); err != nil {
+		return err
+	}
+
+	rng := rand.New(rand.NewSource(r.sc.Workload.Seed)) // #nosec G404 -- reproducible workload, not security
+	for round := 0; round < r.sc.Workload.Rounds; round++ {
+		for k := 0; k < r.sc.Workload.TrustPerRound; k++ {
+			from := rng.Intn(len(r.sc.Identities))
+			to := rng.Intn(len(r.sc.Identities) - 1)
+			if to >= from {
+				to++
+			}
+			e := TrustEdge{From: r.sc.Identities[from], To: r.sc.Identities[to], Level: float64(rng.Intn(101)) / 100}
+			if err := r.grant(ctx, e); err != nil {
+				return err
+			}
+		}
+		if err := r.seal(ctx, r.sc.Workload.TrustPerRound); err != nil {
+			return err
+		}
+	}
+
+	// Keep syncing so the last block reaches any node whose sync ran
+	// before it was sealed, or that was partitioned when it was.
+	r.net.Settle(ctx)
+	return r.check(ctx)
+}
+
+// client returns the next node's client, spreading submissions.
+func (r *runner) client() *client.Client {
+	c := r.clients[r.next%len(r.clients)]
+	r.next++
+	r.report.Transactions++
+	return c
+}
+
+func (r *runner) grant(ctx context.Context, e TrustEdge) error {
+	pair := [2]string{e.From, e.To}
+	r.nonces[pair]++
+	_, err := r.client().GrantTrust(ctx, r.quids[e.From], client.TrustParams{
+		Trustee: r.quids[e.To].ID,
+		Level:   e.Level,
+		Domain:  r.sc.Domain,
+		Nonce:   r.nonces[pair],
+	})
+	if err != nil {
+		return fmt.Errorf("simnet: trust %s -> %s: %w", e.From, e.To, err)
+	}
+	return nil
+}
+
+func (r *runner) seal(ctx context.Context, want int) error {
+	if want == 0 {
+		return nil
+	}
+	block, err := r.net.Seal(ctx, want)
+	if err != nil {
+		return err
+	}
+	if block != nil {
+		r.report.BlocksSealed++
+	}
+	return nil
+}
+
+func (r *runner) check(ctx context.Context) error {
+	for _, x := range r.sc.Expect {
+		for i, c := range r.clients {
+			res, err := c.GetTrust(ctx, r.quids[x.Observer].ID, r.quids[x.Target].ID, r.sc.Domain, 0)
+			if err != nil {
+				return fmt.Errorf("simnet: trust query on node %d: %w", i, err)
+			}
+			passed := res.TrustLevel >= x.MinTrust && (x.MaxTrust == 0 || res.TrustLevel <= x.MaxTrust)
+			r.report.Expectations = append(r.report.Expectations, ExpectationResult{
+				Expectation: x, Node: i, Trust: res.TrustLevel, Passed: passed,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+// Package simnet runs a Quidnug network in-process for testing.
+//
+// Start brings up N QuidnugNodes, each serving its real HTTP
+// handler on a loopback listener, and wires them into one trust
+// domain as equal-weight validators that know each other as
+// peers. Nothing runs on a timer: the harness steps the network
+// itself. Seal has one validator (round-robin) cut a block and
+// every other node pull it through the normal block sync path, so
+// consensus, sync and trust propagation are exercised exactly as
+// in a deployment.
+//
+// With a chaos build (-tags=chaos) a scenario's Faults drop, delay
+// and partition the nodes' peer traffic, so sync can be watched
+// recovering once a partition heals.
+//
+// Run drives a Scenario end to end through the public API via
+// pkg/client: register identities, seed trust, generate a seeded
+// random workload, then check convergence and the scenario's trust
+// expectations on every node.
+package simnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/core"
+	"github.com/quidnug/quidnug/pkg/client"
+)
+
+// simnetRateLimit is the per-IP request budget each node's HTTP
+// handler gets. All simulated traffic comes from loopback, so the
+// production default would throttle the harness itself.
+const simnetRateLimit = 1 << 20
+
+// simnetMaxBodyBytes bounds request bodies, as in production.
+const simnetMaxBodyBytes = 1 << 20
+
+// propagationTimeout bounds how long Seal waits for broadcast
+// transactions to reach the producing validator.
+const propagationTimeout = 5 * time.Second
+
+// Network is a running set of in-process nodes.
+type Network struct {
+	Domain string
+	Nodes  []*core.QuidnugNode
+	URLs   []string
+
+	servers []*http.Server
+	addrs   []string
+	round   int
+
+	// faulty is set once faults are injected; Seal then cuts a
+	// block from whatever reached the producer instead of failing.
+	faulty bool
+	// healsAt is when the last scheduled partition ends, or zero.
+	healsAt    time.Time
+	faultStart time.Time
+	partitions []Partition
+}
+
+// Start launches n validator nodes for domain on loopback.
+func Start(n int, domain string) (*Network, error) {
+	if n <= 0 {
+		return nil, errors.New("simnet: need at least one node")
+	}
+	nw := &Network{Domain: domain}
+	listeners := make([]net.Listener, n)
+	for i := 0; i < n; i++ {
+		node, err := core.NewQuidnugNode(nil)
+		if err != nil {
+			nw.Close()
+			return nil, fmt.Errorf("simnet: node %d: %w", i, err)
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			nw.Close()
+			return nil, fmt.Errorf("simnet: listen: %w", err)
+		}
+		listeners[i] = ln
+		node.SetHTTPClientTimeout(2 * time.Second)
+		// A workload concentrates many writes on a handful of quids;
+		// the per-quid write limits would reject it.
+		node.WriteLimiter = nil
+		nw.Nodes = append(nw.Nodes, node)
+		nw.URLs = append(nw.URLs, "http://"+ln.Addr().String())
+	}
+
+	nw.wire(listeners)
+
+	for i, node := range nw.Nodes {
+		srv := &http.Server{
+			Handler:           node.NewHTTPHandler(simnetRateLimit, simnetMaxBodyBytes),
+			ReadHeaderTimeout: core.DefaultReadHeaderTimeout,
+		}
+		nw.servers = append(nw.servers, srv)
+		go func(ln net.Listener) { _ = srv.Serve(ln) }(listeners[i])
+	}
+	return nw, nil
+}
+
+// wire makes every node a validator of the domain on every other
+// node, a known peer at its loopback address, and fully trusted, so
+// blocks each seals are accepted as trusted rather than tentative.
+func (n *Network) wire(listeners []net.Listener) {
+	validators := make(map[string]float64, len(n.Nodes))
+	keys := make(map[string]string, len(n.Nodes))
+	ids := make([]string, 0, len(n.Nodes))
+	for _, node := range n.Nodes {
+		validators[node.NodeID] = 1.0
+		keys[node.NodeID] = node.GetPublicKeyHex()
+		ids = append(ids, node.NodeID)
+	}
+	addrs := make([]string, len(listeners))
+	for i, ln := range listeners {
+		addrs[i] = ln.Addr().String()
+	}
+	n.addrs = addrs
+
+	for i, node := range n.Nodes {
+		node.TrustDomainsMutex.Lock()
+		domain := core.TrustDomain{
+			Name:                n.Domain,
+			ValidatorNodes:      append([]string(nil), ids...),
+			TrustThreshold:      0.75,
+			Validators:          copyWeights(validators),
+			ValidatorPublicKeys: copyKeys(keys),
+		}
+		if existing, ok := node.TrustDomains[n.Domain]; ok {
+			domain.BlockchainHead = existing.BlockchainHead
+		}
+		node.TrustDomains[n.Domain] = domain
+		node.TrustDomainsMutex.Unlock()
+
+		node.KnownNodesMutex.Lock()
+		for j, other := range n.Nodes {
+			if i == j {
+				continue
+			}
+			node.AddVerifiedTrustEdge(core.TrustEdge{
+				Truster:    node.NodeID,
+				Trustee:    other.NodeID,
+				TrustLevel: 1.0,
+				Domain:     n.Domain,
+				Timestamp:  time.Now().Unix(),
+			})
+			node.KnownNodes[other.NodeID] = core.Node{
+				ID:           other.NodeID,
+				Address:      addrs[j],
+				TrustDomains: []string{n.Domain},
+				IsValidator:  true,
+				LastSeen:     time.Now().Unix(),
+			}
+		}
+		node.KnownNodesMutex.Unlock()
+
+		// Loopback is blocked for peer dials by default.
+		node.PrivateAddrAllowList.Set(addrs)
+	}
+}
+
+func copyWeights(m map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func copyKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// InjectFaults applies f to every node's peer transport, turning
+// each partition's node indices into the peer addresses each side
+// must lose. It fails unless the binary was built with -tags=chaos.
+func (n *Network) InjectFaults(f Faults) error {
+	start := time.Now()
+	heals := time.Duration(0)
+	for _, p := range f.Partitions {
+		if p.End > heals {
+			heals = p.End
+		}
+	}
+	for i, node := range n.Nodes {
+		cfg := core.FaultConfig{
+			DropRate: f.DropRate,
+			Latency:  f.Latency,
+			Jitter:   f.Jitter,
+			Seed:     f.Seed + int64(i),
+		}
+		for _, p := range f.Partitions {
+			if peers := n.partitionPeers(i, p.Isolate); len(peers) > 0 {
+				cfg.Partitions = append(cfg.Partitions, core.FaultPartition{Start: p.Start, End: p.End, Peers: peers})
+			}
+		}
+		if err := node.InjectFaults(cfg); err != nil {
+			return fmt.Errorf("simnet: node %d: %w", i, err)
+		}
+	}
+	n.faulty = true
+	n.faultStart = start
+	n.partitions = f.Partitions
+	n.healsAt = start.Add(heals)
+	return nil
+}
+
+// partitionPeers returns the addresses node i cannot reach while
+// the nodes in isolate are cut off: everyone else if i is isolated,
+// otherwise the isolated nodes.
+func (n *Network) partitionPeers(i int, isolate []int) []string {
+	cut := make(map[int]bool, len(isolate))
+	for _, idx := range isolate {
+		cut[idx] = true
+	}
+	var peers []string
+	for j, addr := range n.addrs {
+		if j != i && cut[i] != cut[j] {
+			peers = append(peers, addr)
+		}
+	}
+	return peers
+}
+
+// Client returns an API client for node i.
+func (n *Network) Client(i int) (*client.Client, error) {
+	return client.New(n.URLs[i], client.WithTimeout(5*time.Second))
+}
+
+// Producer returns the index of the node that seals the next round.
+// Validators currently cut off by a partition sit out their turn:
+// they could neither gather the others' transactions nor deliver a
+// block, and with no fork choice between equal-height blocks a
+// minority chain would never be reconciled.
+func (n *Network) Producer() int {
+	for k := 0; k < len(n.Nodes); k++ {
+		i := (n.round + k) % len(n.Nodes)
+		if !n.isolated(i) {
+			return i
+		}
+	}
+	return n.round % len(n.Nodes)
+}
+
+func (n *Network) isolated(i int) bool {
+	elapsed := time.Since(n.faultStart)
+	for _, p := range n.partitions {
+		if elapsed < p.Start || (p.End != 0 && elapsed >= p.End) {
+			continue
+		}
+		for _, idx := range p.Isolate {
+			if idx == i {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Seal has the next validator in rotation cut a block from its
+// mempool once it holds at least minPending transactions, then has
+// every other node sync. It returns the sealed block, or nil when
+// there was nothing to seal.
+func (n *Network) Seal(ctx context.Context, minPending int) (*core.Block, error) {
+	producer := n.Nodes[n.Producer()]
+	n.round++
+
+	deadline := time.Now().Add(propagationTimeout)
+	for pendingCount(producer) < minPending {
+		if time.Now().After(deadline) {
+			if n.faulty {
+				// Lost broadcasts are expected; seal what arrived.
+				break
+			}
+			return nil, fmt.Errorf("simnet: producer holds %d of %d transactions after %s",
+				pendingCount(producer), minPending, propagationTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if pendingCount(producer) == 0 {
+		return nil, nil
+	}
+
+	// A producer that missed blocks (dropped syncs, a healed
+	// partition) must catch up first or it would fork its own chain.
+	producer.SyncBlocksOnce(ctx)
+	block, err := producer.GenerateBlock(n.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("simnet: generate block: %w", err)
+	}
+	if err := producer.AddBlock(*block); err != nil {
+		return nil, fmt.Errorf("simnet: add block: %w", err)
+	}
+	n.Sync(ctx)
+	return block, nil
+}
+
+// Sync runs one block sync pass on every node.
+func (n *Network) Sync(ctx context.Context) {
+	for _, node := range n.Nodes {
+		node.SyncBlocksOnce(ctx)
+	}
+}
+
+// Settle syncs every node until the network converges, or until
+// propagationTimeout after the last partition heals.
+func (n *Network) Settle(ctx context.Context) {
+	deadline := time.Now().Add(propagationTimeout)
+	if n.healsAt.After(time.Now()) {
+		deadline = n.healsAt.Add(propagationTimeout)
+	}
+	for {
+		n.Sync(ctx)
+		if n.Converged() || time.Now().After(deadline) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func pendingCount(node *core.QuidnugNode) int {
+	node.PendingTxsMutex.RLock()
+	defer node.PendingTxsMutex.RUnlock()
+	return len(node.PendingTxs)
+}
+
+// Heights returns each node's chain length.
+func (n *Network) Heights() []int {
+	out := make([]int, len(n.Nodes))
+	for i, node := range n.Nodes {
+		node.BlockchainMutex.RLock()
+		out[i] = len(node.Blockchain)
+		node.BlockchainMutex.RUnlock()
+	}
+	return out
+}
+
+// DomainHeads returns each node's head hash for the domain.
+func (n *Network) DomainHeads() []string {
+	out := make([]string, len(n.Nodes))
+	for i, node := range n.Nodes {
+		node.TrustDomainsMutex.RLock()
+		out[i] = node.TrustDomains[n.Domain].BlockchainHead
+		node.TrustDomainsMutex.RUnlock()
+	}
+	return out
+}
+
+// Converged reports whether every node has the same domain head.
+func (n *Network) Converged() bool {
+	heads := n.DomainHeads()
+	for _, h := range heads[1:] {
+		if h != heads[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// Close stops every node's server.
+func (n *Network) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, srv := range n.servers {
+		_ = srv.Shutdown(ctx)
+	}
+}
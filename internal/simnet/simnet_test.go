@@ -0,0 +1,52 @@
+package simnet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_ThreeNodeScenario(t *testing.T) {
+	sc, err := LoadScenario(filepath.Join("testdata", "three-node.yaml"))
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := Run(ctx, sc)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Converged {
+		t.Fatalf("network did not converge: heights %v", report.Heights)
+	}
+	if report.Failed() {
+		t.Fatalf("expectations failed: %+v", report.Expectations)
+	}
+	if report.BlocksSealed != 4 || len(report.Expectations) != 3 {
+		t.Fatalf("blocks=%d expectations=%d", report.BlocksSealed, len(report.Expectations))
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sc.yaml")
+	body := "name: tiny\nnodes: 2\nidentities: [a, b]\ntrust:\n  - {from: a, to: b, level: 0.5}\n"
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	if sc.Domain != DefaultDomain || len(sc.Trust) != 1 {
+		t.Fatalf("unexpected scenario %+v", sc)
+	}
+
+	bad := &Scenario{Nodes: 1, Identities: []string{"a"}, Trust: []TrustEdge{{From: "a", To: "z"}}}
+	if err := bad.Validate(); err == nil {
+		t.Fatal("expected unknown identity error")
+	}
+}
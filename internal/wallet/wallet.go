@@ -0,0 +1,265 @@
+// Package wallet is an optional custodial key store for quids.
+//
+// A node normally never sees a quid's private key: clients generate
+// and keep their own. Operators who serve users without key
+// management of their own can turn on custody, and the node then
+// keeps each custodied key encrypted under a passphrase chosen by
+// its owner (typically derived from their API key) and signs on
+// their behalf when the passphrase is presented again.
+//
+// Keys are stored one file per quid under a directory. Each file
+// holds the PKCS8 private key sealed with AES-256-GCM under a key
+// derived from the passphrase with PBKDF2-SHA256; the quid ID is
+// bound in as additional data, so a file copied over another quid's
+// does not decrypt. The passphrase itself is never stored.
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/quidnug/quidnug/internal/safeio"
+)
+
+// DefaultIterations is the PBKDF2 work factor for newly sealed keys.
+// Each file records its own count, so raising this does not strand
+// existing keys.
+const DefaultIterations = 600_000
+
+// MinPassphraseLength is the shortest passphrase Put accepts.
+const MinPassphraseLength = 12
+
+const (
+	sealedVersion = 1
+	kdfPBKDF2     = "pbkdf2-sha256"
+	saltSize      = 16
+	aesKeySize    = 32
+)
+
+// Package-level errors for wallet operations.
+var (
+	ErrNotFound       = errors.New("wallet: quid not custodied")
+	ErrExists         = errors.New("wallet: quid already custodied")
+	ErrPassphrase     = errors.New("wallet: wrong passphrase")
+	ErrWeakPassphrase = fmt.Errorf("wallet: passphrase shorter than %d characters", MinPassphraseLength)
+	ErrInvalidQuid    = errors.New("wallet: invalid quid ID")
+)
+
+// Entry is the public part of a custodied key.
+type Entry struct {
+	QuidID    string `json:"quidId"`
+	PublicKey string `json:"publicKey"`
+	Created   int64  `json:"created"`
+}
+
+// sealedKey is the on-disk form of one custodied key.
+type sealedKey struct {
+	Version    int    `json:"version"`
+	Entry             // QuidID, PublicKey, Created
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Store keeps sealed keys in a directory, one <quidID>.json file
+// each. It is safe for concurrent use.
+type Store struct {
+	dir        string
+	iterations int
+
+	mu sync.Mutex // serializes create/remove of key files
+}
+
+// Open returns a Store rooted at dir, creating it 0700 if needed.
+// iterations <= 0 uses DefaultIterations.
+func Open(dir string, iterations int) (*Store, error) {
+	clean, err := safeio.ValidatePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	if err := safeio.MkdirAllMode(clean, 0o700); err != nil {
+		return nil, fmt.Errorf("wallet: create %s: %w", clean, err)
+	}
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+	return &Store{dir: clean, iterations: iterations}, nil
+}
+
+// validQuidID accepts the 16 lowercase hex characters quid IDs are
+// made of, which also keeps IDs safe to use as file names.
+func validQuidID(id string) bool {
+	if len(id) != 16 {
+		return false
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) path(quidID string) string {
+	return filepath.Join(s.dir, quidID+".json")
+}
+
+// Put seals key under passphrase for quidID. It refuses to replace
+// an existing entry: rotating a custodied key means Remove then Put.
+func (s *Store) Put(quidID, publicKeyHex string, key *ecdsa.PrivateKey, passphrase string) error {
+	if !validQuidID(quidID) {
+		return ErrInvalidQuid
+	}
+	if len(passphrase) < MinPassphraseLength {
+		return ErrWeakPassphrase
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("wallet: encode key: %w", err)
+	}
+	sealed := sealedKey{
+		Version:    sealedVersion,
+		Entry:      Entry{QuidID: quidID, PublicKey: publicKeyHex, Created: time.Now().Unix()},
+		KDF:        kdfPBKDF2,
+		Iterations: s.iterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(sealed.Salt); err != nil {
+		return fmt.Errorf("wallet: salt: %w", err)
+	}
+	aead, err := newAEAD(passphrase, sealed.Salt, sealed.Iterations)
+	if err != nil {
+		return err
+	}
+	sealed.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return fmt.Errorf("wallet: nonce: %w", err)
+	}
+	sealed.Ciphertext = aead.Seal(nil, sealed.Nonce, der, []byte(quidID))
+
+	data, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Lstat(s.path(quidID)); err == nil {
+		return ErrExists
+	}
+	tmp := s.path(quidID) + ".tmp"
+	if err := safeio.WriteFile(tmp, data); err != nil {
+		return fmt.Errorf("wallet: write %s: %w", quidID, err)
+	}
+	if err := os.Rename(tmp, s.path(quidID)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("wallet: write %s: %w", quidID, err)
+	}
+	return nil
+}
+
+// Get returns the public entry for quidID without unlocking it.
+func (s *Store) Get(quidID string) (Entry, error) {
+	sealed, err := s.read(quidID)
+	if err != nil {
+		return Entry{}, err
+	}
+	return sealed.Entry, nil
+}
+
+// Unlock decrypts the key custodied for quidID. A wrong passphrase
+// and a tampered file both report ErrPassphrase.
+func (s *Store) Unlock(quidID, passphrase string) (*ecdsa.PrivateKey, error) {
+	sealed, err := s.read(quidID)
+	if err != nil {
+		return nil, err
+	}
+	if sealed.KDF != kdfPBKDF2 || sealed.Iterations <= 0 {
+		return nil, fmt.Errorf("wallet: %s: unsupported kdf %q", quidID, sealed.KDF)
+	}
+	aead, err := newAEAD(passphrase, sealed.Salt, sealed.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, ErrPassphrase
+	}
+	der, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(quidID))
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("wallet: decode key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("wallet: %s: not an ECDSA key", quidID)
+	}
+	return key, nil
+}
+
+// Remove deletes the key custodied for quidID once passphrase has
+// been shown to unlock it.
+func (s *Store) Remove(quidID, passphrase string) error {
+	if _, err := s.Unlock(quidID, passphrase); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(quidID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("wallet: remove %s: %w", quidID, err)
+	}
+	return nil
+}
+
+func (s *Store) read(quidID string) (sealedKey, error) {
+	if !validQuidID(quidID) {
+		return sealedKey{}, ErrInvalidQuid
+	}
+	data, err := safeio.ReadFile(s.path(quidID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return sealedKey{}, ErrNotFound
+		}
+		return sealedKey{}, fmt.Errorf("wallet: read %s: %w", quidID, err)
+	}
+	var sealed sealedKey
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return sealedKey{}, fmt.Errorf("wallet: decode %s: %w", quidID, err)
+	}
+	if sealed.Version != sealedVersion || sealed.QuidID != quidID {
+		return sealedKey{}, fmt.Errorf("wallet: %s: unrecognized key file", quidID)
+	}
+	return sealed, nil
+}
+
+// newAEAD derives the sealing key for passphrase and salt.
+func newAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, aesKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("wallet: derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPassphrase = "correct horse battery"
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestStore_PutUnlockRemove(t *testing.T) {
+	s, err := Open(t.TempDir(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := newTestKey(t)
+	if err := s.Put("0123456789abcdef", "04aa", key, testPassphrase); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put("0123456789abcdef", "04aa", key, testPassphrase); !errors.Is(err, ErrExists) {
+		t.Fatalf("second Put: err = %v, want ErrExists", err)
+	}
+
+	entry, err := s.Get("0123456789abcdef")
+	if err != nil || entry.PublicKey != "04aa" || entry.Created == 0 {
+		t.Fatalf("Get: %+v, %v", entry, err)
+	}
+	got, err := s.Unlock("0123456789abcdef", testPassphrase)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if !got.Equal(key) {
+		t.Fatal("unlocked key differs from the one stored")
+	}
+	if _, err := s.Unlock("0123456789abcdef", testPassphrase+"!"); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("wrong passphrase: err = %v", err)
+	}
+
+	if err := s.Remove("0123456789abcdef", "wrong passphrase!"); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("Remove with wrong passphrase: err = %v", err)
+	}
+	if err := s.Remove("0123456789abcdef", testPassphrase); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := s.Get("0123456789abcdef"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Remove: err = %v", err)
+	}
+}
+
+func TestStore_RejectsBadInput(t *testing.T) {
+	s, _ := Open(t.TempDir(), 1000)
+	key := newTestKey(t)
+	if err := s.Put("0123456789abcdef", "04aa", key, "short"); !errors.Is(err, ErrWeakPassphrase) {
+		t.Errorf("short passphrase: err = %v", err)
+	}
+	for _, id := range []string{"", "../etc/passwd", "0123456789ABCDEF", "0123456789abcde"} {
+		if err := s.Put(id, "04aa", key, testPassphrase); !errors.Is(err, ErrInvalidQuid) {
+			t.Errorf("Put(%q): err = %v", id, err)
+		}
+	}
+}
+
+func TestStore_FileBoundToQuid(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir, 1000)
+	if err := s.Put("aaaaaaaaaaaaaaaa", "04aa", newTestKey(t), testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "aaaaaaaaaaaaaaaa.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, "aaaaaaaaaaaaaaaa.json")); info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode %v, want 0600", info.Mode().Perm())
+	}
+	// Same file under another quid's name: the quid ID inside no
+	// longer matches, and even if it were rewritten the AEAD data
+	// would not.
+	if err := os.WriteFile(filepath.Join(dir, "bbbbbbbbbbbbbbbb.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Unlock("bbbbbbbbbbbbbbbb", testPassphrase); err == nil {
+		t.Fatal("key file copied to another quid unlocked")
+	}
+}
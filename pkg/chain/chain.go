@@ -0,0 +1,73 @@
+// Package chain reads and extends the block chain of an embedded
+// node.
+//
+// It is the chain half of pkg/node split out under its own import
+// path:
+//
+//	n, err := node.New(cfg)
+//	if err != nil { ... }
+//	c := chain.New(n)
+//	head, ok := c.Head("contractors.example.com")
+//
+// A Chain holds no state of its own; it reads and writes the node's
+// chain directly.
+package chain
+
+import "github.com/quidnug/quidnug/pkg/node"
+
+// Block is a block of the chain.
+type Block = node.Block
+
+// Acceptance is the trust tier a received block was placed in.
+type Acceptance = node.BlockAcceptance
+
+// Trust tiers a received block can be placed in.
+const (
+	Trusted   = node.BlockTrusted
+	Tentative = node.BlockTentative
+	Untrusted = node.BlockUntrusted
+	Invalid   = node.BlockInvalid
+)
+
+// Chain is the block chain of a node.
+type Chain struct {
+	n *node.Node
+}
+
+// New returns the chain of n.
+func New(n *node.Node) *Chain {
+	return &Chain{n: n}
+}
+
+// Blocks returns a copy of the chain.
+func (c *Chain) Blocks() []Block {
+	return c.n.Blocks()
+}
+
+// Head returns the newest block of domain.
+func (c *Chain) Head(domain string) (Block, bool) {
+	return c.n.Head(domain)
+}
+
+// BlockByHash returns the block on the chain with hash.
+func (c *Chain) BlockByHash(hash string) (Block, bool) {
+	return c.n.BlockByHash(hash)
+}
+
+// Tentative returns the blocks of domain held at Tentative, waiting
+// for enough trust to join the chain.
+func (c *Chain) Tentative(domain string) []Block {
+	return c.n.TentativeBlocks(domain)
+}
+
+// Seal seals domain's pending transactions into a block and adds it
+// to the chain. It fails when nothing is pending for domain.
+func (c *Chain) Seal(domain string) (Block, error) {
+	return c.n.SealBlock(domain)
+}
+
+// Receive validates a block from elsewhere and, by its trust tier,
+// appends it to the chain, holds it as tentative, or rejects it.
+func (c *Chain) Receive(block Block) (Acceptance, error) {
+	return c.n.ReceiveBlock(block)
+}
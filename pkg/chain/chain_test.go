@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/pkg/node"
+)
+
+func TestChain_SealAndQuery(t *testing.T) {
+	cfg := node.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.SeedNodes = nil
+	n, err := node.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(n)
+	before := len(c.Blocks())
+
+	if _, err := c.Seal("default"); err == nil {
+		t.Error("sealing with nothing pending succeeded")
+	}
+
+	tx := node.TrustTransaction{
+		BaseTransaction: node.BaseTransaction{
+			ID:          "trust-1",
+			Type:        node.TxTypeTrust,
+			TrustDomain: "default",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   n.PublicKeyHex(),
+		},
+		Truster:    n.NodeID(),
+		Trustee:    "0000000000000002",
+		TrustLevel: 0.5,
+		Nonce:      1,
+	}
+	data, _ := json.Marshal(tx)
+	sig, err := n.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	if _, err := n.SubmitTrust(tx); err != nil {
+		t.Fatalf("SubmitTrust: %v", err)
+	}
+
+	block, err := c.Seal("default")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if got := len(c.Blocks()); got != before+1 {
+		t.Errorf("Blocks has %d blocks, want %d", got, before+1)
+	}
+	if head, ok := c.Head("default"); !ok || head.Hash != block.Hash {
+		t.Errorf("Head = %s, want the sealed block %s", head.Hash, block.Hash)
+	}
+	if _, ok := c.BlockByHash(block.Hash); !ok {
+		t.Error("sealed block not found by hash")
+	}
+	if got := c.Tentative("default"); len(got) != 0 {
+		t.Errorf("Tentative = %d blocks, want none", len(got))
+	}
+
+	forged := block
+	forged.Hash = "00"
+	if acceptance, err := c.Receive(forged); err == nil && acceptance != Invalid {
+		t.Errorf("Receive(forged) = %v, want Invalid or an error", acceptance)
+	}
+}
@@ -247,11 +247,22 @@ func (c *Client) Nodes(ctx context.Context, limit, offset int) (map[string]any,
 // wrapper. The returned bytes are the full JSON envelope —
 // callers parse it themselves.
 func (c *Client) RawGet(ctx context.Context, path string) ([]byte, error) {
+	return c.RawGetWithHeader(ctx, path, nil)
+}
+
+// RawGetWithHeader is RawGet with extra request headers, e.g. the
+// X-Admin-* signature headers some operator endpoints require.
+func (c *Client) RawGetWithHeader(ctx context.Context, path string, header http.Header) ([]byte, error) {
 	url := c.apiBase + "/" + strings.TrimLeft(path, "/")
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
 	req.Header.Set("Accept", "application/json")
 	if c.authToken != "" {
 		req.Header.Set("Authorization", "Bearer "+c.authToken)
@@ -311,6 +322,8 @@ func (c *Client) RegisterIdentity(ctx context.Context, signer *Quid, p IdentityP
 		Creator:     signer.ID,
 		UpdateNonce: nonce,
 		HomeDomain:  p.HomeDomain,
+		Kind:        p.Kind,
+		Signers:     p.Signers,
 	}
 	tx.ID = deriveIdentityID(&tx)
 	signable, err := json.Marshal(tx)
@@ -378,6 +391,8 @@ func (c *Client) GrantTrust(ctx context.Context, signer *Quid, p TrustParams) (m
 		Nonce:       nonce,
 		Description: p.Description,
 		ValidUntil:  p.ValidUntil,
+		Evidence:    p.Evidence,
+		Context:     p.Context,
 	}
 	tx.ID = deriveTrustID(&tx)
 	signable, err := json.Marshal(tx)
@@ -430,6 +445,21 @@ func (c *Client) GetTrustEdges(ctx context.Context, quidID string) ([]TrustEdge,
 	return wrapper.Data, nil
 }
 
+// GetTrustEvidence returns the evidence recorded on the edge truster
+// → trustee, or nil when the edge carries none.
+func (c *Client) GetTrustEvidence(ctx context.Context, truster, trustee string) (*TrustEdgeEvidence, error) {
+	if truster == "" || trustee == "" {
+		return nil, newValidationError("truster and trustee are required")
+	}
+	var out TrustEdgeEvidence
+	err := c.do(ctx, http.MethodGet,
+		"trust/edges/"+url.PathEscape(truster)+"/"+url.PathEscape(trustee)+"/evidence", nil, nil, &out)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return &out, err
+}
+
 // --- Title ---------------------------------------------------------------
 
 // RegisterTitle submits a signed TITLE transaction.
@@ -450,9 +480,14 @@ func (c *Client) RegisterTitle(ctx context.Context, signer *Quid, p TitleParams)
 	if len(p.Owners) == 0 {
 		return nil, newValidationError("owners is required")
 	}
+	// Leases, usufructs and liens don't count toward the total.
 	total := 0.0
 	for _, s := range p.Owners {
-		total += s.Percentage
+		switch s.StakeType {
+		case "lease", "usufruct", "lien":
+		default:
+			total += s.Percentage
+		}
 	}
 	// Accept either 1.0 (fraction) or 100.0 (percent) for
 	// caller ergonomics; normalize to fraction before wire.
@@ -482,6 +517,7 @@ func (c *Client) RegisterTitle(ctx context.Context, signer *Quid, p TitleParams)
 		Owners:      ownersWire,
 		Signatures:  map[string]string{},
 		TitleType:   p.TitleType,
+		TransferPolicy: p.TransferPolicy,
 	}
 	// p.PrevTitleTxID has no on-wire counterpart in the v1.0
 	// TitleTransaction struct; transfers use PreviousOwners.
@@ -512,6 +548,7 @@ func stakeToWire(in []OwnershipStake, normFactor float64) []ownershipStakeWire {
 			OwnerID:    s.OwnerID,
 			Percentage: s.Percentage * normFactor,
 			StakeType:  s.StakeType,
+			ExpiresAt:  s.ExpiresAt,
 		}
 	}
 	return out
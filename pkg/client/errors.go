@@ -113,6 +113,9 @@ func serverErrorToSDKError(status int, code, message string, details map[string]
 func isConflictCode(code string) bool {
 	switch code {
 	case "NONCE_REPLAY",
+		"NONCE_TOO_LOW",
+		"SEQUENCE_TOO_LOW",
+		"PREVIOUS_OWNERS_MISMATCH",
 		"GUARDIAN_SET_MISMATCH",
 		"QUORUM_NOT_MET",
 		"VETOED",
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/quidnug/quidnug/pkg/gcs"
+)
+
+// MembershipFilter is a node's Golomb-coded set over a registry or a
+// block. Check it with HasQuid / HasAsset before issuing the full
+// query: false means the node does not know the item, true means it
+// probably does.
+type MembershipFilter struct {
+	Registry   string `json:"registry,omitempty"`
+	BlockIndex int64  `json:"blockIndex,omitempty"`
+	BlockHash  string `json:"blockHash,omitempty"`
+	Height     int64  `json:"height"`
+	N          uint32 `json:"n"`
+	P          uint8  `json:"p"`
+	M          uint64 `json:"m"`
+	Key        string `json:"key"`
+	Filter     string `json:"filter"`
+}
+
+// HasQuid reports whether quidID may be in the set.
+func (f *MembershipFilter) HasQuid(quidID string) (bool, error) {
+	return f.match("quid:" + quidID)
+}
+
+// HasAsset reports whether assetID may be in the set.
+func (f *MembershipFilter) HasAsset(assetID string) (bool, error) {
+	return f.match("asset:" + assetID)
+}
+
+func (f *MembershipFilter) match(item string) (bool, error) {
+	key, err := hex.DecodeString(f.Key)
+	if err != nil || len(key) != gcs.KeySize {
+		return false, newValidationError("membership filter key is not 16 hex-encoded bytes")
+	}
+	data, err := base64.StdEncoding.DecodeString(f.Filter)
+	if err != nil {
+		return false, newValidationError("membership filter data is not valid base64: " + err.Error())
+	}
+	g := gcs.Filter{N: f.N, P: f.P, M: f.M, Data: data}
+	copy(g.Key[:], key)
+	ok, err := g.Match([]byte(item))
+	if err != nil {
+		return false, newValidationError(err.Error())
+	}
+	return ok, nil
+}
+
+// GetRegistryFilter fetches the membership filter for a registry:
+// "identity", "title" or "trust".
+func (c *Client) GetRegistryFilter(ctx context.Context, registry string) (*MembershipFilter, error) {
+	if registry == "" {
+		return nil, newValidationError("registry is required")
+	}
+	var out MembershipFilter
+	return &out, c.do(ctx, http.MethodGet, "filters/registry/"+url.PathEscape(registry), nil, nil, &out)
+}
+
+// GetBlockFilter fetches the membership filter over the quids and
+// assets the block at index touches.
+func (c *Client) GetBlockFilter(ctx context.Context, index int64) (*MembershipFilter, error) {
+	if index < 0 {
+		return nil, newValidationError("index must be non-negative")
+	}
+	var out MembershipFilter
+	return &out, c.do(ctx, http.MethodGet, "filters/block/"+strconv.FormatInt(index, 10), nil, nil, &out)
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quidnug/quidnug/pkg/gcs"
+)
+
+func TestGetRegistryFilterMatches(t *testing.T) {
+	key := [gcs.KeySize]byte{7}
+	f := gcs.Build(key, gcs.DefaultP, gcs.DefaultM, [][]byte{
+		[]byte("quid:known-quid"), []byte("asset:known-asset"),
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/filters/registry/identity" {
+			t.Errorf("path: %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"registry": "identity", "height": 3,
+				"n": f.N, "p": f.P, "m": f.M,
+				"key":    hex.EncodeToString(f.Key[:]),
+				"filter": base64.StdEncoding.EncodeToString(f.Data),
+			},
+		})
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithMaxRetries(0))
+	mf, err := c.GetRegistryFilter(context.Background(), "identity")
+	if err != nil {
+		t.Fatalf("GetRegistryFilter: %v", err)
+	}
+	if ok, err := mf.HasQuid("known-quid"); err != nil || !ok {
+		t.Errorf("HasQuid(known) = %v, %v", ok, err)
+	}
+	if ok, err := mf.HasAsset("known-asset"); err != nil || !ok {
+		t.Errorf("HasAsset(known) = %v, %v", ok, err)
+	}
+	if ok, _ := mf.HasQuid("known-asset"); ok {
+		t.Error("an asset id should not match as a quid")
+	}
+
+	mf.Key = "zz"
+	if _, err := mf.HasQuid("known-quid"); err == nil {
+		t.Error("expected an error for a malformed key")
+	}
+}
@@ -13,6 +13,9 @@ type OwnershipStake struct {
 	OwnerID    string  `json:"ownerId"`
 	Percentage float64 `json:"percentage"`
 	StakeType  string  `json:"stakeType,omitempty"`
+	// ExpiresAt (Unix seconds) ends a lease, usufruct or lien
+	// stake; leases require it.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
 }
 
 // Title describes asset ownership at the time of query.
@@ -39,6 +42,22 @@ type IdentityRecord struct {
 	PublicKey   string         `json:"publicKey,omitempty"`
 }
 
+// TrustEvidence is one reference substantiating a trust edge. Type
+// is "document-hash" (Value "sha256:<hex>"), "url" or "credential".
+type TrustEvidence struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+// TrustEdgeEvidence is the evidence recorded for one edge.
+type TrustEdgeEvidence struct {
+	Truster  string          `json:"truster"`
+	Trustee  string          `json:"trustee"`
+	TxID     string          `json:"txId"`
+	Evidence []TrustEvidence `json:"evidence"`
+}
+
 // TrustEdge is a direct outbound trust edge.
 type TrustEdge struct {
 	Truster     string         `json:"truster"`
@@ -60,6 +79,9 @@ type TrustResult struct {
 	Path       []string `json:"trustPath"`
 	PathDepth  int      `json:"pathDepth"`
 	Domain     string   `json:"domain"`
+	// Truncated marks a partial result: the node stopped searching
+	// on its time budget or graph size limits.
+	Truncated  bool     `json:"truncated,omitempty"`
 }
 
 // Event is one row of a subject's event stream.
@@ -253,13 +275,26 @@ type Pagination struct {
 
 // IdentityParams are the writable fields for RegisterIdentity.
 type IdentityParams struct {
-	SubjectQuid string         // defaults to signer.ID
-	Domain      string         // defaults to "default"
-	Name        string         // optional
-	Description string         // optional
-	Attributes  map[string]any // optional
-	HomeDomain  string         // optional — QDP-0007
-	UpdateNonce int64          // default 1
+	SubjectQuid string            // defaults to signer.ID
+	Domain      string            // defaults to "default"
+	Name        string            // optional
+	Description string            // optional
+	Attributes  map[string]any    // optional
+	HomeDomain  string            // optional — QDP-0007
+	UpdateNonce int64             // default 1
+	Kind        string            // optional; "organization" for an org quid
+	Signers     []DelegatedSigner // optional; who may sign for an organization
+}
+
+// DelegatedSigner authorizes Quid to sign for an organization quid.
+// Roles are the transaction types it may sign ("TRUST", "TITLE",
+// ...) or "*". ValidFrom and ValidUntil are Unix seconds; zero
+// leaves that end open.
+type DelegatedSigner struct {
+	Quid       string   `json:"quid"`
+	Roles      []string `json:"roles"`
+	ValidFrom  int64    `json:"validFrom,omitempty"`
+	ValidUntil int64    `json:"validUntil,omitempty"`
 }
 
 // TrustParams are the writable fields for GrantTrust.
@@ -270,6 +305,8 @@ type TrustParams struct {
 	Nonce       int64  // default 1
 	ValidUntil  int64  // optional
 	Description string // optional
+	Evidence    []TrustEvidence // optional
+	Context     string // optional activity tag, e.g. "payments"
 }
 
 // TitleParams are the writable fields for RegisterTitle.
@@ -279,6 +316,19 @@ type TitleParams struct {
 	Domain         string // defaults to "default"
 	TitleType      string
 	PrevTitleTxID  string
+	TransferPolicy *TitleTransferPolicy // optional; nil means every owner co-signs transfers
+}
+
+// TitleTransferPolicy says whose co-signatures a title's next
+// transfer needs. Kind is "all" (default), "stake-majority",
+// "stake-threshold" (with RequiredStake) or "designated" (with
+// Trustee). SmallTransferStake, when set, lets a transfer moving
+// less stake than that go through on its sellers' signatures alone.
+type TitleTransferPolicy struct {
+	Kind               string  `json:"kind,omitempty"`
+	RequiredStake      float64 `json:"requiredStake,omitempty"`
+	Trustee            string  `json:"trustee,omitempty"`
+	SmallTransferStake float64 `json:"smallTransferStake,omitempty"`
 }
 
 // EventParams are the writable fields for EmitEvent.
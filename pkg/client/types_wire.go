@@ -38,12 +38,14 @@ type trustTxWire struct {
 	Signature   string `json:"signature"`
 	PublicKey   string `json:"publicKey"`
 	// TrustTransaction-specific.
-	Truster     string  `json:"truster"`
-	Trustee     string  `json:"trustee"`
-	TrustLevel  float64 `json:"trustLevel"`
-	Nonce       int64   `json:"nonce"`
-	Description string  `json:"description,omitempty"`
-	ValidUntil  int64   `json:"validUntil,omitempty"`
+	Truster     string          `json:"truster"`
+	Trustee     string          `json:"trustee"`
+	TrustLevel  float64         `json:"trustLevel"`
+	Nonce       int64           `json:"nonce"`
+	Description string          `json:"description,omitempty"`
+	ValidUntil  int64           `json:"validUntil,omitempty"`
+	Evidence    []TrustEvidence `json:"evidence,omitempty"`
+	Context     string          `json:"context,omitempty"`
 }
 
 // ---------------------------------------------------------------
@@ -66,6 +68,8 @@ type identityTxWire struct {
 	Creator     string                 `json:"creator"`
 	UpdateNonce int64                  `json:"updateNonce"`
 	HomeDomain  string                 `json:"homeDomain,omitempty"`
+	Kind        string                 `json:"kind,omitempty"`
+	Signers     []DelegatedSigner      `json:"signers,omitempty"`
 }
 
 // ---------------------------------------------------------------
@@ -77,6 +81,7 @@ type ownershipStakeWire struct {
 	OwnerID    string  `json:"ownerId"`
 	Percentage float64 `json:"percentage"`
 	StakeType  string  `json:"stakeType,omitempty"`
+	ExpiresAt  int64   `json:"expiresAt,omitempty"`
 }
 
 // titleTxWire mirrors core.TitleTransaction.
@@ -94,6 +99,7 @@ type titleTxWire struct {
 	Signatures     map[string]string    `json:"signatures"`
 	ExpiryDate     int64                `json:"expiryDate,omitempty"`
 	TitleType      string               `json:"titleType,omitempty"`
+	TransferPolicy *TitleTransferPolicy `json:"transferPolicy,omitempty"`
 }
 
 // ---------------------------------------------------------------
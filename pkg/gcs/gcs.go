@@ -0,0 +1,198 @@
+// Package gcs implements Golomb-coded sets: compact, probabilistic
+// membership filters in the style of BIP-158.
+//
+// A filter over N items hashes each one, keyed by a 16-byte Key,
+// into [0, N·M), sorts the results and Golomb-Rice codes the gaps
+// with parameter P. Match answers "possibly present" or "definitely
+// absent"; a false positive comes up about once in M lookups. With
+// the defaults (P=19, M=784931) a filter costs roughly 21 bits per
+// item.
+//
+// Quidnug nodes publish these per registry and per block so light
+// peers can ask "might this node know quid X?" before issuing a full
+// query or sync request. Build and Match are deterministic given
+// Key, P and M, so any party holding the four wire fields can match.
+package gcs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"sort"
+)
+
+// Defaults matching BIP-158's basic filter.
+const (
+	DefaultP = 19
+	DefaultM = 784931
+)
+
+// KeySize is the length of a filter key in bytes.
+const KeySize = 16
+
+// ErrCorrupt is returned when a filter's data ends before N values
+// have been decoded.
+var ErrCorrupt = errors.New("gcs: filter data is truncated")
+
+// Filter is a Golomb-coded set.
+type Filter struct {
+	// N is the number of items the filter was built over.
+	N uint32
+	// P is the Golomb-Rice parameter, in bits.
+	P uint8
+	// M is the inverse false-positive rate.
+	M uint64
+	// Key keys the item hash, so a filter's collisions cannot be
+	// precomputed.
+	Key [KeySize]byte
+	// Data is the Rice-coded, MSB-first bit stream.
+	Data []byte
+}
+
+// Build returns a filter over items. Duplicate items are counted
+// once.
+func Build(key [KeySize]byte, p uint8, m uint64, items [][]byte) *Filter {
+	seen := make(map[string]struct{}, len(items))
+	unique := make([][]byte, 0, len(items))
+	for _, it := range items {
+		if _, dup := seen[string(it)]; dup {
+			continue
+		}
+		seen[string(it)] = struct{}{}
+		unique = append(unique, it)
+	}
+
+	f := &Filter{N: uint32(len(unique)), P: p, M: m, Key: key}
+	if f.N == 0 {
+		return f
+	}
+	values := make([]uint64, len(unique))
+	for i, it := range unique {
+		values[i] = f.hash(it)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var w bitWriter
+	prev := uint64(0)
+	for _, v := range values {
+		delta := v - prev
+		prev = v
+		for q := delta >> p; q > 0; q-- {
+			w.writeBit(1)
+		}
+		w.writeBit(0)
+		w.writeBits(delta, p)
+	}
+	f.Data = w.bytes()
+	return f
+}
+
+// Match reports whether item may be in the set.
+func (f *Filter) Match(item []byte) (bool, error) {
+	return f.MatchAny([][]byte{item})
+}
+
+// MatchAny reports whether any of items may be in the set. It walks
+// the filter once, so it is cheaper than calling Match per item.
+func (f *Filter) MatchAny(items [][]byte) (bool, error) {
+	if f.N == 0 || len(items) == 0 {
+		return false, nil
+	}
+	targets := make([]uint64, len(items))
+	for i, it := range items {
+		targets[i] = f.hash(it)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+
+	r := bitReader{data: f.Data}
+	value := uint64(0)
+	for i := uint32(0); i < f.N; i++ {
+		delta, err := r.readRice(f.P)
+		if err != nil {
+			return false, err
+		}
+		value += delta
+		for len(targets) > 0 && targets[0] < value {
+			targets = targets[1:]
+		}
+		if len(targets) == 0 {
+			return false, nil
+		}
+		if targets[0] == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hash maps item into [0, N·M) by multiply-and-shift over the first
+// eight bytes of SHA-256(Key || item).
+func (f *Filter) hash(item []byte) uint64 {
+	h := sha256.New()
+	h.Write(f.Key[:])
+	h.Write(item)
+	sum := h.Sum(nil)
+	hi, _ := bits.Mul64(binary.BigEndian.Uint64(sum[:8]), uint64(f.N)*f.M)
+	return hi
+}
+
+type bitWriter struct {
+	buf  []byte
+	used uint8 // bits used in the last byte; 0 means start a new one
+}
+
+func (w *bitWriter) writeBit(b uint64) {
+	if w.used == 0 {
+		w.buf = append(w.buf, 0)
+	}
+	if b != 0 {
+		w.buf[len(w.buf)-1] |= 0x80 >> w.used
+	}
+	w.used = (w.used + 1) % 8
+}
+
+func (w *bitWriter) writeBits(v uint64, n uint8) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.writeBit((v >> uint(i)) & 1)
+	}
+}
+
+func (w *bitWriter) bytes() []byte { return w.buf }
+
+type bitReader struct {
+	data []byte
+	pos  int // in bits
+}
+
+func (r *bitReader) readBit() (uint64, error) {
+	if r.pos >= len(r.data)*8 {
+		return 0, ErrCorrupt
+	}
+	b := (r.data[r.pos/8] >> (7 - uint(r.pos%8))) & 1
+	r.pos++
+	return uint64(b), nil
+}
+
+func (r *bitReader) readRice(p uint8) (uint64, error) {
+	q := uint64(0)
+	for {
+		b, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if b == 0 {
+			break
+		}
+		q++
+	}
+	rem := uint64(0)
+	for i := uint8(0); i < p; i++ {
+		b, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		rem = rem<<1 | b
+	}
+	return q<<p | rem, nil
+}
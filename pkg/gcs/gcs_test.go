@@ -0,0 +1,106 @@
+package gcs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testItems(prefix string, n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("%s%06d", prefix, i))
+	}
+	return items
+}
+
+func TestBuildMatchesEveryMember(t *testing.T) {
+	key := [KeySize]byte{1, 2, 3}
+	items := testItems("quid:", 2000)
+	f := Build(key, DefaultP, DefaultM, items)
+	if f.N != 2000 {
+		t.Fatalf("N = %d, want 2000", f.N)
+	}
+	for _, it := range items {
+		ok, err := f.Match(it)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("member %q not matched", it)
+		}
+	}
+	if bitsPer := float64(len(f.Data)*8) / float64(f.N); bitsPer > 22 {
+		t.Errorf("%.1f bits per item, expected about 21", bitsPer)
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	// A small M makes false positives common enough to measure.
+	const m = 64
+	f := Build([KeySize]byte{9}, 6, m, testItems("in:", 1000))
+	hits := 0
+	probes := testItems("out:", 20000)
+	for _, it := range probes {
+		if ok, _ := f.Match(it); ok {
+			hits++
+		}
+	}
+	rate := float64(hits) / float64(len(probes))
+	if rate < 0.5/m || rate > 2.0/m {
+		t.Errorf("false positive rate %.4f, want about %.4f", rate, 1.0/m)
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	items := testItems("asset:", 100)
+	f := Build([KeySize]byte{}, DefaultP, DefaultM, items)
+	ok, err := f.MatchAny([][]byte{[]byte("asset:absent"), items[42]})
+	if err != nil || !ok {
+		t.Errorf("MatchAny with one member = %v, %v", ok, err)
+	}
+	ok, _ = f.MatchAny(testItems("nope:", 10))
+	if ok {
+		t.Error("MatchAny over absent items should (almost surely) miss")
+	}
+}
+
+func TestKeyChangesEncoding(t *testing.T) {
+	items := testItems("q", 50)
+	a := Build([KeySize]byte{1}, DefaultP, DefaultM, items)
+	b := Build([KeySize]byte{2}, DefaultP, DefaultM, items)
+	if string(a.Data) == string(b.Data) {
+		t.Fatal("different keys should give different filters")
+	}
+	// Read under the wrong key, a filter no longer matches its items.
+	b.Data = a.Data
+	matched := 0
+	for _, it := range items {
+		if ok, _ := b.Match(it); ok {
+			matched++
+		}
+	}
+	if matched > 1 {
+		t.Errorf("wrong key matched %d of %d items", matched, len(items))
+	}
+}
+
+func TestEmptyAndDuplicates(t *testing.T) {
+	f := Build([KeySize]byte{}, DefaultP, DefaultM, nil)
+	if ok, err := f.Match([]byte("x")); ok || err != nil {
+		t.Errorf("empty filter matched: %v, %v", ok, err)
+	}
+	f = Build([KeySize]byte{}, DefaultP, DefaultM, [][]byte{[]byte("x"), []byte("x"), []byte("y")})
+	if f.N != 2 {
+		t.Errorf("N = %d, want duplicates counted once", f.N)
+	}
+}
+
+func TestTruncatedData(t *testing.T) {
+	items := testItems("q", 200)
+	f := Build([KeySize]byte{}, DefaultP, DefaultM, items)
+	// One byte cannot hold even the first 20-bit code.
+	f.Data = f.Data[:1]
+	if _, err := f.Match(items[0]); err != ErrCorrupt {
+		t.Errorf("got %v, want ErrCorrupt", err)
+	}
+}
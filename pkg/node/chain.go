@@ -0,0 +1,59 @@
+package node
+
+// Blocks returns a copy of the node's chain.
+func (n *Node) Blocks() []Block {
+	n.core.BlockchainMutex.RLock()
+	defer n.core.BlockchainMutex.RUnlock()
+	return append([]Block(nil), n.core.Blockchain...)
+}
+
+// Head returns the newest block of domain on the node's chain.
+func (n *Node) Head(domain string) (Block, bool) {
+	n.core.BlockchainMutex.RLock()
+	defer n.core.BlockchainMutex.RUnlock()
+	for i := len(n.core.Blockchain) - 1; i >= 0; i-- {
+		if b := n.core.Blockchain[i]; b.TrustProof.TrustDomain == domain {
+			return b, true
+		}
+	}
+	return Block{}, false
+}
+
+// BlockByHash returns the block on the node's chain with hash.
+func (n *Node) BlockByHash(hash string) (Block, bool) {
+	n.core.BlockchainMutex.RLock()
+	defer n.core.BlockchainMutex.RUnlock()
+	for _, b := range n.core.Blockchain {
+		if b.Hash == hash {
+			return b, true
+		}
+	}
+	return Block{}, false
+}
+
+// TentativeBlocks returns the blocks of domain held at
+// BlockTentative, waiting for enough trust to join the chain.
+func (n *Node) TentativeBlocks(domain string) []Block {
+	return n.core.GetTentativeBlocks(domain)
+}
+
+// SealBlock seals domain's pending transactions into a block and adds
+// it to the chain now, as the block loop does each interval. It
+// fails when nothing is pending for domain.
+func (n *Node) SealBlock(domain string) (Block, error) {
+	block, err := n.core.GenerateBlock(domain)
+	if err != nil {
+		return Block{}, err
+	}
+	if err := n.core.AddBlock(*block); err != nil {
+		return Block{}, err
+	}
+	return *block, nil
+}
+
+// ReceiveBlock validates a block from elsewhere and, by its trust
+// tier, appends it to the chain, holds it as tentative, or rejects
+// it.
+func (n *Node) ReceiveBlock(block Block) (BlockAcceptance, error) {
+	return n.core.ReceiveBlock(block)
+}
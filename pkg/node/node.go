@@ -0,0 +1,203 @@
+// Package node embeds a Quidnug node in another Go program.
+//
+// The node itself lives in internal/core, which other modules cannot
+// import; this package is its public surface. A Node is the same
+// node cmd/quidnug runs: the same validation, registries, background
+// loops and HTTP API. The host program decides where the API is
+// served: mount Handler on its own mux, or leave it off and use the
+// in-process methods.
+//
+//	cfg := node.DefaultConfig()
+//	cfg.DataDir = "/var/lib/myservice/quidnug"
+//	cfg.SupportedDomains = []string{"contractors.example.com"}
+//	n, err := node.New(cfg)
+//	if err != nil { ... }
+//	if err := n.Start(ctx); err != nil { ... }
+//	defer n.Stop()
+//	mux.Handle("/quidnug/", http.StripPrefix("/quidnug", n.Handler()))
+//
+// Beyond the lifecycle and transaction submission here, a Node
+// answers trust queries (trust.go: direct and relational trust, edges,
+// rankings) and chain queries (chain.go: blocks, domain heads,
+// tentative blocks, sealing and receiving blocks) in-process. The
+// same queries are available under their own import paths as
+// pkg/trust and pkg/chain, which wrap a Node.
+//
+// Config is the configuration cmd/quidnug loads from its config file
+// and environment; DefaultConfig returns its built-in defaults
+// without reading either.
+package node
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/quidnug/quidnug/internal/config"
+	"github.com/quidnug/quidnug/internal/core"
+)
+
+// Types shared with the node's registries and wire format.
+type (
+	Config              = config.Config
+	Block               = core.Block
+	BlockAcceptance     = core.BlockAcceptance
+	BaseTransaction     = core.BaseTransaction
+	TrustTransaction    = core.TrustTransaction
+	IdentityTransaction = core.IdentityTransaction
+	TitleTransaction    = core.TitleTransaction
+	EventTransaction    = core.EventTransaction
+	OwnershipStake      = core.OwnershipStake
+
+	TrustEdge             = core.TrustEdge
+	RelationalTrustResult = core.RelationalTrustResult
+)
+
+// Block acceptance tiers, as ReceiveBlock returns them.
+const (
+	BlockTrusted   = core.BlockTrusted
+	BlockTentative = core.BlockTentative
+	BlockUntrusted = core.BlockUntrusted
+	BlockInvalid   = core.BlockInvalid
+)
+
+// Transaction types.
+const (
+	TxTypeTrust    = core.TxTypeTrust
+	TxTypeIdentity = core.TxTypeIdentity
+	TxTypeTitle    = core.TxTypeTitle
+	TxTypeEvent    = core.TxTypeEvent
+)
+
+// ErrNotStarted is returned by Stop on a node that is not running,
+// and ErrStarted by a second Start.
+var (
+	ErrNotStarted = errors.New("node: not started")
+	ErrStarted    = errors.New("node: already started")
+)
+
+// DefaultConfig returns the built-in configuration, ignoring config
+// files and environment variables. Its SeedNodes point at the public
+// seeds; clear them for a node that should not join the network.
+func DefaultConfig() *Config {
+	return config.Defaults()
+}
+
+// SetLogger routes node logs to l. It applies to every node in the
+// process.
+func SetLogger(l *slog.Logger) {
+	core.SetLogger(l)
+}
+
+// Node is an embedded Quidnug node.
+type Node struct {
+	cfg  *Config
+	core *core.QuidnugNode
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New builds a node from cfg, loading or creating its key under
+// cfg.DataDir. A nil cfg means DefaultConfig. The node does nothing
+// until Start.
+func New(cfg *Config) (*Node, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	n, err := core.NewQuidnugNode(cfg)
+	if err != nil {
+		return nil, err
+	}
+	n.SetHTTPClientTimeout(cfg.HTTPClientTimeout)
+	return &Node{cfg: cfg, core: n}, nil
+}
+
+// Start loads the node's persisted state and starts its background
+// loops: peer discovery and sync, block generation, persistence and
+// the schedulers. They run until Stop or until ctx is cancelled.
+func (n *Node) Start(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.cancel != nil {
+		return ErrStarted
+	}
+	ctx, n.cancel = context.WithCancel(ctx)
+	n.core.StartBackground(ctx, n.cfg, &n.wg)
+	return nil
+}
+
+// Stop cancels the background loops, waits for them, and saves the
+// node's state to cfg.DataDir.
+func (n *Node) Stop() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.cancel == nil {
+		return ErrNotStarted
+	}
+	n.cancel()
+	n.wg.Wait()
+	n.cancel = nil
+	n.core.Shutdown(context.Background(), n.cfg)
+	return nil
+}
+
+// Handler returns the node's HTTP API (/api/v1/... and the rest)
+// with its usual middleware, limited by cfg.RateLimitPerMinute and
+// cfg.MaxBodySizeBytes.
+func (n *Node) Handler() http.Handler {
+	return n.core.NewHTTPHandler(n.cfg.RateLimitPerMinute, n.cfg.MaxBodySizeBytes)
+}
+
+// NodeID is the node's quid.
+func (n *Node) NodeID() string {
+	return n.core.NodeID
+}
+
+// PublicKeyHex is the node's public key, hex encoded.
+func (n *Node) PublicKeyHex() string {
+	return n.core.GetPublicKeyHex()
+}
+
+// Sign signs data with the node key (IEEE-1363 P-256 ECDSA over
+// SHA-256, the signature every transaction carries).
+func (n *Node) Sign(data []byte) ([]byte, error) {
+	return n.core.SignData(data)
+}
+
+// Identity returns a quid's committed identity.
+func (n *Node) Identity(quidID string) (IdentityTransaction, bool) {
+	return n.core.GetQuidIdentity(quidID)
+}
+
+// Title returns an asset's committed title.
+func (n *Node) Title(assetID string) (TitleTransaction, bool) {
+	return n.core.GetAssetOwnership(assetID)
+}
+
+// SubmitTrust validates a signed trust transaction and adds it to the
+// pending pool, returning its ID.
+func (n *Node) SubmitTrust(tx TrustTransaction) (string, error) {
+	return n.core.AddTrustTransaction(tx)
+}
+
+// SubmitIdentity validates a signed identity transaction and adds it
+// to the pending pool.
+func (n *Node) SubmitIdentity(tx IdentityTransaction) (string, error) {
+	return n.core.AddIdentityTransaction(tx)
+}
+
+// SubmitTitle validates a signed title transaction and adds it to the
+// pending pool.
+func (n *Node) SubmitTitle(tx TitleTransaction) (string, error) {
+	return n.core.AddTitleTransaction(tx)
+}
+
+// SubmitEvent validates a signed event transaction and adds it to the
+// pending pool.
+func (n *Node) SubmitEvent(tx EventTransaction) (string, error) {
+	return n.core.AddEventTransaction(tx)
+}
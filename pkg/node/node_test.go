@@ -0,0 +1,133 @@
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNode_EmbeddedLifecycle(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.SeedNodes = nil
+
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Stop(); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("stop before start: err = %v", err)
+	}
+	if err := n.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Start(context.Background()); !errors.Is(err, ErrStarted) {
+		t.Errorf("second start: err = %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/quidnug/", http.StripPrefix("/quidnug", n.Handler()))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/quidnug/api/v1/info", nil))
+	var resp struct {
+		Data struct {
+			NodeQuid string `json:"nodeQuid"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Data.NodeQuid != n.NodeID() {
+		t.Fatalf("info through the host mux: %d %s", rr.Code, rr.Body.String())
+	}
+	if len(n.Blocks()) == 0 {
+		t.Error("no genesis block")
+	}
+
+	if err := n.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DataDir, "blockchain.json")); err != nil {
+		t.Errorf("state not saved on stop: %v", err)
+	}
+
+	// The key persists, so a node rebuilt from the same directory
+	// keeps its identity.
+	again, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.NodeID() != n.NodeID() {
+		t.Errorf("node ID changed across restarts: %s -> %s", n.NodeID(), again.NodeID())
+	}
+}
+
+func TestNode_TrustAndChainQueries(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.SeedNodes = nil
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const trustee = "0000000000000002"
+	tx := TrustTransaction{
+		BaseTransaction: BaseTransaction{
+			ID:          "trust-1",
+			Type:        TxTypeTrust,
+			TrustDomain: "default",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   n.PublicKeyHex(),
+		},
+		Truster:    n.NodeID(),
+		Trustee:    trustee,
+		TrustLevel: 0.8,
+		Nonce:      1,
+	}
+	data, _ := json.Marshal(tx)
+	sig, err := n.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	if _, err := n.SubmitTrust(tx); err != nil {
+		t.Fatalf("SubmitTrust: %v", err)
+	}
+
+	block, err := n.SealBlock("default")
+	if err != nil {
+		t.Fatalf("SealBlock: %v", err)
+	}
+	if head, ok := n.Head("default"); !ok || head.Hash != block.Hash {
+		t.Errorf("Head = %s, want the sealed block %s", head.Hash, block.Hash)
+	}
+	if _, ok := n.BlockByHash(block.Hash); !ok {
+		t.Error("sealed block not found by hash")
+	}
+	if _, err := n.SealBlock("default"); err == nil {
+		t.Error("sealing with nothing pending succeeded")
+	}
+
+	if got := n.TrustLevel(n.NodeID(), trustee); got != 0.8 {
+		t.Errorf("TrustLevel = %v, want 0.8", got)
+	}
+	if got := n.Trustees(n.NodeID()); got[trustee] != 0.8 {
+		t.Errorf("Trustees = %v", got)
+	}
+	if edge, ok := n.TrustEdges(n.NodeID(), true)[trustee]; !ok || edge.SourceBlock != block.Hash {
+		t.Errorf("TrustEdges = %+v, want an edge from block %s", edge, block.Hash)
+	}
+	level, path, err := n.RelationalTrust(context.Background(), n.NodeID(), trustee, 3)
+	if err != nil || level != 0.8 || len(path) != 2 {
+		t.Errorf("RelationalTrust = %v %v %v", level, path, err)
+	}
+	top, err := n.TopTrusted(context.Background(), n.NodeID(), 5, 3, "")
+	if err != nil || len(top) != 1 || top[0].Target != trustee {
+		t.Errorf("TopTrusted = %+v %v", top, err)
+	}
+}
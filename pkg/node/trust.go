@@ -0,0 +1,42 @@
+package node
+
+import "context"
+
+// TrustLevel is truster's direct trust in trustee, zero when there is
+// no edge.
+func (n *Node) TrustLevel(truster, trustee string) float64 {
+	return n.core.GetTrustLevel(truster, trustee)
+}
+
+// Trustees returns the quids quidID trusts directly, with the level
+// of each.
+func (n *Node) Trustees(quidID string) map[string]float64 {
+	return n.core.GetDirectTrustees(quidID)
+}
+
+// TrustEdges returns quidID's outgoing edges keyed by trustee, with
+// the block each was recorded in. Edges from blocks below the
+// domain's trust threshold are left out unless includeUnverified.
+func (n *Node) TrustEdges(quidID string, includeUnverified bool) map[string]TrustEdge {
+	return n.core.GetTrustEdges(quidID, includeUnverified)
+}
+
+// RelationalTrust is observer's trust in target, and the path that
+// gives it, searching at most maxDepth hops. The search stops with
+// ctx's error once ctx is done.
+func (n *Node) RelationalTrust(ctx context.Context, observer, target string, maxDepth int) (float64, []string, error) {
+	return n.core.ComputeRelationalTrust(ctx, observer, target, maxDepth)
+}
+
+// RelationalTrustBatch is RelationalTrust from observer to each of
+// targets, in targets order, sharing one walk of the graph.
+func (n *Node) RelationalTrustBatch(ctx context.Context, observer string, targets []string, maxDepth int) ([]RelationalTrustResult, error) {
+	return n.core.ComputeRelationalTrustBatch(ctx, observer, targets, maxDepth)
+}
+
+// TopTrusted returns up to k quids observer trusts most within
+// maxDepth hops, best first. A non-empty domain limits the walk to
+// edges set in that domain.
+func (n *Node) TopTrusted(ctx context.Context, observer string, k, maxDepth int, domain string) ([]RelationalTrustResult, error) {
+	return n.core.TopTrustedQuids(ctx, observer, k, maxDepth, domain)
+}
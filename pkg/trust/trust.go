@@ -0,0 +1,73 @@
+// Package trust answers trust-graph queries against an embedded node.
+//
+// It is the trust half of pkg/node split out under its own import
+// path, for programs that only read the graph:
+//
+//	n, err := node.New(cfg)
+//	if err != nil { ... }
+//	g := trust.New(n)
+//	level, path, err := g.Relational(ctx, observer, target, 5)
+//
+// A Graph holds no state of its own; every query reads the node's
+// registries as they are when it is made.
+package trust
+
+import (
+	"context"
+
+	"github.com/quidnug/quidnug/pkg/node"
+)
+
+// Edge is one outgoing trust edge and the block that recorded it.
+type Edge = node.TrustEdge
+
+// Result is the relational trust from an observer to one target.
+type Result = node.RelationalTrustResult
+
+// Graph is the trust graph of a node.
+type Graph struct {
+	n *node.Node
+}
+
+// New returns the trust graph of n.
+func New(n *node.Node) *Graph {
+	return &Graph{n: n}
+}
+
+// Level is truster's direct trust in trustee, zero when there is no
+// edge.
+func (g *Graph) Level(truster, trustee string) float64 {
+	return g.n.TrustLevel(truster, trustee)
+}
+
+// Trustees returns the quids quidID trusts directly, with the level
+// of each.
+func (g *Graph) Trustees(quidID string) map[string]float64 {
+	return g.n.Trustees(quidID)
+}
+
+// Edges returns quidID's outgoing edges keyed by trustee. Edges from
+// blocks below the domain's trust threshold are left out unless
+// includeUnverified.
+func (g *Graph) Edges(quidID string, includeUnverified bool) map[string]Edge {
+	return g.n.TrustEdges(quidID, includeUnverified)
+}
+
+// Relational is observer's trust in target, and the path that gives
+// it, searching at most maxDepth hops.
+func (g *Graph) Relational(ctx context.Context, observer, target string, maxDepth int) (float64, []string, error) {
+	return g.n.RelationalTrust(ctx, observer, target, maxDepth)
+}
+
+// RelationalBatch is Relational from observer to each of targets, in
+// targets order, sharing one walk of the graph.
+func (g *Graph) RelationalBatch(ctx context.Context, observer string, targets []string, maxDepth int) ([]Result, error) {
+	return g.n.RelationalTrustBatch(ctx, observer, targets, maxDepth)
+}
+
+// Top returns up to k quids observer trusts most within maxDepth
+// hops, best first. A non-empty domain limits the walk to edges set
+// in that domain.
+func (g *Graph) Top(ctx context.Context, observer string, k, maxDepth int, domain string) ([]Result, error) {
+	return g.n.TopTrusted(ctx, observer, k, maxDepth, domain)
+}
@@ -0,0 +1,72 @@
+package trust
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quidnug/quidnug/pkg/node"
+)
+
+func TestGraph_Queries(t *testing.T) {
+	cfg := node.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.SeedNodes = nil
+	n, err := node.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const trustee = "0000000000000002"
+	tx := node.TrustTransaction{
+		BaseTransaction: node.BaseTransaction{
+			ID:          "trust-1",
+			Type:        node.TxTypeTrust,
+			TrustDomain: "default",
+			Timestamp:   time.Now().Unix(),
+			PublicKey:   n.PublicKeyHex(),
+		},
+		Truster:    n.NodeID(),
+		Trustee:    trustee,
+		TrustLevel: 0.6,
+		Nonce:      1,
+	}
+	data, _ := json.Marshal(tx)
+	sig, err := n.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = hex.EncodeToString(sig)
+	if _, err := n.SubmitTrust(tx); err != nil {
+		t.Fatalf("SubmitTrust: %v", err)
+	}
+	block, err := n.SealBlock("default")
+	if err != nil {
+		t.Fatalf("SealBlock: %v", err)
+	}
+
+	g := New(n)
+	if got := g.Level(n.NodeID(), trustee); got != 0.6 {
+		t.Errorf("Level = %v, want 0.6", got)
+	}
+	if got := g.Trustees(n.NodeID()); got[trustee] != 0.6 {
+		t.Errorf("Trustees = %v", got)
+	}
+	if edge, ok := g.Edges(n.NodeID(), true)[trustee]; !ok || edge.SourceBlock != block.Hash {
+		t.Errorf("Edges = %+v, want an edge from block %s", edge, block.Hash)
+	}
+	level, path, err := g.Relational(context.Background(), n.NodeID(), trustee, 3)
+	if err != nil || level != 0.6 || len(path) != 2 {
+		t.Errorf("Relational = %v %v %v", level, path, err)
+	}
+	batch, err := g.RelationalBatch(context.Background(), n.NodeID(), []string{trustee, "0000000000000003"}, 3)
+	if err != nil || len(batch) != 2 || batch[0].TrustLevel != 0.6 || batch[1].TrustLevel != 0 {
+		t.Errorf("RelationalBatch = %+v %v", batch, err)
+	}
+	top, err := g.Top(context.Background(), n.NodeID(), 5, 3, "")
+	if err != nil || len(top) != 1 || top[0].Target != trustee {
+		t.Errorf("Top = %+v %v", top, err)
+	}
+}